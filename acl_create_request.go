@@ -47,6 +47,32 @@ func (c *CreateAclsRequest) version() int16 {
 	return c.Version
 }
 
+func (c *CreateAclsRequest) versionRange() (minVersion, maxVersion int16) {
+	if c.Version >= 1 {
+		for _, aclCreation := range c.AclCreations {
+			if aclCreation.ResourcePatternType != AclPatternUnknown && aclCreation.ResourcePatternType != AclPatternLiteral {
+				return 1, 1
+			}
+		}
+	}
+	return 0, 1
+}
+
+func (c *CreateAclsRequest) withVersion(v int16) protocolBody {
+	request := *c
+	request.Version = v
+	if c.Version < 1 {
+		// v0 only creates literal ACLs
+		request.AclCreations = make([]*AclCreation, len(c.AclCreations))
+		for i, aclCreation := range c.AclCreations {
+			tmp := *aclCreation
+			tmp.ResourcePatternType = AclPatternLiteral
+			request.AclCreations[i] = &tmp
+		}
+	}
+	return &request
+}
+
 func (c *CreateAclsRequest) headerVersion() int16 {
 	return 1
 }
@@ -48,6 +48,33 @@ func (d *DeleteAclsRequest) version() int16 {
 	return int16(d.Version)
 }
 
+func (d *DeleteAclsRequest) versionRange() (minVersion, maxVersion int16) {
+	if d.Version >= 1 {
+		for _, filter := range d.Filters {
+			if filter.ResourcePatternTypeFilter != AclPatternLiteral {
+				return 1, 1
+			}
+		}
+	}
+	return 0, 1
+}
+
+func (d *DeleteAclsRequest) withVersion(v int16) protocolBody {
+	request := *d
+	request.Version = int(v)
+	// copied as encoding sets their version
+	request.Filters = make([]*AclFilter, len(d.Filters))
+	for i, filter := range d.Filters {
+		tmp := *filter
+		if d.Version < 1 {
+			// v0 only matches literal ACLs
+			tmp.ResourcePatternTypeFilter = AclPatternLiteral
+		}
+		request.Filters[i] = &tmp
+	}
+	return &request
+}
+
 func (d *DeleteAclsRequest) headerVersion() int16 {
 	return 1
 }
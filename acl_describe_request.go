@@ -25,6 +25,23 @@ func (d *DescribeAclsRequest) version() int16 {
 	return int16(d.Version)
 }
 
+func (d *DescribeAclsRequest) versionRange() (minVersion, maxVersion int16) {
+	if d.Version >= 1 && d.ResourcePatternTypeFilter != AclPatternLiteral {
+		return 1, 1
+	}
+	return 0, 1
+}
+
+func (d *DescribeAclsRequest) withVersion(v int16) protocolBody {
+	request := *d
+	request.Version = int(v)
+	if d.Version < 1 {
+		// v0 only matches literal ACLs
+		request.ResourcePatternTypeFilter = AclPatternLiteral
+	}
+	return &request
+}
+
 func (d *DescribeAclsRequest) headerVersion() int16 {
 	return 1
 }
@@ -0,0 +1,113 @@
+package sarama
+
+import "time"
+
+// AddRaftVoterRequest is a request to add a controller to the voters of the
+// KRaft controller quorum (KIP-853)
+type AddRaftVoterRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	// ClusterID is checked against the cluster ID by the receiver if set
+	ClusterID        *string
+	Timeout          time.Duration
+	VoterID          int32
+	VoterDirectoryID Uuid
+	Listeners        []RaftVoterListener
+}
+
+// RaftVoterListener is an endpoint of a KRaft controller quorum voter
+type RaftVoterListener struct {
+	Name string
+	Host string
+	Port uint16
+}
+
+func (r *AddRaftVoterRequest) encode(pe packetEncoder) error {
+	if err := pe.putNullableCompactString(r.ClusterID); err != nil {
+		return err
+	}
+	pe.putInt32(int32(r.Timeout / time.Millisecond))
+	pe.putInt32(r.VoterID)
+	if err := putUuid(pe, r.VoterDirectoryID); err != nil {
+		return err
+	}
+
+	pe.putCompactArrayLength(len(r.Listeners))
+	for _, l := range r.Listeners {
+		if err := pe.putCompactString(l.Name); err != nil {
+			return err
+		}
+		if err := pe.putCompactString(l.Host); err != nil {
+			return err
+		}
+		pe.putInt16(int16(l.Port))
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *AddRaftVoterRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.ClusterID, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	timeout, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.Timeout = time.Duration(timeout) * time.Millisecond
+	if r.VoterID, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.VoterDirectoryID, err = getUuid(pd); err != nil {
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.Listeners = make([]RaftVoterListener, n)
+		for i := 0; i < n; i++ {
+			l := &r.Listeners[i]
+			if l.Name, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if l.Host, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			port, err := pd.getInt16()
+			if err != nil {
+				return err
+			}
+			l.Port = uint16(port)
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *AddRaftVoterRequest) key() int16 {
+	return 80
+}
+
+func (r *AddRaftVoterRequest) version() int16 {
+	return r.Version
+}
+
+func (r *AddRaftVoterRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *AddRaftVoterRequest) requiredVersion() KafkaVersion {
+	return V3_9_0_0
+}
@@ -0,0 +1,30 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var addRaftVoterRequest = []byte{
+	0,             // null cluster ID
+	0, 0, 117, 48, // timeout (30000 ms)
+	0, 0, 0, 3, // voter ID
+	1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, // voter directory ID
+	2,                                                    // listeners array length 1
+	11, 'C', 'O', 'N', 'T', 'R', 'O', 'L', 'L', 'E', 'R', // name
+	6, 'h', 'o', 's', 't', '3', // host
+	35, 133, // port (9093)
+	0, // empty tagged fields
+	0, // empty tagged fields
+}
+
+func TestAddRaftVoterRequest(t *testing.T) {
+	request := &AddRaftVoterRequest{
+		Version:          0,
+		Timeout:          30 * time.Second,
+		VoterID:          3,
+		VoterDirectoryID: Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		Listeners:        []RaftVoterListener{{Name: "CONTROLLER", Host: "host3", Port: 9093}},
+	}
+	testRequest(t, "basic", request, addRaftVoterRequest)
+}
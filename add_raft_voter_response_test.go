@@ -0,0 +1,38 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	addRaftVoterResponseNoError = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		0, 0, // no error code
+		0, // no error message
+		0, // empty tagged fields
+	}
+
+	addRaftVoterResponseError = []byte{
+		0, 0, 0, 0, // throttle time
+		0, 42, // invalid request
+		5, 'o', 'o', 'p', 's', // error message
+		0, // empty tagged fields
+	}
+)
+
+func TestAddRaftVoterResponse(t *testing.T) {
+	response := &AddRaftVoterResponse{
+		Version:      0,
+		ThrottleTime: 100 * time.Millisecond,
+	}
+	testResponse(t, "no error", response, addRaftVoterResponseNoError)
+
+	errMsg := "oops"
+	response = &AddRaftVoterResponse{
+		Version:      0,
+		ErrorCode:    ErrInvalidRequest,
+		ErrorMessage: &errMsg,
+	}
+	testResponse(t, "with error", response, addRaftVoterResponseError)
+}
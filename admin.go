@@ -1,9 +1,12 @@
 package sarama
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -23,9 +26,15 @@ type ClusterAdmin interface {
 	// List the topics available in the cluster with the default options.
 	ListTopics() (map[string]TopicDetail, error)
 
-	// Describe some topics in the cluster.
+	// Describe some topics in the cluster. Topic IDs are included in the
+	// returned metadata for brokers with version 2.8.0 or higher.
 	DescribeTopics(topics []string) (metadata []*TopicMetadata, err error)
 
+	// Describe some topics in the cluster by their topic IDs. Unknown topic IDs
+	// are returned with ErrUnknownTopicID and no name.
+	// This operation is supported by brokers with version 3.1.0 or higher.
+	DescribeTopicsByID(topicIDs []Uuid) (metadata []*TopicMetadata, err error)
+
 	// Delete a topic. It may take several seconds after the DeleteTopic to returns success
 	// and for all the brokers to become aware that the topics are gone.
 	// During this time, listTopics  may continue to return information about the deleted topic.
@@ -34,6 +43,24 @@ type ClusterAdmin interface {
 	// This operation is supported by brokers with version 0.10.1.0 or higher.
 	DeleteTopic(topic string) error
 
+	// Delete a topic by its topic ID, with the same semantics as DeleteTopic.
+	// This operation is supported by brokers with version 2.8.0 or higher.
+	DeleteTopicByID(topicID Uuid) error
+
+	// Create many topics with batched CreateTopics requests sent concurrently
+	// to the controller, as controlled by the options (nil uses the defaults).
+	// The result maps every topic to its error, or nil if it was created; if
+	// any topic failed, the error is also ErrCreateTopics wrapping them.
+	// This operation is supported by brokers with version 0.10.1.0 or higher.
+	CreateTopics(topics map[string]*TopicDetail, options *BulkTopicOptions) (map[string]error, error)
+
+	// Delete many topics with batched DeleteTopics requests sent concurrently
+	// to the controller, as controlled by the options (nil uses the defaults).
+	// The result maps every topic to its error, or nil if it was deleted; if
+	// any topic failed, the error is also ErrDeleteTopics wrapping them.
+	// This operation is supported by brokers with version 0.10.1.0 or higher.
+	DeleteTopics(topics []string, options *BulkTopicOptions) (map[string]error, error)
+
 	// Increase the number of partitions of the topics  according to the corresponding values.
 	// If partitions are increased for a topic that has a key, the partition logic or ordering of
 	// the messages will be affected. It may take several seconds after this method returns
@@ -42,6 +69,14 @@ type ClusterAdmin interface {
 	// new partitions. This operation is supported by brokers with version 1.0.0 or higher.
 	CreatePartitions(topic string, count int32, assignment [][]int32, validateOnly bool) error
 
+	// Increase the number of partitions of a topic like CreatePartitions, with
+	// the replicas of the new partitions assigned client-side: replicas are
+	// spread across racks and placed on the brokers holding the fewest
+	// replicas, and leaders on the brokers leading the fewest partitions.
+	// The computed assignment of the new partitions is returned.
+	// This operation is supported by brokers with version 1.0.0 or higher.
+	CreateBalancedPartitions(topic string, count int32, validateOnly bool) ([][]int32, error)
+
 	// Alter the replica assignment for partitions.
 	// This operation is supported by brokers with version 2.4.0.0 or higher.
 	AlterPartitionReassignments(topic string, assignment [][]int32) error
@@ -50,10 +85,26 @@ type ClusterAdmin interface {
 	// This operation is supported by brokers with version 2.4.0.0 or higher.
 	ListPartitionReassignments(topics string, partitions []int32) (topicStatus map[string]map[int32]*PartitionReplicaReassignmentsStatus, err error)
 
+	// Monitor the ongoing replica reassignments of the given partitions (or of
+	// all partitions of the topic if partitions is nil) by polling
+	// ListPartitionReassignments every interval. The progress callback, if not
+	// nil, is called on every poll with the state of each partition still being
+	// reassigned, including the log sizes of its replicas, and once with Done set
+	// when its reassignment has completed. It returns nil once all reassignments
+	// have completed, or ErrReassignmentTimeout if they did not complete in time.
+	// This operation is supported by brokers with version 2.4.0.0 or higher.
+	MonitorPartitionReassignments(topic string, partitions []int32, interval, timeout time.Duration, progress func(*PartitionReassignmentProgress)) error
+
 	// Delete records whose offset is smaller than the given offset of the corresponding partition.
 	// This operation is supported by brokers with version 0.11.0.0 or higher.
 	DeleteRecords(topic string, partitionOffsets map[int32]int64) error
 
+	// Delete all records of a topic by deleting the records of every
+	// partition up to its high watermark, and return the result of each
+	// partition. Failures are wrapped in ErrDeleteRecords.
+	// This operation is supported by brokers with version 0.11.0.0 or higher.
+	PurgeTopic(topic string) (map[int32]*DeleteRecordsResponsePartition, error)
+
 	// Get the configuration for the specified resources.
 	// The returned configuration includes default values and the Default is true
 	// can be used to distinguish them from user supplied values.
@@ -76,6 +127,32 @@ type ClusterAdmin interface {
 	// The configs for a particular resource are updated automatically.
 	IncrementalAlterConfig(resourceType ConfigResourceType, name string, entries map[string]IncrementalAlterConfigsEntry, validateOnly bool) error
 
+	// Get the dynamic broker configs set as cluster-wide defaults, that is the
+	// configs of the broker resource with an empty name.
+	// This operation is supported by brokers with version 1.1.0 or higher.
+	DescribeClusterBrokerConfig() ([]ConfigEntry, error)
+
+	// Incrementally alter the dynamic broker configs set as cluster-wide
+	// defaults, which apply to every broker not overriding them.
+	// This operation is supported by brokers with version 2.3.0.0 or higher.
+	IncrementalAlterClusterBrokerConfig(entries map[string]IncrementalAlterConfigsEntry, validateOnly bool) error
+
+	// Compare the dynamic config overrides of every broker against the
+	// cluster-wide defaults and return, by config name, the brokers whose
+	// overrides deviate from them. Overrides of sensitive configs always
+	// deviate since their values cannot be compared.
+	// This operation is supported by brokers with version 1.1.0 or higher.
+	DescribeBrokerConfigDeviations() (map[string]*BrokerConfigDeviation, error)
+
+	// Bring a topic in line with the given spec: the topic is created if it
+	// does not exist, partitions are added if it has fewer than specified and
+	// the configs that differ are altered incrementally. The planned changes
+	// are returned and, unless dryRun is set, executed. A spec asking for
+	// fewer partitions or a different replication factor than the topic has
+	// is rejected since neither can be applied by these operations.
+	// This operation is supported by brokers with version 2.3.0.0 or higher.
+	ApplyTopicSpec(spec TopicSpec, dryRun bool) (*TopicSpecChanges, error)
+
 	// Creates access control lists (ACLs) which are bound to specific resources.
 	// This operation is not transactional so it may succeed for some ACLs while fail for others.
 	// If you attempt to add an ACL that duplicates an existing ACL, no error will be raised, but
@@ -89,30 +166,212 @@ type ClusterAdmin interface {
 
 	// Deletes access control lists (ACLs) according to the supplied filters.
 	// This operation is not transactional so it may succeed for some ACLs while fail for others.
+	// With validateOnly the ACLs matching the filters are returned without being deleted.
 	// This operation is supported by brokers with version 0.11.0.0 or higher.
 	DeleteACL(filter AclFilter, validateOnly bool) ([]MatchingAcl, error)
 
+	// Compare the desired ACLs with the ACLs matching the given filter and
+	// return the ACLs that have to be created or deleted to reconcile them.
+	// The filter scopes the ACLs managed by the caller, ACLs outside of it
+	// are never deleted.
+	// This operation is supported by brokers with version 0.11.0.0 or higher.
+	DiffACLs(desired []ResourceAcls, filter AclFilter) (*AclChanges, error)
+
+	// Reconcile the ACLs matching the given filter with the desired ACLs by
+	// creating the missing ones and deleting the ones that are not desired,
+	// and return the changes. No changes are made for a dry run. Failures are
+	// wrapped in ErrReconcileACLs.
+	// This operation is supported by brokers with version 0.11.0.0 or higher.
+	EnsureACLs(desired []ResourceAcls, filter AclFilter, dryRun bool) (*AclChanges, error)
+
 	// List the consumer groups available in the cluster.
 	ListConsumerGroups() (map[string]string, error)
 
+	// List the consumer groups available in the cluster together with their
+	// state (e.g. "Stable", "Empty", "Dead") and group type (e.g. "classic").
+	// States and types filter the groups on the brokers, empty filters match
+	// all groups. Filtering by state requires brokers with version 2.6.0.0 or
+	// higher and filtering by type requires brokers with version 3.8.0.0 or
+	// higher, otherwise ErrUnsupportedVersion is returned.
+	ListConsumerGroupListings(states []string, types []string) (map[string]*ConsumerGroupListing, error)
+
 	// Describe the given consumer groups.
 	DescribeConsumerGroups(groups []string) ([]*GroupDescription, error)
 
+	// Describe the given groups using the consumer group protocol of KIP-848,
+	// including the member epochs and their current and target assignments,
+	// which DescribeConsumerGroups does not report for these groups. Requests
+	// are sent to the coordinator of each group; errors are reported in the
+	// ErrorCode of the returned ConsumerGroupDescribeGroup.
+	// This operation is supported by brokers with version 3.7.0.0 or higher.
+	DescribeConsumerProtocolGroups(groups []string, includeAuthorizedOperations bool) ([]*ConsumerGroupDescribeGroup, error)
+
 	// List the consumer group offsets available in the cluster.
 	ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*OffsetFetchResponse, error)
 
+	// List the committed offsets of many consumer groups at once (KIP-709).
+	// The groups map each group to the partitions to fetch, a nil map fetches
+	// all committed offsets of the group. The groups sharing a coordinator are
+	// fetched with a single OffsetFetch v8 request; on older brokers every
+	// group is fetched with its own request. Group level errors are reported
+	// in the Err of the returned OffsetFetchResponseGroup.
+	// Batching is supported by brokers with version 3.0.0.0 or higher.
+	ListConsumerGroupOffsetsBatch(groups map[string]map[string][]int32) (map[string]*OffsetFetchResponseGroup, error)
+
 	// Deletes a consumer group offset
 	DeleteConsumerGroupOffset(group string, topic string, partition int32) error
 
 	// Delete a consumer group.
 	DeleteConsumerGroup(group string) error
 
+	// Remove the static members with the given group instance IDs from a
+	// consumer group, e.g. to kick zombie members without waiting for their
+	// session to time out. Member level failures are wrapped in
+	// ErrRemoveConsumerGroupMembers.
+	// This operation is supported by brokers with version 2.4.0.0 or higher.
+	RemoveMembersFromConsumerGroup(group string, groupInstanceIDs []string) error
+
+	// Reset the committed offsets of an inactive consumer group according to
+	// the given spec and return the new offsets, like the --reset-offsets
+	// option of kafka-consumer-groups. The new offsets are clamped to the
+	// offsets available on the brokers and are not committed for a dry run.
+	// ErrNonEmptyGroup is returned if the group has active members.
+	ResetConsumerGroupOffsets(group string, spec OffsetResetSpec) (map[string]map[int32]int64, error)
+
+	// Export the committed offsets of a consumer group, including their
+	// metadata and, for brokers with version 2.1.0.0 or higher, their leader
+	// epochs, to a portable structure that can be stored and re-applied with
+	// ImportConsumerGroupOffsets.
+	ExportConsumerGroupOffsets(group string) (*ConsumerGroupOffsets, error)
+
+	// Commit previously exported offsets to an inactive consumer group of the
+	// same or a different cluster. If group is empty, the group the offsets
+	// were exported from is used. Partition level failures are wrapped in
+	// ErrImportConsumerGroupOffsets and ErrNonEmptyGroup is returned if the
+	// group has active members.
+	ImportConsumerGroupOffsets(group string, offsets *ConsumerGroupOffsets) error
+
 	// Get information about the nodes in the cluster
 	DescribeCluster() (brokers []*Broker, controllerID int32, err error)
 
+	// Get information about the cluster using the DescribeCluster API (KIP-700),
+	// including the cluster ID, the controller, the brokers with their racks and,
+	// if requested, the operations the client is authorized to perform on the cluster.
+	// This operation is supported by brokers with version 2.8.0.0 or higher.
+	DescribeClusterDetails(includeAuthorizedOperations bool) (*ClusterDescription, error)
+
+	// Check the health of the cluster: whether the controller and all brokers
+	// of the cluster metadata can be reached, and which partitions are
+	// offline, under-replicated or below their topic's min.insync.replicas.
+	// An error is only returned if the cluster metadata or the topic configs
+	// could not be fetched.
+	HealthCheck() (*ClusterHealth, error)
+
+	// List the partitions of the given topics, or of all topics if there
+	// are none, which are offline or have fewer in-sync replicas than their
+	// topic's min.insync.replicas, along with their current ISR. Topics
+	// without such partitions are omitted from the result.
+	ListUnhealthyPartitions(topics []string) (map[string][]*UnhealthyPartition, error)
+
+	// Get the log directory usage of all brokers of the cluster, aggregated per
+	// broker, per log directory, per topic and per partition. Partition and
+	// topic totals sum the sizes of all replicas. Like DescribeLogDirs, the
+	// brokers are queried in parallel and only the first error is returned along
+	// with the usage of the brokers which responded.
+	// This operation is supported by brokers with version 1.0.0.0 or higher.
+	DescribeLogDirsSummary() (*LogDirsSummary, error)
+
+	// Move replicas hosted by the given broker to other log directories of that
+	// broker. The assignments map the absolute paths of the target log
+	// directories to the topics and partitions to move there.
+	// This operation is supported by brokers with version 1.0.0.0 or higher.
+	AlterReplicaLogDirs(brokerID int32, assignments map[string]map[string][]int32) error
+
+	// Unregister a decommissioned broker from a KRaft cluster so that it is no
+	// longer part of the cluster metadata.
+	// This operation is supported by brokers with version 3.1.0.0 or higher.
+	UnregisterBroker(brokerID int32) error
+
+	// Add a controller to the voters of the KRaft controller quorum (KIP-853).
+	// The controller is identified by its node ID and the directory ID of its
+	// metadata log directory, and must be reachable on the given listeners.
+	// This operation is supported by brokers with version 3.9.0.0 or higher.
+	AddRaftVoter(voterID int32, voterDirectoryID Uuid, listeners []RaftVoterListener) error
+
+	// Remove a controller from the voters of the KRaft controller quorum (KIP-853).
+	// This operation is supported by brokers with version 3.9.0.0 or higher.
+	RemoveRaftVoter(voterID int32, voterDirectoryID Uuid) error
+
+	// Get the cluster-wide finalized feature levels (e.g. metadata.version)
+	// and the feature ranges supported by a broker (KIP-584).
+	// This operation is supported by brokers with version 2.7.0.0 or higher.
+	DescribeFeatures() (*FeatureMetadata, error)
+
+	// Update the cluster-wide finalized max version levels of the given
+	// features. A MaxVersionLevel below 1 deletes the finalized feature.
+	// Per-feature failures are wrapped in ErrUpdateFeatures.
+	// This operation is supported by brokers with version 2.7.0.0 or higher.
+	UpdateFeatures(updates map[string]FeatureUpdate) error
+
+	// Trigger a leader election of the given type for the given partitions, or
+	// for all partitions if partitions is nil, and return the result of the
+	// election of each partition. Unclean elections require brokers with version
+	// 2.4.0.0 or higher.
+	// This operation is supported by brokers with version 2.2.0.0 or higher.
+	ElectLeaders(electionType ElectionType, partitions map[string][]int32) (map[string]map[int32]*PartitionResult, error)
+
 	// Get information about all log directories on the given set of brokers
 	DescribeLogDirs(brokers []int32) (map[int32][]DescribeLogDirsResponseDirMetadata, error)
 
+	// Get the offset and timestamp of the given partitions for the given time
+	// (in milliseconds), which may also be OffsetOldest, OffsetNewest or
+	// OffsetMaxTimestamp to find the message with the highest timestamp
+	// (KIP-734). Requests are sent to the leader of each partition; partition
+	// level errors are reported in the Err of the returned OffsetResponseBlock.
+	// OffsetMaxTimestamp requires brokers with version 3.0.0.0 or higher.
+	ListOffsets(topicPartitions map[string][]int32, time int64) (map[string]map[int32]*OffsetResponseBlock, error)
+
+	// Get the active producers of the given partitions (KIP-664). Requests are
+	// sent to the leader of each partition; partition level errors are
+	// reported in the ErrorCode of the returned DescribeProducersPartition.
+	// This operation is supported by brokers with version 2.8.0.0 or higher.
+	DescribeProducers(topicPartitions map[string][]int32) (map[string]map[int32]*DescribeProducersPartition, error)
+
+	// List the transactions known to the transaction coordinators of the
+	// cluster, optionally filtered by transaction state (e.g. "Ongoing") and
+	// producer ID. Empty filters match all transactions.
+	// This operation is supported by brokers with version 3.0.0.0 or higher.
+	ListTransactions(stateFilters []string, producerIDFilters []int64) ([]*TransactionListing, error)
+
+	// Describe the state of the given transactions. Requests are sent to the
+	// transaction coordinator of each transactional ID; errors are reported in
+	// the ErrorCode of the returned TransactionDescription.
+	// This operation is supported by brokers with version 3.0.0.0 or higher.
+	DescribeTransactions(transactionalIDs []string) ([]*TransactionDescription, error)
+
+	// Create a delegation token for the authenticated principal. Besides the
+	// owner, the given renewers are allowed to renew the token. A negative
+	// maxLifetime uses the delegation.token.max.lifetime.ms of the broker.
+	// This operation is supported by brokers with version 1.1.0.0 or higher.
+	CreateDelegationToken(renewers []DelegationTokenPrincipal, maxLifetime time.Duration) (*DelegationToken, error)
+
+	// Renew the delegation token with the given HMAC by renewPeriod and return
+	// its new expiry time. A negative renewPeriod uses the
+	// delegation.token.expiry.time.ms of the broker.
+	// This operation is supported by brokers with version 1.1.0.0 or higher.
+	RenewDelegationToken(hmac []byte, renewPeriod time.Duration) (time.Time, error)
+
+	// Change the expiry time of the delegation token with the given HMAC to now
+	// plus expiryTimePeriod and return it. A negative expiryTimePeriod expires
+	// the token immediately.
+	// This operation is supported by brokers with version 1.1.0.0 or higher.
+	ExpireDelegationToken(hmac []byte, expiryTimePeriod time.Duration) (time.Time, error)
+
+	// Describe the delegation tokens owned by the given principals, or all
+	// tokens the client is allowed to describe if owners is nil.
+	// This operation is supported by brokers with version 1.1.0.0 or higher.
+	DescribeDelegationToken(owners []DelegationTokenPrincipal) ([]*DelegationToken, error)
+
 	// Get information about SCRAM users
 	DescribeUserScramCredentials(users []string) ([]*DescribeUserScramCredentialsResult, error)
 
@@ -130,10 +389,38 @@ type ClusterAdmin interface {
 	// This operation is supported by brokers with version 2.6.0.0 or higher.
 	AlterClientQuotas(entity []QuotaEntityComponent, op ClientQuotasOp, validateOnly bool) error
 
+	// Get the client quotas that apply to the given user and client ID,
+	// resolving each quota from the most specific (user, client-id), user or
+	// client-id entity it is set on, including the default entities.
+	// This operation is supported by brokers with version 2.6.0.0 or higher.
+	DescribeEffectiveClientQuotas(user, clientID string) (map[string]EffectiveClientQuota, error)
+
+	// Sets the produce, fetch and request quotas of a client quota entity, built
+	// with QuotaUser, QuotaDefaultUser, QuotaClientID or QuotaDefaultClientID.
+	// This operation is supported by brokers with version 2.6.0.0 or higher.
+	SetClientQuotas(entity []QuotaEntityComponent, quotas ClientQuotaValues, validateOnly bool) error
+
 	// Controller returns the cluster controller broker. It will return a
 	// locally cached value if it's available.
 	Controller() (*Broker, error)
 
+	// Return a ClusterAdmin whose calls honor the deadline and cancellation
+	// of the given context: a call returns the context error as soon as the
+	// context is done, and retries and waits are stopped. Requests already
+	// sent may still be applied by the cluster. The returned admin shares the
+	// underlying client, so closing either closes both.
+	WithContext(ctx context.Context) ClusterAdmin
+
+	// Return a ClusterAdmin that retries failed requests according to the
+	// given policy instead of Config.Admin.Retry. The returned admin shares
+	// the underlying client, so closing either closes both.
+	WithRetryPolicy(policy AdminRetryPolicy) ClusterAdmin
+
+	// Return a ClusterAdmin that applies the given options to all of its
+	// operations, see AdminOptions. The returned admin shares the underlying
+	// client, so closing either closes both.
+	WithOptions(options AdminOptions) ClusterAdmin
+
 	// Close shuts down the admin and closes underlying client.
 	Close() error
 }
@@ -141,6 +428,10 @@ type ClusterAdmin interface {
 type clusterAdmin struct {
 	client Client
 	conf   *Config
+	ctx    context.Context
+	retry  *AdminRetryPolicy
+	// validateOnly is set by WithOptions
+	validateOnly bool
 }
 
 // NewClusterAdmin creates a new ClusterAdmin using the given broker addresses and configuration.
@@ -186,24 +477,119 @@ func isErrNoController(err error) bool {
 	return errors.Is(err, ErrNotController)
 }
 
+// AdminRetryPolicy controls how ClusterAdmin operations retry failed
+// requests, see Config.Admin.Retry for the meaning of the fields
+type AdminRetryPolicy struct {
+	Max         int
+	Backoff     time.Duration
+	BackoffFunc func(retries, maxRetries int) time.Duration
+	Retriable   func(err error) bool
+}
+
+func (p *AdminRetryPolicy) backoff(retries int) time.Duration {
+	if p.BackoffFunc != nil {
+		return p.BackoffFunc(retries, p.Max)
+	}
+	return p.Backoff
+}
+
+// retryPolicy returns the policy set with WithRetryPolicy, or else the
+// policy of the admin client configuration
+func (ca *clusterAdmin) retryPolicy() *AdminRetryPolicy {
+	if ca.retry != nil {
+		return ca.retry
+	}
+	return &AdminRetryPolicy{
+		Max:         ca.conf.Admin.Retry.Max,
+		Backoff:     ca.conf.Admin.Retry.Backoff,
+		BackoffFunc: ca.conf.Admin.Retry.BackoffFunc,
+		Retriable:   ca.conf.Admin.Retry.Retriable,
+	}
+}
+
 // retryOnError will repeatedly call the given (error-returning) func in the
 // case that its response is non-nil and retryable (as determined by the
-// provided retryable func) up to the maximum number of tries permitted by
-// the admin client configuration
+// provided retryable func or the retry policy) up to the maximum number of
+// tries permitted by the retry policy
 func (ca *clusterAdmin) retryOnError(retryable func(error) bool, fn func() error) error {
-	var err error
-	for attempt := 0; attempt < ca.conf.Admin.Retry.Max; attempt++ {
-		err = fn()
-		if err == nil || !retryable(err) {
+	policy := ca.retryPolicy()
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !(retryable(err) || (policy.Retriable != nil && policy.Retriable(err))) {
+			return err
+		}
+		if attempt >= policy.Max {
 			return err
 		}
+		backoff := policy.backoff(attempt)
 		Logger.Printf(
 			"admin/request retrying after %dms... (%d attempts remaining)\n",
-			ca.conf.Admin.Retry.Backoff/time.Millisecond, ca.conf.Admin.Retry.Max-attempt)
-		time.Sleep(ca.conf.Admin.Retry.Backoff)
-		continue
+			backoff/time.Millisecond, policy.Max-attempt)
+		select {
+		case <-time.After(backoff):
+		case <-ca.context().Done():
+			return ca.context().Err()
+		}
 	}
-	return err
+}
+
+// context returns the context the admin was bound to with WithContext
+func (ca *clusterAdmin) context() context.Context {
+	if ca.ctx == nil {
+		return context.Background()
+	}
+	return ca.ctx
+}
+
+// WithContext returns a view of the admin whose requests and retries, and
+// the lookups of the brokers to send them to, stop as soon as ctx is done
+func (ca *clusterAdmin) WithContext(ctx context.Context) ClusterAdmin {
+	if ctx == nil {
+		panic("nil context")
+	}
+	view := *ca
+	view.ctx = ctx
+	view.client = ca.client.WithContext(ctx)
+	return &view
+}
+
+func (ca *clusterAdmin) WithRetryPolicy(policy AdminRetryPolicy) ClusterAdmin {
+	view := *ca
+	view.retry = &policy
+	return &view
+}
+
+// AdminOptions are options applying to all operations of the ClusterAdmin
+// returned by ClusterAdmin.WithOptions
+type AdminOptions struct {
+	// ValidateOnly makes the admin validate mutations without applying them,
+	// so that changes can be planned and reviewed first. Operations sending
+	// a validateOnly flag (CreateTopic, CreateTopics, CreatePartitions,
+	// CreateBalancedPartitions, AlterConfig, IncrementalAlterConfig,
+	// IncrementalAlterClusterBrokerConfig, AlterClientQuotas and
+	// SetClientQuotas) have it set regardless of their arguments, and
+	// ApplyTopicSpec has the brokers validate its changes. DeleteACL returns
+	// the ACLs matching the filter, EnsureACLs and ResetConsumerGroupOffsets
+	// run as dry runs, and AlterPartitionReassignments checks the assignment
+	// against the cluster metadata. All other mutations fail with
+	// ErrValidateOnlyUnsupported.
+	ValidateOnly bool
+}
+
+func (ca *clusterAdmin) WithOptions(options AdminOptions) ClusterAdmin {
+	view := *ca
+	view.validateOnly = options.ValidateOnly
+	return &view
+}
+
+// checkMutable returns ErrValidateOnlyUnsupported for operations which
+// cannot be validated without being applied when the admin is in validate
+// only mode
+func (ca *clusterAdmin) checkMutable() error {
+	if ca.validateOnly {
+		return ErrValidateOnlyUnsupported
+	}
+	return nil
 }
 
 func (ca *clusterAdmin) CreateTopic(topic string, detail *TopicDetail, validateOnly bool) error {
@@ -220,7 +606,7 @@ func (ca *clusterAdmin) CreateTopic(topic string, detail *TopicDetail, validateO
 
 	request := &CreateTopicsRequest{
 		TopicDetails: topicDetails,
-		ValidateOnly: validateOnly,
+		ValidateOnly: validateOnly || ca.validateOnly,
 		Timeout:      ca.conf.Admin.Timeout,
 	}
 
@@ -237,7 +623,7 @@ func (ca *clusterAdmin) CreateTopic(topic string, detail *TopicDetail, validateO
 			return err
 		}
 
-		rsp, err := b.CreateTopics(request)
+		rsp, err := b.createTopics(ca.context(), request)
 		if err != nil {
 			return err
 		}
@@ -269,13 +655,40 @@ func (ca *clusterAdmin) DescribeTopics(topics []string) (metadata []*TopicMetada
 		AllowAutoTopicCreation: false,
 	}
 
-	if ca.conf.Version.IsAtLeast(V1_0_0_0) {
+	if ca.conf.Version.IsAtLeast(V2_8_0_0) {
+		request.Version = 10
+	} else if ca.conf.Version.IsAtLeast(V1_0_0_0) {
 		request.Version = 5
 	} else if ca.conf.Version.IsAtLeast(V0_11_0_0) {
 		request.Version = 4
 	}
 
-	response, err := controller.GetMetadata(request)
+	response, err := controller.getMetadata(ca.context(), request)
+	if err != nil {
+		return nil, err
+	}
+	return response.Topics, nil
+}
+
+func (ca *clusterAdmin) DescribeTopicsByID(topicIDs []Uuid) (metadata []*TopicMetadata, err error) {
+	if !ca.conf.Version.IsAtLeast(V3_1_0_0) {
+		return nil, ErrUnsupportedVersion
+	}
+	if len(topicIDs) == 0 {
+		return nil, ErrInvalidTopic
+	}
+
+	controller, err := ca.Controller()
+	if err != nil {
+		return nil, err
+	}
+
+	request := &MetadataRequest{
+		Version:  12,
+		TopicIDs: topicIDs,
+	}
+
+	response, err := controller.getMetadata(ca.context(), request)
 	if err != nil {
 		return nil, err
 	}
@@ -296,7 +709,7 @@ func (ca *clusterAdmin) DescribeCluster() (brokers []*Broker, controllerID int32
 		request.Version = 1
 	}
 
-	response, err := controller.GetMetadata(request)
+	response, err := controller.getMetadata(ca.context(), request)
 	if err != nil {
 		return nil, int32(0), err
 	}
@@ -304,200 +717,473 @@ func (ca *clusterAdmin) DescribeCluster() (brokers []*Broker, controllerID int32
 	return response.Brokers, response.ControllerID, nil
 }
 
-func (ca *clusterAdmin) findBroker(id int32) (*Broker, error) {
-	brokers := ca.client.Brokers()
-	for _, b := range brokers {
-		if b.ID() == id {
-			return b, nil
-		}
+func (ca *clusterAdmin) DescribeClusterDetails(includeAuthorizedOperations bool) (*ClusterDescription, error) {
+	b, err := ca.findAnyBroker()
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("could not find broker id %d", id)
-}
+	_ = b.Open(ca.client.Config())
 
-func (ca *clusterAdmin) findAnyBroker() (*Broker, error) {
-	brokers := ca.client.Brokers()
-	if len(brokers) > 0 {
-		index := rand.Intn(len(brokers))
-		return brokers[index], nil
+	rsp, err := b.describeCluster(ca.context(), &DescribeClusterRequest{
+		IncludeClusterAuthorizedOperations: includeAuthorizedOperations,
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("no available broker")
+
+	if rsp.ErrorMessage != nil && len(*rsp.ErrorMessage) > 0 {
+		return nil, errors.New(*rsp.ErrorMessage)
+	}
+	if !errors.Is(rsp.ErrorCode, ErrNoError) {
+		return nil, rsp.ErrorCode
+	}
+
+	description := &ClusterDescription{
+		ClusterID:            rsp.ClusterID,
+		ControllerID:         rsp.ControllerID,
+		AuthorizedOperations: rsp.ClusterAuthorizedOperations,
+	}
+	for _, rb := range rsp.Brokers {
+		broker := NewBroker(net.JoinHostPort(rb.Host, strconv.Itoa(int(rb.Port))))
+		broker.id = rb.BrokerID
+		broker.rack = rb.Rack
+		description.Brokers = append(description.Brokers, broker)
+	}
+	return description, nil
 }
 
-func (ca *clusterAdmin) ListTopics() (map[string]TopicDetail, error) {
-	// In order to build TopicDetails we need to first get the list of all
-	// topics using a MetadataRequest and then get their configs using a
-	// DescribeConfigsRequest request. To avoid sending many requests to the
-	// broker, we use a single DescribeConfigsRequest.
+// ClusterHealth is the result of ClusterAdmin.HealthCheck
+type ClusterHealth struct {
+	// ControllerID is the ID of the active controller, or -1 if there is none
+	ControllerID int32
+	// ControllerAvailable is whether there is an active controller and it
+	// could be connected to
+	ControllerAvailable bool
+	// UnreachableBrokers contains the IDs of the brokers that could not be
+	// connected to
+	UnreachableBrokers []int32
+	// OfflinePartitions contains the partitions without a leader, by topic
+	OfflinePartitions map[string][]int32
+	// UnderReplicatedPartitions contains, by topic, the number of partitions
+	// with fewer in-sync replicas than replicas
+	UnderReplicatedPartitions map[string]int
+	// UnderMinIsrPartitions contains, by topic, the number of partitions with
+	// fewer in-sync replicas than the topic's min.insync.replicas
+	UnderMinIsrPartitions map[string]int
+}
 
-	// Send the all-topic MetadataRequest
-	b, err := ca.findAnyBroker()
+// Healthy returns whether none of the checks of the health report failed
+func (h *ClusterHealth) Healthy() bool {
+	return h.ControllerAvailable &&
+		len(h.UnreachableBrokers) == 0 &&
+		len(h.OfflinePartitions) == 0 &&
+		len(h.UnderReplicatedPartitions) == 0 &&
+		len(h.UnderMinIsrPartitions) == 0
+}
+
+func (ca *clusterAdmin) HealthCheck() (*ClusterHealth, error) {
+	b, metadata, err := ca.reachableMetadata(nil)
 	if err != nil {
 		return nil, err
 	}
-	_ = b.Open(ca.client.Config())
 
-	metadataReq := &MetadataRequest{}
-	metadataResp, err := b.GetMetadata(metadataReq)
+	topics := make([]string, 0, len(metadata.Topics))
+	for _, topic := range metadata.Topics {
+		topics = append(topics, topic.Name)
+	}
+	minIsr, err := ca.topicMinIsr(b, topics)
 	if err != nil {
 		return nil, err
 	}
 
-	topicsDetailsMap := make(map[string]TopicDetail)
+	health := &ClusterHealth{
+		ControllerID:              metadata.ControllerID,
+		UnreachableBrokers:        ca.unreachableBrokers(metadata.Brokers),
+		OfflinePartitions:         make(map[string][]int32),
+		UnderReplicatedPartitions: make(map[string]int),
+		UnderMinIsrPartitions:     make(map[string]int),
+	}
 
-	var describeConfigsResources []*ConfigResource
+	controllerFound := false
+	for _, broker := range metadata.Brokers {
+		controllerFound = controllerFound || broker.ID() == metadata.ControllerID
+	}
+	health.ControllerAvailable = controllerFound && !int32Contains(health.UnreachableBrokers, metadata.ControllerID)
 
-	for _, topic := range metadataResp.Topics {
-		topicDetails := TopicDetail{
-			NumPartitions: int32(len(topic.Partitions)),
-		}
-		if len(topic.Partitions) > 0 {
-			topicDetails.ReplicaAssignment = map[int32][]int32{}
-			for _, partition := range topic.Partitions {
-				topicDetails.ReplicaAssignment[partition.ID] = partition.Replicas
+	for _, topic := range metadata.Topics {
+		for _, partition := range topic.Partitions {
+			if isOfflinePartition(partition) {
+				health.OfflinePartitions[topic.Name] = append(health.OfflinePartitions[topic.Name], partition.ID)
+			}
+			if len(partition.Isr) < len(partition.Replicas) {
+				health.UnderReplicatedPartitions[topic.Name]++
+			}
+			if len(partition.Isr) < minIsr[topic.Name] {
+				health.UnderMinIsrPartitions[topic.Name]++
 			}
-			topicDetails.ReplicationFactor = int16(len(topic.Partitions[0].Replicas))
-		}
-		topicsDetailsMap[topic.Name] = topicDetails
-
-		// we populate the resources we want to describe from the MetadataResponse
-		topicResource := ConfigResource{
-			Type: TopicResource,
-			Name: topic.Name,
 		}
-		describeConfigsResources = append(describeConfigsResources, &topicResource)
+		sort.Slice(health.OfflinePartitions[topic.Name], func(i, j int) bool {
+			return health.OfflinePartitions[topic.Name][i] < health.OfflinePartitions[topic.Name][j]
+		})
 	}
 
-	// Send the DescribeConfigsRequest
-	describeConfigsReq := &DescribeConfigsRequest{
-		Resources: describeConfigsResources,
-	}
+	return health, nil
+}
 
-	if ca.conf.Version.IsAtLeast(V1_1_0_0) {
-		describeConfigsReq.Version = 1
-	}
+// UnhealthyPartition is a partition returned by
+// ClusterAdmin.ListUnhealthyPartitions
+type UnhealthyPartition struct {
+	ID       int32
+	Leader   int32
+	Replicas []int32
+	Isr      []int32
+	// MinIsr is the min.insync.replicas of the topic
+	MinIsr int
+	// Offline is whether the partition has no leader
+	Offline bool
+	// UnderMinIsr is whether the partition has fewer in-sync replicas than MinIsr
+	UnderMinIsr bool
+}
 
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
-		describeConfigsReq.Version = 2
+func (ca *clusterAdmin) ListUnhealthyPartitions(topics []string) (map[string][]*UnhealthyPartition, error) {
+	b, metadata, err := ca.reachableMetadata(topics)
+	if err != nil {
+		return nil, err
 	}
 
-	describeConfigsResp, err := b.DescribeConfigs(describeConfigsReq)
+	names := make([]string, 0, len(metadata.Topics))
+	for _, topic := range metadata.Topics {
+		if !errors.Is(topic.Err, ErrNoError) {
+			return nil, fmt.Errorf("%s: %w", topic.Name, topic.Err)
+		}
+		names = append(names, topic.Name)
+	}
+	minIsr, err := ca.topicMinIsr(b, names)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, resource := range describeConfigsResp.Resources {
-		topicDetails := topicsDetailsMap[resource.Name]
-		topicDetails.ConfigEntries = make(map[string]*string)
-
-		for _, entry := range resource.Configs {
-			// only include non-default non-sensitive config
-			// (don't actually think topic config will ever be sensitive)
-			if entry.Default || entry.Sensitive {
-				continue
+	unhealthy := make(map[string][]*UnhealthyPartition)
+	for _, topic := range metadata.Topics {
+		for _, partition := range topic.Partitions {
+			p := &UnhealthyPartition{
+				ID:          partition.ID,
+				Leader:      partition.Leader,
+				Replicas:    partition.Replicas,
+				Isr:         partition.Isr,
+				MinIsr:      minIsr[topic.Name],
+				Offline:     isOfflinePartition(partition),
+				UnderMinIsr: len(partition.Isr) < minIsr[topic.Name],
+			}
+			if p.Offline || p.UnderMinIsr {
+				unhealthy[topic.Name] = append(unhealthy[topic.Name], p)
 			}
-			topicDetails.ConfigEntries[entry.Name] = &entry.Value
 		}
-
-		topicsDetailsMap[resource.Name] = topicDetails
+		sort.Slice(unhealthy[topic.Name], func(i, j int) bool {
+			return unhealthy[topic.Name][i].ID < unhealthy[topic.Name][j].ID
+		})
 	}
 
-	return topicsDetailsMap, nil
+	return unhealthy, nil
 }
 
-func (ca *clusterAdmin) DeleteTopic(topic string) error {
-	if topic == "" {
-		return ErrInvalidTopic
-	}
+func isOfflinePartition(partition *PartitionMetadata) bool {
+	return partition.Leader < 0 || errors.Is(partition.Err, ErrLeaderNotAvailable)
+}
 
-	request := &DeleteTopicsRequest{
-		Topics:  []string{topic},
-		Timeout: ca.conf.Admin.Timeout,
+// reachableMetadata requests the metadata of the given topics, or of all
+// topics if there are none, from the first broker that can be reached, since
+// some of them may not. It returns the broker along with the metadata.
+func (ca *clusterAdmin) reachableMetadata(topics []string) (*Broker, *MetadataResponse, error) {
+	request := &MetadataRequest{
+		Topics:                 topics,
+		AllowAutoTopicCreation: false,
 	}
-
-	if ca.conf.Version.IsAtLeast(V0_11_0_0) {
+	if ca.conf.Version.IsAtLeast(V1_0_0_0) {
+		request.Version = 5
+	} else if ca.conf.Version.IsAtLeast(V0_11_0_0) {
+		request.Version = 4
+	} else if ca.conf.Version.IsAtLeast(V0_10_0_0) {
 		request.Version = 1
 	}
 
-	return ca.retryOnError(isErrNoController, func() error {
-		b, err := ca.Controller()
-		if err != nil {
-			return err
+	brokers := ca.client.Brokers()
+	if len(brokers) == 0 {
+		return nil, nil, errors.New("no available broker")
+	}
+	var err error
+	for _, b := range brokers {
+		_ = b.Open(ca.client.Config())
+		var metadata *MetadataResponse
+		if metadata, err = b.getMetadata(ca.context(), request); err == nil {
+			return b, metadata, nil
 		}
+	}
+	return nil, nil, err
+}
 
-		rsp, err := b.DeleteTopics(request)
-		if err != nil {
-			return err
-		}
+// unreachableBrokers connects to each of the given brokers and returns the
+// IDs of the ones that could not be connected to, in ascending order. Brokers
+// unknown to the client are connected to and disconnected from directly.
+func (ca *clusterAdmin) unreachableBrokers(brokers []*Broker) []int32 {
+	conf := ca.client.Config()
+	unreachable := make(chan int32, len(brokers))
+	wg := sync.WaitGroup{}
 
-		topicErr, ok := rsp.TopicErrorCodes[topic]
-		if !ok {
-			return ErrIncompleteResponse
+	for _, b := range brokers {
+		known, err := ca.findBroker(b.ID())
+		if err == nil {
+			b = known
 		}
-
-		if !errors.Is(topicErr, ErrNoError) {
-			if errors.Is(topicErr, ErrNotController) {
-				_, _ = ca.refreshController()
+		wg.Add(1)
+		go func(b *Broker, known bool) {
+			defer wg.Done()
+			_ = b.Open(conf) // Ensure that broker is opened
+			if connected, _ := b.Connected(); !connected {
+				unreachable <- b.ID()
+			} else if !known {
+				_ = b.Close()
 			}
-			return topicErr
-		}
+		}(b, err == nil)
+	}
 
-		return nil
-	})
-}
+	wg.Wait()
+	close(unreachable)
 
-func (ca *clusterAdmin) CreatePartitions(topic string, count int32, assignment [][]int32, validateOnly bool) error {
-	if topic == "" {
-		return ErrInvalidTopic
+	var ids []int32
+	for id := range unreachable {
+		ids = append(ids, id)
 	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
 
-	topicPartitions := make(map[string]*TopicPartition)
-	topicPartitions[topic] = &TopicPartition{Count: count, Assignment: assignment}
+// topicMinIsr returns the min.insync.replicas of the given topics, using a
+// single DescribeConfigsRequest sent to the given broker
+func (ca *clusterAdmin) topicMinIsr(b *Broker, topics []string) (map[string]int, error) {
+	minIsr := make(map[string]int, len(topics))
+	if len(topics) == 0 {
+		return minIsr, nil
+	}
 
-	request := &CreatePartitionsRequest{
-		TopicPartitions: topicPartitions,
-		Timeout:         ca.conf.Admin.Timeout,
-		ValidateOnly:    validateOnly,
+	request := &DescribeConfigsRequest{}
+	for _, topic := range topics {
+		request.Resources = append(request.Resources, &ConfigResource{
+			Type:        TopicResource,
+			Name:        topic,
+			ConfigNames: []string{"min.insync.replicas"},
+		})
+		// the broker default
+		minIsr[topic] = 1
+	}
+	if ca.conf.Version.IsAtLeast(V1_1_0_0) {
+		request.Version = 1
+	}
+	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		request.Version = 2
 	}
 
-	return ca.retryOnError(isErrNoController, func() error {
-		b, err := ca.Controller()
-		if err != nil {
-			return err
-		}
+	rsp, err := b.describeConfigs(ca.context(), request)
+	if err != nil {
+		return nil, err
+	}
 
-		rsp, err := b.CreatePartitions(request)
-		if err != nil {
-			return err
+	for _, resource := range rsp.Resources {
+		if resource.ErrorCode != 0 {
+			return nil, fmt.Errorf("%s: %w", resource.Name, KError(resource.ErrorCode))
 		}
-
-		topicErr, ok := rsp.TopicPartitionErrors[topic]
-		if !ok {
-			return ErrIncompleteResponse
-		}
-
-		if !errors.Is(topicErr.Err, ErrNoError) {
-			if errors.Is(topicErr.Err, ErrNotController) {
-				_, _ = ca.refreshController()
+		for _, entry := range resource.Configs {
+			if entry.Name != "min.insync.replicas" {
+				continue
 			}
-			return topicErr
+			value, err := strconv.Atoi(entry.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid min.insync.replicas %q", resource.Name, entry.Value)
+			}
+			minIsr[resource.Name] = value
 		}
+	}
+
+	return minIsr, nil
+}
+
+func (ca *clusterAdmin) UnregisterBroker(brokerID int32) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
+	}
+
+	b, err := ca.findAnyBroker()
+	if err != nil {
+		return err
+	}
+	_ = b.Open(ca.client.Config())
+
+	rsp, err := b.unregisterBroker(ca.context(), &UnregisterBrokerRequest{BrokerID: brokerID})
+	if err != nil {
+		return err
+	}
+
+	if rsp.ErrorMessage != nil && len(*rsp.ErrorMessage) > 0 {
+		return errors.New(*rsp.ErrorMessage)
+	}
+	if !errors.Is(rsp.ErrorCode, ErrNoError) {
+		return rsp.ErrorCode
+	}
+	return nil
+}
+
+func (ca *clusterAdmin) AddRaftVoter(voterID int32, voterDirectoryID Uuid, listeners []RaftVoterListener) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
+	}
+
+	if !ca.conf.Version.IsAtLeast(V3_9_0_0) {
+		return ErrUnsupportedVersion
+	}
+
+	b, err := ca.findAnyBroker()
+	if err != nil {
+		return err
+	}
+	_ = b.Open(ca.client.Config())
+
+	rsp, err := b.addRaftVoter(ca.context(), &AddRaftVoterRequest{
+		Timeout:          ca.conf.Admin.Timeout,
+		VoterID:          voterID,
+		VoterDirectoryID: voterDirectoryID,
+		Listeners:        listeners,
+	})
+	if err != nil {
+		return err
+	}
+	return raftVoterError(rsp.ErrorCode, rsp.ErrorMessage)
+}
+
+func (ca *clusterAdmin) RemoveRaftVoter(voterID int32, voterDirectoryID Uuid) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
+	}
+
+	if !ca.conf.Version.IsAtLeast(V3_9_0_0) {
+		return ErrUnsupportedVersion
+	}
+
+	b, err := ca.findAnyBroker()
+	if err != nil {
+		return err
+	}
+	_ = b.Open(ca.client.Config())
+
+	rsp, err := b.removeRaftVoter(ca.context(), &RemoveRaftVoterRequest{
+		VoterID:          voterID,
+		VoterDirectoryID: voterDirectoryID,
+	})
+	if err != nil {
+		return err
+	}
+	return raftVoterError(rsp.ErrorCode, rsp.ErrorMessage)
+}
 
+func raftVoterError(kerr KError, msg *string) error {
+	if errors.Is(kerr, ErrNoError) {
 		return nil
+	}
+	if msg != nil && len(*msg) > 0 {
+		return fmt.Errorf("%w: %s", kerr, *msg)
+	}
+	return kerr
+}
+
+// FinalizedVersionRange is the cluster-wide finalized version range of a feature
+type FinalizedVersionRange struct {
+	MinVersionLevel int16
+	MaxVersionLevel int16
+}
+
+// SupportedVersionRange is the version range of a feature supported by a broker
+type SupportedVersionRange struct {
+	MinVersion int16
+	MaxVersion int16
+}
+
+// FeatureMetadata is the result of ClusterAdmin.DescribeFeatures
+type FeatureMetadata struct {
+	FinalizedFeatures map[string]FinalizedVersionRange
+	// FinalizedFeaturesEpoch is the epoch of the finalized features, or -1
+	// if it is unknown to the broker
+	FinalizedFeaturesEpoch int64
+	SupportedFeatures      map[string]SupportedVersionRange
+}
+
+// FeatureUpdate is a single update of ClusterAdmin.UpdateFeatures
+type FeatureUpdate struct {
+	MaxVersionLevel int16
+	// AllowDowngrade must be set if MaxVersionLevel is lower than the
+	// current finalized max version level or the feature is deleted
+	AllowDowngrade bool
+}
+
+func (ca *clusterAdmin) DescribeFeatures() (*FeatureMetadata, error) {
+	if !ca.conf.Version.IsAtLeast(V2_7_0_0) {
+		return nil, ErrUnsupportedVersion
+	}
+
+	b, err := ca.findAnyBroker()
+	if err != nil {
+		return nil, err
+	}
+	_ = b.Open(ca.client.Config())
+
+	rsp, err := b.getApiVersions(ca.context(), &ApiVersionsRequest{
+		Version:               3,
+		ClientSoftwareName:    defaultClientSoftwareName,
+		ClientSoftwareVersion: version(),
 	})
+	if err != nil {
+		return nil, err
+	}
+	if kerr := KError(rsp.ErrorCode); !errors.Is(kerr, ErrNoError) {
+		return nil, kerr
+	}
+
+	metadata := &FeatureMetadata{
+		FinalizedFeatures:      make(map[string]FinalizedVersionRange),
+		FinalizedFeaturesEpoch: rsp.FinalizedFeaturesEpoch,
+		SupportedFeatures:      make(map[string]SupportedVersionRange),
+	}
+	for _, f := range rsp.FinalizedFeatures {
+		metadata.FinalizedFeatures[f.Name] = FinalizedVersionRange{
+			MinVersionLevel: f.MinVersionLevel,
+			MaxVersionLevel: f.MaxVersionLevel,
+		}
+	}
+	for _, f := range rsp.SupportedFeatures {
+		metadata.SupportedFeatures[f.Name] = SupportedVersionRange{
+			MinVersion: f.MinVersion,
+			MaxVersion: f.MaxVersion,
+		}
+	}
+	return metadata, nil
 }
 
-func (ca *clusterAdmin) AlterPartitionReassignments(topic string, assignment [][]int32) error {
-	if topic == "" {
-		return ErrInvalidTopic
+func (ca *clusterAdmin) UpdateFeatures(updates map[string]FeatureUpdate) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
 	}
 
-	request := &AlterPartitionReassignmentsRequest{
-		TimeoutMs: int32(60000),
-		Version:   int16(0),
+	if len(updates) == 0 {
+		return ErrInvalidRequest
 	}
 
-	for i := 0; i < len(assignment); i++ {
-		request.AddBlock(topic, int32(i), assignment[i])
+	request := &UpdateFeaturesRequest{
+		Timeout: ca.conf.Admin.Timeout,
+	}
+	for feature, u := range updates {
+		request.FeatureUpdates = append(request.FeatureUpdates, &FeatureUpdateKey{
+			Feature:         feature,
+			MaxVersionLevel: u.MaxVersionLevel,
+			AllowDowngrade:  u.AllowDowngrade,
+		})
 	}
 
 	return ca.retryOnError(isErrNoController, func() error {
@@ -506,381 +1192,2540 @@ func (ca *clusterAdmin) AlterPartitionReassignments(topic string, assignment [][
 			return err
 		}
 
+		rsp, err := b.updateFeatures(ca.context(), request)
+		if err != nil {
+			return err
+		}
+
+		if !errors.Is(rsp.ErrorCode, ErrNoError) {
+			if errors.Is(rsp.ErrorCode, ErrNotController) {
+				_, _ = ca.refreshController()
+				return rsp.ErrorCode
+			}
+			if rsp.ErrorMessage != nil && len(*rsp.ErrorMessage) > 0 {
+				return errors.New(*rsp.ErrorMessage)
+			}
+			return rsp.ErrorCode
+		}
+
 		errs := make([]error, 0)
+		for _, res := range rsp.Results {
+			if errors.Is(res.ErrorCode, ErrNoError) {
+				continue
+			}
+			if res.ErrorMessage != nil && len(*res.ErrorMessage) > 0 {
+				errs = append(errs, fmt.Errorf("%s: %w: %s", res.Feature, res.ErrorCode, *res.ErrorMessage))
+			} else {
+				errs = append(errs, fmt.Errorf("%s: %w", res.Feature, res.ErrorCode))
+			}
+		}
+		if len(errs) > 0 {
+			return Wrap(ErrUpdateFeatures, errs...)
+		}
+		return nil
+	})
+}
+
+func (ca *clusterAdmin) ElectLeaders(electionType ElectionType, partitions map[string][]int32) (map[string]map[int32]*PartitionResult, error) {
+	if err := ca.checkMutable(); err != nil {
+		return nil, err
+	}
 
-		rsp, err := b.AlterPartitionReassignments(request)
+	request := &ElectLeadersRequest{
+		Type:            electionType,
+		TopicPartitions: partitions,
+		Timeout:         ca.conf.Admin.Timeout,
+	}
+
+	if ca.conf.Version.IsAtLeast(V2_4_0_0) {
+		request.Version = 2
+	}
 
+	var results map[string]map[int32]*PartitionResult
+	err := ca.retryOnError(isErrNoController, func() error {
+		b, err := ca.Controller()
 		if err != nil {
-			errs = append(errs, err)
-		} else {
-			if rsp.ErrorCode > 0 {
-				errs = append(errs, errors.New(rsp.ErrorCode.Error()))
-			}
+			return err
+		}
 
-			for topic, topicErrors := range rsp.Errors {
-				for partition, partitionError := range topicErrors {
-					if !errors.Is(partitionError.errorCode, ErrNoError) {
-						errStr := fmt.Sprintf("[%s-%d]: %s", topic, partition, partitionError.errorCode.Error())
-						errs = append(errs, errors.New(errStr))
-					}
-				}
-			}
+		rsp, err := b.electLeaders(ca.context(), request)
+		if err != nil {
+			return err
 		}
 
-		if len(errs) > 0 {
-			return Wrap(ErrReassignPartitions, errs...)
+		if !errors.Is(rsp.ErrorCode, ErrNoError) {
+			if errors.Is(rsp.ErrorCode, ErrNotController) {
+				_, _ = ca.refreshController()
+			}
+			return rsp.ErrorCode
 		}
 
+		results = rsp.ReplicaElectionResults
 		return nil
 	})
+	return results, err
 }
 
-func (ca *clusterAdmin) ListPartitionReassignments(topic string, partitions []int32) (topicStatus map[string]map[int32]*PartitionReplicaReassignmentsStatus, err error) {
-	if topic == "" {
-		return nil, ErrInvalidTopic
+func (ca *clusterAdmin) findBroker(id int32) (*Broker, error) {
+	brokers := ca.client.Brokers()
+	for _, b := range brokers {
+		if b.ID() == id {
+			return b, nil
+		}
 	}
+	return nil, fmt.Errorf("could not find broker id %d", id)
+}
 
-	request := &ListPartitionReassignmentsRequest{
-		TimeoutMs: int32(60000),
-		Version:   int16(0),
+func (ca *clusterAdmin) findAnyBroker() (*Broker, error) {
+	if ca.conf.BrokerSelector != nil {
+		if b := ca.client.LeastLoadedBroker(); b != nil {
+			return b, nil
+		}
+		return nil, errors.New("no available broker")
 	}
 
-	request.AddBlock(topic, partitions)
+	brokers := ca.client.Brokers()
+	if len(brokers) > 0 {
+		index := rand.Intn(len(brokers))
+		return brokers[index], nil
+	}
+	return nil, errors.New("no available broker")
+}
 
-	b, err := ca.Controller()
+func (ca *clusterAdmin) ListTopics() (map[string]TopicDetail, error) {
+	// In order to build TopicDetails we need to first get the list of all
+	// topics using a MetadataRequest and then get their configs using a
+	// DescribeConfigsRequest request. To avoid sending many requests to the
+	// broker, we use a single DescribeConfigsRequest.
+
+	// Send the all-topic MetadataRequest
+	b, err := ca.findAnyBroker()
 	if err != nil {
 		return nil, err
 	}
 	_ = b.Open(ca.client.Config())
 
-	rsp, err := b.ListPartitionReassignments(request)
-
-	if err == nil && rsp != nil {
-		return rsp.TopicStatus, nil
-	} else {
+	metadataReq := &MetadataRequest{}
+	metadataResp, err := b.getMetadata(ca.context(), metadataReq)
+	if err != nil {
 		return nil, err
 	}
-}
 
-func (ca *clusterAdmin) DeleteRecords(topic string, partitionOffsets map[int32]int64) error {
-	if topic == "" {
-		return ErrInvalidTopic
-	}
-	partitionPerBroker := make(map[*Broker][]int32)
-	for partition := range partitionOffsets {
-		broker, err := ca.client.Leader(topic, partition)
-		if err != nil {
-			return err
+	topicsDetailsMap := make(map[string]TopicDetail)
+
+	var describeConfigsResources []*ConfigResource
+
+	for _, topic := range metadataResp.Topics {
+		topicDetails := TopicDetail{
+			NumPartitions: int32(len(topic.Partitions)),
 		}
-		partitionPerBroker[broker] = append(partitionPerBroker[broker], partition)
-	}
-	errs := make([]error, 0)
-	for broker, partitions := range partitionPerBroker {
-		topics := make(map[string]*DeleteRecordsRequestTopic)
-		recordsToDelete := make(map[int32]int64)
-		for _, p := range partitions {
-			recordsToDelete[p] = partitionOffsets[p]
+		if len(topic.Partitions) > 0 {
+			topicDetails.ReplicaAssignment = map[int32][]int32{}
+			for _, partition := range topic.Partitions {
+				topicDetails.ReplicaAssignment[partition.ID] = partition.Replicas
+			}
+			topicDetails.ReplicationFactor = int16(len(topic.Partitions[0].Replicas))
 		}
-		topics[topic] = &DeleteRecordsRequestTopic{PartitionOffsets: recordsToDelete}
-		request := &DeleteRecordsRequest{
-			Topics:  topics,
-			Timeout: ca.conf.Admin.Timeout,
+		topicsDetailsMap[topic.Name] = topicDetails
+
+		// we populate the resources we want to describe from the MetadataResponse
+		topicResource := ConfigResource{
+			Type: TopicResource,
+			Name: topic.Name,
 		}
+		describeConfigsResources = append(describeConfigsResources, &topicResource)
+	}
 
-		rsp, err := broker.DeleteRecords(request)
-		if err != nil {
-			errs = append(errs, err)
-		} else {
-			deleteRecordsResponseTopic, ok := rsp.Topics[topic]
-			if !ok {
-				errs = append(errs, ErrIncompleteResponse)
-			} else {
-				for _, deleteRecordsResponsePartition := range deleteRecordsResponseTopic.Partitions {
-					if !errors.Is(deleteRecordsResponsePartition.Err, ErrNoError) {
-						errs = append(errs, errors.New(deleteRecordsResponsePartition.Err.Error()))
-					}
-				}
+	// Send the DescribeConfigsRequest
+	describeConfigsReq := &DescribeConfigsRequest{
+		Resources: describeConfigsResources,
+	}
+
+	if ca.conf.Version.IsAtLeast(V1_1_0_0) {
+		describeConfigsReq.Version = 1
+	}
+
+	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		describeConfigsReq.Version = 2
+	}
+
+	describeConfigsResp, err := b.describeConfigs(ca.context(), describeConfigsReq)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resource := range describeConfigsResp.Resources {
+		topicDetails := topicsDetailsMap[resource.Name]
+		topicDetails.ConfigEntries = make(map[string]*string)
+
+		for _, entry := range resource.Configs {
+			// only include non-default non-sensitive config
+			// (don't actually think topic config will ever be sensitive)
+			if entry.Default || entry.Sensitive {
+				continue
+			}
+			topicDetails.ConfigEntries[entry.Name] = &entry.Value
+		}
+
+		topicsDetailsMap[resource.Name] = topicDetails
+	}
+
+	return topicsDetailsMap, nil
+}
+
+func (ca *clusterAdmin) DeleteTopic(topic string) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
+	}
+
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+
+	request := &DeleteTopicsRequest{
+		Topics:  []string{topic},
+		Timeout: ca.conf.Admin.Timeout,
+	}
+
+	if ca.conf.Version.IsAtLeast(V0_11_0_0) {
+		request.Version = 1
+	}
+
+	return ca.retryOnError(isErrNoController, func() error {
+		b, err := ca.Controller()
+		if err != nil {
+			return err
+		}
+
+		rsp, err := b.deleteTopics(ca.context(), request)
+		if err != nil {
+			return err
+		}
+
+		topicErr, ok := rsp.TopicErrorCodes[topic]
+		if !ok {
+			return ErrIncompleteResponse
+		}
+
+		if !errors.Is(topicErr, ErrNoError) {
+			if errors.Is(topicErr, ErrNotController) {
+				_, _ = ca.refreshController()
+			}
+			return topicErr
+		}
+
+		return nil
+	})
+}
+
+func (ca *clusterAdmin) DeleteTopicByID(topicID Uuid) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
+	}
+
+	if topicID == NullUuid {
+		return ErrInvalidTopic
+	}
+	if !ca.conf.Version.IsAtLeast(V2_8_0_0) {
+		return ErrUnsupportedVersion
+	}
+
+	request := &DeleteTopicsRequest{
+		Version:  6,
+		TopicIDs: []Uuid{topicID},
+		Timeout:  ca.conf.Admin.Timeout,
+	}
+
+	return ca.retryOnError(isErrNoController, func() error {
+		b, err := ca.Controller()
+		if err != nil {
+			return err
+		}
+
+		rsp, err := b.deleteTopics(ca.context(), request)
+		if err != nil {
+			return err
+		}
+
+		topicErr, ok := rsp.TopicIDErrorCodes[topicID]
+		if !ok {
+			for topic, id := range rsp.TopicIDs {
+				if id == topicID {
+					topicErr, ok = rsp.TopicErrorCodes[topic], true
+					break
+				}
+			}
+		}
+		if !ok {
+			return ErrIncompleteResponse
+		}
+
+		if !errors.Is(topicErr, ErrNoError) {
+			if errors.Is(topicErr, ErrNotController) {
+				_, _ = ca.refreshController()
+			}
+			return topicErr
+		}
+
+		return nil
+	})
+}
+
+// BulkTopicOptions controls how ClusterAdmin.CreateTopics and
+// ClusterAdmin.DeleteTopics split up their work
+type BulkTopicOptions struct {
+	// BatchSize is the maximum number of topics per request (defaults to 50)
+	BatchSize int
+	// Concurrency is the maximum number of requests in flight (defaults to 1)
+	Concurrency int
+	// RequestInterval is the minimum time between sending two requests, to
+	// limit the rate of requests to the controller (defaults to 0, no limit)
+	RequestInterval time.Duration
+	// ValidateOnly only validates the topics to create, it is ignored when
+	// deleting topics
+	ValidateOnly bool
+}
+
+func (o *BulkTopicOptions) withDefaults() BulkTopicOptions {
+	var opts BulkTopicOptions
+	if o != nil {
+		opts = *o
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	return opts
+}
+
+// bulkTopicOperation splits the topics into batches and calls fn for each of
+// them, with at most options.Concurrency calls in flight and at least
+// options.RequestInterval between two calls. Topics of batches that fail as a
+// whole are reported with the error of their batch.
+func (ca *clusterAdmin) bulkTopicOperation(topics []string, options BulkTopicOptions, sentinel error, fn func(batch []string) (map[string]error, error)) (map[string]error, error) {
+	sort.Strings(topics)
+
+	var (
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(topics))
+		slots   = make(chan struct{}, options.Concurrency)
+		done    = ca.context().Done()
+		last    time.Time
+	)
+	record := func(batch []string, batchResults map[string]error, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, topic := range batch {
+			if err != nil {
+				results[topic] = err
+			} else if topicErr, ok := batchResults[topic]; ok {
+				results[topic] = topicErr
+			} else {
+				results[topic] = ErrIncompleteResponse
+			}
+		}
+	}
+
+	for start := 0; start < len(topics); start += options.BatchSize {
+		end := start + options.BatchSize
+		if end > len(topics) {
+			end = len(topics)
+		}
+		batch := topics[start:end]
+
+		if wait := options.RequestInterval - time.Since(last); !last.IsZero() && wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-done:
+			}
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-done:
+		}
+		if err := ca.context().Err(); err != nil {
+			record(topics[start:], nil, err)
+			break
+		}
+		last = time.Now()
+
+		wg.Add(1)
+		go withRecover(func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			batchResults, err := fn(batch)
+			record(batch, batchResults, err)
+		})
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, topic := range topics {
+		if err := results[topic]; err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", topic, err))
+		}
+	}
+	if len(errs) > 0 {
+		return results, Wrap(sentinel, errs...)
+	}
+	return results, nil
+}
+
+func (ca *clusterAdmin) CreateTopics(topics map[string]*TopicDetail, options *BulkTopicOptions) (map[string]error, error) {
+	opts := options.withDefaults()
+
+	names := make([]string, 0, len(topics))
+	for topic, detail := range topics {
+		if topic == "" {
+			return nil, ErrInvalidTopic
+		}
+		if detail == nil {
+			return nil, errors.New("you must specify topic details")
+		}
+		names = append(names, topic)
+	}
+
+	return ca.bulkTopicOperation(names, opts, ErrCreateTopics, func(batch []string) (map[string]error, error) {
+		request := &CreateTopicsRequest{
+			TopicDetails: make(map[string]*TopicDetail, len(batch)),
+			ValidateOnly: opts.ValidateOnly || ca.validateOnly,
+			Timeout:      ca.conf.Admin.Timeout,
+		}
+		for _, topic := range batch {
+			request.TopicDetails[topic] = topics[topic]
+		}
+		if ca.conf.Version.IsAtLeast(V0_11_0_0) {
+			request.Version = 1
+		}
+		if ca.conf.Version.IsAtLeast(V1_0_0_0) {
+			request.Version = 2
+		}
+
+		results := make(map[string]error, len(batch))
+		err := ca.retryOnError(isErrNoController, func() error {
+			b, err := ca.Controller()
+			if err != nil {
+				return err
+			}
+
+			rsp, err := b.createTopics(ca.context(), request)
+			if err != nil {
+				return err
+			}
+
+			for topic, topicErr := range rsp.TopicErrors {
+				if errors.Is(topicErr.Err, ErrNotController) {
+					_, _ = ca.refreshController()
+					return topicErr
+				}
+				if errors.Is(topicErr.Err, ErrNoError) {
+					results[topic] = nil
+				} else {
+					results[topic] = topicErr
+				}
+			}
+			return nil
+		})
+		return results, err
+	})
+}
+
+func (ca *clusterAdmin) DeleteTopics(topics []string, options *BulkTopicOptions) (map[string]error, error) {
+	if err := ca.checkMutable(); err != nil {
+		return nil, err
+	}
+
+	opts := options.withDefaults()
+
+	names := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if topic == "" {
+			return nil, ErrInvalidTopic
+		}
+		names = append(names, topic)
+	}
+
+	return ca.bulkTopicOperation(names, opts, ErrDeleteTopics, func(batch []string) (map[string]error, error) {
+		request := &DeleteTopicsRequest{
+			Topics:  batch,
+			Timeout: ca.conf.Admin.Timeout,
+		}
+		if ca.conf.Version.IsAtLeast(V0_11_0_0) {
+			request.Version = 1
+		}
+
+		results := make(map[string]error, len(batch))
+		err := ca.retryOnError(isErrNoController, func() error {
+			b, err := ca.Controller()
+			if err != nil {
+				return err
+			}
+
+			rsp, err := b.deleteTopics(ca.context(), request)
+			if err != nil {
+				return err
+			}
+
+			for topic, topicErr := range rsp.TopicErrorCodes {
+				if errors.Is(topicErr, ErrNotController) {
+					_, _ = ca.refreshController()
+					return topicErr
+				}
+				if errors.Is(topicErr, ErrNoError) {
+					results[topic] = nil
+				} else {
+					results[topic] = topicErr
+				}
+			}
+			return nil
+		})
+		return results, err
+	})
+}
+
+func (ca *clusterAdmin) CreatePartitions(topic string, count int32, assignment [][]int32, validateOnly bool) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+
+	topicPartitions := make(map[string]*TopicPartition)
+	topicPartitions[topic] = &TopicPartition{Count: count, Assignment: assignment}
+
+	request := &CreatePartitionsRequest{
+		TopicPartitions: topicPartitions,
+		Timeout:         ca.conf.Admin.Timeout,
+		ValidateOnly:    validateOnly || ca.validateOnly,
+	}
+
+	return ca.retryOnError(isErrNoController, func() error {
+		b, err := ca.Controller()
+		if err != nil {
+			return err
+		}
+
+		rsp, err := b.createPartitions(ca.context(), request)
+		if err != nil {
+			return err
+		}
+
+		topicErr, ok := rsp.TopicPartitionErrors[topic]
+		if !ok {
+			return ErrIncompleteResponse
+		}
+
+		if !errors.Is(topicErr.Err, ErrNoError) {
+			if errors.Is(topicErr.Err, ErrNotController) {
+				_, _ = ca.refreshController()
+			}
+			return topicErr
+		}
+
+		return nil
+	})
+}
+
+func (ca *clusterAdmin) CreateBalancedPartitions(topic string, count int32, validateOnly bool) ([][]int32, error) {
+	if topic == "" {
+		return nil, ErrInvalidTopic
+	}
+
+	controller, err := ca.Controller()
+	if err != nil {
+		return nil, err
+	}
+
+	request := &MetadataRequest{
+		Topics:                 []string{},
+		AllowAutoTopicCreation: false,
+	}
+	if ca.conf.Version.IsAtLeast(V1_0_0_0) {
+		request.Version = 5
+	} else if ca.conf.Version.IsAtLeast(V0_11_0_0) {
+		request.Version = 4
+	} else if ca.conf.Version.IsAtLeast(V0_10_0_0) {
+		request.Version = 1
+	}
+
+	response, err := controller.getMetadata(ca.context(), request)
+	if err != nil {
+		return nil, err
+	}
+
+	racks := make(map[int32]string, len(response.Brokers))
+	for _, b := range response.Brokers {
+		racks[b.ID()] = b.Rack()
+	}
+
+	var target *TopicMetadata
+	replicaCounts := make(map[int32]int)
+	leaderCounts := make(map[int32]int)
+	for _, t := range response.Topics {
+		if t.Name == topic {
+			target = t
+		}
+		for _, p := range t.Partitions {
+			for _, replica := range p.Replicas {
+				replicaCounts[replica]++
+			}
+			if len(p.Replicas) > 0 {
+				// the preferred leader
+				leaderCounts[p.Replicas[0]]++
+			}
+		}
+	}
+	if target == nil {
+		return nil, ErrUnknownTopicOrPartition
+	}
+	if !errors.Is(target.Err, ErrNoError) {
+		return nil, target.Err
+	}
+	if len(target.Partitions) == 0 || len(target.Partitions[0].Replicas) == 0 {
+		return nil, ErrIncompleteResponse
+	}
+
+	current := int32(len(target.Partitions))
+	if count <= current {
+		return nil, fmt.Errorf("%s already has %d partitions: %w", topic, current, ErrInvalidPartitions)
+	}
+
+	assignment, err := balancedAssignment(racks, replicaCounts, leaderCounts,
+		int(count-current), len(target.Partitions[0].Replicas))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ca.CreatePartitions(topic, count, assignment, validateOnly); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// balancedAssignment assigns the replicas of new partitions to brokers, given
+// the rack of every broker and the number of replicas and preferred leaders
+// they already hold. Each replica goes to a rack the partition is not on yet
+// when possible, then to the least loaded broker, the broker with the lowest
+// ID winning ties. The counts are updated in place.
+func balancedAssignment(racks map[int32]string, replicaCounts, leaderCounts map[int32]int, partitions, replicationFactor int) ([][]int32, error) {
+	if replicationFactor <= 0 || replicationFactor > len(racks) {
+		return nil, fmt.Errorf("replication factor %d with %d brokers: %w", replicationFactor, len(racks), ErrInvalidReplicationFactor)
+	}
+
+	ids := make([]int32, 0, len(racks))
+	for id := range racks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	assignment := make([][]int32, 0, partitions)
+	for i := 0; i < partitions; i++ {
+		replicas := make([]int32, 0, replicationFactor)
+		usedRacks := make(map[string]bool)
+		for len(replicas) < replicationFactor {
+			leader := len(replicas) == 0
+			best := int32(-1)
+			for _, id := range ids {
+				if int32Contains(replicas, id) {
+					continue
+				}
+				if best < 0 || balancedBrokerLess(id, best, leader, racks, usedRacks, replicaCounts, leaderCounts) {
+					best = id
+				}
+			}
+			replicas = append(replicas, best)
+			usedRacks[racks[best]] = true
+			replicaCounts[best]++
+			if leader {
+				leaderCounts[best]++
+			}
+		}
+		assignment = append(assignment, replicas)
+	}
+
+	return assignment, nil
+}
+
+func balancedBrokerLess(a, b int32, leader bool, racks map[int32]string, usedRacks map[string]bool, replicaCounts, leaderCounts map[int32]int) bool {
+	if ua, ub := usedRacks[racks[a]], usedRacks[racks[b]]; ua != ub {
+		return !ua
+	}
+	if leader && leaderCounts[a] != leaderCounts[b] {
+		return leaderCounts[a] < leaderCounts[b]
+	}
+	return replicaCounts[a] < replicaCounts[b]
+}
+
+func int32Contains(values []int32, value int32) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func int16Contains(values []int16, value int16) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (ca *clusterAdmin) AlterPartitionReassignments(topic string, assignment [][]int32) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+	if ca.validateOnly {
+		return ca.validateReassignments(topic, assignment)
+	}
+
+	request := &AlterPartitionReassignmentsRequest{
+		TimeoutMs: int32(60000),
+		Version:   int16(0),
+	}
+
+	for i := 0; i < len(assignment); i++ {
+		request.AddBlock(topic, int32(i), assignment[i])
+	}
+
+	return ca.retryOnError(isErrNoController, func() error {
+		b, err := ca.Controller()
+		if err != nil {
+			return err
+		}
+
+		errs := make([]error, 0)
+
+		rsp, err := b.alterPartitionReassignments(ca.context(), request)
+
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			if rsp.ErrorCode > 0 {
+				errs = append(errs, errors.New(rsp.ErrorCode.Error()))
+			}
+
+			for topic, topicErrors := range rsp.Errors {
+				for partition, partitionError := range topicErrors {
+					if !errors.Is(partitionError.errorCode, ErrNoError) {
+						errStr := fmt.Sprintf("[%s-%d]: %s", topic, partition, partitionError.errorCode.Error())
+						errs = append(errs, errors.New(errStr))
+					}
+				}
+			}
+		}
+
+		if len(errs) > 0 {
+			return Wrap(ErrReassignPartitions, errs...)
+		}
+
+		return nil
+	})
+}
+
+// validateReassignments checks that the partitions of an assignment exist and
+// that their replicas are distinct known brokers, as the protocol has no
+// validateOnly flag for reassignments
+func (ca *clusterAdmin) validateReassignments(topic string, assignment [][]int32) error {
+	metadata, err := ca.DescribeTopics([]string{topic})
+	if err != nil {
+		return err
+	}
+	if len(metadata) != 1 {
+		return ErrIncompleteResponse
+	}
+	if !errors.Is(metadata[0].Err, ErrNoError) {
+		return metadata[0].Err
+	}
+
+	brokers := make(map[int32]bool)
+	for _, b := range ca.client.Brokers() {
+		brokers[b.ID()] = true
+	}
+
+	errs := make([]error, 0)
+	for partition, replicas := range assignment {
+		if partition >= len(metadata[0].Partitions) {
+			errs = append(errs, fmt.Errorf("[%s-%d]: %w", topic, partition, ErrUnknownTopicOrPartition))
+			continue
+		}
+		seen := make(map[int32]bool, len(replicas))
+		for _, replica := range replicas {
+			if seen[replica] || !brokers[replica] {
+				errs = append(errs, fmt.Errorf("[%s-%d]: %w: replica %d", topic, partition, ErrInvalidReplicaAssignment, replica))
+				break
+			}
+			seen[replica] = true
+		}
+	}
+
+	if len(errs) > 0 {
+		return Wrap(ErrReassignPartitions, errs...)
+	}
+	return nil
+}
+
+func (ca *clusterAdmin) ListPartitionReassignments(topic string, partitions []int32) (topicStatus map[string]map[int32]*PartitionReplicaReassignmentsStatus, err error) {
+	if topic == "" {
+		return nil, ErrInvalidTopic
+	}
+
+	request := &ListPartitionReassignmentsRequest{
+		TimeoutMs: int32(60000),
+		Version:   int16(0),
+	}
+
+	request.AddBlock(topic, partitions)
+
+	b, err := ca.Controller()
+	if err != nil {
+		return nil, err
+	}
+	_ = b.Open(ca.client.Config())
+
+	rsp, err := b.listPartitionReassignments(ca.context(), request)
+
+	if err == nil && rsp != nil {
+		return rsp.TopicStatus, nil
+	} else {
+		return nil, err
+	}
+}
+
+// PartitionReassignmentProgress reports the progress of the replica
+// reassignment of a single partition
+type PartitionReassignmentProgress struct {
+	Topic     string
+	Partition int32
+
+	Replicas         []int32
+	AddingReplicas   []int32
+	RemovingReplicas []int32
+
+	// ReplicaSizes maps the IDs of the brokers hosting a replica of the
+	// partition to the size of their log in bytes
+	ReplicaSizes map[int32]int64
+
+	// Done is set once the reassignment of the partition has completed
+	Done bool
+}
+
+func (ca *clusterAdmin) MonitorPartitionReassignments(topic string, partitions []int32, interval, timeout time.Duration, progress func(*PartitionReassignmentProgress)) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+
+	ongoing := make(map[int32]bool)
+	for {
+		topicStatus, err := ca.ListPartitionReassignments(topic, partitions)
+		if err != nil {
+			return err
+		}
+		status := topicStatus[topic]
+
+		for partition := range ongoing {
+			if _, ok := status[partition]; !ok {
+				delete(ongoing, partition)
+				if progress != nil {
+					progress(&PartitionReassignmentProgress{Topic: topic, Partition: partition, Done: true})
+				}
+			}
+		}
+
+		if len(status) == 0 {
+			return nil
+		}
+
+		sizes := ca.replicaLogSizes(topic, status)
+		for partition, s := range status {
+			ongoing[partition] = true
+			if progress != nil {
+				progress(&PartitionReassignmentProgress{
+					Topic:            topic,
+					Partition:        partition,
+					Replicas:         s.Replicas,
+					AddingReplicas:   s.AddingReplicas,
+					RemovingReplicas: s.RemovingReplicas,
+					ReplicaSizes:     sizes[partition],
+				})
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return ErrReassignmentTimeout
+		case <-ca.context().Done():
+			return ca.context().Err()
+		}
+	}
+}
+
+// replicaLogSizes returns the log sizes of the replicas of the given
+// partitions of a topic, as reported by DescribeLogDirs. Failures are logged
+// and result in missing sizes since they are only informational.
+func (ca *clusterAdmin) replicaLogSizes(topic string, status map[int32]*PartitionReplicaReassignmentsStatus) map[int32]map[int32]int64 {
+	brokerIDs := make(map[int32]none)
+	for _, s := range status {
+		for _, id := range s.Replicas {
+			brokerIDs[id] = none{}
+		}
+	}
+	ids := make([]int32, 0, len(brokerIDs))
+	for id := range brokerIDs {
+		ids = append(ids, id)
+	}
+
+	sizes := make(map[int32]map[int32]int64, len(status))
+	logDirs, err := ca.DescribeLogDirs(ids)
+	if err != nil {
+		Logger.Printf("admin/reassignment unable to describe log dirs of topic %s: %v\n", topic, err)
+	}
+	for brokerID, dirs := range logDirs {
+		for _, dir := range dirs {
+			for _, t := range dir.Topics {
+				if t.Topic != topic {
+					continue
+				}
+				for _, p := range t.Partitions {
+					if _, ok := status[p.PartitionID]; !ok || p.IsTemporary {
+						continue
+					}
+					if sizes[p.PartitionID] == nil {
+						sizes[p.PartitionID] = make(map[int32]int64)
+					}
+					sizes[p.PartitionID][brokerID] = p.Size
+				}
+			}
+		}
+	}
+	return sizes
+}
+
+func (ca *clusterAdmin) DeleteRecords(topic string, partitionOffsets map[int32]int64) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
+	}
+
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+	partitionPerBroker := make(map[*Broker][]int32)
+	for partition := range partitionOffsets {
+		broker, err := ca.client.Leader(topic, partition)
+		if err != nil {
+			return err
+		}
+		partitionPerBroker[broker] = append(partitionPerBroker[broker], partition)
+	}
+	errs := make([]error, 0)
+	for broker, partitions := range partitionPerBroker {
+		topics := make(map[string]*DeleteRecordsRequestTopic)
+		recordsToDelete := make(map[int32]int64)
+		for _, p := range partitions {
+			recordsToDelete[p] = partitionOffsets[p]
+		}
+		topics[topic] = &DeleteRecordsRequestTopic{PartitionOffsets: recordsToDelete}
+		request := &DeleteRecordsRequest{
+			Topics:  topics,
+			Timeout: ca.conf.Admin.Timeout,
+		}
+
+		rsp, err := broker.deleteRecords(ca.context(), request)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			deleteRecordsResponseTopic, ok := rsp.Topics[topic]
+			if !ok {
+				errs = append(errs, ErrIncompleteResponse)
+			} else {
+				for _, deleteRecordsResponsePartition := range deleteRecordsResponseTopic.Partitions {
+					if !errors.Is(deleteRecordsResponsePartition.Err, ErrNoError) {
+						errs = append(errs, errors.New(deleteRecordsResponsePartition.Err.Error()))
+					}
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return Wrap(ErrDeleteRecords, errs...)
+	}
+	// todo since we are dealing with couple of partitions it would be good if we return slice of errors
+	// for each partition instead of one error
+	return nil
+}
+
+func (ca *clusterAdmin) PurgeTopic(topic string) (map[int32]*DeleteRecordsResponsePartition, error) {
+	if err := ca.checkMutable(); err != nil {
+		return nil, err
+	}
+
+	if topic == "" {
+		return nil, ErrInvalidTopic
+	}
+
+	partitions, err := ca.client.Partitions(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	highWatermarks, err := ca.ListOffsets(map[string][]int32{topic: partitions}, OffsetNewest)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[int32]*DeleteRecordsResponsePartition, len(partitions))
+	errs := make([]error, 0)
+	partitionPerBroker := make(map[*Broker]map[int32]int64)
+	for _, partition := range partitions {
+		block := highWatermarks[topic][partition]
+		if block == nil {
+			results[partition] = &DeleteRecordsResponsePartition{LowWatermark: -1, Err: ErrUnknown}
+			errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, ErrIncompleteResponse))
+			continue
+		}
+		if !errors.Is(block.Err, ErrNoError) {
+			results[partition] = &DeleteRecordsResponsePartition{LowWatermark: -1, Err: block.Err}
+			errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, block.Err))
+			continue
+		}
+
+		broker, err := ca.client.Leader(topic, partition)
+		if err != nil {
+			return nil, err
+		}
+		if partitionPerBroker[broker] == nil {
+			partitionPerBroker[broker] = make(map[int32]int64)
+		}
+		partitionPerBroker[broker][partition] = offsetOf(block)
+	}
+
+	for broker, partitionOffsets := range partitionPerBroker {
+		request := &DeleteRecordsRequest{
+			Topics:  map[string]*DeleteRecordsRequestTopic{topic: {PartitionOffsets: partitionOffsets}},
+			Timeout: ca.conf.Admin.Timeout,
+		}
+		rsp, err := broker.deleteRecords(ca.context(), request)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for partition := range partitionOffsets {
+			var result *DeleteRecordsResponsePartition
+			if rspTopic := rsp.Topics[topic]; rspTopic != nil {
+				result = rspTopic.Partitions[partition]
+			}
+			if result == nil {
+				errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, ErrIncompleteResponse))
+				continue
+			}
+			results[partition] = result
+			if !errors.Is(result.Err, ErrNoError) {
+				errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, result.Err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return results, Wrap(ErrDeleteRecords, errs...)
+	}
+	return results, nil
+}
+
+// Returns a bool indicating whether the resource request needs to go to a
+// specific broker
+func dependsOnSpecificNode(resource ConfigResource) bool {
+	return (resource.Type == BrokerResource && resource.Name != "") ||
+		resource.Type == BrokerLoggerResource
+}
+
+func (ca *clusterAdmin) DescribeConfig(resource ConfigResource) ([]ConfigEntry, error) {
+	var entries []ConfigEntry
+	var resources []*ConfigResource
+	resources = append(resources, &resource)
+
+	request := &DescribeConfigsRequest{
+		Resources: resources,
+	}
+
+	if ca.conf.Version.IsAtLeast(V1_1_0_0) {
+		request.Version = 1
+	}
+
+	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		request.Version = 2
+	}
+
+	var (
+		b   *Broker
+		err error
+	)
+
+	// DescribeConfig of broker/broker logger must be sent to the broker in question
+	if dependsOnSpecificNode(resource) {
+		var id int64
+		id, err = strconv.ParseInt(resource.Name, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		b, err = ca.findBroker(int32(id))
+	} else {
+		b, err = ca.findAnyBroker()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_ = b.Open(ca.client.Config())
+	rsp, err := b.describeConfigs(ca.context(), request)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rspResource := range rsp.Resources {
+		if rspResource.Name == resource.Name {
+			if rspResource.ErrorMsg != "" {
+				return nil, errors.New(rspResource.ErrorMsg)
+			}
+			if rspResource.ErrorCode != 0 {
+				return nil, KError(rspResource.ErrorCode)
+			}
+			for _, cfgEntry := range rspResource.Configs {
+				entries = append(entries, *cfgEntry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (ca *clusterAdmin) AlterConfig(resourceType ConfigResourceType, name string, entries map[string]*string, validateOnly bool) error {
+	var resources []*AlterConfigsResource
+	resources = append(resources, &AlterConfigsResource{
+		Type:          resourceType,
+		Name:          name,
+		ConfigEntries: entries,
+	})
+
+	request := &AlterConfigsRequest{
+		Resources:    resources,
+		ValidateOnly: validateOnly || ca.validateOnly,
+	}
+
+	var (
+		b   *Broker
+		err error
+	)
+
+	// AlterConfig of broker/broker logger must be sent to the broker in question
+	if dependsOnSpecificNode(ConfigResource{Name: name, Type: resourceType}) {
+		var id int64
+		id, err = strconv.ParseInt(name, 10, 32)
+		if err != nil {
+			return err
+		}
+		b, err = ca.findBroker(int32(id))
+	} else {
+		b, err = ca.findAnyBroker()
+	}
+	if err != nil {
+		return err
+	}
+
+	_ = b.Open(ca.client.Config())
+	rsp, err := b.alterConfigs(ca.context(), request)
+	if err != nil {
+		return err
+	}
+
+	for _, rspResource := range rsp.Resources {
+		if rspResource.Name == name {
+			if rspResource.ErrorMsg != "" {
+				return errors.New(rspResource.ErrorMsg)
+			}
+			if rspResource.ErrorCode != 0 {
+				return KError(rspResource.ErrorCode)
+			}
+		}
+	}
+	return nil
+}
+
+func (ca *clusterAdmin) IncrementalAlterConfig(resourceType ConfigResourceType, name string, entries map[string]IncrementalAlterConfigsEntry, validateOnly bool) error {
+	var resources []*IncrementalAlterConfigsResource
+	resources = append(resources, &IncrementalAlterConfigsResource{
+		Type:          resourceType,
+		Name:          name,
+		ConfigEntries: entries,
+	})
+
+	request := &IncrementalAlterConfigsRequest{
+		Resources:    resources,
+		ValidateOnly: validateOnly || ca.validateOnly,
+	}
+
+	var (
+		b   *Broker
+		err error
+	)
+
+	// AlterConfig of broker/broker logger must be sent to the broker in question
+	if dependsOnSpecificNode(ConfigResource{Name: name, Type: resourceType}) {
+		var id int64
+		id, err = strconv.ParseInt(name, 10, 32)
+		if err != nil {
+			return err
+		}
+		b, err = ca.findBroker(int32(id))
+	} else {
+		b, err = ca.findAnyBroker()
+	}
+	if err != nil {
+		return err
+	}
+
+	_ = b.Open(ca.client.Config())
+	rsp, err := b.incrementalAlterConfigs(ca.context(), request)
+	if err != nil {
+		return err
+	}
+
+	for _, rspResource := range rsp.Resources {
+		if rspResource.Name == name {
+			if rspResource.ErrorMsg != "" {
+				return errors.New(rspResource.ErrorMsg)
+			}
+			if rspResource.ErrorCode != 0 {
+				return KError(rspResource.ErrorCode)
+			}
+		}
+	}
+	return nil
+}
+
+func (ca *clusterAdmin) DescribeClusterBrokerConfig() ([]ConfigEntry, error) {
+	if !ca.conf.Version.IsAtLeast(V1_1_0_0) {
+		return nil, ErrUnsupportedVersion
+	}
+	return ca.DescribeConfig(ConfigResource{Type: BrokerResource})
+}
+
+func (ca *clusterAdmin) IncrementalAlterClusterBrokerConfig(entries map[string]IncrementalAlterConfigsEntry, validateOnly bool) error {
+	if !ca.conf.Version.IsAtLeast(V2_3_0_0) {
+		return ErrUnsupportedVersion
+	}
+	return ca.IncrementalAlterConfig(BrokerResource, "", entries, validateOnly)
+}
+
+// BrokerConfigDeviation describes a broker config which some brokers
+// override with a value different from the cluster-wide default
+type BrokerConfigDeviation struct {
+	// Default is the cluster-wide default, nil if there is none
+	Default *string
+	// Brokers maps the IDs of the deviating brokers to their values
+	Brokers map[int32]string
+}
+
+func (ca *clusterAdmin) DescribeBrokerConfigDeviations() (map[string]*BrokerConfigDeviation, error) {
+	defaults, err := ca.DescribeClusterBrokerConfig()
+	if err != nil {
+		return nil, err
+	}
+	defaultEntries := make(map[string]ConfigEntry, len(defaults))
+	for _, entry := range defaults {
+		defaultEntries[entry.Name] = entry
+	}
+
+	deviations := make(map[string]*BrokerConfigDeviation)
+	for _, b := range ca.client.Brokers() {
+		entries, err := ca.DescribeConfig(ConfigResource{Type: BrokerResource, Name: strconv.Itoa(int(b.ID()))})
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Source != SourceDynamicBroker {
+				continue
+			}
+			def, ok := defaultEntries[entry.Name]
+			if ok && !def.Sensitive && !entry.Sensitive && def.Value == entry.Value {
+				continue
+			}
+			deviation := deviations[entry.Name]
+			if deviation == nil {
+				deviation = &BrokerConfigDeviation{Brokers: make(map[int32]string)}
+				if ok {
+					value := def.Value
+					deviation.Default = &value
+				}
+				deviations[entry.Name] = deviation
+			}
+			deviation.Brokers[b.ID()] = entry.Value
+		}
+	}
+	return deviations, nil
+}
+
+// TopicSpec is the desired state of a topic for ClusterAdmin.ApplyTopicSpec
+type TopicSpec struct {
+	Name string
+	// NumPartitions and ReplicationFactor are left unchecked if <= 0
+	NumPartitions     int32
+	ReplicationFactor int16
+	// ConfigEntries set to nil are reset to their default, configs that are
+	// not listed are left as they are
+	ConfigEntries map[string]*string
+}
+
+// TopicSpecChanges holds the changes needed to bring a topic in line with a
+// TopicSpec
+type TopicSpecChanges struct {
+	// Create is set if the topic does not exist
+	Create bool
+	// CurrentPartitions is the current partition count and NumPartitions
+	// the new one, or 0 if unchanged
+	CurrentPartitions int32
+	NumPartitions     int32
+	// ConfigEntries holds the config alterations, keyed by name
+	ConfigEntries map[string]IncrementalAlterConfigsEntry
+	// Executed is set once all changes have been applied, it stays unset
+	// when they were only validated, see AdminOptions.ValidateOnly
+	Executed bool
+}
+
+// HasChanges reports whether the topic differs from its spec
+func (c *TopicSpecChanges) HasChanges() bool {
+	return c.Create || c.NumPartitions > 0 || len(c.ConfigEntries) > 0
+}
+
+// isTopicConfigOverride reports whether a config is set on the topic itself
+// rather than inherited from the broker or default
+func isTopicConfigOverride(entry ConfigEntry) bool {
+	if entry.Source != SourceUnknown {
+		return entry.Source == SourceTopic
+	}
+	return !entry.Default
+}
+
+func (ca *clusterAdmin) ApplyTopicSpec(spec TopicSpec, dryRun bool) (*TopicSpecChanges, error) {
+	if spec.Name == "" {
+		return nil, ErrInvalidTopic
+	}
+
+	changes, err := ca.diffTopicSpec(spec)
+	if err != nil || dryRun || !changes.HasChanges() {
+		return changes, err
+	}
+
+	if changes.Create {
+		configEntries := make(map[string]*string, len(spec.ConfigEntries))
+		for name, value := range spec.ConfigEntries {
+			if value != nil {
+				configEntries[name] = value
+			}
+		}
+		detail := &TopicDetail{
+			NumPartitions:     spec.NumPartitions,
+			ReplicationFactor: spec.ReplicationFactor,
+			ConfigEntries:     configEntries,
+		}
+		// -1 picks the broker default
+		if detail.NumPartitions <= 0 {
+			detail.NumPartitions = -1
+		}
+		if detail.ReplicationFactor <= 0 {
+			detail.ReplicationFactor = -1
+		}
+		err = ca.CreateTopic(spec.Name, detail, false)
+		if err != nil {
+			return changes, err
+		}
+		changes.Executed = !ca.validateOnly
+		return changes, nil
+	}
+
+	if changes.NumPartitions > 0 {
+		if err := ca.CreatePartitions(spec.Name, changes.NumPartitions, nil, false); err != nil {
+			return changes, err
+		}
+	}
+	if len(changes.ConfigEntries) > 0 {
+		if err := ca.IncrementalAlterConfig(TopicResource, spec.Name, changes.ConfigEntries, false); err != nil {
+			return changes, err
+		}
+	}
+	changes.Executed = !ca.validateOnly
+
+	return changes, nil
+}
+
+func (ca *clusterAdmin) diffTopicSpec(spec TopicSpec) (*TopicSpecChanges, error) {
+	metadata, err := ca.DescribeTopics([]string{spec.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	var topic *TopicMetadata
+	for _, m := range metadata {
+		if m.Name == spec.Name {
+			topic = m
+		}
+	}
+	if topic == nil || errors.Is(topic.Err, ErrUnknownTopicOrPartition) {
+		return &TopicSpecChanges{Create: true, NumPartitions: spec.NumPartitions}, nil
+	}
+	if !errors.Is(topic.Err, ErrNoError) {
+		return nil, topic.Err
+	}
+
+	changes := &TopicSpecChanges{CurrentPartitions: int32(len(topic.Partitions))}
+	if spec.NumPartitions > 0 {
+		if spec.NumPartitions < changes.CurrentPartitions {
+			return nil, fmt.Errorf("%s has %d partitions, cannot shrink to %d: %w",
+				spec.Name, changes.CurrentPartitions, spec.NumPartitions, ErrInvalidPartitions)
+		}
+		if spec.NumPartitions > changes.CurrentPartitions {
+			changes.NumPartitions = spec.NumPartitions
+		}
+	}
+	if spec.ReplicationFactor > 0 {
+		for _, partition := range topic.Partitions {
+			if len(partition.Replicas) != int(spec.ReplicationFactor) {
+				return nil, fmt.Errorf("%s/%d has %d replicas, expected %d: %w",
+					spec.Name, partition.ID, len(partition.Replicas), spec.ReplicationFactor, ErrInvalidReplicationFactor)
+			}
+		}
+	}
+
+	if len(spec.ConfigEntries) == 0 {
+		return changes, nil
+	}
+
+	entries, err := ca.DescribeConfig(ConfigResource{Type: TopicResource, Name: spec.Name})
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]ConfigEntry, len(entries))
+	for _, entry := range entries {
+		current[entry.Name] = entry
+	}
+
+	for name, value := range spec.ConfigEntries {
+		entry, ok := current[name]
+		switch {
+		case value == nil:
+			if ok && isTopicConfigOverride(entry) {
+				changes.addConfigEntry(name, IncrementalAlterConfigsEntry{Operation: IncrementalAlterConfigsOperationDelete})
+			}
+		case !ok || entry.Sensitive || entry.Value != *value || !isTopicConfigOverride(entry):
+			// the value of sensitive configs is not returned so they are always set
+			changes.addConfigEntry(name, IncrementalAlterConfigsEntry{Operation: IncrementalAlterConfigsOperationSet, Value: value})
+		}
+	}
+
+	return changes, nil
+}
+
+func (c *TopicSpecChanges) addConfigEntry(name string, entry IncrementalAlterConfigsEntry) {
+	if c.ConfigEntries == nil {
+		c.ConfigEntries = make(map[string]IncrementalAlterConfigsEntry)
+	}
+	c.ConfigEntries[name] = entry
+}
+
+func (ca *clusterAdmin) CreateACL(resource Resource, acl Acl) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
+	}
+
+	var acls []*AclCreation
+	acls = append(acls, &AclCreation{resource, acl})
+	request := &CreateAclsRequest{AclCreations: acls}
+
+	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		request.Version = 1
+	}
+
+	b, err := ca.Controller()
+	if err != nil {
+		return err
+	}
+
+	_, err = b.createAcls(ca.context(), request)
+	return err
+}
+
+func (ca *clusterAdmin) ListAcls(filter AclFilter) ([]ResourceAcls, error) {
+	request := &DescribeAclsRequest{AclFilter: filter}
+
+	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		request.Version = 1
+	}
+
+	b, err := ca.Controller()
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := b.describeAcls(ca.context(), request)
+	if err != nil {
+		return nil, err
+	}
+
+	var lAcls []ResourceAcls
+	for _, rAcl := range rsp.ResourceAcls {
+		lAcls = append(lAcls, *rAcl)
+	}
+	return lAcls, nil
+}
+
+func (ca *clusterAdmin) DeleteACL(filter AclFilter, validateOnly bool) ([]MatchingAcl, error) {
+	if validateOnly || ca.validateOnly {
+		return ca.matchingAcls(filter)
+	}
+
+	var filters []*AclFilter
+	filters = append(filters, &filter)
+	request := &DeleteAclsRequest{Filters: filters}
+
+	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		request.Version = 1
+	}
+
+	b, err := ca.Controller()
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := b.deleteAcls(ca.context(), request)
+	if err != nil {
+		return nil, err
+	}
+
+	var mAcls []MatchingAcl
+	for _, fr := range rsp.FilterResponses {
+		for _, mACL := range fr.MatchingAcls {
+			mAcls = append(mAcls, *mACL)
+		}
+	}
+	return mAcls, nil
+}
+
+// matchingAcls returns the ACLs DeleteACL would delete for the filter
+func (ca *clusterAdmin) matchingAcls(filter AclFilter) ([]MatchingAcl, error) {
+	resourceAcls, err := ca.ListAcls(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var mAcls []MatchingAcl
+	for _, ra := range resourceAcls {
+		for _, acl := range ra.Acls {
+			mAcls = append(mAcls, MatchingAcl{Resource: ra.Resource, Acl: *acl})
+		}
+	}
+	return mAcls, nil
+}
+
+// AclBinding is a single ACL bound to a resource
+type AclBinding struct {
+	Resource
+	Acl
+}
+
+// AclChanges holds the ACLs to create and delete to reconcile the ACLs of a
+// cluster with a desired set
+type AclChanges struct {
+	Create []AclBinding
+	Delete []AclBinding
+}
+
+func (b AclBinding) String() string {
+	return fmt.Sprintf("%s:%s:%s %s %s %s from %s", b.ResourceType.String(), b.ResourcePatternType.String(), b.ResourceName,
+		b.Principal, b.PermissionType.String(), b.Operation.String(), b.Host)
+}
+
+// normalizedAclBinding treats an unknown pattern type, as returned by v0
+// responses, as literal so bindings can be compared across versions
+func normalizedAclBinding(resource Resource, acl Acl) AclBinding {
+	if resource.ResourcePatternType == AclPatternUnknown {
+		resource.ResourcePatternType = AclPatternLiteral
+	}
+	return AclBinding{Resource: resource, Acl: acl}
+}
+
+func (ca *clusterAdmin) DiffACLs(desired []ResourceAcls, filter AclFilter) (*AclChanges, error) {
+	current, err := ca.ListAcls(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[AclBinding]bool)
+	for _, resourceAcls := range current {
+		for _, acl := range resourceAcls.Acls {
+			existing[normalizedAclBinding(resourceAcls.Resource, *acl)] = true
+		}
+	}
+
+	changes := &AclChanges{}
+	wanted := make(map[AclBinding]bool)
+	for _, resourceAcls := range desired {
+		for _, acl := range resourceAcls.Acls {
+			binding := normalizedAclBinding(resourceAcls.Resource, *acl)
+			if wanted[binding] {
+				continue
+			}
+			wanted[binding] = true
+			if !existing[binding] {
+				changes.Create = append(changes.Create, binding)
+			}
+		}
+	}
+	for _, resourceAcls := range current {
+		for _, acl := range resourceAcls.Acls {
+			binding := normalizedAclBinding(resourceAcls.Resource, *acl)
+			if !wanted[binding] {
+				wanted[binding] = true // report each binding once
+				changes.Delete = append(changes.Delete, binding)
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func (ca *clusterAdmin) EnsureACLs(desired []ResourceAcls, filter AclFilter, dryRun bool) (*AclChanges, error) {
+	changes, err := ca.DiffACLs(desired, filter)
+	if err != nil || dryRun || ca.validateOnly {
+		return changes, err
+	}
+
+	var version int16
+	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		version = 1
+	}
+
+	b, err := ca.Controller()
+	if err != nil {
+		return changes, err
+	}
+
+	errs := make([]error, 0)
+
+	// create before deleting so that replaced ACLs never leave a gap
+	if len(changes.Create) > 0 {
+		request := &CreateAclsRequest{Version: version}
+		for _, binding := range changes.Create {
+			request.AclCreations = append(request.AclCreations, &AclCreation{binding.Resource, binding.Acl})
+		}
+		rsp, err := b.createAcls(ca.context(), request)
+		if err != nil {
+			return changes, err
+		}
+		for i, creation := range rsp.AclCreationResponses {
+			if i >= len(changes.Create) {
+				break
+			}
+			binding := changes.Create[i]
+			if errors.Is(creation.Err, ErrNoError) {
+				continue
+			}
+			if creation.ErrMsg != nil && len(*creation.ErrMsg) > 0 {
+				errs = append(errs, fmt.Errorf("create %s: %w: %s", binding, creation.Err, *creation.ErrMsg))
+			} else {
+				errs = append(errs, fmt.Errorf("create %s: %w", binding, creation.Err))
+			}
+		}
+	}
+
+	if len(changes.Delete) > 0 {
+		request := &DeleteAclsRequest{Version: int(version)}
+		for _, binding := range changes.Delete {
+			resourceName, principal, host := binding.ResourceName, binding.Principal, binding.Host
+			request.Filters = append(request.Filters, &AclFilter{
+				ResourceType:              binding.ResourceType,
+				ResourceName:              &resourceName,
+				ResourcePatternTypeFilter: binding.ResourcePatternType,
+				Principal:                 &principal,
+				Host:                      &host,
+				Operation:                 binding.Operation,
+				PermissionType:            binding.PermissionType,
+			})
+		}
+		rsp, err := b.deleteAcls(ca.context(), request)
+		if err != nil {
+			return changes, err
+		}
+		for i, fr := range rsp.FilterResponses {
+			if i >= len(changes.Delete) {
+				break
+			}
+			binding := changes.Delete[i]
+			if errors.Is(fr.Err, ErrNoError) {
+				continue
+			}
+			if fr.ErrMsg != nil && len(*fr.ErrMsg) > 0 {
+				errs = append(errs, fmt.Errorf("delete %s: %w: %s", binding, fr.Err, *fr.ErrMsg))
+			} else {
+				errs = append(errs, fmt.Errorf("delete %s: %w", binding, fr.Err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return changes, Wrap(ErrReconcileACLs, errs...)
+	}
+
+	return changes, nil
+}
+
+func (ca *clusterAdmin) DescribeConsumerGroups(groups []string) (result []*GroupDescription, err error) {
+	groupsPerBroker := make(map[*Broker][]string)
+
+	for _, group := range groups {
+		controller, err := ca.client.Coordinator(group)
+		if err != nil {
+			return nil, err
+		}
+		groupsPerBroker[controller] = append(groupsPerBroker[controller], group)
+	}
+
+	for broker, brokerGroups := range groupsPerBroker {
+		response, err := broker.describeGroups(ca.context(), &DescribeGroupsRequest{
+			Groups: brokerGroups,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, response.Groups...)
+	}
+	return result, nil
+}
+
+func (ca *clusterAdmin) DescribeConsumerProtocolGroups(groups []string, includeAuthorizedOperations bool) ([]*ConsumerGroupDescribeGroup, error) {
+	if !ca.conf.Version.IsAtLeast(V3_7_0_0) {
+		return nil, ErrUnsupportedVersion
+	}
+
+	groupsPerBroker := make(map[*Broker][]string)
+	for _, group := range groups {
+		coordinator, err := ca.client.Coordinator(group)
+		if err != nil {
+			return nil, err
+		}
+		groupsPerBroker[coordinator] = append(groupsPerBroker[coordinator], group)
+	}
+
+	descriptions := make([]*ConsumerGroupDescribeGroup, 0, len(groups))
+	for broker, brokerGroups := range groupsPerBroker {
+		rsp, err := broker.consumerGroupDescribe(ca.context(), &ConsumerGroupDescribeRequest{
+			GroupIDs:                    brokerGroups,
+			IncludeAuthorizedOperations: includeAuthorizedOperations,
+		})
+		if err != nil {
+			return nil, err
+		}
+		descriptions = append(descriptions, rsp.Groups...)
+	}
+
+	return descriptions, nil
+}
+
+func (ca *clusterAdmin) ListConsumerGroups() (allGroups map[string]string, err error) {
+	allGroups = make(map[string]string)
+
+	listings, err := ca.listGroups(&ListGroupsRequest{})
+	for group, listing := range listings {
+		allGroups[group] = listing.ProtocolType
+	}
+	return allGroups, err
+}
+
+// ConsumerGroupListing is a group returned by ClusterAdmin.ListConsumerGroupListings
+type ConsumerGroupListing struct {
+	GroupID      string
+	ProtocolType string
+	// State is empty if the brokers do not support ListGroups v4
+	State string
+	// Type is empty if the brokers do not support ListGroups v5
+	Type string
+}
+
+func (ca *clusterAdmin) ListConsumerGroupListings(states []string, types []string) (map[string]*ConsumerGroupListing, error) {
+	request := &ListGroupsRequest{
+		StatesFilter: states,
+		TypesFilter:  types,
+	}
+	switch {
+	case ca.conf.Version.IsAtLeast(V3_8_0_0):
+		request.Version = 5
+	case ca.conf.Version.IsAtLeast(V2_6_0_0):
+		request.Version = 4
+	case ca.conf.Version.IsAtLeast(V2_4_0_0):
+		request.Version = 3
+	case ca.conf.Version.IsAtLeast(V2_0_0_0):
+		request.Version = 2
+	case ca.conf.Version.IsAtLeast(V0_11_0_0):
+		request.Version = 1
+	}
+
+	if (len(states) > 0 && request.Version < 4) || (len(types) > 0 && request.Version < 5) {
+		return nil, ErrUnsupportedVersion
+	}
+
+	return ca.listGroups(request)
+}
+
+// listGroups sends the given request to all brokers in parallel and merges
+// the listed groups, returning only the first error for simplicity
+func (ca *clusterAdmin) listGroups(request *ListGroupsRequest) (map[string]*ConsumerGroupListing, error) {
+	allGroups := make(map[string]*ConsumerGroupListing)
+
+	// Query brokers in parallel, since we have to query *all* brokers
+	brokers := ca.client.Brokers()
+	groupMaps := make(chan map[string]*ConsumerGroupListing, len(brokers))
+	errChan := make(chan error, len(brokers))
+	wg := sync.WaitGroup{}
+
+	for _, b := range brokers {
+		wg.Add(1)
+		go func(b *Broker, conf *Config) {
+			defer wg.Done()
+			_ = b.Open(conf) // Ensure that broker is opened
+
+			response, err := b.listGroups(ca.context(), request)
+			if err != nil {
+				errChan <- err
+				return
+			}
+
+			groups := make(map[string]*ConsumerGroupListing)
+			for group, typ := range response.Groups {
+				data := response.GroupsData[group]
+				groups[group] = &ConsumerGroupListing{
+					GroupID:      group,
+					ProtocolType: typ,
+					State:        data.GroupState,
+					Type:         data.GroupType,
+				}
+			}
+
+			groupMaps <- groups
+		}(b, ca.conf)
+	}
+
+	wg.Wait()
+	close(groupMaps)
+	close(errChan)
+
+	for groupMap := range groupMaps {
+		for group, listing := range groupMap {
+			allGroups[group] = listing
+		}
+	}
+
+	// Intentionally return only the first error for simplicity
+	return allGroups, <-errChan
+}
+
+func (ca *clusterAdmin) ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*OffsetFetchResponse, error) {
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &OffsetFetchRequest{
+		ConsumerGroup: group,
+		partitions:    topicPartitions,
+	}
+
+	if ca.conf.Version.IsAtLeast(V0_10_2_0) {
+		request.Version = 2
+	} else if ca.conf.Version.IsAtLeast(V0_8_2_2) {
+		request.Version = 1
+	}
+
+	return coordinator.fetchOffset(ca.context(), request)
+}
+
+func (ca *clusterAdmin) ListConsumerGroupOffsetsBatch(groups map[string]map[string][]int32) (map[string]*OffsetFetchResponseGroup, error) {
+	results := make(map[string]*OffsetFetchResponseGroup, len(groups))
+
+	if !ca.conf.Version.IsAtLeast(V3_0_0_0) {
+		for group, topicPartitions := range groups {
+			rsp, err := ca.ListConsumerGroupOffsets(group, topicPartitions)
+			if err != nil {
+				return results, err
+			}
+			results[group] = &OffsetFetchResponseGroup{Blocks: rsp.Blocks, Err: rsp.Err}
+		}
+		return results, nil
+	}
+
+	requests := make(map[*Broker]*OffsetFetchRequest)
+	for group, topicPartitions := range groups {
+		coordinator, err := ca.client.Coordinator(group)
+		if err != nil {
+			return results, err
+		}
+
+		request, ok := requests[coordinator]
+		if !ok {
+			request = &OffsetFetchRequest{Version: 8}
+			requests[coordinator] = request
+		}
+		request.AddGroup(group, topicPartitions)
+	}
+
+	for coordinator, request := range requests {
+		rsp, err := coordinator.fetchOffset(ca.context(), request)
+		if err != nil {
+			return results, err
+		}
+
+		for group, result := range rsp.Groups {
+			if errors.Is(result.Err, ErrNotCoordinatorForConsumer) {
+				_ = ca.client.RefreshCoordinator(group)
+			}
+			results[group] = result
+		}
+	}
+
+	return results, nil
+}
+
+func (ca *clusterAdmin) DeleteConsumerGroupOffset(group string, topic string, partition int32) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
+	}
+
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return err
+	}
+
+	request := &DeleteOffsetsRequest{
+		Group: group,
+		partitions: map[string][]int32{
+			topic: {partition},
+		},
+	}
+
+	resp, err := coordinator.deleteOffsets(ca.context(), request)
+	if err != nil {
+		return err
+	}
+
+	if !errors.Is(resp.ErrorCode, ErrNoError) {
+		return resp.ErrorCode
+	}
+
+	if !errors.Is(resp.Errors[topic][partition], ErrNoError) {
+		return resp.Errors[topic][partition]
+	}
+	return nil
+}
+
+func (ca *clusterAdmin) DeleteConsumerGroup(group string) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
+	}
+
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return err
+	}
+
+	request := &DeleteGroupsRequest{
+		Groups: []string{group},
+	}
+
+	resp, err := coordinator.deleteGroups(ca.context(), request)
+	if err != nil {
+		return err
+	}
+
+	groupErr, ok := resp.GroupErrorCodes[group]
+	if !ok {
+		return ErrIncompleteResponse
+	}
+
+	if !errors.Is(groupErr, ErrNoError) {
+		return groupErr
+	}
+
+	return nil
+}
+
+func (ca *clusterAdmin) RemoveMembersFromConsumerGroup(group string, groupInstanceIDs []string) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
+	}
+
+	if !ca.conf.Version.IsAtLeast(V2_4_0_0) {
+		return ErrUnsupportedVersion
+	}
+	if len(groupInstanceIDs) == 0 {
+		return ErrInvalidRequest
+	}
+
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return err
+	}
+
+	request := &LeaveGroupRequest{
+		Version: 4,
+		GroupId: group,
+	}
+	for _, id := range groupInstanceIDs {
+		groupInstanceID := id
+		request.Members = append(request.Members, MemberIdentity{GroupInstanceId: &groupInstanceID})
+	}
+
+	resp, err := coordinator.leaveGroup(ca.context(), request)
+	if err != nil {
+		return err
+	}
+
+	if !errors.Is(resp.Err, ErrNoError) {
+		if errors.Is(resp.Err, ErrNotCoordinatorForConsumer) {
+			_ = ca.client.RefreshCoordinator(group)
+		}
+		return resp.Err
+	}
+
+	errs := make([]error, 0)
+	for _, member := range resp.Members {
+		if !errors.Is(member.Err, ErrNoError) {
+			var groupInstanceID string
+			if member.GroupInstanceId != nil {
+				groupInstanceID = *member.GroupInstanceId
 			}
+			errs = append(errs, fmt.Errorf("%s: %w", groupInstanceID, member.Err))
 		}
 	}
 	if len(errs) > 0 {
-		return Wrap(ErrDeleteRecords, errs...)
+		return Wrap(ErrRemoveConsumerGroupMembers, errs...)
 	}
-	// todo since we are dealing with couple of partitions it would be good if we return slice of errors
-	// for each partition instead of one error
+
 	return nil
 }
 
-// Returns a bool indicating whether the resource request needs to go to a
-// specific broker
-func dependsOnSpecificNode(resource ConfigResource) bool {
-	return (resource.Type == BrokerResource && resource.Name != "") ||
-		resource.Type == BrokerLoggerResource
-}
+// OffsetResetStrategy selects how ClusterAdmin.ResetConsumerGroupOffsets
+// computes the new offsets
+type OffsetResetStrategy int
+
+const (
+	// ResetToEarliest resets to the oldest available offsets
+	ResetToEarliest OffsetResetStrategy = iota
+	// ResetToLatest resets to the offsets of the next messages to be produced
+	ResetToLatest
+	// ResetToTimestamp resets to the offsets of the first messages with a
+	// timestamp greater than or equal to OffsetResetSpec.Timestamp
+	ResetToTimestamp
+	// ResetShiftBy moves the committed offsets by OffsetResetSpec.Shift
+	ResetShiftBy
+	// ResetToOffset resets to OffsetResetSpec.Offset
+	ResetToOffset
+)
 
-func (ca *clusterAdmin) DescribeConfig(resource ConfigResource) ([]ConfigEntry, error) {
-	var entries []ConfigEntry
-	var resources []*ConfigResource
-	resources = append(resources, &resource)
+// OffsetResetSpec describes a reset of the committed offsets of a consumer group
+type OffsetResetSpec struct {
+	Strategy OffsetResetStrategy
+	// Timestamp in milliseconds, used by ResetToTimestamp
+	Timestamp int64
+	// Shift added to the committed offsets, may be negative, used by ResetShiftBy
+	Shift int64
+	// Offset used by ResetToOffset
+	Offset int64
+	// TopicPartitions to reset, or nil for all partitions the group has
+	// committed offsets for
+	TopicPartitions map[string][]int32
+	// DryRun computes the new offsets without committing them
+	DryRun bool
+}
 
-	request := &DescribeConfigsRequest{
-		Resources: resources,
+// checkGroupInactive returns ErrNonEmptyGroup if the group has active members
+func (ca *clusterAdmin) checkGroupInactive(group string) error {
+	descriptions, err := ca.DescribeConsumerGroups([]string{group})
+	if err != nil {
+		return err
 	}
-
-	if ca.conf.Version.IsAtLeast(V1_1_0_0) {
-		request.Version = 1
+	if len(descriptions) != 1 {
+		return ErrIncompleteResponse
+	}
+	if !errors.Is(descriptions[0].Err, ErrNoError) {
+		return descriptions[0].Err
 	}
+	if state := descriptions[0].State; state != "Empty" && state != "Dead" {
+		return ErrNonEmptyGroup
+	}
+	return nil
+}
 
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
-		request.Version = 2
+func (ca *clusterAdmin) ResetConsumerGroupOffsets(group string, spec OffsetResetSpec) (map[string]map[int32]int64, error) {
+	if err := ca.checkGroupInactive(group); err != nil {
+		return nil, err
 	}
 
-	var (
-		b   *Broker
-		err error
-	)
+	committed, err := ca.ListConsumerGroupOffsets(group, spec.TopicPartitions)
+	if err != nil {
+		return nil, err
+	}
+	if !errors.Is(committed.Err, ErrNoError) {
+		return nil, committed.Err
+	}
 
-	// DescribeConfig of broker/broker logger must be sent to the broker in question
-	if dependsOnSpecificNode(resource) {
-		var id int64
-		id, err = strconv.ParseInt(resource.Name, 10, 32)
-		if err != nil {
-			return nil, err
+	topicPartitions := spec.TopicPartitions
+	if topicPartitions == nil {
+		topicPartitions = make(map[string][]int32)
+		for topic, blocks := range committed.Blocks {
+			for partition := range blocks {
+				topicPartitions[topic] = append(topicPartitions[topic], partition)
+			}
 		}
-		b, err = ca.findBroker(int32(id))
-	} else {
-		b, err = ca.findAnyBroker()
 	}
+
+	earliest, err := ca.ListOffsets(topicPartitions, OffsetOldest)
 	if err != nil {
 		return nil, err
 	}
-
-	_ = b.Open(ca.client.Config())
-	rsp, err := b.DescribeConfigs(request)
+	latest, err := ca.ListOffsets(topicPartitions, OffsetNewest)
 	if err != nil {
 		return nil, err
 	}
+	var byTimestamp map[string]map[int32]*OffsetResponseBlock
+	if spec.Strategy == ResetToTimestamp {
+		if byTimestamp, err = ca.ListOffsets(topicPartitions, spec.Timestamp); err != nil {
+			return nil, err
+		}
+	}
 
-	for _, rspResource := range rsp.Resources {
-		if rspResource.Name == resource.Name {
-			if rspResource.ErrorMsg != "" {
-				return nil, errors.New(rspResource.ErrorMsg)
+	offsets := make(map[string]map[int32]int64)
+	errs := make([]error, 0)
+	for topic, partitions := range topicPartitions {
+		for _, partition := range partitions {
+			low, high := earliest[topic][partition], latest[topic][partition]
+			if low == nil || high == nil {
+				errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, ErrIncompleteResponse))
+				continue
 			}
-			if rspResource.ErrorCode != 0 {
-				return nil, KError(rspResource.ErrorCode)
+			if !errors.Is(low.Err, ErrNoError) {
+				errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, low.Err))
+				continue
 			}
-			for _, cfgEntry := range rspResource.Configs {
-				entries = append(entries, *cfgEntry)
+			if !errors.Is(high.Err, ErrNoError) {
+				errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, high.Err))
+				continue
+			}
+
+			var offset int64
+			switch spec.Strategy {
+			case ResetToEarliest:
+				offset = offsetOf(low)
+			case ResetToLatest:
+				offset = offsetOf(high)
+			case ResetToTimestamp:
+				block := byTimestamp[topic][partition]
+				if block == nil {
+					errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, ErrIncompleteResponse))
+					continue
+				}
+				if !errors.Is(block.Err, ErrNoError) {
+					errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, block.Err))
+					continue
+				}
+				// no message at or after the timestamp, reset to the end
+				offset = offsetOf(high)
+				if offsetOf(block) >= 0 {
+					offset = offsetOf(block)
+				}
+			case ResetShiftBy:
+				current := committed.GetBlock(topic, partition)
+				if current == nil || current.Offset < 0 {
+					errs = append(errs, fmt.Errorf("%s/%d: no committed offset to shift", topic, partition))
+					continue
+				}
+				offset = current.Offset + spec.Shift
+			case ResetToOffset:
+				offset = spec.Offset
+			default:
+				return nil, ConfigurationError("invalid OffsetResetStrategy")
+			}
+
+			if offset < offsetOf(low) {
+				offset = offsetOf(low)
+			} else if offset > offsetOf(high) {
+				offset = offsetOf(high)
 			}
+
+			if offsets[topic] == nil {
+				offsets[topic] = make(map[int32]int64)
+			}
+			offsets[topic][partition] = offset
 		}
 	}
-	return entries, nil
-}
+	if len(errs) > 0 {
+		return nil, Wrap(ErrResetConsumerGroupOffsets, errs...)
+	}
 
-func (ca *clusterAdmin) AlterConfig(resourceType ConfigResourceType, name string, entries map[string]*string, validateOnly bool) error {
-	var resources []*AlterConfigsResource
-	resources = append(resources, &AlterConfigsResource{
-		Type:          resourceType,
-		Name:          name,
-		ConfigEntries: entries,
-	})
+	if spec.DryRun || ca.validateOnly || len(offsets) == 0 {
+		return offsets, nil
+	}
 
-	request := &AlterConfigsRequest{
-		Resources:    resources,
-		ValidateOnly: validateOnly,
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return nil, err
 	}
 
-	var (
-		b   *Broker
-		err error
-	)
+	request := &OffsetCommitRequest{
+		Version:                 2,
+		ConsumerGroup:           group,
+		ConsumerGroupGeneration: GroupGenerationUndefined,
+		RetentionTime:           -1,
+	}
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			request.AddBlock(topic, partition, offset, 0, "")
+		}
+	}
 
-	// AlterConfig of broker/broker logger must be sent to the broker in question
-	if dependsOnSpecificNode(ConfigResource{Name: name, Type: resourceType}) {
-		var id int64
-		id, err = strconv.ParseInt(name, 10, 32)
-		if err != nil {
-			return err
+	resp, err := coordinator.commitOffset(ca.context(), request)
+	if err != nil {
+		return nil, err
+	}
+	for topic, partitions := range resp.Errors {
+		for partition, kerr := range partitions {
+			if !errors.Is(kerr, ErrNoError) {
+				errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, kerr))
+			}
 		}
-		b, err = ca.findBroker(int32(id))
-	} else {
-		b, err = ca.findAnyBroker()
 	}
+	if len(errs) > 0 {
+		return offsets, Wrap(ErrResetConsumerGroupOffsets, errs...)
+	}
+
+	return offsets, nil
+}
+
+// offsetOf returns the single offset of a ListOffsets block, or -1 if there is
+// none which can happen for v0 responses
+func offsetOf(block *OffsetResponseBlock) int64 {
+	if len(block.Offsets) == 0 {
+		return -1
+	}
+	return block.Offsets[0]
+}
+
+// ConsumerGroupOffsets is a portable snapshot of the committed offsets of a
+// consumer group, as returned by ClusterAdmin.ExportConsumerGroupOffsets
+type ConsumerGroupOffsets struct {
+	Group   string                                    `json:"group"`
+	Offsets map[string]map[int32]*ConsumerGroupOffset `json:"offsets"`
+}
+
+// ConsumerGroupOffset is the committed offset of a single partition
+type ConsumerGroupOffset struct {
+	Offset int64 `json:"offset"`
+	// LeaderEpoch is -1 if unknown
+	LeaderEpoch int32  `json:"leaderEpoch"`
+	Metadata    string `json:"metadata"`
+}
+
+func (ca *clusterAdmin) ExportConsumerGroupOffsets(group string) (*ConsumerGroupOffsets, error) {
+	coordinator, err := ca.client.Coordinator(group)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_ = b.Open(ca.client.Config())
-	rsp, err := b.AlterConfigs(request)
+	request := &OffsetFetchRequest{ConsumerGroup: group}
+	if ca.conf.Version.IsAtLeast(V2_1_0_0) {
+		// v5 returns the leader epochs of the committed offsets
+		request.Version = 5
+	} else if ca.conf.Version.IsAtLeast(V0_10_2_0) {
+		request.Version = 2
+	} else {
+		// fetching all partitions requires v2
+		return nil, ErrUnsupportedVersion
+	}
+
+	resp, err := coordinator.fetchOffset(ca.context(), request)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !errors.Is(resp.Err, ErrNoError) {
+		return nil, resp.Err
 	}
 
-	for _, rspResource := range rsp.Resources {
-		if rspResource.Name == name {
-			if rspResource.ErrorMsg != "" {
-				return errors.New(rspResource.ErrorMsg)
+	offsets := &ConsumerGroupOffsets{
+		Group:   group,
+		Offsets: make(map[string]map[int32]*ConsumerGroupOffset),
+	}
+	errs := make([]error, 0)
+	for topic, blocks := range resp.Blocks {
+		for partition, block := range blocks {
+			if !errors.Is(block.Err, ErrNoError) {
+				errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, block.Err))
+				continue
 			}
-			if rspResource.ErrorCode != 0 {
-				return KError(rspResource.ErrorCode)
+			if block.Offset < 0 {
+				// nothing committed
+				continue
+			}
+			leaderEpoch := int32(-1)
+			if request.Version >= 5 {
+				leaderEpoch = block.LeaderEpoch
+			}
+			if offsets.Offsets[topic] == nil {
+				offsets.Offsets[topic] = make(map[int32]*ConsumerGroupOffset)
+			}
+			offsets.Offsets[topic][partition] = &ConsumerGroupOffset{
+				Offset:      block.Offset,
+				LeaderEpoch: leaderEpoch,
+				Metadata:    block.Metadata,
 			}
 		}
 	}
-	return nil
-}
+	if len(errs) > 0 {
+		return nil, Wrap(ErrExportConsumerGroupOffsets, errs...)
+	}
 
-func (ca *clusterAdmin) IncrementalAlterConfig(resourceType ConfigResourceType, name string, entries map[string]IncrementalAlterConfigsEntry, validateOnly bool) error {
-	var resources []*IncrementalAlterConfigsResource
-	resources = append(resources, &IncrementalAlterConfigsResource{
-		Type:          resourceType,
-		Name:          name,
-		ConfigEntries: entries,
-	})
+	return offsets, nil
+}
 
-	request := &IncrementalAlterConfigsRequest{
-		Resources:    resources,
-		ValidateOnly: validateOnly,
+func (ca *clusterAdmin) ImportConsumerGroupOffsets(group string, offsets *ConsumerGroupOffsets) error {
+	if err := ca.checkMutable(); err != nil {
+		return err
 	}
 
-	var (
-		b   *Broker
-		err error
-	)
-
-	// AlterConfig of broker/broker logger must be sent to the broker in question
-	if dependsOnSpecificNode(ConfigResource{Name: name, Type: resourceType}) {
-		var id int64
-		id, err = strconv.ParseInt(name, 10, 32)
-		if err != nil {
-			return err
-		}
-		b, err = ca.findBroker(int32(id))
-	} else {
-		b, err = ca.findAnyBroker()
+	if offsets == nil {
+		return ErrInvalidRequest
 	}
-	if err != nil {
+	if group == "" {
+		group = offsets.Group
+	}
+	if group == "" {
+		return ErrInvalidRequest
+	}
+
+	if err := ca.checkGroupInactive(group); err != nil {
 		return err
 	}
+	if len(offsets.Offsets) == 0 {
+		return nil
+	}
 
-	_ = b.Open(ca.client.Config())
-	rsp, err := b.IncrementalAlterConfigs(request)
+	coordinator, err := ca.client.Coordinator(group)
 	if err != nil {
 		return err
 	}
 
-	for _, rspResource := range rsp.Resources {
-		if rspResource.Name == name {
-			if rspResource.ErrorMsg != "" {
-				return errors.New(rspResource.ErrorMsg)
-			}
-			if rspResource.ErrorCode != 0 {
-				return KError(rspResource.ErrorCode)
+	request := &OffsetCommitRequest{
+		ConsumerGroup:           group,
+		ConsumerGroupGeneration: GroupGenerationUndefined,
+	}
+	if ca.conf.Version.IsAtLeast(V2_1_0_0) {
+		// v6 commits the leader epochs along with the offsets
+		request.Version = 6
+	} else {
+		request.Version = 2
+		request.RetentionTime = -1
+	}
+	for topic, partitions := range offsets.Offsets {
+		for partition, offset := range partitions {
+			if offset == nil {
+				continue
 			}
+			request.AddBlockWithLeaderEpoch(topic, partition, offset.Offset, offset.LeaderEpoch, 0, offset.Metadata)
 		}
 	}
-	return nil
-}
-
-func (ca *clusterAdmin) CreateACL(resource Resource, acl Acl) error {
-	var acls []*AclCreation
-	acls = append(acls, &AclCreation{resource, acl})
-	request := &CreateAclsRequest{AclCreations: acls}
-
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
-		request.Version = 1
-	}
 
-	b, err := ca.Controller()
+	resp, err := coordinator.commitOffset(ca.context(), request)
 	if err != nil {
 		return err
 	}
 
-	_, err = b.CreateAcls(request)
-	return err
+	errs := make([]error, 0)
+	for topic, partitions := range resp.Errors {
+		for partition, kerr := range partitions {
+			if !errors.Is(kerr, ErrNoError) {
+				errs = append(errs, fmt.Errorf("%s/%d: %w", topic, partition, kerr))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return Wrap(ErrImportConsumerGroupOffsets, errs...)
+	}
+
+	return nil
 }
 
-func (ca *clusterAdmin) ListAcls(filter AclFilter) ([]ResourceAcls, error) {
-	request := &DescribeAclsRequest{AclFilter: filter}
+func (ca *clusterAdmin) DescribeLogDirs(brokerIds []int32) (allLogDirs map[int32][]DescribeLogDirsResponseDirMetadata, err error) {
+	allLogDirs = make(map[int32][]DescribeLogDirsResponseDirMetadata)
 
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
-		request.Version = 1
-	}
+	// Query brokers in parallel, since we may have to query multiple brokers
+	logDirsMaps := make(chan map[int32][]DescribeLogDirsResponseDirMetadata, len(brokerIds))
+	errChan := make(chan error, len(brokerIds))
+	wg := sync.WaitGroup{}
 
-	b, err := ca.Controller()
-	if err != nil {
-		return nil, err
+	for _, b := range brokerIds {
+		broker, err := ca.findBroker(b)
+		if err != nil {
+			Logger.Printf("Unable to find broker with ID = %v\n", b)
+			continue
+		}
+		wg.Add(1)
+		go func(b *Broker, conf *Config) {
+			defer wg.Done()
+			_ = b.Open(conf) // Ensure that broker is opened
+
+			response, err := b.describeLogDirs(ca.context(), &DescribeLogDirsRequest{})
+			if err != nil {
+				errChan <- err
+				return
+			}
+			logDirs := make(map[int32][]DescribeLogDirsResponseDirMetadata)
+			logDirs[b.ID()] = response.LogDirs
+			logDirsMaps <- logDirs
+		}(broker, ca.conf)
 	}
 
-	rsp, err := b.DescribeAcls(request)
-	if err != nil {
-		return nil, err
-	}
+	wg.Wait()
+	close(logDirsMaps)
+	close(errChan)
 
-	var lAcls []ResourceAcls
-	for _, rAcl := range rsp.ResourceAcls {
-		lAcls = append(lAcls, *rAcl)
+	for logDirsMap := range logDirsMaps {
+		for id, logDirs := range logDirsMap {
+			allLogDirs[id] = logDirs
+		}
 	}
-	return lAcls, nil
-}
 
-func (ca *clusterAdmin) DeleteACL(filter AclFilter, validateOnly bool) ([]MatchingAcl, error) {
-	var filters []*AclFilter
-	filters = append(filters, &filter)
-	request := &DeleteAclsRequest{Filters: filters}
+	// Intentionally return only the first error for simplicity
+	err = <-errChan
+	return
+}
 
-	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
-		request.Version = 1
+func (ca *clusterAdmin) ListOffsets(topicPartitions map[string][]int32, time int64) (map[string]map[int32]*OffsetResponseBlock, error) {
+	var version int16
+	switch {
+	case ca.conf.Version.IsAtLeast(V3_0_0_0):
+		version = 7
+	case ca.conf.Version.IsAtLeast(V2_5_0_0):
+		version = 6
+	case ca.conf.Version.IsAtLeast(V2_2_0_0):
+		version = 5
+	case ca.conf.Version.IsAtLeast(V2_1_0_0):
+		version = 4
+	case ca.conf.Version.IsAtLeast(V2_0_0_0):
+		version = 3
+	case ca.conf.Version.IsAtLeast(V0_11_0_0):
+		version = 2
+	case ca.conf.Version.IsAtLeast(V0_10_1_0):
+		version = 1
 	}
-
-	b, err := ca.Controller()
-	if err != nil {
-		return nil, err
+	if time == OffsetMaxTimestamp && version < 7 {
+		return nil, ErrUnsupportedVersion
 	}
 
-	rsp, err := b.DeleteAcls(request)
-	if err != nil {
-		return nil, err
+	requests := make(map[*Broker]*OffsetRequest)
+	for topic, partitions := range topicPartitions {
+		if topic == "" {
+			return nil, ErrInvalidTopic
+		}
+		for _, partition := range partitions {
+			broker, err := ca.client.Leader(topic, partition)
+			if err != nil {
+				return nil, err
+			}
+			request, ok := requests[broker]
+			if !ok {
+				request = &OffsetRequest{Version: version}
+				requests[broker] = request
+			}
+			request.AddBlock(topic, partition, time, 1)
+		}
 	}
 
-	var mAcls []MatchingAcl
-	for _, fr := range rsp.FilterResponses {
-		for _, mACL := range fr.MatchingAcls {
-			mAcls = append(mAcls, *mACL)
+	results := make(map[string]map[int32]*OffsetResponseBlock)
+	for broker, request := range requests {
+		rsp, err := broker.getAvailableOffsets(ca.context(), request)
+		if err != nil {
+			return nil, err
+		}
+
+		for topic, blocks := range rsp.Blocks {
+			if results[topic] == nil {
+				results[topic] = make(map[int32]*OffsetResponseBlock)
+			}
+			for partition, block := range blocks {
+				results[topic][partition] = block
+			}
 		}
 	}
-	return mAcls, nil
-}
 
-func (ca *clusterAdmin) DescribeConsumerGroups(groups []string) (result []*GroupDescription, err error) {
-	groupsPerBroker := make(map[*Broker][]string)
+	return results, nil
+}
 
-	for _, group := range groups {
-		controller, err := ca.client.Coordinator(group)
-		if err != nil {
-			return nil, err
+func (ca *clusterAdmin) DescribeProducers(topicPartitions map[string][]int32) (map[string]map[int32]*DescribeProducersPartition, error) {
+	partitionsPerBroker := make(map[*Broker]map[string][]int32)
+	for topic, partitions := range topicPartitions {
+		if topic == "" {
+			return nil, ErrInvalidTopic
+		}
+		for _, partition := range partitions {
+			broker, err := ca.client.Leader(topic, partition)
+			if err != nil {
+				return nil, err
+			}
+			if partitionsPerBroker[broker] == nil {
+				partitionsPerBroker[broker] = make(map[string][]int32)
+			}
+			partitionsPerBroker[broker][topic] = append(partitionsPerBroker[broker][topic], partition)
 		}
-		groupsPerBroker[controller] = append(groupsPerBroker[controller], group)
 	}
 
-	for broker, brokerGroups := range groupsPerBroker {
-		response, err := broker.DescribeGroups(&DescribeGroupsRequest{
-			Groups: brokerGroups,
-		})
+	results := make(map[string]map[int32]*DescribeProducersPartition)
+	for broker, topics := range partitionsPerBroker {
+		request := &DescribeProducersRequest{}
+		for topic, partitions := range topics {
+			request.Topics = append(request.Topics, DescribeProducersRequestTopic{
+				Name:             topic,
+				PartitionIndexes: partitions,
+			})
+		}
+
+		rsp, err := broker.describeProducers(ca.context(), request)
 		if err != nil {
 			return nil, err
 		}
 
-		result = append(result, response.Groups...)
+		for _, topic := range rsp.Topics {
+			if results[topic.Name] == nil {
+				results[topic.Name] = make(map[int32]*DescribeProducersPartition)
+			}
+			for _, partition := range topic.Partitions {
+				results[topic.Name][partition.PartitionIndex] = partition
+			}
+		}
 	}
-	return result, nil
-}
 
-func (ca *clusterAdmin) ListConsumerGroups() (allGroups map[string]string, err error) {
-	allGroups = make(map[string]string)
+	return results, nil
+}
 
+func (ca *clusterAdmin) ListTransactions(stateFilters []string, producerIDFilters []int64) (allTransactions []*TransactionListing, err error) {
 	// Query brokers in parallel, since we have to query *all* brokers
 	brokers := ca.client.Brokers()
-	groupMaps := make(chan map[string]string, len(brokers))
+	transactions := make(chan []*TransactionListing, len(brokers))
 	errChan := make(chan error, len(brokers))
 	wg := sync.WaitGroup{}
 
@@ -890,29 +3735,29 @@ func (ca *clusterAdmin) ListConsumerGroups() (allGroups map[string]string, err e
 			defer wg.Done()
 			_ = b.Open(conf) // Ensure that broker is opened
 
-			response, err := b.ListGroups(&ListGroupsRequest{})
+			response, err := b.listTransactions(ca.context(), &ListTransactionsRequest{
+				StateFilters:      stateFilters,
+				ProducerIDFilters: producerIDFilters,
+			})
 			if err != nil {
 				errChan <- err
 				return
 			}
-
-			groups := make(map[string]string)
-			for group, typ := range response.Groups {
-				groups[group] = typ
+			if !errors.Is(response.ErrorCode, ErrNoError) {
+				errChan <- response.ErrorCode
+				return
 			}
 
-			groupMaps <- groups
+			transactions <- response.TransactionStates
 		}(b, ca.conf)
 	}
 
 	wg.Wait()
-	close(groupMaps)
+	close(transactions)
 	close(errChan)
 
-	for groupMap := range groupMaps {
-		for group, protocolType := range groupMap {
-			allGroups[group] = protocolType
-		}
+	for t := range transactions {
+		allTransactions = append(allTransactions, t...)
 	}
 
 	// Intentionally return only the first error for simplicity
@@ -920,124 +3765,250 @@ func (ca *clusterAdmin) ListConsumerGroups() (allGroups map[string]string, err e
 	return
 }
 
-func (ca *clusterAdmin) ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*OffsetFetchResponse, error) {
-	coordinator, err := ca.client.Coordinator(group)
-	if err != nil {
-		return nil, err
+func (ca *clusterAdmin) DescribeTransactions(transactionalIDs []string) ([]*TransactionDescription, error) {
+	idsPerBroker := make(map[*Broker][]string)
+	for _, id := range transactionalIDs {
+		coordinator, err := ca.client.TransactionCoordinator(id)
+		if err != nil {
+			return nil, err
+		}
+		idsPerBroker[coordinator] = append(idsPerBroker[coordinator], id)
 	}
 
-	request := &OffsetFetchRequest{
-		ConsumerGroup: group,
-		partitions:    topicPartitions,
+	descriptions := make([]*TransactionDescription, 0, len(transactionalIDs))
+	for broker, ids := range idsPerBroker {
+		rsp, err := broker.describeTransactions(ca.context(), &DescribeTransactionsRequest{
+			TransactionalIDs: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		descriptions = append(descriptions, rsp.TransactionStates...)
 	}
 
-	if ca.conf.Version.IsAtLeast(V0_10_2_0) {
-		request.Version = 2
-	} else if ca.conf.Version.IsAtLeast(V0_8_2_2) {
-		request.Version = 1
+	return descriptions, nil
+}
+
+// LogDirsSummary is the aggregated log directory usage of a cluster
+type LogDirsSummary struct {
+	TotalBytes int64
+	Brokers    map[int32]*BrokerLogDirsSummary
+	Topics     map[string]*TopicLogDirsSummary
+}
+
+// BrokerLogDirsSummary is the aggregated log directory usage of a broker
+type BrokerLogDirsSummary struct {
+	TotalBytes int64
+	// LogDirs maps the absolute log directory paths to their usage in bytes
+	LogDirs map[string]int64
+	// Topics maps the topics to the usage in bytes of their replicas on the broker
+	Topics map[string]int64
+}
+
+// TopicLogDirsSummary is the aggregated log directory usage of a topic
+type TopicLogDirsSummary struct {
+	TotalBytes int64
+	// Partitions maps the partitions to the sum of the sizes of their replicas
+	Partitions map[int32]int64
+}
+
+func (ca *clusterAdmin) DescribeLogDirsSummary() (*LogDirsSummary, error) {
+	brokers := ca.client.Brokers()
+	brokerIDs := make([]int32, 0, len(brokers))
+	for _, b := range brokers {
+		brokerIDs = append(brokerIDs, b.ID())
+	}
+
+	allLogDirs, err := ca.DescribeLogDirs(brokerIDs)
+
+	summary := &LogDirsSummary{
+		Brokers: make(map[int32]*BrokerLogDirsSummary, len(allLogDirs)),
+		Topics:  make(map[string]*TopicLogDirsSummary),
+	}
+	for brokerID, logDirs := range allLogDirs {
+		brokerSummary := &BrokerLogDirsSummary{
+			LogDirs: make(map[string]int64, len(logDirs)),
+			Topics:  make(map[string]int64),
+		}
+		for _, dir := range logDirs {
+			if !errors.Is(dir.ErrorCode, ErrNoError) {
+				Logger.Printf("admin/logdirs log dir %s of broker %d is unavailable: %v\n", dir.Path, brokerID, dir.ErrorCode)
+				continue
+			}
+			var dirBytes int64
+			for _, topic := range dir.Topics {
+				topicSummary := summary.Topics[topic.Topic]
+				if topicSummary == nil {
+					topicSummary = &TopicLogDirsSummary{Partitions: make(map[int32]int64)}
+					summary.Topics[topic.Topic] = topicSummary
+				}
+				for _, partition := range topic.Partitions {
+					dirBytes += partition.Size
+					brokerSummary.Topics[topic.Topic] += partition.Size
+					topicSummary.Partitions[partition.PartitionID] += partition.Size
+					topicSummary.TotalBytes += partition.Size
+				}
+			}
+			brokerSummary.LogDirs[dir.Path] += dirBytes
+			brokerSummary.TotalBytes += dirBytes
+		}
+		summary.Brokers[brokerID] = brokerSummary
+		summary.TotalBytes += brokerSummary.TotalBytes
 	}
 
-	return coordinator.FetchOffset(request)
+	return summary, err
 }
 
-func (ca *clusterAdmin) DeleteConsumerGroupOffset(group string, topic string, partition int32) error {
-	coordinator, err := ca.client.Coordinator(group)
-	if err != nil {
+func (ca *clusterAdmin) AlterReplicaLogDirs(brokerID int32, assignments map[string]map[string][]int32) error {
+	if err := ca.checkMutable(); err != nil {
 		return err
 	}
 
-	request := &DeleteOffsetsRequest{
-		Group: group,
-		partitions: map[string][]int32{
-			topic: {partition},
-		},
+	request := &AlterReplicaLogDirsRequest{}
+	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		request.Version = 1
 	}
 
-	resp, err := coordinator.DeleteOffsets(request)
+	for path, topics := range assignments {
+		dir := AlterReplicaLogDirsRequestDir{Path: path}
+		for topic, partitions := range topics {
+			dir.Topics = append(dir.Topics, AlterReplicaLogDirsRequestTopic{
+				Topic:        topic,
+				PartitionIDs: partitions,
+			})
+		}
+		request.Dirs = append(request.Dirs, dir)
+	}
+
+	b, err := ca.findBroker(brokerID)
 	if err != nil {
 		return err
 	}
+	_ = b.Open(ca.client.Config())
 
-	if !errors.Is(resp.ErrorCode, ErrNoError) {
-		return resp.ErrorCode
+	rsp, err := b.alterReplicaLogDirs(ca.context(), request)
+	if err != nil {
+		return err
 	}
 
-	if !errors.Is(resp.Errors[topic][partition], ErrNoError) {
-		return resp.Errors[topic][partition]
+	errs := make([]error, 0)
+	for _, t := range rsp.Results {
+		for _, p := range t.Partitions {
+			if !errors.Is(p.ErrorCode, ErrNoError) {
+				errs = append(errs, fmt.Errorf("%s/%d: %w", t.Topic, p.PartitionID, p.ErrorCode))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return Wrap(ErrAlterReplicaLogDirs, errs...)
 	}
 	return nil
 }
 
-func (ca *clusterAdmin) DeleteConsumerGroup(group string) error {
-	coordinator, err := ca.client.Coordinator(group)
-	if err != nil {
-		return err
+func (ca *clusterAdmin) delegationTokenVersion() int16 {
+	if ca.conf.Version.IsAtLeast(V2_0_0_0) {
+		return 1
 	}
+	return 0
+}
 
-	request := &DeleteGroupsRequest{
-		Groups: []string{group},
+func (ca *clusterAdmin) CreateDelegationToken(renewers []DelegationTokenPrincipal, maxLifetime time.Duration) (*DelegationToken, error) {
+	if err := ca.checkMutable(); err != nil {
+		return nil, err
 	}
 
-	resp, err := coordinator.DeleteGroups(request)
+	b, err := ca.findAnyBroker()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	_ = b.Open(ca.client.Config())
 
-	groupErr, ok := resp.GroupErrorCodes[group]
-	if !ok {
-		return ErrIncompleteResponse
+	rsp, err := b.createDelegationToken(ca.context(), &CreateDelegationTokenRequest{
+		Version:     ca.delegationTokenVersion(),
+		Renewers:    renewers,
+		MaxLifetime: maxLifetime,
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	if !errors.Is(groupErr, ErrNoError) {
-		return groupErr
+	if !errors.Is(rsp.ErrorCode, ErrNoError) {
+		return nil, rsp.ErrorCode
 	}
 
-	return nil
+	return &rsp.Token, nil
 }
 
-func (ca *clusterAdmin) DescribeLogDirs(brokerIds []int32) (allLogDirs map[int32][]DescribeLogDirsResponseDirMetadata, err error) {
-	allLogDirs = make(map[int32][]DescribeLogDirsResponseDirMetadata)
+func (ca *clusterAdmin) RenewDelegationToken(hmac []byte, renewPeriod time.Duration) (time.Time, error) {
+	if err := ca.checkMutable(); err != nil {
+		return time.Time{}, err
+	}
 
-	// Query brokers in parallel, since we may have to query multiple brokers
-	logDirsMaps := make(chan map[int32][]DescribeLogDirsResponseDirMetadata, len(brokerIds))
-	errChan := make(chan error, len(brokerIds))
-	wg := sync.WaitGroup{}
+	b, err := ca.findAnyBroker()
+	if err != nil {
+		return time.Time{}, err
+	}
+	_ = b.Open(ca.client.Config())
 
-	for _, b := range brokerIds {
-		wg.Add(1)
-		broker, err := ca.findBroker(b)
-		if err != nil {
-			Logger.Printf("Unable to find broker with ID = %v\n", b)
-			continue
-		}
-		go func(b *Broker, conf *Config) {
-			defer wg.Done()
-			_ = b.Open(conf) // Ensure that broker is opened
+	rsp, err := b.renewDelegationToken(ca.context(), &RenewDelegationTokenRequest{
+		Version:     ca.delegationTokenVersion(),
+		HMAC:        hmac,
+		RenewPeriod: renewPeriod,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !errors.Is(rsp.ErrorCode, ErrNoError) {
+		return time.Time{}, rsp.ErrorCode
+	}
 
-			response, err := b.DescribeLogDirs(&DescribeLogDirsRequest{})
-			if err != nil {
-				errChan <- err
-				return
-			}
-			logDirs := make(map[int32][]DescribeLogDirsResponseDirMetadata)
-			logDirs[b.ID()] = response.LogDirs
-			logDirsMaps <- logDirs
-		}(broker, ca.conf)
+	return rsp.ExpiryTime, nil
+}
+
+func (ca *clusterAdmin) ExpireDelegationToken(hmac []byte, expiryTimePeriod time.Duration) (time.Time, error) {
+	if err := ca.checkMutable(); err != nil {
+		return time.Time{}, err
 	}
 
-	wg.Wait()
-	close(logDirsMaps)
-	close(errChan)
+	b, err := ca.findAnyBroker()
+	if err != nil {
+		return time.Time{}, err
+	}
+	_ = b.Open(ca.client.Config())
 
-	for logDirsMap := range logDirsMaps {
-		for id, logDirs := range logDirsMap {
-			allLogDirs[id] = logDirs
-		}
+	rsp, err := b.expireDelegationToken(ca.context(), &ExpireDelegationTokenRequest{
+		Version:          ca.delegationTokenVersion(),
+		HMAC:             hmac,
+		ExpiryTimePeriod: expiryTimePeriod,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !errors.Is(rsp.ErrorCode, ErrNoError) {
+		return time.Time{}, rsp.ErrorCode
 	}
 
-	// Intentionally return only the first error for simplicity
-	err = <-errChan
-	return
+	return rsp.ExpiryTime, nil
+}
+
+func (ca *clusterAdmin) DescribeDelegationToken(owners []DelegationTokenPrincipal) ([]*DelegationToken, error) {
+	b, err := ca.findAnyBroker()
+	if err != nil {
+		return nil, err
+	}
+	_ = b.Open(ca.client.Config())
+
+	rsp, err := b.describeDelegationToken(ca.context(), &DescribeDelegationTokenRequest{
+		Version: ca.delegationTokenVersion(),
+		Owners:  owners,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !errors.Is(rsp.ErrorCode, ErrNoError) {
+		return nil, rsp.ErrorCode
+	}
+
+	return rsp.Tokens, nil
 }
 
 func (ca *clusterAdmin) DescribeUserScramCredentials(users []string) ([]*DescribeUserScramCredentialsResult, error) {
@@ -1053,7 +4024,7 @@ func (ca *clusterAdmin) DescribeUserScramCredentials(users []string) ([]*Describ
 		return nil, err
 	}
 
-	rsp, err := b.DescribeUserScramCredentials(req)
+	rsp, err := b.describeUserScramCredentials(ca.context(), req)
 	if err != nil {
 		return nil, err
 	}
@@ -1080,6 +4051,10 @@ func (ca *clusterAdmin) DeleteUserScramCredentials(delete []AlterUserScramCreden
 }
 
 func (ca *clusterAdmin) AlterUserScramCredentials(u []AlterUserScramCredentialsUpsert, d []AlterUserScramCredentialsDelete) ([]*AlterUserScramCredentialsResult, error) {
+	if err := ca.checkMutable(); err != nil {
+		return nil, err
+	}
+
 	req := &AlterUserScramCredentialsRequest{
 		Deletions:  d,
 		Upsertions: u,
@@ -1090,7 +4065,7 @@ func (ca *clusterAdmin) AlterUserScramCredentials(u []AlterUserScramCredentialsU
 		return nil, err
 	}
 
-	rsp, err := b.AlterUserScramCredentials(req)
+	rsp, err := b.alterUserScramCredentials(ca.context(), req)
 	if err != nil {
 		return nil, err
 	}
@@ -1112,7 +4087,7 @@ func (ca *clusterAdmin) DescribeClientQuotas(components []QuotaFilterComponent,
 		return nil, err
 	}
 
-	rsp, err := b.DescribeClientQuotas(request)
+	rsp, err := b.describeClientQuotas(ca.context(), request)
 	if err != nil {
 		return nil, err
 	}
@@ -1128,14 +4103,26 @@ func (ca *clusterAdmin) DescribeClientQuotas(components []QuotaFilterComponent,
 }
 
 func (ca *clusterAdmin) AlterClientQuotas(entity []QuotaEntityComponent, op ClientQuotasOp, validateOnly bool) error {
+	return ca.alterClientQuotas(entity, []ClientQuotasOp{op}, validateOnly)
+}
+
+func (ca *clusterAdmin) SetClientQuotas(entity []QuotaEntityComponent, quotas ClientQuotaValues, validateOnly bool) error {
+	ops := quotas.ops()
+	if len(ops) == 0 {
+		return nil
+	}
+	return ca.alterClientQuotas(entity, ops, validateOnly)
+}
+
+func (ca *clusterAdmin) alterClientQuotas(entity []QuotaEntityComponent, ops []ClientQuotasOp, validateOnly bool) error {
 	entry := AlterClientQuotasEntry{
 		Entity: entity,
-		Ops:    []ClientQuotasOp{op},
+		Ops:    ops,
 	}
 
 	request := &AlterClientQuotasRequest{
 		Entries:      []AlterClientQuotasEntry{entry},
-		ValidateOnly: validateOnly,
+		ValidateOnly: validateOnly || ca.validateOnly,
 	}
 
 	b, err := ca.Controller()
@@ -1143,7 +4130,7 @@ func (ca *clusterAdmin) AlterClientQuotas(entity []QuotaEntityComponent, op Clie
 		return err
 	}
 
-	rsp, err := b.AlterClientQuotas(request)
+	rsp, err := b.alterClientQuotas(ca.context(), request)
 	if err != nil {
 		return err
 	}
@@ -1159,3 +4146,11 @@ func (ca *clusterAdmin) AlterClientQuotas(entity []QuotaEntityComponent, op Clie
 
 	return nil
 }
+
+func (ca *clusterAdmin) DescribeEffectiveClientQuotas(user, clientID string) (map[string]EffectiveClientQuota, error) {
+	entries, err := ca.DescribeClientQuotas(nil, false)
+	if err != nil {
+		return nil, err
+	}
+	return resolveClientQuotas(entries, user, clientID), nil
+}
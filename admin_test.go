@@ -2,8 +2,11 @@ package sarama
 
 import (
 	"errors"
+	"math"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestClusterAdmin(t *testing.T) {
@@ -216,6 +219,121 @@ func TestClusterAdminDeleteTopic(t *testing.T) {
 	}
 }
 
+func TestClusterAdminDeleteTopicByID(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	topicID := Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DeleteTopicsRequest": NewMockDeleteTopicsResponse(t).
+			SetTopicID("my_topic", topicID),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_8_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.DeleteTopicByID(topicID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.DeleteTopicByID(Uuid{16})
+	if !errors.Is(err, ErrUnknownTopicID) {
+		t.Fatalf("expected ErrUnknownTopicID, got %v", err)
+	}
+
+	err = admin.DeleteTopicByID(NullUuid)
+	if !errors.Is(err, ErrInvalidTopic) {
+		t.Fatalf("expected ErrInvalidTopic, got %v", err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDescribeTopicsByID(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	topicID := Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()).
+			SetTopicID("my_topic", topicID),
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_1_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	topics, err := admin.DescribeTopicsByID([]Uuid{topicID, {16}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 2 {
+		t.Fatalf("expected 2 topics, got %d", len(topics))
+	}
+	if topics[0].Name != "my_topic" || topics[0].Uuid != topicID || len(topics[0].Partitions) != 1 {
+		t.Errorf("unexpected topic metadata %+v", topics[0])
+	}
+	if topics[1].Err != ErrUnknownTopicID || topics[1].Name != "" {
+		t.Errorf("unexpected topic metadata %+v", topics[1])
+	}
+
+	topics, err = admin.DescribeTopics([]string{"my_topic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 1 || topics[0].Uuid != topicID {
+		t.Errorf("expected DescribeTopics to return the topic ID, got %+v", topics)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDescribeTopicsByIDUnsupportedVersion(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_8_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	if _, err := admin.DescribeTopicsByID([]Uuid{{1}}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
 func TestClusterAdminDeleteEmptyTopic(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
@@ -274,6 +392,288 @@ func TestClusterAdminCreatePartitions(t *testing.T) {
 	}
 }
 
+func TestClusterAdminRetryPolicy(t *testing.T) {
+	config := NewTestConfig()
+	config.Admin.Retry.Max = 3
+	config.Admin.Retry.Backoff = time.Hour
+	var backoffs []int
+	config.Admin.Retry.BackoffFunc = func(retries, maxRetries int) time.Duration {
+		backoffs = append(backoffs, retries)
+		return 0
+	}
+	config.Admin.Retry.Retriable = func(err error) bool {
+		return errors.Is(err, ErrRequestTimedOut)
+	}
+	ca := &clusterAdmin{conf: config}
+
+	attempts := 0
+	err := ca.retryOnError(isErrNoController, func() error {
+		attempts++
+		return ErrRequestTimedOut
+	})
+	if !errors.Is(err, ErrRequestTimedOut) {
+		t.Fatalf("Expected ErrRequestTimedOut, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", attempts)
+	}
+	if !reflect.DeepEqual(backoffs, []int{1, 2}) {
+		t.Fatalf("Expected backoffs for retries 1 and 2, got %v", backoffs)
+	}
+
+	view := ca.WithRetryPolicy(AdminRetryPolicy{Max: 1}).(*clusterAdmin)
+	attempts = 0
+	err = view.retryOnError(isErrNoController, func() error {
+		attempts++
+		return ErrNotController
+	})
+	if !errors.Is(err, ErrNotController) || attempts != 1 {
+		t.Fatalf("Expected a single attempt failing with ErrNotController, got %d attempts and %v", attempts, err)
+	}
+}
+
+func TestClusterAdminValidateOnly(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+		"CreateTopicsRequest":                NewMockCreateTopicsResponse(t),
+		"CreatePartitionsRequest":            NewMockCreatePartitionsResponse(t),
+		"AlterConfigsRequest":                NewMockAlterConfigsResponse(t),
+		"DeleteTopicsRequest":                NewMockDeleteTopicsResponse(t),
+		"AlterPartitionReassignmentsRequest": NewMockAlterPartitionReassignmentsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_4_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	view := admin.WithOptions(AdminOptions{ValidateOnly: true})
+
+	if err := view.CreateTopic("new_topic", &TopicDetail{NumPartitions: 1, ReplicationFactor: 1}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := view.CreatePartitions("my_topic", 2, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	value := "1000"
+	if err := view.AlterConfig(TopicResource, "my_topic", map[string]*string{"retention.ms": &value}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.CreateTopic("other_topic", &TopicDetail{NumPartitions: 1, ReplicationFactor: 1}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := view.DeleteTopic("my_topic"); !errors.Is(err, ErrValidateOnlyUnsupported) {
+		t.Fatalf("Expected ErrValidateOnlyUnsupported, got %v", err)
+	}
+
+	if err := view.AlterPartitionReassignments("my_topic", [][]int32{{1}}); err != nil {
+		t.Fatal(err)
+	}
+	err = view.AlterPartitionReassignments("my_topic", [][]int32{{1, 1}})
+	if !errors.Is(err, ErrReassignPartitions) || !errors.Is(err, ErrInvalidReplicaAssignment) {
+		t.Fatalf("Expected ErrInvalidReplicaAssignment, got %v", err)
+	}
+	err = view.AlterPartitionReassignments("my_topic", [][]int32{{1}, {1}})
+	if !errors.Is(err, ErrUnknownTopicOrPartition) {
+		t.Fatalf("Expected ErrUnknownTopicOrPartition, got %v", err)
+	}
+
+	validated := make(map[string]bool)
+	for _, rr := range seedBroker.History() {
+		switch req := rr.Request.(type) {
+		case *CreateTopicsRequest:
+			for topic := range req.TopicDetails {
+				validated[topic] = req.ValidateOnly
+			}
+		case *CreatePartitionsRequest:
+			validated["partitions"] = req.ValidateOnly
+		case *AlterConfigsRequest:
+			validated["configs"] = req.ValidateOnly
+		case *DeleteTopicsRequest, *AlterPartitionReassignmentsRequest:
+			t.Fatalf("Expected no %T to be sent", req)
+		}
+	}
+	expected := map[string]bool{"new_topic": true, "partitions": true, "configs": true, "other_topic": false}
+	if !reflect.DeepEqual(validated, expected) {
+		t.Fatalf("Expected validateOnly flags %v, got %v", expected, validated)
+	}
+}
+
+func TestClusterAdminCreateBalancedPartitions(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+		"CreatePartitionsRequest": NewMockCreatePartitionsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assignment, err := admin.CreateBalancedPartitions("my_topic", 3, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]int32{{1}, {1}}
+	if !reflect.DeepEqual(assignment, expected) {
+		t.Fatalf("Expected assignment %v, got %v", expected, assignment)
+	}
+
+	var request *CreatePartitionsRequest
+	for _, rr := range seedBroker.History() {
+		if req, ok := rr.Request.(*CreatePartitionsRequest); ok {
+			request = req
+		}
+	}
+	if request == nil || !reflect.DeepEqual(request.TopicPartitions["my_topic"].Assignment, expected) {
+		t.Fatalf("Expected the assignment to be sent, got %v", request)
+	}
+
+	if _, err := admin.CreateBalancedPartitions("my_topic", 1, false); !errors.Is(err, ErrInvalidPartitions) {
+		t.Fatalf("Expected ErrInvalidPartitions, got %v", err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBalancedAssignment(t *testing.T) {
+	racks := map[int32]string{1: "a", 2: "a", 3: "b", 4: "b"}
+	replicaCounts := map[int32]int{1: 2, 2: 1, 3: 1, 4: 1}
+	leaderCounts := map[int32]int{1: 1, 2: 0, 3: 1, 4: 0}
+
+	assignment, err := balancedAssignment(racks, replicaCounts, leaderCounts, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]int32{{2, 3}, {4, 1}}
+	if !reflect.DeepEqual(assignment, expected) {
+		t.Fatalf("Expected assignment %v, got %v", expected, assignment)
+	}
+
+	if _, err := balancedAssignment(racks, replicaCounts, leaderCounts, 1, 5); !errors.Is(err, ErrInvalidReplicationFactor) {
+		t.Fatalf("Expected ErrInvalidReplicationFactor, got %v", err)
+	}
+}
+
+func TestClusterAdminApplyTopicSpec(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()).
+			SetLeader("my_topic", 1, seedBroker.BrokerID()),
+		"DescribeConfigsRequest":         NewMockDescribeConfigsResponse(t),
+		"CreatePartitionsRequest":        NewMockCreatePartitionsResponse(t),
+		"IncrementalAlterConfigsRequest": NewMockIncrementalAlterConfigsResponse(t),
+		"CreateTopicsRequest":            NewMockCreateTopicsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_3_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maxMessageBytes, retentionMs := "2000000", "5000"
+	spec := TopicSpec{
+		Name:              "my_topic",
+		NumPartitions:     3,
+		ReplicationFactor: 1,
+		ConfigEntries: map[string]*string{
+			"max.message.bytes": &maxMessageBytes,
+			"retention.ms":      &retentionMs,
+		},
+	}
+
+	changes, err := admin.ApplyTopicSpec(spec, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &TopicSpecChanges{
+		CurrentPartitions: 2,
+		NumPartitions:     3,
+		ConfigEntries: map[string]IncrementalAlterConfigsEntry{
+			"max.message.bytes": {Operation: IncrementalAlterConfigsOperationSet, Value: &maxMessageBytes},
+		},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Expected changes %+v, got %+v", expected, changes)
+	}
+	for _, rr := range seedBroker.History() {
+		switch rr.Request.(type) {
+		case *CreatePartitionsRequest, *IncrementalAlterConfigsRequest:
+			t.Fatal("Expected no changes for a dry run")
+		}
+	}
+
+	changes, err = admin.ApplyTopicSpec(spec, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changes.Executed {
+		t.Fatal("Expected the changes to be executed")
+	}
+	var partitions, alter bool
+	for _, rr := range seedBroker.History() {
+		switch rr.Request.(type) {
+		case *CreatePartitionsRequest:
+			partitions = true
+		case *IncrementalAlterConfigsRequest:
+			alter = true
+		}
+	}
+	if !partitions || !alter {
+		t.Fatal("Expected CreatePartitions and IncrementalAlterConfigs requests")
+	}
+
+	_, err = admin.ApplyTopicSpec(TopicSpec{Name: "my_topic", NumPartitions: 1}, true)
+	if !errors.Is(err, ErrInvalidPartitions) {
+		t.Fatalf("Expected ErrInvalidPartitions, got %v", err)
+	}
+	_, err = admin.ApplyTopicSpec(TopicSpec{Name: "my_topic", ReplicationFactor: 3}, true)
+	if !errors.Is(err, ErrInvalidReplicationFactor) {
+		t.Fatalf("Expected ErrInvalidReplicationFactor, got %v", err)
+	}
+
+	changes, err = admin.ApplyTopicSpec(TopicSpec{Name: "new_topic", NumPartitions: 2, ReplicationFactor: 1}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changes.Create || !changes.Executed {
+		t.Fatalf("Expected the topic to be created, got %+v", changes)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestClusterAdminCreatePartitionsWithDiffVersion(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
@@ -542,32 +942,21 @@ func TestClusterAdminDeleteRecords(t *testing.T) {
 	}
 }
 
-func TestClusterAdminDeleteRecordsWithInCorrectBroker(t *testing.T) {
+func TestClusterAdminPurgeTopic(t *testing.T) {
 	topicName := "my_topic"
 	seedBroker := NewMockBroker(t, 1)
-	secondBroker := NewMockBroker(t, 2)
 	defer seedBroker.Close()
-	defer secondBroker.Close()
 
 	seedBroker.SetHandlerByMap(map[string]MockResponse{
 		"MetadataRequest": NewMockMetadataResponse(t).
 			SetController(seedBroker.BrokerID()).
 			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
-			SetBroker(secondBroker.Addr(), secondBroker.brokerID).
-			SetLeader(topicName, 1, 1).
-			SetLeader(topicName, 2, 1).
-			SetLeader(topicName, 3, 2),
-		"DeleteRecordsRequest": NewMockDeleteRecordsResponse(t),
-	})
-
-	secondBroker.SetHandlerByMap(map[string]MockResponse{
-		"MetadataRequest": NewMockMetadataResponse(t).
-			SetController(seedBroker.BrokerID()).
-			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
-			SetBroker(secondBroker.Addr(), secondBroker.brokerID).
-			SetLeader(topicName, 1, 1).
-			SetLeader(topicName, 2, 1).
-			SetLeader(topicName, 3, 2),
+			SetLeader(topicName, 0, 1).
+			SetLeader(topicName, 1, 1),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(2).
+			SetOffset(topicName, 0, OffsetNewest, 100).
+			SetOffset(topicName, 1, OffsetNewest, 200),
 		"DeleteRecordsRequest": NewMockDeleteRecordsResponse(t),
 	})
 
@@ -577,15 +966,18 @@ func TestClusterAdminDeleteRecordsWithInCorrectBroker(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	partitionOffset := make(map[int32]int64)
-	partitionOffset[1] = 1000
-	partitionOffset[2] = 1000
-	partitionOffset[3] = 1000
 
-	err = admin.DeleteRecords(topicName, partitionOffset)
+	results, err := admin.PurgeTopic(topicName)
 	if err != nil {
 		t.Fatal(err)
 	}
+	expected := map[int32]*DeleteRecordsResponsePartition{
+		0: {LowWatermark: 100, Err: ErrNoError},
+		1: {LowWatermark: 200, Err: ErrNoError},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected results %v, got %v", expected, results)
+	}
 
 	err = admin.Close()
 	if err != nil {
@@ -593,7 +985,58 @@ func TestClusterAdminDeleteRecordsWithInCorrectBroker(t *testing.T) {
 	}
 }
 
-func TestClusterAdminDeleteRecordsWithDiffVersion(t *testing.T) {
+func TestClusterAdminDeleteRecordsWithInCorrectBroker(t *testing.T) {
+	topicName := "my_topic"
+	seedBroker := NewMockBroker(t, 1)
+	secondBroker := NewMockBroker(t, 2)
+	defer seedBroker.Close()
+	defer secondBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker(secondBroker.Addr(), secondBroker.brokerID).
+			SetLeader(topicName, 1, 1).
+			SetLeader(topicName, 2, 1).
+			SetLeader(topicName, 3, 2),
+		"DeleteRecordsRequest": NewMockDeleteRecordsResponse(t),
+	})
+
+	secondBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker(secondBroker.Addr(), secondBroker.brokerID).
+			SetLeader(topicName, 1, 1).
+			SetLeader(topicName, 2, 1).
+			SetLeader(topicName, 3, 2),
+		"DeleteRecordsRequest": NewMockDeleteRecordsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partitionOffset := make(map[int32]int64)
+	partitionOffset[1] = 1000
+	partitionOffset[2] = 1000
+	partitionOffset[3] = 1000
+
+	err = admin.DeleteRecords(topicName, partitionOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDeleteRecordsWithDiffVersion(t *testing.T) {
 	topicName := "my_topic"
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
@@ -1054,6 +1497,101 @@ func TestClusterAdminIncrementalAlterBrokerConfig(t *testing.T) {
 	}
 }
 
+func TestClusterAdminClusterBrokerConfig(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	secondBroker := NewMockBroker(t, 2)
+	defer secondBroker.Close()
+
+	metadata := NewMockMetadataResponse(t).
+		SetController(seedBroker.BrokerID()).
+		SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+		SetBroker(secondBroker.Addr(), secondBroker.BrokerID())
+	describeConfigs := NewMockDescribeConfigsResponse(t).
+		SetBrokerConfig("", "log.cleaner.threads", "2", SourceDynamicDefaultBroker).
+		SetBrokerConfig("", "log.retention.ms", "1000", SourceDynamicDefaultBroker).
+		SetBrokerConfig("1", "log.cleaner.threads", "4", SourceDynamicBroker).
+		SetBrokerConfig("1", "log.retention.ms", "1000", SourceDynamicBroker).
+		SetBrokerConfig("2", "log.cleaner.threads", "2", SourceDynamicBroker).
+		SetBrokerConfig("2", "num.io.threads", "16", SourceDynamicBroker).
+		SetBrokerConfig("2", "log.dirs", "/tmp", SourceStaticBroker)
+	for _, b := range []*MockBroker{seedBroker, secondBroker} {
+		b.SetHandlerByMap(map[string]MockResponse{
+			"MetadataRequest":                metadata,
+			"DescribeConfigsRequest":         describeConfigs,
+			"IncrementalAlterConfigsRequest": NewMockIncrementalAlterConfigsResponse(t),
+		})
+	}
+
+	config := NewTestConfig()
+	config.Version = V2_3_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	defaults, err := admin.DescribeClusterBrokerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defaults) != 3 {
+		t.Fatalf("Expected 3 cluster default configs, got %v", defaults)
+	}
+
+	deviations, err := admin.DescribeBrokerConfigDeviations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deviations) != 2 {
+		t.Fatalf("Expected 2 deviating configs, got %v", deviations)
+	}
+	threads := deviations["log.cleaner.threads"]
+	if threads == nil || threads.Default == nil || *threads.Default != "2" ||
+		!reflect.DeepEqual(threads.Brokers, map[int32]string{1: "4"}) {
+		t.Fatalf("Unexpected log.cleaner.threads deviation %v", threads)
+	}
+	ioThreads := deviations["num.io.threads"]
+	if ioThreads == nil || ioThreads.Default != nil ||
+		!reflect.DeepEqual(ioThreads.Brokers, map[int32]string{2: "16"}) {
+		t.Fatalf("Unexpected num.io.threads deviation %v", ioThreads)
+	}
+
+	value := "3"
+	err = admin.IncrementalAlterClusterBrokerConfig(map[string]IncrementalAlterConfigsEntry{
+		"log.cleaner.threads": {Operation: IncrementalAlterConfigsOperationSet, Value: &value},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var request *IncrementalAlterConfigsRequest
+	for _, b := range []*MockBroker{seedBroker, secondBroker} {
+		for _, rr := range b.History() {
+			if req, ok := rr.Request.(*IncrementalAlterConfigsRequest); ok {
+				request = req
+			}
+		}
+	}
+	if request == nil || len(request.Resources) != 1 ||
+		request.Resources[0].Type != BrokerResource || request.Resources[0].Name != "" {
+		t.Fatalf("Expected the cluster default broker resource to be altered, got %v", request)
+	}
+
+	config = NewTestConfig()
+	config.Version = V1_0_0_0
+	oldAdmin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer oldAdmin.Close()
+	if _, err := oldAdmin.DescribeBrokerConfigDeviations(); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+	if err := oldAdmin.IncrementalAlterClusterBrokerConfig(nil, false); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
 func TestClusterAdminCreateAcl(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
@@ -1133,6 +1671,88 @@ func TestClusterAdminListAcls(t *testing.T) {
 	}
 }
 
+func TestClusterAdminEnsureACLs(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	topic := Resource{ResourceType: AclResourceTopic, ResourceName: "my_topic", ResourcePatternType: AclPatternLiteral}
+	read := &Acl{Principal: "User:alice", Host: "*", Operation: AclOperationRead, PermissionType: AclPermissionAllow}
+	write := &Acl{Principal: "User:alice", Host: "*", Operation: AclOperationWrite, PermissionType: AclPermissionAllow}
+	describe := &Acl{Principal: "User:bob", Host: "*", Operation: AclOperationDescribe, PermissionType: AclPermissionAllow}
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DescribeAclsRequest": NewMockListAclsResponse(t).AddResourceAcls(topic, read, describe),
+		"CreateAclsRequest":   NewMockCreateAclsResponse(t),
+		"DeleteAclsRequest":   NewMockDeleteAclsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_0_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter := AclFilter{
+		ResourceType:              AclResourceAny,
+		ResourcePatternTypeFilter: AclPatternAny,
+		Operation:                 AclOperationAny,
+		PermissionType:            AclPermissionAny,
+	}
+	desired := []ResourceAcls{{Resource: topic, Acls: []*Acl{read, write}}}
+	expected := &AclChanges{
+		Create: []AclBinding{{Resource: topic, Acl: *write}},
+		Delete: []AclBinding{{Resource: topic, Acl: *describe}},
+	}
+
+	changes, err := admin.EnsureACLs(desired, filter, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Expected changes %v, got %v", expected, changes)
+	}
+	for _, rr := range seedBroker.History() {
+		switch rr.Request.(type) {
+		case *CreateAclsRequest, *DeleteAclsRequest:
+			t.Fatal("Expected no ACL changes for a dry run")
+		}
+	}
+
+	changes, err = admin.EnsureACLs(desired, filter, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Expected changes %v, got %v", expected, changes)
+	}
+
+	var create *CreateAclsRequest
+	var remove *DeleteAclsRequest
+	for _, rr := range seedBroker.History() {
+		switch req := rr.Request.(type) {
+		case *CreateAclsRequest:
+			create = req
+		case *DeleteAclsRequest:
+			remove = req
+		}
+	}
+	if create == nil || len(create.AclCreations) != 1 || create.AclCreations[0].Operation != AclOperationWrite {
+		t.Fatalf("Expected the write ACL to be created, got %v", create)
+	}
+	if remove == nil || len(remove.Filters) != 1 || *remove.Filters[0].Principal != "User:bob" {
+		t.Fatalf("Expected the describe ACL to be deleted, got %v", remove)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestClusterAdminDeleteAcl(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
@@ -1333,6 +1953,61 @@ func TestListConsumerGroups(t *testing.T) {
 	}
 }
 
+func TestListConsumerGroupListings(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"ListGroupsRequest": NewMockListGroupsResponse(t).
+			AddGroupWithData("stable-group", "consumer", GroupData{GroupState: "Stable"}).
+			AddGroupWithData("empty-group", "consumer", GroupData{GroupState: "Empty"}),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_6_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := admin.ListConsumerGroupListings(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("Expected %v results, got %v", 2, len(groups))
+	}
+	if groups["stable-group"].State != "Stable" {
+		t.Fatalf("Expected state %v, got %v", "Stable", groups["stable-group"].State)
+	}
+
+	groups, err = admin.ListConsumerGroupListings([]string{"Empty"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("Expected %v results, got %v", 1, len(groups))
+	}
+	expected := &ConsumerGroupListing{GroupID: "empty-group", ProtocolType: "consumer", State: "Empty"}
+	if !reflect.DeepEqual(groups["empty-group"], expected) {
+		t.Fatalf("Expected %v, got %v", expected, groups["empty-group"])
+	}
+
+	if _, err := admin.ListConsumerGroupListings(nil, []string{"classic"}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestListConsumerGroupsMultiBroker(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
@@ -1444,98 +2119,399 @@ func TestListConsumerGroupOffsets(t *testing.T) {
 	}
 }
 
-func TestDeleteConsumerGroup(t *testing.T) {
+func TestListConsumerGroupOffsetsBatch(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
 
-	group := "my-group"
-
 	seedBroker.SetHandlerByMap(map[string]MockResponse{
-		// "OffsetFetchRequest":  NewMockOffsetFetchResponse(t).SetOffset(group, "my-topic", partition, expectedOffset, "", ErrNoError),
-		"DeleteGroupsRequest": NewMockDeleteGroupsRequest(t).SetDeletedGroups([]string{group}),
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"OffsetFetchRequest": NewMockOffsetFetchResponse(t).
+			SetOffset("group-a", "my-topic", 0, 10, "", ErrNoError).
+			SetOffset("group-b", "my-topic", 1, 20, "", ErrNoError),
 		"MetadataRequest": NewMockMetadataResponse(t).
 			SetController(seedBroker.BrokerID()).
 			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
-		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).SetCoordinator(CoordinatorGroup, group, seedBroker),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "group-a", seedBroker).
+			SetCoordinator(CoordinatorGroup, "group-b", seedBroker),
 	})
 
 	config := NewTestConfig()
-	config.Version = V1_1_0_0
+	config.Version = V3_0_0_0
 
 	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	err = admin.DeleteConsumerGroup(group)
+	results, err := admin.ListConsumerGroupOffsetsBatch(map[string]map[string][]int32{
+		"group-a": {"my-topic": {0}},
+		"group-b": nil,
+	})
 	if err != nil {
-		t.Fatalf("DeleteConsumerGroup failed with error %v", err)
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected %v results, got %v", 2, len(results))
+	}
+	if block := results["group-a"].Blocks["my-topic"][0]; block == nil || block.Offset != 10 {
+		t.Fatalf("Expected offset 10 for group-a, got %v", block)
+	}
+	if block := results["group-b"].Blocks["my-topic"][1]; block == nil || block.Offset != 20 {
+		t.Fatalf("Expected offset 20 for group-b, got %v", block)
+	}
+
+	fetches := 0
+	for _, rr := range seedBroker.History() {
+		if _, ok := rr.Request.(*OffsetFetchRequest); ok {
+			fetches++
+		}
+	}
+	if fetches != 1 {
+		t.Fatalf("Expected a single OffsetFetchRequest, got %v", fetches)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
 	}
 }
 
-func TestDeleteOffset(t *testing.T) {
+func TestClusterAdminListOffsets(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
 
-	group := "group-delete-offset"
-	topic := "topic-delete-offset"
-	partition := int32(0)
-
-	handlerMap := map[string]MockResponse{
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
 		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
 		"MetadataRequest": NewMockMetadataResponse(t).
 			SetController(seedBroker.BrokerID()).
-			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
-		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).SetCoordinator(CoordinatorGroup, group, seedBroker),
-	}
-	seedBroker.SetHandlerByMap(handlerMap)
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my-topic", 0, seedBroker.BrokerID()).
+			SetLeader("my-topic", 1, seedBroker.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(7).
+			SetOffsetAndTimestamp("my-topic", 0, OffsetMaxTimestamp, 10, 1000).
+			SetOffsetAndTimestamp("my-topic", 1, OffsetMaxTimestamp, 20, 2000),
+	})
 
 	config := NewTestConfig()
-	config.Version = V2_4_0_0
+	config.Version = V3_0_0_0
 
 	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// Test NoError
-	handlerMap["DeleteOffsetsRequest"] = NewMockDeleteOffsetRequest(t).SetDeletedOffset(ErrNoError, topic, partition, ErrNoError)
-	seedBroker.SetHandlerByMap(handlerMap)
-	err = admin.DeleteConsumerGroupOffset(group, topic, partition)
+	results, err := admin.ListOffsets(map[string][]int32{"my-topic": {0, 1}}, OffsetMaxTimestamp)
 	if err != nil {
-		t.Fatalf("DeleteConsumerGroupOffset failed with error %v", err)
+		t.Fatal(err)
 	}
 
-	// Test Error
-	handlerMap["DeleteOffsetsRequest"] = NewMockDeleteOffsetRequest(t).SetDeletedOffset(ErrNotCoordinatorForConsumer, topic, partition, ErrNoError)
-	seedBroker.SetHandlerByMap(handlerMap)
-	err = admin.DeleteConsumerGroupOffset(group, topic, partition)
-	if !errors.Is(err, ErrNotCoordinatorForConsumer) {
-		t.Fatalf("DeleteConsumerGroupOffset should have failed with error %v", ErrNotCoordinatorForConsumer)
+	if block := results["my-topic"][0]; block == nil || block.Offset != 10 || block.Timestamp != 1000 {
+		t.Fatalf("Unexpected result for partition 0: %v", block)
+	}
+	if block := results["my-topic"][1]; block == nil || block.Offset != 20 || block.Timestamp != 2000 {
+		t.Fatalf("Unexpected result for partition 1: %v", block)
 	}
 
-	// Test Error for partition
-	handlerMap["DeleteOffsetsRequest"] = NewMockDeleteOffsetRequest(t).SetDeletedOffset(ErrNoError, topic, partition, ErrGroupSubscribedToTopic)
-	seedBroker.SetHandlerByMap(handlerMap)
-	err = admin.DeleteConsumerGroupOffset(group, topic, partition)
-	if !errors.Is(err, ErrGroupSubscribedToTopic) {
-		t.Fatalf("DeleteConsumerGroupOffset should have failed with error %v", ErrGroupSubscribedToTopic)
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
 	}
 }
 
-// TestRefreshMetaDataWithDifferentController ensures that the cached
-// controller can be forcibly updated from Metadata by the admin client
-func TestRefreshMetaDataWithDifferentController(t *testing.T) {
-	seedBroker1 := NewMockBroker(t, 1)
-	seedBroker2 := NewMockBroker(t, 2)
-	defer seedBroker1.Close()
-	defer seedBroker2.Close()
+func TestRemoveMembersFromConsumerGroup(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
 
-	seedBroker1.SetHandlerByMap(map[string]MockResponse{
+	group := "my-group"
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
 		"MetadataRequest": NewMockMetadataResponse(t).
-			SetController(seedBroker1.BrokerID()).
-			SetBroker(seedBroker1.Addr(), seedBroker1.BrokerID()).
-			SetBroker(seedBroker2.Addr(), seedBroker2.BrokerID()),
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).SetCoordinator(CoordinatorGroup, group, seedBroker),
+		"LeaveGroupRequest": NewMockLeaveGroupResponse(t).
+			SetMemberError("zombie", ErrUnknownMemberId),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_4_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := admin.RemoveMembersFromConsumerGroup(group, []string{"instance-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.RemoveMembersFromConsumerGroup(group, []string{"instance-1", "zombie"})
+	if !errors.Is(err, ErrRemoveConsumerGroupMembers) || !errors.Is(err, ErrUnknownMemberId) {
+		t.Fatalf("Expected ErrRemoveConsumerGroupMembers wrapping ErrUnknownMemberId, got %v", err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResetConsumerGroupOffsets(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	group := "my-group"
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my-topic", 0, seedBroker.BrokerID()).
+			SetLeader("my-topic", 1, seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, group, seedBroker).
+			SetCoordinator(CoordinatorGroup, "active-group", seedBroker),
+		"DescribeGroupsRequest": NewMockDescribeGroupsResponse(t).
+			AddGroupDescription(group, &GroupDescription{GroupId: group, State: "Empty"}).
+			AddGroupDescription("active-group", &GroupDescription{GroupId: "active-group", State: "Stable"}),
+		"OffsetFetchRequest": NewMockOffsetFetchResponse(t).
+			SetOffset(group, "my-topic", 0, 10, "", ErrNoError).
+			SetOffset(group, "my-topic", 1, 30, "", ErrNoError),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(2).
+			SetOffset("my-topic", 0, OffsetOldest, 8).
+			SetOffset("my-topic", 0, OffsetNewest, 100).
+			SetOffset("my-topic", 1, OffsetOldest, 0).
+			SetOffset("my-topic", 1, OffsetNewest, 50),
+		"OffsetCommitRequest": NewMockOffsetCommitResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := admin.ResetConsumerGroupOffsets("active-group", OffsetResetSpec{Strategy: ResetToEarliest}); !errors.Is(err, ErrNonEmptyGroup) {
+		t.Fatalf("Expected ErrNonEmptyGroup, got %v", err)
+	}
+
+	offsets, err := admin.ResetConsumerGroupOffsets(group, OffsetResetSpec{Strategy: ResetShiftBy, Shift: -5, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]map[int32]int64{"my-topic": {0: 8, 1: 25}}
+	if !reflect.DeepEqual(offsets, expected) {
+		t.Fatalf("Expected offsets %v, got %v", expected, offsets)
+	}
+	for _, rr := range seedBroker.History() {
+		if _, ok := rr.Request.(*OffsetCommitRequest); ok {
+			t.Fatal("Expected no OffsetCommitRequest for a dry run")
+		}
+	}
+
+	offsets, err = admin.ResetConsumerGroupOffsets(group, OffsetResetSpec{
+		Strategy:        ResetToLatest,
+		TopicPartitions: map[string][]int32{"my-topic": {1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]map[int32]int64{"my-topic": {1: 50}}
+	if !reflect.DeepEqual(offsets, expected) {
+		t.Fatalf("Expected offsets %v, got %v", expected, offsets)
+	}
+
+	var commit *OffsetCommitRequest
+	for _, rr := range seedBroker.History() {
+		if req, ok := rr.Request.(*OffsetCommitRequest); ok {
+			commit = req
+		}
+	}
+	if commit == nil {
+		t.Fatal("Expected an OffsetCommitRequest")
+	}
+	if block := commit.blocks["my-topic"][1]; block == nil || block.offset != 50 {
+		t.Fatalf("Expected offset 50 to be committed, got %v", block)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImportConsumerGroupOffsets(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "source", seedBroker).
+			SetCoordinator(CoordinatorGroup, "target", seedBroker).
+			SetCoordinator(CoordinatorGroup, "active-group", seedBroker),
+		"DescribeGroupsRequest": NewMockDescribeGroupsResponse(t).
+			AddGroupDescription("target", &GroupDescription{GroupId: "target", State: "Dead"}).
+			AddGroupDescription("active-group", &GroupDescription{GroupId: "active-group", State: "Stable"}),
+		"OffsetFetchRequest": NewMockOffsetFetchResponse(t).
+			SetOffsetWithLeaderEpoch("source", "my-topic", 0, 10, 3, "meta", ErrNoError).
+			SetOffset("source", "my-topic", 1, -1, "", ErrNoError),
+		"OffsetCommitRequest": NewMockOffsetCommitResponse(t).
+			SetError("target", "my-topic", 0, ErrNoError),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_1_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offsets, err := admin.ExportConsumerGroupOffsets("source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &ConsumerGroupOffsets{
+		Group: "source",
+		Offsets: map[string]map[int32]*ConsumerGroupOffset{
+			"my-topic": {0: {Offset: 10, LeaderEpoch: 3, Metadata: "meta"}},
+		},
+	}
+	if !reflect.DeepEqual(offsets, expected) {
+		t.Fatalf("Expected offsets %v, got %v", expected, offsets)
+	}
+
+	if err := admin.ImportConsumerGroupOffsets("active-group", offsets); !errors.Is(err, ErrNonEmptyGroup) {
+		t.Fatalf("Expected ErrNonEmptyGroup, got %v", err)
+	}
+
+	if err := admin.ImportConsumerGroupOffsets("target", offsets); err != nil {
+		t.Fatal(err)
+	}
+
+	var commit *OffsetCommitRequest
+	for _, rr := range seedBroker.History() {
+		if req, ok := rr.Request.(*OffsetCommitRequest); ok {
+			commit = req
+		}
+	}
+	if commit == nil {
+		t.Fatal("Expected an OffsetCommitRequest")
+	}
+	if commit.Version != 6 || commit.ConsumerGroup != "target" {
+		t.Fatalf("Expected a v6 commit for target, got v%d for %s", commit.Version, commit.ConsumerGroup)
+	}
+	block := commit.blocks["my-topic"][0]
+	if block == nil || block.offset != 10 || block.committedLeaderEpoch != 3 || block.metadata != "meta" {
+		t.Fatalf("Unexpected committed block %v", block)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteConsumerGroup(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	group := "my-group"
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		// "OffsetFetchRequest":  NewMockOffsetFetchResponse(t).SetOffset(group, "my-topic", partition, expectedOffset, "", ErrNoError),
+		"DeleteGroupsRequest": NewMockDeleteGroupsRequest(t).SetDeletedGroups([]string{group}),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).SetCoordinator(CoordinatorGroup, group, seedBroker),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_1_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.DeleteConsumerGroup(group)
+	if err != nil {
+		t.Fatalf("DeleteConsumerGroup failed with error %v", err)
+	}
+}
+
+func TestDeleteOffset(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	group := "group-delete-offset"
+	topic := "topic-delete-offset"
+	partition := int32(0)
+
+	handlerMap := map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).SetCoordinator(CoordinatorGroup, group, seedBroker),
+	}
+	seedBroker.SetHandlerByMap(handlerMap)
+
+	config := NewTestConfig()
+	config.Version = V2_4_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Test NoError
+	handlerMap["DeleteOffsetsRequest"] = NewMockDeleteOffsetRequest(t).SetDeletedOffset(ErrNoError, topic, partition, ErrNoError)
+	seedBroker.SetHandlerByMap(handlerMap)
+	err = admin.DeleteConsumerGroupOffset(group, topic, partition)
+	if err != nil {
+		t.Fatalf("DeleteConsumerGroupOffset failed with error %v", err)
+	}
+
+	// Test Error
+	handlerMap["DeleteOffsetsRequest"] = NewMockDeleteOffsetRequest(t).SetDeletedOffset(ErrNotCoordinatorForConsumer, topic, partition, ErrNoError)
+	seedBroker.SetHandlerByMap(handlerMap)
+	err = admin.DeleteConsumerGroupOffset(group, topic, partition)
+	if !errors.Is(err, ErrNotCoordinatorForConsumer) {
+		t.Fatalf("DeleteConsumerGroupOffset should have failed with error %v", ErrNotCoordinatorForConsumer)
+	}
+
+	// Test Error for partition
+	handlerMap["DeleteOffsetsRequest"] = NewMockDeleteOffsetRequest(t).SetDeletedOffset(ErrNoError, topic, partition, ErrGroupSubscribedToTopic)
+	seedBroker.SetHandlerByMap(handlerMap)
+	err = admin.DeleteConsumerGroupOffset(group, topic, partition)
+	if !errors.Is(err, ErrGroupSubscribedToTopic) {
+		t.Fatalf("DeleteConsumerGroupOffset should have failed with error %v", ErrGroupSubscribedToTopic)
+	}
+}
+
+// TestRefreshMetaDataWithDifferentController ensures that the cached
+// controller can be forcibly updated from Metadata by the admin client
+func TestRefreshMetaDataWithDifferentController(t *testing.T) {
+	seedBroker1 := NewMockBroker(t, 1)
+	seedBroker2 := NewMockBroker(t, 2)
+	defer seedBroker1.Close()
+	defer seedBroker2.Close()
+
+	seedBroker1.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker1.BrokerID()).
+			SetBroker(seedBroker1.Addr(), seedBroker1.BrokerID()).
+			SetBroker(seedBroker2.Addr(), seedBroker2.BrokerID()),
 	})
 
 	config := NewTestConfig()
@@ -1618,3 +2594,969 @@ func TestDescribeLogDirs(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestClusterAdminDescribeClusterDetails(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	rack := "rack-a"
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DescribeClusterRequest": NewMockDescribeClusterResponse(t).
+			SetClusterID("cluster-1").
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID(), &rack).
+			SetAuthorizedOperations(int32(1 << AclOperationDescribe)),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_8_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cluster, err := admin.DescribeClusterDetails(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cluster.ClusterID != "cluster-1" {
+		t.Errorf("Expected cluster id cluster-1, got %s", cluster.ClusterID)
+	}
+	if cluster.ControllerID != seedBroker.BrokerID() {
+		t.Errorf("Expected controller %d, got %d", seedBroker.BrokerID(), cluster.ControllerID)
+	}
+	if len(cluster.Brokers) != 1 {
+		t.Fatalf("Expected 1 broker, got %d", len(cluster.Brokers))
+	}
+	if cluster.Brokers[0].ID() != seedBroker.BrokerID() || cluster.Brokers[0].Addr() != seedBroker.Addr() {
+		t.Errorf("Unexpected broker %d at %s", cluster.Brokers[0].ID(), cluster.Brokers[0].Addr())
+	}
+	if cluster.Brokers[0].Rack() != rack {
+		t.Errorf("Expected rack %s, got %s", rack, cluster.Brokers[0].Rack())
+	}
+	if cluster.AuthorizedOperations != int32(1<<AclOperationDescribe) {
+		t.Errorf("Unexpected authorized operations %d", cluster.AuthorizedOperations)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDescribeProducers(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	state := &ProducerState{
+		ProducerID:            1000,
+		ProducerEpoch:         2,
+		LastSequence:          10,
+		LastTimestamp:         1607000000000,
+		CoordinatorEpoch:      1,
+		CurrentTxnStartOffset: 42,
+	}
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()).
+			SetLeader("my_topic", 1, seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DescribeProducersRequest": NewMockDescribeProducersResponse(t).
+			SetProducer("my_topic", 0, state),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_8_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := admin.DescribeProducers(map[string][]int32{"my_topic": {0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partitions, ok := results["my_topic"]
+	if !ok || len(partitions) != 2 {
+		t.Fatalf("Expected 2 partitions for my_topic, got %v", partitions)
+	}
+	if len(partitions[0].ActiveProducers) != 1 || !reflect.DeepEqual(partitions[0].ActiveProducers[0], state) {
+		t.Errorf("Unexpected producers for partition 0: %v", partitions[0].ActiveProducers)
+	}
+	if len(partitions[1].ActiveProducers) != 0 {
+		t.Errorf("Expected no producers for partition 1, got %v", partitions[1].ActiveProducers)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminListTransactions(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"ListTransactionsRequest": NewMockListTransactionsResponse(t).
+			AddTransaction("tx1", 1, "Ongoing").
+			AddTransaction("tx2", 2, "CompleteCommit"),
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions, err := admin.ListTransactions(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(transactions))
+	}
+
+	transactions, err = admin.ListTransactions([]string{"Ongoing"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 1 || transactions[0].TransactionalID != "tx1" {
+		t.Fatalf("Expected only tx1 to be listed, got %v", transactions)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDescribeTransactions(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	description := &TransactionDescription{
+		ErrorCode:            ErrNoError,
+		TransactionalID:      "tx1",
+		TransactionState:     "Ongoing",
+		TransactionTimeout:   time.Minute,
+		TransactionStartTime: 1607000000000,
+		ProducerID:           7,
+		ProducerEpoch:        1,
+		Topics:               map[string][]int32{"my_topic": {0}},
+	}
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorTransaction, "tx1", seedBroker).
+			SetCoordinator(CoordinatorTransaction, "tx2", seedBroker),
+		"DescribeTransactionsRequest": NewMockDescribeTransactionsResponse(t).
+			SetTransaction(description),
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	descriptions, err := admin.DescribeTransactions([]string{"tx1", "tx2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(descriptions) != 2 {
+		t.Fatalf("Expected 2 descriptions, got %d", len(descriptions))
+	}
+	if !reflect.DeepEqual(descriptions[0], description) {
+		t.Errorf("Unexpected description for tx1: %v", descriptions[0])
+	}
+	if !errors.Is(descriptions[1].ErrorCode, ErrTransactionalIDNotFound) {
+		t.Errorf("Expected ErrTransactionalIDNotFound for tx2, got %v", descriptions[1].ErrorCode)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDescribeConsumerProtocolGroups(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	group := &ConsumerGroupDescribeGroup{
+		ErrorCode:       ErrNoError,
+		GroupID:         "g1",
+		GroupState:      "Stable",
+		GroupEpoch:      5,
+		AssignmentEpoch: 5,
+		AssignorName:    "uniform",
+		Members: []*ConsumerGroupDescribeMember{{
+			MemberID:             "m1",
+			MemberEpoch:          5,
+			ClientID:             "c1",
+			ClientHost:           "/127.0.0.1",
+			SubscribedTopicNames: []string{"my_topic"},
+			Assignment: []*ConsumerGroupDescribeTopicPartitions{{
+				TopicID:    Uuid{1},
+				TopicName:  "my_topic",
+				Partitions: []int32{0, 1},
+			}},
+			TargetAssignment: []*ConsumerGroupDescribeTopicPartitions{{
+				TopicID:    Uuid{1},
+				TopicName:  "my_topic",
+				Partitions: []int32{0},
+			}},
+		}},
+		AuthorizedOperations: math.MinInt32,
+	}
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "g1", seedBroker).
+			SetCoordinator(CoordinatorGroup, "g2", seedBroker),
+		"ConsumerGroupDescribeRequest": NewMockConsumerGroupDescribeResponse(t).
+			SetGroup(group),
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_7_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	descriptions, err := admin.DescribeConsumerProtocolGroups([]string{"g1", "g2"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(descriptions) != 2 {
+		t.Fatalf("Expected 2 descriptions, got %d", len(descriptions))
+	}
+	if !reflect.DeepEqual(descriptions[0], group) {
+		t.Errorf("Unexpected description for g1: %v", descriptions[0])
+	}
+	if !errors.Is(descriptions[1].ErrorCode, ErrGroupIDNotFound) {
+		t.Errorf("Expected ErrGroupIDNotFound for g2, got %v", descriptions[1].ErrorCode)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminUnregisterBroker(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"UnregisterBrokerRequest": NewMockUnregisterBrokerResponse(t).
+			SetError(5, ErrBrokerIDNotRegistered),
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_1_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := admin.UnregisterBroker(4); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := admin.UnregisterBroker(5); !errors.Is(err, ErrBrokerIDNotRegistered) {
+		t.Fatalf("Expected ErrBrokerIDNotRegistered, got %v", err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminRaftVoters(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	directoryID := Uuid{1, 2, 3}
+	voters := NewMockRaftVoterResponse(t).SetVoter(3000, Uuid{3})
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"AddRaftVoterRequest":    voters,
+		"RemoveRaftVoterRequest": voters,
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_9_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listeners := []RaftVoterListener{{Name: "CONTROLLER", Host: "controller-3001", Port: 9093}}
+	if err := admin.AddRaftVoter(3001, directoryID, listeners); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.AddRaftVoter(3000, Uuid{3}, listeners); !errors.Is(err, ErrDuplicateVoter) {
+		t.Fatalf("Expected ErrDuplicateVoter, got %v", err)
+	}
+
+	if err := admin.RemoveRaftVoter(3000, Uuid{3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.RemoveRaftVoter(3001, Uuid{3}); !errors.Is(err, ErrVoterNotFound) {
+		t.Fatalf("Expected ErrVoterNotFound, got %v", err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDescribeFeatures(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t).
+			SetSupportedFeature("metadata.version", 1, 5).
+			SetFinalizedFeature("metadata.version", 1, 4).
+			SetFinalizedFeaturesEpoch(12),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	features, err := admin.DescribeFeatures()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if features.FinalizedFeaturesEpoch != 12 {
+		t.Errorf("Expected finalized features epoch 12, got %d", features.FinalizedFeaturesEpoch)
+	}
+	expectedFinalized := FinalizedVersionRange{MinVersionLevel: 1, MaxVersionLevel: 4}
+	if features.FinalizedFeatures["metadata.version"] != expectedFinalized {
+		t.Errorf("Expected finalized metadata.version %v, got %v", expectedFinalized, features.FinalizedFeatures["metadata.version"])
+	}
+	expectedSupported := SupportedVersionRange{MinVersion: 1, MaxVersion: 5}
+	if features.SupportedFeatures["metadata.version"] != expectedSupported {
+		t.Errorf("Expected supported metadata.version %v, got %v", expectedSupported, features.SupportedFeatures["metadata.version"])
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminUpdateFeatures(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"UpdateFeaturesRequest": NewMockUpdateFeaturesResponse(t).
+			SetError("unknown.feature", ErrFeatureUpdateFailed),
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.UpdateFeatures(map[string]FeatureUpdate{
+		"metadata.version": {MaxVersionLevel: 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.UpdateFeatures(map[string]FeatureUpdate{
+		"unknown.feature": {MaxVersionLevel: 1},
+	})
+	if !errors.Is(err, ErrUpdateFeatures) || !errors.Is(err, ErrFeatureUpdateFailed) {
+		t.Fatalf("Expected ErrUpdateFeatures wrapping ErrFeatureUpdateFailed, got %v", err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminElectLeaders(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"ElectLeadersRequest": NewMockElectLeadersResponse(t).
+			SetError("my_topic", 1, ErrElectionNotNeeded),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_4_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := admin.ElectLeaders(UncleanElection, map[string][]int32{"my_topic": {0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partitions, ok := results["my_topic"]
+	if !ok || len(partitions) != 2 {
+		t.Fatalf("Expected results for 2 partitions of my_topic, got %v", results)
+	}
+	if !errors.Is(partitions[0].ErrorCode, ErrNoError) {
+		t.Errorf("Expected no error for partition 0, got %v", partitions[0].ErrorCode)
+	}
+	if !errors.Is(partitions[1].ErrorCode, ErrElectionNotNeeded) {
+		t.Errorf("Expected ErrElectionNotNeeded for partition 1, got %v", partitions[1].ErrorCode)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminMonitorPartitionReassignments(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	ongoing := &ListPartitionReassignmentsResponse{}
+	ongoing.AddBlock("my_topic", 0, []int32{1}, []int32{1}, []int32{})
+	completed := &ListPartitionReassignmentsResponse{}
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"ListPartitionReassignmentsRequest": NewMockSequence(ongoing, completed),
+		"DescribeLogDirsRequest": NewMockDescribeLogDirsResponse(t).
+			SetLogDirs("/tmp/logs", map[string]int{"my_topic": 1}),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_4_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reports []*PartitionReassignmentProgress
+	err = admin.MonitorPartitionReassignments("my_topic", []int32{0}, time.Millisecond, time.Second, func(p *PartitionReassignmentProgress) {
+		reports = append(reports, p)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 progress reports, got %d", len(reports))
+	}
+	if reports[0].Done || reports[0].ReplicaSizes[seedBroker.BrokerID()] != 1234 {
+		t.Errorf("Unexpected first progress report %+v", reports[0])
+	}
+	if !reports[1].Done || reports[1].Partition != 0 {
+		t.Errorf("Expected partition 0 to be reported as done, got %+v", reports[1])
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminMonitorPartitionReassignmentsTimeout(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"ListPartitionReassignmentsRequest": NewMockListPartitionReassignmentsResponse(t),
+		"DescribeLogDirsRequest": NewMockDescribeLogDirsResponse(t).
+			SetLogDirs("/tmp/logs", map[string]int{"my_topic": 1}),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_4_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.MonitorPartitionReassignments("my_topic", []int32{0}, 10*time.Millisecond, 50*time.Millisecond, nil)
+	if !errors.Is(err, ErrReassignmentTimeout) {
+		t.Fatalf("Expected ErrReassignmentTimeout, got %v", err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminAlterReplicaLogDirs(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"AlterReplicaLogDirsRequest": NewMockAlterReplicaLogDirsResponse(t).
+			SetError("my_topic", 1, ErrLogDirNotFound),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.AlterReplicaLogDirs(seedBroker.BrokerID(), map[string]map[string][]int32{
+		"/data/kfk": {"my_topic": {0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.AlterReplicaLogDirs(seedBroker.BrokerID(), map[string]map[string][]int32{
+		"/data/missing": {"my_topic": {1}},
+	})
+	if !errors.Is(err, ErrAlterReplicaLogDirs) || !errors.Is(err, ErrLogDirNotFound) {
+		t.Fatalf("Expected ErrAlterReplicaLogDirs wrapping ErrLogDirNotFound, got %v", err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDescribeLogDirsSummary(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	secondBroker := NewMockBroker(t, 2)
+	defer secondBroker.Close()
+
+	metadata := NewMockMetadataResponse(t).
+		SetController(seedBroker.BrokerID()).
+		SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+		SetBroker(secondBroker.Addr(), secondBroker.BrokerID())
+	logDirs := NewMockDescribeLogDirsResponse(t).
+		SetLogDirs("/tmp/logs", map[string]int{"topic1": 2, "topic2": 1})
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest":        metadata,
+		"DescribeLogDirsRequest": logDirs,
+	})
+	secondBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest":        metadata,
+		"DescribeLogDirsRequest": logDirs,
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := admin.DescribeLogDirsSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every partition replica is reported with a size of 1234 bytes
+	if summary.TotalBytes != 6*1234 {
+		t.Errorf("Expected total of %d bytes, got %d", 6*1234, summary.TotalBytes)
+	}
+	if len(summary.Brokers) != 2 {
+		t.Fatalf("Expected 2 brokers, got %d", len(summary.Brokers))
+	}
+	broker := summary.Brokers[secondBroker.BrokerID()]
+	if broker.TotalBytes != 3*1234 || broker.LogDirs["/tmp/logs"] != 3*1234 || broker.Topics["topic1"] != 2*1234 {
+		t.Errorf("Unexpected broker summary %+v", broker)
+	}
+	topic := summary.Topics["topic1"]
+	if topic == nil || topic.TotalBytes != 4*1234 || topic.Partitions[1] != 2*1234 {
+		t.Errorf("Unexpected topic summary %+v", topic)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDelegationTokens(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	tokens := NewMockDelegationTokenResponse(t).SetOwner("User", "alice")
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"CreateDelegationTokenRequest":   tokens,
+		"RenewDelegationTokenRequest":    tokens,
+		"ExpireDelegationTokenRequest":   tokens,
+		"DescribeDelegationTokenRequest": tokens,
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renewers := []DelegationTokenPrincipal{{Type: "User", Name: "bob"}}
+	token, err := admin.CreateDelegationToken(renewers, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Owner.Name != "alice" || len(token.HMAC) == 0 {
+		t.Fatalf("Unexpected token %+v", token)
+	}
+
+	expiry, err := admin.RenewDelegationToken(token.HMAC, 48*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expiry.After(token.ExpiryTime) {
+		t.Errorf("Expected expiry time %v to be after %v", expiry, token.ExpiryTime)
+	}
+
+	described, err := admin.DescribeDelegationToken([]DelegationTokenPrincipal{{Type: "User", Name: "alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(described) != 1 || described[0].TokenID != token.TokenID || !reflect.DeepEqual(described[0].Renewers, renewers) {
+		t.Fatalf("Unexpected described tokens %+v", described)
+	}
+
+	if _, err := admin.ExpireDelegationToken(token.HMAC, -1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admin.RenewDelegationToken(token.HMAC, -1); !errors.Is(err, ErrDelegationTokenExpired) {
+		t.Errorf("Expected ErrDelegationTokenExpired, got %v", err)
+	}
+	if _, err := admin.RenewDelegationToken([]byte("unknown"), -1); !errors.Is(err, ErrDelegationTokenNotFound) {
+		t.Errorf("Expected ErrDelegationTokenNotFound, got %v", err)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminClientQuotas(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	quotas := NewMockClientQuotasResponse(t).
+		SetQuota([]QuotaEntityComponent{QuotaDefaultUser()}, QuotaProducerByteRate, 1024).
+		SetQuota([]QuotaEntityComponent{QuotaDefaultUser()}, QuotaConsumerByteRate, 2048).
+		SetQuota([]QuotaEntityComponent{QuotaClientID("my-client")}, QuotaRequestPercentage, 50).
+		SetQuota([]QuotaEntityComponent{QuotaUser("other")}, QuotaProducerByteRate, 1)
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DescribeClientQuotasRequest": quotas,
+		"AlterClientQuotasRequest":    quotas,
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_6_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	rate := float64(4096)
+	entity := []QuotaEntityComponent{QuotaUser("alice"), QuotaDefaultClientID()}
+	if err := admin.SetClientQuotas(entity, ClientQuotaValues{ProducerByteRate: &rate}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	effective, err := admin.DescribeEffectiveClientQuotas("alice", "my-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(effective) != 3 {
+		t.Fatalf("expected 3 effective quotas, got %v", effective)
+	}
+	if q := effective[QuotaProducerByteRate]; q.Value != 4096 || !quotaEntityEqual(q.Entity, entity) {
+		t.Errorf("unexpected producer quota %+v", q)
+	}
+	if q := effective[QuotaConsumerByteRate]; q.Value != 2048 || !quotaEntityEqual(q.Entity, []QuotaEntityComponent{QuotaDefaultUser()}) {
+		t.Errorf("unexpected consumer quota %+v", q)
+	}
+	if q := effective[QuotaRequestPercentage]; q.Value != 50 {
+		t.Errorf("unexpected request quota %+v", q)
+	}
+}
+
+func TestResolveClientQuotas(t *testing.T) {
+	entries := []DescribeClientQuotasEntry{
+		{Entity: []QuotaEntityComponent{QuotaDefaultClientID()}, Values: map[string]float64{"a": 8, "b": 8, "c": 8}},
+		{Entity: []QuotaEntityComponent{QuotaDefaultClientID(), QuotaDefaultUser()}, Values: map[string]float64{"b": 5}},
+		{Entity: []QuotaEntityComponent{QuotaClientID("client"), QuotaUser("user")}, Values: map[string]float64{"a": 1}},
+		{Entity: []QuotaEntityComponent{QuotaClientID("other"), QuotaUser("user")}, Values: map[string]float64{"c": 1}},
+	}
+
+	quotas := resolveClientQuotas(entries, "user", "client")
+	expected := map[string]float64{"a": 1, "b": 5, "c": 8}
+	if len(quotas) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, quotas)
+	}
+	for key, value := range expected {
+		if quotas[key].Value != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, quotas[key].Value)
+		}
+	}
+}
+
+func TestClusterAdminHealthCheck(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	deadBroker := NewMockBroker(t, 2)
+	deadBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker(deadBroker.Addr(), deadBroker.BrokerID()).
+			SetLeader("a", 0, seedBroker.BrokerID()).
+			SetLeader("a", 1, -1).
+			SetLeader("a", 2, seedBroker.BrokerID()).
+			SetLeader("b", 0, seedBroker.BrokerID()).
+			SetIsr("a", 0, []int32{seedBroker.BrokerID()}).
+			SetIsr("a", 1, []int32{}),
+		"DescribeConfigsRequest": NewMockDescribeConfigsResponse(t).
+			SetTopicConfig("a", "min.insync.replicas", "2"),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	health, err := admin.HealthCheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Healthy() {
+		t.Error("expected the cluster to be unhealthy")
+	}
+	if health.ControllerID != 1 || !health.ControllerAvailable {
+		t.Errorf("expected controller 1 to be available, got %d %v", health.ControllerID, health.ControllerAvailable)
+	}
+	if !reflect.DeepEqual(health.UnreachableBrokers, []int32{2}) {
+		t.Errorf("expected broker 2 to be unreachable, got %v", health.UnreachableBrokers)
+	}
+	if !reflect.DeepEqual(health.OfflinePartitions, map[string][]int32{"a": {1}}) {
+		t.Errorf("unexpected offline partitions %v", health.OfflinePartitions)
+	}
+	if !reflect.DeepEqual(health.UnderReplicatedPartitions, map[string]int{"a": 2}) {
+		t.Errorf("unexpected under-replicated partitions %v", health.UnderReplicatedPartitions)
+	}
+	if !reflect.DeepEqual(health.UnderMinIsrPartitions, map[string]int{"a": 2}) {
+		t.Errorf("unexpected under-min-ISR partitions %v", health.UnderMinIsrPartitions)
+	}
+}
+
+func TestClusterAdminListUnhealthyPartitions(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("a", 0, seedBroker.BrokerID()).
+			SetLeader("a", 1, -1).
+			SetLeader("b", 0, seedBroker.BrokerID()).
+			SetLeader("c", 0, seedBroker.BrokerID()).
+			SetIsr("a", 1, []int32{}).
+			SetIsr("b", 0, []int32{}),
+		"DescribeConfigsRequest": NewMockDescribeConfigsResponse(t).
+			SetTopicConfig("b", "min.insync.replicas", "1"),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	unhealthy, err := admin.ListUnhealthyPartitions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unhealthy) != 2 || len(unhealthy["a"]) != 1 || len(unhealthy["b"]) != 1 {
+		t.Fatalf("expected one unhealthy partition for a and b, got %v", unhealthy)
+	}
+	if p := unhealthy["a"][0]; p.ID != 1 || p.Leader != -1 || !p.Offline || !p.UnderMinIsr || len(p.Isr) != 0 {
+		t.Errorf("unexpected unhealthy partition for a %+v", p)
+	}
+	if p := unhealthy["b"][0]; p.ID != 0 || p.Offline || !p.UnderMinIsr || p.MinIsr != 1 || !reflect.DeepEqual(p.Replicas, []int32{1}) {
+		t.Errorf("unexpected unhealthy partition for b %+v", p)
+	}
+
+	unhealthy, err = admin.ListUnhealthyPartitions([]string{"c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unhealthy) != 0 {
+		t.Errorf("expected no unhealthy partitions, got %v", unhealthy)
+	}
+}
+
+func TestClusterAdminBulkTopicOperations(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"CreateTopicsRequest": NewMockCreateTopicsResponse(t),
+		"DeleteTopicsRequest": NewMockDeleteTopicsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	topics := make(map[string]*TopicDetail)
+	for _, topic := range []string{"a", "b", "c", "d", "_reserved"} {
+		topics[topic] = &TopicDetail{NumPartitions: 1, ReplicationFactor: 1}
+	}
+	options := &BulkTopicOptions{BatchSize: 2, Concurrency: 2, RequestInterval: time.Millisecond}
+	results, err := admin.CreateTopics(topics, options)
+	if !errors.Is(err, ErrCreateTopics) || !errors.Is(err, ErrTopicAuthorizationFailed) {
+		t.Fatalf("expected ErrCreateTopics wrapping ErrTopicAuthorizationFailed, got %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %v", results)
+	}
+	for topic, topicErr := range results {
+		if topic == "_reserved" {
+			if !errors.Is(topicErr, ErrTopicAuthorizationFailed) {
+				t.Errorf("expected ErrTopicAuthorizationFailed for %s, got %v", topic, topicErr)
+			}
+		} else if topicErr != nil {
+			t.Errorf("unexpected error for %s: %v", topic, topicErr)
+		}
+	}
+
+	results, err = admin.DeleteTopics([]string{"a", "b", "c"}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %v", results)
+	}
+
+	var creates, deletes int
+	for _, rr := range seedBroker.History() {
+		switch req := rr.Request.(type) {
+		case *CreateTopicsRequest:
+			creates++
+			if len(req.TopicDetails) > 2 {
+				t.Errorf("expected at most 2 topics per request, got %d", len(req.TopicDetails))
+			}
+		case *DeleteTopicsRequest:
+			deletes++
+		}
+	}
+	if creates != 3 || deletes != 2 {
+		t.Errorf("expected 3 create and 2 delete requests, got %d and %d", creates, deletes)
+	}
+}
@@ -0,0 +1,105 @@
+package sarama
+
+// AlterReplicaLogDirsRequest is a request to move replicas hosted by a broker
+// to other log directories of that broker
+type AlterReplicaLogDirsRequest struct {
+	// Version 0 and 1 are equal
+	// The version number is bumped to indicate that on quota violation brokers send out responses before throttling.
+	Version int16
+
+	Dirs []AlterReplicaLogDirsRequestDir
+}
+
+// AlterReplicaLogDirsRequestDir holds the partitions to move to a log directory
+type AlterReplicaLogDirsRequestDir struct {
+	// The absolute log directory path
+	Path   string
+	Topics []AlterReplicaLogDirsRequestTopic
+}
+
+// AlterReplicaLogDirsRequestTopic holds the partitions of a topic to move to a log directory
+type AlterReplicaLogDirsRequestTopic struct {
+	Topic        string
+	PartitionIDs []int32
+}
+
+func (r *AlterReplicaLogDirsRequest) encode(pe packetEncoder) error {
+	if err := pe.putArrayLength(len(r.Dirs)); err != nil {
+		return err
+	}
+
+	for _, d := range r.Dirs {
+		if err := pe.putString(d.Path); err != nil {
+			return err
+		}
+
+		if err := pe.putArrayLength(len(d.Topics)); err != nil {
+			return err
+		}
+
+		for _, t := range d.Topics {
+			if err := pe.putString(t.Topic); err != nil {
+				return err
+			}
+
+			if err := pe.putInt32Array(t.PartitionIDs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *AlterReplicaLogDirsRequest) decode(pd packetDecoder, version int16) error {
+	r.Version = version
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+
+	r.Dirs = make([]AlterReplicaLogDirsRequestDir, n)
+	for i := 0; i < n; i++ {
+		if r.Dirs[i].Path, err = pd.getString(); err != nil {
+			return err
+		}
+
+		m, err := pd.getArrayLength()
+		if err != nil {
+			return err
+		}
+
+		r.Dirs[i].Topics = make([]AlterReplicaLogDirsRequestTopic, m)
+		for j := 0; j < m; j++ {
+			if r.Dirs[i].Topics[j].Topic, err = pd.getString(); err != nil {
+				return err
+			}
+
+			if r.Dirs[i].Topics[j].PartitionIDs, err = pd.getInt32Array(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *AlterReplicaLogDirsRequest) key() int16 {
+	return 34
+}
+
+func (r *AlterReplicaLogDirsRequest) version() int16 {
+	return r.Version
+}
+
+func (r *AlterReplicaLogDirsRequest) headerVersion() int16 {
+	return 1
+}
+
+func (r *AlterReplicaLogDirsRequest) requiredVersion() KafkaVersion {
+	if r.Version > 0 {
+		return V2_0_0_0
+	}
+	return V1_0_0_0
+}
@@ -0,0 +1,33 @@
+package sarama
+
+import "testing"
+
+var alterReplicaLogDirsRequest = []byte{
+	0, 0, 0, 1, // Dirs array, Array length 1
+	0, 9, // Path length 9
+	'/', 'd', 'a', 't', 'a', '/', 'k', 'f', 'k', // Path
+	0, 0, 0, 1, // Topics array, Array length 1
+	0, 6, // Topic name length 6
+	'r', 'a', 'n', 'd', 'o', 'm', // Topic name
+	0, 0, 0, 2, // PartitionIDs int32 array, Array length 2
+	0, 0, 0, 25, // PartitionID 25
+	0, 0, 0, 26, // PartitionID 26
+}
+
+func TestAlterReplicaLogDirsRequest(t *testing.T) {
+	request := &AlterReplicaLogDirsRequest{
+		Version: 1,
+		Dirs: []AlterReplicaLogDirsRequestDir{
+			{
+				Path: "/data/kfk",
+				Topics: []AlterReplicaLogDirsRequestTopic{
+					{
+						Topic:        "random",
+						PartitionIDs: []int32{25, 26},
+					},
+				},
+			},
+		},
+	}
+	testRequest(t, "one dir", request, alterReplicaLogDirsRequest)
+}
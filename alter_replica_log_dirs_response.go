@@ -0,0 +1,112 @@
+package sarama
+
+import "time"
+
+// AlterReplicaLogDirsResponse is the response to an AlterReplicaLogDirsRequest
+type AlterReplicaLogDirsResponse struct {
+	ThrottleTime time.Duration
+
+	// Version 0 and 1 are equal
+	// The version number is bumped to indicate that on quota violation brokers send out responses before throttling.
+	Version int16
+
+	Results []AlterReplicaLogDirsResponseTopic
+}
+
+// AlterReplicaLogDirsResponseTopic holds the results for the partitions of a topic
+type AlterReplicaLogDirsResponseTopic struct {
+	Topic      string
+	Partitions []AlterReplicaLogDirsResponsePartition
+}
+
+// AlterReplicaLogDirsResponsePartition holds the result for a single partition
+type AlterReplicaLogDirsResponsePartition struct {
+	PartitionID int32
+	ErrorCode   KError
+}
+
+func (r *AlterReplicaLogDirsResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+
+	if err := pe.putArrayLength(len(r.Results)); err != nil {
+		return err
+	}
+
+	for _, t := range r.Results {
+		if err := pe.putString(t.Topic); err != nil {
+			return err
+		}
+
+		if err := pe.putArrayLength(len(t.Partitions)); err != nil {
+			return err
+		}
+
+		for _, p := range t.Partitions {
+			pe.putInt32(p.PartitionID)
+			pe.putInt16(int16(p.ErrorCode))
+		}
+	}
+
+	return nil
+}
+
+func (r *AlterReplicaLogDirsResponse) decode(pd packetDecoder, version int16) error {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+
+	r.Results = make([]AlterReplicaLogDirsResponseTopic, n)
+	for i := 0; i < n; i++ {
+		if r.Results[i].Topic, err = pd.getString(); err != nil {
+			return err
+		}
+
+		m, err := pd.getArrayLength()
+		if err != nil {
+			return err
+		}
+
+		r.Results[i].Partitions = make([]AlterReplicaLogDirsResponsePartition, m)
+		for j := 0; j < m; j++ {
+			if r.Results[i].Partitions[j].PartitionID, err = pd.getInt32(); err != nil {
+				return err
+			}
+
+			kerr, err := pd.getInt16()
+			if err != nil {
+				return err
+			}
+			r.Results[i].Partitions[j].ErrorCode = KError(kerr)
+		}
+	}
+
+	return nil
+}
+
+func (r *AlterReplicaLogDirsResponse) key() int16 {
+	return 34
+}
+
+func (r *AlterReplicaLogDirsResponse) version() int16 {
+	return r.Version
+}
+
+func (r *AlterReplicaLogDirsResponse) headerVersion() int16 {
+	return 0
+}
+
+func (r *AlterReplicaLogDirsResponse) requiredVersion() KafkaVersion {
+	if r.Version > 0 {
+		return V2_0_0_0
+	}
+	return V1_0_0_0
+}
@@ -0,0 +1,31 @@
+package sarama
+
+import "testing"
+
+var alterReplicaLogDirsResponse = []byte{
+	0, 0, 0, 0, // no throttle time
+	0, 0, 0, 1, // Results array, Array length 1
+	0, 6, // Topic name length 6
+	'r', 'a', 'n', 'd', 'o', 'm', // Topic name
+	0, 0, 0, 2, // Partitions array, Array length 2
+	0, 0, 0, 25, // PartitionID 25
+	0, 0, // no error
+	0, 0, 0, 26, // PartitionID 26
+	0, 57, // ErrLogDirNotFound
+}
+
+func TestAlterReplicaLogDirsResponse(t *testing.T) {
+	response := &AlterReplicaLogDirsResponse{
+		Version: 1,
+		Results: []AlterReplicaLogDirsResponseTopic{
+			{
+				Topic: "random",
+				Partitions: []AlterReplicaLogDirsResponsePartition{
+					{PartitionID: 25, ErrorCode: ErrNoError},
+					{PartitionID: 26, ErrorCode: ErrLogDirNotFound},
+				},
+			},
+		},
+	}
+	testResponse(t, "one topic", response, alterReplicaLogDirsResponse)
+}
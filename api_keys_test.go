@@ -0,0 +1,18 @@
+package sarama
+
+import "testing"
+
+func TestAPIKeyName(t *testing.T) {
+	for key, expected := range map[int16]string{
+		0:                              "Produce",
+		(&MetadataRequest{}).key():     "Metadata",
+		(&OffsetCommitRequest{}).key(): "OffsetCommit",
+		72:                             "PushTelemetry",
+		1000:                           "ApiKey1000",
+		-1:                             "ApiKey-1",
+	} {
+		if name := APIKeyName(key); name != expected {
+			t.Errorf("Expected %s for %d, got %s", expected, key, name)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package sarama
 
+import "encoding/binary"
+
 // ApiVersionsResponseKey contains the APIs supported by the broker.
 type ApiVersionsResponseKey struct {
 	// Version defines the protocol version to use for encode and decode
@@ -50,6 +52,32 @@ func (a *ApiVersionsResponseKey) decode(pd packetDecoder, version int16) (err er
 	return nil
 }
 
+// ApiVersionsResponseSupportedFeature contains a feature supported by the broker (KIP-584).
+type ApiVersionsResponseSupportedFeature struct {
+	// Name contains the name of the feature.
+	Name string
+	// MinVersion contains the minimum supported version for the feature.
+	MinVersion int16
+	// MaxVersion contains the maximum supported version for the feature.
+	MaxVersion int16
+}
+
+// ApiVersionsResponseFinalizedFeature contains a cluster-wide finalized feature (KIP-584).
+type ApiVersionsResponseFinalizedFeature struct {
+	// Name contains the name of the feature.
+	Name string
+	// MaxVersionLevel contains the cluster-wide finalized max version level for the feature.
+	MaxVersionLevel int16
+	// MinVersionLevel contains the cluster-wide finalized min version level for the feature.
+	MinVersionLevel int16
+}
+
+const (
+	apiVersionsSupportedFeaturesTag      = 0
+	apiVersionsFinalizedFeaturesEpochTag = 1
+	apiVersionsFinalizedFeaturesTag      = 2
+)
+
 type ApiVersionsResponse struct {
 	// Version defines the protocol version to use for encode and decode
 	Version int16
@@ -59,6 +87,12 @@ type ApiVersionsResponse struct {
 	ApiKeys []ApiVersionsResponseKey
 	// ThrottleTimeMs contains the duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
 	ThrottleTimeMs int32
+	// SupportedFeatures contains the features supported by the broker, requires v3+ and Kafka 2.7+.
+	SupportedFeatures []ApiVersionsResponseSupportedFeature
+	// FinalizedFeaturesEpoch contains the monotonically increasing epoch of the finalized features information, requires v3+ and Kafka 2.7+.
+	FinalizedFeaturesEpoch int64
+	// FinalizedFeatures contains the cluster-wide finalized features, requires v3+ and Kafka 2.7+.
+	FinalizedFeatures []ApiVersionsResponseFinalizedFeature
 }
 
 func (r *ApiVersionsResponse) encode(pe packetEncoder) (err error) {
@@ -82,9 +116,169 @@ func (r *ApiVersionsResponse) encode(pe packetEncoder) (err error) {
 	}
 
 	if r.Version >= 3 {
+		return r.encodeTaggedFields(pe)
+	}
+
+	return nil
+}
+
+// encodeTaggedFields encodes the KIP-584 features as tagged fields. The
+// finalized features epoch is only encoded along with the finalized features.
+func (r *ApiVersionsResponse) encodeTaggedFields(pe packetEncoder) error {
+	type taggedField struct {
+		tag   uint64
+		value []byte
+	}
+	var fields []taggedField
+
+	if r.SupportedFeatures != nil {
+		value, err := encode(apiVersionsSupportedFeatures(r.SupportedFeatures), nil)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, taggedField{apiVersionsSupportedFeaturesTag, value})
+	}
+	if r.FinalizedFeatures != nil {
+		epoch := make([]byte, 8)
+		binary.BigEndian.PutUint64(epoch, uint64(r.FinalizedFeaturesEpoch))
+		fields = append(fields, taggedField{apiVersionsFinalizedFeaturesEpochTag, epoch})
+
+		value, err := encode(apiVersionsFinalizedFeatures(r.FinalizedFeatures), nil)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, taggedField{apiVersionsFinalizedFeaturesTag, value})
+	}
+
+	pe.putUVarint(uint64(len(fields)))
+	for _, field := range fields {
+		pe.putUVarint(field.tag)
+		pe.putUVarint(uint64(len(field.value)))
+		if err := pe.putRawBytes(field.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ApiVersionsResponse) decodeTaggedFields(pd packetDecoder) error {
+	tagCount, err := pd.getUVarint()
+	if err != nil {
+		return err
+	}
+
+	for i := uint64(0); i < tagCount; i++ {
+		tag, err := pd.getUVarint()
+		if err != nil {
+			return err
+		}
+		length, err := pd.getUVarint()
+		if err != nil {
+			return err
+		}
+		field, err := pd.getSubset(int(length))
+		if err != nil {
+			return err
+		}
+
+		switch tag {
+		case apiVersionsSupportedFeaturesTag:
+			var features apiVersionsSupportedFeatures
+			if err := features.decode(field); err != nil {
+				return err
+			}
+			r.SupportedFeatures = features
+		case apiVersionsFinalizedFeaturesEpochTag:
+			if r.FinalizedFeaturesEpoch, err = field.getInt64(); err != nil {
+				return err
+			}
+		case apiVersionsFinalizedFeaturesTag:
+			var features apiVersionsFinalizedFeatures
+			if err := features.decode(field); err != nil {
+				return err
+			}
+			r.FinalizedFeatures = features
+		}
+	}
+
+	return nil
+}
+
+type apiVersionsSupportedFeatures []ApiVersionsResponseSupportedFeature
+
+func (f apiVersionsSupportedFeatures) encode(pe packetEncoder) error {
+	pe.putCompactArrayLength(len(f))
+	for _, feature := range f {
+		if err := pe.putCompactString(feature.Name); err != nil {
+			return err
+		}
+		pe.putInt16(feature.MinVersion)
+		pe.putInt16(feature.MaxVersion)
 		pe.putEmptyTaggedFieldArray()
 	}
+	return nil
+}
 
+func (f *apiVersionsSupportedFeatures) decode(pd packetDecoder) error {
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	*f = make(apiVersionsSupportedFeatures, n)
+	for i := range *f {
+		feature := &(*f)[i]
+		if feature.Name, err = pd.getCompactString(); err != nil {
+			return err
+		}
+		if feature.MinVersion, err = pd.getInt16(); err != nil {
+			return err
+		}
+		if feature.MaxVersion, err = pd.getInt16(); err != nil {
+			return err
+		}
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type apiVersionsFinalizedFeatures []ApiVersionsResponseFinalizedFeature
+
+func (f apiVersionsFinalizedFeatures) encode(pe packetEncoder) error {
+	pe.putCompactArrayLength(len(f))
+	for _, feature := range f {
+		if err := pe.putCompactString(feature.Name); err != nil {
+			return err
+		}
+		pe.putInt16(feature.MaxVersionLevel)
+		pe.putInt16(feature.MinVersionLevel)
+		pe.putEmptyTaggedFieldArray()
+	}
+	return nil
+}
+
+func (f *apiVersionsFinalizedFeatures) decode(pd packetDecoder) error {
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	*f = make(apiVersionsFinalizedFeatures, n)
+	for i := range *f {
+		feature := &(*f)[i]
+		if feature.Name, err = pd.getCompactString(); err != nil {
+			return err
+		}
+		if feature.MaxVersionLevel, err = pd.getInt16(); err != nil {
+			return err
+		}
+		if feature.MinVersionLevel, err = pd.getInt16(); err != nil {
+			return err
+		}
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -122,7 +316,7 @@ func (r *ApiVersionsResponse) decode(pd packetDecoder, version int16) (err error
 	}
 
 	if r.Version >= 3 {
-		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+		if err = r.decodeTaggedFields(pd); err != nil {
 			return err
 		}
 	}
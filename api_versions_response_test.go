@@ -21,6 +21,31 @@ var (
 		0x00, 0x00, 0x00, 0x00, // throttle time
 		0x01, 0x01, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // tagged fields (empty SupportedFeatures)
 	}
+
+	apiVersionResponseV3WithFeatures = []byte{
+		0x00, 0x00, // no error
+		0x02, // compact array length 1
+		0x00, 0x03,
+		0x00, 0x02,
+		0x00, 0x01,
+		0x00,                   // tagged fields
+		0x00, 0x00, 0x00, 0x00, // throttle time
+		0x03,       // 3 tagged fields
+		0x00, 0x17, // SupportedFeatures tag, length 23
+		0x02, // compact array length 1
+		0x11, 'm', 'e', 't', 'a', 'd', 'a', 't', 'a', '.', 'v', 'e', 'r', 's', 'i', 'o', 'n',
+		0x00, 0x01, // MinVersion
+		0x00, 0x05, // MaxVersion
+		0x00,       // tagged fields
+		0x01, 0x08, // FinalizedFeaturesEpoch tag, length 8
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x07,
+		0x02, 0x17, // FinalizedFeatures tag, length 23
+		0x02, // compact array length 1
+		0x11, 'm', 'e', 't', 'a', 'd', 'a', 't', 'a', '.', 'v', 'e', 'r', 's', 'i', 'o', 'n',
+		0x00, 0x04, // MaxVersionLevel
+		0x00, 0x01, // MinVersionLevel
+		0x00, // tagged fields
+	}
 )
 
 func TestApiVersionsResponse(t *testing.T) {
@@ -57,3 +82,32 @@ func TestApiVersionsResponseV3(t *testing.T) {
 		t.Error("Decoding error: expected 0x01 but got", response.ApiKeys[0].MaxVersion)
 	}
 }
+
+func TestApiVersionsResponseV3WithFeatures(t *testing.T) {
+	response := &ApiVersionsResponse{
+		Version: 3,
+		ApiKeys: []ApiVersionsResponseKey{
+			{Version: 3, ApiKey: 0x03, MinVersion: 0x02, MaxVersion: 0x01},
+		},
+		SupportedFeatures: []ApiVersionsResponseSupportedFeature{
+			{Name: "metadata.version", MinVersion: 1, MaxVersion: 5},
+		},
+		FinalizedFeaturesEpoch: 7,
+		FinalizedFeatures: []ApiVersionsResponseFinalizedFeature{
+			{Name: "metadata.version", MaxVersionLevel: 4, MinVersionLevel: 1},
+		},
+	}
+	testResponse(t, "with features", response, apiVersionResponseV3WithFeatures)
+
+	decoded := new(ApiVersionsResponse)
+	testVersionDecodable(t, "with features", decoded, apiVersionResponseV3WithFeatures, 3)
+	if len(decoded.SupportedFeatures) != 1 || decoded.SupportedFeatures[0] != response.SupportedFeatures[0] {
+		t.Error("Decoding error: unexpected supported features", decoded.SupportedFeatures)
+	}
+	if decoded.FinalizedFeaturesEpoch != 7 {
+		t.Error("Decoding error: expected finalized features epoch 7 but got", decoded.FinalizedFeaturesEpoch)
+	}
+	if len(decoded.FinalizedFeatures) != 1 || decoded.FinalizedFeatures[0] != response.FinalizedFeatures[0] {
+		t.Error("Decoding error: unexpected finalized features", decoded.FinalizedFeatures)
+	}
+}
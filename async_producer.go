@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/eapache/go-resiliency/breaker"
@@ -47,71 +48,74 @@ type AsyncProducer interface {
 	// you can set Producer.Return.Errors in your config to false, which prevents
 	// errors to be returned.
 	Errors() <-chan *ProducerError
-}
-
-// transactionManager keeps the state necessary to ensure idempotent production
-type transactionManager struct {
-	producerID      int64
-	producerEpoch   int16
-	sequenceNumbers map[string]int32
-	mutex           sync.Mutex
-}
-
-const (
-	noProducerID    = -1
-	noProducerEpoch = -1
-)
 
-func (t *transactionManager) getAndIncrementSequenceNumber(topic string, partition int32) (int32, int16) {
-	key := fmt.Sprintf("%s-%d", topic, partition)
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
-	sequence := t.sequenceNumbers[key]
-	t.sequenceNumbers[key] = sequence + 1
-	return sequence, t.producerEpoch
-}
-
-func (t *transactionManager) bumpEpoch() {
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
-	t.producerEpoch++
-	for k := range t.sequenceNumbers {
-		t.sequenceNumbers[k] = 0
-	}
-}
-
-func (t *transactionManager) getProducerID() (int64, int16) {
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
-	return t.producerID, t.producerEpoch
-}
-
-func newTransactionManager(conf *Config, client Client) (*transactionManager, error) {
-	txnmgr := &transactionManager{
-		producerID:    noProducerID,
-		producerEpoch: noProducerEpoch,
-	}
-
-	if conf.Producer.Idempotent {
-		initProducerIDResponse, err := client.InitProducerID()
-		if err != nil {
-			return nil, err
-		}
-		txnmgr.producerID = initProducerIDResponse.ProducerID
-		txnmgr.producerEpoch = initProducerIDResponse.ProducerEpoch
-		txnmgr.sequenceNumbers = make(map[string]int32)
-		txnmgr.mutex = sync.Mutex{}
-
-		Logger.Printf("Obtained a ProducerId: %d and ProducerEpoch: %d\n", txnmgr.producerID, txnmgr.producerEpoch)
-	}
-
-	return txnmgr, nil
+	// IsTransactional returns whether the producer is transactional, see
+	// Producer.Transaction.ID.
+	IsTransactional() bool
+
+	// TxnStatus returns the status of the transactions of the producer,
+	// TxnUninitialized if it is not transactional. See also
+	// Producer.Transaction.TransitionHook to be notified of its changes.
+	TxnStatus() TxnStatus
+
+	// BeginTxn begins a transaction, which the messages sent to Input are
+	// produced in until it is committed or aborted. The messages of a
+	// transactional producer sent outside of a transaction fail with
+	// ErrTransactionNotReady.
+	BeginTxn() error
+
+	// CommitTxn waits for the messages of the transaction to be acknowledged
+	// and commits it, so that its messages become visible to the consumers
+	// reading committed messages and the offsets added to it are committed.
+	// The Successes and Errors channels must be read while it waits. If a
+	// message of the transaction or its commit failed, the error wraps
+	// ErrTxnAbortable and the transaction must be aborted. If the
+	// producer was fenced by another one with the same transactional ID, the
+	// error wraps ErrTxnFatal and the producer must be closed.
+	CommitTxn() error
+
+	// AbortTxn waits for the messages of the transaction to be acknowledged
+	// or to fail and aborts it, discarding its messages and offsets.
+	AbortTxn() error
+
+	// AddOffsetsToTxn adds the offsets of the messages consumed by the
+	// consumer group to the transaction, so that they are committed with
+	// it. The offsets are those of the next messages to consume.
+	AddOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, groupID string) error
+
+	// AddGroupOffsetsToTxn adds the offsets consumed by the member of the
+	// consumer group to the transaction like AddOffsetsToTxn, the
+	// transaction coordinator rejecting them if the member was fenced by a
+	// rebalance since (KIP-447, requires Version >= V2_5_0_0).
+	AddGroupOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, group *ConsumerGroupMetadata) error
+
+	// AddMessageToTxn adds the offset following the consumed message to the
+	// transaction, see AddOffsetsToTxn.
+	AddMessageToTxn(msg *ConsumerMessage, groupID string, metadata *string) error
+
+	// PrepareTxn waits for the messages of the transaction to be
+	// acknowledged and prepares it for a two-phase commit (KIP-939,
+	// requires Producer.Transaction.TwoPhaseCommit.Enable). The returned
+	// state must be recorded by the external transaction coordinator, such
+	// as in a database transaction, and the transaction completed with
+	// CompleteTxn. No messages nor offsets can be added to a prepared
+	// transaction.
+	PrepareTxn() (PreparedTxnState, error)
+
+	// CompleteTxn commits the prepared transaction if state is its state,
+	// and aborts it otherwise, such as when the external transaction
+	// coordinator did not record it. It also completes the transaction
+	// prepared by the previous producer with the same transactional ID if
+	// kept with Producer.Transaction.TwoPhaseCommit.KeepPreparedTxn.
+	CompleteTxn(state PreparedTxnState) error
 }
 
 type asyncProducer struct {
 	client Client
 	conf   *Config
 
+	queued int64 // the messages in flight, see ProducerStats
+
 	errors                    chan *ProducerError
 	input, successes, retries chan *ProducerMessage
 	inFlight                  sync.WaitGroup
@@ -121,6 +125,8 @@ type asyncProducer struct {
 	brokerLock sync.Mutex
 
 	txnmgr *transactionManager
+
+	compression *compressionPool
 }
 
 // NewAsyncProducer creates a new AsyncProducer using the given broker addresses and configuration.
@@ -163,6 +169,11 @@ func newAsyncProducer(client Client) (AsyncProducer, error) {
 		brokerRefs: make(map[*brokerProducer]int),
 		txnmgr:     txnmgr,
 	}
+	if p.conf.Producer.CompressionWorkers > 0 {
+		p.compression = newCompressionPool(p.conf.Producer.CompressionWorkers)
+	}
+
+	registerStats(client, p)
 
 	// launch our singleton dispatchers
 	go withRecover(p.dispatcher)
@@ -177,6 +188,7 @@ const (
 	syn      flagSet = 1 << iota // first message from partitionProducer to brokerProducer
 	fin                          // final message from partitionProducer to brokerProducer and back
 	shutdown                     // start the shutdown process
+	flush                        // signals that the messages sent before are accounted for by the transaction
 )
 
 // ProducerMessage is the collection of elements passed to the Producer in order to send a message.
@@ -227,6 +239,11 @@ type ProducerMessage struct {
 	sequenceNumber int32
 	producerEpoch  int16
 	hasSequence    bool
+	// enqueued is when the message entered the producer, for the
+	// produce-latency-in-ms metric
+	enqueued time.Time
+	// the span of the message with Config.Tracing
+	span Span
 }
 
 const producerMessageOverhead = 26 // the metadata overhead of CRC, flags, etc.
@@ -323,6 +340,88 @@ func (p *asyncProducer) AsyncClose() {
 	go withRecover(p.shutdown)
 }
 
+func (p *asyncProducer) IsTransactional() bool {
+	return p.txnmgr.isTransactional()
+}
+
+func (p *asyncProducer) TxnStatus() TxnStatus {
+	status, _ := p.txnmgr.txnStatus()
+	return status
+}
+
+func (p *asyncProducer) BeginTxn() error {
+	if !p.IsTransactional() {
+		return ErrNonTransactedProducer
+	}
+	return p.txnmgr.beginTxn()
+}
+
+func (p *asyncProducer) CommitTxn() error {
+	return p.endTxn(true)
+}
+
+func (p *asyncProducer) AbortTxn() error {
+	return p.endTxn(false)
+}
+
+func (p *asyncProducer) endTxn(commit bool) error {
+	if !p.IsTransactional() {
+		return ErrNonTransactedProducer
+	}
+
+	p.flushInput()
+	if err := p.txnmgr.prepareEndTxn(commit); err != nil {
+		return err
+	}
+	return p.txnmgr.endTxn(commit)
+}
+
+// flushInput waits for the dispatcher to account for the messages sent
+// before to the transaction, which rejects them once it ends or is prepared
+func (p *asyncProducer) flushInput() {
+	flushed := make(chan *ProducerError)
+	p.input <- &ProducerMessage{flags: flush, expectation: flushed}
+	<-flushed
+}
+
+func (p *asyncProducer) PrepareTxn() (PreparedTxnState, error) {
+	if !p.IsTransactional() {
+		return PreparedTxnState{}, ErrNonTransactedProducer
+	}
+	p.flushInput()
+	return p.txnmgr.prepareTxn()
+}
+
+func (p *asyncProducer) CompleteTxn(state PreparedTxnState) error {
+	if !p.IsTransactional() {
+		return ErrNonTransactedProducer
+	}
+	return p.txnmgr.completeTxn(state)
+}
+
+func (p *asyncProducer) AddOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, groupID string) error {
+	return p.AddGroupOffsetsToTxn(offsets, &ConsumerGroupMetadata{GroupID: groupID})
+}
+
+func (p *asyncProducer) AddGroupOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, group *ConsumerGroupMetadata) error {
+	if !p.IsTransactional() {
+		return ErrNonTransactedProducer
+	}
+	return p.txnmgr.addOffsetsToTxn(offsets, group)
+}
+
+func (p *asyncProducer) AddMessageToTxn(msg *ConsumerMessage, groupID string, metadata *string) error {
+	offsets := map[string][]*PartitionOffsetMetadata{
+		msg.Topic: {{
+			Partition:   msg.Partition,
+			Offset:      msg.Offset + 1,
+			LeaderEpoch: -1,
+			Metadata:    metadata,
+		}},
+	}
+	return p.AddOffsetsToTxn(offsets, groupID)
+}
+
 // singleton
 // dispatches messages by topic
 func (p *asyncProducer) dispatcher() {
@@ -331,7 +430,12 @@ func (p *asyncProducer) dispatcher() {
 
 	for msg := range p.input {
 		if msg == nil {
-			Logger.Println("Something tried to send a nil message, it was ignored.")
+			newContextLogger().Warnf("Something tried to send a nil message, it was ignored.")
+			continue
+		}
+
+		if msg.flags&flush != 0 {
+			close(msg.expectation)
 			continue
 		}
 
@@ -341,22 +445,22 @@ func (p *asyncProducer) dispatcher() {
 			continue
 		} else if msg.retries == 0 {
 			if shuttingDown {
-				// we can't just call returnError here because that decrements the wait group,
-				// which hasn't been incremented yet for this message, and shouldn't be
-				pErr := &ProducerError{Msg: msg, Err: ErrShuttingDown}
-				if p.conf.Producer.Return.Errors {
-					p.errors <- pErr
-				} else {
-					Logger.Println(pErr)
-				}
+				p.rejectMessage(msg, ErrShuttingDown)
+				continue
+			}
+			if err := p.txnmgr.addMessage(); err != nil {
+				p.rejectMessage(msg, err)
 				continue
 			}
 			p.inFlight.Add(1)
+			atomic.AddInt64(&p.queued, 1)
+			msg.enqueued = p.conf.Clock.Now()
 		}
 
 		for _, interceptor := range p.conf.Producer.Interceptors {
 			msg.safelyApplyInterceptor(interceptor)
 		}
+		msg.startSpan(p.conf)
 
 		version := 1
 		if p.conf.Version.IsAtLeast(V0_11_0_0) {
@@ -365,7 +469,7 @@ func (p *asyncProducer) dispatcher() {
 			p.returnError(msg, ConfigurationError("Producing headers requires Kafka at least v0.11"))
 			continue
 		}
-		if msg.byteSize(version) > p.conf.Producer.MaxMessageBytes {
+		if msg.byteSize(version) > p.conf.producerMaxMessageBytes(msg.Topic) {
 			p.returnError(msg, ErrMessageSizeTooLarge)
 			continue
 		}
@@ -384,6 +488,18 @@ func (p *asyncProducer) dispatcher() {
 	}
 }
 
+// rejectMessage returns an error for a message entering the producer
+func (p *asyncProducer) rejectMessage(msg *ProducerMessage, err error) {
+	// we can't just call returnError here because that decrements the wait group,
+	// which hasn't been incremented yet for this message, and shouldn't be
+	pErr := &ProducerError{Msg: msg, Err: err}
+	if p.conf.Producer.Return.Errors {
+		p.errors <- pErr
+	} else {
+		newContextLogger("topic", msg.Topic).Errorf("%v", pErr)
+	}
+}
+
 // one per topic
 // partitions messages, then dispatches them by partition
 type topicProducer struct {
@@ -482,6 +598,7 @@ type partitionProducer struct {
 	topic     string
 	partition int32
 	input     <-chan *ProducerMessage
+	log       contextLogger
 
 	leader         *Broker
 	breaker        *breaker.Breaker
@@ -507,6 +624,7 @@ func (p *asyncProducer) newPartitionProducer(topic string, partition int32) chan
 		topic:     topic,
 		partition: partition,
 		input:     input,
+		log:       newContextLogger("topic", topic, "partition", partition),
 
 		breaker:    breaker.New(3, 1, 10*time.Second),
 		retryState: make([]partitionRetryState, p.conf.Producer.Retry.Max+1),
@@ -524,7 +642,7 @@ func (pp *partitionProducer) backoff(retries int) {
 		backoff = pp.parent.conf.Producer.Retry.Backoff
 	}
 	if backoff > 0 {
-		time.Sleep(backoff)
+		pp.parent.conf.Clock.Sleep(backoff)
 	}
 }
 
@@ -549,10 +667,10 @@ func (pp *partitionProducer) dispatch() {
 			select {
 			case <-pp.brokerProducer.abandoned:
 				// a message on the abandoned channel means that our current broker selection is out of date
-				Logger.Printf("producer/leader/%s/%d abandoning broker %d\n", pp.topic, pp.partition, pp.leader.ID())
+				pp.log.with("broker", pp.leader.ID()).Infof("producer/leader/%s/%d abandoning broker %d", pp.topic, pp.partition, pp.leader.ID())
 				pp.parent.unrefBrokerProducer(pp.leader, pp.brokerProducer)
 				pp.brokerProducer = nil
-				time.Sleep(pp.parent.conf.Producer.Retry.Backoff)
+				pp.parent.conf.Clock.Sleep(pp.parent.conf.Producer.Retry.Backoff)
 			default:
 				// producer connection is still open.
 			}
@@ -592,7 +710,7 @@ func (pp *partitionProducer) dispatch() {
 				pp.backoff(msg.retries)
 				continue
 			}
-			Logger.Printf("producer/leader/%s/%d selected broker %d\n", pp.topic, pp.partition, pp.leader.ID())
+			pp.log.with("broker", pp.leader.ID()).Infof("producer/leader/%s/%d selected broker %d", pp.topic, pp.partition, pp.leader.ID())
 		}
 
 		// Now that we know we have a broker to actually try and send this message to, generate the sequence
@@ -609,7 +727,7 @@ func (pp *partitionProducer) dispatch() {
 }
 
 func (pp *partitionProducer) newHighWatermark(hwm int) {
-	Logger.Printf("producer/leader/%s/%d state change to [retrying-%d]\n", pp.topic, pp.partition, hwm)
+	pp.log.Infof("producer/leader/%s/%d state change to [retrying-%d]", pp.topic, pp.partition, hwm)
 	pp.highWatermark = hwm
 
 	// send off a fin so that we know when everything "in between" has made it
@@ -619,13 +737,13 @@ func (pp *partitionProducer) newHighWatermark(hwm int) {
 	pp.brokerProducer.input <- &ProducerMessage{Topic: pp.topic, Partition: pp.partition, flags: fin, retries: pp.highWatermark - 1}
 
 	// a new HWM means that our current broker selection is out of date
-	Logger.Printf("producer/leader/%s/%d abandoning broker %d\n", pp.topic, pp.partition, pp.leader.ID())
+	pp.log.with("broker", pp.leader.ID()).Infof("producer/leader/%s/%d abandoning broker %d", pp.topic, pp.partition, pp.leader.ID())
 	pp.parent.unrefBrokerProducer(pp.leader, pp.brokerProducer)
 	pp.brokerProducer = nil
 }
 
 func (pp *partitionProducer) flushRetryBuffers() {
-	Logger.Printf("producer/leader/%s/%d state change to [flushing-%d]\n", pp.topic, pp.partition, pp.highWatermark)
+	pp.log.Infof("producer/leader/%s/%d state change to [flushing-%d]", pp.topic, pp.partition, pp.highWatermark)
 	for {
 		pp.highWatermark--
 
@@ -634,7 +752,7 @@ func (pp *partitionProducer) flushRetryBuffers() {
 				pp.parent.returnErrors(pp.retryState[pp.highWatermark].buf, err)
 				goto flushDone
 			}
-			Logger.Printf("producer/leader/%s/%d selected broker %d\n", pp.topic, pp.partition, pp.leader.ID())
+			pp.log.with("broker", pp.leader.ID()).Infof("producer/leader/%s/%d selected broker %d", pp.topic, pp.partition, pp.leader.ID())
 		}
 
 		for _, msg := range pp.retryState[pp.highWatermark].buf {
@@ -644,10 +762,10 @@ func (pp *partitionProducer) flushRetryBuffers() {
 	flushDone:
 		pp.retryState[pp.highWatermark].buf = nil
 		if pp.retryState[pp.highWatermark].expectChaser {
-			Logger.Printf("producer/leader/%s/%d state change to [retrying-%d]\n", pp.topic, pp.partition, pp.highWatermark)
+			pp.log.Infof("producer/leader/%s/%d state change to [retrying-%d]", pp.topic, pp.partition, pp.highWatermark)
 			break
 		} else if pp.highWatermark == 0 {
-			Logger.Printf("producer/leader/%s/%d state change to [normal]\n", pp.topic, pp.partition)
+			pp.log.Infof("producer/leader/%s/%d state change to [normal]", pp.topic, pp.partition)
 			break
 		}
 	}
@@ -683,6 +801,7 @@ func (p *asyncProducer) newBrokerProducer(broker *Broker) *brokerProducer {
 	bp := &brokerProducer{
 		parent:         p,
 		broker:         broker,
+		log:            newContextLogger("broker", broker.ID()),
 		input:          input,
 		output:         bridge,
 		responses:      responses,
@@ -696,37 +815,50 @@ func (p *asyncProducer) newBrokerProducer(broker *Broker) *brokerProducer {
 		// Use a wait group to know if we still have in flight requests
 		var wg sync.WaitGroup
 
-		for set := range bridge {
-			request := set.buildRequest()
-
-			// Count the in flight requests to know when we can close the pending channel safely
-			wg.Add(1)
-			// Capture the current set to forward in the callback
-			sendResponse := func(set *produceSet) ProduceCallback {
-				return func(response *ProduceResponse, err error) {
-					// Forward the response to make sure we do not block the responseReceiver
-					pending <- &brokerProducerResponse{
-						set: set,
-						err: err,
-						res: response,
-					}
-					wg.Done()
-				}
-			}(set)
-
-			// Use AsyncProduce vs Produce to not block waiting for the response
-			// so that we can pipeline multiple produce requests and achieve higher throughput, see:
-			// https://kafka.apache.org/protocol#protocol_network
-			err := broker.AsyncProduce(request, sendResponse)
-			if err != nil {
-				// Request failed to be sent
-				sendResponse(nil, err)
+		for flushed := range bridge {
+			if err := p.txnmgr.addPartitionsToTxn(flushed); err != nil {
+				// the messages cannot be produced in the transaction
+				pending <- &brokerProducerResponse{set: flushed, err: err}
 				continue
 			}
-			// Callback is not called when using NoResponse
-			if p.conf.Producer.RequiredAcks == NoResponse {
-				// Provide the expected nil response
-				sendResponse(nil, nil)
+
+			// the messages of the topics requiring different acks are sent
+			// in separate requests
+			for _, set := range flushed.splitByRequiredAcks() {
+				request := set.buildRequest()
+				if p.compression != nil {
+					p.compression.compress(request)
+				}
+
+				// Count the in flight requests to know when we can close the pending channel safely
+				wg.Add(1)
+				// Capture the current set to forward in the callback
+				sendResponse := func(set *produceSet) ProduceCallback {
+					return func(response *ProduceResponse, err error) {
+						// Forward the response to make sure we do not block the responseReceiver
+						pending <- &brokerProducerResponse{
+							set: set,
+							err: err,
+							res: response,
+						}
+						wg.Done()
+					}
+				}(set)
+
+				// Use AsyncProduce vs Produce to not block waiting for the response
+				// so that we can pipeline multiple produce requests and achieve higher throughput, see:
+				// https://kafka.apache.org/protocol#protocol_network
+				err := broker.AsyncProduce(request, sendResponse)
+				if err != nil {
+					// Request failed to be sent
+					sendResponse(nil, err)
+					continue
+				}
+				// Callback is not called when using NoResponse
+				if request.RequiredAcks == NoResponse {
+					// Provide the expected nil response
+					sendResponse(nil, nil)
+				}
 			}
 		}
 		// Wait for all in flight requests to close the pending channel safely
@@ -785,6 +917,7 @@ type brokerProducerResponse struct {
 type brokerProducer struct {
 	parent *asyncProducer
 	broker *Broker
+	log    contextLogger
 
 	input     chan *ProducerMessage
 	output    chan<- *produceSet
@@ -801,13 +934,13 @@ type brokerProducer struct {
 
 func (bp *brokerProducer) run() {
 	var output chan<- *produceSet
-	Logger.Printf("producer/broker/%d starting up\n", bp.broker.ID())
+	bp.log.Infof("producer/broker/%d starting up", bp.broker.ID())
 
 	for {
 		select {
 		case msg, ok := <-bp.input:
 			if !ok {
-				Logger.Printf("producer/broker/%d input chan closed\n", bp.broker.ID())
+				bp.log.Infof("producer/broker/%d input chan closed", bp.broker.ID())
 				bp.shutdown()
 				return
 			}
@@ -817,7 +950,7 @@ func (bp *brokerProducer) run() {
 			}
 
 			if msg.flags&syn == syn {
-				Logger.Printf("producer/broker/%d state change to [open] on %s/%d\n",
+				bp.log.with("topic", msg.Topic, "partition", msg.Partition).Infof("producer/broker/%d state change to [open] on %s/%d",
 					bp.broker.ID(), msg.Topic, msg.Partition)
 				if bp.currentRetries[msg.Topic] == nil {
 					bp.currentRetries[msg.Topic] = make(map[int32]error)
@@ -833,7 +966,7 @@ func (bp *brokerProducer) run() {
 				if bp.closing == nil && msg.flags&fin == fin {
 					// we were retrying this partition but we can start processing again
 					delete(bp.currentRetries[msg.Topic], msg.Partition)
-					Logger.Printf("producer/broker/%d state change to [closed] on %s/%d\n",
+					bp.log.with("topic", msg.Topic, "partition", msg.Partition).Infof("producer/broker/%d state change to [closed] on %s/%d",
 						bp.broker.ID(), msg.Topic, msg.Partition)
 				}
 
@@ -841,7 +974,7 @@ func (bp *brokerProducer) run() {
 			}
 
 			if bp.buffer.wouldOverflow(msg) {
-				Logger.Printf("producer/broker/%d maximum request accumulated, waiting for space\n", bp.broker.ID())
+				bp.log.Debugf("producer/broker/%d maximum request accumulated, waiting for space", bp.broker.ID())
 				if err := bp.waitForSpace(msg, false); err != nil {
 					bp.parent.retryMessage(msg, err)
 					continue
@@ -850,7 +983,7 @@ func (bp *brokerProducer) run() {
 
 			if bp.parent.txnmgr.producerID != noProducerID && bp.buffer.producerEpoch != msg.producerEpoch {
 				// The epoch was reset, need to roll the buffer over
-				Logger.Printf("producer/broker/%d detected epoch rollover, waiting for new buffer\n", bp.broker.ID())
+				bp.log.Infof("producer/broker/%d detected epoch rollover, waiting for new buffer", bp.broker.ID())
 				if err := bp.waitForSpace(msg, true); err != nil {
 					bp.parent.retryMessage(msg, err)
 					continue
@@ -862,7 +995,7 @@ func (bp *brokerProducer) run() {
 			}
 
 			if bp.parent.conf.Producer.Flush.Frequency > 0 && bp.timer == nil {
-				bp.timer = time.After(bp.parent.conf.Producer.Flush.Frequency)
+				bp.timer = bp.parent.conf.Clock.After(bp.parent.conf.Producer.Flush.Frequency)
 			}
 		case <-bp.timer:
 			bp.timerFired = true
@@ -897,7 +1030,7 @@ func (bp *brokerProducer) shutdown() {
 		bp.handleResponse(response)
 	}
 	// No more brokerProducer related goroutine should be running
-	Logger.Printf("producer/broker/%d shut down\n", bp.broker.ID())
+	bp.log.Infof("producer/broker/%d shut down", bp.broker.ID())
 }
 
 func (bp *brokerProducer) needsRetry(msg *ProducerMessage) error {
@@ -981,7 +1114,7 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 			ErrRequestTimedOut, ErrNotEnoughReplicas, ErrNotEnoughReplicasAfterAppend:
 			if bp.parent.conf.Producer.Retry.Max <= 0 {
 				bp.parent.abandonBrokerConnection(bp.broker)
-				bp.parent.returnErrors(pSet.msgs, block.Err)
+				bp.parent.returnErrors(pSet.msgs, bp.partitionError(topic, partition, response, block.Err))
 			} else {
 				retryTopics = append(retryTopics, topic)
 			}
@@ -990,7 +1123,7 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 			if bp.parent.conf.Producer.Retry.Max <= 0 {
 				bp.parent.abandonBrokerConnection(bp.broker)
 			}
-			bp.parent.returnErrors(pSet.msgs, block.Err)
+			bp.parent.returnErrors(pSet.msgs, bp.partitionError(topic, partition, response, block.Err))
 		}
 	})
 
@@ -998,7 +1131,7 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 		if bp.parent.conf.Producer.Idempotent {
 			err := bp.parent.client.RefreshMetadata(retryTopics...)
 			if err != nil {
-				Logger.Printf("Failed refreshing metadata because of %v\n", err)
+				bp.log.Warnf("Failed refreshing metadata because of %v", err)
 			}
 		}
 
@@ -1012,26 +1145,39 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 			switch block.Err {
 			case ErrInvalidMessage, ErrUnknownTopicOrPartition, ErrLeaderNotAvailable, ErrNotLeaderForPartition,
 				ErrRequestTimedOut, ErrNotEnoughReplicas, ErrNotEnoughReplicasAfterAppend:
-				Logger.Printf("producer/broker/%d state change to [retrying] on %s/%d because %v\n",
+				bp.log.with("topic", topic, "partition", partition).Warnf("producer/broker/%d state change to [retrying] on %s/%d because %v",
 					bp.broker.ID(), topic, partition, block.Err)
 				if bp.currentRetries[topic] == nil {
 					bp.currentRetries[topic] = make(map[int32]error)
 				}
-				bp.currentRetries[topic][partition] = block.Err
+				err := bp.partitionError(topic, partition, response, block.Err)
+				bp.currentRetries[topic][partition] = err
 				if bp.parent.conf.Producer.Idempotent {
-					go bp.parent.retryBatch(topic, partition, pSet, block.Err)
+					go bp.parent.retryBatch(topic, partition, pSet, err)
 				} else {
-					bp.parent.retryMessages(pSet.msgs, block.Err)
+					bp.parent.retryMessages(pSet.msgs, err)
 				}
 				// dropping the following messages has the side effect of incrementing their retry count
-				bp.parent.retryMessages(bp.buffer.dropPartition(topic, partition), block.Err)
+				bp.parent.retryMessages(bp.buffer.dropPartition(topic, partition), err)
 			}
 		})
 	}
 }
 
-func (p *asyncProducer) retryBatch(topic string, partition int32, pSet *partitionSet, kerr KError) {
-	Logger.Printf("Retrying batch for %v-%d because of %s\n", topic, partition, kerr)
+// partitionError attaches the partition, the broker and the request to the
+// error of a block of the response
+func (bp *brokerProducer) partitionError(topic string, partition int32, response *ProduceResponse, kerr KError) error {
+	return &PartitionRequestError{
+		Topic:         topic,
+		Partition:     partition,
+		BrokerID:      bp.broker.ID(),
+		CorrelationID: response.correlationID,
+		Err:           kerr,
+	}
+}
+
+func (p *asyncProducer) retryBatch(topic string, partition int32, pSet *partitionSet, blockErr error) {
+	newContextLogger("topic", topic, "partition", partition).Warnf("Retrying batch for %v-%d because of %v", topic, partition, blockErr)
 	produceSet := newProduceSet(p)
 	produceSet.msgs[topic] = make(map[int32]*partitionSet)
 	produceSet.msgs[topic][partition] = pSet
@@ -1039,7 +1185,7 @@ func (p *asyncProducer) retryBatch(topic string, partition int32, pSet *partitio
 	produceSet.bufferCount += len(pSet.msgs)
 	for _, msg := range pSet.msgs {
 		if msg.retries >= p.conf.Producer.Retry.Max {
-			p.returnError(msg, kerr)
+			p.returnError(msg, blockErr)
 			return
 		}
 		msg.retries++
@@ -1048,9 +1194,9 @@ func (p *asyncProducer) retryBatch(topic string, partition int32, pSet *partitio
 	// it's expected that a metadata refresh has been requested prior to calling retryBatch
 	leader, err := p.client.Leader(topic, partition)
 	if err != nil {
-		Logger.Printf("Failed retrying batch for %v-%d because of %v while looking up for new leader\n", topic, partition, err)
+		newContextLogger("topic", topic, "partition", partition).Warnf("Failed retrying batch for %v-%d because of %v while looking up for new leader", topic, partition, err)
 		for _, msg := range pSet.msgs {
-			p.returnError(msg, kerr)
+			p.returnError(msg, blockErr)
 		}
 		return
 	}
@@ -1060,12 +1206,12 @@ func (p *asyncProducer) retryBatch(topic string, partition int32, pSet *partitio
 
 func (bp *brokerProducer) handleError(sent *produceSet, err error) {
 	var target PacketEncodingError
-	if errors.As(err, &target) {
+	if errors.As(err, &target) || errors.Is(err, ErrAddPartitionsToTxn) {
 		sent.eachPartition(func(topic string, partition int32, pSet *partitionSet) {
 			bp.parent.returnErrors(pSet.msgs, err)
 		})
 	} else {
-		Logger.Printf("producer/broker/%d state change to [closing] because %s\n", bp.broker.ID(), err)
+		bp.log.Warnf("producer/broker/%d state change to [closing] because %s", bp.broker.ID(), err)
 		bp.parent.abandonBrokerConnection(bp.broker)
 		_ = bp.broker.Close()
 		bp.closing = err
@@ -1109,15 +1255,21 @@ func (p *asyncProducer) retryHandler() {
 // utility functions
 
 func (p *asyncProducer) shutdown() {
-	Logger.Println("Producer shutting down.")
+	newContextLogger().Infof("Producer shutting down.")
 	p.inFlight.Add(1)
 	p.input <- &ProducerMessage{flags: shutdown}
 
 	p.inFlight.Wait()
+	unregisterStats(p.client, p)
+	p.txnmgr.close()
+
+	if p.compression != nil {
+		p.compression.close()
+	}
 
 	err := p.client.Close()
 	if err != nil {
-		Logger.Println("producer/shutdown failed to close the embedded client:", err)
+		newContextLogger().Warnf("producer/shutdown failed to close the embedded client: %v", err)
 	}
 
 	close(p.input)
@@ -1127,19 +1279,24 @@ func (p *asyncProducer) shutdown() {
 }
 
 func (p *asyncProducer) returnError(msg *ProducerMessage, err error) {
+	p.txnmgr.messageDone(msg, err)
+
 	// We need to reset the producer ID epoch if we set a sequence number on it, because the broker
 	// will never see a message with this number, so we can never continue the sequence.
-	if msg.hasSequence {
-		Logger.Printf("producer/txnmanager rolling over epoch due to publish failure on %s/%d", msg.Topic, msg.Partition)
+	// A transactional producer does so once the transaction is aborted instead.
+	if msg.hasSequence && !p.txnmgr.isTransactional() {
+		newContextLogger("topic", msg.Topic, "partition", msg.Partition).Warnf("producer/txnmanager rolling over epoch due to publish failure on %s/%d", msg.Topic, msg.Partition)
 		p.txnmgr.bumpEpoch()
 	}
 	msg.clear()
+	msg.endSpan(err)
 	pErr := &ProducerError{Msg: msg, Err: err}
 	if p.conf.Producer.Return.Errors {
 		p.errors <- pErr
 	} else {
-		Logger.Println(pErr)
+		newContextLogger("topic", msg.Topic, "partition", msg.Partition).Errorf("%v", pErr)
 	}
+	atomic.AddInt64(&p.queued, -1)
 	p.inFlight.Done()
 }
 
@@ -1150,15 +1307,41 @@ func (p *asyncProducer) returnErrors(batch []*ProducerMessage, err error) {
 }
 
 func (p *asyncProducer) returnSuccesses(batch []*ProducerMessage) {
+	p.recordLatencies(batch)
 	for _, msg := range batch {
+		p.txnmgr.messageDone(msg, nil)
+		msg.endSpan(nil)
 		if p.conf.Producer.Return.Successes {
 			msg.clear()
 			p.successes <- msg
 		}
+		atomic.AddInt64(&p.queued, -1)
 		p.inFlight.Done()
 	}
 }
 
+// recordLatencies records the time from the messages entering the producer
+// to their acknowledgement by the brokers
+func (p *asyncProducer) recordLatencies(batch []*ProducerMessage) {
+	recorder := p.conf.metricsRecorder()
+	latency := recorder.Histogram("produce-latency-in-ms", nil)
+	var topic string
+	var topicLatency MetricHistogram
+	now := p.conf.Clock.Now()
+	for _, msg := range batch {
+		if msg.enqueued.IsZero() {
+			continue
+		}
+		if topicLatency == nil || msg.Topic != topic {
+			topic = msg.Topic
+			topicLatency = recorder.Histogram("produce-latency-in-ms", topicMetricLabels(topic))
+		}
+		elapsed := now.Sub(msg.enqueued).Milliseconds()
+		latency.Observe(elapsed)
+		topicLatency.Observe(elapsed)
+	}
+}
+
 func (p *asyncProducer) retryMessage(msg *ProducerMessage, err error) {
 	if msg.retries >= p.conf.Producer.Retry.Max {
 		p.returnError(msg, err)
@@ -265,6 +265,47 @@ func TestAsyncProducerCustomPartitioner(t *testing.T) {
 	seedBroker.Close()
 }
 
+func TestAsyncProducerPartitionRequestError(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	prodTooLarge := new(ProduceResponse)
+	prodTooLarge.AddTopicPartition("my_topic", 0, ErrMessageSizeTooLarge)
+	leader.Returns(prodTooLarge)
+
+	config := NewTestConfig()
+	config.Producer.Retry.Max = 0
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	pErr := <-producer.Errors()
+
+	var partitionErr *PartitionRequestError
+	if !errors.As(pErr, &partitionErr) {
+		t.Fatalf("Expected a PartitionRequestError, got %v", pErr.Err)
+	}
+	if !errors.Is(pErr, ErrMessageSizeTooLarge) {
+		t.Errorf("Expected the error to wrap ErrMessageSizeTooLarge, got %v", partitionErr.Err)
+	}
+	history := leader.History()
+	if partitionErr.Topic != "my_topic" || partitionErr.Partition != 0 || partitionErr.BrokerID != leader.BrokerID() ||
+		partitionErr.CorrelationID != int32(len(history)-1) {
+		t.Errorf("Unexpected coordinates of the error: %+v", partitionErr)
+	}
+
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
+
 func TestAsyncProducerFailureRetry(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	leader1 := NewMockBroker(t, 2)
@@ -1539,3 +1580,37 @@ func NewTestConfig() *Config {
 	config.Version = MinVersion
 	return config
 }
+
+func TestAsyncProducerLatencyMetrics(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	prodSuccess := new(ProduceResponse)
+	prodSuccess.AddTopicPartition("my_topic", 0, ErrNoError)
+	leader.Returns(prodSuccess)
+
+	config := NewTestConfig()
+	config.Producer.Flush.Messages = 5
+	config.Producer.Return.Successes = true
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	expectResults(t, producer, 5, 0)
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+
+	validators := newMetricValidators()
+	validators.registerForGlobalAndTopic("my_topic", countHistogramValidator("produce-latency-in-ms", 5))
+	validators.run(t, config.MetricRegistry)
+}
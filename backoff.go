@@ -0,0 +1,40 @@
+package sarama
+
+import (
+	"math/rand"
+	"time"
+)
+
+// ExponentialBackoff returns a function for Admin.Retry.BackoffFunc,
+// Metadata.Retry.BackoffFunc or Producer.Retry.BackoffFunc doubling the
+// backoff from min with every retry up to max, randomized by the jitter
+// fraction so that the clients retrying after a broker incident do not all
+// retry at once. ExponentialBackoff(100*time.Millisecond, time.Second, 0.2)
+// matches the defaults of retry.backoff.ms and retry.backoff.max.ms of the
+// Java client (KIP-580) and is the recommended setting.
+func ExponentialBackoff(min, max time.Duration, jitter float64) func(retries, maxRetries int) time.Duration {
+	return func(retries, _ int) time.Duration {
+		return exponentialBackoff(min, max, jitter, retries)
+	}
+}
+
+// ExponentialConsumerBackoff is ExponentialBackoff for
+// Consumer.Retry.BackoffFunc.
+func ExponentialConsumerBackoff(min, max time.Duration, jitter float64) func(retries int) time.Duration {
+	return func(retries int) time.Duration {
+		return exponentialBackoff(min, max, jitter, retries)
+	}
+}
+
+// exponentialBackoff returns min doubled for every attempt after the first
+// one, capped to max and randomized by +/- jitter
+func exponentialBackoff(min, max time.Duration, jitter float64, attempts int) time.Duration {
+	backoff := min
+	for i := 1; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return time.Duration(float64(backoff) * (1 - jitter + 2*jitter*rand.Float64()))
+}
@@ -0,0 +1,31 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(100*time.Millisecond, time.Second, 0.2)
+	consumerBackoff := ExponentialConsumerBackoff(100*time.Millisecond, time.Second, 0.2)
+	for retries, expected := range map[int]time.Duration{
+		0:  100 * time.Millisecond,
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		5:  time.Second,
+		50: time.Second,
+	} {
+		lower, upper := time.Duration(float64(expected)*0.8), time.Duration(float64(expected)*1.2)
+		if d := backoff(retries, 3); d < lower || d > upper {
+			t.Errorf("expected a backoff between %s and %s after %d retries, got %s", lower, upper, retries, d)
+		}
+		if d := consumerBackoff(retries); d < lower || d > upper {
+			t.Errorf("expected a consumer backoff between %s and %s after %d retries, got %s", lower, upper, retries, d)
+		}
+	}
+
+	if d := ExponentialBackoff(100*time.Millisecond, time.Second, 0)(3, 3); d != 400*time.Millisecond {
+		t.Errorf("expected exactly 400ms without jitter, got %s", d)
+	}
+}
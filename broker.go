@@ -1,6 +1,7 @@
 package sarama
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/binary"
 	"errors"
@@ -29,28 +30,72 @@ type Broker struct {
 	connErr       error
 	lock          sync.Mutex
 	opened        int32
+	inFlight      int32
+
 	responses     chan *responsePromise
 	done          chan bool
 
-	registeredMetrics []string
+	// whether the connection is being drained, see Drain
+	draining bool
+
+	// the consecutive failed connection attempts, see Net.ReconnectBackoff
+	connFailures    int
+	lastConnFailure time.Time
+
+	// the in-flight slots of the connection with Net.PrioritizeRequests
+	slots requestSlots
+
+	// the hooks registered with AddHooks
+	hooks     []BrokerHooks
+	hooksLock sync.Mutex
+	// the spans of the requests with Config.Tracing
+	tracing *rpcTracer
+
+	// the quota throttling reported by the broker, in nanoseconds
+	throttledUntil int64
+	throttledTotal int64
+	// until when the requests wait with Net.PauseOnThrottle, in nanoseconds
+	pausedUntil int64
+	// when the last request was sent, in nanoseconds, see Net.MaxIdleTime
+	lastUsed int64
+
+	// the timer re-authenticating the SASL session before its lifetime
+	// reported by the broker expires (KIP-368)
+	sessionLifetime time.Duration
+	reauthTimer     *time.Timer
+
+	// the additional connections to the broker with Net.ConnectionsPerBroker,
+	// and for those the broker owning them
+	pool     []*Broker
+	nextConn uint32
+	owner    *Broker
+
+	// the API versions the broker reported in its ApiVersionsResponse
+	apiVersions     map[int16]ApiVersionsResponseKey
+	apiVersionsLock sync.RWMutex
 
-	incomingByteRate       metrics.Meter
-	requestRate            metrics.Meter
-	requestSize            metrics.Histogram
-	requestLatency         metrics.Histogram
-	outgoingByteRate       metrics.Meter
-	responseRate           metrics.Meter
-	responseSize           metrics.Histogram
-	requestsInFlight       metrics.Counter
-	brokerIncomingByteRate metrics.Meter
-	brokerRequestRate      metrics.Meter
-	brokerRequestSize      metrics.Histogram
-	brokerRequestLatency   metrics.Histogram
-	brokerOutgoingByteRate metrics.Meter
-	brokerResponseRate     metrics.Meter
-	brokerResponseSize     metrics.Histogram
-	brokerRequestsInFlight metrics.Counter
-	brokerThrottleTime     metrics.Histogram
+	registeredMetrics []string
+	// the metrics of the requests by API key, see apiMetricsFor
+	apiMetricsLock sync.Mutex
+	apiMetrics     map[int16]*apiMetrics
+
+	incomingByteRate       MetricCounter
+	requestRate            MetricCounter
+	requestSize            MetricHistogram
+	requestLatency         MetricHistogram
+	outgoingByteRate       MetricCounter
+	responseRate           MetricCounter
+	responseSize           MetricHistogram
+	requestsInFlight       MetricGauge
+	brokerIncomingByteRate MetricCounter
+	brokerRequestRate      MetricCounter
+	brokerRequestSize      MetricHistogram
+	brokerRequestLatency   MetricHistogram
+	brokerOutgoingByteRate MetricCounter
+	brokerResponseRate     MetricCounter
+	brokerResponseSize     MetricHistogram
+	brokerRequestsInFlight MetricGauge
+	brokerThrottleTime     MetricHistogram
 
 	kerberosAuthenticator GSSAPIKerberosAuth
 }
@@ -104,6 +149,29 @@ type AccessTokenProvider interface {
 	Token() (*AccessToken, error)
 }
 
+// SASLCredentialsProvider is the interface that encapsulates how implementors
+// can provide the user and password for SASL/PLAIN and SASL/SCRAM
+// authentication when connecting, rather than in the Config, so that rotated
+// credentials take effect on the next connection.
+type SASLCredentialsProvider interface {
+	// Credentials returns the user and password to authenticate with. Like
+	// AccessTokenProvider.Token, it should not block indefinitely.
+	Credentials() (user, password string, err error)
+}
+
+// saslCredentials returns the user and password of Net.SASL, from the
+// CredentialsProvider if set.
+func (b *Broker) saslCredentials() (user, password string, err error) {
+	if b.conf.Net.SASL.CredentialsProvider == nil {
+		return b.conf.Net.SASL.User, b.conf.Net.SASL.Password, nil
+	}
+	user, password, err = b.conf.Net.SASL.CredentialsProvider.Credentials()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get the SASL credentials: %w", err)
+	}
+	return user, password, nil
+}
+
 // SCRAMClient is a an interface to a SCRAM
 // client implementation.
 type SCRAMClient interface {
@@ -125,9 +193,78 @@ type responsePromise struct {
 	handler       func([]byte, error)
 	packets       chan []byte
 	errors        chan error
+	// bodies receives the body of the response in place of packets, to be
+	// read off the connection as it is decoded, see Broker.fetchStream
+	bodies chan *responseBody
+	// release frees the in-flight slot of the request, if any
+	release func()
+	// the hooks the request was sent with, and its description for them
+	hooks []BrokerHooks
+	event RequestEvent
+	// how long to wait for the response, see Net.ReadTimeouts
+	readTimeout time.Duration
+	// the metrics of the API key of the request
+	apiMetrics *apiMetrics
+}
+
+// responseBody is the body of a response read off the connection as its
+// caller decodes it. The connection reads the next response once it is
+// closed.
+type responseBody struct {
+	conn    net.Conn
+	timeout time.Duration
+	// left is the number of bytes of the body not read yet, read the number
+	// of those read
+	left int
+	read int
+	err  error
+	// closed is closed by Close
+	closed chan none
+}
+
+func (rb *responseBody) Read(p []byte) (int, error) {
+	if rb.err != nil {
+		return 0, rb.err
+	}
+	if rb.left == 0 {
+		return 0, io.EOF
+	}
+	if len(p) > rb.left {
+		p = p[:rb.left]
+	}
+	// the deadline applies to each read, the body being read as slowly as
+	// it is consumed
+	if rb.err = rb.conn.SetReadDeadline(time.Now().Add(rb.timeout)); rb.err != nil {
+		return 0, rb.err
+	}
+	n, err := rb.conn.Read(p)
+	rb.left -= n
+	rb.read += n
+	if errors.Is(err, io.EOF) {
+		if rb.left == 0 {
+			err = nil
+		} else {
+			err = io.ErrUnexpectedEOF
+		}
+	}
+	rb.err = err
+	return n, err
+}
+
+// Close discards the part of the body not read, and returns the error
+// reading it, if any
+func (rb *responseBody) Close() error {
+	if rb.err == nil && rb.left > 0 {
+		_, _ = io.Copy(io.Discard, rb)
+	}
+	close(rb.closed)
+	return rb.err
 }
 
 func (p *responsePromise) handle(packets []byte, err error) {
+	if p.release != nil {
+		p.release()
+	}
 	// Use callback when provided
 	if p.handler != nil {
 		p.handler(packets, err)
@@ -148,7 +285,8 @@ func NewBroker(addr string) *Broker {
 }
 
 // Open tries to connect to the Broker if it is not already connected or connecting, but does not block
-// waiting for the connection to complete. This means that any subsequent operations on the broker will
+// waiting for the connection to complete, only for the delay of Net.ReconnectBackoff before connecting
+// again to a broker it failed to connect to. This means that any subsequent operations on the broker will
 // block waiting for the connection to succeed or fail. To get the effect of a fully synchronous Open call,
 // follow it by a call to Connected(). The only errors Open will return directly are ConfigurationError or
 // AlreadyConnected. If conf is nil, the result of NewConfig() is used.
@@ -165,8 +303,32 @@ func (b *Broker) Open(conf *Config) error {
 	if err != nil {
 		return err
 	}
+	if conf.Version == VersionAuto {
+		return ConfigurationError("VersionAuto is only supported by NewClient")
+	}
+
+	b.connect(conf)
+	return nil
+}
 
-	usingApiVersionsRequests := conf.Version.IsAtLeast(V2_4_0_0) && conf.ApiVersionsRequest
+// connect connects to the broker in the background with the config, which
+// must be valid, once the broker is marked as opened.
+func (b *Broker) connect(conf *Config) {
+	var err error
+
+	// with NegotiateApiVersions the request is sent while connecting instead
+	usingApiVersionsRequests := conf.Version.IsAtLeast(V2_4_0_0) && conf.ApiVersionsRequest && !conf.NegotiateApiVersions
+
+	// wait before taking the lock for the connection so that the broker can
+	// still be used meanwhile, if only to be closed
+	b.lock.Lock()
+	delay := b.reconnectDelay(conf)
+	b.lock.Unlock()
+	if delay > 0 {
+		b.log().Debugf("Waiting %s before connecting again to broker %s", delay, b.addr)
+		time.Sleep(delay)
+	}
+	conf.waitForConnectionAttempt()
 
 	b.lock.Lock()
 
@@ -184,49 +346,85 @@ func (b *Broker) Open(conf *Config) error {
 					ClientSoftwareVersion: version(),
 				})
 				if err != nil {
-					Logger.Printf("Error while sending ApiVersionsRequest to broker %s: %s\n", b.addr, err)
+					b.log().Warnf("Error while sending ApiVersionsRequest to broker %s: %s", b.addr, err)
 				}
 			}
 		}()
-		dialer := conf.getDialer()
-		b.conn, b.connErr = dialer.Dial("tcp", b.addr)
+		b.conn, b.connErr = conf.dial("tcp", b.addr)
 		if b.connErr != nil {
-			Logger.Printf("Failed to connect to broker %s: %s\n", b.addr, b.connErr)
+			b.log().Warnf("Failed to connect to broker %s: %s", b.addr, b.connErr)
+			b.connectionFailed()
 			b.conn = nil
 			atomic.StoreInt32(&b.opened, 0)
 			return
 		}
+		var tlsConn *tls.Conn
 		if conf.Net.TLS.Enable {
-			b.conn = tls.Client(b.conn, validServerNameTLS(b.addr, conf.Net.TLS.Config))
+			var tlsConfig *tls.Config
+			if tlsConfig, b.connErr = conf.tlsConfig(); b.connErr != nil {
+				b.log().Warnf("Failed to get the TLS config of broker %s: %s", b.addr, b.connErr)
+				b.connectionFailed()
+				_ = b.conn.Close()
+				b.conn = nil
+				atomic.StoreInt32(&b.opened, 0)
+				return
+			}
+			tlsConn = tls.Client(b.conn, conf.connectionTLSConfig(b.addr, tlsConfig))
+			b.conn = tlsConn
+		}
+
+		if conf, b.connErr = conf.forEndpoint(b.addr); b.connErr != nil {
+			b.log().Warnf("Failed to get the SASL config of broker %s: %s", b.addr, b.connErr)
+			b.connectionFailed()
+			_ = b.conn.Close()
+			b.conn = nil
+			atomic.StoreInt32(&b.opened, 0)
+			return
 		}
 
 		b.conn = newBufConn(b.conn)
 		b.conf = conf
 
 		// Create or reuse the global metrics shared between brokers
-		b.incomingByteRate = metrics.GetOrRegisterMeter("incoming-byte-rate", conf.MetricRegistry)
-		b.requestRate = metrics.GetOrRegisterMeter("request-rate", conf.MetricRegistry)
-		b.requestSize = getOrRegisterHistogram("request-size", conf.MetricRegistry)
-		b.requestLatency = getOrRegisterHistogram("request-latency-in-ms", conf.MetricRegistry)
-		b.outgoingByteRate = metrics.GetOrRegisterMeter("outgoing-byte-rate", conf.MetricRegistry)
-		b.responseRate = metrics.GetOrRegisterMeter("response-rate", conf.MetricRegistry)
-		b.responseSize = getOrRegisterHistogram("response-size", conf.MetricRegistry)
-		b.requestsInFlight = metrics.GetOrRegisterCounter("requests-in-flight", conf.MetricRegistry)
+		recorder := conf.metricsRecorder()
+		b.incomingByteRate = recorder.Counter("incoming-byte-rate", nil)
+		b.requestRate = recorder.Counter("request-rate", nil)
+		b.requestSize = recorder.Histogram("request-size", nil)
+		b.requestLatency = recorder.Histogram("request-latency-in-ms", nil)
+		b.outgoingByteRate = recorder.Counter("outgoing-byte-rate", nil)
+		b.responseRate = recorder.Counter("response-rate", nil)
+		b.responseSize = recorder.Histogram("response-size", nil)
+		b.requestsInFlight = recorder.Gauge("requests-in-flight", nil)
 		// Do not gather metrics for seeded broker (only used during bootstrap) because they share
 		// the same id (-1) and are already exposed through the global metrics above
 		if b.id >= 0 && !metrics.UseNilMetrics {
 			b.registerMetrics()
 		}
 
+		if tlsConn != nil {
+			if b.connErr = b.handshakeTLS(tlsConn); b.connErr != nil {
+				b.log().Warnf("Failed the TLS handshake with broker %s: %s", b.addr, b.connErr)
+				b.connectionFailed()
+				_ = b.conn.Close()
+				b.conn = nil
+				b.unregisterMetrics()
+				atomic.StoreInt32(&b.opened, 0)
+				return
+			}
+		}
+
 		if conf.Net.SASL.Enable {
+			start := time.Now()
 			b.connErr = b.authenticateViaSASL()
+			b.recordAuthentication(false, start, b.connErr)
 
 			if b.connErr != nil {
+				b.connectionFailed()
 				err = b.conn.Close()
 				if err == nil {
-					DebugLogger.Printf("Closed connection to broker %s\n", b.addr)
+					b.log().Debugf("Closed connection to broker %s", b.addr)
 				} else {
-					Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
+					b.log().Warnf("Error while closing connection to broker %s: %s", b.addr, err)
 				}
 				b.conn = nil
 				atomic.StoreInt32(&b.opened, 0)
@@ -234,18 +432,36 @@ func (b *Broker) Open(conf *Config) error {
 			}
 		}
 
+		b.connFailures = 0
+		b.markUsed()
 		b.done = make(chan bool)
 		b.responses = make(chan *responsePromise, b.conf.Net.MaxOpenRequests-1)
+		if conf.Net.PrioritizeRequests {
+			b.slots.reset(conf.Net.MaxOpenRequests)
+		}
 
 		if b.id >= 0 {
-			DebugLogger.Printf("Connected to broker at %s (registered as #%d)\n", b.addr, b.id)
+			b.log().Debugf("Connected to broker at %s (registered as #%d)", b.addr, b.id)
 		} else {
-			DebugLogger.Printf("Connected to broker at %s (unregistered)\n", b.addr)
+			b.log().Debugf("Connected to broker at %s (unregistered)", b.addr)
 		}
 		go withRecover(b.responseReceiver)
-	})
 
-	return nil
+		if conf.Net.SASL.Enable {
+			b.scheduleReauthentication()
+		}
+
+		if conf.NegotiateApiVersions && conf.ApiVersionsRequest && conf.Version.IsAtLeast(V0_10_0_0) {
+			// the lock is held until the versions are known so that no other
+			// request is sent before, brokers older than 0.10 not supporting
+			// ApiVersionsRequest
+			b.fetchSupportedVersions()
+		}
+
+		if b.owner == nil && conf.Net.ConnectionsPerBroker > 1 {
+			b.openPool()
+		}
+	})
 }
 
 // Connected returns true if the broker is connected and false otherwise. If the broker is not
@@ -277,6 +493,51 @@ func (b *Broker) TLSConnectionState() (state tls.ConnectionState, ok bool) {
 
 // Close closes the broker resources
 func (b *Broker) Close() error {
+	return b.close(false)
+}
+
+// Drain closes the connection to the broker gracefully: the requests sent
+// from then on fail with ErrNotConnected, and the connection is closed once
+// the responses to those already in flight are received. If they are not
+// within timeout, the connection is closed anyway, failing them, and
+// ErrDrainTimeout is returned.
+func (b *Broker) Drain(timeout time.Duration) error {
+	b.lock.Lock()
+	if b.conn == nil {
+		b.lock.Unlock()
+		return ErrNotConnected
+	}
+	b.draining = true
+	pool := b.pool
+	b.lock.Unlock()
+
+	for _, conn := range pool {
+		conn.lock.Lock()
+		conn.draining = true
+		conn.lock.Unlock()
+	}
+
+	// the requests in flight cover those of the pool as well
+	deadline := time.Now().Add(timeout)
+	for b.RequestsInFlight() > 0 && time.Now().Before(deadline) {
+		time.Sleep(drainPollInterval)
+	}
+	if b.RequestsInFlight() > 0 {
+		b.log().Warnf("Closing connection to broker %s with %d requests still in flight", b.addr, b.RequestsInFlight())
+		if err := b.close(true); err != nil && err != ErrNotConnected {
+			b.log().Warnf("Error while closing connection to broker %s: %s", b.addr, err)
+		}
+		return ErrDrainTimeout
+	}
+	return b.close(false)
+}
+
+// drainPollInterval is how often Drain checks for the requests in flight
+const drainPollInterval = 10 * time.Millisecond
+
+// close closes the connection once the responses in flight are read or, with
+// abort, right away, failing them
+func (b *Broker) close(abort bool) error {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
@@ -284,22 +545,39 @@ func (b *Broker) Close() error {
 		return ErrNotConnected
 	}
 
+	b.closePool(abort)
+	b.kerberosAuthenticator.destroy()
+
+	if b.reauthTimer != nil {
+		b.reauthTimer.Stop()
+		b.reauthTimer = nil
+	}
+	b.slots.reset(0)
+
+	var err error
+	if abort {
+		err = b.conn.Close()
+	}
+
 	close(b.responses)
 	<-b.done
 
-	err := b.conn.Close()
+	if !abort {
+		err = b.conn.Close()
+	}
 
 	b.conn = nil
 	b.connErr = nil
+	b.draining = false
 	b.done = nil
 	b.responses = nil
 
 	b.unregisterMetrics()
 
 	if err == nil {
-		DebugLogger.Printf("Closed connection to broker %s\n", b.addr)
+		b.log().Debugf("Closed connection to broker %s", b.addr)
 	} else {
-		Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
+		b.log().Warnf("Error while closing connection to broker %s: %s", b.addr, err)
 	}
 
 	atomic.StoreInt32(&b.opened, 0)
@@ -312,11 +590,39 @@ func (b *Broker) ID() int32 {
 	return b.id
 }
 
+// log returns the logger of the lines about the broker
+func (b *Broker) log() contextLogger {
+	return newContextLogger("broker", b.id, "addr", b.addr)
+}
+
 // Addr returns the broker address as either retrieved from Kafka's metadata or passed to NewBroker.
 func (b *Broker) Addr() string {
 	return b.addr
 }
 
+// RequestsInFlight returns the number of requests sent to the broker which
+// are still awaiting a response.
+func (b *Broker) RequestsInFlight() int {
+	return int(atomic.LoadInt32(&b.inFlight))
+}
+
+// ThrottledUntil returns until when the broker asked the client to back off
+// because of a quota violation in its last throttled produce, fetch or
+// metadata response, or the zero time if it never did.
+func (b *Broker) ThrottledUntil() time.Time {
+	until := atomic.LoadInt64(&b.throttledUntil)
+	if until == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, until)
+}
+
+// ThrottleTime returns the cumulative throttle time reported by the broker
+// in produce, fetch and metadata responses.
+func (b *Broker) ThrottleTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&b.throttledTotal))
+}
+
 // Rack returns the broker's rack as retrieved from Kafka's metadata or the
 // empty string if it is not known.  The returned value corresponds to the
 // broker's broker.rack configuration setting.  Requires protocol version to be
@@ -330,12 +636,18 @@ func (b *Broker) Rack() string {
 
 // GetMetadata send a metadata request and returns a metadata response or error
 func (b *Broker) GetMetadata(request *MetadataRequest) (*MetadataResponse, error) {
+	return b.getMetadata(context.Background(), request)
+}
+
+func (b *Broker) getMetadata(ctx context.Context, request *MetadataRequest) (*MetadataResponse, error) {
 	response := new(MetadataResponse)
+	response.Version = request.Version // needed to handle the two header versions
 
-	err := b.sendAndReceive(request, response)
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
+	b.updateThrottleMetric(response, time.Duration(response.ThrottleTimeMs)*time.Millisecond)
 
 	return response, nil
 }
@@ -354,9 +666,14 @@ func (b *Broker) GetConsumerMetadata(request *ConsumerMetadataRequest) (*Consume
 
 // FindCoordinator sends a find coordinate request and returns a response or error
 func (b *Broker) FindCoordinator(request *FindCoordinatorRequest) (*FindCoordinatorResponse, error) {
+	return b.findCoordinator(context.Background(), request)
+}
+
+func (b *Broker) findCoordinator(ctx context.Context, request *FindCoordinatorRequest) (*FindCoordinatorResponse, error) {
 	response := new(FindCoordinatorResponse)
+	response.Version = request.Version // needed to handle the two header versions
 
-	err := b.sendAndReceive(request, response)
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -366,9 +683,14 @@ func (b *Broker) FindCoordinator(request *FindCoordinatorRequest) (*FindCoordina
 
 // GetAvailableOffsets return an offset response or error
 func (b *Broker) GetAvailableOffsets(request *OffsetRequest) (*OffsetResponse, error) {
+	return b.getAvailableOffsets(context.Background(), request)
+}
+
+func (b *Broker) getAvailableOffsets(ctx context.Context, request *OffsetRequest) (*OffsetResponse, error) {
 	response := new(OffsetResponse)
+	response.Version = request.Version // needed to handle the two header versions
 
-	err := b.sendAndReceive(request, response)
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -393,13 +715,19 @@ type ProduceCallback func(*ProduceResponse, error)
 //
 // Make sure not to Close the broker in the callback as it will lead to a deadlock.
 func (b *Broker) AsyncProduce(request *ProduceRequest, cb ProduceCallback) error {
+	b.lock.Lock()
+	conf := b.conf
+	b.lock.Unlock()
+
 	needAcks := request.RequiredAcks != NoResponse
 	// Use a nil promise when no acks is required
 	var promise *responsePromise
 
+	// Create ProduceResponse early to provide the header version
+	res := new(ProduceResponse)
+	req := b.negotiateVersion(conf, request, res)
+
 	if needAcks {
-		// Create ProduceResponse early to provide the header version
-		res := new(ProduceResponse)
 		promise = &responsePromise{
 			headerVersion: res.headerVersion(),
 			// Packets will be converted to a ProduceResponse in the responseReceiver goroutine
@@ -410,22 +738,87 @@ func (b *Broker) AsyncProduce(request *ProduceRequest, cb ProduceCallback) error
 					return
 				}
 
-				if err := versionedDecode(packets, res, request.version()); err != nil {
+				if err := versionedDecode(packets, res, req.version()); err != nil {
 					// Malformed response
 					cb(nil, err)
 					return
 				}
 
 				// Wellformed response
-				b.updateThrottleMetric(res.ThrottleTime)
+				b.updateThrottleMetric(res, res.ThrottleTime)
 				cb(res, nil)
 			},
 		}
 	}
 
+	return b.sendWithPromise(req, promise)
+}
+
+// ProtocolBody is a request or response of the Kafka protocol, such as
+// *MetadataRequest or *MetadataResponse, see AsyncSend.
+type ProtocolBody interface {
+	protocolBody
+}
+
+// ResponseCallback function is called by AsyncSend with the response once it
+// has been decoded, or with the error reading or decoding it.
+type ResponseCallback func(ProtocolBody, error)
+
+// AsyncSend sends any request and eventually calls the provided callback with
+// the given response, of the type and version matching the request, decoded
+// from the response of the broker, or with an error. This allows pipelining
+// requests for which Broker has no asynchronous method.
+//
+// Like with AsyncProduce, waiting for the response is generally not blocking,
+// unless the maximum number of requests in flight is reached. If response is
+// nil, the request expects no response and the callback is not invoked. If an
+// error is returned because the request could not be sent then the callback
+// will not be invoked either.
+//
+// Make sure not to Close the broker in the callback as it will lead to a deadlock.
+func (b *Broker) AsyncSend(request, response ProtocolBody, cb ResponseCallback) error {
+	b.lock.Lock()
+	conf := b.conf
+	b.lock.Unlock()
+
+	request = b.negotiateVersion(conf, request, response)
+	if response == nil {
+		return b.sendWithPromise(request, nil)
+	}
+
+	var promise *responsePromise
+	promise = &responsePromise{
+		headerVersion: response.headerVersion(),
+		handler: func(packets []byte, err error) {
+			if err == nil {
+				err = b.decodeResponse(packets, response, request.version(), promise.correlationID)
+			}
+			if err != nil {
+				cb(nil, err)
+				return
+			}
+			cb(response, nil)
+		},
+	}
+
 	return b.sendWithPromise(request, promise)
 }
 
+// SendRaw sends a request encoded by the caller and returns the response the
+// broker sent back, undecoded. It is an escape hatch for the APIs and versions
+// sarama does not support yet, the requests not being checked against
+// Config.Version.
+func (b *Broker) SendRaw(request *RawRequest) (*RawResponse, error) {
+	response := NewRawResponse(request)
+
+	err := b.sendAndReceive(request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
 //Produce returns a produce response or error
 func (b *Broker) Produce(request *ProduceRequest) (*ProduceResponse, error) {
 	var (
@@ -438,7 +831,7 @@ func (b *Broker) Produce(request *ProduceRequest) (*ProduceResponse, error) {
 	} else {
 		response = new(ProduceResponse)
 		err = b.sendAndReceive(request, response)
-		b.updateThrottleMetric(response.ThrottleTime)
+		b.updateThrottleMetric(response, response.ThrottleTime)
 	}
 
 	if err != nil {
@@ -451,20 +844,87 @@ func (b *Broker) Produce(request *ProduceRequest) (*ProduceResponse, error) {
 // Fetch returns a FetchResponse or error
 func (b *Broker) Fetch(request *FetchRequest) (*FetchResponse, error) {
 	response := new(FetchResponse)
+	response.Version = request.Version // needed to handle the two header versions
+	if request.Version >= 13 {
+		// resolves the names of the topic IDs of the response
+		response.TopicIDs = request.TopicIDs
+	}
+	response.deferRecords = request.deferRecords
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
 		return nil, err
 	}
+	b.updateThrottleMetric(response, response.ThrottleTime)
+
+	return response, nil
+}
+
+// fetchStream sends the fetch request like Fetch, but decodes its response
+// as it is read off the connection, calling onBlock with each partition
+// block, see decodeFetchResponse. The response is returned without its
+// blocks, and is not dumped with Net.WireDump.
+func (b *Broker) fetchStream(request *FetchRequest, onBlock func(topic string, partition int32, block *FetchResponseBlock)) (*FetchResponse, error) {
+	response := new(FetchResponse)
+	response.Version = request.Version
+	if request.Version >= 13 {
+		response.TopicIDs = request.TopicIDs
+	}
+
+	b.lock.Lock()
+	conf := b.conf
+	b.lock.Unlock()
+	req := b.negotiateVersion(conf, request, response)
+
+	promise := &responsePromise{
+		headerVersion: response.headerVersion(),
+		bodies:        make(chan *responseBody, 1),
+		errors:        make(chan error, 1),
+	}
+	if err := b.sendWithPromise(req, promise); err != nil {
+		return nil, err
+	}
+
+	var body *responseBody
+	select {
+	case body = <-promise.bodies:
+	case err := <-promise.errors:
+		return nil, err
+	}
+
+	opts := decodeOptions{
+		maxDecompressedBytes: conf.Consumer.Fetch.MaxDecompressedBytes,
+		skipCRC:              conf.Consumer.Fetch.SkipCRC,
+		deferRecords:         request.deferRecords,
+	}
+	err := decodeFetchResponse(body, body.left, response, opts, func(topic string, partition int32, block *FetchResponseBlock) {
+		block.correlationID = promise.correlationID
+		onBlock(topic, partition, block)
+	})
+	if closeErr := body.Close(); closeErr != nil {
+		// the decoding failed reading the body
+		err = closeErr
+	}
+	if err != nil {
+		b.log().with("api", APIKeyName(req.key()), "correlationID", promise.correlationID).Warnf(
+			"Failed to decode %s response (correlation ID %d) from broker %d: %v",
+			APIKeyName(req.key()), promise.correlationID, b.id, err)
+		return nil, &RequestError{BrokerID: b.id, APIKey: req.key(), CorrelationID: promise.correlationID, Err: err}
+	}
+	b.updateThrottleMetric(response, response.ThrottleTime)
 
 	return response, nil
 }
 
 // CommitOffset return an Offset commit response or error
 func (b *Broker) CommitOffset(request *OffsetCommitRequest) (*OffsetCommitResponse, error) {
+	return b.commitOffset(context.Background(), request)
+}
+
+func (b *Broker) commitOffset(ctx context.Context, request *OffsetCommitRequest) (*OffsetCommitResponse, error) {
 	response := new(OffsetCommitResponse)
 
-	err := b.sendAndReceive(request, response)
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -474,10 +934,14 @@ func (b *Broker) CommitOffset(request *OffsetCommitRequest) (*OffsetCommitRespon
 
 // FetchOffset returns an offset fetch response or error
 func (b *Broker) FetchOffset(request *OffsetFetchRequest) (*OffsetFetchResponse, error) {
+	return b.fetchOffset(context.Background(), request)
+}
+
+func (b *Broker) fetchOffset(ctx context.Context, request *OffsetFetchRequest) (*OffsetFetchResponse, error) {
 	response := new(OffsetFetchResponse)
 	response.Version = request.Version // needed to handle the two header versions
 
-	err := b.sendAndReceive(request, response)
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -488,6 +952,7 @@ func (b *Broker) FetchOffset(request *OffsetFetchRequest) (*OffsetFetchResponse,
 // JoinGroup returns a join group response or error
 func (b *Broker) JoinGroup(request *JoinGroupRequest) (*JoinGroupResponse, error) {
 	response := new(JoinGroupResponse)
+	response.Version = request.Version // needed to handle the two header versions
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
@@ -500,6 +965,7 @@ func (b *Broker) JoinGroup(request *JoinGroupRequest) (*JoinGroupResponse, error
 // SyncGroup returns a sync group response or error
 func (b *Broker) SyncGroup(request *SyncGroupRequest) (*SyncGroupResponse, error) {
 	response := new(SyncGroupResponse)
+	response.Version = request.Version // needed to handle the two header versions
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
@@ -511,9 +977,14 @@ func (b *Broker) SyncGroup(request *SyncGroupRequest) (*SyncGroupResponse, error
 
 // LeaveGroup return a leave group response or error
 func (b *Broker) LeaveGroup(request *LeaveGroupRequest) (*LeaveGroupResponse, error) {
+	return b.leaveGroup(context.Background(), request)
+}
+
+func (b *Broker) leaveGroup(ctx context.Context, request *LeaveGroupRequest) (*LeaveGroupResponse, error) {
 	response := new(LeaveGroupResponse)
+	response.Version = request.Version // needed to handle the two header versions
 
-	err := b.sendAndReceive(request, response)
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -524,6 +995,7 @@ func (b *Broker) LeaveGroup(request *LeaveGroupRequest) (*LeaveGroupResponse, er
 // Heartbeat returns a heartbeat response or error
 func (b *Broker) Heartbeat(request *HeartbeatRequest) (*HeartbeatResponse, error) {
 	response := new(HeartbeatResponse)
+	response.Version = request.Version // needed to handle the two header versions
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
@@ -535,9 +1007,14 @@ func (b *Broker) Heartbeat(request *HeartbeatRequest) (*HeartbeatResponse, error
 
 // ListGroups return a list group response or error
 func (b *Broker) ListGroups(request *ListGroupsRequest) (*ListGroupsResponse, error) {
+	return b.listGroups(context.Background(), request)
+}
+
+func (b *Broker) listGroups(ctx context.Context, request *ListGroupsRequest) (*ListGroupsResponse, error) {
 	response := new(ListGroupsResponse)
+	response.Version = request.Version // needed to handle the two header versions
 
-	err := b.sendAndReceive(request, response)
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -547,9 +1024,13 @@ func (b *Broker) ListGroups(request *ListGroupsRequest) (*ListGroupsResponse, er
 
 // DescribeGroups return describe group response or error
 func (b *Broker) DescribeGroups(request *DescribeGroupsRequest) (*DescribeGroupsResponse, error) {
+	return b.describeGroups(context.Background(), request)
+}
+
+func (b *Broker) describeGroups(ctx context.Context, request *DescribeGroupsRequest) (*DescribeGroupsResponse, error) {
 	response := new(DescribeGroupsResponse)
 
-	err := b.sendAndReceive(request, response)
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -559,46 +1040,371 @@ func (b *Broker) DescribeGroups(request *DescribeGroupsRequest) (*DescribeGroups
 
 // ApiVersions return api version response or error
 func (b *Broker) ApiVersions(request *ApiVersionsRequest) (*ApiVersionsResponse, error) {
+	return b.getApiVersions(context.Background(), request)
+}
+
+func (b *Broker) getApiVersions(ctx context.Context, request *ApiVersionsRequest) (*ApiVersionsResponse, error) {
 	response := new(ApiVersionsResponse)
 
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CreateTopics send a create topic request and returns create topic response
+func (b *Broker) CreateTopics(request *CreateTopicsRequest) (*CreateTopicsResponse, error) {
+	return b.createTopics(context.Background(), request)
+}
+
+func (b *Broker) createTopics(ctx context.Context, request *CreateTopicsRequest) (*CreateTopicsResponse, error) {
+	response := new(CreateTopicsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteTopics sends a delete topic request and returns delete topic response
+func (b *Broker) DeleteTopics(request *DeleteTopicsRequest) (*DeleteTopicsResponse, error) {
+	return b.deleteTopics(context.Background(), request)
+}
+
+func (b *Broker) deleteTopics(ctx context.Context, request *DeleteTopicsRequest) (*DeleteTopicsResponse, error) {
+	response := new(DeleteTopicsResponse)
+	response.Version = request.Version // needed to handle the two header versions
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CreatePartitions sends a create partition request and returns create
+// partitions response or error
+func (b *Broker) CreatePartitions(request *CreatePartitionsRequest) (*CreatePartitionsResponse, error) {
+	return b.createPartitions(context.Background(), request)
+}
+
+func (b *Broker) createPartitions(ctx context.Context, request *CreatePartitionsRequest) (*CreatePartitionsResponse, error) {
+	response := new(CreatePartitionsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// AlterPartitionReassignments sends a alter partition reassignments request and
+// returns alter partition reassignments response
+func (b *Broker) AlterPartitionReassignments(request *AlterPartitionReassignmentsRequest) (*AlterPartitionReassignmentsResponse, error) {
+	return b.alterPartitionReassignments(context.Background(), request)
+}
+
+func (b *Broker) alterPartitionReassignments(ctx context.Context, request *AlterPartitionReassignmentsRequest) (*AlterPartitionReassignmentsResponse, error) {
+	response := new(AlterPartitionReassignmentsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ListPartitionReassignments sends a list partition reassignments request and
+// returns list partition reassignments response
+func (b *Broker) ListPartitionReassignments(request *ListPartitionReassignmentsRequest) (*ListPartitionReassignmentsResponse, error) {
+	return b.listPartitionReassignments(context.Background(), request)
+}
+
+func (b *Broker) listPartitionReassignments(ctx context.Context, request *ListPartitionReassignmentsRequest) (*ListPartitionReassignmentsResponse, error) {
+	response := new(ListPartitionReassignmentsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteRecords send a request to delete records and return delete record
+// response or error
+func (b *Broker) DeleteRecords(request *DeleteRecordsRequest) (*DeleteRecordsResponse, error) {
+	return b.deleteRecords(context.Background(), request)
+}
+
+func (b *Broker) deleteRecords(ctx context.Context, request *DeleteRecordsRequest) (*DeleteRecordsResponse, error) {
+	response := new(DeleteRecordsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DescribeAcls sends a describe acl request and returns a response or error
+func (b *Broker) DescribeAcls(request *DescribeAclsRequest) (*DescribeAclsResponse, error) {
+	return b.describeAcls(context.Background(), request)
+}
+
+func (b *Broker) describeAcls(ctx context.Context, request *DescribeAclsRequest) (*DescribeAclsResponse, error) {
+	response := new(DescribeAclsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CreateAcls sends a create acl request and returns a response or error
+func (b *Broker) CreateAcls(request *CreateAclsRequest) (*CreateAclsResponse, error) {
+	return b.createAcls(context.Background(), request)
+}
+
+func (b *Broker) createAcls(ctx context.Context, request *CreateAclsRequest) (*CreateAclsResponse, error) {
+	response := new(CreateAclsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteAcls sends a delete acl request and returns a response or error
+func (b *Broker) DeleteAcls(request *DeleteAclsRequest) (*DeleteAclsResponse, error) {
+	return b.deleteAcls(context.Background(), request)
+}
+
+func (b *Broker) deleteAcls(ctx context.Context, request *DeleteAclsRequest) (*DeleteAclsResponse, error) {
+	response := new(DeleteAclsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// InitProducerID sends an init producer request and returns a response or error
+func (b *Broker) InitProducerID(request *InitProducerIDRequest) (*InitProducerIDResponse, error) {
+	return b.initProducerID(context.Background(), request)
+}
+
+func (b *Broker) initProducerID(ctx context.Context, request *InitProducerIDRequest) (*InitProducerIDResponse, error) {
+	response := &InitProducerIDResponse{Version: request.Version}
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// AddPartitionsToTxn send a request to add partition to txn and returns
+// a response or error
+func (b *Broker) AddPartitionsToTxn(request *AddPartitionsToTxnRequest) (*AddPartitionsToTxnResponse, error) {
+	response := new(AddPartitionsToTxnResponse)
+
+	err := b.sendAndReceive(request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// AddOffsetsToTxn sends a request to add offsets to txn and returns a response
+// or error
+func (b *Broker) AddOffsetsToTxn(request *AddOffsetsToTxnRequest) (*AddOffsetsToTxnResponse, error) {
+	response := new(AddOffsetsToTxnResponse)
+
+	err := b.sendAndReceive(request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// EndTxn sends a request to end txn and returns a response or error
+func (b *Broker) EndTxn(request *EndTxnRequest) (*EndTxnResponse, error) {
+	response := new(EndTxnResponse)
+
+	err := b.sendAndReceive(request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// TxnOffsetCommit sends a request to commit transaction offsets and returns
+// a response or error
+func (b *Broker) TxnOffsetCommit(request *TxnOffsetCommitRequest) (*TxnOffsetCommitResponse, error) {
+	response := &TxnOffsetCommitResponse{Version: request.Version}
+
 	err := b.sendAndReceive(request, response)
 	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
+	return response, nil
+}
+
+// DescribeConfigs sends a request to describe config and returns a response or
+// error
+func (b *Broker) DescribeConfigs(request *DescribeConfigsRequest) (*DescribeConfigsResponse, error) {
+	return b.describeConfigs(context.Background(), request)
+}
+
+func (b *Broker) describeConfigs(ctx context.Context, request *DescribeConfigsRequest) (*DescribeConfigsResponse, error) {
+	response := new(DescribeConfigsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// AlterConfigs sends a request to alter config and return a response or error
+func (b *Broker) AlterConfigs(request *AlterConfigsRequest) (*AlterConfigsResponse, error) {
+	return b.alterConfigs(context.Background(), request)
+}
+
+func (b *Broker) alterConfigs(ctx context.Context, request *AlterConfigsRequest) (*AlterConfigsResponse, error) {
+	response := new(AlterConfigsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// IncrementalAlterConfigs sends a request to incremental alter config and return a response or error
+func (b *Broker) IncrementalAlterConfigs(request *IncrementalAlterConfigsRequest) (*IncrementalAlterConfigsResponse, error) {
+	return b.incrementalAlterConfigs(context.Background(), request)
+}
+
+func (b *Broker) incrementalAlterConfigs(ctx context.Context, request *IncrementalAlterConfigsRequest) (*IncrementalAlterConfigsResponse, error) {
+	response := new(IncrementalAlterConfigsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteGroups sends a request to delete groups and returns a response or error
+func (b *Broker) DeleteGroups(request *DeleteGroupsRequest) (*DeleteGroupsResponse, error) {
+	return b.deleteGroups(context.Background(), request)
+}
+
+func (b *Broker) deleteGroups(ctx context.Context, request *DeleteGroupsRequest) (*DeleteGroupsResponse, error) {
+	response := new(DeleteGroupsResponse)
+
+	if err := b.sendAndReceiveContext(ctx, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteOffsets sends a request to delete group offsets and returns a response or error
+func (b *Broker) DeleteOffsets(request *DeleteOffsetsRequest) (*DeleteOffsetsResponse, error) {
+	return b.deleteOffsets(context.Background(), request)
+}
+
+func (b *Broker) deleteOffsets(ctx context.Context, request *DeleteOffsetsRequest) (*DeleteOffsetsResponse, error) {
+	response := new(DeleteOffsetsResponse)
+
+	if err := b.sendAndReceiveContext(ctx, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DescribeLogDirs sends a request to get the broker's log dir paths and sizes
+func (b *Broker) DescribeLogDirs(request *DescribeLogDirsRequest) (*DescribeLogDirsResponse, error) {
+	return b.describeLogDirs(context.Background(), request)
+}
+
+func (b *Broker) describeLogDirs(ctx context.Context, request *DescribeLogDirsRequest) (*DescribeLogDirsResponse, error) {
+	response := new(DescribeLogDirsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DescribeUserScramCredentials sends a request to get SCRAM users
+func (b *Broker) DescribeUserScramCredentials(req *DescribeUserScramCredentialsRequest) (*DescribeUserScramCredentialsResponse, error) {
+	return b.describeUserScramCredentials(context.Background(), req)
 }
 
-// CreateTopics send a create topic request and returns create topic response
-func (b *Broker) CreateTopics(request *CreateTopicsRequest) (*CreateTopicsResponse, error) {
-	response := new(CreateTopicsResponse)
+func (b *Broker) describeUserScramCredentials(ctx context.Context, req *DescribeUserScramCredentialsRequest) (*DescribeUserScramCredentialsResponse, error) {
+	res := new(DescribeUserScramCredentialsResponse)
 
-	err := b.sendAndReceive(request, response)
+	err := b.sendAndReceiveContext(ctx, req, res)
 	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
+	return res, err
 }
 
-// DeleteTopics sends a delete topic request and returns delete topic response
-func (b *Broker) DeleteTopics(request *DeleteTopicsRequest) (*DeleteTopicsResponse, error) {
-	response := new(DeleteTopicsResponse)
+func (b *Broker) AlterUserScramCredentials(req *AlterUserScramCredentialsRequest) (*AlterUserScramCredentialsResponse, error) {
+	return b.alterUserScramCredentials(context.Background(), req)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) alterUserScramCredentials(ctx context.Context, req *AlterUserScramCredentialsRequest) (*AlterUserScramCredentialsResponse, error) {
+	res := new(AlterUserScramCredentialsResponse)
+
+	err := b.sendAndReceiveContext(ctx, req, res)
 	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
+	return res, nil
 }
 
-// CreatePartitions sends a create partition request and returns create
-// partitions response or error
-func (b *Broker) CreatePartitions(request *CreatePartitionsRequest) (*CreatePartitionsResponse, error) {
-	response := new(CreatePartitionsResponse)
+// DescribeClientQuotas sends a request to get the broker's quotas
+func (b *Broker) DescribeClientQuotas(request *DescribeClientQuotasRequest) (*DescribeClientQuotasResponse, error) {
+	return b.describeClientQuotas(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) describeClientQuotas(ctx context.Context, request *DescribeClientQuotasRequest) (*DescribeClientQuotasResponse, error) {
+	response := new(DescribeClientQuotasResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -606,12 +1412,15 @@ func (b *Broker) CreatePartitions(request *CreatePartitionsRequest) (*CreatePart
 	return response, nil
 }
 
-// AlterPartitionReassignments sends a alter partition reassignments request and
-// returns alter partition reassignments response
-func (b *Broker) AlterPartitionReassignments(request *AlterPartitionReassignmentsRequest) (*AlterPartitionReassignmentsResponse, error) {
-	response := new(AlterPartitionReassignmentsResponse)
+// AlterClientQuotas sends a request to alter the broker's quotas
+func (b *Broker) AlterClientQuotas(request *AlterClientQuotasRequest) (*AlterClientQuotasResponse, error) {
+	return b.alterClientQuotas(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) alterClientQuotas(ctx context.Context, request *AlterClientQuotasRequest) (*AlterClientQuotasResponse, error) {
+	response := new(AlterClientQuotasResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -619,12 +1428,15 @@ func (b *Broker) AlterPartitionReassignments(request *AlterPartitionReassignment
 	return response, nil
 }
 
-// ListPartitionReassignments sends a list partition reassignments request and
-// returns list partition reassignments response
-func (b *Broker) ListPartitionReassignments(request *ListPartitionReassignmentsRequest) (*ListPartitionReassignmentsResponse, error) {
-	response := new(ListPartitionReassignmentsResponse)
+// DescribeCluster sends a request to describe the cluster and returns a response or error
+func (b *Broker) DescribeCluster(request *DescribeClusterRequest) (*DescribeClusterResponse, error) {
+	return b.describeCluster(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) describeCluster(ctx context.Context, request *DescribeClusterRequest) (*DescribeClusterResponse, error) {
+	response := new(DescribeClusterResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -632,12 +1444,15 @@ func (b *Broker) ListPartitionReassignments(request *ListPartitionReassignmentsR
 	return response, nil
 }
 
-// DeleteRecords send a request to delete records and return delete record
-// response or error
-func (b *Broker) DeleteRecords(request *DeleteRecordsRequest) (*DeleteRecordsResponse, error) {
-	response := new(DeleteRecordsResponse)
+// DescribeProducers sends a describe producers request and returns describe producers response or error
+func (b *Broker) DescribeProducers(request *DescribeProducersRequest) (*DescribeProducersResponse, error) {
+	return b.describeProducers(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) describeProducers(ctx context.Context, request *DescribeProducersRequest) (*DescribeProducersResponse, error) {
+	response := new(DescribeProducersResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -645,11 +1460,15 @@ func (b *Broker) DeleteRecords(request *DeleteRecordsRequest) (*DeleteRecordsRes
 	return response, nil
 }
 
-// DescribeAcls sends a describe acl request and returns a response or error
-func (b *Broker) DescribeAcls(request *DescribeAclsRequest) (*DescribeAclsResponse, error) {
-	response := new(DescribeAclsResponse)
+// DescribeTransactions sends a describe transactions request and returns describe transactions response or error
+func (b *Broker) DescribeTransactions(request *DescribeTransactionsRequest) (*DescribeTransactionsResponse, error) {
+	return b.describeTransactions(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) describeTransactions(ctx context.Context, request *DescribeTransactionsRequest) (*DescribeTransactionsResponse, error) {
+	response := new(DescribeTransactionsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -657,11 +1476,15 @@ func (b *Broker) DescribeAcls(request *DescribeAclsRequest) (*DescribeAclsRespon
 	return response, nil
 }
 
-// CreateAcls sends a create acl request and returns a response or error
-func (b *Broker) CreateAcls(request *CreateAclsRequest) (*CreateAclsResponse, error) {
-	response := new(CreateAclsResponse)
+// ListTransactions sends a list transactions request and returns list transactions response or error
+func (b *Broker) ListTransactions(request *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return b.listTransactions(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) listTransactions(ctx context.Context, request *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	response := new(ListTransactionsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -669,11 +1492,15 @@ func (b *Broker) CreateAcls(request *CreateAclsRequest) (*CreateAclsResponse, er
 	return response, nil
 }
 
-// DeleteAcls sends a delete acl request and returns a response or error
-func (b *Broker) DeleteAcls(request *DeleteAclsRequest) (*DeleteAclsResponse, error) {
-	response := new(DeleteAclsResponse)
+// ConsumerGroupDescribe sends a consumer group describe request and returns consumer group describe response or error
+func (b *Broker) ConsumerGroupDescribe(request *ConsumerGroupDescribeRequest) (*ConsumerGroupDescribeResponse, error) {
+	return b.consumerGroupDescribe(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) consumerGroupDescribe(ctx context.Context, request *ConsumerGroupDescribeRequest) (*ConsumerGroupDescribeResponse, error) {
+	response := new(ConsumerGroupDescribeResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -681,9 +1508,9 @@ func (b *Broker) DeleteAcls(request *DeleteAclsRequest) (*DeleteAclsResponse, er
 	return response, nil
 }
 
-// InitProducerID sends an init producer request and returns a response or error
-func (b *Broker) InitProducerID(request *InitProducerIDRequest) (*InitProducerIDResponse, error) {
-	response := new(InitProducerIDResponse)
+// ShareGroupHeartbeat sends a share group heartbeat request and returns share group heartbeat response or error
+func (b *Broker) ShareGroupHeartbeat(request *ShareGroupHeartbeatRequest) (*ShareGroupHeartbeatResponse, error) {
+	response := new(ShareGroupHeartbeatResponse)
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
@@ -693,10 +1520,9 @@ func (b *Broker) InitProducerID(request *InitProducerIDRequest) (*InitProducerID
 	return response, nil
 }
 
-// AddPartitionsToTxn send a request to add partition to txn and returns
-// a response or error
-func (b *Broker) AddPartitionsToTxn(request *AddPartitionsToTxnRequest) (*AddPartitionsToTxnResponse, error) {
-	response := new(AddPartitionsToTxnResponse)
+// ShareFetch sends a share fetch request and returns share fetch response or error
+func (b *Broker) ShareFetch(request *ShareFetchRequest) (*ShareFetchResponse, error) {
+	response := new(ShareFetchResponse)
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
@@ -706,10 +1532,9 @@ func (b *Broker) AddPartitionsToTxn(request *AddPartitionsToTxnRequest) (*AddPar
 	return response, nil
 }
 
-// AddOffsetsToTxn sends a request to add offsets to txn and returns a response
-// or error
-func (b *Broker) AddOffsetsToTxn(request *AddOffsetsToTxnRequest) (*AddOffsetsToTxnResponse, error) {
-	response := new(AddOffsetsToTxnResponse)
+// ShareAcknowledge sends a share acknowledge request and returns share acknowledge response or error
+func (b *Broker) ShareAcknowledge(request *ShareAcknowledgeRequest) (*ShareAcknowledgeResponse, error) {
+	response := new(ShareAcknowledgeResponse)
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
@@ -719,11 +1544,15 @@ func (b *Broker) AddOffsetsToTxn(request *AddOffsetsToTxnRequest) (*AddOffsetsTo
 	return response, nil
 }
 
-// EndTxn sends a request to end txn and returns a response or error
-func (b *Broker) EndTxn(request *EndTxnRequest) (*EndTxnResponse, error) {
-	response := new(EndTxnResponse)
+// GetTelemetrySubscriptions sends a get telemetry subscriptions request and returns get telemetry subscriptions response or error
+func (b *Broker) GetTelemetrySubscriptions(request *GetTelemetrySubscriptionsRequest) (*GetTelemetrySubscriptionsResponse, error) {
+	return b.getTelemetrySubscriptions(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) getTelemetrySubscriptions(ctx context.Context, request *GetTelemetrySubscriptionsRequest) (*GetTelemetrySubscriptionsResponse, error) {
+	response := new(GetTelemetrySubscriptionsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -731,10 +1560,9 @@ func (b *Broker) EndTxn(request *EndTxnRequest) (*EndTxnResponse, error) {
 	return response, nil
 }
 
-// TxnOffsetCommit sends a request to commit transaction offsets and returns
-// a response or error
-func (b *Broker) TxnOffsetCommit(request *TxnOffsetCommitRequest) (*TxnOffsetCommitResponse, error) {
-	response := new(TxnOffsetCommitResponse)
+// PushTelemetry sends a push telemetry request and returns push telemetry response or error
+func (b *Broker) PushTelemetry(request *PushTelemetryRequest) (*PushTelemetryResponse, error) {
+	response := new(PushTelemetryResponse)
 
 	err := b.sendAndReceive(request, response)
 	if err != nil {
@@ -744,12 +1572,15 @@ func (b *Broker) TxnOffsetCommit(request *TxnOffsetCommitRequest) (*TxnOffsetCom
 	return response, nil
 }
 
-// DescribeConfigs sends a request to describe config and returns a response or
-// error
-func (b *Broker) DescribeConfigs(request *DescribeConfigsRequest) (*DescribeConfigsResponse, error) {
-	response := new(DescribeConfigsResponse)
+// AddRaftVoter sends an add raft voter request and returns add raft voter response or error
+func (b *Broker) AddRaftVoter(request *AddRaftVoterRequest) (*AddRaftVoterResponse, error) {
+	return b.addRaftVoter(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) addRaftVoter(ctx context.Context, request *AddRaftVoterRequest) (*AddRaftVoterResponse, error) {
+	response := new(AddRaftVoterResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -757,11 +1588,15 @@ func (b *Broker) DescribeConfigs(request *DescribeConfigsRequest) (*DescribeConf
 	return response, nil
 }
 
-// AlterConfigs sends a request to alter config and return a response or error
-func (b *Broker) AlterConfigs(request *AlterConfigsRequest) (*AlterConfigsResponse, error) {
-	response := new(AlterConfigsResponse)
+// RemoveRaftVoter sends a remove raft voter request and returns remove raft voter response or error
+func (b *Broker) RemoveRaftVoter(request *RemoveRaftVoterRequest) (*RemoveRaftVoterResponse, error) {
+	return b.removeRaftVoter(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) removeRaftVoter(ctx context.Context, request *RemoveRaftVoterRequest) (*RemoveRaftVoterResponse, error) {
+	response := new(RemoveRaftVoterResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -769,11 +1604,15 @@ func (b *Broker) AlterConfigs(request *AlterConfigsRequest) (*AlterConfigsRespon
 	return response, nil
 }
 
-// IncrementalAlterConfigs sends a request to incremental alter config and return a response or error
-func (b *Broker) IncrementalAlterConfigs(request *IncrementalAlterConfigsRequest) (*IncrementalAlterConfigsResponse, error) {
-	response := new(IncrementalAlterConfigsResponse)
+// UnregisterBroker sends an unregister broker request and returns unregister broker response or error
+func (b *Broker) UnregisterBroker(request *UnregisterBrokerRequest) (*UnregisterBrokerResponse, error) {
+	return b.unregisterBroker(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) unregisterBroker(ctx context.Context, request *UnregisterBrokerRequest) (*UnregisterBrokerResponse, error) {
+	response := new(UnregisterBrokerResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -781,33 +1620,48 @@ func (b *Broker) IncrementalAlterConfigs(request *IncrementalAlterConfigsRequest
 	return response, nil
 }
 
-// DeleteGroups sends a request to delete groups and returns a response or error
-func (b *Broker) DeleteGroups(request *DeleteGroupsRequest) (*DeleteGroupsResponse, error) {
-	response := new(DeleteGroupsResponse)
+// UpdateFeatures sends an update features request and returns update features response or error
+func (b *Broker) UpdateFeatures(request *UpdateFeaturesRequest) (*UpdateFeaturesResponse, error) {
+	return b.updateFeatures(context.Background(), request)
+}
 
-	if err := b.sendAndReceive(request, response); err != nil {
+func (b *Broker) updateFeatures(ctx context.Context, request *UpdateFeaturesRequest) (*UpdateFeaturesResponse, error) {
+	response := new(UpdateFeaturesResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
 		return nil, err
 	}
 
 	return response, nil
 }
 
-// DeleteOffsets sends a request to delete group offsets and returns a response or error
-func (b *Broker) DeleteOffsets(request *DeleteOffsetsRequest) (*DeleteOffsetsResponse, error) {
-	response := new(DeleteOffsetsResponse)
+// ElectLeaders sends an elect leaders request and returns elect leaders response or error
+func (b *Broker) ElectLeaders(request *ElectLeadersRequest) (*ElectLeadersResponse, error) {
+	return b.electLeaders(context.Background(), request)
+}
 
-	if err := b.sendAndReceive(request, response); err != nil {
+func (b *Broker) electLeaders(ctx context.Context, request *ElectLeadersRequest) (*ElectLeadersResponse, error) {
+	response := new(ElectLeadersResponse)
+	response.Version = request.Version // needed to handle the two header versions
+
+	err := b.sendAndReceiveContext(ctx, request, response)
+	if err != nil {
 		return nil, err
 	}
 
 	return response, nil
 }
 
-// DescribeLogDirs sends a request to get the broker's log dir paths and sizes
-func (b *Broker) DescribeLogDirs(request *DescribeLogDirsRequest) (*DescribeLogDirsResponse, error) {
-	response := new(DescribeLogDirsResponse)
+// AlterReplicaLogDirs sends an alter replica log dirs request and returns alter replica log dirs response or error
+func (b *Broker) AlterReplicaLogDirs(request *AlterReplicaLogDirsRequest) (*AlterReplicaLogDirsResponse, error) {
+	return b.alterReplicaLogDirs(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) alterReplicaLogDirs(ctx context.Context, request *AlterReplicaLogDirsRequest) (*AlterReplicaLogDirsResponse, error) {
+	response := new(AlterReplicaLogDirsResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -815,34 +1669,47 @@ func (b *Broker) DescribeLogDirs(request *DescribeLogDirsRequest) (*DescribeLogD
 	return response, nil
 }
 
-// DescribeUserScramCredentials sends a request to get SCRAM users
-func (b *Broker) DescribeUserScramCredentials(req *DescribeUserScramCredentialsRequest) (*DescribeUserScramCredentialsResponse, error) {
-	res := new(DescribeUserScramCredentialsResponse)
+// CreateDelegationToken sends a create delegation token request and returns create delegation token response or error
+func (b *Broker) CreateDelegationToken(request *CreateDelegationTokenRequest) (*CreateDelegationTokenResponse, error) {
+	return b.createDelegationToken(context.Background(), request)
+}
+
+func (b *Broker) createDelegationToken(ctx context.Context, request *CreateDelegationTokenRequest) (*CreateDelegationTokenResponse, error) {
+	response := new(CreateDelegationTokenResponse)
 
-	err := b.sendAndReceive(req, res)
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
 
-	return res, err
+	return response, nil
 }
 
-func (b *Broker) AlterUserScramCredentials(req *AlterUserScramCredentialsRequest) (*AlterUserScramCredentialsResponse, error) {
-	res := new(AlterUserScramCredentialsResponse)
+// RenewDelegationToken sends a renew delegation token request and returns renew delegation token response or error
+func (b *Broker) RenewDelegationToken(request *RenewDelegationTokenRequest) (*RenewDelegationTokenResponse, error) {
+	return b.renewDelegationToken(context.Background(), request)
+}
+
+func (b *Broker) renewDelegationToken(ctx context.Context, request *RenewDelegationTokenRequest) (*RenewDelegationTokenResponse, error) {
+	response := new(RenewDelegationTokenResponse)
 
-	err := b.sendAndReceive(req, res)
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
 
-	return res, nil
+	return response, nil
 }
 
-// DescribeClientQuotas sends a request to get the broker's quotas
-func (b *Broker) DescribeClientQuotas(request *DescribeClientQuotasRequest) (*DescribeClientQuotasResponse, error) {
-	response := new(DescribeClientQuotasResponse)
+// ExpireDelegationToken sends a expire delegation token request and returns expire delegation token response or error
+func (b *Broker) ExpireDelegationToken(request *ExpireDelegationTokenRequest) (*ExpireDelegationTokenResponse, error) {
+	return b.expireDelegationToken(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) expireDelegationToken(ctx context.Context, request *ExpireDelegationTokenRequest) (*ExpireDelegationTokenResponse, error) {
+	response := new(ExpireDelegationTokenResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -850,11 +1717,15 @@ func (b *Broker) DescribeClientQuotas(request *DescribeClientQuotasRequest) (*De
 	return response, nil
 }
 
-// AlterClientQuotas sends a request to alter the broker's quotas
-func (b *Broker) AlterClientQuotas(request *AlterClientQuotasRequest) (*AlterClientQuotasResponse, error) {
-	response := new(AlterClientQuotasResponse)
+// DescribeDelegationToken sends a describe delegation token request and returns describe delegation token response or error
+func (b *Broker) DescribeDelegationToken(request *DescribeDelegationTokenRequest) (*DescribeDelegationTokenResponse, error) {
+	return b.describeDelegationToken(context.Background(), request)
+}
 
-	err := b.sendAndReceive(request, response)
+func (b *Broker) describeDelegationToken(ctx context.Context, request *DescribeDelegationTokenRequest) (*DescribeDelegationTokenResponse, error) {
+	response := new(DescribeDelegationTokenResponse)
+
+	err := b.sendAndReceiveContext(ctx, request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -865,7 +1736,12 @@ func (b *Broker) AlterClientQuotas(request *AlterClientQuotasRequest) (*AlterCli
 // readFull ensures the conn ReadDeadline has been setup before making a
 // call to io.ReadFull
 func (b *Broker) readFull(buf []byte) (n int, err error) {
-	if err := b.conn.SetReadDeadline(time.Now().Add(b.conf.Net.ReadTimeout)); err != nil {
+	return b.readFullWithin(buf, b.conf.Net.ReadTimeout)
+}
+
+// readFullWithin is readFull with a read timeout other than Net.ReadTimeout
+func (b *Broker) readFullWithin(buf []byte, timeout time.Duration) (n int, err error) {
+	if err := b.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
 		return 0, err
 	}
 
@@ -886,11 +1762,12 @@ func (b *Broker) send(rb protocolBody, promiseResponse bool, responseHeaderVersi
 	var promise *responsePromise
 	if promiseResponse {
 		// Packets or error will be sent to the following channels
-		// once the response is received
+		// once the response is received, which are buffered so that the
+		// response is not waited for when the caller gave up on it
 		promise = &responsePromise{
 			headerVersion: responseHeaderVersion,
-			packets:       make(chan []byte),
-			errors:        make(chan error),
+			packets:       make(chan []byte, 1),
+			errors:        make(chan error, 1),
 		}
 	}
 
@@ -902,33 +1779,71 @@ func (b *Broker) send(rb protocolBody, promiseResponse bool, responseHeaderVersi
 }
 
 func (b *Broker) sendWithPromise(rb protocolBody, promise *responsePromise) error {
-	b.lock.Lock()
-	defer b.lock.Unlock()
+	b.waitForThrottle()
+	conn := b.connectionFor(rb)
 
-	if b.conn == nil {
+	// wait for an in-flight slot before the lock so that the requests are
+	// sent by priority with Net.PrioritizeRequests
+	release := conn.slots.acquire(requestPriority(rb))
+	if promise != nil {
+		promise.release = release
+	}
+
+	conn.lock.Lock()
+	err := conn.sendInternal(rb, promise)
+	conn.lock.Unlock()
+
+	if err != nil || promise == nil {
+		release()
+	}
+	return err
+}
+
+// sendInternal must be called with b.lock held
+func (b *Broker) sendInternal(rb protocolBody, promise *responsePromise) error {
+	if b.conn == nil || b.draining {
 		if b.connErr != nil {
 			return b.connErr
 		}
 		return ErrNotConnected
 	}
 
-	if !b.conf.Version.IsAtLeast(rb.requiredVersion()) {
+	if !b.conf.Version.IsAtLeast(rb.requiredVersion()) && !b.negotiated(rb) {
 		return ErrUnsupportedVersion
 	}
 
-	req := &request{correlationID: b.correlationID, clientID: b.conf.ClientID, body: rb}
-	buf, err := encode(req, b.conf.MetricRegistry)
+	req := &request{correlationID: b.correlationID, clientID: b.conf.requestClientID(rb.key()), body: rb}
+	buf, err := encode(req, b.conf.metricsRecorder())
 	if err != nil {
 		return err
 	}
+	b.dumpRequest(rb, req.correlationID, buf)
+
+	hooks := b.brokerHooks()
+	event := RequestEvent{
+		BrokerID:      b.id,
+		Addr:          b.addr,
+		APIKey:        rb.key(),
+		APIVersion:    rb.version(),
+		CorrelationID: req.correlationID,
+		RequestSize:   len(buf),
+	}
+	runBeforeRequestHooks(hooks, event)
 
+	b.markUsed()
 	requestTime := time.Now()
 	// Will be decremented in responseReceiver (except error or request with NoResponse)
 	b.addRequestInFlightMetrics(1)
 	bytes, err := b.write(buf)
 	b.updateOutgoingCommunicationMetrics(bytes)
+	apiMetrics := b.apiMetricsFor(rb.key())
+	apiMetrics.updateRequest(bytes, err)
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
+		if promise != nil {
+			// no response is read for the request
+			runAfterResponseHooks(hooks, ResponseEvent{RequestEvent: event, Latency: time.Since(requestTime), Err: err})
+		}
 		return err
 	}
 	b.correlationID++
@@ -936,17 +1851,38 @@ func (b *Broker) sendWithPromise(rb protocolBody, promise *responsePromise) erro
 	if promise == nil {
 		// Record request latency without the response
 		b.updateRequestLatencyAndInFlightMetrics(time.Since(requestTime))
+		apiMetrics.updateResponse(0, time.Since(requestTime), nil)
 		return nil
 	}
 
 	promise.requestTime = requestTime
 	promise.correlationID = req.correlationID
+	promise.hooks = hooks
+	promise.event = event
+	promise.apiMetrics = apiMetrics
+	promise.readTimeout = b.conf.readTimeoutFor(rb)
 	b.responses <- promise
 
 	return nil
 }
 
 func (b *Broker) sendAndReceive(req protocolBody, res protocolBody) error {
+	return b.sendAndReceiveContext(context.Background(), req, res)
+}
+
+// sendAndReceiveContext is sendAndReceive, but returns the error of the
+// context once it is done instead of waiting for the response. The request
+// is still sent unless the context is done first.
+func (b *Broker) sendAndReceiveContext(ctx context.Context, req protocolBody, res protocolBody) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	conf := b.conf
+	b.lock.Unlock()
+	req = b.negotiateVersion(conf, req, res)
+
 	responseHeaderVersion := int16(-1)
 	if res != nil {
 		responseHeaderVersion = res.headerVersion()
@@ -963,19 +1899,97 @@ func (b *Broker) sendAndReceive(req protocolBody, res protocolBody) error {
 
 	select {
 	case buf := <-promise.packets:
-		return versionedDecode(buf, res, req.version())
+		return b.decodeResponse(buf, res, req.version(), promise.correlationID)
 	case err = <-promise.errors:
 		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// decodeResponse decodes the response to a request, applying the
+// Consumer.Fetch options to its record batches, and dumps it with
+// Net.WireDump
+func (b *Broker) decodeResponse(buf []byte, res versionedDecoder, version int16, correlationID int32) error {
+	opts := decodeOptions{
+		maxDecompressedBytes: b.conf.Consumer.Fetch.MaxDecompressedBytes,
+		skipCRC:              b.conf.Consumer.Fetch.SkipCRC,
+	}
+	if fetch, ok := res.(*FetchResponse); ok {
+		opts.deferRecords = fetch.deferRecords
+	}
+	err := versionedDecodeWithOptions(buf, res, version, opts)
+	b.dumpResponse(res, version, correlationID, buf, err)
+	if correlated, ok := res.(correlatedResponse); ok && err == nil {
+		correlated.setCorrelationID(correlationID)
+	}
+	if body, ok := res.(protocolBody); ok && err != nil {
+		err = &RequestError{BrokerID: b.id, APIKey: body.key(), CorrelationID: correlationID, Err: err}
+		b.log().with("api", APIKeyName(body.key()), "correlationID", correlationID).Warnf(
+			"Failed to decode %s response (correlation ID %d) from broker %d: %v",
+			APIKeyName(body.key()), correlationID, b.id, err.(*RequestError).Err)
+	}
+	return err
+}
+
+// correlatedResponse is implemented by the responses recording the
+// correlation ID of their request, which is attached to the errors of their
+// partitions, see PartitionRequestError
+type correlatedResponse interface {
+	setCorrelationID(correlationID int32)
+}
+
+// responseFailed logs the failure to read the response to a request, from
+// which the connection does not recover, and returns it as a RequestError
+func (b *Broker) responseFailed(response *responsePromise, err error) error {
+	key := response.event.APIKey
+	b.log().with("api", APIKeyName(key), "correlationID", response.correlationID).Warnf(
+		"Failed to read %s response (correlation ID %d) from broker %d: %v",
+		APIKeyName(key), response.correlationID, b.id, err)
+	return b.requestError(response, err)
+}
+
+func (b *Broker) requestError(response *responsePromise, err error) error {
+	return &RequestError{BrokerID: b.id, APIKey: response.event.APIKey, CorrelationID: response.correlationID, Err: err}
+}
+
+// sendAndReceiveLocked sends the request and waits for its response like
+// sendAndReceive, but must be called with b.lock held, so that no other
+// request can be sent on the connection until the response is received.
+func (b *Broker) sendAndReceiveLocked(req protocolBody, res protocolBody) error {
+	promise := &responsePromise{
+		headerVersion: res.headerVersion(),
+		packets:       make(chan []byte),
+		errors:        make(chan error),
+	}
+
+	if err := b.sendInternal(req, promise); err != nil {
+		return err
+	}
+
+	select {
+	case buf := <-promise.packets:
+		return b.decodeResponse(buf, res, req.version(), promise.correlationID)
+	case err := <-promise.errors:
+		return err
 	}
 }
 
 func (b *Broker) decode(pd packetDecoder, version int16) (err error) {
+	// decoded as part of a MetadataResponse, which is flexible from v9
+	isFlexible := version >= 9
+
 	b.id, err = pd.getInt32()
 	if err != nil {
 		return err
 	}
 
-	host, err := pd.getString()
+	var host string
+	if isFlexible {
+		host, err = pd.getCompactString()
+	} else {
+		host, err = pd.getString()
+	}
 	if err != nil {
 		return err
 	}
@@ -986,12 +2000,22 @@ func (b *Broker) decode(pd packetDecoder, version int16) (err error) {
 	}
 
 	if version >= 1 {
-		b.rack, err = pd.getNullableString()
+		if isFlexible {
+			b.rack, err = pd.getCompactNullableString()
+		} else {
+			b.rack, err = pd.getNullableString()
+		}
 		if err != nil {
 			return err
 		}
 	}
 
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	b.addr = net.JoinHostPort(host, fmt.Sprint(port))
 	if _, _, err := net.SplitHostPort(b.addr); err != nil {
 		return err
@@ -1011,9 +2035,15 @@ func (b *Broker) encode(pe packetEncoder, version int16) (err error) {
 		return err
 	}
 
+	isFlexible := version >= 9
+
 	pe.putInt32(b.id)
 
-	err = pe.putString(host)
+	if isFlexible {
+		err = pe.putCompactString(host)
+	} else {
+		err = pe.putString(host)
+	}
 	if err != nil {
 		return err
 	}
@@ -1021,12 +2051,20 @@ func (b *Broker) encode(pe packetEncoder, version int16) (err error) {
 	pe.putInt32(int32(port))
 
 	if version >= 1 {
-		err = pe.putNullableString(b.rack)
+		if isFlexible {
+			err = pe.putNullableCompactString(b.rack)
+		} else {
+			err = pe.putNullableString(b.rack)
+		}
 		if err != nil {
 			return err
 		}
 	}
 
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
@@ -1038,19 +2076,21 @@ func (b *Broker) responseReceiver() {
 			// This was previously incremented in send() and
 			// we are not calling updateIncomingCommunicationMetrics()
 			b.addRequestInFlightMetrics(-1)
-			response.handle(nil, dead)
+			response.runAfterResponseHooks(0, time.Since(response.requestTime), dead)
+			response.handle(nil, b.requestError(response, dead))
 			continue
 		}
 
 		headerLength := getHeaderLength(response.headerVersion)
 		header := make([]byte, headerLength)
 
-		bytesReadHeader, err := b.readFull(header)
+		bytesReadHeader, err := b.readFullWithin(header, response.readTimeout)
 		requestLatency := time.Since(response.requestTime)
 		if err != nil {
 			b.updateIncomingCommunicationMetrics(bytesReadHeader, requestLatency)
 			dead = err
-			response.handle(nil, err)
+			response.runAfterResponseHooks(bytesReadHeader, requestLatency, err)
+			response.handle(nil, b.responseFailed(response, err))
 			continue
 		}
 
@@ -1059,7 +2099,8 @@ func (b *Broker) responseReceiver() {
 		if err != nil {
 			b.updateIncomingCommunicationMetrics(bytesReadHeader, requestLatency)
 			dead = err
-			response.handle(nil, err)
+			response.runAfterResponseHooks(bytesReadHeader, requestLatency, err)
+			response.handle(nil, b.responseFailed(response, err))
 			continue
 		}
 		if decodedHeader.correlationID != response.correlationID {
@@ -1067,16 +2108,38 @@ func (b *Broker) responseReceiver() {
 			// TODO if decoded ID < cur ID, discard until we catch up
 			// TODO if decoded ID > cur ID, save it so when cur ID catches up we have a response
 			dead = PacketDecodingError{fmt.Sprintf("correlation ID didn't match, wanted %d, got %d", response.correlationID, decodedHeader.correlationID)}
-			response.handle(nil, dead)
+			response.runAfterResponseHooks(bytesReadHeader, requestLatency, dead)
+			response.handle(nil, b.responseFailed(response, dead))
+			continue
+		}
+
+		if response.bodies != nil {
+			body := &responseBody{
+				conn:    b.conn,
+				timeout: response.readTimeout,
+				left:    int(decodedHeader.length-int32(headerLength)) + 4,
+				closed:  make(chan none),
+			}
+			response.bodies <- body
+			<-body.closed
+			b.updateIncomingCommunicationMetrics(bytesReadHeader+body.read, requestLatency)
+			response.runAfterResponseHooks(bytesReadHeader+body.read, requestLatency, body.err)
+			if response.release != nil {
+				response.release()
+			}
+			if body.err != nil {
+				dead = body.err
+			}
 			continue
 		}
 
 		buf := make([]byte, decodedHeader.length-int32(headerLength)+4)
-		bytesReadBody, err := b.readFull(buf)
+		bytesReadBody, err := b.readFullWithin(buf, response.readTimeout)
 		b.updateIncomingCommunicationMetrics(bytesReadHeader+bytesReadBody, requestLatency)
+		response.runAfterResponseHooks(bytesReadHeader+bytesReadBody, requestLatency, err)
 		if err != nil {
 			dead = err
-			response.handle(nil, err)
+			response.handle(nil, b.responseFailed(response, err))
 			continue
 		}
 
@@ -1095,6 +2158,10 @@ func getHeaderLength(headerVersion int16) int8 {
 }
 
 func (b *Broker) authenticateViaSASL() error {
+	b.sessionLifetime = 0
+	if newClient, ok := registeredSASLMechanism(b.conf.Net.SASL.Mechanism); ok {
+		return b.sendAndReceiveSASLMechanism(newClient)
+	}
 	switch b.conf.Net.SASL.Mechanism {
 	case SASLTypeOAuth:
 		return b.sendAndReceiveSASLOAuth(b.conf.Net.SASL.TokenProvider)
@@ -1118,8 +2185,8 @@ func (b *Broker) sendAndReceiveKerberos() error {
 func (b *Broker) sendAndReceiveSASLHandshake(saslType SASLMechanism, version int16) error {
 	rb := &SaslHandshakeRequest{Mechanism: string(saslType), Version: version}
 
-	req := &request{correlationID: b.correlationID, clientID: b.conf.ClientID, body: rb}
-	buf, err := encode(req, b.conf.MetricRegistry)
+	req := &request{correlationID: b.correlationID, clientID: b.conf.requestClientID(rb.key()), body: rb}
+	buf, err := encode(req, b.conf.metricsRecorder())
 	if err != nil {
 		return err
 	}
@@ -1131,7 +2198,7 @@ func (b *Broker) sendAndReceiveSASLHandshake(saslType SASLMechanism, version int
 	b.updateOutgoingCommunicationMetrics(bytes)
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
-		Logger.Printf("Failed to send SASL handshake %s: %s\n", b.addr, err.Error())
+		b.log().Warnf("Failed to send SASL handshake %s: %s", b.addr, err.Error())
 		return err
 	}
 	b.correlationID++
@@ -1140,7 +2207,7 @@ func (b *Broker) sendAndReceiveSASLHandshake(saslType SASLMechanism, version int
 	_, err = b.readFull(header)
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
-		Logger.Printf("Failed to read SASL handshake header : %s\n", err.Error())
+		b.log().Warnf("Failed to read SASL handshake header : %s", err.Error())
 		return err
 	}
 
@@ -1149,7 +2216,7 @@ func (b *Broker) sendAndReceiveSASLHandshake(saslType SASLMechanism, version int
 	n, err := b.readFull(payload)
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
-		Logger.Printf("Failed to read SASL handshake payload : %s\n", err.Error())
+		b.log().Warnf("Failed to read SASL handshake payload : %s", err.Error())
 		return err
 	}
 
@@ -1158,16 +2225,16 @@ func (b *Broker) sendAndReceiveSASLHandshake(saslType SASLMechanism, version int
 
 	err = versionedDecode(payload, res, 0)
 	if err != nil {
-		Logger.Printf("Failed to parse SASL handshake : %s\n", err.Error())
+		b.log().Warnf("Failed to parse SASL handshake : %s", err.Error())
 		return err
 	}
 
 	if !errors.Is(res.Err, ErrNoError) {
-		Logger.Printf("Invalid SASL Mechanism : %s\n", res.Err.Error())
+		b.log().Errorf("Invalid SASL Mechanism : %s", res.Err.Error())
 		return res.Err
 	}
 
-	DebugLogger.Print("Completed pre-auth SASL handshake. Available mechanisms: ", res.EnabledMechanisms)
+	b.log().Debugf("Completed pre-auth SASL handshake. Available mechanisms: %v", res.EnabledMechanisms)
 	return nil
 }
 
@@ -1201,7 +2268,7 @@ func (b *Broker) sendAndReceiveSASLPlainAuth() error {
 	if b.conf.Net.SASL.Handshake {
 		handshakeErr := b.sendAndReceiveSASLHandshake(SASLTypePlaintext, b.conf.Net.SASL.Version)
 		if handshakeErr != nil {
-			Logger.Printf("Error while performing SASL handshake %s\n", b.addr)
+			b.log().Warnf("Error while performing SASL handshake %s", b.addr)
 			return handshakeErr
 		}
 	}
@@ -1214,10 +2281,14 @@ func (b *Broker) sendAndReceiveSASLPlainAuth() error {
 
 // sendAndReceiveV0SASLPlainAuth flows the v0 sasl auth NOT wrapped in the kafka protocol
 func (b *Broker) sendAndReceiveV0SASLPlainAuth() error {
-	length := len(b.conf.Net.SASL.AuthIdentity) + 1 + len(b.conf.Net.SASL.User) + 1 + len(b.conf.Net.SASL.Password)
+	user, password, err := b.saslCredentials()
+	if err != nil {
+		return err
+	}
+	length := len(b.conf.Net.SASL.AuthIdentity) + 1 + len(user) + 1 + len(password)
 	authBytes := make([]byte, length+4) // 4 byte length header + auth data
 	binary.BigEndian.PutUint32(authBytes, uint32(length))
-	copy(authBytes[4:], b.conf.Net.SASL.AuthIdentity+"\x00"+b.conf.Net.SASL.User+"\x00"+b.conf.Net.SASL.Password)
+	copy(authBytes[4:], b.conf.Net.SASL.AuthIdentity+"\x00"+user+"\x00"+password)
 
 	requestTime := time.Now()
 	// Will be decremented in updateIncomingCommunicationMetrics (except error)
@@ -1226,7 +2297,7 @@ func (b *Broker) sendAndReceiveV0SASLPlainAuth() error {
 	b.updateOutgoingCommunicationMetrics(bytesWritten)
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
-		Logger.Printf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
+		b.log().Warnf("Failed to write SASL auth header to broker %s: %s", b.addr, err.Error())
 		return err
 	}
 
@@ -1236,11 +2307,11 @@ func (b *Broker) sendAndReceiveV0SASLPlainAuth() error {
 	// If the credentials are valid, we would get a 4 byte response filled with null characters.
 	// Otherwise, the broker closes the connection and we get an EOF
 	if err != nil {
-		Logger.Printf("Failed to read response while authenticating with SASL to broker %s: %s\n", b.addr, err.Error())
+		b.log().Warnf("Failed to read response while authenticating with SASL to broker %s: %s", b.addr, err.Error())
 		return err
 	}
 
-	DebugLogger.Printf("SASL authentication successful with broker %s:%v - %v\n", b.addr, n, header)
+	b.log().Debugf("SASL authentication successful with broker %s:%v - %v", b.addr, n, header)
 	return nil
 }
 
@@ -1257,7 +2328,7 @@ func (b *Broker) sendAndReceiveV1SASLPlainAuth() error {
 
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
-		Logger.Printf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
+		b.log().Warnf("Failed to write SASL auth header to broker %s: %s", b.addr, err.Error())
 		return err
 	}
 
@@ -1268,8 +2339,8 @@ func (b *Broker) sendAndReceiveV1SASLPlainAuth() error {
 
 	// With v1 sasl we get an error message set in the response we can return
 	if err != nil {
-		Logger.Printf(
-			"Error returned from broker %s during SASL authentication: %v\n",
+		b.log().Errorf(
+			"Error returned from broker %s during SASL authentication: %v",
 			b.addr, err.Error())
 		return err
 	}
@@ -1334,7 +2405,7 @@ func (b *Broker) sendClientMessage(message []byte) (bool, error) {
 	isChallenge := len(res.SaslAuthBytes) > 0
 
 	if isChallenge && err != nil {
-		Logger.Printf("Broker rejected authentication token: %s", res.SaslAuthBytes)
+		b.log().Errorf("Broker rejected authentication token: %s", res.SaslAuthBytes)
 	}
 
 	return isChallenge, err
@@ -1352,8 +2423,15 @@ func (b *Broker) sendAndReceiveSASLSCRAMv0() error {
 		return err
 	}
 
-	scramClient := b.conf.Net.SASL.SCRAMClientGeneratorFunc()
-	if err := scramClient.Begin(b.conf.Net.SASL.User, b.conf.Net.SASL.Password, b.conf.Net.SASL.SCRAMAuthzID); err != nil {
+	user, password, err := b.saslCredentials()
+	if err != nil {
+		return err
+	}
+	scramClient, err := b.scramClient()
+	if err != nil {
+		return err
+	}
+	if err := scramClient.Begin(user, password, b.conf.Net.SASL.SCRAMAuthzID); err != nil {
 		return fmt.Errorf("failed to start SCRAM exchange with the server: %w", err)
 	}
 
@@ -1361,6 +2439,7 @@ func (b *Broker) sendAndReceiveSASLSCRAMv0() error {
 	if err != nil {
 		return fmt.Errorf("failed to advance the SCRAM exchange: %w", err)
 	}
+	msg = b.scramClientFirstMessage(msg)
 
 	for !scramClient.Done() {
 		requestTime := time.Now()
@@ -1374,7 +2453,7 @@ func (b *Broker) sendAndReceiveSASLSCRAMv0() error {
 		b.updateOutgoingCommunicationMetrics(length + 4)
 		if err != nil {
 			b.addRequestInFlightMetrics(-1)
-			Logger.Printf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
+			b.log().Warnf("Failed to write SASL auth header to broker %s: %s", b.addr, err.Error())
 			return err
 		}
 		b.correlationID++
@@ -1382,25 +2461,25 @@ func (b *Broker) sendAndReceiveSASLSCRAMv0() error {
 		_, err = b.readFull(header)
 		if err != nil {
 			b.addRequestInFlightMetrics(-1)
-			Logger.Printf("Failed to read response header while authenticating with SASL to broker %s: %s\n", b.addr, err.Error())
+			b.log().Warnf("Failed to read response header while authenticating with SASL to broker %s: %s", b.addr, err.Error())
 			return err
 		}
 		payload := make([]byte, int32(binary.BigEndian.Uint32(header)))
 		n, err := b.readFull(payload)
 		if err != nil {
 			b.addRequestInFlightMetrics(-1)
-			Logger.Printf("Failed to read response payload while authenticating with SASL to broker %s: %s\n", b.addr, err.Error())
+			b.log().Warnf("Failed to read response payload while authenticating with SASL to broker %s: %s", b.addr, err.Error())
 			return err
 		}
 		b.updateIncomingCommunicationMetrics(n+4, time.Since(requestTime))
 		msg, err = scramClient.Step(string(payload))
 		if err != nil {
-			Logger.Println("SASL authentication failed", err)
+			b.log().Errorf("SASL authentication failed %v", err)
 			return err
 		}
 	}
 
-	DebugLogger.Println("SASL authentication succeeded")
+	b.log().Debugf("SASL authentication succeeded")
 	return nil
 }
 
@@ -1409,8 +2488,15 @@ func (b *Broker) sendAndReceiveSASLSCRAMv1() error {
 		return err
 	}
 
-	scramClient := b.conf.Net.SASL.SCRAMClientGeneratorFunc()
-	if err := scramClient.Begin(b.conf.Net.SASL.User, b.conf.Net.SASL.Password, b.conf.Net.SASL.SCRAMAuthzID); err != nil {
+	user, password, err := b.saslCredentials()
+	if err != nil {
+		return err
+	}
+	scramClient, err := b.scramClient()
+	if err != nil {
+		return err
+	}
+	if err := scramClient.Begin(user, password, b.conf.Net.SASL.SCRAMAuthzID); err != nil {
 		return fmt.Errorf("failed to start SCRAM exchange with the server: %w", err)
 	}
 
@@ -1418,6 +2504,7 @@ func (b *Broker) sendAndReceiveSASLSCRAMv1() error {
 	if err != nil {
 		return fmt.Errorf("failed to advance the SCRAM exchange: %w", err)
 	}
+	msg = b.scramClientFirstMessage(msg)
 
 	for !scramClient.Done() {
 		requestTime := time.Now()
@@ -1428,7 +2515,7 @@ func (b *Broker) sendAndReceiveSASLSCRAMv1() error {
 		b.updateOutgoingCommunicationMetrics(bytesWritten)
 		if err != nil {
 			b.addRequestInFlightMetrics(-1)
-			Logger.Printf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
+			b.log().Warnf("Failed to write SASL auth header to broker %s: %s", b.addr, err.Error())
 			return err
 		}
 
@@ -1436,26 +2523,26 @@ func (b *Broker) sendAndReceiveSASLSCRAMv1() error {
 		challenge, err := b.receiveSaslAuthenticateResponse(correlationID)
 		if err != nil {
 			b.addRequestInFlightMetrics(-1)
-			Logger.Printf("Failed to read response while authenticating with SASL to broker %s: %s\n", b.addr, err.Error())
+			b.log().Warnf("Failed to read response while authenticating with SASL to broker %s: %s", b.addr, err.Error())
 			return err
 		}
 
 		b.updateIncomingCommunicationMetrics(len(challenge), time.Since(requestTime))
 		msg, err = scramClient.Step(string(challenge))
 		if err != nil {
-			Logger.Println("SASL authentication failed", err)
+			b.log().Errorf("SASL authentication failed %v", err)
 			return err
 		}
 	}
 
-	DebugLogger.Println("SASL authentication succeeded")
+	b.log().Debugf("SASL authentication succeeded")
 	return nil
 }
 
 func (b *Broker) sendSaslAuthenticateRequest(correlationID int32, msg []byte) (int, error) {
-	rb := &SaslAuthenticateRequest{msg}
-	req := &request{correlationID: correlationID, clientID: b.conf.ClientID, body: rb}
-	buf, err := encode(req, b.conf.MetricRegistry)
+	rb := &SaslAuthenticateRequest{Version: b.saslAuthenticateVersion(), SaslAuthBytes: msg}
+	req := &request{correlationID: correlationID, clientID: b.conf.requestClientID(rb.key()), body: rb}
+	buf, err := encode(req, b.conf.metricsRecorder())
 	if err != nil {
 		return 0, err
 	}
@@ -1487,12 +2574,13 @@ func (b *Broker) receiveSaslAuthenticateResponse(correlationID int32) ([]byte, e
 	}
 
 	res := &SaslAuthenticateResponse{}
-	if err := versionedDecode(buf, res, 0); err != nil {
+	if err := versionedDecode(buf, res, b.saslAuthenticateVersion()); err != nil {
 		return nil, err
 	}
 	if !errors.Is(res.Err, ErrNoError) {
 		return nil, res.Err
 	}
+	b.setSessionLifetime(res)
 	return res.SaslAuthBytes, nil
 }
 
@@ -1505,6 +2593,14 @@ func buildClientFirstMessage(token *AccessToken) ([]byte, error) {
 		if _, ok := token.Extensions[SASLExtKeyAuth]; ok {
 			return []byte{}, fmt.Errorf("the extension `%s` is invalid", SASLExtKeyAuth)
 		}
+		for key, value := range token.Extensions {
+			if !validSASLExtensionKey(key) {
+				return []byte{}, fmt.Errorf("the extension key `%s` is invalid, it must only contain letters", key)
+			}
+			if !validSASLExtensionValue(value) {
+				return []byte{}, fmt.Errorf("the value of the extension `%s` contains invalid characters", key)
+			}
+		}
 		ext = "\x01" + mapToString(token.Extensions, "=", "\x01")
 	}
 
@@ -1513,6 +2609,32 @@ func buildClientFirstMessage(token *AccessToken) ([]byte, error) {
 	return resp, nil
 }
 
+// validSASLExtensionKey returns whether key is a valid key of a SASL/OAUTHBEARER
+// extension: key = 1*(ALPHA), see RFC 7628 section 3.1
+func validSASLExtensionKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// validSASLExtensionValue returns whether value is a valid value of a
+// SASL/OAUTHBEARER extension: value = *(VCHAR / SP / HTAB / CR / LF), see
+// RFC 7628 section 3.1
+func validSASLExtensionValue(value string) bool {
+	for _, c := range value {
+		if (c < 0x21 || c > 0x7e) && c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
 // mapToString returns a list of key-value pairs ordered by key.
 // keyValSep separates the key from the value. elemSep separates each pair.
 func mapToString(extensions map[string]string, keyValSep string, elemSep string) string {
@@ -1528,10 +2650,14 @@ func mapToString(extensions map[string]string, keyValSep string, elemSep string)
 }
 
 func (b *Broker) sendSASLPlainAuthClientResponse(correlationID int32) (int, error) {
-	authBytes := []byte(b.conf.Net.SASL.AuthIdentity + "\x00" + b.conf.Net.SASL.User + "\x00" + b.conf.Net.SASL.Password)
-	rb := &SaslAuthenticateRequest{authBytes}
-	req := &request{correlationID: correlationID, clientID: b.conf.ClientID, body: rb}
-	buf, err := encode(req, b.conf.MetricRegistry)
+	user, password, err := b.saslCredentials()
+	if err != nil {
+		return 0, err
+	}
+	authBytes := []byte(b.conf.Net.SASL.AuthIdentity + "\x00" + user + "\x00" + password)
+	rb := &SaslAuthenticateRequest{Version: b.saslAuthenticateVersion(), SaslAuthBytes: authBytes}
+	req := &request{correlationID: correlationID, clientID: b.conf.requestClientID(rb.key()), body: rb}
+	buf, err := encode(req, b.conf.metricsRecorder())
 	if err != nil {
 		return 0, err
 	}
@@ -1540,11 +2666,11 @@ func (b *Broker) sendSASLPlainAuthClientResponse(correlationID int32) (int, erro
 }
 
 func (b *Broker) sendSASLOAuthBearerClientMessage(initialResp []byte, correlationID int32) (int, error) {
-	rb := &SaslAuthenticateRequest{initialResp}
+	rb := &SaslAuthenticateRequest{Version: b.saslAuthenticateVersion(), SaslAuthBytes: initialResp}
 
-	req := &request{correlationID: correlationID, clientID: b.conf.ClientID, body: rb}
+	req := &request{correlationID: correlationID, clientID: b.conf.requestClientID(rb.key()), body: rb}
 
-	buf, err := encode(req, b.conf.MetricRegistry)
+	buf, err := encode(req, b.conf.metricsRecorder())
 	if err != nil {
 		return 0, err
 	}
@@ -1576,7 +2702,7 @@ func (b *Broker) receiveSASLServerResponse(res *SaslAuthenticateResponse, correl
 		return bytesRead, err
 	}
 
-	if err := versionedDecode(buf, res, 0); err != nil {
+	if err := versionedDecode(buf, res, b.saslAuthenticateVersion()); err != nil {
 		return bytesRead, err
 	}
 
@@ -1588,73 +2714,86 @@ func (b *Broker) receiveSASLServerResponse(res *SaslAuthenticateResponse, correl
 		return bytesRead, err
 	}
 
+	b.setSessionLifetime(res)
 	return bytesRead, nil
 }
 
 func (b *Broker) updateIncomingCommunicationMetrics(bytes int, requestLatency time.Duration) {
 	b.updateRequestLatencyAndInFlightMetrics(requestLatency)
-	b.responseRate.Mark(1)
+	b.responseRate.Add(1)
 
 	if b.brokerResponseRate != nil {
-		b.brokerResponseRate.Mark(1)
+		b.brokerResponseRate.Add(1)
 	}
 
 	responseSize := int64(bytes)
-	b.incomingByteRate.Mark(responseSize)
+	b.incomingByteRate.Add(responseSize)
 	if b.brokerIncomingByteRate != nil {
-		b.brokerIncomingByteRate.Mark(responseSize)
+		b.brokerIncomingByteRate.Add(responseSize)
 	}
 
-	b.responseSize.Update(responseSize)
+	b.responseSize.Observe(responseSize)
 	if b.brokerResponseSize != nil {
-		b.brokerResponseSize.Update(responseSize)
+		b.brokerResponseSize.Observe(responseSize)
 	}
 }
 
 func (b *Broker) updateRequestLatencyAndInFlightMetrics(requestLatency time.Duration) {
 	requestLatencyInMs := int64(requestLatency / time.Millisecond)
-	b.requestLatency.Update(requestLatencyInMs)
+	b.requestLatency.Observe(requestLatencyInMs)
 
 	if b.brokerRequestLatency != nil {
-		b.brokerRequestLatency.Update(requestLatencyInMs)
+		b.brokerRequestLatency.Observe(requestLatencyInMs)
 	}
 
 	b.addRequestInFlightMetrics(-1)
 }
 
 func (b *Broker) addRequestInFlightMetrics(i int64) {
-	b.requestsInFlight.Inc(i)
+	atomic.AddInt32(&b.inFlight, int32(i))
+	if b.owner != nil {
+		// so that RequestsInFlight covers the whole pool
+		atomic.AddInt32(&b.owner.inFlight, int32(i))
+	}
+	b.requestsInFlight.Add(i)
 	if b.brokerRequestsInFlight != nil {
-		b.brokerRequestsInFlight.Inc(i)
+		b.brokerRequestsInFlight.Add(i)
 	}
 }
 
 func (b *Broker) updateOutgoingCommunicationMetrics(bytes int) {
-	b.requestRate.Mark(1)
+	b.requestRate.Add(1)
 	if b.brokerRequestRate != nil {
-		b.brokerRequestRate.Mark(1)
+		b.brokerRequestRate.Add(1)
 	}
 
 	requestSize := int64(bytes)
-	b.outgoingByteRate.Mark(requestSize)
+	b.outgoingByteRate.Add(requestSize)
 	if b.brokerOutgoingByteRate != nil {
-		b.brokerOutgoingByteRate.Mark(requestSize)
+		b.brokerOutgoingByteRate.Add(requestSize)
 	}
 
-	b.requestSize.Update(requestSize)
+	b.requestSize.Observe(requestSize)
 	if b.brokerRequestSize != nil {
-		b.brokerRequestSize.Update(requestSize)
+		b.brokerRequestSize.Observe(requestSize)
 	}
 }
 
-func (b *Broker) updateThrottleMetric(throttleTime time.Duration) {
+func (b *Broker) updateThrottleMetric(rb protocolBody, throttleTime time.Duration) {
 	if throttleTime != time.Duration(0) {
-		DebugLogger.Printf(
-			"producer/broker/%d ProduceResponse throttled %v\n",
+		b.log().Debugf(
+			"broker/%d response throttled %v",
 			b.ID(), throttleTime)
 		if b.brokerThrottleTime != nil {
 			throttleTimeInMs := int64(throttleTime / time.Millisecond)
-			b.brokerThrottleTime.Update(throttleTimeInMs)
+			b.brokerThrottleTime.Observe(throttleTimeInMs)
+		}
+
+		atomic.StoreInt64(&b.throttledUntil, time.Now().Add(throttleTime).UnixNano())
+		atomic.AddInt64(&b.throttledTotal, int64(throttleTime))
+		b.pauseForThrottle(rb, throttleTime)
+		if b.conf != nil && b.conf.Net.ThrottleHook != nil {
+			b.conf.Net.ThrottleHook(b.ID(), throttleTime)
 		}
 	}
 }
@@ -1672,28 +2811,28 @@ func (b *Broker) registerMetrics() {
 }
 
 func (b *Broker) unregisterMetrics() {
+	b.unregisterAPIMetrics()
+	recorder := b.conf.metricsRecorder()
+	labels := brokerMetricLabels(b)
 	for _, name := range b.registeredMetrics {
-		b.conf.MetricRegistry.Unregister(name)
+		recorder.Unregister(name, labels)
 	}
 	b.registeredMetrics = nil
 }
 
-func (b *Broker) registerMeter(name string) metrics.Meter {
-	nameForBroker := getMetricNameForBroker(name, b)
-	b.registeredMetrics = append(b.registeredMetrics, nameForBroker)
-	return metrics.GetOrRegisterMeter(nameForBroker, b.conf.MetricRegistry)
+func (b *Broker) registerMeter(name string) MetricCounter {
+	b.registeredMetrics = append(b.registeredMetrics, name)
+	return b.conf.metricsRecorder().Counter(name, brokerMetricLabels(b))
 }
 
-func (b *Broker) registerHistogram(name string) metrics.Histogram {
-	nameForBroker := getMetricNameForBroker(name, b)
-	b.registeredMetrics = append(b.registeredMetrics, nameForBroker)
-	return getOrRegisterHistogram(nameForBroker, b.conf.MetricRegistry)
+func (b *Broker) registerHistogram(name string) MetricHistogram {
+	b.registeredMetrics = append(b.registeredMetrics, name)
+	return b.conf.metricsRecorder().Histogram(name, brokerMetricLabels(b))
 }
 
-func (b *Broker) registerCounter(name string) metrics.Counter {
-	nameForBroker := getMetricNameForBroker(name, b)
-	b.registeredMetrics = append(b.registeredMetrics, nameForBroker)
-	return metrics.GetOrRegisterCounter(nameForBroker, b.conf.MetricRegistry)
+func (b *Broker) registerCounter(name string) MetricGauge {
+	b.registeredMetrics = append(b.registeredMetrics, name)
+	return b.conf.metricsRecorder().Gauge(name, brokerMetricLabels(b))
 }
 
 func validServerNameTLS(addr string, cfg *tls.Config) *tls.Config {
@@ -1709,7 +2848,7 @@ func validServerNameTLS(addr string, cfg *tls.Config) *tls.Config {
 	c := cfg.Clone()
 	sn, _, err := net.SplitHostPort(addr)
 	if err != nil {
-		Logger.Println(fmt.Errorf("failed to get ServerName from addr %w", err))
+		newContextLogger("addr", addr).Warnf("failed to get ServerName from addr %v", err)
 	}
 	c.ServerName = sn
 	return c
@@ -0,0 +1,111 @@
+package sarama
+
+import (
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// apiMetricNames are the names of the metrics of the requests of an API key,
+// registered by newAPIMetrics
+var apiMetricNames = []string{
+	"request-rate",
+	"request-size",
+	"request-latency-in-ms",
+	"response-size",
+	"request-error-rate",
+}
+
+// apiMetrics are the metrics of the requests of an API key sent to all the
+// brokers, along with those sent to a given broker if any
+type apiMetrics struct {
+	requestRate    MetricCounter
+	requestSize    MetricHistogram
+	requestLatency MetricHistogram
+	responseSize   MetricHistogram
+	errorRate      MetricCounter
+
+	// broker are the metrics of the API key for the broker, nil for the
+	// seed brokers
+	broker *apiMetrics
+}
+
+func newAPIMetrics(recorder MetricsRecorder, labels MetricLabels) *apiMetrics {
+	return &apiMetrics{
+		requestRate:    recorder.Counter("request-rate", labels),
+		requestSize:    recorder.Histogram("request-size", labels),
+		requestLatency: recorder.Histogram("request-latency-in-ms", labels),
+		responseSize:   recorder.Histogram("response-size", labels),
+		errorRate:      recorder.Counter("request-error-rate", labels),
+	}
+}
+
+// updateRequest records a request written, which failed if err is not nil
+func (m *apiMetrics) updateRequest(size int, err error) {
+	for ; m != nil; m = m.broker {
+		m.requestRate.Add(1)
+		m.requestSize.Observe(int64(size))
+		if err != nil {
+			m.errorRate.Add(1)
+		}
+	}
+}
+
+// updateResponse records the response to a request, read after the latency,
+// which failed if err is not nil
+func (m *apiMetrics) updateResponse(size int, latency time.Duration, err error) {
+	for ; m != nil; m = m.broker {
+		m.requestLatency.Observe(int64(latency / time.Millisecond))
+		if size > 0 {
+			m.responseSize.Observe(int64(size))
+		}
+		if err != nil {
+			m.errorRate.Add(1)
+		}
+	}
+}
+
+// apiMetricsFor returns the metrics of the requests of the API key sent to
+// the broker, registering them on first use
+func (b *Broker) apiMetricsFor(key int16) *apiMetrics {
+	b.apiMetricsLock.Lock()
+	defer b.apiMetricsLock.Unlock()
+
+	if m, ok := b.apiMetrics[key]; ok {
+		return m
+	}
+	if b.apiMetrics == nil {
+		b.apiMetrics = make(map[int16]*apiMetrics)
+	}
+	recorder := b.conf.metricsRecorder()
+	api := APIKeyName(key)
+	m := newAPIMetrics(recorder, MetricLabels{"api": api})
+	// as with the other metrics, none are specific to the seed brokers
+	if b.id >= 0 && !metrics.UseNilMetrics {
+		labels := brokerMetricLabels(b)
+		labels["api"] = api
+		m.broker = newAPIMetrics(recorder, labels)
+	}
+	b.apiMetrics[key] = m
+	return m
+}
+
+// unregisterAPIMetrics unregisters the metrics of the API keys specific to
+// the broker
+func (b *Broker) unregisterAPIMetrics() {
+	b.apiMetricsLock.Lock()
+	defer b.apiMetricsLock.Unlock()
+
+	recorder := b.conf.metricsRecorder()
+	for key, m := range b.apiMetrics {
+		if m.broker == nil {
+			continue
+		}
+		labels := brokerMetricLabels(b)
+		labels["api"] = APIKeyName(key)
+		for _, name := range apiMetricNames {
+			recorder.Unregister(name, labels)
+		}
+	}
+	b.apiMetrics = nil
+}
@@ -0,0 +1,62 @@
+package sarama
+
+import (
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestBrokerAPIMetrics(t *testing.T) {
+	mockBroker := NewMockBroker(t, 1)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(mockBroker.Addr(), mockBroker.BrokerID()),
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_1_0_0
+	broker := NewBroker(mockBroker.Addr())
+	broker.id = 1
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	validators := newMetricValidators()
+	validators.registerForAllBrokers(broker, countMeterValidator("request-rate-for-api-Metadata", 2))
+	validators.registerForAllBrokers(broker, countHistogramValidator("request-size-for-api-Metadata", 2))
+	validators.registerForAllBrokers(broker, countHistogramValidator("request-latency-in-ms-for-api-Metadata", 2))
+	validators.registerForAllBrokers(broker, countHistogramValidator("response-size-for-api-Metadata", 2))
+	validators.registerForAllBrokers(broker, countMeterValidator("request-error-rate-for-api-Metadata", 0))
+	validators.run(t, config.MetricRegistry)
+
+	safeClose(t, broker)
+	if config.MetricRegistry.Get("request-rate-for-api-Metadata-for-broker-1") != nil {
+		t.Error("Expected the metrics of the broker to be unregistered once closed")
+	}
+	if config.MetricRegistry.Get("request-rate-for-api-Metadata") == nil {
+		t.Error("Expected the metrics of all the brokers to remain registered")
+	}
+}
+
+func TestBrokerAPIMetricsSeedBroker(t *testing.T) {
+	registry := metrics.NewRegistry()
+	config := NewTestConfig()
+	config.MetricRegistry = registry
+	broker := NewBroker("localhost:9092")
+	broker.conf = config
+
+	broker.apiMetricsFor(0).updateRequest(10, nil)
+	if m := broker.apiMetricsFor(0); m.broker != nil {
+		t.Error("Expected no metrics specific to a seed broker")
+	}
+	validators := newMetricValidators()
+	validators.register(countMeterValidator("request-rate-for-api-Produce", 1))
+	validators.run(t, registry)
+}
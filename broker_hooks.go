@@ -0,0 +1,173 @@
+package sarama
+
+import (
+	"time"
+)
+
+// BrokerHooks are functions called around the requests sent to a broker, for
+// tracing, auditing or custom metrics, see Config.Net.Hooks and
+// Broker.AddHooks. Either function may be nil. They must not block as they
+// are called while sending the requests and from the goroutine reading the
+// responses.
+type BrokerHooks struct {
+	// BeforeRequest is called before a request is written.
+	BeforeRequest func(RequestEvent)
+	// AfterResponse is called after the response to a request is read, or
+	// failed to be, as when the request could not be written. It is not
+	// called for requests without response, such as produce requests with
+	// Producer.RequiredAcks set to NoResponse.
+	AfterResponse func(ResponseEvent)
+	// AfterAuthentication is called once a SASL authentication, or
+	// re-authentication, of the connection to the broker succeeded or
+	// failed.
+	AfterAuthentication func(AuthenticationEvent)
+}
+
+// RequestEvent describes a request sent to a broker.
+type RequestEvent struct {
+	// BrokerID is the ID of the broker, or -1 for a seed broker whose ID is
+	// not known yet.
+	BrokerID int32
+	Addr     string
+
+	APIKey        int16
+	APIVersion    int16
+	CorrelationID int32
+	// RequestSize is the size in bytes of the encoded request, header
+	// included.
+	RequestSize int
+}
+
+// ResponseEvent describes the response to a request sent to a broker.
+type ResponseEvent struct {
+	RequestEvent
+	// ResponseSize is the size in bytes of the response read, header
+	// included.
+	ResponseSize int
+	// Latency is the time elapsed between the request being written and its
+	// response being read.
+	Latency time.Duration
+	// Err is the error writing the request or reading the response, if
+	// any. The errors reported by the response itself are not decoded at
+	// this point.
+	Err error
+}
+
+// AuthenticationEvent describes a SASL authentication with a broker.
+type AuthenticationEvent struct {
+	// BrokerID is the ID of the broker, or -1 for a seed broker whose ID is
+	// not known yet.
+	BrokerID  int32
+	Addr      string
+	Mechanism SASLMechanism
+	// Reauthentication is whether the session of the connection was
+	// authenticated again before its lifetime expired (KIP-368).
+	Reauthentication bool
+	// Latency is the duration of the authentication exchange.
+	Latency time.Duration
+	// Err is the reason the authentication failed, nil if it succeeded.
+	Err error
+}
+
+// AddHooks registers hooks called around the requests sent on the
+// connection to the broker in addition to those of Config.Net.Hooks. They
+// apply to all the connections of Net.ConnectionsPerBroker.
+func (b *Broker) AddHooks(hooks BrokerHooks) {
+	b.hooksLock.Lock()
+	defer b.hooksLock.Unlock()
+
+	// copied so that the slices returned by brokerHooks are never modified
+	registered := make([]BrokerHooks, len(b.hooks), len(b.hooks)+1)
+	copy(registered, b.hooks)
+	b.hooks = append(registered, hooks)
+}
+
+// brokerHooks returns the hooks of Config.Net.Hooks and those registered with
+// AddHooks, on the owner of the connection for the additional ones of
+// Net.ConnectionsPerBroker, followed by those tracing the requests with
+// Config.Tracing. It must be called with b.lock held.
+func (b *Broker) brokerHooks() []BrokerHooks {
+	registrar := b
+	if b.owner != nil {
+		registrar = b.owner
+	}
+	registrar.hooksLock.Lock()
+	registered := registrar.hooks
+	registrar.hooksLock.Unlock()
+
+	if b.conf.Tracing.Tracer == nil {
+		if len(registered) == 0 {
+			return b.conf.Net.Hooks
+		}
+		if len(b.conf.Net.Hooks) == 0 {
+			return registered
+		}
+	} else if b.tracing == nil {
+		// the correlation IDs identifying the spans are those of the
+		// connection
+		b.tracing = newRPCTracer(b.conf)
+	}
+	hooks := make([]BrokerHooks, 0, len(b.conf.Net.Hooks)+len(registered)+1)
+	hooks = append(hooks, b.conf.Net.Hooks...)
+	hooks = append(hooks, registered...)
+	if b.tracing != nil {
+		hooks = append(hooks, b.tracing.hooks())
+	}
+	return hooks
+}
+
+func runBeforeRequestHooks(hooks []BrokerHooks, event RequestEvent) {
+	for _, hook := range hooks {
+		if hook.BeforeRequest != nil {
+			hook.BeforeRequest(event)
+		}
+	}
+}
+
+func runAfterResponseHooks(hooks []BrokerHooks, event ResponseEvent) {
+	for _, hook := range hooks {
+		if hook.AfterResponse != nil {
+			hook.AfterResponse(event)
+		}
+	}
+}
+
+// runAfterResponseHooks calls the hooks the request was sent with, after
+// updating the metrics of its API key
+func (p *responsePromise) runAfterResponseHooks(size int, latency time.Duration, err error) {
+	p.apiMetrics.updateResponse(size, latency, err)
+	if len(p.hooks) == 0 {
+		return
+	}
+	runAfterResponseHooks(p.hooks, ResponseEvent{RequestEvent: p.event, ResponseSize: size, Latency: latency, Err: err})
+}
+
+// recordAuthentication runs the AfterAuthentication hooks and updates the
+// authentication metrics once an authentication started at start completed
+// with err. It must be called with b.lock held.
+func (b *Broker) recordAuthentication(reauthentication bool, start time.Time, err error) {
+	name := "authentication-rate"
+	if reauthentication {
+		name = "reauthentication-rate"
+	}
+	if err == nil {
+		name = "successful-" + name
+	} else {
+		name = "failed-" + name
+	}
+	b.conf.metricsRecorder().Counter(name, nil).Add(1)
+
+	event := AuthenticationEvent{
+		BrokerID:         b.id,
+		Addr:             b.addr,
+		Mechanism:        b.conf.Net.SASL.Mechanism,
+		Reauthentication: reauthentication,
+		Latency:          time.Since(start),
+		Err:              err,
+	}
+	for _, hook := range b.brokerHooks() {
+		if hook.AfterAuthentication != nil {
+			hook.AfterAuthentication(event)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package sarama
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestBrokerHooks(t *testing.T) {
+	mockBroker := NewMockBroker(t, 1)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(mockBroker.Addr(), mockBroker.BrokerID()),
+	})
+
+	var lock sync.Mutex
+	var requests []RequestEvent
+	var responses []ResponseEvent
+	var registered int
+
+	config := NewTestConfig()
+	config.Net.Hooks = []BrokerHooks{{
+		BeforeRequest: func(event RequestEvent) {
+			lock.Lock()
+			defer lock.Unlock()
+			requests = append(requests, event)
+		},
+		AfterResponse: func(event ResponseEvent) {
+			lock.Lock()
+			defer lock.Unlock()
+			responses = append(responses, event)
+		},
+	}}
+
+	broker := NewBroker(mockBroker.Addr())
+	broker.AddHooks(BrokerHooks{
+		AfterResponse: func(ResponseEvent) {
+			lock.Lock()
+			defer lock.Unlock()
+			registered++
+		},
+	})
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	for i := 0; i < 2; i++ {
+		if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(requests) != 2 || len(responses) != 2 {
+		t.Fatalf("expected 2 requests and responses, got %d and %d", len(requests), len(responses))
+	}
+	if registered != 2 {
+		t.Errorf("expected the registered hook to be called twice, got %d", registered)
+	}
+	for i, response := range responses {
+		request := requests[i]
+		if request.APIKey != 3 || request.APIVersion != 0 || request.Addr != mockBroker.Addr() {
+			t.Errorf("unexpected request event %+v", request)
+		}
+		if request.RequestSize <= 0 {
+			t.Errorf("expected the request size to be positive, got %d", request.RequestSize)
+		}
+		if response.RequestEvent != request {
+			t.Errorf("expected the response event of request %+v, got %+v", request, response.RequestEvent)
+		}
+		if response.ResponseSize <= 0 || response.Err != nil {
+			t.Errorf("unexpected response event %+v", response)
+		}
+	}
+	if requests[0].CorrelationID == requests[1].CorrelationID {
+		t.Error("expected distinct correlation IDs")
+	}
+}
+
+func TestBrokerHooksWriteFailure(t *testing.T) {
+	mockBroker := NewMockBroker(t, 1)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(mockBroker.Addr(), mockBroker.BrokerID()),
+	})
+
+	var lock sync.Mutex
+	var requests int
+	var responses []ResponseEvent
+
+	config := NewTestConfig()
+	config.Net.Hooks = []BrokerHooks{{
+		BeforeRequest: func(RequestEvent) {
+			lock.Lock()
+			defer lock.Unlock()
+			requests++
+		},
+		AfterResponse: func(event ResponseEvent) {
+			lock.Lock()
+			defer lock.Unlock()
+			responses = append(responses, event)
+		},
+	}}
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = broker.Close() }()
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the request fails to be written on the closed connection
+	broker.lock.Lock()
+	_ = broker.conn.Close()
+	broker.lock.Unlock()
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if requests != 2 || len(responses) != 2 {
+		t.Fatalf("expected 2 requests and responses, got %d and %d", requests, len(responses))
+	}
+	if responses[1].Err == nil || responses[1].ResponseSize != 0 {
+		t.Errorf("expected the response event of the failed write, got %+v", responses[1])
+	}
+}
+
+func TestBrokerAuthenticationHooks(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"SaslHandshakeRequest": NewMockSaslHandshakeResponse(t).
+			SetEnabledMechanisms([]string{SASLTypePlaintext}),
+		"SaslAuthenticateRequest": NewMockSaslAuthenticateResponse(t).
+			SetSessionLifetimeMs(100),
+	})
+
+	var lock sync.Mutex
+	var events []AuthenticationEvent
+
+	config := NewTestConfig()
+	config.Version = V2_2_0_0
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Mechanism = SASLTypePlaintext
+	config.Net.SASL.User = "user"
+	config.Net.SASL.Password = "password"
+	config.Net.SASL.Version = SASLHandshakeV1
+	config.Net.Hooks = []BrokerHooks{{
+		AfterAuthentication: func(event AuthenticationEvent) {
+			lock.Lock()
+			defer lock.Unlock()
+			events = append(events, event)
+		},
+	}}
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+	if _, err := broker.Connected(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lock.Lock()
+		count := len(events)
+		lock.Unlock()
+		if count >= 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(events) < 2 {
+		t.Fatalf("expected an authentication and a re-authentication, got %d events", len(events))
+	}
+	for i, event := range events[:2] {
+		if event.Addr != mockBroker.Addr() || event.Mechanism != SASLTypePlaintext || event.Err != nil {
+			t.Errorf("unexpected authentication event %+v", event)
+		}
+		if event.Reauthentication != (i > 0) {
+			t.Errorf("expected event %d to have Reauthentication %t", i, i > 0)
+		}
+	}
+	if count := metrics.GetOrRegisterMeter("successful-authentication-rate", config.MetricRegistry).Count(); count != 1 {
+		t.Errorf("expected 1 successful authentication, got %d", count)
+	}
+	if count := metrics.GetOrRegisterMeter("successful-reauthentication-rate", config.MetricRegistry).Count(); count < 1 {
+		t.Errorf("expected a successful re-authentication, got %d", count)
+	}
+}
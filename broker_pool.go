@@ -0,0 +1,56 @@
+package sarama
+
+import "sync/atomic"
+
+// openPool opens the additional connections of Net.ConnectionsPerBroker. It
+// must be called with b.lock held once b is connected.
+func (b *Broker) openPool() {
+	b.pool = make([]*Broker, 0, b.conf.Net.ConnectionsPerBroker-1)
+	for i := 1; i < b.conf.Net.ConnectionsPerBroker; i++ {
+		conn := &Broker{id: b.id, addr: b.addr, rack: b.rack, owner: b}
+		if err := conn.Open(b.conf); err != nil {
+			b.log().Warnf("Failed to open additional connection to broker %s: %s", b.addr, err)
+			continue
+		}
+		b.pool = append(b.pool, conn)
+	}
+}
+
+// closePool must be called with b.lock held
+func (b *Broker) closePool(abort bool) {
+	for _, conn := range b.pool {
+		if err := conn.close(abort); err != nil && err != ErrNotConnected {
+			b.log().Warnf("Error while closing additional connection to broker %s: %s", b.addr, err)
+		}
+	}
+	b.pool = nil
+}
+
+// connectionFor returns the connection to send the request on, any of the
+// pool in turn except for the produce requests of the idempotent producers,
+// which are pinned to b so that the brokers receive their sequence numbers in
+// order
+func (b *Broker) connectionFor(rb protocolBody) *Broker {
+	b.lock.Lock()
+	pool := b.pool
+	conf := b.conf
+	b.lock.Unlock()
+
+	if len(pool) == 0 {
+		return b
+	}
+	if _, ok := rb.(*ProduceRequest); ok && conf.Producer.Idempotent {
+		return b
+	}
+
+	next := atomic.AddUint32(&b.nextConn, 1) % uint32(len(pool)+1)
+	if next == 0 {
+		return b
+	}
+	conn := pool[next-1]
+	if connected, _ := conn.Connected(); !connected {
+		// the connection failed, fall back to the first one
+		return b
+	}
+	return conn
+}
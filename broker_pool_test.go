@@ -0,0 +1,104 @@
+package sarama
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+)
+
+type countingListener struct {
+	net.Listener
+	accepted int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		atomic.AddInt32(&l.accepted, 1)
+	}
+	return conn, err
+}
+
+func TestBrokerConnectionsPerBroker(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counting := &countingListener{Listener: listener}
+	mockBroker := NewMockBrokerListener(t, 1, counting)
+	defer mockBroker.Close()
+
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(mockBroker.Addr(), mockBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Net.ConnectionsPerBroker = 3
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 6; i++ {
+		if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if accepted := atomic.LoadInt32(&counting.accepted); accepted != 3 {
+		t.Errorf("expected 3 connections, got %d", accepted)
+	}
+	if requests := len(mockBroker.History()); requests != 6 {
+		t.Errorf("expected 6 requests, got %d", requests)
+	}
+
+	conns := make(map[*Broker]int)
+	for i := 0; i < 6; i++ {
+		conns[broker.connectionFor(&MetadataRequest{})]++
+	}
+	if len(conns) != 3 {
+		t.Errorf("expected requests to be spread over 3 connections, got %d", len(conns))
+	}
+
+	if err := broker.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if broker.pool != nil {
+		t.Error("expected the additional connections to be closed")
+	}
+}
+
+func TestBrokerConnectionsPerBrokerProduce(t *testing.T) {
+	for _, idempotent := range []bool{false, true} {
+		mockBroker := NewMockBroker(t, 1)
+
+		config := NewTestConfig()
+		config.Version = V0_11_0_0
+		config.Net.ConnectionsPerBroker = 3
+		config.Net.MaxOpenRequests = 1
+		config.Producer.Idempotent = idempotent
+		config.Producer.RequiredAcks = WaitForAll
+
+		broker := NewBroker(mockBroker.Addr())
+		if err := broker.Open(config); err != nil {
+			t.Fatal(err)
+		}
+		if connected, err := broker.Connected(); !connected {
+			t.Fatal(err)
+		}
+
+		conns := make(map[*Broker]int)
+		for i := 0; i < 6; i++ {
+			conns[broker.connectionFor(&ProduceRequest{})]++
+		}
+		if idempotent && (len(conns) != 1 || conns[broker] != 6) {
+			t.Error("expected the idempotent produce requests to be pinned to the first connection")
+		} else if !idempotent && len(conns) != 3 {
+			t.Errorf("expected produce requests to be spread over 3 connections, got %d", len(conns))
+		}
+
+		safeClose(t, broker)
+		mockBroker.Close()
+	}
+}
@@ -0,0 +1,88 @@
+package sarama
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// BrokerSelector picks the broker to send a request which any broker can
+// answer to, such as metadata requests, coordinator lookups and most
+// ClusterAdmin requests. See Config.BrokerSelector.
+type BrokerSelector interface {
+	// Select returns one of the given brokers, which are sorted by ID and never
+	// empty. It may be called concurrently.
+	Select(brokers []*Broker) *Broker
+}
+
+// BrokerSelectorFunc is an adapter to use a function as a BrokerSelector.
+type BrokerSelectorFunc func(brokers []*Broker) *Broker
+
+// Select calls f(brokers).
+func (f BrokerSelectorFunc) Select(brokers []*Broker) *Broker {
+	return f(brokers)
+}
+
+type roundRobinBrokerSelector struct {
+	next uint32
+}
+
+// NewRoundRobinBrokerSelector returns a BrokerSelector cycling through the
+// brokers in turn.
+func NewRoundRobinBrokerSelector() BrokerSelector {
+	return &roundRobinBrokerSelector{}
+}
+
+func (s *roundRobinBrokerSelector) Select(brokers []*Broker) *Broker {
+	next := atomic.AddUint32(&s.next, 1) - 1
+	return brokers[next%uint32(len(brokers))]
+}
+
+type leastInFlightBrokerSelector struct{}
+
+// NewLeastInFlightBrokerSelector returns a BrokerSelector picking the broker
+// with the fewest requests in flight, ties being broken at random. This is
+// the policy of Client.LeastLoadedBroker when Config.BrokerSelector is not set.
+func NewLeastInFlightBrokerSelector() BrokerSelector {
+	return leastInFlightBrokerSelector{}
+}
+
+func (leastInFlightBrokerSelector) Select(brokers []*Broker) *Broker {
+	offset := rand.Intn(len(brokers))
+	var selected *Broker
+	for i := range brokers {
+		broker := brokers[(offset+i)%len(brokers)]
+		if selected == nil || broker.RequestsInFlight() < selected.RequestsInFlight() {
+			selected = broker
+		}
+	}
+	return selected
+}
+
+type rackLocalBrokerSelector struct {
+	rack     string
+	fallback BrokerSelector
+}
+
+// NewRackLocalBrokerSelector returns a BrokerSelector preferring the brokers
+// in the given rack, usually Config.RackID, and picking among them, or among
+// all brokers if none is in the rack, with the fallback selector. A nil
+// fallback picks the broker with the fewest requests in flight.
+func NewRackLocalBrokerSelector(rack string, fallback BrokerSelector) BrokerSelector {
+	if fallback == nil {
+		fallback = NewLeastInFlightBrokerSelector()
+	}
+	return &rackLocalBrokerSelector{rack: rack, fallback: fallback}
+}
+
+func (s *rackLocalBrokerSelector) Select(brokers []*Broker) *Broker {
+	local := make([]*Broker, 0, len(brokers))
+	for _, broker := range brokers {
+		if broker.Rack() == s.rack {
+			local = append(local, broker)
+		}
+	}
+	if len(local) == 0 {
+		return s.fallback.Select(brokers)
+	}
+	return s.fallback.Select(local)
+}
@@ -0,0 +1,55 @@
+package sarama
+
+import "testing"
+
+func testSelectorBrokers(racks ...string) []*Broker {
+	brokers := make([]*Broker, len(racks))
+	for i := range racks {
+		brokers[i] = &Broker{id: int32(i + 1), rack: &racks[i]}
+	}
+	return brokers
+}
+
+func TestRoundRobinBrokerSelector(t *testing.T) {
+	brokers := testSelectorBrokers("a", "b", "c")
+	selector := NewRoundRobinBrokerSelector()
+
+	for i, expected := range []int32{1, 2, 3, 1} {
+		if id := selector.Select(brokers).ID(); id != expected {
+			t.Errorf("selection %d: expected broker %d, got %d", i, expected, id)
+		}
+	}
+}
+
+func TestLeastInFlightBrokerSelector(t *testing.T) {
+	brokers := testSelectorBrokers("a", "b", "c")
+	brokers[0].inFlight = 3
+	brokers[1].inFlight = 1
+	brokers[2].inFlight = 2
+	selector := NewLeastInFlightBrokerSelector()
+
+	for i := 0; i < 10; i++ {
+		if id := selector.Select(brokers).ID(); id != 2 {
+			t.Fatalf("expected broker 2 with the fewest requests in flight, got %d", id)
+		}
+	}
+}
+
+func TestRackLocalBrokerSelector(t *testing.T) {
+	brokers := testSelectorBrokers("a", "b", "b")
+	brokers[1].inFlight = 1
+
+	if id := NewRackLocalBrokerSelector("b", nil).Select(brokers).ID(); id != 3 {
+		t.Errorf("expected the least loaded broker of rack b, got %d", id)
+	}
+	if id := NewRackLocalBrokerSelector("a", NewRoundRobinBrokerSelector()).Select(brokers).ID(); id != 1 {
+		t.Errorf("expected the broker of rack a, got %d", id)
+	}
+
+	selector := NewRackLocalBrokerSelector("c", NewRoundRobinBrokerSelector())
+	for i, expected := range []int32{1, 2, 3} {
+		if id := selector.Select(brokers).ID(); id != expected {
+			t.Errorf("selection %d without local broker: expected broker %d, got %d", i, expected, id)
+		}
+	}
+}
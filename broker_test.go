@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"reflect"
 	"testing"
@@ -294,14 +295,14 @@ func TestSASLOAuthBearer(t *testing.T) {
 
 			// broker executes SASL requests against mockBroker
 			broker := NewBroker(mockBroker.Addr())
-			broker.requestRate = metrics.NilMeter{}
-			broker.outgoingByteRate = metrics.NilMeter{}
-			broker.incomingByteRate = metrics.NilMeter{}
-			broker.requestSize = metrics.NilHistogram{}
-			broker.responseSize = metrics.NilHistogram{}
-			broker.responseRate = metrics.NilMeter{}
-			broker.requestLatency = metrics.NilHistogram{}
-			broker.requestsInFlight = metrics.NilCounter{}
+			broker.requestRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.outgoingByteRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.incomingByteRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.requestSize = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.responseSize = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.responseRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.requestLatency = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.requestsInFlight = goMetricsCounter{metrics.NilCounter{}}
 
 			conf := NewTestConfig()
 			conf.Net.SASL.Mechanism = SASLTypeOAuth
@@ -411,14 +412,14 @@ func TestSASLSCRAMSHAXXX(t *testing.T) {
 			mockBroker := NewMockBroker(t, 0)
 			broker := NewBroker(mockBroker.Addr())
 			// broker executes SASL requests against mockBroker
-			broker.requestRate = metrics.NilMeter{}
-			broker.outgoingByteRate = metrics.NilMeter{}
-			broker.incomingByteRate = metrics.NilMeter{}
-			broker.requestSize = metrics.NilHistogram{}
-			broker.responseSize = metrics.NilHistogram{}
-			broker.responseRate = metrics.NilMeter{}
-			broker.requestLatency = metrics.NilHistogram{}
-			broker.requestsInFlight = metrics.NilCounter{}
+			broker.requestRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.outgoingByteRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.incomingByteRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.requestSize = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.responseSize = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.responseRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.requestLatency = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.requestsInFlight = goMetricsCounter{metrics.NilCounter{}}
 
 			mockSASLAuthResponse := NewMockSaslAuthenticateResponse(t).SetAuthBytes([]byte(test.scramChallengeResp))
 			mockSASLHandshakeResponse := NewMockSaslHandshakeResponse(t).SetEnabledMechanisms([]string{SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512})
@@ -534,14 +535,14 @@ func TestSASLPlainAuth(t *testing.T) {
 
 			// broker executes SASL requests against mockBroker
 			broker := NewBroker(mockBroker.Addr())
-			broker.requestRate = metrics.NilMeter{}
-			broker.outgoingByteRate = metrics.NilMeter{}
-			broker.incomingByteRate = metrics.NilMeter{}
-			broker.requestSize = metrics.NilHistogram{}
-			broker.responseSize = metrics.NilHistogram{}
-			broker.responseRate = metrics.NilMeter{}
-			broker.requestLatency = metrics.NilHistogram{}
-			broker.requestsInFlight = metrics.NilCounter{}
+			broker.requestRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.outgoingByteRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.incomingByteRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.requestSize = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.responseSize = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.responseRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.requestLatency = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.requestsInFlight = goMetricsCounter{metrics.NilCounter{}}
 
 			conf := NewTestConfig()
 			conf.Net.SASL.Mechanism = SASLTypePlaintext
@@ -618,14 +619,14 @@ func TestSASLReadTimeout(t *testing.T) {
 
 	broker := NewBroker(mockBroker.Addr())
 	{
-		broker.requestRate = metrics.NilMeter{}
-		broker.outgoingByteRate = metrics.NilMeter{}
-		broker.incomingByteRate = metrics.NilMeter{}
-		broker.requestSize = metrics.NilHistogram{}
-		broker.responseSize = metrics.NilHistogram{}
-		broker.responseRate = metrics.NilMeter{}
-		broker.requestLatency = metrics.NilHistogram{}
-		broker.requestsInFlight = metrics.NilCounter{}
+		broker.requestRate = goMetricsMeter{metrics.NilMeter{}}
+		broker.outgoingByteRate = goMetricsMeter{metrics.NilMeter{}}
+		broker.incomingByteRate = goMetricsMeter{metrics.NilMeter{}}
+		broker.requestSize = goMetricsHistogram{metrics.NilHistogram{}}
+		broker.responseSize = goMetricsHistogram{metrics.NilHistogram{}}
+		broker.responseRate = goMetricsMeter{metrics.NilMeter{}}
+		broker.requestLatency = goMetricsHistogram{metrics.NilHistogram{}}
+		broker.requestsInFlight = goMetricsCounter{metrics.NilCounter{}}
 	}
 
 	conf := NewTestConfig()
@@ -711,14 +712,14 @@ func TestGSSAPIKerberosAuth_Authorize(t *testing.T) {
 				return nil
 			})
 			broker := NewBroker(mockBroker.Addr())
-			broker.requestRate = metrics.NilMeter{}
-			broker.outgoingByteRate = metrics.NilMeter{}
-			broker.incomingByteRate = metrics.NilMeter{}
-			broker.requestSize = metrics.NilHistogram{}
-			broker.responseSize = metrics.NilHistogram{}
-			broker.responseRate = metrics.NilMeter{}
-			broker.requestLatency = metrics.NilHistogram{}
-			broker.requestsInFlight = metrics.NilCounter{}
+			broker.requestRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.outgoingByteRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.incomingByteRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.requestSize = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.responseSize = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.responseRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.requestLatency = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.requestsInFlight = goMetricsCounter{metrics.NilCounter{}}
 			conf := NewTestConfig()
 			conf.Net.SASL.Mechanism = SASLTypeGSSAPI
 			conf.Net.SASL.GSSAPI.ServiceName = "kafka"
@@ -808,6 +809,39 @@ func TestBuildClientFirstMessage(t *testing.T) {
 			expected:    []byte(""),
 			expectError: true,
 		},
+		{
+			name: "Build SASL client initial response with hosted Kafka extensions",
+			token: &AccessToken{
+				Token: "the-token",
+				Extensions: map[string]string{
+					"logicalCluster": "lkc-1",
+					"identityPoolId": "pool-1",
+				},
+			},
+			expected: []byte("n,,\x01auth=Bearer the-token\x01identityPoolId=pool-1\x01logicalCluster=lkc-1\x01\x01"),
+		},
+		{
+			name: "Build SASL client initial response using an invalid extension key",
+			token: &AccessToken{
+				Token: "the-token",
+				Extensions: map[string]string{
+					"logical_cluster": "lkc-1",
+				},
+			},
+			expected:    []byte(""),
+			expectError: true,
+		},
+		{
+			name: "Build SASL client initial response using an invalid extension value",
+			token: &AccessToken{
+				Token: "the-token",
+				Extensions: map[string]string{
+					"x": "1\x01",
+				},
+			},
+			expected:    []byte(""),
+			expectError: true,
+		},
 	}
 
 	for i, test := range testTable {
@@ -1264,6 +1298,170 @@ func validateBrokerMetrics(t *testing.T, broker *Broker, mockBrokerMetrics broke
 	metricValidators.run(t, broker.conf.MetricRegistry)
 }
 
+func TestBrokerAsyncSend(t *testing.T) {
+	mockBroker := NewMockBroker(t, 1)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(mockBroker.Addr(), mockBroker.BrokerID()),
+		"ListGroupsRequest": NewMockListGroupsResponse(t).
+			AddGroup("my-group", "consumer"),
+	})
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.AsyncSend(&MetadataRequest{}, &MetadataResponse{}, func(ProtocolBody, error) {}); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected before opening the broker, got %v", err)
+	}
+	config := NewTestConfig()
+	config.Version = V0_10_0_0
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	responses := make(chan ProtocolBody, 2)
+	callback := func(response ProtocolBody, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		responses <- response
+	}
+	if err := broker.AsyncSend(&MetadataRequest{}, &MetadataResponse{}, callback); err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.AsyncSend(&ListGroupsRequest{}, &ListGroupsResponse{}, callback); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, ok := (<-responses).(*MetadataResponse)
+	if !ok || len(metadata.Brokers) != 1 {
+		t.Errorf("expected a metadata response with 1 broker, got %#v", metadata)
+	}
+	groups, ok := (<-responses).(*ListGroupsResponse)
+	if !ok || groups.Groups["my-group"] != "consumer" {
+		t.Errorf("expected a list groups response with my-group, got %#v", groups)
+	}
+}
+
+func TestBrokerDrain(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		timeout  time.Duration
+		expected error
+	}{
+		{"drained", 5 * time.Second, nil},
+		{"timeout", 50 * time.Millisecond, ErrDrainTimeout},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mockBroker := NewMockBroker(t, 1)
+			defer mockBroker.Close()
+			mockBroker.SetLatency(300 * time.Millisecond)
+			mockBroker.SetHandlerByMap(map[string]MockResponse{
+				"MetadataRequest": NewMockMetadataResponse(t).
+					SetBroker(mockBroker.Addr(), mockBroker.BrokerID()),
+			})
+
+			broker := NewBroker(mockBroker.Addr())
+			if err := broker.Open(NewTestConfig()); err != nil {
+				t.Fatal(err)
+			}
+
+			inFlight := make(chan error)
+			go func() {
+				_, err := broker.GetMetadata(&MetadataRequest{})
+				inFlight <- err
+			}()
+			for broker.RequestsInFlight() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			drained := make(chan error)
+			go func() { drained <- broker.Drain(test.timeout) }()
+			for {
+				broker.lock.Lock()
+				draining := broker.draining || broker.conn == nil
+				broker.lock.Unlock()
+				if draining {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+			if _, err := broker.GetMetadata(&MetadataRequest{}); !errors.Is(err, ErrNotConnected) {
+				t.Errorf("expected requests sent while draining to fail with ErrNotConnected, got %v", err)
+			}
+
+			if err := <-drained; !errors.Is(err, test.expected) {
+				t.Errorf("expected Drain to return %v, got %v", test.expected, err)
+			}
+			err := <-inFlight
+			if test.expected == nil && err != nil {
+				t.Errorf("expected the request in flight to succeed, got %v", err)
+			}
+			if test.expected != nil && err == nil {
+				t.Error("expected the request in flight to fail")
+			}
+			if connected, _ := broker.Connected(); connected {
+				t.Error("expected the broker to be closed")
+			}
+		})
+	}
+}
+
+type rotatingCredentials struct {
+	passwords []string
+	calls     int
+}
+
+func (r *rotatingCredentials) Credentials() (string, string, error) {
+	password := r.passwords[r.calls%len(r.passwords)]
+	r.calls++
+	return "user", password, nil
+}
+
+func TestSASLCredentialsProvider(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"SaslAuthenticateRequest": NewMockSaslAuthenticateResponse(t),
+		"SaslHandshakeRequest": NewMockSaslHandshakeResponse(t).
+			SetEnabledMechanisms([]string{SASLTypePlaintext}),
+	})
+
+	provider := &rotatingCredentials{passwords: []string{"old", "new"}}
+	conf := NewTestConfig()
+	conf.Version = V1_0_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypePlaintext
+	conf.Net.SASL.Version = SASLHandshakeV1
+	conf.Net.SASL.CredentialsProvider = provider
+	if err := conf.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	broker := NewBroker(mockBroker.Addr())
+	for i := 0; i < 2; i++ {
+		if err := broker.Open(conf); err != nil {
+			t.Fatal(err)
+		}
+		if connected, err := broker.Connected(); !connected {
+			t.Fatal(err)
+		}
+		safeClose(t, broker)
+	}
+
+	var authBytes []string
+	for _, rr := range mockBroker.History() {
+		if req, ok := rr.Request.(*SaslAuthenticateRequest); ok {
+			authBytes = append(authBytes, string(req.SaslAuthBytes))
+		}
+	}
+	expected := []string{"\x00user\x00old", "\x00user\x00new"}
+	if !reflect.DeepEqual(authBytes, expected) {
+		t.Errorf("expected the authentications %q, got %q", expected, authBytes)
+	}
+}
+
 func BenchmarkBroker_Open(b *testing.B) {
 	mb := NewMockBroker(nil, 0)
 	broker := NewBroker(mb.Addr())
@@ -1296,3 +1494,29 @@ func BenchmarkBroker_No_Metrics_Open(b *testing.B) {
 		broker.Close()
 	}
 }
+
+func TestBrokerRequestErrors(t *testing.T) {
+	broker := &Broker{id: 3, conf: NewTestConfig()}
+
+	err := broker.decodeResponse([]byte{0, 0}, &MetadataResponse{}, 1, 42)
+	var requestErr *RequestError
+	if !errors.As(err, &requestErr) {
+		t.Fatalf("Expected a RequestError, got %v", err)
+	}
+	if requestErr.BrokerID != 3 || requestErr.APIKey != 3 || requestErr.CorrelationID != 42 {
+		t.Errorf("Unexpected request of the error %+v", requestErr)
+	}
+	if !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("Expected the decoding error to be wrapped, got %v", err)
+	}
+
+	promise := &responsePromise{correlationID: 7, event: RequestEvent{APIKey: 1}}
+	err = broker.responseFailed(promise, io.ErrUnexpectedEOF)
+	expected := "kafka: Fetch request to broker 3 (correlation ID 7) failed: unexpected EOF"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected the read error to be wrapped, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package sarama
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// tlsSessionCaches are the client session caches used for TLS session
+// resumption by the configs which do not set their own, by Net.TLS.Config so
+// that sessions established with some client certificates are never resumed
+// with a config presenting others.
+var tlsSessionCaches sync.Map
+
+// tlsConfigFor returns the TLS config of a connection to the broker at addr,
+// with resumption enabled unless the config disables session tickets.
+func tlsConfigFor(addr string, cfg *tls.Config) *tls.Config {
+	c := validServerNameTLS(addr, cfg)
+	if c.ClientSessionCache != nil || c.SessionTicketsDisabled {
+		return c
+	}
+
+	cache, ok := tlsSessionCaches.Load(cfg)
+	if !ok {
+		cache, _ = tlsSessionCaches.LoadOrStore(cfg, tls.NewLRUClientSessionCache(0))
+	}
+	if c == cfg {
+		c = c.Clone()
+	}
+	c.ClientSessionCache = cache.(tls.ClientSessionCache)
+	return c
+}
+
+// connectionTLSConfig returns the TLS config of a connection to the broker
+// at addr derived from the one of Net.TLS.
+func (conf *Config) connectionTLSConfig(addr string, cfg *tls.Config) *tls.Config {
+	c := tlsConfigFor(addr, cfg)
+	c = conf.withClientCertificate(c, cfg)
+	c = conf.withFIPS(c, cfg)
+	return conf.withPinnedCertificates(addr, c, cfg)
+}
+
+// tlsConfig returns the TLS config of Net.TLS, from the ConfigProvider if set.
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	if c.Net.TLS.ConfigProvider == nil {
+		return c.Net.TLS.Config, nil
+	}
+	cfg, err := c.Net.TLS.ConfigProvider()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the TLS config: %w", err)
+	}
+	if c.Net.FIPSMode && cfg != nil {
+		if err := fipsTLSConfigError(cfg); err != nil {
+			return nil, fmt.Errorf("the TLS config is not allowed with Net.FIPSMode: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// handshakeTLS performs the TLS handshake of the connection within
+// Net.DialTimeout and records its duration and whether the session was
+// resumed. It must be called with b.lock held once the metrics are set up.
+func (b *Broker) handshakeTLS(conn *tls.Conn) error {
+	if err := conn.SetDeadline(time.Now().Add(b.conf.Net.DialTimeout)); err != nil {
+		return err
+	}
+	start := time.Now()
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	latency := time.Since(start)
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	resumed := conn.ConnectionState().DidResume
+	latencyInMs := int64(latency / time.Millisecond)
+	recorder := b.conf.metricsRecorder()
+	recorder.Counter("tls-handshake-rate", nil).Add(1)
+	recorder.Histogram("tls-handshake-in-ms", nil).Observe(latencyInMs)
+	resumptionRate := recorder.Counter("tls-resumption-rate", nil)
+	if resumed {
+		resumptionRate.Add(1)
+	}
+	// like the other broker metrics, not gathered for seed brokers
+	if b.id >= 0 && !metrics.UseNilMetrics {
+		b.registerMeter("tls-handshake-rate").Add(1)
+		b.registerHistogram("tls-handshake-in-ms").Observe(latencyInMs)
+		brokerResumptionRate := b.registerMeter("tls-resumption-rate")
+		if resumed {
+			brokerResumptionRate.Add(1)
+		}
+	}
+
+	b.log().Debugf("Completed TLS handshake with broker %s in %s (resumed: %t)", b.addr, latency, resumed)
+	return nil
+}
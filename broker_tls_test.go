@@ -0,0 +1,153 @@
+package sarama
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// newTLSListener returns a TLS listener on localhost with a self-signed
+// certificate, and the pool to verify it with
+func newTLSListener(t *testing.T) (net.Listener, *x509.CertPool) {
+	return newTLSListenerWithClientAuth(t, tls.NoClientCert)
+}
+
+// newTLSListenerWithClientAuth is newTLSListener with the client
+// certificates policy of the listener
+func newTLSListenerWithClientAuth(t *testing.T, clientAuth tls.ClientAuthType) (net.Listener, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "host"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &x509.Certificate{SerialNumber: big.NewInt(1)}, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		ClientAuth:   clientAuth,
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return listener, pool
+}
+
+func TestBrokerTLSSessionResumption(t *testing.T) {
+	listener, pool := newTLSListener(t)
+	mockBroker := NewMockBrokerListener(t, 1, listener)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(mockBroker.Addr(), mockBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Net.TLS.Enable = true
+	config.Net.TLS.Config = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+
+	broker := NewBroker(mockBroker.Addr())
+	broker.id = 1
+	for i := 0; i < 2; i++ {
+		if err := broker.Open(config); err != nil {
+			t.Fatal(err)
+		}
+		// the session ticket is received after the handshake of TLS 1.3
+		if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		state, _ := broker.TLSConnectionState()
+		if resumed := i > 0; state.DidResume != resumed {
+			t.Errorf("expected connection %d resumed to be %t, got %t", i, resumed, state.DidResume)
+		}
+		if i == 0 {
+			safeClose(t, broker)
+		}
+	}
+	defer safeClose(t, broker)
+
+	if config.Net.TLS.Config.ClientSessionCache != nil {
+		t.Error("expected the TLS config not to be modified")
+	}
+	for name, expected := range map[string]int64{
+		"tls-handshake-rate":               2,
+		"tls-resumption-rate":              1,
+		"tls-resumption-rate-for-broker-1": 1,
+		"tls-handshake-in-ms-for-broker-1": 1,
+		"tls-handshake-rate-for-broker-1":  1,
+		"tls-handshake-in-ms":              2,
+	} {
+		var count int64
+		switch metric := config.MetricRegistry.Get(name).(type) {
+		case metrics.Meter:
+			count = metric.Count()
+		case metrics.Histogram:
+			count = metric.Count()
+		}
+		if count != expected {
+			t.Errorf("expected %s count to be %d, got %d", name, expected, count)
+		}
+	}
+}
+
+func TestBrokerTLSConfigProvider(t *testing.T) {
+	listener, pool := newTLSListener(t)
+	mockBroker := NewMockBrokerListener(t, 1, listener)
+	defer mockBroker.Close()
+
+	calls := 0
+	config := NewTestConfig()
+	config.Net.TLS.Enable = true
+	config.Net.TLS.ConfigProvider = func() (*tls.Config, error) {
+		calls++
+		if calls > 1 {
+			return nil, errors.New("certificates unavailable")
+		}
+		return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+	}
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	if connected, err := broker.Connected(); !connected {
+		t.Fatal(err)
+	}
+	safeClose(t, broker)
+
+	_ = broker.Open(config)
+	if connected, err := broker.Connected(); connected || err == nil {
+		t.Errorf("expected the provider error, got connected %v, error %v", connected, err)
+	}
+	if calls != 2 {
+		t.Errorf("expected the provider to be called for each connection, got %d calls", calls)
+	}
+}
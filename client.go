@@ -1,9 +1,14 @@
 package sarama
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"math/rand"
+	"net"
+	"reflect"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -27,6 +32,24 @@ type Client interface {
 	// and stores it in the local cache. Requires Kafka 0.10 or higher.
 	RefreshController() (*Broker, error)
 
+	// ClusterID returns the ID of the cluster as reported in the cluster
+	// metadata, so that applications can check that they are connected to
+	// the intended cluster. It returns the empty string if the brokers did
+	// not report one. Requires Kafka 1.0 or higher.
+	ClusterID() (string, error)
+
+	// TopicID returns the ID of the topic (KIP-516) as reported in the
+	// cluster metadata, refreshing the metadata if the topic is unknown. It
+	// returns NullUuid if the brokers did not report one. Requires Kafka 2.8
+	// or higher.
+	TopicID(topic string) (Uuid, error)
+
+	// ClientInstanceID returns the ID the brokers assigned to the client to
+	// identify the metrics it pushes with Telemetry.Enabled (KIP-714),
+	// getting it from the brokers if not known yet. Requires Kafka 3.7 or
+	// higher.
+	ClientInstanceID() (Uuid, error)
+
 	// Brokers returns the current set of active brokers as retrieved from cluster metadata.
 	Brokers() []*Broker
 
@@ -41,9 +64,16 @@ type Client interface {
 
 	// WritablePartitions returns the sorted list of all writable partition IDs for
 	// the given topic, where "writable" means "having a valid leader accepting
-	// writes".
+	// writes": partitions reported by OfflinePartitions are excluded, those
+	// below Metadata.MinInSyncReplicas only if Producer.RequiredAcks is
+	// WaitForAll.
 	WritablePartitions(topic string) ([]int32, error)
 
+	// OfflinePartitions returns why the partitions of the given topic which
+	// cannot be written to and may not be read from are unavailable, by
+	// partition ID, as determined by the cluster metadata.
+	OfflinePartitions(topic string) (map[int32]PartitionUnavailability, error)
+
 	// Leader returns the broker object that is the leader of the current
 	// topic/partition, as determined by querying the cluster metadata.
 	Leader(topic string, partitionID int32) (*Broker, error)
@@ -74,8 +104,23 @@ type Client interface {
 	// given time (in milliseconds) on the topic/partition combination.
 	// Time should be OffsetOldest for the earliest available offset,
 	// OffsetNewest for the offset of the message that will be produced next, or a time.
+	// OffsetMaxTimestamp returns the offset of the message with the highest timestamp
+	// and requires Kafka 3.0 or higher.
 	GetOffset(topic string, partitionID int32, time int64) (int64, error)
 
+	// GetOffsetAndTimestamp is like GetOffset but also returns the timestamp (in
+	// milliseconds) of the message at the returned offset, or -1 if the broker did
+	// not report one (e.g. for OffsetNewest and OffsetOldest). Requires Kafka 0.10.1
+	// or higher.
+	GetOffsetAndTimestamp(topic string, partitionID int32, time int64) (offset int64, timestamp int64, err error)
+
+	// OffsetsForTimestamps is like GetOffsetAndTimestamp for many partitions at
+	// once, given as a map of topics to partitions to times, sending a single
+	// request to the leader of each partition. The result of each partition is
+	// returned in its PartitionOffset, the error being only set if the client
+	// is closed or the Version too old for any of the times.
+	OffsetsForTimestamps(times map[string]map[int32]int64) (map[string]map[int32]*PartitionOffset, error)
+
 	// Coordinator returns the coordinating broker for a consumer group. It will
 	// return a locally cached value if it's available. You can call
 	// RefreshCoordinator to update the cached value. This function only works on
@@ -86,9 +131,54 @@ type Client interface {
 	// in local cache. This function only works on Kafka 0.8.2 and higher.
 	RefreshCoordinator(consumerGroup string) error
 
+	// TransactionCoordinator returns the coordinating broker for a transactional
+	// ID. It will return a locally cached value if it's available. You can call
+	// RefreshTransactionCoordinator to update the cached value. This function only
+	// works on Kafka 0.11.0.0 and higher.
+	TransactionCoordinator(transactionID string) (*Broker, error)
+
+	// RefreshTransactionCoordinator retrieves the coordinator for a transactional
+	// ID and stores it in local cache. This function only works on Kafka 0.11.0.0
+	// and higher.
+	RefreshTransactionCoordinator(transactionID string) error
+
 	// InitProducerID retrieves information required for Idempotent Producer
 	InitProducerID() (*InitProducerIDResponse, error)
 
+	// LeastLoadedBroker returns the broker to send a request which any broker
+	// can answer to, as picked by Config.BrokerSelector among the brokers known
+	// from the cluster metadata, or the seed brokers if none are known. Without
+	// a BrokerSelector the broker with the fewest requests in flight is picked.
+	// It returns nil if no broker is known.
+	LeastLoadedBroker() *Broker
+
+	// SubscribeMetadataChanges returns a channel receiving the changes of the
+	// cluster topology (topics added or deleted, partitions added, leaders
+	// moved, brokers added or removed) found by each metadata refresh, and a
+	// function to cancel the subscription and close the channel. Topics are
+	// only found deleted by refreshes of their own or of all topics. Changes
+	// are dropped when the channel, buffered with Config.ChannelBufferSize,
+	// is full. The channel is closed when the client is.
+	SubscribeMetadataChanges() (<-chan *MetadataChange, func())
+
+	// Throttling returns the quota throttling state of the brokers known from
+	// the cluster metadata, by broker ID. See also Config.Net.ThrottleHook.
+	Throttling() map[int32]BrokerThrottle
+
+	// Stats returns a snapshot of the state of the client, of its brokers and
+	// of the producers and consumers created from it, such as to be exposed
+	// on a debug endpoint. It does not wait for the brokers being connected.
+	Stats() *ClientStats
+
+	// WithContext returns a Client whose calls sending requests, including
+	// their retries and the lookups of the brokers to send them to, return
+	// the context error as soon as the context is done, instead of only being
+	// bounded by the Net and Metadata.Retry settings. The work already started,
+	// such as a metadata refresh shared with other callers, is completed in the
+	// background. The returned client shares the state of this one, so closing
+	// either closes both.
+	WithContext(ctx context.Context) Client
+
 	// Close shuts down all broker connections managed by this client. It is required
 	// to call this function before a client object passes out of scope, as it will
 	// otherwise leak memory. You must close any Producers or Consumers using a client
@@ -110,8 +200,70 @@ const (
 	// offset, or when calling ConsumePartition to start consuming from the
 	// oldest offset that is still available on the broker.
 	OffsetOldest int64 = -2
+	// OffsetMaxTimestamp stands for the offset of the message with the highest
+	// timestamp of a partition, which is not necessarily the latest message
+	// (KIP-734). You can send this to a client's GetOffset or
+	// GetOffsetAndTimestamp method. Requires Kafka 3.0 or higher.
+	OffsetMaxTimestamp int64 = -3
+)
+
+// PartitionOffset is the result of Client.OffsetsForTimestamps for a partition.
+type PartitionOffset struct {
+	// Offset is the offset found for the time, -1 on error.
+	Offset int64
+	// Timestamp is the timestamp (in milliseconds) of the message at Offset,
+	// or -1 if the broker did not report one. Requires Kafka 0.10.1 or higher.
+	Timestamp int64
+	// LeaderEpoch is the leader epoch of the message at Offset, or -1 if the
+	// broker did not report one. Requires Kafka 2.1 or higher.
+	LeaderEpoch int32
+	// Err is the error, if any, of the lookup of the partition.
+	Err error
+}
+
+// PartitionUnavailability is why a partition is unavailable, see
+// Client.OfflinePartitions.
+type PartitionUnavailability int
+
+const (
+	// PartitionLeaderless is a partition without a leader, e.g. during a
+	// leader election.
+	PartitionLeaderless PartitionUnavailability = iota
+	// PartitionFencedLeaderEpoch is a partition the metadata reported a
+	// fenced or unknown leader epoch for, e.g. because the broker answering
+	// was behind the controller.
+	PartitionFencedLeaderEpoch
+	// PartitionBelowMinInSyncReplicas is a partition with fewer in-sync
+	// replicas than Metadata.MinInSyncReplicas, rejecting writes with
+	// acknowledgements from all in-sync replicas.
+	PartitionBelowMinInSyncReplicas
 )
 
+func (u PartitionUnavailability) String() string {
+	switch u {
+	case PartitionLeaderless:
+		return "Leaderless"
+	case PartitionFencedLeaderEpoch:
+		return "FencedLeaderEpoch"
+	case PartitionBelowMinInSyncReplicas:
+		return "BelowMinInSyncReplicas"
+	default:
+		return fmt.Sprintf("PartitionUnavailability(%d)", int(u))
+	}
+}
+
+// BrokerThrottle is the quota throttling state of a broker, see
+// Client.Throttling.
+type BrokerThrottle struct {
+	// Throttled is whether the broker currently asks the client to back off.
+	Throttled bool
+	// Until is when the last throttling reported by the broker ends, the zero
+	// time if it never reported any.
+	Until time.Time
+	// Total is the cumulative throttle time reported by the broker.
+	Total time.Duration
+}
+
 type client struct {
 	conf           *Config
 	closer, closed chan none // for shutting down background metadata updater
@@ -121,17 +273,46 @@ type client struct {
 	// so we store them separately
 	seedBrokers []*Broker
 	deadSeeds   []*Broker
-
-	controllerID   int32                                   // cluster controller broker id
-	brokers        map[int32]*Broker                       // maps broker ids to brokers
-	metadata       map[string]map[int32]*PartitionMetadata // maps topics to partition ids to metadata
-	metadataTopics map[string]none                         // topics that need to collect metadata
-	coordinators   map[string]int32                        // Maps consumer group names to coordinating broker IDs
+	// the addresses of the seed brokers, kept to rebootstrap from and to
+	// re-resolve their host names
+	seedAddrs  []string
+	seedHosts  map[string][]string // maps seed addresses to the addresses their host resolved to
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+
+	// the bootstrap addresses of the clusters to fail over to, those given
+	// to the constructor first, the current one and since when none of its
+	// brokers could be reached, if they cannot
+	bootstrapAddrs   [][]string
+	currentBootstrap int
+	unreachableSince time.Time
+
+	refreshes       refreshQueue // coalesces concurrent metadata refreshes
+	lastFullRefresh time.Time    // completion of the last successful refresh of all topics
+	lastRefresh     time.Time    // when metadata was last received
+
+	// channels of SubscribeMetadataChanges
+	metadataSubscribers map[chan *MetadataChange]none
+
+	clusterID               *string                                 // cluster ID, if reported by the brokers
+	controllerID            int32                                   // cluster controller broker id
+	brokers                 map[int32]*Broker                       // maps broker ids to brokers
+	metadata                map[string]map[int32]*PartitionMetadata // maps topics to partition ids to metadata
+	topicIDs                map[string]Uuid                         // maps topics to their IDs, if reported by the brokers
+	metadataTopics          map[string]none                         // topics that need to collect metadata
+	coordinators            map[string]int32                        // Maps consumer group names to coordinating broker IDs
+	transactionCoordinators map[string]int32                        // Maps transactional IDs to coordinating broker IDs
 
 	// If the number of partitions is large, we can get some churn calling cachedPartitions,
 	// so the result is cached.  It is important to update this value whenever metadata is changed
 	cachedPartitionsResults map[string][maxPartitionIndex][]int32
 
+	telemetry *telemetryReporter // pushes the metrics with Telemetry.Enabled
+
+	// the producers and consumers created from the client, see Stats and
+	// reapIdleBrokers
+	statsReporters []statsReporter
+	statsLock      sync.Mutex
+
 	lock sync.RWMutex // protects access to the maps that hold cluster state.
 }
 
@@ -139,7 +320,7 @@ type client struct {
 // and uses that broker to automatically fetch metadata on the rest of the kafka cluster. If metadata cannot
 // be retrieved from any of the given broker addresses, the client is not created.
 func NewClient(addrs []string, conf *Config) (Client, error) {
-	DebugLogger.Println("Initializing new client")
+	newContextLogger().Debugf("Initializing new client")
 
 	if conf == nil {
 		conf = NewConfig()
@@ -159,20 +340,43 @@ func NewClient(addrs []string, conf *Config) (Client, error) {
 		closed:                  make(chan none),
 		brokers:                 make(map[int32]*Broker),
 		metadata:                make(map[string]map[int32]*PartitionMetadata),
+		topicIDs:                make(map[string]Uuid),
 		metadataTopics:          make(map[string]none),
 		cachedPartitionsResults: make(map[string][maxPartitionIndex][]int32),
 		coordinators:            make(map[string]int32),
+		transactionCoordinators: make(map[string]int32),
+		seedHosts:               make(map[string][]string),
+		metadataSubscribers:     make(map[chan *MetadataChange]none),
+		lookupHost:              net.DefaultResolver.LookupHost,
 	}
 
+	client.bootstrapAddrs = append([][]string{addrs}, conf.Metadata.Failover.Bootstrap...)
 	client.randomizeSeedBrokers(addrs)
 
+	if conf.Version == VersionAuto {
+		client.detectVersion()
+		if err := conf.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	var snapshot *MetadataResponse
+	if conf.Metadata.Store != nil {
+		snapshot = client.loadMetadata()
+	}
+
 	if conf.Metadata.Full {
 		// do an initial fetch of all cluster metadata by specifying an empty list of topics
 		err := client.RefreshMetadata()
 		if err == nil {
 		} else if errors.Is(err, ErrLeaderNotAvailable) || errors.Is(err, ErrReplicaNotAvailable) || errors.Is(err, ErrTopicAuthorizationFailed) || errors.Is(err, ErrClusterAuthorizationFailed) {
 			// indicates that maybe part of the cluster is down, but is not fatal to creating the client
-			Logger.Println(err)
+			newContextLogger().Warnf("%v", err)
+		} else if snapshot != nil && errors.Is(err, ErrOutOfBrokers) {
+			// the cluster cannot be reached for now, start from the snapshot,
+			// again as the brokers which failed were deregistered
+			newContextLogger().Warnf("client/metadata starting from the metadata snapshot: %v", err)
+			_, _ = client.updateMetadata(snapshot, true)
 		} else {
 			close(client.closed) // we haven't started the background updater yet, so we have to do this manually
 			_ = client.Close()
@@ -180,8 +384,12 @@ func NewClient(addrs []string, conf *Config) (Client, error) {
 		}
 	}
 	go withRecover(client.backgroundMetadataUpdater)
+	if conf.Telemetry.Enabled {
+		client.telemetry = newTelemetryReporter(client)
+		go withRecover(client.telemetry.run)
+	}
 
-	DebugLogger.Println("Successfully initialized new client")
+	newContextLogger().Debugf("Successfully initialized new client")
 
 	return client, nil
 }
@@ -190,6 +398,25 @@ func (client *client) Config() *Config {
 	return client.conf
 }
 
+// log returns the logger of the lines about the client
+func (client *client) log() contextLogger {
+	return newContextLogger("clientID", client.conf.ClientID)
+}
+
+func (client *client) ClientInstanceID() (Uuid, error) {
+	return client.clientInstanceIDContext(context.Background())
+}
+
+func (client *client) clientInstanceIDContext(ctx context.Context) (Uuid, error) {
+	if client.Closed() {
+		return NullUuid, ErrClosedClient
+	}
+	if client.telemetry == nil {
+		return NullUuid, ConfigurationError("ClientInstanceID requires Telemetry.Enabled")
+	}
+	return client.telemetry.clientInstanceID(ctx)
+}
+
 func (client *client) Brokers() []*Broker {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
@@ -212,17 +439,23 @@ func (client *client) Broker(brokerID int32) (*Broker, error) {
 }
 
 func (client *client) InitProducerID() (*InitProducerIDResponse, error) {
+	return client.initProducerIDContext(context.Background())
+}
+
+func (client *client) initProducerIDContext(ctx context.Context) (*InitProducerIDResponse, error) {
 	brokerErrors := make([]error, 0)
 	for broker := client.any(); broker != nil; broker = client.any() {
 		var response *InitProducerIDResponse
 		req := &InitProducerIDRequest{}
 
-		response, err := broker.InitProducerID(req)
+		response, err := broker.initProducerID(ctx, req)
 		if err == nil {
 			return response, nil
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
 		} else {
 			// some error, remove that broker and try again
-			Logger.Printf("Client got error from broker %d when issuing InitProducerID : %v\n", broker.ID(), err)
+			newContextLogger("broker", broker.ID()).Warnf("Client got error from broker %d when issuing InitProducerID : %v", broker.ID(), err)
 			_ = broker.Close()
 			brokerErrors = append(brokerErrors, err)
 			client.deregisterBroker(broker)
@@ -236,17 +469,22 @@ func (client *client) Close() error {
 	if client.Closed() {
 		// Chances are this is being called from a defer() and the error will go unobserved
 		// so we go ahead and log the event in this case.
-		Logger.Printf("Close() called on already closed client")
+		newContextLogger().Warnf("Close() called on already closed client")
 		return ErrClosedClient
 	}
 
+	// push the last metrics while the brokers are still connected
+	if client.telemetry != nil {
+		client.telemetry.close()
+	}
+
 	// shutdown and wait for the background thread before we take the lock, to avoid races
 	close(client.closer)
 	<-client.closed
 
 	client.lock.Lock()
 	defer client.lock.Unlock()
-	DebugLogger.Println("Closing Client")
+	newContextLogger().Debugf("Closing Client")
 
 	for _, broker := range client.brokers {
 		safeAsyncClose(broker)
@@ -256,9 +494,14 @@ func (client *client) Close() error {
 		safeAsyncClose(broker)
 	}
 
+	for subscriber := range client.metadataSubscribers {
+		close(subscriber)
+	}
+
 	client.brokers = nil
 	client.metadata = nil
 	client.metadataTopics = nil
+	client.metadataSubscribers = nil
 
 	return nil
 }
@@ -303,6 +546,10 @@ func (client *client) MetadataTopics() ([]string, error) {
 }
 
 func (client *client) Partitions(topic string) ([]int32, error) {
+	return client.partitionsContext(context.Background(), topic)
+}
+
+func (client *client) partitionsContext(ctx context.Context, topic string) ([]int32, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
@@ -310,7 +557,7 @@ func (client *client) Partitions(topic string) ([]int32, error) {
 	partitions := client.cachedPartitions(topic, allPartitions)
 
 	if len(partitions) == 0 {
-		err := client.RefreshMetadata(topic)
+		err := client.refreshMetadataContext(ctx, topic)
 		if err != nil {
 			return nil, err
 		}
@@ -326,6 +573,10 @@ func (client *client) Partitions(topic string) ([]int32, error) {
 }
 
 func (client *client) WritablePartitions(topic string) ([]int32, error) {
+	return client.writablePartitionsContext(context.Background(), topic)
+}
+
+func (client *client) writablePartitionsContext(ctx context.Context, topic string) ([]int32, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
@@ -339,7 +590,7 @@ func (client *client) WritablePartitions(topic string) ([]int32, error) {
 	// a metadata refresh as a nicety so callers can just try again and don't have to manually
 	// trigger a refresh (otherwise they'd just keep getting a stale cached copy).
 	if len(partitions) == 0 {
-		err := client.RefreshMetadata(topic)
+		err := client.refreshMetadataContext(ctx, topic)
 		if err != nil {
 			return nil, err
 		}
@@ -353,7 +604,42 @@ func (client *client) WritablePartitions(topic string) ([]int32, error) {
 	return partitions, nil
 }
 
+func (client *client) OfflinePartitions(topic string) (map[int32]PartitionUnavailability, error) {
+	return client.offlinePartitionsContext(context.Background(), topic)
+}
+
+func (client *client) offlinePartitionsContext(ctx context.Context, topic string) (map[int32]PartitionUnavailability, error) {
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+
+	if len(client.cachedPartitions(topic, allPartitions)) == 0 {
+		if err := client.refreshMetadataContext(ctx, topic); err != nil {
+			return nil, err
+		}
+	}
+
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	partitions := client.metadata[topic]
+	if len(partitions) == 0 {
+		return nil, ErrUnknownTopicOrPartition
+	}
+	offline := make(map[int32]PartitionUnavailability)
+	for id, partition := range partitions {
+		if unavailability, ok := client.partitionUnavailability(partition); ok {
+			offline[id] = unavailability
+		}
+	}
+	return offline, nil
+}
+
 func (client *client) Replicas(topic string, partitionID int32) ([]int32, error) {
+	return client.replicasContext(context.Background(), topic, partitionID)
+}
+
+func (client *client) replicasContext(ctx context.Context, topic string, partitionID int32) ([]int32, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
@@ -361,7 +647,7 @@ func (client *client) Replicas(topic string, partitionID int32) ([]int32, error)
 	metadata := client.cachedMetadata(topic, partitionID)
 
 	if metadata == nil {
-		err := client.RefreshMetadata(topic)
+		err := client.refreshMetadataContext(ctx, topic)
 		if err != nil {
 			return nil, err
 		}
@@ -379,6 +665,10 @@ func (client *client) Replicas(topic string, partitionID int32) ([]int32, error)
 }
 
 func (client *client) InSyncReplicas(topic string, partitionID int32) ([]int32, error) {
+	return client.inSyncReplicasContext(context.Background(), topic, partitionID)
+}
+
+func (client *client) inSyncReplicasContext(ctx context.Context, topic string, partitionID int32) ([]int32, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
@@ -386,7 +676,7 @@ func (client *client) InSyncReplicas(topic string, partitionID int32) ([]int32,
 	metadata := client.cachedMetadata(topic, partitionID)
 
 	if metadata == nil {
-		err := client.RefreshMetadata(topic)
+		err := client.refreshMetadataContext(ctx, topic)
 		if err != nil {
 			return nil, err
 		}
@@ -404,6 +694,10 @@ func (client *client) InSyncReplicas(topic string, partitionID int32) ([]int32,
 }
 
 func (client *client) OfflineReplicas(topic string, partitionID int32) ([]int32, error) {
+	return client.offlineReplicasContext(context.Background(), topic, partitionID)
+}
+
+func (client *client) offlineReplicasContext(ctx context.Context, topic string, partitionID int32) ([]int32, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
@@ -411,7 +705,7 @@ func (client *client) OfflineReplicas(topic string, partitionID int32) ([]int32,
 	metadata := client.cachedMetadata(topic, partitionID)
 
 	if metadata == nil {
-		err := client.RefreshMetadata(topic)
+		err := client.refreshMetadataContext(ctx, topic)
 		if err != nil {
 			return nil, err
 		}
@@ -429,6 +723,10 @@ func (client *client) OfflineReplicas(topic string, partitionID int32) ([]int32,
 }
 
 func (client *client) Leader(topic string, partitionID int32) (*Broker, error) {
+	return client.leaderContext(context.Background(), topic, partitionID)
+}
+
+func (client *client) leaderContext(ctx context.Context, topic string, partitionID int32) (*Broker, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
@@ -436,7 +734,7 @@ func (client *client) Leader(topic string, partitionID int32) (*Broker, error) {
 	leader, err := client.cachedLeader(topic, partitionID)
 
 	if leader == nil {
-		err = client.RefreshMetadata(topic)
+		err = client.refreshMetadataContext(ctx, topic)
 		if err != nil {
 			return nil, err
 		}
@@ -461,6 +759,7 @@ func (client *client) RefreshBrokers(addrs []string) error {
 
 	client.seedBrokers = nil
 	client.deadSeeds = nil
+	client.seedHosts = make(map[string][]string)
 
 	client.randomizeSeedBrokers(addrs)
 
@@ -468,6 +767,10 @@ func (client *client) RefreshBrokers(addrs []string) error {
 }
 
 func (client *client) RefreshMetadata(topics ...string) error {
+	return client.refreshMetadataContext(context.Background(), topics...)
+}
+
+func (client *client) refreshMetadataContext(ctx context.Context, topics ...string) error {
 	if client.Closed() {
 		return ErrClosedClient
 	}
@@ -481,30 +784,147 @@ func (client *client) RefreshMetadata(topics ...string) error {
 		}
 	}
 
+	if !client.conf.Metadata.SingleFlight {
+		return client.doRefreshMetadata(ctx, topics)
+	}
+	// the refresh may be shared with other callers so it is not canceled
+	// with the context, which only stops waiting for it
+	return client.refreshes.refresh(ctx, topics, client.conf.Metadata.AllowAutoTopicCreation, func(topics []string) error {
+		return client.doRefreshMetadata(context.Background(), topics)
+	})
+}
+
+func (client *client) doRefreshMetadata(ctx context.Context, topics []string) error {
+	if len(topics) == 0 && client.conf.Metadata.FullRefreshMinInterval > 0 {
+		client.lock.RLock()
+		last := client.lastFullRefresh
+		client.lock.RUnlock()
+		if !last.IsZero() && client.conf.Clock.Now().Sub(last) < client.conf.Metadata.FullRefreshMinInterval {
+			newContextLogger().Debugf("client/metadata skipping refresh of all topics as the last one is too recent")
+			return nil
+		}
+	}
+
 	deadline := time.Time{}
 	if client.conf.Metadata.Timeout > 0 {
-		deadline = time.Now().Add(client.conf.Metadata.Timeout)
+		deadline = client.conf.Clock.Now().Add(client.conf.Metadata.Timeout)
+	}
+	err := client.tryRefreshMetadata(ctx, topics, client.conf.Metadata.Retry.Max, deadline)
+	if err == nil && len(topics) == 0 {
+		client.lock.Lock()
+		client.lastFullRefresh = client.conf.Clock.Now()
+		client.lock.Unlock()
 	}
-	return client.tryRefreshMetadata(topics, client.conf.Metadata.Retry.Max, deadline)
+	return err
 }
 
 func (client *client) GetOffset(topic string, partitionID int32, time int64) (int64, error) {
+	return client.getOffsetContext(context.Background(), topic, partitionID, time)
+}
+
+func (client *client) getOffsetContext(ctx context.Context, topic string, partitionID int32, time int64) (int64, error) {
 	if client.Closed() {
 		return -1, ErrClosedClient
 	}
 
-	offset, err := client.getOffset(topic, partitionID, time)
+	if time == OffsetMaxTimestamp && !client.conf.Version.IsAtLeast(V3_0_0_0) {
+		return -1, ErrUnsupportedVersion
+	}
+
+	block, err := client.getOffsetBlock(ctx, topic, partitionID, time)
 	if err != nil {
-		if err := client.RefreshMetadata(topic); err != nil {
+		if err := client.refreshMetadataContext(ctx, topic); err != nil {
 			return -1, err
 		}
-		return client.getOffset(topic, partitionID, time)
+		if block, err = client.getOffsetBlock(ctx, topic, partitionID, time); err != nil {
+			return -1, err
+		}
+	}
+
+	return block.Offsets[0], nil
+}
+
+func (client *client) GetOffsetAndTimestamp(topic string, partitionID int32, time int64) (int64, int64, error) {
+	return client.getOffsetAndTimestampContext(context.Background(), topic, partitionID, time)
+}
+
+func (client *client) getOffsetAndTimestampContext(ctx context.Context, topic string, partitionID int32, time int64) (int64, int64, error) {
+	if client.Closed() {
+		return -1, -1, ErrClosedClient
+	}
+
+	if !client.conf.Version.IsAtLeast(V0_10_1_0) ||
+		(time == OffsetMaxTimestamp && !client.conf.Version.IsAtLeast(V3_0_0_0)) {
+		return -1, -1, ErrUnsupportedVersion
+	}
+
+	block, err := client.getOffsetBlock(ctx, topic, partitionID, time)
+	if err != nil {
+		if err := client.refreshMetadataContext(ctx, topic); err != nil {
+			return -1, -1, err
+		}
+		if block, err = client.getOffsetBlock(ctx, topic, partitionID, time); err != nil {
+			return -1, -1, err
+		}
+	}
+
+	return block.Offset, block.Timestamp, nil
+}
+
+func (client *client) OffsetsForTimestamps(times map[string]map[int32]int64) (map[string]map[int32]*PartitionOffset, error) {
+	return client.offsetsForTimestampsContext(context.Background(), times)
+}
+
+func (client *client) offsetsForTimestampsContext(ctx context.Context, times map[string]map[int32]int64) (map[string]map[int32]*PartitionOffset, error) {
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+
+	for _, partitions := range times {
+		for _, time := range partitions {
+			if time == OffsetMaxTimestamp && !client.conf.Version.IsAtLeast(V3_0_0_0) {
+				return nil, ErrUnsupportedVersion
+			}
+		}
 	}
 
-	return offset, err
+	offsets := client.getPartitionOffsets(ctx, times)
+
+	// as for GetOffset, refresh the metadata of the topics of the failed
+	// partitions and retry these once
+	retry := make(map[string]map[int32]int64)
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			if offset.Err != nil {
+				if retry[topic] == nil {
+					retry[topic] = make(map[int32]int64)
+				}
+				retry[topic][partition] = times[topic][partition]
+			}
+		}
+	}
+	if len(retry) > 0 {
+		topics := make([]string, 0, len(retry))
+		for topic := range retry {
+			topics = append(topics, topic)
+		}
+		if err := client.refreshMetadataContext(ctx, topics...); err == nil {
+			for topic, partitions := range client.getPartitionOffsets(ctx, retry) {
+				for partition, offset := range partitions {
+					offsets[topic][partition] = offset
+				}
+			}
+		}
+	}
+
+	return offsets, nil
 }
 
 func (client *client) Controller() (*Broker, error) {
+	return client.controllerContext(context.Background())
+}
+
+func (client *client) controllerContext(ctx context.Context) (*Broker, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
@@ -515,7 +935,7 @@ func (client *client) Controller() (*Broker, error) {
 
 	controller := client.cachedController()
 	if controller == nil {
-		if err := client.refreshMetadata(); err != nil {
+		if err := client.refreshMetadata(ctx); err != nil {
 			return nil, err
 		}
 		controller = client.cachedController()
@@ -539,13 +959,17 @@ func (client *client) deregisterController() {
 // RefreshController retrieves the cluster controller from fresh metadata
 // and stores it in the local cache. Requires Kafka 0.10 or higher.
 func (client *client) RefreshController() (*Broker, error) {
+	return client.refreshControllerContext(context.Background())
+}
+
+func (client *client) refreshControllerContext(ctx context.Context) (*Broker, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
 
 	client.deregisterController()
 
-	if err := client.refreshMetadata(); err != nil {
+	if err := client.refreshMetadata(ctx); err != nil {
 		return nil, err
 	}
 
@@ -558,7 +982,63 @@ func (client *client) RefreshController() (*Broker, error) {
 	return controller, nil
 }
 
+func (client *client) ClusterID() (string, error) {
+	return client.clusterIDContext(context.Background())
+}
+
+func (client *client) clusterIDContext(ctx context.Context) (string, error) {
+	if client.Closed() {
+		return "", ErrClosedClient
+	}
+
+	// older versions do not request the metadata versions returning it
+	if !client.conf.Version.IsAtLeast(V1_0_0_0) {
+		return "", ErrUnsupportedVersion
+	}
+
+	clusterID := client.cachedClusterID()
+	if clusterID == nil {
+		if err := client.refreshMetadata(ctx); err != nil {
+			return "", err
+		}
+		clusterID = client.cachedClusterID()
+	}
+
+	if clusterID == nil {
+		return "", nil
+	}
+	return *clusterID, nil
+}
+
+func (client *client) TopicID(topic string) (Uuid, error) {
+	return client.topicIDContext(context.Background(), topic)
+}
+
+func (client *client) topicIDContext(ctx context.Context, topic string) (Uuid, error) {
+	if client.Closed() {
+		return NullUuid, ErrClosedClient
+	}
+
+	// older versions do not request the metadata versions returning it
+	if !client.conf.Version.IsAtLeast(V2_8_0_0) {
+		return NullUuid, ErrUnsupportedVersion
+	}
+
+	id, known := client.cachedTopicID(topic)
+	if !known {
+		if err := client.refreshMetadataContext(ctx, topic); err != nil {
+			return NullUuid, err
+		}
+		id, _ = client.cachedTopicID(topic)
+	}
+	return id, nil
+}
+
 func (client *client) Coordinator(consumerGroup string) (*Broker, error) {
+	return client.coordinatorContext(context.Background(), consumerGroup)
+}
+
+func (client *client) coordinatorContext(ctx context.Context, consumerGroup string) (*Broker, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
@@ -566,7 +1046,7 @@ func (client *client) Coordinator(consumerGroup string) (*Broker, error) {
 	coordinator := client.cachedCoordinator(consumerGroup)
 
 	if coordinator == nil {
-		if err := client.RefreshCoordinator(consumerGroup); err != nil {
+		if err := client.refreshCoordinatorContext(ctx, consumerGroup); err != nil {
 			return nil, err
 		}
 		coordinator = client.cachedCoordinator(consumerGroup)
@@ -581,15 +1061,21 @@ func (client *client) Coordinator(consumerGroup string) (*Broker, error) {
 }
 
 func (client *client) RefreshCoordinator(consumerGroup string) error {
+	return client.refreshCoordinatorContext(context.Background(), consumerGroup)
+}
+
+func (client *client) refreshCoordinatorContext(ctx context.Context, consumerGroup string) error {
 	if client.Closed() {
 		return ErrClosedClient
 	}
 
-	response, err := client.getConsumerMetadata(consumerGroup, client.conf.Metadata.Retry.Max)
+	response, err := client.getConsumerMetadata(ctx, consumerGroup, client.conf.Metadata.Retry.Max)
 	if err != nil {
 		return err
 	}
 
+	client.mapBrokerAddr(response.Coordinator)
+
 	client.lock.Lock()
 	defer client.lock.Unlock()
 	client.registerBroker(response.Coordinator)
@@ -597,9 +1083,59 @@ func (client *client) RefreshCoordinator(consumerGroup string) error {
 	return nil
 }
 
+func (client *client) TransactionCoordinator(transactionID string) (*Broker, error) {
+	return client.transactionCoordinatorContext(context.Background(), transactionID)
+}
+
+func (client *client) transactionCoordinatorContext(ctx context.Context, transactionID string) (*Broker, error) {
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+
+	coordinator := client.cachedTransactionCoordinator(transactionID)
+
+	if coordinator == nil {
+		if err := client.refreshTransactionCoordinatorContext(ctx, transactionID); err != nil {
+			return nil, err
+		}
+		coordinator = client.cachedTransactionCoordinator(transactionID)
+	}
+
+	if coordinator == nil {
+		return nil, ErrConsumerCoordinatorNotAvailable
+	}
+
+	_ = coordinator.Open(client.conf)
+	return coordinator, nil
+}
+
+func (client *client) RefreshTransactionCoordinator(transactionID string) error {
+	return client.refreshTransactionCoordinatorContext(context.Background(), transactionID)
+}
+
+func (client *client) refreshTransactionCoordinatorContext(ctx context.Context, transactionID string) error {
+	if client.Closed() {
+		return ErrClosedClient
+	}
+
+	response, err := client.getTransactionCoordinator(ctx, transactionID, client.conf.Metadata.Retry.Max)
+	if err != nil {
+		return err
+	}
+
+	client.mapBrokerAddr(response.Coordinator)
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.registerBroker(response.Coordinator)
+	client.transactionCoordinators[transactionID] = response.Coordinator.ID()
+	return nil
+}
+
 // private broker management helpers
 
 func (client *client) randomizeSeedBrokers(addrs []string) {
+	client.seedAddrs = append([]string(nil), addrs...)
 	random := rand.New(rand.NewSource(time.Now().UnixNano()))
 	for _, index := range random.Perm(len(addrs)) {
 		client.seedBrokers = append(client.seedBrokers, NewBroker(addrs[index]))
@@ -613,11 +1149,11 @@ func (client *client) updateBroker(brokers []*Broker) {
 		currentBroker[broker.ID()] = broker
 		if client.brokers[broker.ID()] == nil { // add new broker
 			client.brokers[broker.ID()] = broker
-			DebugLogger.Printf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
+			newContextLogger("broker", broker.ID()).Debugf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
 		} else if broker.Addr() != client.brokers[broker.ID()].Addr() { // replace broker with new address
 			safeAsyncClose(client.brokers[broker.ID()])
 			client.brokers[broker.ID()] = broker
-			Logger.Printf("client/brokers replaced registered broker #%d with %s", broker.ID(), broker.Addr())
+			newContextLogger("broker", broker.ID()).Infof("client/brokers replaced registered broker #%d with %s", broker.ID(), broker.Addr())
 		}
 	}
 
@@ -625,7 +1161,7 @@ func (client *client) updateBroker(brokers []*Broker) {
 		if _, exist := currentBroker[id]; !exist { // remove old broker
 			safeAsyncClose(broker)
 			delete(client.brokers, id)
-			Logger.Printf("client/broker remove invalid broker #%d with %s", broker.ID(), broker.Addr())
+			newContextLogger("broker", broker.ID()).Infof("client/broker remove invalid broker #%d with %s", broker.ID(), broker.Addr())
 		}
 	}
 }
@@ -635,17 +1171,42 @@ func (client *client) updateBroker(brokers []*Broker) {
 // or a previously registered Broker instance. You must hold the write lock before calling this function.
 func (client *client) registerBroker(broker *Broker) {
 	if client.brokers == nil {
-		Logger.Printf("cannot register broker #%d at %s, client already closed", broker.ID(), broker.Addr())
+		newContextLogger("broker", broker.ID()).Warnf("cannot register broker #%d at %s, client already closed", broker.ID(), broker.Addr())
 		return
 	}
 
 	if client.brokers[broker.ID()] == nil {
 		client.brokers[broker.ID()] = broker
-		DebugLogger.Printf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
+		newContextLogger("broker", broker.ID()).Debugf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
 	} else if broker.Addr() != client.brokers[broker.ID()].Addr() {
 		safeAsyncClose(client.brokers[broker.ID()])
 		client.brokers[broker.ID()] = broker
-		Logger.Printf("client/brokers replaced registered broker #%d with %s", broker.ID(), broker.Addr())
+		newContextLogger("broker", broker.ID()).Infof("client/brokers replaced registered broker #%d with %s", broker.ID(), broker.Addr())
+	}
+}
+
+// mapBrokerAddr rewrites the address of a broker learned from the cluster
+// with Net.AddressMapper, if set
+func (client *client) mapBrokerAddr(broker *Broker) {
+	mapper := client.conf.Net.AddressMapper
+	if mapper == nil {
+		return
+	}
+
+	host, portstr, err := net.SplitHostPort(broker.addr)
+	if err != nil {
+		return
+	}
+	port, err := strconv.ParseInt(portstr, 10, 32)
+	if err != nil {
+		return
+	}
+
+	mappedHost, mappedPort := mapper(broker.id, host, int32(port))
+	addr := net.JoinHostPort(mappedHost, strconv.Itoa(int(mappedPort)))
+	if addr != broker.addr {
+		newContextLogger("broker", broker.id).Debugf("client/brokers mapped the address of broker #%d from %s to %s", broker.id, broker.addr, addr)
+		broker.addr = addr
 	}
 }
 
@@ -655,15 +1216,17 @@ func (client *client) deregisterBroker(broker *Broker) {
 	client.lock.Lock()
 	defer client.lock.Unlock()
 
-	if len(client.seedBrokers) > 0 && broker == client.seedBrokers[0] {
+	if i := brokerIndex(client.seedBrokers, broker); i >= 0 {
 		client.deadSeeds = append(client.deadSeeds, broker)
-		client.seedBrokers = client.seedBrokers[1:]
+		seeds := make([]*Broker, 0, len(client.seedBrokers)-1)
+		seeds = append(seeds, client.seedBrokers[:i]...)
+		client.seedBrokers = append(seeds, client.seedBrokers[i+1:]...)
 	} else {
 		// we do this so that our loop in `tryRefreshMetadata` doesn't go on forever,
 		// but we really shouldn't have to; once that loop is made better this case can be
 		// removed, and the function generally can be renamed from `deregisterBroker` to
 		// `nextSeedBroker` or something
-		DebugLogger.Printf("client/brokers deregistered broker #%d at %s", broker.ID(), broker.Addr())
+		newContextLogger("broker", broker.ID()).Debugf("client/brokers deregistered broker #%d at %s", broker.ID(), broker.Addr())
 		delete(client.brokers, broker.ID())
 	}
 }
@@ -672,12 +1235,198 @@ func (client *client) resurrectDeadBrokers() {
 	client.lock.Lock()
 	defer client.lock.Unlock()
 
-	Logger.Printf("client/brokers resurrecting %d dead seed brokers", len(client.deadSeeds))
+	newContextLogger().Infof("client/brokers resurrecting %d dead seed brokers", len(client.deadSeeds))
 	client.seedBrokers = append(client.seedBrokers, client.deadSeeds...)
 	client.deadSeeds = nil
 }
 
+// rebootstrap discards all known brokers and creates new seed brokers from
+// the seed addresses, so that a cluster replaced behind them is found again
+func (client *client) rebootstrap() {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	if client.brokers == nil {
+		return
+	}
+
+	newContextLogger().Infof("client/brokers rebootstrapping from %d seed brokers", len(client.seedAddrs))
+	client.closeAllBrokers()
+	client.randomizeSeedBrokers(client.seedAddrs)
+}
+
+// closeAllBrokers closes and forgets all known and seed brokers. It must be
+// called with the client lock held.
+func (client *client) closeAllBrokers() {
+	for id, broker := range client.brokers {
+		safeAsyncClose(broker)
+		delete(client.brokers, id)
+	}
+	for _, broker := range client.seedBrokers {
+		safeAsyncClose(broker)
+	}
+	for _, broker := range client.deadSeeds {
+		safeAsyncClose(broker)
+	}
+	client.seedBrokers = nil
+	client.deadSeeds = nil
+}
+
+// failover records that no broker could be reached and, once that has been
+// the case for Metadata.Failover.After, bootstraps from the addresses of the
+// next cluster, returning whether it did
+func (client *client) failover() bool {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	if client.brokers == nil || len(client.bootstrapAddrs) < 2 {
+		return false
+	}
+	if client.unreachableSince.IsZero() {
+		client.unreachableSince = client.conf.Clock.Now()
+		return false
+	}
+	if client.conf.Clock.Now().Sub(client.unreachableSince) < client.conf.Metadata.Failover.After {
+		return false
+	}
+
+	previous := client.bootstrapAddrs[client.currentBootstrap]
+	client.currentBootstrap = (client.currentBootstrap + 1) % len(client.bootstrapAddrs)
+	client.unreachableSince = time.Time{}
+	newContextLogger().Warnf("client/brokers failing over from %v to %v after %s without reachable brokers",
+		previous, client.bootstrapAddrs[client.currentBootstrap], client.conf.Metadata.Failover.After)
+
+	client.closeAllBrokers()
+	client.seedHosts = make(map[string][]string)
+	// the coordinators are brokers of the previous cluster
+	client.coordinators = make(map[string]int32)
+	client.transactionCoordinators = make(map[string]int32)
+
+	client.randomizeSeedBrokers(client.bootstrapAddrs[client.currentBootstrap])
+	client.publishMetadataChanges([]*MetadataChange{{
+		Type:      MetadataClusterFailover,
+		Bootstrap: client.seedAddrs,
+	}})
+	return true
+}
+
+// resolveSeedBrokers re-resolves the host names of the seed addresses and
+// closes the connections of the seed brokers whose host resolves to new
+// addresses, so that they reconnect to the new ones
+func (client *client) resolveSeedBrokers() {
+	client.lock.RLock()
+	addrs := client.seedAddrs
+	client.lock.RUnlock()
+
+	resolved := make(map[string][]string, len(addrs))
+	for _, addr := range addrs {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), client.conf.Net.DialTimeout)
+		hosts, err := client.lookupHost(ctx, host)
+		cancel()
+		if err != nil {
+			newContextLogger("seed", addr).Warnf("client/brokers failed to resolve seed broker %s: %v", addr, err)
+			continue
+		}
+		sort.Strings(hosts)
+		resolved[addr] = hosts
+	}
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	changed := make(map[string]bool)
+	for addr, hosts := range resolved {
+		if previous, ok := client.seedHosts[addr]; ok && !reflect.DeepEqual(previous, hosts) {
+			newContextLogger("seed", addr).Infof("client/brokers seed broker %s now resolves to %v, reconnecting", addr, hosts)
+			changed[addr] = true
+		}
+		client.seedHosts[addr] = hosts
+	}
+	for _, broker := range client.seedBrokers {
+		if changed[broker.Addr()] {
+			safeAsyncClose(broker)
+		}
+	}
+	for _, broker := range client.deadSeeds {
+		if changed[broker.Addr()] {
+			safeAsyncClose(broker)
+		}
+	}
+}
+
+func brokerIndex(brokers []*Broker, broker *Broker) int {
+	for i, b := range brokers {
+		if b == broker {
+			return i
+		}
+	}
+	return -1
+}
+
+func (client *client) LeastLoadedBroker() *Broker {
+	selector := client.conf.BrokerSelector
+	if selector == nil {
+		selector = NewLeastInFlightBrokerSelector()
+	}
+	return client.selectBroker(selector)
+}
+
+func (client *client) Throttling() map[int32]BrokerThrottle {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	now := time.Now()
+	throttling := make(map[int32]BrokerThrottle, len(client.brokers))
+	for id, broker := range client.brokers {
+		until := broker.ThrottledUntil()
+		throttling[id] = BrokerThrottle{
+			Throttled: until.After(now),
+			Until:     until,
+			Total:     broker.ThrottleTime(),
+		}
+	}
+	return throttling
+}
+
+// selectBroker opens and returns the broker picked by the selector among the
+// known brokers, or among the seed brokers if none are known
+func (client *client) selectBroker(selector BrokerSelector) *Broker {
+	client.lock.RLock()
+	candidates := make([]*Broker, 0, len(client.brokers))
+	for _, broker := range client.brokers {
+		candidates = append(candidates, broker)
+	}
+	if len(candidates) == 0 {
+		candidates = append(candidates, client.seedBrokers...)
+	}
+	client.lock.RUnlock()
+
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].ID() != candidates[j].ID() {
+			return candidates[i].ID() < candidates[j].ID()
+		}
+		return candidates[i].Addr() < candidates[j].Addr()
+	})
+
+	broker := selector.Select(candidates)
+	if broker != nil {
+		_ = broker.Open(client.conf)
+	}
+	return broker
+}
+
 func (client *client) any() *Broker {
+	if client.conf.BrokerSelector != nil {
+		return client.selectBroker(client.conf.BrokerSelector)
+	}
+
 	client.lock.RLock()
 	defer client.lock.RUnlock()
 
@@ -741,8 +1490,11 @@ func (client *client) setPartitionCache(topic string, partitionSet partitionType
 
 	ret := make([]int32, 0, len(partitions))
 	for _, partition := range partitions {
-		if partitionSet == writablePartitions && errors.Is(partition.Err, ErrLeaderNotAvailable) {
-			continue
+		if partitionSet == writablePartitions {
+			unavailability, ok := client.partitionUnavailability(partition)
+			if ok && (unavailability != PartitionBelowMinInSyncReplicas || client.conf.producerRequiredAcks(topic) == WaitForAll) {
+				continue
+			}
 		}
 		ret = append(ret, partition.ID)
 	}
@@ -751,6 +1503,19 @@ func (client *client) setPartitionCache(topic string, partitionSet partitionType
 	return ret
 }
 
+// partitionUnavailability returns why the partition is unavailable, if it is
+func (client *client) partitionUnavailability(partition *PartitionMetadata) (PartitionUnavailability, bool) {
+	switch {
+	case errors.Is(partition.Err, ErrLeaderNotAvailable) || partition.Leader < 0:
+		return PartitionLeaderless, true
+	case errors.Is(partition.Err, ErrFencedLeaderEpoch) || errors.Is(partition.Err, ErrUnknownLeaderEpoch):
+		return PartitionFencedLeaderEpoch, true
+	case client.conf.Metadata.MinInSyncReplicas > 0 && len(partition.Isr) < client.conf.Metadata.MinInSyncReplicas:
+		return PartitionBelowMinInSyncReplicas, true
+	}
+	return 0, false
+}
+
 func (client *client) cachedLeader(topic string, partitionID int32) (*Broker, error) {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
@@ -774,64 +1539,289 @@ func (client *client) cachedLeader(topic string, partitionID int32) (*Broker, er
 	return nil, ErrUnknownTopicOrPartition
 }
 
-func (client *client) getOffset(topic string, partitionID int32, time int64) (int64, error) {
-	broker, err := client.Leader(topic, partitionID)
-	if err != nil {
-		return -1, err
-	}
-
+func (client *client) getOffsetBlock(ctx context.Context, topic string, partitionID int32, time int64) (*OffsetResponseBlock, error) {
 	request := &OffsetRequest{}
-	if client.conf.Version.IsAtLeast(V0_10_1_0) {
+	switch {
+	case time == OffsetMaxTimestamp:
+		request.Version = 7
+	case client.conf.Version.IsAtLeast(V0_10_1_0):
 		request.Version = 1
 	}
 	request.AddBlock(topic, partitionID, time, 1)
 
-	response, err := broker.GetAvailableOffsets(request)
+	broker, err := client.leaderContext(ctx, topic, partitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := broker.getAvailableOffsets(ctx, request)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		_ = broker.Close()
-		return -1, err
+		return nil, err
 	}
 
 	block := response.GetBlock(topic, partitionID)
 	if block == nil {
 		_ = broker.Close()
-		return -1, ErrIncompleteResponse
+		return nil, ErrIncompleteResponse
 	}
 	if !errors.Is(block.Err, ErrNoError) {
-		return -1, block.Err
+		return nil, block.Err
 	}
 	if len(block.Offsets) != 1 {
-		return -1, ErrOffsetOutOfRange
+		return nil, ErrOffsetOutOfRange
 	}
 
-	return block.Offsets[0], nil
+	return block, nil
+}
+
+// getPartitionOffsets sends one OffsetRequest per leader for the partitions
+// and returns their offsets, or the error of each
+func (client *client) getPartitionOffsets(ctx context.Context, times map[string]map[int32]int64) map[string]map[int32]*PartitionOffset {
+	var version int16
+	switch {
+	case client.conf.Version.IsAtLeast(V3_0_0_0):
+		// required for OffsetMaxTimestamp
+		version = 7
+	case client.conf.Version.IsAtLeast(V2_1_0_0):
+		// the first version returning leader epochs
+		version = 4
+	case client.conf.Version.IsAtLeast(V0_10_1_0):
+		version = 1
+	}
+
+	offsets := make(map[string]map[int32]*PartitionOffset, len(times))
+	requests := make(map[*Broker]*OffsetRequest)
+	for topic, partitions := range times {
+		offsets[topic] = make(map[int32]*PartitionOffset, len(partitions))
+		for partition, time := range partitions {
+			broker, err := client.leaderContext(ctx, topic, partition)
+			if err != nil {
+				offsets[topic][partition] = &PartitionOffset{Offset: -1, Timestamp: -1, LeaderEpoch: -1, Err: err}
+				continue
+			}
+			request := requests[broker]
+			if request == nil {
+				request = &OffsetRequest{Version: version}
+				requests[broker] = request
+			}
+			request.AddBlock(topic, partition, time, 1)
+		}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+	)
+	for broker, request := range requests {
+		wg.Add(1)
+		go func(broker *Broker, request *OffsetRequest) {
+			defer wg.Done()
+
+			response, err := broker.getAvailableOffsets(ctx, request)
+			if err != nil && ctx.Err() == nil {
+				_ = broker.Close()
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			for topic, partitions := range request.blocks {
+				for partition := range partitions {
+					offsets[topic][partition] = partitionOffset(response, err, topic, partition, request.Version)
+				}
+			}
+		}(broker, request)
+	}
+	wg.Wait()
+
+	return offsets
+}
+
+func partitionOffset(response *OffsetResponse, err error, topic string, partition int32, version int16) *PartitionOffset {
+	offset := &PartitionOffset{Offset: -1, Timestamp: -1, LeaderEpoch: -1, Err: err}
+	if err != nil {
+		return offset
+	}
+
+	block := response.GetBlock(topic, partition)
+	switch {
+	case block == nil:
+		offset.Err = ErrIncompleteResponse
+	case !errors.Is(block.Err, ErrNoError):
+		offset.Err = block.Err
+	case len(block.Offsets) != 1:
+		offset.Err = ErrOffsetOutOfRange
+	default:
+		offset.Offset = block.Offsets[0]
+		if version >= 1 {
+			offset.Timestamp = block.Timestamp
+		}
+		if version >= 4 {
+			offset.LeaderEpoch = block.LeaderEpoch
+		}
+	}
+	return offset
 }
 
 // core metadata update logic
 
+// metadataRefresh is a refresh of the metadata of some topics, or of all
+// topics, shared by all the callers waiting for it
+type metadataRefresh struct {
+	all    bool
+	topics map[string]none
+	done   chan none
+	err    error
+}
+
+// merge adds the topics, or all topics if there are none, to the refresh
+// unless this would change whether they are auto-created
+func (r *metadataRefresh) merge(topics []string, autoCreate bool) bool {
+	if len(topics) == 0 {
+		if autoCreate && len(r.topics) > 0 {
+			return false
+		}
+		r.all = true
+		r.topics = nil
+		return true
+	}
+	if r.all {
+		return !autoCreate
+	}
+	for _, topic := range topics {
+		r.topics[topic] = none{}
+	}
+	return true
+}
+
+// wait returns the error of the refresh once done, or the error of ctx if it
+// is done first
+func (r *metadataRefresh) wait(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return r.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *metadataRefresh) topicList() []string {
+	if r.all {
+		return nil
+	}
+	topics := make([]string, 0, len(r.topics))
+	for topic := range r.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// refreshQueue runs one metadata refresh at a time: refreshes requested
+// while one is in flight are merged into the refreshes queued up after it,
+// as the one in flight may have been sent before they were needed
+type refreshQueue struct {
+	lock     sync.Mutex
+	inFlight *metadataRefresh
+	queued   []*metadataRefresh
+}
+
+func (q *refreshQueue) refresh(ctx context.Context, topics []string, autoCreate bool, fn func([]string) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	q.lock.Lock()
+	var r *metadataRefresh
+	for _, queued := range q.queued {
+		if queued.merge(topics, autoCreate) {
+			r = queued
+			break
+		}
+	}
+	if r == nil {
+		r = &metadataRefresh{topics: make(map[string]none), done: make(chan none)}
+		r.merge(topics, autoCreate)
+		q.queued = append(q.queued, r)
+	}
+	if q.inFlight == nil {
+		q.next(fn)
+	}
+	q.lock.Unlock()
+
+	return r.wait(ctx)
+}
+
+// next starts the first queued refresh, q.lock must be held
+func (q *refreshQueue) next(fn func([]string) error) {
+	r := q.queued[0]
+	q.queued = q.queued[1:]
+	q.inFlight = r
+	go withRecover(func() {
+		defer func() {
+			close(r.done)
+
+			q.lock.Lock()
+			q.inFlight = nil
+			if len(q.queued) > 0 {
+				q.next(fn)
+			}
+			q.lock.Unlock()
+		}()
+		r.err = fn(r.topicList())
+	})
+}
+
 func (client *client) backgroundMetadataUpdater() {
 	defer close(client.closed)
 
-	if client.conf.Metadata.RefreshFrequency == time.Duration(0) {
-		return
+	var refresh, resolve, reap <-chan time.Time
+
+	if client.conf.Metadata.RefreshFrequency > 0 {
+		ticker := client.conf.Clock.NewTicker(client.conf.Metadata.RefreshFrequency)
+		defer ticker.Stop()
+		refresh = ticker.C()
 	}
 
-	ticker := time.NewTicker(client.conf.Metadata.RefreshFrequency)
-	defer ticker.Stop()
+	if client.conf.Metadata.Rebootstrap.ResolveFrequency > 0 {
+		ticker := client.conf.Clock.NewTicker(client.conf.Metadata.Rebootstrap.ResolveFrequency)
+		defer ticker.Stop()
+		resolve = ticker.C()
+		// record the addresses the seed brokers currently resolve to
+		client.resolveSeedBrokers()
+	}
+
+	if client.conf.Net.MaxIdleTime > 0 {
+		// the connections are closed within 1.5 times the idle time
+		ticker := time.NewTicker(client.conf.Net.MaxIdleTime / 2)
+		defer ticker.Stop()
+		reap = ticker.C
+	}
+
+	if refresh == nil && resolve == nil && reap == nil {
+		return
+	}
 
 	for {
 		select {
-		case <-ticker.C:
-			if err := client.refreshMetadata(); err != nil {
-				Logger.Println("Client background metadata update:", err)
+		case <-refresh:
+			if err := client.refreshMetadata(context.Background()); err != nil {
+				newContextLogger().Warnf("Client background metadata update: %v", err)
 			}
+		case <-resolve:
+			client.resolveSeedBrokers()
+		case <-reap:
+			client.reapIdleBrokers()
 		case <-client.closer:
 			return
 		}
 	}
 }
 
-func (client *client) refreshMetadata() error {
+func (client *client) refreshMetadata(ctx context.Context) error {
 	var topics []string
 
 	if !client.conf.Metadata.Full {
@@ -844,16 +1834,20 @@ func (client *client) refreshMetadata() error {
 		}
 	}
 
-	if err := client.RefreshMetadata(topics...); err != nil {
+	if err := client.refreshMetadataContext(ctx, topics...); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int, deadline time.Time) error {
+func (client *client) tryRefreshMetadata(ctx context.Context, topics []string, attemptsRemaining int, deadline time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	pastDeadline := func(backoff time.Duration) bool {
-		if !deadline.IsZero() && time.Now().Add(backoff).After(deadline) {
+		if !deadline.IsZero() && client.conf.Clock.Now().Add(backoff).After(deadline) {
 			// we are past the deadline
 			return true
 		}
@@ -863,14 +1857,14 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int,
 		if attemptsRemaining > 0 {
 			backoff := client.computeBackoff(attemptsRemaining)
 			if pastDeadline(backoff) {
-				Logger.Println("client/metadata skipping last retries as we would go past the metadata timeout")
+				newContextLogger().Warnf("client/metadata skipping last retries as we would go past the metadata timeout")
 				return err
 			}
-			Logger.Printf("client/metadata retrying after %dms... (%d attempts remaining)\n", backoff/time.Millisecond, attemptsRemaining)
-			if backoff > 0 {
-				time.Sleep(backoff)
+			newContextLogger().Warnf("client/metadata retrying after %dms... (%d attempts remaining)", backoff/time.Millisecond, attemptsRemaining)
+			if err := client.sleep(ctx, backoff); err != nil {
+				return err
 			}
-			return client.tryRefreshMetadata(topics, attemptsRemaining-1, deadline)
+			return client.tryRefreshMetadata(ctx, topics, attemptsRemaining-1, deadline)
 		}
 		return err
 	}
@@ -880,27 +1874,40 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int,
 	for ; broker != nil && !pastDeadline(0); broker = client.any() {
 		allowAutoTopicCreation := client.conf.Metadata.AllowAutoTopicCreation
 		if len(topics) > 0 {
-			DebugLogger.Printf("client/metadata fetching metadata for %v from broker %s\n", topics, broker.addr)
+			newContextLogger("addr", broker.addr).Debugf("client/metadata fetching metadata for %v from broker %s", topics, broker.addr)
 		} else {
 			allowAutoTopicCreation = false
-			DebugLogger.Printf("client/metadata fetching metadata for all topics from broker %s\n", broker.addr)
+			newContextLogger("addr", broker.addr).Debugf("client/metadata fetching metadata for all topics from broker %s", broker.addr)
 		}
 
 		req := &MetadataRequest{Topics: topics, AllowAutoTopicCreation: allowAutoTopicCreation}
-		if client.conf.Version.IsAtLeast(V1_0_0_0) {
+		if client.conf.Version.IsAtLeast(V2_8_0_0) {
+			// returns the topic IDs
+			req.Version = 10
+		} else if client.conf.Version.IsAtLeast(V1_0_0_0) {
 			req.Version = 5
 		} else if client.conf.Version.IsAtLeast(V0_10_0_0) {
 			req.Version = 1
 		}
-		response, err := broker.GetMetadata(req)
+		response, err := broker.getMetadata(ctx, req)
+		if err != nil && ctx.Err() != nil {
+			// the broker is not to blame
+			return ctx.Err()
+		}
 		var kerror KError
 		var packetEncodingError PacketEncodingError
 		if err == nil {
 			allKnownMetaData := len(topics) == 0
+			for _, broker := range response.Brokers {
+				client.mapBrokerAddr(broker)
+			}
 			// valid response, use it
 			shouldRetry, err := client.updateMetadata(response, allKnownMetaData)
+			if client.conf.Metadata.Store != nil {
+				client.storeMetadata()
+			}
 			if shouldRetry {
-				Logger.Println("client/metadata found some partitions to be leaderless")
+				newContextLogger().Warnf("client/metadata found some partitions to be leaderless")
 				return retry(err) // note: err can be nil
 			}
 			return err
@@ -910,21 +1917,21 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int,
 		} else if errors.As(err, &kerror) {
 			// if SASL auth error return as this _should_ be a non retryable err for all brokers
 			if errors.Is(err, ErrSASLAuthenticationFailed) {
-				Logger.Println("client/metadata failed SASL authentication")
+				newContextLogger("broker", broker.ID()).Errorf("client/metadata failed SASL authentication")
 				return err
 			}
 
 			if errors.Is(err, ErrTopicAuthorizationFailed) {
-				Logger.Println("client is not authorized to access this topic. The topics were: ", topics)
+				newContextLogger().Errorf("client is not authorized to access this topic. The topics were: %v", topics)
 				return err
 			}
 			// else remove that broker and try again
-			Logger.Printf("client/metadata got error from broker %d while fetching metadata: %v\n", broker.ID(), err)
+			newContextLogger("broker", broker.ID()).Warnf("client/metadata got error from broker %d while fetching metadata: %v", broker.ID(), err)
 			_ = broker.Close()
 			client.deregisterBroker(broker)
 		} else {
 			// some other error, remove that broker and try again
-			Logger.Printf("client/metadata got error from broker %d while fetching metadata: %v\n", broker.ID(), err)
+			newContextLogger("broker", broker.ID()).Warnf("client/metadata got error from broker %d while fetching metadata: %v", broker.ID(), err)
 			brokerErrors = append(brokerErrors, err)
 			_ = broker.Close()
 			client.deregisterBroker(broker)
@@ -933,12 +1940,19 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int,
 
 	error := Wrap(ErrOutOfBrokers, brokerErrors...)
 	if broker != nil {
-		Logger.Printf("client/metadata not fetching metadata from broker %s as we would go past the metadata timeout\n", broker.addr)
+		newContextLogger("addr", broker.addr).Warnf("client/metadata not fetching metadata from broker %s as we would go past the metadata timeout", broker.addr)
 		return retry(error)
 	}
 
-	Logger.Println("client/metadata no available broker to send metadata request to")
-	client.resurrectDeadBrokers()
+	newContextLogger().Warnf("client/metadata no available broker to send metadata request to")
+	if client.failover() {
+		return retry(error)
+	}
+	if client.conf.Metadata.Rebootstrap.Enable {
+		client.rebootstrap()
+	} else {
+		client.resurrectDeadBrokers()
+	}
 	return retry(error)
 }
 
@@ -950,6 +1964,14 @@ func (client *client) updateMetadata(data *MetadataResponse, allKnownMetaData bo
 
 	client.lock.Lock()
 	defer client.lock.Unlock()
+	client.lastRefresh = client.conf.Clock.Now()
+
+	if len(client.metadataSubscribers) > 0 {
+		snapshot := client.snapshotMetadata()
+		defer func() {
+			client.publishMetadataChanges(client.metadataChanges(snapshot, data, allKnownMetaData))
+		}()
+	}
 
 	// For all the brokers we received:
 	// - if it is a new ID, save it
@@ -957,11 +1979,16 @@ func (client *client) updateMetadata(data *MetadataResponse, allKnownMetaData bo
 	// - if some brokers is not exist in it, remove old broker
 	// - otherwise ignore it, replacing our existing one would just bounce the connection
 	client.updateBroker(data.Brokers)
+	client.unreachableSince = time.Time{}
 
 	client.controllerID = data.ControllerID
+	if data.ClusterID != nil {
+		client.clusterID = data.ClusterID
+	}
 
 	if allKnownMetaData {
 		client.metadata = make(map[string]map[int32]*PartitionMetadata)
+		client.topicIDs = make(map[string]Uuid)
 		client.metadataTopics = make(map[string]none)
 		client.cachedPartitionsResults = make(map[string][maxPartitionIndex][]int32)
 	}
@@ -973,6 +2000,7 @@ func (client *client) updateMetadata(data *MetadataResponse, allKnownMetaData bo
 			client.metadataTopics[topic.Name] = none{}
 		}
 		delete(client.metadata, topic.Name)
+		delete(client.topicIDs, topic.Name)
 		delete(client.cachedPartitionsResults, topic.Name)
 
 		switch topic.Err {
@@ -988,12 +2016,15 @@ func (client *client) updateMetadata(data *MetadataResponse, allKnownMetaData bo
 		case ErrLeaderNotAvailable: // retry, but store partial partition results
 			retry = true
 		default: // don't retry, don't store partial results
-			Logger.Printf("Unexpected topic-level metadata error: %s", topic.Err)
+			newContextLogger("topic", topic.Name).Warnf("Unexpected topic-level metadata error: %s", topic.Err)
 			err = topic.Err
 			continue
 		}
 
 		client.metadata[topic.Name] = make(map[int32]*PartitionMetadata, len(topic.Partitions))
+		if topic.Uuid != NullUuid {
+			client.topicIDs[topic.Name] = topic.Uuid
+		}
 		for _, partition := range topic.Partitions {
 			client.metadata[topic.Name][partition.ID] = partition
 			if errors.Is(partition.Err, ErrLeaderNotAvailable) {
@@ -1019,6 +2050,22 @@ func (client *client) cachedCoordinator(consumerGroup string) *Broker {
 	return nil
 }
 
+func (client *client) cachedClusterID() *string {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	return client.clusterID
+}
+
+// cachedTopicID returns the ID of the topic and whether its metadata is known
+func (client *client) cachedTopicID(topic string) (Uuid, bool) {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	_, known := client.metadata[topic]
+	return client.topicIDs[topic], known
+}
+
 func (client *client) cachedController() *Broker {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
@@ -1026,6 +2073,30 @@ func (client *client) cachedController() *Broker {
 	return client.brokers[client.controllerID]
 }
 
+func (client *client) cachedTransactionCoordinator(transactionID string) *Broker {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	if coordinatorID, ok := client.transactionCoordinators[transactionID]; ok {
+		return client.brokers[coordinatorID]
+	}
+	return nil
+}
+
+// sleep waits for d, or until ctx is done in which case its error is returned
+func (client *client) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := client.conf.Clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (client *client) computeBackoff(attemptsRemaining int) time.Duration {
 	if client.conf.Metadata.Retry.BackoffFunc != nil {
 		maxRetries := client.conf.Metadata.Retry.Max
@@ -1035,28 +2106,33 @@ func (client *client) computeBackoff(attemptsRemaining int) time.Duration {
 	return client.conf.Metadata.Retry.Backoff
 }
 
-func (client *client) getConsumerMetadata(consumerGroup string, attemptsRemaining int) (*FindCoordinatorResponse, error) {
+func (client *client) getConsumerMetadata(ctx context.Context, consumerGroup string, attemptsRemaining int) (*FindCoordinatorResponse, error) {
 	retry := func(err error) (*FindCoordinatorResponse, error) {
 		if attemptsRemaining > 0 {
 			backoff := client.computeBackoff(attemptsRemaining)
-			Logger.Printf("client/coordinator retrying after %dms... (%d attempts remaining)\n", backoff/time.Millisecond, attemptsRemaining)
-			time.Sleep(backoff)
-			return client.getConsumerMetadata(consumerGroup, attemptsRemaining-1)
+			newContextLogger().Warnf("client/coordinator retrying after %dms... (%d attempts remaining)", backoff/time.Millisecond, attemptsRemaining)
+			if err := client.sleep(ctx, backoff); err != nil {
+				return nil, err
+			}
+			return client.getConsumerMetadata(ctx, consumerGroup, attemptsRemaining-1)
 		}
 		return nil, err
 	}
 
 	brokerErrors := make([]error, 0)
 	for broker := client.any(); broker != nil; broker = client.any() {
-		DebugLogger.Printf("client/coordinator requesting coordinator for consumergroup %s from %s\n", consumerGroup, broker.Addr())
+		newContextLogger("group", consumerGroup).Debugf("client/coordinator requesting coordinator for consumergroup %s from %s", consumerGroup, broker.Addr())
 
 		request := new(FindCoordinatorRequest)
 		request.CoordinatorKey = consumerGroup
 		request.CoordinatorType = CoordinatorGroup
 
-		response, err := broker.FindCoordinator(request)
+		response, err := broker.findCoordinator(ctx, request)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if err != nil {
-			Logger.Printf("client/coordinator request to broker %s failed: %s\n", broker.Addr(), err)
+			newContextLogger("group", consumerGroup).Warnf("client/coordinator request to broker %s failed: %s", broker.Addr(), err)
 
 			var packetEncodingError PacketEncodingError
 			if errors.As(err, &packetEncodingError) {
@@ -1070,29 +2146,90 @@ func (client *client) getConsumerMetadata(consumerGroup string, attemptsRemainin
 		}
 
 		if errors.Is(response.Err, ErrNoError) {
-			DebugLogger.Printf("client/coordinator coordinator for consumergroup %s is #%d (%s)\n", consumerGroup, response.Coordinator.ID(), response.Coordinator.Addr())
+			newContextLogger("group", consumerGroup, "broker", response.Coordinator.ID()).Debugf("client/coordinator coordinator for consumergroup %s is #%d (%s)", consumerGroup, response.Coordinator.ID(), response.Coordinator.Addr())
 			return response, nil
 		} else if errors.Is(response.Err, ErrConsumerCoordinatorNotAvailable) {
-			Logger.Printf("client/coordinator coordinator for consumer group %s is not available\n", consumerGroup)
+			newContextLogger("group", consumerGroup).Warnf("client/coordinator coordinator for consumer group %s is not available", consumerGroup)
 
 			// This is very ugly, but this scenario will only happen once per cluster.
 			// The __consumer_offsets topic only has to be created one time.
 			// The number of partitions not configurable, but partition 0 should always exist.
-			if _, err := client.Leader("__consumer_offsets", 0); err != nil {
-				Logger.Printf("client/coordinator the __consumer_offsets topic is not initialized completely yet. Waiting 2 seconds...\n")
-				time.Sleep(2 * time.Second)
+			if _, err := client.leaderContext(ctx, "__consumer_offsets", 0); err != nil {
+				newContextLogger("group", consumerGroup).Warnf("client/coordinator the __consumer_offsets topic is not initialized completely yet. Waiting 2 seconds...")
+				if err := client.sleep(ctx, 2*time.Second); err != nil {
+					return nil, err
+				}
 			}
 
 			return retry(ErrConsumerCoordinatorNotAvailable)
 		} else if errors.Is(response.Err, ErrGroupAuthorizationFailed) {
-			Logger.Printf("client was not authorized to access group %s while attempting to find coordinator", consumerGroup)
+			newContextLogger("group", consumerGroup).Errorf("client was not authorized to access group %s while attempting to find coordinator", consumerGroup)
 			return retry(ErrGroupAuthorizationFailed)
 		} else {
 			return nil, response.Err
 		}
 	}
 
-	Logger.Println("client/coordinator no available broker to send consumer metadata request to")
+	newContextLogger().Warnf("client/coordinator no available broker to send consumer metadata request to")
+	client.resurrectDeadBrokers()
+	return retry(Wrap(ErrOutOfBrokers, brokerErrors...))
+}
+
+func (client *client) getTransactionCoordinator(ctx context.Context, transactionID string, attemptsRemaining int) (*FindCoordinatorResponse, error) {
+	retry := func(err error) (*FindCoordinatorResponse, error) {
+		if attemptsRemaining > 0 {
+			backoff := client.computeBackoff(attemptsRemaining)
+			newContextLogger().Warnf("client/coordinator retrying after %dms... (%d attempts remaining)", backoff/time.Millisecond, attemptsRemaining)
+			if err := client.sleep(ctx, backoff); err != nil {
+				return nil, err
+			}
+			return client.getTransactionCoordinator(ctx, transactionID, attemptsRemaining-1)
+		}
+		return nil, err
+	}
+
+	brokerErrors := make([]error, 0)
+	for broker := client.any(); broker != nil; broker = client.any() {
+		newContextLogger("transactionalID", transactionID).Debugf("client/coordinator requesting coordinator for transactional id %s from %s", transactionID, broker.Addr())
+
+		request := new(FindCoordinatorRequest)
+		request.CoordinatorKey = transactionID
+		request.CoordinatorType = CoordinatorTransaction
+		request.Version = 1
+
+		response, err := broker.findCoordinator(ctx, request)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			newContextLogger("transactionalID", transactionID).Warnf("client/coordinator request to broker %s failed: %s", broker.Addr(), err)
+
+			var packetEncodingError PacketEncodingError
+			if errors.As(err, &packetEncodingError) {
+				return nil, err
+			} else {
+				_ = broker.Close()
+				brokerErrors = append(brokerErrors, err)
+				client.deregisterBroker(broker)
+				continue
+			}
+		}
+
+		if errors.Is(response.Err, ErrNoError) {
+			newContextLogger("transactionalID", transactionID, "broker", response.Coordinator.ID()).Debugf("client/coordinator coordinator for transactional id %s is #%d (%s)", transactionID, response.Coordinator.ID(), response.Coordinator.Addr())
+			return response, nil
+		} else if errors.Is(response.Err, ErrConsumerCoordinatorNotAvailable) {
+			newContextLogger("transactionalID", transactionID).Warnf("client/coordinator coordinator for transactional id %s is not available", transactionID)
+			return retry(ErrConsumerCoordinatorNotAvailable)
+		} else if errors.Is(response.Err, ErrTransactionalIDAuthorizationFailed) {
+			newContextLogger("transactionalID", transactionID).Errorf("client was not authorized to access transactional id %s while attempting to find coordinator", transactionID)
+			return retry(ErrTransactionalIDAuthorizationFailed)
+		} else {
+			return nil, response.Err
+		}
+	}
+
+	newContextLogger().Warnf("client/coordinator no available broker to send transaction coordinator request to")
 	client.resurrectDeadBrokers()
 	return retry(Wrap(ErrOutOfBrokers, brokerErrors...))
 }
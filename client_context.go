@@ -0,0 +1,150 @@
+package sarama
+
+import "context"
+
+// clientContext is the Client returned by Client.WithContext. The calls which
+// may send requests pass the context down to their retries and to the
+// brokers, which stop waiting as soon as it is done.
+type clientContext struct {
+	client *client
+	ctx    context.Context
+}
+
+func (client *client) WithContext(ctx context.Context) Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	return &clientContext{client: client, ctx: ctx}
+}
+
+func (c *clientContext) WithContext(ctx context.Context) Client {
+	return c.client.WithContext(ctx)
+}
+
+func (c *clientContext) Config() *Config {
+	return c.client.Config()
+}
+
+func (c *clientContext) Controller() (*Broker, error) {
+	return c.client.controllerContext(c.ctx)
+}
+
+func (c *clientContext) RefreshController() (*Broker, error) {
+	return c.client.refreshControllerContext(c.ctx)
+}
+
+func (c *clientContext) ClusterID() (string, error) {
+	return c.client.clusterIDContext(c.ctx)
+}
+
+func (c *clientContext) TopicID(topic string) (Uuid, error) {
+	return c.client.topicIDContext(c.ctx, topic)
+}
+
+func (c *clientContext) ClientInstanceID() (Uuid, error) {
+	return c.client.clientInstanceIDContext(c.ctx)
+}
+
+func (c *clientContext) Brokers() []*Broker {
+	return c.client.Brokers()
+}
+
+func (c *clientContext) Broker(brokerID int32) (*Broker, error) {
+	return c.client.Broker(brokerID)
+}
+
+func (c *clientContext) Topics() ([]string, error) {
+	return c.client.Topics()
+}
+
+func (c *clientContext) Partitions(topic string) ([]int32, error) {
+	return c.client.partitionsContext(c.ctx, topic)
+}
+
+func (c *clientContext) WritablePartitions(topic string) ([]int32, error) {
+	return c.client.writablePartitionsContext(c.ctx, topic)
+}
+
+func (c *clientContext) OfflinePartitions(topic string) (map[int32]PartitionUnavailability, error) {
+	return c.client.offlinePartitionsContext(c.ctx, topic)
+}
+
+func (c *clientContext) Leader(topic string, partitionID int32) (*Broker, error) {
+	return c.client.leaderContext(c.ctx, topic, partitionID)
+}
+
+func (c *clientContext) Replicas(topic string, partitionID int32) ([]int32, error) {
+	return c.client.replicasContext(c.ctx, topic, partitionID)
+}
+
+func (c *clientContext) InSyncReplicas(topic string, partitionID int32) ([]int32, error) {
+	return c.client.inSyncReplicasContext(c.ctx, topic, partitionID)
+}
+
+func (c *clientContext) OfflineReplicas(topic string, partitionID int32) ([]int32, error) {
+	return c.client.offlineReplicasContext(c.ctx, topic, partitionID)
+}
+
+func (c *clientContext) RefreshBrokers(addrs []string) error {
+	return c.client.RefreshBrokers(addrs)
+}
+
+func (c *clientContext) RefreshMetadata(topics ...string) error {
+	return c.client.refreshMetadataContext(c.ctx, topics...)
+}
+
+func (c *clientContext) GetOffset(topic string, partitionID int32, time int64) (int64, error) {
+	return c.client.getOffsetContext(c.ctx, topic, partitionID, time)
+}
+
+func (c *clientContext) GetOffsetAndTimestamp(topic string, partitionID int32, time int64) (int64, int64, error) {
+	return c.client.getOffsetAndTimestampContext(c.ctx, topic, partitionID, time)
+}
+
+func (c *clientContext) OffsetsForTimestamps(times map[string]map[int32]int64) (map[string]map[int32]*PartitionOffset, error) {
+	return c.client.offsetsForTimestampsContext(c.ctx, times)
+}
+
+func (c *clientContext) Coordinator(consumerGroup string) (*Broker, error) {
+	return c.client.coordinatorContext(c.ctx, consumerGroup)
+}
+
+func (c *clientContext) RefreshCoordinator(consumerGroup string) error {
+	return c.client.refreshCoordinatorContext(c.ctx, consumerGroup)
+}
+
+func (c *clientContext) TransactionCoordinator(transactionID string) (*Broker, error) {
+	return c.client.transactionCoordinatorContext(c.ctx, transactionID)
+}
+
+func (c *clientContext) RefreshTransactionCoordinator(transactionID string) error {
+	return c.client.refreshTransactionCoordinatorContext(c.ctx, transactionID)
+}
+
+func (c *clientContext) InitProducerID() (*InitProducerIDResponse, error) {
+	return c.client.initProducerIDContext(c.ctx)
+}
+
+func (c *clientContext) LeastLoadedBroker() *Broker {
+	return c.client.LeastLoadedBroker()
+}
+
+func (c *clientContext) SubscribeMetadataChanges() (<-chan *MetadataChange, func()) {
+	return c.client.SubscribeMetadataChanges()
+}
+
+func (c *clientContext) Throttling() map[int32]BrokerThrottle {
+	return c.client.Throttling()
+}
+
+func (c *clientContext) Stats() *ClientStats {
+	return c.client.Stats()
+}
+
+func (c *clientContext) Close() error {
+	return c.client.Close()
+}
+
+func (c *clientContext) Closed() bool {
+	return c.client.Closed()
+}
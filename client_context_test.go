@@ -0,0 +1,100 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClientWithContext(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetNewest, 42),
+	})
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	offset, err := c.WithContext(context.Background()).GetOffset("my_topic", 0, OffsetNewest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 42 {
+		t.Errorf("expected offset 42, got %d", offset)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.WithContext(ctx).RefreshMetadata(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// the broker now takes longer to answer than the deadline
+	seedBroker.SetLatency(time.Second)
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := c.WithContext(ctx).RefreshMetadata("my_topic"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected RefreshMetadata to return at the deadline, took %v", elapsed)
+	}
+}
+
+func TestClientWithContextStopsRetrying(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(seedBroker.Addr(), seedBroker.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, -1, []int32{}, []int32{}, []int32{}, ErrLeaderNotAvailable)
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetError(CoordinatorGroup, "my_group", ErrConsumerCoordinatorNotAvailable),
+	})
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	config.Metadata.SingleFlight = false
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	// the retries would wait for minutes without the context
+	config.Metadata.Retry.Max = 10
+	config.Metadata.Retry.Backoff = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := c.WithContext(ctx).RefreshMetadata("my_topic"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if err := c.WithContext(ctx).RefreshCoordinator("my_group"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the retries to stop at the deadline, took %v", elapsed)
+	}
+
+	// the broker was not blamed for the context being done
+	if len(c.Brokers()) != 1 {
+		t.Errorf("expected the broker to be kept, got %d brokers", len(c.Brokers()))
+	}
+}
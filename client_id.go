@@ -0,0 +1,56 @@
+package sarama
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	hostname     string
+	hostnameOnce sync.Once
+)
+
+func clientIDHost() string {
+	hostnameOnce.Do(func() {
+		hostname, _ = os.Hostname()
+	})
+	return hostname
+}
+
+// requestClientIDSuffix returns the suffix of ClientIDSuffix for the
+// component sending the requests of the API, if any: the metadata,
+// coordinator, API versions and SASL requests are shared by all of them.
+func (c *Config) requestClientIDSuffix(key int16) string {
+	switch key {
+	case 0, 22, 24, 25, 26, 28: // Produce, InitProducerId and the transactions
+		return c.ClientIDSuffix.Producer
+	case 1, 2, 8, 9, 11, 12, 13, 14, 68: // Fetch, ListOffsets, offsets and groups
+		return c.ClientIDSuffix.Consumer
+	case 3, 10, 17, 18, 36: // Metadata, FindCoordinator, ApiVersions and SASL
+		return ""
+	default:
+		return c.ClientIDSuffix.Admin
+	}
+}
+
+// requestClientID returns the client ID to send in the header of the
+// requests of the API, ClientID decorated by ClientIDSuffix
+func (c *Config) requestClientID(key int16) string {
+	suffix := c.requestClientIDSuffix(key)
+	if suffix == "" && !c.ClientIDSuffix.Host && !c.ClientIDSuffix.Version {
+		return c.ClientID
+	}
+
+	parts := []string{c.ClientID}
+	if suffix != "" {
+		parts = append(parts, suffix)
+	}
+	if host := clientIDHost(); c.ClientIDSuffix.Host && host != "" {
+		parts = append(parts, host)
+	}
+	if c.ClientIDSuffix.Version {
+		parts = append(parts, "sarama", version())
+	}
+	return strings.Join(parts, "-")
+}
@@ -0,0 +1,53 @@
+package sarama
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRequestClientID(t *testing.T) {
+	config := NewTestConfig()
+	config.ClientID = "my-service"
+	if id := config.requestClientID(0); id != "my-service" {
+		t.Errorf("expected the undecorated ClientID, got %q", id)
+	}
+
+	config.ClientIDSuffix.Producer = "producer"
+	config.ClientIDSuffix.Consumer = "consumer"
+	config.ClientIDSuffix.Admin = "admin"
+	for key, expected := range map[int16]string{
+		0:  "my-service-producer",
+		26: "my-service-producer",
+		1:  "my-service-consumer",
+		11: "my-service-consumer",
+		19: "my-service-admin",
+		32: "my-service-admin",
+		3:  "my-service",
+		18: "my-service",
+	} {
+		if id := config.requestClientID(key); id != expected {
+			t.Errorf("expected %q for API %d, got %q", expected, key, id)
+		}
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+	config.ClientIDSuffix.Host = true
+	config.ClientIDSuffix.Version = true
+	if id, expected := config.requestClientID(1), "my-service-consumer-"+host+"-sarama-"+version(); id != expected {
+		t.Errorf("expected %q, got %q", expected, id)
+	}
+	if id, expected := config.requestClientID(3), "my-service-"+host+"-sarama-"+version(); id != expected {
+		t.Errorf("expected %q, got %q", expected, id)
+	}
+}
+
+func TestClientIDSuffixValidation(t *testing.T) {
+	config := NewTestConfig()
+	config.ClientIDSuffix.Consumer = "consumer group"
+	if err := config.Validate(); string(err.(ConfigurationError)) != "ClientIDSuffix.Consumer is invalid" {
+		t.Errorf("expected the consumer suffix to be invalid, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package sarama
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ClientStats is a snapshot of the state of a Client and of the producers
+// and consumers using it, see Client.Stats.
+type ClientStats struct {
+	// Time is when the snapshot was taken.
+	Time time.Time
+	// Brokers are the brokers known from the cluster metadata, by ID.
+	Brokers map[int32]BrokerStats
+	// SeedBrokers are the seed brokers the client bootstraps from.
+	SeedBrokers []BrokerStats
+	// LastMetadataRefresh is when metadata was last received from the
+	// brokers, the zero time if never.
+	LastMetadataRefresh time.Time
+	// LastFullMetadataRefresh is when the metadata of all the topics was
+	// last refreshed, the zero time if never.
+	LastFullMetadataRefresh time.Time
+	// Producers are the stats of the producers created from the client
+	// which are not closed.
+	Producers []ProducerStats
+	// Consumers are the stats of the consumers, including those of the
+	// consumer groups, created from the client which are not closed.
+	Consumers []ConsumerStats
+}
+
+// BrokerStats is the state of a broker in ClientStats.
+type BrokerStats struct {
+	ID   int32
+	Addr string
+	// Open is whether the connection to the broker is open or being opened.
+	Open bool
+	// RequestsInFlight is the number of requests awaiting a response.
+	RequestsInFlight int
+	// LastUsed is when a request was last sent to the broker, the zero time
+	// if never.
+	LastUsed time.Time
+	// Throttle is the quota throttling state of the broker.
+	Throttle BrokerThrottle
+}
+
+// ProducerStats is the state of a producer in ClientStats.
+type ProducerStats struct {
+	// Queued is the number of messages accepted by the producer which are
+	// neither acknowledged by the brokers nor failed yet, including those
+	// being retried.
+	Queued int
+}
+
+// ConsumerStats is the state of a consumer in ClientStats.
+type ConsumerStats struct {
+	// GroupID is the consumer group of the consumer, if created by a
+	// ConsumerGroup.
+	GroupID string
+	// Partitions are the partitions being consumed, by topic and partition.
+	Partitions map[string]map[int32]PartitionConsumerStats
+}
+
+// PartitionConsumerStats is the state of a PartitionConsumer in
+// ConsumerStats.
+type PartitionConsumerStats struct {
+	// Buffered is the number of messages fetched and waiting to be read
+	// from the Messages channel.
+	Buffered int
+	// HighWaterMarkOffset is the last high watermark offset fetched, see
+	// PartitionConsumer.HighWaterMarkOffset.
+	HighWaterMarkOffset int64
+	// Paused is whether the consumption of the partition is paused.
+	Paused bool
+}
+
+// statsReporter is a producer or consumer adding its state to the
+// ClientStats of the client it was created from
+type statsReporter interface {
+	addStats(stats *ClientStats)
+}
+
+func (client *client) Stats() *ClientStats {
+	client.lock.RLock()
+	stats := &ClientStats{
+		Time:                    client.conf.Clock.Now(),
+		Brokers:                 make(map[int32]BrokerStats, len(client.brokers)),
+		LastMetadataRefresh:     client.lastRefresh,
+		LastFullMetadataRefresh: client.lastFullRefresh,
+	}
+	now := time.Now()
+	for id, broker := range client.brokers {
+		stats.Brokers[id] = broker.stats(now)
+	}
+	for _, broker := range client.seedBrokers {
+		stats.SeedBrokers = append(stats.SeedBrokers, broker.stats(now))
+	}
+	client.lock.RUnlock()
+
+	client.statsLock.Lock()
+	defer client.statsLock.Unlock()
+	for _, reporter := range client.statsReporters {
+		reporter.addStats(stats)
+	}
+	return stats
+}
+
+// stats returns the state of the broker, without waiting for its lock,
+// which is held while connecting
+func (b *Broker) stats(now time.Time) BrokerStats {
+	stats := BrokerStats{
+		ID:               b.ID(),
+		Addr:             b.Addr(),
+		Open:             atomic.LoadInt32(&b.opened) == 1,
+		RequestsInFlight: b.RequestsInFlight(),
+		Throttle: BrokerThrottle{
+			Until: b.ThrottledUntil(),
+			Total: b.ThrottleTime(),
+		},
+	}
+	stats.Throttle.Throttled = stats.Throttle.Until.After(now)
+	if lastUsed := atomic.LoadInt64(&b.lastUsed); lastUsed != 0 {
+		stats.LastUsed = time.Unix(0, lastUsed)
+	}
+	return stats
+}
+
+// registerStats adds the state of the reporter to the stats of the client
+// it was created from, unless not created by NewClient
+func registerStats(c Client, reporter statsReporter) {
+	if client := statsClient(c); client != nil {
+		client.statsLock.Lock()
+		defer client.statsLock.Unlock()
+		client.statsReporters = append(client.statsReporters, reporter)
+	}
+}
+
+func unregisterStats(c Client, reporter statsReporter) {
+	if client := statsClient(c); client != nil {
+		client.statsLock.Lock()
+		defer client.statsLock.Unlock()
+		for i, r := range client.statsReporters {
+			if r == reporter {
+				client.statsReporters = append(client.statsReporters[:i], client.statsReporters[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// statsClient returns the client created by NewClient which c is or wraps,
+// nil if none
+func statsClient(c Client) *client {
+	switch c := c.(type) {
+	case *client:
+		return c
+	case *nopCloserClient:
+		return statsClient(c.Client)
+	case *clientContext:
+		return c.client
+	}
+	return nil
+}
+
+func (p *asyncProducer) addStats(stats *ClientStats) {
+	stats.Producers = append(stats.Producers, ProducerStats{
+		Queued: int(atomic.LoadInt64(&p.queued)),
+	})
+}
+
+func (c *consumer) addStats(stats *ClientStats) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	consumerStats := ConsumerStats{
+		GroupID:    c.groupID,
+		Partitions: make(map[string]map[int32]PartitionConsumerStats, len(c.children)),
+	}
+	for topic, partitions := range c.children {
+		topicStats := make(map[int32]PartitionConsumerStats, len(partitions))
+		for partition, child := range partitions {
+			topicStats[partition] = PartitionConsumerStats{
+				Buffered:            len(child.messages),
+				HighWaterMarkOffset: child.HighWaterMarkOffset(),
+				Paused:              child.IsPaused(),
+			}
+		}
+		consumerStats.Partitions[topic] = topicStats
+	}
+	stats.Consumers = append(stats.Consumers, consumerStats)
+}
@@ -0,0 +1,73 @@
+package sarama
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClientStats(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 10),
+		"FetchRequest": NewMockFetchResponse(t, 1).
+			SetMessage("my_topic", 0, 0, testMsg).
+			SetHighWaterMark("my_topic", 0, 10),
+	})
+
+	client, err := NewClient([]string{seedBroker.Addr()}, NewTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	producer, err := NewAsyncProducerFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumer, err := NewConsumerFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc, err := consumer.ConsumePartition("my_topic", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc.Pause()
+
+	stats := client.WithContext(context.Background()).Stats()
+	if stats.LastMetadataRefresh.IsZero() || time.Since(stats.LastMetadataRefresh) > time.Minute {
+		t.Errorf("Unexpected last metadata refresh %v", stats.LastMetadataRefresh)
+	}
+	broker, ok := stats.Brokers[1]
+	if !ok || broker.Addr != seedBroker.Addr() || !broker.Open {
+		t.Errorf("Unexpected broker stats %+v", stats.Brokers)
+	}
+	if len(stats.SeedBrokers) != 1 {
+		t.Errorf("Expected 1 seed broker, got %+v", stats.SeedBrokers)
+	}
+	if len(stats.Producers) != 1 || stats.Producers[0].Queued != 0 {
+		t.Errorf("Unexpected producer stats %+v", stats.Producers)
+	}
+	if len(stats.Consumers) != 1 {
+		t.Fatalf("Unexpected consumer stats %+v", stats.Consumers)
+	}
+	partition, ok := stats.Consumers[0].Partitions["my_topic"][0]
+	if !ok || !partition.Paused || partition.HighWaterMarkOffset != 10 {
+		t.Errorf("Unexpected partition consumer stats %+v", stats.Consumers[0].Partitions)
+	}
+
+	safeClose(t, pc)
+	safeClose(t, consumer)
+	safeClose(t, producer)
+	stats = client.Stats()
+	if len(stats.Producers) != 0 || len(stats.Consumers) != 0 {
+		t.Errorf("Expected the closed producer and consumer to be gone, got %+v", stats)
+	}
+}
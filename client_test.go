@@ -1,8 +1,12 @@
 package sarama
 
 import (
+	"context"
 	"errors"
 	"io"
+	"net"
+	"reflect"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -70,6 +74,53 @@ func TestCachedPartitions(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientOfflinePartitions(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	replicas := []int32{1, 2, 3}
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(seedBroker.Addr(), seedBroker.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, 1, replicas, []int32{1, 2}, []int32{}, ErrNoError)
+	metadataResponse.AddTopicPartition("my_topic", 1, -1, replicas, []int32{}, []int32{}, ErrLeaderNotAvailable)
+	metadataResponse.AddTopicPartition("my_topic", 2, 1, replicas, []int32{1, 2}, []int32{}, ErrFencedLeaderEpoch)
+	metadataResponse.AddTopicPartition("my_topic", 3, 1, replicas, []int32{1}, []int32{}, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	config.Metadata.MinInSyncReplicas = 2
+	config.Producer.RequiredAcks = WaitForAll
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	offline, err := c.OfflinePartitions("my_topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[int32]PartitionUnavailability{
+		1: PartitionLeaderless,
+		2: PartitionFencedLeaderEpoch,
+		3: PartitionBelowMinInSyncReplicas,
+	}
+	if !reflect.DeepEqual(offline, expected) {
+		t.Errorf("expected %v, got %v", expected, offline)
+	}
+
+	writable, err := c.WritablePartitions("my_topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(writable, []int32{0}) {
+		t.Errorf("expected only partition 0 to be writable, got %v", writable)
+	}
+
+}
+
 func TestClientDoesntCachePartitionsForTopicsWithErrors(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 
@@ -351,6 +402,163 @@ func TestClientGetOffset(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientGetOffsetMaxTimestamp(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("foo", 0, seedBroker.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(7).
+			SetOffsetAndTimestamp("foo", 0, OffsetMaxTimestamp, 42, 1234567890),
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_0_0_0
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	offset, timestamp, err := c.GetOffsetAndTimestamp("foo", 0, OffsetMaxTimestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 42 || timestamp != 1234567890 {
+		t.Errorf("Unexpected offset %d and timestamp %d", offset, timestamp)
+	}
+
+	offset, err = c.GetOffset("foo", 0, OffsetMaxTimestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 42 {
+		t.Error("Unexpected offset, got ", offset)
+	}
+
+	for _, rr := range seedBroker.History() {
+		if req, ok := rr.Request.(*OffsetRequest); ok && req.Version != 7 {
+			t.Errorf("Expected OffsetRequest v7, got v%d", req.Version)
+		}
+	}
+}
+
+func TestClientGetOffsetMaxTimestampUnsupported(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("foo", 0, seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_0_0_0
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	if _, err := c.GetOffset("foo", 0, OffsetMaxTimestamp); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
+func TestClientOffsetsForTimestamps(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader1 := NewMockBroker(t, 2)
+	defer leader1.Close()
+	leader2 := NewMockBroker(t, 3)
+	defer leader2.Close()
+
+	metadataResponse := NewMockMetadataResponse(t).
+		SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+		SetBroker(leader1.Addr(), leader1.BrokerID()).
+		SetBroker(leader2.Addr(), leader2.BrokerID()).
+		SetLeader("foo", 0, leader1.BrokerID()).
+		SetLeader("foo", 1, leader1.BrokerID()).
+		SetLeader("bar", 0, leader2.BrokerID())
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadataResponse,
+	})
+	leader1.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadataResponse,
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(4).
+			SetOffsetAndTimestamp("foo", 0, 1000, 10, 1001).
+			SetOffsetAndTimestamp("foo", 1, OffsetNewest, 20, -1).
+			SetLeaderEpoch("foo", 0, 5).
+			SetLeaderEpoch("foo", 1, 6),
+	})
+	leader2.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadataResponse,
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(4).
+			SetOffsetAndTimestamp("bar", 0, OffsetOldest, 30, -1).
+			SetLeaderEpoch("bar", 0, 7),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_1_0_0
+	config.Metadata.Retry.Max = 0
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	offsets, err := c.OffsetsForTimestamps(map[string]map[int32]int64{
+		"foo": {0: 1000, 1: OffsetNewest},
+		"bar": {0: OffsetOldest, 1: OffsetOldest},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]map[int32]PartitionOffset{
+		"foo": {
+			0: {Offset: 10, Timestamp: 1001, LeaderEpoch: 5},
+			1: {Offset: 20, Timestamp: -1, LeaderEpoch: 6},
+		},
+		"bar": {
+			0: {Offset: 30, Timestamp: -1, LeaderEpoch: 7},
+		},
+	}
+	for topic, partitions := range expected {
+		for partition, want := range partitions {
+			got := offsets[topic][partition]
+			if got == nil || *got != want {
+				t.Errorf("unexpected offset for %s/%d: %+v", topic, partition, got)
+			}
+		}
+	}
+	if got := offsets["bar"][1]; got == nil || !errors.Is(got.Err, ErrUnknownTopicOrPartition) {
+		t.Errorf("expected ErrUnknownTopicOrPartition for bar/1, got %+v", got)
+	}
+
+	for _, leader := range []*MockBroker{leader1, leader2} {
+		requests := 0
+		for _, rr := range leader.History() {
+			if req, ok := rr.Request.(*OffsetRequest); ok {
+				requests++
+				if req.Version != 4 {
+					t.Errorf("expected OffsetRequest v4, got v%d", req.Version)
+				}
+			}
+		}
+		if requests != 1 {
+			t.Errorf("expected a single OffsetRequest to broker %d, got %d", leader.BrokerID(), requests)
+		}
+	}
+}
+
 func TestClientReceivingUnknownTopicWithBackoffFunc(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 
@@ -672,6 +880,413 @@ func TestClientResurrectDeadSeeds(t *testing.T) {
 	safeClose(t, c)
 }
 
+func TestClientRebootstrap(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+	seedAddr := seedBroker.Addr()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(leader.Addr(), leader.BrokerID()),
+	})
+
+	conf := NewTestConfig()
+	conf.Metadata.Retry.Max = 1
+	conf.Metadata.Retry.Backoff = 0
+	conf.Metadata.RefreshFrequency = 0
+	conf.Metadata.Rebootstrap.Enable = true
+	c, err := NewClient([]string{seedAddr}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+	client := c.(*client)
+	oldSeed := client.seedBrokers[0]
+
+	// the whole cluster is replaced behind the seed address
+	seedBroker.Close()
+	leader.Close()
+	newBroker := NewMockBrokerAddr(t, 3, seedAddr)
+	defer newBroker.Close()
+	newBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(newBroker.Addr(), newBroker.BrokerID()),
+	})
+
+	if err := client.RefreshMetadata(); err != nil {
+		t.Fatal(err)
+	}
+
+	brokers := client.Brokers()
+	if len(brokers) != 1 || brokers[0].ID() != newBroker.BrokerID() {
+		t.Errorf("Expected only the new broker to be known, got %v", brokers)
+	}
+	if len(client.seedBrokers) != 1 || client.seedBrokers[0] == oldSeed || client.seedBrokers[0].Addr() != seedAddr {
+		t.Error("Expected a new seed broker for the seed address")
+	}
+	if len(client.deadSeeds) != 0 {
+		t.Error("Expected no dead seeds")
+	}
+}
+
+func TestClientBootstrapFailover(t *testing.T) {
+	primary := NewMockBroker(t, 1)
+	primary.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(primary.Addr(), primary.BrokerID()),
+	})
+	secondary := NewMockBroker(t, 2)
+	defer secondary.Close()
+	secondary.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(secondary.Addr(), secondary.BrokerID()),
+	})
+
+	conf := NewTestConfig()
+	conf.Metadata.Retry.Max = 2
+	conf.Metadata.Retry.Backoff = 0
+	conf.Metadata.RefreshFrequency = 0
+	conf.Metadata.Failover.Bootstrap = [][]string{{secondary.Addr()}}
+	conf.Metadata.Failover.After = 0
+	c, err := NewClient([]string{primary.Addr()}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	changes, unsubscribe := c.SubscribeMetadataChanges()
+	defer unsubscribe()
+
+	// the whole primary cluster goes away
+	primary.Close()
+
+	if err := c.RefreshMetadata(); err != nil {
+		t.Fatal(err)
+	}
+
+	brokers := c.Brokers()
+	if len(brokers) != 1 || brokers[0].ID() != secondary.BrokerID() {
+		t.Errorf("Expected only the secondary broker to be known, got %v", brokers)
+	}
+
+	select {
+	case change := <-changes:
+		if change.Type != MetadataClusterFailover || !reflect.DeepEqual(change.Bootstrap, []string{secondary.Addr()}) {
+			t.Errorf("Expected a failover to %s, got %+v", secondary.Addr(), change)
+		}
+	default:
+		t.Error("Expected a failover change")
+	}
+}
+
+func TestClientResolveSeedBrokers(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	conf := NewTestConfig()
+	conf.Metadata.RefreshFrequency = 0
+	c, err := NewClient([]string{seedBroker.Addr()}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+	client := c.(*client)
+
+	var lock sync.Mutex
+	resolved := []string{"10.0.0.1"}
+	client.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return resolved, nil
+	}
+	seed := client.seedBrokers[0]
+
+	client.resolveSeedBrokers()
+	client.resolveSeedBrokers()
+	time.Sleep(50 * time.Millisecond)
+	if connected, _ := seed.Connected(); !connected {
+		t.Fatal("Expected the seed broker to stay connected while its address is unchanged")
+	}
+
+	lock.Lock()
+	resolved = []string{"10.0.0.2"}
+	lock.Unlock()
+	client.resolveSeedBrokers()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if connected, _ := seed.Connected(); !connected {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the seed broker to be disconnected once its address changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMetadataRefreshMerge(t *testing.T) {
+	r := &metadataRefresh{topics: make(map[string]none)}
+	if !r.merge([]string{"b", "a"}, true) {
+		t.Fatal("Expected topics to merge into a refresh of topics")
+	}
+	if !reflect.DeepEqual(r.topicList(), []string{"a", "b"}) {
+		t.Errorf("Unexpected topics refreshed %v", r.topicList())
+	}
+	if r.merge(nil, true) {
+		t.Error("Expected a refresh of all topics not to merge into a refresh of auto-created topics")
+	}
+	if !r.merge(nil, false) || !r.all || r.topicList() != nil {
+		t.Error("Expected a refresh of all topics to merge without auto-creation")
+	}
+	if r.merge([]string{"c"}, true) || !r.merge([]string{"c"}, false) {
+		t.Error("Expected only topics not auto-created to merge into a refresh of all topics")
+	}
+}
+
+func TestClientMetadataSingleFlight(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()).
+			SetLeader("other_topic", 0, seedBroker.BrokerID()).
+			SetLeader("third_topic", 0, seedBroker.BrokerID()),
+	})
+
+	conf := NewTestConfig()
+	conf.Metadata.RefreshFrequency = 0
+	c, err := NewClient([]string{seedBroker.Addr()}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+	seedBroker.SetLatency(200 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	refresh := func(topics ...string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.RefreshMetadata(topics...); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	refresh("my_topic")
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		refresh("my_topic")
+	}
+	refresh("other_topic")
+	refresh("third_topic")
+	wg.Wait()
+
+	var requests []*MetadataRequest
+	for _, rr := range seedBroker.History() {
+		if req, ok := rr.Request.(*MetadataRequest); ok {
+			requests = append(requests, req)
+		}
+	}
+	// the first request is the initial refresh of NewClient
+	if len(requests) != 3 {
+		t.Fatalf("Expected 2 coalesced metadata requests, got %d", len(requests)-1)
+	}
+	if !reflect.DeepEqual(requests[1].Topics, []string{"my_topic"}) {
+		t.Errorf("Expected the first refresh to fetch my_topic, got %v", requests[1].Topics)
+	}
+	// including those of my_topic, which may need more recent metadata than
+	// the refresh in flight when they were requested
+	if !reflect.DeepEqual(requests[2].Topics, []string{"my_topic", "other_topic", "third_topic"}) {
+		t.Errorf("Expected the queued refreshes to be merged, got %v", requests[2].Topics)
+	}
+}
+
+func TestClientFullRefreshMinInterval(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+	})
+
+	conf := NewTestConfig()
+	conf.Metadata.RefreshFrequency = 0
+	conf.Metadata.FullRefreshMinInterval = time.Hour
+	c, err := NewClient([]string{seedBroker.Addr()}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	if err := c.RefreshMetadata(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RefreshMetadata("my_topic"); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := 0
+	for _, rr := range seedBroker.History() {
+		if _, ok := rr.Request.(*MetadataRequest); ok {
+			requests++
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Expected the second refresh of all topics to be skipped, got %d requests", requests)
+	}
+}
+
+func TestClientLeastLoadedBroker(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	secondBroker := NewMockBroker(t, 2)
+	defer secondBroker.Close()
+
+	metadata := NewMockMetadataResponse(t).
+		SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+		SetBroker(secondBroker.Addr(), secondBroker.BrokerID())
+	seedBroker.SetHandlerByMap(map[string]MockResponse{"MetadataRequest": metadata})
+	secondBroker.SetHandlerByMap(map[string]MockResponse{"MetadataRequest": metadata})
+
+	conf := NewTestConfig()
+	conf.Metadata.RefreshFrequency = 0
+	conf.BrokerSelector = BrokerSelectorFunc(func(brokers []*Broker) *Broker {
+		return brokers[len(brokers)-1]
+	})
+	c, err := NewClient([]string{seedBroker.Addr()}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	if broker := c.LeastLoadedBroker(); broker == nil || broker.ID() != secondBroker.BrokerID() {
+		t.Fatalf("Expected the selected broker 2, got %v", broker)
+	}
+
+	if err := c.RefreshMetadata(); err != nil {
+		t.Fatal(err)
+	}
+	if len(secondBroker.History()) != 1 {
+		t.Errorf("Expected the metadata request to be sent to the selected broker, got %d requests", len(secondBroker.History()))
+	}
+}
+
+func TestClientThrottling(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader := NewMockBroker(t, 2)
+	defer leader.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(leader.Addr(), leader.BrokerID()).
+			SetLeader("foo", 0, leader.BrokerID()),
+	})
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"FetchRequest": NewMockWrapper(&FetchResponse{Version: 1, ThrottleTime: time.Minute}),
+	})
+
+	var hooked int32
+	config := NewTestConfig()
+	config.Version = V0_10_0_0
+	config.Net.ThrottleHook = func(brokerID int32, throttleTime time.Duration) {
+		if brokerID != leader.BrokerID() || throttleTime != time.Minute {
+			t.Errorf("unexpected throttling of broker %d for %v", brokerID, throttleTime)
+		}
+		atomic.AddInt32(&hooked, 1)
+	}
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	if throttle := c.Throttling()[leader.BrokerID()]; throttle.Throttled || !throttle.Until.IsZero() || throttle.Total != 0 {
+		t.Errorf("expected no throttling yet, got %+v", throttle)
+	}
+
+	broker, err := c.Leader("foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := broker.Fetch(&FetchRequest{Version: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&hooked); n != 2 {
+		t.Errorf("expected the throttle hook to be called twice, got %d", n)
+	}
+	throttle := c.Throttling()[leader.BrokerID()]
+	if !throttle.Throttled || throttle.Until.Before(time.Now()) || throttle.Total != 2*time.Minute {
+		t.Errorf("expected the leader to be throttled for 2 minutes in total, got %+v", throttle)
+	}
+}
+
+func TestClientAddressMapper(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader := NewMockBroker(t, 2)
+	defer leader.Close()
+
+	advertised := "advertised.invalid:9092"
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(advertised, leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+		"FindCoordinatorRequest": NewMockWrapper(&FindCoordinatorResponse{
+			Coordinator: &Broker{id: leader.BrokerID(), addr: advertised},
+		}),
+	})
+
+	leaderHost, leaderPort, err := net.SplitHostPort(leader.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(leaderPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	config.Net.AddressMapper = func(brokerID int32, host string, _ int32) (string, int32) {
+		if brokerID != leader.BrokerID() || host != "advertised.invalid" {
+			t.Errorf("unexpected address of broker %d: %s", brokerID, host)
+		}
+		return leaderHost, int32(port)
+	}
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	broker, err := c.Leader("my_topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broker.Addr() != leader.Addr() {
+		t.Errorf("expected the leader at %s, got %s", leader.Addr(), broker.Addr())
+	}
+
+	coordinator, err := c.Coordinator("my_group")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coordinator.Addr() != leader.Addr() {
+		t.Errorf("expected the coordinator at %s, got %s", leader.Addr(), coordinator.Addr())
+	}
+}
+
 //nolint:paralleltest
 func TestClientController(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
@@ -719,6 +1334,44 @@ func TestClientController(t *testing.T) {
 	})
 }
 
+func TestClientClusterID(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetClusterID("my-cluster"),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	clusterID, err := c.ClusterID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clusterID != "my-cluster" {
+		t.Errorf("expected cluster ID my-cluster, got %q", clusterID)
+	}
+
+	config = NewTestConfig()
+	config.Version = V0_10_0_0
+	old, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, old)
+	if _, err := old.ClusterID(); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
 func TestClientMetadataTimeout(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -1049,3 +1702,85 @@ func TestInitProducerIDConnectionRefused(t *testing.T) {
 
 	safeClose(t, client)
 }
+
+func TestClientTransactionCoordinator(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	coordinator := NewMockBroker(t, 2)
+	defer coordinator.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorTransaction, "my_transaction", coordinator).
+			SetError(CoordinatorTransaction, "denied", ErrTransactionalIDAuthorizationFailed),
+	})
+
+	config := NewTestConfig()
+	config.Version = V0_11_0_0
+	config.Metadata.Retry.Max = 0
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	broker, err := c.TransactionCoordinator("my_transaction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broker.ID() != coordinator.BrokerID() || broker.Addr() != coordinator.Addr() {
+		t.Errorf("Expected coordinator %d at %s, got %d at %s", coordinator.BrokerID(), coordinator.Addr(), broker.ID(), broker.Addr())
+	}
+
+	// the group coordinator cache must be unaffected
+	if cached := c.(*client).cachedCoordinator("my_transaction"); cached != nil {
+		t.Errorf("Expected no group coordinator to be cached, got %d", cached.ID())
+	}
+
+	if _, err := c.TransactionCoordinator("denied"); !errors.Is(err, ErrTransactionalIDAuthorizationFailed) {
+		t.Errorf("Expected ErrTransactionalIDAuthorizationFailed, got %v", err)
+	}
+}
+
+func TestClientTopicID(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	id := Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("foo", 0, seedBroker.BrokerID()).
+			SetTopicID("foo", id),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_8_0_0
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	topicID, err := c.TopicID("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topicID != id {
+		t.Errorf("Unexpected topic ID %s", topicID)
+	}
+
+	config = NewTestConfig()
+	config.Version = V2_7_0_0
+	old, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, old)
+	if _, err := old.TopicID("foo"); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+}
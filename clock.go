@@ -0,0 +1,56 @@
+package sarama
+
+import "time"
+
+// Clock is the source of time of the timers of the clients, producers,
+// consumers and consumer groups, such as the flush frequency, the retry
+// backoffs, the heartbeats and the metadata refreshes. It is set with
+// Config.Clock, so that tests can control the time with a fake clock instead
+// of waiting for the wall clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel receiving the current time once d elapsed.
+	After(d time.Duration) <-chan time.Time
+	// Sleep pauses the current goroutine for d.
+	Sleep(d time.Duration)
+	// NewTimer returns a Timer firing once d elapsed.
+	NewTimer(d time.Duration) Timer
+	// NewTicker returns a Ticker firing every d, which must be > 0.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the time.Timer of a Clock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the time.Ticker of a Clock.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// NewSystemClock returns the Clock of the wall clock, which is the default
+// of Config.Clock.
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (systemClock) NewTimer(d time.Duration) Timer         { return systemTimer{time.NewTimer(d)} }
+func (systemClock) NewTicker(d time.Duration) Ticker       { return systemTicker{time.NewTicker(d)} }
+
+type systemTimer struct{ *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.Timer.C }
+
+type systemTicker struct{ *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.Ticker.C }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"fmt"
+	"io"
 	"sync"
 
 	snappy "github.com/eapache/go-xerial-snappy"
@@ -105,7 +106,7 @@ var (
 	}
 )
 
-func compress(cc CompressionCodec, level int, data []byte) ([]byte, error) {
+func compress(cc CompressionCodec, level int, zstdDictionaryID uint32, data []byte) ([]byte, error) {
 	switch cc {
 	case CompressionNone:
 		return data, nil
@@ -178,6 +179,10 @@ func compress(cc CompressionCodec, level int, data []byte) ([]byte, error) {
 
 		var buf bytes.Buffer
 		writer.Reset(&buf)
+		writer.Header.CompressionLevel = 0
+		if level != CompressionLevelDefault {
+			writer.Header.CompressionLevel = level
+		}
 
 		if _, err := writer.Write(data); err != nil {
 			return nil, err
@@ -187,8 +192,42 @@ func compress(cc CompressionCodec, level int, data []byte) ([]byte, error) {
 		}
 		return buf.Bytes(), nil
 	case CompressionZSTD:
-		return zstdCompress(ZstdEncoderParams{level}, nil, data)
+		return zstdCompress(ZstdEncoderParams{Level: level, DictionaryID: zstdDictionaryID}, nil, data)
 	default:
+		if impl, ok := registeredCompressionCodec(cc); ok {
+			return impl.encode(level, data)
+		}
 		return nil, PacketEncodingError{fmt.Sprintf("unsupported compression codec (%d)", cc)}
 	}
 }
+
+// Ranges of the compression levels, as accepted by Kafka
+const (
+	lz4MinCompressionLevel  = 1
+	lz4MaxCompressionLevel  = 17
+	zstdMinCompressionLevel = -131072
+	zstdMaxCompressionLevel = 22
+)
+
+// checkCompressionLevel returns an error when the compression level is out of
+// the range of the codec, the level of the other codecs being ignored
+func checkCompressionLevel(cc CompressionCodec, level int) error {
+	if level == CompressionLevelDefault {
+		return nil
+	}
+	switch cc {
+	case CompressionGZIP:
+		if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+			return fmt.Errorf("gzip compression does not work with level %d: %w", level, err)
+		}
+	case CompressionLZ4:
+		if level < lz4MinCompressionLevel || level > lz4MaxCompressionLevel {
+			return fmt.Errorf("lz4 compression level must be between %d and %d, got %d", lz4MinCompressionLevel, lz4MaxCompressionLevel, level)
+		}
+	case CompressionZSTD:
+		if level < zstdMinCompressionLevel || level > zstdMaxCompressionLevel {
+			return fmt.Errorf("zstd compression level must be between %d and %d, got %d", zstdMinCompressionLevel, zstdMaxCompressionLevel, level)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package sarama
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressionLevels(t *testing.T) {
+	data := bytes.Repeat([]byte(TestMessage), 1000)
+
+	for _, tc := range []struct {
+		codec  CompressionCodec
+		levels []int
+	}{
+		{CompressionGZIP, []int{CompressionLevelDefault, 1, 9}},
+		{CompressionLZ4, []int{CompressionLevelDefault, 1, 9, 17}},
+		{CompressionZSTD, []int{CompressionLevelDefault, -5, 1, 22}},
+	} {
+		for _, level := range tc.levels {
+			compressed, err := compress(tc.codec, level, 0, data)
+			if err != nil {
+				t.Fatalf("%s level %d: %v", tc.codec, level, err)
+			}
+			decompressed, err := decompress(tc.codec, compressed, 0)
+			if err != nil || !bytes.Equal(decompressed, data) {
+				t.Errorf("%s level %d: unexpected round trip: %v", tc.codec, level, err)
+			}
+		}
+	}
+}
+
+func TestCompressionLevelValidation(t *testing.T) {
+	for _, tc := range []struct {
+		codec CompressionCodec
+		level int
+		valid bool
+	}{
+		{CompressionGZIP, 9, true},
+		{CompressionGZIP, 10, false},
+		{CompressionLZ4, 17, true},
+		{CompressionLZ4, 0, false},
+		{CompressionLZ4, 18, false},
+		{CompressionZSTD, -131072, true},
+		{CompressionZSTD, 23, false},
+		{CompressionSnappy, 100, true},
+		{CompressionZSTD, CompressionLevelDefault, true},
+	} {
+		config := NewTestConfig()
+		config.Version = V2_1_0_0
+		config.Producer.Compression = tc.codec
+		config.Producer.CompressionLevel = tc.level
+		if err := config.Validate(); (err == nil) != tc.valid {
+			t.Errorf("%s level %d: unexpected validation %v", tc.codec, tc.level, err)
+		}
+
+		// the same level overriding the one of a topic
+		config = NewTestConfig()
+		config.Version = V2_1_0_0
+		codec, level := tc.codec, tc.level
+		config.Topics = map[string]TopicOverrides{"my_topic": {}}
+		overrides := config.Topics["my_topic"]
+		overrides.Producer.Compression = &codec
+		overrides.Producer.CompressionLevel = &level
+		config.Topics["my_topic"] = overrides
+		if err := config.Validate(); (err == nil) != tc.valid {
+			t.Errorf("%s level %d: unexpected validation of the topic %v", tc.codec, tc.level, err)
+		}
+	}
+}
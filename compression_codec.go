@@ -0,0 +1,84 @@
+package sarama
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// CompressionEncoder compresses the data of a batch with a registered codec,
+// level being the Producer.CompressionLevel of its topic.
+type CompressionEncoder func(level int, data []byte) ([]byte, error)
+
+// CompressionDecoder decompresses the data of a batch compressed with a
+// registered codec.
+type CompressionDecoder func(data []byte) ([]byte, error)
+
+type compressionCodecImpl struct {
+	name   string
+	encode CompressionEncoder
+	decode CompressionDecoder
+}
+
+var (
+	compressionCodecsLock sync.RWMutex
+	compressionCodecs     = make(map[CompressionCodec]compressionCodecImpl)
+)
+
+// RegisterCompressionCodec registers a nonstandard compression codec which
+// can then be used as Producer.Compression, and which is used to decompress
+// the batches consumed with its ID in their attributes. The ID must fit the
+// three bits of the attributes reserved for the codec, i.e. be 5, 6 or 7 as
+// the lower ones are used by the built-in codecs, and the name is used to
+// print it and to parse it in the compression.type property. Registering a
+// codec again replaces its previous implementation.
+func RegisterCompressionCodec(codec CompressionCodec, name string, encode CompressionEncoder, decode CompressionDecoder) error {
+	switch {
+	case codec >= CompressionNone && codec <= CompressionZSTD:
+		return ConfigurationError(fmt.Sprintf("the built-in %s compression codec cannot be registered", codec))
+	case codec < 0 || int8(codec) > compressionCodecMask:
+		return ConfigurationError(fmt.Sprintf("the compression codec %d does not fit the batch attributes", codec))
+	case name == "":
+		return ConfigurationError(fmt.Sprintf("the name of the compression codec %d must not be empty", codec))
+	case encode == nil || decode == nil:
+		return ConfigurationError(fmt.Sprintf("the encoder and decoder of the %s compression codec must not be nil", name))
+	}
+	if _, err := parseBuiltinCompressionCodec(name); err == nil {
+		return ConfigurationError(fmt.Sprintf("the name %s is used by a built-in compression codec", name))
+	}
+
+	compressionCodecsLock.Lock()
+	defer compressionCodecsLock.Unlock()
+	compressionCodecs[codec] = compressionCodecImpl{name: name, encode: encode, decode: decode}
+	return nil
+}
+
+// registeredCompressionCodec returns the implementation of a registered codec
+func registeredCompressionCodec(codec CompressionCodec) (compressionCodecImpl, bool) {
+	compressionCodecsLock.RLock()
+	defer compressionCodecsLock.RUnlock()
+	impl, ok := compressionCodecs[codec]
+	return impl, ok
+}
+
+// registeredCompressionCodecByName returns the ID of the registered codec
+// with the given name, ignoring the case
+func registeredCompressionCodecByName(name string) (CompressionCodec, bool) {
+	compressionCodecsLock.RLock()
+	defer compressionCodecsLock.RUnlock()
+	for codec, impl := range compressionCodecs {
+		if strings.EqualFold(impl.name, name) {
+			return codec, true
+		}
+	}
+	return 0, false
+}
+
+// knownCompressionCodec reports whether the codec is built-in or registered
+func knownCompressionCodec(codec CompressionCodec) bool {
+	if codec >= CompressionNone && codec <= CompressionZSTD {
+		return true
+	}
+	_, ok := registeredCompressionCodec(codec)
+	return ok
+}
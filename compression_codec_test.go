@@ -0,0 +1,105 @@
+package sarama
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// reverseCodec is a toy codec reversing the bytes of the batch
+const reverseCodec CompressionCodec = 7
+
+func reverseBytes(level int, data []byte) ([]byte, error) {
+	reversed := make([]byte, len(data))
+	for i, b := range data {
+		reversed[len(data)-1-i] = b
+	}
+	return reversed, nil
+}
+
+func registerReverseCodec(t *testing.T) {
+	t.Helper()
+	if err := RegisterCompressionCodec(reverseCodec, "reverse", reverseBytes,
+		func(data []byte) ([]byte, error) { return reverseBytes(0, data) }); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		compressionCodecsLock.Lock()
+		defer compressionCodecsLock.Unlock()
+		delete(compressionCodecs, reverseCodec)
+	})
+}
+
+func TestRegisterCompressionCodec(t *testing.T) {
+	noop := func(level int, data []byte) ([]byte, error) { return data, nil }
+	decode := func(data []byte) ([]byte, error) { return data, nil }
+	if err := RegisterCompressionCodec(CompressionZSTD, "zstd2", noop, decode); err == nil {
+		t.Error("expected the built-in codecs not to be replaceable")
+	}
+	if err := RegisterCompressionCodec(8, "eight", noop, decode); err == nil {
+		t.Error("expected a codec not fitting the attributes to be rejected")
+	}
+	if err := RegisterCompressionCodec(reverseCodec, "", noop, decode); err == nil {
+		t.Error("expected an empty name to be rejected")
+	}
+	if err := RegisterCompressionCodec(reverseCodec, "gzip", noop, decode); err == nil {
+		t.Error("expected the name of a built-in codec to be rejected")
+	}
+	if err := RegisterCompressionCodec(reverseCodec, "reverse", nil, decode); err == nil {
+		t.Error("expected a nil encoder to be rejected")
+	}
+
+	config := NewTestConfig()
+	config.Producer.Compression = reverseCodec
+	if err := config.Validate(); err == nil {
+		t.Error("expected an unregistered codec to be invalid")
+	}
+
+	registerReverseCodec(t)
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+	if reverseCodec.String() != "reverse" {
+		t.Errorf("expected the codec to be printed with its name, got %s", reverseCodec)
+	}
+	if codec, err := parseCompressionCodec("Reverse"); err != nil || codec != reverseCodec {
+		t.Errorf("expected the codec to be parsed from its name, got %s %v", codec, err)
+	}
+}
+
+func TestRecordBatchRegisteredCompressionCodec(t *testing.T) {
+	registerReverseCodec(t)
+
+	batch := &RecordBatch{
+		Version:        2,
+		Codec:          reverseCodec,
+		FirstTimestamp: time.Unix(1479847795, 0),
+		MaxTimestamp:   time.Unix(0, 0),
+		Records: []*Record{{
+			Key:   []byte{1, 2, 3, 4},
+			Value: []byte{5, 6, 7},
+		}},
+	}
+	buf, err := encode(batch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &RecordBatch{}
+	if err := decode(buf, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Codec != reverseCodec || len(decoded.Records) != 1 ||
+		!bytes.Equal(decoded.Records[0].Value, []byte{5, 6, 7}) {
+		t.Errorf("unexpected decoded batch %+v", decoded)
+	}
+}
+
+func TestDecompressUnknownCodec(t *testing.T) {
+	if _, err := decompress(reverseCodec, []byte{1}, 0); err == nil {
+		t.Error("expected an unregistered codec to fail decompression")
+	}
+	if s := CompressionCodec(6).String(); s != "unknown(6)" {
+		t.Errorf("unexpected name %s of an unknown codec", s)
+	}
+}
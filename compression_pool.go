@@ -0,0 +1,52 @@
+package sarama
+
+import "sync"
+
+// compressionPool compresses the record batches of the produce requests on
+// Producer.CompressionWorkers goroutines shared by the broker producers
+type compressionPool struct {
+	jobs chan compressionJob
+}
+
+type compressionJob struct {
+	batch *RecordBatch
+	done  *sync.WaitGroup
+}
+
+func newCompressionPool(workers int) *compressionPool {
+	pool := &compressionPool{jobs: make(chan compressionJob)}
+	for i := 0; i < workers; i++ {
+		go withRecover(pool.run)
+	}
+	return pool
+}
+
+func (pool *compressionPool) run() {
+	for job := range pool.jobs {
+		// a failure leaves the records uncompressed, encoding the request
+		// failing the same way when it is sent
+		_ = job.batch.encodeRecords(&prepEncoder{})
+		job.done.Done()
+	}
+}
+
+// compress compresses the batches of the request in parallel, returning once
+// all of them are compressed so that the requests are still sent in order
+func (pool *compressionPool) compress(req *ProduceRequest) {
+	var done sync.WaitGroup
+	for _, partitions := range req.records {
+		for _, records := range partitions {
+			batch := records.RecordBatch
+			if batch == nil || batch.Codec == CompressionNone || batch.compressedRecords != nil {
+				continue
+			}
+			done.Add(1)
+			pool.jobs <- compressionJob{batch: batch, done: &done}
+		}
+	}
+	done.Wait()
+}
+
+func (pool *compressionPool) close() {
+	close(pool.jobs)
+}
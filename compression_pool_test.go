@@ -0,0 +1,89 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompressionPool(t *testing.T) {
+	pool := newCompressionPool(3)
+	defer pool.close()
+
+	req := &ProduceRequest{Version: 3}
+	for partition := int32(0); partition < 8; partition++ {
+		codec := CompressionGZIP
+		if partition == 0 {
+			codec = CompressionNone
+		}
+		req.AddBatch("my_topic", partition, &RecordBatch{
+			Version:          2,
+			Codec:            codec,
+			CompressionLevel: CompressionLevelDefault,
+			FirstTimestamp:   time.Unix(1479847795, 0),
+			MaxTimestamp:     time.Unix(0, 0),
+			Records:          []*Record{{Value: []byte(TestMessage)}},
+		})
+	}
+	pool.compress(req)
+
+	for partition, records := range req.records["my_topic"] {
+		compressed := records.RecordBatch.compressedRecords != nil
+		if compressed != (partition != 0) {
+			t.Errorf("unexpected compression %t of partition %d", compressed, partition)
+		}
+	}
+
+	buf, err := encode(req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(ProduceRequest)
+	if err := versionedDecode(buf, decoded, 3); err != nil {
+		t.Fatal(err)
+	}
+	for partition, records := range decoded.records["my_topic"] {
+		if batch := records.RecordBatch; len(batch.Records) != 1 || string(batch.Records[0].Value) != TestMessage {
+			t.Errorf("unexpected records of partition %d: %+v", partition, batch.Records)
+		}
+	}
+}
+
+func TestAsyncProducerCompressionWorkers(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := &MetadataResponse{Version: 1}
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	for partition := int32(0); partition < 4; partition++ {
+		metadataResponse.AddTopicPartition("my_topic", partition, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	}
+	seedBroker.Returns(metadataResponse)
+
+	prodSuccess := new(ProduceResponse)
+	prodSuccess.Version = 3
+	for partition := int32(0); partition < 4; partition++ {
+		prodSuccess.AddTopicPartition("my_topic", partition, ErrNoError)
+	}
+	leader.Returns(prodSuccess)
+
+	config := NewTestConfig()
+	config.Version = V0_11_0_0
+	config.Producer.Compression = CompressionGZIP
+	config.Producer.CompressionWorkers = 2
+	config.Producer.Flush.Messages = 8
+	config.Producer.Partitioner = NewRoundRobinPartitioner
+	config.Producer.Return.Successes = true
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 8; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	expectResults(t, producer, 8, 0)
+
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
@@ -0,0 +1,75 @@
+package sarama
+
+// Clone returns a deep copy of the configuration which can be changed without
+// affecting the original one, e.g. to derive the configuration of a consumer
+// from the one of a producer. The slices, the maps, the Topics overrides,
+// Net.TLS.Config and the validators added with RegisterValidator are copied
+// while the functions and the implementations of interfaces, such as
+// Net.SASL.TokenProvider, the interceptors and MetricRegistry, are shared.
+func (c *Config) Clone() *Config {
+	clone := *c
+
+	if c.Net.TLS.Config != nil {
+		clone.Net.TLS.Config = c.Net.TLS.Config.Clone()
+	}
+	if c.Net.TLS.PinnedCertificates != nil {
+		clone.Net.TLS.PinnedCertificates = make(map[string][]string, len(c.Net.TLS.PinnedCertificates))
+		for addr, pins := range c.Net.TLS.PinnedCertificates {
+			clone.Net.TLS.PinnedCertificates[addr] = append([]string(nil), pins...)
+		}
+	}
+	clone.Net.Hooks = append([]BrokerHooks(nil), c.Net.Hooks...)
+	clone.Net.WireDump.APIKeys = append([]int16(nil), c.Net.WireDump.APIKeys...)
+	clone.Net.WireDump.Brokers = append([]int32(nil), c.Net.WireDump.Brokers...)
+
+	if c.Metadata.Failover.Bootstrap != nil {
+		clone.Metadata.Failover.Bootstrap = make([][]string, len(c.Metadata.Failover.Bootstrap))
+		for i, addrs := range c.Metadata.Failover.Bootstrap {
+			clone.Metadata.Failover.Bootstrap[i] = append([]string(nil), addrs...)
+		}
+	}
+
+	clone.Producer.Interceptors = append([]ProducerInterceptor(nil), c.Producer.Interceptors...)
+	clone.Consumer.Interceptors = append([]ConsumerInterceptor(nil), c.Consumer.Interceptors...)
+	clone.Consumer.Group.Member.UserData = append([]byte(nil), c.Consumer.Group.Member.UserData...)
+
+	if c.Topics != nil {
+		clone.Topics = make(map[string]TopicOverrides, len(c.Topics))
+		for topic, overrides := range c.Topics {
+			clone.Topics[topic] = overrides.clone()
+		}
+	}
+
+	clone.validators = append([]func(*Config) error(nil), c.validators...)
+	clone.connectionAttempts = new(connectionAttemptLimiter)
+	return &clone
+}
+
+// With returns a Clone of the configuration changed by the given function,
+// e.g. config.With(func(c *Config) { c.ClientID = "my-consumer" }).
+func (c *Config) With(change func(*Config)) *Config {
+	clone := c.Clone()
+	change(clone)
+	return clone
+}
+
+// clone returns a copy of the overrides not sharing their pointers
+func (o TopicOverrides) clone() TopicOverrides {
+	if o.Producer.Compression != nil {
+		codec := *o.Producer.Compression
+		o.Producer.Compression = &codec
+	}
+	if o.Producer.CompressionLevel != nil {
+		level := *o.Producer.CompressionLevel
+		o.Producer.CompressionLevel = &level
+	}
+	if o.Producer.RequiredAcks != nil {
+		acks := *o.Producer.RequiredAcks
+		o.Producer.RequiredAcks = &acks
+	}
+	if o.Consumer.IsolationLevel != nil {
+		isolation := *o.Consumer.IsolationLevel
+		o.Consumer.IsolationLevel = &isolation
+	}
+	return o
+}
@@ -0,0 +1,77 @@
+package sarama
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestConfigClone(t *testing.T) {
+	gzip := CompressionGZIP
+	var overrides TopicOverrides
+	overrides.Producer.Compression = &gzip
+
+	config := NewTestConfig()
+	config.Net.TLS.Config = &tls.Config{ServerName: "kafka"}
+	config.Net.SASL.TokenProvider = &DummyTokenProvider{}
+	config.Net.Hooks = []BrokerHooks{{}}
+	config.Net.WireDump.APIKeys = []int16{0}
+	config.Net.WireDump.Brokers = []int32{1}
+	config.Metadata.Failover.Bootstrap = [][]string{{"dr:9092"}}
+	config.Consumer.Group.Member.UserData = []byte("user data")
+	config.Topics = map[string]TopicOverrides{"logs": overrides}
+
+	clone := config.Clone()
+	clone.ClientID = "clone"
+	clone.Net.TLS.Config.ServerName = "clone"
+	clone.Net.Hooks[0].BeforeRequest = func(RequestEvent) {}
+	clone.Net.WireDump.APIKeys[0] = 1
+	clone.Net.WireDump.Brokers[0] = 2
+	clone.Metadata.Failover.Bootstrap[0][0] = "clone:9092"
+	clone.Consumer.Group.Member.UserData[0] = 'U'
+	*clone.Topics["logs"].Producer.Compression = CompressionSnappy
+	clone.Topics["metrics"] = TopicOverrides{}
+
+	if config.ClientID == "clone" || config.Net.TLS.Config.ServerName != "kafka" || config.Net.Hooks[0].BeforeRequest != nil {
+		t.Error("expected the changes of the clone not to affect the Net settings of the original")
+	}
+	if config.Net.WireDump.APIKeys[0] != 0 || config.Net.WireDump.Brokers[0] != 1 {
+		t.Error("expected the changes of the clone not to affect the WireDump filters of the original")
+	}
+	if config.Metadata.Failover.Bootstrap[0][0] != "dr:9092" || string(config.Consumer.Group.Member.UserData) != "user data" {
+		t.Error("expected the changes of the clone not to affect the slices of the original")
+	}
+	if *config.Topics["logs"].Producer.Compression != CompressionGZIP || len(config.Topics) != 1 {
+		t.Error("expected the changes of the clone not to affect the Topics of the original")
+	}
+	if clone.Net.SASL.TokenProvider != config.Net.SASL.TokenProvider || clone.MetricRegistry != config.MetricRegistry {
+		t.Error("expected the interfaces to be shared")
+	}
+	if err := clone.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfigWith(t *testing.T) {
+	config := NewTestConfig()
+	config.RegisterValidator(func(c *Config) error {
+		if c.ClientID == "forbidden" {
+			return ConfigurationError("forbidden ClientID")
+		}
+		return nil
+	})
+
+	consumerConfig := config.With(func(c *Config) {
+		c.ClientID = "forbidden"
+		c.Consumer.Return.Errors = true
+	})
+	if config.ClientID == "forbidden" || config.Consumer.Return.Errors {
+		t.Error("expected With not to change the original config")
+	}
+	if err := consumerConfig.Validate(); err == nil {
+		t.Error("expected the validators to be cloned")
+	}
+	consumerConfig.RegisterValidator(func(*Config) error { return nil })
+	if len(config.validators) != 1 {
+		t.Error("expected the validators of the clone not to be shared")
+	}
+}
@@ -0,0 +1,203 @@
+package sarama
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// defaultConfigEnvPrefix is the prefix of the environment variables read by
+// NewConfigFromEnv if none is given
+const defaultConfigEnvPrefix = "KAFKA_"
+
+// NewConfigFromEnv returns a new configuration with the defaults of NewConfig
+// overridden by the environment variables with the given prefix, KAFKA_ if
+// empty, followed by the path of a field of Config upper-cased with its words
+// separated by underscores. E.g. KAFKA_CLIENT_ID sets ClientID,
+// KAFKA_NET_SASL_USER sets Net.SASL.User and KAFKA_PRODUCER_FLUSH_FREQUENCY
+// sets Producer.Flush.Frequency.
+//
+// The strings, booleans and numbers are parsed with the strconv package, the
+// durations with time.ParseDuration, such as 500ms, Version with
+// ParseKafkaVersion, Producer.Compression from its name, such as lz4, and
+// Producer.RequiredAcks as 0, 1, all or -1. The fields of other types, such
+// as the functions and interfaces, cannot be set from the environment. The
+// variables with the prefix that do not correspond to a field, or with values
+// which cannot be parsed, are reported as a ConfigurationError, so the prefix
+// should be dedicated to the configuration.
+//
+// The configuration is not validated, so that it can be completed before
+// Validate is called, e.g. with the TLS configuration or the SCRAM client.
+func NewConfigFromEnv(prefix string) (*Config, error) {
+	if prefix == "" {
+		prefix = defaultConfigEnvPrefix
+	}
+	c := NewConfig()
+
+	values := make(map[string]string)
+	for _, variable := range os.Environ() {
+		name, value := variable, ""
+		if i := strings.IndexByte(variable, '='); i >= 0 {
+			name, value = variable[:i], variable[i+1:]
+		}
+		if strings.HasPrefix(name, prefix) {
+			values[name] = value
+		}
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := configEnvFields()
+	config := reflect.ValueOf(c).Elem()
+	for _, name := range names {
+		index, ok := fields[strings.TrimPrefix(name, prefix)]
+		if !ok {
+			return nil, ConfigurationError(fmt.Sprintf("unknown or unsupported environment variable %q", name))
+		}
+		if err := setConfigEnvField(config.FieldByIndex(index), values[name]); err != nil {
+			return nil, ConfigurationError(fmt.Sprintf("invalid value %q of environment variable %q: %s", values[name], name, err))
+		}
+	}
+	return c, nil
+}
+
+var (
+	durationType         = reflect.TypeOf(time.Duration(0))
+	kafkaVersionType     = reflect.TypeOf(KafkaVersion{})
+	compressionCodecType = reflect.TypeOf(CompressionCodec(0))
+	requiredAcksType     = reflect.TypeOf(RequiredAcks(0))
+)
+
+// configEnvFields returns the indexes of the fields of Config which can be
+// set from the environment by the names of their variables, without prefix
+func configEnvFields() map[string][]int {
+	fields := make(map[string][]int)
+	var walk func(name string, index []int, t reflect.Type)
+	walk = func(name string, index []int, t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue // unexported
+			}
+			fieldName := configEnvName(field.Name)
+			if name != "" {
+				fieldName = name + "_" + fieldName
+			}
+			fieldIndex := append(append([]int(nil), index...), i)
+			switch {
+			case field.Type == durationType, field.Type == kafkaVersionType:
+				fields[fieldName] = fieldIndex
+			case field.Type.Kind() == reflect.Struct:
+				walk(fieldName, fieldIndex, field.Type)
+			case configEnvSettable(field.Type.Kind()):
+				fields[fieldName] = fieldIndex
+			}
+		}
+	}
+	walk("", nil, reflect.TypeOf(Config{}))
+	return fields
+}
+
+func configEnvSettable(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// configEnvName returns the name of a field upper-cased with its words
+// separated by underscores, e.g. MAX_OPEN_REQUESTS for MaxOpenRequests and
+// SCRAM_AUTHZ_ID for SCRAMAuthzID
+func configEnvName(field string) string {
+	runes := []rune(field)
+	var name strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				name.WriteByte('_')
+			}
+		}
+		name.WriteRune(unicode.ToUpper(r))
+	}
+	return name.String()
+}
+
+// setConfigEnvField sets the field of the configuration to the value of its
+// environment variable
+func setConfigEnvField(field reflect.Value, value string) error {
+	switch field.Type() {
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	case kafkaVersionType:
+		version, err := ParseKafkaVersion(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(version))
+		return nil
+	case compressionCodecType:
+		codec, err := parseCompressionCodec(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(codec))
+		return nil
+	case requiredAcksType:
+		acks, err := parseRequiredAcks(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(acks))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	}
+	return nil
+}
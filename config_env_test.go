@@ -0,0 +1,109 @@
+package sarama
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func setTestEnv(t *testing.T, variables map[string]string) {
+	t.Helper()
+	for name, value := range variables {
+		if err := os.Setenv(name, value); err != nil {
+			t.Fatal(err)
+		}
+		name := name
+		t.Cleanup(func() { _ = os.Unsetenv(name) })
+	}
+}
+
+func TestNewConfigFromEnv(t *testing.T) {
+	setTestEnv(t, map[string]string{
+		"SARAMA_TEST_CLIENT_ID":                 "my-service",
+		"SARAMA_TEST_VERSION":                   "2.8.0",
+		"SARAMA_TEST_NET_MAX_OPEN_REQUESTS":     "1",
+		"SARAMA_TEST_NET_SASL_ENABLE":           "true",
+		"SARAMA_TEST_NET_SASL_MECHANISM":        "SCRAM-SHA-512",
+		"SARAMA_TEST_NET_SASL_SCRAM_AUTHZ_ID":   "admin",
+		"SARAMA_TEST_PRODUCER_COMPRESSION":      "zstd",
+		"SARAMA_TEST_PRODUCER_REQUIRED_ACKS":    "all",
+		"SARAMA_TEST_PRODUCER_FLUSH_FREQUENCY":  "5ms",
+		"SARAMA_TEST_CONSUMER_ISOLATION_LEVEL":  "1",
+		"SARAMA_TEST_CONSUMER_FETCH_DEFAULT":    "65536",
+		"SARAMA_TEST_NET_RECONNECT_BACKOFF_MAX": "10s",
+	})
+
+	config, err := NewConfigFromEnv("SARAMA_TEST_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ClientID != "my-service" || config.Version != V2_8_0_0 || config.Net.MaxOpenRequests != 1 {
+		t.Errorf("unexpected ClientID %q, Version %s or Net.MaxOpenRequests %d", config.ClientID, config.Version, config.Net.MaxOpenRequests)
+	}
+	if !config.Net.SASL.Enable || config.Net.SASL.Mechanism != SASLTypeSCRAMSHA512 || config.Net.SASL.SCRAMAuthzID != "admin" {
+		t.Errorf("unexpected SASL settings %+v", config.Net.SASL)
+	}
+	if config.Producer.Compression != CompressionZSTD || config.Producer.RequiredAcks != WaitForAll || config.Producer.Flush.Frequency != 5*time.Millisecond {
+		t.Errorf("unexpected producer settings %+v", config.Producer)
+	}
+	if config.Consumer.IsolationLevel != ReadCommitted || config.Consumer.Fetch.Default != 65536 || config.Net.ReconnectBackoff.Max != 10*time.Second {
+		t.Errorf("unexpected consumer or backoff settings")
+	}
+	// the defaults are kept
+	if config.Producer.MaxMessageBytes != 1000000 || config.ChannelBufferSize != 256 {
+		t.Error("expected the other settings to keep their defaults")
+	}
+}
+
+func TestNewConfigFromEnvErrors(t *testing.T) {
+	tests := []struct {
+		variables map[string]string
+		err       string
+	}{
+		{
+			map[string]string{"SARAMA_TEST_NET_MAX_OPEN_REQUEST": "1"},
+			`unknown or unsupported environment variable "SARAMA_TEST_NET_MAX_OPEN_REQUEST"`,
+		},
+		{
+			map[string]string{"SARAMA_TEST_PRODUCER_PARTITIONER": "hash"},
+			`unknown or unsupported environment variable "SARAMA_TEST_PRODUCER_PARTITIONER"`,
+		},
+		{
+			map[string]string{"SARAMA_TEST_NET_DIAL_TIMEOUT": "30"},
+			`invalid value "30" of environment variable "SARAMA_TEST_NET_DIAL_TIMEOUT": time: missing unit in duration "30"`,
+		},
+		{
+			map[string]string{"SARAMA_TEST_PRODUCER_COMPRESSION": "brotli"},
+			`invalid value "brotli" of environment variable "SARAMA_TEST_PRODUCER_COMPRESSION": possible values are none, gzip, snappy, lz4, zstd and the registered codecs`,
+		},
+		{
+			map[string]string{"SARAMA_TEST_CONSUMER_ISOLATION_LEVEL": "300"},
+			`invalid value "300" of environment variable "SARAMA_TEST_CONSUMER_ISOLATION_LEVEL": strconv.ParseInt: parsing "300": value out of range`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.err, func(t *testing.T) {
+			setTestEnv(t, test.variables)
+			_, err := NewConfigFromEnv("SARAMA_TEST_")
+			var target ConfigurationError
+			if !errors.As(err, &target) || string(target) != test.err {
+				t.Errorf("expected %s, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestConfigEnvName(t *testing.T) {
+	for field, name := range map[string]string{
+		"ClientID":           "CLIENT_ID",
+		"MaxOpenRequests":    "MAX_OPEN_REQUESTS",
+		"SASL":               "SASL",
+		"SCRAMAuthzID":       "SCRAM_AUTHZ_ID",
+		"ApiVersionsRequest": "API_VERSIONS_REQUEST",
+	} {
+		if actual := configEnvName(field); actual != name {
+			t.Errorf("expected %s for %s, got %s", name, field, actual)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package sarama
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+)
+
+// redacted replaces the secrets set in the serialized configuration
+const redacted = "[REDACTED]"
+
+// redactedConfigFields are the fields of the configuration holding secrets,
+// which are serialized as redacted if set
+var redactedConfigFields = map[string]bool{
+	"Net.SASL.Password":        true,
+	"Net.SASL.GSSAPI.Password": true,
+}
+
+// String returns the JSON of the effective configuration, see MarshalJSON, so
+// that it can be logged safely.
+func (c *Config) String() string {
+	encoded, err := c.MarshalJSON()
+	if err != nil {
+		return fmt.Sprintf("sarama.Config{%s}", err)
+	}
+	return string(encoded)
+}
+
+// MarshalJSON encodes the effective configuration as a JSON object of its
+// fields, in declaration order, with the passwords redacted, including the
+// one of Net.Proxy.URL, and only the non-secret settings of
+// Net.TLS.Config, the certificates being counted. The functions are encoded
+// as "func" and the other implementations of interfaces, such as
+// Net.SASL.TokenProvider or MetricRegistry, by their type, as they may hold
+// secrets. Unset functions and interfaces are encoded as null.
+func (c *Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(configJSONValue("", reflect.ValueOf(c).Elem()))
+}
+
+// configJSONField is a field of a struct of the configuration, encoded in
+// declaration order as opposed to the keys of a map
+type configJSONField struct {
+	name  string
+	value interface{}
+}
+
+type configJSONObject []configJSONField
+
+func (o configJSONObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// configJSONValue returns the value encoding the field of the configuration
+// at the given path
+func configJSONValue(path string, v reflect.Value) interface{} {
+	switch path {
+	case "Net.TLS.Config":
+		if v.IsNil() {
+			return nil
+		}
+		return tlsConfigJSON(v.Interface().(*tls.Config))
+	case "Net.Proxy.URL":
+		return redactURL(v.String())
+	}
+	if redactedConfigFields[path] {
+		if v.String() == "" {
+			return ""
+		}
+		return redacted
+	}
+
+	switch v.Kind() {
+	case reflect.Func:
+		if v.IsNil() {
+			return nil
+		}
+		return "func"
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return fmt.Sprintf("%T", v.Interface())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return configJSONValue(path, v.Elem())
+	}
+
+	if v.Type().Implements(stringerType) {
+		// such as time.Duration, KafkaVersion and CompressionCodec
+		return v.Interface().(fmt.Stringer).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		object := make(configJSONObject, 0, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue // unexported
+			}
+			fieldPath := field.Name
+			if path != "" {
+				fieldPath = path + "." + field.Name
+			}
+			object = append(object, configJSONField{field.Name, configJSONValue(fieldPath, v.Field(i))})
+		}
+		return object
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes()
+		}
+		fallthrough
+	case reflect.Array:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = configJSONValue(path, v.Index(i))
+		}
+		return values
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		object := make(configJSONObject, 0, len(keys))
+		for _, key := range keys {
+			object = append(object, configJSONField{fmt.Sprint(key.Interface()), configJSONValue(path, v.MapIndex(key))})
+		}
+		return object
+	default:
+		return v.Interface()
+	}
+}
+
+// tlsConfigJSON returns the settings of the TLS configuration which are not
+// secrets
+func tlsConfigJSON(config *tls.Config) configJSONObject {
+	return configJSONObject{
+		{"ServerName", config.ServerName},
+		{"InsecureSkipVerify", config.InsecureSkipVerify},
+		{"MinVersion", config.MinVersion},
+		{"MaxVersion", config.MaxVersion},
+		{"Certificates", len(config.Certificates)},
+		{"GetClientCertificate", config.GetClientCertificate != nil},
+		{"RootCAs", config.RootCAs != nil},
+		{"ClientSessionCache", config.ClientSessionCache != nil},
+	}
+}
+
+// redactURL returns the URL with its password, if any, redacted
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		if rawURL == "" {
+			return ""
+		}
+		return redacted
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,83 @@
+package sarama
+
+import "time"
+
+// NewConfigLowLatency returns a configuration favoring the latency of each
+// message over throughput and durability. The messages are produced one
+// request each as soon as they are sent, uncompressed and acknowledged by the
+// leader only, and the consumers are answered as soon as a single byte is
+// available. The brokers and the network handle many more, smaller requests,
+// and an acknowledged message is lost if the leader fails before it is
+// replicated. The timeouts are shorter so that failures are noticed faster.
+func NewConfigLowLatency() *Config {
+	c := NewConfig()
+
+	c.Net.DialTimeout = 10 * time.Second
+	c.Net.ReadTimeout = 10 * time.Second
+	c.Net.WriteTimeout = 10 * time.Second
+	c.Metadata.Retry.Backoff = 100 * time.Millisecond
+
+	c.Producer.RequiredAcks = WaitForLocal
+	c.Producer.Compression = CompressionNone
+	c.Producer.Flush.Frequency = 0
+	c.Producer.Flush.Bytes = 0
+	c.Producer.Flush.Messages = 0
+	c.Producer.Retry.Backoff = 50 * time.Millisecond
+
+	c.Consumer.Fetch.Min = 1
+	c.Consumer.MaxWaitTime = 100 * time.Millisecond
+	c.Consumer.Retry.Backoff = 500 * time.Millisecond
+
+	return c
+}
+
+// NewConfigHighThroughput returns a configuration favoring throughput over
+// the latency of each message. The messages are batched for up to 10ms or
+// 512KiB and compressed with LZ4, the consumers wait for 64KiB to be
+// available for up to 500ms and fetch up to 4MiB per partition, and the
+// channels buffer more messages. The messages take longer to be delivered
+// and the clients use more memory. The messages are acknowledged by the
+// leader only, like with NewConfig.
+func NewConfigHighThroughput() *Config {
+	c := NewConfig()
+
+	c.ChannelBufferSize = 1024
+
+	c.Producer.RequiredAcks = WaitForLocal
+	c.Producer.Compression = CompressionLZ4
+	c.Producer.Flush.Frequency = 10 * time.Millisecond
+	c.Producer.Flush.Bytes = 512 * 1024
+
+	c.Consumer.Fetch.Min = 64 * 1024
+	c.Consumer.Fetch.Default = 4 * 1024 * 1024
+	c.Consumer.MaxWaitTime = 500 * time.Millisecond
+
+	return c
+}
+
+// NewConfigStrictDurability returns a configuration favoring not losing nor
+// duplicating messages over latency and throughput. The messages are
+// acknowledged once replicated to all the in-sync replicas, produced by an
+// idempotent producer with one request in flight per broker so that retries
+// neither duplicate nor reorder them, and retried for longer. The consumers
+// only read the messages of committed transactions, start from the oldest
+// offset rather than skipping the messages produced before they joined, and
+// do not commit the offsets automatically: the applications must mark and
+// commit them once the messages are processed.
+func NewConfigStrictDurability() *Config {
+	c := NewConfig()
+
+	c.Net.MaxOpenRequests = 1
+
+	c.Producer.RequiredAcks = WaitForAll
+	c.Producer.Idempotent = true
+	c.Producer.Timeout = 30 * time.Second
+	c.Producer.Retry.Max = 10
+	c.Producer.Retry.Backoff = 250 * time.Millisecond
+
+	c.Consumer.IsolationLevel = ReadCommitted
+	c.Consumer.Offsets.Initial = OffsetOldest
+	c.Consumer.Offsets.AutoCommit.Enable = false
+
+	return c
+}
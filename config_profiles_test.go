@@ -0,0 +1,25 @@
+package sarama
+
+import "testing"
+
+func TestConfigProfilesValidate(t *testing.T) {
+	for name, constructor := range map[string]func() *Config{
+		"NewConfigLowLatency":       NewConfigLowLatency,
+		"NewConfigHighThroughput":   NewConfigHighThroughput,
+		"NewConfigStrictDurability": NewConfigStrictDurability,
+	} {
+		if err := constructor().Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestNewConfigStrictDurability(t *testing.T) {
+	config := NewConfigStrictDurability()
+	if config.Producer.RequiredAcks != WaitForAll || !config.Producer.Idempotent || config.Net.MaxOpenRequests != 1 {
+		t.Error("expected an idempotent producer waiting for all the in-sync replicas")
+	}
+	if config.Consumer.Offsets.AutoCommit.Enable {
+		t.Error("expected the offsets not to be committed automatically")
+	}
+}
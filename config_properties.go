@@ -0,0 +1,376 @@
+package sarama
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configProperty sets the part of a Config corresponding to a property of
+// the Java client or librdkafka
+type configProperty func(c *Config, value string) error
+
+// configProperties are the properties supported by NewConfigFromMap, the
+// librdkafka names next to the Java ones where they differ
+var configProperties = map[string]configProperty{
+	// given to NewClient and NewConsumerGroup rather than in the Config
+	"bootstrap.servers":    func(*Config, string) error { return nil },
+	"metadata.broker.list": func(*Config, string) error { return nil },
+	"group.id":             func(*Config, string) error { return nil },
+
+	"client.id":   func(c *Config, v string) error { c.ClientID = v; return nil },
+	"client.rack": func(c *Config, v string) error { c.RackID = v; return nil },
+
+	"security.protocol": func(c *Config, v string) error {
+		switch strings.ToUpper(v) {
+		case "PLAINTEXT":
+			c.Net.TLS.Enable, c.Net.SASL.Enable = false, false
+		case "SSL":
+			c.Net.TLS.Enable, c.Net.SASL.Enable = true, false
+		case "SASL_PLAINTEXT":
+			c.Net.TLS.Enable, c.Net.SASL.Enable = false, true
+		case "SASL_SSL":
+			c.Net.TLS.Enable, c.Net.SASL.Enable = true, true
+		default:
+			return fmt.Errorf("possible values are PLAINTEXT, SSL, SASL_PLAINTEXT and SASL_SSL")
+		}
+		return nil
+	},
+	"sasl.mechanism":  saslMechanismProperty,
+	"sasl.mechanisms": saslMechanismProperty,
+	"sasl.username":   func(c *Config, v string) error { c.Net.SASL.User = v; return nil },
+	"sasl.password":   func(c *Config, v string) error { c.Net.SASL.Password = v; return nil },
+	"sasl.jaas.config": func(c *Config, v string) error {
+		options := jaasOptions(v)
+		user, ok := options["username"]
+		if !ok {
+			return fmt.Errorf("missing username option")
+		}
+		c.Net.SASL.User, c.Net.SASL.Password = user, options["password"]
+		return nil
+	},
+	// set up by NewConfigFromMap once all the ssl properties are known
+	"ssl.ca.location":          func(*Config, string) error { return nil },
+	"ssl.certificate.location": func(*Config, string) error { return nil },
+	"ssl.key.location":         func(*Config, string) error { return nil },
+	"ssl.endpoint.identification.algorithm": func(c *Config, v string) error {
+		switch strings.ToLower(v) {
+		case "https", "none", "":
+			return nil
+		default:
+			return fmt.Errorf("possible values are https, none and the empty string")
+		}
+	},
+
+	"socket.connection.setup.timeout.ms":    durationProperty(func(c *Config) *time.Duration { return &c.Net.DialTimeout }),
+	"request.timeout.ms":                    durationProperty(func(c *Config) *time.Duration { return &c.Net.ReadTimeout }),
+	"connections.max.idle.ms":               durationProperty(func(c *Config) *time.Duration { return &c.Net.MaxIdleTime }),
+	"reconnect.backoff.ms":                  durationProperty(func(c *Config) *time.Duration { return &c.Net.ReconnectBackoff.Initial }),
+	"reconnect.backoff.max.ms":              durationProperty(func(c *Config) *time.Duration { return &c.Net.ReconnectBackoff.Max }),
+	"max.in.flight.requests.per.connection": intProperty(func(c *Config) *int { return &c.Net.MaxOpenRequests }),
+
+	"metadata.max.age.ms":                durationProperty(func(c *Config) *time.Duration { return &c.Metadata.RefreshFrequency }),
+	"topic.metadata.refresh.interval.ms": durationProperty(func(c *Config) *time.Duration { return &c.Metadata.RefreshFrequency }),
+	"allow.auto.create.topics":           boolProperty(func(c *Config) *bool { return &c.Metadata.AllowAutoTopicCreation }),
+	"retry.backoff.ms": func(c *Config, v string) error {
+		backoff, err := parseMillis(v)
+		if err != nil {
+			return err
+		}
+		c.Admin.Retry.Backoff = backoff
+		c.Metadata.Retry.Backoff = backoff
+		c.Producer.Retry.Backoff = backoff
+		return nil
+	},
+
+	"acks":                   acksProperty,
+	"request.required.acks":  acksProperty,
+	"linger.ms":              durationProperty(func(c *Config) *time.Duration { return &c.Producer.Flush.Frequency }),
+	"queue.buffering.max.ms": durationProperty(func(c *Config) *time.Duration { return &c.Producer.Flush.Frequency }),
+	"batch.size":             intProperty(func(c *Config) *int { return &c.Producer.Flush.Bytes }),
+	"batch.num.messages":     intProperty(func(c *Config) *int { return &c.Producer.Flush.MaxMessages }),
+	"compression.type":       compressionProperty,
+	"compression.codec":      compressionProperty,
+	"compression.level":      intProperty(func(c *Config) *int { return &c.Producer.CompressionLevel }),
+	"retries":                intProperty(func(c *Config) *int { return &c.Producer.Retry.Max }),
+	"max.request.size":       intProperty(func(c *Config) *int { return &c.Producer.MaxMessageBytes }),
+	"message.max.bytes":      intProperty(func(c *Config) *int { return &c.Producer.MaxMessageBytes }),
+	"enable.idempotence":     boolProperty(func(c *Config) *bool { return &c.Producer.Idempotent }),
+
+	"auto.offset.reset": func(c *Config, v string) error {
+		switch strings.ToLower(v) {
+		case "earliest", "smallest", "beginning":
+			c.Consumer.Offsets.Initial = OffsetOldest
+		case "latest", "largest", "end":
+			c.Consumer.Offsets.Initial = OffsetNewest
+		default:
+			return fmt.Errorf("possible values are earliest and latest")
+		}
+		return nil
+	},
+	"enable.auto.commit":      boolProperty(func(c *Config) *bool { return &c.Consumer.Offsets.AutoCommit.Enable }),
+	"auto.commit.interval.ms": durationProperty(func(c *Config) *time.Duration { return &c.Consumer.Offsets.AutoCommit.Interval }),
+	"session.timeout.ms":      durationProperty(func(c *Config) *time.Duration { return &c.Consumer.Group.Session.Timeout }),
+	"heartbeat.interval.ms":   durationProperty(func(c *Config) *time.Duration { return &c.Consumer.Group.Heartbeat.Interval }),
+	"max.poll.interval.ms":    durationProperty(func(c *Config) *time.Duration { return &c.Consumer.Group.Rebalance.Timeout }),
+	"partition.assignment.strategy": func(c *Config, v string) error {
+		// the first strategy of a list is the preferred one
+		strategy := strings.TrimSpace(strings.Split(v, ",")[0])
+		switch strings.ToLower(strategy[strings.LastIndex(strategy, ".")+1:]) {
+		case "range", "rangeassignor":
+			c.Consumer.Group.Rebalance.Strategy = BalanceStrategyRange
+		case "roundrobin", "roundrobinassignor":
+			c.Consumer.Group.Rebalance.Strategy = BalanceStrategyRoundRobin
+		case "sticky", "stickyassignor":
+			c.Consumer.Group.Rebalance.Strategy = BalanceStrategySticky
+		default:
+			return fmt.Errorf("possible values are range, roundrobin and sticky")
+		}
+		return nil
+	},
+	"fetch.min.bytes":           int32Property(func(c *Config) *int32 { return &c.Consumer.Fetch.Min }),
+	"fetch.max.bytes":           int32Property(func(c *Config) *int32 { return &c.Consumer.Fetch.Max }),
+	"max.partition.fetch.bytes": int32Property(func(c *Config) *int32 { return &c.Consumer.Fetch.Default }),
+	"fetch.max.wait.ms":         durationProperty(func(c *Config) *time.Duration { return &c.Consumer.MaxWaitTime }),
+	"fetch.wait.max.ms":         durationProperty(func(c *Config) *time.Duration { return &c.Consumer.MaxWaitTime }),
+	"check.crcs": func(c *Config, v string) error {
+		check, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		c.Consumer.Fetch.SkipCRC = !check
+		return nil
+	},
+	"isolation.level": func(c *Config, v string) error {
+		switch strings.ToLower(v) {
+		case "read_uncommitted":
+			c.Consumer.IsolationLevel = ReadUncommitted
+		case "read_committed":
+			c.Consumer.IsolationLevel = ReadCommitted
+		default:
+			return fmt.Errorf("possible values are read_uncommitted and read_committed")
+		}
+		return nil
+	},
+}
+
+// NewConfigFromMap returns a new configuration with the defaults of NewConfig
+// overridden by the given properties of the Java client or librdkafka, such
+// as acks, linger.ms, security.protocol or sasl.mechanism, so that one
+// configuration can be shared by services written in different languages.
+// The unknown properties and those sarama has no equivalent for are reported
+// as a ConfigurationError. bootstrap.servers and group.id are accepted but
+// left to the caller to pass to NewClient and NewConsumerGroup.
+//
+// The configuration is not validated, so that it can be completed before
+// Validate is called, e.g. with Version or the SCRAM client.
+func NewConfigFromMap(properties map[string]string) (*Config, error) {
+	c := NewConfig()
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		property, ok := configProperties[key]
+		if !ok {
+			return nil, ConfigurationError(fmt.Sprintf("unknown or unsupported property %q", key))
+		}
+		if err := property(c, properties[key]); err != nil {
+			return nil, ConfigurationError(fmt.Sprintf("invalid value %q of property %q: %s", properties[key], key, err))
+		}
+	}
+
+	if err := c.setTLSFromProperties(properties); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// setTLSFromProperties sets Net.TLS.Config from the ssl properties, if any
+func (c *Config) setTLSFromProperties(properties map[string]string) error {
+	caFile, certFile, keyFile := properties["ssl.ca.location"], properties["ssl.certificate.location"], properties["ssl.key.location"]
+	skipVerify := strings.EqualFold(properties["ssl.endpoint.identification.algorithm"], "none")
+	if caFile == "" && certFile == "" && keyFile == "" && !skipVerify {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if skipVerify {
+		// like the Java client, still verify the certificate chain but not
+		// the host name
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyCertificateChain(tlsConfig.RootCAs, state)
+		}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return ConfigurationError(fmt.Sprintf("invalid property \"ssl.ca.location\": %s", err))
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return ConfigurationError(fmt.Sprintf("invalid property \"ssl.ca.location\": no certificate found in %s", caFile))
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return ConfigurationError(fmt.Sprintf("invalid properties \"ssl.certificate.location\" and \"ssl.key.location\": %s", err))
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	c.Net.TLS.Config = tlsConfig
+	return nil
+}
+
+func verifyCertificateChain(roots *x509.CertPool, state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("no certificate presented by the broker")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+	return err
+}
+
+func acksProperty(c *Config, v string) (err error) {
+	c.Producer.RequiredAcks, err = parseRequiredAcks(v)
+	return err
+}
+
+func parseRequiredAcks(v string) (RequiredAcks, error) {
+	switch v {
+	case "0":
+		return NoResponse, nil
+	case "1":
+		return WaitForLocal, nil
+	case "all", "-1":
+		return WaitForAll, nil
+	default:
+		return 0, fmt.Errorf("possible values are 0, 1, all and -1")
+	}
+}
+
+func saslMechanismProperty(c *Config, v string) error {
+	switch mechanism := SASLMechanism(strings.ToUpper(v)); mechanism {
+	case SASLTypePlaintext, SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512, SASLTypeOAuth, SASLTypeGSSAPI:
+		c.Net.SASL.Mechanism = mechanism
+		return nil
+	default:
+		if _, ok := registeredSASLMechanism(SASLMechanism(v)); ok {
+			c.Net.SASL.Mechanism = SASLMechanism(v)
+			return nil
+		}
+		return fmt.Errorf("possible values are PLAIN, SCRAM-SHA-256, SCRAM-SHA-512, OAUTHBEARER, GSSAPI and the registered mechanisms")
+	}
+}
+
+func compressionProperty(c *Config, v string) (err error) {
+	c.Producer.Compression, err = parseCompressionCodec(v)
+	return err
+}
+
+func parseCompressionCodec(v string) (CompressionCodec, error) {
+	codec, err := parseBuiltinCompressionCodec(v)
+	if err != nil {
+		if registered, ok := registeredCompressionCodecByName(v); ok {
+			return registered, nil
+		}
+	}
+	return codec, err
+}
+
+func parseBuiltinCompressionCodec(v string) (CompressionCodec, error) {
+	switch strings.ToLower(v) {
+	case "none":
+		return CompressionNone, nil
+	case "gzip":
+		return CompressionGZIP, nil
+	case "snappy":
+		return CompressionSnappy, nil
+	case "lz4":
+		return CompressionLZ4, nil
+	case "zstd":
+		return CompressionZSTD, nil
+	default:
+		return 0, fmt.Errorf("possible values are none, gzip, snappy, lz4, zstd and the registered codecs")
+	}
+}
+
+// jaasOptionPattern matches the options of a JAAS login module, such as
+// username="alice"
+var jaasOptionPattern = regexp.MustCompile(`(\w+)\s*=\s*"((?:[^"\\]|\\.)*)"`)
+
+// jaasOptions returns the options of the JAAS configuration of the Java
+// client, e.g. org.apache.kafka.common.security.plain.PlainLoginModule
+// required username="alice" password="secret";
+func jaasOptions(config string) map[string]string {
+	options := make(map[string]string)
+	for _, match := range jaasOptionPattern.FindAllStringSubmatch(config, -1) {
+		options[match[1]] = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(match[2])
+	}
+	return options
+}
+
+func parseMillis(v string) (time.Duration, error) {
+	millis, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(millis) * time.Millisecond, nil
+}
+
+func durationProperty(field func(*Config) *time.Duration) configProperty {
+	return func(c *Config, v string) error {
+		d, err := parseMillis(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func intProperty(field func(*Config) *int) configProperty {
+	return func(c *Config, v string) error {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = i
+		return nil
+	}
+}
+
+func int32Property(field func(*Config) *int32) configProperty {
+	return func(c *Config, v string) error {
+		i, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return err
+		}
+		*field(c) = int32(i)
+		return nil
+	}
+}
+
+func boolProperty(field func(*Config) *bool) configProperty {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewConfigFromMap(t *testing.T) {
+	config, err := NewConfigFromMap(map[string]string{
+		"bootstrap.servers":                     "localhost:9092",
+		"client.id":                             "my-service",
+		"acks":                                  "all",
+		"linger.ms":                             "5",
+		"compression.type":                      "zstd",
+		"enable.idempotence":                    "true",
+		"max.in.flight.requests.per.connection": "1",
+		"security.protocol":                     "SASL_SSL",
+		"sasl.mechanism":                        "PLAIN",
+		"sasl.jaas.config":                      `org.apache.kafka.common.security.plain.PlainLoginModule required username="alice" password="s3cr\"t";`,
+		"auto.offset.reset":                     "earliest",
+		"partition.assignment.strategy":         "org.apache.kafka.clients.consumer.RoundRobinAssignor",
+		"fetch.min.bytes":                       "1024",
+		"isolation.level":                       "read_committed",
+		"check.crcs":                            "false",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.ClientID != "my-service" {
+		t.Errorf("unexpected client ID %q", config.ClientID)
+	}
+	if config.Producer.RequiredAcks != WaitForAll || config.Producer.Flush.Frequency != 5*time.Millisecond ||
+		config.Producer.Compression != CompressionZSTD || !config.Producer.Idempotent || config.Net.MaxOpenRequests != 1 {
+		t.Errorf("unexpected producer config %+v", config.Producer)
+	}
+	if !config.Net.TLS.Enable || !config.Net.SASL.Enable || config.Net.SASL.Mechanism != SASLTypePlaintext {
+		t.Error("expected SASL/PLAIN over TLS")
+	}
+	if config.Net.SASL.User != "alice" || config.Net.SASL.Password != `s3cr"t` {
+		t.Errorf("unexpected SASL credentials %q %q", config.Net.SASL.User, config.Net.SASL.Password)
+	}
+	if config.Consumer.Offsets.Initial != OffsetOldest || config.Consumer.Group.Rebalance.Strategy != BalanceStrategyRoundRobin ||
+		config.Consumer.Fetch.Min != 1024 || config.Consumer.IsolationLevel != ReadCommitted || !config.Consumer.Fetch.SkipCRC {
+		t.Errorf("unexpected consumer config %+v", config.Consumer)
+	}
+	// unset properties keep their defaults
+	if config.Producer.Retry.Max != 3 {
+		t.Errorf("expected the default producer retries, got %d", config.Producer.Retry.Max)
+	}
+
+	config.Version = V2_1_0_0
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewConfigFromMapErrors(t *testing.T) {
+	for _, properties := range []map[string]string{
+		{"unknown.property": "1"},
+		{"delivery.timeout.ms": "120000"},
+		{"acks": "2"},
+		{"linger.ms": "five"},
+		{"security.protocol": "TLS"},
+		{"sasl.jaas.config": "LoginModule required;"},
+		{"ssl.ca.location": "/nonexistent/ca.pem"},
+	} {
+		_, err := NewConfigFromMap(properties)
+		var target ConfigurationError
+		if !errors.As(err, &target) {
+			t.Errorf("expected a ConfigurationError for %v, got %v", properties, err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/rcrowley/go-metrics"
 )
@@ -38,6 +39,16 @@ func TestEmptyClientIDConfigValidates(t *testing.T) {
 	}
 }
 
+func TestNilClockConfigValidates(t *testing.T) {
+	config := NewTestConfig()
+	config.Clock = nil
+	err := config.Validate()
+	var target ConfigurationError
+	if !errors.As(err, &target) || string(target) != "Clock must not be nil" {
+		t.Error("Expected nil Clock to be invalid, got ", err)
+	}
+}
+
 type DummyTokenProvider struct{}
 
 func (t *DummyTokenProvider) Token() (*AccessToken, error) {
@@ -57,6 +68,20 @@ func TestNetConfigValidates(t *testing.T) {
 			},
 			"Net.MaxOpenRequests must be > 0",
 		},
+		{
+			"ConnectionsPerBroker",
+			func(cfg *Config) {
+				cfg.Net.ConnectionsPerBroker = 0
+			},
+			"Net.ConnectionsPerBroker must be > 0",
+		},
+		{
+			"WireDump.MaxBytes",
+			func(cfg *Config) {
+				cfg.Net.WireDump.MaxBytes = -1
+			},
+			"Net.WireDump.MaxBytes must be >= 0",
+		},
 		{
 			"DialTimeout",
 			func(cfg *Config) {
@@ -78,6 +103,50 @@ func TestNetConfigValidates(t *testing.T) {
 			},
 			"Net.WriteTimeout must be > 0",
 		},
+		{
+			"KeepAliveCount",
+			func(cfg *Config) {
+				cfg.Net.KeepAliveCount = -1
+			},
+			"Net.KeepAliveInterval and Net.KeepAliveCount must be >= 0",
+		},
+		{
+			"KeepAliveInterval without keep-alives",
+			func(cfg *Config) {
+				cfg.Net.KeepAlive = -1
+				cfg.Net.KeepAliveInterval = time.Second
+			},
+			"Net.KeepAliveInterval and Net.KeepAliveCount cannot be set when keep-alives are disabled",
+		},
+		{
+			"ReconnectBackoff.Initial",
+			func(cfg *Config) {
+				cfg.Net.ReconnectBackoff.Initial = -time.Second
+			},
+			"Net.ReconnectBackoff.Initial and Net.ReconnectBackoff.MaxAttemptsPerSecond must be >= 0",
+		},
+		{
+			"ReconnectBackoff.Max",
+			func(cfg *Config) {
+				cfg.Net.ReconnectBackoff.Initial = time.Second
+				cfg.Net.ReconnectBackoff.Max = 100 * time.Millisecond
+			},
+			"Net.ReconnectBackoff.Max must be >= Net.ReconnectBackoff.Initial",
+		},
+		{
+			"UserTimeout",
+			func(cfg *Config) {
+				cfg.Net.UserTimeout = -time.Second
+			},
+			"Net.UserTimeout must be >= 0",
+		},
+		{
+			"ReceiveBufferSize",
+			func(cfg *Config) {
+				cfg.Net.ReceiveBufferSize = -1
+			},
+			"Net.SendBufferSize and Net.ReceiveBufferSize must be >= 0",
+		},
 		{
 			"SASL.User",
 			func(cfg *Config) {
@@ -102,7 +171,7 @@ func TestNetConfigValidates(t *testing.T) {
 				cfg.Net.SASL.Mechanism = "AnIncorrectSASLMechanism"
 				cfg.Net.SASL.TokenProvider = &DummyTokenProvider{}
 			},
-			"The SASL mechanism configuration is invalid. Possible values are `OAUTHBEARER`, `PLAIN`, `SCRAM-SHA-256`, `SCRAM-SHA-512` and `GSSAPI`",
+			"The SASL mechanism configuration is invalid. Possible values are `OAUTHBEARER`, `PLAIN`, `SCRAM-SHA-256`, `SCRAM-SHA-512`, `GSSAPI` and those added with RegisterSASLMechanism",
 		},
 		{
 			"SASL.Mechanism.OAUTHBEARER - Missing token provider",
@@ -113,28 +182,6 @@ func TestNetConfigValidates(t *testing.T) {
 			},
 			"An AccessTokenProvider instance must be provided to Net.SASL.TokenProvider",
 		},
-		{
-			"SASL.Mechanism SCRAM-SHA-256 - Missing SCRAM client",
-			func(cfg *Config) {
-				cfg.Net.SASL.Enable = true
-				cfg.Net.SASL.Mechanism = SASLTypeSCRAMSHA256
-				cfg.Net.SASL.SCRAMClientGeneratorFunc = nil
-				cfg.Net.SASL.User = "user"
-				cfg.Net.SASL.Password = "strong_password"
-			},
-			"A SCRAMClientGeneratorFunc function must be provided to Net.SASL.SCRAMClientGeneratorFunc",
-		},
-		{
-			"SASL.Mechanism SCRAM-SHA-512 - Missing SCRAM client",
-			func(cfg *Config) {
-				cfg.Net.SASL.Enable = true
-				cfg.Net.SASL.Mechanism = SASLTypeSCRAMSHA512
-				cfg.Net.SASL.SCRAMClientGeneratorFunc = nil
-				cfg.Net.SASL.User = "user"
-				cfg.Net.SASL.Password = "strong_password"
-			},
-			"A SCRAMClientGeneratorFunc function must be provided to Net.SASL.SCRAMClientGeneratorFunc",
-		},
 		{
 			"SASL.Mechanism GSSAPI (Kerberos) - Using User/Password, Missing password field",
 			func(cfg *Config) {
@@ -200,7 +247,7 @@ func TestNetConfigValidates(t *testing.T) {
 				cfg.Net.SASL.GSSAPI.Realm = "kafka"
 				cfg.Net.SASL.GSSAPI.KerberosConfigPath = "/etc/krb5.conf"
 			},
-			"Net.SASL.GSSAPI.AuthType is invalid. Possible values are KRB5_USER_AUTH and KRB5_KEYTAB_AUTH",
+			"Net.SASL.GSSAPI.AuthType is invalid. Possible values are KRB5_USER_AUTH, KRB5_KEYTAB_AUTH and KRB5_CCACHE_AUTH",
 		},
 		{
 			"SASL.Mechanism GSSAPI (Kerberos) - Missing KerberosConfigPath",
@@ -268,6 +315,27 @@ func TestMetadataConfigValidates(t *testing.T) {
 			},
 			"Metadata.RefreshFrequency must be >= 0",
 		},
+		{
+			"FullRefreshMinInterval",
+			func(cfg *Config) {
+				cfg.Metadata.FullRefreshMinInterval = -1
+			},
+			"Metadata.FullRefreshMinInterval must be >= 0",
+		},
+		{
+			"Rebootstrap.ResolveFrequency",
+			func(cfg *Config) {
+				cfg.Metadata.Rebootstrap.ResolveFrequency = -1
+			},
+			"Metadata.Rebootstrap.ResolveFrequency must be >= 0",
+		},
+		{
+			"Failover.After",
+			func(cfg *Config) {
+				cfg.Metadata.Failover.After = -1
+			},
+			"Metadata.Failover.After must be >= 0",
+		},
 	}
 
 	for i, test := range tests {
@@ -425,6 +493,66 @@ func TestProducerConfigValidates(t *testing.T) {
 			},
 			"Idempotent producer requires Net.MaxOpenRequests to be 1",
 		},
+		{
+			"Transaction.ID without Idempotent",
+			func(cfg *Config) {
+				cfg.Version = V0_11_0_0
+				cfg.Producer.Transaction.ID = "txn"
+			},
+			"Transactional producer requires Producer.Idempotent to be true",
+		},
+		{
+			"Transaction.Timeout",
+			func(cfg *Config) {
+				cfg.Version = V0_11_0_0
+				cfg.Producer.Idempotent = true
+				cfg.Producer.RequiredAcks = WaitForAll
+				cfg.Net.MaxOpenRequests = 1
+				cfg.Producer.Transaction.ID = "txn"
+				cfg.Producer.Transaction.Timeout = 0
+			},
+			"Producer.Transaction.Timeout must be >= 1ms",
+		},
+		{
+			"Transaction.Watchdog.Margin",
+			func(cfg *Config) {
+				cfg.Version = V0_11_0_0
+				cfg.Producer.Idempotent = true
+				cfg.Producer.RequiredAcks = WaitForAll
+				cfg.Net.MaxOpenRequests = 1
+				cfg.Producer.Transaction.ID = "txn"
+				cfg.Producer.Transaction.Watchdog.Enable = true
+				cfg.Producer.Transaction.Watchdog.Margin = cfg.Producer.Transaction.Timeout
+			},
+			"Producer.Transaction.Watchdog.Margin must be < Producer.Transaction.Timeout",
+		},
+		{
+			"TwoPhaseCommit without Transaction.ID",
+			func(cfg *Config) {
+				cfg.Version = V4_1_0_0
+				cfg.Producer.Transaction.TwoPhaseCommit.Enable = true
+			},
+			"Two-phase commit requires Producer.Transaction.ID",
+		},
+		{
+			"TwoPhaseCommit with Version",
+			func(cfg *Config) {
+				cfg.Version = V4_0_0_0
+				cfg.Producer.Idempotent = true
+				cfg.Producer.RequiredAcks = WaitForAll
+				cfg.Net.MaxOpenRequests = 1
+				cfg.Producer.Transaction.ID = "txn"
+				cfg.Producer.Transaction.TwoPhaseCommit.Enable = true
+			},
+			"Two-phase commit requires Version >= V4_1_0_0",
+		},
+		{
+			"TwoPhaseCommit.KeepPreparedTxn without Enable",
+			func(cfg *Config) {
+				cfg.Producer.Transaction.TwoPhaseCommit.KeepPreparedTxn = true
+			},
+			"Producer.Transaction.TwoPhaseCommit.KeepPreparedTxn requires Producer.Transaction.TwoPhaseCommit.Enable",
+		},
 	}
 
 	for i, test := range tests {
@@ -524,3 +652,87 @@ func ExampleConfig_metrics() {
 	// gauge sarama.m2
 	//   value:               2
 }
+
+func TestTopicOverridesValidation(t *testing.T) {
+	zstd, invalidAcks, readCommitted := CompressionZSTD, RequiredAcks(2), ReadCommitted
+	tests := []struct {
+		name      string
+		overrides func(*TopicOverrides)
+		err       string
+	}{
+		{
+			"zstd",
+			func(o *TopicOverrides) { o.Producer.Compression = &zstd },
+			`Topics["my-topic"].Producer.Compression zstd requires Version >= V2_1_0_0`,
+		},
+		{
+			"RequiredAcks",
+			func(o *TopicOverrides) { o.Producer.RequiredAcks = &invalidAcks },
+			`Topics["my-topic"].Producer.RequiredAcks must be NoResponse, WaitForLocal or WaitForAll`,
+		},
+		{
+			"Fetch.Default",
+			func(o *TopicOverrides) { o.Consumer.Fetch.Default, o.Consumer.Fetch.Max = 2048, 1024 },
+			`Topics["my-topic"].Consumer.Fetch.Default must be <= Consumer.Fetch.Max`,
+		},
+		{
+			"ReadCommitted",
+			func(o *TopicOverrides) { o.Consumer.IsolationLevel = &readCommitted },
+			`Topics["my-topic"].Consumer.IsolationLevel ReadCommitted requires Version >= V0_11_0_0`,
+		},
+	}
+	for _, test := range tests {
+		config := NewTestConfig()
+		var overrides TopicOverrides
+		test.overrides(&overrides)
+		config.Topics = map[string]TopicOverrides{"my-topic": overrides}
+		err := config.Validate()
+		var target ConfigurationError
+		if !errors.As(err, &target) || string(target) != test.err {
+			t.Errorf("[%s] Expected %s, Got %s\n", test.name, test.err, err)
+		}
+	}
+}
+
+func TestConfigRegisterValidator(t *testing.T) {
+	errAcks := errors.New("Producer.RequiredAcks must be WaitForAll")
+	errTLS := errors.New("Net.TLS must be enabled")
+	var calls []string
+	config := NewTestConfig().
+		RegisterValidator(func(c *Config) error {
+			calls = append(calls, "acks")
+			if c.Producer.RequiredAcks != WaitForAll {
+				return errAcks
+			}
+			return nil
+		}).
+		RegisterValidator(func(c *Config) error {
+			calls = append(calls, "tls")
+			if !c.Net.TLS.Enable {
+				return errTLS
+			}
+			return nil
+		})
+
+	if err := config.Validate(); !errors.Is(err, errAcks) || len(calls) != 1 {
+		t.Errorf("expected the first validator to fail, got %v after %v", err, calls)
+	}
+
+	calls = nil
+	config.Producer.RequiredAcks = WaitForAll
+	if err := config.Validate(); !errors.Is(err, errTLS) || len(calls) != 2 {
+		t.Errorf("expected the second validator to fail, got %v after %v", err, calls)
+	}
+
+	calls = nil
+	config.Net.TLS.Enable = true
+	if err := config.Validate(); err != nil || len(calls) != 2 {
+		t.Errorf("expected the validators to pass, got %v after %v", err, calls)
+	}
+
+	calls = nil
+	config.ChannelBufferSize = -1
+	if err := config.Validate(); err == nil || len(calls) != 0 {
+		t.Errorf("expected the checks of sarama to fail before the validators, got %v after %v", err, calls)
+	}
+}
@@ -0,0 +1,130 @@
+package sarama
+
+import "fmt"
+
+// TopicOverrides are the producer and consumer settings of a topic which
+// differ from those of the Config, see Config.Topics. The nil pointers and
+// zero sizes keep the settings of the Config.
+type TopicOverrides struct {
+	Producer struct {
+		// Compression and CompressionLevel override Producer.Compression
+		// and Producer.CompressionLevel.
+		Compression      *CompressionCodec
+		CompressionLevel *int
+		// RequiredAcks overrides Producer.RequiredAcks. The messages of the
+		// topics with different required acks are sent in separate
+		// requests.
+		RequiredAcks *RequiredAcks
+		// MaxMessageBytes overrides Producer.MaxMessageBytes.
+		MaxMessageBytes int
+	}
+	Consumer struct {
+		Fetch struct {
+			// Default and Max override Consumer.Fetch.Default and
+			// Consumer.Fetch.Max.
+			Default int32
+			Max     int32
+		}
+		// IsolationLevel overrides Consumer.IsolationLevel. The partitions
+		// of the topics with different isolation levels are fetched in
+		// separate requests.
+		IsolationLevel *IsolationLevel
+	}
+}
+
+func (c *Config) topicOverrides(topic string) (TopicOverrides, bool) {
+	overrides, ok := c.Topics[topic]
+	return overrides, ok
+}
+
+// producerCompression returns the compression codec and level of the topic
+func (c *Config) producerCompression(topic string) (CompressionCodec, int) {
+	codec, level := c.Producer.Compression, c.Producer.CompressionLevel
+	if overrides, ok := c.topicOverrides(topic); ok {
+		if overrides.Producer.Compression != nil {
+			codec = *overrides.Producer.Compression
+		}
+		if overrides.Producer.CompressionLevel != nil {
+			level = *overrides.Producer.CompressionLevel
+		}
+	}
+	return codec, level
+}
+
+// producerRequiredAcks returns the acks required for the messages of the topic
+func (c *Config) producerRequiredAcks(topic string) RequiredAcks {
+	if overrides, ok := c.topicOverrides(topic); ok && overrides.Producer.RequiredAcks != nil {
+		return *overrides.Producer.RequiredAcks
+	}
+	return c.Producer.RequiredAcks
+}
+
+// producerMaxMessageBytes returns the maximum size of the messages of the topic
+func (c *Config) producerMaxMessageBytes(topic string) int {
+	if overrides, ok := c.topicOverrides(topic); ok && overrides.Producer.MaxMessageBytes > 0 {
+		return overrides.Producer.MaxMessageBytes
+	}
+	return c.Producer.MaxMessageBytes
+}
+
+// consumerFetchSizes returns the default and maximum fetch sizes of the topic
+func (c *Config) consumerFetchSizes(topic string) (fetchDefault, fetchMax int32) {
+	fetchDefault, fetchMax = c.Consumer.Fetch.Default, c.Consumer.Fetch.Max
+	if overrides, ok := c.topicOverrides(topic); ok {
+		if overrides.Consumer.Fetch.Default > 0 {
+			fetchDefault = overrides.Consumer.Fetch.Default
+		}
+		if overrides.Consumer.Fetch.Max > 0 {
+			fetchMax = overrides.Consumer.Fetch.Max
+		}
+	}
+	return fetchDefault, fetchMax
+}
+
+// consumerIsolationLevel returns the isolation level of the topic
+func (c *Config) consumerIsolationLevel(topic string) IsolationLevel {
+	if overrides, ok := c.topicOverrides(topic); ok && overrides.Consumer.IsolationLevel != nil {
+		return *overrides.Consumer.IsolationLevel
+	}
+	return c.Consumer.IsolationLevel
+}
+
+// validateTopics validates the overrides of Topics like the settings they
+// override.
+func (c *Config) validateTopics() error {
+	for topic, overrides := range c.Topics {
+		codec, level := c.producerCompression(topic)
+		acks := c.producerRequiredAcks(topic)
+		fetchDefault, fetchMax := c.consumerFetchSizes(topic)
+		isolation := c.consumerIsolationLevel(topic)
+
+		invalid := func(format string, args ...interface{}) error {
+			return ConfigurationError(fmt.Sprintf("Topics[%q].", topic) + fmt.Sprintf(format, args...))
+		}
+		switch {
+		case overrides.Producer.MaxMessageBytes < 0:
+			return invalid("Producer.MaxMessageBytes must be >= 0")
+		case acks < WaitForAll || acks > WaitForLocal:
+			return invalid("Producer.RequiredAcks must be NoResponse, WaitForLocal or WaitForAll")
+		case c.Producer.Idempotent && acks != WaitForAll:
+			return invalid("Producer.RequiredAcks must be WaitForAll with the idempotent producer")
+		case !knownCompressionCodec(codec):
+			return invalid("Producer.Compression %s is neither built-in nor registered", codec)
+		case checkCompressionLevel(codec, level) != nil:
+			return invalid("Producer.CompressionLevel: %v", checkCompressionLevel(codec, level))
+		case codec == CompressionLZ4 && !c.versionAllows(V0_10_0_0):
+			return invalid("Producer.Compression lz4 requires Version >= V0_10_0_0")
+		case codec == CompressionZSTD && !c.versionAllows(V2_1_0_0):
+			return invalid("Producer.Compression zstd requires Version >= V2_1_0_0")
+		case overrides.Consumer.Fetch.Default < 0 || overrides.Consumer.Fetch.Max < 0:
+			return invalid("Consumer.Fetch.Default and Consumer.Fetch.Max must be >= 0")
+		case fetchMax > 0 && fetchDefault > fetchMax:
+			return invalid("Consumer.Fetch.Default must be <= Consumer.Fetch.Max")
+		case isolation != ReadUncommitted && isolation != ReadCommitted:
+			return invalid("Consumer.IsolationLevel must be ReadUncommitted or ReadCommitted")
+		case isolation == ReadCommitted && !c.versionAllows(V0_11_0_0):
+			return invalid("Consumer.IsolationLevel ReadCommitted requires Version >= V0_11_0_0")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package sarama
+
+import "fmt"
+
+// VersionConflict is a setting of a Config which requires a more recent
+// Kafka version than Config.Version, see Config.VersionConflicts.
+type VersionConflict struct {
+	// Field is the path of the setting in the Config, e.g.
+	// Producer.Compression.
+	Field string
+	// Setting describes the value of the field conflicting with the version.
+	Setting string
+	// Required is the first Kafka version supporting the setting.
+	Required KafkaVersion
+	// Version is Config.Version.
+	Version KafkaVersion
+	// Warning is whether the setting is ignored, or only fails once
+	// connected, with Version rather than rejected by Config.Validate.
+	Warning bool
+}
+
+func (v VersionConflict) String() string {
+	return fmt.Sprintf("%s: %s requires Version >= %s, got %s", v.Field, v.Setting, v.Required, v.Version)
+}
+
+// Error implements the error interface, for the conflicts to be returned as
+// errors.
+func (v VersionConflict) Error() string {
+	return v.String()
+}
+
+// versionRequirement is a setting requiring a Kafka version
+type versionRequirement struct {
+	field    string
+	setting  string
+	required KafkaVersion
+	warning  bool
+	applies  func(c *Config) bool
+}
+
+var versionRequirements = []versionRequirement{
+	{"Producer.Compression", "lz4 compression", V0_10_0_0, false, func(c *Config) bool {
+		return c.Producer.Compression == CompressionLZ4
+	}},
+	{"Producer.Compression", "zstd compression", V2_1_0_0, false, func(c *Config) bool {
+		return c.Producer.Compression == CompressionZSTD
+	}},
+	{"Producer.Idempotent", "the idempotent producer", V0_11_0_0, false, func(c *Config) bool {
+		return c.Producer.Idempotent
+	}},
+	{"Consumer.IsolationLevel", "ReadCommitted", V0_11_0_0, false, func(c *Config) bool {
+		return c.Consumer.IsolationLevel == ReadCommitted
+	}},
+	{"Net.SASL.Version", "SASLHandshakeV1", V1_0_0_0, true, func(c *Config) bool {
+		return c.Net.SASL.Enable && c.Net.SASL.Version == SASLHandshakeV1
+	}},
+	{"Net.SASL.Mechanism", "SASL/SCRAM", V0_10_2_0, true, func(c *Config) bool {
+		return c.Net.SASL.Enable && (c.Net.SASL.Mechanism == SASLTypeSCRAMSHA256 || c.Net.SASL.Mechanism == SASLTypeSCRAMSHA512)
+	}},
+	{"Net.SASL.Mechanism", "SASL/OAUTHBEARER", V2_0_0_0, true, func(c *Config) bool {
+		return c.Net.SASL.Enable && c.Net.SASL.Mechanism == SASLTypeOAuth
+	}},
+	{"Net.PauseOnThrottle", "pausing for the throttle time", V2_0_0_0, true, func(c *Config) bool {
+		return c.Net.PauseOnThrottle
+	}},
+	{"Metadata.AllowAutoTopicCreation", "disabling the topic creation", V0_11_0_0, true, func(c *Config) bool {
+		return !c.Metadata.AllowAutoTopicCreation
+	}},
+	{"RackID", "fetching from the closest replica", V2_4_0_0, true, func(c *Config) bool {
+		return c.RackID != ""
+	}},
+}
+
+// VersionConflicts returns the settings of the configuration which require a
+// more recent Kafka version than Version: those rejected by Validate, and
+// those which are ignored or only fail once connected, which Validate logs as
+// warnings.
+func (c *Config) VersionConflicts() []VersionConflict {
+	var conflicts []VersionConflict
+	for _, requirement := range versionRequirements {
+		if !c.versionAllows(requirement.required) && requirement.applies(c) {
+			conflicts = append(conflicts, VersionConflict{
+				Field:    requirement.field,
+				Setting:  requirement.setting,
+				Required: requirement.required,
+				Version:  c.Version,
+				Warning:  requirement.warning,
+			})
+		}
+	}
+	return conflicts
+}
+
+// versionAllows returns whether Version allows the features requiring the
+// given one, which is assumed with VersionAuto until the version is derived
+// from the brokers
+func (c *Config) versionAllows(required KafkaVersion) bool {
+	return c.Version == VersionAuto || c.Version.IsAtLeast(required)
+}
@@ -0,0 +1,32 @@
+package sarama
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigVersionConflicts(t *testing.T) {
+	config := NewTestConfig()
+	config.Version = V0_10_2_0
+	if conflicts := config.VersionConflicts(); len(conflicts) != 0 {
+		t.Errorf("expected no conflict by default, got %v", conflicts)
+	}
+
+	config.Producer.Compression = CompressionZSTD
+	config.RackID = "rack-1"
+	expected := []VersionConflict{
+		{Field: "Producer.Compression", Setting: "zstd compression", Required: V2_1_0_0, Version: V0_10_2_0},
+		{Field: "RackID", Setting: "fetching from the closest replica", Required: V2_4_0_0, Version: V0_10_2_0, Warning: true},
+	}
+	if conflicts := config.VersionConflicts(); !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("expected %v, got %v", expected, conflicts)
+	}
+	if s := expected[0].String(); s != "Producer.Compression: zstd compression requires Version >= 2.1.0, got 0.10.2.0" {
+		t.Errorf("unexpected description %q", s)
+	}
+
+	config.Version = V2_4_0_0
+	if conflicts := config.VersionConflicts(); len(conflicts) != 0 {
+		t.Errorf("expected no conflict with Kafka 2.4, got %v", conflicts)
+	}
+}
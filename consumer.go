@@ -4,11 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
-
-	"github.com/rcrowley/go-metrics"
 )
 
 // ConsumerMessage encapsulates a Kafka message returned by the consumer.
@@ -21,6 +20,10 @@ type ConsumerMessage struct {
 	Topic      string
 	Partition  int32
 	Offset     int64
+
+	// ControlRecord is the decoded control record (transaction marker) of
+	// the message, only set when Consumer.Return.ControlRecords is enabled
+	ControlRecord *ControlRecord
 }
 
 // ConsumerError is what is provided to the user when an error occurs.
@@ -102,6 +105,10 @@ type consumer struct {
 	brokerConsumers map[*Broker]*brokerConsumer
 	client          Client
 	lock            sync.Mutex
+
+	// groupID is the consumer group of the consumer, if created by one, as
+	// labelled in the lag metrics
+	groupID string
 }
 
 // NewConsumer creates a new consumer using the given broker addresses and configuration.
@@ -134,11 +141,13 @@ func newConsumer(client Client) (Consumer, error) {
 		children:        make(map[string]map[int32]*partitionConsumer),
 		brokerConsumers: make(map[*Broker]*brokerConsumer),
 	}
+	registerStats(client, c)
 
 	return c, nil
 }
 
 func (c *consumer) Close() error {
+	unregisterStats(c.client, c)
 	return c.client.Close()
 }
 
@@ -162,8 +171,10 @@ func (c *consumer) ConsumePartition(topic string, partition int32, offset int64)
 		preferredReadReplica: invalidPreferredReplicaID,
 		trigger:              make(chan none, 1),
 		dying:                make(chan none),
-		fetchSize:            c.conf.Consumer.Fetch.Default,
+		latency:              c.conf.metricsRecorder().Histogram("end-to-end-latency-in-ms", nil),
+		topicLatency:         c.conf.metricsRecorder().Histogram("end-to-end-latency-in-ms", topicMetricLabels(topic)),
 	}
+	child.fetchSize, _ = c.conf.consumerFetchSizes(topic)
 
 	if err := child.chooseStartingOffset(offset); err != nil {
 		return nil, err
@@ -178,6 +189,7 @@ func (c *consumer) ConsumePartition(topic string, partition int32, offset int64)
 	if err := c.addChild(child); err != nil {
 		return nil, err
 	}
+	child.registerLag()
 
 	go withRecover(child.dispatcher)
 	go withRecover(child.responseFeeder)
@@ -406,6 +418,17 @@ type partitionConsumer struct {
 	retries        int32
 
 	paused int32
+
+	// lag is the gauge of the difference between the high watermark and the
+	// position, the offset of the next message to deliver, only updated by
+	// the responseFeeder
+	lag              MetricGauge
+	lagHighWaterMark int64
+	position         int64
+
+	// latency and topicLatency are the histograms of the time from the
+	// timestamp of the messages to their delivery
+	latency, topicLatency MetricHistogram
 }
 
 var errTimedOut = errors.New("timed out feeding messages to the user") // not user-facing
@@ -420,10 +443,15 @@ func (child *partitionConsumer) sendError(err error) {
 	if child.conf.Consumer.Return.Errors {
 		child.errors <- cErr
 	} else {
-		Logger.Println(cErr)
+		child.log().Errorf("%v", cErr)
 	}
 }
 
+// log returns the logger of the lines about the partition
+func (child *partitionConsumer) log() contextLogger {
+	return newContextLogger("topic", child.topic, "partition", child.partition)
+}
+
 func (child *partitionConsumer) computeBackoff() time.Duration {
 	if child.conf.Consumer.Retry.BackoffFunc != nil {
 		retries := atomic.AddInt32(&child.retries, 1)
@@ -437,7 +465,7 @@ func (child *partitionConsumer) dispatcher() {
 		select {
 		case <-child.dying:
 			close(child.trigger)
-		case <-time.After(child.computeBackoff()):
+		case <-child.conf.Clock.After(child.computeBackoff()):
 			if child.broker != nil {
 				child.consumer.unrefBrokerConsumer(child.broker)
 				child.broker = nil
@@ -453,6 +481,8 @@ func (child *partitionConsumer) dispatcher() {
 	if child.broker != nil {
 		child.consumer.unrefBrokerConsumer(child.broker)
 	}
+	// before the partition can be consumed again, registering its lag anew
+	child.unregisterLag()
 	child.consumer.removeChild(child)
 	close(child.feeder)
 }
@@ -463,7 +493,7 @@ func (child *partitionConsumer) preferredBroker() (*Broker, error) {
 		if err == nil {
 			return broker, nil
 		}
-		Logger.Printf(
+		child.log().with("broker", child.preferredReadReplica).Warnf(
 			"consumer/%s/%d failed to find active broker for preferred read replica %d - will fallback to leader",
 			child.topic, child.partition, child.preferredReadReplica)
 
@@ -557,52 +587,139 @@ func (child *partitionConsumer) HighWaterMarkOffset() int64 {
 	return atomic.LoadInt64(&child.highWaterMarkOffset)
 }
 
+// lagLabels returns the labels of the consumer-lag metric of the partition
+func (child *partitionConsumer) lagLabels() MetricLabels {
+	labels := MetricLabels{"topic": child.topic, "partition": strconv.Itoa(int(child.partition))}
+	if child.consumer.groupID != "" {
+		labels["group"] = child.consumer.groupID
+	}
+	return labels
+}
+
+// registerLag registers the lag of the partition, starting from the high
+// watermark and offset chosen by chooseStartingOffset
+func (child *partitionConsumer) registerLag() {
+	child.lag = child.conf.metricsRecorder().Gauge("consumer-lag", child.lagLabels())
+	child.lagHighWaterMark = atomic.LoadInt64(&child.highWaterMarkOffset)
+	child.position = child.offset
+	child.updateLag()
+}
+
+func (child *partitionConsumer) unregisterLag() {
+	child.conf.metricsRecorder().Unregister("consumer-lag", child.lagLabels())
+}
+
+// delivered moves the position past a message delivered to the user and
+// records its end-to-end latency, unless it has no timestamp
+func (child *partitionConsumer) delivered(msg *ConsumerMessage) {
+	child.position = msg.Offset + 1
+	child.updateLag()
+
+	if child.latency == nil || msg.Timestamp.IsZero() {
+		return
+	}
+	latency := child.conf.Clock.Now().Sub(msg.Timestamp).Milliseconds()
+	if latency < 0 {
+		// the clocks of the producer and the consumer are skewed
+		latency = 0
+	}
+	child.latency.Observe(latency)
+	child.topicLatency.Observe(latency)
+}
+
+func (child *partitionConsumer) updateLag() {
+	if child.lag == nil {
+		return
+	}
+	lag := child.lagHighWaterMark - child.position
+	if lag < 0 {
+		lag = 0
+	}
+	child.lag.Set(lag)
+}
+
 func (child *partitionConsumer) responseFeeder() {
 	var msgs []*ConsumerMessage
-	expiryTicker := time.NewTicker(child.conf.Consumer.MaxProcessingTime)
+	var parser *recordsParser
+	expiryTicker := child.conf.Clock.NewTicker(child.conf.Consumer.MaxProcessingTime)
 	firstAttempt := true
 
 feederLoop:
 	for response := range child.feeder {
-		msgs, child.responseResult = child.parseResponse(response)
+		if child.conf.Consumer.Fetch.LazyBatchDecoding {
+			// the records sets are parsed as their messages are delivered
+			msgs = nil
+			parser, child.responseResult = child.newRecordsParser(response)
+		} else {
+			parser = nil
+			msgs, child.responseResult = child.parseResponse(response)
+		}
 
 		if child.responseResult == nil {
 			atomic.StoreInt32(&child.retries, 0)
 		}
 
-		for i, msg := range msgs {
-			child.interceptors(msg)
-		messageSelect:
-			select {
-			case <-child.dying:
-				child.broker.acks.Done()
-				continue feederLoop
-			case child.messages <- msg:
-				firstAttempt = true
-			case <-expiryTicker.C:
-				if !firstAttempt {
-					child.responseResult = errTimedOut
+		for {
+			for i, msg := range msgs {
+				child.interceptors(msg)
+			messageSelect:
+				select {
+				case <-child.dying:
+					response.closeRecordsStreams()
 					child.broker.acks.Done()
-				remainingLoop:
-					for _, msg = range msgs[i:] {
-						child.interceptors(msg)
-						select {
-						case child.messages <- msg:
-						case <-child.dying:
-							break remainingLoop
+					continue feederLoop
+				case child.messages <- msg:
+					child.delivered(msg)
+					firstAttempt = true
+				case <-expiryTicker.C():
+					if !firstAttempt {
+						child.responseResult = errTimedOut
+						// the records left to read off the connection are
+						// fetched again once redispatched
+						response.closeRecordsStreams()
+						child.broker.acks.Done()
+						remaining := msgs[i:]
+					remainingLoop:
+						for {
+							for _, msg = range remaining {
+								child.interceptors(msg)
+								select {
+								case child.messages <- msg:
+									child.delivered(msg)
+								case <-child.dying:
+									break remainingLoop
+								}
+							}
+							if parser == nil || !parser.more() {
+								break
+							}
+							var err error
+							if remaining, err = parser.next(); err != nil {
+								child.sendError(err)
+								break
+							}
 						}
+						child.broker.input <- child
+						continue feederLoop
+					} else {
+						// current message has not been sent, return to select
+						// statement
+						firstAttempt = false
+						goto messageSelect
 					}
-					child.broker.input <- child
-					continue feederLoop
-				} else {
-					// current message has not been sent, return to select
-					// statement
-					firstAttempt = false
-					goto messageSelect
 				}
 			}
+
+			if parser == nil || !parser.more() || child.responseResult != nil {
+				break
+			}
+			msgs, child.responseResult = parser.next()
 		}
 
+		// past the control records and aborted transactions too
+		child.position = child.offset
+		child.updateLag()
+		response.closeRecordsStreams()
 		child.broker.acks.Done()
 	}
 
@@ -675,19 +792,29 @@ func (child *partitionConsumer) parseRecords(batch *RecordBatch) ([]*ConsumerMes
 }
 
 func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*ConsumerMessage, error) {
-	var (
-		metricRegistry          = child.conf.MetricRegistry
-		consumerBatchSizeMetric metrics.Histogram
-	)
+	parser, err := child.newRecordsParser(response)
+	if parser == nil || err != nil {
+		return nil, err
+	}
 
-	if metricRegistry != nil {
-		consumerBatchSizeMetric = getOrRegisterHistogram("consumer-batch-size", metricRegistry)
+	var messages []*ConsumerMessage
+	for parser.more() {
+		recordsMessages, err := parser.next()
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, recordsMessages...)
 	}
+	return messages, nil
+}
 
+// newRecordsParser returns the parser of the records fetched for the
+// partition, nil when the response has none to deliver
+func (child *partitionConsumer) newRecordsParser(response *FetchResponse) (*recordsParser, error) {
 	// If request was throttled and empty we log and return without error
 	if response.ThrottleTime != time.Duration(0) && len(response.Blocks) == 0 {
-		Logger.Printf(
-			"consumer/broker/%d FetchResponse throttled %v\n",
+		child.broker.log().Infof(
+			"consumer/broker/%d FetchResponse throttled %v",
 			child.broker.broker.ID(), response.ThrottleTime)
 		return nil, nil
 	}
@@ -698,29 +825,65 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 	}
 
 	if !errors.Is(block.Err, ErrNoError) {
-		return nil, block.Err
+		return nil, &PartitionRequestError{
+			Topic:         child.topic,
+			Partition:     child.partition,
+			BrokerID:      child.broker.broker.ID(),
+			CorrelationID: block.correlationID,
+			Err:           block.Err,
+		}
+	}
+
+	// even without records, the lag is down to what is left to deliver
+	child.lagHighWaterMark = block.HighWaterMarkOffset
+	child.updateLag()
+
+	if block.PreferredReadReplica != invalidPreferredReplicaID {
+		child.preferredReadReplica = block.PreferredReadReplica
+	}
+
+	readCommitted := child.conf.consumerIsolationLevel(child.topic) == ReadCommitted
+	parser := &recordsParser{
+		child:               child,
+		block:               block,
+		recordsSet:          block.RecordsSet,
+		stream:              block.stream,
+		readCommitted:       readCommitted,
+		abortedProducerIDs:  make(map[int64]struct{}, len(block.AbortedTransactions)),
+		abortedTransactions: block.getAbortedTransactions(),
+	}
+	if block.stream != nil {
+		// the records are counted as they are read off the connection
+		return parser, nil
 	}
 
 	nRecs, err := block.numRecords()
 	if err != nil {
 		return nil, err
 	}
-
-	consumerBatchSizeMetric.Update(int64(nRecs))
-
-	if block.PreferredReadReplica != invalidPreferredReplicaID {
-		child.preferredReadReplica = block.PreferredReadReplica
+	if ok, err := child.fetchedRecords(block, nRecs); !ok || err != nil {
+		return nil, err
 	}
+	return parser, nil
+}
+
+// fetchedRecords handles the number of records fetched for the partition,
+// asking for more data when only a partial one could be, and returns whether
+// there are any to deliver
+func (child *partitionConsumer) fetchedRecords(block *FetchResponseBlock, nRecs int) (bool, error) {
+	consumerBatchSizeMetric := child.conf.metricsRecorder().Histogram("consumer-batch-size", nil)
+	consumerBatchSizeMetric.Observe(int64(nRecs))
 
 	if nRecs == 0 {
 		partialTrailingMessage, err := block.isPartial()
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 		// We got no messages. If we got a trailing one then we need to ask for more data.
 		// Otherwise we just poll again and wait for one to be produced...
 		if partialTrailingMessage {
-			if child.conf.Consumer.Fetch.Max > 0 && child.fetchSize == child.conf.Consumer.Fetch.Max {
+			_, fetchMax := child.conf.consumerFetchSizes(child.topic)
+			if fetchMax > 0 && child.fetchSize == fetchMax {
 				// we can't ask for more data, we've hit the configured limit
 				child.sendError(ErrMessageTooLarge)
 				child.offset++ // skip this one so we can keep processing future messages
@@ -730,97 +893,165 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 				if child.fetchSize < 0 {
 					child.fetchSize = math.MaxInt32
 				}
-				if child.conf.Consumer.Fetch.Max > 0 && child.fetchSize > child.conf.Consumer.Fetch.Max {
-					child.fetchSize = child.conf.Consumer.Fetch.Max
+				if fetchMax > 0 && child.fetchSize > fetchMax {
+					child.fetchSize = fetchMax
 				}
 			}
 		} else if block.LastRecordsBatchOffset != nil && *block.LastRecordsBatchOffset < block.HighWaterMarkOffset {
 			// check last record offset to avoid stuck if high watermark was not reached
-			Logger.Printf("consumer/broker/%d received batch with zero records but high watermark was not reached, topic %s, partition %d, offset %d\n", child.broker.broker.ID(), child.topic, child.partition, *block.LastRecordsBatchOffset)
+			child.log().with("broker", child.broker.broker.ID()).Debugf("consumer/broker/%d received batch with zero records but high watermark was not reached, topic %s, partition %d, offset %d", child.broker.broker.ID(), child.topic, child.partition, *block.LastRecordsBatchOffset)
 			child.offset = *block.LastRecordsBatchOffset + 1
 		}
 
-		return nil, nil
+		return false, nil
 	}
 
 	// we got messages, reset our fetch size in case it was increased for a previous request
-	child.fetchSize = child.conf.Consumer.Fetch.Default
+	child.fetchSize, _ = child.conf.consumerFetchSizes(child.topic)
 	atomic.StoreInt64(&child.highWaterMarkOffset, block.HighWaterMarkOffset)
 
+	return true, nil
+}
+
+// recordsParser parses the records sets fetched for a partition one at a
+// time, so that those fetched with Consumer.Fetch.LazyBatchDecoding are only
+// read off the connection and decoded once the messages of the previous ones
+// are delivered.
+type recordsParser struct {
+	child         *partitionConsumer
+	block         *FetchResponseBlock
+	recordsSet    []*Records
+	readCommitted bool
+
+	// stream reads the records sets off the connection, nil once they are
+	// all read; nRecs counts their records and err is the error reading them
+	stream *recordsStream
+	nRecs  int
+	err    error
+
 	// abortedProducerIDs contains producerID which message should be ignored as uncommitted
 	// - producerID are added when the partitionConsumer iterate over the offset at which an aborted transaction begins (abortedTransaction.FirstOffset)
 	// - producerID are removed when partitionConsumer iterate over an aborted controlRecord, meaning the aborted transaction for this producer is over
-	abortedProducerIDs := make(map[int64]struct{}, len(block.AbortedTransactions))
-	abortedTransactions := block.getAbortedTransactions()
+	abortedProducerIDs  map[int64]struct{}
+	abortedTransactions []*AbortedTransaction
+}
 
-	var messages []*ConsumerMessage
-	for _, records := range block.RecordsSet {
-		switch records.recordsType {
-		case legacyRecords:
-			messageSetMessages, err := child.parseMessages(records.MsgSet)
-			if err != nil {
-				return nil, err
-			}
+func (p *recordsParser) more() bool {
+	if len(p.recordsSet) == 0 && p.stream != nil {
+		p.readRecords()
+	}
+	return len(p.recordsSet) > 0 || p.err != nil
+}
 
-			messages = append(messages, messageSetMessages...)
-		case defaultRecords:
-			// Consume remaining abortedTransaction up to last offset of current batch
-			for _, txn := range abortedTransactions {
-				if txn.FirstOffset > records.RecordBatch.LastOffset() {
-					break
-				}
-				abortedProducerIDs[txn.ProducerID] = struct{}{}
-				// Pop abortedTransactions so that we never add it again
-				abortedTransactions = abortedTransactions[1:]
-			}
+// readRecords reads the next records set off the connection, handling the
+// records fetched once they are all read
+func (p *recordsParser) readRecords() {
+	if p.stream.closed {
+		// given up on, see responseFeeder
+		p.stream = nil
+		return
+	}
 
-			recordBatchMessages, err := child.parseRecords(records.RecordBatch)
-			if err != nil {
-				return nil, err
+	records, err := p.stream.next()
+	if records != nil {
+		n, err := records.numRecords()
+		if err != nil {
+			p.err = err
+			return
+		}
+		p.nRecs += n
+		p.recordsSet = append(p.recordsSet, records)
+		return
+	}
+
+	p.stream.close()
+	p.stream = nil
+	if err == nil {
+		_, err = p.child.fetchedRecords(p.block, p.nRecs)
+	}
+	p.err = err
+}
+
+// next parses the next records set, returning the messages to deliver
+func (p *recordsParser) next() ([]*ConsumerMessage, error) {
+	if len(p.recordsSet) == 0 {
+		err := p.err
+		p.err = nil
+		return nil, err
+	}
+
+	child := p.child
+	records := p.recordsSet[0]
+	p.recordsSet = p.recordsSet[1:]
+
+	switch records.recordsType {
+	case legacyRecords:
+		return child.parseMessages(records.MsgSet)
+	case defaultRecords:
+		if err := records.RecordBatch.decodeDeferred(); err != nil {
+			return nil, err
+		}
+
+		// Consume remaining abortedTransaction up to last offset of current batch
+		for _, txn := range p.abortedTransactions {
+			if txn.FirstOffset > records.RecordBatch.LastOffset() {
+				break
 			}
+			p.abortedProducerIDs[txn.ProducerID] = struct{}{}
+			// Pop abortedTransactions so that we never add it again
+			p.abortedTransactions = p.abortedTransactions[1:]
+		}
 
-			// Parse and commit offset but do not expose messages that are:
-			// - control records
-			// - part of an aborted transaction when set to `ReadCommitted`
+		recordBatchMessages, err := child.parseRecords(records.RecordBatch)
+		if err != nil {
+			return nil, err
+		}
+
+		// Parse and commit offset but do not expose messages that are:
+		// - control records
+		// - part of an aborted transaction when set to `ReadCommitted`
 
-			// control record
-			isControl, err := records.isControl()
+		// control record
+		isControl, err := records.isControl()
+		if err != nil {
+			// I don't know why there is this continue in case of error to begin with
+			// Safe bet is to ignore control messages if ReadUncommitted
+			// and block on them in case of error and ReadCommitted
+			if p.readCommitted {
+				return nil, err
+			}
+			return nil, nil
+		}
+		if isControl {
+			controlRecord, err := records.getControlRecord()
 			if err != nil {
-				// I don't know why there is this continue in case of error to begin with
-				// Safe bet is to ignore control messages if ReadUncommitted
-				// and block on them in case of error and ReadCommitted
-				if child.conf.Consumer.IsolationLevel == ReadCommitted {
-					return nil, err
-				}
-				continue
+				return nil, err
 			}
-			if isControl {
-				controlRecord, err := records.getControlRecord()
-				if err != nil {
-					return nil, err
-				}
 
-				if controlRecord.Type == ControlRecordAbort {
-					delete(abortedProducerIDs, records.RecordBatch.ProducerID)
-				}
-				continue
+			if controlRecord.Type == ControlRecordAbort {
+				delete(p.abortedProducerIDs, records.RecordBatch.ProducerID)
 			}
-
-			// filter aborted transactions
-			if child.conf.Consumer.IsolationLevel == ReadCommitted {
-				_, isAborted := abortedProducerIDs[records.RecordBatch.ProducerID]
-				if records.RecordBatch.IsTransactional && isAborted {
-					continue
+			if child.conf.Consumer.Return.ControlRecords {
+				for _, msg := range recordBatchMessages {
+					msg.ControlRecord = &controlRecord
 				}
+				return recordBatchMessages, nil
 			}
+			return nil, nil
+		}
 
-			messages = append(messages, recordBatchMessages...)
-		default:
-			return nil, fmt.Errorf("unknown records type: %v", records.recordsType)
+		// filter aborted transactions
+		if p.readCommitted {
+			_, isAborted := p.abortedProducerIDs[records.RecordBatch.ProducerID]
+			if records.RecordBatch.IsTransactional && isAborted {
+				return nil, nil
+			}
 		}
-	}
 
-	return messages, nil
+		return recordBatchMessages, nil
+	default:
+		return nil, fmt.Errorf("unknown records type: %v", records.recordsType)
+	}
 }
 
 func (child *partitionConsumer) interceptors(msg *ConsumerMessage) {
@@ -855,6 +1086,17 @@ type brokerConsumer struct {
 	refs             int
 }
 
+// log returns the logger of the lines about the broker
+func (bc *brokerConsumer) log() contextLogger {
+	return newContextLogger("broker", bc.broker.ID())
+}
+
+// childLog returns the logger of the lines about a partition consumed from
+// the broker
+func (bc *brokerConsumer) childLog(child *partitionConsumer) contextLogger {
+	return bc.log().with("topic", child.topic, "partition", child.partition)
+}
+
 func (c *consumer) newBrokerConsumer(broker *Broker) *brokerConsumer {
 	bc := &brokerConsumer{
 		consumer:         c,
@@ -903,13 +1145,13 @@ func (bc *brokerConsumer) subscriptionManager() {
 					}
 
 					partitionConsumers = append(partitionConsumers, pc)
-				case <-time.After(250 * time.Millisecond):
+				case <-bc.consumer.conf.Clock.After(250 * time.Millisecond):
 					batchComplete = true
 				}
 			}
 
-			Logger.Printf(
-				"consumer/broker/%d accumulated %d new subscriptions\n",
+			bc.log().Debugf(
+				"consumer/broker/%d accumulated %d new subscriptions",
 				bc.broker.ID(), len(partitionConsumers))
 
 			bc.wait <- none{}
@@ -944,17 +1186,23 @@ func (bc *brokerConsumer) subscriptionConsumer() {
 			continue
 		}
 
-		response, err := bc.fetchNewMessages()
+		var err error
+		if bc.consumer.conf.Consumer.Fetch.LazyBatchDecoding {
+			err = bc.streamNewMessages()
+		} else {
+			var response *FetchResponse
+			if response, err = bc.fetchNewMessages(); err == nil {
+				bc.acks.Add(len(bc.subscriptions))
+				for child := range bc.subscriptions {
+					child.feeder <- response
+				}
+			}
+		}
 		if err != nil {
-			Logger.Printf("consumer/broker/%d disconnecting due to error processing FetchRequest: %s\n", bc.broker.ID(), err)
+			bc.log().Warnf("consumer/broker/%d disconnecting due to error processing FetchRequest: %s", bc.broker.ID(), err)
 			bc.abort(err)
 			return
 		}
-
-		bc.acks.Add(len(bc.subscriptions))
-		for child := range bc.subscriptions {
-			child.feeder <- response
-		}
 		bc.acks.Wait()
 		bc.handleResponses()
 	}
@@ -963,13 +1211,13 @@ func (bc *brokerConsumer) subscriptionConsumer() {
 func (bc *brokerConsumer) updateSubscriptions(newSubscriptions []*partitionConsumer) {
 	for _, child := range newSubscriptions {
 		bc.subscriptions[child] = none{}
-		Logger.Printf("consumer/broker/%d added subscription to %s/%d\n", bc.broker.ID(), child.topic, child.partition)
+		bc.childLog(child).Infof("consumer/broker/%d added subscription to %s/%d", bc.broker.ID(), child.topic, child.partition)
 	}
 
 	for child := range bc.subscriptions {
 		select {
 		case <-child.dying:
-			Logger.Printf("consumer/broker/%d closed dead subscription to %s/%d\n", bc.broker.ID(), child.topic, child.partition)
+			bc.childLog(child).Infof("consumer/broker/%d closed dead subscription to %s/%d", bc.broker.ID(), child.topic, child.partition)
 			close(child.trigger)
 			delete(bc.subscriptions, child)
 		default:
@@ -988,8 +1236,8 @@ func (bc *brokerConsumer) handleResponses() {
 			if preferredBroker, err := child.preferredBroker(); err == nil {
 				if bc.broker.ID() != preferredBroker.ID() {
 					// not an error but needs redispatching to consume from preferred replica
-					Logger.Printf(
-						"consumer/broker/%d abandoned in favor of preferred replica broker/%d\n",
+					bc.childLog(child).Infof(
+						"consumer/broker/%d abandoned in favor of preferred replica broker/%d",
 						bc.broker.ID(), preferredBroker.ID())
 					child.trigger <- none{}
 					delete(bc.subscriptions, child)
@@ -1002,26 +1250,27 @@ func (bc *brokerConsumer) handleResponses() {
 		child.preferredReadReplica = invalidPreferredReplicaID
 
 		if errors.Is(result, errTimedOut) {
-			Logger.Printf("consumer/broker/%d abandoned subscription to %s/%d because consuming was taking too long\n",
+			bc.childLog(child).Warnf("consumer/broker/%d abandoned subscription to %s/%d because consuming was taking too long",
 				bc.broker.ID(), child.topic, child.partition)
 			delete(bc.subscriptions, child)
 		} else if errors.Is(result, ErrOffsetOutOfRange) {
 			// there's no point in retrying this it will just fail the same way again
 			// shut it down and force the user to choose what to do
 			child.sendError(result)
-			Logger.Printf("consumer/%s/%d shutting down because %s\n", child.topic, child.partition, result)
+			child.log().Errorf("consumer/%s/%d shutting down because %s", child.topic, child.partition, result)
 			close(child.trigger)
 			delete(bc.subscriptions, child)
-		} else if errors.Is(result, ErrUnknownTopicOrPartition) || errors.Is(result, ErrNotLeaderForPartition) || errors.Is(result, ErrLeaderNotAvailable) || errors.Is(result, ErrReplicaNotAvailable) {
+		} else if errors.Is(result, ErrUnknownTopicOrPartition) || errors.Is(result, ErrNotLeaderForPartition) || errors.Is(result, ErrLeaderNotAvailable) || errors.Is(result, ErrReplicaNotAvailable) ||
+			errors.Is(result, ErrUnknownTopicID) || errors.Is(result, ErrInconsistentTopicID) {
 			// not an error, but does need redispatching
-			Logger.Printf("consumer/broker/%d abandoned subscription to %s/%d because %s\n",
+			bc.childLog(child).Infof("consumer/broker/%d abandoned subscription to %s/%d because %s",
 				bc.broker.ID(), child.topic, child.partition, result)
 			child.trigger <- none{}
 			delete(bc.subscriptions, child)
 		} else {
 			// dunno, tell the user and try redispatching
 			child.sendError(result)
-			Logger.Printf("consumer/broker/%d abandoned subscription to %s/%d because %s\n",
+			bc.childLog(child).Warnf("consumer/broker/%d abandoned subscription to %s/%d because %s",
 				bc.broker.ID(), child.topic, child.partition, result)
 			child.trigger <- none{}
 			delete(bc.subscriptions, child)
@@ -1051,9 +1300,127 @@ func (bc *brokerConsumer) abort(err error) {
 }
 
 func (bc *brokerConsumer) fetchNewMessages() (*FetchResponse, error) {
+	var merged *FetchResponse
+	for _, request := range bc.newFetchRequests() {
+		response, err := bc.fetch(request)
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = response
+			continue
+		}
+		if response.ThrottleTime > merged.ThrottleTime {
+			merged.ThrottleTime = response.ThrottleTime
+		}
+		for topic, blocks := range response.Blocks {
+			if merged.Blocks == nil {
+				merged.Blocks = make(map[string]map[int32]*FetchResponseBlock)
+			}
+			if merged.Blocks[topic] == nil {
+				merged.Blocks[topic] = make(map[int32]*FetchResponseBlock)
+			}
+			for partition, block := range blocks {
+				merged.Blocks[topic][partition] = block
+			}
+		}
+	}
+	return merged, nil
+}
+
+// streamNewMessages fetches the messages like fetchNewMessages, but feeds
+// each subscription with the records of its partition as they are read off
+// the connection, waiting for it to be done reading them before decoding the
+// next partition, see Consumer.Fetch.LazyBatchDecoding. The subscriptions
+// missing from the responses are fed without records.
+func (bc *brokerConsumer) streamNewMessages() error {
+	children := make(map[string]map[int32]*partitionConsumer)
+	for child := range bc.subscriptions {
+		if children[child.topic] == nil {
+			children[child.topic] = make(map[int32]*partitionConsumer)
+		}
+		children[child.topic][child.partition] = child
+	}
+
+	bc.acks.Add(len(bc.subscriptions))
+	var merged *FetchResponse
+	for _, request := range bc.newFetchRequests() {
+		bc.resolveTopicIDs(request)
+		response, err := bc.broker.fetchStream(request, func(topic string, partition int32, block *FetchResponseBlock) {
+			child := children[topic][partition]
+			if child == nil {
+				block.stream.close()
+				return
+			}
+			delete(children[topic], partition)
+			child.feeder <- &FetchResponse{
+				Blocks:  map[string]map[int32]*FetchResponseBlock{topic: {partition: block}},
+				Version: request.Version,
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if merged == nil || response.ThrottleTime > merged.ThrottleTime {
+			merged = response
+		}
+	}
+
+	for _, partitions := range children {
+		for _, child := range partitions {
+			child.feeder <- merged
+		}
+	}
+	return nil
+}
+
+// newFetchRequests returns the requests fetching the subscriptions that are
+// not paused, those of the topics with different isolation levels, see
+// TopicOverrides, being fetched in separate requests
+func (bc *brokerConsumer) newFetchRequests() []*FetchRequest {
+	if len(bc.consumer.conf.Topics) == 0 {
+		request := bc.newFetchRequest(bc.consumer.conf.Consumer.IsolationLevel)
+		for child := range bc.subscriptions {
+			if !child.IsPaused() {
+				request.AddBlock(child.topic, child.partition, child.offset, child.fetchSize)
+			}
+		}
+		return []*FetchRequest{request}
+	}
+
+	byIsolation := make(map[IsolationLevel]*FetchRequest)
+	for child := range bc.subscriptions {
+		if child.IsPaused() {
+			continue
+		}
+		isolation := bc.consumer.conf.consumerIsolationLevel(child.topic)
+		request := byIsolation[isolation]
+		if request == nil {
+			request = bc.newFetchRequest(isolation)
+			byIsolation[isolation] = request
+		}
+		request.AddBlock(child.topic, child.partition, child.offset, child.fetchSize)
+	}
+	if len(byIsolation) == 0 {
+		return []*FetchRequest{bc.newFetchRequest(bc.consumer.conf.Consumer.IsolationLevel)}
+	}
+
+	var requests []*FetchRequest
+	for _, isolation := range []IsolationLevel{ReadUncommitted, ReadCommitted} {
+		if request, ok := byIsolation[isolation]; ok {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// newFetchRequest returns a fetch request with the given isolation level,
+// without any partition yet.
+func (bc *brokerConsumer) newFetchRequest(isolation IsolationLevel) *FetchRequest {
 	request := &FetchRequest{
-		MinBytes:    bc.consumer.conf.Consumer.Fetch.Min,
-		MaxWaitTime: int32(bc.consumer.conf.Consumer.MaxWaitTime / time.Millisecond),
+		MinBytes:     bc.consumer.conf.Consumer.Fetch.Min,
+		MaxWaitTime:  int32(bc.consumer.conf.Consumer.MaxWaitTime / time.Millisecond),
+		deferRecords: bc.consumer.conf.Consumer.Fetch.LazyBatchDecoding,
 	}
 	if bc.consumer.conf.Version.IsAtLeast(V0_9_0_0) {
 		request.Version = 1
@@ -1067,7 +1434,7 @@ func (bc *brokerConsumer) fetchNewMessages() (*FetchResponse, error) {
 	}
 	if bc.consumer.conf.Version.IsAtLeast(V0_11_0_0) {
 		request.Version = 4
-		request.Isolation = bc.consumer.conf.Consumer.IsolationLevel
+		request.Isolation = isolation
 	}
 	if bc.consumer.conf.Version.IsAtLeast(V1_1_0_0) {
 		request.Version = 7
@@ -1084,12 +1451,35 @@ func (bc *brokerConsumer) fetchNewMessages() (*FetchResponse, error) {
 		request.Version = 11
 		request.RackID = bc.consumer.conf.RackID
 	}
-
-	for child := range bc.subscriptions {
-		if !child.IsPaused() {
-			request.AddBlock(child.topic, child.partition, child.offset, child.fetchSize)
-		}
+	if bc.consumer.conf.Version.IsAtLeast(V2_7_0_0) {
+		request.Version = 12
 	}
+	if bc.consumer.conf.Version.IsAtLeast(V3_1_0_0) {
+		request.Version = 13
+	}
+
+	return request
+}
 
+// fetch sends the fetch request once its topic IDs are resolved.
+func (bc *brokerConsumer) fetch(request *FetchRequest) (*FetchResponse, error) {
+	bc.resolveTopicIDs(request)
 	return bc.broker.Fetch(request)
 }
+
+// resolveTopicIDs addresses the topics of the request by ID from version 13,
+// or falls back to version 12 if the ID of a topic is not known.
+func (bc *brokerConsumer) resolveTopicIDs(request *FetchRequest) {
+	if request.Version >= 13 {
+		request.TopicIDs = make(map[string]Uuid, len(request.blocks))
+		for topic := range request.blocks {
+			id, err := bc.consumer.client.TopicID(topic)
+			if err != nil || id == NullUuid {
+				request.Version = 12
+				request.TopicIDs = nil
+				break
+			}
+			request.TopicIDs[topic] = id
+		}
+	}
+}
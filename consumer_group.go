@@ -7,8 +7,6 @@ import (
 	"sort"
 	"sync"
 	"time"
-
-	"github.com/rcrowley/go-metrics"
 )
 
 // ErrClosedConsumerGroup is the error returned when a method is called on a consumer group that has been closed.
@@ -122,14 +120,18 @@ func newConsumerGroup(groupID string, client Client) (ConsumerGroup, error) {
 		return nil, ConfigurationError("consumer groups require Version to be >= V0_10_2_0")
 	}
 
-	consumer, err := NewConsumerFromClient(client)
+	cons, err := NewConsumerFromClient(client)
 	if err != nil {
 		return nil, err
 	}
+	c := cons.(*consumer)
+	c.lock.Lock()
+	c.groupID = groupID
+	c.lock.Unlock()
 
 	return &consumerGroup{
 		client:   client,
-		consumer: consumer,
+		consumer: cons,
 		config:   config,
 		groupID:  groupID,
 		errors:   make(chan error, config.ChannelBufferSize),
@@ -159,6 +161,9 @@ func (c *consumerGroup) Close() (err error) {
 			err = e
 		}
 
+		// only unregisters the stats of the consumer, which does not own
+		// the client
+		_ = c.consumer.Close()
 		if e := c.client.Close(); e != nil {
 			err = e
 		}
@@ -232,7 +237,7 @@ func (c *consumerGroup) retryNewSession(ctx context.Context, topics []string, ha
 	select {
 	case <-c.closed:
 		return nil, ErrClosedConsumerGroup
-	case <-time.After(c.config.Consumer.Group.Rebalance.Retry.Backoff):
+	case <-c.config.Clock.After(c.config.Consumer.Group.Rebalance.Retry.Backoff):
 	}
 
 	if refreshCoordinator {
@@ -256,36 +261,24 @@ func (c *consumerGroup) newSession(ctx context.Context, topics []string, handler
 	}
 
 	var (
-		metricRegistry          = c.config.MetricRegistry
-		consumerGroupJoinTotal  metrics.Counter
-		consumerGroupJoinFailed metrics.Counter
-		consumerGroupSyncTotal  metrics.Counter
-		consumerGroupSyncFailed metrics.Counter
+		recorder                = c.config.metricsRecorder()
+		groupLabels             = MetricLabels{"group": c.groupID}
+		consumerGroupJoinTotal  = recorder.Counter("consumer-group-join-total", groupLabels)
+		consumerGroupJoinFailed = recorder.Counter("consumer-group-join-failed", groupLabels)
+		consumerGroupSyncTotal  = recorder.Counter("consumer-group-sync-total", groupLabels)
+		consumerGroupSyncFailed = recorder.Counter("consumer-group-sync-failed", groupLabels)
 	)
 
-	if metricRegistry != nil {
-		consumerGroupJoinTotal = metrics.GetOrRegisterCounter(fmt.Sprintf("consumer-group-join-total-%s", c.groupID), metricRegistry)
-		consumerGroupJoinFailed = metrics.GetOrRegisterCounter(fmt.Sprintf("consumer-group-join-failed-%s", c.groupID), metricRegistry)
-		consumerGroupSyncTotal = metrics.GetOrRegisterCounter(fmt.Sprintf("consumer-group-sync-total-%s", c.groupID), metricRegistry)
-		consumerGroupSyncFailed = metrics.GetOrRegisterCounter(fmt.Sprintf("consumer-group-sync-failed-%s", c.groupID), metricRegistry)
-	}
-
 	// Join consumer group
 	join, err := c.joinGroupRequest(coordinator, topics)
-	if consumerGroupJoinTotal != nil {
-		consumerGroupJoinTotal.Inc(1)
-	}
+	consumerGroupJoinTotal.Add(1)
 	if err != nil {
 		_ = coordinator.Close()
-		if consumerGroupJoinFailed != nil {
-			consumerGroupJoinFailed.Inc(1)
-		}
+		consumerGroupJoinFailed.Add(1)
 		return nil, err
 	}
 	if !errors.Is(join.Err, ErrNoError) {
-		if consumerGroupJoinFailed != nil {
-			consumerGroupJoinFailed.Inc(1)
-		}
+		consumerGroupJoinFailed.Add(1)
 	}
 	switch join.Err {
 	case ErrNoError:
@@ -325,20 +318,14 @@ func (c *consumerGroup) newSession(ctx context.Context, topics []string, handler
 
 	// Sync consumer group
 	groupRequest, err := c.syncGroupRequest(coordinator, plan, join.GenerationId)
-	if consumerGroupSyncTotal != nil {
-		consumerGroupSyncTotal.Inc(1)
-	}
+	consumerGroupSyncTotal.Add(1)
 	if err != nil {
 		_ = coordinator.Close()
-		if consumerGroupSyncFailed != nil {
-			consumerGroupSyncFailed.Inc(1)
-		}
+		consumerGroupSyncFailed.Add(1)
 		return nil, err
 	}
 	if !errors.Is(groupRequest.Err, ErrNoError) {
-		if consumerGroupSyncFailed != nil {
-			consumerGroupSyncFailed.Inc(1)
-		}
+		consumerGroupSyncFailed.Add(1)
 	}
 
 	switch groupRequest.Err {
@@ -508,7 +495,11 @@ func (c *consumerGroup) handleError(err error, topic string, partition int32) {
 	}
 
 	if !c.config.Consumer.Return.Errors {
-		Logger.Println(err)
+		log := newContextLogger("group", c.groupID)
+		if topic != "" && partition > -1 {
+			log = log.with("topic", topic, "partition", partition)
+		}
+		log.Errorf("%v", err)
 		return
 	}
 
@@ -527,7 +518,7 @@ func (c *consumerGroup) handleError(err error, topic string, partition int32) {
 }
 
 func (c *consumerGroup) loopCheckPartitionNumbers(topics []string, session *consumerGroupSession) {
-	pause := time.NewTicker(c.config.Metadata.RefreshFrequency)
+	pause := c.config.Clock.NewTicker(c.config.Metadata.RefreshFrequency)
 	defer session.cancel()
 	defer pause.Stop()
 	var oldTopicToPartitionNum map[string]int
@@ -546,10 +537,10 @@ func (c *consumerGroup) loopCheckPartitionNumbers(topics []string, session *cons
 			}
 		}
 		select {
-		case <-pause.C:
+		case <-pause.C():
 		case <-session.ctx.Done():
-			Logger.Printf(
-				"consumergroup/%s loop check partition number coroutine will exit, topics %s\n",
+			newContextLogger("group", c.groupID).Debugf(
+				"consumergroup/%s loop check partition number coroutine will exit, topics %s",
 				c.groupID, topics)
 			// if session closed by other, should be exited
 			return
@@ -563,9 +554,9 @@ func (c *consumerGroup) topicToPartitionNumbers(topics []string) (map[string]int
 	topicToPartitionNum := make(map[string]int, len(topics))
 	for _, topic := range topics {
 		if partitionNum, err := c.client.Partitions(topic); err != nil {
-			Logger.Printf(
-				"consumergroup/%s topic %s get partition number failed %v\n",
-				c.groupID, err)
+			newContextLogger("group", c.groupID, "topic", topic).Warnf(
+				"consumergroup/%s topic %s get partition number failed %v",
+				c.groupID, topic, err)
 			return nil, err
 		} else {
 			topicToPartitionNum[topic] = len(partitionNum)
@@ -787,6 +778,11 @@ func (s *consumerGroupSession) consume(topic string, partition int32) {
 	}
 }
 
+// log returns the logger of the lines about the session
+func (s *consumerGroupSession) log() contextLogger {
+	return newContextLogger("group", s.parent.groupID, "member", s.MemberID(), "generation", s.GenerationID())
+}
+
 func (s *consumerGroupSession) release(withCleanup bool) (err error) {
 	// signal release, stop heartbeat
 	s.cancel()
@@ -811,8 +807,8 @@ func (s *consumerGroupSession) release(withCleanup bool) (err error) {
 		<-s.hbDead
 	})
 
-	Logger.Printf(
-		"consumergroup/session/%s/%d released\n",
+	s.log().Infof(
+		"consumergroup/session/%s/%d released",
 		s.MemberID(), s.GenerationID())
 
 	return
@@ -822,15 +818,15 @@ func (s *consumerGroupSession) heartbeatLoop() {
 	defer close(s.hbDead)
 	defer s.cancel() // trigger the end of the session on exit
 	defer func() {
-		Logger.Printf(
-			"consumergroup/session/%s/%d heartbeat loop stopped\n",
+		s.log().Infof(
+			"consumergroup/session/%s/%d heartbeat loop stopped",
 			s.MemberID(), s.GenerationID())
 	}()
 
-	pause := time.NewTicker(s.parent.config.Consumer.Group.Heartbeat.Interval)
+	pause := s.parent.config.Clock.NewTicker(s.parent.config.Consumer.Group.Heartbeat.Interval)
 	defer pause.Stop()
 
-	retryBackoff := time.NewTimer(s.parent.config.Metadata.Retry.Backoff)
+	retryBackoff := s.parent.config.Clock.NewTimer(s.parent.config.Metadata.Retry.Backoff)
 	defer retryBackoff.Stop()
 
 	retries := s.parent.config.Metadata.Retry.Max
@@ -845,7 +841,7 @@ func (s *consumerGroupSession) heartbeatLoop() {
 			select {
 			case <-s.hbDying:
 				return
-			case <-retryBackoff.C:
+			case <-retryBackoff.C():
 				retries--
 			}
 			continue
@@ -878,7 +874,7 @@ func (s *consumerGroupSession) heartbeatLoop() {
 		}
 
 		select {
-		case <-pause.C:
+		case <-pause.C():
 		case <-s.hbDying:
 			return
 		}
@@ -0,0 +1,64 @@
+package sarama
+
+// ConsumerGroupDescribeRequest is a request to describe groups using the
+// consumer group protocol of KIP-848
+type ConsumerGroupDescribeRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	GroupIDs                    []string
+	IncludeAuthorizedOperations bool
+}
+
+func (r *ConsumerGroupDescribeRequest) encode(pe packetEncoder) error {
+	pe.putCompactArrayLength(len(r.GroupIDs))
+	for _, id := range r.GroupIDs {
+		if err := pe.putCompactString(id); err != nil {
+			return err
+		}
+	}
+	pe.putBool(r.IncludeAuthorizedOperations)
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ConsumerGroupDescribeRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.GroupIDs = make([]string, n)
+		for i := 0; i < n; i++ {
+			if r.GroupIDs[i], err = pd.getCompactString(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.IncludeAuthorizedOperations, err = pd.getBool(); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *ConsumerGroupDescribeRequest) key() int16 {
+	return 69
+}
+
+func (r *ConsumerGroupDescribeRequest) version() int16 {
+	return r.Version
+}
+
+func (r *ConsumerGroupDescribeRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *ConsumerGroupDescribeRequest) requiredVersion() KafkaVersion {
+	return V3_7_0_0
+}
@@ -0,0 +1,20 @@
+package sarama
+
+import "testing"
+
+var consumerGroupDescribeRequest = []byte{
+	3,           // group IDs array length 2
+	3, 'g', '1', // group ID
+	3, 'g', '2', // group ID
+	1, // include authorized operations
+	0, // empty tagged fields
+}
+
+func TestConsumerGroupDescribeRequest(t *testing.T) {
+	request := &ConsumerGroupDescribeRequest{
+		Version:                     0,
+		GroupIDs:                    []string{"g1", "g2"},
+		IncludeAuthorizedOperations: true,
+	}
+	testRequest(t, "basic", request, consumerGroupDescribeRequest)
+}
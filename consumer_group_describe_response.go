@@ -0,0 +1,327 @@
+package sarama
+
+import "time"
+
+// ConsumerGroupDescribeResponse is the response to a ConsumerGroupDescribeRequest
+type ConsumerGroupDescribeResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime time.Duration
+	Groups       []*ConsumerGroupDescribeGroup
+}
+
+// ConsumerGroupDescribeGroup describes a group using the consumer group
+// protocol of KIP-848
+type ConsumerGroupDescribeGroup struct {
+	ErrorCode    KError
+	ErrorMessage *string
+	GroupID      string
+	GroupState   string
+	// GroupEpoch is the epoch of the group membership and subscriptions
+	GroupEpoch int32
+	// AssignmentEpoch is the epoch of the target assignment
+	AssignmentEpoch int32
+	AssignorName    string
+	Members         []*ConsumerGroupDescribeMember
+	// AuthorizedOperations is a 32-bit bitfield of the AclOperations
+	// authorized for the group, or math.MinInt32 if they were not requested
+	AuthorizedOperations int32
+}
+
+// ConsumerGroupDescribeMember is a member of a ConsumerGroupDescribeGroup
+type ConsumerGroupDescribeMember struct {
+	MemberID             string
+	InstanceID           *string
+	RackID               *string
+	MemberEpoch          int32
+	ClientID             string
+	ClientHost           string
+	SubscribedTopicNames []string
+	SubscribedTopicRegex *string
+	// Assignment contains the partitions currently assigned to the member
+	Assignment []*ConsumerGroupDescribeTopicPartitions
+	// TargetAssignment contains the partitions the member is converging to
+	TargetAssignment []*ConsumerGroupDescribeTopicPartitions
+}
+
+// ConsumerGroupDescribeTopicPartitions are the assigned partitions of a topic
+type ConsumerGroupDescribeTopicPartitions struct {
+	TopicID    Uuid
+	TopicName  string
+	Partitions []int32
+}
+
+func (r *ConsumerGroupDescribeResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+
+	pe.putCompactArrayLength(len(r.Groups))
+	for _, g := range r.Groups {
+		if err := g.encode(pe); err != nil {
+			return err
+		}
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ConsumerGroupDescribeResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.Groups = make([]*ConsumerGroupDescribeGroup, n)
+		for i := 0; i < n; i++ {
+			r.Groups[i] = &ConsumerGroupDescribeGroup{}
+			if err := r.Groups[i].decode(pd); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (g *ConsumerGroupDescribeGroup) encode(pe packetEncoder) error {
+	pe.putInt16(int16(g.ErrorCode))
+	if err := pe.putNullableCompactString(g.ErrorMessage); err != nil {
+		return err
+	}
+	if err := pe.putCompactString(g.GroupID); err != nil {
+		return err
+	}
+	if err := pe.putCompactString(g.GroupState); err != nil {
+		return err
+	}
+	pe.putInt32(g.GroupEpoch)
+	pe.putInt32(g.AssignmentEpoch)
+	if err := pe.putCompactString(g.AssignorName); err != nil {
+		return err
+	}
+
+	pe.putCompactArrayLength(len(g.Members))
+	for _, m := range g.Members {
+		if err := m.encode(pe); err != nil {
+			return err
+		}
+	}
+
+	pe.putInt32(g.AuthorizedOperations)
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (g *ConsumerGroupDescribeGroup) decode(pd packetDecoder) (err error) {
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	g.ErrorCode = KError(kerr)
+
+	if g.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if g.GroupID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if g.GroupState, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if g.GroupEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if g.AssignmentEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if g.AssignorName, err = pd.getCompactString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		g.Members = make([]*ConsumerGroupDescribeMember, n)
+		for i := 0; i < n; i++ {
+			g.Members[i] = &ConsumerGroupDescribeMember{}
+			if err := g.Members[i].decode(pd); err != nil {
+				return err
+			}
+		}
+	}
+
+	if g.AuthorizedOperations, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (m *ConsumerGroupDescribeMember) encode(pe packetEncoder) error {
+	if err := pe.putCompactString(m.MemberID); err != nil {
+		return err
+	}
+	if err := pe.putNullableCompactString(m.InstanceID); err != nil {
+		return err
+	}
+	if err := pe.putNullableCompactString(m.RackID); err != nil {
+		return err
+	}
+	pe.putInt32(m.MemberEpoch)
+	if err := pe.putCompactString(m.ClientID); err != nil {
+		return err
+	}
+	if err := pe.putCompactString(m.ClientHost); err != nil {
+		return err
+	}
+
+	pe.putCompactArrayLength(len(m.SubscribedTopicNames))
+	for _, topic := range m.SubscribedTopicNames {
+		if err := pe.putCompactString(topic); err != nil {
+			return err
+		}
+	}
+	if err := pe.putNullableCompactString(m.SubscribedTopicRegex); err != nil {
+		return err
+	}
+
+	if err := encodeConsumerGroupDescribeAssignment(pe, m.Assignment); err != nil {
+		return err
+	}
+	if err := encodeConsumerGroupDescribeAssignment(pe, m.TargetAssignment); err != nil {
+		return err
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (m *ConsumerGroupDescribeMember) decode(pd packetDecoder) (err error) {
+	if m.MemberID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if m.InstanceID, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if m.RackID, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if m.MemberEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if m.ClientID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if m.ClientHost, err = pd.getCompactString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		m.SubscribedTopicNames = make([]string, n)
+		for i := 0; i < n; i++ {
+			if m.SubscribedTopicNames[i], err = pd.getCompactString(); err != nil {
+				return err
+			}
+		}
+	}
+	if m.SubscribedTopicRegex, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+
+	if m.Assignment, err = decodeConsumerGroupDescribeAssignment(pd); err != nil {
+		return err
+	}
+	if m.TargetAssignment, err = decodeConsumerGroupDescribeAssignment(pd); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func encodeConsumerGroupDescribeAssignment(pe packetEncoder, assignment []*ConsumerGroupDescribeTopicPartitions) error {
+	pe.putCompactArrayLength(len(assignment))
+	for _, tp := range assignment {
+		if err := putUuid(pe, tp.TopicID); err != nil {
+			return err
+		}
+		if err := pe.putCompactString(tp.TopicName); err != nil {
+			return err
+		}
+		partitions := tp.Partitions
+		if partitions == nil {
+			partitions = []int32{}
+		}
+		if err := pe.putCompactInt32Array(partitions); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func decodeConsumerGroupDescribeAssignment(pd packetDecoder) ([]*ConsumerGroupDescribeTopicPartitions, error) {
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return nil, err
+	}
+	var assignment []*ConsumerGroupDescribeTopicPartitions
+	if n > 0 {
+		assignment = make([]*ConsumerGroupDescribeTopicPartitions, n)
+		for i := 0; i < n; i++ {
+			tp := &ConsumerGroupDescribeTopicPartitions{}
+			if tp.TopicID, err = getUuid(pd); err != nil {
+				return nil, err
+			}
+			if tp.TopicName, err = pd.getCompactString(); err != nil {
+				return nil, err
+			}
+			if tp.Partitions, err = pd.getCompactInt32Array(); err != nil {
+				return nil, err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return nil, err
+			}
+			assignment[i] = tp
+		}
+	}
+	if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+func (r *ConsumerGroupDescribeResponse) key() int16 {
+	return 69
+}
+
+func (r *ConsumerGroupDescribeResponse) version() int16 {
+	return r.Version
+}
+
+func (r *ConsumerGroupDescribeResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *ConsumerGroupDescribeResponse) requiredVersion() KafkaVersion {
+	return V3_7_0_0
+}
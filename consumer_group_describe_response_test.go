@@ -0,0 +1,85 @@
+package sarama
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var (
+	consumerGroupDescribeResponseEmpty = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		1, // empty groups
+		0, // empty tagged fields
+	}
+
+	consumerGroupDescribeResponseGroup = []byte{
+		0, 0, 0, 0, // throttle time
+		2,    // groups array length 1
+		0, 0, // no error code
+		0,           // null error message
+		3, 'g', '1', // group ID
+		7, 'S', 't', 'a', 'b', 'l', 'e', // group state
+		0, 0, 0, 5, // group epoch
+		0, 0, 0, 4, // assignment epoch
+		6, 'r', 'a', 'n', 'g', 'e', // assignor name
+		2,           // members array length 1
+		3, 'm', '1', // member ID
+		0,           // null instance ID
+		3, 'r', '1', // rack ID
+		0, 0, 0, 5, // member epoch
+		3, 'c', '1', // client ID
+		5, '/', '1', '.', '2', // client host
+		2,                          // subscribed topic names array length 1
+		6, 't', 'o', 'p', 'i', 'c', // topic
+		0,                                                     // null subscribed topic regex
+		2,                                                     // assignment topic partitions array length 1
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, // topic ID
+		6, 't', 'o', 'p', 'i', 'c', // topic name
+		2, 0, 0, 0, 0, // partitions
+		0,            // empty tagged fields
+		0,            // empty tagged fields
+		1,            // empty target assignment topic partitions
+		0,            // empty tagged fields
+		0,            // empty tagged fields
+		128, 0, 0, 0, // authorized operations
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+)
+
+func TestConsumerGroupDescribeResponse(t *testing.T) {
+	response := &ConsumerGroupDescribeResponse{
+		Version:      0,
+		ThrottleTime: 100 * time.Millisecond,
+	}
+	testResponse(t, "empty", response, consumerGroupDescribeResponseEmpty)
+
+	rack := "r1"
+	response = &ConsumerGroupDescribeResponse{
+		Version: 0,
+		Groups: []*ConsumerGroupDescribeGroup{{
+			ErrorCode:       ErrNoError,
+			GroupID:         "g1",
+			GroupState:      "Stable",
+			GroupEpoch:      5,
+			AssignmentEpoch: 4,
+			AssignorName:    "range",
+			Members: []*ConsumerGroupDescribeMember{{
+				MemberID:             "m1",
+				RackID:               &rack,
+				MemberEpoch:          5,
+				ClientID:             "c1",
+				ClientHost:           "/1.2",
+				SubscribedTopicNames: []string{"topic"},
+				Assignment: []*ConsumerGroupDescribeTopicPartitions{{
+					TopicID:    Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+					TopicName:  "topic",
+					Partitions: []int32{0},
+				}},
+			}},
+			AuthorizedOperations: math.MinInt32,
+		}},
+	}
+	testResponse(t, "with group", response, consumerGroupDescribeResponseGroup)
+}
@@ -556,6 +556,51 @@ func TestConsumerShutsDownOutOfRange(t *testing.T) {
 	broker0.Close()
 }
 
+func TestConsumerPartitionRequestError(t *testing.T) {
+	// Given
+	broker0 := NewMockBroker(t, 0)
+	fetchResponse := new(FetchResponse)
+	fetchResponse.AddError("my_topic", 0, ErrOffsetOutOfRange)
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetNewest, 1234).
+			SetOffset("my_topic", 0, OffsetOldest, 7),
+		"FetchRequest": NewMockWrapper(fetchResponse),
+	})
+
+	config := NewTestConfig()
+	config.Consumer.Return.Errors = true
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// When
+	consumer, err := master.ConsumePartition("my_topic", 0, 101)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Then: the error identifies the partition and the fetch request
+	cErr := <-consumer.Errors()
+	var partitionErr *PartitionRequestError
+	if !errors.As(cErr, &partitionErr) || !errors.Is(cErr, ErrOffsetOutOfRange) {
+		t.Fatalf("Expected a PartitionRequestError wrapping ErrOffsetOutOfRange, got %v", cErr.Err)
+	}
+	// the fetch follows the offset requests on the connection to the leader
+	if partitionErr.Topic != "my_topic" || partitionErr.Partition != 0 ||
+		partitionErr.BrokerID != broker0.BrokerID() || partitionErr.CorrelationID == 0 {
+		t.Errorf("Unexpected coordinates of the error: %+v", partitionErr)
+	}
+	safeClose(t, consumer)
+
+	safeClose(t, master)
+	broker0.Close()
+}
+
 // If a fetch response contains messages with offsets that are smaller then
 // requested, then such messages are ignored.
 func TestConsumerExtraOffsets(t *testing.T) {
@@ -1819,6 +1864,137 @@ func TestExcludeUncommitted(t *testing.T) {
 	broker0.Close()
 }
 
+// When Return.ControlRecords is enabled, the transaction markers are returned
+// among the messages with their decoded control record
+func TestConsumerReturnsControlRecords(t *testing.T) {
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	fetchResponse := &FetchResponse{Version: 4}
+	fetchResponse.AddRecordBatch("my_topic", 0, nil, testMsg, 1234, 7, true)
+	fetchResponse.AddControlRecord("my_topic", 0, 1235, 7, ControlRecordCommit)
+	fetchResponse.AddRecordBatch("my_topic", 0, nil, testMsg, 1236, 8, true)
+	fetchResponse.AddControlRecord("my_topic", 0, 1237, 8, ControlRecordAbort)
+
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 1238),
+		"FetchRequest": NewMockWrapper(fetchResponse),
+	})
+
+	cfg := NewTestConfig()
+	cfg.Consumer.Return.Errors = true
+	cfg.Consumer.Return.ControlRecords = true
+	cfg.Version = V0_11_0_0
+
+	master, err := NewConsumer([]string{broker0.Addr()}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+
+	consumer, err := master.ConsumePartition("my_topic", 0, 1234)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, consumer)
+
+	expected := []*ControlRecord{
+		nil,
+		{Type: ControlRecordCommit, ProducerID: 7},
+		nil,
+		{Type: ControlRecordAbort, ProducerID: 8},
+	}
+	for i, controlRecord := range expected {
+		select {
+		case message := <-consumer.Messages():
+			assertMessageOffset(t, message, int64(1234+i))
+			if controlRecord == nil {
+				if message.ControlRecord != nil {
+					t.Errorf("Unexpected control record %+v at offset %d", message.ControlRecord, message.Offset)
+				}
+			} else if message.ControlRecord == nil || *message.ControlRecord != *controlRecord {
+				t.Errorf("Expected control record %+v at offset %d, got %+v", controlRecord, message.Offset, message.ControlRecord)
+			}
+		case err := <-consumer.Errors():
+			t.Fatal(err)
+		}
+	}
+}
+
+// When Fetch.LazyBatchDecoding is enabled, the batches are decoded as their
+// messages are delivered, filtering the aborted transactions the same way
+func TestConsumerLazyBatchDecoding(t *testing.T) {
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	fetchResponse := &FetchResponse{
+		Version: 4,
+		Blocks: map[string]map[int32]*FetchResponseBlock{"my_topic": {0: {
+			AbortedTransactions: []*AbortedTransaction{{ProducerID: 7, FirstOffset: 1235}},
+		}}},
+	}
+	fetchResponse.AddRecordBatch("my_topic", 0, nil, testMsg, 1234, 7, true)   // committed msg
+	fetchResponse.AddRecordBatch("my_topic", 0, nil, testMsg, 1235, 7, true)   // uncommitted msg
+	fetchResponse.AddControlRecord("my_topic", 0, 1236, 7, ControlRecordAbort) // abort control record
+	compressed := newDefaultRecords(NewRecordBatchBuilder(1237).
+		SetCompression(CompressionGZIP, CompressionLevelDefault).
+		AddRecord(nil, []byte("foo"), nil, time.Now()).
+		AddRecord(nil, []byte("bar"), nil, time.Now()).
+		Build())
+	block := fetchResponse.GetBlock("my_topic", 0)
+	block.RecordsSet = append(block.RecordsSet, &compressed)
+
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 1239),
+		"FetchRequest": NewMockWrapper(fetchResponse),
+	})
+
+	cfg := NewTestConfig()
+	cfg.Consumer.Return.Errors = true
+	cfg.Consumer.Fetch.LazyBatchDecoding = true
+	cfg.Consumer.IsolationLevel = ReadCommitted
+	cfg.Version = V0_11_0_0
+
+	master, err := NewConsumer([]string{broker0.Addr()}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+
+	consumer, err := master.ConsumePartition("my_topic", 0, 1234)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, consumer)
+
+	for _, expected := range []struct {
+		offset int64
+		value  string
+	}{{1234, string(testMsg)}, {1237, "foo"}, {1238, "bar"}} {
+		select {
+		case message := <-consumer.Messages():
+			assertMessageOffset(t, message, expected.offset)
+			if string(message.Value) != expected.value {
+				t.Errorf("Unexpected value %q at offset %d", message.Value, message.Offset)
+			}
+		case err := <-consumer.Errors():
+			t.Fatal(err)
+		}
+	}
+}
+
 func assertMessageOffset(t *testing.T, msg *ConsumerMessage, expectedOffset int64) {
 	t.Helper()
 	if msg.Offset != expectedOffset {
@@ -1950,6 +2126,75 @@ func Test_partitionConsumer_parseResponseEmptyBatch(t *testing.T) {
 	}
 }
 
+func TestConsumerLagMetric(t *testing.T) {
+	for group, name := range map[string]string{
+		"":         "consumer-lag-for-topic-my_topic-for-partition-3",
+		"my-group": "consumer-lag-for-topic-my_topic-for-partition-3-my-group",
+	} {
+		conf := NewTestConfig()
+		child := &partitionConsumer{
+			highWaterMarkOffset: 25,
+			consumer:            &consumer{conf: conf, groupID: group},
+			conf:                conf,
+			topic:               "my_topic",
+			partition:           3,
+			offset:              10,
+		}
+		validate := func(lag int) {
+			t.Helper()
+			validators := newMetricValidators()
+			validators.register(counterValidator(name, lag))
+			validators.run(t, conf.MetricRegistry)
+		}
+
+		child.registerLag()
+		validate(15)
+
+		response := &FetchResponse{Version: 4}
+		response.AddRecord("my_topic", 3, nil, StringEncoder("foo"), 10)
+		response.GetBlock("my_topic", 3).HighWaterMarkOffset = 30
+		if _, err := child.parseResponse(response); err != nil {
+			t.Fatal(err)
+		}
+		validate(20)
+
+		child.delivered(&ConsumerMessage{Offset: 10})
+		validate(19)
+
+		child.position = 31
+		child.updateLag()
+		validate(0)
+
+		child.unregisterLag()
+		if conf.MetricRegistry.Get(name) != nil {
+			t.Errorf("Expected %s to be unregistered", name)
+		}
+	}
+}
+
+func TestConsumerEndToEndLatencyMetric(t *testing.T) {
+	now := time.Now()
+	conf := NewTestConfig()
+	conf.Clock = NewMockClock(now)
+	child := &partitionConsumer{
+		consumer:     &consumer{conf: conf},
+		conf:         conf,
+		topic:        "my_topic",
+		latency:      conf.metricsRecorder().Histogram("end-to-end-latency-in-ms", nil),
+		topicLatency: conf.metricsRecorder().Histogram("end-to-end-latency-in-ms", topicMetricLabels("my_topic")),
+	}
+
+	child.delivered(&ConsumerMessage{Offset: 0, Timestamp: now.Add(-250 * time.Millisecond)})
+	child.delivered(&ConsumerMessage{Offset: 1, Timestamp: now.Add(-50 * time.Millisecond)})
+	// without timestamp, as before Kafka v0.10
+	child.delivered(&ConsumerMessage{Offset: 2})
+
+	validators := newMetricValidators()
+	validators.registerForGlobalAndTopic("my_topic", countHistogramValidator("end-to-end-latency-in-ms", 2))
+	validators.registerForGlobalAndTopic("my_topic", minMaxHistogramValidator("end-to-end-latency-in-ms", 50, 250))
+	validators.run(t, conf.MetricRegistry)
+}
+
 func testConsumerInterceptor(
 	t *testing.T,
 	interceptors []ConsumerInterceptor,
@@ -2069,3 +2314,110 @@ func TestConsumerError(t *testing.T) {
 		t.Error("unexpected errors.Is")
 	}
 }
+
+// Fetch requests address the topics by ID once the brokers support it.
+func TestConsumerFetchTopicIDs(t *testing.T) {
+	id := Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	broker0 := NewMockBroker(t, 0)
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()).
+			SetTopicID("my_topic", id),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("my_topic", 0, OffsetNewest, 1).
+			SetOffset("my_topic", 0, OffsetOldest, 0),
+		"FetchRequest": NewMockFetchResponse(t, 1).
+			SetVersion(13).
+			SetTopicID("my_topic", id).
+			SetMessage("my_topic", 0, 0, testMsg),
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_1_0_0
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consumer, err := master.ConsumePartition("my_topic", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMessageOffset(t, <-consumer.Messages(), 0)
+
+	safeClose(t, consumer)
+	safeClose(t, master)
+	broker0.Close()
+
+	for _, rr := range broker0.History() {
+		if req, ok := rr.Request.(*FetchRequest); ok {
+			if req.Version != 13 || req.TopicIDs[id.String()] != id {
+				t.Errorf("Expected a FetchRequest v13 addressing the topic by ID, got v%d %v", req.Version, req.TopicIDs)
+			}
+		}
+	}
+}
+
+func TestConsumerReadCommittedWithMockFetchRecords(t *testing.T) {
+	timestamp := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("my_topic", 0, OffsetNewest, 5).
+			SetOffset("my_topic", 0, OffsetOldest, 0),
+		"FetchRequest": NewMockFetchResponse(t, 5).
+			SetVersion(4).
+			SetRecord("my_topic", 0, 0, &MockFetchRecord{Value: StringEncoder("aborted"), Timestamp: timestamp, ProducerID: 7, IsTransactional: true}).
+			SetControlRecord("my_topic", 0, 1, 7, ControlRecordAbort, timestamp).
+			SetRecord("my_topic", 0, 2, &MockFetchRecord{
+				Key:       StringEncoder("key"),
+				Value:     StringEncoder("value"),
+				Headers:   []*RecordHeader{{Key: []byte("header"), Value: []byte("1")}},
+				Timestamp: timestamp,
+			}).
+			SetRecord("my_topic", 0, 3, &MockFetchRecord{Value: StringEncoder("committed"), Timestamp: timestamp, ProducerID: 8, IsTransactional: true}).
+			SetControlRecord("my_topic", 0, 4, 8, ControlRecordCommit, timestamp).
+			SetAbortedTransaction("my_topic", 0, 7, 0).
+			SetHighWaterMark("my_topic", 0, 5),
+	})
+
+	cfg := NewTestConfig()
+	cfg.Version = V0_11_0_0
+	cfg.Consumer.IsolationLevel = ReadCommitted
+	master, err := NewConsumer([]string{broker0.Addr()}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+	consumer, err := master.ConsumePartition("my_topic", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, consumer)
+
+	msg := <-consumer.Messages()
+	assertMessageOffset(t, msg, 2)
+	if string(msg.Key) != "key" || string(msg.Value) != "value" {
+		t.Errorf("unexpected message %q: %q", msg.Key, msg.Value)
+	}
+	if len(msg.Headers) != 1 || string(msg.Headers[0].Key) != "header" || string(msg.Headers[0].Value) != "1" {
+		t.Errorf("unexpected headers %v", msg.Headers)
+	}
+	if !msg.Timestamp.Equal(timestamp) {
+		t.Errorf("expected timestamp %v, got %v", timestamp, msg.Timestamp)
+	}
+
+	msg = <-consumer.Messages()
+	assertMessageOffset(t, msg, 3)
+	if string(msg.Value) != "committed" {
+		t.Errorf("unexpected message value %q", msg.Value)
+	}
+}
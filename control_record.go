@@ -19,6 +19,10 @@ type ControlRecord struct {
 	Version          int16
 	CoordinatorEpoch int32
 	Type             ControlRecordType
+	// ProducerID and ProducerEpoch identify the producer whose transaction
+	// the record ends, they are taken from the batch of the record
+	ProducerID    int64
+	ProducerEpoch int16
 }
 
 func (cr *ControlRecord) decode(key, value packetDecoder) error {
@@ -30,6 +30,9 @@ func releaseCrc32Field(c *crc32Field) {
 	crc32FieldPool.Put(c)
 }
 
+// castagnoliTable is the table of hash/crc32, which computes the checksums
+// with the CRC32 instructions of SSE 4.2 on amd64 and of ARMv8 on arm64, or
+// with vector instructions on s390x and ppc64le, when they are available.
 var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
 
 // crc32Field implements the pushEncoder and pushDecoder interfaces for calculating CRC32s.
@@ -0,0 +1,41 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordBatchSkipCRC(t *testing.T) {
+	batch := &RecordBatch{
+		Version:        2,
+		FirstTimestamp: time.Unix(1479847795, 0),
+		MaxTimestamp:   time.Unix(0, 0),
+		Records:        []*Record{{Value: []byte(TestMessage)}},
+	}
+	buf, err := encode(batch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// corrupt the CRC following the offset, length, leader epoch and magic
+	buf[8+4+4+1] ^= 0xff
+
+	if err := (&RecordBatch{}).decode(&realDecoder{raw: buf}); err == nil {
+		t.Error("expected the corrupt CRC to fail the decoding")
+	}
+	decoded := &RecordBatch{}
+	if err := decoded.decode(&realDecoder{raw: buf, opts: decodeOptions{skipCRC: true}}); err != nil {
+		t.Error(err)
+	}
+	if len(decoded.Records) != 1 || string(decoded.Records[0].Value) != TestMessage {
+		t.Errorf("unexpected decoded batch %+v", decoded)
+	}
+}
+
+func BenchmarkCRC32Castagnoli(b *testing.B) {
+	buf := make([]byte, 1<<20)
+	field := newCRC32Field(crcCastagnoli)
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		_, _ = field.crc(len(buf), buf)
+	}
+}
@@ -0,0 +1,111 @@
+package sarama
+
+import "time"
+
+// DelegationTokenPrincipal is a Kafka principal owning or allowed to renew a
+// delegation token
+type DelegationTokenPrincipal struct {
+	Type string
+	Name string
+}
+
+func (p *DelegationTokenPrincipal) encode(pe packetEncoder) error {
+	if err := pe.putString(p.Type); err != nil {
+		return err
+	}
+	return pe.putString(p.Name)
+}
+
+func (p *DelegationTokenPrincipal) decode(pd packetDecoder) (err error) {
+	if p.Type, err = pd.getString(); err != nil {
+		return err
+	}
+	p.Name, err = pd.getString()
+	return err
+}
+
+// CreateDelegationTokenRequest is a request to create a delegation token for
+// the authenticated principal (KIP-48)
+type CreateDelegationTokenRequest struct {
+	// Version 0 and 1 are equal
+	// The version number is bumped to indicate that on quota violation brokers send out responses before throttling.
+	Version int16
+
+	// Renewers are the principals allowed to renew the token besides its owner
+	Renewers []DelegationTokenPrincipal
+
+	// MaxLifetime is the maximum lifetime of the token, -1 to use the
+	// delegation.token.max.lifetime.ms of the broker
+	MaxLifetime time.Duration
+}
+
+func (r *CreateDelegationTokenRequest) encode(pe packetEncoder) error {
+	if err := pe.putArrayLength(len(r.Renewers)); err != nil {
+		return err
+	}
+	for _, renewer := range r.Renewers {
+		if err := renewer.encode(pe); err != nil {
+			return err
+		}
+	}
+
+	pe.putInt64(durationToMillis(r.MaxLifetime))
+	return nil
+}
+
+func (r *CreateDelegationTokenRequest) decode(pd packetDecoder, version int16) error {
+	r.Version = version
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Renewers = make([]DelegationTokenPrincipal, n)
+	for i := 0; i < n; i++ {
+		if err := r.Renewers[i].decode(pd); err != nil {
+			return err
+		}
+	}
+
+	maxLifetime, err := pd.getInt64()
+	if err != nil {
+		return err
+	}
+	r.MaxLifetime = millisToDuration(maxLifetime)
+	return nil
+}
+
+func (r *CreateDelegationTokenRequest) key() int16 {
+	return 38
+}
+
+func (r *CreateDelegationTokenRequest) version() int16 {
+	return r.Version
+}
+
+func (r *CreateDelegationTokenRequest) headerVersion() int16 {
+	return 1
+}
+
+func (r *CreateDelegationTokenRequest) requiredVersion() KafkaVersion {
+	if r.Version > 0 {
+		return V2_0_0_0
+	}
+	return V1_1_0_0
+}
+
+// durationToMillis converts a duration to milliseconds, keeping negative
+// durations as -1 which Kafka uses to denote a default or unset duration
+func durationToMillis(d time.Duration) int64 {
+	if d < 0 {
+		return -1
+	}
+	return int64(d / time.Millisecond)
+}
+
+func millisToDuration(millis int64) time.Duration {
+	if millis < 0 {
+		return -1
+	}
+	return time.Duration(millis) * time.Millisecond
+}
@@ -0,0 +1,36 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	createDelegationTokenRequestNoRenewers = []byte{
+		0, 0, 0, 0, // renewers array length 0
+		255, 255, 255, 255, 255, 255, 255, 255, // max lifetime (-1)
+	}
+
+	createDelegationTokenRequestRenewers = []byte{
+		0, 0, 0, 1, // renewers array length 1
+		0, 4, 'U', 's', 'e', 'r', // principal type
+		0, 5, 'a', 'l', 'i', 'c', 'e', // principal name
+		0, 0, 0, 0, 5, 38, 92, 0, // max lifetime (1 day)
+	}
+)
+
+func TestCreateDelegationTokenRequest(t *testing.T) {
+	request := &CreateDelegationTokenRequest{
+		Version:     1,
+		Renewers:    []DelegationTokenPrincipal{},
+		MaxLifetime: -1,
+	}
+	testRequest(t, "no renewers", request, createDelegationTokenRequestNoRenewers)
+
+	request = &CreateDelegationTokenRequest{
+		Version:     1,
+		Renewers:    []DelegationTokenPrincipal{{Type: "User", Name: "alice"}},
+		MaxLifetime: 24 * time.Hour,
+	}
+	testRequest(t, "with renewers", request, createDelegationTokenRequestRenewers)
+}
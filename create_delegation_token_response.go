@@ -0,0 +1,114 @@
+package sarama
+
+import "time"
+
+// DelegationToken describes a delegation token
+type DelegationToken struct {
+	Owner      DelegationTokenPrincipal
+	IssueTime  time.Time
+	ExpiryTime time.Time
+	MaxTime    time.Time
+	TokenID    string
+	HMAC       []byte
+	// Renewers is only populated by DescribeDelegationToken
+	Renewers []DelegationTokenPrincipal
+}
+
+func (t *DelegationToken) encode(pe packetEncoder) error {
+	if err := t.Owner.encode(pe); err != nil {
+		return err
+	}
+	if err := (Timestamp{&t.IssueTime}).encode(pe); err != nil {
+		return err
+	}
+	if err := (Timestamp{&t.ExpiryTime}).encode(pe); err != nil {
+		return err
+	}
+	if err := (Timestamp{&t.MaxTime}).encode(pe); err != nil {
+		return err
+	}
+	if err := pe.putString(t.TokenID); err != nil {
+		return err
+	}
+	return pe.putBytes(t.HMAC)
+}
+
+func (t *DelegationToken) decode(pd packetDecoder) (err error) {
+	if err = t.Owner.decode(pd); err != nil {
+		return err
+	}
+	if err = (Timestamp{&t.IssueTime}).decode(pd); err != nil {
+		return err
+	}
+	if err = (Timestamp{&t.ExpiryTime}).decode(pd); err != nil {
+		return err
+	}
+	if err = (Timestamp{&t.MaxTime}).decode(pd); err != nil {
+		return err
+	}
+	if t.TokenID, err = pd.getString(); err != nil {
+		return err
+	}
+	t.HMAC, err = pd.getBytes()
+	return err
+}
+
+// CreateDelegationTokenResponse is the response to a CreateDelegationTokenRequest
+type CreateDelegationTokenResponse struct {
+	// Version 0 and 1 are equal
+	// The version number is bumped to indicate that on quota violation brokers send out responses before throttling.
+	Version int16
+
+	ErrorCode    KError
+	Token        DelegationToken
+	ThrottleTime time.Duration
+}
+
+func (r *CreateDelegationTokenResponse) encode(pe packetEncoder) error {
+	pe.putInt16(int16(r.ErrorCode))
+	if err := r.Token.encode(pe); err != nil {
+		return err
+	}
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	return nil
+}
+
+func (r *CreateDelegationTokenResponse) decode(pd packetDecoder, version int16) error {
+	r.Version = version
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.ErrorCode = KError(kerr)
+
+	if err := r.Token.decode(pd); err != nil {
+		return err
+	}
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+	return nil
+}
+
+func (r *CreateDelegationTokenResponse) key() int16 {
+	return 38
+}
+
+func (r *CreateDelegationTokenResponse) version() int16 {
+	return r.Version
+}
+
+func (r *CreateDelegationTokenResponse) headerVersion() int16 {
+	return 0
+}
+
+func (r *CreateDelegationTokenResponse) requiredVersion() KafkaVersion {
+	if r.Version > 0 {
+		return V2_0_0_0
+	}
+	return V1_1_0_0
+}
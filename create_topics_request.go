@@ -79,6 +79,22 @@ func (c *CreateTopicsRequest) version() int16 {
 	return c.Version
 }
 
+func (c *CreateTopicsRequest) versionRange() (minVersion, maxVersion int16) {
+	if c.Version >= 1 && c.ValidateOnly {
+		return 1, 2
+	}
+	return 0, 2
+}
+
+func (c *CreateTopicsRequest) withVersion(v int16) protocolBody {
+	request := *c
+	request.Version = v
+	if c.Version < 1 {
+		request.ValidateOnly = false
+	}
+	return &request
+}
+
 func (r *CreateTopicsRequest) headerVersion() int16 {
 	return 1
 }
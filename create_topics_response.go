@@ -70,6 +70,10 @@ func (c *CreateTopicsResponse) version() int16 {
 	return c.Version
 }
 
+func (c *CreateTopicsResponse) setVersion(v int16) {
+	c.Version = v
+}
+
 func (c *CreateTopicsResponse) headerVersion() int16 {
 	return 0
 }
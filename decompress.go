@@ -3,14 +3,21 @@ package sarama
 import (
 	"bytes"
 	"compress/gzip"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
 
 	snappy "github.com/eapache/go-xerial-snappy"
+	rawsnappy "github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4"
 )
 
+// xerialHeader starts the snappy data framed by xerial
+var xerialHeader = []byte{130, 83, 78, 65, 80, 80, 89, 0}
+
 var (
 	lz4ReaderPool = sync.Pool{
 		New: func() interface{} {
@@ -21,7 +28,9 @@ var (
 	gzipReaderPool sync.Pool
 )
 
-func decompress(cc CompressionCodec, data []byte) ([]byte, error) {
+// decompress decompresses the data, failing with a PacketDecodingError once
+// more than limit bytes are decompressed when limit is positive
+func decompress(cc CompressionCodec, data []byte, limit int) ([]byte, error) {
 	switch cc {
 	case CompressionNone:
 		return data, nil
@@ -40,8 +49,15 @@ func decompress(cc CompressionCodec, data []byte) ([]byte, error) {
 
 		defer gzipReaderPool.Put(reader)
 
-		return io.ReadAll(reader)
+		return readAllLimited(cc, reader, limit)
 	case CompressionSnappy:
+		if limit > 0 {
+			if size, err := snappyDecodedLen(data); err != nil {
+				return nil, err
+			} else if size > limit {
+				return nil, errDecompressedSize(cc, limit)
+			}
+		}
 		return snappy.Decode(data)
 	case CompressionLZ4:
 		reader, ok := lz4ReaderPool.Get().(*lz4.Reader)
@@ -52,10 +68,72 @@ func decompress(cc CompressionCodec, data []byte) ([]byte, error) {
 		}
 		defer lz4ReaderPool.Put(reader)
 
-		return io.ReadAll(reader)
+		return readAllLimited(cc, reader, limit)
 	case CompressionZSTD:
-		return zstdDecompress(ZstdDecoderParams{}, nil, data)
+		var params ZstdDecoderParams
+		if limit > 0 {
+			params.MaxDecodedSize = uint64(limit)
+		}
+		decompressed, err := zstdDecompress(params, nil, data)
+		// the window of the frames is limited as well
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+			return nil, errDecompressedSize(cc, limit)
+		}
+		return decompressed, err
 	default:
+		if impl, ok := registeredCompressionCodec(cc); ok {
+			decompressed, err := impl.decode(data)
+			if err == nil && limit > 0 && len(decompressed) > limit {
+				return nil, errDecompressedSize(cc, limit)
+			}
+			return decompressed, err
+		}
 		return nil, PacketDecodingError{fmt.Sprintf("invalid compression specified (%d)", cc)}
 	}
 }
+
+func errDecompressedSize(cc CompressionCodec, limit int) error {
+	return PacketDecodingError{fmt.Sprintf("%s decompressed data exceeds the limit of %d bytes", cc, limit)}
+}
+
+// readAllLimited reads the decompressed data, at most limit bytes when limit
+// is positive
+func readAllLimited(cc CompressionCodec, reader io.Reader, limit int) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(reader)
+	}
+	decompressed, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decompressed) > limit {
+		return nil, errDecompressedSize(cc, limit)
+	}
+	return decompressed, nil
+}
+
+// snappyDecodedLen returns the size of the snappy data once decompressed,
+// which is either a raw snappy block or chunks framed by xerial
+func snappyDecodedLen(data []byte) (int, error) {
+	if !bytes.HasPrefix(data, xerialHeader) {
+		return rawsnappy.DecodedLen(data)
+	}
+	size := 0
+	for pos := 16; pos < len(data); {
+		if pos+4 > len(data) {
+			return 0, snappy.ErrMalformed
+		}
+		chunkLen := int(binary.BigEndian.Uint32(data[pos:]))
+		pos += 4
+		if chunkLen < 0 || pos+chunkLen > len(data) {
+			return 0, snappy.ErrMalformed
+		}
+		chunkSize, err := rawsnappy.DecodedLen(data[pos : pos+chunkLen])
+		if err != nil {
+			return 0, err
+		}
+		size += chunkSize
+		pos += chunkLen
+	}
+	return size, nil
+}
@@ -0,0 +1,60 @@
+package sarama
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	snappy "github.com/eapache/go-xerial-snappy"
+)
+
+func TestDecompressLimit(t *testing.T) {
+	data := bytes.Repeat([]byte(TestMessage), 1000)
+
+	for _, codec := range []CompressionCodec{CompressionGZIP, CompressionSnappy, CompressionLZ4, CompressionZSTD} {
+		compressed, err := compress(codec, CompressionLevelDefault, 0, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decompressed, err := decompress(codec, compressed, len(data)); err != nil || !bytes.Equal(decompressed, data) {
+			t.Errorf("%s: expected the data to be decompressed within the limit, got %v", codec, err)
+		}
+		_, err = decompress(codec, compressed, len(data)-1)
+		var decodingErr PacketDecodingError
+		if !errors.As(err, &decodingErr) {
+			t.Errorf("%s: expected a PacketDecodingError exceeding the limit, got %v", codec, err)
+		}
+	}
+
+	// snappy data framed by xerial
+	compressed := snappy.EncodeStream(nil, data)
+	if _, err := decompress(CompressionSnappy, compressed, len(data)); err != nil {
+		t.Error(err)
+	}
+	if _, err := decompress(CompressionSnappy, compressed, len(data)-1); err == nil {
+		t.Error("expected xerial framed snappy data to exceed the limit")
+	}
+}
+
+func TestRecordBatchDecompressLimit(t *testing.T) {
+	batch := &RecordBatch{
+		Version:          2,
+		Codec:            CompressionGZIP,
+		CompressionLevel: CompressionLevelDefault,
+		FirstTimestamp:   time.Unix(1479847795, 0),
+		MaxTimestamp:     time.Unix(0, 0),
+		Records:          []*Record{{Value: bytes.Repeat([]byte(TestMessage), 100)}},
+	}
+	buf, err := encode(batch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&RecordBatch{}).decode(&realDecoder{raw: buf, opts: decodeOptions{maxDecompressedBytes: 100}}); err == nil {
+		t.Error("expected the batch to exceed the limit")
+	}
+	if err := (&RecordBatch{}).decode(&realDecoder{raw: buf, opts: decodeOptions{maxDecompressedBytes: 10000}}); err != nil {
+		t.Error(err)
+	}
+}
@@ -5,28 +5,113 @@ import "time"
 type DeleteTopicsRequest struct {
 	Version int16
 	Topics  []string
-	Timeout time.Duration
+	// TopicIDs contains the IDs of topics to delete (v6 or later)
+	TopicIDs []Uuid
+	Timeout  time.Duration
 }
 
 func (d *DeleteTopicsRequest) encode(pe packetEncoder) error {
-	if err := pe.putStringArray(d.Topics); err != nil {
-		return err
+	if d.Version < 6 && len(d.TopicIDs) > 0 {
+		return PacketEncodingError{"DeleteTopicsRequest TopicIDs require version 6 or later"}
+	}
+
+	switch {
+	case d.Version >= 6:
+		pe.putCompactArrayLength(len(d.Topics) + len(d.TopicIDs))
+		for _, topic := range d.Topics {
+			if err := pe.putCompactString(topic); err != nil {
+				return err
+			}
+			if err := putUuid(pe, NullUuid); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		}
+		for _, id := range d.TopicIDs {
+			if err := pe.putNullableCompactString(nil); err != nil {
+				return err
+			}
+			if err := putUuid(pe, id); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		}
+	case d.Version >= 4:
+		pe.putCompactArrayLength(len(d.Topics))
+		for _, topic := range d.Topics {
+			if err := pe.putCompactString(topic); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := pe.putStringArray(d.Topics); err != nil {
+			return err
+		}
 	}
 	pe.putInt32(int32(d.Timeout / time.Millisecond))
 
+	if d.Version >= 4 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
 func (d *DeleteTopicsRequest) decode(pd packetDecoder, version int16) (err error) {
-	if d.Topics, err = pd.getStringArray(); err != nil {
-		return err
+	d.Version = version
+
+	switch {
+	case version >= 6:
+		n, err := pd.getCompactArrayLength()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			name, err := pd.getCompactNullableString()
+			if err != nil {
+				return err
+			}
+			id, err := getUuid(pd)
+			if err != nil {
+				return err
+			}
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			if name != nil {
+				d.Topics = append(d.Topics, *name)
+			} else {
+				d.TopicIDs = append(d.TopicIDs, id)
+			}
+		}
+	case version >= 4:
+		n, err := pd.getCompactArrayLength()
+		if err != nil {
+			return err
+		}
+		d.Topics = make([]string, n)
+		for i := 0; i < n; i++ {
+			if d.Topics[i], err = pd.getCompactString(); err != nil {
+				return err
+			}
+		}
+	default:
+		if d.Topics, err = pd.getStringArray(); err != nil {
+			return err
+		}
 	}
+
 	timeout, err := pd.getInt32()
 	if err != nil {
 		return err
 	}
 	d.Timeout = time.Duration(timeout) * time.Millisecond
-	d.Version = version
+
+	if version >= 4 {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -38,7 +123,23 @@ func (d *DeleteTopicsRequest) version() int16 {
 	return d.Version
 }
 
+func (d *DeleteTopicsRequest) versionRange() (minVersion, maxVersion int16) {
+	if len(d.TopicIDs) > 0 {
+		return 6, 6
+	}
+	return 0, 6
+}
+
+func (d *DeleteTopicsRequest) withVersion(v int16) protocolBody {
+	request := *d
+	request.Version = v
+	return &request
+}
+
 func (d *DeleteTopicsRequest) headerVersion() int16 {
+	if d.Version >= 4 {
+		return 2
+	}
 	return 1
 }
 
@@ -46,6 +147,16 @@ func (d *DeleteTopicsRequest) requiredVersion() KafkaVersion {
 	switch d.Version {
 	case 1:
 		return V0_11_0_0
+	case 2:
+		return V2_1_0_0
+	case 3:
+		return V2_3_0_0
+	case 4:
+		return V2_4_0_0
+	case 5:
+		return V2_6_0_0
+	case 6:
+		return V2_8_0_0
 	default:
 		return V0_10_1_0
 	}
@@ -31,3 +31,49 @@ func TestDeleteTopicsRequestV1(t *testing.T) {
 
 	testRequest(t, "", req, deleteTopicsRequest)
 }
+
+var deleteTopicsRequestV4 = []byte{
+	3,
+	6, 't', 'o', 'p', 'i', 'c',
+	6, 'o', 't', 'h', 'e', 'r',
+	0, 0, 0, 100,
+	0, // empty tagged fields
+}
+
+func TestDeleteTopicsRequestV4(t *testing.T) {
+	req := &DeleteTopicsRequest{
+		Version: 4,
+		Topics:  []string{"topic", "other"},
+		Timeout: 100 * time.Millisecond,
+	}
+
+	testRequest(t, "", req, deleteTopicsRequestV4)
+}
+
+var deleteTopicsRequestV6 = []byte{
+	3,
+	6, 't', 'o', 'p', 'i', 'c',
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // null topic id
+	0, // empty tagged fields
+	0, // null name
+	1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+	0, // empty tagged fields
+	0, 0, 0, 100,
+	0, // empty tagged fields
+}
+
+func TestDeleteTopicsRequestV6(t *testing.T) {
+	req := &DeleteTopicsRequest{
+		Version:  6,
+		Topics:   []string{"topic"},
+		TopicIDs: []Uuid{{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}},
+		Timeout:  100 * time.Millisecond,
+	}
+
+	testRequest(t, "", req, deleteTopicsRequestV6)
+
+	req.Version = 5
+	if _, err := encode(req, nil); err == nil {
+		t.Error("Expected an error encoding TopicIDs with version 5")
+	}
+}
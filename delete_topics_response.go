@@ -6,55 +6,166 @@ type DeleteTopicsResponse struct {
 	Version         int16
 	ThrottleTime    time.Duration
 	TopicErrorCodes map[string]KError
+	// TopicErrorMessages contains the error messages of the named topics (v5 or later)
+	TopicErrorMessages map[string]*string
+	// TopicIDs contains the IDs of the named topics (v6 or later)
+	TopicIDs map[string]Uuid
+	// TopicIDErrorCodes contains the results for topic IDs that could not be
+	// resolved to a name (v6 or later)
+	TopicIDErrorCodes map[Uuid]KError
 }
 
 func (d *DeleteTopicsResponse) encode(pe packetEncoder) error {
+	isFlexible := d.Version >= 4
+
 	if d.Version >= 1 {
 		pe.putInt32(int32(d.ThrottleTime / time.Millisecond))
 	}
 
-	if err := pe.putArrayLength(len(d.TopicErrorCodes)); err != nil {
+	if isFlexible {
+		pe.putCompactArrayLength(len(d.TopicErrorCodes) + len(d.TopicIDErrorCodes))
+	} else if err := pe.putArrayLength(len(d.TopicErrorCodes)); err != nil {
 		return err
 	}
 	for topic, errorCode := range d.TopicErrorCodes {
-		if err := pe.putString(topic); err != nil {
+		var err error
+		if isFlexible {
+			err = pe.putCompactString(topic)
+		} else {
+			err = pe.putString(topic)
+		}
+		if err != nil {
 			return err
 		}
+		if d.Version >= 6 {
+			if err := putUuid(pe, d.TopicIDs[topic]); err != nil {
+				return err
+			}
+		}
 		pe.putInt16(int16(errorCode))
+		if d.Version >= 5 {
+			if err := pe.putNullableCompactString(d.TopicErrorMessages[topic]); err != nil {
+				return err
+			}
+		}
+		if isFlexible {
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+	if d.Version >= 6 {
+		for id, errorCode := range d.TopicIDErrorCodes {
+			if err := pe.putNullableCompactString(nil); err != nil {
+				return err
+			}
+			if err := putUuid(pe, id); err != nil {
+				return err
+			}
+			pe.putInt16(int16(errorCode))
+			if err := pe.putNullableCompactString(nil); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
 	}
 
 	return nil
 }
 
 func (d *DeleteTopicsResponse) decode(pd packetDecoder, version int16) (err error) {
+	d.Version = version
+	isFlexible := version >= 4
+
 	if version >= 1 {
 		throttleTime, err := pd.getInt32()
 		if err != nil {
 			return err
 		}
 		d.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
-
-		d.Version = version
 	}
 
-	n, err := pd.getArrayLength()
+	var n int
+	if isFlexible {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
 
 	d.TopicErrorCodes = make(map[string]KError, n)
+	if version >= 5 {
+		d.TopicErrorMessages = make(map[string]*string, n)
+	}
+	if version >= 6 {
+		d.TopicIDs = make(map[string]Uuid, n)
+		d.TopicIDErrorCodes = make(map[Uuid]KError)
+	}
 
 	for i := 0; i < n; i++ {
-		topic, err := pd.getString()
+		var topic *string
+		switch {
+		case version >= 6:
+			topic, err = pd.getCompactNullableString()
+		case isFlexible:
+			var name string
+			name, err = pd.getCompactString()
+			topic = &name
+		default:
+			var name string
+			name, err = pd.getString()
+			topic = &name
+		}
 		if err != nil {
 			return err
 		}
+
+		id := NullUuid
+		if version >= 6 {
+			if id, err = getUuid(pd); err != nil {
+				return err
+			}
+		}
+
 		errorCode, err := pd.getInt16()
 		if err != nil {
 			return err
 		}
 
-		d.TopicErrorCodes[topic] = KError(errorCode)
+		var errorMessage *string
+		if version >= 5 {
+			if errorMessage, err = pd.getCompactNullableString(); err != nil {
+				return err
+			}
+		}
+
+		if isFlexible {
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+
+		if topic == nil {
+			d.TopicIDErrorCodes[id] = KError(errorCode)
+			continue
+		}
+		d.TopicErrorCodes[*topic] = KError(errorCode)
+		if version >= 5 {
+			d.TopicErrorMessages[*topic] = errorMessage
+		}
+		if version >= 6 {
+			d.TopicIDs[*topic] = id
+		}
+	}
+
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -68,7 +179,14 @@ func (d *DeleteTopicsResponse) version() int16 {
 	return d.Version
 }
 
+func (d *DeleteTopicsResponse) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DeleteTopicsResponse) headerVersion() int16 {
+	if d.Version >= 4 {
+		return 1
+	}
 	return 0
 }
 
@@ -76,6 +194,16 @@ func (d *DeleteTopicsResponse) requiredVersion() KafkaVersion {
 	switch d.Version {
 	case 1:
 		return V0_11_0_0
+	case 2:
+		return V2_1_0_0
+	case 3:
+		return V2_3_0_0
+	case 4:
+		return V2_4_0_0
+	case 5:
+		return V2_6_0_0
+	case 6:
+		return V2_8_0_0
 	default:
 		return V0_10_1_0
 	}
@@ -34,3 +34,68 @@ func TestDeleteTopicsResponse(t *testing.T) {
 
 	testResponse(t, "version 1", resp, deleteTopicsResponseV1)
 }
+
+var (
+	deleteTopicsResponseV5 = []byte{
+		0, 0, 0, 100,
+		2,
+		6, 't', 'o', 'p', 'i', 'c',
+		0, 41, // ErrNotController
+		5, 'o', 'o', 'p', 's',
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+
+	deleteTopicsResponseV6 = []byte{
+		0, 0, 0, 100,
+		3,
+		6, 't', 'o', 'p', 'i', 'c',
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+		0, 0,
+		0, // null error message
+		0, // empty tagged fields
+		0, // null name
+		16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1,
+		0, 100, // ErrUnknownTopicID
+		0, // null error message
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+)
+
+func TestDeleteTopicsResponseV5(t *testing.T) {
+	message := "oops"
+	resp := &DeleteTopicsResponse{
+		Version:      5,
+		ThrottleTime: 100 * time.Millisecond,
+		TopicErrorCodes: map[string]KError{
+			"topic": ErrNotController,
+		},
+		TopicErrorMessages: map[string]*string{
+			"topic": &message,
+		},
+	}
+
+	testResponse(t, "version 5", resp, deleteTopicsResponseV5)
+}
+
+func TestDeleteTopicsResponseV6(t *testing.T) {
+	resp := &DeleteTopicsResponse{
+		Version:      6,
+		ThrottleTime: 100 * time.Millisecond,
+		TopicErrorCodes: map[string]KError{
+			"topic": ErrNoError,
+		},
+		TopicErrorMessages: map[string]*string{
+			"topic": nil,
+		},
+		TopicIDs: map[string]Uuid{
+			"topic": {1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		},
+		TopicIDErrorCodes: map[Uuid]KError{
+			{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}: ErrUnknownTopicID,
+		},
+	}
+
+	testResponse(t, "version 6", resp, deleteTopicsResponseV6)
+}
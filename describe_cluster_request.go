@@ -0,0 +1,43 @@
+package sarama
+
+// DescribeClusterRequest is a request to describe the cluster (KIP-700)
+type DescribeClusterRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	// IncludeClusterAuthorizedOperations indicates whether to include the
+	// authorized operations for the cluster in the response
+	IncludeClusterAuthorizedOperations bool
+}
+
+func (r *DescribeClusterRequest) encode(pe packetEncoder) error {
+	pe.putBool(r.IncludeClusterAuthorizedOperations)
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *DescribeClusterRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+	if r.IncludeClusterAuthorizedOperations, err = pd.getBool(); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *DescribeClusterRequest) key() int16 {
+	return 60
+}
+
+func (r *DescribeClusterRequest) version() int16 {
+	return r.Version
+}
+
+func (r *DescribeClusterRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *DescribeClusterRequest) requiredVersion() KafkaVersion {
+	return V2_8_0_0
+}
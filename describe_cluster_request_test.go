@@ -0,0 +1,25 @@
+package sarama
+
+import "testing"
+
+var (
+	describeClusterRequestNoOperations = []byte{
+		0, // IncludeClusterAuthorizedOperations
+		0, // empty tagged fields
+	}
+
+	describeClusterRequestWithOperations = []byte{
+		1, // IncludeClusterAuthorizedOperations
+		0, // empty tagged fields
+	}
+)
+
+func TestDescribeClusterRequest(t *testing.T) {
+	request := &DescribeClusterRequest{
+		Version: 0,
+	}
+	testRequest(t, "no authorized operations", request, describeClusterRequestNoOperations)
+
+	request.IncludeClusterAuthorizedOperations = true
+	testRequest(t, "with authorized operations", request, describeClusterRequestWithOperations)
+}
@@ -0,0 +1,152 @@
+package sarama
+
+import "time"
+
+// DescribeClusterResponse is the response to a DescribeClusterRequest (KIP-700)
+type DescribeClusterResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime time.Duration
+
+	ErrorCode    KError
+	ErrorMessage *string
+
+	ClusterID    string
+	ControllerID int32
+	Brokers      []*DescribeClusterBroker
+
+	// ClusterAuthorizedOperations is a 32-bit bitfield of the AclOperations
+	// authorized for the cluster, or math.MinInt32 if they were not requested
+	ClusterAuthorizedOperations int32
+}
+
+// ClusterDescription is the result of ClusterAdmin.DescribeClusterDetails
+type ClusterDescription struct {
+	ClusterID    string
+	ControllerID int32
+	Brokers      []*Broker
+
+	// AuthorizedOperations is a 32-bit bitfield of the AclOperations
+	// authorized for the cluster, or math.MinInt32 if they were not requested
+	AuthorizedOperations int32
+}
+
+// DescribeClusterBroker is a single broker entry of a DescribeClusterResponse
+type DescribeClusterBroker struct {
+	BrokerID int32
+	Host     string
+	Port     int32
+	Rack     *string
+}
+
+func (r *DescribeClusterResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+
+	pe.putInt16(int16(r.ErrorCode))
+	if err := pe.putNullableCompactString(r.ErrorMessage); err != nil {
+		return err
+	}
+
+	if err := pe.putCompactString(r.ClusterID); err != nil {
+		return err
+	}
+	pe.putInt32(r.ControllerID)
+
+	pe.putCompactArrayLength(len(r.Brokers))
+	for _, b := range r.Brokers {
+		pe.putInt32(b.BrokerID)
+		if err := pe.putCompactString(b.Host); err != nil {
+			return err
+		}
+		pe.putInt32(b.Port)
+		if err := pe.putNullableCompactString(b.Rack); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putInt32(r.ClusterAuthorizedOperations)
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *DescribeClusterResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.ErrorCode = KError(kerr)
+
+	if r.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+
+	if r.ClusterID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+
+	if r.ControllerID, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	numBrokers, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+
+	if numBrokers > 0 {
+		r.Brokers = make([]*DescribeClusterBroker, numBrokers)
+		for i := 0; i < numBrokers; i++ {
+			b := &DescribeClusterBroker{}
+			if b.BrokerID, err = pd.getInt32(); err != nil {
+				return err
+			}
+			if b.Host, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if b.Port, err = pd.getInt32(); err != nil {
+				return err
+			}
+			if b.Rack, err = pd.getCompactNullableString(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			r.Brokers[i] = b
+		}
+	}
+
+	if r.ClusterAuthorizedOperations, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *DescribeClusterResponse) key() int16 {
+	return 60
+}
+
+func (r *DescribeClusterResponse) version() int16 {
+	return r.Version
+}
+
+func (r *DescribeClusterResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *DescribeClusterResponse) requiredVersion() KafkaVersion {
+	return V2_8_0_0
+}
@@ -0,0 +1,64 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	describeClusterResponseEmpty = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		0, 0, // no error code
+		0,                // no error message
+		4, 'f', 'o', 'o', // cluster ID
+		0, 0, 0, 1, // controller ID
+		1,            // empty brokers array
+		128, 0, 0, 0, // cluster authorized operations (not requested)
+		0, // empty tagged fields
+	}
+
+	describeClusterResponseBrokers = []byte{
+		0, 0, 0, 0, // throttle time
+		0, 0, // no error code
+		0,                // no error message
+		4, 'f', 'o', 'o', // cluster ID
+		0, 0, 0, 2, // controller ID
+		3,          // brokers array length 2
+		0, 0, 0, 1, // broker ID
+		10, 'l', 'o', 'c', 'a', 'l', 'h', 'o', 's', 't', // host
+		0, 0, 35, 132, // port (9092)
+		3, 'r', '1', // rack
+		0,          // empty tagged fields
+		0, 0, 0, 2, // broker ID
+		10, 'l', 'o', 'c', 'a', 'l', 'h', 'o', 's', 't', // host
+		0, 0, 35, 133, // port (9093)
+		0,          // no rack
+		0,          // empty tagged fields
+		0, 0, 9, 0, // cluster authorized operations (describe, alter configs)
+		0, // empty tagged fields
+	}
+)
+
+func TestDescribeClusterResponse(t *testing.T) {
+	response := &DescribeClusterResponse{
+		Version:                     0,
+		ThrottleTime:                100 * time.Millisecond,
+		ClusterID:                   "foo",
+		ControllerID:                1,
+		ClusterAuthorizedOperations: -2147483648,
+	}
+	testResponse(t, "empty", response, describeClusterResponseEmpty)
+
+	rack := "r1"
+	response = &DescribeClusterResponse{
+		Version:      0,
+		ClusterID:    "foo",
+		ControllerID: 2,
+		Brokers: []*DescribeClusterBroker{
+			{BrokerID: 1, Host: "localhost", Port: 9092, Rack: &rack},
+			{BrokerID: 2, Host: "localhost", Port: 9093},
+		},
+		ClusterAuthorizedOperations: 1<<AclOperationDescribe | 1<<AclOperationAlterConfigs,
+	}
+	testResponse(t, "brokers", response, describeClusterResponseBrokers)
+}
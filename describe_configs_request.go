@@ -99,6 +99,22 @@ func (r *DescribeConfigsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *DescribeConfigsRequest) versionRange() (minVersion, maxVersion int16) {
+	if r.Version >= 1 && r.IncludeSynonyms {
+		return 1, 2
+	}
+	return 0, 2
+}
+
+func (r *DescribeConfigsRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	if r.Version < 1 {
+		request.IncludeSynonyms = false
+	}
+	return &request
+}
+
 func (r *DescribeConfigsRequest) headerVersion() int16 {
 	return 1
 }
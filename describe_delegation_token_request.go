@@ -0,0 +1,68 @@
+package sarama
+
+// DescribeDelegationTokenRequest is a request to describe delegation tokens
+type DescribeDelegationTokenRequest struct {
+	// Version 0 and 1 are equal
+	// The version number is bumped to indicate that on quota violation brokers send out responses before throttling.
+	Version int16
+
+	// Owners restricts the tokens described to those owned by the given
+	// principals, a nil slice describes all tokens the client may describe
+	Owners []DelegationTokenPrincipal
+}
+
+func (r *DescribeDelegationTokenRequest) encode(pe packetEncoder) error {
+	if r.Owners == nil {
+		pe.putInt32(-1)
+		return nil
+	}
+
+	if err := pe.putArrayLength(len(r.Owners)); err != nil {
+		return err
+	}
+	for _, owner := range r.Owners {
+		if err := owner.encode(pe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *DescribeDelegationTokenRequest) decode(pd packetDecoder, version int16) error {
+	r.Version = version
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return nil
+	}
+
+	r.Owners = make([]DelegationTokenPrincipal, n)
+	for i := 0; i < n; i++ {
+		if err := r.Owners[i].decode(pd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *DescribeDelegationTokenRequest) key() int16 {
+	return 41
+}
+
+func (r *DescribeDelegationTokenRequest) version() int16 {
+	return r.Version
+}
+
+func (r *DescribeDelegationTokenRequest) headerVersion() int16 {
+	return 1
+}
+
+func (r *DescribeDelegationTokenRequest) requiredVersion() KafkaVersion {
+	if r.Version > 0 {
+		return V2_0_0_0
+	}
+	return V1_1_0_0
+}
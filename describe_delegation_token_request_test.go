@@ -0,0 +1,25 @@
+package sarama
+
+import "testing"
+
+var (
+	describeDelegationTokenRequestAll = []byte{
+		255, 255, 255, 255, // null owners array
+	}
+
+	describeDelegationTokenRequestOwners = []byte{
+		0, 0, 0, 1, // owners array length 1
+		0, 4, 'U', 's', 'e', 'r', // principal type
+		0, 5, 'a', 'l', 'i', 'c', 'e', // principal name
+	}
+)
+
+func TestDescribeDelegationTokenRequest(t *testing.T) {
+	request := &DescribeDelegationTokenRequest{
+		Version: 1,
+	}
+	testRequest(t, "all tokens", request, describeDelegationTokenRequestAll)
+
+	request.Owners = []DelegationTokenPrincipal{{Type: "User", Name: "alice"}}
+	testRequest(t, "with owners", request, describeDelegationTokenRequestOwners)
+}
@@ -0,0 +1,100 @@
+package sarama
+
+import "time"
+
+// DescribeDelegationTokenResponse is the response to a DescribeDelegationTokenRequest
+type DescribeDelegationTokenResponse struct {
+	// Version 0 and 1 are equal
+	// The version number is bumped to indicate that on quota violation brokers send out responses before throttling.
+	Version int16
+
+	ErrorCode    KError
+	Tokens       []*DelegationToken
+	ThrottleTime time.Duration
+}
+
+func (r *DescribeDelegationTokenResponse) encode(pe packetEncoder) error {
+	pe.putInt16(int16(r.ErrorCode))
+
+	if err := pe.putArrayLength(len(r.Tokens)); err != nil {
+		return err
+	}
+	for _, token := range r.Tokens {
+		if err := token.encode(pe); err != nil {
+			return err
+		}
+		if err := pe.putArrayLength(len(token.Renewers)); err != nil {
+			return err
+		}
+		for _, renewer := range token.Renewers {
+			if err := renewer.encode(pe); err != nil {
+				return err
+			}
+		}
+	}
+
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	return nil
+}
+
+func (r *DescribeDelegationTokenResponse) decode(pd packetDecoder, version int16) error {
+	r.Version = version
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.ErrorCode = KError(kerr)
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.Tokens = make([]*DelegationToken, n)
+		for i := 0; i < n; i++ {
+			token := &DelegationToken{}
+			if err := token.decode(pd); err != nil {
+				return err
+			}
+
+			m, err := pd.getArrayLength()
+			if err != nil {
+				return err
+			}
+			token.Renewers = make([]DelegationTokenPrincipal, m)
+			for j := 0; j < m; j++ {
+				if err := token.Renewers[j].decode(pd); err != nil {
+					return err
+				}
+			}
+			r.Tokens[i] = token
+		}
+	}
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+	return nil
+}
+
+func (r *DescribeDelegationTokenResponse) key() int16 {
+	return 41
+}
+
+func (r *DescribeDelegationTokenResponse) version() int16 {
+	return r.Version
+}
+
+func (r *DescribeDelegationTokenResponse) headerVersion() int16 {
+	return 0
+}
+
+func (r *DescribeDelegationTokenResponse) requiredVersion() KafkaVersion {
+	if r.Version > 0 {
+		return V2_0_0_0
+	}
+	return V1_1_0_0
+}
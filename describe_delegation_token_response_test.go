@@ -0,0 +1,53 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	describeDelegationTokenResponseEmpty = []byte{
+		0, 0, // no error
+		0, 0, 0, 0, // tokens array length 0
+		0, 0, 0, 0, // no throttle time
+	}
+
+	describeDelegationTokenResponseTokens = []byte{
+		0, 0, // no error
+		0, 0, 0, 1, // tokens array length 1
+		0, 4, 'U', 's', 'e', 'r', // principal type
+		0, 5, 'a', 'l', 'i', 'c', 'e', // principal name
+		0, 0, 1, 118, 40, 170, 6, 0, // issue timestamp
+		0, 0, 1, 118, 45, 208, 98, 0, // expiry timestamp
+		0, 0, 1, 118, 76, 182, 138, 0, // max timestamp
+		0, 2, 'i', 'd', // token ID
+		0, 0, 0, 3, 1, 2, 3, // hmac
+		0, 0, 0, 1, // renewers array length 1
+		0, 4, 'U', 's', 'e', 'r', // principal type
+		0, 3, 'b', 'o', 'b', // principal name
+		0, 0, 0, 100, // throttle time (100 ms)
+	}
+)
+
+func TestDescribeDelegationTokenResponse(t *testing.T) {
+	response := &DescribeDelegationTokenResponse{
+		Version: 1,
+	}
+	testResponse(t, "empty", response, describeDelegationTokenResponseEmpty)
+
+	issued := time.Unix(1607000000, 0)
+	response = &DescribeDelegationTokenResponse{
+		Version: 1,
+		Tokens: []*DelegationToken{{
+			Owner:      DelegationTokenPrincipal{Type: "User", Name: "alice"},
+			IssueTime:  issued,
+			ExpiryTime: issued.Add(24 * time.Hour),
+			MaxTime:    issued.Add(7 * 24 * time.Hour),
+			TokenID:    "id",
+			HMAC:       []byte{1, 2, 3},
+			Renewers:   []DelegationTokenPrincipal{{Type: "User", Name: "bob"}},
+		}},
+		ThrottleTime: 100 * time.Millisecond,
+	}
+	testResponse(t, "with tokens", response, describeDelegationTokenResponseTokens)
+}
@@ -0,0 +1,79 @@
+package sarama
+
+// DescribeProducersRequest is a request to describe the active producers of
+// a set of partitions (KIP-664)
+type DescribeProducersRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	Topics []DescribeProducersRequestTopic
+}
+
+// DescribeProducersRequestTopic holds the partitions of a single topic to be
+// described in a DescribeProducersRequest
+type DescribeProducersRequestTopic struct {
+	Name             string
+	PartitionIndexes []int32
+}
+
+func (r *DescribeProducersRequest) encode(pe packetEncoder) error {
+	pe.putCompactArrayLength(len(r.Topics))
+	for _, t := range r.Topics {
+		if err := pe.putCompactString(t.Name); err != nil {
+			return err
+		}
+		if err := pe.putCompactInt32Array(t.PartitionIndexes); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *DescribeProducersRequest) decode(pd packetDecoder, version int16) error {
+	r.Version = version
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n == -1 {
+		n = 0
+	}
+
+	r.Topics = make([]DescribeProducersRequestTopic, n)
+	for i := 0; i < n; i++ {
+		if r.Topics[i].Name, err = pd.getCompactString(); err != nil {
+			return err
+		}
+		if r.Topics[i].PartitionIndexes, err = pd.getCompactInt32Array(); err != nil {
+			return err
+		}
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
+	if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *DescribeProducersRequest) key() int16 {
+	return 61
+}
+
+func (r *DescribeProducersRequest) version() int16 {
+	return r.Version
+}
+
+func (r *DescribeProducersRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *DescribeProducersRequest) requiredVersion() KafkaVersion {
+	return V2_8_0_0
+}
@@ -0,0 +1,33 @@
+package sarama
+
+import "testing"
+
+var (
+	describeProducersRequestEmpty = []byte{
+		1, // empty topics array
+		0, // empty tagged fields
+	}
+
+	describeProducersRequestTopics = []byte{
+		2,                          // topics array length 1
+		6, 't', 'o', 'p', 'i', 'c', // topic name
+		3,          // partitions array length 2
+		0, 0, 0, 0, // partition 0
+		0, 0, 0, 1, // partition 1
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+)
+
+func TestDescribeProducersRequest(t *testing.T) {
+	request := &DescribeProducersRequest{
+		Version: 0,
+		Topics:  []DescribeProducersRequestTopic{},
+	}
+	testRequest(t, "no topics", request, describeProducersRequestEmpty)
+
+	request.Topics = []DescribeProducersRequestTopic{
+		{Name: "topic", PartitionIndexes: []int32{0, 1}},
+	}
+	testRequest(t, "with topics", request, describeProducersRequestTopics)
+}
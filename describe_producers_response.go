@@ -0,0 +1,191 @@
+package sarama
+
+import "time"
+
+// DescribeProducersResponse is the response to a DescribeProducersRequest
+type DescribeProducersResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime time.Duration
+	Topics       []*DescribeProducersTopic
+}
+
+// DescribeProducersTopic holds the producer state of the partitions of a
+// single topic
+type DescribeProducersTopic struct {
+	Name       string
+	Partitions []*DescribeProducersPartition
+}
+
+// DescribeProducersPartition holds the active producers of a single partition
+type DescribeProducersPartition struct {
+	PartitionIndex  int32
+	ErrorCode       KError
+	ErrorMessage    *string
+	ActiveProducers []*ProducerState
+}
+
+// ProducerState describes an active producer of a partition
+type ProducerState struct {
+	ProducerID       int64
+	ProducerEpoch    int32
+	LastSequence     int32
+	LastTimestamp    int64
+	CoordinatorEpoch int32
+	// CurrentTxnStartOffset is the first offset of the producer's ongoing
+	// transaction, or -1 if there is none
+	CurrentTxnStartOffset int64
+}
+
+func (r *DescribeProducersResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+
+	pe.putCompactArrayLength(len(r.Topics))
+	for _, t := range r.Topics {
+		if err := pe.putCompactString(t.Name); err != nil {
+			return err
+		}
+
+		pe.putCompactArrayLength(len(t.Partitions))
+		for _, p := range t.Partitions {
+			pe.putInt32(p.PartitionIndex)
+			pe.putInt16(int16(p.ErrorCode))
+			if err := pe.putNullableCompactString(p.ErrorMessage); err != nil {
+				return err
+			}
+
+			pe.putCompactArrayLength(len(p.ActiveProducers))
+			for _, s := range p.ActiveProducers {
+				pe.putInt64(s.ProducerID)
+				pe.putInt32(s.ProducerEpoch)
+				pe.putInt32(s.LastSequence)
+				pe.putInt64(s.LastTimestamp)
+				pe.putInt32(s.CoordinatorEpoch)
+				pe.putInt64(s.CurrentTxnStartOffset)
+				pe.putEmptyTaggedFieldArray()
+			}
+			pe.putEmptyTaggedFieldArray()
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *DescribeProducersResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	numTopics, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+
+	if numTopics > 0 {
+		r.Topics = make([]*DescribeProducersTopic, numTopics)
+		for i := 0; i < numTopics; i++ {
+			t := &DescribeProducersTopic{}
+			if t.Name, err = pd.getCompactString(); err != nil {
+				return err
+			}
+
+			numPartitions, err := pd.getCompactArrayLength()
+			if err != nil {
+				return err
+			}
+			if numPartitions > 0 {
+				t.Partitions = make([]*DescribeProducersPartition, numPartitions)
+				for j := 0; j < numPartitions; j++ {
+					p := &DescribeProducersPartition{}
+					if err := p.decode(pd); err != nil {
+						return err
+					}
+					t.Partitions[j] = p
+				}
+			}
+
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			r.Topics[i] = t
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (p *DescribeProducersPartition) decode(pd packetDecoder) (err error) {
+	if p.PartitionIndex, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	p.ErrorCode = KError(kerr)
+
+	if p.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+
+	numProducers, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if numProducers > 0 {
+		p.ActiveProducers = make([]*ProducerState, numProducers)
+		for i := 0; i < numProducers; i++ {
+			s := &ProducerState{}
+			if s.ProducerID, err = pd.getInt64(); err != nil {
+				return err
+			}
+			if s.ProducerEpoch, err = pd.getInt32(); err != nil {
+				return err
+			}
+			if s.LastSequence, err = pd.getInt32(); err != nil {
+				return err
+			}
+			if s.LastTimestamp, err = pd.getInt64(); err != nil {
+				return err
+			}
+			if s.CoordinatorEpoch, err = pd.getInt32(); err != nil {
+				return err
+			}
+			if s.CurrentTxnStartOffset, err = pd.getInt64(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			p.ActiveProducers[i] = s
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *DescribeProducersResponse) key() int16 {
+	return 61
+}
+
+func (r *DescribeProducersResponse) version() int16 {
+	return r.Version
+}
+
+func (r *DescribeProducersResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *DescribeProducersResponse) requiredVersion() KafkaVersion {
+	return V2_8_0_0
+}
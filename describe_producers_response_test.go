@@ -0,0 +1,76 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	describeProducersResponseEmpty = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		1, // empty topics array
+		0, // empty tagged fields
+	}
+
+	describeProducersResponseTopics = []byte{
+		0, 0, 0, 0, // throttle time
+		2,                          // topics array length 1
+		6, 't', 'o', 'p', 'i', 'c', // topic name
+		3,          // partitions array length 2
+		0, 0, 0, 0, // partition 0
+		0, 0, // no error code
+		0,                        // no error message
+		2,                        // active producers array length 1
+		0, 0, 0, 0, 0, 0, 3, 232, // producer ID (1000)
+		0, 0, 0, 5, // producer epoch
+		0, 0, 0, 42, // last sequence
+		0, 0, 1, 118, 40, 170, 6, 0, // last timestamp
+		0, 0, 0, 3, // coordinator epoch
+		255, 255, 255, 255, 255, 255, 255, 255, // current txn start offset (-1)
+		0,          // empty tagged fields
+		0,          // empty tagged fields
+		0, 0, 0, 1, // partition 1
+		0, 3, // unknown topic or partition
+		5, 'o', 'o', 'p', 's', // error message
+		1, // empty active producers array
+		0, // empty tagged fields
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+)
+
+func TestDescribeProducersResponse(t *testing.T) {
+	response := &DescribeProducersResponse{
+		Version:      0,
+		ThrottleTime: 100 * time.Millisecond,
+	}
+	testResponse(t, "empty", response, describeProducersResponseEmpty)
+
+	errMsg := "oops"
+	response = &DescribeProducersResponse{
+		Version: 0,
+		Topics: []*DescribeProducersTopic{{
+			Name: "topic",
+			Partitions: []*DescribeProducersPartition{
+				{
+					PartitionIndex: 0,
+					ErrorCode:      ErrNoError,
+					ActiveProducers: []*ProducerState{{
+						ProducerID:            1000,
+						ProducerEpoch:         5,
+						LastSequence:          42,
+						LastTimestamp:         1607000000000,
+						CoordinatorEpoch:      3,
+						CurrentTxnStartOffset: -1,
+					}},
+				},
+				{
+					PartitionIndex: 1,
+					ErrorCode:      ErrUnknownTopicOrPartition,
+					ErrorMessage:   &errMsg,
+				},
+			},
+		}},
+	}
+	testResponse(t, "with topics", response, describeProducersResponseTopics)
+}
@@ -0,0 +1,27 @@
+package sarama
+
+import "testing"
+
+var (
+	describeTransactionsRequestEmpty = []byte{
+		1, // empty transactional IDs
+		0, // empty tagged fields
+	}
+
+	describeTransactionsRequestIDs = []byte{
+		3,                // transactional IDs array length 2
+		4, 't', 'x', '1', // transactional ID
+		4, 't', 'x', '2', // transactional ID
+		0, // empty tagged fields
+	}
+)
+
+func TestDescribeTransactionsRequest(t *testing.T) {
+	request := &DescribeTransactionsRequest{
+		Version: 0,
+	}
+	testRequest(t, "no transactional IDs", request, describeTransactionsRequestEmpty)
+
+	request.TransactionalIDs = []string{"tx1", "tx2"}
+	testRequest(t, "with transactional IDs", request, describeTransactionsRequestIDs)
+}
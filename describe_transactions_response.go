@@ -0,0 +1,161 @@
+package sarama
+
+import "time"
+
+// DescribeTransactionsResponse is the response to a DescribeTransactionsRequest
+type DescribeTransactionsResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime      time.Duration
+	TransactionStates []*TransactionDescription
+}
+
+// TransactionDescription describes the state of a single transaction
+type TransactionDescription struct {
+	ErrorCode          KError
+	TransactionalID    string
+	TransactionState   string
+	TransactionTimeout time.Duration
+	// TransactionStartTime is the start time of the ongoing transaction in
+	// milliseconds since the epoch, or -1 if there is none
+	TransactionStartTime int64
+	ProducerID           int64
+	ProducerEpoch        int16
+
+	// Topics maps the topics which are part of the ongoing transaction to
+	// their partitions
+	Topics map[string][]int32
+}
+
+func (r *DescribeTransactionsResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+
+	pe.putCompactArrayLength(len(r.TransactionStates))
+	for _, t := range r.TransactionStates {
+		pe.putInt16(int16(t.ErrorCode))
+		if err := pe.putCompactString(t.TransactionalID); err != nil {
+			return err
+		}
+		if err := pe.putCompactString(t.TransactionState); err != nil {
+			return err
+		}
+		pe.putInt32(int32(t.TransactionTimeout / time.Millisecond))
+		pe.putInt64(t.TransactionStartTime)
+		pe.putInt64(t.ProducerID)
+		pe.putInt16(t.ProducerEpoch)
+
+		pe.putCompactArrayLength(len(t.Topics))
+		for topic, partitions := range t.Topics {
+			if err := pe.putCompactString(topic); err != nil {
+				return err
+			}
+			if err := pe.putCompactInt32Array(partitions); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *DescribeTransactionsResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	numTransactions, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if numTransactions > 0 {
+		r.TransactionStates = make([]*TransactionDescription, numTransactions)
+		for i := 0; i < numTransactions; i++ {
+			t := &TransactionDescription{}
+			if err := t.decode(pd); err != nil {
+				return err
+			}
+			r.TransactionStates[i] = t
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (t *TransactionDescription) decode(pd packetDecoder) (err error) {
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	t.ErrorCode = KError(kerr)
+
+	if t.TransactionalID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if t.TransactionState, err = pd.getCompactString(); err != nil {
+		return err
+	}
+
+	timeout, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	t.TransactionTimeout = time.Duration(timeout) * time.Millisecond
+
+	if t.TransactionStartTime, err = pd.getInt64(); err != nil {
+		return err
+	}
+	if t.ProducerID, err = pd.getInt64(); err != nil {
+		return err
+	}
+	if t.ProducerEpoch, err = pd.getInt16(); err != nil {
+		return err
+	}
+
+	numTopics, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if numTopics > 0 {
+		t.Topics = make(map[string][]int32, numTopics)
+		for i := 0; i < numTopics; i++ {
+			topic, err := pd.getCompactString()
+			if err != nil {
+				return err
+			}
+			if t.Topics[topic], err = pd.getCompactInt32Array(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *DescribeTransactionsResponse) key() int16 {
+	return 65
+}
+
+func (r *DescribeTransactionsResponse) version() int16 {
+	return r.Version
+}
+
+func (r *DescribeTransactionsResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *DescribeTransactionsResponse) requiredVersion() KafkaVersion {
+	return V3_0_0_0
+}
@@ -0,0 +1,55 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	describeTransactionsResponseEmpty = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		1, // empty transaction states
+		0, // empty tagged fields
+	}
+
+	describeTransactionsResponseStates = []byte{
+		0, 0, 0, 0, // throttle time
+		2,    // transaction states array length 1
+		0, 0, // no error code
+		4, 't', 'x', '1', // transactional ID
+		8, 'O', 'n', 'g', 'o', 'i', 'n', 'g', // transaction state
+		0, 0, 234, 96, // transaction timeout (60000 ms)
+		0, 0, 1, 118, 40, 170, 6, 0, // transaction start time
+		0, 0, 0, 0, 0, 0, 0, 7, // producer ID
+		0, 1, // producer epoch
+		2,                          // topics array length 1
+		6, 't', 'o', 'p', 'i', 'c', // topic
+		2, 0, 0, 0, 3, // partitions
+		0, // empty tagged fields
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+)
+
+func TestDescribeTransactionsResponse(t *testing.T) {
+	response := &DescribeTransactionsResponse{
+		Version:      0,
+		ThrottleTime: 100 * time.Millisecond,
+	}
+	testResponse(t, "empty", response, describeTransactionsResponseEmpty)
+
+	response = &DescribeTransactionsResponse{
+		Version: 0,
+		TransactionStates: []*TransactionDescription{{
+			ErrorCode:            ErrNoError,
+			TransactionalID:      "tx1",
+			TransactionState:     "Ongoing",
+			TransactionTimeout:   time.Minute,
+			TransactionStartTime: 1607000000000,
+			ProducerID:           7,
+			ProducerEpoch:        1,
+			Topics:               map[string][]int32{"topic": {3}},
+		}},
+	}
+	testResponse(t, "with states", response, describeTransactionsResponseStates)
+}
@@ -0,0 +1,205 @@
+package sarama
+
+import "time"
+
+// ElectionType is the type of leader election to perform
+type ElectionType int8
+
+const (
+	// PreferredElection elects the preferred replica as the leader
+	PreferredElection ElectionType = 0
+	// UncleanElection elects the first live replica if there are no in-sync
+	// replicas, potentially losing data
+	UncleanElection ElectionType = 1
+)
+
+func (e ElectionType) String() string {
+	switch e {
+	case PreferredElection:
+		return "Preferred"
+	case UncleanElection:
+		return "Unclean"
+	default:
+		return "Unknown"
+	}
+}
+
+// ElectLeadersRequest is a request to trigger a leader election for a set of
+// partitions (KIP-183, KIP-460)
+type ElectLeadersRequest struct {
+	Version int16
+
+	// Type requires v1+, only preferred elections are supported by v0
+	Type ElectionType
+
+	// TopicPartitions holds the partitions to elect leaders for, a nil map
+	// triggers an election for all partitions
+	TopicPartitions map[string][]int32
+	Timeout         time.Duration
+}
+
+func (r *ElectLeadersRequest) encode(pe packetEncoder) (err error) {
+	isFlexible := r.Version >= 2
+
+	if r.Version >= 1 {
+		pe.putInt8(int8(r.Type))
+	} else if r.Type != PreferredElection {
+		return PacketEncodingError{"unclean leader election is not supported. use version 1 or later"}
+	}
+
+	if isFlexible {
+		if r.TopicPartitions == nil {
+			pe.putUVarint(0)
+		} else {
+			pe.putCompactArrayLength(len(r.TopicPartitions))
+		}
+	} else {
+		if r.TopicPartitions == nil {
+			pe.putInt32(-1)
+		} else if err = pe.putArrayLength(len(r.TopicPartitions)); err != nil {
+			return err
+		}
+	}
+
+	for topic, partitions := range r.TopicPartitions {
+		if isFlexible {
+			err = pe.putCompactString(topic)
+		} else {
+			err = pe.putString(topic)
+		}
+		if err != nil {
+			return err
+		}
+
+		if isFlexible {
+			err = pe.putCompactInt32Array(partitions)
+		} else {
+			err = pe.putInt32Array(partitions)
+		}
+		if err != nil {
+			return err
+		}
+
+		if isFlexible {
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+
+	pe.putInt32(int32(r.Timeout / time.Millisecond))
+
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	return nil
+}
+
+func (r *ElectLeadersRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+	isFlexible := r.Version >= 2
+
+	if r.Version >= 1 {
+		t, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		r.Type = ElectionType(t)
+	}
+
+	var topicCount int
+	if isFlexible {
+		topicCount, err = pd.getCompactArrayLength()
+	} else {
+		topicCount, err = pd.getArrayLength()
+	}
+	if err != nil {
+		return err
+	}
+
+	if topicCount > 0 {
+		r.TopicPartitions = make(map[string][]int32, topicCount)
+		for i := 0; i < topicCount; i++ {
+			var topic string
+			if isFlexible {
+				topic, err = pd.getCompactString()
+			} else {
+				topic, err = pd.getString()
+			}
+			if err != nil {
+				return err
+			}
+
+			var partitions []int32
+			if isFlexible {
+				partitions, err = pd.getCompactInt32Array()
+			} else {
+				partitions, err = pd.getInt32Array()
+			}
+			if err != nil {
+				return err
+			}
+
+			if isFlexible {
+				if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+					return err
+				}
+			}
+
+			r.TopicPartitions[topic] = partitions
+		}
+	}
+
+	timeout, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.Timeout = time.Duration(timeout) * time.Millisecond
+
+	if isFlexible {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ElectLeadersRequest) key() int16 {
+	return 43
+}
+
+func (r *ElectLeadersRequest) version() int16 {
+	return r.Version
+}
+
+func (r *ElectLeadersRequest) versionRange() (minVersion, maxVersion int16) {
+	if r.Version >= 1 && r.Type != PreferredElection {
+		return 1, 2
+	}
+	return 0, 2
+}
+
+func (r *ElectLeadersRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	if r.Version < 1 {
+		request.Type = PreferredElection
+	}
+	return &request
+}
+
+func (r *ElectLeadersRequest) headerVersion() int16 {
+	if r.Version >= 2 {
+		return 2
+	}
+	return 1
+}
+
+func (r *ElectLeadersRequest) requiredVersion() KafkaVersion {
+	switch r.Version {
+	case 1, 2:
+		return V2_4_0_0
+	default:
+		return V2_2_0_0
+	}
+}
@@ -0,0 +1,67 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	electLeadersRequestV0 = []byte{
+		0, 0, 0, 1, // topics array length 1
+		0, 5, 't', 'o', 'p', 'i', 'c', // topic
+		0, 0, 0, 1, // partitions array length 1
+		0, 0, 0, 0, // partition 0
+		0, 0, 39, 16, // timeout (10000 ms)
+	}
+
+	electLeadersRequestV1AllPartitions = []byte{
+		1,                  // unclean election
+		255, 255, 255, 255, // null topics array
+		0, 0, 39, 16, // timeout (10000 ms)
+	}
+
+	electLeadersRequestV2 = []byte{
+		0,                          // preferred election
+		2,                          // topics array length 1
+		6, 't', 'o', 'p', 'i', 'c', // topic
+		2,          // partitions array length 1
+		0, 0, 0, 0, // partition 0
+		0,            // empty tagged fields
+		0, 0, 39, 16, // timeout (10000 ms)
+		0, // empty tagged fields
+	}
+)
+
+func TestElectLeadersRequest(t *testing.T) {
+	request := &ElectLeadersRequest{
+		Version:         0,
+		TopicPartitions: map[string][]int32{"topic": {0}},
+		Timeout:         10 * time.Second,
+	}
+	testRequest(t, "v0", request, electLeadersRequestV0)
+
+	request = &ElectLeadersRequest{
+		Version: 1,
+		Type:    UncleanElection,
+		Timeout: 10 * time.Second,
+	}
+	testRequest(t, "v1 all partitions", request, electLeadersRequestV1AllPartitions)
+
+	request = &ElectLeadersRequest{
+		Version:         2,
+		Type:            PreferredElection,
+		TopicPartitions: map[string][]int32{"topic": {0}},
+		Timeout:         10 * time.Second,
+	}
+	testRequest(t, "v2", request, electLeadersRequestV2)
+}
+
+func TestElectLeadersRequestUncleanV0(t *testing.T) {
+	request := &ElectLeadersRequest{
+		Version: 0,
+		Type:    UncleanElection,
+	}
+	if _, err := encode(request, nil); err == nil {
+		t.Error("Expected an error encoding an unclean election request with v0")
+	}
+}
@@ -0,0 +1,208 @@
+package sarama
+
+import "time"
+
+// ElectLeadersResponse is the response to an ElectLeadersRequest
+type ElectLeadersResponse struct {
+	Version      int16
+	ThrottleTime time.Duration
+
+	// ErrorCode is the top level error code, requires v1+
+	ErrorCode KError
+
+	// ReplicaElectionResults maps topics to partitions to the result of their
+	// leader election
+	ReplicaElectionResults map[string]map[int32]*PartitionResult
+}
+
+// PartitionResult is the result of a leader election for a single partition
+type PartitionResult struct {
+	ErrorCode    KError
+	ErrorMessage *string
+}
+
+func (r *ElectLeadersResponse) encode(pe packetEncoder) (err error) {
+	isFlexible := r.Version >= 2
+
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+
+	if r.Version >= 1 {
+		pe.putInt16(int16(r.ErrorCode))
+	}
+
+	if isFlexible {
+		pe.putCompactArrayLength(len(r.ReplicaElectionResults))
+	} else if err = pe.putArrayLength(len(r.ReplicaElectionResults)); err != nil {
+		return err
+	}
+
+	for topic, partitions := range r.ReplicaElectionResults {
+		if isFlexible {
+			err = pe.putCompactString(topic)
+		} else {
+			err = pe.putString(topic)
+		}
+		if err != nil {
+			return err
+		}
+
+		if isFlexible {
+			pe.putCompactArrayLength(len(partitions))
+		} else if err = pe.putArrayLength(len(partitions)); err != nil {
+			return err
+		}
+
+		for partition, result := range partitions {
+			pe.putInt32(partition)
+			pe.putInt16(int16(result.ErrorCode))
+			if isFlexible {
+				err = pe.putNullableCompactString(result.ErrorMessage)
+			} else {
+				err = pe.putNullableString(result.ErrorMessage)
+			}
+			if err != nil {
+				return err
+			}
+			if isFlexible {
+				pe.putEmptyTaggedFieldArray()
+			}
+		}
+
+		if isFlexible {
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	return nil
+}
+
+func (r *ElectLeadersResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+	isFlexible := r.Version >= 2
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	if r.Version >= 1 {
+		kerr, err := pd.getInt16()
+		if err != nil {
+			return err
+		}
+		r.ErrorCode = KError(kerr)
+	}
+
+	var topicCount int
+	if isFlexible {
+		topicCount, err = pd.getCompactArrayLength()
+	} else {
+		topicCount, err = pd.getArrayLength()
+	}
+	if err != nil {
+		return err
+	}
+
+	r.ReplicaElectionResults = make(map[string]map[int32]*PartitionResult, topicCount)
+	for i := 0; i < topicCount; i++ {
+		var topic string
+		if isFlexible {
+			topic, err = pd.getCompactString()
+		} else {
+			topic, err = pd.getString()
+		}
+		if err != nil {
+			return err
+		}
+
+		var partitionCount int
+		if isFlexible {
+			partitionCount, err = pd.getCompactArrayLength()
+		} else {
+			partitionCount, err = pd.getArrayLength()
+		}
+		if err != nil {
+			return err
+		}
+
+		partitions := make(map[int32]*PartitionResult, partitionCount)
+		for j := 0; j < partitionCount; j++ {
+			partition, err := pd.getInt32()
+			if err != nil {
+				return err
+			}
+
+			kerr, err := pd.getInt16()
+			if err != nil {
+				return err
+			}
+			result := &PartitionResult{ErrorCode: KError(kerr)}
+
+			if isFlexible {
+				result.ErrorMessage, err = pd.getCompactNullableString()
+			} else {
+				result.ErrorMessage, err = pd.getNullableString()
+			}
+			if err != nil {
+				return err
+			}
+
+			if isFlexible {
+				if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+					return err
+				}
+			}
+
+			partitions[partition] = result
+		}
+
+		if isFlexible {
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+
+		r.ReplicaElectionResults[topic] = partitions
+	}
+
+	if isFlexible {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ElectLeadersResponse) key() int16 {
+	return 43
+}
+
+func (r *ElectLeadersResponse) version() int16 {
+	return r.Version
+}
+
+func (r *ElectLeadersResponse) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *ElectLeadersResponse) headerVersion() int16 {
+	if r.Version >= 2 {
+		return 1
+	}
+	return 0
+}
+
+func (r *ElectLeadersResponse) requiredVersion() KafkaVersion {
+	switch r.Version {
+	case 1, 2:
+		return V2_4_0_0
+	default:
+		return V2_2_0_0
+	}
+}
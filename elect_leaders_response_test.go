@@ -0,0 +1,52 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	electLeadersResponseV0 = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		0, 0, 0, 1, // topics array length 1
+		0, 5, 't', 'o', 'p', 'i', 'c', // topic
+		0, 0, 0, 1, // partitions array length 1
+		0, 0, 0, 0, // partition 0
+		0, 84, // election not needed
+		255, 255, // no error message
+	}
+
+	electLeadersResponseV2 = []byte{
+		0, 0, 0, 0, // throttle time
+		0, 0, // no error code
+		2,                          // topics array length 1
+		6, 't', 'o', 'p', 'i', 'c', // topic
+		2,          // partitions array length 1
+		0, 0, 0, 1, // partition 1
+		0, 0, // no error code
+		0, // no error message
+		0, // empty tagged fields
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+)
+
+func TestElectLeadersResponse(t *testing.T) {
+	response := &ElectLeadersResponse{
+		Version:      0,
+		ThrottleTime: 100 * time.Millisecond,
+		ReplicaElectionResults: map[string]map[int32]*PartitionResult{
+			"topic": {0: {ErrorCode: ErrElectionNotNeeded}},
+		},
+	}
+	testResponse(t, "v0", response, electLeadersResponseV0)
+
+	response = &ElectLeadersResponse{
+		Version:   2,
+		ErrorCode: ErrNoError,
+		ReplicaElectionResults: map[string]map[int32]*PartitionResult{
+			"topic": {1: {ErrorCode: ErrNoError}},
+		},
+	}
+	testResponse(t, "v2", response, electLeadersResponseV2)
+}
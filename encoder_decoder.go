@@ -1,10 +1,6 @@
 package sarama
 
-import (
-	"fmt"
-
-	"github.com/rcrowley/go-metrics"
-)
+import "fmt"
 
 // Encoder is the interface that wraps the basic Encode method.
 // Anything implementing Encoder can be turned into bytes using Kafka's encoding rules.
@@ -18,7 +14,7 @@ type encoderWithHeader interface {
 }
 
 // Encode takes an Encoder and turns it into bytes while potentially recording metrics.
-func encode(e encoder, metricRegistry metrics.Registry) ([]byte, error) {
+func encode(e encoder, recorder MetricsRecorder) ([]byte, error) {
 	if e == nil {
 		return nil, nil
 	}
@@ -36,7 +32,7 @@ func encode(e encoder, metricRegistry metrics.Registry) ([]byte, error) {
 	}
 
 	realEnc.raw = make([]byte, prepEnc.length)
-	realEnc.registry = metricRegistry
+	realEnc.recorder = recorder
 	err = e.encode(&realEnc)
 	if err != nil {
 		return nil, err
@@ -76,11 +72,17 @@ func decode(buf []byte, in decoder) error {
 }
 
 func versionedDecode(buf []byte, in versionedDecoder, version int16) error {
+	return versionedDecodeWithOptions(buf, in, version, decodeOptions{})
+}
+
+// versionedDecodeWithOptions decodes like versionedDecode, applying the
+// options of the consumer to the record batches and messages.
+func versionedDecodeWithOptions(buf []byte, in versionedDecoder, version int16, opts decodeOptions) error {
 	if buf == nil {
 		return nil
 	}
 
-	helper := realDecoder{raw: buf}
+	helper := realDecoder{raw: buf, opts: opts}
 	err := in.decode(&helper, version)
 	if err != nil {
 		return err
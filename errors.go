@@ -1,8 +1,11 @@
 package sarama
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
@@ -32,6 +35,9 @@ var ErrAlreadyConnected = errors.New("kafka: broker connection already initiated
 // ErrNotConnected is the error returned when trying to send or call Close() on a Broker that is not connected.
 var ErrNotConnected = errors.New("kafka: broker not connected")
 
+// ErrDrainTimeout is the error returned by Broker.Drain when the responses in flight were not all received in time.
+var ErrDrainTimeout = errors.New("kafka: timed out waiting for the responses in flight while draining the broker connection")
+
 // ErrInsufficientData is returned when decoding and the packet is truncated. This can be expected
 // when requesting messages, since as an optimization the server is allowed to return a partial message at the end
 // of the message set.
@@ -61,9 +67,68 @@ var ErrUnknownScramMechanism = errors.New("kafka: unknown SCRAM mechanism provid
 // ErrReassignPartitions is returned when altering partition assignments for a topic fails
 var ErrReassignPartitions = errors.New("failed to reassign partitions for topic")
 
+// ErrReassignmentTimeout is returned when partition reassignments did not complete in time
+var ErrReassignmentTimeout = errors.New("kafka: timed out waiting for partition reassignments to complete")
+
+// ErrAlterReplicaLogDirs is the type of error returned when moving replicas between log directories fails
+var ErrAlterReplicaLogDirs = errors.New("kafka server: failed to alter replica log dirs")
+
+// ErrRemoveConsumerGroupMembers is the type of error returned when removing members from a consumer group fails
+var ErrRemoveConsumerGroupMembers = errors.New("kafka server: failed to remove members from consumer group")
+
+// ErrResetConsumerGroupOffsets is the type of error returned when resetting the offsets of a consumer group fails
+var ErrResetConsumerGroupOffsets = errors.New("kafka: failed to reset consumer group offsets")
+
+// ErrExportConsumerGroupOffsets is the type of error returned when exporting the offsets of a consumer group fails
+var ErrExportConsumerGroupOffsets = errors.New("kafka: failed to export consumer group offsets")
+
+// ErrImportConsumerGroupOffsets is the type of error returned when importing the offsets of a consumer group fails
+var ErrImportConsumerGroupOffsets = errors.New("kafka: failed to import consumer group offsets")
+
+// ErrReconcileACLs is the type of error returned when reconciling ACLs fails
+var ErrReconcileACLs = errors.New("kafka server: failed to reconcile ACLs")
+
+// ErrUpdateFeatures is the type of error returned when updating finalized feature levels fails
+var ErrUpdateFeatures = errors.New("kafka server: failed to update features")
+
 // ErrDeleteRecords is the type of error returned when fail to delete the required records
 var ErrDeleteRecords = errors.New("kafka server: failed to delete records")
 
+// ErrCreateTopics is the type of error returned when creating some of the topics of a batch fails
+var ErrCreateTopics = errors.New("kafka server: failed to create topics")
+
+// ErrDeleteTopics is the type of error returned when deleting some of the topics of a batch fails
+var ErrDeleteTopics = errors.New("kafka server: failed to delete topics")
+
+// ErrValidateOnlyUnsupported is the type of error returned by a ClusterAdmin in validate only mode for
+// operations which cannot be validated without being applied
+var ErrValidateOnlyUnsupported = errors.New("kafka: operation cannot be validated without being applied")
+
+// ErrNonTransactedProducer is returned by the transactional methods of a producer which is not
+// transactional, see Producer.Transaction.ID
+var ErrNonTransactedProducer = errors.New("kafka: producer is not transactional")
+
+// ErrTransactionNotReady is returned when a transactional producer cannot begin, commit or abort a
+// transaction or produce a message in its current state, such as when producing outside of a transaction
+var ErrTransactionNotReady = errors.New("kafka: transaction is not ready")
+
+// ErrTxnAbortable is returned by a transactional producer whose transaction failed, which must
+// be aborted before beginning another one. It wraps the error the transaction failed with.
+var ErrTxnAbortable = errors.New("kafka: transaction failed and must be aborted")
+
+// ErrTxnFatal is returned by a transactional producer which cannot produce anymore, such as when
+// fenced by another producer with the same transactional ID, and must be closed. It wraps the error the
+// producer failed with.
+var ErrTxnFatal = errors.New("kafka: transactional producer failed and must be closed")
+
+// ErrTxnTimedOut is the error a transaction aborted by the watchdog of its producer fails with, as it
+// was about to exceed Producer.Transaction.Timeout, see Producer.Transaction.Watchdog
+var ErrTxnTimedOut = errors.New("kafka: transaction aborted before exceeding its timeout")
+
+// ErrAddPartitionsToTxn is returned for the messages of a transaction whose partitions could not be
+// added to it
+var ErrAddPartitionsToTxn = errors.New("kafka: failed to add partitions to the transaction")
+
 // MultiErrorFormat specifies the formatter applied to format multierrors. The
 // default implementation is a consensed version of the hashicorp/go-multierror
 // default one
@@ -135,6 +200,53 @@ func (err PacketDecodingError) Error() string {
 	return fmt.Sprintf("kafka: error decoding packet: %s", err.Info)
 }
 
+// RequestError is returned when the response to a request timed out or failed to be read or decoded. It
+// identifies the request, so that the failure can be matched against the request logs of the broker, and
+// wraps the underlying error.
+type RequestError struct {
+	BrokerID      int32
+	APIKey        int16
+	CorrelationID int32
+	Err           error
+}
+
+func (err *RequestError) Error() string {
+	return fmt.Sprintf("kafka: %s request to broker %d (correlation ID %d) failed: %v",
+		APIKeyName(err.APIKey), err.BrokerID, err.CorrelationID, err.Err)
+}
+
+func (err *RequestError) Unwrap() error {
+	return err.Err
+}
+
+// PartitionRequestError is the error of a partition in the response to a produce, fetch or offset commit request,
+// as returned by the producer, the consumer and the offset manager. It identifies the partition, the broker and
+// the request, so that the failure can be matched against the request logs of the broker, and wraps the error
+// of the partition, usually a KError.
+type PartitionRequestError struct {
+	Topic         string
+	Partition     int32
+	BrokerID      int32
+	CorrelationID int32
+	Err           error
+}
+
+func (err *PartitionRequestError) Error() string {
+	return fmt.Sprintf("kafka: %s/%d on broker %d (correlation ID %d): %v",
+		err.Topic, err.Partition, err.BrokerID, err.CorrelationID, err.Err)
+}
+
+func (err *PartitionRequestError) Unwrap() error {
+	return err.Err
+}
+
+// Is reports whether target is a PartitionRequestError of the same topic and partition, so that
+// errors.Is(err, &PartitionRequestError{Topic: topic, Partition: partition}) tells the errors of a partition.
+func (err *PartitionRequestError) Is(target error) bool {
+	t, ok := target.(*PartitionRequestError)
+	return ok && t.Topic == err.Topic && t.Partition == err.Partition
+}
+
 // ConfigurationError is the type of error returned from a constructor (e.g. NewClient, or NewConsumer)
 // when the specified configuration is invalid.
 type ConfigurationError string
@@ -239,6 +351,45 @@ const (
 	ErrGroupSubscribedToTopic             KError = 86
 	ErrInvalidRecord                      KError = 87
 	ErrUnstableOffsetCommit               KError = 88
+	ErrThrottlingQuotaExceeded            KError = 89
+	ErrProducerFenced                     KError = 90
+	ErrResourceNotFound                   KError = 91
+	ErrDuplicateResource                  KError = 92
+	ErrUnacceptableCredential             KError = 93
+	ErrInconsistentVoterSet               KError = 94
+	ErrInvalidUpdateVersion               KError = 95
+	ErrFeatureUpdateFailed                KError = 96
+	ErrPrincipalDeserializationFailure    KError = 97
+	ErrSnapshotNotFound                   KError = 98
+	ErrPositionOutOfRange                 KError = 99
+	ErrUnknownTopicID                     KError = 100
+	ErrDuplicateBrokerRegistration        KError = 101
+	ErrBrokerIDNotRegistered              KError = 102
+	ErrInconsistentTopicID                KError = 103
+	ErrInconsistentClusterID              KError = 104
+	ErrTransactionalIDNotFound            KError = 105
+	ErrFetchSessionTopicIDError           KError = 106
+	ErrIneligibleReplica                  KError = 107
+	ErrNewLeaderElected                   KError = 108
+	ErrOffsetMovedToTieredStorage         KError = 109
+	ErrFencedMemberEpoch                  KError = 110
+	ErrUnreleasedInstanceID               KError = 111
+	ErrUnsupportedAssignor                KError = 112
+	ErrStaleMemberEpoch                   KError = 113
+	ErrMismatchedEndpointType             KError = 114
+	ErrUnsupportedEndpointType            KError = 115
+	ErrUnknownControllerID                KError = 116
+	ErrUnknownSubscriptionID              KError = 117
+	ErrTelemetryTooLarge                  KError = 118
+	ErrInvalidRegistration                KError = 119
+	ErrTransactionAbortable               KError = 120
+	ErrInvalidRecordState                 KError = 121
+	ErrShareSessionNotFound               KError = 122
+	ErrInvalidShareSessionEpoch           KError = 123
+	ErrFencedStateEpoch                   KError = 124
+	ErrInvalidVoterKey                    KError = 125
+	ErrDuplicateVoter                     KError = 126
+	ErrVoterNotFound                      KError = 127
 )
 
 func (err KError) Error() string {
@@ -425,7 +576,171 @@ func (err KError) Error() string {
 		return "kafka server: This record has failed the validation on broker and hence will be rejected"
 	case ErrUnstableOffsetCommit:
 		return "kafka server: There are unstable offsets that need to be cleared"
+	case ErrThrottlingQuotaExceeded:
+		return "kafka server: The throttling quota has been exceeded"
+	case ErrProducerFenced:
+		return "kafka server: There is a newer producer with the same transactionalId which fences the current one"
+	case ErrResourceNotFound:
+		return "kafka server: A request illegally referred to a resource that does not exist"
+	case ErrDuplicateResource:
+		return "kafka server: A request illegally referred to the same resource twice"
+	case ErrUnacceptableCredential:
+		return "kafka server: Requested credential would not meet criteria for acceptability"
+	case ErrInconsistentVoterSet:
+		return "kafka server: Indicates that either the sender or recipient of a voter-only request is not one of the expected voters"
+	case ErrInvalidUpdateVersion:
+		return "kafka server: The given update version was invalid"
+	case ErrFeatureUpdateFailed:
+		return "kafka server: Unable to update finalized features due to an unexpected server error"
+	case ErrPrincipalDeserializationFailure:
+		return "kafka server: Request principal deserialization failed during forwarding"
+	case ErrSnapshotNotFound:
+		return "kafka server: Requested snapshot was not found"
+	case ErrPositionOutOfRange:
+		return "kafka server: Requested position is not greater than or equal to zero, and less than the size of the snapshot"
+	case ErrUnknownTopicID:
+		return "kafka server: This server does not host this topic ID"
+	case ErrDuplicateBrokerRegistration:
+		return "kafka server: This broker ID is already in use"
+	case ErrBrokerIDNotRegistered:
+		return "kafka server: The given broker ID was not registered"
+	case ErrInconsistentTopicID:
+		return "kafka server: The log's topic ID did not match the topic ID in the request"
+	case ErrInconsistentClusterID:
+		return "kafka server: The clusterId in the request does not match that found on the server"
+	case ErrTransactionalIDNotFound:
+		return "kafka server: The transactionalId could not be found"
+	case ErrFetchSessionTopicIDError:
+		return "kafka server: The fetch session encountered inconsistent topic ID usage"
+	case ErrIneligibleReplica:
+		return "kafka server: The new ISR contains at least one ineligible replica"
+	case ErrNewLeaderElected:
+		return "kafka server: The AlterPartition request successfully updated the partition state but the leader has changed"
+	case ErrOffsetMovedToTieredStorage:
+		return "kafka server: The requested offset is moved to tiered storage"
+	case ErrFencedMemberEpoch:
+		return "kafka server: The member epoch is fenced by the group coordinator"
+	case ErrUnreleasedInstanceID:
+		return "kafka server: The instance ID is still used by another member in the consumer group"
+	case ErrUnsupportedAssignor:
+		return "kafka server: The assignor or its version range is not supported by the consumer group"
+	case ErrStaleMemberEpoch:
+		return "kafka server: The member epoch is stale"
+	case ErrMismatchedEndpointType:
+		return "kafka server: The request was sent to an endpoint of the wrong type"
+	case ErrUnsupportedEndpointType:
+		return "kafka server: This endpoint type is not supported yet"
+	case ErrUnknownControllerID:
+		return "kafka server: This controller ID is not known"
+	case ErrUnknownSubscriptionID:
+		return "kafka server: Client sent a push telemetry request with an invalid or outdated subscription ID"
+	case ErrTelemetryTooLarge:
+		return "kafka server: Client sent a push telemetry request larger than the maximum size the broker will accept"
+	case ErrInvalidRegistration:
+		return "kafka server: The controller has considered the broker registration to be invalid"
+	case ErrTransactionAbortable:
+		return "kafka server: The server encountered an error with the transaction, the client can abort the transaction to continue using this transactional ID"
+	case ErrInvalidRecordState:
+		return "kafka server: The record state is invalid, the acknowledgement of delivery could not be completed"
+	case ErrShareSessionNotFound:
+		return "kafka server: The share session was not found"
+	case ErrInvalidShareSessionEpoch:
+		return "kafka server: The share session epoch is invalid"
+	case ErrFencedStateEpoch:
+		return "kafka server: The share coordinator rejected the request because the share-group state epoch did not match"
+	case ErrInvalidVoterKey:
+		return "kafka server: The voter key doesn't match the receiving replica's key"
+	case ErrDuplicateVoter:
+		return "kafka server: The voter is already part of the set of voters"
+	case ErrVoterNotFound:
+		return "kafka server: The voter is not part of the set of voters"
 	}
 
 	return fmt.Sprintf("Unknown error, how did this happen? Error code = %d", err)
 }
+
+// IsRetriable reports whether the request which failed with the error may
+// succeed if retried, possibly after refreshing the metadata or the
+// coordinator, as flagged by the Kafka protocol for each error code.
+func (err KError) IsRetriable() bool {
+	switch err {
+	case ErrInvalidMessage,
+		ErrUnknownTopicOrPartition,
+		ErrLeaderNotAvailable,
+		ErrNotLeaderForPartition,
+		ErrRequestTimedOut,
+		ErrReplicaNotAvailable,
+		ErrNetworkException,
+		ErrOffsetsLoadInProgress,
+		ErrConsumerCoordinatorNotAvailable,
+		ErrNotCoordinatorForConsumer,
+		ErrNotEnoughReplicas,
+		ErrNotEnoughReplicasAfterAppend,
+		ErrNotController,
+		ErrConcurrentTransactions,
+		ErrKafkaStorageError,
+		ErrFetchSessionIDNotFound,
+		ErrInvalidFetchSessionEpoch,
+		ErrListenerNotFound,
+		ErrFencedLeaderEpoch,
+		ErrUnknownLeaderEpoch,
+		ErrOffsetNotAvailable,
+		ErrPreferredLeaderNotAvailable,
+		ErrUnstableOffsetCommit,
+		ErrThrottlingQuotaExceeded,
+		ErrUnknownTopicID,
+		ErrInconsistentTopicID,
+		ErrFetchSessionTopicIDError,
+		ErrShareSessionNotFound,
+		ErrInvalidShareSessionEpoch:
+		return true
+	}
+	return false
+}
+
+// IsRetriable reports whether the operation which failed with err may
+// succeed if retried: the KErrors flagged retriable by the Kafka protocol,
+// see KError.IsRetriable, the network errors and the errors of the client
+// running out of brokers or losing its connection. The errors of a producer
+// or client which was closed or fenced, of a transaction which must be
+// aborted, and of a canceled context are not retriable, whatever the errors
+// they wrap.
+func IsRetriable(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, final := range []error{
+		ErrClosedClient,
+		ErrShuttingDown,
+		ErrTxnAbortable,
+		ErrTxnFatal,
+		ErrTransactionNotReady,
+		ErrNonTransactedProducer,
+		context.Canceled,
+		context.DeadlineExceeded,
+	} {
+		if errors.Is(err, final) {
+			return false
+		}
+	}
+
+	var kerr KError
+	if errors.As(err, &kerr) {
+		return kerr.IsRetriable()
+	}
+	for _, transient := range []error{
+		ErrOutOfBrokers,
+		ErrBrokerNotFound,
+		ErrNotConnected,
+		ErrControllerNotAvailable,
+		ErrIncompleteResponse,
+		io.EOF,
+		io.ErrUnexpectedEOF,
+	} {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -1,8 +1,10 @@
 package sarama
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"testing"
 )
@@ -63,3 +65,81 @@ func TestSentinelWithMultipleWrappedErrors(t *testing.T) {
 		t.Errorf("unwrapped value unexpected result")
 	}
 }
+
+func TestKErrorIsRetriable(t *testing.T) {
+	t.Parallel()
+	for _, kerr := range []KError{ErrNotLeaderForPartition, ErrRequestTimedOut, ErrNotCoordinatorForConsumer, ErrConcurrentTransactions, ErrUnknownTopicID} {
+		if !kerr.IsRetriable() {
+			t.Errorf("expected %d to be retriable", kerr)
+		}
+	}
+	for _, kerr := range []KError{ErrNoError, ErrUnknown, ErrOffsetOutOfRange, ErrBrokerNotAvailable, ErrRebalanceInProgress, ErrProducerFenced, ErrTopicAuthorizationFailed, KError(10000)} {
+		if kerr.IsRetriable() {
+			t.Errorf("expected %d not to be retriable", kerr)
+		}
+	}
+}
+
+func TestIsRetriable(t *testing.T) {
+	t.Parallel()
+	for i, err := range []error{
+		ErrLeaderNotAvailable,
+		fmt.Errorf("fetching metadata: %w", ErrNotEnoughReplicas),
+		Wrap(ErrOutOfBrokers, &net.OpError{Op: "dial", Err: errors.New("connection refused")}),
+		&RequestError{APIKey: 0, Err: io.EOF},
+		ErrNotConnected,
+		&net.DNSError{IsTimeout: true},
+	} {
+		if !IsRetriable(err) {
+			t.Errorf("[%d] expected %v to be retriable", i, err)
+		}
+	}
+	for i, err := range []error{
+		nil,
+		ErrInvalidProducerEpoch,
+		ConfigurationError("invalid"),
+		PacketDecodingError{Info: "invalid"},
+		ErrClosedClient,
+		Wrap(ErrTxnAbortable, ErrConcurrentTransactions),
+		Wrap(ErrTxnFatal, io.EOF),
+		fmt.Errorf("sending: %w", context.Canceled),
+		errors.New("unknown"),
+	} {
+		if IsRetriable(err) {
+			t.Errorf("[%d] expected %v not to be retriable", i, err)
+		}
+	}
+}
+
+func TestPartitionRequestError(t *testing.T) {
+	t.Parallel()
+	err := fmt.Errorf("committing: %w", &PartitionRequestError{
+		Topic:         "my_topic",
+		Partition:     1,
+		BrokerID:      2,
+		CorrelationID: 3,
+		Err:           ErrNotLeaderForPartition,
+	})
+
+	if !errors.Is(err, ErrNotLeaderForPartition) {
+		t.Error("Expected the error to match its KError")
+	}
+	if !errors.Is(err, &PartitionRequestError{Topic: "my_topic", Partition: 1}) {
+		t.Error("Expected the error to match its partition")
+	}
+	if errors.Is(err, &PartitionRequestError{Topic: "my_topic", Partition: 0}) {
+		t.Error("Expected the error not to match another partition")
+	}
+	var kerr KError
+	if !errors.As(err, &kerr) || kerr != ErrNotLeaderForPartition {
+		t.Errorf("Expected the error to unwrap to its KError, got %v", kerr)
+	}
+	if !IsRetriable(err) {
+		t.Error("Expected the error to be retriable")
+	}
+
+	expected := "committing: kafka: my_topic/1 on broker 2 (correlation ID 3): " + ErrNotLeaderForPartition.Error()
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+}
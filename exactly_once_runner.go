@@ -0,0 +1,262 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// TransformFunc transforms a batch of messages consumed from a partition
+// into the messages to produce, see ExactlyOnceRunner.
+type TransformFunc func(ctx context.Context, msgs []*ConsumerMessage) ([]*ProducerMessage, error)
+
+// ExactlyOnceRunner consumes messages with a ConsumerGroup, transforms them
+// and produces the result with a transactional AsyncProducer, so that each
+// consumed message is transformed and produced exactly once.
+//
+// The messages of each batch are produced in a transaction, along with the
+// offsets of the consumed messages and the metadata of the member of the
+// group, so that the transaction coordinator rejects the offsets of a member
+// fenced by a rebalance (KIP-447). A transaction failing with an abortable
+// error is aborted, and the consumption resumes from the last committed
+// offsets in a new session. The runner stops if the producer fails fatally,
+// for example because it was fenced by another producer with the same
+// transactional ID, or if the TransformFunc returns an error.
+//
+// The consumer group should be configured with Consumer.IsolationLevel set
+// to ReadCommitted if the consumed messages are themselves produced in
+// transactions. The runner owns the producer while it runs: the messages
+// and errors returned by the producer are drained by the runner.
+type ExactlyOnceRunner struct {
+	// BatchSize is the maximum number of messages transformed and produced
+	// in a transaction (defaults to 100).
+	BatchSize int
+	// BatchTimeout is how long to wait for a batch to fill up before
+	// producing it anyway (defaults to 100ms).
+	BatchTimeout time.Duration
+
+	groupID   string
+	group     ConsumerGroup
+	producer  AsyncProducer
+	transform TransformFunc
+	log       contextLogger
+
+	// txnLock serializes the transactions of the claims of a session, the
+	// producer having a single ongoing transaction
+	txnLock sync.Mutex
+
+	lock sync.Mutex
+	err  error
+	stop context.CancelFunc
+}
+
+// NewExactlyOnceRunner creates a runner transforming the messages consumed
+// by the group groupID with transform, and producing the results with the
+// producer, which must be transactional.
+func NewExactlyOnceRunner(groupID string, group ConsumerGroup, producer AsyncProducer, transform TransformFunc) (*ExactlyOnceRunner, error) {
+	if !producer.IsTransactional() {
+		return nil, ErrNonTransactedProducer
+	}
+	if transform == nil {
+		return nil, ConfigurationError("ExactlyOnceRunner requires a TransformFunc")
+	}
+	return &ExactlyOnceRunner{
+		BatchSize:    100,
+		BatchTimeout: 100 * time.Millisecond,
+		groupID:      groupID,
+		group:        group,
+		producer:     producer,
+		transform:    transform,
+		log:          newContextLogger("group", groupID),
+	}, nil
+}
+
+// Run consumes the topics until ctx is done, in which case it returns nil,
+// or until the runner fails, in which case it returns the error. The batches
+// being produced when ctx is done are committed or aborted before Run
+// returns.
+func (r *ExactlyOnceRunner) Run(ctx context.Context, topics []string) error {
+	if r.BatchSize <= 0 {
+		return ConfigurationError("ExactlyOnceRunner.BatchSize must be > 0")
+	}
+	if r.BatchTimeout <= 0 {
+		return ConfigurationError("ExactlyOnceRunner.BatchTimeout must be > 0")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r.lock.Lock()
+	r.err = nil
+	r.stop = cancel
+	r.lock.Unlock()
+
+	// the producer is drained until the last transaction ends
+	done, drained := make(chan struct{}), make(chan struct{})
+	go r.drain(done, drained)
+	defer func() {
+		close(done)
+		<-drained
+	}()
+
+	for {
+		err := r.group.Consume(ctx, topics, r)
+		if runErr := r.failure(); runErr != nil {
+			return runErr
+		}
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// drain reads the messages and errors returned by the producer, the failed
+// messages failing their transaction
+func (r *ExactlyOnceRunner) drain(done <-chan struct{}, drained chan<- struct{}) {
+	defer close(drained)
+	successes, failures := r.producer.Successes(), r.producer.Errors()
+	for {
+		select {
+		case _, ok := <-successes:
+			if !ok {
+				// the producer is closed, stop selecting the channel
+				successes = nil
+			}
+		case err, ok := <-failures:
+			if !ok {
+				failures = nil
+				continue
+			}
+			r.log.Warnf("exactly-once/runner failed to produce a message to %s/%d: %v", err.Msg.Topic, err.Msg.Partition, err.Err)
+		case <-done:
+			return
+		}
+	}
+}
+
+// fail stops the runner because of err
+func (r *ExactlyOnceRunner) fail(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+	r.stop()
+}
+
+func (r *ExactlyOnceRunner) failure() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+// Setup implements ConsumerGroupHandler.
+func (r *ExactlyOnceRunner) Setup(ConsumerGroupSession) error { return nil }
+
+// Cleanup implements ConsumerGroupHandler.
+func (r *ExactlyOnceRunner) Cleanup(ConsumerGroupSession) error { return nil }
+
+// ConsumeClaim implements ConsumerGroupHandler, producing the messages of
+// the claim in batches. It returns when a transaction is aborted, which ends
+// the session so that the messages of the aborted transaction are consumed
+// again from the committed offsets.
+func (r *ExactlyOnceRunner) ConsumeClaim(session ConsumerGroupSession, claim ConsumerGroupClaim) error {
+	var batch []*ConsumerMessage
+	var timeout <-chan time.Time
+	timer := time.NewTimer(r.BatchTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				// the messages of the partial batch are consumed again by
+				// the next owner of the partition
+				return nil
+			}
+			batch = append(batch, msg)
+			if len(batch) == 1 {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(r.BatchTimeout)
+				timeout = timer.C
+			}
+			if len(batch) < r.BatchSize {
+				continue
+			}
+		case <-timeout:
+		case <-session.Context().Done():
+			return nil
+		}
+
+		if err := r.process(session, batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		timeout = nil
+	}
+}
+
+// process transforms and produces a batch of messages of a partition in a
+// transaction, along with their offsets
+func (r *ExactlyOnceRunner) process(session ConsumerGroupSession, batch []*ConsumerMessage) error {
+	r.txnLock.Lock()
+	defer r.txnLock.Unlock()
+
+	if err := r.producer.BeginTxn(); err != nil {
+		// the previous transaction failed to be aborted
+		r.fail(err)
+		return err
+	}
+
+	msgs, err := r.transform(session.Context(), batch)
+	if err != nil {
+		r.abort(err)
+		r.fail(err)
+		return err
+	}
+	for _, msg := range msgs {
+		r.producer.Input() <- msg
+	}
+
+	last := batch[len(batch)-1]
+	offsets := map[string][]*PartitionOffsetMetadata{
+		last.Topic: {{
+			Partition:   last.Partition,
+			Offset:      last.Offset + 1,
+			LeaderEpoch: -1,
+		}},
+	}
+	if err = r.producer.AddGroupOffsetsToTxn(offsets, NewConsumerGroupMetadata(r.groupID, session)); err == nil {
+		err = r.producer.CommitTxn()
+	}
+	if err != nil {
+		if errors.Is(err, ErrTxnFatal) || txnFatal(err) {
+			r.fail(err)
+			return err
+		}
+		if abortErr := r.abort(err); abortErr != nil {
+			r.fail(abortErr)
+			return abortErr
+		}
+		return err
+	}
+	return nil
+}
+
+// abort aborts the ongoing transaction, which failed because of err
+func (r *ExactlyOnceRunner) abort(err error) error {
+	r.log.Warnf("exactly-once/runner aborting the transaction: %v", err)
+	if abortErr := r.producer.AbortTxn(); abortErr != nil {
+		r.log.Errorf("exactly-once/runner failed to abort the transaction: %v", abortErr)
+		return abortErr
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newExactlyOnceBroker(t *testing.T) (*MockBroker, *MockTransactionCoordinator, *MockGroupCoordinator) {
+	broker := NewMockBroker(t, 0)
+	coordinator := NewMockTransactionCoordinator(t, broker)
+	groups := NewMockGroupCoordinator(t, broker)
+	coordinator.SetGroupCoordinator(groups)
+
+	broker.SetHandlerByMap(coordinator.RegisterHandlers(groups.RegisterHandlers(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("my_topic", 0, broker.BrokerID()).
+			SetLeader("out_topic", 0, broker.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).SetVersion(1).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 3),
+		"FetchRequest": NewMockFetchResponse(t, 1).SetVersion(4).
+			SetMessage("my_topic", 0, 0, StringEncoder("foo")).
+			SetMessage("my_topic", 0, 1, StringEncoder("bar")).
+			SetMessage("my_topic", 0, 2, StringEncoder("baz")),
+		"ProduceRequest": NewMockProduceResponse(t).SetVersion(3),
+	})))
+	return broker, coordinator, groups
+}
+
+func newExactlyOnceRunner(t *testing.T, broker *MockBroker, producerVersion KafkaVersion, transform TransformFunc) (*ExactlyOnceRunner, ConsumerGroup, AsyncProducer) {
+	groupConfig := newMockGroupsConfig()
+	groupConfig.Version = V0_11_0_0
+	groupConfig.Consumer.IsolationLevel = ReadCommitted
+	group, err := NewConsumerGroup([]string{broker.Addr()}, "my_group", groupConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producerConfig := newTxnProducerConfig("txn")
+	producerConfig.Version = producerVersion
+	producer, err := NewAsyncProducer([]string{broker.Addr()}, producerConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := NewExactlyOnceRunner("my_group", group, producer, transform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.BatchSize = 2
+	runner.BatchTimeout = 10 * time.Millisecond
+	return runner, group, producer
+}
+
+func upperCase(ctx context.Context, msgs []*ConsumerMessage) ([]*ProducerMessage, error) {
+	out := make([]*ProducerMessage, len(msgs))
+	for i, msg := range msgs {
+		out[i] = &ProducerMessage{Topic: "out_topic", Value: StringEncoder(strings.ToUpper(string(msg.Value)))}
+	}
+	return out, nil
+}
+
+func TestExactlyOnceRunnerCommits(t *testing.T) {
+	broker, coordinator, groups := newExactlyOnceBroker(t)
+	defer broker.Close()
+
+	transformed := make(chan int, 10)
+	runner, group, producer := newExactlyOnceRunner(t, broker, V0_11_0_0, func(ctx context.Context, msgs []*ConsumerMessage) ([]*ProducerMessage, error) {
+		transformed <- len(msgs)
+		return upperCase(ctx, msgs)
+	})
+	defer safeClose(t, producer)
+	defer safeClose(t, group)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- runner.Run(ctx, []string{"my_topic"}) }()
+
+	// a full batch, then a batch flushed by the timeout
+	for _, expected := range []int{2, 1} {
+		if n := <-transformed; n != expected {
+			t.Errorf("expected a batch of %d messages, got %d", expected, n)
+		}
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if offset, _ := groups.Offset("my_group", "my_topic", 0); offset == 3 {
+			break
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+	if offset, ok := groups.Offset("my_group", "my_topic", 0); !ok || offset != 3 {
+		t.Errorf("expected committed offset 3, got %d (%v)", offset, ok)
+	}
+	if results := coordinator.Results("txn"); len(results) != 2 || !results[0] || !results[1] {
+		t.Errorf("expected 2 committed transactions, got %v", results)
+	}
+}
+
+func TestExactlyOnceRunnerTransformError(t *testing.T) {
+	broker, coordinator, groups := newExactlyOnceBroker(t)
+	defer broker.Close()
+
+	errTransform := errors.New("transform failed")
+	runner, group, producer := newExactlyOnceRunner(t, broker, V0_11_0_0, func(ctx context.Context, msgs []*ConsumerMessage) ([]*ProducerMessage, error) {
+		return nil, errTransform
+	})
+	defer safeClose(t, producer)
+	defer safeClose(t, group)
+
+	if err := runner.Run(context.Background(), []string{"my_topic"}); !errors.Is(err, errTransform) {
+		t.Errorf("expected the transform error, got %v", err)
+	}
+	if _, ok := groups.Offset("my_group", "my_topic", 0); ok {
+		t.Error("expected no committed offset")
+	}
+	if results := coordinator.Results("txn"); len(results) != 0 {
+		t.Errorf("expected no ended transaction, got %v", results)
+	}
+}
+
+func TestExactlyOnceRunnerFenced(t *testing.T) {
+	broker, _, groups := newExactlyOnceBroker(t)
+	defer broker.Close()
+
+	fenced := make(chan struct{})
+	var fencing AsyncProducer
+	runner, group, producer := newExactlyOnceRunner(t, broker, V0_11_0_0, func(ctx context.Context, msgs []*ConsumerMessage) ([]*ProducerMessage, error) {
+		// another producer with the same transactional ID starts
+		fencing = newTxnProducer(t, broker, "txn")
+		close(fenced)
+		return upperCase(ctx, msgs)
+	})
+	defer safeClose(t, group)
+
+	if err := runner.Run(context.Background(), []string{"my_topic"}); !errors.Is(err, ErrTxnFatal) {
+		t.Errorf("expected ErrTxnFatal, got %v", err)
+	}
+	<-fenced
+	safeClose(t, fencing)
+	// the messages of the fenced producer fail
+	_ = producer.Close()
+	if _, ok := groups.Offset("my_group", "my_topic", 0); ok {
+		t.Error("expected no committed offset")
+	}
+}
+
+func TestExactlyOnceRunnerFencedConsumer(t *testing.T) {
+	broker, coordinator, groups := newExactlyOnceBroker(t)
+	defer broker.Close()
+
+	var batches int
+	// the group metadata is only sent to the coordinator by producers of
+	// v2.5.0 or later
+	runner, group, producer := newExactlyOnceRunner(t, broker, V2_5_0_0, func(ctx context.Context, msgs []*ConsumerMessage) ([]*ProducerMessage, error) {
+		batches++
+		if batches == 1 {
+			// the member is fenced by a rebalance before its offsets are
+			// added to the transaction
+			groups.Rebalance("my_group")
+		}
+		return upperCase(ctx, msgs)
+	})
+	defer safeClose(t, producer)
+	defer safeClose(t, group)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- runner.Run(ctx, []string{"my_topic"}) }()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if offset, _ := groups.Offset("my_group", "my_topic", 0); offset == 3 {
+			break
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+
+	if offset, ok := groups.Offset("my_group", "my_topic", 0); !ok || offset != 3 {
+		t.Errorf("expected committed offset 3, got %d (%v)", offset, ok)
+	}
+	// the transaction of the fenced member is aborted, and its messages are
+	// consumed again in the next generation
+	if results := coordinator.Results("txn"); len(results) < 2 || results[0] || !results[len(results)-1] {
+		t.Errorf("expected an aborted then committed transactions, got %v", results)
+	}
+}
+
+func TestExactlyOnceRunnerRequiresTransactionalProducer(t *testing.T) {
+	broker, _, _ := newExactlyOnceBroker(t)
+	defer broker.Close()
+
+	producer := newTxnProducer(t, broker, "")
+	defer safeClose(t, producer)
+
+	if _, err := NewExactlyOnceRunner("my_group", nil, producer, upperCase); !errors.Is(err, ErrNonTransactedProducer) {
+		t.Errorf("expected ErrNonTransactedProducer, got %v", err)
+	}
+}
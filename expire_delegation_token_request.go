@@ -0,0 +1,59 @@
+package sarama
+
+import "time"
+
+// ExpireDelegationTokenRequest is a request to change the expiry time of a
+// delegation token
+type ExpireDelegationTokenRequest struct {
+	// Version 0 and 1 are equal
+	// The version number is bumped to indicate that on quota violation brokers send out responses before throttling.
+	Version int16
+
+	HMAC []byte
+
+	// ExpiryTimePeriod is the period after which the token expires, a negative
+	// period expires the token immediately
+	ExpiryTimePeriod time.Duration
+}
+
+func (r *ExpireDelegationTokenRequest) encode(pe packetEncoder) error {
+	if err := pe.putBytes(r.HMAC); err != nil {
+		return err
+	}
+	pe.putInt64(durationToMillis(r.ExpiryTimePeriod))
+	return nil
+}
+
+func (r *ExpireDelegationTokenRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.HMAC, err = pd.getBytes(); err != nil {
+		return err
+	}
+
+	expiryTimePeriod, err := pd.getInt64()
+	if err != nil {
+		return err
+	}
+	r.ExpiryTimePeriod = millisToDuration(expiryTimePeriod)
+	return nil
+}
+
+func (r *ExpireDelegationTokenRequest) key() int16 {
+	return 40
+}
+
+func (r *ExpireDelegationTokenRequest) version() int16 {
+	return r.Version
+}
+
+func (r *ExpireDelegationTokenRequest) headerVersion() int16 {
+	return 1
+}
+
+func (r *ExpireDelegationTokenRequest) requiredVersion() KafkaVersion {
+	if r.Version > 0 {
+		return V2_0_0_0
+	}
+	return V1_1_0_0
+}
@@ -0,0 +1,32 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var expireDelegationTokenRequest = []byte{
+	0, 0, 0, 3, 1, 2, 3, // hmac
+	0, 0, 0, 0, 5, 38, 92, 0, // period (1 day)
+}
+
+func TestExpireDelegationTokenRequest(t *testing.T) {
+	request := &ExpireDelegationTokenRequest{
+		Version:          1,
+		HMAC:             []byte{1, 2, 3},
+		ExpiryTimePeriod: 24 * time.Hour,
+	}
+	testRequest(t, "basic", request, expireDelegationTokenRequest)
+}
+
+func TestExpireDelegationTokenRequestImmediately(t *testing.T) {
+	request := &ExpireDelegationTokenRequest{
+		Version:          1,
+		HMAC:             []byte{1, 2, 3},
+		ExpiryTimePeriod: -1,
+	}
+	testRequest(t, "expire immediately", request, []byte{
+		0, 0, 0, 3, 1, 2, 3, // hmac
+		255, 255, 255, 255, 255, 255, 255, 255, // period (-1)
+	})
+}
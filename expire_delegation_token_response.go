@@ -0,0 +1,63 @@
+package sarama
+
+import "time"
+
+// ExpireDelegationTokenResponse is the response to a ExpireDelegationTokenRequest
+type ExpireDelegationTokenResponse struct {
+	// Version 0 and 1 are equal
+	// The version number is bumped to indicate that on quota violation brokers send out responses before throttling.
+	Version int16
+
+	ErrorCode    KError
+	ExpiryTime   time.Time
+	ThrottleTime time.Duration
+}
+
+func (r *ExpireDelegationTokenResponse) encode(pe packetEncoder) error {
+	pe.putInt16(int16(r.ErrorCode))
+	if err := (Timestamp{&r.ExpiryTime}).encode(pe); err != nil {
+		return err
+	}
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	return nil
+}
+
+func (r *ExpireDelegationTokenResponse) decode(pd packetDecoder, version int16) error {
+	r.Version = version
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.ErrorCode = KError(kerr)
+
+	if err := (Timestamp{&r.ExpiryTime}).decode(pd); err != nil {
+		return err
+	}
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+	return nil
+}
+
+func (r *ExpireDelegationTokenResponse) key() int16 {
+	return 40
+}
+
+func (r *ExpireDelegationTokenResponse) version() int16 {
+	return r.Version
+}
+
+func (r *ExpireDelegationTokenResponse) headerVersion() int16 {
+	return 0
+}
+
+func (r *ExpireDelegationTokenResponse) requiredVersion() KafkaVersion {
+	if r.Version > 0 {
+		return V2_0_0_0
+	}
+	return V1_1_0_0
+}
@@ -4,6 +4,7 @@ type fetchRequestBlock struct {
 	Version            int16
 	currentLeaderEpoch int32
 	fetchOffset        int64
+	lastFetchedEpoch   int32
 	logStartOffset     int64
 	maxBytes           int32
 }
@@ -14,10 +15,16 @@ func (b *fetchRequestBlock) encode(pe packetEncoder, version int16) error {
 		pe.putInt32(b.currentLeaderEpoch)
 	}
 	pe.putInt64(b.fetchOffset)
+	if b.Version >= 12 {
+		pe.putInt32(b.lastFetchedEpoch)
+	}
 	if b.Version >= 5 {
 		pe.putInt64(b.logStartOffset)
 	}
 	pe.putInt32(b.maxBytes)
+	if b.Version >= 12 {
+		pe.putEmptyTaggedFieldArray()
+	}
 	return nil
 }
 
@@ -31,6 +38,11 @@ func (b *fetchRequestBlock) decode(pd packetDecoder, version int16) (err error)
 	if b.fetchOffset, err = pd.getInt64(); err != nil {
 		return err
 	}
+	if b.Version >= 12 {
+		if b.lastFetchedEpoch, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
 	if b.Version >= 5 {
 		if b.logStartOffset, err = pd.getInt64(); err != nil {
 			return err
@@ -39,6 +51,11 @@ func (b *fetchRequestBlock) decode(pd packetDecoder, version int16) (err error)
 	if b.maxBytes, err = pd.getInt32(); err != nil {
 		return err
 	}
+	if b.Version >= 12 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -56,6 +73,14 @@ type FetchRequest struct {
 	blocks       map[string]map[int32]*fetchRequestBlock
 	forgotten    map[string][]int32
 	RackID       string
+	// TopicIDs maps the topics of the request to their IDs, which replace
+	// their names from version 13 (KIP-516). The topics of a decoded request
+	// are named after the String of their ID.
+	TopicIDs map[string]Uuid
+
+	// deferRecords defers the decoding of the records of the response to
+	// the consumer, see Consumer.Fetch.LazyBatchDecoding
+	deferRecords bool
 }
 
 type IsolationLevel int8
@@ -65,6 +90,10 @@ const (
 	ReadCommitted
 )
 
+func (r *FetchRequest) isFlexible() bool {
+	return r.Version >= 12
+}
+
 func (r *FetchRequest) encode(pe packetEncoder) (err error) {
 	pe.putInt32(-1) // replica ID is always -1 for clients
 	pe.putInt32(r.MaxWaitTime)
@@ -79,17 +108,14 @@ func (r *FetchRequest) encode(pe packetEncoder) (err error) {
 		pe.putInt32(r.SessionID)
 		pe.putInt32(r.SessionEpoch)
 	}
-	err = pe.putArrayLength(len(r.blocks))
-	if err != nil {
+	if err = r.putArrayLength(pe, len(r.blocks)); err != nil {
 		return err
 	}
 	for topic, blocks := range r.blocks {
-		err = pe.putString(topic)
-		if err != nil {
+		if err = r.putTopic(pe, topic); err != nil {
 			return err
 		}
-		err = pe.putArrayLength(len(blocks))
-		if err != nil {
+		if err = r.putArrayLength(pe, len(blocks)); err != nil {
 			return err
 		}
 		for partition, block := range blocks {
@@ -99,36 +125,69 @@ func (r *FetchRequest) encode(pe packetEncoder) (err error) {
 				return err
 			}
 		}
+		if r.isFlexible() {
+			pe.putEmptyTaggedFieldArray()
+		}
 	}
 	if r.Version >= 7 {
-		err = pe.putArrayLength(len(r.forgotten))
-		if err != nil {
+		if err = r.putArrayLength(pe, len(r.forgotten)); err != nil {
 			return err
 		}
 		for topic, partitions := range r.forgotten {
-			err = pe.putString(topic)
-			if err != nil {
+			if err = r.putTopic(pe, topic); err != nil {
 				return err
 			}
-			err = pe.putArrayLength(len(partitions))
-			if err != nil {
+			if err = r.putArrayLength(pe, len(partitions)); err != nil {
 				return err
 			}
 			for _, partition := range partitions {
 				pe.putInt32(partition)
 			}
+			if r.isFlexible() {
+				pe.putEmptyTaggedFieldArray()
+			}
 		}
 	}
 	if r.Version >= 11 {
-		err = pe.putString(r.RackID)
+		if r.isFlexible() {
+			err = pe.putCompactString(r.RackID)
+		} else {
+			err = pe.putString(r.RackID)
+		}
 		if err != nil {
 			return err
 		}
 	}
+	if r.isFlexible() {
+		pe.putEmptyTaggedFieldArray()
+	}
 
 	return nil
 }
 
+func (r *FetchRequest) putArrayLength(pe packetEncoder, length int) error {
+	if r.isFlexible() {
+		pe.putCompactArrayLength(length)
+		return nil
+	}
+	return pe.putArrayLength(length)
+}
+
+// putTopic puts the name of the topic, or its ID from version 13
+func (r *FetchRequest) putTopic(pe packetEncoder, topic string) error {
+	if r.Version >= 13 {
+		id, ok := r.TopicIDs[topic]
+		if !ok || id == NullUuid {
+			return PacketEncodingError{"FetchRequest v13 requires the ID of the topic " + topic}
+		}
+		return putUuid(pe, id)
+	}
+	if r.isFlexible() {
+		return pe.putCompactString(topic)
+	}
+	return pe.putString(topic)
+}
+
 func (r *FetchRequest) decode(pd packetDecoder, version int16) (err error) {
 	r.Version = version
 
@@ -163,20 +222,19 @@ func (r *FetchRequest) decode(pd packetDecoder, version int16) (err error) {
 			return err
 		}
 	}
-	topicCount, err := pd.getArrayLength()
+	topicCount, err := r.getArrayLength(pd)
 	if err != nil {
 		return err
 	}
-	if topicCount == 0 {
-		return nil
+	if topicCount > 0 {
+		r.blocks = make(map[string]map[int32]*fetchRequestBlock)
 	}
-	r.blocks = make(map[string]map[int32]*fetchRequestBlock)
 	for i := 0; i < topicCount; i++ {
-		topic, err := pd.getString()
+		topic, err := r.getTopic(pd)
 		if err != nil {
 			return err
 		}
-		partitionCount, err := pd.getArrayLength()
+		partitionCount, err := r.getArrayLength(pd)
 		if err != nil {
 			return err
 		}
@@ -192,20 +250,25 @@ func (r *FetchRequest) decode(pd packetDecoder, version int16) (err error) {
 			}
 			r.blocks[topic][partition] = fetchBlock
 		}
+		if r.isFlexible() {
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
 	}
 
 	if r.Version >= 7 {
-		forgottenCount, err := pd.getArrayLength()
+		forgottenCount, err := r.getArrayLength(pd)
 		if err != nil {
 			return err
 		}
 		r.forgotten = make(map[string][]int32)
 		for i := 0; i < forgottenCount; i++ {
-			topic, err := pd.getString()
+			topic, err := r.getTopic(pd)
 			if err != nil {
 				return err
 			}
-			partitionCount, err := pd.getArrayLength()
+			partitionCount, err := r.getArrayLength(pd)
 			if err != nil {
 				return err
 			}
@@ -218,19 +281,60 @@ func (r *FetchRequest) decode(pd packetDecoder, version int16) (err error) {
 				}
 				r.forgotten[topic][j] = partition
 			}
+			if r.isFlexible() {
+				if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
 	if r.Version >= 11 {
-		r.RackID, err = pd.getString()
+		if r.isFlexible() {
+			r.RackID, err = pd.getCompactString()
+		} else {
+			r.RackID, err = pd.getString()
+		}
 		if err != nil {
 			return err
 		}
 	}
+	if r.isFlexible() {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+func (r *FetchRequest) getArrayLength(pd packetDecoder) (int, error) {
+	if r.isFlexible() {
+		return pd.getCompactArrayLength()
+	}
+	return pd.getArrayLength()
+}
+
+// getTopic returns the name of the topic, or the String of its ID from
+// version 13
+func (r *FetchRequest) getTopic(pd packetDecoder) (string, error) {
+	if r.Version >= 13 {
+		id, err := getUuid(pd)
+		if err != nil {
+			return "", err
+		}
+		if r.TopicIDs == nil {
+			r.TopicIDs = make(map[string]Uuid)
+		}
+		r.TopicIDs[id.String()] = id
+		return id.String(), nil
+	}
+	if r.isFlexible() {
+		return pd.getCompactString()
+	}
+	return pd.getString()
+}
+
 func (r *FetchRequest) key() int16 {
 	return 1
 }
@@ -239,7 +343,64 @@ func (r *FetchRequest) version() int16 {
 	return r.Version
 }
 
+func (r *FetchRequest) versionRange() (minVersion, maxVersion int16) {
+	if r.Version >= 13 {
+		// the topics are identified by their IDs
+		return 13, 13
+	}
+	maxVersion = 12
+	if r.Version >= 4 && r.Isolation == ReadCommitted {
+		minVersion = 4
+	}
+	if r.Version >= 7 && (r.SessionID != 0 || r.SessionEpoch != -1) {
+		minVersion = 7
+	}
+	if r.Version >= 11 && r.RackID != "" {
+		minVersion = 11
+	}
+	return minVersion, maxVersion
+}
+
+func (r *FetchRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	if r.Version < 3 && request.MaxBytes == 0 {
+		request.MaxBytes = MaxResponseSize
+	}
+	if r.Version < 4 {
+		request.Isolation = ReadUncommitted
+	}
+	if r.Version < 7 {
+		// a full fetch outside of any fetch session
+		request.SessionID = 0
+		request.SessionEpoch = -1
+	}
+	if r.Version < 11 {
+		request.RackID = ""
+	}
+
+	request.blocks = make(map[string]map[int32]*fetchRequestBlock, len(r.blocks))
+	for topic, partitions := range r.blocks {
+		request.blocks[topic] = make(map[int32]*fetchRequestBlock, len(partitions))
+		for partition, block := range partitions {
+			tmp := *block
+			tmp.Version = v
+			if r.Version < 9 {
+				tmp.currentLeaderEpoch = -1
+			}
+			if r.Version < 12 {
+				tmp.lastFetchedEpoch = -1
+			}
+			request.blocks[topic][partition] = &tmp
+		}
+	}
+	return &request
+}
+
 func (r *FetchRequest) headerVersion() int16 {
+	if r.isFlexible() {
+		return 2
+	}
 	return 1
 }
 
@@ -265,6 +426,10 @@ func (r *FetchRequest) requiredVersion() KafkaVersion {
 		return V2_1_0_0
 	case 11:
 		return V2_3_0_0
+	case 12:
+		return V2_7_0_0
+	case 13:
+		return V3_1_0_0
 	default:
 		return MaxVersion
 	}
@@ -290,6 +455,9 @@ func (r *FetchRequest) AddBlock(topic string, partitionID int32, fetchOffset int
 	if r.Version >= 9 {
 		tmp.currentLeaderEpoch = int32(-1)
 	}
+	if r.Version >= 12 {
+		tmp.lastFetchedEpoch = int32(-1)
+	}
 
 	r.blocks[topic][partitionID] = tmp
 }
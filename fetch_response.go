@@ -13,7 +13,7 @@ type AbortedTransaction struct {
 	FirstOffset int64
 }
 
-func (t *AbortedTransaction) decode(pd packetDecoder) (err error) {
+func (t *AbortedTransaction) decode(pd packetDecoder, version int16) (err error) {
 	if t.ProducerID, err = pd.getInt64(); err != nil {
 		return err
 	}
@@ -22,13 +22,23 @@ func (t *AbortedTransaction) decode(pd packetDecoder) (err error) {
 		return err
 	}
 
+	if version >= 12 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (t *AbortedTransaction) encode(pe packetEncoder) (err error) {
+func (t *AbortedTransaction) encode(pe packetEncoder, version int16) (err error) {
 	pe.putInt64(t.ProducerID)
 	pe.putInt64(t.FirstOffset)
 
+	if version >= 12 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
@@ -43,36 +53,104 @@ type FetchResponseBlock struct {
 	Records                *Records // deprecated: use FetchResponseBlock.RecordsSet
 	RecordsSet             []*Records
 	Partial                bool
+
+	// correlationID is the correlation ID of the request of the block, which
+	// the consumer attaches to its error
+	correlationID int32
+	// stream reads the records of the block off the connection in place of
+	// RecordsSet, see decodeFetchResponse
+	stream *recordsStream
 }
 
 func (b *FetchResponseBlock) decode(pd packetDecoder, version int16) (err error) {
-	tmp, err := pd.getInt16()
+	recordsSize, err := b.decodeHeader(pd, version)
 	if err != nil {
 		return err
 	}
+
+	recordsDecoder, err := pd.getSubset(recordsSize)
+	if err != nil {
+		return err
+	}
+	if version >= 12 {
+		// skip the tagged fields, such as the diverging epoch, following
+		// the records
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
+	b.RecordsSet = []*Records{}
+
+	for recordsDecoder.remaining() > 0 {
+		records := &Records{}
+		if err := records.decode(recordsDecoder); err != nil {
+			// If we have at least one decoded records, this is not an error
+			if errors.Is(err, ErrInsufficientData) {
+				if len(b.RecordsSet) == 0 {
+					b.Partial = true
+				}
+				break
+			}
+			return err
+		}
+
+		keep, last, err := b.checkRecords(records, len(b.RecordsSet) == 0)
+		if err != nil {
+			return err
+		}
+
+		if keep {
+			b.RecordsSet = append(b.RecordsSet, records)
+
+			if b.Records == nil {
+				b.Records = records
+			}
+		}
+
+		if last {
+			break
+		}
+	}
+
+	return nil
+}
+
+// decodeHeader decodes the fields of the block up to its records, returning
+// their size
+func (b *FetchResponseBlock) decodeHeader(pd packetDecoder, version int16) (recordsSize int, err error) {
+	tmp, err := pd.getInt16()
+	if err != nil {
+		return 0, err
+	}
 	b.Err = KError(tmp)
 
 	b.HighWaterMarkOffset, err = pd.getInt64()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if version >= 4 {
 		b.LastStableOffset, err = pd.getInt64()
 		if err != nil {
-			return err
+			return 0, err
 		}
 
 		if version >= 5 {
 			b.LogStartOffset, err = pd.getInt64()
 			if err != nil {
-				return err
+				return 0, err
 			}
 		}
 
-		numTransact, err := pd.getArrayLength()
+		var numTransact int
+		if version >= 12 {
+			numTransact, err = pd.getCompactArrayLength()
+		} else {
+			numTransact, err = pd.getArrayLength()
+		}
 		if err != nil {
-			return err
+			return 0, err
 		}
 
 		if numTransact >= 0 {
@@ -81,8 +159,8 @@ func (b *FetchResponseBlock) decode(pd packetDecoder, version int16) (err error)
 
 		for i := 0; i < numTransact; i++ {
 			transact := new(AbortedTransaction)
-			if err = transact.decode(pd); err != nil {
-				return err
+			if err = transact.decode(pd, version); err != nil {
+				return 0, err
 			}
 			b.AbortedTransactions[i] = transact
 		}
@@ -91,71 +169,57 @@ func (b *FetchResponseBlock) decode(pd packetDecoder, version int16) (err error)
 	if version >= 11 {
 		b.PreferredReadReplica, err = pd.getInt32()
 		if err != nil {
-			return err
+			return 0, err
 		}
 	} else {
 		b.PreferredReadReplica = -1
 	}
 
-	recordsSize, err := pd.getInt32()
-	if err != nil {
-		return err
-	}
-
-	recordsDecoder, err := pd.getSubset(int(recordsSize))
-	if err != nil {
-		return err
-	}
-
-	b.RecordsSet = []*Records{}
-
-	for recordsDecoder.remaining() > 0 {
-		records := &Records{}
-		if err := records.decode(recordsDecoder); err != nil {
-			// If we have at least one decoded records, this is not an error
-			if errors.Is(err, ErrInsufficientData) {
-				if len(b.RecordsSet) == 0 {
-					b.Partial = true
-				}
-				break
-			}
-			return err
-		}
-
-		b.LastRecordsBatchOffset, err = records.recordsOffset()
+	if version >= 12 {
+		// compact nullable records, 0 being null
+		size, err := pd.getUVarint()
 		if err != nil {
-			return err
+			return 0, err
 		}
-
-		partial, err := records.isPartial()
-		if err != nil {
-			return err
+		if size > 0 {
+			recordsSize = int(size - 1)
 		}
-
-		n, err := records.numRecords()
+	} else {
+		size, err := pd.getInt32()
 		if err != nil {
-			return err
+			return 0, err
 		}
+		recordsSize = int(size)
+	}
 
-		if n > 0 || (partial && len(b.RecordsSet) == 0) {
-			b.RecordsSet = append(b.RecordsSet, records)
+	return recordsSize, nil
+}
 
-			if b.Records == nil {
-				b.Records = records
-			}
-		}
+// checkRecords updates the block with a records set decoded for it, first
+// being whether it is the first one kept, and returns whether to keep it and
+// whether the records following it are to be ignored
+func (b *FetchResponseBlock) checkRecords(records *Records, first bool) (keep, last bool, err error) {
+	b.LastRecordsBatchOffset, err = records.recordsOffset()
+	if err != nil {
+		return false, false, err
+	}
 
-		overflow, err := records.isOverflow()
-		if err != nil {
-			return err
-		}
+	partial, err := records.isPartial()
+	if err != nil {
+		return false, false, err
+	}
 
-		if partial || overflow {
-			break
-		}
+	n, err := records.numRecords()
+	if err != nil {
+		return false, false, err
 	}
 
-	return nil
+	overflow, err := records.isOverflow()
+	if err != nil {
+		return false, false, err
+	}
+
+	return n > 0 || (partial && first), partial || overflow, nil
 }
 
 func (b *FetchResponseBlock) numRecords() (int, error) {
@@ -197,11 +261,13 @@ func (b *FetchResponseBlock) encode(pe packetEncoder, version int16) (err error)
 			pe.putInt64(b.LogStartOffset)
 		}
 
-		if err = pe.putArrayLength(len(b.AbortedTransactions)); err != nil {
+		if version >= 12 {
+			pe.putCompactArrayLength(len(b.AbortedTransactions))
+		} else if err = pe.putArrayLength(len(b.AbortedTransactions)); err != nil {
 			return err
 		}
 		for _, transact := range b.AbortedTransactions {
-			if err = transact.encode(pe); err != nil {
+			if err = transact.encode(pe, version); err != nil {
 				return err
 			}
 		}
@@ -211,6 +277,22 @@ func (b *FetchResponseBlock) encode(pe packetEncoder, version int16) (err error)
 		pe.putInt32(b.PreferredReadReplica)
 	}
 
+	if version >= 12 {
+		var raw []byte
+		for _, records := range b.RecordsSet {
+			buf, err := encode(records, pe.metricsRecorder())
+			if err != nil {
+				return err
+			}
+			raw = append(raw, buf...)
+		}
+		if err = pe.putCompactBytes(raw); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+		return nil
+	}
+
 	pe.push(&lengthField{})
 	for _, records := range b.RecordsSet {
 		err = records.encode(pe)
@@ -240,43 +322,35 @@ type FetchResponse struct {
 	Version       int16
 	LogAppendTime bool
 	Timestamp     time.Time
+	// TopicIDs maps the topics of the response to their IDs, which replace
+	// their names from version 13 (KIP-516). Decoding resolves the names of
+	// the IDs it already contains, those of the FetchRequest when the
+	// response is returned by Broker.Fetch, the other topics being named
+	// after the String of their ID.
+	TopicIDs map[string]Uuid
+
+	// deferRecords keeps the records of the batches raw until they are
+	// consumed, see Consumer.Fetch.LazyBatchDecoding
+	deferRecords bool
 }
 
 func (r *FetchResponse) decode(pd packetDecoder, version int16) (err error) {
 	r.Version = version
 
-	if r.Version >= 1 {
-		throttle, err := pd.getInt32()
-		if err != nil {
-			return err
-		}
-		r.ThrottleTime = time.Duration(throttle) * time.Millisecond
-	}
-
-	if r.Version >= 7 {
-		r.ErrorCode, err = pd.getInt16()
-		if err != nil {
-			return err
-		}
-		r.SessionID, err = pd.getInt32()
-		if err != nil {
-			return err
-		}
-	}
-
-	numTopics, err := pd.getArrayLength()
+	numTopics, err := r.decodeHeader(pd)
 	if err != nil {
 		return err
 	}
 
+	names := r.topicNames(numTopics)
 	r.Blocks = make(map[string]map[int32]*FetchResponseBlock, numTopics)
 	for i := 0; i < numTopics; i++ {
-		name, err := pd.getString()
+		name, err := r.decodeTopic(pd, names)
 		if err != nil {
 			return err
 		}
 
-		numBlocks, err := pd.getArrayLength()
+		numBlocks, err := r.getArrayLength(pd)
 		if err != nil {
 			return err
 		}
@@ -296,11 +370,98 @@ func (r *FetchResponse) decode(pd packetDecoder, version int16) (err error) {
 			}
 			r.Blocks[name][id] = block
 		}
+
+		if r.Version >= 12 {
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.Version >= 12 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// decodeHeader decodes the fields of the response preceding its topics,
+// returning their number
+func (r *FetchResponse) decodeHeader(pd packetDecoder) (numTopics int, err error) {
+	if r.Version >= 1 {
+		throttle, err := pd.getInt32()
+		if err != nil {
+			return 0, err
+		}
+		r.ThrottleTime = time.Duration(throttle) * time.Millisecond
+	}
+
+	if r.Version >= 7 {
+		r.ErrorCode, err = pd.getInt16()
+		if err != nil {
+			return 0, err
+		}
+		r.SessionID, err = pd.getInt32()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return r.getArrayLength(pd)
+}
+
+// topicNames maps the IDs of TopicIDs to their names from version 13,
+// resetting TopicIDs for the numTopics topics of the response
+func (r *FetchResponse) topicNames(numTopics int) map[Uuid]string {
+	if r.Version < 13 {
+		return nil
+	}
+	names := make(map[Uuid]string, len(r.TopicIDs))
+	for name, id := range r.TopicIDs {
+		names[id] = name
+	}
+	r.TopicIDs = make(map[string]Uuid, numTopics)
+	return names
+}
+
+// decodeTopic decodes the name of a topic of the response, or its ID from
+// version 13, named after names
+func (r *FetchResponse) decodeTopic(pd packetDecoder, names map[Uuid]string) (name string, err error) {
+	if r.Version >= 13 {
+		id, err := getUuid(pd)
+		if err != nil {
+			return "", err
+		}
+		var ok bool
+		if name, ok = names[id]; !ok {
+			name = id.String()
+		}
+		r.TopicIDs[name] = id
+		return name, nil
+	}
+	if r.Version >= 12 {
+		return pd.getCompactString()
+	}
+	return pd.getString()
+}
+
+func (r *FetchResponse) getArrayLength(pd packetDecoder) (int, error) {
+	if r.Version >= 12 {
+		return pd.getCompactArrayLength()
+	}
+	return pd.getArrayLength()
+}
+
+func (r *FetchResponse) putArrayLength(pe packetEncoder, length int) error {
+	if r.Version >= 12 {
+		pe.putCompactArrayLength(length)
+		return nil
+	}
+	return pe.putArrayLength(length)
+}
+
 func (r *FetchResponse) encode(pe packetEncoder) (err error) {
 	if r.Version >= 1 {
 		pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
@@ -311,18 +472,28 @@ func (r *FetchResponse) encode(pe packetEncoder) (err error) {
 		pe.putInt32(r.SessionID)
 	}
 
-	err = pe.putArrayLength(len(r.Blocks))
+	err = r.putArrayLength(pe, len(r.Blocks))
 	if err != nil {
 		return err
 	}
 
 	for topic, partitions := range r.Blocks {
-		err = pe.putString(topic)
+		if r.Version >= 13 {
+			id, ok := r.TopicIDs[topic]
+			if !ok || id == NullUuid {
+				return PacketEncodingError{"FetchResponse v13 requires the ID of the topic " + topic}
+			}
+			err = putUuid(pe, id)
+		} else if r.Version >= 12 {
+			err = pe.putCompactString(topic)
+		} else {
+			err = pe.putString(topic)
+		}
 		if err != nil {
 			return err
 		}
 
-		err = pe.putArrayLength(len(partitions))
+		err = r.putArrayLength(pe, len(partitions))
 		if err != nil {
 			return err
 		}
@@ -334,10 +505,40 @@ func (r *FetchResponse) encode(pe packetEncoder) (err error) {
 				return err
 			}
 		}
+
+		if r.Version >= 12 {
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+
+	if r.Version >= 12 {
+		pe.putEmptyTaggedFieldArray()
 	}
 	return nil
 }
 
+// setCorrelationID records the correlation ID of the request on the blocks,
+// which the consumer may merge with those of another response
+func (r *FetchResponse) setCorrelationID(correlationID int32) {
+	for _, partitions := range r.Blocks {
+		for _, block := range partitions {
+			block.correlationID = correlationID
+		}
+	}
+}
+
+// closeRecordsStreams closes the records streams of the blocks, if any, once
+// the consumer no longer reads them, see decodeFetchResponse
+func (r *FetchResponse) closeRecordsStreams() {
+	for _, partitions := range r.Blocks {
+		for _, block := range partitions {
+			if block.stream != nil {
+				block.stream.close()
+			}
+		}
+	}
+}
+
 func (r *FetchResponse) key() int16 {
 	return 1
 }
@@ -346,7 +547,14 @@ func (r *FetchResponse) version() int16 {
 	return r.Version
 }
 
+func (r *FetchResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *FetchResponse) headerVersion() int16 {
+	if r.Version >= 12 {
+		return 1
+	}
 	return 0
 }
 
@@ -372,6 +580,10 @@ func (r *FetchResponse) requiredVersion() KafkaVersion {
 		return V2_1_0_0
 	case 11:
 		return V2_3_0_0
+	case 12:
+		return V2_7_0_0
+	case 13:
+		return V3_1_0_0
 	default:
 		return MaxVersion
 	}
@@ -0,0 +1,292 @@
+package sarama
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// minStreamRead is the number of bytes read at least from the body of a
+// fetch response when decoding the fields preceding its records
+const minStreamRead = 512
+
+// fetchResponseStream is the body of a fetch response being decoded as it is
+// read off the connection, see decodeFetchResponse
+type fetchResponseStream struct {
+	body io.Reader
+	// left is the number of bytes of the body not read yet
+	left int
+	// buf holds the bytes read ahead of the decoding
+	buf  []byte
+	opts decodeOptions
+}
+
+// decodeFetchResponse decodes the response whose body of length bytes is
+// read from body, calling onBlock with each partition block as soon as the
+// fields preceding its records are decoded. The records are not decoded into
+// the RecordsSet of the block but read one records set at a time by its
+// stream, which onBlock either closes or hands over to the consumer of the
+// partition, the decoding resuming once it is closed. The blocks are not
+// added to the response.
+func decodeFetchResponse(body io.Reader, length int, r *FetchResponse, opts decodeOptions,
+	onBlock func(topic string, partition int32, block *FetchResponseBlock),
+) error {
+	s := &fetchResponseStream{body: body, left: length, opts: opts}
+
+	var numTopics int
+	err := s.decode(func(pd packetDecoder) (err error) {
+		numTopics, err = r.decodeHeader(pd)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	names := r.topicNames(numTopics)
+	for i := 0; i < numTopics; i++ {
+		var name string
+		var numBlocks int
+		err := s.decode(func(pd packetDecoder) (err error) {
+			if name, err = r.decodeTopic(pd, names); err != nil {
+				return err
+			}
+			numBlocks, err = r.getArrayLength(pd)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for j := 0; j < numBlocks; j++ {
+			var id int32
+			var recordsSize int
+			block := new(FetchResponseBlock)
+			err := s.decode(func(pd packetDecoder) (err error) {
+				if id, err = pd.getInt32(); err != nil {
+					return err
+				}
+				recordsSize, err = block.decodeHeader(pd, r.Version)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			if recordsSize < 0 || recordsSize > s.available() {
+				return ErrInsufficientData
+			}
+
+			block.stream = &recordsStream{s: s, block: block, left: recordsSize, done: make(chan none)}
+			onBlock(name, id, block)
+			<-block.stream.done
+
+			if err := s.discard(block.stream.left); err != nil {
+				return err
+			}
+			if err := s.skipTaggedFields(r.Version); err != nil {
+				return err
+			}
+		}
+
+		if err := s.skipTaggedFields(r.Version); err != nil {
+			return err
+		}
+	}
+
+	return s.skipTaggedFields(r.Version)
+}
+
+// available returns the number of bytes of the body left to decode
+func (s *fetchResponseStream) available() int {
+	return len(s.buf) + s.left
+}
+
+// decode decodes the next fields of the body with f, reading more of it
+// until they are complete
+func (s *fetchResponseStream) decode(f func(pd packetDecoder) error) error {
+	for {
+		rd := &realDecoder{raw: s.buf, opts: s.opts}
+		err := f(rd)
+		if err == nil {
+			s.buf = s.buf[rd.off:]
+			return nil
+		}
+		if !errors.Is(err, ErrInsufficientData) || s.left == 0 {
+			return err
+		}
+
+		n := 2 * len(s.buf)
+		if n < minStreamRead {
+			n = minStreamRead
+		}
+		if n > s.available() {
+			n = s.available()
+		}
+		if err := s.fill(n); err != nil {
+			return err
+		}
+	}
+}
+
+// skipTaggedFields skips the tagged fields of the flexible versions
+func (s *fetchResponseStream) skipTaggedFields(version int16) error {
+	if version < 12 {
+		return nil
+	}
+	return s.decode(func(pd packetDecoder) error {
+		_, err := pd.getEmptyTaggedFieldArray()
+		return err
+	})
+}
+
+// fill reads the body until n bytes are read ahead of the decoding
+func (s *fetchResponseStream) fill(n int) error {
+	if n <= len(s.buf) {
+		return nil
+	}
+	if n > s.available() {
+		return ErrInsufficientData
+	}
+	if n > cap(s.buf) {
+		buf := make([]byte, len(s.buf), n)
+		copy(buf, s.buf)
+		s.buf = buf
+	}
+	read, err := io.ReadFull(s.body, s.buf[len(s.buf):n])
+	s.buf = s.buf[:len(s.buf)+read]
+	s.left -= read
+	return err
+}
+
+// read returns the next n bytes of the body, in a buffer of their own since
+// the records decoded from them keep referencing it
+func (s *fetchResponseStream) read(n int) ([]byte, error) {
+	if n > s.available() {
+		return nil, ErrInsufficientData
+	}
+	raw := make([]byte, n)
+	copied := copy(raw, s.buf)
+	s.buf = s.buf[copied:]
+	read, err := io.ReadFull(s.body, raw[copied:])
+	s.left -= read
+	return raw, err
+}
+
+// discard skips the next n bytes of the body
+func (s *fetchResponseStream) discard(n int) error {
+	if n > s.available() {
+		return ErrInsufficientData
+	}
+	skipped := n
+	if skipped > len(s.buf) {
+		skipped = len(s.buf)
+	}
+	s.buf = s.buf[skipped:]
+	read, err := io.CopyN(io.Discard, s.body, int64(n-skipped))
+	s.left -= int(read)
+	return err
+}
+
+// recordsStream reads the records of a partition block off its fetch
+// response stream, one records set at a time. It is owned by the consumer
+// of the partition until closed.
+type recordsStream struct {
+	s     *fetchResponseStream
+	block *FetchResponseBlock
+	// left is the number of bytes of the records not read yet
+	left int
+	// kept is the number of records sets returned, firstPartial whether the
+	// first one is partial
+	kept         int
+	firstPartial bool
+	// end is set once the records following those read are to be ignored
+	end    bool
+	closed bool
+	done   chan none
+}
+
+// next returns the next records set of the block, nil once they are all
+// read, at which point the block is updated as if it was decoded in full.
+// Like FetchResponseBlock.decode, it skips the empty batches and stops at a
+// partial one.
+func (rs *recordsStream) next() (*Records, error) {
+	for !rs.end {
+		if rs.left < magicOffset+1 {
+			rs.stop(rs.left > 0)
+			break
+		}
+		if err := rs.s.fill(magicOffset + 1); err != nil {
+			return nil, err
+		}
+
+		// each batch, and each message of the legacy message sets, starts
+		// with its offset followed by its size
+		size := int(int32(binary.BigEndian.Uint32(rs.s.buf[8:])))
+		if size < magicOffset+1-12 {
+			return nil, PacketDecodingError{fmt.Sprintf("invalid records size %d", size)}
+		}
+		length := 12 + size
+		if magic := int8(rs.s.buf[magicOffset]); magic < 2 {
+			// the legacy message sets are decoded as a whole
+			length = rs.left
+		} else if length > rs.left {
+			rs.stop(true)
+			break
+		}
+
+		raw, err := rs.s.read(length)
+		if err != nil {
+			return nil, err
+		}
+		rs.left -= length
+
+		records := &Records{}
+		if err := records.decode(&realDecoder{raw: raw, opts: rs.s.opts}); err != nil {
+			if errors.Is(err, ErrInsufficientData) {
+				rs.stop(true)
+				break
+			}
+			return nil, err
+		}
+
+		keep, last, err := rs.block.checkRecords(records, rs.kept == 0)
+		if err != nil {
+			return nil, err
+		}
+		rs.end = last
+		if !keep {
+			continue
+		}
+
+		if rs.kept == 0 {
+			if rs.firstPartial, err = records.isPartial(); err != nil {
+				return nil, err
+			}
+		}
+		rs.kept++
+		return records, nil
+	}
+
+	if rs.kept == 1 && rs.firstPartial {
+		rs.block.Partial = true
+	}
+	return nil, nil
+}
+
+// stop ignores the records left, partial being whether they start with a
+// partial records set
+func (rs *recordsStream) stop(partial bool) {
+	rs.end = true
+	if partial && rs.kept == 0 {
+		rs.block.Partial = true
+	}
+}
+
+// close hands the fetch response stream back to its decoder, which skips
+// the records not read
+func (rs *recordsStream) close() {
+	if !rs.closed {
+		rs.closed = true
+		close(rs.done)
+	}
+}
@@ -0,0 +1,107 @@
+package sarama
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestDecodeFetchResponse(t *testing.T) {
+	id := Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	value := strings.Repeat("x", 1000)
+
+	for _, version := range []int16{4, 12, 13} {
+		response := &FetchResponse{Version: version, ThrottleTime: 5 * time.Millisecond}
+		for offset := int64(0); offset < 3; offset++ {
+			response.AddRecordBatch("my_topic", 0, nil, StringEncoder(value), offset, 7, false)
+		}
+		response.GetBlock("my_topic", 0).AbortedTransactions = []*AbortedTransaction{{ProducerID: 7, FirstOffset: 1}}
+		response.AddError("my_topic", 1, ErrOffsetOutOfRange)
+		if version >= 13 {
+			response.TopicIDs = map[string]Uuid{"my_topic": id}
+		}
+		buf, err := encode(response, nil)
+		if err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+
+		for _, skip := range []bool{false, true} {
+			reader := &countingReader{r: bytes.NewReader(buf)}
+			decoded := &FetchResponse{Version: version}
+			if version >= 13 {
+				decoded.TopicIDs = map[string]Uuid{"my_topic": id}
+			}
+
+			var offsets []int64
+			errs := make(map[int32]KError)
+			err := decodeFetchResponse(reader, len(buf), decoded, decodeOptions{deferRecords: true},
+				func(topic string, partition int32, block *FetchResponseBlock) {
+					defer block.stream.close()
+					if topic != "my_topic" {
+						t.Errorf("v%d: unexpected topic %s", version, topic)
+					}
+					errs[partition] = block.Err
+					if partition != 0 || skip {
+						return
+					}
+					if len(block.AbortedTransactions) != 1 {
+						t.Errorf("v%d: unexpected aborted transactions %v", version, block.AbortedTransactions)
+					}
+					for {
+						records, err := block.stream.next()
+						if err != nil {
+							t.Errorf("v%d: %v", version, err)
+							return
+						}
+						if records == nil {
+							return
+						}
+						if len(offsets) == 0 && reader.n == len(buf) {
+							t.Errorf("v%d: the first batch was read after the whole response", version)
+						}
+						if err := records.RecordBatch.decodeDeferred(); err != nil {
+							t.Errorf("v%d: %v", version, err)
+							return
+						}
+						if string(records.RecordBatch.Records[0].Value) != value {
+							t.Errorf("v%d: unexpected value of the batch at offset %d", version, records.RecordBatch.FirstOffset)
+						}
+						offsets = append(offsets, records.RecordBatch.FirstOffset)
+					}
+				})
+			if err != nil {
+				t.Fatalf("v%d: %v", version, err)
+			}
+
+			if reader.n != len(buf) {
+				t.Errorf("v%d: read %d bytes of %d", version, reader.n, len(buf))
+			}
+			if decoded.ThrottleTime != 5*time.Millisecond || len(decoded.Blocks) != 0 {
+				t.Errorf("v%d: unexpected response %+v", version, decoded)
+			}
+			if len(errs) != 2 || !errors.Is(errs[0], ErrNoError) || !errors.Is(errs[1], ErrOffsetOutOfRange) {
+				t.Errorf("v%d: unexpected errors of the blocks %v", version, errs)
+			}
+			if skip && len(offsets) != 0 {
+				t.Errorf("v%d: expected the batches to be skipped, got %v", version, offsets)
+			} else if !skip && (len(offsets) != 3 || offsets[0] != 0 || offsets[2] != 2) {
+				t.Errorf("v%d: unexpected batches %v", version, offsets)
+			}
+		}
+	}
+}
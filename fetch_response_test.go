@@ -590,3 +590,96 @@ func TestPreferredReplicaFetchResponseV11(t *testing.T) {
 		t.Error("Decoding produced incorrect message value.")
 	}
 }
+
+func TestFetchResponseV12V13RoundTrip(t *testing.T) {
+	id := Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	for _, version := range []int16{12, 13} {
+		response := &FetchResponse{Version: version, SessionID: 0xAC}
+		response.AddRecordBatch("my_topic", 3, nil, StringEncoder(TestMessage), 5, 7, true)
+		response.AddError("my_topic", 4, ErrOffsetOutOfRange)
+		block := response.GetBlock("my_topic", 3)
+		block.HighWaterMarkOffset = 6
+		block.AbortedTransactions = []*AbortedTransaction{{ProducerID: 7, FirstOffset: 5}}
+		if version >= 13 {
+			response.TopicIDs = map[string]Uuid{"my_topic": id}
+		}
+
+		buf, err := encode(response, nil)
+		if err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+
+		decoded := &FetchResponse{}
+		if version >= 13 {
+			// names resolved from the IDs of the request
+			decoded.TopicIDs = map[string]Uuid{"my_topic": id}
+		}
+		if err := versionedDecode(buf, decoded, version); err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+		if decoded.SessionID != 0xAC {
+			t.Errorf("v%d: unexpected session ID %d", version, decoded.SessionID)
+		}
+		block = decoded.GetBlock("my_topic", 3)
+		if block == nil {
+			t.Fatalf("v%d: missing block of my_topic", version)
+		}
+		if block.HighWaterMarkOffset != 6 || len(block.AbortedTransactions) != 1 ||
+			block.AbortedTransactions[0].ProducerID != 7 {
+			t.Errorf("v%d: unexpected block %+v", version, block)
+		}
+		if n, err := block.numRecords(); err != nil || n != 1 {
+			t.Errorf("v%d: unexpected number of records %d: %v", version, n, err)
+		}
+		if errBlock := decoded.GetBlock("my_topic", 4); errBlock == nil || !errors.Is(errBlock.Err, ErrOffsetOutOfRange) {
+			t.Errorf("v%d: unexpected error block %+v", version, errBlock)
+		}
+		if version >= 13 && decoded.TopicIDs["my_topic"] != id {
+			t.Errorf("v%d: unexpected topic IDs %v", version, decoded.TopicIDs)
+		}
+	}
+
+	// without the name of the ID the topic is named after it
+	response := &FetchResponse{Version: 13, TopicIDs: map[string]Uuid{"my_topic": id}}
+	response.AddError("my_topic", 0, ErrNoError)
+	buf, err := encode(response, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &FetchResponse{}
+	if err := versionedDecode(buf, decoded, 13); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.GetBlock(id.String(), 0) == nil {
+		t.Errorf("expected the topic to be named after its ID, got %v", decoded.Blocks)
+	}
+}
+
+func TestFetchResponseDeferRecords(t *testing.T) {
+	response := &FetchResponse{Version: 4}
+	response.AddRecordBatch("my_topic", 0, nil, StringEncoder("foo"), 5, 7, false)
+	buf, err := encode(response, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &FetchResponse{}
+	if err := versionedDecodeWithOptions(buf, decoded, 4, decodeOptions{deferRecords: true}); err != nil {
+		t.Fatal(err)
+	}
+	block := decoded.GetBlock("my_topic", 0)
+	if n, err := block.numRecords(); err != nil || n != 1 {
+		t.Fatalf("unexpected number of records %d: %v", n, err)
+	}
+	batch := block.RecordsSet[0].RecordBatch
+	if batch.deferredRecords == nil || batch.Records[0] != nil {
+		t.Fatal("expected the records to be deferred")
+	}
+	if err := batch.decodeDeferred(); err != nil {
+		t.Fatal(err)
+	}
+	if batch.deferredRecords != nil || string(batch.Records[0].Value) != "foo" {
+		t.Errorf("unexpected records %+v", batch.Records)
+	}
+}
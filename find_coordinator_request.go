@@ -13,8 +13,15 @@ type FindCoordinatorRequest struct {
 	CoordinatorType CoordinatorType
 }
 
-func (f *FindCoordinatorRequest) encode(pe packetEncoder) error {
-	if err := pe.putString(f.CoordinatorKey); err != nil {
+func (f *FindCoordinatorRequest) encode(pe packetEncoder) (err error) {
+	isFlexible := f.Version >= 3
+
+	if isFlexible {
+		err = pe.putCompactString(f.CoordinatorKey)
+	} else {
+		err = pe.putString(f.CoordinatorKey)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -22,11 +29,22 @@ func (f *FindCoordinatorRequest) encode(pe packetEncoder) error {
 		pe.putInt8(int8(f.CoordinatorType))
 	}
 
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
 func (f *FindCoordinatorRequest) decode(pd packetDecoder, version int16) (err error) {
-	if f.CoordinatorKey, err = pd.getString(); err != nil {
+	isFlexible := version >= 3
+
+	if isFlexible {
+		f.CoordinatorKey, err = pd.getCompactString()
+	} else {
+		f.CoordinatorKey, err = pd.getString()
+	}
+	if err != nil {
 		return err
 	}
 
@@ -40,6 +58,12 @@ func (f *FindCoordinatorRequest) decode(pd packetDecoder, version int16) (err er
 		f.CoordinatorType = CoordinatorType(coordinatorType)
 	}
 
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -51,12 +75,35 @@ func (f *FindCoordinatorRequest) version() int16 {
 	return f.Version
 }
 
-func (r *FindCoordinatorRequest) headerVersion() int16 {
+func (f *FindCoordinatorRequest) versionRange() (minVersion, maxVersion int16) {
+	if f.Version >= 1 && f.CoordinatorType != CoordinatorGroup {
+		return 1, 3
+	}
+	return 0, 3
+}
+
+func (f *FindCoordinatorRequest) withVersion(v int16) protocolBody {
+	request := *f
+	request.Version = v
+	if f.Version < 1 {
+		request.CoordinatorType = CoordinatorGroup
+	}
+	return &request
+}
+
+func (f *FindCoordinatorRequest) headerVersion() int16 {
+	if f.Version >= 3 {
+		return 2
+	}
 	return 1
 }
 
 func (f *FindCoordinatorRequest) requiredVersion() KafkaVersion {
 	switch f.Version {
+	case 3:
+		return V2_4_0_0
+	case 2:
+		return V2_0_0_0
 	case 1:
 		return V0_11_0_0
 	default:
@@ -31,3 +31,19 @@ func TestFindCoordinatorRequest(t *testing.T) {
 
 	testRequest(t, "version 1 - transaction", req, findCoordinatorRequestTransaction)
 }
+
+var findCoordinatorRequestV3 = []byte{
+	6, 'g', 'r', 'o', 'u', 'p', // CoordinatorKey
+	0, // CoordinatorType
+	0, // empty tagged fields
+}
+
+func TestFindCoordinatorRequestV3(t *testing.T) {
+	req := &FindCoordinatorRequest{
+		Version:         3,
+		CoordinatorKey:  "group",
+		CoordinatorType: CoordinatorGroup,
+	}
+
+	testRequest(t, "version 3 - group", req, findCoordinatorRequestV3)
+}
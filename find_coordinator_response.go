@@ -1,6 +1,9 @@
 package sarama
 
 import (
+	"fmt"
+	"net"
+	"strconv"
 	"time"
 )
 
@@ -15,6 +18,8 @@ type FindCoordinatorResponse struct {
 }
 
 func (f *FindCoordinatorResponse) decode(pd packetDecoder, version int16) (err error) {
+	isFlexible := version >= 3
+
 	if version >= 1 {
 		f.Version = version
 
@@ -31,18 +36,36 @@ func (f *FindCoordinatorResponse) decode(pd packetDecoder, version int16) (err e
 	}
 	f.Err = KError(tmp)
 
-	if version >= 1 {
+	if isFlexible {
+		if f.ErrMsg, err = pd.getCompactNullableString(); err != nil {
+			return err
+		}
+	} else if version >= 1 {
 		if f.ErrMsg, err = pd.getNullableString(); err != nil {
 			return err
 		}
 	}
 
 	coordinator := new(Broker)
-	// The version is hardcoded to 0, as version 1 of the Broker-decode
-	// contains the rack-field which is not present in the FindCoordinatorResponse.
-	if err := coordinator.decode(pd, 0); err != nil {
+	if isFlexible {
+		// the fields of the flexible Broker-decode differ from those of the
+		// coordinator, which has neither a rack nor tagged fields
+		err = decodeFlexibleCoordinator(pd, coordinator)
+	} else {
+		// The version is hardcoded to 0, as version 1 of the Broker-decode
+		// contains the rack-field which is not present in the FindCoordinatorResponse.
+		err = coordinator.decode(pd, 0)
+	}
+	if err != nil {
 		return err
 	}
+
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	if coordinator.addr == ":0" {
 		return nil
 	}
@@ -51,14 +74,53 @@ func (f *FindCoordinatorResponse) decode(pd packetDecoder, version int16) (err e
 	return nil
 }
 
+func decodeFlexibleCoordinator(pd packetDecoder, coordinator *Broker) (err error) {
+	if coordinator.id, err = pd.getInt32(); err != nil {
+		return err
+	}
+	host, err := pd.getCompactString()
+	if err != nil {
+		return err
+	}
+	port, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	coordinator.addr = net.JoinHostPort(host, fmt.Sprint(port))
+	return nil
+}
+
+func encodeFlexibleCoordinator(pe packetEncoder, coordinator *Broker) error {
+	host, portstr, err := net.SplitHostPort(coordinator.addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseInt(portstr, 10, 32)
+	if err != nil {
+		return err
+	}
+	pe.putInt32(coordinator.id)
+	if err := pe.putCompactString(host); err != nil {
+		return err
+	}
+	pe.putInt32(int32(port))
+	return nil
+}
+
 func (f *FindCoordinatorResponse) encode(pe packetEncoder) error {
+	isFlexible := f.Version >= 3
+
 	if f.Version >= 1 {
 		pe.putInt32(int32(f.ThrottleTime / time.Millisecond))
 	}
 
 	pe.putInt16(int16(f.Err))
 
-	if f.Version >= 1 {
+	if isFlexible {
+		if err := pe.putNullableCompactString(f.ErrMsg); err != nil {
+			return err
+		}
+	} else if f.Version >= 1 {
 		if err := pe.putNullableString(f.ErrMsg); err != nil {
 			return err
 		}
@@ -68,6 +130,13 @@ func (f *FindCoordinatorResponse) encode(pe packetEncoder) error {
 	if coordinator == nil {
 		coordinator = NoNode
 	}
+	if isFlexible {
+		if err := encodeFlexibleCoordinator(pe, coordinator); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+		return nil
+	}
 	if err := coordinator.encode(pe, 0); err != nil {
 		return err
 	}
@@ -82,12 +151,23 @@ func (f *FindCoordinatorResponse) version() int16 {
 	return f.Version
 }
 
-func (r *FindCoordinatorResponse) headerVersion() int16 {
+func (f *FindCoordinatorResponse) setVersion(v int16) {
+	f.Version = v
+}
+
+func (f *FindCoordinatorResponse) headerVersion() int16 {
+	if f.Version >= 3 {
+		return 1
+	}
 	return 0
 }
 
 func (f *FindCoordinatorResponse) requiredVersion() KafkaVersion {
 	switch f.Version {
+	case 3:
+		return V2_4_0_0
+	case 2:
+		return V2_0_0_0
 	case 1:
 		return V0_11_0_0
 	default:
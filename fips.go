@@ -0,0 +1,99 @@
+package sarama
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// fipsCipherSuites are the FIPS 140 approved TLS 1.2 cipher suites, the TLS
+// 1.3 ones not being configurable
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// fipsCurves are the FIPS 140 approved key exchange curves
+var fipsCurves = []tls.CurveID{tls.CurveP256, tls.CurveP384, tls.CurveP521}
+
+// validateFIPS returns why the configuration does not comply with
+// Net.FIPSMode, if it does not.
+func (c *Config) validateFIPS() error {
+	if !fipsCryptoEnabled() {
+		return ConfigurationError("Net.FIPSMode requires the FIPS 140 mode of the Go cryptographic module, " +
+			"available from Go 1.24 with GODEBUG=fips140=on")
+	}
+	if c.Net.SASL.Enable {
+		switch c.Net.SASL.Mechanism {
+		case SASLTypePlaintext, SASLTypeOAuth, SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512:
+		default:
+			return ConfigurationError(fmt.Sprintf("Net.SASL.Mechanism %s is not allowed with Net.FIPSMode", c.Net.SASL.Mechanism))
+		}
+	}
+	if c.Net.TLS.Config != nil {
+		if err := fipsTLSConfigError(c.Net.TLS.Config); err != nil {
+			return ConfigurationError(fmt.Sprintf("Net.TLS.Config is not allowed with Net.FIPSMode: %s", err))
+		}
+	}
+	return nil
+}
+
+// fipsTLSConfigError returns why the TLS config does not comply with
+// Net.FIPSMode, if it does not.
+func fipsTLSConfigError(cfg *tls.Config) error {
+	if cfg.MinVersion != 0 && cfg.MinVersion < tls.VersionTLS12 {
+		return fmt.Errorf("TLS versions before 1.2 are not approved")
+	}
+	for _, suite := range cfg.CipherSuites {
+		if !fipsCipherSuite(suite) {
+			return fmt.Errorf("the cipher suite %s is not approved", tls.CipherSuiteName(suite))
+		}
+	}
+	for _, curve := range cfg.CurvePreferences {
+		if !fipsCurve(curve) {
+			return fmt.Errorf("the curve %d is not approved", curve)
+		}
+	}
+	return nil
+}
+
+func fipsCipherSuite(suite uint16) bool {
+	for _, approved := range fipsCipherSuites {
+		if suite == approved {
+			return true
+		}
+	}
+	return false
+}
+
+func fipsCurve(curve tls.CurveID) bool {
+	for _, approved := range fipsCurves {
+		if curve == approved {
+			return true
+		}
+	}
+	return false
+}
+
+// withFIPS returns the TLS config of a connection, c derived from the base
+// config, restricted to the approved versions, cipher suites and curves
+// where it does not restrict them already with Net.FIPSMode.
+func (conf *Config) withFIPS(c, base *tls.Config) *tls.Config {
+	if !conf.Net.FIPSMode {
+		return c
+	}
+	if c == base {
+		c = c.Clone()
+	}
+	if c.MinVersion < tls.VersionTLS12 {
+		c.MinVersion = tls.VersionTLS12
+	}
+	if len(c.CipherSuites) == 0 {
+		c.CipherSuites = fipsCipherSuites
+	}
+	if len(c.CurvePreferences) == 0 {
+		c.CurvePreferences = fipsCurves
+	}
+	return c
+}
@@ -0,0 +1,10 @@
+//go:build go1.24
+// +build go1.24
+
+package sarama
+
+import "crypto/fips140"
+
+// fipsCryptoEnabled returns whether the Go cryptographic module runs in its
+// FIPS 140 mode
+var fipsCryptoEnabled = fips140.Enabled
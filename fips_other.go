@@ -0,0 +1,8 @@
+//go:build !go1.24
+// +build !go1.24
+
+package sarama
+
+// fipsCryptoEnabled returns false, the FIPS 140 mode of the Go cryptographic
+// module being available from Go 1.24
+var fipsCryptoEnabled = func() bool { return false }
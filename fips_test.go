@@ -0,0 +1,64 @@
+package sarama
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestConfigFIPSMode(t *testing.T) {
+	enabled := fipsCryptoEnabled
+	defer func() { fipsCryptoEnabled = enabled }()
+
+	config := NewTestConfig()
+	config.Net.FIPSMode = true
+
+	fipsCryptoEnabled = func() bool { return false }
+	if err := config.Validate(); err == nil {
+		t.Error("expected FIPSMode to require the FIPS 140 mode of the cryptographic module")
+	}
+
+	fipsCryptoEnabled = func() bool { return true }
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	for name, change := range map[string]func(*Config){
+		"GSSAPI": func(c *Config) {
+			c.Net.SASL.Enable = true
+			c.Net.SASL.Mechanism = SASLTypeGSSAPI
+		},
+		"TLS 1.0": func(c *Config) {
+			c.Net.TLS.Config = &tls.Config{MinVersion: tls.VersionTLS10} //nolint:gosec
+		},
+		"ChaCha20": func(c *Config) {
+			c.Net.TLS.Config = &tls.Config{MinVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256}}
+		},
+		"X25519": func(c *Config) {
+			c.Net.TLS.Config = &tls.Config{MinVersion: tls.VersionTLS12, CurvePreferences: []tls.CurveID{tls.X25519}}
+		},
+	} {
+		if err := config.With(change).validateFIPS(); err == nil {
+			t.Errorf("expected %s not to be allowed", name)
+		}
+	}
+}
+
+func TestFIPSConnectionTLSConfig(t *testing.T) {
+	config := NewTestConfig()
+	config.Net.FIPSMode = true
+	base := &tls.Config{ServerName: "broker", CurvePreferences: []tls.CurveID{tls.CurveP384}}
+
+	c := config.connectionTLSConfig("broker:9092", base)
+	if c.MinVersion != tls.VersionTLS12 {
+		t.Errorf("expected TLS 1.2 or later, got %x", c.MinVersion)
+	}
+	if len(c.CipherSuites) != len(fipsCipherSuites) {
+		t.Errorf("expected the approved cipher suites, got %v", c.CipherSuites)
+	}
+	if len(c.CurvePreferences) != 1 || c.CurvePreferences[0] != tls.CurveP384 {
+		t.Errorf("expected the configured curves to be kept, got %v", c.CurvePreferences)
+	}
+	if base.MinVersion != 0 || base.CipherSuites != nil {
+		t.Error("expected the base config to be left unchanged")
+	}
+}
@@ -53,6 +53,10 @@ func TestMain(m *testing.M) {
 	// and use Docker to bring up a 5-node zookeeper cluster & 5-node kafka
 	// cluster, with toxiproxy configured as above.
 	//
+	// The same environment is exported to the integration tests of other
+	// projects by the kafkatest subpackage, which cannot be imported here as
+	// it imports sarama.
+	//
 	// In either case, the following topics will be deleted (if they exist) and
 	// then created/pre-seeded with data for the functional test run:
 	//     * uncommitted-topic-test-4
@@ -0,0 +1,48 @@
+package sarama
+
+// GetTelemetrySubscriptionsRequest is a request to get the metrics the
+// brokers want the client to push (KIP-714)
+type GetTelemetrySubscriptionsRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	// ClientInstanceID is the ID assigned to the client by the brokers,
+	// NullUuid to be assigned one
+	ClientInstanceID Uuid
+}
+
+func (r *GetTelemetrySubscriptionsRequest) encode(pe packetEncoder) error {
+	if err := putUuid(pe, r.ClientInstanceID); err != nil {
+		return err
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *GetTelemetrySubscriptionsRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.ClientInstanceID, err = getUuid(pd); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *GetTelemetrySubscriptionsRequest) key() int16 {
+	return 71
+}
+
+func (r *GetTelemetrySubscriptionsRequest) version() int16 {
+	return r.Version
+}
+
+func (r *GetTelemetrySubscriptionsRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *GetTelemetrySubscriptionsRequest) requiredVersion() KafkaVersion {
+	return V3_7_0_0
+}
@@ -0,0 +1,26 @@
+package sarama
+
+import "testing"
+
+var (
+	getTelemetrySubscriptionsRequestNew = []byte{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // null client instance ID
+		0, // empty tagged fields
+	}
+
+	getTelemetrySubscriptionsRequestKnown = []byte{
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, // client instance ID
+		0, // empty tagged fields
+	}
+)
+
+func TestGetTelemetrySubscriptionsRequest(t *testing.T) {
+	request := &GetTelemetrySubscriptionsRequest{Version: 0}
+	testRequest(t, "new client", request, getTelemetrySubscriptionsRequestNew)
+
+	request = &GetTelemetrySubscriptionsRequest{
+		Version:          0,
+		ClientInstanceID: Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+	}
+	testRequest(t, "known client", request, getTelemetrySubscriptionsRequestKnown)
+}
@@ -0,0 +1,139 @@
+package sarama
+
+import "time"
+
+// GetTelemetrySubscriptionsResponse is the response to a
+// GetTelemetrySubscriptionsRequest
+type GetTelemetrySubscriptionsResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime time.Duration
+	ErrorCode    KError
+	// ClientInstanceID is the ID of the client, assigned by the broker if
+	// requested with NullUuid
+	ClientInstanceID Uuid
+	// SubscriptionID identifies the subscription in the pushes
+	SubscriptionID int32
+	// AcceptedCompressionTypes are the compression types the metrics may be
+	// pushed with, in order of preference, any if empty
+	AcceptedCompressionTypes []CompressionCodec
+	PushInterval             time.Duration
+	// TelemetryMaxBytes is the maximum size of the metrics of a push
+	TelemetryMaxBytes int32
+	// DeltaTemporality is whether the sums are pushed as deltas rather
+	// than cumulatively
+	DeltaTemporality bool
+	// RequestedMetrics are the prefixes of the names of the metrics to push,
+	// all if "*" and none if empty
+	RequestedMetrics []string
+}
+
+func (r *GetTelemetrySubscriptionsResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	pe.putInt16(int16(r.ErrorCode))
+	if err := putUuid(pe, r.ClientInstanceID); err != nil {
+		return err
+	}
+	pe.putInt32(r.SubscriptionID)
+
+	pe.putCompactArrayLength(len(r.AcceptedCompressionTypes))
+	for _, codec := range r.AcceptedCompressionTypes {
+		pe.putInt8(int8(codec))
+	}
+
+	pe.putInt32(int32(r.PushInterval / time.Millisecond))
+	pe.putInt32(r.TelemetryMaxBytes)
+	pe.putBool(r.DeltaTemporality)
+
+	pe.putCompactArrayLength(len(r.RequestedMetrics))
+	for _, name := range r.RequestedMetrics {
+		if err := pe.putCompactString(name); err != nil {
+			return err
+		}
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *GetTelemetrySubscriptionsResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.ErrorCode = KError(kerr)
+
+	if r.ClientInstanceID, err = getUuid(pd); err != nil {
+		return err
+	}
+	if r.SubscriptionID, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.AcceptedCompressionTypes = make([]CompressionCodec, n)
+		for i := range r.AcceptedCompressionTypes {
+			codec, err := pd.getInt8()
+			if err != nil {
+				return err
+			}
+			r.AcceptedCompressionTypes[i] = CompressionCodec(codec)
+		}
+	}
+
+	pushInterval, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.PushInterval = time.Duration(pushInterval) * time.Millisecond
+	if r.TelemetryMaxBytes, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.DeltaTemporality, err = pd.getBool(); err != nil {
+		return err
+	}
+
+	if n, err = pd.getCompactArrayLength(); err != nil {
+		return err
+	}
+	if n > 0 {
+		r.RequestedMetrics = make([]string, n)
+		for i := range r.RequestedMetrics {
+			if r.RequestedMetrics[i], err = pd.getCompactString(); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *GetTelemetrySubscriptionsResponse) key() int16 {
+	return 71
+}
+
+func (r *GetTelemetrySubscriptionsResponse) version() int16 {
+	return r.Version
+}
+
+func (r *GetTelemetrySubscriptionsResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *GetTelemetrySubscriptionsResponse) requiredVersion() KafkaVersion {
+	return V3_7_0_0
+}
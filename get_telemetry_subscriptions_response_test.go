@@ -0,0 +1,56 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	getTelemetrySubscriptionsResponseNoError = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		0, 0, // no error code
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, // client instance ID
+		0, 0, 0, 7, // subscription ID
+		3, 4, 1, // accepted compression types (zstd, gzip)
+		0, 0, 234, 96, // push interval (60000 ms)
+		0, 16, 0, 0, // telemetry max bytes (1 MiB)
+		1,                                                                                                                               // delta temporality
+		2,                                                                                                                               // requested metrics array length 1
+		26, 'o', 'r', 'g', '.', 'a', 'p', 'a', 'c', 'h', 'e', '.', 'k', 'a', 'f', 'k', 'a', '.', 'p', 'r', 'o', 'd', 'u', 'c', 'e', 'r', // requested metric
+		0, // empty tagged fields
+	}
+
+	getTelemetrySubscriptionsResponseError = []byte{
+		0, 0, 0, 0, // throttle time
+		0, 35, // unsupported version
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // null client instance ID
+		0, 0, 0, 0, // subscription ID
+		1,          // no accepted compression types
+		0, 0, 0, 0, // push interval
+		0, 0, 0, 0, // telemetry max bytes
+		0, // cumulative temporality
+		1, // no requested metrics
+		0, // empty tagged fields
+	}
+)
+
+func TestGetTelemetrySubscriptionsResponse(t *testing.T) {
+	response := &GetTelemetrySubscriptionsResponse{
+		Version:                  0,
+		ThrottleTime:             100 * time.Millisecond,
+		ClientInstanceID:         Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SubscriptionID:           7,
+		AcceptedCompressionTypes: []CompressionCodec{CompressionZSTD, CompressionGZIP},
+		PushInterval:             time.Minute,
+		TelemetryMaxBytes:        1 << 20,
+		DeltaTemporality:         true,
+		RequestedMetrics:         []string{"org.apache.kafka.producer"},
+	}
+	testResponse(t, "no error", response, getTelemetrySubscriptionsResponseNoError)
+
+	response = &GetTelemetrySubscriptionsResponse{
+		Version:   0,
+		ErrorCode: ErrUnsupportedVersion,
+	}
+	testResponse(t, "with error", response, getTelemetrySubscriptionsResponseError)
+}
@@ -23,6 +23,7 @@ const (
 	GSS_API_GENERIC_TAG = 0x60
 	KRB5_USER_AUTH      = 1
 	KRB5_KEYTAB_AUTH    = 2
+	KRB5_CCACHE_AUTH    = 3
 	GSS_API_INITIAL     = 1
 	GSS_API_VERIFY      = 2
 	GSS_API_FINISH      = 3
@@ -37,6 +38,17 @@ type GSSAPIConfig struct {
 	Password           string
 	Realm              string
 	DisablePAFXFAST    bool
+	// CCachePath is the credentials cache with AuthType KRB5_CCACHE_AUTH,
+	// reloaded when its TGT is about to expire.
+	CCachePath string
+	// RenewalLeadTime is how long before its TGT expires the Kerberos client
+	// kept by each broker is logged in again, from the keytab or password,
+	// or reloaded from CCachePath (defaults to 1 minute). If zero, a client
+	// is created and logged in on each authentication instead.
+	RenewalLeadTime time.Duration
+	// OnRenewalError, if set, is called when a renewal fails, the current
+	// client being used until its TGT expires.
+	OnRenewalError func(err error)
 }
 
 type GSSAPIKerberosAuth struct {
@@ -45,6 +57,7 @@ type GSSAPIKerberosAuth struct {
 	encKey                types.EncryptionKey
 	NewKerberosClientFunc func(config *GSSAPIConfig) (KerberosClient, error)
 	step                  int
+	client                KerberosClient
 }
 
 type KerberosClient interface {
@@ -55,6 +68,13 @@ type KerberosClient interface {
 	Destroy()
 }
 
+// renewableKerberosClient is a KerberosClient knowing when its TGT expires,
+// which can be kept across authentications until then.
+type renewableKerberosClient interface {
+	KerberosClient
+	ticketExpiry() time.Time
+}
+
 // writePackage appends length in big endian before the payload, and sends it to kafka
 func (krbAuth *GSSAPIKerberosAuth) writePackage(broker *Broker, payload []byte) (int, error) {
 	length := uint64(len(payload))
@@ -197,16 +217,13 @@ func (krbAuth *GSSAPIKerberosAuth) initSecContext(bytes []byte, kerberosClient K
 
 /* This does the handshake for authorization */
 func (krbAuth *GSSAPIKerberosAuth) Authorize(broker *Broker) error {
-	kerberosClient, err := krbAuth.NewKerberosClientFunc(krbAuth.Config)
+	kerberosClient, err := krbAuth.kerberosClient()
 	if err != nil {
 		Logger.Printf("Kerberos client error: %s", err)
 		return err
 	}
-
-	err = kerberosClient.Login()
-	if err != nil {
-		Logger.Printf("Kerberos client error: %s", err)
-		return err
+	if kerberosClient != krbAuth.client {
+		defer kerberosClient.Destroy()
 	}
 	// Construct SPN using serviceName and host
 	// SPN format: <SERVICE>/<FQDN>
@@ -223,7 +240,6 @@ func (krbAuth *GSSAPIKerberosAuth) Authorize(broker *Broker) error {
 	krbAuth.encKey = encKey
 	krbAuth.step = GSS_API_INITIAL
 	var receivedBytes []byte = nil
-	defer kerberosClient.Destroy()
 	for {
 		packBytes, err := krbAuth.initSecContext(receivedBytes, kerberosClient)
 		if err != nil {
@@ -251,3 +267,52 @@ func (krbAuth *GSSAPIKerberosAuth) Authorize(broker *Broker) error {
 		}
 	}
 }
+
+// kerberosClient returns the logged in client to authenticate with, the one
+// kept since a previous authentication unless its TGT expires within
+// RenewalLeadTime. A client that cannot be kept is returned logged in as
+// well, and must be destroyed by the caller.
+func (krbAuth *GSSAPIKerberosAuth) kerberosClient() (KerberosClient, error) {
+	leadTime := krbAuth.Config.RenewalLeadTime
+	if krbAuth.client != nil {
+		expiry := krbAuth.client.(renewableKerberosClient).ticketExpiry()
+		if time.Now().Add(leadTime).Before(expiry) {
+			return krbAuth.client, nil
+		}
+	}
+
+	kerberosClient, err := krbAuth.NewKerberosClientFunc(krbAuth.Config)
+	if err == nil {
+		if err = kerberosClient.Login(); err != nil {
+			kerberosClient.Destroy()
+		}
+	}
+	if err != nil {
+		if krbAuth.client == nil {
+			return nil, err
+		}
+		if krbAuth.Config.OnRenewalError != nil {
+			krbAuth.Config.OnRenewalError(err)
+		}
+		if time.Now().Before(krbAuth.client.(renewableKerberosClient).ticketExpiry()) {
+			Logger.Printf("Failed to renew the Kerberos credentials, using the current ones: %s\n", err)
+			return krbAuth.client, nil
+		}
+		krbAuth.destroy()
+		return nil, err
+	}
+
+	krbAuth.destroy()
+	if renewable, ok := kerberosClient.(renewableKerberosClient); ok && leadTime > 0 && !renewable.ticketExpiry().IsZero() {
+		krbAuth.client = kerberosClient
+	}
+	return kerberosClient, nil
+}
+
+// destroy destroys the client kept across authentications, if any
+func (krbAuth *GSSAPIKerberosAuth) destroy() {
+	if krbAuth.client != nil {
+		krbAuth.client.Destroy()
+		krbAuth.client = nil
+	}
+}
@@ -0,0 +1,131 @@
+package sarama
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// happyEyeballsDialer dials all the IP addresses a broker hostname resolves
+// to concurrently, each attempt starting a delay after the previous one or
+// as soon as it failed, and keeps the first connection established (RFC
+// 8305), see Net.HappyEyeballsDelay.
+type happyEyeballsDialer struct {
+	dialer *net.Dialer
+	delay  time.Duration
+	// lookup is net.DefaultResolver.LookupIPAddr but in tests
+	lookup func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+func (d *happyEyeballsDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+func (d *happyEyeballsDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+	if d.dialer.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.dialer.Timeout)
+		defer cancel()
+	}
+
+	ips, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 1 {
+		return d.dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].String(), port))
+	}
+	return d.dialParallel(ctx, network, interleaveAddressFamilies(ips), port)
+}
+
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+func (d *happyEyeballsDialer) dialParallel(ctx context.Context, network string, ips []net.IPAddr, port string) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, len(ips))
+	dial := func(ip net.IPAddr) {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		results <- dialResult{conn: conn, err: err}
+	}
+
+	var firstErr error
+	started, pending := 1, 1
+	go dial(ips[0])
+	timer := time.NewTimer(d.delay)
+	defer timer.Stop()
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if started < len(ips) {
+				go dial(ips[started])
+				started++
+				pending++
+				timer.Reset(d.delay)
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				// the attempts still pending are canceled, closing the
+				// connections established meanwhile
+				go closeLateConnections(results, pending)
+				return result.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			if started < len(ips) {
+				// start the next attempt right away
+				if !timer.Stop() {
+					<-timer.C
+				}
+				go dial(ips[started])
+				started++
+				pending++
+				timer.Reset(d.delay)
+			}
+		}
+	}
+	return nil, firstErr
+}
+
+func closeLateConnections(results <-chan dialResult, pending int) {
+	for ; pending > 0; pending-- {
+		if result := <-results; result.conn != nil {
+			_ = result.conn.Close()
+		}
+	}
+}
+
+// interleaveAddressFamilies orders the addresses alternating between IPv6
+// and IPv4, starting with the family of the first one, so that an
+// unreachable family only delays the connection by one attempt.
+func interleaveAddressFamilies(ips []net.IPAddr) []net.IPAddr {
+	var first, second []net.IPAddr
+	firstIsV4 := ips[0].IP.To4() != nil
+	for _, ip := range ips {
+		if (ip.IP.To4() != nil) == firstIsV4 {
+			first = append(first, ip)
+		} else {
+			second = append(second, ip)
+		}
+	}
+	interleaved := make([]net.IPAddr, 0, len(ips))
+	for i := 0; i < len(first) || i < len(second); i++ {
+		if i < len(first) {
+			interleaved = append(interleaved, first[i])
+		}
+		if i < len(second) {
+			interleaved = append(interleaved, second[i])
+		}
+	}
+	return interleaved
+}
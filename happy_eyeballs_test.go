@@ -0,0 +1,69 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestInterleaveAddressFamilies(t *testing.T) {
+	ips := []net.IPAddr{
+		{IP: net.ParseIP("2001:db8::1")},
+		{IP: net.ParseIP("2001:db8::2")},
+		{IP: net.ParseIP("2001:db8::3")},
+		{IP: net.ParseIP("192.0.2.1")},
+	}
+	expected := []string{"2001:db8::1", "192.0.2.1", "2001:db8::2", "2001:db8::3"}
+	for i, ip := range interleaveAddressFamilies(ips) {
+		if ip.String() != expected[i] {
+			t.Errorf("expected %s at %d, got %s", expected[i], i, ip)
+		}
+	}
+}
+
+func TestHappyEyeballsDialer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	closed, err := net.Listen("tcp", "127.0.0.2:"+port)
+	if err != nil {
+		t.Skip("127.0.0.2 is not available:", err)
+	}
+	closed.Close()
+
+	lookup := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host != "broker.example" {
+			return nil, errors.New("unexpected host " + host)
+		}
+		// the first address refuses the connections
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.2")}, {IP: net.ParseIP("127.0.0.1")}}, nil
+	}
+	dialer := &happyEyeballsDialer{dialer: &net.Dialer{Timeout: 5 * time.Second}, delay: time.Second, lookup: lookup}
+
+	start := time.Now()
+	conn, err := dialer.Dial("tcp", net.JoinHostPort("broker.example", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if conn.RemoteAddr().String() != listener.Addr().String() {
+		t.Errorf("expected a connection to %s, got %s", listener.Addr(), conn.RemoteAddr())
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("expected the next address to be tried as soon as the first failed, took %s", elapsed)
+	}
+
+	failing := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.2")}, {IP: net.ParseIP("127.0.0.2")}}, nil
+	}
+	dialer.lookup = failing
+	if _, err := dialer.Dial("tcp", net.JoinHostPort("broker.example", port)); err == nil {
+		t.Error("expected the dial to fail when no address is reachable")
+	}
+}
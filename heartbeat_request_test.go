@@ -15,3 +15,22 @@ func TestHeartbeatRequest(t *testing.T) {
 	request.MemberId = "baz"
 	testRequest(t, "basic", request, basicHeartbeatRequest)
 }
+
+var heartbeatRequestV4 = []byte{
+	4, 'f', 'o', 'o', // Group ID
+	0x00, 0x01, 0x02, 0x03, // Generation ID
+	4, 'b', 'a', 'z', // Member ID
+	9, 'i', 'n', 's', 't', 'a', 'n', 'c', 'e', // Group Instance ID
+	0, // empty tagged fields
+}
+
+func TestHeartbeatRequestV4(t *testing.T) {
+	instance := "instance"
+	request := new(HeartbeatRequest)
+	request.Version = 4
+	request.GroupId = "foo"
+	request.GenerationId = 66051
+	request.MemberId = "baz"
+	request.GroupInstanceId = &instance
+	testRequest(t, "v4", request, heartbeatRequestV4)
+}
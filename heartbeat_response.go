@@ -1,21 +1,44 @@
 package sarama
 
 type HeartbeatResponse struct {
-	Err KError
+	Version      int16
+	ThrottleTime int32
+	Err          KError
 }
 
 func (r *HeartbeatResponse) encode(pe packetEncoder) error {
+	if r.Version >= 1 {
+		pe.putInt32(r.ThrottleTime)
+	}
 	pe.putInt16(int16(r.Err))
+
+	if r.Version >= 4 {
+		pe.putEmptyTaggedFieldArray()
+	}
 	return nil
 }
 
-func (r *HeartbeatResponse) decode(pd packetDecoder, version int16) error {
+func (r *HeartbeatResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.Version >= 1 {
+		if r.ThrottleTime, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+
 	kerr, err := pd.getInt16()
 	if err != nil {
 		return err
 	}
 	r.Err = KError(kerr)
 
+	if r.Version >= 4 {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -24,13 +47,31 @@ func (r *HeartbeatResponse) key() int16 {
 }
 
 func (r *HeartbeatResponse) version() int16 {
-	return 0
+	return r.Version
+}
+
+func (r *HeartbeatResponse) setVersion(v int16) {
+	r.Version = v
 }
 
 func (r *HeartbeatResponse) headerVersion() int16 {
+	if r.Version >= 4 {
+		return 1
+	}
 	return 0
 }
 
 func (r *HeartbeatResponse) requiredVersion() KafkaVersion {
-	return V0_9_0_0
+	switch r.Version {
+	case 4:
+		return V2_4_0_0
+	case 3:
+		return V2_3_0_0
+	case 2:
+		return V2_0_0_0
+	case 1:
+		return V0_11_0_0
+	default:
+		return V0_9_0_0
+	}
 }
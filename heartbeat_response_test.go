@@ -16,3 +16,18 @@ func TestHeartbeatResponse(t *testing.T) {
 		t.Error("Decoding error failed: no error expected but found", response.Err)
 	}
 }
+
+var heartbeatResponseV4 = []byte{
+	0x00, 0x00, 0x00, 0x64, // Throttle time
+	0x00, 0x1b, // ErrRebalanceInProgress
+	0x00, // empty tagged fields
+}
+
+func TestHeartbeatResponseV4(t *testing.T) {
+	response := &HeartbeatResponse{
+		Version:      4,
+		ThrottleTime: 100,
+		Err:          ErrRebalanceInProgress,
+	}
+	testResponse(t, "v4", response, heartbeatResponseV4)
+}
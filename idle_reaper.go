@@ -0,0 +1,116 @@
+package sarama
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// markUsed records that a request is sent on the connection, and on its owner
+// with Net.ConnectionsPerBroker, see Net.MaxIdleTime.
+func (b *Broker) markUsed() {
+	now := time.Now().UnixNano()
+	atomic.StoreInt64(&b.lastUsed, now)
+	if b.owner != nil {
+		atomic.StoreInt64(&b.owner.lastUsed, now)
+	}
+}
+
+// closeIfIdle closes the connection if no request was sent on it for maxIdle
+// and none is in flight, and reports whether it did.
+func (b *Broker) closeIfIdle(maxIdle time.Duration) bool {
+	b.lock.Lock()
+	idle := b.conn != nil && !b.draining && b.RequestsInFlight() == 0 &&
+		time.Since(time.Unix(0, atomic.LoadInt64(&b.lastUsed))) >= maxIdle
+	if idle {
+		// no more requests, those which raced with the check are answered
+		// before closing
+		b.draining = true
+	}
+	b.lock.Unlock()
+	if !idle {
+		return false
+	}
+
+	b.log().Debugf("Closing connection to broker %s idle for more than %s", b.addr, maxIdle)
+	if err := b.close(false); err != nil && err != ErrNotConnected {
+		b.log().Warnf("Error while closing idle connection to broker %s: %s", b.addr, err)
+	}
+	return true
+}
+
+// brokerUser is a producer or consumer created from the client, whose
+// connections to the brokers it sends requests to are kept even when idle
+type brokerUser interface {
+	usedBrokers() []*Broker
+}
+
+func (p *asyncProducer) usedBrokers() []*Broker {
+	p.brokerLock.Lock()
+	defer p.brokerLock.Unlock()
+
+	brokers := make([]*Broker, 0, len(p.brokers))
+	for broker := range p.brokers {
+		brokers = append(brokers, broker)
+	}
+	return brokers
+}
+
+func (c *consumer) usedBrokers() []*Broker {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	brokers := make([]*Broker, 0, len(c.brokerConsumers))
+	for broker := range c.brokerConsumers {
+		brokers = append(brokers, broker)
+	}
+	return brokers
+}
+
+// usedBrokers returns the brokers used by the producers and consumers
+// created from the client
+func (client *client) usedBrokers() map[*Broker]none {
+	client.statsLock.Lock()
+	defer client.statsLock.Unlock()
+
+	used := make(map[*Broker]none)
+	for _, reporter := range client.statsReporters {
+		if user, ok := reporter.(brokerUser); ok {
+			for _, broker := range user.usedBrokers() {
+				used[broker] = none{}
+			}
+		}
+	}
+	return used
+}
+
+// reapIdleBrokers closes the connections to the brokers idle for more than
+// Net.MaxIdleTime, but those to the group and transaction coordinators and
+// those used by the producers and consumers created from the client.
+func (client *client) reapIdleBrokers() {
+	used := client.usedBrokers()
+
+	client.lock.RLock()
+	coordinators := make(map[int32]none, len(client.coordinators)+len(client.transactionCoordinators))
+	for _, id := range client.coordinators {
+		coordinators[id] = none{}
+	}
+	for _, id := range client.transactionCoordinators {
+		coordinators[id] = none{}
+	}
+	brokers := make([]*Broker, 0, len(client.brokers)+len(client.seedBrokers))
+	for id, broker := range client.brokers {
+		if _, ok := coordinators[id]; ok {
+			continue
+		}
+		if _, ok := used[broker]; ok {
+			continue
+		}
+		brokers = append(brokers, broker)
+	}
+	brokers = append(brokers, client.seedBrokers...)
+	client.lock.RUnlock()
+
+	for _, broker := range brokers {
+		broker.closeIfIdle(client.conf.Net.MaxIdleTime)
+	}
+}
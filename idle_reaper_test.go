@@ -0,0 +1,135 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClientMaxIdleTime(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader := NewMockBroker(t, 2)
+	defer leader.Close()
+	coordinator := NewMockBroker(t, 3)
+	defer coordinator.Close()
+
+	metadataResponse := NewMockMetadataResponse(t).
+		SetBroker(leader.Addr(), leader.BrokerID()).
+		SetBroker(coordinator.Addr(), coordinator.BrokerID()).
+		SetLeader("my-topic", 0, leader.BrokerID())
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadataResponse,
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "my-group", coordinator),
+	})
+
+	config := NewTestConfig()
+	config.Net.MaxIdleTime = 100 * time.Millisecond
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	leaderBroker, err := client.Leader("my-topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coordinatorBroker, err := client.Coordinator("my-group")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connected, _ := leaderBroker.Connected(); !connected {
+		t.Fatal("expected the leader to be connected")
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if connected, _ := leaderBroker.Connected(); connected {
+		t.Error("expected the idle connection to the leader to be closed")
+	}
+	if connected, _ := coordinatorBroker.Connected(); !connected {
+		t.Error("expected the connection to the coordinator to be kept")
+	}
+
+	// and opened again when needed
+	if leaderBroker, err = client.Leader("my-topic", 0); err != nil {
+		t.Fatal(err)
+	}
+	if connected, err := leaderBroker.Connected(); !connected {
+		t.Errorf("expected the leader to be connected again, got %v", err)
+	}
+}
+
+func TestBrokerCloseIfIdle(t *testing.T) {
+	mockBroker := NewMockBroker(t, 1)
+	defer mockBroker.Close()
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(NewTestConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if broker.closeIfIdle(time.Hour) {
+		t.Error("expected the connection just opened not to be idle")
+	}
+	if !broker.closeIfIdle(0) {
+		t.Error("expected the connection to be closed")
+	}
+	if connected, _ := broker.Connected(); connected {
+		t.Error("expected the broker to be disconnected")
+	}
+	if broker.closeIfIdle(0) {
+		t.Error("expected a closed connection not to be closed again")
+	}
+}
+
+func TestClientMaxIdleTimeKeepsProducerBrokers(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader := NewMockBroker(t, 2)
+	defer leader.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(leader.Addr(), leader.BrokerID()).
+			SetLeader("my-topic", 0, leader.BrokerID()),
+	})
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"ProduceRequest": NewMockProduceResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Net.MaxIdleTime = 100 * time.Millisecond
+	config.Producer.Return.Successes = true
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	producer, err := NewAsyncProducerFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	producer.Input() <- &ProducerMessage{Topic: "my-topic", Value: StringEncoder(TestMessage)}
+	select {
+	case <-producer.Successes():
+	case err := <-producer.Errors():
+		t.Fatal(err)
+	}
+
+	leaderBroker, err := client.Leader("my-topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if connected, _ := leaderBroker.Connected(); !connected {
+		t.Error("expected the connection used by the producer to be kept")
+	}
+
+	// and reaped once the producer is closed
+	safeClose(t, producer)
+	time.Sleep(300 * time.Millisecond)
+	if connected, _ := leaderBroker.Connected(); connected {
+		t.Error("expected the idle connection to the leader to be closed")
+	}
+}
@@ -3,21 +3,52 @@ package sarama
 import "time"
 
 type InitProducerIDRequest struct {
+	Version            int16
 	TransactionalID    *string
 	TransactionTimeout time.Duration
+	// ProducerID and ProducerEpoch are those of the producer initializing
+	// its producer ID again, -1 if none (v3 or later, KIP-360)
+	ProducerID    int64
+	ProducerEpoch int16
+	// Enable2Pc is whether the producer participates in two-phase commits,
+	// its transactions being exempt from the transaction timeout, and
+	// KeepPreparedTxn whether its prepared transaction is kept rather than
+	// aborted (v6 or later, KIP-939)
+	Enable2Pc       bool
+	KeepPreparedTxn bool
 }
 
 func (i *InitProducerIDRequest) encode(pe packetEncoder) error {
-	if err := pe.putNullableString(i.TransactionalID); err != nil {
+	if i.Version >= 2 {
+		if err := pe.putNullableCompactString(i.TransactionalID); err != nil {
+			return err
+		}
+	} else if err := pe.putNullableString(i.TransactionalID); err != nil {
 		return err
 	}
 	pe.putInt32(int32(i.TransactionTimeout / time.Millisecond))
+	if i.Version >= 3 {
+		pe.putInt64(i.ProducerID)
+		pe.putInt16(i.ProducerEpoch)
+	}
+	if i.Version >= 6 {
+		pe.putBool(i.Enable2Pc)
+		pe.putBool(i.KeepPreparedTxn)
+	}
+	if i.Version >= 2 {
+		pe.putEmptyTaggedFieldArray()
+	}
 
 	return nil
 }
 
 func (i *InitProducerIDRequest) decode(pd packetDecoder, version int16) (err error) {
-	if i.TransactionalID, err = pd.getNullableString(); err != nil {
+	i.Version = version
+	if version >= 2 {
+		if i.TransactionalID, err = pd.getCompactNullableString(); err != nil {
+			return err
+		}
+	} else if i.TransactionalID, err = pd.getNullableString(); err != nil {
 		return err
 	}
 
@@ -27,6 +58,28 @@ func (i *InitProducerIDRequest) decode(pd packetDecoder, version int16) (err err
 	}
 	i.TransactionTimeout = time.Duration(timeout) * time.Millisecond
 
+	if version >= 3 {
+		if i.ProducerID, err = pd.getInt64(); err != nil {
+			return err
+		}
+		if i.ProducerEpoch, err = pd.getInt16(); err != nil {
+			return err
+		}
+	}
+	if version >= 6 {
+		if i.Enable2Pc, err = pd.getBool(); err != nil {
+			return err
+		}
+		if i.KeepPreparedTxn, err = pd.getBool(); err != nil {
+			return err
+		}
+	}
+	if version >= 2 {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -35,13 +88,55 @@ func (i *InitProducerIDRequest) key() int16 {
 }
 
 func (i *InitProducerIDRequest) version() int16 {
-	return 0
+	return i.Version
+}
+
+func (i *InitProducerIDRequest) versionRange() (minVersion, maxVersion int16) {
+	if i.Version >= 3 && (i.ProducerID != -1 || i.ProducerEpoch != -1) {
+		minVersion = 3
+	}
+	if i.Version >= 6 && (i.Enable2Pc || i.KeepPreparedTxn) {
+		minVersion = 6
+	}
+	return minVersion, 6
+}
+
+func (i *InitProducerIDRequest) withVersion(v int16) protocolBody {
+	request := *i
+	request.Version = v
+	if i.Version < 3 {
+		request.ProducerID = -1
+		request.ProducerEpoch = -1
+	}
+	if i.Version < 6 {
+		request.Enable2Pc = false
+		request.KeepPreparedTxn = false
+	}
+	return &request
 }
 
 func (i *InitProducerIDRequest) headerVersion() int16 {
+	if i.Version >= 2 {
+		return 2
+	}
 	return 1
 }
 
 func (i *InitProducerIDRequest) requiredVersion() KafkaVersion {
-	return V0_11_0_0
+	switch i.Version {
+	case 1:
+		return V2_0_0_0
+	case 2:
+		return V2_4_0_0
+	case 3:
+		return V2_5_0_0
+	case 4:
+		return V2_7_0_0
+	case 5:
+		return V3_8_0_0
+	case 6:
+		return V4_1_0_0
+	default:
+		return V0_11_0_0
+	}
 }
@@ -15,6 +15,16 @@ var (
 		0, 3, 't', 'x', 'n',
 		0, 0, 0, 100,
 	}
+
+	initProducerIDRequestV6 = []byte{
+		4, 't', 'x', 'n',
+		0, 0, 0, 100,
+		255, 255, 255, 255, 255, 255, 255, 255, // producerID = -1
+		255, 255, // epoch = -1
+		1, // enable2Pc
+		1, // keepPreparedTxn
+		0, // empty tagged fields
+	}
 )
 
 func TestInitProducerIDRequest(t *testing.T) {
@@ -29,3 +39,18 @@ func TestInitProducerIDRequest(t *testing.T) {
 
 	testRequest(t, "transaction id", req, initProducerIDRequest)
 }
+
+func TestInitProducerIDRequestV6(t *testing.T) {
+	transactionID := "txn"
+	req := &InitProducerIDRequest{
+		Version:            6,
+		TransactionalID:    &transactionID,
+		TransactionTimeout: 100 * time.Millisecond,
+		ProducerID:         -1,
+		ProducerEpoch:      -1,
+		Enable2Pc:          true,
+		KeepPreparedTxn:    true,
+	}
+
+	testRequest(t, "two-phase commit", req, initProducerIDRequestV6)
+}
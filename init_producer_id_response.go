@@ -3,10 +3,16 @@ package sarama
 import "time"
 
 type InitProducerIDResponse struct {
+	Version       int16
 	ThrottleTime  time.Duration
 	Err           KError
 	ProducerID    int64
 	ProducerEpoch int16
+	// OngoingTxnProducerID and OngoingTxnProducerEpoch identify the prepared
+	// transaction kept with KeepPreparedTxn, -1 if none (v6 or later,
+	// KIP-939)
+	OngoingTxnProducerID    int64
+	OngoingTxnProducerEpoch int16
 }
 
 func (i *InitProducerIDResponse) encode(pe packetEncoder) error {
@@ -14,11 +20,19 @@ func (i *InitProducerIDResponse) encode(pe packetEncoder) error {
 	pe.putInt16(int16(i.Err))
 	pe.putInt64(i.ProducerID)
 	pe.putInt16(i.ProducerEpoch)
+	if i.Version >= 6 {
+		pe.putInt64(i.OngoingTxnProducerID)
+		pe.putInt16(i.OngoingTxnProducerEpoch)
+	}
+	if i.Version >= 2 {
+		pe.putEmptyTaggedFieldArray()
+	}
 
 	return nil
 }
 
 func (i *InitProducerIDResponse) decode(pd packetDecoder, version int16) (err error) {
+	i.Version = version
 	throttleTime, err := pd.getInt32()
 	if err != nil {
 		return err
@@ -39,6 +53,20 @@ func (i *InitProducerIDResponse) decode(pd packetDecoder, version int16) (err er
 		return err
 	}
 
+	if version >= 6 {
+		if i.OngoingTxnProducerID, err = pd.getInt64(); err != nil {
+			return err
+		}
+		if i.OngoingTxnProducerEpoch, err = pd.getInt16(); err != nil {
+			return err
+		}
+	}
+	if version >= 2 {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -47,13 +75,35 @@ func (i *InitProducerIDResponse) key() int16 {
 }
 
 func (i *InitProducerIDResponse) version() int16 {
-	return 0
+	return i.Version
+}
+
+func (i *InitProducerIDResponse) setVersion(v int16) {
+	i.Version = v
 }
 
 func (i *InitProducerIDResponse) headerVersion() int16 {
+	if i.Version >= 2 {
+		return 1
+	}
 	return 0
 }
 
 func (i *InitProducerIDResponse) requiredVersion() KafkaVersion {
-	return V0_11_0_0
+	switch i.Version {
+	case 1:
+		return V2_0_0_0
+	case 2:
+		return V2_4_0_0
+	case 3:
+		return V2_5_0_0
+	case 4:
+		return V2_7_0_0
+	case 5:
+		return V3_8_0_0
+	case 6:
+		return V4_1_0_0
+	default:
+		return V0_11_0_0
+	}
 }
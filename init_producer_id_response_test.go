@@ -19,6 +19,16 @@ var (
 		255, 255, 255, 255, 255, 255, 255, 255,
 		0, 0,
 	}
+
+	initProducerIDResponseV6 = []byte{
+		0, 0, 0, 100,
+		0, 0,
+		0, 0, 0, 0, 0, 0, 31, 64, // producerID = 8000
+		0, 2, // epoch
+		0, 0, 0, 0, 0, 0, 31, 64, // ongoingTxnProducerID = 8000
+		0, 1, // ongoingTxnProducerEpoch
+		0, // empty tagged fields
+	}
 )
 
 func TestInitProducerIDResponse(t *testing.T) {
@@ -35,3 +45,16 @@ func TestInitProducerIDResponse(t *testing.T) {
 
 	testResponse(t, "with error", resp, initProducerIDRequestError)
 }
+
+func TestInitProducerIDResponseV6(t *testing.T) {
+	resp := &InitProducerIDResponse{
+		Version:                 6,
+		ThrottleTime:            100 * time.Millisecond,
+		ProducerID:              8000,
+		ProducerEpoch:           2,
+		OngoingTxnProducerID:    8000,
+		OngoingTxnProducerEpoch: 1,
+	}
+
+	testResponse(t, "kept prepared transaction", resp, initProducerIDResponseV6)
+}
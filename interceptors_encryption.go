@@ -0,0 +1,222 @@
+package sarama
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// EncryptionKeyIDHeader is the header of the messages encrypted by the
+	// envelope encryption interceptors holding the ID of the key of the key
+	// provider which encrypted their data key.
+	EncryptionKeyIDHeader = "sarama-encryption-key-id"
+	// EncryptionDataKeyHeader is the header of the messages encrypted by the
+	// envelope encryption interceptors holding their encrypted data key.
+	EncryptionDataKeyHeader = "sarama-encryption-data-key"
+
+	// maxDecryptedDataKeys bounds the data keys cached by the consumer
+	// interceptor
+	maxDecryptedDataKeys = 1024
+)
+
+// EnvelopeKeyProvider is the key management service of the envelope
+// encryption interceptors: the values of the messages are encrypted with
+// AES-GCM by data keys, stored encrypted by the provider in the headers of
+// the messages.
+type EnvelopeKeyProvider interface {
+	// GenerateDataKey returns a new AES data key of 16, 24 or 32 bytes,
+	// the same key encrypted, and the ID of the key encrypting it.
+	GenerateDataKey() (keyID string, dataKey, encryptedDataKey []byte, err error)
+	// DecryptDataKey returns the data key encrypted by the key with the ID.
+	DecryptDataKey(keyID string, encryptedDataKey []byte) ([]byte, error)
+}
+
+// envelopeDataKey is a data key along with its encrypted form
+type envelopeDataKey struct {
+	keyID     string
+	encrypted []byte
+	aead      cipher.AEAD
+	expiry    time.Time
+}
+
+// envelopeEncryptionInterceptor is the ProducerInterceptor encrypting the
+// values of the messages.
+type envelopeEncryptionInterceptor struct {
+	provider        EnvelopeKeyProvider
+	dataKeyLifetime time.Duration
+
+	lock    sync.Mutex
+	dataKey *envelopeDataKey
+}
+
+// NewEnvelopeEncryptionInterceptor returns a ProducerInterceptor encrypting
+// the values of the messages with a data key of the provider, generated again
+// once it encrypted messages for dataKeyLifetime, or for each message if zero.
+// The headers of the messages hold the encrypted data key and the ID of the
+// key encrypting it. A message failing to be encrypted fails to be produced
+// with the error. The keys of the messages are not encrypted so that they
+// are partitioned as usual. It must run after the interceptors changing the
+// values, and requires Kafka 0.11 or later for the headers.
+func NewEnvelopeEncryptionInterceptor(provider EnvelopeKeyProvider, dataKeyLifetime time.Duration) ProducerInterceptor {
+	return &envelopeEncryptionInterceptor{provider: provider, dataKeyLifetime: dataKeyLifetime}
+}
+
+func (i *envelopeEncryptionInterceptor) OnSend(msg *ProducerMessage) {
+	if msg.Value == nil {
+		return
+	}
+	value, err := msg.Value.Encode()
+	if err != nil {
+		msg.Value = failedEncoder{err}
+		return
+	}
+	dataKey, err := i.currentDataKey()
+	if err != nil {
+		msg.Value = failedEncoder{fmt.Errorf("failed to generate the data key of the message: %w", err)}
+		return
+	}
+
+	nonce := make([]byte, dataKey.aead.NonceSize(), dataKey.aead.NonceSize()+len(value)+dataKey.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		msg.Value = failedEncoder{err}
+		return
+	}
+	msg.Value = ByteEncoder(dataKey.aead.Seal(nonce, nonce, value, []byte(dataKey.keyID)))
+	msg.Headers = append(msg.Headers,
+		RecordHeader{Key: []byte(EncryptionKeyIDHeader), Value: []byte(dataKey.keyID)},
+		RecordHeader{Key: []byte(EncryptionDataKeyHeader), Value: dataKey.encrypted},
+	)
+}
+
+// currentDataKey returns the data key to encrypt a message with
+func (i *envelopeEncryptionInterceptor) currentDataKey() (*envelopeDataKey, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.dataKey != nil && time.Now().Before(i.dataKey.expiry) {
+		return i.dataKey, nil
+	}
+	keyID, key, encrypted, err := i.provider.GenerateDataKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newEnvelopeAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	i.dataKey = &envelopeDataKey{keyID: keyID, encrypted: encrypted, aead: aead, expiry: time.Now().Add(i.dataKeyLifetime)}
+	return i.dataKey, nil
+}
+
+// envelopeDecryptionInterceptor is the ConsumerInterceptor decrypting the
+// values of the messages.
+type envelopeDecryptionInterceptor struct {
+	provider EnvelopeKeyProvider
+
+	lock     sync.Mutex
+	dataKeys map[string]cipher.AEAD
+}
+
+// NewEnvelopeDecryptionInterceptor returns a ConsumerInterceptor decrypting
+// the values of the messages encrypted by NewEnvelopeEncryptionInterceptor,
+// whose encryption headers are then removed. The messages failing to be
+// decrypted are delivered as they were consumed, encrypted with their
+// headers, the error being logged, and the messages without the headers are
+// delivered unchanged. The decrypted data keys are cached.
+func NewEnvelopeDecryptionInterceptor(provider EnvelopeKeyProvider) ConsumerInterceptor {
+	return &envelopeDecryptionInterceptor{provider: provider, dataKeys: make(map[string]cipher.AEAD)}
+}
+
+func (i *envelopeDecryptionInterceptor) OnConsume(msg *ConsumerMessage) {
+	var keyID, encryptedDataKey []byte
+	var headers []*RecordHeader
+	for _, header := range msg.Headers {
+		switch {
+		case header != nil && bytes.Equal(header.Key, []byte(EncryptionKeyIDHeader)):
+			keyID = header.Value
+		case header != nil && bytes.Equal(header.Key, []byte(EncryptionDataKeyHeader)):
+			encryptedDataKey = header.Value
+		default:
+			headers = append(headers, header)
+		}
+	}
+	if keyID == nil || encryptedDataKey == nil || msg.Value == nil {
+		return
+	}
+
+	value, err := i.decrypt(string(keyID), encryptedDataKey, msg.Value)
+	if err != nil {
+		Logger.Printf("Failed to decrypt the message of %s/%d at offset %d: %s\n", msg.Topic, msg.Partition, msg.Offset, err)
+		return
+	}
+	msg.Value = value
+	msg.Headers = headers
+}
+
+func (i *envelopeDecryptionInterceptor) decrypt(keyID string, encryptedDataKey, value []byte) ([]byte, error) {
+	aead, err := i.dataKey(keyID, encryptedDataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) < aead.NonceSize() {
+		return nil, errors.New("the encrypted value is too short")
+	}
+	nonce, ciphertext := value[:aead.NonceSize()], value[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(keyID))
+}
+
+// dataKey returns the cipher of the encrypted data key
+func (i *envelopeDecryptionInterceptor) dataKey(keyID string, encryptedDataKey []byte) (cipher.AEAD, error) {
+	cacheKey := keyID + "\x00" + string(encryptedDataKey)
+	i.lock.Lock()
+	aead, ok := i.dataKeys[cacheKey]
+	i.lock.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	key, err := i.provider.DecryptDataKey(keyID, encryptedDataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the data key: %w", err)
+	}
+	if aead, err = newEnvelopeAEAD(key); err != nil {
+		return nil, err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if len(i.dataKeys) >= maxDecryptedDataKeys {
+		i.dataKeys = make(map[string]cipher.AEAD)
+	}
+	i.dataKeys[cacheKey] = aead
+	return aead, nil
+}
+
+func newEnvelopeAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// failedEncoder is the value of a message which failed to be transformed,
+// failing it with the error when produced.
+type failedEncoder struct {
+	err error
+}
+
+func (e failedEncoder) Encode() ([]byte, error) {
+	return nil, e.err
+}
+
+func (e failedEncoder) Length() int {
+	return 0
+}
@@ -0,0 +1,109 @@
+package sarama
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// xorKeyProvider encrypts the data keys with a XOR, for testing only
+type xorKeyProvider struct {
+	generated int
+	failing   bool
+}
+
+func (p *xorKeyProvider) GenerateDataKey() (string, []byte, []byte, error) {
+	if p.failing {
+		return "", nil, nil, errors.New("KMS unavailable")
+	}
+	p.generated++
+	key := bytes.Repeat([]byte{byte(p.generated)}, 32)
+	return "master", key, p.xor(key), nil
+}
+
+func (p *xorKeyProvider) DecryptDataKey(keyID string, encrypted []byte) ([]byte, error) {
+	if keyID != "master" {
+		return nil, errors.New("unknown key")
+	}
+	return p.xor(encrypted), nil
+}
+
+func (p *xorKeyProvider) xor(key []byte) []byte {
+	result := make([]byte, len(key))
+	for i, b := range key {
+		result[i] = b ^ 0x5a
+	}
+	return result
+}
+
+// consumed returns the message as consumed after being produced
+func consumed(msg *ProducerMessage) *ConsumerMessage {
+	value, _ := msg.Value.Encode()
+	consumerMsg := &ConsumerMessage{Topic: msg.Topic, Value: value}
+	for i := range msg.Headers {
+		consumerMsg.Headers = append(consumerMsg.Headers, &msg.Headers[i])
+	}
+	return consumerMsg
+}
+
+func TestEnvelopeEncryptionInterceptors(t *testing.T) {
+	provider := &xorKeyProvider{}
+	encrypt := NewEnvelopeEncryptionInterceptor(provider, time.Hour)
+	decrypt := NewEnvelopeDecryptionInterceptor(provider)
+
+	for i := 0; i < 2; i++ {
+		msg := &ProducerMessage{
+			Topic:   "payments",
+			Value:   StringEncoder("secret"),
+			Headers: []RecordHeader{{Key: []byte("trace"), Value: []byte("1")}},
+		}
+		encrypt.OnSend(msg)
+		if value, err := msg.Value.Encode(); err != nil || bytes.Contains(value, []byte("secret")) {
+			t.Fatalf("expected the value to be encrypted, got %q %v", value, err)
+		}
+		if len(msg.Headers) != 3 {
+			t.Fatalf("expected the encryption headers, got %d headers", len(msg.Headers))
+		}
+
+		consumerMsg := consumed(msg)
+		decrypt.OnConsume(consumerMsg)
+		if string(consumerMsg.Value) != "secret" {
+			t.Errorf("expected the value to be decrypted, got %q", consumerMsg.Value)
+		}
+		if len(consumerMsg.Headers) != 1 || string(consumerMsg.Headers[0].Key) != "trace" {
+			t.Errorf("expected the encryption headers to be removed, got %v", consumerMsg.Headers)
+		}
+	}
+	if provider.generated != 1 {
+		t.Errorf("expected the data key to be reused, got %d data keys", provider.generated)
+	}
+}
+
+func TestEnvelopeEncryptionInterceptorFailures(t *testing.T) {
+	provider := &xorKeyProvider{failing: true}
+	msg := &ProducerMessage{Topic: "payments", Value: StringEncoder("secret")}
+	NewEnvelopeEncryptionInterceptor(provider, 0).OnSend(msg)
+	if _, err := msg.Value.Encode(); err == nil {
+		t.Error("expected the message to fail with the data key error")
+	}
+
+	// a tampered message is delivered as consumed
+	provider.failing = false
+	msg = &ProducerMessage{Topic: "payments", Value: StringEncoder("secret")}
+	NewEnvelopeEncryptionInterceptor(provider, 0).OnSend(msg)
+	consumerMsg := consumed(msg)
+	consumerMsg.Value[len(consumerMsg.Value)-1] ^= 0xff
+	tampered := append([]byte(nil), consumerMsg.Value...)
+	NewEnvelopeDecryptionInterceptor(provider).OnConsume(consumerMsg)
+	if !bytes.Equal(consumerMsg.Value, tampered) || len(consumerMsg.Headers) != 2 {
+		t.Error("expected the message failing to be decrypted to be left unchanged")
+	}
+
+	// the messages which are not encrypted are left unchanged
+	plain := &ConsumerMessage{Value: []byte("plain")}
+	NewEnvelopeDecryptionInterceptor(provider).OnConsume(plain)
+	if string(plain.Value) != "plain" {
+		t.Errorf("expected the value to be left unchanged, got %q", plain.Value)
+	}
+}
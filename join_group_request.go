@@ -5,7 +5,17 @@ type GroupProtocol struct {
 	Metadata []byte
 }
 
-func (p *GroupProtocol) decode(pd packetDecoder) (err error) {
+func (p *GroupProtocol) decode(pd packetDecoder, version int16) (err error) {
+	if version >= 6 {
+		if p.Name, err = pd.getCompactString(); err != nil {
+			return err
+		}
+		if p.Metadata, err = pd.getCompactBytes(); err != nil {
+			return err
+		}
+		_, err = pd.getEmptyTaggedFieldArray()
+		return err
+	}
 	p.Name, err = pd.getString()
 	if err != nil {
 		return err
@@ -14,7 +24,17 @@ func (p *GroupProtocol) decode(pd packetDecoder) (err error) {
 	return err
 }
 
-func (p *GroupProtocol) encode(pe packetEncoder) (err error) {
+func (p *GroupProtocol) encode(pe packetEncoder, version int16) (err error) {
+	if version >= 6 {
+		if err := pe.putCompactString(p.Name); err != nil {
+			return err
+		}
+		if err := pe.putCompactBytes(p.Metadata); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+		return nil
+	}
 	if err := pe.putString(p.Name); err != nil {
 		return err
 	}
@@ -30,23 +50,51 @@ type JoinGroupRequest struct {
 	SessionTimeout        int32
 	RebalanceTimeout      int32
 	MemberId              string
+	GroupInstanceId       *string // version 5 or later
 	ProtocolType          string
 	GroupProtocols        map[string][]byte // deprecated; use OrderedGroupProtocols
 	OrderedGroupProtocols []*GroupProtocol
 }
 
-func (r *JoinGroupRequest) encode(pe packetEncoder) error {
-	if err := pe.putString(r.GroupId); err != nil {
+func (r *JoinGroupRequest) encode(pe packetEncoder) (err error) {
+	isFlexible := r.Version >= 6
+
+	if isFlexible {
+		err = pe.putCompactString(r.GroupId)
+	} else {
+		err = pe.putString(r.GroupId)
+	}
+	if err != nil {
 		return err
 	}
 	pe.putInt32(r.SessionTimeout)
 	if r.Version >= 1 {
 		pe.putInt32(r.RebalanceTimeout)
 	}
-	if err := pe.putString(r.MemberId); err != nil {
+	if isFlexible {
+		err = pe.putCompactString(r.MemberId)
+	} else {
+		err = pe.putString(r.MemberId)
+	}
+	if err != nil {
 		return err
 	}
-	if err := pe.putString(r.ProtocolType); err != nil {
+	if r.Version >= 5 {
+		if isFlexible {
+			err = pe.putNullableCompactString(r.GroupInstanceId)
+		} else {
+			err = pe.putNullableString(r.GroupInstanceId)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if isFlexible {
+		err = pe.putCompactString(r.ProtocolType)
+	} else {
+		err = pe.putString(r.ProtocolType)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -55,35 +103,47 @@ func (r *JoinGroupRequest) encode(pe packetEncoder) error {
 			return PacketDecodingError{"cannot specify both GroupProtocols and OrderedGroupProtocols on JoinGroupRequest"}
 		}
 
-		if err := pe.putArrayLength(len(r.GroupProtocols)); err != nil {
+		if isFlexible {
+			pe.putCompactArrayLength(len(r.GroupProtocols))
+		} else if err := pe.putArrayLength(len(r.GroupProtocols)); err != nil {
 			return err
 		}
 		for name, metadata := range r.GroupProtocols {
-			if err := pe.putString(name); err != nil {
-				return err
-			}
-			if err := pe.putBytes(metadata); err != nil {
+			protocol := &GroupProtocol{Name: name, Metadata: metadata}
+			if err := protocol.encode(pe, r.Version); err != nil {
 				return err
 			}
 		}
 	} else {
-		if err := pe.putArrayLength(len(r.OrderedGroupProtocols)); err != nil {
+		if isFlexible {
+			pe.putCompactArrayLength(len(r.OrderedGroupProtocols))
+		} else if err := pe.putArrayLength(len(r.OrderedGroupProtocols)); err != nil {
 			return err
 		}
 		for _, protocol := range r.OrderedGroupProtocols {
-			if err := protocol.encode(pe); err != nil {
+			if err := protocol.encode(pe, r.Version); err != nil {
 				return err
 			}
 		}
 	}
 
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
 func (r *JoinGroupRequest) decode(pd packetDecoder, version int16) (err error) {
 	r.Version = version
+	isFlexible := r.Version >= 6
 
-	if r.GroupId, err = pd.getString(); err != nil {
+	if isFlexible {
+		r.GroupId, err = pd.getCompactString()
+	} else {
+		r.GroupId, err = pd.getString()
+	}
+	if err != nil {
 		return
 	}
 
@@ -97,32 +157,63 @@ func (r *JoinGroupRequest) decode(pd packetDecoder, version int16) (err error) {
 		}
 	}
 
-	if r.MemberId, err = pd.getString(); err != nil {
+	if isFlexible {
+		r.MemberId, err = pd.getCompactString()
+	} else {
+		r.MemberId, err = pd.getString()
+	}
+	if err != nil {
 		return
 	}
 
-	if r.ProtocolType, err = pd.getString(); err != nil {
+	if version >= 5 {
+		if isFlexible {
+			r.GroupInstanceId, err = pd.getCompactNullableString()
+		} else {
+			r.GroupInstanceId, err = pd.getNullableString()
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	if isFlexible {
+		r.ProtocolType, err = pd.getCompactString()
+	} else {
+		r.ProtocolType, err = pd.getString()
+	}
+	if err != nil {
 		return
 	}
 
-	n, err := pd.getArrayLength()
+	var n int
+	if isFlexible {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
-	if n == 0 {
-		return nil
-	}
 
-	r.GroupProtocols = make(map[string][]byte)
+	if n > 0 {
+		r.GroupProtocols = make(map[string][]byte)
+	}
 	for i := 0; i < n; i++ {
 		protocol := &GroupProtocol{}
-		if err := protocol.decode(pd); err != nil {
+		if err := protocol.decode(pd, version); err != nil {
 			return err
 		}
 		r.GroupProtocols[protocol.Name] = protocol.Metadata
 		r.OrderedGroupProtocols = append(r.OrderedGroupProtocols, protocol)
 	}
 
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -134,12 +225,50 @@ func (r *JoinGroupRequest) version() int16 {
 	return r.Version
 }
 
+func (r *JoinGroupRequest) versionRange() (minVersion, maxVersion int16) {
+	maxVersion = 6
+	if r.Version < 4 && r.MemberId == "" && r.GroupInstanceId == nil {
+		// v4 and later return MEMBER_ID_REQUIRED to the new members
+		maxVersion = 3
+	}
+	if r.Version >= 1 {
+		minVersion = 1
+	}
+	if r.Version >= 5 && r.GroupInstanceId != nil {
+		minVersion = 5
+	}
+	return minVersion, maxVersion
+}
+
+func (r *JoinGroupRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	if r.Version < 1 {
+		request.RebalanceTimeout = r.SessionTimeout
+	}
+	if r.Version < 5 {
+		request.GroupInstanceId = nil
+	}
+	return &request
+}
+
 func (r *JoinGroupRequest) headerVersion() int16 {
+	if r.Version >= 6 {
+		return 2
+	}
 	return 1
 }
 
 func (r *JoinGroupRequest) requiredVersion() KafkaVersion {
 	switch r.Version {
+	case 6:
+		return V2_4_0_0
+	case 5:
+		return V2_3_0_0
+	case 4:
+		return V2_2_0_0
+	case 3:
+		return V2_0_0_0
 	case 2:
 		return V0_11_0_0
 	case 1:
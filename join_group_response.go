@@ -9,6 +9,9 @@ type JoinGroupResponse struct {
 	LeaderId      string
 	MemberId      string
 	Members       map[string][]byte
+	// GroupInstanceIds are the group instance IDs of the static members,
+	// by member ID (version 5 or later)
+	GroupInstanceIds map[string]*string
 }
 
 func (r *JoinGroupResponse) GetMembers() (map[string]ConsumerGroupMemberMetadata, error) {
@@ -23,35 +26,70 @@ func (r *JoinGroupResponse) GetMembers() (map[string]ConsumerGroupMemberMetadata
 	return members, nil
 }
 
-func (r *JoinGroupResponse) encode(pe packetEncoder) error {
+func (r *JoinGroupResponse) encode(pe packetEncoder) (err error) {
+	isFlexible := r.Version >= 6
+
 	if r.Version >= 2 {
 		pe.putInt32(r.ThrottleTime)
 	}
 	pe.putInt16(int16(r.Err))
 	pe.putInt32(r.GenerationId)
 
-	if err := pe.putString(r.GroupProtocol); err != nil {
-		return err
-	}
-	if err := pe.putString(r.LeaderId); err != nil {
-		return err
-	}
-	if err := pe.putString(r.MemberId); err != nil {
-		return err
+	for _, s := range []string{r.GroupProtocol, r.LeaderId, r.MemberId} {
+		if isFlexible {
+			err = pe.putCompactString(s)
+		} else {
+			err = pe.putString(s)
+		}
+		if err != nil {
+			return err
+		}
 	}
 
-	if err := pe.putArrayLength(len(r.Members)); err != nil {
+	if isFlexible {
+		pe.putCompactArrayLength(len(r.Members))
+	} else if err := pe.putArrayLength(len(r.Members)); err != nil {
 		return err
 	}
 
 	for memberId, memberMetadata := range r.Members {
-		if err := pe.putString(memberId); err != nil {
+		if isFlexible {
+			err = pe.putCompactString(memberId)
+		} else {
+			err = pe.putString(memberId)
+		}
+		if err != nil {
 			return err
 		}
 
-		if err := pe.putBytes(memberMetadata); err != nil {
+		if r.Version >= 5 {
+			groupInstanceId := r.GroupInstanceIds[memberId]
+			if isFlexible {
+				err = pe.putNullableCompactString(groupInstanceId)
+			} else {
+				err = pe.putNullableString(groupInstanceId)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if isFlexible {
+			err = pe.putCompactBytes(memberMetadata)
+		} else {
+			err = pe.putBytes(memberMetadata)
+		}
+		if err != nil {
 			return err
 		}
+
+		if isFlexible {
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
 	}
 
 	return nil
@@ -59,6 +97,7 @@ func (r *JoinGroupResponse) encode(pe packetEncoder) error {
 
 func (r *JoinGroupResponse) decode(pd packetDecoder, version int16) (err error) {
 	r.Version = version
+	isFlexible := r.Version >= 6
 
 	if version >= 2 {
 		if r.ThrottleTime, err = pd.getInt32(); err != nil {
@@ -77,41 +116,84 @@ func (r *JoinGroupResponse) decode(pd packetDecoder, version int16) (err error)
 		return
 	}
 
-	if r.GroupProtocol, err = pd.getString(); err != nil {
-		return
-	}
-
-	if r.LeaderId, err = pd.getString(); err != nil {
-		return
+	for _, s := range []*string{&r.GroupProtocol, &r.LeaderId, &r.MemberId} {
+		if isFlexible {
+			*s, err = pd.getCompactString()
+		} else {
+			*s, err = pd.getString()
+		}
+		if err != nil {
+			return
+		}
 	}
 
-	if r.MemberId, err = pd.getString(); err != nil {
-		return
+	var n int
+	if isFlexible {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
 	}
-
-	n, err := pd.getArrayLength()
 	if err != nil {
 		return err
 	}
-	if n == 0 {
-		return nil
-	}
 
-	r.Members = make(map[string][]byte)
+	if n > 0 {
+		r.Members = make(map[string][]byte)
+	}
 	for i := 0; i < n; i++ {
-		memberId, err := pd.getString()
+		var memberId string
+		if isFlexible {
+			memberId, err = pd.getCompactString()
+		} else {
+			memberId, err = pd.getString()
+		}
 		if err != nil {
 			return err
 		}
 
-		memberMetadata, err := pd.getBytes()
+		if version >= 5 {
+			var groupInstanceId *string
+			if isFlexible {
+				groupInstanceId, err = pd.getCompactNullableString()
+			} else {
+				groupInstanceId, err = pd.getNullableString()
+			}
+			if err != nil {
+				return err
+			}
+			if groupInstanceId != nil {
+				if r.GroupInstanceIds == nil {
+					r.GroupInstanceIds = make(map[string]*string)
+				}
+				r.GroupInstanceIds[memberId] = groupInstanceId
+			}
+		}
+
+		var memberMetadata []byte
+		if isFlexible {
+			memberMetadata, err = pd.getCompactBytes()
+		} else {
+			memberMetadata, err = pd.getBytes()
+		}
 		if err != nil {
 			return err
 		}
 
+		if isFlexible {
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+
 		r.Members[memberId] = memberMetadata
 	}
 
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -123,12 +205,27 @@ func (r *JoinGroupResponse) version() int16 {
 	return r.Version
 }
 
+func (r *JoinGroupResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *JoinGroupResponse) headerVersion() int16 {
+	if r.Version >= 6 {
+		return 1
+	}
 	return 0
 }
 
 func (r *JoinGroupResponse) requiredVersion() KafkaVersion {
 	switch r.Version {
+	case 6:
+		return V2_4_0_0
+	case 5:
+		return V2_3_0_0
+	case 4:
+		return V2_2_0_0
+	case 3:
+		return V2_0_0_0
 	case 2:
 		return V0_11_0_0
 	case 1:
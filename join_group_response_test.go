@@ -171,3 +171,33 @@ func TestJoinGroupResponseV2(t *testing.T) {
 		t.Error("Decoding Members failed, found:", response.Members)
 	}
 }
+
+var joinGroupResponseV6 = []byte{
+	0, 0, 0, 100, // Throttle time
+	0x00, 0x00, // No error
+	0x00, 0x01, 0x02, 0x03, // Generation ID
+	9, 'p', 'r', 'o', 't', 'o', 'c', 'o', 'l', // Protocol name chosen
+	4, 'f', 'o', 'o', // Leader ID
+	4, 'f', 'o', 'o', // Member ID
+	2,                // One member info
+	4, 'f', 'o', 'o', // Member ID
+	9, 'i', 'n', 's', 't', 'a', 'n', 'c', 'e', // Group Instance ID
+	4, 0x01, 0x02, 0x03, // Member metadata
+	0, // empty tagged fields
+	0, // empty tagged fields
+}
+
+func TestJoinGroupResponseV6(t *testing.T) {
+	instance := "instance"
+	response := &JoinGroupResponse{
+		Version:          6,
+		ThrottleTime:     100,
+		GenerationId:     66051,
+		GroupProtocol:    "protocol",
+		LeaderId:         "foo",
+		MemberId:         "foo",
+		Members:          map[string][]byte{"foo": {0x01, 0x02, 0x03}},
+		GroupInstanceIds: map[string]*string{"foo": &instance},
+	}
+	testResponse(t, "v6", response, joinGroupResponseV6)
+}
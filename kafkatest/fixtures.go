@@ -0,0 +1,187 @@
+package kafkatest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+var topicSeq int64
+
+// SkipUnlessVersion skips the test if the brokers are older than the
+// required version.
+func (env *Environment) SkipUnlessVersion(t testing.TB, required sarama.KafkaVersion) {
+	t.Helper()
+	if !env.KafkaVersion.IsAtLeast(required) {
+		t.Skipf("Kafka version %s is required for this test; you have %s. Skipping...", required, env.KafkaVersion)
+	}
+}
+
+// NewClient returns a client of the brokers, closed at the end of the test.
+// A nil config defaults to NewConfig.
+func (env *Environment) NewClient(t testing.TB, config *sarama.Config) sarama.Client {
+	t.Helper()
+	if config == nil {
+		config = env.NewConfig()
+	}
+	client, err := sarama.NewClient(env.KafkaBrokerAddrs, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := client.Close(); err != nil && !errors.Is(err, sarama.ErrClosedClient) {
+			t.Error(err)
+		}
+	})
+	return client
+}
+
+// NewTopic creates a topic named after the test, which is deleted at the end
+// of the test, and returns its name once its metadata is available.
+func (env *Environment) NewTopic(t testing.TB, partitions int32, replicationFactor int16) string {
+	t.Helper()
+	name := strings.NewReplacer("/", ".", " ", "_").Replace(t.Name())
+	topic := fmt.Sprintf("%s-%d-%d", name, time.Now().UnixNano(), atomic.AddInt64(&topicSeq, 1))
+	if len(topic) > 249 {
+		topic = topic[len(topic)-249:]
+	}
+
+	if err := env.CreateTopics(map[string]*sarama.TopicDetail{
+		topic: {NumPartitions: partitions, ReplicationFactor: replicationFactor},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := env.DeleteTopics(topic); err != nil {
+			t.Error(err)
+		}
+	})
+	return topic
+}
+
+// CreateTopics deletes the topics if they already exist, then creates them
+// empty, returning once their metadata is available.
+func (env *Environment) CreateTopics(details map[string]*sarama.TopicDetail) error {
+	topics := make([]string, 0, len(details))
+	for topic := range details {
+		topics = append(topics, topic)
+	}
+	if err := env.DeleteTopics(topics...); err != nil {
+		return err
+	}
+
+	return env.withController(func(controller *sarama.Broker) error {
+		res, err := controller.CreateTopics(&sarama.CreateTopicsRequest{
+			Version:      env.createTopicsVersion(),
+			TopicDetails: details,
+			Timeout:      time.Minute,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create test topics: %w", err)
+		}
+		for topic, topicErr := range res.TopicErrors {
+			if !errors.Is(topicErr.Err, sarama.ErrTopicAlreadyExists) && !errors.Is(topicErr.Err, sarama.ErrNoError) {
+				return fmt.Errorf("failed to create test topic %s: %w", topic, topicErr)
+			}
+		}
+
+		// the creates are not guaranteed to be processed synchronously
+		return waitForTopics(controller, topics, "created", func(err sarama.KError) bool {
+			return err == sarama.ErrNoError
+		})
+	})
+}
+
+// DeleteTopics deletes the topics, returning once they are gone.
+func (env *Environment) DeleteTopics(topics ...string) error {
+	return env.withController(func(controller *sarama.Broker) error {
+		res, err := controller.DeleteTopics(&sarama.DeleteTopicsRequest{
+			Version: env.deleteTopicsVersion(),
+			Topics:  topics,
+			Timeout: time.Minute,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete test topics: %w", err)
+		}
+		for topic, topicErr := range res.TopicErrorCodes {
+			if !isTopicNotExistsErrorOrOk(topicErr) {
+				return fmt.Errorf("failed to delete topic %s: %w", topic, topicErr)
+			}
+		}
+
+		// the deletes are not guaranteed to be processed synchronously
+		return waitForTopics(controller, topics, "gone", isTopicNotExistsErrorOrOk)
+	})
+}
+
+func (env *Environment) withController(f func(controller *sarama.Broker) error) error {
+	config := env.NewConfig()
+	config.Metadata.Retry.Max = 5
+	config.Metadata.Retry.Backoff = 10 * time.Second
+	config.ClientID = "sarama-kafkatest"
+
+	client, err := sarama.NewClient(env.KafkaBrokerAddrs, config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	defer client.Close()
+
+	controller, err := client.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to connect to kafka controller: %w", err)
+	}
+	defer controller.Close()
+	return f(controller)
+}
+
+func (env *Environment) createTopicsVersion() int16 {
+	if env.KafkaVersion.IsAtLeast(sarama.V2_0_0_0) {
+		return 2
+	} else if env.KafkaVersion.IsAtLeast(sarama.V0_11_0_0) {
+		return 1
+	}
+	return 0
+}
+
+func (env *Environment) deleteTopicsVersion() int16 {
+	if env.KafkaVersion.IsAtLeast(sarama.V2_1_0_0) {
+		return 3
+	} else if env.KafkaVersion.IsAtLeast(sarama.V2_0_0_0) {
+		return 2
+	} else if env.KafkaVersion.IsAtLeast(sarama.V0_11_0_0) {
+		return 1
+	}
+	return 0
+}
+
+// waitForTopics polls the metadata of the topics until all their errors are
+// accepted
+func waitForTopics(controller *sarama.Broker, topics []string, state string, accept func(sarama.KError) bool) error {
+	for i := 0; i < 60; i++ {
+		time.Sleep(time.Second)
+		md, err := controller.GetMetadata(&sarama.MetadataRequest{Topics: topics})
+		if err != nil {
+			return fmt.Errorf("failed to get metadata for test topics: %w", err)
+		}
+		if len(md.Topics) != len(topics) {
+			continue
+		}
+		ok := true
+		for _, topic := range md.Topics {
+			ok = ok && accept(topic.Err)
+		}
+		if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("timed out waiting for test topics to be %s", state)
+}
+
+func isTopicNotExistsErrorOrOk(err sarama.KError) bool {
+	return errors.Is(err, sarama.ErrUnknownTopicOrPartition) || errors.Is(err, sarama.ErrInvalidTopic) || errors.Is(err, sarama.ErrNoError)
+}
@@ -0,0 +1,285 @@
+/*
+Package kafkatest runs integration tests against a real Kafka cluster, as the
+functional tests of Sarama do. It brings up the docker-compose environment of
+Sarama, or uses one already running behind toxiproxy, and provides topic and
+client fixtures, so that applications using Sarama can reuse it for their own
+integration tests:
+
+	var env *kafkatest.Environment
+
+	func TestMain(m *testing.M) {
+		var err error
+		env, err = kafkatest.NewEnvironment(context.Background(), nil)
+		if err != nil {
+			panic(err)
+		}
+		code := m.Run()
+		_ = env.Close()
+		os.Exit(code)
+	}
+
+	func TestSomething(t *testing.T) {
+		topic := env.NewTopic(t, 4, 3)
+		client := env.NewClient(t, nil)
+		...
+	}
+
+The brokers are reached through toxiproxy, so that the tests can inject
+network faults with the proxies of the environment.
+
+NOTE: this package currently does not fall under the API stability
+guarantee of Sarama as it is still considered experimental.
+*/
+package kafkatest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"time"
+
+	toxiproxy "github.com/Shopify/toxiproxy/v2/client"
+
+	"github.com/Shopify/sarama"
+)
+
+// Options configures the environment brought up by NewEnvironment.
+type Options struct {
+	// ToxiproxyAddr is the address of a toxiproxy already set up with
+	// 29091 and on bound to the kafka brokers, in which case the environment
+	// is used as is (defaults to $TOXIPROXY_ADDR, the docker-compose
+	// environment being started when both are empty).
+	ToxiproxyAddr string
+	// KafkaVersion is the version of the brokers (defaults to
+	// $KAFKA_VERSION, or 3.1.0 for the docker-compose environment).
+	KafkaVersion string
+	// ComposeFile is the docker-compose file of the environment, as the
+	// one at the root of the Sarama repository (defaults to
+	// docker-compose.yml).
+	ComposeFile string
+	// Brokers is the number of kafka brokers, named kafka-1 and on in the
+	// docker-compose file (defaults to 5).
+	Brokers int
+	// StartTimeout is how long to wait for the brokers to come up (defaults
+	// to 90s).
+	StartTimeout time.Duration
+}
+
+// Environment is a Kafka cluster reached through toxiproxy.
+type Environment struct {
+	ToxiproxyClient  *toxiproxy.Client
+	Proxies          map[string]*toxiproxy.Proxy
+	KafkaBrokerAddrs []string
+	KafkaVersion     sarama.KafkaVersion
+
+	composeFile string
+	brokers     int
+	started     bool
+}
+
+// NewEnvironment returns the environment of the options, which are all
+// optional, starting it with docker-compose unless a toxiproxy address is
+// given.
+func NewEnvironment(ctx context.Context, opts *Options) (*Environment, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.ToxiproxyAddr == "" {
+		o.ToxiproxyAddr = os.Getenv("TOXIPROXY_ADDR")
+	}
+	if o.KafkaVersion == "" {
+		o.KafkaVersion = os.Getenv("KAFKA_VERSION")
+	}
+	if o.ComposeFile == "" {
+		o.ComposeFile = "docker-compose.yml"
+	}
+	if o.Brokers <= 0 {
+		o.Brokers = 5
+	}
+	if o.StartTimeout <= 0 {
+		o.StartTimeout = 90 * time.Second
+	}
+
+	env := &Environment{composeFile: o.ComposeFile, brokers: o.Brokers}
+
+	if o.ToxiproxyAddr != "" {
+		toxiproxyURL, err := url.Parse(o.ToxiproxyAddr)
+		if err != nil {
+			return nil, fmt.Errorf("toxiproxy address %q not parseable as url", o.ToxiproxyAddr)
+		}
+		if o.KafkaVersion == "" {
+			return nil, errors.New("the kafka version needs to be provided with the toxiproxy address")
+		}
+		if env.KafkaVersion, err = sarama.ParseKafkaVersion(o.KafkaVersion); err != nil {
+			return nil, err
+		}
+		if err := env.setupToxiProxies(toxiproxyURL.String()); err != nil {
+			return nil, fmt.Errorf("failed to setup toxiproxies: %w", err)
+		}
+		return env, nil
+	}
+
+	if o.KafkaVersion == "" {
+		o.KafkaVersion = "3.1.0"
+	}
+	var err error
+	if env.KafkaVersion, err = sarama.ParseKafkaVersion(o.KafkaVersion); err != nil {
+		return nil, err
+	}
+	if err := env.startDocker(ctx, o.KafkaVersion, o.StartTimeout); err != nil {
+		_ = env.compose(ctx, "down", "--volumes")
+		return nil, err
+	}
+	return env, nil
+}
+
+// Close tears down the docker-compose environment, if started by
+// NewEnvironment.
+func (env *Environment) Close() error {
+	if !env.started {
+		return nil
+	}
+	ctx := context.Background()
+	downErr := env.compose(ctx, "down", "--volumes")
+	rmErr := env.compose(ctx, "rm", "-v", "--force", "--stop")
+	if downErr != nil {
+		return downErr
+	}
+	return rmErr
+}
+
+// NewConfig returns a new configuration for the version of the brokers.
+func (env *Environment) NewConfig() *sarama.Config {
+	config := sarama.NewConfig()
+	config.Version = env.KafkaVersion
+	return config
+}
+
+// ResetProxies removes the toxics of all the proxies and enables them again.
+func (env *Environment) ResetProxies() error {
+	return env.ToxiproxyClient.ResetState()
+}
+
+// Proxy returns the proxy of the broker.
+func (env *Environment) Proxy(brokerID int32) *toxiproxy.Proxy {
+	return env.Proxies[fmt.Sprintf("kafka%d", brokerID)]
+}
+
+// StartBroker starts the docker-compose service of a broker stopped with
+// StopBroker.
+func (env *Environment) StartBroker(ctx context.Context, brokerID int32) error {
+	return env.compose(ctx, "start", fmt.Sprintf("kafka-%d", brokerID))
+}
+
+// StopBroker stops the docker-compose service of a broker.
+func (env *Environment) StopBroker(ctx context.Context, brokerID int32) error {
+	return env.compose(ctx, "stop", fmt.Sprintf("kafka-%d", brokerID))
+}
+
+// setupToxiProxies configures the toxiproxy proxies with routes for the
+// kafka brokers if they don't already exist
+func (env *Environment) setupToxiProxies(endpoint string) error {
+	env.ToxiproxyClient = toxiproxy.NewClient(endpoint)
+	env.Proxies = map[string]*toxiproxy.Proxy{}
+	env.KafkaBrokerAddrs = nil
+	for i := 1; i <= env.brokers; i++ {
+		proxyName := fmt.Sprintf("kafka%d", i)
+		proxy, err := env.ToxiproxyClient.Proxy(proxyName)
+		if err != nil {
+			proxy, err = env.ToxiproxyClient.CreateProxy(
+				proxyName,
+				fmt.Sprintf("0.0.0.0:%d", 29090+i),
+				fmt.Sprintf("kafka-%d:%d", i, 29090+i),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to create toxiproxy: %w", err)
+			}
+		}
+		env.Proxies[proxyName] = proxy
+		env.KafkaBrokerAddrs = append(env.KafkaBrokerAddrs, fmt.Sprintf("127.0.0.1:%d", 29090+i))
+	}
+	return nil
+}
+
+func (env *Environment) startDocker(ctx context.Context, kafkaVersion string, timeout time.Duration) error {
+	sarama.Logger.Println("bringing up docker-based test environment")
+
+	// Always (try to) tear down first.
+	if err := env.compose(ctx, "down", "--volumes"); err != nil {
+		return fmt.Errorf("failed to tear down existing env: %w", err)
+	}
+
+	c := exec.CommandContext(ctx, "docker-compose", "-f", env.composeFile, "up", "-d")
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(), fmt.Sprintf("KAFKA_VERSION=%s", kafkaVersion))
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("failed to run docker-compose to start test environment: %w", err)
+	}
+	env.started = true
+
+	if err := env.setupToxiProxies("http://localhost:8474"); err != nil {
+		return fmt.Errorf("failed to setup toxiproxies: %w", err)
+	}
+
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		sarama.Logger.Println("waiting for kafka brokers to come up")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+		if env.brokersUp() {
+			return nil
+		}
+	}
+	return errors.New("timed out waiting for broker to come up")
+}
+
+// brokersUp returns whether all the brokers can be reached through all the
+// proxies
+func (env *Environment) brokersUp() bool {
+	config := env.NewConfig()
+	config.Net.DialTimeout = time.Second
+	config.Net.ReadTimeout = time.Second
+	config.Net.WriteTimeout = time.Second
+	config.ClientID = "sarama-kafkatest"
+	for _, addr := range env.KafkaBrokerAddrs {
+		client, err := sarama.NewClient([]string{addr}, config)
+		if err != nil {
+			return false
+		}
+		ok := client.RefreshMetadata() == nil && len(client.Brokers()) >= env.brokers
+		if ok {
+			for _, broker := range client.Brokers() {
+				if err := broker.Open(client.Config()); err != nil && !errors.Is(err, sarama.ErrAlreadyConnected) {
+					ok = false
+					break
+				}
+				if connected, err := broker.Connected(); err != nil || !connected {
+					ok = false
+					break
+				}
+			}
+		}
+		_ = client.Close()
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (env *Environment) compose(ctx context.Context, args ...string) error {
+	c := exec.CommandContext(ctx, "docker-compose", append([]string{"-f", env.composeFile}, args...)...)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("failed to run docker-compose %s: %w", args[0], err)
+	}
+	return nil
+}
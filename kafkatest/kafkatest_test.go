@@ -0,0 +1,55 @@
+package kafkatest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestNewEnvironmentRequiresVersion(t *testing.T) {
+	if os.Getenv("KAFKA_VERSION") == "" {
+		if _, err := NewEnvironment(context.Background(), &Options{ToxiproxyAddr: "http://localhost:8474"}); err == nil {
+			t.Error("Expected an error without kafka version")
+		}
+	}
+	if _, err := NewEnvironment(context.Background(), &Options{ToxiproxyAddr: "http://localhost:8474", KafkaVersion: "banana"}); err == nil {
+		t.Error("Expected an error with an invalid kafka version")
+	}
+}
+
+func TestSkipUnlessVersion(t *testing.T) {
+	env := &Environment{KafkaVersion: sarama.V2_0_0_0}
+	t.Run("older", func(t *testing.T) {
+		env.SkipUnlessVersion(t, sarama.V2_1_0_0)
+		t.Error("Expected the test to be skipped")
+	})
+	t.Run("newer", func(t *testing.T) {
+		env.SkipUnlessVersion(t, sarama.V1_0_0_0)
+	})
+}
+
+func TestEnvironmentFixtures(t *testing.T) {
+	if os.Getenv("TOXIPROXY_ADDR") == "" {
+		t.Skip("TOXIPROXY_ADDR not set, skipping the test against a running environment")
+	}
+	env, err := NewEnvironment(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+	if err := env.ResetProxies(); err != nil {
+		t.Fatal(err)
+	}
+
+	topic := env.NewTopic(t, 2, 1)
+	client := env.NewClient(t, nil)
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partitions) != 2 {
+		t.Errorf("Expected 2 partitions, got %d", len(partitions))
+	}
+}
@@ -1,14 +1,39 @@
 package sarama
 
 import (
+	"time"
+
 	krb5client "github.com/jcmturner/gokrb5/v8/client"
 	krb5config "github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
 	"github.com/jcmturner/gokrb5/v8/keytab"
 	"github.com/jcmturner/gokrb5/v8/types"
 )
 
 type KerberosGoKrb5Client struct {
 	krb5client.Client
+
+	// expiry is when the TGT expires, from the credentials cache or
+	// estimated from the ticket lifetime of the Kerberos configuration
+	expiry         time.Time
+	ticketLifetime time.Duration
+}
+
+// Login logs in with the KDC, or checks the TGT of the credentials cache is
+// still valid.
+func (c *KerberosGoKrb5Client) Login() error {
+	if err := c.Client.Login(); err != nil {
+		return err
+	}
+	if c.ticketLifetime > 0 {
+		c.expiry = time.Now().Add(c.ticketLifetime)
+	}
+	return nil
+}
+
+func (c *KerberosGoKrb5Client) ticketExpiry() time.Time {
+	return c.expiry
 }
 
 func (c *KerberosGoKrb5Client) Domain() string {
@@ -32,15 +57,36 @@ func NewKerberosClient(config *GSSAPIConfig) (KerberosClient, error) {
 
 func createClient(config *GSSAPIConfig, cfg *krb5config.Config) (KerberosClient, error) {
 	var client *krb5client.Client
-	if config.AuthType == KRB5_KEYTAB_AUTH {
+	switch config.AuthType {
+	case KRB5_KEYTAB_AUTH:
 		kt, err := keytab.Load(config.KeyTabPath)
 		if err != nil {
 			return nil, err
 		}
 		client = krb5client.NewWithKeytab(config.Username, config.Realm, kt, cfg, krb5client.DisablePAFXFAST(config.DisablePAFXFAST))
-	} else {
+	case KRB5_CCACHE_AUTH:
+		return createCCacheClient(config, cfg)
+	default:
 		client = krb5client.NewWithPassword(config.Username,
 			config.Realm, config.Password, cfg, krb5client.DisablePAFXFAST(config.DisablePAFXFAST))
 	}
-	return &KerberosGoKrb5Client{*client}, nil
+	return &KerberosGoKrb5Client{Client: *client, ticketLifetime: cfg.LibDefaults.TicketLifetime}, nil
+}
+
+// createCCacheClient loads the TGT from the credentials cache, e.g. kept
+// up to date by kinit, a client created again reloading it.
+func createCCacheClient(config *GSSAPIConfig, cfg *krb5config.Config) (KerberosClient, error) {
+	ccache, err := credentials.LoadCCache(config.CCachePath)
+	if err != nil {
+		return nil, err
+	}
+	client, err := krb5client.NewFromCCache(ccache, cfg, krb5client.DisablePAFXFAST(config.DisablePAFXFAST))
+	if err != nil {
+		return nil, err
+	}
+	tgt, _ := ccache.GetEntry(types.PrincipalName{
+		NameType:   nametype.KRB_NT_SRV_INST,
+		NameString: []string{"krbtgt", ccache.DefaultPrincipal.Realm},
+	})
+	return &KerberosGoKrb5Client{Client: *client, expiry: tgt.EndTime}, nil
 }
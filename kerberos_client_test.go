@@ -3,6 +3,7 @@ package sarama
 import (
 	"errors"
 	"testing"
+	"time"
 
 	krbcfg "github.com/jcmturner/gokrb5/v8/config"
 )
@@ -150,3 +151,103 @@ func TestCreateWithDisablePAFXFAST(t *testing.T) {
 		t.Errorf("Expected error:%s, got:%s.", err, expectedErr)
 	}
 }
+
+func TestCreateWithCCache(t *testing.T) {
+	kerberosConfig, err := krbcfg.NewFromString(krb5cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedErr := errors.New("open nonexist.ccache: no such file or directory")
+	clientConfig := NewTestConfig()
+	clientConfig.Net.SASL.GSSAPI.AuthType = KRB5_CCACHE_AUTH
+	clientConfig.Net.SASL.GSSAPI.CCachePath = "nonexist.ccache"
+	_, err = createClient(&clientConfig.Net.SASL.GSSAPI, kerberosConfig)
+	if err == nil || err.Error() != expectedErr.Error() {
+		t.Errorf("Expected error:%s, got:%s.", expectedErr, err)
+	}
+}
+
+type renewableMockKerberosClient struct {
+	MockKerberosClient
+	expiry    time.Time
+	destroyed bool
+}
+
+func (c *renewableMockKerberosClient) ticketExpiry() time.Time {
+	return c.expiry
+}
+
+func (c *renewableMockKerberosClient) Destroy() {
+	c.destroyed = true
+}
+
+func TestGSSAPIKerberosAuthRenewal(t *testing.T) {
+	var created []*renewableMockKerberosClient
+	var createErr error
+	var renewalErrors []error
+	config := &GSSAPIConfig{
+		RenewalLeadTime: time.Minute,
+		OnRenewalError:  func(err error) { renewalErrors = append(renewalErrors, err) },
+	}
+	auth := &GSSAPIKerberosAuth{
+		Config: config,
+		NewKerberosClientFunc: func(config *GSSAPIConfig) (KerberosClient, error) {
+			if createErr != nil {
+				return nil, createErr
+			}
+			client := &renewableMockKerberosClient{expiry: time.Now().Add(time.Hour)}
+			created = append(created, client)
+			return client, nil
+		},
+	}
+
+	first, err := auth.kerberosClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second, err := auth.kerberosClient(); err != nil || second != first {
+		t.Errorf("expected the client to be kept, got %v %v", second, err)
+	}
+
+	// within the lead time the client is renewed
+	created[0].expiry = time.Now().Add(30 * time.Second)
+	renewed, err := auth.kerberosClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed == first || len(created) != 2 || !created[0].destroyed {
+		t.Error("expected the client to be renewed and the previous one destroyed")
+	}
+
+	// a failed renewal keeps the current client until it expires
+	createErr = errors.New("KDC unreachable")
+	created[1].expiry = time.Now().Add(30 * time.Second)
+	if current, err := auth.kerberosClient(); err != nil || current != renewed {
+		t.Errorf("expected the current client, got %v %v", current, err)
+	}
+	created[1].expiry = time.Now().Add(-time.Second)
+	if _, err := auth.kerberosClient(); err != createErr {
+		t.Errorf("expected %v, got %v", createErr, err)
+	}
+	if len(renewalErrors) != 2 {
+		t.Errorf("expected 2 renewal errors, got %d", len(renewalErrors))
+	}
+	if auth.client != nil || !created[1].destroyed {
+		t.Error("expected the expired client to be destroyed")
+	}
+}
+
+func TestGSSAPIKerberosAuthWithoutRenewal(t *testing.T) {
+	auth := &GSSAPIKerberosAuth{
+		Config: &GSSAPIConfig{},
+		NewKerberosClientFunc: func(config *GSSAPIConfig) (KerberosClient, error) {
+			return &renewableMockKerberosClient{expiry: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	if _, err := auth.kerberosClient(); err != nil {
+		t.Fatal(err)
+	}
+	if auth.client != nil {
+		t.Error("expected the client not to be kept without a RenewalLeadTime")
+	}
+}
@@ -1,29 +1,129 @@
 package sarama
 
+// MemberIdentity identifies a member leaving a group, by member ID or, for
+// static members, by group instance ID
+type MemberIdentity struct {
+	MemberId        string
+	GroupInstanceId *string
+}
+
 type LeaveGroupRequest struct {
+	Version  int16
 	GroupId  string
-	MemberId string
+	MemberId string           // Removed in Version 3
+	Members  []MemberIdentity // Added in Version 3
 }
 
 func (r *LeaveGroupRequest) encode(pe packetEncoder) error {
-	if err := pe.putString(r.GroupId); err != nil {
+	isFlexible := r.Version >= 4
+
+	var err error
+	if isFlexible {
+		err = pe.putCompactString(r.GroupId)
+	} else {
+		err = pe.putString(r.GroupId)
+	}
+	if err != nil {
 		return err
 	}
-	if err := pe.putString(r.MemberId); err != nil {
+
+	if r.Version < 3 {
+		if err := pe.putString(r.MemberId); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if isFlexible {
+		pe.putCompactArrayLength(len(r.Members))
+	} else if err := pe.putArrayLength(len(r.Members)); err != nil {
 		return err
 	}
+	for _, member := range r.Members {
+		if isFlexible {
+			if err := pe.putCompactString(member.MemberId); err != nil {
+				return err
+			}
+			if err := pe.putNullableCompactString(member.GroupInstanceId); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		} else {
+			if err := pe.putString(member.MemberId); err != nil {
+				return err
+			}
+			if err := pe.putNullableString(member.GroupInstanceId); err != nil {
+				return err
+			}
+		}
+	}
+
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
 
 	return nil
 }
 
 func (r *LeaveGroupRequest) decode(pd packetDecoder, version int16) (err error) {
-	if r.GroupId, err = pd.getString(); err != nil {
-		return
+	r.Version = version
+	isFlexible := r.Version >= 4
+
+	if isFlexible {
+		r.GroupId, err = pd.getCompactString()
+	} else {
+		r.GroupId, err = pd.getString()
 	}
-	if r.MemberId, err = pd.getString(); err != nil {
+	if err != nil {
 		return
 	}
 
+	if r.Version < 3 {
+		if r.MemberId, err = pd.getString(); err != nil {
+			return
+		}
+		return nil
+	}
+
+	var memberCount int
+	if isFlexible {
+		memberCount, err = pd.getCompactArrayLength()
+	} else {
+		memberCount, err = pd.getArrayLength()
+	}
+	if err != nil {
+		return err
+	}
+
+	r.Members = make([]MemberIdentity, memberCount)
+	for i := range r.Members {
+		member := &r.Members[i]
+		if isFlexible {
+			if member.MemberId, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if member.GroupInstanceId, err = pd.getCompactNullableString(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		} else {
+			if member.MemberId, err = pd.getString(); err != nil {
+				return err
+			}
+			if member.GroupInstanceId, err = pd.getNullableString(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if isFlexible {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -32,13 +132,39 @@ func (r *LeaveGroupRequest) key() int16 {
 }
 
 func (r *LeaveGroupRequest) version() int16 {
-	return 0
+	return r.Version
+}
+
+func (r *LeaveGroupRequest) versionRange() (minVersion, maxVersion int16) {
+	if r.Version >= 3 {
+		// the request holds members
+		return 3, 4
+	}
+	return 0, 2
+}
+
+func (r *LeaveGroupRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	return &request
 }
 
 func (r *LeaveGroupRequest) headerVersion() int16 {
+	if r.Version >= 4 {
+		return 2
+	}
 	return 1
 }
 
 func (r *LeaveGroupRequest) requiredVersion() KafkaVersion {
-	return V0_9_0_0
+	switch r.Version {
+	case 1:
+		return V0_11_0_0
+	case 2:
+		return V2_0_0_0
+	case 3, 4:
+		return V2_4_0_0
+	default:
+		return V0_9_0_0
+	}
 }
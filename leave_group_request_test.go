@@ -13,3 +13,34 @@ func TestLeaveGroupRequest(t *testing.T) {
 	request.MemberId = "bar"
 	testRequest(t, "basic", request, basicLeaveGroupRequest)
 }
+
+var (
+	leaveGroupRequestV3 = []byte{
+		0, 3, 'f', 'o', 'o',
+		0, 0, 0, 1, // 1 member
+		0, 0, // empty member ID
+		0, 3, 'b', 'a', 'z', // group instance ID
+	}
+
+	leaveGroupRequestV4 = []byte{
+		4, 'f', 'o', 'o',
+		2,                // 1 member
+		1,                // empty member ID
+		4, 'b', 'a', 'z', // group instance ID
+		0, // member tagged fields
+		0, // tagged fields
+	}
+)
+
+func TestLeaveGroupRequestMembers(t *testing.T) {
+	instanceID := "baz"
+	request := &LeaveGroupRequest{
+		Version: 3,
+		GroupId: "foo",
+		Members: []MemberIdentity{{GroupInstanceId: &instanceID}},
+	}
+	testRequest(t, "v3", request, leaveGroupRequestV3)
+
+	request.Version = 4
+	testRequest(t, "v4", request, leaveGroupRequestV4)
+}
@@ -1,21 +1,130 @@
 package sarama
 
+// MemberResponse is the result of a single member leaving a group
+type MemberResponse struct {
+	MemberId        string
+	GroupInstanceId *string
+	Err             KError
+}
+
 type LeaveGroupResponse struct {
-	Err KError
+	Version      int16
+	ThrottleTime int32
+	Err          KError
+	Members      []MemberResponse // Added in Version 3
 }
 
 func (r *LeaveGroupResponse) encode(pe packetEncoder) error {
+	isFlexible := r.Version >= 4
+
+	if r.Version >= 1 {
+		pe.putInt32(r.ThrottleTime)
+	}
 	pe.putInt16(int16(r.Err))
+
+	if r.Version < 3 {
+		return nil
+	}
+
+	if isFlexible {
+		pe.putCompactArrayLength(len(r.Members))
+	} else if err := pe.putArrayLength(len(r.Members)); err != nil {
+		return err
+	}
+	for _, member := range r.Members {
+		if isFlexible {
+			if err := pe.putCompactString(member.MemberId); err != nil {
+				return err
+			}
+			if err := pe.putNullableCompactString(member.GroupInstanceId); err != nil {
+				return err
+			}
+		} else {
+			if err := pe.putString(member.MemberId); err != nil {
+				return err
+			}
+			if err := pe.putNullableString(member.GroupInstanceId); err != nil {
+				return err
+			}
+		}
+		pe.putInt16(int16(member.Err))
+		if isFlexible {
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
 func (r *LeaveGroupResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+	isFlexible := r.Version >= 4
+
+	if r.Version >= 1 {
+		if r.ThrottleTime, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
 	kerr, err := pd.getInt16()
 	if err != nil {
 		return err
 	}
 	r.Err = KError(kerr)
 
+	if r.Version < 3 {
+		return nil
+	}
+
+	var memberCount int
+	if isFlexible {
+		memberCount, err = pd.getCompactArrayLength()
+	} else {
+		memberCount, err = pd.getArrayLength()
+	}
+	if err != nil {
+		return err
+	}
+
+	r.Members = make([]MemberResponse, memberCount)
+	for i := range r.Members {
+		member := &r.Members[i]
+		if isFlexible {
+			if member.MemberId, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if member.GroupInstanceId, err = pd.getCompactNullableString(); err != nil {
+				return err
+			}
+		} else {
+			if member.MemberId, err = pd.getString(); err != nil {
+				return err
+			}
+			if member.GroupInstanceId, err = pd.getNullableString(); err != nil {
+				return err
+			}
+		}
+		memberErr, err := pd.getInt16()
+		if err != nil {
+			return err
+		}
+		member.Err = KError(memberErr)
+		if isFlexible {
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if isFlexible {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -24,13 +133,29 @@ func (r *LeaveGroupResponse) key() int16 {
 }
 
 func (r *LeaveGroupResponse) version() int16 {
-	return 0
+	return r.Version
+}
+
+func (r *LeaveGroupResponse) setVersion(v int16) {
+	r.Version = v
 }
 
 func (r *LeaveGroupResponse) headerVersion() int16 {
+	if r.Version >= 4 {
+		return 1
+	}
 	return 0
 }
 
 func (r *LeaveGroupResponse) requiredVersion() KafkaVersion {
-	return V0_9_0_0
+	switch r.Version {
+	case 1:
+		return V0_11_0_0
+	case 2:
+		return V2_0_0_0
+	case 3, 4:
+		return V2_4_0_0
+	default:
+		return V0_9_0_0
+	}
 }
@@ -25,3 +25,35 @@ func TestLeaveGroupResponse(t *testing.T) {
 		t.Error("Decoding error failed: ErrUnknownMemberId expected but found", response.Err)
 	}
 }
+
+var (
+	leaveGroupResponseV1 = []byte{
+		0, 0, 0, 100, // throttle time
+		0, 0, // no error
+	}
+
+	leaveGroupResponseV4 = []byte{
+		0, 0, 0, 0, // throttle time
+		0, 0, // no error
+		2,                // 1 member
+		1,                // empty member ID
+		4, 'b', 'a', 'z', // group instance ID
+		0, 25, // ErrUnknownMemberId
+		0, // member tagged fields
+		0, // tagged fields
+	}
+)
+
+func TestLeaveGroupResponseVersions(t *testing.T) {
+	response := &LeaveGroupResponse{Version: 1, ThrottleTime: 100}
+	testResponse(t, "v1", response, leaveGroupResponseV1)
+
+	instanceID := "baz"
+	response = &LeaveGroupResponse{
+		Version: 4,
+		Members: []MemberResponse{
+			{GroupInstanceId: &instanceID, Err: ErrUnknownMemberId},
+		},
+	}
+	testResponse(t, "v4", response, leaveGroupResponseV4)
+}
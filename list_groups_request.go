@@ -1,27 +1,122 @@
 package sarama
 
-type ListGroupsRequest struct{}
+type ListGroupsRequest struct {
+	Version      int16
+	StatesFilter []string // version 4 or later
+	TypesFilter  []string // version 5 or later
+}
 
 func (r *ListGroupsRequest) encode(pe packetEncoder) error {
+	if r.Version >= 4 {
+		if err := putCompactStrings(pe, r.StatesFilter); err != nil {
+			return err
+		}
+	}
+	if r.Version >= 5 {
+		if err := putCompactStrings(pe, r.TypesFilter); err != nil {
+			return err
+		}
+	}
+	if r.Version >= 3 {
+		pe.putEmptyTaggedFieldArray()
+	}
 	return nil
 }
 
 func (r *ListGroupsRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+	if r.Version >= 4 {
+		if r.StatesFilter, err = getCompactStrings(pd); err != nil {
+			return err
+		}
+	}
+	if r.Version >= 5 {
+		if r.TypesFilter, err = getCompactStrings(pd); err != nil {
+			return err
+		}
+	}
+	if r.Version >= 3 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func putCompactStrings(pe packetEncoder, in []string) error {
+	pe.putCompactArrayLength(len(in))
+	for _, s := range in {
+		if err := pe.putCompactString(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getCompactStrings(pd packetDecoder) ([]string, error) {
+	n, err := pd.getCompactArrayLength()
+	if err != nil || n <= 0 {
+		return nil, err
+	}
+	out := make([]string, n)
+	for i := range out {
+		if out[i], err = pd.getCompactString(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
 func (r *ListGroupsRequest) key() int16 {
 	return 16
 }
 
 func (r *ListGroupsRequest) version() int16 {
-	return 0
+	return r.Version
+}
+
+func (r *ListGroupsRequest) versionRange() (minVersion, maxVersion int16) {
+	if r.Version >= 4 && len(r.StatesFilter) > 0 {
+		minVersion = 4
+	}
+	if r.Version >= 5 && len(r.TypesFilter) > 0 {
+		minVersion = 5
+	}
+	return minVersion, 5
+}
+
+func (r *ListGroupsRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	if r.Version < 4 {
+		request.StatesFilter = nil
+	}
+	if r.Version < 5 {
+		request.TypesFilter = nil
+	}
+	return &request
 }
 
 func (r *ListGroupsRequest) headerVersion() int16 {
+	if r.Version >= 3 {
+		return 2
+	}
 	return 1
 }
 
 func (r *ListGroupsRequest) requiredVersion() KafkaVersion {
-	return V0_9_0_0
+	switch r.Version {
+	case 1:
+		return V0_11_0_0
+	case 2:
+		return V2_0_0_0
+	case 3:
+		return V2_4_0_0
+	case 4:
+		return V2_6_0_0
+	case 5:
+		return V3_8_0_0
+	default:
+		return V0_9_0_0
+	}
 }
@@ -2,6 +2,36 @@ package sarama
 
 import "testing"
 
+var (
+	listGroupsRequestV4 = []byte{
+		3,                          // 2 states filters
+		6, 'E', 'm', 'p', 't', 'y', // Empty
+		5, 'D', 'e', 'a', 'd', // Dead
+		0, // empty tagged fields
+	}
+
+	listGroupsRequestV5 = []byte{
+		2,                          // 1 states filter
+		6, 'E', 'm', 'p', 't', 'y', // Empty
+		2,                                    // 1 types filter
+		8, 'c', 'l', 'a', 's', 's', 'i', 'c', // classic
+		0, // empty tagged fields
+	}
+)
+
 func TestListGroupsRequest(t *testing.T) {
 	testRequest(t, "ListGroupsRequest", &ListGroupsRequest{}, []byte{})
+
+	testRequest(t, "ListGroupsRequest v3", &ListGroupsRequest{Version: 3}, []byte{0})
+
+	testRequest(t, "ListGroupsRequest v4", &ListGroupsRequest{
+		Version:      4,
+		StatesFilter: []string{"Empty", "Dead"},
+	}, listGroupsRequestV4)
+
+	testRequest(t, "ListGroupsRequest v5", &ListGroupsRequest{
+		Version:      5,
+		StatesFilter: []string{"Empty"},
+		TypesFilter:  []string{"classic"},
+	}, listGroupsRequestV5)
 }
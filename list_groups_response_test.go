@@ -22,6 +22,29 @@ var (
 		0, 3, 'f', 'o', 'o', // group name
 		0, 8, 'c', 'o', 'n', 's', 'u', 'm', 'e', 'r', // protocol type
 	}
+
+	listGroupsResponseV4 = []byte{
+		0, 0, 0, 0, // no throttle time
+		0, 0, // no error
+		2,                // 1 group
+		4, 'f', 'o', 'o', // group name
+		9, 'c', 'o', 'n', 's', 'u', 'm', 'e', 'r', // protocol type
+		6, 'E', 'm', 'p', 't', 'y', // group state
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+
+	listGroupsResponseV5 = []byte{
+		0, 0, 0, 0, // no throttle time
+		0, 0, // no error
+		2,                // 1 group
+		4, 'f', 'o', 'o', // group name
+		9, 'c', 'o', 'n', 's', 'u', 'm', 'e', 'r', // protocol type
+		7, 'S', 't', 'a', 'b', 'l', 'e', // group state
+		8, 'c', 'l', 'a', 's', 's', 'i', 'c', // group type
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
 )
 
 func TestListGroupsResponse(t *testing.T) {
@@ -57,3 +80,25 @@ func TestListGroupsResponse(t *testing.T) {
 		t.Error("Expected foo group to use consumer protocol")
 	}
 }
+
+func TestListGroupsResponseV4(t *testing.T) {
+	response := &ListGroupsResponse{
+		Version: 4,
+		Groups:  map[string]string{"foo": "consumer"},
+		GroupsData: map[string]GroupData{
+			"foo": {GroupState: "Empty"},
+		},
+	}
+	testResponse(t, "v4", response, listGroupsResponseV4)
+}
+
+func TestListGroupsResponseV5(t *testing.T) {
+	response := &ListGroupsResponse{
+		Version: 5,
+		Groups:  map[string]string{"foo": "consumer"},
+		GroupsData: map[string]GroupData{
+			"foo": {GroupState: "Stable", GroupType: "classic"},
+		},
+	}
+	testResponse(t, "v5", response, listGroupsResponseV5)
+}
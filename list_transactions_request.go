@@ -0,0 +1,82 @@
+package sarama
+
+// ListTransactionsRequest is a request to list the transactions known to a
+// transaction coordinator (KIP-664)
+type ListTransactionsRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	// StateFilters restricts the listing to transactions in the given states,
+	// all transactions are returned if it is empty
+	StateFilters []string
+
+	// ProducerIDFilters restricts the listing to transactions of the given
+	// producer IDs, all transactions are returned if it is empty
+	ProducerIDFilters []int64
+}
+
+func (r *ListTransactionsRequest) encode(pe packetEncoder) error {
+	pe.putCompactArrayLength(len(r.StateFilters))
+	for _, s := range r.StateFilters {
+		if err := pe.putCompactString(s); err != nil {
+			return err
+		}
+	}
+
+	pe.putCompactArrayLength(len(r.ProducerIDFilters))
+	for _, id := range r.ProducerIDFilters {
+		pe.putInt64(id)
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ListTransactionsRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	numStates, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if numStates > 0 {
+		r.StateFilters = make([]string, numStates)
+		for i := 0; i < numStates; i++ {
+			if r.StateFilters[i], err = pd.getCompactString(); err != nil {
+				return err
+			}
+		}
+	}
+
+	numProducerIDs, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if numProducerIDs > 0 {
+		r.ProducerIDFilters = make([]int64, numProducerIDs)
+		for i := 0; i < numProducerIDs; i++ {
+			if r.ProducerIDFilters[i], err = pd.getInt64(); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *ListTransactionsRequest) key() int16 {
+	return 66
+}
+
+func (r *ListTransactionsRequest) version() int16 {
+	return r.Version
+}
+
+func (r *ListTransactionsRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *ListTransactionsRequest) requiredVersion() KafkaVersion {
+	return V3_0_0_0
+}
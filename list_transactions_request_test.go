@@ -0,0 +1,30 @@
+package sarama
+
+import "testing"
+
+var (
+	listTransactionsRequestEmpty = []byte{
+		1, // empty state filters
+		1, // empty producer ID filters
+		0, // empty tagged fields
+	}
+
+	listTransactionsRequestFilters = []byte{
+		2,                                    // state filters array length 1
+		8, 'O', 'n', 'g', 'o', 'i', 'n', 'g', // state filter
+		2,                      // producer ID filters array length 1
+		0, 0, 0, 0, 0, 0, 0, 7, // producer ID
+		0, // empty tagged fields
+	}
+)
+
+func TestListTransactionsRequest(t *testing.T) {
+	request := &ListTransactionsRequest{
+		Version: 0,
+	}
+	testRequest(t, "no filters", request, listTransactionsRequestEmpty)
+
+	request.StateFilters = []string{"Ongoing"}
+	request.ProducerIDFilters = []int64{7}
+	testRequest(t, "with filters", request, listTransactionsRequestFilters)
+}
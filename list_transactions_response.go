@@ -0,0 +1,125 @@
+package sarama
+
+import "time"
+
+// ListTransactionsResponse is the response to a ListTransactionsRequest
+type ListTransactionsResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime time.Duration
+	ErrorCode    KError
+
+	// UnknownStateFilters holds the state filters of the request which were
+	// unknown to the broker
+	UnknownStateFilters []string
+
+	TransactionStates []*TransactionListing
+}
+
+// TransactionListing is the summary of a single transaction returned by
+// ListTransactions
+type TransactionListing struct {
+	TransactionalID  string
+	ProducerID       int64
+	TransactionState string
+}
+
+func (r *ListTransactionsResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	pe.putInt16(int16(r.ErrorCode))
+
+	pe.putCompactArrayLength(len(r.UnknownStateFilters))
+	for _, s := range r.UnknownStateFilters {
+		if err := pe.putCompactString(s); err != nil {
+			return err
+		}
+	}
+
+	pe.putCompactArrayLength(len(r.TransactionStates))
+	for _, t := range r.TransactionStates {
+		if err := pe.putCompactString(t.TransactionalID); err != nil {
+			return err
+		}
+		pe.putInt64(t.ProducerID)
+		if err := pe.putCompactString(t.TransactionState); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ListTransactionsResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.ErrorCode = KError(kerr)
+
+	numStates, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if numStates > 0 {
+		r.UnknownStateFilters = make([]string, numStates)
+		for i := 0; i < numStates; i++ {
+			if r.UnknownStateFilters[i], err = pd.getCompactString(); err != nil {
+				return err
+			}
+		}
+	}
+
+	numTransactions, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if numTransactions > 0 {
+		r.TransactionStates = make([]*TransactionListing, numTransactions)
+		for i := 0; i < numTransactions; i++ {
+			t := &TransactionListing{}
+			if t.TransactionalID, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if t.ProducerID, err = pd.getInt64(); err != nil {
+				return err
+			}
+			if t.TransactionState, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			r.TransactionStates[i] = t
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *ListTransactionsResponse) key() int16 {
+	return 66
+}
+
+func (r *ListTransactionsResponse) version() int16 {
+	return r.Version
+}
+
+func (r *ListTransactionsResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *ListTransactionsResponse) requiredVersion() KafkaVersion {
+	return V3_0_0_0
+}
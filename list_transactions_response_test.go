@@ -0,0 +1,48 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	listTransactionsResponseEmpty = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		0, 0, // no error code
+		1, // empty unknown state filters
+		1, // empty transaction states
+		0, // empty tagged fields
+	}
+
+	listTransactionsResponseStates = []byte{
+		0, 0, 0, 0, // throttle time
+		0, 0, // no error code
+		2,                // unknown state filters array length 1
+		4, 'F', 'o', 'o', // unknown state filter
+		2,                // transaction states array length 1
+		4, 't', 'x', '1', // transactional ID
+		0, 0, 0, 0, 0, 0, 0, 7, // producer ID
+		8, 'O', 'n', 'g', 'o', 'i', 'n', 'g', // transaction state
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+)
+
+func TestListTransactionsResponse(t *testing.T) {
+	response := &ListTransactionsResponse{
+		Version:      0,
+		ThrottleTime: 100 * time.Millisecond,
+	}
+	testResponse(t, "empty", response, listTransactionsResponseEmpty)
+
+	response = &ListTransactionsResponse{
+		Version:             0,
+		UnknownStateFilters: []string{"Foo"},
+		TransactionStates: []*TransactionListing{{
+			TransactionalID:  "tx1",
+			ProducerID:       7,
+			TransactionState: "Ongoing",
+		}},
+	}
+	testResponse(t, "with states", response, listTransactionsResponseStates)
+}
@@ -0,0 +1,198 @@
+package sarama
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LogLevel is the severity of the lines logged to a LeveledLogger.
+type LogLevel int8
+
+const (
+	// LogLevelDebug is the level of the verbose lines, written to
+	// DebugLogger without StructuredLogger.
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo is the level of the state changes of the clients.
+	LogLevelInfo
+	// LogLevelWarn is the level of the failures the clients recover from,
+	// such as retries.
+	LogLevelWarn
+	// LogLevelError is the level of the failures reported to the
+	// application.
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int8(l))
+}
+
+// LeveledLogger is a logger whose lines have a level and key/value fields,
+// such as the "broker", "topic", "partition" and "group" the clients,
+// producers and consumers attach to the lines about them. The keys are
+// strings, the values are strings, int32s, int64s or errors.
+type LeveledLogger interface {
+	Log(level LogLevel, msg string, keysAndValues ...interface{})
+}
+
+// StructuredLogger is the LeveledLogger Sarama writes its lines to, along
+// with their fields. Without it (the default), the lines are written to
+// Logger and DebugLogger as they always were, without their fields, which are
+// already part of their messages. Set Logger to NewStdLogger to also have the
+// lines which are not leveled yet written to StructuredLogger.
+var StructuredLogger LeveledLogger
+
+// LeveledLoggerFunc is a function usable as a LeveledLogger.
+type LeveledLoggerFunc func(level LogLevel, msg string, keysAndValues ...interface{})
+
+func (f LeveledLoggerFunc) Log(level LogLevel, msg string, keysAndValues ...interface{}) {
+	f(level, msg, keysAndValues...)
+}
+
+// NewFieldsLogger returns a LeveledLogger passing the fields of the lines as
+// a map to log, which suits the loggers taking fields as maps, such as
+// logrus:
+//
+//	levels := map[sarama.LogLevel]logrus.Level{
+//		sarama.LogLevelDebug: logrus.DebugLevel,
+//		sarama.LogLevelInfo:  logrus.InfoLevel,
+//		sarama.LogLevelWarn:  logrus.WarnLevel,
+//		sarama.LogLevelError: logrus.ErrorLevel,
+//	}
+//	sarama.StructuredLogger = sarama.NewFieldsLogger(func(level sarama.LogLevel, msg string, fields map[string]interface{}) {
+//		logrus.WithFields(fields).Log(levels[level], msg)
+//	})
+func NewFieldsLogger(log func(level LogLevel, msg string, fields map[string]interface{})) LeveledLogger {
+	return LeveledLoggerFunc(func(level LogLevel, msg string, keysAndValues ...interface{}) {
+		fields := make(map[string]interface{}, len(keysAndValues)/2)
+		for i := 0; i+1 < len(keysAndValues); i += 2 {
+			fields[fmt.Sprint(keysAndValues[i])] = keysAndValues[i+1]
+		}
+		log(level, msg, fields)
+	})
+}
+
+// SugaredLogger is the subset of the methods of zap.SugaredLogger used by
+// NewZapLogger.
+type SugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// NewZapLogger returns a LeveledLogger writing to a zap.SugaredLogger, such
+// as zap.L().Sugar().
+func NewZapLogger(l SugaredLogger) LeveledLogger {
+	return LeveledLoggerFunc(func(level LogLevel, msg string, keysAndValues ...interface{}) {
+		switch level {
+		case LogLevelDebug:
+			l.Debugw(msg, keysAndValues...)
+		case LogLevelInfo:
+			l.Infow(msg, keysAndValues...)
+		case LogLevelWarn:
+			l.Warnw(msg, keysAndValues...)
+		default:
+			l.Errorw(msg, keysAndValues...)
+		}
+	})
+}
+
+// NewStdLeveledLogger returns a LeveledLogger writing the lines at or above
+// the minimum level to a StdLogger, such as a log.Logger, as the level, the
+// message and the fields in the key=value format.
+func NewStdLeveledLogger(l StdLogger, min LogLevel) LeveledLogger {
+	return LeveledLoggerFunc(func(level LogLevel, msg string, keysAndValues ...interface{}) {
+		if level < min {
+			return
+		}
+		var b strings.Builder
+		b.WriteString(strings.ToUpper(level.String()))
+		b.WriteByte(' ')
+		b.WriteString(msg)
+		for i := 0; i+1 < len(keysAndValues); i += 2 {
+			fmt.Fprintf(&b, " %v=%v", keysAndValues[i], keysAndValues[i+1])
+		}
+		l.Println(b.String())
+	})
+}
+
+// NewStdLogger returns a StdLogger writing to a LeveledLogger at the level,
+// so that Logger and DebugLogger can be redirected to StructuredLogger.
+func NewStdLogger(l LeveledLogger, level LogLevel) StdLogger {
+	return &stdLogger{logger: l, level: level}
+}
+
+type stdLogger struct {
+	logger LeveledLogger
+	level  LogLevel
+}
+
+func (s *stdLogger) Print(v ...interface{}) {
+	s.logger.Log(s.level, strings.TrimSuffix(fmt.Sprint(v...), "\n"))
+}
+
+func (s *stdLogger) Printf(format string, v ...interface{}) {
+	s.logger.Log(s.level, strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+func (s *stdLogger) Println(v ...interface{}) {
+	s.logger.Log(s.level, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// contextLogger writes the lines of a client, producer or consumer along with
+// the fields of its context, such as its broker, topic and partition, to
+// StructuredLogger, or to Logger and DebugLogger without them
+type contextLogger struct {
+	fields []interface{}
+}
+
+// newContextLogger returns the logger of the lines with the fields
+func newContextLogger(keysAndValues ...interface{}) contextLogger {
+	return contextLogger{fields: keysAndValues}
+}
+
+// with returns the logger of the lines with the fields of l and more
+func (l contextLogger) with(keysAndValues ...interface{}) contextLogger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keysAndValues))
+	fields = append(fields, l.fields...)
+	return contextLogger{fields: append(fields, keysAndValues...)}
+}
+
+func (l contextLogger) Debugf(format string, v ...interface{}) {
+	l.logf(LogLevelDebug, format, v...)
+}
+
+func (l contextLogger) Infof(format string, v ...interface{}) {
+	l.logf(LogLevelInfo, format, v...)
+}
+
+func (l contextLogger) Warnf(format string, v ...interface{}) {
+	l.logf(LogLevelWarn, format, v...)
+}
+
+func (l contextLogger) Errorf(format string, v ...interface{}) {
+	l.logf(LogLevelError, format, v...)
+}
+
+func (l contextLogger) logf(level LogLevel, format string, v ...interface{}) {
+	msg := strings.TrimSuffix(fmt.Sprintf(format, v...), "\n")
+	if structured := StructuredLogger; structured != nil {
+		structured.Log(level, msg, l.fields...)
+		return
+	}
+	if level == LogLevelDebug {
+		DebugLogger.Println(msg)
+	} else {
+		Logger.Println(msg)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build go1.21
+// +build go1.21
+
+package sarama
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLevels are the slog levels of the LogLevels
+var slogLevels = map[LogLevel]slog.Level{
+	LogLevelDebug: slog.LevelDebug,
+	LogLevelInfo:  slog.LevelInfo,
+	LogLevelWarn:  slog.LevelWarn,
+	LogLevelError: slog.LevelError,
+}
+
+// NewSlogLogger returns a LeveledLogger writing to a slog.Logger, such as
+// slog.Default(). Requires Go 1.21 or higher.
+func NewSlogLogger(l *slog.Logger) LeveledLogger {
+	return LeveledLoggerFunc(func(level LogLevel, msg string, keysAndValues ...interface{}) {
+		l.Log(context.Background(), slogLevels[level], msg, keysAndValues...)
+	})
+}
@@ -0,0 +1,30 @@
+//go:build go1.21
+// +build go1.21
+
+package sarama
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestNewSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	l := NewSlogLogger(slog.New(handler))
+	l.Log(LogLevelDebug, "ignored")
+	l.Log(LogLevelWarn, "retrying", "topic", "my_topic", "partition", int32(0))
+
+	if buf.String() != "level=WARN msg=retrying topic=my_topic partition=0\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
@@ -1,36 +1,127 @@
 package sarama
 
-import "testing"
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
 
-// testLogger implements the StdLogger interface and records the text in the
-// logs of the given T passed from Test functions.
-// and records the text in the error log.
-//
-// nolint:unused
-type testLogger struct {
-	t *testing.T
+type logLine struct {
+	level  LogLevel
+	msg    string
+	fields []interface{}
 }
 
-// nolint:unused
-func (l *testLogger) Print(v ...interface{}) {
-	if l.t != nil {
-		l.t.Helper()
-		l.t.Log(v...)
+// captureStructuredLogs sets StructuredLogger to record the lines until the
+// returned function restores it
+func captureStructuredLogs() (*[]logLine, func()) {
+	var lines []logLine
+	previous := StructuredLogger
+	StructuredLogger = LeveledLoggerFunc(func(level LogLevel, msg string, keysAndValues ...interface{}) {
+		lines = append(lines, logLine{level: level, msg: msg, fields: keysAndValues})
+	})
+	return &lines, func() { StructuredLogger = previous }
+}
+
+func TestContextLoggerStructured(t *testing.T) {
+	lines, restore := captureStructuredLogs()
+	defer restore()
+
+	log := newContextLogger("broker", int32(1))
+	log.with("topic", "my_topic", "partition", int32(2)).Warnf("producer/broker/%d retrying\n", 1)
+	log.Debugf("connected")
+
+	expected := []logLine{
+		{level: LogLevelWarn, msg: "producer/broker/1 retrying", fields: []interface{}{"broker", int32(1), "topic", "my_topic", "partition", int32(2)}},
+		{level: LogLevelDebug, msg: "connected", fields: []interface{}{"broker", int32(1)}},
+	}
+	if !reflect.DeepEqual(*lines, expected) {
+		t.Errorf("unexpected lines %+v", *lines)
+	}
+}
+
+func TestContextLoggerStd(t *testing.T) {
+	var std, debug bytes.Buffer
+	previousLogger, previousDebugLogger := Logger, DebugLogger
+	Logger, DebugLogger = log.New(&std, "", 0), log.New(&debug, "", 0)
+	defer func() { Logger, DebugLogger = previousLogger, previousDebugLogger }()
+
+	log := newContextLogger("broker", int32(1))
+	log.Infof("producer/broker/%d starting up\n", 1)
+	log.Debugf("connected")
+
+	if std.String() != "producer/broker/1 starting up\n" {
+		t.Errorf("unexpected Logger output %q", std.String())
+	}
+	if debug.String() != "connected\n" {
+		t.Errorf("unexpected DebugLogger output %q", debug.String())
 	}
 }
 
-// nolint:unused
-func (l *testLogger) Printf(format string, v ...interface{}) {
-	if l.t != nil {
-		l.t.Helper()
-		l.t.Logf(format, v...)
+func TestNewFieldsLogger(t *testing.T) {
+	var fields map[string]interface{}
+	l := NewFieldsLogger(func(level LogLevel, msg string, f map[string]interface{}) {
+		fields = f
+	})
+	l.Log(LogLevelInfo, "msg", "group", "my-group", "partition", int32(3))
+	if !reflect.DeepEqual(fields, map[string]interface{}{"group": "my-group", "partition": int32(3)}) {
+		t.Errorf("unexpected fields %v", fields)
 	}
 }
 
-// nolint:unused
-func (l *testLogger) Println(v ...interface{}) {
-	if l.t != nil {
-		l.t.Helper()
-		l.t.Log(v...)
+type testSugaredLogger struct {
+	lines []string
+}
+
+func (l *testSugaredLogger) log(level, msg string, keysAndValues ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(level, " ", msg, keysAndValues))
+}
+
+func (l *testSugaredLogger) Debugw(msg string, kv ...interface{}) { l.log("debug", msg, kv...) }
+func (l *testSugaredLogger) Infow(msg string, kv ...interface{})  { l.log("info", msg, kv...) }
+func (l *testSugaredLogger) Warnw(msg string, kv ...interface{})  { l.log("warn", msg, kv...) }
+func (l *testSugaredLogger) Errorw(msg string, kv ...interface{}) { l.log("error", msg, kv...) }
+
+func TestNewZapLogger(t *testing.T) {
+	sugared := &testSugaredLogger{}
+	l := NewZapLogger(sugared)
+	l.Log(LogLevelDebug, "a", "broker", int32(1))
+	l.Log(LogLevelError, "b")
+
+	expected := []string{"debug a[broker 1]", "error b[]"}
+	if !reflect.DeepEqual(sugared.lines, expected) {
+		t.Errorf("unexpected lines %q", sugared.lines)
+	}
+}
+
+func TestNewStdLeveledLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewStdLeveledLogger(log.New(&buf, "", 0), LogLevelInfo)
+	l.Log(LogLevelDebug, "ignored")
+	l.Log(LogLevelWarn, "retrying", "topic", "my_topic", "partition", int32(0))
+
+	if buf.String() != "WARN retrying topic=my_topic partition=0\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestNewStdLogger(t *testing.T) {
+	lines, restore := captureStructuredLogs()
+	defer restore()
+
+	std := NewStdLogger(StructuredLogger, LogLevelInfo)
+	std.Printf("client/metadata retrying after %dms\n", 250)
+	std.Println("client/coordinator", "retrying")
+
+	if len(*lines) != 2 || (*lines)[0].msg != "client/metadata retrying after 250ms" || (*lines)[1].msg != "client/coordinator retrying" {
+		t.Errorf("unexpected lines %+v", *lines)
+	}
+	for _, line := range *lines {
+		if line.level != LogLevelInfo || strings.HasSuffix(line.msg, "\n") {
+			t.Errorf("unexpected line %+v", line)
+		}
 	}
 }
@@ -33,13 +33,19 @@ const (
 type CompressionCodec int8
 
 func (cc CompressionCodec) String() string {
-	return []string{
-		"none",
-		"gzip",
-		"snappy",
-		"lz4",
-		"zstd",
-	}[int(cc)]
+	if cc >= CompressionNone && cc <= CompressionZSTD {
+		return []string{
+			"none",
+			"gzip",
+			"snappy",
+			"lz4",
+			"zstd",
+		}[int(cc)]
+	}
+	if impl, ok := registeredCompressionCodec(cc); ok {
+		return impl.name
+	}
+	return fmt.Sprintf("unknown(%d)", int8(cc))
 }
 
 // Message is a kafka message type
@@ -85,7 +91,7 @@ func (m *Message) encode(pe packetEncoder) error {
 		payload = m.compressedCache
 		m.compressedCache = nil
 	} else if m.Value != nil {
-		payload, err = compress(m.Codec, m.CompressionLevel, m.Value)
+		payload, err = compress(m.Codec, m.CompressionLevel, 0, m.Value)
 		if err != nil {
 			return err
 		}
@@ -147,7 +153,7 @@ func (m *Message) decode(pd packetDecoder) (err error) {
 	m.compressedSize = len(m.Value)
 
 	if m.Value != nil && m.Codec != CompressionNone {
-		m.Value, err = decompress(m.Codec, m.Value)
+		m.Value, err = decompress(m.Codec, m.Value, pd.maxDecompressedBytes())
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,220 @@
+package sarama
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MetadataChangeType is the kind of a MetadataChange.
+type MetadataChangeType int
+
+const (
+	// MetadataTopicAdded is a topic appearing in the cluster metadata.
+	MetadataTopicAdded MetadataChangeType = iota
+	// MetadataTopicDeleted is a topic disappearing from the cluster metadata.
+	MetadataTopicDeleted
+	// MetadataPartitionAdded is a partition added to a known topic.
+	MetadataPartitionAdded
+	// MetadataLeaderMoved is a partition whose leader changed.
+	MetadataLeaderMoved
+	// MetadataBrokerAdded is a broker joining the cluster.
+	MetadataBrokerAdded
+	// MetadataBrokerRemoved is a broker leaving the cluster.
+	MetadataBrokerRemoved
+	// MetadataClusterFailover is the client failing over to another cluster,
+	// see Config.Metadata.Failover.
+	MetadataClusterFailover
+)
+
+func (t MetadataChangeType) String() string {
+	switch t {
+	case MetadataTopicAdded:
+		return "TopicAdded"
+	case MetadataTopicDeleted:
+		return "TopicDeleted"
+	case MetadataPartitionAdded:
+		return "PartitionAdded"
+	case MetadataLeaderMoved:
+		return "LeaderMoved"
+	case MetadataBrokerAdded:
+		return "BrokerAdded"
+	case MetadataBrokerRemoved:
+		return "BrokerRemoved"
+	case MetadataClusterFailover:
+		return "ClusterFailover"
+	default:
+		return fmt.Sprintf("MetadataChangeType(%d)", int(t))
+	}
+}
+
+// MetadataChange is a change of the cluster topology found between two
+// metadata refreshes, see Client.SubscribeMetadataChanges.
+type MetadataChange struct {
+	Type MetadataChangeType
+	// Topic is set for all but broker changes.
+	Topic string
+	// Partition is set for MetadataPartitionAdded and MetadataLeaderMoved.
+	Partition int32
+	// PreviousLeader and Leader are the IDs of the previous and current leader
+	// of the partition for MetadataLeaderMoved (the current one also for
+	// MetadataPartitionAdded), -1 meaning no leader.
+	PreviousLeader int32
+	Leader         int32
+	// Broker is the ID of the broker for MetadataBrokerAdded and
+	// MetadataBrokerRemoved.
+	Broker int32
+	// Bootstrap is the bootstrap addresses of the cluster failed over to for
+	// MetadataClusterFailover.
+	Bootstrap []string
+}
+
+// metadataSnapshot holds what is needed from the cached metadata to diff it
+// against the next metadata response
+type metadataSnapshot struct {
+	brokers map[int32]none
+	topics  map[string]map[int32]*PartitionMetadata
+}
+
+// snapshotMetadata must be called with the client lock held
+func (client *client) snapshotMetadata() *metadataSnapshot {
+	snapshot := &metadataSnapshot{
+		brokers: make(map[int32]none, len(client.brokers)),
+		topics:  make(map[string]map[int32]*PartitionMetadata, len(client.metadata)),
+	}
+	for id := range client.brokers {
+		snapshot.brokers[id] = none{}
+	}
+	// the partition maps are replaced rather than modified on update, so
+	// they need not be copied
+	for topic, partitions := range client.metadata {
+		snapshot.topics[topic] = partitions
+	}
+	return snapshot
+}
+
+// metadataChanges diffs the updated cached metadata against the snapshot
+// taken before the update of the response, which covers all topics if
+// allKnownMetaData is set. It must be called with the client lock held.
+func (client *client) metadataChanges(snapshot *metadataSnapshot, data *MetadataResponse, allKnownMetaData bool) []*MetadataChange {
+	var changes []*MetadataChange
+
+	brokerIDs := make([]int32, 0, len(client.brokers))
+	for id := range client.brokers {
+		brokerIDs = append(brokerIDs, id)
+	}
+	sort.Slice(brokerIDs, func(i, j int) bool { return brokerIDs[i] < brokerIDs[j] })
+	for _, id := range brokerIDs {
+		if _, ok := snapshot.brokers[id]; !ok {
+			changes = append(changes, &MetadataChange{Type: MetadataBrokerAdded, Broker: id})
+		}
+	}
+	removedIDs := make([]int32, 0)
+	for id := range snapshot.brokers {
+		if _, ok := client.brokers[id]; !ok {
+			removedIDs = append(removedIDs, id)
+		}
+	}
+	sort.Slice(removedIDs, func(i, j int) bool { return removedIDs[i] < removedIDs[j] })
+	for _, id := range removedIDs {
+		changes = append(changes, &MetadataChange{Type: MetadataBrokerRemoved, Broker: id})
+	}
+
+	var deleted []string
+	for _, topic := range data.Topics {
+		previous, known := snapshot.topics[topic.Name]
+		current, ok := client.metadata[topic.Name]
+		if !ok {
+			if known && topic.Err == ErrUnknownTopicOrPartition {
+				deleted = append(deleted, topic.Name)
+			}
+			continue
+		}
+		if !known {
+			changes = append(changes, &MetadataChange{Type: MetadataTopicAdded, Topic: topic.Name})
+			continue
+		}
+
+		partitions := make([]int32, 0, len(current))
+		for id := range current {
+			partitions = append(partitions, id)
+		}
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+		for _, id := range partitions {
+			partition := current[id]
+			before, ok := previous[id]
+			switch {
+			case !ok:
+				changes = append(changes, &MetadataChange{
+					Type:           MetadataPartitionAdded,
+					Topic:          topic.Name,
+					Partition:      id,
+					PreviousLeader: -1,
+					Leader:         partition.Leader,
+				})
+			case before.Leader != partition.Leader:
+				changes = append(changes, &MetadataChange{
+					Type:           MetadataLeaderMoved,
+					Topic:          topic.Name,
+					Partition:      id,
+					PreviousLeader: before.Leader,
+					Leader:         partition.Leader,
+				})
+			}
+		}
+	}
+
+	if allKnownMetaData {
+		returned := make(map[string]none, len(data.Topics))
+		for _, topic := range data.Topics {
+			returned[topic.Name] = none{}
+		}
+		for topic := range snapshot.topics {
+			if _, ok := returned[topic]; !ok {
+				deleted = append(deleted, topic)
+			}
+		}
+	}
+	sort.Strings(deleted)
+	for _, topic := range deleted {
+		changes = append(changes, &MetadataChange{Type: MetadataTopicDeleted, Topic: topic})
+	}
+
+	return changes
+}
+
+// publishMetadataChanges must be called with the client lock held
+func (client *client) publishMetadataChanges(changes []*MetadataChange) {
+	for subscriber := range client.metadataSubscribers {
+		for _, change := range changes {
+			select {
+			case subscriber <- change:
+			default:
+				client.log().with("topic", change.Topic).Warnf("client/metadata dropping %s metadata change as a subscriber is not keeping up", change.Type)
+			}
+		}
+	}
+}
+
+func (client *client) SubscribeMetadataChanges() (<-chan *MetadataChange, func()) {
+	changes := make(chan *MetadataChange, client.conf.ChannelBufferSize)
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	if client.brokers == nil {
+		// already closed
+		close(changes)
+		return changes, func() {}
+	}
+	client.metadataSubscribers[changes] = none{}
+
+	return changes, func() {
+		client.lock.Lock()
+		defer client.lock.Unlock()
+
+		if _, ok := client.metadataSubscribers[changes]; ok {
+			delete(client.metadataSubscribers, changes)
+			close(changes)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package sarama
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func sortMetadataChanges(changes []*MetadataChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		return a.Partition < b.Partition
+	})
+}
+
+func TestClientSubscribeMetadataChanges(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("a", 0, 1).
+			SetLeader("b", 0, 1),
+	})
+
+	conf := NewTestConfig()
+	conf.Metadata.RefreshFrequency = 0
+	c, err := NewClient([]string{seedBroker.Addr()}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, cancel := c.SubscribeMetadataChanges()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker("127.0.0.1:1", 2).
+			SetLeader("a", 0, 2).
+			SetLeader("a", 1, 1).
+			SetLeader("c", 0, 1),
+	})
+	if err := c.RefreshMetadata(); err != nil {
+		t.Fatal(err)
+	}
+
+	var received []*MetadataChange
+	for len(changes) > 0 {
+		received = append(received, <-changes)
+	}
+	sortMetadataChanges(received)
+	expected := []*MetadataChange{
+		{Type: MetadataTopicAdded, Topic: "c"},
+		{Type: MetadataTopicDeleted, Topic: "b"},
+		{Type: MetadataPartitionAdded, Topic: "a", Partition: 1, PreviousLeader: -1, Leader: 1},
+		{Type: MetadataLeaderMoved, Topic: "a", Partition: 0, PreviousLeader: 1, Leader: 2},
+		{Type: MetadataBrokerAdded, Broker: 2},
+	}
+	if !reflect.DeepEqual(received, expected) {
+		for _, change := range received {
+			t.Logf("received %+v", *change)
+		}
+		t.Fatal("Unexpected metadata changes")
+	}
+
+	cancel()
+	if _, ok := <-changes; ok {
+		t.Error("Expected the channel to be closed once the subscription is cancelled")
+	}
+	cancel()
+
+	changes, _ = c.SubscribeMetadataChanges()
+	safeClose(t, c)
+	if _, ok := <-changes; ok {
+		t.Error("Expected the channel to be closed with the client")
+	}
+}
+
+func TestMetadataChangesTopicDeleted(t *testing.T) {
+	client := &client{
+		brokers: map[int32]*Broker{},
+		metadata: map[string]map[int32]*PartitionMetadata{
+			"a": {0: {ID: 0, Leader: 1}},
+		},
+	}
+	snapshot := client.snapshotMetadata()
+	delete(client.metadata, "a")
+
+	data := &MetadataResponse{Topics: []*TopicMetadata{{Name: "a", Err: ErrUnknownTopicOrPartition}}}
+	changes := client.metadataChanges(snapshot, data, false)
+	if len(changes) != 1 || changes[0].Type != MetadataTopicDeleted || changes[0].Topic != "a" {
+		t.Errorf("Expected topic a to be deleted, got %v", changes)
+	}
+}
@@ -1,58 +1,151 @@
 package sarama
 
 type MetadataRequest struct {
-	Version                int16
-	Topics                 []string
+	// Version defines the protocol version to use for encode and decode
+	Version int16
+	// Topics contains the topics to fetch metadata for, or all topics if
+	// neither Topics nor TopicIDs are set
+	Topics []string
+	// TopicIDs contains the IDs of topics to fetch metadata for (v12 or later)
+	TopicIDs []Uuid
+	// AllowAutoTopicCreation contains whether the broker may auto-create topics (v4 or later)
 	AllowAutoTopicCreation bool
+	// IncludeClusterAuthorizedOperations contains whether to include cluster authorized operations (v8 to v10)
+	IncludeClusterAuthorizedOperations bool
+	// IncludeTopicAuthorizedOperations contains whether to include topic authorized operations (v8 or later)
+	IncludeTopicAuthorizedOperations bool
 }
 
-func (r *MetadataRequest) encode(pe packetEncoder) error {
-	if r.Version < 0 || r.Version > 5 {
+func (r *MetadataRequest) encode(pe packetEncoder) (err error) {
+	if r.Version < 0 || r.Version > 12 {
 		return PacketEncodingError{"invalid or unsupported MetadataRequest version field"}
 	}
-	if r.Version == 0 || len(r.Topics) > 0 {
-		err := pe.putArrayLength(len(r.Topics))
-		if err != nil {
+	if r.Version < 12 && len(r.TopicIDs) > 0 {
+		return PacketEncodingError{"MetadataRequest TopicIDs require version 12 or later"}
+	}
+
+	isFlexible := r.Version >= 9
+	numTopics := len(r.Topics) + len(r.TopicIDs)
+
+	if r.Version == 0 || numTopics > 0 {
+		if isFlexible {
+			pe.putCompactArrayLength(numTopics)
+		} else if err = pe.putArrayLength(numTopics); err != nil {
 			return err
 		}
 
 		for i := range r.Topics {
-			err = pe.putString(r.Topics[i])
+			if r.Version >= 10 {
+				if err = putUuid(pe, NullUuid); err != nil {
+					return err
+				}
+			}
+			if isFlexible {
+				err = pe.putCompactString(r.Topics[i])
+			} else {
+				err = pe.putString(r.Topics[i])
+			}
 			if err != nil {
 				return err
 			}
+			if isFlexible {
+				pe.putEmptyTaggedFieldArray()
+			}
 		}
+		for _, id := range r.TopicIDs {
+			if err = putUuid(pe, id); err != nil {
+				return err
+			}
+			if err = pe.putNullableCompactString(nil); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		}
+	} else if isFlexible {
+		pe.putCompactArrayLength(-1)
 	} else {
 		pe.putInt32(-1)
 	}
+
 	if r.Version > 3 {
 		pe.putBool(r.AllowAutoTopicCreation)
 	}
+	if r.Version >= 8 {
+		if r.Version <= 10 {
+			pe.putBool(r.IncludeClusterAuthorizedOperations)
+		}
+		pe.putBool(r.IncludeTopicAuthorizedOperations)
+	}
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
 	return nil
 }
 
 func (r *MetadataRequest) decode(pd packetDecoder, version int16) error {
 	r.Version = version
-	size, err := pd.getInt32()
+	isFlexible := version >= 9
+
+	var size int
+	var err error
+	if isFlexible {
+		size, err = pd.getCompactArrayLength()
+	} else {
+		size, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
-	if size > 0 {
-		r.Topics = make([]string, size)
-		for i := range r.Topics {
-			topic, err := pd.getString()
-			if err != nil {
+
+	for i := 0; i < size; i++ {
+		id := NullUuid
+		if version >= 10 {
+			if id, err = getUuid(pd); err != nil {
+				return err
+			}
+		}
+		var topic *string
+		if isFlexible {
+			topic, err = pd.getCompactNullableString()
+		} else {
+			var name string
+			name, err = pd.getString()
+			topic = &name
+		}
+		if err != nil {
+			return err
+		}
+		if isFlexible {
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
 				return err
 			}
-			r.Topics[i] = topic
+		}
+		if topic != nil {
+			r.Topics = append(r.Topics, *topic)
+		} else {
+			r.TopicIDs = append(r.TopicIDs, id)
 		}
 	}
+
 	if r.Version > 3 {
-		autoCreation, err := pd.getBool()
-		if err != nil {
+		if r.AllowAutoTopicCreation, err = pd.getBool(); err != nil {
+			return err
+		}
+	}
+	if r.Version >= 8 {
+		if r.Version <= 10 {
+			if r.IncludeClusterAuthorizedOperations, err = pd.getBool(); err != nil {
+				return err
+			}
+		}
+		if r.IncludeTopicAuthorizedOperations, err = pd.getBool(); err != nil {
+			return err
+		}
+	}
+	if isFlexible {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
 			return err
 		}
-		r.AllowAutoTopicCreation = autoCreation
 	}
 	return nil
 }
@@ -65,7 +158,41 @@ func (r *MetadataRequest) version() int16 {
 	return r.Version
 }
 
+func (r *MetadataRequest) versionRange() (minVersion, maxVersion int16) {
+	if len(r.TopicIDs) > 0 {
+		return 12, 12
+	}
+	maxVersion = 12
+	if r.Version >= 4 && !r.AllowAutoTopicCreation {
+		minVersion = 4
+	}
+	if r.Version >= 8 && r.IncludeTopicAuthorizedOperations {
+		minVersion = 8
+	}
+	if r.Version >= 8 && r.Version <= 10 && r.IncludeClusterAuthorizedOperations {
+		minVersion, maxVersion = 8, 10
+	}
+	return minVersion, maxVersion
+}
+
+func (r *MetadataRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	if r.Version < 4 {
+		// the brokers decide whether to create the topics
+		request.AllowAutoTopicCreation = true
+	}
+	if r.Version < 8 {
+		request.IncludeClusterAuthorizedOperations = false
+		request.IncludeTopicAuthorizedOperations = false
+	}
+	return &request
+}
+
 func (r *MetadataRequest) headerVersion() int16 {
+	if r.Version >= 9 {
+		return 2
+	}
 	return 1
 }
 
@@ -79,6 +206,18 @@ func (r *MetadataRequest) requiredVersion() KafkaVersion {
 		return V0_11_0_0
 	case 5:
 		return V1_0_0_0
+	case 6:
+		return V2_0_0_0
+	case 7:
+		return V2_1_0_0
+	case 8:
+		return V2_3_0_0
+	case 9:
+		return V2_4_0_0
+	case 10, 11:
+		return V2_8_0_0
+	case 12:
+		return V3_1_0_0
 	default:
 		return MinVersion
 	}
@@ -136,3 +136,87 @@ func TestMetadataRequestV5(t *testing.T) {
 	request.AllowAutoTopicCreation = false
 	testRequest(t, "one topic", request, metadataRequestNoAutoCreateV5)
 }
+
+var (
+	// The v9 metadata request is flexible: compact arrays and strings as
+	// well as tagged fields. v8 added the authorized operations flags
+
+	metadataRequestNoTopicsV9 = []byte{
+		0x00, // null topics
+		0x01, // allow auto topic creation
+		0x00, // include cluster authorized operations
+		0x01, // include topic authorized operations
+		0x00, // tagged fields
+	}
+
+	metadataRequestOneTopicV9 = []byte{
+		0x02, // one topic
+		0x07, 't', 'o', 'p', 'i', 'c', '1',
+		0x00, // topic tagged fields
+		0x00, // allow auto topic creation
+		0x00, // include cluster authorized operations
+		0x00, // include topic authorized operations
+		0x00, // tagged fields
+	}
+
+	// The v10 metadata request adds a topic ID to every topic
+
+	metadataRequestOneTopicV10 = []byte{
+		0x02, // one topic
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x07, 't', 'o', 'p', 'i', 'c', '1',
+		0x00, // topic tagged fields
+		0x00, // allow auto topic creation
+		0x00, // include cluster authorized operations
+		0x00, // include topic authorized operations
+		0x00, // tagged fields
+	}
+
+	// The v12 metadata request drops the cluster authorized operations flag
+	// (from v11) and supports looking up topics by ID with a null name
+
+	metadataRequestOneTopicIDV12 = []byte{
+		0x02, // one topic
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+		0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10,
+		0x00, // null name
+		0x00, // topic tagged fields
+		0x00, // allow auto topic creation
+		0x01, // include topic authorized operations
+		0x00, // tagged fields
+	}
+)
+
+func TestMetadataRequestV9(t *testing.T) {
+	request := new(MetadataRequest)
+	request.Version = 9
+	request.AllowAutoTopicCreation = true
+	request.IncludeTopicAuthorizedOperations = true
+	testRequest(t, "no topics", request, metadataRequestNoTopicsV9)
+
+	request = new(MetadataRequest)
+	request.Version = 9
+	request.Topics = []string{"topic1"}
+	testRequest(t, "one topic", request, metadataRequestOneTopicV9)
+}
+
+func TestMetadataRequestV10(t *testing.T) {
+	request := new(MetadataRequest)
+	request.Version = 10
+	request.Topics = []string{"topic1"}
+	testRequest(t, "one topic", request, metadataRequestOneTopicV10)
+}
+
+func TestMetadataRequestV12(t *testing.T) {
+	request := new(MetadataRequest)
+	request.Version = 12
+	request.TopicIDs = []Uuid{{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}}
+	request.IncludeTopicAuthorizedOperations = true
+	testRequest(t, "one topic ID", request, metadataRequestOneTopicIDV12)
+
+	request.Version = 11
+	if _, err := encode(request, nil); err == nil {
+		t.Error("Expected an error encoding topic IDs before v12")
+	}
+}
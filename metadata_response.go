@@ -4,12 +4,15 @@ type PartitionMetadata struct {
 	Err             KError
 	ID              int32
 	Leader          int32
+	LeaderEpoch     int32 // Only valid for Version >= 7
 	Replicas        []int32
 	Isr             []int32
 	OfflineReplicas []int32
 }
 
 func (pm *PartitionMetadata) decode(pd packetDecoder, version int16) (err error) {
+	isFlexible := version >= 9
+
 	tmp, err := pd.getInt16()
 	if err != nil {
 		return err
@@ -26,70 +29,137 @@ func (pm *PartitionMetadata) decode(pd packetDecoder, version int16) (err error)
 		return err
 	}
 
-	pm.Replicas, err = pd.getInt32Array()
+	pm.LeaderEpoch = -1
+	if version >= 7 {
+		pm.LeaderEpoch, err = pd.getInt32()
+		if err != nil {
+			return err
+		}
+	}
+
+	if isFlexible {
+		pm.Replicas, err = pd.getCompactInt32Array()
+	} else {
+		pm.Replicas, err = pd.getInt32Array()
+	}
 	if err != nil {
 		return err
 	}
 
-	pm.Isr, err = pd.getInt32Array()
+	if isFlexible {
+		pm.Isr, err = pd.getCompactInt32Array()
+	} else {
+		pm.Isr, err = pd.getInt32Array()
+	}
 	if err != nil {
 		return err
 	}
 
 	if version >= 5 {
-		pm.OfflineReplicas, err = pd.getInt32Array()
+		if isFlexible {
+			pm.OfflineReplicas, err = pd.getCompactInt32Array()
+		} else {
+			pm.OfflineReplicas, err = pd.getInt32Array()
+		}
 		if err != nil {
 			return err
 		}
 	}
 
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+func putMetadataInt32Array(pe packetEncoder, in []int32, isFlexible bool) error {
+	if !isFlexible {
+		return pe.putInt32Array(in)
+	}
+	if in == nil {
+		// putCompactInt32Array rejects nil
+		in = []int32{}
+	}
+	return pe.putCompactInt32Array(in)
+}
+
 func (pm *PartitionMetadata) encode(pe packetEncoder, version int16) (err error) {
+	isFlexible := version >= 9
+
 	pe.putInt16(int16(pm.Err))
 	pe.putInt32(pm.ID)
 	pe.putInt32(pm.Leader)
 
-	err = pe.putInt32Array(pm.Replicas)
+	if version >= 7 {
+		pe.putInt32(pm.LeaderEpoch)
+	}
+
+	err = putMetadataInt32Array(pe, pm.Replicas, isFlexible)
 	if err != nil {
 		return err
 	}
 
-	err = pe.putInt32Array(pm.Isr)
+	err = putMetadataInt32Array(pe, pm.Isr, isFlexible)
 	if err != nil {
 		return err
 	}
 
 	if version >= 5 {
-		err = pe.putInt32Array(pm.OfflineReplicas)
+		err = putMetadataInt32Array(pe, pm.OfflineReplicas, isFlexible)
 		if err != nil {
 			return err
 		}
 	}
 
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
 type TopicMetadata struct {
-	Err        KError
-	Name       string
-	IsInternal bool // Only valid for Version >= 1
-	Partitions []*PartitionMetadata
+	Err                       KError
+	Name                      string
+	Uuid                      Uuid // Only valid for Version >= 10
+	IsInternal                bool // Only valid for Version >= 1
+	Partitions                []*PartitionMetadata
+	TopicAuthorizedOperations int32 // Only valid for Version >= 8
 }
 
 func (tm *TopicMetadata) decode(pd packetDecoder, version int16) (err error) {
+	isFlexible := version >= 9
+
 	tmp, err := pd.getInt16()
 	if err != nil {
 		return err
 	}
 	tm.Err = KError(tmp)
 
-	tm.Name, err = pd.getString()
+	if isFlexible {
+		// topics looked up by an unknown ID have no name
+		var name *string
+		name, err = pd.getCompactNullableString()
+		if name != nil {
+			tm.Name = *name
+		}
+	} else {
+		tm.Name, err = pd.getString()
+	}
 	if err != nil {
 		return err
 	}
 
+	if version >= 10 {
+		tm.Uuid, err = getUuid(pd)
+		if err != nil {
+			return err
+		}
+	}
+
 	if version >= 1 {
 		tm.IsInternal, err = pd.getBool()
 		if err != nil {
@@ -97,7 +167,12 @@ func (tm *TopicMetadata) decode(pd packetDecoder, version int16) (err error) {
 		}
 	}
 
-	n, err := pd.getArrayLength()
+	var n int
+	if isFlexible {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
@@ -110,22 +185,52 @@ func (tm *TopicMetadata) decode(pd packetDecoder, version int16) (err error) {
 		}
 	}
 
+	if version >= 8 {
+		tm.TopicAuthorizedOperations, err = pd.getInt32()
+		if err != nil {
+			return err
+		}
+	}
+
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (tm *TopicMetadata) encode(pe packetEncoder, version int16) (err error) {
+	isFlexible := version >= 9
+
 	pe.putInt16(int16(tm.Err))
 
-	err = pe.putString(tm.Name)
+	if isFlexible {
+		err = pe.putCompactString(tm.Name)
+	} else {
+		err = pe.putString(tm.Name)
+	}
 	if err != nil {
 		return err
 	}
 
+	if version >= 10 {
+		err = putUuid(pe, tm.Uuid)
+		if err != nil {
+			return err
+		}
+	}
+
 	if version >= 1 {
 		pe.putBool(tm.IsInternal)
 	}
 
-	err = pe.putArrayLength(len(tm.Partitions))
+	if isFlexible {
+		pe.putCompactArrayLength(len(tm.Partitions))
+	} else {
+		err = pe.putArrayLength(len(tm.Partitions))
+	}
 	if err != nil {
 		return err
 	}
@@ -137,20 +242,30 @@ func (tm *TopicMetadata) encode(pe packetEncoder, version int16) (err error) {
 		}
 	}
 
+	if version >= 8 {
+		pe.putInt32(tm.TopicAuthorizedOperations)
+	}
+
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
 type MetadataResponse struct {
-	Version        int16
-	ThrottleTimeMs int32
-	Brokers        []*Broker
-	ClusterID      *string
-	ControllerID   int32
-	Topics         []*TopicMetadata
+	Version                     int16
+	ThrottleTimeMs              int32
+	Brokers                     []*Broker
+	ClusterID                   *string
+	ControllerID                int32
+	Topics                      []*TopicMetadata
+	ClusterAuthorizedOperations int32 // Only valid for Version 8 to 10
 }
 
 func (r *MetadataResponse) decode(pd packetDecoder, version int16) (err error) {
 	r.Version = version
+	isFlexible := version >= 9
 
 	if version >= 3 {
 		r.ThrottleTimeMs, err = pd.getInt32()
@@ -159,7 +274,12 @@ func (r *MetadataResponse) decode(pd packetDecoder, version int16) (err error) {
 		}
 	}
 
-	n, err := pd.getArrayLength()
+	var n int
+	if isFlexible {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
@@ -174,7 +294,11 @@ func (r *MetadataResponse) decode(pd packetDecoder, version int16) (err error) {
 	}
 
 	if version >= 2 {
-		r.ClusterID, err = pd.getNullableString()
+		if isFlexible {
+			r.ClusterID, err = pd.getCompactNullableString()
+		} else {
+			r.ClusterID, err = pd.getNullableString()
+		}
 		if err != nil {
 			return err
 		}
@@ -189,7 +313,11 @@ func (r *MetadataResponse) decode(pd packetDecoder, version int16) (err error) {
 		r.ControllerID = -1
 	}
 
-	n, err = pd.getArrayLength()
+	if isFlexible {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
@@ -203,15 +331,34 @@ func (r *MetadataResponse) decode(pd packetDecoder, version int16) (err error) {
 		}
 	}
 
+	if version >= 8 && version <= 10 {
+		r.ClusterAuthorizedOperations, err = pd.getInt32()
+		if err != nil {
+			return err
+		}
+	}
+
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (r *MetadataResponse) encode(pe packetEncoder) error {
+func (r *MetadataResponse) encode(pe packetEncoder) (err error) {
+	isFlexible := r.Version >= 9
+
 	if r.Version >= 3 {
 		pe.putInt32(r.ThrottleTimeMs)
 	}
 
-	err := pe.putArrayLength(len(r.Brokers))
+	if isFlexible {
+		pe.putCompactArrayLength(len(r.Brokers))
+	} else {
+		err = pe.putArrayLength(len(r.Brokers))
+	}
 	if err != nil {
 		return err
 	}
@@ -223,7 +370,11 @@ func (r *MetadataResponse) encode(pe packetEncoder) error {
 	}
 
 	if r.Version >= 2 {
-		err := pe.putNullableString(r.ClusterID)
+		if isFlexible {
+			err = pe.putNullableCompactString(r.ClusterID)
+		} else {
+			err = pe.putNullableString(r.ClusterID)
+		}
 		if err != nil {
 			return err
 		}
@@ -233,7 +384,11 @@ func (r *MetadataResponse) encode(pe packetEncoder) error {
 		pe.putInt32(r.ControllerID)
 	}
 
-	err = pe.putArrayLength(len(r.Topics))
+	if isFlexible {
+		pe.putCompactArrayLength(len(r.Topics))
+	} else {
+		err = pe.putArrayLength(len(r.Topics))
+	}
 	if err != nil {
 		return err
 	}
@@ -244,6 +399,14 @@ func (r *MetadataResponse) encode(pe packetEncoder) error {
 		}
 	}
 
+	if r.Version >= 8 && r.Version <= 10 {
+		pe.putInt32(r.ClusterAuthorizedOperations)
+	}
+
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
@@ -255,7 +418,14 @@ func (r *MetadataResponse) version() int16 {
 	return r.Version
 }
 
+func (r *MetadataResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *MetadataResponse) headerVersion() int16 {
+	if r.Version >= 9 {
+		return 1
+	}
 	return 0
 }
 
@@ -269,6 +439,18 @@ func (r *MetadataResponse) requiredVersion() KafkaVersion {
 		return V0_11_0_0
 	case 5:
 		return V1_0_0_0
+	case 6:
+		return V2_0_0_0
+	case 7:
+		return V2_1_0_0
+	case 8:
+		return V2_3_0_0
+	case 9:
+		return V2_4_0_0
+	case 10, 11:
+		return V2_8_0_0
+	case 12:
+		return V3_1_0_0
 	default:
 		return MinVersion
 	}
@@ -297,3 +297,76 @@ func TestMetadataResponseWithOfflineReplicasV5(t *testing.T) {
 		t.Error("Decoding produced", len(response.Topics[0].Partitions[0].OfflineReplicas), "should have been 1!")
 	}
 }
+
+var oneTopicWithTopicIDV10 = []byte{
+	0x00, 0x00, 0x00, 0x00, // throttle time
+	0x02,                   // one broker
+	0x00, 0x00, 0x00, 0x01, // id
+	0x05, 'h', 'o', 's', 't', // host
+	0x00, 0x00, 0x23, 0x84, // port
+	0x00,                          // null rack
+	0x00,                          // broker tagged fields
+	0x06, 'c', 'l', 'u', 's', 't', // cluster id
+	0x00, 0x00, 0x00, 0x01, // controller id
+	0x02,       // one topic
+	0x00, 0x00, // error
+	0x06, 't', 'o', 'p', 'i', 'c', // name
+	0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, // topic id
+	0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10,
+	0x00,       // is internal
+	0x02,       // one partition
+	0x00, 0x00, // error
+	0x00, 0x00, 0x00, 0x00, // id
+	0x00, 0x00, 0x00, 0x01, // leader
+	0x00, 0x00, 0x00, 0x05, // leader epoch
+	0x02, 0x00, 0x00, 0x00, 0x01, // replicas
+	0x02, 0x00, 0x00, 0x00, 0x01, // isr
+	0x01,                   // no offline replicas
+	0x00,                   // partition tagged fields
+	0x00, 0x00, 0x00, 0x08, // topic authorized operations
+	0x00,                   // topic tagged fields
+	0x00, 0x00, 0x00, 0x00, // cluster authorized operations
+	0x00, // tagged fields
+}
+
+func TestMetadataResponseWithTopicIDV10(t *testing.T) {
+	response := MetadataResponse{}
+
+	testVersionDecodable(t, "one topic with topic ID V10", &response, oneTopicWithTopicIDV10, 10)
+	if len(response.Brokers) != 1 || response.Brokers[0].Addr() != "host:9092" {
+		t.Fatal("Decoding produced", response.Brokers, "should have been host:9092")
+	}
+	if len(response.Topics) != 1 {
+		t.Fatal("Decoding produced", len(response.Topics), "topics, should have been 1!")
+	}
+	topic := response.Topics[0]
+	if topic.Name != "topic" {
+		t.Error("Decoding produced", topic.Name, "should have been topic!")
+	}
+	expected := Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	if topic.Uuid != expected {
+		t.Error("Decoding produced", topic.Uuid, "should have been", expected)
+	}
+	if topic.TopicAuthorizedOperations != 8 {
+		t.Error("Decoding produced", topic.TopicAuthorizedOperations, "should have been 8!")
+	}
+	if topic.Partitions[0].LeaderEpoch != 5 {
+		t.Error("Decoding produced", topic.Partitions[0].LeaderEpoch, "should have been 5!")
+	}
+
+	testResponse(t, "one topic with topic ID V10", &response, oneTopicWithTopicIDV10)
+}
+
+func TestUuid(t *testing.T) {
+	u := Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	parsed, err := ParseUuid(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != u {
+		t.Errorf("Expected %v, got %v", u, parsed)
+	}
+	if _, err := ParseUuid("AAAA"); err == nil {
+		t.Error("Expected an error parsing a short Uuid")
+	}
+}
@@ -0,0 +1,123 @@
+package sarama
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// metadataSnapshotVersion is the version of the MetadataResponse encoding
+// the snapshots of MetadataStore, the last one before flexible versions with
+// racks, cluster ID and leader epochs
+const metadataSnapshotVersion = 7
+
+// MetadataStore persists the last cluster metadata known to a Client, so
+// that it can start from it if the brokers cannot be reached when it is
+// created, see Config.Metadata.Store.
+type MetadataStore interface {
+	// Load returns the last stored snapshot, or nil if there is none.
+	Load() ([]byte, error)
+	// Store replaces the stored snapshot. It is called after each successful
+	// metadata refresh and may be called concurrently.
+	Store(snapshot []byte) error
+}
+
+type fileMetadataStore struct {
+	path string
+}
+
+// NewFileMetadataStore returns a MetadataStore keeping the snapshot in the
+// file at the given path, which is replaced atomically.
+func NewFileMetadataStore(path string) MetadataStore {
+	return &fileMetadataStore{path: path}
+}
+
+func (s *fileMetadataStore) Load() ([]byte, error) {
+	snapshot, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return snapshot, err
+}
+
+func (s *fileMetadataStore) Store(snapshot []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(snapshot); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// loadMetadata fills the cache from the snapshot of Metadata.Store, if any,
+// and returns it
+func (client *client) loadMetadata() *MetadataResponse {
+	snapshot, err := client.conf.Metadata.Store.Load()
+	if err != nil {
+		client.log().Warnf("client/metadata failed to load the metadata snapshot: %s", err)
+		return nil
+	}
+	if snapshot == nil {
+		return nil
+	}
+
+	response := new(MetadataResponse)
+	if err := versionedDecode(snapshot, response, metadataSnapshotVersion); err != nil {
+		client.log().Warnf("client/metadata failed to decode the metadata snapshot: %s", err)
+		return nil
+	}
+	if _, err := client.updateMetadata(response, true); err != nil {
+		client.log().Warnf("client/metadata failed to load the metadata snapshot: %s", err)
+	}
+	client.log().Debugf("client/metadata loaded the metadata of %d brokers and %d topics from the snapshot",
+		len(response.Brokers), len(response.Topics))
+	return response
+}
+
+// storeMetadata saves the cached metadata to Metadata.Store
+func (client *client) storeMetadata() {
+	client.lock.RLock()
+	response := &MetadataResponse{
+		Version:      metadataSnapshotVersion,
+		ClusterID:    client.clusterID,
+		ControllerID: client.controllerID,
+		Brokers:      make([]*Broker, 0, len(client.brokers)),
+		Topics:       make([]*TopicMetadata, 0, len(client.metadata)),
+	}
+	for _, broker := range client.brokers {
+		response.Brokers = append(response.Brokers, &Broker{id: broker.id, addr: broker.addr, rack: broker.rack})
+	}
+	for topic, partitions := range client.metadata {
+		metadata := &TopicMetadata{Name: topic, Partitions: make([]*PartitionMetadata, 0, len(partitions))}
+		for _, partition := range partitions {
+			metadata.Partitions = append(metadata.Partitions, partition)
+		}
+		response.Topics = append(response.Topics, metadata)
+	}
+	client.lock.RUnlock()
+
+	// for stable snapshots
+	sort.Slice(response.Brokers, func(i, j int) bool { return response.Brokers[i].id < response.Brokers[j].id })
+	sort.Slice(response.Topics, func(i, j int) bool { return response.Topics[i].Name < response.Topics[j].Name })
+	for _, topic := range response.Topics {
+		partitions := topic.Partitions
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i].ID < partitions[j].ID })
+	}
+
+	snapshot, err := encode(response, nil)
+	if err == nil {
+		err = client.conf.Metadata.Store.Store(snapshot)
+	}
+	if err != nil {
+		client.log().Warnf("client/metadata failed to store the metadata snapshot: %s", err)
+	}
+}
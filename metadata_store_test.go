@@ -0,0 +1,103 @@
+package sarama
+
+import (
+	"bytes"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+type memoryMetadataStore struct {
+	lock     sync.Mutex
+	snapshot []byte
+	stored   int
+}
+
+func (s *memoryMetadataStore) Load() ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.snapshot, nil
+}
+
+func (s *memoryMetadataStore) Store(snapshot []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.snapshot = snapshot
+	s.stored++
+	return nil
+}
+
+func TestFileMetadataStore(t *testing.T) {
+	store := NewFileMetadataStore(filepath.Join(t.TempDir(), "metadata"))
+
+	snapshot, err := store.Load()
+	if err != nil || snapshot != nil {
+		t.Fatalf("expected no snapshot, got %v, %v", snapshot, err)
+	}
+
+	for _, expected := range [][]byte{[]byte("first"), []byte("second")} {
+		if err := store.Store(expected); err != nil {
+			t.Fatal(err)
+		}
+		snapshot, err = store.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(snapshot, expected) {
+			t.Errorf("expected %q, got %q", expected, snapshot)
+		}
+	}
+}
+
+func TestClientMetadataStore(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(leader.Addr(), leader.BrokerID()).
+			SetLeader("foo", 0, leader.BrokerID()).
+			SetLeader("foo", 1, leader.BrokerID()),
+	})
+
+	store := &memoryMetadataStore{}
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	config.Metadata.RefreshFrequency = 0
+	config.Metadata.Store = store
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	safeClose(t, c)
+	if store.stored != 1 {
+		t.Fatalf("expected the metadata to be stored once, got %d", store.stored)
+	}
+
+	// the cluster cannot be reached anymore
+	seedAddr := seedBroker.Addr()
+	seedBroker.Close()
+	leader.Close()
+
+	c, err = NewClient([]string{seedAddr}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, c)
+
+	partitions, err := c.Partitions("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partitions) != 2 {
+		t.Errorf("expected 2 partitions from the snapshot, got %v", partitions)
+	}
+	broker, err := c.Leader("foo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broker.ID() != leader.BrokerID() || broker.Addr() != leader.Addr() {
+		t.Errorf("expected broker #%d at %s from the snapshot, got #%d at %s",
+			leader.BrokerID(), leader.Addr(), broker.ID(), broker.Addr())
+	}
+}
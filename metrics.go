@@ -2,6 +2,8 @@ package sarama
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/rcrowley/go-metrics"
@@ -34,10 +36,168 @@ func getMetricNameForTopic(name string, topic string) string {
 	return fmt.Sprintf(name+"-for-topic-%s", strings.Replace(topic, ".", "_", -1))
 }
 
-func getOrRegisterTopicMeter(name string, topic string, r metrics.Registry) metrics.Meter {
-	return metrics.GetOrRegisterMeter(getMetricNameForTopic(name, topic), r)
+// MetricLabels are the labels of a metric, such as the "broker" ID or the
+// "topic" the metric is about.
+type MetricLabels map[string]string
+
+// MetricsRecorder records the metrics of the brokers, producers and
+// consumers, see Config.MetricsRecorder. The metrics are created on first
+// use and are identified by their name and labels, so that they can be
+// reported to any monitoring stack with an adapter. NewGoMetricsRecorder
+// records them into a go-metrics registry.
+type MetricsRecorder interface {
+	// Counter returns the counter of the name and labels, which can only be
+	// increased.
+	Counter(name string, labels MetricLabels) MetricCounter
+	// Gauge returns the gauge of the name and labels.
+	Gauge(name string, labels MetricLabels) MetricGauge
+	// Histogram returns the histogram of the name and labels.
+	Histogram(name string, labels MetricLabels) MetricHistogram
+	// Unregister removes the metric of the name and labels, such as the
+	// metrics of a broker whose connection is closed.
+	Unregister(name string, labels MetricLabels)
+}
+
+// MetricCounter is a counter of a MetricsRecorder.
+type MetricCounter interface {
+	Add(delta int64)
+}
+
+// MetricGauge is a gauge of a MetricsRecorder.
+type MetricGauge interface {
+	Set(value int64)
+	Add(delta int64)
+}
+
+// MetricHistogram is a histogram of a MetricsRecorder.
+type MetricHistogram interface {
+	Observe(value int64)
+}
+
+// NewGoMetricsRecorder returns the MetricsRecorder recording the metrics
+// into the go-metrics registry, as Sarama always did: the counters whose name
+// ends with "-rate" are meters and the others counters, the gauges are
+// counters too, and the histograms sample the last 5 minutes or so. The
+// labels are appended to the names, as "-for-broker-<id>", "-for-topic-<topic>"
+// and "-<group>" for the "broker", "topic" and "group" labels. A nil registry
+// is the default registry of go-metrics.
+func NewGoMetricsRecorder(registry metrics.Registry) MetricsRecorder {
+	if registry == nil {
+		registry = metrics.DefaultRegistry
+	}
+	return goMetricsRecorder{registry: registry}
+}
+
+type goMetricsRecorder struct {
+	registry metrics.Registry
+}
+
+func (r goMetricsRecorder) Counter(name string, labels MetricLabels) MetricCounter {
+	if strings.HasSuffix(name, "-rate") {
+		return goMetricsMeter{metrics.GetOrRegisterMeter(goMetricsName(name, labels), r.registry)}
+	}
+	return goMetricsCounter{metrics.GetOrRegisterCounter(goMetricsName(name, labels), r.registry)}
+}
+
+func (r goMetricsRecorder) Gauge(name string, labels MetricLabels) MetricGauge {
+	return goMetricsCounter{metrics.GetOrRegisterCounter(goMetricsName(name, labels), r.registry)}
 }
 
-func getOrRegisterTopicHistogram(name string, topic string, r metrics.Registry) metrics.Histogram {
-	return getOrRegisterHistogram(getMetricNameForTopic(name, topic), r)
+func (r goMetricsRecorder) Histogram(name string, labels MetricLabels) MetricHistogram {
+	return goMetricsHistogram{getOrRegisterHistogram(goMetricsName(name, labels), r.registry)}
 }
+
+func (r goMetricsRecorder) Unregister(name string, labels MetricLabels) {
+	r.registry.Unregister(goMetricsName(name, labels))
+}
+
+type goMetricsMeter struct{ metrics.Meter }
+
+func (m goMetricsMeter) Add(delta int64) { m.Mark(delta) }
+
+type goMetricsCounter struct{ metrics.Counter }
+
+func (c goMetricsCounter) Add(delta int64) { c.Inc(delta) }
+
+func (c goMetricsCounter) Set(value int64) {
+	c.Clear()
+	c.Inc(value)
+}
+
+type goMetricsHistogram struct{ metrics.Histogram }
+
+func (h goMetricsHistogram) Observe(value int64) { h.Update(value) }
+
+// goMetricsName returns the go-metrics name of a metric, with its labels
+func goMetricsName(name string, labels MetricLabels) string {
+	if len(labels) == 0 {
+		return name
+	}
+	if topic, ok := labels["topic"]; ok {
+		name = getMetricNameForTopic(name, topic)
+	}
+	if partition, ok := labels["partition"]; ok {
+		name += "-for-partition-" + partition
+	}
+	if api, ok := labels["api"]; ok {
+		name += "-for-api-" + api
+	}
+	if broker, ok := labels["broker"]; ok {
+		name += "-for-broker-" + broker
+	}
+	if group, ok := labels["group"]; ok {
+		name += "-" + group
+	}
+	var others []string
+	for key := range labels {
+		if key != "topic" && key != "partition" && key != "api" && key != "broker" && key != "group" {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	for _, key := range others {
+		name += "-" + key + "-" + labels[key]
+	}
+	return name
+}
+
+// brokerMetricLabels returns the labels of the metrics of a broker
+func brokerMetricLabels(broker *Broker) MetricLabels {
+	return MetricLabels{"broker": strconv.Itoa(int(broker.ID()))}
+}
+
+func topicMetricLabels(topic string) MetricLabels {
+	return MetricLabels{"topic": topic}
+}
+
+// optionalMetricsRecorder returns the recorder, recording into the registry
+// by default, nil when both are
+func optionalMetricsRecorder(recorder MetricsRecorder, registry metrics.Registry) MetricsRecorder {
+	if recorder == nil && registry != nil {
+		return goMetricsRecorder{registry: registry}
+	}
+	return recorder
+}
+
+// metricsRecorder returns Config.MetricsRecorder, recording into
+// Config.MetricRegistry by default, and discarding the metrics when both are
+// nil
+func (c *Config) metricsRecorder() MetricsRecorder {
+	if recorder := optionalMetricsRecorder(c.MetricsRecorder, c.MetricRegistry); recorder != nil {
+		return recorder
+	}
+	return nopMetricsRecorder{}
+}
+
+type nopMetricsRecorder struct{}
+
+func (nopMetricsRecorder) Counter(string, MetricLabels) MetricCounter     { return nopMetric{} }
+func (nopMetricsRecorder) Gauge(string, MetricLabels) MetricGauge         { return nopMetric{} }
+func (nopMetricsRecorder) Histogram(string, MetricLabels) MetricHistogram { return nopMetric{} }
+func (nopMetricsRecorder) Unregister(string, MetricLabels)                {}
+
+type nopMetric struct{}
+
+func (nopMetric) Add(int64)     {}
+func (nopMetric) Set(int64)     {}
+func (nopMetric) Observe(int64) {}
@@ -1,7 +1,12 @@
 package sarama
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/rcrowley/go-metrics"
 )
@@ -37,6 +42,158 @@ func TestGetMetricNameForBroker(t *testing.T) {
 	}
 }
 
+func TestGoMetricsRecorder(t *testing.T) {
+	registry := metrics.NewRegistry()
+	recorder := NewGoMetricsRecorder(registry)
+
+	recorder.Counter("request-rate", MetricLabels{"broker": "1"}).Add(2)
+	recorder.Counter("consumer-group-join-total", MetricLabels{"group": "my-group"}).Add(1)
+	recorder.Gauge("requests-in-flight", nil).Add(3)
+	recorder.Gauge("requests-in-flight", nil).Set(1)
+	recorder.Histogram("batch-size", MetricLabels{"topic": "my.topic"}).Observe(42)
+	recorder.Counter("request-rate", MetricLabels{"broker": "1", "api": "Fetch"}).Add(1)
+
+	validators := newMetricValidators()
+	validators.register(countMeterValidator("request-rate-for-broker-1", 2))
+	validators.register(counterValidator("consumer-group-join-total-my-group", 1))
+	validators.register(counterValidator("requests-in-flight", 1))
+	validators.register(countHistogramValidator("batch-size-for-topic-my_topic", 1))
+	validators.register(countMeterValidator("request-rate-for-api-Fetch-for-broker-1", 1))
+	validators.run(t, registry)
+
+	recorder.Unregister("request-rate", MetricLabels{"broker": "1"})
+	if registry.Get("request-rate-for-broker-1") != nil {
+		t.Error("Expected the broker metric to be unregistered")
+	}
+}
+
+// testMetricsRecorder records the sum of the values of its metrics
+type testMetricsRecorder struct {
+	lock   sync.Mutex
+	values map[string]int64
+}
+
+type testMetric struct {
+	recorder *testMetricsRecorder
+	key      string
+}
+
+func (m testMetric) Add(delta int64) {
+	m.recorder.lock.Lock()
+	defer m.recorder.lock.Unlock()
+	m.recorder.values[m.key] += delta
+}
+
+func (m testMetric) Set(value int64) {
+	m.recorder.lock.Lock()
+	defer m.recorder.lock.Unlock()
+	m.recorder.values[m.key] = value
+}
+
+func (m testMetric) Observe(value int64) { m.Add(value) }
+
+func (r *testMetricsRecorder) metric(name string, labels MetricLabels) testMetric {
+	keys := make([]string, 0, len(labels))
+	for key, value := range labels {
+		keys = append(keys, key+"="+value)
+	}
+	sort.Strings(keys)
+	key := fmt.Sprintf("%s{%s}", name, strings.Join(keys, ","))
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.values == nil {
+		r.values = make(map[string]int64)
+	}
+	if _, ok := r.values[key]; !ok {
+		r.values[key] = 0
+	}
+	return testMetric{recorder: r, key: key}
+}
+
+func (r *testMetricsRecorder) Counter(name string, labels MetricLabels) MetricCounter {
+	return r.metric(name, labels)
+}
+
+func (r *testMetricsRecorder) Gauge(name string, labels MetricLabels) MetricGauge {
+	return r.metric(name, labels)
+}
+
+func (r *testMetricsRecorder) Histogram(name string, labels MetricLabels) MetricHistogram {
+	return r.metric(name, labels)
+}
+
+func (r *testMetricsRecorder) Unregister(name string, labels MetricLabels) {
+	m := r.metric(name, labels)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.values, m.key)
+}
+
+func (r *testMetricsRecorder) value(key string) (int64, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	value, ok := r.values[key]
+	return value, ok
+}
+
+func TestConfigMetricsRecorder(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+		"ProduceRequest": NewMockProduceResponse(t),
+	})
+
+	recorder := new(testMetricsRecorder)
+	config := NewTestConfig()
+	config.MetricsRecorder = recorder
+	config.Producer.Return.Successes = true
+	producer, err := NewSyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, _, err := producer.SendMessage(&ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	safeClose(t, producer)
+
+	for key, expected := range map[string]int64{
+		"record-send-rate{}":               3,
+		"record-send-rate{topic=my_topic}": 3,
+		"requests-in-flight{}":             0,
+	} {
+		if value, ok := recorder.value(key); !ok || value != expected {
+			t.Errorf("Expected %s to be %d, got %d", key, expected, value)
+		}
+	}
+	if value, _ := recorder.value("request-rate{}"); value < 4 {
+		t.Errorf("Expected at least 4 requests, got %d", value)
+	}
+	// the metrics of the broker are unregistered once closed, which the
+	// client does in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, registered := recorder.value("request-rate{broker=1}")
+		if !registered && len(config.MetricRegistry.GetAll()) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := recorder.value("request-rate{broker=1}"); ok {
+		t.Error("Expected the broker metrics to be unregistered")
+	}
+	if len(config.MetricRegistry.GetAll()) != 0 {
+		t.Errorf("Expected no go-metrics, got %v", config.MetricRegistry.GetAll())
+	}
+}
+
 // Common type and functions for metric validation
 type metricValidator struct {
 	name      string
@@ -2,10 +2,17 @@ package sarama
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net"
 	"reflect"
 	"strconv"
@@ -63,8 +70,30 @@ type MockBroker struct {
 	handler       requestHandlerFunc
 	notifier      RequestNotifierFunc
 	history       []RequestResponse
+	faults        map[string]*MockFault
 	lock          sync.Mutex
 	gssApiHandler GSSApiHandlerFunc
+	certPool      *x509.CertPool
+	recorder      io.Writer
+}
+
+// MockFault describes the faults injected by a MockBroker in its replies to
+// a request type, see SetFault.
+type MockFault struct {
+	// Latency is added to the latency of the broker before replying.
+	Latency time.Duration
+	// ThrottleTime overrides the throttle time of the response, for the
+	// versions of the response which have one.
+	ThrottleTime time.Duration
+	// Drop closes the connection after writing the first DropAfter bytes of
+	// the reply, its header included, so 0 closes it without replying.
+	Drop      bool
+	DropAfter int
+	// CorruptBytes are the offsets in the response body, after its header,
+	// of the bytes flipped before writing it.
+	CorruptBytes []int
+	// Count is the number of requests the fault is injected in, 0 for all.
+	Count int
 }
 
 // RequestResponse represents a Request/Response pair processed by MockBroker.
@@ -79,6 +108,40 @@ func (b *MockBroker) SetLatency(latency time.Duration) {
 	b.latency = latency
 }
 
+// SetFault injects the fault in the replies to the request type, named as
+// in SetHandlerByMap, replacing its previous fault. A nil fault removes it.
+func (b *MockBroker) SetFault(requestType string, fault *MockFault) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if fault == nil {
+		delete(b.faults, requestType)
+		return
+	}
+	if b.faults == nil {
+		b.faults = make(map[string]*MockFault)
+	}
+	f := *fault
+	b.faults[requestType] = &f
+}
+
+// fault returns the fault to inject in the reply to the request, nil if none
+func (b *MockBroker) fault(req *request) *MockFault {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	reqTypeName := reflect.TypeOf(req.body).Elem().Name()
+	fault := b.faults[reqTypeName]
+	if fault == nil {
+		return nil
+	}
+	if fault.Count > 0 {
+		fault.Count--
+		if fault.Count == 0 {
+			delete(b.faults, reqTypeName)
+		}
+	}
+	return fault
+}
+
 // SetHandlerByMap defines mapping of Request types to MockResponses. When a
 // request is received by the broker, it looks up the request type in the map
 // and uses the found MockResponse instance to generate an appropriate reply.
@@ -258,8 +321,13 @@ func (b *MockBroker) handleRequests(conn io.ReadWriteCloser, idx int, wg *sync.W
 				break
 			}
 
-			if b.latency > 0 {
-				time.Sleep(b.latency)
+			fault := b.fault(req)
+			latency := b.latency
+			if fault != nil {
+				latency += fault.Latency
+			}
+			if latency > 0 {
+				time.Sleep(latency)
 			}
 
 			b.lock.Lock()
@@ -267,7 +335,12 @@ func (b *MockBroker) handleRequests(conn io.ReadWriteCloser, idx int, wg *sync.W
 			b.history = append(b.history, RequestResponse{req.body, res})
 			b.lock.Unlock()
 
+			if fault != nil && fault.Drop && (res == nil || fault.DropAfter <= 0) {
+				Logger.Printf("*** mockbroker/%d/%d: dropped connection on %T", b.brokerID, idx, req.body)
+				break
+			}
 			if res == nil {
+				b.record(req, nil, nil)
 				Logger.Printf("*** mockbroker/%d/%d: ignored %v", b.brokerID, idx, spew.Sdump(req))
 				continue
 			}
@@ -278,11 +351,22 @@ func (b *MockBroker) handleRequests(conn io.ReadWriteCloser, idx int, wg *sync.W
 				s.Sprintf("%#v", res),
 			)
 
+			if fault != nil && fault.ThrottleTime > 0 {
+				setMockThrottleTime(res, fault.ThrottleTime)
+			}
 			encodedRes, err := encode(res, nil)
 			if err != nil {
 				b.serverError(err)
 				break
 			}
+			b.record(req, res, encodedRes)
+			if fault != nil {
+				for _, i := range fault.CorruptBytes {
+					if i >= 0 && i < len(encodedRes) {
+						encodedRes[i] ^= 0xff
+					}
+				}
+			}
 			if len(encodedRes) == 0 {
 				b.lock.Lock()
 				if b.notifier != nil {
@@ -293,6 +377,16 @@ func (b *MockBroker) handleRequests(conn io.ReadWriteCloser, idx int, wg *sync.W
 			}
 
 			resHeader := b.encodeHeader(res.headerVersion(), req.correlationID, uint32(len(encodedRes)))
+			if fault != nil && fault.Drop {
+				// the connection is closed in the middle of the reply
+				reply := append(resHeader, encodedRes...)
+				if fault.DropAfter < len(reply) {
+					reply = reply[:fault.DropAfter]
+				}
+				_, _ = conn.Write(reply)
+				Logger.Printf("*** mockbroker/%d/%d: dropped connection on %T after %d bytes", b.brokerID, idx, req.body, len(reply))
+				break
+			}
 			if _, err = conn.Write(resHeader); err != nil {
 				b.serverError(err)
 				break
@@ -358,6 +452,27 @@ func (b *MockBroker) defaultRequestHandler(req *request) (res encoderWithHeader)
 	}
 }
 
+// setMockThrottleTime sets the throttle time of the response, whatever the
+// type of its field
+func setMockThrottleTime(res encoderWithHeader, throttleTime time.Duration) {
+	v := reflect.ValueOf(res)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	for _, name := range []string{"ThrottleTime", "ThrottleTimeMs"} {
+		field := v.Elem().FieldByName(name)
+		if !field.IsValid() || !field.CanSet() {
+			continue
+		}
+		switch {
+		case field.Type() == reflect.TypeOf(time.Duration(0)):
+			field.SetInt(int64(throttleTime))
+		case field.Kind() == reflect.Int32:
+			field.SetInt(int64(throttleTime / time.Millisecond))
+		}
+	}
+}
+
 func (b *MockBroker) serverError(err error) {
 	isConnectionClosedError := false
 	opError := &net.OpError{}
@@ -423,6 +538,71 @@ func NewMockBrokerListener(t TestReporter, brokerID int32, listener net.Listener
 	return broker
 }
 
+// NewMockBrokerTLS behaves like NewMockBroker but accepts TLS connections
+// with the config given. If it has no certificate, a self-signed one for
+// localhost is generated, which the clients can verify with the pool
+// returned by CertPool.
+func NewMockBrokerTLS(t TestReporter, brokerID int32, config *tls.Config) *MockBroker {
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	} else {
+		config = config.Clone()
+	}
+
+	var pool *x509.CertPool
+	if len(config.Certificates) == 0 && config.GetCertificate == nil {
+		cert, err := newMockCertificate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+		pool = x509.NewCertPool()
+		pool.AddCert(cert.Leaf)
+	}
+
+	listener, err := tls.Listen("tcp", "localhost:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker := NewMockBrokerListener(t, brokerID, listener)
+	broker.certPool = pool
+	return broker
+}
+
+// CertPool returns the pool verifying the certificate generated by
+// NewMockBrokerTLS, or nil if the broker was given one.
+func (b *MockBroker) CertPool() *x509.CertPool {
+	return b.certPool
+}
+
+// newMockCertificate generates a self-signed certificate for localhost
+func newMockCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &x509.Certificate{SerialNumber: big.NewInt(1)}, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
 func (b *MockBroker) Returns(e encoderWithHeader) {
 	b.expectations <- e
 }
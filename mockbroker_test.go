@@ -0,0 +1,93 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newMockFaultBroker(t *testing.T) (*MockBroker, *Broker) {
+	mb := NewMockBroker(t, 0)
+	mb.SetHandlerByMap(map[string]MockResponse{
+		"EndTxnRequest": NewMockEndTxnResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V0_11_0_0
+	config.Net.ReadTimeout = time.Second
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	return mb, broker
+}
+
+func TestMockBrokerFaultLatencyAndThrottle(t *testing.T) {
+	mb, broker := newMockFaultBroker(t)
+	defer mb.Close()
+	defer safeClose(t, broker)
+
+	mb.SetFault("EndTxnRequest", &MockFault{Latency: 50 * time.Millisecond, ThrottleTime: 200 * time.Millisecond, Count: 1})
+
+	start := time.Now()
+	res, err := broker.EndTxn(&EndTxnRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected a reply after 50ms, got one after %v", elapsed)
+	}
+	if res.ThrottleTime != 200*time.Millisecond {
+		t.Errorf("expected a throttle time of 200ms, got %v", res.ThrottleTime)
+	}
+
+	// the fault was injected once only
+	if res, err = broker.EndTxn(&EndTxnRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if res.ThrottleTime != 0 {
+		t.Errorf("expected no throttle time, got %v", res.ThrottleTime)
+	}
+}
+
+func TestMockBrokerFaultCorruptBytes(t *testing.T) {
+	mb, broker := newMockFaultBroker(t)
+	defer mb.Close()
+	defer safeClose(t, broker)
+
+	// the error code follows the throttle time
+	mb.SetFault("EndTxnRequest", &MockFault{CorruptBytes: []int{4, 5}})
+
+	res, err := broker.EndTxn(&EndTxnRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(res.Err, ErrUnknown) {
+		t.Errorf("expected the corrupted error code to be ErrUnknown, got %v", res.Err)
+	}
+
+	mb.SetFault("EndTxnRequest", nil)
+	if res, err = broker.EndTxn(&EndTxnRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(res.Err, ErrNoError) {
+		t.Errorf("expected no error once the fault is removed, got %v", res.Err)
+	}
+}
+
+func TestMockBrokerFaultDrop(t *testing.T) {
+	for _, dropAfter := range []int{0, 6} {
+		mb, broker := newMockFaultBroker(t)
+
+		mb.SetFault("EndTxnRequest", &MockFault{Drop: true, DropAfter: dropAfter, Count: 1})
+		if _, err := broker.EndTxn(&EndTxnRequest{}); err == nil {
+			t.Errorf("expected an error when dropping the connection after %d bytes", dropAfter)
+		}
+		if len(mb.History()) != 1 {
+			t.Errorf("expected the dropped request in the history, got %d requests", len(mb.History()))
+		}
+
+		safeClose(t, broker)
+		mb.Close()
+	}
+}
@@ -0,0 +1,153 @@
+package sarama
+
+import (
+	"sync"
+	"time"
+)
+
+// MockClock is a fake Clock for Config.Clock, whose time only passes when
+// advanced by the test, firing the timers and tickers due. The sleeps and
+// timers of the clients, producers and consumers using it therefore wait for
+// the test, which can wait for them to be set with BlockUntil.
+type MockClock struct {
+	lock   sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*mockTimer
+}
+
+type mockTimer struct {
+	clock    *MockClock
+	c        chan time.Time
+	deadline time.Time
+	period   time.Duration
+}
+
+// NewMockClock returns a fake clock starting at now.
+func NewMockClock(now time.Time) *MockClock {
+	c := &MockClock{now: now}
+	c.cond = sync.NewCond(&c.lock)
+	return c
+}
+
+func (c *MockClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *MockClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+func (c *MockClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+func (c *MockClock) NewTimer(d time.Duration) Timer {
+	t := &mockTimer{clock: c, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+func (c *MockClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for MockClock.NewTicker")
+	}
+	t := &mockTimer{clock: c, c: make(chan time.Time, 1), period: d}
+	t.Reset(d)
+	return mockTicker{t}
+}
+
+// Advance moves the clock forward by d, firing in order the timers and
+// tickers due by then. Like time.Ticker, a ticker whose previous tick was not
+// received yet drops the next ones.
+func (c *MockClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	target := c.now.Add(d)
+	for {
+		var next *mockTimer
+		for _, t := range c.timers {
+			if !t.deadline.After(target) && (next == nil || t.deadline.Before(next.deadline)) {
+				next = t
+			}
+		}
+		if next == nil {
+			break
+		}
+
+		c.now = next.deadline
+		select {
+		case next.c <- c.now:
+		default:
+		}
+		if next.period > 0 {
+			next.deadline = next.deadline.Add(next.period)
+		} else {
+			c.remove(next)
+		}
+	}
+	c.now = target
+}
+
+// Timers returns the number of timers and tickers pending.
+func (c *MockClock) Timers() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntil waits for at least n timers and tickers to be pending, which
+// lets the test advance the clock once the code under test waits for it.
+func (c *MockClock) BlockUntil(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// remove removes a pending timer, returning whether it was pending
+func (c *MockClock) remove(t *mockTimer) bool {
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type mockTicker struct{ *mockTimer }
+
+func (t mockTicker) Stop() { t.mockTimer.Stop() }
+
+func (t *mockTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *mockTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	return t.clock.remove(t)
+}
+
+func (t *mockTimer) Reset(d time.Duration) bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	active := t.clock.remove(t)
+	t.deadline = t.clock.now.Add(d)
+	if d <= 0 && t.period == 0 {
+		// like time.Timer, fire right away
+		select {
+		case t.c <- t.clock.now:
+		default:
+		}
+		return active
+	}
+	t.clock.timers = append(t.clock.timers, t)
+	t.clock.cond.Broadcast()
+	return active
+}
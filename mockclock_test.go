@@ -0,0 +1,96 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMockClockTimers(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	clock := NewMockClock(start)
+
+	timer := clock.NewTimer(time.Second)
+	ticker := clock.NewTicker(400 * time.Millisecond)
+	stopped := clock.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Error("expected the timer to be pending when stopped")
+	}
+	select {
+	case <-clock.After(0):
+	default:
+		t.Error("expected a timer of 0 to fire right away")
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if tick := <-ticker.C(); !tick.Equal(start.Add(400 * time.Millisecond)) {
+		t.Errorf("unexpected tick at %v", tick)
+	}
+	select {
+	case <-timer.C():
+		t.Error("the timer fired too early")
+	default:
+	}
+
+	// the tick at 800ms is dropped as the one at 1.2s was not received
+	clock.Advance(time.Second)
+	if fired := <-timer.C(); !fired.Equal(start.Add(time.Second)) {
+		t.Errorf("unexpected timer firing at %v", fired)
+	}
+	if tick := <-ticker.C(); !tick.Equal(start.Add(800 * time.Millisecond)) {
+		t.Errorf("unexpected tick at %v", tick)
+	}
+	if now := clock.Now(); !now.Equal(start.Add(1500 * time.Millisecond)) {
+		t.Errorf("unexpected time %v", now)
+	}
+	select {
+	case <-stopped.C():
+		t.Error("the stopped timer fired")
+	default:
+	}
+
+	ticker.Stop()
+	if clock.Timers() != 0 {
+		t.Errorf("expected no pending timer, got %d", clock.Timers())
+	}
+}
+
+func TestAsyncProducerFlushFrequencyWithMockClock(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+		"ProduceRequest": NewMockProduceResponse(t),
+	})
+
+	clock := NewMockClock(time.Now())
+	config := NewTestConfig()
+	config.Clock = clock
+	config.Metadata.RefreshFrequency = 0
+	config.Producer.Flush.Messages = 10
+	config.Producer.Flush.Frequency = time.Hour
+	config.Producer.Return.Successes = true
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, producer)
+
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	clock.BlockUntil(1)
+	select {
+	case msg := <-producer.Successes():
+		t.Fatalf("unexpected success before the flush %v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clock.Advance(time.Hour)
+	select {
+	case <-producer.Successes():
+	case err := <-producer.Errors():
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the message to be flushed once the clock advanced")
+	}
+}
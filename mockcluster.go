@@ -0,0 +1,499 @@
+package sarama
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// mockClusterMaxFetchRecords is the maximum number of records of a partition
+// returned by a fetch from a MockCluster
+const mockClusterMaxFetchRecords = 500
+
+// MockCluster simulates a Kafka cluster of MockBrokers sharing the metadata
+// of its topics, so that failover logic can be tested end-to-end in-process.
+// Every broker answers the Metadata requests with the state of the whole
+// cluster, while only the leader of a partition accepts its Produce, Fetch
+// and ListOffsets requests, the other brokers answering them with
+// ErrNotLeaderForPartition. The leaders can be moved with MoveLeader and
+// MovePartition, and the brokers stopped and restarted with StopBroker and
+// RestartBroker, their partitions failing over to the next live replica.
+//
+// The records produced are kept in memory and can be fetched back. The other
+// requests are answered with the handlers set with SetHandlerByMap.
+type MockCluster struct {
+	t TestReporter
+
+	lock     sync.Mutex
+	brokers  map[int32]*MockBroker
+	addrs    map[int32]string
+	handlers map[string]MockResponse
+	topics   map[string][]*mockClusterPartition
+}
+
+type mockClusterPartition struct {
+	leader      int32
+	leaderEpoch int32
+	replicas    []int32
+	records     []mockClusterRecord
+}
+
+type mockClusterRecord struct {
+	key, value []byte
+	timestamp  time.Time
+}
+
+// NewMockCluster starts a cluster of n brokers, whose IDs are 1 to n.
+func NewMockCluster(t TestReporter, n int) *MockCluster {
+	c := &MockCluster{
+		t:        t,
+		brokers:  make(map[int32]*MockBroker),
+		addrs:    make(map[int32]string),
+		handlers: make(map[string]MockResponse),
+		topics:   make(map[string][]*mockClusterPartition),
+	}
+	for id := int32(1); id <= int32(n); id++ {
+		broker := NewMockBroker(t, id)
+		c.brokers[id] = broker
+		c.addrs[id] = broker.Addr()
+		c.setHandler(broker, nil)
+	}
+	return c
+}
+
+// setHandler sets the handlers of a broker of the cluster, which must not be
+// called with the lock of the cluster held by a running broker as its handler
+// takes it
+func (c *MockCluster) setHandler(broker *MockBroker, handlers map[string]MockResponse) {
+	handlerMap := map[string]MockResponse{
+		"MetadataRequest": &mockClusterHandler{c, broker.BrokerID()},
+		"ProduceRequest":  &mockClusterHandler{c, broker.BrokerID()},
+		"FetchRequest":    &mockClusterHandler{c, broker.BrokerID()},
+		"OffsetRequest":   &mockClusterHandler{c, broker.BrokerID()},
+	}
+	for name, handler := range handlers {
+		handlerMap[name] = handler
+	}
+	broker.SetHandlerByMap(handlerMap)
+}
+
+// SetHandlerByMap sets the MockResponses of the requests the cluster does not
+// answer itself on all its brokers, see MockBroker.SetHandlerByMap.
+func (c *MockCluster) SetHandlerByMap(handlerMap map[string]MockResponse) {
+	handlers := make(map[string]MockResponse, len(handlerMap))
+	for name, handler := range handlerMap {
+		handlers[name] = handler
+	}
+
+	c.lock.Lock()
+	c.handlers = handlers
+	brokers := make([]*MockBroker, 0, len(c.brokers))
+	for _, broker := range c.brokers {
+		brokers = append(brokers, broker)
+	}
+	c.lock.Unlock()
+
+	for _, broker := range brokers {
+		c.setHandler(broker, handlers)
+	}
+}
+
+// Addrs returns the addresses of the brokers, to bootstrap the clients with.
+func (c *MockCluster) Addrs() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	addrs := make([]string, 0, len(c.addrs))
+	for _, id := range c.brokerIDs() {
+		addrs = append(addrs, c.addrs[id])
+	}
+	return addrs
+}
+
+// Broker returns the broker with the ID, or nil if it is stopped.
+func (c *MockCluster) Broker(id int32) *MockBroker {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.brokers[id]
+}
+
+// CreateTopic creates a topic whose replicas are assigned to the brokers in
+// turn, the first replica of each partition being its leader.
+func (c *MockCluster) CreateTopic(topic string, partitions int32, replicationFactor int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ids := c.brokerIDs()
+	if replicationFactor < 1 || replicationFactor > len(ids) {
+		c.t.Errorf("MockCluster cannot create %s with a replication factor of %d", topic, replicationFactor)
+		return
+	}
+	c.topics[topic] = make([]*mockClusterPartition, partitions)
+	for p := range c.topics[topic] {
+		replicas := make([]int32, replicationFactor)
+		for i := range replicas {
+			replicas[i] = ids[(p+i)%len(ids)]
+		}
+		c.topics[topic][p] = &mockClusterPartition{leader: replicas[0], replicas: replicas}
+	}
+}
+
+// Leader returns the ID of the leader of the partition, -1 if it has none.
+func (c *MockCluster) Leader(topic string, partition int32) int32 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if p := c.partition(topic, partition); p != nil {
+		return p.leader
+	}
+	return -1
+}
+
+// MoveLeader elects one of the replicas of the partition as its leader.
+func (c *MockCluster) MoveLeader(topic string, partition int32, leader int32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	p := c.partition(topic, partition)
+	if p == nil || !int32Contains(p.replicas, leader) {
+		c.t.Errorf("MockCluster cannot move the leader of %s/%d to %d", topic, partition, leader)
+		return
+	}
+	p.leader = leader
+	p.leaderEpoch++
+}
+
+// MovePartition migrates the partition to the replicas, the first one
+// becoming its leader, or the next one if it is stopped.
+func (c *MockCluster) MovePartition(topic string, partition int32, replicas []int32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	p := c.partition(topic, partition)
+	if p == nil || len(replicas) == 0 {
+		c.t.Errorf("MockCluster cannot move %s/%d to %v", topic, partition, replicas)
+		return
+	}
+	for _, id := range replicas {
+		if _, ok := c.addrs[id]; !ok {
+			c.t.Errorf("MockCluster cannot move %s/%d to the unknown broker %d", topic, partition, id)
+			return
+		}
+	}
+	p.replicas = append([]int32(nil), replicas...)
+	p.leader = -1
+	c.electLeader(p)
+}
+
+// StopBroker closes the broker, the partitions it leads failing over to
+// their next live replica, if any.
+func (c *MockCluster) StopBroker(id int32) {
+	c.lock.Lock()
+	broker := c.brokers[id]
+	delete(c.brokers, id)
+	for _, partitions := range c.topics {
+		for _, p := range partitions {
+			if p.leader == id {
+				c.electLeader(p)
+			}
+		}
+	}
+	c.lock.Unlock()
+
+	if broker != nil {
+		broker.Close()
+	}
+}
+
+// RestartBroker restarts a stopped broker on its previous address, the
+// partitions without leader electing it if it is one of their replicas.
+func (c *MockCluster) RestartBroker(id int32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	addr, ok := c.addrs[id]
+	if !ok || c.brokers[id] != nil {
+		c.t.Errorf("MockCluster cannot restart the broker %d", id)
+		return
+	}
+	// the new broker does not take the lock of the cluster before its
+	// handlers are set
+	broker := NewMockBrokerAddr(c.t, id, addr)
+	c.setHandler(broker, c.handlers)
+	c.brokers[id] = broker
+	for _, partitions := range c.topics {
+		for _, p := range partitions {
+			if p.leader == -1 {
+				c.electLeader(p)
+			}
+		}
+	}
+}
+
+// HighWaterMark returns the offset of the next record produced to the
+// partition.
+func (c *MockCluster) HighWaterMark(topic string, partition int32) int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if p := c.partition(topic, partition); p != nil {
+		return int64(len(p.records))
+	}
+	return 0
+}
+
+// Values returns the values of the records produced to the partition.
+func (c *MockCluster) Values(topic string, partition int32) [][]byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	p := c.partition(topic, partition)
+	if p == nil {
+		return nil
+	}
+	values := make([][]byte, len(p.records))
+	for i, record := range p.records {
+		values[i] = record.value
+	}
+	return values
+}
+
+// Close stops all the brokers.
+func (c *MockCluster) Close() {
+	c.lock.Lock()
+	brokers := c.brokers
+	c.brokers = make(map[int32]*MockBroker)
+	c.lock.Unlock()
+
+	for _, broker := range brokers {
+		broker.Close()
+	}
+}
+
+func (c *MockCluster) brokerIDs() []int32 {
+	ids := make([]int32, 0, len(c.addrs))
+	for id := range c.addrs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (c *MockCluster) partition(topic string, partition int32) *mockClusterPartition {
+	partitions := c.topics[topic]
+	if partition < 0 || int(partition) >= len(partitions) {
+		return nil
+	}
+	return partitions[partition]
+}
+
+// electLeader elects the first live replica of the partition, starting after
+// its current leader
+func (c *MockCluster) electLeader(p *mockClusterPartition) {
+	start := 0
+	for i, id := range p.replicas {
+		if id == p.leader {
+			start = i + 1
+		}
+	}
+	p.leader = -1
+	for i := range p.replicas {
+		id := p.replicas[(start+i)%len(p.replicas)]
+		if c.brokers[id] != nil {
+			p.leader = id
+			break
+		}
+	}
+	p.leaderEpoch++
+}
+
+// mockClusterHandler answers the requests of a broker of the cluster
+type mockClusterHandler struct {
+	cluster  *MockCluster
+	brokerID int32
+}
+
+func (h *mockClusterHandler) For(reqBody versionedDecoder) encoderWithHeader {
+	c := h.cluster
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch req := reqBody.(type) {
+	case *MetadataRequest:
+		return h.metadata(req)
+	case *ProduceRequest:
+		return h.produce(req)
+	case *FetchRequest:
+		return h.fetch(req)
+	case *OffsetRequest:
+		return h.listOffsets(req)
+	}
+	return nil
+}
+
+func (h *mockClusterHandler) metadata(req *MetadataRequest) *MetadataResponse {
+	c := h.cluster
+	res := &MetadataResponse{Version: req.Version, ControllerID: h.brokerID}
+	for _, id := range c.brokerIDs() {
+		if c.brokers[id] != nil {
+			res.AddBroker(c.addrs[id], id)
+		}
+	}
+	if ids := c.brokerIDs(); len(ids) > 0 && c.brokers[ids[0]] != nil {
+		res.ControllerID = ids[0]
+	}
+
+	topics := req.Topics
+	if len(topics) == 0 {
+		for topic := range c.topics {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+	}
+	for _, topic := range topics {
+		partitions, ok := c.topics[topic]
+		if !ok {
+			res.AddTopic(topic, ErrUnknownTopicOrPartition)
+			continue
+		}
+		for i, p := range partitions {
+			var isr, offline []int32
+			for _, id := range p.replicas {
+				if c.brokers[id] != nil {
+					isr = append(isr, id)
+				} else {
+					offline = append(offline, id)
+				}
+			}
+			kerr := ErrNoError
+			if p.leader == -1 {
+				kerr = ErrLeaderNotAvailable
+			}
+			res.AddTopicPartition(topic, int32(i), p.leader, p.replicas, isr, offline, kerr)
+		}
+		for _, pm := range res.AddTopic(topic, ErrNoError).Partitions {
+			pm.LeaderEpoch = partitions[pm.ID].leaderEpoch
+		}
+	}
+	return res
+}
+
+// leaderError returns the error of a request to the broker for the partition
+func (h *mockClusterHandler) leaderError(topic string, partition int32) (*mockClusterPartition, KError) {
+	p := h.cluster.partition(topic, partition)
+	switch {
+	case p == nil:
+		return nil, ErrUnknownTopicOrPartition
+	case p.leader != h.brokerID:
+		return nil, ErrNotLeaderForPartition
+	}
+	return p, ErrNoError
+}
+
+func (h *mockClusterHandler) produce(req *ProduceRequest) *ProduceResponse {
+	res := &ProduceResponse{Version: req.Version}
+	for topic, partitions := range req.records {
+		for partition, records := range partitions {
+			p, kerr := h.leaderError(topic, partition)
+			res.AddTopicPartition(topic, partition, kerr)
+			if p == nil {
+				continue
+			}
+			res.Blocks[topic][partition].Offset = int64(len(p.records))
+			p.records = append(p.records, mockClusterRecords(&records)...)
+		}
+	}
+	return res
+}
+
+// mockClusterRecords returns the records of a produced records set
+func mockClusterRecords(records *Records) []mockClusterRecord {
+	var result []mockClusterRecord
+	if batch := records.RecordBatch; batch != nil {
+		for _, rec := range batch.Records {
+			result = append(result, mockClusterRecord{
+				key:       rec.Key,
+				value:     rec.Value,
+				timestamp: batch.FirstTimestamp.Add(rec.TimestampDelta),
+			})
+		}
+	}
+	if set := records.MsgSet; set != nil {
+		var addMessages func(set *MessageSet)
+		addMessages = func(set *MessageSet) {
+			for _, block := range set.Messages {
+				if block.Msg.Set != nil {
+					addMessages(block.Msg.Set)
+					continue
+				}
+				result = append(result, mockClusterRecord{
+					key:       block.Msg.Key,
+					value:     block.Msg.Value,
+					timestamp: block.Msg.Timestamp,
+				})
+			}
+		}
+		addMessages(set)
+	}
+	return result
+}
+
+func (h *mockClusterHandler) fetch(req *FetchRequest) *FetchResponse {
+	res := &FetchResponse{Version: req.Version}
+	for topic, partitions := range req.blocks {
+		for partition, block := range partitions {
+			p, kerr := h.leaderError(topic, partition)
+			if p != nil && (block.fetchOffset < 0 || block.fetchOffset > int64(len(p.records))) {
+				kerr = ErrOffsetOutOfRange
+			}
+			if kerr != ErrNoError {
+				res.AddError(topic, partition, kerr)
+				continue
+			}
+
+			for offset := block.fetchOffset; offset < int64(len(p.records)) && offset < block.fetchOffset+mockClusterMaxFetchRecords; offset++ {
+				record := p.records[offset]
+				key, value := mockClusterEncoder(record.key), mockClusterEncoder(record.value)
+				switch {
+				case req.Version >= 4:
+					res.AddRecordBatchWithTimestamp(topic, partition, key, value, offset, -1, false, record.timestamp)
+				case req.Version >= 2:
+					res.AddMessageWithTimestamp(topic, partition, key, value, offset, record.timestamp, 1)
+				default:
+					res.AddMessage(topic, partition, key, value, offset)
+				}
+			}
+			fb := res.getOrCreateBlock(topic, partition)
+			fb.HighWaterMarkOffset = int64(len(p.records))
+			fb.LastStableOffset = fb.HighWaterMarkOffset
+		}
+	}
+	return res
+}
+
+func mockClusterEncoder(b []byte) Encoder {
+	if b == nil {
+		return nil
+	}
+	return ByteEncoder(b)
+}
+
+func (h *mockClusterHandler) listOffsets(req *OffsetRequest) *OffsetResponse {
+	res := &OffsetResponse{Version: req.Version}
+	for topic, partitions := range req.blocks {
+		for partition, block := range partitions {
+			p, kerr := h.leaderError(topic, partition)
+			offset := int64(-1)
+			if p != nil {
+				switch block.time {
+				case OffsetOldest:
+					offset = 0
+				default:
+					offset = int64(len(p.records))
+				}
+			}
+			res.AddTopicPartition(topic, partition, offset)
+			res.Blocks[topic][partition].Err = kerr
+			if p != nil {
+				res.Blocks[topic][partition].LeaderEpoch = p.leaderEpoch
+			}
+		}
+	}
+	return res
+}
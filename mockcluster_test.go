@@ -0,0 +1,88 @@
+package sarama
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMockClusterFailover(t *testing.T) {
+	cluster := NewMockCluster(t, 3)
+	defer cluster.Close()
+	cluster.CreateTopic("my_topic", 1, 2)
+	if leader := cluster.Leader("my_topic", 0); leader != 1 {
+		t.Fatalf("expected broker 1 to lead my_topic/0, got %d", leader)
+	}
+
+	config := NewTestConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Backoff = 10 * time.Millisecond
+	config.Metadata.Retry.Backoff = 10 * time.Millisecond
+	client, err := NewClient(cluster.Addrs(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+	producer, err := NewSyncProducerFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, producer)
+
+	produce := func(i int) {
+		t.Helper()
+		_, offset, err := producer.SendMessage(&ProducerMessage{Topic: "my_topic", Value: StringEncoder(fmt.Sprint(i))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if offset != int64(i) {
+			t.Errorf("expected offset %d, got %d", i, offset)
+		}
+	}
+
+	produce(0)
+	// the producer follows the leader once refused by broker 1
+	cluster.MoveLeader("my_topic", 0, 2)
+	produce(1)
+	// the partition fails over to broker 1
+	cluster.StopBroker(2)
+	if leader := cluster.Leader("my_topic", 0); leader != 1 {
+		t.Fatalf("expected broker 1 to lead my_topic/0 again, got %d", leader)
+	}
+	produce(2)
+	// the partition is migrated to the brokers 3 and 2, the stopped one
+	cluster.MovePartition("my_topic", 0, []int32{2, 3})
+	if leader := cluster.Leader("my_topic", 0); leader != 3 {
+		t.Fatalf("expected broker 3 to lead my_topic/0, got %d", leader)
+	}
+	produce(3)
+	if hwm := cluster.HighWaterMark("my_topic", 0); hwm != 4 {
+		t.Errorf("expected a high water mark of 4, got %d", hwm)
+	}
+
+	consumer, err := NewConsumerFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, consumer)
+	pc, err := consumer.ConsumePartition("my_topic", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, pc)
+	for i := 0; i < 4; i++ {
+		select {
+		case msg := <-pc.Messages():
+			if msg.Offset != int64(i) || string(msg.Value) != fmt.Sprint(i) {
+				t.Errorf("unexpected message %q at offset %d", msg.Value, msg.Offset)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected message %d", i)
+		}
+	}
+
+	cluster.RestartBroker(2)
+	if cluster.Broker(2) == nil {
+		t.Error("expected broker 2 to be restarted")
+	}
+}
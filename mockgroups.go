@@ -0,0 +1,456 @@
+package sarama
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type mockGroupState int
+
+const (
+	mockGroupEmpty mockGroupState = iota
+	mockGroupCompletingRebalance
+	mockGroupStable
+)
+
+// MockGroupCoordinator is a stateful MockResponse acting as the coordinator
+// of the consumer groups of a MockBroker. It answers the FindCoordinator,
+// JoinGroup, SyncGroup, Heartbeat, LeaveGroup, OffsetCommit and OffsetFetch
+// requests following the group membership protocol, so that ConsumerGroup
+// flows, rebalances included, can be exercised without a real cluster.
+//
+// Unlike a real coordinator it never holds a response back. A join changing
+// the members of the group or their metadata starts the next generation right
+// away, with the last metadata of the members which did not rejoin yet. The
+// other members notice the rebalance with their next heartbeat and rejoin,
+// and the followers syncing before the leader are answered with
+// ErrRebalanceInProgress and join again after
+// Consumer.Group.Rebalance.Retry.Backoff, which tests with several members
+// should lower.
+type MockGroupCoordinator struct {
+	t      TestReporter
+	broker *MockBroker
+
+	lock      sync.Mutex
+	groups    map[string]*mockGroup
+	memberSeq int
+}
+
+type mockGroup struct {
+	state      mockGroupState
+	generation int32
+	protocol   string
+	leader     string
+	members    map[string]*mockGroupMember
+	offsets    map[string]map[int32]*OffsetFetchResponseBlock
+}
+
+type mockGroupMember struct {
+	id             string
+	seq            int
+	protocols      []*GroupProtocol
+	sessionTimeout time.Duration
+	lastSeen       time.Time
+	assignment     []byte
+}
+
+// NewMockGroupCoordinator returns the group coordinator of the broker.
+func NewMockGroupCoordinator(t TestReporter, broker *MockBroker) *MockGroupCoordinator {
+	return &MockGroupCoordinator{
+		t:      t,
+		broker: broker,
+		groups: make(map[string]*mockGroup),
+	}
+}
+
+// RegisterHandlers adds the coordinator as the MockResponse of the requests
+// it answers to the handler map, which is returned for SetHandlerByMap.
+func (c *MockGroupCoordinator) RegisterHandlers(handlerMap map[string]MockResponse) map[string]MockResponse {
+	if handlerMap == nil {
+		handlerMap = make(map[string]MockResponse)
+	}
+	for _, name := range []string{
+		"FindCoordinatorRequest",
+		"JoinGroupRequest",
+		"SyncGroupRequest",
+		"HeartbeatRequest",
+		"LeaveGroupRequest",
+		"OffsetCommitRequest",
+		"OffsetFetchRequest",
+	} {
+		handlerMap[name] = c
+	}
+	return handlerMap
+}
+
+// Rebalance starts a rebalance of the group, which its members notice with
+// their next heartbeat.
+func (c *MockGroupCoordinator) Rebalance(group string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if g := c.groups[group]; g != nil && len(g.members) > 0 {
+		g.rebalance()
+	}
+}
+
+// Members returns the IDs of the members of the group.
+func (c *MockGroupCoordinator) Members(group string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	g := c.groups[group]
+	if g == nil {
+		return nil
+	}
+	members := make([]string, 0, len(g.members))
+	for id := range g.members {
+		members = append(members, id)
+	}
+	return members
+}
+
+// Generation returns the generation of the group, 0 before its first
+// rebalance.
+func (c *MockGroupCoordinator) Generation(group string) int32 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if g := c.groups[group]; g != nil {
+		return g.generation
+	}
+	return 0
+}
+
+// SetOffset sets the committed offset of a partition for the group.
+func (c *MockGroupCoordinator) SetOffset(group, topic string, partition int32, offset int64, metadata string) *MockGroupCoordinator {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.group(group).commit(topic, partition, offset, metadata)
+	return c
+}
+
+// Offset returns the committed offset of a partition for the group, false if
+// none was committed.
+func (c *MockGroupCoordinator) Offset(group, topic string, partition int32) (int64, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	g := c.groups[group]
+	if g == nil {
+		return 0, false
+	}
+	block, ok := g.offsets[topic][partition]
+	if !ok {
+		return 0, false
+	}
+	return block.Offset, true
+}
+
+func (c *MockGroupCoordinator) For(reqBody versionedDecoder) encoderWithHeader {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch req := reqBody.(type) {
+	case *FindCoordinatorRequest:
+		return &FindCoordinatorResponse{
+			Version:     req.Version,
+			Coordinator: &Broker{id: c.broker.BrokerID(), addr: c.broker.Addr()},
+		}
+	case *JoinGroupRequest:
+		return c.join(req)
+	case *SyncGroupRequest:
+		return c.sync(req)
+	case *HeartbeatRequest:
+		return c.heartbeat(req)
+	case *LeaveGroupRequest:
+		return c.leave(req)
+	case *OffsetCommitRequest:
+		return c.commit(req)
+	case *OffsetFetchRequest:
+		return c.fetch(req)
+	}
+	c.t.Errorf("mockgroups: unexpected request %T", reqBody)
+	return nil
+}
+
+func (c *MockGroupCoordinator) group(id string) *mockGroup {
+	g := c.groups[id]
+	if g == nil {
+		g = &mockGroup{
+			members: make(map[string]*mockGroupMember),
+			offsets: make(map[string]map[int32]*OffsetFetchResponseBlock),
+		}
+		c.groups[id] = g
+	}
+	g.expire(time.Now())
+	return g
+}
+
+func (c *MockGroupCoordinator) join(req *JoinGroupRequest) *JoinGroupResponse {
+	res := &JoinGroupResponse{Version: req.Version, GenerationId: -1}
+	g := c.group(req.GroupId)
+
+	member := g.members[req.MemberId]
+	if req.MemberId != "" && member == nil {
+		res.Err = ErrUnknownMemberId
+		return res
+	}
+	changed := member == nil
+	if member == nil {
+		c.memberSeq++
+		member = &mockGroupMember{id: fmt.Sprintf("%s-member-%d", req.GroupId, c.memberSeq), seq: c.memberSeq}
+		g.members[member.id] = member
+	}
+	res.MemberId = member.id
+
+	changed = changed || !sameGroupProtocols(member.protocols, req.OrderedGroupProtocols)
+	member.protocols = req.OrderedGroupProtocols
+	member.sessionTimeout = time.Duration(req.SessionTimeout) * time.Millisecond
+	member.lastSeen = time.Now()
+
+	// the followers rejoining with the same metadata keep their
+	// assignment, like with a real coordinator
+	if changed || g.state == mockGroupEmpty || (g.state == mockGroupStable && member.id == g.leader) {
+		g.rebalance()
+	}
+
+	res.GenerationId = g.generation
+	res.GroupProtocol = g.protocol
+	res.LeaderId = g.leader
+	if member.id == g.leader {
+		res.Members = make(map[string][]byte, len(g.members))
+		for id, m := range g.members {
+			res.Members[id], _ = groupProtocolMetadata(m.protocols, g.protocol)
+		}
+	}
+	return res
+}
+
+func (c *MockGroupCoordinator) sync(req *SyncGroupRequest) *SyncGroupResponse {
+	res := &SyncGroupResponse{Version: req.Version}
+	g := c.group(req.GroupId)
+
+	member := g.members[req.MemberId]
+	switch {
+	case member == nil:
+		res.Err = ErrUnknownMemberId
+	case req.GenerationId != g.generation:
+		res.Err = ErrIllegalGeneration
+	case g.state == mockGroupCompletingRebalance && member.id != g.leader:
+		// the leader did not send the assignments yet
+		res.Err = ErrRebalanceInProgress
+	default:
+		if g.state == mockGroupCompletingRebalance {
+			for id, assignment := range req.GroupAssignments {
+				if m := g.members[id]; m != nil {
+					m.assignment = assignment
+				}
+			}
+			g.state = mockGroupStable
+		}
+		member.lastSeen = time.Now()
+		res.MemberAssignment = member.assignment
+	}
+	return res
+}
+
+func (c *MockGroupCoordinator) heartbeat(req *HeartbeatRequest) *HeartbeatResponse {
+	res := &HeartbeatResponse{Version: req.Version}
+	g := c.group(req.GroupId)
+
+	member := g.members[req.MemberId]
+	switch {
+	case member == nil:
+		res.Err = ErrUnknownMemberId
+	case g.state != mockGroupStable || req.GenerationId != g.generation:
+		// the member did not join the current generation yet
+		res.Err = ErrRebalanceInProgress
+	default:
+		member.lastSeen = time.Now()
+	}
+	return res
+}
+
+func (c *MockGroupCoordinator) leave(req *LeaveGroupRequest) *LeaveGroupResponse {
+	res := &LeaveGroupResponse{Version: req.Version}
+	g := c.group(req.GroupId)
+
+	if req.Version < 3 {
+		if !g.remove(req.MemberId) {
+			res.Err = ErrUnknownMemberId
+		}
+	} else {
+		for _, m := range req.Members {
+			memberRes := MemberResponse{MemberId: m.MemberId, GroupInstanceId: m.GroupInstanceId}
+			if !g.remove(m.MemberId) {
+				memberRes.Err = ErrUnknownMemberId
+			}
+			res.Members = append(res.Members, memberRes)
+		}
+	}
+	return res
+}
+
+func (c *MockGroupCoordinator) commit(req *OffsetCommitRequest) *OffsetCommitResponse {
+	res := &OffsetCommitResponse{Version: req.Version}
+	g := c.group(req.ConsumerGroup)
+
+	kerr := ErrNoError
+	// the commits of the members are checked against the group
+	if req.Version >= 1 && req.ConsumerGroupGeneration >= 0 {
+		member := g.members[req.ConsumerID]
+		switch {
+		case member == nil:
+			kerr = ErrUnknownMemberId
+		case req.ConsumerGroupGeneration != g.generation:
+			kerr = ErrIllegalGeneration
+		default:
+			member.lastSeen = time.Now()
+		}
+	}
+
+	for topic, partitions := range req.blocks {
+		for partition, block := range partitions {
+			if errors.Is(kerr, ErrNoError) {
+				g.commit(topic, partition, block.offset, block.metadata)
+			}
+			res.AddError(topic, partition, kerr)
+		}
+	}
+	return res
+}
+
+func (c *MockGroupCoordinator) fetch(req *OffsetFetchRequest) *OffsetFetchResponse {
+	res := &OffsetFetchResponse{Version: req.Version}
+
+	if req.Version >= 8 {
+		for group, partitions := range req.groups {
+			g := c.group(group)
+			if res.Groups == nil {
+				res.Groups = make(map[string]*OffsetFetchResponseGroup)
+			}
+			res.Groups[group] = &OffsetFetchResponseGroup{}
+			g.fetch(partitions, func(topic string, partition int32, block *OffsetFetchResponseBlock) {
+				res.AddGroupBlock(group, topic, partition, block)
+			})
+		}
+		return res
+	}
+
+	c.group(req.ConsumerGroup).fetch(req.partitions, res.AddBlock)
+	return res
+}
+
+// expire removes the members whose session timed out
+func (g *mockGroup) expire(now time.Time) {
+	expired := false
+	for id, m := range g.members {
+		if m.sessionTimeout > 0 && now.Sub(m.lastSeen) > m.sessionTimeout {
+			delete(g.members, id)
+			expired = true
+		}
+	}
+	if expired {
+		g.rebalance()
+	}
+}
+
+// rebalance starts the next generation of the group with its current
+// members, waiting for the assignments of its leader
+func (g *mockGroup) rebalance() {
+	for _, m := range g.members {
+		m.assignment = nil
+	}
+	if len(g.members) == 0 {
+		g.state = mockGroupEmpty
+		return
+	}
+
+	g.generation++
+	g.state = mockGroupCompletingRebalance
+	if _, ok := g.members[g.leader]; !ok {
+		// the member which joined first
+		var first *mockGroupMember
+		for _, m := range g.members {
+			if first == nil || m.seq < first.seq {
+				first = m
+			}
+		}
+		g.leader = first.id
+	}
+
+	// the first protocol of the leader supported by all members
+	g.protocol = ""
+	for _, p := range g.members[g.leader].protocols {
+		supported := true
+		for _, m := range g.members {
+			if _, ok := groupProtocolMetadata(m.protocols, p.Name); !ok {
+				supported = false
+			}
+		}
+		if supported {
+			g.protocol = p.Name
+			break
+		}
+	}
+}
+
+func (g *mockGroup) remove(memberID string) bool {
+	if _, ok := g.members[memberID]; !ok {
+		return false
+	}
+	delete(g.members, memberID)
+	g.rebalance()
+	return true
+}
+
+func (g *mockGroup) commit(topic string, partition int32, offset int64, metadata string) {
+	partitions := g.offsets[topic]
+	if partitions == nil {
+		partitions = make(map[int32]*OffsetFetchResponseBlock)
+		g.offsets[topic] = partitions
+	}
+	partitions[partition] = &OffsetFetchResponseBlock{Offset: offset, LeaderEpoch: -1, Metadata: metadata, Err: ErrNoError}
+}
+
+// fetch adds the committed offsets of the partitions, or of all partitions
+// when nil, to a response
+func (g *mockGroup) fetch(partitions map[string][]int32, add func(topic string, partition int32, block *OffsetFetchResponseBlock)) {
+	if partitions == nil {
+		for topic, blocks := range g.offsets {
+			for partition, block := range blocks {
+				add(topic, partition, block)
+			}
+		}
+		return
+	}
+	for topic, ids := range partitions {
+		for _, partition := range ids {
+			block, ok := g.offsets[topic][partition]
+			if !ok {
+				block = &OffsetFetchResponseBlock{Offset: -1, LeaderEpoch: -1, Err: ErrNoError}
+			}
+			add(topic, partition, block)
+		}
+	}
+}
+
+func sameGroupProtocols(a, b []*GroupProtocol) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || string(a[i].Metadata) != string(b[i].Metadata) {
+			return false
+		}
+	}
+	return true
+}
+
+func groupProtocolMetadata(protocols []*GroupProtocol, name string) ([]byte, bool) {
+	for _, p := range protocols {
+		if p.Name == name {
+			return p.Metadata, true
+		}
+	}
+	return nil, false
+}
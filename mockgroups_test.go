@@ -0,0 +1,223 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockGroupsHandler struct {
+	claims   chan []int32
+	messages chan *ConsumerMessage
+}
+
+func (h *mockGroupsHandler) Setup(sess ConsumerGroupSession) error {
+	h.claims <- sess.Claims()["my_topic"]
+	return nil
+}
+
+func (h *mockGroupsHandler) Cleanup(ConsumerGroupSession) error { return nil }
+
+func (h *mockGroupsHandler) ConsumeClaim(sess ConsumerGroupSession, claim ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		sess.MarkMessage(msg, "")
+		if h.messages != nil {
+			h.messages <- msg
+		}
+	}
+	return nil
+}
+
+func newMockGroupsBroker(t *testing.T, partitions int32, fetch MockResponse) (*MockBroker, *MockGroupCoordinator) {
+	broker := NewMockBroker(t, 0)
+	coordinator := NewMockGroupCoordinator(t, broker)
+
+	metadata := NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID())
+	offsets := NewMockOffsetResponse(t).SetVersion(1)
+	for p := int32(0); p < partitions; p++ {
+		metadata.SetLeader("my_topic", p, broker.BrokerID())
+		offsets.SetOffset("my_topic", p, OffsetOldest, 0).SetOffset("my_topic", p, OffsetNewest, 2)
+	}
+	broker.SetHandlerByMap(coordinator.RegisterHandlers(map[string]MockResponse{
+		"MetadataRequest": metadata,
+		"OffsetRequest":   offsets,
+		"FetchRequest":    fetch,
+	}))
+	return broker, coordinator
+}
+
+func newMockGroupsConfig() *Config {
+	config := NewTestConfig()
+	config.Version = V0_10_2_0
+	config.Consumer.Offsets.Initial = OffsetOldest
+	config.Consumer.Group.Heartbeat.Interval = 10 * time.Millisecond
+	config.Consumer.Group.Rebalance.Retry.Backoff = 10 * time.Millisecond
+	config.Consumer.Group.Rebalance.Retry.Max = 100
+	return config
+}
+
+func TestMockGroupCoordinatorConsumes(t *testing.T) {
+	fetch := NewMockFetchResponse(t, 1).SetVersion(3).
+		SetMessage("my_topic", 0, 0, StringEncoder("foo")).
+		SetMessage("my_topic", 0, 1, StringEncoder("bar"))
+	broker, coordinator := newMockGroupsBroker(t, 1, fetch)
+	defer broker.Close()
+
+	group, err := NewConsumerGroup([]string{broker.Addr()}, "my_group", newMockGroupsConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &mockGroupsHandler{claims: make(chan []int32, 1), messages: make(chan *ConsumerMessage, 2)}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- group.Consume(ctx, []string{"my_topic"}, handler) }()
+
+	if claims := <-handler.claims; len(claims) != 1 || claims[0] != 0 {
+		t.Errorf("unexpected claims %v", claims)
+	}
+	for i := int64(0); i < 2; i++ {
+		if msg := <-handler.messages; msg.Offset != i {
+			t.Errorf("expected offset %d, got %d", i, msg.Offset)
+		}
+	}
+	if members := coordinator.Members("my_group"); len(members) != 1 {
+		t.Errorf("expected 1 member, got %v", members)
+	}
+	if generation := coordinator.Generation("my_group"); generation != 1 {
+		t.Errorf("expected generation 1, got %d", generation)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+	safeClose(t, group)
+
+	if offset, ok := coordinator.Offset("my_group", "my_topic", 0); !ok || offset != 2 {
+		t.Errorf("expected committed offset 2, got %d (%v)", offset, ok)
+	}
+	if members := coordinator.Members("my_group"); len(members) != 0 {
+		t.Errorf("expected the member to leave, got %v", members)
+	}
+}
+
+func TestMockGroupCoordinatorRebalances(t *testing.T) {
+	broker, coordinator := newMockGroupsBroker(t, 2, NewMockFetchResponse(t, 1).SetVersion(3))
+	defer broker.Close()
+
+	type member struct {
+		group   ConsumerGroup
+		handler *mockGroupsHandler
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	join := func() *member {
+		group, err := NewConsumerGroup([]string{broker.Addr()}, "my_group", newMockGroupsConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := &member{group: group, handler: &mockGroupsHandler{claims: make(chan []int32, 10)}}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if err := group.Consume(ctx, []string{"my_topic"}, m.handler); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		return m
+	}
+	// claims returns the claims of the next session with the given number
+	// of partitions
+	claims := func(m *member, n int) []int32 {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case claims := <-m.handler.claims:
+				if len(claims) == n {
+					return claims
+				}
+			case <-timeout:
+				t.Fatalf("no session claiming %d partitions", n)
+			}
+		}
+	}
+
+	first := join()
+	claims(first, 2)
+
+	second := join()
+	a, b := claims(first, 1), claims(second, 1)
+	if a[0] == b[0] {
+		t.Errorf("both members claim partition %d", a[0])
+	}
+	if members := coordinator.Members("my_group"); len(members) != 2 {
+		t.Errorf("expected 2 members, got %v", members)
+	}
+	if generation := coordinator.Generation("my_group"); generation != 2 {
+		t.Errorf("expected generation 2, got %d", generation)
+	}
+
+	cancel()
+	wg.Wait()
+	safeClose(t, first.group)
+	safeClose(t, second.group)
+}
+
+func TestMockGroupCoordinatorErrors(t *testing.T) {
+	broker := NewMockBroker(t, 0)
+	defer broker.Close()
+	coordinator := NewMockGroupCoordinator(t, broker)
+
+	protocols := []*GroupProtocol{{Name: "range", Metadata: []byte{1}}}
+	join := coordinator.For(&JoinGroupRequest{GroupId: "my_group", OrderedGroupProtocols: protocols}).(*JoinGroupResponse)
+	if !errors.Is(join.Err, ErrNoError) || join.LeaderId != join.MemberId || join.GenerationId != 1 {
+		t.Fatalf("unexpected join response %+v", join)
+	}
+
+	res := coordinator.For(&HeartbeatRequest{GroupId: "my_group", MemberId: join.MemberId, GenerationId: 1}).(*HeartbeatResponse)
+	if !errors.Is(res.Err, ErrRebalanceInProgress) {
+		t.Errorf("expected ErrRebalanceInProgress before the sync, got %v", res.Err)
+	}
+
+	syncRes := coordinator.For(&SyncGroupRequest{
+		GroupId:          "my_group",
+		MemberId:         join.MemberId,
+		GenerationId:     1,
+		GroupAssignments: map[string][]byte{join.MemberId: {2}},
+	}).(*SyncGroupResponse)
+	if !errors.Is(syncRes.Err, ErrNoError) || string(syncRes.MemberAssignment) != "\x02" {
+		t.Errorf("unexpected sync response %+v", syncRes)
+	}
+
+	res = coordinator.For(&HeartbeatRequest{GroupId: "my_group", MemberId: "unknown", GenerationId: 1}).(*HeartbeatResponse)
+	if !errors.Is(res.Err, ErrUnknownMemberId) {
+		t.Errorf("expected ErrUnknownMemberId, got %v", res.Err)
+	}
+
+	commit := &OffsetCommitRequest{Version: 2, ConsumerGroup: "my_group", ConsumerID: join.MemberId, ConsumerGroupGeneration: 0}
+	commit.AddBlock("my_topic", 0, 5, 0, "")
+	commitRes := coordinator.For(commit).(*OffsetCommitResponse)
+	if kerr := commitRes.Errors["my_topic"][0]; !errors.Is(kerr, ErrIllegalGeneration) {
+		t.Errorf("expected ErrIllegalGeneration, got %v", kerr)
+	}
+
+	coordinator.Rebalance("my_group")
+	res = coordinator.For(&HeartbeatRequest{GroupId: "my_group", MemberId: join.MemberId, GenerationId: 1}).(*HeartbeatResponse)
+	if !errors.Is(res.Err, ErrRebalanceInProgress) {
+		t.Errorf("expected ErrRebalanceInProgress after a rebalance, got %v", res.Err)
+	}
+
+	fetch := &OffsetFetchRequest{Version: 1, ConsumerGroup: "my_group"}
+	fetch.AddPartition("my_topic", 0)
+	coordinator.SetOffset("my_group", "my_topic", 0, 7, "meta")
+	block := coordinator.For(fetch).(*OffsetFetchResponse).GetBlock("my_topic", 0)
+	if block == nil || block.Offset != 7 || block.Metadata != "meta" {
+		t.Errorf("unexpected offset fetch block %+v", block)
+	}
+}
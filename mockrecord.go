@@ -0,0 +1,191 @@
+package sarama
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// MockExchange is a request received by a MockBroker and its response, as
+// recorded with Record and replayed with MockReplay. The bodies are encoded
+// with the version of the request, without the request and response
+// headers.
+type MockExchange struct {
+	APIKey  int16  `json:"api_key"`
+	Version int16  `json:"version"`
+	Request string `json:"request"`
+	Body    []byte `json:"body"`
+	// ResponseHeaderVersion and Response are not set if the broker did not
+	// reply to the request.
+	ResponseHeaderVersion int16  `json:"response_header_version,omitempty"`
+	Response              []byte `json:"response,omitempty"`
+}
+
+// Record makes the broker write the requests it receives and its responses
+// to w, one MockExchange in JSON per line, which can be saved as a golden
+// file and replayed with NewMockReplay. A nil writer stops the recording.
+func (b *MockBroker) Record(w io.Writer) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.recorder = w
+}
+
+// record writes the exchange to the recorder of the broker, if any
+func (b *MockBroker) record(req *request, res encoderWithHeader, encodedRes []byte) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.recorder == nil {
+		return
+	}
+
+	body, err := encode(req.body, nil)
+	if err != nil {
+		b.t.Errorf("mockbroker/%d: failed to record %T: %v", b.brokerID, req.body, err)
+		return
+	}
+	exchange := MockExchange{
+		APIKey:  req.body.key(),
+		Version: req.body.version(),
+		Request: reflect.TypeOf(req.body).Elem().Name(),
+		Body:    body,
+	}
+	if res != nil {
+		exchange.ResponseHeaderVersion = res.headerVersion()
+		exchange.Response = encodedRes
+	}
+
+	line, err := json.Marshal(&exchange)
+	if err == nil {
+		_, err = b.recorder.Write(append(line, '\n'))
+	}
+	if err != nil {
+		b.t.Errorf("mockbroker/%d: failed to record %T: %v", b.brokerID, req.body, err)
+	}
+}
+
+// ReadMockExchanges reads the exchanges written by MockBroker.Record.
+func ReadMockExchanges(r io.Reader) ([]*MockExchange, error) {
+	var exchanges []*MockExchange
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 2*int(MaxResponseSize))
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		exchange := new(MockExchange)
+		if err := json.Unmarshal(scanner.Bytes(), exchange); err != nil {
+			return nil, fmt.Errorf("invalid exchange on line %d: %w", line, err)
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return exchanges, nil
+}
+
+// MockReplay is a MockResponse replaying recorded exchanges: every request
+// is answered with the response of the next exchange recorded for its type,
+// whatever its body, unless it is strict. Requests of a type with no exchange
+// left are reported to the TestReporter and not answered.
+type MockReplay struct {
+	t TestReporter
+
+	lock      sync.Mutex
+	strict    bool
+	exchanges map[string][]*MockExchange
+}
+
+type mockRawResponse struct {
+	version int16
+	bytes   []byte
+}
+
+func (r *mockRawResponse) encode(pe packetEncoder) error {
+	return pe.putRawBytes(r.bytes)
+}
+
+func (r *mockRawResponse) headerVersion() int16 {
+	return r.version
+}
+
+// NewMockReplay returns a replay of the exchanges, as read by
+// ReadMockExchanges.
+func NewMockReplay(t TestReporter, exchanges []*MockExchange) *MockReplay {
+	r := &MockReplay{t: t, exchanges: make(map[string][]*MockExchange)}
+	for _, exchange := range exchanges {
+		r.exchanges[exchange.Request] = append(r.exchanges[exchange.Request], exchange)
+	}
+	return r
+}
+
+// SetStrict sets whether the requests must have the recorded bodies, the
+// differences being reported to the TestReporter, which makes the replay a
+// protocol regression test.
+func (r *MockReplay) SetStrict(strict bool) *MockReplay {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.strict = strict
+	return r
+}
+
+// RegisterHandlers adds the replay as the MockResponse of the recorded
+// request types to the handler map, which is returned for SetHandlerByMap.
+func (r *MockReplay) RegisterHandlers(handlerMap map[string]MockResponse) map[string]MockResponse {
+	if handlerMap == nil {
+		handlerMap = make(map[string]MockResponse)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for name := range r.exchanges {
+		handlerMap[name] = r
+	}
+	return handlerMap
+}
+
+// Remaining returns the number of exchanges not replayed yet.
+func (r *MockReplay) Remaining() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	n := 0
+	for _, exchanges := range r.exchanges {
+		n += len(exchanges)
+	}
+	return n
+}
+
+func (r *MockReplay) For(reqBody versionedDecoder) encoderWithHeader {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	name := reflect.TypeOf(reqBody).Elem().Name()
+	exchanges := r.exchanges[name]
+	if len(exchanges) == 0 {
+		r.t.Errorf("MockReplay has no exchange left for %s", name)
+		return nil
+	}
+	exchange := exchanges[0]
+	r.exchanges[name] = exchanges[1:]
+
+	if r.strict {
+		req := reqBody.(protocolBody)
+		body, err := encode(req, nil)
+		switch {
+		case err != nil:
+			r.t.Errorf("MockReplay failed to encode %s: %v", name, err)
+		case req.key() != exchange.APIKey || req.version() != exchange.Version:
+			r.t.Errorf("MockReplay expected %s v%d, got v%d", name, exchange.Version, req.version())
+		case !bytes.Equal(body, exchange.Body):
+			r.t.Errorf("MockReplay expected %s body %x, got %x", name, exchange.Body, body)
+		}
+	}
+
+	if exchange.Response == nil {
+		return nil
+	}
+	return &mockRawResponse{version: exchange.ResponseHeaderVersion, bytes: exchange.Response}
+}
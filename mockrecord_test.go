@@ -0,0 +1,81 @@
+package sarama
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// recordingReporter is a TestReporter keeping the errors reported to it
+type recordingReporter struct {
+	*testing.T
+	errors []string
+}
+
+func (r *recordingReporter) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockBrokerRecordAndReplay(t *testing.T) {
+	var golden bytes.Buffer
+	recorder := NewMockBroker(t, 1)
+	recorder.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(recorder.Addr(), recorder.BrokerID()).
+			SetLeader("my_topic", 0, recorder.BrokerID()),
+	})
+	recorder.Record(&golden)
+
+	config := NewTestConfig()
+	broker := NewBroker(recorder.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := broker.GetMetadata(&MetadataRequest{Topics: []string{"my_topic"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	safeClose(t, broker)
+	recorder.Close()
+
+	exchanges, err := ReadMockExchanges(&golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exchanges) != 1 || exchanges[0].Request != "MetadataRequest" || exchanges[0].APIKey != 3 {
+		t.Fatalf("unexpected exchanges %+v", exchanges)
+	}
+
+	reporter := &recordingReporter{T: t}
+	replay := NewMockReplay(reporter, append(exchanges, exchanges[0])).SetStrict(true)
+	replayer := NewMockBroker(t, 1)
+	defer replayer.Close()
+	replayer.SetHandlerByMap(replay.RegisterHandlers(nil))
+
+	broker = NewBroker(replayer.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+	replayed, err := broker.GetMetadata(&MetadataRequest{Topics: []string{"my_topic"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed.Topics) != 1 || replayed.Topics[0].Name != "my_topic" || len(replayed.Brokers) != len(recorded.Brokers) {
+		t.Errorf("expected the recorded metadata, got %+v", replayed)
+	}
+	if len(reporter.errors) != 0 {
+		t.Errorf("expected no difference, got %v", reporter.errors)
+	}
+
+	// the second request differs from the recorded one
+	if _, err := broker.GetMetadata(&MetadataRequest{Topics: []string{"other_topic"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(reporter.errors) != 1 {
+		t.Errorf("expected the difference to be reported, got %v", reporter.errors)
+	}
+	if replay.Remaining() != 0 {
+		t.Errorf("expected all the exchanges to be replayed, %d are left", replay.Remaining())
+	}
+}
@@ -1,9 +1,14 @@
 package sarama
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"net"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // TestReporter has methods matching go's testing.T to avoid importing
@@ -69,31 +74,68 @@ func (mc *MockSequence) For(reqBody versionedDecoder) (res encoderWithHeader) {
 }
 
 type MockListGroupsResponse struct {
-	groups map[string]string
-	t      TestReporter
+	groups     map[string]string
+	groupsData map[string]GroupData
+	t          TestReporter
 }
 
 func NewMockListGroupsResponse(t TestReporter) *MockListGroupsResponse {
 	return &MockListGroupsResponse{
-		groups: make(map[string]string),
-		t:      t,
+		groups:     make(map[string]string),
+		groupsData: make(map[string]GroupData),
+		t:          t,
 	}
 }
 
 func (m *MockListGroupsResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	request := reqBody.(*ListGroupsRequest)
-	_ = request
 	response := &ListGroupsResponse{
-		Groups: m.groups,
+		Version: request.Version,
+		Groups:  m.groups,
+	}
+	if request.Version < 4 {
+		return response
+	}
+
+	response.Groups = make(map[string]string)
+	response.GroupsData = make(map[string]GroupData)
+	for groupID, protocolType := range m.groups {
+		data := m.groupsData[groupID]
+		if !matchesListGroupsFilter(request.StatesFilter, data.GroupState) ||
+			!matchesListGroupsFilter(request.TypesFilter, data.GroupType) {
+			continue
+		}
+		response.Groups[groupID] = protocolType
+		response.GroupsData[groupID] = data
 	}
 	return response
 }
 
+func matchesListGroupsFilter(filter []string, value string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if strings.EqualFold(f, value) {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *MockListGroupsResponse) AddGroup(groupID, protocolType string) *MockListGroupsResponse {
 	m.groups[groupID] = protocolType
 	return m
 }
 
+// AddGroupWithData adds a group along with the state and type returned by
+// ListGroups v4 and later
+func (m *MockListGroupsResponse) AddGroupWithData(groupID, protocolType string, data GroupData) *MockListGroupsResponse {
+	m.groups[groupID] = protocolType
+	m.groupsData[groupID] = data
+	return m
+}
+
 type MockDescribeGroupsResponse struct {
 	groups map[string]*GroupDescription
 	t      TestReporter
@@ -134,16 +176,21 @@ func (m *MockDescribeGroupsResponse) For(reqBody versionedDecoder) encoderWithHe
 // MockMetadataResponse is a `MetadataResponse` builder.
 type MockMetadataResponse struct {
 	controllerID int32
+	clusterID    *string
 	leaders      map[string]map[int32]int32
 	brokers      map[string]int32
+	topicIDs     map[string]Uuid
+	isrs         map[string]map[int32][]int32
 	t            TestReporter
 }
 
 func NewMockMetadataResponse(t TestReporter) *MockMetadataResponse {
 	return &MockMetadataResponse{
-		leaders: make(map[string]map[int32]int32),
-		brokers: make(map[string]int32),
-		t:       t,
+		leaders:  make(map[string]map[int32]int32),
+		brokers:  make(map[string]int32),
+		topicIDs: make(map[string]Uuid),
+		isrs:     make(map[string]map[int32][]int32),
+		t:        t,
 	}
 }
 
@@ -167,11 +214,35 @@ func (mmr *MockMetadataResponse) SetController(brokerID int32) *MockMetadataResp
 	return mmr
 }
 
+// SetClusterID sets the cluster ID returned for v2+ requests
+func (mmr *MockMetadataResponse) SetClusterID(clusterID string) *MockMetadataResponse {
+	mmr.clusterID = &clusterID
+	return mmr
+}
+
+// SetIsr sets the in-sync replicas of a partition, which otherwise contain
+// all brokers
+func (mmr *MockMetadataResponse) SetIsr(topic string, partition int32, isr []int32) *MockMetadataResponse {
+	partitions := mmr.isrs[topic]
+	if partitions == nil {
+		partitions = make(map[int32][]int32)
+		mmr.isrs[topic] = partitions
+	}
+	partitions[partition] = isr
+	return mmr
+}
+
+func (mmr *MockMetadataResponse) SetTopicID(topic string, id Uuid) *MockMetadataResponse {
+	mmr.topicIDs[topic] = id
+	return mmr
+}
+
 func (mmr *MockMetadataResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	metadataRequest := reqBody.(*MetadataRequest)
 	metadataResponse := &MetadataResponse{
 		Version:      metadataRequest.version(),
 		ControllerID: mmr.controllerID,
+		ClusterID:    mmr.clusterID,
 	}
 	for addr, brokerID := range mmr.brokers {
 		metadataResponse.AddBroker(addr, brokerID)
@@ -184,12 +255,14 @@ func (mmr *MockMetadataResponse) For(reqBody versionedDecoder) encoderWithHeader
 		replicas = append(replicas, brokerID)
 	}
 
-	if len(metadataRequest.Topics) == 0 {
+	if len(metadataRequest.Topics) == 0 && len(metadataRequest.TopicIDs) == 0 {
 		for topic, partitions := range mmr.leaders {
 			for partition, brokerID := range partitions {
 				metadataResponse.AddTopicPartition(topic, partition, brokerID, replicas, replicas, offlineReplicas, ErrNoError)
 			}
 		}
+		mmr.setTopicIDs(metadataResponse)
+		mmr.setIsrs(metadataResponse)
 		return metadataResponse
 	}
 	for _, topic := range metadataRequest.Topics {
@@ -197,20 +270,59 @@ func (mmr *MockMetadataResponse) For(reqBody versionedDecoder) encoderWithHeader
 			metadataResponse.AddTopicPartition(topic, partition, brokerID, replicas, replicas, offlineReplicas, ErrNoError)
 		}
 	}
+	for _, id := range metadataRequest.TopicIDs {
+		found := false
+		for topic, topicID := range mmr.topicIDs {
+			if topicID != id {
+				continue
+			}
+			found = true
+			for partition, brokerID := range mmr.leaders[topic] {
+				metadataResponse.AddTopicPartition(topic, partition, brokerID, replicas, replicas, offlineReplicas, ErrNoError)
+			}
+		}
+		if !found {
+			metadataResponse.Topics = append(metadataResponse.Topics, &TopicMetadata{Err: ErrUnknownTopicID, Uuid: id})
+		}
+	}
+	mmr.setTopicIDs(metadataResponse)
+	mmr.setIsrs(metadataResponse)
 	return metadataResponse
 }
 
+func (mmr *MockMetadataResponse) setIsrs(res *MetadataResponse) {
+	for _, tm := range res.Topics {
+		for _, pm := range tm.Partitions {
+			if isr, ok := mmr.isrs[tm.Name][pm.ID]; ok {
+				pm.Isr = isr
+			}
+		}
+	}
+}
+
+func (mmr *MockMetadataResponse) setTopicIDs(res *MetadataResponse) {
+	for _, tm := range res.Topics {
+		if id, ok := mmr.topicIDs[tm.Name]; ok {
+			tm.Uuid = id
+		}
+	}
+}
+
 // MockOffsetResponse is an `OffsetResponse` builder.
 type MockOffsetResponse struct {
-	offsets map[string]map[int32]map[int64]int64
-	t       TestReporter
-	version int16
+	offsets      map[string]map[int32]map[int64]int64
+	timestamps   map[string]map[int32]map[int64]int64
+	leaderEpochs map[string]map[int32]int32
+	t            TestReporter
+	version      int16
 }
 
 func NewMockOffsetResponse(t TestReporter) *MockOffsetResponse {
 	return &MockOffsetResponse{
-		offsets: make(map[string]map[int32]map[int64]int64),
-		t:       t,
+		offsets:      make(map[string]map[int32]map[int64]int64),
+		timestamps:   make(map[string]map[int32]map[int64]int64),
+		leaderEpochs: make(map[string]map[int32]int32),
+		t:            t,
 	}
 }
 
@@ -234,6 +346,36 @@ func (mor *MockOffsetResponse) SetOffset(topic string, partition int32, time, of
 	return mor
 }
 
+// SetOffsetAndTimestamp is like SetOffset but also sets the timestamp of the
+// message at the given offset, which is returned for v1+ requests
+func (mor *MockOffsetResponse) SetOffsetAndTimestamp(topic string, partition int32, time, offset, timestamp int64) *MockOffsetResponse {
+	mor.SetOffset(topic, partition, time, offset)
+	partitions := mor.timestamps[topic]
+	if partitions == nil {
+		partitions = make(map[int32]map[int64]int64)
+		mor.timestamps[topic] = partitions
+	}
+	times := partitions[partition]
+	if times == nil {
+		times = make(map[int64]int64)
+		partitions[partition] = times
+	}
+	times[time] = timestamp
+	return mor
+}
+
+// SetLeaderEpoch sets the leader epoch returned for the partition by v4+
+// requests
+func (mor *MockOffsetResponse) SetLeaderEpoch(topic string, partition int32, epoch int32) *MockOffsetResponse {
+	partitions := mor.leaderEpochs[topic]
+	if partitions == nil {
+		partitions = make(map[int32]int32)
+		mor.leaderEpochs[topic] = partitions
+	}
+	partitions[partition] = epoch
+	return mor
+}
+
 func (mor *MockOffsetResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	offsetRequest := reqBody.(*OffsetRequest)
 	offsetResponse := &OffsetResponse{Version: mor.version}
@@ -241,6 +383,12 @@ func (mor *MockOffsetResponse) For(reqBody versionedDecoder) encoderWithHeader {
 		for partition, block := range partitions {
 			offset := mor.getOffset(topic, partition, block.time)
 			offsetResponse.AddTopicPartition(topic, partition, offset)
+			if timestamp, ok := mor.timestamps[topic][partition][block.time]; ok {
+				offsetResponse.Blocks[topic][partition].Timestamp = timestamp
+			}
+			if epoch, ok := mor.leaderEpochs[topic][partition]; ok {
+				offsetResponse.Blocks[topic][partition].LeaderEpoch = epoch
+			}
 		}
 	}
 	return offsetResponse
@@ -262,19 +410,46 @@ func (mor *MockOffsetResponse) getOffset(topic string, partition int32, time int
 	return offset
 }
 
-// MockFetchResponse is a `FetchResponse` builder.
+// MockFetchResponse is a `FetchResponse` builder. The messages set with
+// SetMessage are returned in legacy message sets, while the records set with
+// SetRecord and SetControlRecord are returned from version 4 in v2 record
+// batches, one per record.
 type MockFetchResponse struct {
-	messages       map[string]map[int32]map[int64]Encoder
-	messagesLock   *sync.RWMutex
-	highWaterMarks map[string]map[int32]int64
-	t              TestReporter
-	batchSize      int
-	version        int16
+	messages            map[string]map[int32]map[int64]interface{}
+	messagesLock        *sync.RWMutex
+	highWaterMarks      map[string]map[int32]int64
+	lastStableOffsets   map[string]map[int32]int64
+	abortedTransactions map[string]map[int32][]*AbortedTransaction
+	topicIDs            map[string]Uuid
+	t                   TestReporter
+	batchSize           int
+	version             int16
+}
+
+// MockFetchRecord is a record returned by MockFetchResponse in a v2 record
+// batch of its own, see SetRecord.
+type MockFetchRecord struct {
+	Key       Encoder
+	Value     Encoder
+	Headers   []*RecordHeader
+	Timestamp time.Time
+	// ProducerID and ProducerEpoch are the producer of the batch, which is
+	// part of a transaction if IsTransactional is set.
+	ProducerID      int64
+	ProducerEpoch   int16
+	IsTransactional bool
+}
+
+// mockFetchControlRecord is a transaction marker set with SetControlRecord
+type mockFetchControlRecord struct {
+	producerID int64
+	recordType ControlRecordType
+	timestamp  time.Time
 }
 
 func NewMockFetchResponse(t TestReporter, batchSize int) *MockFetchResponse {
 	return &MockFetchResponse{
-		messages:       make(map[string]map[int32]map[int64]Encoder),
+		messages:       make(map[string]map[int32]map[int64]interface{}),
 		messagesLock:   &sync.RWMutex{},
 		highWaterMarks: make(map[string]map[int32]int64),
 		t:              t,
@@ -288,22 +463,83 @@ func (mfr *MockFetchResponse) SetVersion(version int16) *MockFetchResponse {
 }
 
 func (mfr *MockFetchResponse) SetMessage(topic string, partition int32, offset int64, msg Encoder) *MockFetchResponse {
+	return mfr.setMessage(topic, partition, offset, msg)
+}
+
+// SetRecord sets the record at the offset, which is returned from version 4
+// in a v2 record batch with its headers, timestamp and producer, and as a
+// legacy message of its key and value before.
+func (mfr *MockFetchResponse) SetRecord(topic string, partition int32, offset int64, record *MockFetchRecord) *MockFetchResponse {
+	return mfr.setMessage(topic, partition, offset, record)
+}
+
+// SetControlRecord sets the commit or abort marker of a transaction of the
+// producer at the offset, which is returned from version 4 only.
+func (mfr *MockFetchResponse) SetControlRecord(topic string, partition int32, offset int64, producerID int64, recordType ControlRecordType, timestamp time.Time) *MockFetchResponse {
+	return mfr.setMessage(topic, partition, offset, &mockFetchControlRecord{
+		producerID: producerID,
+		recordType: recordType,
+		timestamp:  timestamp,
+	})
+}
+
+func (mfr *MockFetchResponse) setMessage(topic string, partition int32, offset int64, msg interface{}) *MockFetchResponse {
 	mfr.messagesLock.Lock()
 	defer mfr.messagesLock.Unlock()
 	partitions := mfr.messages[topic]
 	if partitions == nil {
-		partitions = make(map[int32]map[int64]Encoder)
+		partitions = make(map[int32]map[int64]interface{})
 		mfr.messages[topic] = partitions
 	}
 	messages := partitions[partition]
 	if messages == nil {
-		messages = make(map[int64]Encoder)
+		messages = make(map[int64]interface{})
 		partitions[partition] = messages
 	}
 	messages[offset] = msg
 	return mfr
 }
 
+// SetAbortedTransaction adds a transaction of the producer starting at
+// firstOffset to the aborted transactions of the partition, returned from
+// version 4 to the consumers reading committed messages.
+func (mfr *MockFetchResponse) SetAbortedTransaction(topic string, partition int32, producerID int64, firstOffset int64) *MockFetchResponse {
+	if mfr.abortedTransactions == nil {
+		mfr.abortedTransactions = make(map[string]map[int32][]*AbortedTransaction)
+	}
+	partitions := mfr.abortedTransactions[topic]
+	if partitions == nil {
+		partitions = make(map[int32][]*AbortedTransaction)
+		mfr.abortedTransactions[topic] = partitions
+	}
+	partitions[partition] = append(partitions[partition], &AbortedTransaction{ProducerID: producerID, FirstOffset: firstOffset})
+	return mfr
+}
+
+// SetLastStableOffset sets the last stable offset of the partition returned
+// from version 4, which defaults to its high water mark.
+func (mfr *MockFetchResponse) SetLastStableOffset(topic string, partition int32, offset int64) *MockFetchResponse {
+	if mfr.lastStableOffsets == nil {
+		mfr.lastStableOffsets = make(map[string]map[int32]int64)
+	}
+	partitions := mfr.lastStableOffsets[topic]
+	if partitions == nil {
+		partitions = make(map[int32]int64)
+		mfr.lastStableOffsets[topic] = partitions
+	}
+	partitions[partition] = offset
+	return mfr
+}
+
+// SetTopicID sets the ID of the topic, which addresses it from version 13
+func (mfr *MockFetchResponse) SetTopicID(topic string, id Uuid) *MockFetchResponse {
+	if mfr.topicIDs == nil {
+		mfr.topicIDs = make(map[string]Uuid)
+	}
+	mfr.topicIDs[topic] = id
+	return mfr
+}
+
 func (mfr *MockFetchResponse) SetHighWaterMark(topic string, partition int32, offset int64) *MockFetchResponse {
 	partitions := mfr.highWaterMarks[topic]
 	if partitions == nil {
@@ -317,17 +553,25 @@ func (mfr *MockFetchResponse) SetHighWaterMark(topic string, partition int32, of
 func (mfr *MockFetchResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	fetchRequest := reqBody.(*FetchRequest)
 	res := &FetchResponse{
-		Version: mfr.version,
+		Version:  mfr.version,
+		TopicIDs: mfr.topicIDs,
 	}
 	for topic, partitions := range fetchRequest.blocks {
+		if id, ok := fetchRequest.TopicIDs[topic]; ok {
+			// the topics of a request addressing them by ID are named after
+			// the ID
+			for name, topicID := range mfr.topicIDs {
+				if topicID == id {
+					topic = name
+				}
+			}
+		}
 		for partition, block := range partitions {
 			initialOffset := block.fetchOffset
 			offset := initialOffset
 			maxOffset := initialOffset + int64(mfr.getMessageCount(topic, partition))
 			for i := 0; i < mfr.batchSize && offset < maxOffset; {
-				msg := mfr.getMessage(topic, partition, offset)
-				if msg != nil {
-					res.AddMessage(topic, partition, nil, msg, offset)
+				if mfr.addMessage(res, topic, partition, offset) {
 					i++
 				}
 				offset++
@@ -338,12 +582,54 @@ func (mfr *MockFetchResponse) For(reqBody versionedDecoder) encoderWithHeader {
 				fb = res.GetBlock(topic, partition)
 			}
 			fb.HighWaterMarkOffset = mfr.getHighWaterMark(topic, partition)
+			if res.Version >= 4 {
+				fb.LastStableOffset = fb.HighWaterMarkOffset
+				if lso, ok := mfr.lastStableOffsets[topic][partition]; ok {
+					fb.LastStableOffset = lso
+				}
+				fb.AbortedTransactions = mfr.abortedTransactions[topic][partition]
+			}
 		}
 	}
 	return res
 }
 
-func (mfr *MockFetchResponse) getMessage(topic string, partition int32, offset int64) Encoder {
+// addMessage adds the message at the offset to the response, if any
+func (mfr *MockFetchResponse) addMessage(res *FetchResponse, topic string, partition int32, offset int64) bool {
+	switch msg := mfr.getMessage(topic, partition, offset).(type) {
+	case Encoder:
+		res.AddMessage(topic, partition, nil, msg, offset)
+	case *MockFetchRecord:
+		if res.Version < 4 {
+			res.AddMessageWithTimestamp(topic, partition, msg.Key, msg.Value, offset, msg.Timestamp, 0)
+			break
+		}
+		key, value := encodeKV(msg.Key, msg.Value)
+		batch := &RecordBatch{
+			Version:         2,
+			FirstOffset:     offset,
+			FirstTimestamp:  msg.Timestamp,
+			MaxTimestamp:    msg.Timestamp,
+			ProducerID:      msg.ProducerID,
+			ProducerEpoch:   msg.ProducerEpoch,
+			IsTransactional: msg.IsTransactional,
+		}
+		batch.addRecord(&Record{Key: key, Value: value, Headers: msg.Headers})
+		records := newDefaultRecords(batch)
+		fb := res.getOrCreateBlock(topic, partition)
+		fb.RecordsSet = append(fb.RecordsSet, &records)
+	case *mockFetchControlRecord:
+		if res.Version < 4 {
+			return false
+		}
+		res.AddControlRecordWithTimestamp(topic, partition, offset, msg.producerID, msg.recordType, msg.timestamp)
+	default:
+		return false
+	}
+	return true
+}
+
+func (mfr *MockFetchResponse) getMessage(topic string, partition int32, offset int64) interface{} {
 	mfr.messagesLock.RLock()
 	defer mfr.messagesLock.RUnlock()
 	partitions := mfr.messages[topic]
@@ -453,7 +739,7 @@ func (mr *MockFindCoordinatorResponse) SetError(coordinatorType CoordinatorType,
 
 func (mr *MockFindCoordinatorResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	req := reqBody.(*FindCoordinatorRequest)
-	res := &FindCoordinatorResponse{}
+	res := &FindCoordinatorResponse{Version: req.Version}
 	var v interface{}
 	switch req.CoordinatorType {
 	case CoordinatorGroup:
@@ -501,7 +787,7 @@ func (mr *MockOffsetCommitResponse) SetError(group, topic string, partition int3
 func (mr *MockOffsetCommitResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	req := reqBody.(*OffsetCommitRequest)
 	group := req.ConsumerGroup
-	res := &OffsetCommitResponse{}
+	res := &OffsetCommitResponse{Version: req.Version}
 	for topic, partitions := range req.blocks {
 		for partition := range partitions {
 			res.AddError(topic, partition, mr.getError(group, topic, partition))
@@ -609,6 +895,12 @@ func (mr *MockOffsetFetchResponse) SetOffset(group, topic string, partition int3
 	return mr
 }
 
+func (mr *MockOffsetFetchResponse) SetOffsetWithLeaderEpoch(group, topic string, partition int32, offset int64, leaderEpoch int32, metadata string, kerror KError) *MockOffsetFetchResponse {
+	mr.SetOffset(group, topic, partition, offset, metadata, kerror)
+	mr.offsets[group][topic][partition].LeaderEpoch = leaderEpoch
+	return mr
+}
+
 func (mr *MockOffsetFetchResponse) SetError(kerror KError) *MockOffsetFetchResponse {
 	mr.error = kerror
 	return mr
@@ -616,9 +908,23 @@ func (mr *MockOffsetFetchResponse) SetError(kerror KError) *MockOffsetFetchRespo
 
 func (mr *MockOffsetFetchResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	req := reqBody.(*OffsetFetchRequest)
-	group := req.ConsumerGroup
 	res := &OffsetFetchResponse{Version: req.Version}
 
+	if req.Version >= 8 {
+		res.Groups = make(map[string]*OffsetFetchResponseGroup, len(req.groups))
+		for group := range req.groups {
+			res.Groups[group] = &OffsetFetchResponseGroup{Err: mr.error}
+			for topic, partitions := range mr.offsets[group] {
+				for partition, block := range partitions {
+					res.AddGroupBlock(group, topic, partition, block)
+				}
+			}
+		}
+		return res
+	}
+
+	group := req.ConsumerGroup
+
 	for topic, partitions := range mr.offsets[group] {
 		for partition, block := range partitions {
 			res.AddBlock(topic, partition, block)
@@ -661,21 +967,38 @@ func (mr *MockCreateTopicsResponse) For(reqBody versionedDecoder) encoderWithHea
 }
 
 type MockDeleteTopicsResponse struct {
-	t TestReporter
+	t        TestReporter
+	topicIDs map[Uuid]string
 }
 
 func NewMockDeleteTopicsResponse(t TestReporter) *MockDeleteTopicsResponse {
-	return &MockDeleteTopicsResponse{t: t}
+	return &MockDeleteTopicsResponse{t: t, topicIDs: make(map[Uuid]string)}
+}
+
+func (mr *MockDeleteTopicsResponse) SetTopicID(topic string, id Uuid) *MockDeleteTopicsResponse {
+	mr.topicIDs[id] = topic
+	return mr
 }
 
 func (mr *MockDeleteTopicsResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	req := reqBody.(*DeleteTopicsRequest)
 	res := &DeleteTopicsResponse{}
 	res.TopicErrorCodes = make(map[string]KError)
+	res.TopicIDs = make(map[string]Uuid)
+	res.TopicIDErrorCodes = make(map[Uuid]KError)
 
 	for _, topic := range req.Topics {
 		res.TopicErrorCodes[topic] = ErrNoError
 	}
+	for _, id := range req.TopicIDs {
+		topic, ok := mr.topicIDs[id]
+		if !ok {
+			res.TopicIDErrorCodes[id] = ErrUnknownTopicID
+			continue
+		}
+		res.TopicErrorCodes[topic] = ErrNoError
+		res.TopicIDs[topic] = id
+	}
 	res.Version = req.Version
 	return res
 }
@@ -759,8 +1082,8 @@ func (mr *MockDeleteRecordsResponse) For(reqBody versionedDecoder) encoderWithHe
 
 	for topic, deleteRecordRequestTopic := range req.Topics {
 		partitions := make(map[int32]*DeleteRecordsResponsePartition)
-		for partition := range deleteRecordRequestTopic.PartitionOffsets {
-			partitions[partition] = &DeleteRecordsResponsePartition{Err: ErrNoError}
+		for partition, offset := range deleteRecordRequestTopic.PartitionOffsets {
+			partitions[partition] = &DeleteRecordsResponsePartition{LowWatermark: offset, Err: ErrNoError}
 		}
 		res.Topics[topic] = &DeleteRecordsResponseTopic{Partitions: partitions}
 	}
@@ -768,11 +1091,35 @@ func (mr *MockDeleteRecordsResponse) For(reqBody versionedDecoder) encoderWithHe
 }
 
 type MockDescribeConfigsResponse struct {
-	t TestReporter
+	t             TestReporter
+	topicConfigs  map[string]map[string]string
+	brokerConfigs map[string][]*ConfigEntry
 }
 
 func NewMockDescribeConfigsResponse(t TestReporter) *MockDescribeConfigsResponse {
-	return &MockDescribeConfigsResponse{t: t}
+	return &MockDescribeConfigsResponse{
+		t:             t,
+		topicConfigs:  make(map[string]map[string]string),
+		brokerConfigs: make(map[string][]*ConfigEntry),
+	}
+}
+
+// SetBrokerConfig adds a config entry to the configs returned for the broker
+// resource, use an empty broker for the cluster-wide defaults
+func (mr *MockDescribeConfigsResponse) SetBrokerConfig(broker, name, value string, source ConfigSource) *MockDescribeConfigsResponse {
+	mr.brokerConfigs[broker] = append(mr.brokerConfigs[broker], &ConfigEntry{Name: name, Value: value, Source: source})
+	return mr
+}
+
+// SetTopicConfig adds a config entry to the configs returned for the topic
+func (mr *MockDescribeConfigsResponse) SetTopicConfig(topic, name, value string) *MockDescribeConfigsResponse {
+	configs := mr.topicConfigs[topic]
+	if configs == nil {
+		configs = make(map[string]string)
+		mr.topicConfigs[topic] = configs
+	}
+	configs[name] = value
+	return mr
 }
 
 func (mr *MockDescribeConfigsResponse) For(reqBody versionedDecoder) encoderWithHeader {
@@ -796,6 +1143,7 @@ func (mr *MockDescribeConfigsResponse) For(reqBody versionedDecoder) encoderWith
 					Default:  false,
 				},
 			)
+			configEntries = append(configEntries, mr.brokerConfigs[r.Name]...)
 			res.Resources = append(res.Resources, &ResourceResponse{
 				Name:    r.Name,
 				Configs: configEntries,
@@ -856,6 +1204,9 @@ func (mr *MockDescribeConfigsResponse) For(reqBody versionedDecoder) encoderWith
 			}
 			configEntries = append(
 				configEntries, maxMessageBytes, retentionMs, password)
+			for name, value := range mr.topicConfigs[r.Name] {
+				configEntries = append(configEntries, &ConfigEntry{Name: name, Value: value})
+			}
 			res.Resources = append(res.Resources, &ResourceResponse{
 				Name:    r.Name,
 				Configs: configEntries,
@@ -999,17 +1350,30 @@ func (mr *MockCreateAclsResponse) For(reqBody versionedDecoder) encoderWithHeade
 }
 
 type MockListAclsResponse struct {
-	t TestReporter
+	resourceAcls []*ResourceAcls
+	t            TestReporter
 }
 
 func NewMockListAclsResponse(t TestReporter) *MockListAclsResponse {
 	return &MockListAclsResponse{t: t}
 }
 
+// AddResourceAcls makes the response return the given ACLs instead of
+// echoing the request filter
+func (mr *MockListAclsResponse) AddResourceAcls(resource Resource, acls ...*Acl) *MockListAclsResponse {
+	mr.resourceAcls = append(mr.resourceAcls, &ResourceAcls{Resource: resource, Acls: acls})
+	return mr
+}
+
 func (mr *MockListAclsResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	req := reqBody.(*DescribeAclsRequest)
 	res := &DescribeAclsResponse{}
 	res.Err = ErrNoError
+	if mr.resourceAcls != nil {
+		res.ResourceAcls = mr.resourceAcls
+		res.Version = int16(req.Version)
+		return res
+	}
 	acl := &ResourceAcls{}
 	if req.ResourceName != nil {
 		acl.Resource.ResourceName = *req.ResourceName
@@ -1039,9 +1403,10 @@ func (mr *MockListAclsResponse) For(reqBody versionedDecoder) encoderWithHeader
 }
 
 type MockSaslAuthenticateResponse struct {
-	t             TestReporter
-	kerror        KError
-	saslAuthBytes []byte
+	t                 TestReporter
+	kerror            KError
+	saslAuthBytes     []byte
+	sessionLifetimeMs int64
 }
 
 func NewMockSaslAuthenticateResponse(t TestReporter) *MockSaslAuthenticateResponse {
@@ -1049,9 +1414,12 @@ func NewMockSaslAuthenticateResponse(t TestReporter) *MockSaslAuthenticateRespon
 }
 
 func (msar *MockSaslAuthenticateResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*SaslAuthenticateRequest)
 	res := &SaslAuthenticateResponse{}
+	res.Version = req.Version
 	res.Err = msar.kerror
 	res.SaslAuthBytes = msar.saslAuthBytes
+	res.SessionLifetimeMs = msar.sessionLifetimeMs
 	return res
 }
 
@@ -1065,6 +1433,11 @@ func (msar *MockSaslAuthenticateResponse) SetAuthBytes(saslAuthBytes []byte) *Mo
 	return msar
 }
 
+func (msar *MockSaslAuthenticateResponse) SetSessionLifetimeMs(sessionLifetimeMs int64) *MockSaslAuthenticateResponse {
+	msar.sessionLifetimeMs = sessionLifetimeMs
+	return msar
+}
+
 type MockDeleteAclsResponse struct {
 	t TestReporter
 }
@@ -1241,16 +1614,29 @@ func (m *MockJoinGroupResponse) SetMember(id string, meta *ConsumerGroupMemberMe
 type MockLeaveGroupResponse struct {
 	t TestReporter
 
-	Err KError
+	Err          KError
+	MemberErrors map[string]KError
 }
 
 func NewMockLeaveGroupResponse(t TestReporter) *MockLeaveGroupResponse {
-	return &MockLeaveGroupResponse{t: t}
+	return &MockLeaveGroupResponse{t: t, MemberErrors: make(map[string]KError)}
 }
 
 func (m *MockLeaveGroupResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*LeaveGroupRequest)
 	resp := &LeaveGroupResponse{
-		Err: m.Err,
+		Version: req.Version,
+		Err:     m.Err,
+	}
+	for _, member := range req.Members {
+		memberResp := MemberResponse{
+			MemberId:        member.MemberId,
+			GroupInstanceId: member.GroupInstanceId,
+		}
+		if member.GroupInstanceId != nil {
+			memberResp.Err = m.MemberErrors[*member.GroupInstanceId]
+		}
+		resp.Members = append(resp.Members, memberResp)
 	}
 	return resp
 }
@@ -1260,6 +1646,13 @@ func (m *MockLeaveGroupResponse) SetError(kerr KError) *MockLeaveGroupResponse {
 	return m
 }
 
+// SetMemberError sets the error returned for the static member with the given
+// group instance ID by v3+ requests
+func (m *MockLeaveGroupResponse) SetMemberError(groupInstanceID string, kerr KError) *MockLeaveGroupResponse {
+	m.MemberErrors[groupInstanceID] = kerr
+	return m
+}
+
 type MockSyncGroupResponse struct {
 	t TestReporter
 
@@ -1272,7 +1665,9 @@ func NewMockSyncGroupResponse(t TestReporter) *MockSyncGroupResponse {
 }
 
 func (m *MockSyncGroupResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*SyncGroupRequest)
 	resp := &SyncGroupResponse{
+		Version:          req.Version,
 		Err:              m.Err,
 		MemberAssignment: m.MemberAssignment,
 	}
@@ -1304,7 +1699,8 @@ func NewMockHeartbeatResponse(t TestReporter) *MockHeartbeatResponse {
 }
 
 func (m *MockHeartbeatResponse) For(reqBody versionedDecoder) encoderWithHeader {
-	resp := &HeartbeatResponse{}
+	req := reqBody.(*HeartbeatRequest)
+	resp := &HeartbeatResponse{Version: req.Version}
 	return resp
 }
 
@@ -1356,8 +1752,11 @@ func (m *MockDescribeLogDirsResponse) For(reqBody versionedDecoder) encoderWithH
 }
 
 type MockApiVersionsResponse struct {
-	t       TestReporter
-	apiKeys []ApiVersionsResponseKey
+	t                      TestReporter
+	apiKeys                []ApiVersionsResponseKey
+	supportedFeatures      []ApiVersionsResponseSupportedFeature
+	finalizedFeaturesEpoch int64
+	finalizedFeatures      []ApiVersionsResponseFinalizedFeature
 }
 
 func NewMockApiVersionsResponse(t TestReporter) *MockApiVersionsResponse {
@@ -1383,11 +1782,798 @@ func (m *MockApiVersionsResponse) SetApiKeys(apiKeys []ApiVersionsResponseKey) *
 	return m
 }
 
+func (m *MockApiVersionsResponse) SetSupportedFeature(name string, minVersion, maxVersion int16) *MockApiVersionsResponse {
+	m.supportedFeatures = append(m.supportedFeatures, ApiVersionsResponseSupportedFeature{
+		Name:       name,
+		MinVersion: minVersion,
+		MaxVersion: maxVersion,
+	})
+	return m
+}
+
+func (m *MockApiVersionsResponse) SetFinalizedFeature(name string, minVersionLevel, maxVersionLevel int16) *MockApiVersionsResponse {
+	m.finalizedFeatures = append(m.finalizedFeatures, ApiVersionsResponseFinalizedFeature{
+		Name:            name,
+		MinVersionLevel: minVersionLevel,
+		MaxVersionLevel: maxVersionLevel,
+	})
+	return m
+}
+
+func (m *MockApiVersionsResponse) SetFinalizedFeaturesEpoch(epoch int64) *MockApiVersionsResponse {
+	m.finalizedFeaturesEpoch = epoch
+	return m
+}
+
 func (m *MockApiVersionsResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	req := reqBody.(*ApiVersionsRequest)
 	res := &ApiVersionsResponse{
-		Version: req.Version,
-		ApiKeys: m.apiKeys,
+		Version:                req.Version,
+		ApiKeys:                m.apiKeys,
+		SupportedFeatures:      m.supportedFeatures,
+		FinalizedFeaturesEpoch: m.finalizedFeaturesEpoch,
+		FinalizedFeatures:      m.finalizedFeatures,
+	}
+	return res
+}
+
+type MockDescribeClusterResponse struct {
+	t            TestReporter
+	clusterID    string
+	controllerID int32
+	brokers      []*DescribeClusterBroker
+	operations   int32
+}
+
+func NewMockDescribeClusterResponse(t TestReporter) *MockDescribeClusterResponse {
+	return &MockDescribeClusterResponse{t: t, operations: math.MinInt32}
+}
+
+func (m *MockDescribeClusterResponse) SetClusterID(clusterID string) *MockDescribeClusterResponse {
+	m.clusterID = clusterID
+	return m
+}
+
+func (m *MockDescribeClusterResponse) SetController(brokerID int32) *MockDescribeClusterResponse {
+	m.controllerID = brokerID
+	return m
+}
+
+func (m *MockDescribeClusterResponse) SetBroker(addr string, brokerID int32, rack *string) *MockDescribeClusterResponse {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	port, err := strconv.ParseInt(portStr, 10, 32)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	m.brokers = append(m.brokers, &DescribeClusterBroker{
+		BrokerID: brokerID,
+		Host:     host,
+		Port:     int32(port),
+		Rack:     rack,
+	})
+	return m
+}
+
+func (m *MockDescribeClusterResponse) SetAuthorizedOperations(operations int32) *MockDescribeClusterResponse {
+	m.operations = operations
+	return m
+}
+
+func (m *MockDescribeClusterResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*DescribeClusterRequest)
+	res := &DescribeClusterResponse{
+		Version:                     req.Version,
+		ClusterID:                   m.clusterID,
+		ControllerID:                m.controllerID,
+		Brokers:                     m.brokers,
+		ClusterAuthorizedOperations: math.MinInt32,
+	}
+	if req.IncludeClusterAuthorizedOperations {
+		res.ClusterAuthorizedOperations = m.operations
+	}
+	return res
+}
+
+type MockDescribeProducersResponse struct {
+	t         TestReporter
+	producers map[string]map[int32][]*ProducerState
+}
+
+func NewMockDescribeProducersResponse(t TestReporter) *MockDescribeProducersResponse {
+	return &MockDescribeProducersResponse{
+		t:         t,
+		producers: make(map[string]map[int32][]*ProducerState),
+	}
+}
+
+func (m *MockDescribeProducersResponse) SetProducer(topic string, partition int32, state *ProducerState) *MockDescribeProducersResponse {
+	partitions := m.producers[topic]
+	if partitions == nil {
+		partitions = make(map[int32][]*ProducerState)
+		m.producers[topic] = partitions
+	}
+	partitions[partition] = append(partitions[partition], state)
+	return m
+}
+
+func (m *MockDescribeProducersResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*DescribeProducersRequest)
+	res := &DescribeProducersResponse{Version: req.Version}
+	for _, t := range req.Topics {
+		topic := &DescribeProducersTopic{Name: t.Name}
+		for _, p := range t.PartitionIndexes {
+			topic.Partitions = append(topic.Partitions, &DescribeProducersPartition{
+				PartitionIndex:  p,
+				ErrorCode:       ErrNoError,
+				ActiveProducers: m.producers[t.Name][p],
+			})
+		}
+		res.Topics = append(res.Topics, topic)
+	}
+	return res
+}
+
+type MockListTransactionsResponse struct {
+	t            TestReporter
+	transactions []*TransactionListing
+}
+
+func NewMockListTransactionsResponse(t TestReporter) *MockListTransactionsResponse {
+	return &MockListTransactionsResponse{t: t}
+}
+
+func (m *MockListTransactionsResponse) AddTransaction(transactionalID string, producerID int64, state string) *MockListTransactionsResponse {
+	m.transactions = append(m.transactions, &TransactionListing{
+		TransactionalID:  transactionalID,
+		ProducerID:       producerID,
+		TransactionState: state,
+	})
+	return m
+}
+
+func (m *MockListTransactionsResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*ListTransactionsRequest)
+	res := &ListTransactionsResponse{Version: req.Version}
+	states := make(map[string]bool, len(req.StateFilters))
+	for _, state := range req.StateFilters {
+		states[state] = true
+	}
+	producerIDs := make(map[int64]bool, len(req.ProducerIDFilters))
+	for _, id := range req.ProducerIDFilters {
+		producerIDs[id] = true
+	}
+	for _, t := range m.transactions {
+		if len(states) > 0 && !states[t.TransactionState] {
+			continue
+		}
+		if len(producerIDs) > 0 && !producerIDs[t.ProducerID] {
+			continue
+		}
+		res.TransactionStates = append(res.TransactionStates, t)
+	}
+	return res
+}
+
+type MockDescribeTransactionsResponse struct {
+	t            TestReporter
+	transactions map[string]*TransactionDescription
+}
+
+func NewMockDescribeTransactionsResponse(t TestReporter) *MockDescribeTransactionsResponse {
+	return &MockDescribeTransactionsResponse{
+		t:            t,
+		transactions: make(map[string]*TransactionDescription),
+	}
+}
+
+func (m *MockDescribeTransactionsResponse) SetTransaction(description *TransactionDescription) *MockDescribeTransactionsResponse {
+	m.transactions[description.TransactionalID] = description
+	return m
+}
+
+func (m *MockDescribeTransactionsResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*DescribeTransactionsRequest)
+	res := &DescribeTransactionsResponse{Version: req.Version}
+	for _, id := range req.TransactionalIDs {
+		description, ok := m.transactions[id]
+		if !ok {
+			description = &TransactionDescription{
+				ErrorCode:            ErrTransactionalIDNotFound,
+				TransactionalID:      id,
+				TransactionStartTime: -1,
+				ProducerID:           -1,
+				ProducerEpoch:        -1,
+			}
+		}
+		res.TransactionStates = append(res.TransactionStates, description)
+	}
+	return res
+}
+
+type MockConsumerGroupDescribeResponse struct {
+	t      TestReporter
+	groups map[string]*ConsumerGroupDescribeGroup
+}
+
+func NewMockConsumerGroupDescribeResponse(t TestReporter) *MockConsumerGroupDescribeResponse {
+	return &MockConsumerGroupDescribeResponse{
+		t:      t,
+		groups: make(map[string]*ConsumerGroupDescribeGroup),
+	}
+}
+
+func (m *MockConsumerGroupDescribeResponse) SetGroup(group *ConsumerGroupDescribeGroup) *MockConsumerGroupDescribeResponse {
+	m.groups[group.GroupID] = group
+	return m
+}
+
+func (m *MockConsumerGroupDescribeResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*ConsumerGroupDescribeRequest)
+	res := &ConsumerGroupDescribeResponse{Version: req.Version}
+	for _, id := range req.GroupIDs {
+		group, ok := m.groups[id]
+		if !ok {
+			group = &ConsumerGroupDescribeGroup{
+				ErrorCode:            ErrGroupIDNotFound,
+				GroupID:              id,
+				GroupState:           "Dead",
+				AuthorizedOperations: math.MinInt32,
+			}
+		}
+		res.Groups = append(res.Groups, group)
+	}
+	return res
+}
+
+type MockUnregisterBrokerResponse struct {
+	t      TestReporter
+	errors map[int32]KError
+}
+
+func NewMockUnregisterBrokerResponse(t TestReporter) *MockUnregisterBrokerResponse {
+	return &MockUnregisterBrokerResponse{t: t, errors: make(map[int32]KError)}
+}
+
+func (m *MockUnregisterBrokerResponse) SetError(brokerID int32, kerror KError) *MockUnregisterBrokerResponse {
+	m.errors[brokerID] = kerror
+	return m
+}
+
+func (m *MockUnregisterBrokerResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*UnregisterBrokerRequest)
+	res := &UnregisterBrokerResponse{Version: req.Version}
+	if kerror, ok := m.errors[req.BrokerID]; ok {
+		res.ErrorCode = kerror
+	}
+	return res
+}
+
+// MockRaftVoterResponse keeps the voters of a KRaft controller quorum in
+// memory. It answers AddRaftVoterRequest and RemoveRaftVoterRequest so that
+// the same instance should be registered for both of them.
+type MockRaftVoterResponse struct {
+	t      TestReporter
+	voters map[int32]Uuid
+}
+
+func NewMockRaftVoterResponse(t TestReporter) *MockRaftVoterResponse {
+	return &MockRaftVoterResponse{t: t, voters: make(map[int32]Uuid)}
+}
+
+// SetVoter adds a voter to the quorum
+func (m *MockRaftVoterResponse) SetVoter(voterID int32, voterDirectoryID Uuid) *MockRaftVoterResponse {
+	m.voters[voterID] = voterDirectoryID
+	return m
+}
+
+func (m *MockRaftVoterResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	switch req := reqBody.(type) {
+	case *AddRaftVoterRequest:
+		res := &AddRaftVoterResponse{Version: req.Version}
+		if _, ok := m.voters[req.VoterID]; ok {
+			res.ErrorCode = ErrDuplicateVoter
+			return res
+		}
+		m.voters[req.VoterID] = req.VoterDirectoryID
+		return res
+	case *RemoveRaftVoterRequest:
+		res := &RemoveRaftVoterResponse{Version: req.Version}
+		if id, ok := m.voters[req.VoterID]; !ok || id != req.VoterDirectoryID {
+			res.ErrorCode = ErrVoterNotFound
+			return res
+		}
+		delete(m.voters, req.VoterID)
+		return res
+	default:
+		m.t.Errorf("unexpected request %T for MockRaftVoterResponse", reqBody)
+		return nil
+	}
+}
+
+// MockTelemetryResponse subscribes the clients to the metrics and keeps the
+// metrics they push in memory. It answers GetTelemetrySubscriptionsRequest and
+// PushTelemetryRequest so that the same instance should be registered for both
+// of them.
+type MockTelemetryResponse struct {
+	t            TestReporter
+	subscription GetTelemetrySubscriptionsResponse
+	instances    int
+
+	lock   sync.Mutex
+	pushes []*PushTelemetryRequest
+}
+
+func NewMockTelemetryResponse(t TestReporter) *MockTelemetryResponse {
+	return &MockTelemetryResponse{
+		t: t,
+		subscription: GetTelemetrySubscriptionsResponse{
+			SubscriptionID:   1,
+			PushInterval:     time.Minute,
+			RequestedMetrics: []string{"*"},
+		},
+	}
+}
+
+// SetSubscription sets the metrics requested from the clients and how often
+// they are pushed, which changes the subscription ID
+func (m *MockTelemetryResponse) SetSubscription(requestedMetrics []string, pushInterval time.Duration, deltaTemporality bool) *MockTelemetryResponse {
+	m.subscription.SubscriptionID++
+	m.subscription.RequestedMetrics = requestedMetrics
+	m.subscription.PushInterval = pushInterval
+	m.subscription.DeltaTemporality = deltaTemporality
+	return m
+}
+
+// SetAcceptedCompressionTypes sets the compression types accepted for the
+// metrics pushed
+func (m *MockTelemetryResponse) SetAcceptedCompressionTypes(codecs ...CompressionCodec) *MockTelemetryResponse {
+	m.subscription.AcceptedCompressionTypes = codecs
+	return m
+}
+
+// Pushes returns the requests with which the metrics were pushed
+func (m *MockTelemetryResponse) Pushes() []*PushTelemetryRequest {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]*PushTelemetryRequest(nil), m.pushes...)
+}
+
+func (m *MockTelemetryResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	switch req := reqBody.(type) {
+	case *GetTelemetrySubscriptionsRequest:
+		res := m.subscription
+		res.Version = req.Version
+		res.ClientInstanceID = req.ClientInstanceID
+		if res.ClientInstanceID == NullUuid {
+			m.instances++
+			res.ClientInstanceID = Uuid{15: byte(m.instances)}
+		}
+		return &res
+	case *PushTelemetryRequest:
+		res := &PushTelemetryResponse{Version: req.Version}
+		if req.SubscriptionID != m.subscription.SubscriptionID {
+			res.ErrorCode = ErrUnknownSubscriptionID
+			return res
+		}
+		m.lock.Lock()
+		m.pushes = append(m.pushes, req)
+		m.lock.Unlock()
+		return res
+	default:
+		m.t.Errorf("unexpected request %T for MockTelemetryResponse", reqBody)
+		return nil
+	}
+}
+
+type MockUpdateFeaturesResponse struct {
+	t      TestReporter
+	errors map[string]KError
+}
+
+func NewMockUpdateFeaturesResponse(t TestReporter) *MockUpdateFeaturesResponse {
+	return &MockUpdateFeaturesResponse{t: t, errors: make(map[string]KError)}
+}
+
+func (m *MockUpdateFeaturesResponse) SetError(feature string, kerror KError) *MockUpdateFeaturesResponse {
+	m.errors[feature] = kerror
+	return m
+}
+
+func (m *MockUpdateFeaturesResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*UpdateFeaturesRequest)
+	res := &UpdateFeaturesResponse{Version: req.Version}
+	for _, u := range req.FeatureUpdates {
+		result := &UpdatableFeatureResult{Feature: u.Feature}
+		if kerror, ok := m.errors[u.Feature]; ok {
+			result.ErrorCode = kerror
+		}
+		res.Results = append(res.Results, result)
+	}
+	return res
+}
+
+type MockElectLeadersResponse struct {
+	t      TestReporter
+	errors map[string]map[int32]KError
+}
+
+func NewMockElectLeadersResponse(t TestReporter) *MockElectLeadersResponse {
+	return &MockElectLeadersResponse{t: t, errors: make(map[string]map[int32]KError)}
+}
+
+func (m *MockElectLeadersResponse) SetError(topic string, partition int32, kerror KError) *MockElectLeadersResponse {
+	partitions := m.errors[topic]
+	if partitions == nil {
+		partitions = make(map[int32]KError)
+		m.errors[topic] = partitions
+	}
+	partitions[partition] = kerror
+	return m
+}
+
+func (m *MockElectLeadersResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*ElectLeadersRequest)
+	res := &ElectLeadersResponse{
+		Version:                req.Version,
+		ReplicaElectionResults: make(map[string]map[int32]*PartitionResult),
+	}
+	for topic, partitions := range req.TopicPartitions {
+		results := make(map[int32]*PartitionResult, len(partitions))
+		for _, p := range partitions {
+			results[p] = &PartitionResult{ErrorCode: m.errors[topic][p]}
+		}
+		res.ReplicaElectionResults[topic] = results
+	}
+	return res
+}
+
+type MockAlterReplicaLogDirsResponse struct {
+	t      TestReporter
+	errors map[string]map[int32]KError
+}
+
+func NewMockAlterReplicaLogDirsResponse(t TestReporter) *MockAlterReplicaLogDirsResponse {
+	return &MockAlterReplicaLogDirsResponse{t: t, errors: make(map[string]map[int32]KError)}
+}
+
+func (m *MockAlterReplicaLogDirsResponse) SetError(topic string, partition int32, kerror KError) *MockAlterReplicaLogDirsResponse {
+	partitions := m.errors[topic]
+	if partitions == nil {
+		partitions = make(map[int32]KError)
+		m.errors[topic] = partitions
+	}
+	partitions[partition] = kerror
+	return m
+}
+
+func (m *MockAlterReplicaLogDirsResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*AlterReplicaLogDirsRequest)
+	res := &AlterReplicaLogDirsResponse{Version: req.Version}
+	for _, d := range req.Dirs {
+		for _, t := range d.Topics {
+			result := AlterReplicaLogDirsResponseTopic{Topic: t.Topic}
+			for _, p := range t.PartitionIDs {
+				result.Partitions = append(result.Partitions, AlterReplicaLogDirsResponsePartition{
+					PartitionID: p,
+					ErrorCode:   m.errors[t.Topic][p],
+				})
+			}
+			res.Results = append(res.Results, result)
+		}
+	}
+	return res
+}
+
+// MockDelegationTokenResponse keeps the delegation tokens of a mock broker in
+// memory. It answers CreateDelegationTokenRequest, RenewDelegationTokenRequest,
+// ExpireDelegationTokenRequest and DescribeDelegationTokenRequest so that the
+// same instance should be registered for all of them.
+type MockDelegationTokenResponse struct {
+	t      TestReporter
+	owner  DelegationTokenPrincipal
+	tokens []*DelegationToken
+}
+
+func NewMockDelegationTokenResponse(t TestReporter) *MockDelegationTokenResponse {
+	return &MockDelegationTokenResponse{
+		t:     t,
+		owner: DelegationTokenPrincipal{Type: "User", Name: "ANONYMOUS"},
+	}
+}
+
+// SetOwner sets the principal owning the tokens created by the mock
+func (m *MockDelegationTokenResponse) SetOwner(principalType, name string) *MockDelegationTokenResponse {
+	m.owner = DelegationTokenPrincipal{Type: principalType, Name: name}
+	return m
+}
+
+func (m *MockDelegationTokenResponse) findToken(hmac []byte) *DelegationToken {
+	for _, token := range m.tokens {
+		if string(token.HMAC) == string(hmac) {
+			return token
+		}
+	}
+	return nil
+}
+
+func (m *MockDelegationTokenResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	now := time.Now().Truncate(time.Millisecond)
+	switch req := reqBody.(type) {
+	case *CreateDelegationTokenRequest:
+		maxLifetime := req.MaxLifetime
+		if maxLifetime < 0 {
+			maxLifetime = 7 * 24 * time.Hour
+		}
+		token := &DelegationToken{
+			Owner:      m.owner,
+			IssueTime:  now,
+			ExpiryTime: now.Add(24 * time.Hour),
+			MaxTime:    now.Add(maxLifetime),
+			TokenID:    fmt.Sprintf("token-%d", len(m.tokens)),
+			HMAC:       []byte(fmt.Sprintf("hmac-%d", len(m.tokens))),
+			Renewers:   req.Renewers,
+		}
+		m.tokens = append(m.tokens, token)
+		res := &CreateDelegationTokenResponse{Version: req.Version, Token: *token}
+		res.Token.Renewers = nil
+		return res
+	case *RenewDelegationTokenRequest:
+		res := &RenewDelegationTokenResponse{Version: req.Version}
+		token := m.findToken(req.HMAC)
+		switch {
+		case token == nil:
+			res.ErrorCode = ErrDelegationTokenNotFound
+		case !token.ExpiryTime.After(now):
+			res.ErrorCode = ErrDelegationTokenExpired
+		default:
+			renewPeriod := req.RenewPeriod
+			if renewPeriod < 0 {
+				renewPeriod = 24 * time.Hour
+			}
+			token.ExpiryTime = now.Add(renewPeriod)
+			if token.ExpiryTime.After(token.MaxTime) {
+				token.ExpiryTime = token.MaxTime
+			}
+			res.ExpiryTime = token.ExpiryTime
+		}
+		return res
+	case *ExpireDelegationTokenRequest:
+		res := &ExpireDelegationTokenResponse{Version: req.Version}
+		token := m.findToken(req.HMAC)
+		if token == nil {
+			res.ErrorCode = ErrDelegationTokenNotFound
+			return res
+		}
+		if req.ExpiryTimePeriod < 0 {
+			token.ExpiryTime = now
+		} else {
+			token.ExpiryTime = now.Add(req.ExpiryTimePeriod)
+		}
+		res.ExpiryTime = token.ExpiryTime
+		return res
+	case *DescribeDelegationTokenRequest:
+		res := &DescribeDelegationTokenResponse{Version: req.Version}
+		for _, token := range m.tokens {
+			if !token.ExpiryTime.After(now) {
+				continue
+			}
+			if req.Owners != nil {
+				owned := false
+				for _, owner := range req.Owners {
+					owned = owned || owner == token.Owner
+				}
+				if !owned {
+					continue
+				}
+			}
+			res.Tokens = append(res.Tokens, token)
+		}
+		return res
+	default:
+		m.t.Errorf("unexpected request %T for MockDelegationTokenResponse", reqBody)
+		return nil
+	}
+}
+
+// MockClientQuotasResponse keeps client quotas in memory. It answers
+// DescribeClientQuotasRequest and AlterClientQuotasRequest so that the same
+// instance should be registered for both of them. Describe filters are
+// ignored and all quotas are returned.
+type MockClientQuotasResponse struct {
+	t       TestReporter
+	entries []DescribeClientQuotasEntry
+}
+
+func NewMockClientQuotasResponse(t TestReporter) *MockClientQuotasResponse {
+	return &MockClientQuotasResponse{t: t}
+}
+
+// SetQuota sets a quota value of the given entity
+func (m *MockClientQuotasResponse) SetQuota(entity []QuotaEntityComponent, key string, value float64) *MockClientQuotasResponse {
+	m.entry(entity).Values[key] = value
+	return m
+}
+
+func (m *MockClientQuotasResponse) entry(entity []QuotaEntityComponent) *DescribeClientQuotasEntry {
+	for i := range m.entries {
+		if quotaEntityEqual(m.entries[i].Entity, entity) {
+			return &m.entries[i]
+		}
+	}
+	m.entries = append(m.entries, DescribeClientQuotasEntry{Entity: entity, Values: make(map[string]float64)})
+	return &m.entries[len(m.entries)-1]
+}
+
+func (m *MockClientQuotasResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	switch req := reqBody.(type) {
+	case *DescribeClientQuotasRequest:
+		res := &DescribeClientQuotasResponse{}
+		for _, entry := range m.entries {
+			if len(entry.Values) > 0 {
+				res.Entries = append(res.Entries, entry)
+			}
+		}
+		return res
+	case *AlterClientQuotasRequest:
+		res := &AlterClientQuotasResponse{}
+		for _, entry := range req.Entries {
+			if !req.ValidateOnly {
+				values := m.entry(entry.Entity).Values
+				for _, op := range entry.Ops {
+					if op.Remove {
+						delete(values, op.Key)
+					} else {
+						values[op.Key] = op.Value
+					}
+				}
+			}
+			res.Entries = append(res.Entries, AlterClientQuotasEntryResponse{Entity: entry.Entity})
+		}
+		return res
+	default:
+		m.t.Errorf("unexpected request %T for MockClientQuotasResponse", reqBody)
+		return nil
+	}
+}
+
+type MockInitProducerIDResponse struct {
+	t TestReporter
+
+	Err           KError
+	ProducerID    int64
+	ProducerEpoch int16
+}
+
+func NewMockInitProducerIDResponse(t TestReporter) *MockInitProducerIDResponse {
+	return &MockInitProducerIDResponse{t: t}
+}
+
+func (m *MockInitProducerIDResponse) SetError(kerr KError) *MockInitProducerIDResponse {
+	m.Err = kerr
+	return m
+}
+
+func (m *MockInitProducerIDResponse) SetProducerID(producerID int64, producerEpoch int16) *MockInitProducerIDResponse {
+	m.ProducerID = producerID
+	m.ProducerEpoch = producerEpoch
+	return m
+}
+
+func (m *MockInitProducerIDResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	res := &InitProducerIDResponse{Err: m.Err, ProducerID: -1, ProducerEpoch: -1}
+	if errors.Is(m.Err, ErrNoError) {
+		res.ProducerID = m.ProducerID
+		res.ProducerEpoch = m.ProducerEpoch
+	}
+	return res
+}
+
+type MockAddPartitionsToTxnResponse struct {
+	t      TestReporter
+	errors map[string]map[int32]KError
+}
+
+func NewMockAddPartitionsToTxnResponse(t TestReporter) *MockAddPartitionsToTxnResponse {
+	return &MockAddPartitionsToTxnResponse{t: t, errors: make(map[string]map[int32]KError)}
+}
+
+// SetError sets the error returned for the partition, the other requested
+// partitions being added without error
+func (m *MockAddPartitionsToTxnResponse) SetError(topic string, partition int32, kerr KError) *MockAddPartitionsToTxnResponse {
+	setMockPartitionError(m.errors, topic, partition, kerr)
+	return m
+}
+
+func (m *MockAddPartitionsToTxnResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*AddPartitionsToTxnRequest)
+	return &AddPartitionsToTxnResponse{Errors: mockPartitionErrors(req.TopicPartitions, m.errors)}
+}
+
+type MockAddOffsetsToTxnResponse struct {
+	t TestReporter
+
+	Err KError
+}
+
+func NewMockAddOffsetsToTxnResponse(t TestReporter) *MockAddOffsetsToTxnResponse {
+	return &MockAddOffsetsToTxnResponse{t: t}
+}
+
+func (m *MockAddOffsetsToTxnResponse) SetError(kerr KError) *MockAddOffsetsToTxnResponse {
+	m.Err = kerr
+	return m
+}
+
+func (m *MockAddOffsetsToTxnResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	return &AddOffsetsToTxnResponse{Err: m.Err}
+}
+
+type MockTxnOffsetCommitResponse struct {
+	t      TestReporter
+	errors map[string]map[int32]KError
+}
+
+func NewMockTxnOffsetCommitResponse(t TestReporter) *MockTxnOffsetCommitResponse {
+	return &MockTxnOffsetCommitResponse{t: t, errors: make(map[string]map[int32]KError)}
+}
+
+// SetError sets the error returned for the partition, the offsets of the
+// other requested partitions being committed without error
+func (m *MockTxnOffsetCommitResponse) SetError(topic string, partition int32, kerr KError) *MockTxnOffsetCommitResponse {
+	setMockPartitionError(m.errors, topic, partition, kerr)
+	return m
+}
+
+func (m *MockTxnOffsetCommitResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*TxnOffsetCommitRequest)
+	partitions := make(map[string][]int32, len(req.Topics))
+	for topic, offsets := range req.Topics {
+		for _, offset := range offsets {
+			partitions[topic] = append(partitions[topic], offset.Partition)
+		}
+	}
+	return &TxnOffsetCommitResponse{Topics: mockPartitionErrors(partitions, m.errors)}
+}
+
+type MockEndTxnResponse struct {
+	t TestReporter
+
+	Err KError
+}
+
+func NewMockEndTxnResponse(t TestReporter) *MockEndTxnResponse {
+	return &MockEndTxnResponse{t: t}
+}
+
+func (m *MockEndTxnResponse) SetError(kerr KError) *MockEndTxnResponse {
+	m.Err = kerr
+	return m
+}
+
+func (m *MockEndTxnResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	return &EndTxnResponse{Err: m.Err}
+}
+
+func setMockPartitionError(kerrs map[string]map[int32]KError, topic string, partition int32, kerr KError) {
+	partitions := kerrs[topic]
+	if partitions == nil {
+		partitions = make(map[int32]KError)
+		kerrs[topic] = partitions
+	}
+	partitions[partition] = kerr
+}
+
+// mockPartitionErrors returns the errors of the requested partitions, those
+// without a set error having ErrNoError
+func mockPartitionErrors(partitions map[string][]int32, kerrs map[string]map[int32]KError) map[string][]*PartitionError {
+	res := make(map[string][]*PartitionError, len(partitions))
+	for topic, ids := range partitions {
+		for _, partition := range ids {
+			res[topic] = append(res[topic], &PartitionError{Partition: partition, Err: kerrs[topic][partition]})
+		}
 	}
 	return res
 }
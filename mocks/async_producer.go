@@ -22,6 +22,8 @@ type AsyncProducer struct {
 	successes    chan *sarama.ProducerMessage
 	errors       chan *sarama.ProducerError
 	lastOffset   int64
+	unordered    bool
+	txn          txnState
 	*TopicConfig
 }
 
@@ -42,6 +44,7 @@ func NewAsyncProducer(t ErrorReporter, config *sarama.Config) *AsyncProducer {
 		errors:       make(chan *sarama.ProducerError, config.ChannelBufferSize),
 		TopicConfig:  NewTopicConfig(),
 	}
+	mp.txn.transactional = config.Producer.Transaction.ID != ""
 
 	go func() {
 		defer func() {
@@ -63,8 +66,11 @@ func NewAsyncProducer(t ErrorReporter, config *sarama.Config) *AsyncProducer {
 				mp.expectations = nil
 				mp.t.Errorf("No more expectation set on this mock producer to handle the input message.")
 			} else {
-				expectation := mp.expectations[0]
-				mp.expectations = mp.expectations[1:]
+				var expectation *producerExpectation
+				if !mp.unordered {
+					expectation = mp.expectations[0]
+					mp.expectations = mp.expectations[1:]
+				}
 
 				partition, err := partitioner.Partition(msg, mp.partitions(msg.Topic))
 				if err != nil {
@@ -72,23 +78,27 @@ func NewAsyncProducer(t ErrorReporter, config *sarama.Config) *AsyncProducer {
 					mp.errors <- &sarama.ProducerError{Err: err, Msg: msg}
 				} else {
 					msg.Partition = partition
-					if expectation.CheckFunction != nil {
-						err := expectation.CheckFunction(msg)
-						if err != nil {
-							mp.t.Errorf("Check function returned an error: %s", err.Error())
-							mp.errors <- &sarama.ProducerError{Err: err, Msg: msg}
-						}
+					var errCheck error
+					if mp.unordered {
+						expectation, mp.expectations, errCheck = matchExpectation(mp.expectations, msg)
+					} else {
+						errCheck = expectation.check(msg)
 					}
-					if errors.Is(expectation.Result, errProduceSuccess) {
+					if errCheck != nil {
+						mp.t.Errorf("Check function returned an error: %s", errCheck.Error())
+						mp.errors <- &sarama.ProducerError{Err: errCheck, Msg: msg}
+					}
+					switch {
+					case expectation == nil:
+						// no unordered expectation accepted the message
+					case errors.Is(expectation.Result, errProduceSuccess):
 						mp.lastOffset++
 						if config.Producer.Return.Successes {
 							msg.Offset = mp.lastOffset
 							mp.successes <- msg
 						}
-					} else {
-						if config.Producer.Return.Errors {
-							mp.errors <- &sarama.ProducerError{Err: expectation.Result, Msg: msg}
-						}
+					case config.Producer.Return.Errors:
+						mp.errors <- &sarama.ProducerError{Err: expectation.Result, Msg: msg}
 					}
 				}
 			}
@@ -144,6 +154,68 @@ func (mp *AsyncProducer) Errors() <-chan *sarama.ProducerError {
 	return mp.errors
 }
 
+// IsTransactional corresponds with the IsTransactional method of sarama's Producer
+// implementation, the mock being transactional if its config sets Producer.Transaction.ID.
+func (mp *AsyncProducer) IsTransactional() bool {
+	return mp.txn.transactional
+}
+
+// TxnStatus corresponds with the TxnStatus method of sarama's Producer implementation,
+// the transactions of the mock never failing.
+func (mp *AsyncProducer) TxnStatus() sarama.TxnStatus {
+	return mp.txn.status()
+}
+
+// BeginTxn corresponds with the BeginTxn method of sarama's Producer implementation. The
+// messages are handled according to the expectations whether a transaction is ongoing or not.
+func (mp *AsyncProducer) BeginTxn() error {
+	return mp.txn.begin()
+}
+
+// CommitTxn corresponds with the CommitTxn method of sarama's Producer implementation.
+func (mp *AsyncProducer) CommitTxn() error {
+	return mp.txn.end(true)
+}
+
+// AbortTxn corresponds with the AbortTxn method of sarama's Producer implementation.
+func (mp *AsyncProducer) AbortTxn() error {
+	return mp.txn.end(false)
+}
+
+// AddOffsetsToTxn corresponds with the AddOffsetsToTxn method of sarama's Producer
+// implementation.
+func (mp *AsyncProducer) AddOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, groupID string) error {
+	return mp.txn.addOffsets()
+}
+
+// AddGroupOffsetsToTxn corresponds with the AddGroupOffsetsToTxn method of sarama's Producer
+// implementation.
+func (mp *AsyncProducer) AddGroupOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, group *sarama.ConsumerGroupMetadata) error {
+	return mp.txn.addOffsets()
+}
+
+// AddMessageToTxn corresponds with the AddMessageToTxn method of sarama's Producer
+// implementation.
+func (mp *AsyncProducer) AddMessageToTxn(msg *sarama.ConsumerMessage, groupID string, metadata *string) error {
+	return mp.txn.addOffsets()
+}
+
+// PrepareTxn corresponds with the PrepareTxn method of sarama's Producer implementation.
+func (mp *AsyncProducer) PrepareTxn() (sarama.PreparedTxnState, error) {
+	return mp.txn.prepare()
+}
+
+// CompleteTxn corresponds with the CompleteTxn method of sarama's Producer implementation,
+// committing the prepared transaction if state is the one returned by PrepareTxn.
+func (mp *AsyncProducer) CompleteTxn(state sarama.PreparedTxnState) error {
+	return mp.txn.complete(state)
+}
+
+// Transactions returns the number of transactions committed and aborted.
+func (mp *AsyncProducer) Transactions() (committed, aborted int) {
+	return mp.txn.counts()
+}
+
 ////////////////////////////////////////////////
 // Setting expectations
 ////////////////////////////////////////////////
@@ -214,3 +286,15 @@ func (mp *AsyncProducer) ExpectInputAndFail(err error) *AsyncProducer {
 
 	return mp
 }
+
+// SetExpectationsOrdered sets whether the mock producer meets its expectations in the order they
+// were set, which is the default. Once unordered, every message meets the first remaining
+// expectation whose checker function accepts it, and a message no expectation accepts is
+// reported as an error and made available on the Errors channel.
+func (mp *AsyncProducer) SetExpectationsOrdered(ordered bool) *AsyncProducer {
+	mp.l.Lock()
+	defer mp.l.Unlock()
+	mp.unordered = !ordered
+
+	return mp
+}
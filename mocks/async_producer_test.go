@@ -159,6 +159,37 @@ func TestProducerWithBrokenPartitioner(t *testing.T) {
 	}
 }
 
+func TestProducerWithUnorderedExpectations(t *testing.T) {
+	trm := newTestReporterMock()
+	config := NewTestConfig()
+	config.Producer.Return.Successes = true
+	mp := NewAsyncProducer(trm, config).
+		SetExpectationsOrdered(false).
+		ExpectInputWithMessageCheckerFunctionAndFail(MatchTopic("first"), sarama.ErrOutOfBrokers).
+		ExpectInputWithMessageCheckerFunctionAndSucceed(MatchAll(MatchTopic("second"), MatchKey([]byte("key"))))
+
+	mp.Input() <- &sarama.ProducerMessage{Topic: "second", Key: sarama.StringEncoder("key")}
+	mp.Input() <- &sarama.ProducerMessage{Topic: "third"}
+	mp.Input() <- &sarama.ProducerMessage{Topic: "first"}
+
+	if msg := <-mp.Successes(); msg.Topic != "second" {
+		t.Errorf(`Expected a success for topic "second", found %q`, msg.Topic)
+	}
+	if err := <-mp.Errors(); !strings.HasPrefix(err.Err.Error(), "No expectation matches") {
+		t.Error("Expected to report an unmatched message, found: ", err.Err)
+	}
+	if err := <-mp.Errors(); !errors.Is(err.Err, sarama.ErrOutOfBrokers) || err.Msg.Topic != "first" {
+		t.Error("Expected ErrOutOfBrokers for topic \"first\", found: ", err.Err)
+	}
+	if err := mp.Close(); err != nil {
+		t.Error(err)
+	}
+
+	if len(trm.errors) != 1 {
+		t.Errorf("Expected to report 1 error, found %v", trm.errors)
+	}
+}
+
 // brokeProducer refuses to partition anything not on the “test” topic, and sends everything on
 // that topic to partition 15.
 type brokePartitioner struct{}
@@ -171,3 +202,105 @@ func (brokePartitioner) Partition(msg *sarama.ProducerMessage, n int32) (int32,
 }
 
 func (brokePartitioner) RequiresConsistency() bool { return false }
+
+func TestProducerTransactions(t *testing.T) {
+	config := NewTestConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Idempotent = true
+	config.Producer.Transaction.ID = "txn"
+	mp := NewAsyncProducer(t, config).ExpectInputAndSucceed()
+
+	if !mp.IsTransactional() {
+		t.Fatal("Expected the producer to be transactional")
+	}
+	if err := mp.CommitTxn(); !errors.Is(err, sarama.ErrTransactionNotReady) {
+		t.Error("Expected ErrTransactionNotReady without a transaction, found", err)
+	}
+	if err := mp.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if status := mp.TxnStatus(); status != sarama.TxnInTransaction {
+		t.Error("Expected the producer to be in transaction, found", status)
+	}
+	mp.Input() <- &sarama.ProducerMessage{Topic: "test"}
+	<-mp.Successes()
+	if err := mp.AddMessageToTxn(&sarama.ConsumerMessage{Topic: "in"}, "group", nil); err != nil {
+		t.Error(err)
+	}
+	if err := mp.CommitTxn(); err != nil {
+		t.Error(err)
+	}
+	if err := mp.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mp.AbortTxn(); err != nil {
+		t.Error(err)
+	}
+	if committed, aborted := mp.Transactions(); committed != 1 || aborted != 1 {
+		t.Errorf("Expected 1 committed and 1 aborted transactions, found %d and %d", committed, aborted)
+	}
+
+	if err := mp.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProducerPreparedTransactions(t *testing.T) {
+	config := NewTestConfig()
+	config.Producer.Idempotent = true
+	config.Producer.Transaction.ID = "txn"
+	mp := NewAsyncProducer(t, config)
+
+	if _, err := mp.PrepareTxn(); !errors.Is(err, sarama.ErrTransactionNotReady) {
+		t.Error("Expected ErrTransactionNotReady without a transaction, found", err)
+	}
+	if err := mp.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	state, err := mp.PrepareTxn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := mp.TxnStatus(); status != sarama.TxnPreparedTransaction {
+		t.Error("Expected the transaction to be prepared, found", status)
+	}
+	if err := mp.CommitTxn(); !errors.Is(err, sarama.ErrTransactionNotReady) {
+		t.Error("Expected ErrTransactionNotReady for a prepared transaction, found", err)
+	}
+	if err := mp.CompleteTxn(state); err != nil {
+		t.Error(err)
+	}
+
+	// a transaction is aborted if its state is not the prepared one
+	if err := mp.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mp.PrepareTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mp.CompleteTxn(state); err != nil {
+		t.Error(err)
+	}
+	if committed, aborted := mp.Transactions(); committed != 1 || aborted != 1 {
+		t.Errorf("Expected 1 committed and 1 aborted transactions, found %d and %d", committed, aborted)
+	}
+
+	if err := mp.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProducerNotTransactional(t *testing.T) {
+	mp := NewAsyncProducer(t, NewTestConfig())
+
+	if mp.IsTransactional() {
+		t.Error("Expected the producer not to be transactional")
+	}
+	if err := mp.BeginTxn(); !errors.Is(err, sarama.ErrNonTransactedProducer) {
+		t.Error("Expected ErrNonTransactedProducer, found", err)
+	}
+
+	if err := mp.Close(); err != nil {
+		t.Error(err)
+	}
+}
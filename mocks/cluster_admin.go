@@ -0,0 +1,682 @@
+package mocks
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// CallChecker is a function type to be set in each expectation of the cluster
+// admin mock to check the arguments of the call.
+type CallChecker func(args []interface{}) error
+
+// ClusterAdminExpectation is an expected call of a method of the mock
+// ClusterAdmin, see ClusterAdmin.Expect.
+type ClusterAdminExpectation struct {
+	method        string
+	results       []interface{}
+	checkFunction CallChecker
+}
+
+// WithCheck sets the function checking the arguments of the call.
+func (e *ClusterAdminExpectation) WithCheck(check CallChecker) *ClusterAdminExpectation {
+	e.checkFunction = check
+	return e
+}
+
+// ClusterAdmin implements sarama's ClusterAdmin interface for testing purposes.
+// Before calling its methods, you have to set expectations on the mock
+// ClusterAdmin to tell it what each call returns, so you can easily test
+// success and failure scenarios. The expectations of a method are met in the
+// order they were set, whatever the order of the calls of the other methods.
+type ClusterAdmin struct {
+	l            sync.Mutex
+	t            ErrorReporter
+	expectations map[string][]*ClusterAdminExpectation
+}
+
+// NewClusterAdmin returns a new mock ClusterAdmin instance. The t argument
+// should be the *testing.T instance of your test method. An error will be
+// written to it if an expectation is violated.
+func NewClusterAdmin(t ErrorReporter) *ClusterAdmin {
+	return &ClusterAdmin{
+		t:            t,
+		expectations: make(map[string][]*ClusterAdminExpectation),
+	}
+}
+
+////////////////////////////////////////////////
+// Setting expectations
+////////////////////////////////////////////////
+
+// Expect sets an expectation on the mock ClusterAdmin: the next call of the
+// method returns the results, which are the values returned by the method
+// in order, its error included. Omitted trailing results are zero values, so
+// Expect("DeleteTopic") expects a successful DeleteTopic call.
+func (ca *ClusterAdmin) Expect(method string, results ...interface{}) *ClusterAdminExpectation {
+	ca.l.Lock()
+	defer ca.l.Unlock()
+
+	if _, ok := reflect.TypeOf((*sarama.ClusterAdmin)(nil)).Elem().MethodByName(method); !ok {
+		ca.t.Errorf("%s is not a method of sarama.ClusterAdmin", method)
+	}
+	e := &ClusterAdminExpectation{method: method, results: results}
+	ca.expectations[method] = append(ca.expectations[method], e)
+	return e
+}
+
+// ExpectCreateTopic sets an expectation on the mock ClusterAdmin that the
+// topic is created, returning err.
+func (ca *ClusterAdmin) ExpectCreateTopic(topic string, err error) *ClusterAdminExpectation {
+	return ca.Expect("CreateTopic", err).WithCheck(argChecker("topic", 0, topic))
+}
+
+// ExpectDeleteTopic sets an expectation on the mock ClusterAdmin that the
+// topic is deleted, returning err.
+func (ca *ClusterAdmin) ExpectDeleteTopic(topic string, err error) *ClusterAdminExpectation {
+	return ca.Expect("DeleteTopic", err).WithCheck(argChecker("topic", 0, topic))
+}
+
+// ExpectListTopics sets an expectation on the mock ClusterAdmin that the
+// topics are listed, returning topics and err.
+func (ca *ClusterAdmin) ExpectListTopics(topics map[string]sarama.TopicDetail, err error) *ClusterAdminExpectation {
+	return ca.Expect("ListTopics", topics, err)
+}
+
+// ExpectCreatePartitions sets an expectation on the mock ClusterAdmin that
+// the partitions of the topic are increased to count, returning err.
+func (ca *ClusterAdmin) ExpectCreatePartitions(topic string, count int32, err error) *ClusterAdminExpectation {
+	return ca.Expect("CreatePartitions", err).WithCheck(func(args []interface{}) error {
+		if err := argChecker("topic", 0, topic)(args); err != nil {
+			return err
+		}
+		return argChecker("count", 1, count)(args)
+	})
+}
+
+// ExpectDescribeConfig sets an expectation on the mock ClusterAdmin that the
+// configs of the resource are described, returning entries and err.
+func (ca *ClusterAdmin) ExpectDescribeConfig(resourceType sarama.ConfigResourceType, name string, entries []sarama.ConfigEntry, err error) *ClusterAdminExpectation {
+	return ca.Expect("DescribeConfig", entries, err).WithCheck(func(args []interface{}) error {
+		resource := args[0].(sarama.ConfigResource)
+		if resource.Type != resourceType || resource.Name != name {
+			return fmt.Errorf("expected the configs of resource %s of type %v, got %s of type %v", name, resourceType, resource.Name, resource.Type)
+		}
+		return nil
+	})
+}
+
+// ExpectAlterConfig sets an expectation on the mock ClusterAdmin that the
+// configs of the resource are altered, returning err.
+func (ca *ClusterAdmin) ExpectAlterConfig(resourceType sarama.ConfigResourceType, name string, err error) *ClusterAdminExpectation {
+	return ca.Expect("AlterConfig", err).WithCheck(func(args []interface{}) error {
+		if err := argChecker("resource type", 0, resourceType)(args); err != nil {
+			return err
+		}
+		return argChecker("resource name", 1, name)(args)
+	})
+}
+
+// ExpectCreateACL sets an expectation on the mock ClusterAdmin that an ACL
+// is created for the resource, returning err.
+func (ca *ClusterAdmin) ExpectCreateACL(resource sarama.Resource, err error) *ClusterAdminExpectation {
+	return ca.Expect("CreateACL", err).WithCheck(argChecker("resource", 0, resource))
+}
+
+// ExpectListAcls sets an expectation on the mock ClusterAdmin that ACLs are
+// listed, returning acls and err.
+func (ca *ClusterAdmin) ExpectListAcls(acls []sarama.ResourceAcls, err error) *ClusterAdminExpectation {
+	return ca.Expect("ListAcls", acls, err)
+}
+
+// ExpectDeleteACL sets an expectation on the mock ClusterAdmin that ACLs are
+// deleted, returning matching and err.
+func (ca *ClusterAdmin) ExpectDeleteACL(matching []sarama.MatchingAcl, err error) *ClusterAdminExpectation {
+	return ca.Expect("DeleteACL", matching, err)
+}
+
+// ExpectListConsumerGroupOffsets sets an expectation on the mock ClusterAdmin
+// that the offsets of the group are listed, returning res and err.
+func (ca *ClusterAdmin) ExpectListConsumerGroupOffsets(group string, res *sarama.OffsetFetchResponse, err error) *ClusterAdminExpectation {
+	return ca.Expect("ListConsumerGroupOffsets", res, err).WithCheck(argChecker("group", 0, group))
+}
+
+// ExpectDeleteConsumerGroupOffset sets an expectation on the mock
+// ClusterAdmin that the offset of the partition is deleted for the group,
+// returning err.
+func (ca *ClusterAdmin) ExpectDeleteConsumerGroupOffset(group, topic string, partition int32, err error) *ClusterAdminExpectation {
+	return ca.Expect("DeleteConsumerGroupOffset", err).WithCheck(func(args []interface{}) error {
+		for i, expected := range []interface{}{group, topic, partition} {
+			if err := argChecker([]string{"group", "topic", "partition"}[i], i, expected)(args); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// argChecker returns a CallChecker comparing an argument of the call with
+// the expected one
+func argChecker(name string, i int, expected interface{}) CallChecker {
+	return func(args []interface{}) error {
+		if !reflect.DeepEqual(args[i], expected) {
+			return fmt.Errorf("expected %s %v, got %v", name, expected, args[i])
+		}
+		return nil
+	}
+}
+
+// call meets the next expectation of the method, checking its arguments and
+// setting its results to the pointers of outs, and returns its error
+func (ca *ClusterAdmin) call(method string, args []interface{}, outs ...interface{}) error {
+	ca.l.Lock()
+	defer ca.l.Unlock()
+
+	expectations := ca.expectations[method]
+	if len(expectations) == 0 {
+		ca.t.Errorf("No more expectations set on this mock cluster admin to handle the call of %s.", method)
+		return errOutOfExpectations
+	}
+	expectation := expectations[0]
+	ca.expectations[method] = expectations[1:]
+
+	if expectation.checkFunction != nil {
+		if err := expectation.checkFunction(args); err != nil {
+			ca.t.Errorf("Check function of %s returned an error: %s", method, err.Error())
+			return err
+		}
+	}
+
+	for i, out := range outs {
+		if i >= len(expectation.results) || expectation.results[i] == nil {
+			break
+		}
+		result := reflect.ValueOf(expectation.results[i])
+		target := reflect.ValueOf(out).Elem()
+		if !result.Type().AssignableTo(target.Type()) {
+			ca.t.Errorf("Result %d of %s is a %s, expected a %s.", i, method, result.Type(), target.Type())
+			continue
+		}
+		target.Set(result)
+	}
+
+	if len(expectation.results) > len(outs) && expectation.results[len(outs)] != nil {
+		err, ok := expectation.results[len(outs)].(error)
+		if !ok {
+			ca.t.Errorf("Result %d of %s is a %T, expected an error.", len(outs), method, expectation.results[len(outs)])
+		}
+		return err
+	}
+	return nil
+}
+
+////////////////////////////////////////////////
+// Implement ClusterAdmin interface
+////////////////////////////////////////////////
+
+// WithContext returns the mock ClusterAdmin itself, sharing its expectations.
+func (ca *ClusterAdmin) WithContext(ctx context.Context) sarama.ClusterAdmin {
+	return ca
+}
+
+// WithRetryPolicy returns the mock ClusterAdmin itself, sharing its
+// expectations.
+func (ca *ClusterAdmin) WithRetryPolicy(policy sarama.AdminRetryPolicy) sarama.ClusterAdmin {
+	return ca
+}
+
+// WithOptions returns the mock ClusterAdmin itself, sharing its expectations.
+func (ca *ClusterAdmin) WithOptions(options sarama.AdminOptions) sarama.ClusterAdmin {
+	return ca
+}
+
+// Close corresponds with the Close method of sarama's ClusterAdmin
+// implementation. It reports the expectations which were not met.
+func (ca *ClusterAdmin) Close() error {
+	ca.l.Lock()
+	defer ca.l.Unlock()
+
+	for method, expectations := range ca.expectations {
+		if len(expectations) > 0 {
+			ca.t.Errorf("Expected %d more calls of %s.", len(expectations), method)
+		}
+	}
+	return nil
+}
+
+// CreateTopic implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) CreateTopic(topic string, detail *sarama.TopicDetail, validateOnly bool) error {
+	return ca.call("CreateTopic", []interface{}{topic, detail, validateOnly})
+}
+
+// ListTopics implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	var res map[string]sarama.TopicDetail
+	err := ca.call("ListTopics", nil, &res)
+	return res, err
+}
+
+// DescribeTopics implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeTopics(topics []string) ([]*sarama.TopicMetadata, error) {
+	var metadata []*sarama.TopicMetadata
+	err := ca.call("DescribeTopics", []interface{}{topics}, &metadata)
+	return metadata, err
+}
+
+// DescribeTopicsByID implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeTopicsByID(topicIDs []sarama.Uuid) ([]*sarama.TopicMetadata, error) {
+	var metadata []*sarama.TopicMetadata
+	err := ca.call("DescribeTopicsByID", []interface{}{topicIDs}, &metadata)
+	return metadata, err
+}
+
+// DeleteTopic implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DeleteTopic(topic string) error {
+	return ca.call("DeleteTopic", []interface{}{topic})
+}
+
+// DeleteTopicByID implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DeleteTopicByID(topicID sarama.Uuid) error {
+	return ca.call("DeleteTopicByID", []interface{}{topicID})
+}
+
+// CreateTopics implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) CreateTopics(topics map[string]*sarama.TopicDetail, options *sarama.BulkTopicOptions) (map[string]error, error) {
+	var res map[string]error
+	err := ca.call("CreateTopics", []interface{}{topics, options}, &res)
+	return res, err
+}
+
+// DeleteTopics implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DeleteTopics(topics []string, options *sarama.BulkTopicOptions) (map[string]error, error) {
+	var res map[string]error
+	err := ca.call("DeleteTopics", []interface{}{topics, options}, &res)
+	return res, err
+}
+
+// CreatePartitions implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) CreatePartitions(topic string, count int32, assignment [][]int32, validateOnly bool) error {
+	return ca.call("CreatePartitions", []interface{}{topic, count, assignment, validateOnly})
+}
+
+// CreateBalancedPartitions implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) CreateBalancedPartitions(topic string, count int32, validateOnly bool) ([][]int32, error) {
+	var res [][]int32
+	err := ca.call("CreateBalancedPartitions", []interface{}{topic, count, validateOnly}, &res)
+	return res, err
+}
+
+// AlterPartitionReassignments implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) AlterPartitionReassignments(topic string, assignment [][]int32) error {
+	return ca.call("AlterPartitionReassignments", []interface{}{topic, assignment})
+}
+
+// ListPartitionReassignments implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ListPartitionReassignments(topics string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+	var topicStatus map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus
+	err := ca.call("ListPartitionReassignments", []interface{}{topics, partitions}, &topicStatus)
+	return topicStatus, err
+}
+
+// MonitorPartitionReassignments implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) MonitorPartitionReassignments(topic string, partitions []int32, interval, timeout time.Duration, progress func(*sarama.PartitionReassignmentProgress)) error {
+	return ca.call("MonitorPartitionReassignments", []interface{}{topic, partitions, interval, timeout, progress})
+}
+
+// DeleteRecords implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DeleteRecords(topic string, partitionOffsets map[int32]int64) error {
+	return ca.call("DeleteRecords", []interface{}{topic, partitionOffsets})
+}
+
+// PurgeTopic implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) PurgeTopic(topic string) (map[int32]*sarama.DeleteRecordsResponsePartition, error) {
+	var res map[int32]*sarama.DeleteRecordsResponsePartition
+	err := ca.call("PurgeTopic", []interface{}{topic}, &res)
+	return res, err
+}
+
+// DescribeConfig implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeConfig(resource sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
+	var res []sarama.ConfigEntry
+	err := ca.call("DescribeConfig", []interface{}{resource}, &res)
+	return res, err
+}
+
+// AlterConfig implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) AlterConfig(resourceType sarama.ConfigResourceType, name string, entries map[string]*string, validateOnly bool) error {
+	return ca.call("AlterConfig", []interface{}{resourceType, name, entries, validateOnly})
+}
+
+// IncrementalAlterConfig implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) IncrementalAlterConfig(resourceType sarama.ConfigResourceType, name string, entries map[string]sarama.IncrementalAlterConfigsEntry, validateOnly bool) error {
+	return ca.call("IncrementalAlterConfig", []interface{}{resourceType, name, entries, validateOnly})
+}
+
+// DescribeClusterBrokerConfig implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeClusterBrokerConfig() ([]sarama.ConfigEntry, error) {
+	var res []sarama.ConfigEntry
+	err := ca.call("DescribeClusterBrokerConfig", nil, &res)
+	return res, err
+}
+
+// IncrementalAlterClusterBrokerConfig implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) IncrementalAlterClusterBrokerConfig(entries map[string]sarama.IncrementalAlterConfigsEntry, validateOnly bool) error {
+	return ca.call("IncrementalAlterClusterBrokerConfig", []interface{}{entries, validateOnly})
+}
+
+// DescribeBrokerConfigDeviations implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeBrokerConfigDeviations() (map[string]*sarama.BrokerConfigDeviation, error) {
+	var res map[string]*sarama.BrokerConfigDeviation
+	err := ca.call("DescribeBrokerConfigDeviations", nil, &res)
+	return res, err
+}
+
+// ApplyTopicSpec implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ApplyTopicSpec(spec sarama.TopicSpec, dryRun bool) (*sarama.TopicSpecChanges, error) {
+	var res *sarama.TopicSpecChanges
+	err := ca.call("ApplyTopicSpec", []interface{}{spec, dryRun}, &res)
+	return res, err
+}
+
+// CreateACL implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) CreateACL(resource sarama.Resource, acl sarama.Acl) error {
+	return ca.call("CreateACL", []interface{}{resource, acl})
+}
+
+// ListAcls implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ListAcls(filter sarama.AclFilter) ([]sarama.ResourceAcls, error) {
+	var res []sarama.ResourceAcls
+	err := ca.call("ListAcls", []interface{}{filter}, &res)
+	return res, err
+}
+
+// DeleteACL implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DeleteACL(filter sarama.AclFilter, validateOnly bool) ([]sarama.MatchingAcl, error) {
+	var res []sarama.MatchingAcl
+	err := ca.call("DeleteACL", []interface{}{filter, validateOnly}, &res)
+	return res, err
+}
+
+// DiffACLs implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DiffACLs(desired []sarama.ResourceAcls, filter sarama.AclFilter) (*sarama.AclChanges, error) {
+	var res *sarama.AclChanges
+	err := ca.call("DiffACLs", []interface{}{desired, filter}, &res)
+	return res, err
+}
+
+// EnsureACLs implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) EnsureACLs(desired []sarama.ResourceAcls, filter sarama.AclFilter, dryRun bool) (*sarama.AclChanges, error) {
+	var res *sarama.AclChanges
+	err := ca.call("EnsureACLs", []interface{}{desired, filter, dryRun}, &res)
+	return res, err
+}
+
+// ListConsumerGroups implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ListConsumerGroups() (map[string]string, error) {
+	var res map[string]string
+	err := ca.call("ListConsumerGroups", nil, &res)
+	return res, err
+}
+
+// ListConsumerGroupListings implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ListConsumerGroupListings(states []string, types []string) (map[string]*sarama.ConsumerGroupListing, error) {
+	var res map[string]*sarama.ConsumerGroupListing
+	err := ca.call("ListConsumerGroupListings", []interface{}{states, types}, &res)
+	return res, err
+}
+
+// DescribeConsumerGroups implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeConsumerGroups(groups []string) ([]*sarama.GroupDescription, error) {
+	var res []*sarama.GroupDescription
+	err := ca.call("DescribeConsumerGroups", []interface{}{groups}, &res)
+	return res, err
+}
+
+// DescribeConsumerProtocolGroups implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeConsumerProtocolGroups(groups []string, includeAuthorizedOperations bool) ([]*sarama.ConsumerGroupDescribeGroup, error) {
+	var res []*sarama.ConsumerGroupDescribeGroup
+	err := ca.call("DescribeConsumerProtocolGroups", []interface{}{groups, includeAuthorizedOperations}, &res)
+	return res, err
+}
+
+// ListConsumerGroupOffsets implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	var res *sarama.OffsetFetchResponse
+	err := ca.call("ListConsumerGroupOffsets", []interface{}{group, topicPartitions}, &res)
+	return res, err
+}
+
+// ListConsumerGroupOffsetsBatch implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ListConsumerGroupOffsetsBatch(groups map[string]map[string][]int32) (map[string]*sarama.OffsetFetchResponseGroup, error) {
+	var res map[string]*sarama.OffsetFetchResponseGroup
+	err := ca.call("ListConsumerGroupOffsetsBatch", []interface{}{groups}, &res)
+	return res, err
+}
+
+// DeleteConsumerGroupOffset implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DeleteConsumerGroupOffset(group string, topic string, partition int32) error {
+	return ca.call("DeleteConsumerGroupOffset", []interface{}{group, topic, partition})
+}
+
+// DeleteConsumerGroup implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DeleteConsumerGroup(group string) error {
+	return ca.call("DeleteConsumerGroup", []interface{}{group})
+}
+
+// RemoveMembersFromConsumerGroup implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) RemoveMembersFromConsumerGroup(group string, groupInstanceIDs []string) error {
+	return ca.call("RemoveMembersFromConsumerGroup", []interface{}{group, groupInstanceIDs})
+}
+
+// ResetConsumerGroupOffsets implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ResetConsumerGroupOffsets(group string, spec sarama.OffsetResetSpec) (map[string]map[int32]int64, error) {
+	var res map[string]map[int32]int64
+	err := ca.call("ResetConsumerGroupOffsets", []interface{}{group, spec}, &res)
+	return res, err
+}
+
+// ExportConsumerGroupOffsets implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ExportConsumerGroupOffsets(group string) (*sarama.ConsumerGroupOffsets, error) {
+	var res *sarama.ConsumerGroupOffsets
+	err := ca.call("ExportConsumerGroupOffsets", []interface{}{group}, &res)
+	return res, err
+}
+
+// ImportConsumerGroupOffsets implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ImportConsumerGroupOffsets(group string, offsets *sarama.ConsumerGroupOffsets) error {
+	return ca.call("ImportConsumerGroupOffsets", []interface{}{group, offsets})
+}
+
+// DescribeCluster implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeCluster() ([]*sarama.Broker, int32, error) {
+	var brokers []*sarama.Broker
+	var controllerID int32
+	err := ca.call("DescribeCluster", nil, &brokers, &controllerID)
+	return brokers, controllerID, err
+}
+
+// DescribeClusterDetails implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeClusterDetails(includeAuthorizedOperations bool) (*sarama.ClusterDescription, error) {
+	var res *sarama.ClusterDescription
+	err := ca.call("DescribeClusterDetails", []interface{}{includeAuthorizedOperations}, &res)
+	return res, err
+}
+
+// HealthCheck implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) HealthCheck() (*sarama.ClusterHealth, error) {
+	var res *sarama.ClusterHealth
+	err := ca.call("HealthCheck", nil, &res)
+	return res, err
+}
+
+// ListUnhealthyPartitions implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ListUnhealthyPartitions(topics []string) (map[string][]*sarama.UnhealthyPartition, error) {
+	var res map[string][]*sarama.UnhealthyPartition
+	err := ca.call("ListUnhealthyPartitions", []interface{}{topics}, &res)
+	return res, err
+}
+
+// DescribeLogDirsSummary implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeLogDirsSummary() (*sarama.LogDirsSummary, error) {
+	var res *sarama.LogDirsSummary
+	err := ca.call("DescribeLogDirsSummary", nil, &res)
+	return res, err
+}
+
+// AlterReplicaLogDirs implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) AlterReplicaLogDirs(brokerID int32, assignments map[string]map[string][]int32) error {
+	return ca.call("AlterReplicaLogDirs", []interface{}{brokerID, assignments})
+}
+
+// UnregisterBroker implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) UnregisterBroker(brokerID int32) error {
+	return ca.call("UnregisterBroker", []interface{}{brokerID})
+}
+
+// AddRaftVoter implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) AddRaftVoter(voterID int32, voterDirectoryID sarama.Uuid, listeners []sarama.RaftVoterListener) error {
+	return ca.call("AddRaftVoter", []interface{}{voterID, voterDirectoryID, listeners})
+}
+
+// RemoveRaftVoter implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) RemoveRaftVoter(voterID int32, voterDirectoryID sarama.Uuid) error {
+	return ca.call("RemoveRaftVoter", []interface{}{voterID, voterDirectoryID})
+}
+
+// DescribeFeatures implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeFeatures() (*sarama.FeatureMetadata, error) {
+	var res *sarama.FeatureMetadata
+	err := ca.call("DescribeFeatures", nil, &res)
+	return res, err
+}
+
+// UpdateFeatures implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) UpdateFeatures(updates map[string]sarama.FeatureUpdate) error {
+	return ca.call("UpdateFeatures", []interface{}{updates})
+}
+
+// ElectLeaders implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ElectLeaders(electionType sarama.ElectionType, partitions map[string][]int32) (map[string]map[int32]*sarama.PartitionResult, error) {
+	var res map[string]map[int32]*sarama.PartitionResult
+	err := ca.call("ElectLeaders", []interface{}{electionType, partitions}, &res)
+	return res, err
+}
+
+// DescribeLogDirs implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeLogDirs(brokers []int32) (map[int32][]sarama.DescribeLogDirsResponseDirMetadata, error) {
+	var res map[int32][]sarama.DescribeLogDirsResponseDirMetadata
+	err := ca.call("DescribeLogDirs", []interface{}{brokers}, &res)
+	return res, err
+}
+
+// ListOffsets implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ListOffsets(topicPartitions map[string][]int32, timestamp int64) (map[string]map[int32]*sarama.OffsetResponseBlock, error) {
+	var res map[string]map[int32]*sarama.OffsetResponseBlock
+	err := ca.call("ListOffsets", []interface{}{topicPartitions, timestamp}, &res)
+	return res, err
+}
+
+// DescribeProducers implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeProducers(topicPartitions map[string][]int32) (map[string]map[int32]*sarama.DescribeProducersPartition, error) {
+	var res map[string]map[int32]*sarama.DescribeProducersPartition
+	err := ca.call("DescribeProducers", []interface{}{topicPartitions}, &res)
+	return res, err
+}
+
+// ListTransactions implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ListTransactions(stateFilters []string, producerIDFilters []int64) ([]*sarama.TransactionListing, error) {
+	var res []*sarama.TransactionListing
+	err := ca.call("ListTransactions", []interface{}{stateFilters, producerIDFilters}, &res)
+	return res, err
+}
+
+// DescribeTransactions implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeTransactions(transactionalIDs []string) ([]*sarama.TransactionDescription, error) {
+	var res []*sarama.TransactionDescription
+	err := ca.call("DescribeTransactions", []interface{}{transactionalIDs}, &res)
+	return res, err
+}
+
+// CreateDelegationToken implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) CreateDelegationToken(renewers []sarama.DelegationTokenPrincipal, maxLifetime time.Duration) (*sarama.DelegationToken, error) {
+	var res *sarama.DelegationToken
+	err := ca.call("CreateDelegationToken", []interface{}{renewers, maxLifetime}, &res)
+	return res, err
+}
+
+// RenewDelegationToken implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) RenewDelegationToken(hmac []byte, renewPeriod time.Duration) (time.Time, error) {
+	var res time.Time
+	err := ca.call("RenewDelegationToken", []interface{}{hmac, renewPeriod}, &res)
+	return res, err
+}
+
+// ExpireDelegationToken implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) ExpireDelegationToken(hmac []byte, expiryTimePeriod time.Duration) (time.Time, error) {
+	var res time.Time
+	err := ca.call("ExpireDelegationToken", []interface{}{hmac, expiryTimePeriod}, &res)
+	return res, err
+}
+
+// DescribeDelegationToken implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeDelegationToken(owners []sarama.DelegationTokenPrincipal) ([]*sarama.DelegationToken, error) {
+	var res []*sarama.DelegationToken
+	err := ca.call("DescribeDelegationToken", []interface{}{owners}, &res)
+	return res, err
+}
+
+// DescribeUserScramCredentials implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeUserScramCredentials(users []string) ([]*sarama.DescribeUserScramCredentialsResult, error) {
+	var res []*sarama.DescribeUserScramCredentialsResult
+	err := ca.call("DescribeUserScramCredentials", []interface{}{users}, &res)
+	return res, err
+}
+
+// DeleteUserScramCredentials implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DeleteUserScramCredentials(deletions []sarama.AlterUserScramCredentialsDelete) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	var res []*sarama.AlterUserScramCredentialsResult
+	err := ca.call("DeleteUserScramCredentials", []interface{}{deletions}, &res)
+	return res, err
+}
+
+// UpsertUserScramCredentials implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) UpsertUserScramCredentials(upsert []sarama.AlterUserScramCredentialsUpsert) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	var res []*sarama.AlterUserScramCredentialsResult
+	err := ca.call("UpsertUserScramCredentials", []interface{}{upsert}, &res)
+	return res, err
+}
+
+// DescribeClientQuotas implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeClientQuotas(components []sarama.QuotaFilterComponent, strict bool) ([]sarama.DescribeClientQuotasEntry, error) {
+	var res []sarama.DescribeClientQuotasEntry
+	err := ca.call("DescribeClientQuotas", []interface{}{components, strict}, &res)
+	return res, err
+}
+
+// AlterClientQuotas implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) AlterClientQuotas(entity []sarama.QuotaEntityComponent, op sarama.ClientQuotasOp, validateOnly bool) error {
+	return ca.call("AlterClientQuotas", []interface{}{entity, op, validateOnly})
+}
+
+// DescribeEffectiveClientQuotas implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) DescribeEffectiveClientQuotas(user, clientID string) (map[string]sarama.EffectiveClientQuota, error) {
+	var res map[string]sarama.EffectiveClientQuota
+	err := ca.call("DescribeEffectiveClientQuotas", []interface{}{user, clientID}, &res)
+	return res, err
+}
+
+// SetClientQuotas implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) SetClientQuotas(entity []sarama.QuotaEntityComponent, quotas sarama.ClientQuotaValues, validateOnly bool) error {
+	return ca.call("SetClientQuotas", []interface{}{entity, quotas, validateOnly})
+}
+
+// Controller implements sarama.ClusterAdmin.
+func (ca *ClusterAdmin) Controller() (*sarama.Broker, error) {
+	var res *sarama.Broker
+	err := ca.call("Controller", nil, &res)
+	return res, err
+}
@@ -0,0 +1,88 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestMockClusterAdminImplementsClusterAdminInterface(t *testing.T) {
+	var ca interface{} = &ClusterAdmin{}
+	if _, ok := ca.(sarama.ClusterAdmin); !ok {
+		t.Error("The mock cluster admin should implement the sarama.ClusterAdmin interface.")
+	}
+}
+
+func TestClusterAdminReturnsExpectations(t *testing.T) {
+	trm := newTestReporterMock()
+	ca := NewClusterAdmin(trm)
+
+	topics := map[string]sarama.TopicDetail{"my_topic": {NumPartitions: 3, ReplicationFactor: 1}}
+	ca.ExpectListTopics(topics, nil)
+	ca.ExpectCreateTopic("my_topic", sarama.ErrTopicAlreadyExists)
+	ca.Expect("DescribeCluster", []*sarama.Broker{sarama.NewBroker("localhost:9092")}, int32(1))
+	ca.ExpectDeleteConsumerGroupOffset("my_group", "my_topic", 0, nil)
+
+	if err := ca.CreateTopic("my_topic", &sarama.TopicDetail{NumPartitions: 3}, false); !errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		t.Errorf("Expected ErrTopicAlreadyExists, got %v", err)
+	}
+	res, err := ca.ListTopics()
+	if err != nil || res["my_topic"].NumPartitions != 3 {
+		t.Errorf("Unexpected topics %v (%v)", res, err)
+	}
+	brokers, controllerID, err := ca.WithContext(context.Background()).DescribeCluster()
+	if err != nil || len(brokers) != 1 || controllerID != 1 {
+		t.Errorf("Unexpected cluster %v with controller %d (%v)", brokers, controllerID, err)
+	}
+	if err := ca.DeleteConsumerGroupOffset("my_group", "my_topic", 0); err != nil {
+		t.Error(err)
+	}
+
+	if err := ca.Close(); err != nil {
+		t.Error(err)
+	}
+	if len(trm.errors) != 0 {
+		t.Errorf("Expected no expectation violations, got %v", trm.errors)
+	}
+}
+
+func TestClusterAdminReportsViolations(t *testing.T) {
+	trm := newTestReporterMock()
+	ca := NewClusterAdmin(trm)
+
+	ca.ExpectDeleteTopic("my_topic", nil)
+	ca.ExpectAlterConfig(sarama.TopicResource, "my_topic", nil)
+	ca.Expect("ListAcls")
+
+	if err := ca.DeleteTopic("other_topic"); err == nil {
+		t.Error("Expected the check of the topic to fail")
+	}
+	if _, err := ca.ListConsumerGroups(); !errors.Is(err, errOutOfExpectations) {
+		t.Errorf("Expected errOutOfExpectations, got %v", err)
+	}
+	if acls, err := ca.ListAcls(sarama.AclFilter{}); acls != nil || err != nil {
+		t.Errorf("Expected zero results, got %v (%v)", acls, err)
+	}
+	_ = ca.Close()
+
+	if len(trm.errors) != 3 {
+		t.Errorf("Expected 3 expectation violations, got %v", trm.errors)
+	}
+}
+
+func TestClusterAdminReportsUnknownMethods(t *testing.T) {
+	trm := newTestReporterMock()
+	ca := NewClusterAdmin(trm)
+
+	ca.Expect("DeleteTopicz")
+	ca.Expect("ListTopics", "not topics")
+	if _, err := ca.ListTopics(); err != nil {
+		t.Error(err)
+	}
+
+	if len(trm.errors) != 2 {
+		t.Errorf("Expected 2 expectation violations, got %v", trm.errors)
+	}
+}
@@ -0,0 +1,546 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/Shopify/sarama"
+)
+
+type none struct{}
+
+type markedOffset struct {
+	offset   int64
+	metadata string
+}
+
+// ConsumerGroup implements sarama's ConsumerGroup interface for testing
+// purposes. It runs the sessions of a ConsumerGroupHandler without any
+// broker: the messages scripted with YieldMessage are delivered to the
+// claims of their partitions, Rebalance ends the current session so that the
+// next Consume call starts one with the new claims, and the offsets marked
+// and committed by the handler can be checked with MarkedOffset,
+// CommittedOffset and the expectations verified by Close.
+//
+// The messages delivered to a claim are not delivered again by the next
+// sessions, whether their offsets were marked or not.
+type ConsumerGroup struct {
+	l          sync.Mutex
+	t          ErrorReporter
+	config     *sarama.Config
+	memberID   string
+	generation int32
+
+	claims         map[string][]int32
+	messages       map[string]map[int32][]*sarama.ConsumerMessage
+	highWaterMarks map[string]map[int32]int64
+	paused         map[string]map[int32]bool
+	pausedAll      bool
+	notify         chan none
+
+	marked    map[string]map[int32]markedOffset
+	committed map[string]map[int32]markedOffset
+	commits   int
+
+	expectedMarks   map[string]map[int32]int64
+	expectedCommits int
+
+	errors    chan error
+	rebalance chan none
+	sessions  sync.WaitGroup
+	closing   bool
+	closed    chan none
+	closeOnce sync.Once
+}
+
+// NewConsumerGroup returns a new mock ConsumerGroup instance. The t argument
+// should be the *testing.T instance of your test method. An error will be
+// written to it if an expectation is violated. The config argument can be
+// set to nil.
+func NewConsumerGroup(t ErrorReporter, config *sarama.Config) *ConsumerGroup {
+	if config == nil {
+		config = sarama.NewConfig()
+	}
+	return &ConsumerGroup{
+		t:              t,
+		config:         config,
+		memberID:       "mock-member",
+		messages:       make(map[string]map[int32][]*sarama.ConsumerMessage),
+		highWaterMarks: make(map[string]map[int32]int64),
+		paused:         make(map[string]map[int32]bool),
+		notify:         make(chan none),
+		marked:         make(map[string]map[int32]markedOffset),
+		committed:      make(map[string]map[int32]markedOffset),
+		expectedMarks:  make(map[string]map[int32]int64),
+		errors:         make(chan error, config.ChannelBufferSize),
+		rebalance:      make(chan none),
+		closed:         make(chan none),
+	}
+}
+
+////////////////////////////////////////////////
+// Scripting the group
+////////////////////////////////////////////////
+
+// SetClaims sets the partitions claimed by the next sessions. By default the
+// sessions claim the partitions of the consumed topics with yielded messages.
+func (cg *ConsumerGroup) SetClaims(claims map[string][]int32) *ConsumerGroup {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	cg.claims = copyClaims(claims)
+	return cg
+}
+
+// SetCommittedOffset sets the offset committed for the partition before the
+// first session, which is the initial offset of its claims.
+func (cg *ConsumerGroup) SetCommittedOffset(topic string, partition int32, offset int64) *ConsumerGroup {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	setMarkedOffset(cg.committed, topic, partition, markedOffset{offset: offset})
+	if cg.highWaterMark(topic, partition) < offset {
+		cg.highWaterMarks[topic][partition] = offset
+	}
+	return cg
+}
+
+// YieldMessage queues the message for the claim of its partition, in the
+// current session or the next one claiming it. Its topic and partition are
+// set to the given ones, and its offset to the next offset of the partition.
+func (cg *ConsumerGroup) YieldMessage(topic string, partition int32, msg *sarama.ConsumerMessage) *ConsumerGroup {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+
+	msg.Topic = topic
+	msg.Partition = partition
+	msg.Offset = cg.highWaterMark(topic, partition)
+	cg.highWaterMarks[topic][partition]++
+
+	if cg.messages[topic] == nil {
+		cg.messages[topic] = make(map[int32][]*sarama.ConsumerMessage)
+	}
+	cg.messages[topic][partition] = append(cg.messages[topic][partition], msg)
+	cg.wakeUp()
+	return cg
+}
+
+// YieldError sends the error on the Errors channel of the group.
+func (cg *ConsumerGroup) YieldError(err error) *ConsumerGroup {
+	cg.errors <- err
+	return cg
+}
+
+// Rebalance ends the current session, if any, like a rebalance of the group
+// does. The next sessions claim the given partitions, so omitting partitions
+// of the current claims simulates their revocation. A nil claims keeps the
+// claims of the next sessions unchanged.
+func (cg *ConsumerGroup) Rebalance(claims map[string][]int32) {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	if claims != nil {
+		cg.claims = copyClaims(claims)
+	}
+	close(cg.rebalance)
+	cg.rebalance = make(chan none)
+}
+
+// ExpectMarkedOffset sets an expectation on the mock ConsumerGroup that the
+// offset of the partition is marked, which is verified by Close.
+func (cg *ConsumerGroup) ExpectMarkedOffset(topic string, partition int32, offset int64) *ConsumerGroup {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	if cg.expectedMarks[topic] == nil {
+		cg.expectedMarks[topic] = make(map[int32]int64)
+	}
+	cg.expectedMarks[topic][partition] = offset
+	return cg
+}
+
+// ExpectCommits sets an expectation on the mock ConsumerGroup that the
+// sessions call Commit at least n times, which is verified by Close.
+func (cg *ConsumerGroup) ExpectCommits(n int) *ConsumerGroup {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	cg.expectedCommits = n
+	return cg
+}
+
+// MarkedOffset returns the last offset marked for the partition with its
+// metadata, false if none was.
+func (cg *ConsumerGroup) MarkedOffset(topic string, partition int32) (int64, string, bool) {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	marked, ok := cg.marked[topic][partition]
+	return marked.offset, marked.metadata, ok
+}
+
+// CommittedOffset returns the last offset committed for the partition with
+// its metadata, false if none was.
+func (cg *ConsumerGroup) CommittedOffset(topic string, partition int32) (int64, string, bool) {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	committed, ok := cg.committed[topic][partition]
+	return committed.offset, committed.metadata, ok
+}
+
+// Commits returns the number of Commit calls of the sessions.
+func (cg *ConsumerGroup) Commits() int {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	return cg.commits
+}
+
+////////////////////////////////////////////////
+// Implement ConsumerGroup interface
+////////////////////////////////////////////////
+
+// Consume runs a session of the handler, claiming the partitions set with
+// SetClaims or Rebalance, until the context is canceled, the group is
+// rebalanced or closed, or a ConsumeClaim call returns.
+func (cg *ConsumerGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	cg.l.Lock()
+	if cg.closing {
+		cg.l.Unlock()
+		return sarama.ErrClosedConsumerGroup
+	}
+	cg.sessions.Add(1)
+	defer cg.sessions.Done()
+	cg.generation++
+	sess := &ConsumerGroupSession{
+		parent:     cg,
+		claims:     cg.sessionClaims(topics),
+		generation: cg.generation,
+	}
+	rebalance := cg.rebalance
+	cg.l.Unlock()
+	sess.ctx, sess.cancel = context.WithCancel(ctx)
+	defer sess.cancel()
+
+	if err := handler.Setup(sess); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for topic, partitions := range sess.claims {
+		for _, partition := range partitions {
+			claim := cg.newClaim(sess, topic, partition)
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				cg.feed(sess, claim)
+			}()
+			go func() {
+				defer wg.Done()
+				// the session ends with any of its claims, as with sarama
+				defer sess.cancel()
+				if err := handler.ConsumeClaim(sess, claim); err != nil {
+					cg.handleError(err)
+				}
+			}()
+		}
+	}
+
+	select {
+	case <-sess.ctx.Done():
+	case <-rebalance:
+	case <-cg.closed:
+	}
+	sess.cancel()
+	wg.Wait()
+
+	err := handler.Cleanup(sess)
+	if cg.config.Consumer.Offsets.AutoCommit.Enable {
+		sess.commit(false)
+	}
+	return err
+}
+
+// Errors returns the channel of the errors yielded with YieldError and, when
+// Consumer.Return.Errors is set, of the errors returned by ConsumeClaim.
+func (cg *ConsumerGroup) Errors() <-chan error {
+	return cg.errors
+}
+
+// Close ends the current session, closes the Errors channel and verifies the
+// expectations set on the mock ConsumerGroup.
+func (cg *ConsumerGroup) Close() error {
+	cg.closeOnce.Do(func() {
+		cg.l.Lock()
+		cg.closing = true
+		cg.l.Unlock()
+		close(cg.closed)
+		cg.sessions.Wait()
+		close(cg.errors)
+
+		cg.l.Lock()
+		defer cg.l.Unlock()
+		for topic, partitions := range cg.expectedMarks {
+			for partition, offset := range partitions {
+				if marked, ok := cg.marked[topic][partition]; !ok || marked.offset != offset {
+					cg.t.Errorf("Expected offset %d to be marked for %s/%d, but got %d.", offset, topic, partition, marked.offset)
+				}
+			}
+		}
+		if cg.commits < cg.expectedCommits {
+			cg.t.Errorf("Expected at least %d commits, but got %d.", cg.expectedCommits, cg.commits)
+		}
+	})
+	return nil
+}
+
+// Pause suspends the delivery of the messages of the partitions.
+func (cg *ConsumerGroup) Pause(partitions map[string][]int32) {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	for topic, ids := range partitions {
+		if cg.paused[topic] == nil {
+			cg.paused[topic] = make(map[int32]bool)
+		}
+		for _, partition := range ids {
+			cg.paused[topic][partition] = true
+		}
+	}
+}
+
+// Resume resumes the delivery of the messages of the partitions.
+func (cg *ConsumerGroup) Resume(partitions map[string][]int32) {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	for topic, ids := range partitions {
+		for _, partition := range ids {
+			delete(cg.paused[topic], partition)
+		}
+	}
+	cg.wakeUp()
+}
+
+// PauseAll suspends the delivery of the messages of all partitions.
+func (cg *ConsumerGroup) PauseAll() {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	cg.pausedAll = true
+}
+
+// ResumeAll resumes the delivery of the messages of all partitions.
+func (cg *ConsumerGroup) ResumeAll() {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+	cg.paused = make(map[string]map[int32]bool)
+	cg.pausedAll = false
+	cg.wakeUp()
+}
+
+func (cg *ConsumerGroup) handleError(err error) {
+	if !cg.config.Consumer.Return.Errors {
+		sarama.Logger.Println(err)
+		return
+	}
+	select {
+	case cg.errors <- err:
+	case <-cg.closed:
+	}
+}
+
+// sessionClaims returns the claims of a session consuming the topics
+func (cg *ConsumerGroup) sessionClaims(topics []string) map[string][]int32 {
+	claims := make(map[string][]int32, len(topics))
+	for _, topic := range topics {
+		var partitions []int32
+		if cg.claims != nil {
+			partitions = append(partitions, cg.claims[topic]...)
+		} else {
+			for partition := range cg.highWaterMarks[topic] {
+				partitions = append(partitions, partition)
+			}
+			sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+		}
+		if len(partitions) > 0 {
+			claims[topic] = partitions
+		}
+	}
+	return claims
+}
+
+func (cg *ConsumerGroup) newClaim(sess *ConsumerGroupSession, topic string, partition int32) *ConsumerGroupClaim {
+	cg.l.Lock()
+	defer cg.l.Unlock()
+
+	initialOffset := sarama.OffsetOldest
+	if committed, ok := cg.committed[topic][partition]; ok {
+		initialOffset = committed.offset
+	} else if queue := cg.messages[topic][partition]; len(queue) > 0 {
+		initialOffset = queue[0].Offset
+	}
+	return &ConsumerGroupClaim{
+		topic:               topic,
+		partition:           partition,
+		initialOffset:       initialOffset,
+		highWaterMarkOffset: cg.highWaterMark(topic, partition),
+		messages:            make(chan *sarama.ConsumerMessage),
+	}
+}
+
+// feed delivers the queued messages of the partition of the claim until the
+// end of the session
+func (cg *ConsumerGroup) feed(sess *ConsumerGroupSession, claim *ConsumerGroupClaim) {
+	defer close(claim.messages)
+	for {
+		cg.l.Lock()
+		queue := cg.messages[claim.topic][claim.partition]
+		paused := cg.pausedAll || cg.paused[claim.topic][claim.partition]
+		notify := cg.notify
+		cg.l.Unlock()
+
+		if len(queue) == 0 || paused {
+			select {
+			case <-notify:
+				continue
+			case <-sess.ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case claim.messages <- queue[0]:
+			cg.l.Lock()
+			cg.messages[claim.topic][claim.partition] = cg.messages[claim.topic][claim.partition][1:]
+			cg.l.Unlock()
+		case <-sess.ctx.Done():
+			return
+		}
+	}
+}
+
+// wakeUp notifies the feeders of a change of the messages or paused
+// partitions, with the lock held
+func (cg *ConsumerGroup) wakeUp() {
+	close(cg.notify)
+	cg.notify = make(chan none)
+}
+
+// highWaterMark returns the next offset of the partition, with the lock held
+func (cg *ConsumerGroup) highWaterMark(topic string, partition int32) int64 {
+	if cg.highWaterMarks[topic] == nil {
+		cg.highWaterMarks[topic] = make(map[int32]int64)
+	}
+	return cg.highWaterMarks[topic][partition]
+}
+
+// ConsumerGroupSession implements sarama's ConsumerGroupSession interface
+// for the sessions of the mock ConsumerGroup.
+type ConsumerGroupSession struct {
+	parent     *ConsumerGroup
+	claims     map[string][]int32
+	generation int32
+	ctx        context.Context
+	cancel     func()
+}
+
+// Claims returns the partitions claimed by the session.
+func (s *ConsumerGroupSession) Claims() map[string][]int32 {
+	return copyClaims(s.claims)
+}
+
+// MemberID returns the member ID of the mock ConsumerGroup.
+func (s *ConsumerGroupSession) MemberID() string {
+	return s.parent.memberID
+}
+
+// GenerationID returns the generation of the session, incremented by every
+// Consume call.
+func (s *ConsumerGroupSession) GenerationID() int32 {
+	return s.generation
+}
+
+// MarkOffset marks the offset of the partition, unless a greater one is
+// already marked.
+func (s *ConsumerGroupSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	s.parent.l.Lock()
+	defer s.parent.l.Unlock()
+	if marked, ok := s.parent.marked[topic][partition]; ok && marked.offset > offset {
+		return
+	}
+	setMarkedOffset(s.parent.marked, topic, partition, markedOffset{offset: offset, metadata: metadata})
+}
+
+// ResetOffset marks the offset of the partition, even if a greater one is
+// already marked.
+func (s *ConsumerGroupSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+	s.parent.l.Lock()
+	defer s.parent.l.Unlock()
+	setMarkedOffset(s.parent.marked, topic, partition, markedOffset{offset: offset, metadata: metadata})
+}
+
+// MarkMessage marks the offset following the one of the message.
+func (s *ConsumerGroupSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+// Commit commits the marked offsets.
+func (s *ConsumerGroupSession) Commit() {
+	s.commit(true)
+}
+
+func (s *ConsumerGroupSession) commit(explicit bool) {
+	s.parent.l.Lock()
+	defer s.parent.l.Unlock()
+	if explicit {
+		s.parent.commits++
+	}
+	for topic, partitions := range s.parent.marked {
+		for partition, marked := range partitions {
+			setMarkedOffset(s.parent.committed, topic, partition, marked)
+		}
+	}
+}
+
+// Context returns the context of the session, canceled when it ends.
+func (s *ConsumerGroupSession) Context() context.Context {
+	return s.ctx
+}
+
+// ConsumerGroupClaim implements sarama's ConsumerGroupClaim interface for the
+// claims of the sessions of the mock ConsumerGroup.
+type ConsumerGroupClaim struct {
+	topic               string
+	partition           int32
+	initialOffset       int64
+	highWaterMarkOffset int64
+	messages            chan *sarama.ConsumerMessage
+}
+
+// Topic returns the topic of the claim.
+func (c *ConsumerGroupClaim) Topic() string { return c.topic }
+
+// Partition returns the partition of the claim.
+func (c *ConsumerGroupClaim) Partition() int32 { return c.partition }
+
+// InitialOffset returns the committed offset of the partition, or the offset
+// of its first queued message.
+func (c *ConsumerGroupClaim) InitialOffset() int64 { return c.initialOffset }
+
+// HighWaterMarkOffset returns the offset of the next message yielded for the
+// partition when the session started.
+func (c *ConsumerGroupClaim) HighWaterMarkOffset() int64 { return c.highWaterMarkOffset }
+
+// Messages returns the messages delivered to the claim, closed at the end of
+// the session.
+func (c *ConsumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func setMarkedOffset(offsets map[string]map[int32]markedOffset, topic string, partition int32, offset markedOffset) {
+	if offsets[topic] == nil {
+		offsets[topic] = make(map[int32]markedOffset)
+	}
+	offsets[topic][partition] = offset
+}
+
+func copyClaims(claims map[string][]int32) map[string][]int32 {
+	if claims == nil {
+		return nil
+	}
+	res := make(map[string][]int32, len(claims))
+	for topic, partitions := range claims {
+		res[topic] = append([]int32(nil), partitions...)
+	}
+	return res
+}
@@ -0,0 +1,198 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+type testConsumerGroupHandler struct {
+	sessions chan sarama.ConsumerGroupSession
+	messages chan *sarama.ConsumerMessage
+	commit   bool
+}
+
+func newTestConsumerGroupHandler() *testConsumerGroupHandler {
+	return &testConsumerGroupHandler{
+		sessions: make(chan sarama.ConsumerGroupSession, 10),
+		messages: make(chan *sarama.ConsumerMessage, 10),
+	}
+}
+
+func (h *testConsumerGroupHandler) Setup(sess sarama.ConsumerGroupSession) error {
+	h.sessions <- sess
+	return nil
+}
+
+func (h *testConsumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h *testConsumerGroupHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		sess.MarkMessage(msg, "")
+		if h.commit {
+			sess.Commit()
+		}
+		h.messages <- msg
+	}
+	return nil
+}
+
+func TestMockConsumerGroupImplementsConsumerGroupInterface(t *testing.T) {
+	var cg interface{} = &ConsumerGroup{}
+	if _, ok := cg.(sarama.ConsumerGroup); !ok {
+		t.Error("The mock consumer group should implement the sarama.ConsumerGroup interface.")
+	}
+	var sess interface{} = &ConsumerGroupSession{}
+	if _, ok := sess.(sarama.ConsumerGroupSession); !ok {
+		t.Error("The mock session should implement the sarama.ConsumerGroupSession interface.")
+	}
+	var claim interface{} = &ConsumerGroupClaim{}
+	if _, ok := claim.(sarama.ConsumerGroupClaim); !ok {
+		t.Error("The mock claim should implement the sarama.ConsumerGroupClaim interface.")
+	}
+}
+
+func TestConsumerGroupFeedsHandler(t *testing.T) {
+	trm := newTestReporterMock()
+	cg := NewConsumerGroup(trm, NewTestConfig())
+	cg.YieldMessage("my_topic", 0, &sarama.ConsumerMessage{Value: []byte("foo")})
+	cg.YieldMessage("my_topic", 0, &sarama.ConsumerMessage{Value: []byte("bar")})
+	cg.ExpectMarkedOffset("my_topic", 0, 2).ExpectCommits(2)
+
+	handler := newTestConsumerGroupHandler()
+	handler.commit = true
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- cg.Consume(ctx, []string{"my_topic"}, handler) }()
+
+	sess := <-handler.sessions
+	if claims := sess.Claims(); len(claims["my_topic"]) != 1 || claims["my_topic"][0] != 0 {
+		t.Errorf("Unexpected claims %v", claims)
+	}
+	for i, value := range []string{"foo", "bar"} {
+		msg := <-handler.messages
+		if msg.Offset != int64(i) || string(msg.Value) != value {
+			t.Errorf("Unexpected message %q at offset %d", msg.Value, msg.Offset)
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+	if offset, _, ok := cg.CommittedOffset("my_topic", 0); !ok || offset != 2 {
+		t.Errorf("Expected committed offset 2, got %d", offset)
+	}
+	if err := cg.Close(); err != nil {
+		t.Error(err)
+	}
+	if len(trm.errors) != 0 {
+		t.Errorf("Expected no expectation violations, got %v", trm.errors)
+	}
+}
+
+func TestConsumerGroupRebalances(t *testing.T) {
+	trm := newTestReporterMock()
+	cg := NewConsumerGroup(trm, NewTestConfig())
+	cg.SetClaims(map[string][]int32{"my_topic": {0, 1}})
+
+	handler := newTestConsumerGroupHandler()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for ctx.Err() == nil {
+			if err := cg.Consume(ctx, []string{"my_topic"}, handler); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	first := <-handler.sessions
+	cg.YieldMessage("my_topic", 1, &sarama.ConsumerMessage{})
+	<-handler.messages
+
+	// partition 1 is revoked
+	cg.Rebalance(map[string][]int32{"my_topic": {0}})
+	second := <-handler.sessions
+	if first.Context().Err() == nil {
+		t.Error("Expected the first session to end with the rebalance")
+	}
+	if second.GenerationID() != first.GenerationID()+1 {
+		t.Errorf("Expected generation %d, got %d", first.GenerationID()+1, second.GenerationID())
+	}
+	if claims := second.Claims(); len(claims["my_topic"]) != 1 || claims["my_topic"][0] != 0 {
+		t.Errorf("Unexpected claims %v after the revocation", claims)
+	}
+
+	cg.YieldMessage("my_topic", 1, &sarama.ConsumerMessage{})
+	cg.YieldMessage("my_topic", 0, &sarama.ConsumerMessage{})
+	if msg := <-handler.messages; msg.Partition != 0 {
+		t.Errorf("Expected a message of partition 0 only, got one of partition %d", msg.Partition)
+	}
+	select {
+	case msg := <-handler.messages:
+		t.Errorf("Unexpected message of partition %d", msg.Partition)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancel()
+	wg.Wait()
+	_ = cg.Close()
+	if offset, _, ok := cg.CommittedOffset("my_topic", 1); !ok || offset != 1 {
+		t.Errorf("Expected the offset of partition 1 to be committed on the rebalance, got %d", offset)
+	}
+	if len(trm.errors) != 0 {
+		t.Errorf("Expected no expectation violations, got %v", trm.errors)
+	}
+}
+
+func TestConsumerGroupPausesAndReportsViolations(t *testing.T) {
+	trm := newTestReporterMock()
+	config := NewTestConfig()
+	config.Consumer.Offsets.AutoCommit.Enable = false
+	cg := NewConsumerGroup(trm, config)
+	cg.ExpectMarkedOffset("my_topic", 0, 5).ExpectCommits(1)
+	cg.YieldError(sarama.ErrOutOfBrokers)
+
+	handler := newTestConsumerGroupHandler()
+	cg.PauseAll()
+	cg.YieldMessage("my_topic", 0, &sarama.ConsumerMessage{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- cg.Consume(ctx, []string{"my_topic"}, handler) }()
+
+	<-handler.sessions
+	select {
+	case <-handler.messages:
+		t.Error("Unexpected message of a paused partition")
+	case <-time.After(10 * time.Millisecond):
+	}
+	cg.ResumeAll()
+	<-handler.messages
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+	if _, _, ok := cg.CommittedOffset("my_topic", 0); ok {
+		t.Error("Expected no commit without auto-commit")
+	}
+	if err := <-cg.Errors(); !errors.Is(err, sarama.ErrOutOfBrokers) {
+		t.Errorf("Expected ErrOutOfBrokers, got %v", err)
+	}
+
+	_ = cg.Close()
+	if err := cg.Consume(context.Background(), []string{"my_topic"}, handler); !errors.Is(err, sarama.ErrClosedConsumerGroup) {
+		t.Errorf("Expected ErrClosedConsumerGroup, got %v", err)
+	}
+	if len(trm.errors) != 2 {
+		t.Errorf("Expected 2 expectation violations, got %v", trm.errors)
+	}
+}
@@ -14,8 +14,12 @@ guarantee of Sarama as it is still considered experimental.
 package mocks
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/Shopify/sarama"
 )
@@ -50,6 +54,113 @@ func messageValueChecker(f ValueChecker) MessageChecker {
 	}
 }
 
+// MatchAll returns a MessageChecker accepting the messages every one of the given
+// checkers accepts. The checkers run in order and the first error is returned.
+func MatchAll(checkers ...MessageChecker) MessageChecker {
+	return func(msg *sarama.ProducerMessage) error {
+		for _, checker := range checkers {
+			if checker == nil {
+				continue
+			}
+			if err := checker(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// MatchTopic returns a MessageChecker accepting the messages produced to topic.
+func MatchTopic(topic string) MessageChecker {
+	return func(msg *sarama.ProducerMessage) error {
+		if msg.Topic != topic {
+			return fmt.Errorf("Expected topic %q, got %q", topic, msg.Topic)
+		}
+		return nil
+	}
+}
+
+// MatchPartition returns a MessageChecker accepting the messages assigned to partition
+// by the partitioner of the mock producer.
+func MatchPartition(partition int32) MessageChecker {
+	return func(msg *sarama.ProducerMessage) error {
+		if msg.Partition != partition {
+			return fmt.Errorf("Expected partition %d, got %d", partition, msg.Partition)
+		}
+		return nil
+	}
+}
+
+// MatchKey returns a MessageChecker accepting the messages whose encoded key is key.
+// A nil key only accepts the messages without a key.
+func MatchKey(key []byte) MessageChecker {
+	return func(msg *sarama.ProducerMessage) error {
+		return matchEncoder("key", msg.Key, key)
+	}
+}
+
+// MatchValue returns a MessageChecker accepting the messages whose encoded value is value.
+// A nil value only accepts the messages without a value.
+func MatchValue(value []byte) MessageChecker {
+	return func(msg *sarama.ProducerMessage) error {
+		return matchEncoder("value", msg.Value, value)
+	}
+}
+
+func matchEncoder(field string, encoder sarama.Encoder, expected []byte) error {
+	if encoder == nil {
+		if expected != nil {
+			return fmt.Errorf("Expected %s %q, got none", field, expected)
+		}
+		return nil
+	}
+	actual, err := encoder.Encode()
+	if err != nil {
+		return fmt.Errorf("Input message %s encoding failed: %w", field, err)
+	}
+	if expected == nil {
+		return fmt.Errorf("Expected no %s, got %q", field, actual)
+	}
+	if !bytes.Equal(actual, expected) {
+		return fmt.Errorf("Expected %s %q, got %q", field, expected, actual)
+	}
+	return nil
+}
+
+// MatchHeader returns a MessageChecker accepting the messages carrying a header with the
+// given key and value.
+func MatchHeader(key, value []byte) MessageChecker {
+	return func(msg *sarama.ProducerMessage) error {
+		for _, header := range msg.Headers {
+			if bytes.Equal(header.Key, key) && bytes.Equal(header.Value, value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("Expected header %q with value %q, got none", key, value)
+	}
+}
+
+// MatchTimestamp returns a MessageChecker accepting the messages with the given timestamp.
+func MatchTimestamp(timestamp time.Time) MessageChecker {
+	return func(msg *sarama.ProducerMessage) error {
+		if !msg.Timestamp.Equal(timestamp) {
+			return fmt.Errorf("Expected timestamp %v, got %v", timestamp, msg.Timestamp)
+		}
+		return nil
+	}
+}
+
+// MatchMetadata returns a MessageChecker accepting the messages whose Metadata field is
+// deeply equal to metadata.
+func MatchMetadata(metadata interface{}) MessageChecker {
+	return func(msg *sarama.ProducerMessage) error {
+		if !reflect.DeepEqual(msg.Metadata, metadata) {
+			return fmt.Errorf("Expected metadata %v, got %v", metadata, msg.Metadata)
+		}
+		return nil
+	}
+}
+
 var (
 	errProduceSuccess              error = nil
 	errOutOfExpectations                 = errors.New("No more expectations set on mock")
@@ -63,6 +174,30 @@ type producerExpectation struct {
 	CheckFunction MessageChecker
 }
 
+func (pe *producerExpectation) check(msg *sarama.ProducerMessage) error {
+	if pe.CheckFunction == nil {
+		return nil
+	}
+	return pe.CheckFunction(msg)
+}
+
+// matchExpectation removes the first of the expectations accepting msg and returns it along
+// with the remaining expectations. It is used by the mock producers once their expectations
+// are no longer ordered; if none accepts msg, the expectations are returned unchanged.
+func matchExpectation(expectations []*producerExpectation, msg *sarama.ProducerMessage) (*producerExpectation, []*producerExpectation, error) {
+	var firstErr error
+	for i, expectation := range expectations {
+		err := expectation.check(msg)
+		if err == nil {
+			return expectation, append(expectations[:i:i], expectations[i+1:]...), nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, expectations, fmt.Errorf("No expectation matches the message: %w", firstErr)
+}
+
 // TopicConfig describes a mock topic structure for the mock producers’ partitioning needs.
 type TopicConfig struct {
 	overridePartitions map[string]int32
@@ -98,6 +233,118 @@ func (pc *TopicConfig) partitions(topic string) int32 {
 	return pc.defaultPartitions
 }
 
+// txnState is the state of the transactions of a producer mock, which is
+// transactional if created with a config setting Producer.Transaction.ID.
+// The messages are produced whether a transaction is ongoing or not.
+type txnState struct {
+	l             sync.Mutex
+	transactional bool
+	ongoing       bool
+	// prepared is the state of the ongoing transaction if prepared for a
+	// two-phase commit
+	prepared *sarama.PreparedTxnState
+	epoch    int16
+	// committed and aborted count the ended transactions
+	committed, aborted int
+}
+
+func (s *txnState) begin() error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	switch {
+	case !s.transactional:
+		return sarama.ErrNonTransactedProducer
+	case s.ongoing:
+		return sarama.ErrTransactionNotReady
+	}
+	s.ongoing = true
+	return nil
+}
+
+func (s *txnState) end(commit bool) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	switch {
+	case !s.transactional:
+		return sarama.ErrNonTransactedProducer
+	case !s.ongoing, s.prepared != nil:
+		return sarama.ErrTransactionNotReady
+	}
+	s.ongoing = false
+	if commit {
+		s.committed++
+	} else {
+		s.aborted++
+	}
+	return nil
+}
+
+func (s *txnState) addOffsets() error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	switch {
+	case !s.transactional:
+		return sarama.ErrNonTransactedProducer
+	case !s.ongoing, s.prepared != nil:
+		return sarama.ErrTransactionNotReady
+	}
+	return nil
+}
+
+func (s *txnState) prepare() (sarama.PreparedTxnState, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	switch {
+	case !s.transactional:
+		return sarama.PreparedTxnState{}, sarama.ErrNonTransactedProducer
+	case !s.ongoing, s.prepared != nil:
+		return sarama.PreparedTxnState{}, sarama.ErrTransactionNotReady
+	}
+	// each transaction is prepared with its own epoch
+	s.prepared = &sarama.PreparedTxnState{ProducerEpoch: s.epoch}
+	s.epoch++
+	return *s.prepared, nil
+}
+
+func (s *txnState) complete(state sarama.PreparedTxnState) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	switch {
+	case !s.transactional:
+		return sarama.ErrNonTransactedProducer
+	case s.prepared == nil:
+		return sarama.ErrTransactionNotReady
+	}
+	if *s.prepared == state {
+		s.committed++
+	} else {
+		s.aborted++
+	}
+	s.ongoing = false
+	s.prepared = nil
+	return nil
+}
+
+func (s *txnState) status() sarama.TxnStatus {
+	s.l.Lock()
+	defer s.l.Unlock()
+	switch {
+	case !s.transactional:
+		return sarama.TxnUninitialized
+	case s.prepared != nil:
+		return sarama.TxnPreparedTransaction
+	case s.ongoing:
+		return sarama.TxnInTransaction
+	}
+	return sarama.TxnReady
+}
+
+func (s *txnState) counts() (committed, aborted int) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.committed, s.aborted
+}
+
 // NewTestConfig returns a config meant to be used by tests.
 // Due to inconsistencies with the request versions the clients send using the default Kafka version
 // and the response versions our mocks use, we default to the minimum Kafka version in most tests
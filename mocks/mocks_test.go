@@ -0,0 +1,57 @@
+package mocks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestMessageMatchers(t *testing.T) {
+	timestamp := time.Unix(1600000000, 0)
+	msg := &sarama.ProducerMessage{
+		Topic:     "my_topic",
+		Key:       sarama.StringEncoder("my_key"),
+		Value:     sarama.ByteEncoder("my_value"),
+		Headers:   []sarama.RecordHeader{{Key: []byte("trace"), Value: []byte("abc")}},
+		Metadata:  map[string]int{"attempt": 1},
+		Partition: 3,
+		Timestamp: timestamp,
+	}
+
+	accepting := map[string]MessageChecker{
+		"topic":     MatchTopic("my_topic"),
+		"key":       MatchKey([]byte("my_key")),
+		"value":     MatchValue([]byte("my_value")),
+		"header":    MatchHeader([]byte("trace"), []byte("abc")),
+		"partition": MatchPartition(3),
+		"timestamp": MatchTimestamp(timestamp.UTC()),
+		"metadata":  MatchMetadata(map[string]int{"attempt": 1}),
+		"all":       MatchAll(MatchTopic("my_topic"), nil, MatchPartition(3)),
+	}
+	for name, checker := range accepting {
+		if err := checker(msg); err != nil {
+			t.Errorf("Expected the %s matcher to accept the message, got %v", name, err)
+		}
+	}
+
+	rejecting := map[string]MessageChecker{
+		"topic":     MatchTopic("other_topic"),
+		"key":       MatchKey(nil),
+		"value":     MatchValue([]byte("other_value")),
+		"header":    MatchHeader([]byte("trace"), []byte("def")),
+		"partition": MatchPartition(4),
+		"timestamp": MatchTimestamp(timestamp.Add(time.Second)),
+		"metadata":  MatchMetadata(map[string]int{"attempt": 2}),
+		"all":       MatchAll(MatchTopic("my_topic"), MatchPartition(4)),
+	}
+	for name, checker := range rejecting {
+		if err := checker(msg); err == nil {
+			t.Errorf("Expected the %s matcher to reject the message", name)
+		}
+	}
+
+	if err := MatchKey([]byte("my_key"))(&sarama.ProducerMessage{}); err == nil {
+		t.Error("Expected the key matcher to reject a message without a key")
+	}
+}
@@ -16,6 +16,8 @@ type SyncProducer struct {
 	t            ErrorReporter
 	expectations []*producerExpectation
 	lastOffset   int64
+	unordered    bool
+	txn          txnState
 
 	*TopicConfig
 	newPartitioner sarama.PartitionerConstructor
@@ -29,13 +31,15 @@ func NewSyncProducer(t ErrorReporter, config *sarama.Config) *SyncProducer {
 	if config == nil {
 		config = sarama.NewConfig()
 	}
-	return &SyncProducer{
+	sp := &SyncProducer{
 		t:              t,
 		expectations:   make([]*producerExpectation, 0),
 		TopicConfig:    NewTopicConfig(),
 		newPartitioner: config.Producer.Partitioner,
 		partitioners:   make(map[string]sarama.Partitioner, 1),
 	}
+	sp.txn.transactional = config.Producer.Transaction.ID != ""
+	return sp
 }
 
 ////////////////////////////////////////////////
@@ -53,8 +57,11 @@ func (sp *SyncProducer) SendMessage(msg *sarama.ProducerMessage) (partition int3
 	defer sp.l.Unlock()
 
 	if len(sp.expectations) > 0 {
-		expectation := sp.expectations[0]
-		sp.expectations = sp.expectations[1:]
+		var expectation *producerExpectation
+		if !sp.unordered {
+			expectation = sp.expectations[0]
+			sp.expectations = sp.expectations[1:]
+		}
 		topic := msg.Topic
 		partition, err := sp.partitioner(topic).Partition(msg, sp.partitions(topic))
 		if err != nil {
@@ -62,12 +69,15 @@ func (sp *SyncProducer) SendMessage(msg *sarama.ProducerMessage) (partition int3
 			return -1, -1, err
 		}
 		msg.Partition = partition
-		if expectation.CheckFunction != nil {
-			errCheck := expectation.CheckFunction(msg)
-			if errCheck != nil {
-				sp.t.Errorf("Check function returned an error: %s", errCheck.Error())
-				return -1, -1, errCheck
-			}
+		var errCheck error
+		if sp.unordered {
+			expectation, sp.expectations, errCheck = matchExpectation(sp.expectations, msg)
+		} else {
+			errCheck = expectation.check(msg)
+		}
+		if errCheck != nil {
+			sp.t.Errorf("Check function returned an error: %s", errCheck.Error())
+			return -1, -1, errCheck
 		}
 		if errors.Is(expectation.Result, errProduceSuccess) {
 			sp.lastOffset++
@@ -89,10 +99,13 @@ func (sp *SyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
 	defer sp.l.Unlock()
 
 	if len(sp.expectations) >= len(msgs) {
-		expectations := sp.expectations[0:len(msgs)]
-		sp.expectations = sp.expectations[len(msgs):]
+		var expectations []*producerExpectation
+		if !sp.unordered {
+			expectations = sp.expectations[0:len(msgs)]
+			sp.expectations = sp.expectations[len(msgs):]
+		}
 
-		for i, expectation := range expectations {
+		for i := range msgs {
 			topic := msgs[i].Topic
 			partition, err := sp.partitioner(topic).Partition(msgs[i], sp.partitions(topic))
 			if err != nil {
@@ -100,12 +113,17 @@ func (sp *SyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
 				return err
 			}
 			msgs[i].Partition = partition
-			if expectation.CheckFunction != nil {
-				errCheck := expectation.CheckFunction(msgs[i])
-				if errCheck != nil {
-					sp.t.Errorf("Check function returned an error: %s", errCheck.Error())
-					return errCheck
-				}
+			var expectation *producerExpectation
+			var errCheck error
+			if sp.unordered {
+				expectation, sp.expectations, errCheck = matchExpectation(sp.expectations, msgs[i])
+			} else {
+				expectation = expectations[i]
+				errCheck = expectation.check(msgs[i])
+			}
+			if errCheck != nil {
+				sp.t.Errorf("Check function returned an error: %s", errCheck.Error())
+				return errCheck
 			}
 			if !errors.Is(expectation.Result, errProduceSuccess) {
 				return expectation.Result
@@ -128,6 +146,69 @@ func (sp *SyncProducer) partitioner(topic string) sarama.Partitioner {
 	return partitioner
 }
 
+// IsTransactional corresponds with the IsTransactional method of sarama's SyncProducer
+// implementation, the mock being transactional if its config sets Producer.Transaction.ID.
+func (sp *SyncProducer) IsTransactional() bool {
+	return sp.txn.transactional
+}
+
+// TxnStatus corresponds with the TxnStatus method of sarama's SyncProducer implementation,
+// the transactions of the mock never failing.
+func (sp *SyncProducer) TxnStatus() sarama.TxnStatus {
+	return sp.txn.status()
+}
+
+// BeginTxn corresponds with the BeginTxn method of sarama's SyncProducer implementation. The
+// messages are handled according to the expectations whether a transaction is ongoing or not.
+func (sp *SyncProducer) BeginTxn() error {
+	return sp.txn.begin()
+}
+
+// CommitTxn corresponds with the CommitTxn method of sarama's SyncProducer implementation.
+func (sp *SyncProducer) CommitTxn() error {
+	return sp.txn.end(true)
+}
+
+// AbortTxn corresponds with the AbortTxn method of sarama's SyncProducer implementation.
+func (sp *SyncProducer) AbortTxn() error {
+	return sp.txn.end(false)
+}
+
+// AddOffsetsToTxn corresponds with the AddOffsetsToTxn method of sarama's SyncProducer
+// implementation.
+func (sp *SyncProducer) AddOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, groupID string) error {
+	return sp.txn.addOffsets()
+}
+
+// AddGroupOffsetsToTxn corresponds with the AddGroupOffsetsToTxn method of sarama's
+// SyncProducer implementation.
+func (sp *SyncProducer) AddGroupOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, group *sarama.ConsumerGroupMetadata) error {
+	return sp.txn.addOffsets()
+}
+
+// AddMessageToTxn corresponds with the AddMessageToTxn method of sarama's SyncProducer
+// implementation.
+func (sp *SyncProducer) AddMessageToTxn(msg *sarama.ConsumerMessage, groupID string, metadata *string) error {
+	return sp.txn.addOffsets()
+}
+
+// PrepareTxn corresponds with the PrepareTxn method of sarama's SyncProducer implementation.
+func (sp *SyncProducer) PrepareTxn() (sarama.PreparedTxnState, error) {
+	return sp.txn.prepare()
+}
+
+// CompleteTxn corresponds with the CompleteTxn method of sarama's SyncProducer
+// implementation, committing the prepared transaction if state is the one returned by
+// PrepareTxn.
+func (sp *SyncProducer) CompleteTxn(state sarama.PreparedTxnState) error {
+	return sp.txn.complete(state)
+}
+
+// Transactions returns the number of transactions committed and aborted.
+func (sp *SyncProducer) Transactions() (committed, aborted int) {
+	return sp.txn.counts()
+}
+
 // Close corresponds with the Close method of sarama's SyncProducer implementation.
 // By closing a mock syncproducer, you also tell it that no more SendMessage calls will follow,
 // so it will write an error to the test state if there's any remaining expectations.
@@ -207,3 +288,15 @@ func (sp *SyncProducer) ExpectSendMessageAndFail(err error) *SyncProducer {
 
 	return sp
 }
+
+// SetExpectationsOrdered sets whether the mock producer meets its expectations in the order they
+// were set, which is the default. Once unordered, every message meets the first remaining
+// expectation whose checker function accepts it, and SendMessage and SendMessages return an
+// error for a message no expectation accepts.
+func (sp *SyncProducer) SetExpectationsOrdered(ordered bool) *SyncProducer {
+	sp.l.Lock()
+	defer sp.l.Unlock()
+	sp.unordered = !ordered
+
+	return sp
+}
@@ -245,6 +245,38 @@ func TestSyncProducerSendMessagesFaultyEncoder(t *testing.T) {
 	}
 }
 
+func TestSyncProducerWithUnorderedExpectations(t *testing.T) {
+	trm := newTestReporterMock()
+
+	sp := NewSyncProducer(trm, nil).
+		SetExpectationsOrdered(false).
+		ExpectSendMessageWithMessageCheckerFunctionAndSucceed(MatchHeader([]byte("id"), []byte("1"))).
+		ExpectSendMessageWithMessageCheckerFunctionAndSucceed(MatchHeader([]byte("id"), []byte("2"))).
+		ExpectSendMessageWithMessageCheckerFunctionAndSucceed(MatchHeader([]byte("id"), []byte("3")))
+
+	msgs := []*sarama.ProducerMessage{
+		{Topic: "test", Headers: []sarama.RecordHeader{{Key: []byte("id"), Value: []byte("2")}}},
+		{Topic: "test", Headers: []sarama.RecordHeader{{Key: []byte("id"), Value: []byte("1")}}},
+	}
+	if err := sp.SendMessages(msgs); err != nil {
+		t.Error("No error expected on SendMessages call, found: ", err)
+	}
+
+	msg := &sarama.ProducerMessage{Topic: "test", Headers: []sarama.RecordHeader{{Key: []byte("id"), Value: []byte("4")}}}
+	if _, _, err := sp.SendMessage(msg); err == nil || !strings.HasPrefix(err.Error(), "No expectation matches") {
+		t.Error("Error expected for an unmatched message, found: ", err)
+	}
+
+	if err := sp.Close(); err != nil {
+		t.Error(err)
+	}
+
+	// the unmatched message and the expectation of the third message
+	if len(trm.errors) != 2 {
+		t.Errorf("Expected to report 2 errors, found %v", trm.errors)
+	}
+}
+
 type faultyEncoder []byte
 
 func (f faultyEncoder) Encode() ([]byte, error) {
@@ -0,0 +1,236 @@
+package sarama
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/xdg-go/scram"
+)
+
+// MockSASLAuthenticator is a stateful MockResponse authenticating the clients
+// of a MockBroker. It answers the SaslHandshake and SaslAuthenticate requests
+// of the PLAIN, SCRAM-SHA-256 and SCRAM-SHA-512 mechanisms against the users
+// set with SetUser, so that the security configuration of the clients can be
+// covered by unit tests.
+//
+// Only the v1 handshake is supported, as the v0 one exchanges the SASL
+// messages outside of Kafka requests, so the clients must set
+// Net.SASL.Version to SASLHandshakeV1 and Version to V1_0_0_0 or later. The
+// SCRAM exchanges use the mechanism of the last SCRAM handshake, so
+// connections authenticating concurrently must agree on it.
+type MockSASLAuthenticator struct {
+	t TestReporter
+
+	lock              sync.Mutex
+	mechanisms        []string
+	users             map[string]string
+	credentials       map[string]map[string]scram.StoredCredentials
+	scramMechanism    string
+	conversations     map[string]*scram.ServerConversation
+	sessionLifetimeMs int64
+	authenticated     []string
+}
+
+// NewMockSASLAuthenticator returns an authenticator enabling the PLAIN,
+// SCRAM-SHA-256 and SCRAM-SHA-512 mechanisms, without any user.
+func NewMockSASLAuthenticator(t TestReporter) *MockSASLAuthenticator {
+	return &MockSASLAuthenticator{
+		t:             t,
+		mechanisms:    []string{SASLTypePlaintext, SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512},
+		users:         make(map[string]string),
+		credentials:   make(map[string]map[string]scram.StoredCredentials),
+		conversations: make(map[string]*scram.ServerConversation),
+	}
+}
+
+// RegisterHandlers adds the authenticator as the MockResponse of the requests
+// it answers to the handler map, which is returned for SetHandlerByMap.
+func (a *MockSASLAuthenticator) RegisterHandlers(handlerMap map[string]MockResponse) map[string]MockResponse {
+	if handlerMap == nil {
+		handlerMap = make(map[string]MockResponse)
+	}
+	handlerMap["SaslHandshakeRequest"] = a
+	handlerMap["SaslAuthenticateRequest"] = a
+	return handlerMap
+}
+
+// SetMechanisms sets the mechanisms enabled by the authenticator, the
+// handshakes of the others failing with ErrUnsupportedSASLMechanism.
+func (a *MockSASLAuthenticator) SetMechanisms(mechanisms ...SASLMechanism) *MockSASLAuthenticator {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.mechanisms = a.mechanisms[:0]
+	for _, mechanism := range mechanisms {
+		a.mechanisms = append(a.mechanisms, string(mechanism))
+	}
+	return a
+}
+
+// SetUser adds a user, or changes its password.
+func (a *MockSASLAuthenticator) SetUser(user, password string) *MockSASLAuthenticator {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.users[user] = password
+	delete(a.credentials, user)
+	return a
+}
+
+// SetSessionLifetimeMs sets the session lifetime of the successful
+// authentications, to cover the re-authentication of the clients (KIP-368).
+func (a *MockSASLAuthenticator) SetSessionLifetimeMs(sessionLifetimeMs int64) *MockSASLAuthenticator {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.sessionLifetimeMs = sessionLifetimeMs
+	return a
+}
+
+// Authenticated returns the users authenticated successfully, in order.
+func (a *MockSASLAuthenticator) Authenticated() []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return append([]string(nil), a.authenticated...)
+}
+
+func (a *MockSASLAuthenticator) For(reqBody versionedDecoder) encoderWithHeader {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	switch req := reqBody.(type) {
+	case *SaslHandshakeRequest:
+		return a.handshake(req)
+	case *SaslAuthenticateRequest:
+		res := &SaslAuthenticateResponse{Version: req.Version}
+		authBytes, user, err := a.authenticate(req.SaslAuthBytes)
+		switch {
+		case err != nil:
+			msg := err.Error()
+			res.Err = ErrSASLAuthenticationFailed
+			res.ErrorMessage = &msg
+		case user != "":
+			a.authenticated = append(a.authenticated, user)
+			if req.Version >= 1 {
+				res.SessionLifetimeMs = a.sessionLifetimeMs
+			}
+		}
+		res.SaslAuthBytes = authBytes
+		return res
+	}
+	a.t.Errorf("MockSASLAuthenticator does not answer %T", reqBody)
+	return nil
+}
+
+func (a *MockSASLAuthenticator) handshake(req *SaslHandshakeRequest) *SaslHandshakeResponse {
+	res := &SaslHandshakeResponse{
+		Err:               ErrUnsupportedSASLMechanism,
+		EnabledMechanisms: append([]string(nil), a.mechanisms...),
+	}
+	for _, mechanism := range a.mechanisms {
+		if mechanism == req.Mechanism {
+			res.Err = ErrNoError
+		}
+	}
+	if res.Err == ErrNoError && strings.HasPrefix(req.Mechanism, "SCRAM-") {
+		a.scramMechanism = req.Mechanism
+	}
+	return res
+}
+
+// authenticate handles a SASL message, returning the reply and the user
+// once authenticated.
+func (a *MockSASLAuthenticator) authenticate(msg []byte) ([]byte, string, error) {
+	switch {
+	case bytes.HasPrefix(msg, []byte("c=")):
+		return a.scramFinal(string(msg))
+	case bytes.HasPrefix(msg, []byte("n,")), bytes.HasPrefix(msg, []byte("y,")), bytes.HasPrefix(msg, []byte("p=")):
+		return a.scramFirst(string(msg))
+	}
+
+	// PLAIN: authzid NUL user NUL password
+	fields := bytes.Split(msg, []byte{0})
+	if len(fields) != 3 {
+		return nil, "", errors.New("invalid SASL/PLAIN message")
+	}
+	user, password := string(fields[1]), string(fields[2])
+	if expected, ok := a.users[user]; !ok || expected != password {
+		return nil, "", fmt.Errorf("invalid username or password for %s", user)
+	}
+	return nil, user, nil
+}
+
+func (a *MockSASLAuthenticator) scramFirst(msg string) ([]byte, string, error) {
+	server, err := a.scramHashGenerator().NewServer(func(user string) (scram.StoredCredentials, error) {
+		return a.scramCredentials(user)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	conversation := server.NewConversation()
+	reply, err := conversation.Step(msg)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, field := range strings.Split(reply, ",") {
+		if strings.HasPrefix(field, "r=") {
+			a.conversations[field] = conversation
+		}
+	}
+	return []byte(reply), "", nil
+}
+
+func (a *MockSASLAuthenticator) scramFinal(msg string) ([]byte, string, error) {
+	var conversation *scram.ServerConversation
+	for _, field := range strings.Split(msg, ",") {
+		if strings.HasPrefix(field, "r=") {
+			conversation = a.conversations[field]
+			delete(a.conversations, field)
+		}
+	}
+	if conversation == nil {
+		return nil, "", errors.New("unknown SCRAM exchange")
+	}
+
+	reply, err := conversation.Step(msg)
+	if err != nil || !conversation.Valid() {
+		return nil, "", fmt.Errorf("invalid SCRAM credentials for %s: %v", conversation.Username(), err)
+	}
+	return []byte(reply), conversation.Username(), nil
+}
+
+func (a *MockSASLAuthenticator) scramHashGenerator() scram.HashGeneratorFcn {
+	if a.scramMechanism == SASLTypeSCRAMSHA256 {
+		return scram.SHA256
+	}
+	return scram.SHA512
+}
+
+// scramCredentials derives the SCRAM credentials of a user with the
+// mechanism of the last handshake, the salt being drawn on first use.
+func (a *MockSASLAuthenticator) scramCredentials(user string) (scram.StoredCredentials, error) {
+	password, ok := a.users[user]
+	if !ok {
+		return scram.StoredCredentials{}, fmt.Errorf("unknown user %s", user)
+	}
+	if credentials, ok := a.credentials[user][a.scramMechanism]; ok {
+		return credentials, nil
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return scram.StoredCredentials{}, err
+	}
+	client, err := a.scramHashGenerator().NewClient(user, password, "")
+	if err != nil {
+		return scram.StoredCredentials{}, err
+	}
+	credentials := client.GetStoredCredentials(scram.KeyFactors{Salt: string(salt), Iters: 4096})
+	if a.credentials[user] == nil {
+		a.credentials[user] = make(map[string]scram.StoredCredentials)
+	}
+	a.credentials[user][a.scramMechanism] = credentials
+	return credentials, nil
+}
@@ -0,0 +1,76 @@
+package sarama
+
+import (
+	"crypto/tls"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newMockSASLConfig(mechanism SASLMechanism, user, password string) *Config {
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Version = SASLHandshakeV1
+	config.Net.SASL.Mechanism = mechanism
+	config.Net.SASL.User = user
+	config.Net.SASL.Password = password
+	return config
+}
+
+func TestMockSASLAuthenticator(t *testing.T) {
+	for _, mechanism := range []SASLMechanism{SASLTypePlaintext, SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512} {
+		t.Run(string(mechanism), func(t *testing.T) {
+			mb := NewMockBrokerTLS(t, 1, nil)
+			defer mb.Close()
+			authenticator := NewMockSASLAuthenticator(t).SetUser("alice", "secret")
+			mb.SetHandlerByMap(authenticator.RegisterHandlers(map[string]MockResponse{
+				"MetadataRequest": NewMockMetadataResponse(t).SetBroker(mb.Addr(), mb.BrokerID()),
+			}))
+
+			for _, password := range []string{"wrong", "secret"} {
+				config := newMockSASLConfig(mechanism, "alice", password)
+				config.Net.TLS.Enable = true
+				config.Net.TLS.Config = &tls.Config{RootCAs: mb.CertPool(), MinVersion: tls.VersionTLS12}
+
+				broker := NewBroker(mb.Addr())
+				if err := broker.Open(config); err != nil {
+					t.Fatal(err)
+				}
+				_, err := broker.GetMetadata(&MetadataRequest{})
+				if password == "wrong" && !errors.Is(err, ErrSASLAuthenticationFailed) {
+					t.Errorf("expected ErrSASLAuthenticationFailed with a wrong password, got %v", err)
+				}
+				if password == "secret" && err != nil {
+					t.Errorf("expected the authentication to succeed, got %v", err)
+				}
+				_ = broker.Close()
+			}
+
+			if users := authenticator.Authenticated(); !reflect.DeepEqual(users, []string{"alice"}) {
+				t.Errorf("expected alice to be authenticated once, got %v", users)
+			}
+		})
+	}
+}
+
+func TestMockSASLAuthenticatorMechanisms(t *testing.T) {
+	mb := NewMockBroker(t, 1)
+	defer mb.Close()
+	authenticator := NewMockSASLAuthenticator(t).
+		SetMechanisms(SASLTypeSCRAMSHA512).
+		SetUser("alice", "secret")
+	mb.SetHandlerByMap(authenticator.RegisterHandlers(nil))
+
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(newMockSASLConfig(SASLTypePlaintext, "alice", "secret")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); !errors.Is(err, ErrUnsupportedSASLMechanism) {
+		t.Errorf("expected ErrUnsupportedSASLMechanism, got %v", err)
+	}
+	_ = broker.Close()
+	if users := authenticator.Authenticated(); len(users) != 0 {
+		t.Errorf("expected no authentication, got %v", users)
+	}
+}
@@ -0,0 +1,327 @@
+package sarama
+
+import (
+	"errors"
+	"sync"
+)
+
+// MockTransactionCoordinator is a stateful MockResponse acting as the
+// transaction coordinator of a MockBroker. It answers the FindCoordinator,
+// InitProducerId, AddPartitionsToTxn, AddOffsetsToTxn, TxnOffsetCommit and
+// EndTxn requests following the transaction protocol, so that transactional
+// producer code paths can be exercised deterministically.
+//
+// The producer IDs are allocated from 1000 in the order of the
+// InitProducerId requests. Initializing a transactional ID again bumps its
+// epoch, aborts its ongoing transaction and fences its previous producers,
+// whose requests are answered with ErrInvalidProducerEpoch. The ongoing
+// transaction is kept instead if the producer sets KeepPreparedTxn for a
+// two-phase commit (KIP-939). The offsets
+// committed with transactions are kept until their transaction is committed,
+// when they are set on the group coordinator given with SetGroupCoordinator,
+// if any, and are returned by Offset. The offsets committed with the group
+// metadata of KIP-447 by a member which is not part of the current
+// generation of the group coordinator are answered with
+// ErrIllegalGeneration or ErrUnknownMemberId.
+type MockTransactionCoordinator struct {
+	t      TestReporter
+	broker *MockBroker
+
+	lock         sync.Mutex
+	transactions map[string]*mockTransaction
+	groups       *MockGroupCoordinator
+	offsets      map[string]map[string]map[int32]int64
+	nextID       int64
+}
+
+type mockTransaction struct {
+	producerID    int64
+	producerEpoch int16
+	ongoing       bool
+	partitions    map[string]map[int32]bool
+	groups        map[string]bool
+	offsets       map[string]map[string][]*PartitionOffsetMetadata
+	results       []bool
+}
+
+// NewMockTransactionCoordinator returns the transaction coordinator of the
+// broker.
+func NewMockTransactionCoordinator(t TestReporter, broker *MockBroker) *MockTransactionCoordinator {
+	return &MockTransactionCoordinator{
+		t:            t,
+		broker:       broker,
+		transactions: make(map[string]*mockTransaction),
+		offsets:      make(map[string]map[string]map[int32]int64),
+		nextID:       1000,
+	}
+}
+
+// RegisterHandlers adds the coordinator as the MockResponse of the requests
+// it answers to the handler map, which is returned for SetHandlerByMap.
+func (c *MockTransactionCoordinator) RegisterHandlers(handlerMap map[string]MockResponse) map[string]MockResponse {
+	if handlerMap == nil {
+		handlerMap = make(map[string]MockResponse)
+	}
+	for _, name := range []string{
+		"FindCoordinatorRequest",
+		"InitProducerIDRequest",
+		"AddPartitionsToTxnRequest",
+		"AddOffsetsToTxnRequest",
+		"TxnOffsetCommitRequest",
+		"EndTxnRequest",
+	} {
+		handlerMap[name] = c
+	}
+	return handlerMap
+}
+
+// SetGroupCoordinator sets the group coordinator the offsets of the
+// committed transactions are committed to.
+func (c *MockTransactionCoordinator) SetGroupCoordinator(groups *MockGroupCoordinator) *MockTransactionCoordinator {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.groups = groups
+	return c
+}
+
+// ProducerID returns the producer ID and epoch of the transactional ID,
+// false if it was not initialized.
+func (c *MockTransactionCoordinator) ProducerID(transactionalID string) (int64, int16, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	txn := c.transactions[transactionalID]
+	if txn == nil {
+		return -1, -1, false
+	}
+	return txn.producerID, txn.producerEpoch, true
+}
+
+// Partitions returns the partitions of the ongoing transaction of the
+// transactional ID.
+func (c *MockTransactionCoordinator) Partitions(transactionalID string) map[string][]int32 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	txn := c.transactions[transactionalID]
+	if txn == nil {
+		return nil
+	}
+	partitions := make(map[string][]int32, len(txn.partitions))
+	for topic, ids := range txn.partitions {
+		for id := range ids {
+			partitions[topic] = append(partitions[topic], id)
+		}
+	}
+	return partitions
+}
+
+// Results returns the results of the ended transactions of the
+// transactional ID in order, true for the committed ones.
+func (c *MockTransactionCoordinator) Results(transactionalID string) []bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if txn := c.transactions[transactionalID]; txn != nil {
+		return append([]bool(nil), txn.results...)
+	}
+	return nil
+}
+
+// Offset returns the offset of a partition committed for the group by a
+// transaction, false if none was.
+func (c *MockTransactionCoordinator) Offset(group, topic string, partition int32) (int64, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	offset, ok := c.offsets[group][topic][partition]
+	return offset, ok
+}
+
+func (c *MockTransactionCoordinator) For(reqBody versionedDecoder) encoderWithHeader {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch req := reqBody.(type) {
+	case *FindCoordinatorRequest:
+		return &FindCoordinatorResponse{
+			Version:     req.Version,
+			Coordinator: &Broker{id: c.broker.BrokerID(), addr: c.broker.Addr()},
+		}
+	case *InitProducerIDRequest:
+		return c.initProducerID(req)
+	case *AddPartitionsToTxnRequest:
+		res := &AddPartitionsToTxnResponse{}
+		kerr := c.check(req.TransactionalID, req.ProducerID, req.ProducerEpoch)
+		if errors.Is(kerr, ErrNoError) {
+			txn := c.transactions[req.TransactionalID]
+			for topic, partitions := range req.TopicPartitions {
+				if txn.partitions[topic] == nil {
+					txn.partitions[topic] = make(map[int32]bool)
+				}
+				for _, partition := range partitions {
+					txn.partitions[topic][partition] = true
+				}
+			}
+			txn.ongoing = true
+		}
+		res.Errors = make(map[string][]*PartitionError, len(req.TopicPartitions))
+		for topic, partitions := range req.TopicPartitions {
+			for _, partition := range partitions {
+				res.Errors[topic] = append(res.Errors[topic], &PartitionError{Partition: partition, Err: kerr})
+			}
+		}
+		return res
+	case *AddOffsetsToTxnRequest:
+		kerr := c.check(req.TransactionalID, req.ProducerID, req.ProducerEpoch)
+		if errors.Is(kerr, ErrNoError) {
+			txn := c.transactions[req.TransactionalID]
+			txn.groups[req.GroupID] = true
+			txn.ongoing = true
+		}
+		return &AddOffsetsToTxnResponse{Err: kerr}
+	case *TxnOffsetCommitRequest:
+		return c.txnOffsetCommit(req)
+	case *EndTxnRequest:
+		kerr := c.check(req.TransactionalID, req.ProducerID, req.ProducerEpoch)
+		if errors.Is(kerr, ErrNoError) {
+			txn := c.transactions[req.TransactionalID]
+			if txn.ongoing {
+				c.end(txn, req.TransactionResult)
+			} else {
+				kerr = ErrInvalidTxnState
+			}
+		}
+		return &EndTxnResponse{Err: kerr}
+	}
+	c.t.Errorf("mocktxns: unexpected request %T", reqBody)
+	return nil
+}
+
+func (c *MockTransactionCoordinator) initProducerID(req *InitProducerIDRequest) *InitProducerIDResponse {
+	if req.TransactionalID == nil {
+		// idempotent producers only need a producer ID
+		c.nextID++
+		return &InitProducerIDResponse{Version: req.Version, ProducerID: c.nextID - 1}
+	}
+	res := &InitProducerIDResponse{
+		Version:                 req.Version,
+		OngoingTxnProducerID:    -1,
+		OngoingTxnProducerEpoch: -1,
+	}
+
+	txn := c.transactions[*req.TransactionalID]
+	if txn == nil {
+		txn = &mockTransaction{producerID: c.nextID}
+		c.nextID++
+		c.transactions[*req.TransactionalID] = txn
+	} else {
+		if txn.ongoing && req.Enable2Pc && req.KeepPreparedTxn {
+			// the prepared transaction is completed by the new producer
+			res.OngoingTxnProducerID, res.OngoingTxnProducerEpoch = txn.producerID, txn.producerEpoch
+			txn.producerEpoch++
+			res.ProducerID, res.ProducerEpoch = txn.producerID, txn.producerEpoch
+			return res
+		}
+		if txn.ongoing {
+			c.end(txn, false)
+		}
+		txn.producerEpoch++
+	}
+	txn.reset()
+	res.ProducerID, res.ProducerEpoch = txn.producerID, txn.producerEpoch
+	return res
+}
+
+func (c *MockTransactionCoordinator) txnOffsetCommit(req *TxnOffsetCommitRequest) *TxnOffsetCommitResponse {
+	kerr := c.check(req.TransactionalID, req.ProducerID, req.ProducerEpoch)
+	if errors.Is(kerr, ErrNoError) {
+		txn := c.transactions[req.TransactionalID]
+		if !txn.groups[req.GroupID] {
+			// AddOffsetsToTxn was not called for the group
+			kerr = ErrInvalidTxnState
+		} else if kerr = c.checkMember(req); errors.Is(kerr, ErrNoError) {
+			if txn.offsets[req.GroupID] == nil {
+				txn.offsets[req.GroupID] = make(map[string][]*PartitionOffsetMetadata)
+			}
+			for topic, partitions := range req.Topics {
+				txn.offsets[req.GroupID][topic] = append(txn.offsets[req.GroupID][topic], partitions...)
+			}
+		}
+	}
+
+	res := &TxnOffsetCommitResponse{Version: req.Version, Topics: make(map[string][]*PartitionError, len(req.Topics))}
+	for topic, partitions := range req.Topics {
+		for _, partition := range partitions {
+			res.Topics[topic] = append(res.Topics[topic], &PartitionError{Partition: partition.Partition, Err: kerr})
+		}
+	}
+	return res
+}
+
+// check returns the error of a request of the producer with the given ID
+// and epoch for the transactional ID
+func (c *MockTransactionCoordinator) check(transactionalID string, producerID int64, producerEpoch int16) KError {
+	txn := c.transactions[transactionalID]
+	switch {
+	case txn == nil || txn.producerID != producerID:
+		return ErrInvalidProducerIDMapping
+	case txn.producerEpoch != producerEpoch:
+		return ErrInvalidProducerEpoch
+	}
+	return ErrNoError
+}
+
+// checkMember returns the error of the offsets committed by a member of a
+// group of the group coordinator which is not part of its generation, if
+// any (v3 or later, KIP-447)
+func (c *MockTransactionCoordinator) checkMember(req *TxnOffsetCommitRequest) KError {
+	if req.Version < 3 || c.groups == nil || req.MemberID == "" {
+		return ErrNoError
+	}
+	if generation := c.groups.Generation(req.GroupID); req.GenerationID != generation {
+		return ErrIllegalGeneration
+	}
+	for _, member := range c.groups.Members(req.GroupID) {
+		if member == req.MemberID {
+			return ErrNoError
+		}
+	}
+	return ErrUnknownMemberId
+}
+
+// end commits or aborts the ongoing transaction
+func (c *MockTransactionCoordinator) end(txn *mockTransaction, commit bool) {
+	if commit {
+		for group, topics := range txn.offsets {
+			for topic, partitions := range topics {
+				for _, partition := range partitions {
+					c.commit(group, topic, partition)
+				}
+			}
+		}
+	}
+	txn.results = append(txn.results, commit)
+	txn.reset()
+}
+
+func (c *MockTransactionCoordinator) commit(group, topic string, partition *PartitionOffsetMetadata) {
+	if c.offsets[group] == nil {
+		c.offsets[group] = make(map[string]map[int32]int64)
+	}
+	if c.offsets[group][topic] == nil {
+		c.offsets[group][topic] = make(map[int32]int64)
+	}
+	c.offsets[group][topic][partition.Partition] = partition.Offset
+
+	if c.groups != nil {
+		metadata := ""
+		if partition.Metadata != nil {
+			metadata = *partition.Metadata
+		}
+		c.groups.SetOffset(group, topic, partition.Partition, partition.Offset, metadata)
+	}
+}
+
+func (txn *mockTransaction) reset() {
+	txn.ongoing = false
+	txn.partitions = make(map[string]map[int32]bool)
+	txn.groups = make(map[string]bool)
+	txn.offsets = make(map[string]map[string][]*PartitionOffsetMetadata)
+}
@@ -0,0 +1,243 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+)
+
+func newMockTxnsBroker(t *testing.T) (*MockBroker, *MockTransactionCoordinator, *Broker) {
+	mb := NewMockBroker(t, 0)
+	coordinator := NewMockTransactionCoordinator(t, mb)
+	mb.SetHandlerByMap(coordinator.RegisterHandlers(nil))
+
+	config := NewTestConfig()
+	config.Version = V0_11_0_0
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	return mb, coordinator, broker
+}
+
+func TestMockTransactionCoordinatorCommits(t *testing.T) {
+	mb, coordinator, broker := newMockTxnsBroker(t)
+	defer mb.Close()
+	defer safeClose(t, broker)
+
+	groups := NewMockGroupCoordinator(t, mb)
+	coordinator.SetGroupCoordinator(groups)
+
+	txnID := "txn"
+	initRes, err := broker.InitProducerID(&InitProducerIDRequest{TransactionalID: &txnID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(initRes.Err, ErrNoError) || initRes.ProducerID != 1000 || initRes.ProducerEpoch != 0 {
+		t.Fatalf("unexpected InitProducerID response %+v", initRes)
+	}
+	pid, epoch := initRes.ProducerID, initRes.ProducerEpoch
+
+	addRes, err := broker.AddPartitionsToTxn(&AddPartitionsToTxnRequest{
+		TransactionalID: txnID,
+		ProducerID:      pid,
+		ProducerEpoch:   epoch,
+		TopicPartitions: map[string][]int32{"my_topic": {0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kerr := addRes.Errors["my_topic"][0].Err; !errors.Is(kerr, ErrNoError) {
+		t.Errorf("unexpected AddPartitionsToTxn error %v", kerr)
+	}
+	if partitions := coordinator.Partitions(txnID); len(partitions["my_topic"]) != 1 {
+		t.Errorf("expected my_topic/0 in the transaction, got %v", partitions)
+	}
+
+	offsets := map[string][]*PartitionOffsetMetadata{"my_topic": {{Partition: 0, Offset: 42}}}
+	commitReq := &TxnOffsetCommitRequest{
+		TransactionalID: txnID,
+		GroupID:         "my_group",
+		ProducerID:      pid,
+		ProducerEpoch:   epoch,
+		Topics:          offsets,
+	}
+	commitRes, err := broker.TxnOffsetCommit(commitReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kerr := commitRes.Topics["my_topic"][0].Err; !errors.Is(kerr, ErrInvalidTxnState) {
+		t.Errorf("expected ErrInvalidTxnState before AddOffsetsToTxn, got %v", kerr)
+	}
+
+	offsetsRes, err := broker.AddOffsetsToTxn(&AddOffsetsToTxnRequest{
+		TransactionalID: txnID,
+		ProducerID:      pid,
+		ProducerEpoch:   epoch,
+		GroupID:         "my_group",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(offsetsRes.Err, ErrNoError) {
+		t.Errorf("unexpected AddOffsetsToTxn error %v", offsetsRes.Err)
+	}
+	if commitRes, err = broker.TxnOffsetCommit(commitReq); err != nil {
+		t.Fatal(err)
+	}
+	if kerr := commitRes.Topics["my_topic"][0].Err; !errors.Is(kerr, ErrNoError) {
+		t.Errorf("unexpected TxnOffsetCommit error %v", kerr)
+	}
+	if _, ok := coordinator.Offset("my_group", "my_topic", 0); ok {
+		t.Error("expected the offset to be committed with the transaction only")
+	}
+
+	endRes, err := broker.EndTxn(&EndTxnRequest{TransactionalID: txnID, ProducerID: pid, ProducerEpoch: epoch, TransactionResult: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(endRes.Err, ErrNoError) {
+		t.Errorf("unexpected EndTxn error %v", endRes.Err)
+	}
+	if offset, ok := coordinator.Offset("my_group", "my_topic", 0); !ok || offset != 42 {
+		t.Errorf("expected committed offset 42, got %d (%v)", offset, ok)
+	}
+	if offset, ok := groups.Offset("my_group", "my_topic", 0); !ok || offset != 42 {
+		t.Errorf("expected offset 42 on the group coordinator, got %d (%v)", offset, ok)
+	}
+	if results := coordinator.Results(txnID); len(results) != 1 || !results[0] {
+		t.Errorf("expected one committed transaction, got %v", results)
+	}
+
+	if endRes, err = broker.EndTxn(&EndTxnRequest{TransactionalID: txnID, ProducerID: pid, ProducerEpoch: epoch}); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(endRes.Err, ErrInvalidTxnState) {
+		t.Errorf("expected ErrInvalidTxnState without transaction, got %v", endRes.Err)
+	}
+}
+
+func TestMockTransactionCoordinatorFences(t *testing.T) {
+	mb, coordinator, broker := newMockTxnsBroker(t)
+	defer mb.Close()
+	defer safeClose(t, broker)
+
+	txnID := "txn"
+	first, err := broker.InitProducerID(&InitProducerIDRequest{TransactionalID: &txnID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.AddPartitionsToTxn(&AddPartitionsToTxnRequest{
+		TransactionalID: txnID,
+		ProducerID:      first.ProducerID,
+		ProducerEpoch:   first.ProducerEpoch,
+		TopicPartitions: map[string][]int32{"my_topic": {0, 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := broker.InitProducerID(&InitProducerIDRequest{TransactionalID: &txnID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ProducerID != first.ProducerID || second.ProducerEpoch != first.ProducerEpoch+1 {
+		t.Errorf("expected the epoch to be bumped, got %+v", second)
+	}
+	if results := coordinator.Results(txnID); len(results) != 1 || results[0] {
+		t.Errorf("expected the ongoing transaction to be aborted, got %v", results)
+	}
+	if pid, epoch, ok := coordinator.ProducerID(txnID); !ok || pid != second.ProducerID || epoch != second.ProducerEpoch {
+		t.Errorf("unexpected producer ID %d and epoch %d", pid, epoch)
+	}
+
+	endRes, err := broker.EndTxn(&EndTxnRequest{TransactionalID: txnID, ProducerID: first.ProducerID, ProducerEpoch: first.ProducerEpoch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(endRes.Err, ErrInvalidProducerEpoch) {
+		t.Errorf("expected ErrInvalidProducerEpoch for the fenced producer, got %v", endRes.Err)
+	}
+
+	offsetsRes, err := broker.AddOffsetsToTxn(&AddOffsetsToTxnRequest{TransactionalID: "unknown", GroupID: "my_group"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(offsetsRes.Err, ErrInvalidProducerIDMapping) {
+		t.Errorf("expected ErrInvalidProducerIDMapping, got %v", offsetsRes.Err)
+	}
+
+	idempotent, err := broker.InitProducerID(&InitProducerIDRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idempotent.ProducerID != 1001 {
+		t.Errorf("expected producer ID 1001, got %d", idempotent.ProducerID)
+	}
+}
+
+func TestMockTransactionResponses(t *testing.T) {
+	mb := NewMockBroker(t, 0)
+	defer mb.Close()
+	mb.SetHandlerByMap(map[string]MockResponse{
+		"InitProducerIDRequest":     NewMockInitProducerIDResponse(t).SetProducerID(7, 3),
+		"AddPartitionsToTxnRequest": NewMockAddPartitionsToTxnResponse(t).SetError("my_topic", 1, ErrConcurrentTransactions),
+		"AddOffsetsToTxnRequest":    NewMockAddOffsetsToTxnResponse(t).SetError(ErrConcurrentTransactions),
+		"TxnOffsetCommitRequest":    NewMockTxnOffsetCommitResponse(t).SetError("my_topic", 0, ErrUnknownMemberId),
+		"EndTxnRequest":             NewMockEndTxnResponse(t).SetError(ErrInvalidTxnState),
+	})
+
+	config := NewTestConfig()
+	config.Version = V0_11_0_0
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	initRes, err := broker.InitProducerID(&InitProducerIDRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if initRes.ProducerID != 7 || initRes.ProducerEpoch != 3 {
+		t.Errorf("unexpected InitProducerID response %+v", initRes)
+	}
+
+	addRes, err := broker.AddPartitionsToTxn(&AddPartitionsToTxnRequest{TopicPartitions: map[string][]int32{"my_topic": {0, 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, partition := range addRes.Errors["my_topic"] {
+		expected := ErrNoError
+		if partition.Partition == 1 {
+			expected = ErrConcurrentTransactions
+		}
+		if !errors.Is(partition.Err, expected) {
+			t.Errorf("expected %v for partition %d, got %v", expected, partition.Partition, partition.Err)
+		}
+	}
+
+	offsetsRes, err := broker.AddOffsetsToTxn(&AddOffsetsToTxnRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(offsetsRes.Err, ErrConcurrentTransactions) {
+		t.Errorf("expected ErrConcurrentTransactions, got %v", offsetsRes.Err)
+	}
+
+	commitRes, err := broker.TxnOffsetCommit(&TxnOffsetCommitRequest{
+		Topics: map[string][]*PartitionOffsetMetadata{"my_topic": {{Partition: 0, Offset: 1}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kerr := commitRes.Topics["my_topic"][0].Err; !errors.Is(kerr, ErrUnknownMemberId) {
+		t.Errorf("expected ErrUnknownMemberId, got %v", kerr)
+	}
+
+	endRes, err := broker.EndTxn(&EndTxnRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(endRes.Err, ErrInvalidTxnState) {
+		t.Errorf("expected ErrInvalidTxnState, got %v", endRes.Err)
+	}
+}
@@ -0,0 +1,189 @@
+package sarama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// OIDCConfig configures the AccessTokenProvider obtaining tokens with the
+// OAuth 2.0 client credentials grant from an OpenID Connect provider, like
+// the sasl.oauthbearer.token.endpoint.url login of the Java client (KIP-768).
+type OIDCConfig struct {
+	// TokenEndpoint is the URL of the token endpoint of the provider.
+	TokenEndpoint string
+	// ClientID and ClientSecret are the credentials of the client,
+	// sent with HTTP basic authentication.
+	ClientID     string
+	ClientSecret string
+	// Scope is the optional space separated list of scopes requested.
+	Scope string
+	// Extensions are sent along each token with the SASL/OAUTHBEARER
+	// initial client response (KIP-342).
+	Extensions map[string]string
+	// RefreshWindowFactor is the fraction of the lifetime of a token after
+	// which a new one is requested, 0.8 by default like the Java client.
+	RefreshWindowFactor float64
+	// Timeout bounds a token request, 10s by default.
+	Timeout time.Duration
+	// HTTPClient sends the token requests, http.DefaultClient by default.
+	HTTPClient *http.Client
+	// MetricRegistry, when set, records the oauth-token-refresh-rate and
+	// oauth-token-refresh-failure-rate meters, e.g. Config.MetricRegistry.
+	MetricRegistry metrics.Registry
+	// MetricsRecorder, when set, records them instead of MetricRegistry,
+	// e.g. Config.MetricsRecorder.
+	MetricsRecorder MetricsRecorder
+}
+
+// oidcTokenProvider caches the access token of the client credentials
+// grant, requesting a new one once the refresh window of the current one
+// is reached.
+type oidcTokenProvider struct {
+	conf OIDCConfig
+
+	lock      sync.Mutex
+	token     *AccessToken
+	refreshAt time.Time
+	expiresAt time.Time
+
+	refreshRate        MetricCounter
+	refreshFailureRate MetricCounter
+}
+
+// NewOIDCTokenProvider returns an AccessTokenProvider for Net.SASL.TokenProvider
+// performing the OAuth 2.0 client credentials grant against the token endpoint.
+// When a refresh fails while the cached token has not expired yet, the cached
+// token is returned and the refresh attempted again on the next call.
+func NewOIDCTokenProvider(conf OIDCConfig) (AccessTokenProvider, error) {
+	if conf.TokenEndpoint == "" {
+		return nil, ConfigurationError("OIDCConfig.TokenEndpoint must not be empty")
+	}
+	if _, err := url.Parse(conf.TokenEndpoint); err != nil {
+		return nil, ConfigurationError(fmt.Sprintf("OIDCConfig.TokenEndpoint is invalid: %v", err))
+	}
+	if conf.ClientID == "" {
+		return nil, ConfigurationError("OIDCConfig.ClientID must not be empty")
+	}
+	if conf.RefreshWindowFactor < 0 || conf.RefreshWindowFactor > 1 {
+		return nil, ConfigurationError("OIDCConfig.RefreshWindowFactor must be between 0 and 1")
+	}
+	if conf.RefreshWindowFactor == 0 {
+		conf.RefreshWindowFactor = 0.8
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = 10 * time.Second
+	}
+	if conf.HTTPClient == nil {
+		conf.HTTPClient = http.DefaultClient
+	}
+
+	p := &oidcTokenProvider{conf: conf}
+	if recorder := optionalMetricsRecorder(conf.MetricsRecorder, conf.MetricRegistry); recorder != nil {
+		p.refreshRate = recorder.Counter("oauth-token-refresh-rate", nil)
+		p.refreshFailureRate = recorder.Counter("oauth-token-refresh-failure-rate", nil)
+	}
+	return p, nil
+}
+
+func (p *oidcTokenProvider) Token() (*AccessToken, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	if p.token != nil && now.Before(p.refreshAt) {
+		return p.token, nil
+	}
+
+	token, lifetime, err := p.requestToken()
+	if err != nil {
+		if p.refreshFailureRate != nil {
+			p.refreshFailureRate.Add(1)
+		}
+		if p.token != nil && now.Before(p.expiresAt) {
+			newContextLogger("tokenEndpoint", p.conf.TokenEndpoint).Warnf("Failed to refresh the OAuth token from %s, using the current one: %v", p.conf.TokenEndpoint, err)
+			return p.token, nil
+		}
+		return nil, err
+	}
+	if p.refreshRate != nil {
+		p.refreshRate.Add(1)
+	}
+
+	p.token = token
+	p.expiresAt = now.Add(lifetime)
+	p.refreshAt = now.Add(time.Duration(float64(lifetime) * p.conf.RefreshWindowFactor))
+	return p.token, nil
+}
+
+// oidcTokenResponse is the successful response of the token endpoint (RFC 6749 section 5.1)
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// oidcErrorResponse is the error response of the token endpoint (RFC 6749 section 5.2)
+type oidcErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// requestToken performs the client credentials grant, returning the token
+// and its lifetime
+func (p *oidcTokenProvider) requestToken() (*AccessToken, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if p.conf.Scope != "" {
+		form.Set("scope", p.conf.Scope)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.conf.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.conf.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.conf.ClientID), url.QueryEscape(p.conf.ClientSecret))
+
+	res, err := p.conf.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to request an OAuth token: %w", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read the OAuth token response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var errRes oidcErrorResponse
+		if json.Unmarshal(body, &errRes) == nil && errRes.Error != "" {
+			return nil, 0, fmt.Errorf("OAuth token request failed with status %d: %s %s", res.StatusCode, errRes.Error, errRes.ErrorDescription)
+		}
+		return nil, 0, fmt.Errorf("OAuth token request failed with status %d", res.StatusCode)
+	}
+
+	var tokenRes oidcTokenResponse
+	if err := json.Unmarshal(body, &tokenRes); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode the OAuth token response: %w", err)
+	}
+	if tokenRes.AccessToken == "" {
+		return nil, 0, errors.New("OAuth token response without access_token")
+	}
+	if tokenRes.ExpiresIn <= 0 {
+		return nil, 0, errors.New("OAuth token response without a positive expires_in")
+	}
+
+	return &AccessToken{Token: tokenRes.AccessToken, Extensions: p.conf.Extensions}, time.Duration(tokenRes.ExpiresIn) * time.Second, nil
+}
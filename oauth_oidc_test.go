@@ -0,0 +1,127 @@
+package sarama
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestOIDCTokenProvider(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		if grant := r.PostForm.Get("grant_type"); grant != "client_credentials" {
+			t.Errorf("unexpected grant_type %q", grant)
+		}
+		if scope := r.PostForm.Get("scope"); scope != "kafka" {
+			t.Errorf("unexpected scope %q", scope)
+		}
+		if user, password, ok := r.BasicAuth(); !ok || user != "client" || password != "secret" {
+			t.Errorf("unexpected credentials %q %q", user, password)
+		}
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer server.Close()
+
+	registry := metrics.NewRegistry()
+	provider, err := NewOIDCTokenProvider(OIDCConfig{
+		TokenEndpoint:  server.URL,
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		Scope:          "kafka",
+		Extensions:     map[string]string{"logicalCluster": "lkc-1"},
+		MetricRegistry: registry,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := provider.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Token != "token-1" || token.Extensions["logicalCluster"] != "lkc-1" {
+			t.Errorf("unexpected token %+v", token)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("expected the token to be cached, got %d requests", n)
+	}
+
+	// past the refresh window a new token is requested
+	provider.(*oidcTokenProvider).refreshAt = time.Now().Add(-time.Second)
+	token, err := provider.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "token-2" {
+		t.Errorf("expected a refreshed token, got %s", token.Token)
+	}
+	if count := metrics.GetOrRegisterMeter("oauth-token-refresh-rate", registry).Count(); count != 2 {
+		t.Errorf("expected 2 refreshes, got %d", count)
+	}
+}
+
+func TestOIDCTokenProviderRefreshFailure(t *testing.T) {
+	var fail int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client"}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"token","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer server.Close()
+
+	registry := metrics.NewRegistry()
+	provider, err := NewOIDCTokenProvider(OIDCConfig{
+		TokenEndpoint:  server.URL,
+		ClientID:       "client",
+		MetricRegistry: registry,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Token(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the unexpired token is kept when the refresh fails
+	atomic.StoreInt32(&fail, 1)
+	p := provider.(*oidcTokenProvider)
+	p.refreshAt = time.Now().Add(-time.Second)
+	if token, err := provider.Token(); err != nil || token.Token != "token" {
+		t.Errorf("expected the current token, got %v %v", token, err)
+	}
+
+	// and the failure is returned once it expired
+	p.expiresAt = time.Now().Add(-time.Second)
+	if _, err := provider.Token(); err == nil {
+		t.Error("expected the refresh failure")
+	}
+	if count := metrics.GetOrRegisterMeter("oauth-token-refresh-failure-rate", registry).Count(); count != 2 {
+		t.Errorf("expected 2 refresh failures, got %d", count)
+	}
+}
+
+func TestNewOIDCTokenProviderValidation(t *testing.T) {
+	for _, conf := range []OIDCConfig{
+		{ClientID: "client"},
+		{TokenEndpoint: "https://idp/token"},
+		{TokenEndpoint: "https://idp/token", ClientID: "client", RefreshWindowFactor: 2},
+	} {
+		if _, err := NewOIDCTokenProvider(conf); err == nil {
+			t.Errorf("expected %+v to be invalid", conf)
+		}
+	}
+}
@@ -13,13 +13,17 @@ const ReceiveTime int64 = -1
 const GroupGenerationUndefined = -1
 
 type offsetCommitRequestBlock struct {
-	offset    int64
-	timestamp int64
-	metadata  string
+	offset               int64
+	timestamp            int64
+	committedLeaderEpoch int32
+	metadata             string
 }
 
 func (b *offsetCommitRequestBlock) encode(pe packetEncoder, version int16) error {
 	pe.putInt64(b.offset)
+	if version >= 6 {
+		pe.putInt32(b.committedLeaderEpoch)
+	}
 	if version == 1 {
 		pe.putInt64(b.timestamp)
 	} else if b.timestamp != 0 {
@@ -33,6 +37,12 @@ func (b *offsetCommitRequestBlock) decode(pd packetDecoder, version int16) (err
 	if b.offset, err = pd.getInt64(); err != nil {
 		return err
 	}
+	b.committedLeaderEpoch = -1
+	if version >= 6 {
+		if b.committedLeaderEpoch, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
 	if version == 1 {
 		if b.timestamp, err = pd.getInt64(); err != nil {
 			return err
@@ -44,9 +54,10 @@ func (b *offsetCommitRequestBlock) decode(pd packetDecoder, version int16) (err
 
 type OffsetCommitRequest struct {
 	ConsumerGroup           string
-	ConsumerGroupGeneration int32  // v1 or later
-	ConsumerID              string // v1 or later
-	RetentionTime           int64  // v2 or later
+	ConsumerGroupGeneration int32   // v1 or later
+	ConsumerID              string  // v1 or later
+	GroupInstanceId         *string // v7 or later
+	RetentionTime           int64   // v2 to v4
 
 	// Version can be:
 	// - 0 (kafka 0.8.1 and later)
@@ -54,12 +65,15 @@ type OffsetCommitRequest struct {
 	// - 2 (kafka 0.9.0 and later)
 	// - 3 (kafka 0.11.0 and later)
 	// - 4 (kafka 2.0.0 and later)
+	// - 5 (kafka 2.1.0 and later)
+	// - 6 (kafka 2.1.0 and later)
+	// - 7 (kafka 2.3.0 and later)
 	Version int16
 	blocks  map[string]map[int32]*offsetCommitRequestBlock
 }
 
 func (r *OffsetCommitRequest) encode(pe packetEncoder) error {
-	if r.Version < 0 || r.Version > 4 {
+	if r.Version < 0 || r.Version > 7 {
 		return PacketEncodingError{"invalid or unsupported OffsetCommitRequest version field"}
 	}
 
@@ -72,6 +86,11 @@ func (r *OffsetCommitRequest) encode(pe packetEncoder) error {
 		if err := pe.putString(r.ConsumerID); err != nil {
 			return err
 		}
+		if r.Version >= 7 {
+			if err := pe.putNullableString(r.GroupInstanceId); err != nil {
+				return err
+			}
+		}
 	} else {
 		if r.ConsumerGroupGeneration != 0 {
 			Logger.Println("Non-zero ConsumerGroupGeneration specified for OffsetCommitRequest v0, it will be ignored")
@@ -81,10 +100,10 @@ func (r *OffsetCommitRequest) encode(pe packetEncoder) error {
 		}
 	}
 
-	if r.Version >= 2 {
+	if r.Version >= 2 && r.Version <= 4 {
 		pe.putInt64(r.RetentionTime)
 	} else if r.RetentionTime != 0 {
-		Logger.Println("Non-zero RetentionTime specified for OffsetCommitRequest version <2, it will be ignored")
+		Logger.Println("Non-zero RetentionTime specified for OffsetCommitRequest version <2 or >4, it will be ignored")
 	}
 
 	if err := pe.putArrayLength(len(r.blocks)); err != nil {
@@ -121,9 +140,14 @@ func (r *OffsetCommitRequest) decode(pd packetDecoder, version int16) (err error
 		if r.ConsumerID, err = pd.getString(); err != nil {
 			return err
 		}
+		if r.Version >= 7 {
+			if r.GroupInstanceId, err = pd.getNullableString(); err != nil {
+				return err
+			}
+		}
 	}
 
-	if r.Version >= 2 {
+	if r.Version >= 2 && r.Version <= 4 {
 		if r.RetentionTime, err = pd.getInt64(); err != nil {
 			return err
 		}
@@ -170,6 +194,59 @@ func (r *OffsetCommitRequest) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetCommitRequest) versionRange() (minVersion, maxVersion int16) {
+	if r.Version == 0 {
+		// the offsets are committed to ZooKeeper
+		return 0, 0
+	}
+	if r.Version == 1 {
+		for _, partitions := range r.blocks {
+			for _, block := range partitions {
+				if block.timestamp != ReceiveTime {
+					// only v1 carries the timestamps
+					return 1, 1
+				}
+			}
+		}
+	}
+	minVersion, maxVersion = 1, 7
+	if r.Version >= 2 && r.Version <= 4 && r.RetentionTime != -1 {
+		minVersion, maxVersion = 2, 4
+	}
+	if r.Version >= 7 && r.GroupInstanceId != nil {
+		minVersion = 7
+	}
+	return minVersion, maxVersion
+}
+
+func (r *OffsetCommitRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	if v >= 2 && v <= 4 {
+		if r.Version < 2 || r.Version > 4 {
+			// the retention time of the broker
+			request.RetentionTime = -1
+		}
+	} else {
+		request.RetentionTime = 0
+	}
+	if r.Version < 7 {
+		request.GroupInstanceId = nil
+	}
+	if r.Version == 1 && v != 1 {
+		request.blocks = make(map[string]map[int32]*offsetCommitRequestBlock, len(r.blocks))
+		for topic, partitions := range r.blocks {
+			request.blocks[topic] = make(map[int32]*offsetCommitRequestBlock, len(partitions))
+			for partition, block := range partitions {
+				tmp := *block
+				tmp.timestamp = 0
+				request.blocks[topic][partition] = &tmp
+			}
+		}
+	}
+	return &request
+}
+
 func (r *OffsetCommitRequest) headerVersion() int16 {
 	return 1
 }
@@ -184,6 +261,10 @@ func (r *OffsetCommitRequest) requiredVersion() KafkaVersion {
 		return V0_11_0_0
 	case 4:
 		return V2_0_0_0
+	case 5, 6:
+		return V2_1_0_0
+	case 7:
+		return V2_3_0_0
 	default:
 		return MinVersion
 	}
@@ -198,7 +279,14 @@ func (r *OffsetCommitRequest) AddBlock(topic string, partitionID int32, offset i
 		r.blocks[topic] = make(map[int32]*offsetCommitRequestBlock)
 	}
 
-	r.blocks[topic][partitionID] = &offsetCommitRequestBlock{offset, timestamp, metadata}
+	r.blocks[topic][partitionID] = &offsetCommitRequestBlock{offset, timestamp, -1, metadata}
+}
+
+// AddBlockWithLeaderEpoch is like AddBlock but also sets the leader epoch of
+// the committed offset, which is only sent by v6 and later
+func (r *OffsetCommitRequest) AddBlockWithLeaderEpoch(topic string, partitionID int32, offset int64, leaderEpoch int32, timestamp int64, metadata string) {
+	r.AddBlock(topic, partitionID, offset, timestamp, metadata)
+	r.blocks[topic][partitionID].committedLeaderEpoch = leaderEpoch
 }
 
 func (r *OffsetCommitRequest) Offset(topic string, partitionID int32) (int64, string, error) {
@@ -99,3 +99,67 @@ func TestOffsetCommitRequestV2ToV4(t *testing.T) {
 		testRequest(t, fmt.Sprintf("one block v%d", version), request, offsetCommitRequestOneBlockV2)
 	}
 }
+
+var (
+	offsetCommitRequestOneBlockV5 = []byte{
+		0x00, 0x06, 'f', 'o', 'o', 'b', 'a', 'r',
+		0x00, 0x00, 0x11, 0x22,
+		0x00, 0x04, 'c', 'o', 'n', 's',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x05, 't', 'o', 'p', 'i', 'c',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x52, 0x21,
+		0x00, 0x00, 0x00, 0x00, 0xDE, 0xAD, 0xBE, 0xEF,
+		0x00, 0x08, 'm', 'e', 't', 'a', 'd', 'a', 't', 'a',
+	}
+
+	offsetCommitRequestOneBlockV6 = []byte{
+		0x00, 0x06, 'f', 'o', 'o', 'b', 'a', 'r',
+		0x00, 0x00, 0x11, 0x22,
+		0x00, 0x04, 'c', 'o', 'n', 's',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x05, 't', 'o', 'p', 'i', 'c',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x52, 0x21,
+		0x00, 0x00, 0x00, 0x00, 0xDE, 0xAD, 0xBE, 0xEF,
+		0x00, 0x00, 0x00, 0x07, // leader epoch
+		0x00, 0x08, 'm', 'e', 't', 'a', 'd', 'a', 't', 'a',
+	}
+
+	offsetCommitRequestOneBlockV7 = []byte{
+		0x00, 0x06, 'f', 'o', 'o', 'b', 'a', 'r',
+		0x00, 0x00, 0x11, 0x22,
+		0x00, 0x04, 'c', 'o', 'n', 's',
+		0x00, 0x02, 'g', 'i', // group instance id
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x05, 't', 'o', 'p', 'i', 'c',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x52, 0x21,
+		0x00, 0x00, 0x00, 0x00, 0xDE, 0xAD, 0xBE, 0xEF,
+		0x00, 0x00, 0x00, 0x07, // leader epoch
+		0x00, 0x08, 'm', 'e', 't', 'a', 'd', 'a', 't', 'a',
+	}
+)
+
+func TestOffsetCommitRequestV5ToV7(t *testing.T) {
+	request := new(OffsetCommitRequest)
+	request.ConsumerGroup = "foobar"
+	request.ConsumerID = "cons"
+	request.ConsumerGroupGeneration = 0x1122
+	request.Version = 5
+	request.AddBlock("topic", 0x5221, 0xDEADBEEF, 0, "metadata")
+	testRequest(t, "one block v5", request, offsetCommitRequestOneBlockV5)
+
+	request = new(OffsetCommitRequest)
+	request.ConsumerGroup = "foobar"
+	request.ConsumerID = "cons"
+	request.ConsumerGroupGeneration = 0x1122
+	request.Version = 6
+	request.AddBlockWithLeaderEpoch("topic", 0x5221, 0xDEADBEEF, 7, 0, "metadata")
+	testRequest(t, "one block v6", request, offsetCommitRequestOneBlockV6)
+
+	instanceID := "gi"
+	request.GroupInstanceId = &instanceID
+	request.Version = 7
+	testRequest(t, "one block v7", request, offsetCommitRequestOneBlockV7)
+}
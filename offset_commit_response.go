@@ -4,6 +4,10 @@ type OffsetCommitResponse struct {
 	Version        int16
 	ThrottleTimeMs int32
 	Errors         map[string]map[int32]KError
+
+	// correlationID is the correlation ID of the request, which the offset
+	// manager attaches to the errors of the partitions
+	correlationID int32
 }
 
 func (r *OffsetCommitResponse) AddError(topic string, partition int32, kerror KError) {
@@ -86,6 +90,10 @@ func (r *OffsetCommitResponse) decode(pd packetDecoder, version int16) (err erro
 	return nil
 }
 
+func (r *OffsetCommitResponse) setCorrelationID(correlationID int32) {
+	r.correlationID = correlationID
+}
+
 func (r *OffsetCommitResponse) key() int16 {
 	return 8
 }
@@ -94,6 +102,10 @@ func (r *OffsetCommitResponse) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetCommitResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *OffsetCommitResponse) headerVersion() int16 {
 	return 0
 }
@@ -108,6 +120,10 @@ func (r *OffsetCommitResponse) requiredVersion() KafkaVersion {
 		return V0_11_0_0
 	case 4:
 		return V2_0_0_0
+	case 5, 6:
+		return V2_1_0_0
+	case 7:
+		return V2_3_0_0
 	default:
 		return MinVersion
 	}
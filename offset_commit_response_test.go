@@ -24,7 +24,7 @@ func TestNormalOffsetCommitResponse(t *testing.T) {
 }
 
 func TestOffsetCommitResponseWithThrottleTime(t *testing.T) {
-	for version := 3; version <= 4; version++ {
+	for version := 3; version <= 7; version++ {
 		response := OffsetCommitResponse{
 			Version:        int16(version),
 			ThrottleTimeMs: 123,
@@ -5,13 +5,20 @@ type OffsetFetchRequest struct {
 	ConsumerGroup string
 	RequireStable bool // requires v7+
 	partitions    map[string][]int32
+	// groups replaces ConsumerGroup and partitions from v8 onwards, a nil
+	// partitions map fetches the offsets of all topics of the group
+	groups map[string]map[string][]int32
 }
 
 func (r *OffsetFetchRequest) encode(pe packetEncoder) (err error) {
-	if r.Version < 0 || r.Version > 7 {
+	if r.Version < 0 || r.Version > 8 {
 		return PacketEncodingError{"invalid or unsupported OffsetFetchRequest version field"}
 	}
 
+	if r.Version >= 8 {
+		return r.encodeGroups(pe)
+	}
+
 	isFlexible := r.Version >= 6
 
 	if isFlexible {
@@ -80,8 +87,43 @@ func (r *OffsetFetchRequest) encode(pe packetEncoder) (err error) {
 	return nil
 }
 
+func (r *OffsetFetchRequest) encodeGroups(pe packetEncoder) (err error) {
+	pe.putCompactArrayLength(len(r.groups))
+	for group, partitions := range r.groups {
+		if err = pe.putCompactString(group); err != nil {
+			return err
+		}
+
+		if partitions == nil {
+			pe.putUVarint(0)
+		} else {
+			pe.putCompactArrayLength(len(partitions))
+		}
+		for topic, partitionIDs := range partitions {
+			if err = pe.putCompactString(topic); err != nil {
+				return err
+			}
+			if err = pe.putCompactInt32Array(partitionIDs); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		}
+
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putBool(r.RequireStable)
+	pe.putEmptyTaggedFieldArray()
+
+	return nil
+}
+
 func (r *OffsetFetchRequest) decode(pd packetDecoder, version int16) (err error) {
 	r.Version = version
+	if r.Version >= 8 {
+		return r.decodeGroups(pd)
+	}
+
 	isFlexible := r.Version >= 6
 	if isFlexible {
 		r.ConsumerGroup, err = pd.getCompactString()
@@ -155,6 +197,59 @@ func (r *OffsetFetchRequest) decode(pd packetDecoder, version int16) (err error)
 	return nil
 }
 
+func (r *OffsetFetchRequest) decodeGroups(pd packetDecoder) (err error) {
+	groupCount, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+
+	r.groups = make(map[string]map[string][]int32, groupCount)
+	for i := 0; i < groupCount; i++ {
+		group, err := pd.getCompactString()
+		if err != nil {
+			return err
+		}
+
+		// a null topics array fetches the offsets of all topics
+		topicCount, err := pd.getCompactArrayLength()
+		if err != nil {
+			return err
+		}
+
+		var partitions map[string][]int32
+		if topicCount > 0 {
+			partitions = make(map[string][]int32, topicCount)
+		}
+		for j := 0; j < topicCount; j++ {
+			topic, err := pd.getCompactString()
+			if err != nil {
+				return err
+			}
+			partitionIDs, err := pd.getCompactInt32Array()
+			if err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			partitions[topic] = partitionIDs
+		}
+
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+
+		r.groups[group] = partitions
+	}
+
+	if r.RequireStable, err = pd.getBool(); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
 func (r *OffsetFetchRequest) key() int16 {
 	return 9
 }
@@ -163,6 +258,38 @@ func (r *OffsetFetchRequest) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetFetchRequest) versionRange() (minVersion, maxVersion int16) {
+	switch r.Version {
+	case 0:
+		// the offsets are fetched from ZooKeeper
+		return 0, 0
+	case 8:
+		// the request holds groups
+		return 8, 8
+	}
+	minVersion, maxVersion = 1, 7
+	if r.Version >= 2 && r.partitions == nil {
+		minVersion = 2
+	}
+	if r.Version >= 7 && r.RequireStable {
+		minVersion = 7
+	}
+	return minVersion, maxVersion
+}
+
+func (r *OffsetFetchRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	if r.Version < 2 && request.partitions == nil {
+		// no partitions rather than all of them
+		request.partitions = make(map[string][]int32)
+	}
+	if r.Version < 7 {
+		request.RequireStable = false
+	}
+	return &request
+}
+
 func (r *OffsetFetchRequest) headerVersion() int16 {
 	if r.Version >= 6 {
 		return 2
@@ -187,6 +314,8 @@ func (r *OffsetFetchRequest) requiredVersion() KafkaVersion {
 		return V2_4_0_0
 	case 7:
 		return V2_5_0_0
+	case 8:
+		return V3_0_0_0
 	default:
 		return MinVersion
 	}
@@ -205,3 +334,13 @@ func (r *OffsetFetchRequest) AddPartition(topic string, partitionID int32) {
 
 	r.partitions[topic] = append(r.partitions[topic], partitionID)
 }
+
+// AddGroup adds a group whose offsets of the given partitions are fetched by
+// a v8+ request, a nil topicPartitions map fetches all committed offsets
+func (r *OffsetFetchRequest) AddGroup(group string, topicPartitions map[string][]int32) {
+	if r.groups == nil {
+		r.groups = make(map[string]map[string][]int32)
+	}
+
+	r.groups[group] = topicPartitions
+}
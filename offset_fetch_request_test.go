@@ -52,6 +52,27 @@ var (
 		0x00, 0x04, 'b', 'l', 'a', 'h',
 		0xff, 0xff, 0xff, 0xff,
 	}
+
+	offsetFetchRequestOneGroupV8 = []byte{
+		0x02,                     // 1 group
+		0x05, 'b', 'l', 'a', 'h', // group
+		0x02, 0x0E, 't', 'o', 'p', 'i', 'c', 'T', 'h', 'e', 'F', 'i', 'r', 's', 't',
+		0x02,
+		0x4F, 0x4F, 0x4F, 0x4F,
+		0x00, // topic tagged fields
+		0x00, // group tagged fields
+		0x01, // require stable
+		0x00, // tagged fields
+	}
+
+	offsetFetchRequestAllPartitionsV8 = []byte{
+		0x02,                     // 1 group
+		0x05, 'b', 'l', 'a', 'h', // group
+		0x00, // null topics
+		0x00, // group tagged fields
+		0x00, // require stable
+		0x00, // tagged fields
+	}
 )
 
 func TestOffsetFetchRequestNoPartitions(t *testing.T) {
@@ -121,3 +142,13 @@ func TestOffsetFetchRequestAllPartitions(t *testing.T) {
 		testRequest(t, fmt.Sprintf("all partitions %d", version), request, offsetFetchRequestAllPartitions)
 	}
 }
+
+func TestOffsetFetchRequestGroupsV8(t *testing.T) {
+	request := &OffsetFetchRequest{Version: 8, RequireStable: true}
+	request.AddGroup("blah", map[string][]int32{"topicTheFirst": {0x4F4F4F4F}})
+	testRequest(t, "one group", request, offsetFetchRequestOneGroupV8)
+
+	request = &OffsetFetchRequest{Version: 8}
+	request.AddGroup("blah", nil)
+	testRequest(t, "all partitions", request, offsetFetchRequestAllPartitionsV8)
+}
@@ -71,14 +71,148 @@ func (b *OffsetFetchResponseBlock) encode(pe packetEncoder, version int16) (err
 	return nil
 }
 
+// OffsetFetchResponseGroup holds the committed offsets of a single group of
+// a v8+ OffsetFetchResponse
+type OffsetFetchResponseGroup struct {
+	Blocks map[string]map[int32]*OffsetFetchResponseBlock
+	Err    KError
+}
+
 type OffsetFetchResponse struct {
 	Version        int16
 	ThrottleTimeMs int32
 	Blocks         map[string]map[int32]*OffsetFetchResponseBlock
 	Err            KError
+	// Groups replaces Blocks and Err from v8 onwards
+	Groups map[string]*OffsetFetchResponseGroup
+}
+
+func encodeOffsetFetchBlocks(pe packetEncoder, blocks map[string]map[int32]*OffsetFetchResponseBlock, version int16) (err error) {
+	pe.putCompactArrayLength(len(blocks))
+	for topic, partitions := range blocks {
+		if err = pe.putCompactString(topic); err != nil {
+			return err
+		}
+		pe.putCompactArrayLength(len(partitions))
+		for partition, block := range partitions {
+			pe.putInt32(partition)
+			if err := block.encode(pe, version); err != nil {
+				return err
+			}
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+	return nil
+}
+
+func decodeOffsetFetchBlocks(pd packetDecoder, version int16) (map[string]map[int32]*OffsetFetchResponseBlock, error) {
+	numTopics, err := pd.getCompactArrayLength()
+	if err != nil || numTopics <= 0 {
+		return nil, err
+	}
+
+	blocks := make(map[string]map[int32]*OffsetFetchResponseBlock, numTopics)
+	for i := 0; i < numTopics; i++ {
+		name, err := pd.getCompactString()
+		if err != nil {
+			return nil, err
+		}
+
+		numBlocks, err := pd.getCompactArrayLength()
+		if err != nil {
+			return nil, err
+		}
+
+		blocks[name] = nil
+		if numBlocks > 0 {
+			blocks[name] = make(map[int32]*OffsetFetchResponseBlock, numBlocks)
+		}
+		for j := 0; j < numBlocks; j++ {
+			id, err := pd.getInt32()
+			if err != nil {
+				return nil, err
+			}
+
+			block := new(OffsetFetchResponseBlock)
+			if err := block.decode(pd, version); err != nil {
+				return nil, err
+			}
+
+			blocks[name][id] = block
+		}
+
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+func (r *OffsetFetchResponse) encodeGroups(pe packetEncoder) (err error) {
+	pe.putInt32(r.ThrottleTimeMs)
+
+	pe.putCompactArrayLength(len(r.Groups))
+	for group, g := range r.Groups {
+		if err = pe.putCompactString(group); err != nil {
+			return err
+		}
+		if err = encodeOffsetFetchBlocks(pe, g.Blocks, r.Version); err != nil {
+			return err
+		}
+		pe.putInt16(int16(g.Err))
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *OffsetFetchResponse) decodeGroups(pd packetDecoder) (err error) {
+	if r.ThrottleTimeMs, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	numGroups, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+
+	if numGroups > 0 {
+		r.Groups = make(map[string]*OffsetFetchResponseGroup, numGroups)
+	}
+	for i := 0; i < numGroups; i++ {
+		group, err := pd.getCompactString()
+		if err != nil {
+			return err
+		}
+
+		g := new(OffsetFetchResponseGroup)
+		if g.Blocks, err = decodeOffsetFetchBlocks(pd, r.Version); err != nil {
+			return err
+		}
+
+		kerr, err := pd.getInt16()
+		if err != nil {
+			return err
+		}
+		g.Err = KError(kerr)
+
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+
+		r.Groups[group] = g
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
 }
 
 func (r *OffsetFetchResponse) encode(pe packetEncoder) (err error) {
+	if r.Version >= 8 {
+		return r.encodeGroups(pe)
+	}
+
 	isFlexible := r.Version >= 6
 
 	if r.Version >= 3 {
@@ -132,6 +266,10 @@ func (r *OffsetFetchResponse) encode(pe packetEncoder) (err error) {
 
 func (r *OffsetFetchResponse) decode(pd packetDecoder, version int16) (err error) {
 	r.Version = version
+	if version >= 8 {
+		return r.decodeGroups(pd)
+	}
+
 	isFlexible := version >= 6
 
 	if version >= 3 {
@@ -226,6 +364,10 @@ func (r *OffsetFetchResponse) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetFetchResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *OffsetFetchResponse) headerVersion() int16 {
 	if r.Version >= 6 {
 		return 1
@@ -250,6 +392,8 @@ func (r *OffsetFetchResponse) requiredVersion() KafkaVersion {
 		return V2_4_0_0
 	case 7:
 		return V2_5_0_0
+	case 8:
+		return V3_0_0_0
 	default:
 		return MinVersion
 	}
@@ -267,6 +411,17 @@ func (r *OffsetFetchResponse) GetBlock(topic string, partition int32) *OffsetFet
 	return r.Blocks[topic][partition]
 }
 
+// GetGroupBlock returns the committed offset of a partition of the given group
+// of a v8+ response
+func (r *OffsetFetchResponse) GetGroupBlock(group, topic string, partition int32) *OffsetFetchResponseBlock {
+	g := r.Groups[group]
+	if g == nil || g.Blocks[topic] == nil {
+		return nil
+	}
+
+	return g.Blocks[topic][partition]
+}
+
 func (r *OffsetFetchResponse) AddBlock(topic string, partition int32, block *OffsetFetchResponseBlock) {
 	if r.Blocks == nil {
 		r.Blocks = make(map[string]map[int32]*OffsetFetchResponseBlock)
@@ -278,3 +433,25 @@ func (r *OffsetFetchResponse) AddBlock(topic string, partition int32, block *Off
 	}
 	partitions[partition] = block
 }
+
+// AddGroupBlock adds the committed offset of a partition of the given group
+// to a v8+ response
+func (r *OffsetFetchResponse) AddGroupBlock(group, topic string, partition int32, block *OffsetFetchResponseBlock) {
+	if r.Groups == nil {
+		r.Groups = make(map[string]*OffsetFetchResponseGroup)
+	}
+	g := r.Groups[group]
+	if g == nil {
+		g = &OffsetFetchResponseGroup{}
+		r.Groups[group] = g
+	}
+	if g.Blocks == nil {
+		g.Blocks = make(map[string]map[int32]*OffsetFetchResponseBlock)
+	}
+	partitions := g.Blocks[topic]
+	if partitions == nil {
+		partitions = make(map[int32]*OffsetFetchResponseBlock)
+		g.Blocks[topic] = partitions
+	}
+	partitions[partition] = block
+}
@@ -20,6 +20,25 @@ var (
 		0x00, 0x00, 0x00, 0x00,
 		0x00, 0x2A,
 	}
+
+	offsetFetchResponseOneGroupV8 = []byte{
+		0x00, 0x00, 0x00, 0x09, // throttle time
+		0x02,                     // 1 group
+		0x05, 'b', 'l', 'a', 'h', // group
+		0x02,      // 1 topic
+		0x02, 't', // topic
+		0x02,                   // 1 partition
+		0x00, 0x00, 0x00, 0x00, // partition
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0A, // offset
+		0x00, 0x00, 0x00, 0x01, // leader epoch
+		0x03, 'm', 'd', // metadata
+		0x00, 0x00, // no error
+		0x00,       // partition tagged fields
+		0x00,       // topic tagged fields
+		0x00, 0x10, // ErrNotCoordinatorForConsumer
+		0x00, // group tagged fields
+		0x00, // tagged fields
+	}
 )
 
 func TestEmptyOffsetFetchResponse(t *testing.T) {
@@ -66,3 +85,17 @@ func TestNormalOffsetFetchResponse(t *testing.T) {
 	responseV5.Blocks["m"] = nil
 	testResponse(t, "normal V5", &responseV5, nil)
 }
+
+func TestOffsetFetchResponseGroupsV8(t *testing.T) {
+	response := OffsetFetchResponse{Version: 8, ThrottleTimeMs: 9}
+	response.AddGroupBlock("blah", "t", 0, &OffsetFetchResponseBlock{10, 1, "md", ErrNoError})
+	response.Groups["blah"].Err = ErrNotCoordinatorForConsumer
+	testResponse(t, "one group v8", &response, offsetFetchResponseOneGroupV8)
+
+	if block := response.GetGroupBlock("blah", "t", 0); block == nil || block.Offset != 10 {
+		t.Errorf("Expected offset 10 for blah/t/0, got %v", block)
+	}
+	if block := response.GetGroupBlock("other", "t", 0); block != nil {
+		t.Errorf("Expected no block for unknown group, got %v", block)
+	}
+}
@@ -29,7 +29,7 @@ type offsetManager struct {
 	client Client
 	conf   *Config
 	group  string
-	ticker *time.Ticker
+	ticker Ticker
 
 	memberID   string
 	generation int32
@@ -71,7 +71,7 @@ func newOffsetManagerFromClient(group, memberID string, generation int32, client
 		closed:  make(chan none),
 	}
 	if conf.Consumer.Offsets.AutoCommit.Enable {
-		om.ticker = time.NewTicker(conf.Consumer.Offsets.AutoCommit.Interval)
+		om.ticker = conf.Clock.NewTicker(conf.Consumer.Offsets.AutoCommit.Interval)
 		go withRecover(om.mainLoop)
 	}
 
@@ -183,7 +183,7 @@ func (om *offsetManager) fetchInitialOffset(topic string, partition int32, retri
 		select {
 		case <-om.closing:
 			return 0, "", block.Err
-		case <-time.After(backoff):
+		case <-om.conf.Clock.After(backoff):
 		}
 		return om.fetchInitialOffset(topic, partition, retries-1)
 	default:
@@ -234,7 +234,7 @@ func (om *offsetManager) mainLoop() {
 
 	for {
 		select {
-		case <-om.ticker.C:
+		case <-om.ticker.C():
 			om.Commit()
 		case <-om.closing:
 			return
@@ -343,7 +343,7 @@ func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest
 				om.releaseCoordinator(broker)
 			case ErrOffsetMetadataTooLarge, ErrInvalidCommitOffsetSize:
 				// nothing we can do about this, just tell the user and carry on
-				pom.handleError(err)
+				pom.handleError(commitError(broker, resp, pom, err))
 			case ErrOffsetsLoadInProgress:
 				// nothing wrong but we didn't commit, we'll get it next time round
 			case ErrUnknownTopicOrPartition:
@@ -354,13 +354,25 @@ func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest
 				fallthrough
 			default:
 				// dunno, tell the user and try redispatching
-				pom.handleError(err)
+				pom.handleError(commitError(broker, resp, pom, err))
 				om.releaseCoordinator(broker)
 			}
 		}
 	}
 }
 
+// commitError attaches the partition, the broker and the request to the error
+// committing the offset of a partition
+func commitError(broker *Broker, resp *OffsetCommitResponse, pom *partitionOffsetManager, kerr KError) error {
+	return &PartitionRequestError{
+		Topic:         pom.topic,
+		Partition:     pom.partition,
+		BrokerID:      broker.ID(),
+		CorrelationID: resp.correlationID,
+		Err:           kerr,
+	}
+}
+
 func (om *offsetManager) handleError(err error) {
 	om.pomsLock.RLock()
 	defer om.pomsLock.RUnlock()
@@ -582,7 +594,7 @@ func (pom *partitionOffsetManager) handleError(err error) {
 	if pom.parent.conf.Consumer.Return.Errors {
 		pom.errors <- cErr
 	} else {
-		Logger.Println(cErr)
+		newContextLogger("group", pom.parent.group, "topic", pom.topic, "partition", pom.partition).Errorf("%v", cErr)
 	}
 }
 
@@ -542,6 +542,43 @@ func TestPartitionOffsetManagerCommitErr(t *testing.T) {
 	safeClose(t, testClient)
 }
 
+func TestPartitionOffsetManagerCommitPartitionRequestError(t *testing.T) {
+	config := NewTestConfig()
+	config.Consumer.Return.Errors = true
+	om, testClient, broker, coordinator := initOffsetManagerWithBackoffFunc(t, 0, nil, config)
+	pom := initPartitionOffsetManager(t, om, coordinator, 5, "meta")
+
+	ocResponse := new(OffsetCommitResponse)
+	ocResponse.AddError("my_topic", 0, ErrOffsetMetadataTooLarge)
+	coordinator.Returns(ocResponse)
+	ocResponse2 := new(OffsetCommitResponse)
+	ocResponse2.AddError("my_topic", 0, ErrNoError)
+	coordinator.Returns(ocResponse2)
+
+	pom.MarkOffset(100, "modified_meta")
+
+	select {
+	case cErr := <-pom.Errors():
+		var partitionErr *PartitionRequestError
+		if !errors.As(cErr, &partitionErr) || !errors.Is(cErr, ErrOffsetMetadataTooLarge) {
+			t.Fatalf("Expected a PartitionRequestError wrapping ErrOffsetMetadataTooLarge, got %v", cErr.Err)
+		}
+		// the commit is the second request to the coordinator, after the offset fetch
+		if partitionErr.Topic != "my_topic" || partitionErr.Partition != 0 ||
+			partitionErr.BrokerID != coordinator.BrokerID() || partitionErr.CorrelationID != 1 {
+			t.Errorf("Unexpected coordinates of the error: %+v", partitionErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the commit to fail")
+	}
+
+	safeClose(t, pom)
+	safeClose(t, om)
+	safeClose(t, testClient)
+	broker.Close()
+	coordinator.Close()
+}
+
 // Test of recovery from abort
 func TestAbortPartitionOffsetManager(t *testing.T) {
 	om, testClient, broker, coordinator := initOffsetManager(t, 0)
@@ -1,20 +1,35 @@
 package sarama
 
 type offsetRequestBlock struct {
-	time       int64
-	maxOffsets int32 // Only used in version 0
+	currentLeaderEpoch int32 // Only used in version 4 or later
+	time               int64
+	maxOffsets         int32 // Only used in version 0
 }
 
 func (b *offsetRequestBlock) encode(pe packetEncoder, version int16) error {
+	if version >= 4 {
+		pe.putInt32(b.currentLeaderEpoch)
+	}
+
 	pe.putInt64(b.time)
 	if version == 0 {
 		pe.putInt32(b.maxOffsets)
 	}
 
+	if version >= 6 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
 func (b *offsetRequestBlock) decode(pd packetDecoder, version int16) (err error) {
+	b.currentLeaderEpoch = -1
+	if version >= 4 {
+		if b.currentLeaderEpoch, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
 	if b.time, err = pd.getInt64(); err != nil {
 		return err
 	}
@@ -23,6 +38,11 @@ func (b *offsetRequestBlock) decode(pd packetDecoder, version int16) (err error)
 			return err
 		}
 	}
+	if version >= 6 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -46,16 +66,31 @@ func (r *OffsetRequest) encode(pe packetEncoder) error {
 		pe.putBool(r.IsolationLevel == ReadCommitted)
 	}
 
-	err := pe.putArrayLength(len(r.blocks))
+	isFlexible := r.Version >= 6
+
+	var err error
+	if isFlexible {
+		pe.putCompactArrayLength(len(r.blocks))
+	} else {
+		err = pe.putArrayLength(len(r.blocks))
+	}
 	if err != nil {
 		return err
 	}
 	for topic, partitions := range r.blocks {
-		err = pe.putString(topic)
+		if isFlexible {
+			err = pe.putCompactString(topic)
+		} else {
+			err = pe.putString(topic)
+		}
 		if err != nil {
 			return err
 		}
-		err = pe.putArrayLength(len(partitions))
+		if isFlexible {
+			pe.putCompactArrayLength(len(partitions))
+		} else {
+			err = pe.putArrayLength(len(partitions))
+		}
 		if err != nil {
 			return err
 		}
@@ -65,6 +100,13 @@ func (r *OffsetRequest) encode(pe packetEncoder) error {
 				return err
 			}
 		}
+		if isFlexible {
+			pe.putEmptyTaggedFieldArray()
+		}
+	}
+
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
 	}
 	return nil
 }
@@ -92,20 +134,40 @@ func (r *OffsetRequest) decode(pd packetDecoder, version int16) error {
 		}
 	}
 
-	blockCount, err := pd.getArrayLength()
+	isFlexible := r.Version >= 6
+
+	var blockCount int
+	if isFlexible {
+		blockCount, err = pd.getCompactArrayLength()
+	} else {
+		blockCount, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
 	if blockCount == 0 {
-		return nil
+		if isFlexible {
+			_, err = pd.getEmptyTaggedFieldArray()
+		}
+		return err
 	}
 	r.blocks = make(map[string]map[int32]*offsetRequestBlock)
 	for i := 0; i < blockCount; i++ {
-		topic, err := pd.getString()
+		var topic string
+		if isFlexible {
+			topic, err = pd.getCompactString()
+		} else {
+			topic, err = pd.getString()
+		}
 		if err != nil {
 			return err
 		}
-		partitionCount, err := pd.getArrayLength()
+		var partitionCount int
+		if isFlexible {
+			partitionCount, err = pd.getCompactArrayLength()
+		} else {
+			partitionCount, err = pd.getArrayLength()
+		}
 		if err != nil {
 			return err
 		}
@@ -121,8 +183,17 @@ func (r *OffsetRequest) decode(pd packetDecoder, version int16) error {
 			}
 			r.blocks[topic][partition] = block
 		}
+		if isFlexible {
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
 	}
-	return nil
+
+	if isFlexible {
+		_, err = pd.getEmptyTaggedFieldArray()
+	}
+	return err
 }
 
 func (r *OffsetRequest) key() int16 {
@@ -133,7 +204,38 @@ func (r *OffsetRequest) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetRequest) versionRange() (minVersion, maxVersion int16) {
+	if r.Version == 0 {
+		for _, partitions := range r.blocks {
+			for _, block := range partitions {
+				if block.maxOffsets > 1 {
+					// only v0 returns more than one offset
+					return 0, 0
+				}
+			}
+		}
+		return 0, 7
+	}
+	minVersion, maxVersion = 1, 7
+	if r.Version >= 2 && r.IsolationLevel == ReadCommitted {
+		minVersion = 2
+	}
+	return minVersion, maxVersion
+}
+
+func (r *OffsetRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	if r.Version < 2 {
+		request.IsolationLevel = ReadUncommitted
+	}
+	return &request
+}
+
 func (r *OffsetRequest) headerVersion() int16 {
+	if r.Version >= 6 {
+		return 2
+	}
 	return 1
 }
 
@@ -143,6 +245,16 @@ func (r *OffsetRequest) requiredVersion() KafkaVersion {
 		return V0_10_1_0
 	case 2:
 		return V0_11_0_0
+	case 3:
+		return V2_0_0_0
+	case 4:
+		return V2_1_0_0
+	case 5:
+		return V2_2_0_0
+	case 6:
+		return V2_5_0_0
+	case 7:
+		return V3_0_0_0
 	default:
 		return MinVersion
 	}
@@ -170,6 +282,7 @@ func (r *OffsetRequest) AddBlock(topic string, partitionID int32, time int64, ma
 	}
 
 	tmp := new(offsetRequestBlock)
+	tmp.currentLeaderEpoch = -1
 	tmp.time = time
 	if r.Version == 0 {
 		tmp.maxOffsets = maxOffsets
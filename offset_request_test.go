@@ -42,6 +42,20 @@ var (
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
 	}
 
+	offsetRequestOneBlockV7 = []byte{
+		0xFF, 0xFF, 0xFF, 0xFF,
+		0x00,                // isolation level
+		0x02,                // 1 topic
+		0x04, 'b', 'a', 'r', // topic
+		0x02,                   // 1 partition
+		0x00, 0x00, 0x00, 0x04, // partition
+		0xFF, 0xFF, 0xFF, 0xFF, // current leader epoch
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFD, // max timestamp
+		0x00, // partition tagged fields
+		0x00, // topic tagged fields
+		0x00, // tagged fields
+	}
+
 	offsetRequestReplicaID = []byte{
 		0x00, 0x00, 0x00, 0x2a,
 		0x00, 0x00, 0x00, 0x00,
@@ -75,6 +89,13 @@ func TestOffsetRequestV2(t *testing.T) {
 	testRequest(t, "one block", request, offsetRequestOneBlockReadCommittedV2)
 }
 
+func TestOffsetRequestV7(t *testing.T) {
+	request := new(OffsetRequest)
+	request.Version = 7
+	request.AddBlock("bar", 4, OffsetMaxTimestamp, 1)
+	testRequest(t, "one block", request, offsetRequestOneBlockV7)
+}
+
 func TestOffsetRequestReplicaID(t *testing.T) {
 	request := new(OffsetRequest)
 	replicaID := int32(42)
@@ -1,10 +1,11 @@
 package sarama
 
 type OffsetResponseBlock struct {
-	Err       KError
-	Offsets   []int64 // Version 0
-	Offset    int64   // Version 1
-	Timestamp int64   // Version 1
+	Err         KError
+	Offsets     []int64 // Version 0
+	Offset      int64   // Version 1
+	Timestamp   int64   // Version 1
+	LeaderEpoch int32   // Version 4
 }
 
 func (b *OffsetResponseBlock) decode(pd packetDecoder, version int16) (err error) {
@@ -33,6 +34,18 @@ func (b *OffsetResponseBlock) decode(pd packetDecoder, version int16) (err error
 	// For backwards compatibility put the offset in the offsets array too
 	b.Offsets = []int64{b.Offset}
 
+	if version >= 4 {
+		if b.LeaderEpoch, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+
+	if version >= 6 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -46,6 +59,14 @@ func (b *OffsetResponseBlock) encode(pe packetEncoder, version int16) (err error
 	pe.putInt64(b.Timestamp)
 	pe.putInt64(b.Offset)
 
+	if version >= 4 {
+		pe.putInt32(b.LeaderEpoch)
+	}
+
+	if version >= 6 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
@@ -56,6 +77,7 @@ type OffsetResponse struct {
 }
 
 func (r *OffsetResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
 	if version >= 2 {
 		r.ThrottleTimeMs, err = pd.getInt32()
 		if err != nil {
@@ -63,19 +85,36 @@ func (r *OffsetResponse) decode(pd packetDecoder, version int16) (err error) {
 		}
 	}
 
-	numTopics, err := pd.getArrayLength()
+	isFlexible := version >= 6
+
+	var numTopics int
+	if isFlexible {
+		numTopics, err = pd.getCompactArrayLength()
+	} else {
+		numTopics, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
 
 	r.Blocks = make(map[string]map[int32]*OffsetResponseBlock, numTopics)
 	for i := 0; i < numTopics; i++ {
-		name, err := pd.getString()
+		var name string
+		if isFlexible {
+			name, err = pd.getCompactString()
+		} else {
+			name, err = pd.getString()
+		}
 		if err != nil {
 			return err
 		}
 
-		numBlocks, err := pd.getArrayLength()
+		var numBlocks int
+		if isFlexible {
+			numBlocks, err = pd.getCompactArrayLength()
+		} else {
+			numBlocks, err = pd.getArrayLength()
+		}
 		if err != nil {
 			return err
 		}
@@ -95,9 +134,18 @@ func (r *OffsetResponse) decode(pd packetDecoder, version int16) (err error) {
 			}
 			r.Blocks[name][id] = block
 		}
+
+		if isFlexible {
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
 	}
 
-	return nil
+	if isFlexible {
+		_, err = pd.getEmptyTaggedFieldArray()
+	}
+	return err
 }
 
 func (r *OffsetResponse) GetBlock(topic string, partition int32) *OffsetResponseBlock {
@@ -132,15 +180,26 @@ func (r *OffsetResponse) encode(pe packetEncoder) (err error) {
 		pe.putInt32(r.ThrottleTimeMs)
 	}
 
-	if err = pe.putArrayLength(len(r.Blocks)); err != nil {
+	isFlexible := r.Version >= 6
+
+	if isFlexible {
+		pe.putCompactArrayLength(len(r.Blocks))
+	} else if err = pe.putArrayLength(len(r.Blocks)); err != nil {
 		return err
 	}
 
 	for topic, partitions := range r.Blocks {
-		if err = pe.putString(topic); err != nil {
+		if isFlexible {
+			err = pe.putCompactString(topic)
+		} else {
+			err = pe.putString(topic)
+		}
+		if err != nil {
 			return err
 		}
-		if err = pe.putArrayLength(len(partitions)); err != nil {
+		if isFlexible {
+			pe.putCompactArrayLength(len(partitions))
+		} else if err = pe.putArrayLength(len(partitions)); err != nil {
 			return err
 		}
 		for partition, block := range partitions {
@@ -149,8 +208,14 @@ func (r *OffsetResponse) encode(pe packetEncoder) (err error) {
 				return err
 			}
 		}
+		if isFlexible {
+			pe.putEmptyTaggedFieldArray()
+		}
 	}
 
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
 	return nil
 }
 
@@ -162,7 +227,14 @@ func (r *OffsetResponse) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *OffsetResponse) headerVersion() int16 {
+	if r.Version >= 6 {
+		return 1
+	}
 	return 0
 }
 
@@ -172,6 +244,16 @@ func (r *OffsetResponse) requiredVersion() KafkaVersion {
 		return V0_10_1_0
 	case 2:
 		return V0_11_0_0
+	case 3:
+		return V2_0_0_0
+	case 4:
+		return V2_1_0_0
+	case 5:
+		return V2_2_0_0
+	case 6:
+		return V2_5_0_0
+	case 7:
+		return V3_0_0_0
 	default:
 		return MinVersion
 	}
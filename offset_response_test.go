@@ -40,6 +40,21 @@ var (
 	}
 )
 
+var offsetResponseV7 = []byte{
+	0x00, 0x00, 0x00, 0x00, // throttle time
+	0x02,      // 1 topic
+	0x02, 'z', // topic
+	0x02,                   // 1 partition
+	0x00, 0x00, 0x00, 0x02, // partition
+	0x00, 0x00, // no error
+	0x00, 0x00, 0x01, 0x58, 0x1A, 0xE6, 0x48, 0x86, // timestamp
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x06, // offset
+	0x00, 0x00, 0x00, 0x03, // leader epoch
+	0x00, // partition tagged fields
+	0x00, // topic tagged fields
+	0x00, // tagged fields
+}
+
 func TestEmptyOffsetResponse(t *testing.T) {
 	response := OffsetResponse{}
 
@@ -115,3 +130,11 @@ func TestNormalOffsetResponseV1(t *testing.T) {
 		t.Fatal("Decoding produced invalid offsets for topic z partition 2.")
 	}
 }
+
+func TestOffsetResponseV7(t *testing.T) {
+	response := OffsetResponse{Version: 7}
+	response.AddTopicPartition("z", 2, 6)
+	response.Blocks["z"][2].Timestamp = 1477920049286
+	response.Blocks["z"][2].LeaderEpoch = 3
+	testResponse(t, "v7", &response, offsetResponseV7)
+}
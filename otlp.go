@@ -0,0 +1,143 @@
+package sarama
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// otlpMetric is a metric of the OpenTelemetry (OTLP) MetricsData pushed to
+// the brokers with KIP-714, either a gauge or a monotonic sum
+type otlpMetric struct {
+	name   string
+	unit   string
+	sum    bool
+	points []otlpDataPoint
+}
+
+// otlpDataPoint is a NumberDataPoint of an otlpMetric, whose value is an
+// integer if isInt
+type otlpDataPoint struct {
+	attributes []otlpAttribute
+	start      time.Time
+	time       time.Time
+	value      float64
+	intValue   int64
+	isInt      bool
+}
+
+type otlpAttribute struct {
+	key, value string
+}
+
+// the AggregationTemporality of the OTLP sums
+const (
+	otlpTemporalityDelta      = 1
+	otlpTemporalityCumulative = 2
+)
+
+// encodeOTLPMetrics serializes the metrics as the protobuf OTLP MetricsData
+// of a single resource and instrumentation scope
+func encodeOTLPMetrics(scope string, metrics []*otlpMetric, temporality int) []byte {
+	var data protoBuffer
+	data.message(1, func(resourceMetrics *protoBuffer) {
+		resourceMetrics.message(2, func(scopeMetrics *protoBuffer) {
+			scopeMetrics.message(1, func(instrumentationScope *protoBuffer) {
+				instrumentationScope.string(1, scope)
+			})
+			for _, metric := range metrics {
+				scopeMetrics.message(2, func(m *protoBuffer) {
+					m.string(1, metric.name)
+					if metric.unit != "" {
+						m.string(3, metric.unit)
+					}
+					if metric.sum {
+						m.message(7, func(sum *protoBuffer) {
+							encodeOTLPDataPoints(sum, metric.points)
+							sum.uint(2, uint64(temporality))
+							sum.uint(3, 1) // is_monotonic
+						})
+					} else {
+						m.message(5, func(gauge *protoBuffer) {
+							encodeOTLPDataPoints(gauge, metric.points)
+						})
+					}
+				})
+			}
+		})
+	})
+	return data
+}
+
+func encodeOTLPDataPoints(pb *protoBuffer, points []otlpDataPoint) {
+	for _, point := range points {
+		pb.message(1, func(p *protoBuffer) {
+			if !point.start.IsZero() {
+				p.fixed64(2, uint64(point.start.UnixNano()))
+			}
+			p.fixed64(3, uint64(point.time.UnixNano()))
+			if point.isInt {
+				p.fixed64(6, uint64(point.intValue))
+			} else {
+				p.fixed64(4, math.Float64bits(point.value))
+			}
+			for _, attribute := range point.attributes {
+				p.message(7, func(keyValue *protoBuffer) {
+					keyValue.string(1, attribute.key)
+					keyValue.message(2, func(anyValue *protoBuffer) {
+						anyValue.string(1, attribute.value)
+					})
+				})
+			}
+		})
+	}
+}
+
+// protoBuffer appends the fields of a protobuf message
+type protoBuffer []byte
+
+// the protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+)
+
+func (pb *protoBuffer) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	*pb = append(*pb, b[:n]...)
+}
+
+func (pb *protoBuffer) tag(field, wireType int) {
+	pb.varint(uint64(field<<3 | wireType))
+}
+
+func (pb *protoBuffer) uint(field int, v uint64) {
+	pb.tag(field, protoVarint)
+	pb.varint(v)
+}
+
+func (pb *protoBuffer) fixed64(field int, v uint64) {
+	pb.tag(field, protoFixed64)
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	*pb = append(*pb, b[:]...)
+}
+
+func (pb *protoBuffer) bytes(field int, b []byte) {
+	pb.tag(field, protoBytes)
+	pb.varint(uint64(len(b)))
+	*pb = append(*pb, b...)
+}
+
+func (pb *protoBuffer) string(field int, s string) {
+	pb.bytes(field, []byte(s))
+}
+
+// message appends the embedded message built by f
+func (pb *protoBuffer) message(field int, f func(*protoBuffer)) {
+	var embedded protoBuffer
+	f(&embedded)
+	pb.bytes(field, embedded)
+}
@@ -0,0 +1,130 @@
+package sarama
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+// protoField is a field of a protobuf message decoded by protoFields
+type protoField struct {
+	number int
+	value  uint64
+	bytes  []byte
+}
+
+// protoFields decodes the varint, fixed64 and length-delimited fields of a
+// protobuf message
+func protoFields(t *testing.T, b []byte) []protoField {
+	t.Helper()
+	var fields []protoField
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatal("invalid tag")
+		}
+		b = b[n:]
+		field := protoField{number: int(tag >> 3)}
+		switch tag & 7 {
+		case protoVarint:
+			field.value, n = binary.Uvarint(b)
+			b = b[n:]
+		case protoFixed64:
+			field.value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case protoBytes:
+			length, n := binary.Uvarint(b)
+			field.bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// protoFieldOf returns the only field of the number, failing if not exactly one
+func protoFieldOf(t *testing.T, fields []protoField, number int) protoField {
+	t.Helper()
+	var found []protoField
+	for _, field := range fields {
+		if field.number == number {
+			found = append(found, field)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected one field %d, got %d", number, len(found))
+	}
+	return found[0]
+}
+
+func TestEncodeOTLPMetrics(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	data := encodeOTLPMetrics("sarama", []*otlpMetric{
+		{
+			name: "org.apache.kafka.sarama.request.total",
+			sum:  true,
+			points: []otlpDataPoint{{
+				attributes: []otlpAttribute{{key: "broker", value: "1"}},
+				start:      now.Add(-time.Minute),
+				time:       now,
+				value:      42,
+			}},
+		},
+		{
+			name:   "org.apache.kafka.sarama.requests.in.flight",
+			points: []otlpDataPoint{{time: now, intValue: 3, isInt: true}},
+		},
+	}, otlpTemporalityDelta)
+
+	resourceMetrics := protoFields(t, protoFieldOf(t, protoFields(t, data), 1).bytes)
+	scopeMetrics := protoFields(t, protoFieldOf(t, resourceMetrics, 2).bytes)
+	scope := protoFields(t, protoFieldOf(t, scopeMetrics, 1).bytes)
+	if name := string(protoFieldOf(t, scope, 1).bytes); name != "sarama" {
+		t.Errorf("unexpected scope %q", name)
+	}
+
+	var metrics [][]protoField
+	for _, field := range scopeMetrics {
+		if field.number == 2 {
+			metrics = append(metrics, protoFields(t, field.bytes))
+		}
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(metrics))
+	}
+
+	if name := string(protoFieldOf(t, metrics[0], 1).bytes); name != "org.apache.kafka.sarama.request.total" {
+		t.Errorf("unexpected name %q", name)
+	}
+	sum := protoFields(t, protoFieldOf(t, metrics[0], 7).bytes)
+	if temporality := protoFieldOf(t, sum, 2).value; temporality != otlpTemporalityDelta {
+		t.Errorf("unexpected temporality %d", temporality)
+	}
+	if monotonic := protoFieldOf(t, sum, 3).value; monotonic != 1 {
+		t.Error("expected a monotonic sum")
+	}
+	point := protoFields(t, protoFieldOf(t, sum, 1).bytes)
+	if start := protoFieldOf(t, point, 2).value; start != uint64(now.Add(-time.Minute).UnixNano()) {
+		t.Errorf("unexpected start time %d", start)
+	}
+	if ts := protoFieldOf(t, point, 3).value; ts != uint64(now.UnixNano()) {
+		t.Errorf("unexpected time %d", ts)
+	}
+	if value := math.Float64frombits(protoFieldOf(t, point, 4).value); value != 42 {
+		t.Errorf("unexpected value %v", value)
+	}
+	attribute := protoFields(t, protoFieldOf(t, point, 7).bytes)
+	anyValue := protoFields(t, protoFieldOf(t, attribute, 2).bytes)
+	if key, value := string(protoFieldOf(t, attribute, 1).bytes), string(protoFieldOf(t, anyValue, 1).bytes); key != "broker" || value != "1" {
+		t.Errorf("unexpected attribute %s=%s", key, value)
+	}
+
+	gauge := protoFields(t, protoFieldOf(t, metrics[1], 5).bytes)
+	point = protoFields(t, protoFieldOf(t, gauge, 1).bytes)
+	if value := int64(protoFieldOf(t, point, 6).value); value != 3 {
+		t.Errorf("unexpected value %d", value)
+	}
+}
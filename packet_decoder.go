@@ -40,6 +40,14 @@ type packetDecoder interface {
 	// Stacks, see PushDecoder
 	push(in pushDecoder) error
 	pop() error
+
+	// maxDecompressedBytes limits the size of the decompressed record
+	// batches and messages, 0 not limiting it
+	maxDecompressedBytes() int
+
+	// deferRecords defers the decompression and decoding of the records of
+	// the record batches until they are consumed
+	deferRecords() bool
 }
 
 // PushDecoder is the interface for decoding fields like CRCs and lengths where the validity
@@ -1,7 +1,5 @@
 package sarama
 
-import "github.com/rcrowley/go-metrics"
-
 // RequiredAcks is used in Produce Requests to tell the broker how many replica acknowledgements
 // it must see before responding. Any of the constants defined here are valid. On broker versions
 // prior to 0.8.2.0 any other positive int16 is also valid (the broker will wait for that many
@@ -28,8 +26,8 @@ type ProduceRequest struct {
 	records         map[string]map[int32]Records
 }
 
-func updateMsgSetMetrics(msgSet *MessageSet, compressionRatioMetric metrics.Histogram,
-	topicCompressionRatioMetric metrics.Histogram) int64 {
+func updateMsgSetMetrics(msgSet *MessageSet, compressionRatioMetric MetricHistogram,
+	topicCompressionRatioMetric MetricHistogram) int64 {
 	var topicRecordCount int64
 	for _, messageBlock := range msgSet.Messages {
 		// Is this a fake "message" wrapping real messages?
@@ -45,19 +43,19 @@ func updateMsgSetMetrics(msgSet *MessageSet, compressionRatioMetric metrics.Hist
 				float64(messageBlock.Msg.compressedSize)
 			// Histogram do not support decimal values, let's multiple it by 100 for better precision
 			intCompressionRatio := int64(100 * compressionRatio)
-			compressionRatioMetric.Update(intCompressionRatio)
-			topicCompressionRatioMetric.Update(intCompressionRatio)
+			compressionRatioMetric.Observe(intCompressionRatio)
+			topicCompressionRatioMetric.Observe(intCompressionRatio)
 		}
 	}
 	return topicRecordCount
 }
 
-func updateBatchMetrics(recordBatch *RecordBatch, compressionRatioMetric metrics.Histogram,
-	topicCompressionRatioMetric metrics.Histogram) int64 {
+func updateBatchMetrics(recordBatch *RecordBatch, compressionRatioMetric MetricHistogram,
+	topicCompressionRatioMetric MetricHistogram) int64 {
 	if recordBatch.compressedRecords != nil {
 		compressionRatio := int64(float64(recordBatch.recordsLen) / float64(len(recordBatch.compressedRecords)) * 100)
-		compressionRatioMetric.Update(compressionRatio)
-		topicCompressionRatioMetric.Update(compressionRatio)
+		compressionRatioMetric.Observe(compressionRatio)
+		topicCompressionRatioMetric.Observe(compressionRatio)
 	}
 
 	return int64(len(recordBatch.Records))
@@ -71,12 +69,12 @@ func (r *ProduceRequest) encode(pe packetEncoder) error {
 	}
 	pe.putInt16(int16(r.RequiredAcks))
 	pe.putInt32(r.Timeout)
-	metricRegistry := pe.metricRegistry()
-	var batchSizeMetric metrics.Histogram
-	var compressionRatioMetric metrics.Histogram
-	if metricRegistry != nil {
-		batchSizeMetric = getOrRegisterHistogram("batch-size", metricRegistry)
-		compressionRatioMetric = getOrRegisterHistogram("compression-ratio", metricRegistry)
+	recorder := pe.metricsRecorder()
+	var batchSizeMetric MetricHistogram
+	var compressionRatioMetric MetricHistogram
+	if recorder != nil {
+		batchSizeMetric = recorder.Histogram("batch-size", nil)
+		compressionRatioMetric = recorder.Histogram("compression-ratio", nil)
 	}
 	totalRecordCount := int64(0)
 
@@ -95,9 +93,9 @@ func (r *ProduceRequest) encode(pe packetEncoder) error {
 			return err
 		}
 		topicRecordCount := int64(0)
-		var topicCompressionRatioMetric metrics.Histogram
-		if metricRegistry != nil {
-			topicCompressionRatioMetric = getOrRegisterTopicHistogram("compression-ratio", topic, metricRegistry)
+		var topicCompressionRatioMetric MetricHistogram
+		if recorder != nil {
+			topicCompressionRatioMetric = recorder.Histogram("compression-ratio", topicMetricLabels(topic))
 		}
 		for id, records := range partitions {
 			startOffset := pe.offset()
@@ -111,26 +109,26 @@ func (r *ProduceRequest) encode(pe packetEncoder) error {
 			if err != nil {
 				return err
 			}
-			if metricRegistry != nil {
+			if recorder != nil {
 				if r.Version >= 3 {
 					topicRecordCount += updateBatchMetrics(records.RecordBatch, compressionRatioMetric, topicCompressionRatioMetric)
 				} else {
 					topicRecordCount += updateMsgSetMetrics(records.MsgSet, compressionRatioMetric, topicCompressionRatioMetric)
 				}
 				batchSize := int64(pe.offset() - startOffset)
-				batchSizeMetric.Update(batchSize)
-				getOrRegisterTopicHistogram("batch-size", topic, metricRegistry).Update(batchSize)
+				batchSizeMetric.Observe(batchSize)
+				recorder.Histogram("batch-size", topicMetricLabels(topic)).Observe(batchSize)
 			}
 		}
 		if topicRecordCount > 0 {
-			getOrRegisterTopicMeter("record-send-rate", topic, metricRegistry).Mark(topicRecordCount)
-			getOrRegisterTopicHistogram("records-per-request", topic, metricRegistry).Update(topicRecordCount)
+			recorder.Counter("record-send-rate", topicMetricLabels(topic)).Add(topicRecordCount)
+			recorder.Histogram("records-per-request", topicMetricLabels(topic)).Observe(topicRecordCount)
 			totalRecordCount += topicRecordCount
 		}
 	}
 	if totalRecordCount > 0 {
-		metrics.GetOrRegisterMeter("record-send-rate", metricRegistry).Mark(totalRecordCount)
-		getOrRegisterHistogram("records-per-request", metricRegistry).Update(totalRecordCount)
+		recorder.Counter("record-send-rate", nil).Add(totalRecordCount)
+		recorder.Histogram("records-per-request", nil).Observe(totalRecordCount)
 	}
 
 	return nil
@@ -206,6 +204,20 @@ func (r *ProduceRequest) version() int16 {
 	return r.Version
 }
 
+func (r *ProduceRequest) versionRange() (minVersion, maxVersion int16) {
+	if r.Version >= 3 {
+		// the records are record batches
+		return 3, 7
+	}
+	return 0, 2
+}
+
+func (r *ProduceRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	return &request
+}
+
 func (r *ProduceRequest) headerVersion() int16 {
 	return 1
 }
@@ -83,6 +83,10 @@ type ProduceResponse struct {
 	Blocks       map[string]map[int32]*ProduceResponseBlock // v0, responses
 	Version      int16
 	ThrottleTime time.Duration // v1, throttle_time_ms
+
+	// correlationID is the correlation ID of the request, which the producer
+	// attaches to the errors of the blocks
+	correlationID int32
 }
 
 func (r *ProduceResponse) decode(pd packetDecoder, version int16) (err error) {
@@ -163,6 +167,10 @@ func (r *ProduceResponse) encode(pe packetEncoder) error {
 	return nil
 }
 
+func (r *ProduceResponse) setCorrelationID(correlationID int32) {
+	r.correlationID = correlationID
+}
+
 func (r *ProduceResponse) key() int16 {
 	return 0
 }
@@ -171,6 +179,10 @@ func (r *ProduceResponse) version() int16 {
 	return r.Version
 }
 
+func (r *ProduceResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ProduceResponse) headerVersion() int16 {
 	return 0
 }
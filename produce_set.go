@@ -50,7 +50,7 @@ func (ps *produceSet) add(msg *ProducerMessage) error {
 
 	timestamp := msg.Timestamp
 	if timestamp.IsZero() {
-		timestamp = time.Now()
+		timestamp = ps.parent.conf.Clock.Now()
 	}
 	timestamp = timestamp.Truncate(time.Millisecond)
 
@@ -65,13 +65,18 @@ func (ps *produceSet) add(msg *ProducerMessage) error {
 	set := partitions[msg.Partition]
 	if set == nil {
 		if ps.parent.conf.Version.IsAtLeast(V0_11_0_0) {
+			codec, level := ps.parent.conf.producerCompression(msg.Topic)
 			batch := &RecordBatch{
 				FirstTimestamp:   timestamp,
 				Version:          2,
-				Codec:            ps.parent.conf.Producer.Compression,
-				CompressionLevel: ps.parent.conf.Producer.CompressionLevel,
+				Codec:            codec,
+				CompressionLevel: level,
 				ProducerID:       ps.producerID,
 				ProducerEpoch:    ps.producerEpoch,
+				IsTransactional:  ps.parent.txnmgr.isTransactional(),
+			}
+			if codec == CompressionZSTD {
+				batch.ZstdDictionaryID = ps.parent.conf.Producer.ZstdDictionaryID
 			}
 			if ps.parent.conf.Producer.Idempotent {
 				batch.FirstSequence = msg.sequenceNumber
@@ -127,9 +132,56 @@ func (ps *produceSet) add(msg *ProducerMessage) error {
 	return nil
 }
 
+// splitByRequiredAcks returns the sets of the messages of the topics
+// requiring the same acks, see TopicOverrides.
+func (ps *produceSet) splitByRequiredAcks() []*produceSet {
+	if len(ps.parent.conf.Topics) == 0 {
+		return []*produceSet{ps}
+	}
+	sets := make(map[RequiredAcks]*produceSet)
+	for topic, partitions := range ps.msgs {
+		acks := ps.parent.conf.producerRequiredAcks(topic)
+		set := sets[acks]
+		if set == nil {
+			set = &produceSet{
+				parent:        ps.parent,
+				msgs:          make(map[string]map[int32]*partitionSet),
+				producerID:    ps.producerID,
+				producerEpoch: ps.producerEpoch,
+			}
+			sets[acks] = set
+		}
+		set.msgs[topic] = partitions
+		for _, partitionSet := range partitions {
+			set.bufferBytes += partitionSet.bufferBytes
+			set.bufferCount += len(partitionSet.msgs)
+		}
+	}
+	if len(sets) == 1 {
+		return []*produceSet{ps}
+	}
+	// in a consistent order, the strongest acks first
+	split := make([]*produceSet, 0, len(sets))
+	for _, acks := range []RequiredAcks{WaitForAll, WaitForLocal, NoResponse} {
+		if set, ok := sets[acks]; ok {
+			split = append(split, set)
+		}
+	}
+	return split
+}
+
+// requiredAcks returns the acks required for the messages of the set, all
+// its topics requiring the same ones once split by splitByRequiredAcks.
+func (ps *produceSet) requiredAcks() RequiredAcks {
+	for topic := range ps.msgs {
+		return ps.parent.conf.producerRequiredAcks(topic)
+	}
+	return ps.parent.conf.Producer.RequiredAcks
+}
+
 func (ps *produceSet) buildRequest() *ProduceRequest {
 	req := &ProduceRequest{
-		RequiredAcks: ps.parent.conf.Producer.RequiredAcks,
+		RequiredAcks: ps.requiredAcks(),
 		Timeout:      int32(ps.parent.conf.Producer.Timeout / time.Millisecond),
 	}
 	if ps.parent.conf.Version.IsAtLeast(V0_10_0_0) {
@@ -139,10 +191,14 @@ func (ps *produceSet) buildRequest() *ProduceRequest {
 		req.Version = 3
 	}
 
-	if ps.parent.conf.Producer.Compression == CompressionZSTD && ps.parent.conf.Version.IsAtLeast(V2_1_0_0) {
+	if ps.usesZSTD() && ps.parent.conf.Version.IsAtLeast(V2_1_0_0) {
 		req.Version = 7
 	}
 
+	if ps.parent.txnmgr.isTransactional() {
+		req.TransactionalID = &ps.parent.conf.Producer.Transaction.ID
+	}
+
 	for topic, partitionSets := range ps.msgs {
 		for partition, set := range partitionSets {
 			if req.Version >= 3 {
@@ -163,7 +219,8 @@ func (ps *produceSet) buildRequest() *ProduceRequest {
 				req.AddBatch(topic, partition, rb)
 				continue
 			}
-			if ps.parent.conf.Producer.Compression == CompressionNone {
+			codec, level := ps.parent.conf.producerCompression(topic)
+			if codec == CompressionNone {
 				req.AddSet(topic, partition, set.recordsToSend.MsgSet)
 			} else {
 				// When compression is enabled, the entire set for each partition is compressed
@@ -181,14 +238,14 @@ func (ps *produceSet) buildRequest() *ProduceRequest {
 						msg.Offset = int64(i)
 					}
 				}
-				payload, err := encode(set.recordsToSend.MsgSet, ps.parent.conf.MetricRegistry)
+				payload, err := encode(set.recordsToSend.MsgSet, ps.parent.conf.metricsRecorder())
 				if err != nil {
 					Logger.Println(err) // if this happens, it's basically our fault.
 					panic(err)
 				}
 				compMsg := &Message{
-					Codec:            ps.parent.conf.Producer.Compression,
-					CompressionLevel: ps.parent.conf.Producer.CompressionLevel,
+					Codec:            codec,
+					CompressionLevel: level,
 					Key:              nil,
 					Value:            payload,
 					Set:              set.recordsToSend.MsgSet, // Provide the underlying message set for accurate metrics
@@ -205,6 +262,20 @@ func (ps *produceSet) buildRequest() *ProduceRequest {
 	return req
 }
 
+// usesZSTD returns whether the messages of any topic of the set are
+// compressed with zstd
+func (ps *produceSet) usesZSTD() bool {
+	if len(ps.parent.conf.Topics) == 0 {
+		return ps.parent.conf.Producer.Compression == CompressionZSTD
+	}
+	for topic := range ps.msgs {
+		if codec, _ := ps.parent.conf.producerCompression(topic); codec == CompressionZSTD {
+			return true
+		}
+	}
+	return false
+}
+
 func (ps *produceSet) eachPartition(cb func(topic string, partition int32, pSet *partitionSet)) {
 	for topic, partitionSet := range ps.msgs {
 		for partition, set := range partitionSet {
@@ -239,7 +310,7 @@ func (ps *produceSet) wouldOverflow(msg *ProducerMessage) bool {
 		return true
 	// Would we overflow the size-limit of a message-batch for this partition?
 	case ps.msgs[msg.Topic] != nil && ps.msgs[msg.Topic][msg.Partition] != nil &&
-		ps.msgs[msg.Topic][msg.Partition].bufferBytes+msg.byteSize(version) >= ps.parent.conf.Producer.MaxMessageBytes:
+		ps.msgs[msg.Topic][msg.Partition].bufferBytes+msg.byteSize(version) >= ps.parent.conf.producerMaxMessageBytes(msg.Topic):
 		return true
 	// Would we overflow simply in number of messages?
 	case ps.parent.conf.Producer.Flush.MaxMessages > 0 && ps.bufferCount >= ps.parent.conf.Producer.Flush.MaxMessages:
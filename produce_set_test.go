@@ -425,3 +425,43 @@ func TestProduceSetConsistentTimestamps(t *testing.T) {
 		t.Errorf("Message timestamps do not match: %v, %v", time1, time2)
 	}
 }
+
+func TestProduceSetTopicOverrides(t *testing.T) {
+	parent, ps := makeProduceSet()
+	parent.conf.Version = V0_11_0_0
+	noResponse, gzip := NoResponse, CompressionGZIP
+	var overrides TopicOverrides
+	overrides.Producer.RequiredAcks = &noResponse
+	overrides.Producer.Compression = &gzip
+	parent.conf.Topics = map[string]TopicOverrides{"logs": overrides}
+
+	safeAddMessage(t, ps, &ProducerMessage{Topic: "orders", Partition: 0, Value: StringEncoder(TestMessage)})
+	safeAddMessage(t, ps, &ProducerMessage{Topic: "logs", Partition: 0, Value: StringEncoder(TestMessage)})
+	safeAddMessage(t, ps, &ProducerMessage{Topic: "logs", Partition: 1, Value: StringEncoder(TestMessage)})
+
+	sets := ps.splitByRequiredAcks()
+	if len(sets) != 2 {
+		t.Fatalf("expected 2 sets, got %d", len(sets))
+	}
+	orders, logs := sets[0].buildRequest(), sets[1].buildRequest()
+	if orders.RequiredAcks != WaitForLocal || len(orders.records) != 1 || orders.records["orders"] == nil {
+		t.Errorf("expected the orders to wait for the leader, got %+v", orders)
+	}
+	if logs.RequiredAcks != NoResponse || len(logs.records) != 1 || len(logs.records["logs"]) != 2 {
+		t.Errorf("expected the logs not to wait for a response, got %+v", logs)
+	}
+	if sets[1].bufferCount != 2 {
+		t.Errorf("expected 2 messages in the logs set, got %d", sets[1].bufferCount)
+	}
+	if codec := logs.records["logs"][0].RecordBatch.Codec; codec != CompressionGZIP {
+		t.Errorf("expected the logs to be compressed with gzip, got %s", codec)
+	}
+	if codec := orders.records["orders"][0].RecordBatch.Codec; codec != CompressionNone {
+		t.Errorf("expected the orders not to be compressed, got %s", codec)
+	}
+
+	parent.conf.Topics = nil
+	if sets := ps.splitByRequiredAcks(); len(sets) != 1 || sets[0] != ps {
+		t.Error("expected the set not to be split without overrides")
+	}
+}
@@ -0,0 +1,248 @@
+package sarama
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// prometheusQuantiles are the quantiles of the summaries of the histograms
+var prometheusQuantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+// prometheusGroupMetrics are the metrics whose name ends with the consumer
+// group
+var prometheusGroupMetrics = []string{
+	"consumer-group-join-total-",
+	"consumer-group-join-failed-",
+	"consumer-group-sync-total-",
+	"consumer-group-sync-failed-",
+}
+
+// PrometheusMetric is a sample of a metric of a PrometheusExporter, in the
+// terms of Prometheus, so that it can be turned into a constant metric of a
+// prometheus.Collector of the Prometheus client.
+type PrometheusMetric struct {
+	// Name is the name of the metric, such as sarama_request_total.
+	Name string
+	// Type is "counter", "gauge" or "summary".
+	Type string
+	Help string
+	// Labels are the "broker", "topic", "partition", "api" and "group" of
+	// the metric, if any.
+	Labels map[string]string
+	// Value is the value of the counters and gauges.
+	Value float64
+	// Count, Sum and Quantiles are the values of the summaries.
+	Count     uint64
+	Sum       float64
+	Quantiles map[float64]float64
+}
+
+// PrometheusExporter exposes the metrics of a go-metrics registry, such as
+// Config.MetricRegistry, to Prometheus, so that they can be scraped from its
+// ServeHTTP or added to the collectors of the Prometheus client with
+// Metrics. The metrics are renamed after the conventions of Prometheus, the
+// "-for-broker-<broker-id>", "-for-api-<api-key>", "-for-topic-<topic>" and
+// "-for-partition-<partition>" suffixes and the consumer groups becoming
+// labels:
+//
+//   - the meters, such as request-rate-for-broker-1, are counters of the
+//     events, such as sarama_request_total{broker="1"}
+//   - the histograms, such as request-latency-in-ms, are summaries, such as
+//     sarama_request_latency_in_ms
+//   - the counters, such as requests-in-flight, and the gauges are gauges,
+//     such as sarama_requests_in_flight, except the totals of the consumer
+//     groups, which are counters
+type PrometheusExporter struct {
+	registry  metrics.Registry
+	namespace string
+}
+
+// NewPrometheusExporter returns the exporter of the metrics of the registry,
+// whose names are prefixed with the namespace, "sarama" if empty.
+func NewPrometheusExporter(registry metrics.Registry, namespace string) *PrometheusExporter {
+	if namespace == "" {
+		namespace = "sarama"
+	}
+	return &PrometheusExporter{registry: registry, namespace: namespace}
+}
+
+// Metrics returns a snapshot of the metrics, sorted by name and labels.
+func (e *PrometheusExporter) Metrics() []*PrometheusMetric {
+	var samples []*PrometheusMetric
+	e.registry.Each(func(name string, metric interface{}) {
+		if sample := e.sample(name, metric); sample != nil {
+			samples = append(samples, sample)
+		}
+	})
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Name != samples[j].Name {
+			return samples[i].Name < samples[j].Name
+		}
+		return prometheusLabels(samples[i].Labels) < prometheusLabels(samples[j].Labels)
+	})
+	return samples
+}
+
+// WriteTo writes the metrics in the text exposition format of Prometheus.
+func (e *PrometheusExporter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	write := func(format string, args ...interface{}) {
+		written, _ := fmt.Fprintf(bw, format, args...)
+		n += int64(written)
+	}
+
+	var family string
+	for _, sample := range e.Metrics() {
+		if sample.Name != family {
+			family = sample.Name
+			write("# HELP %s %s\n", sample.Name, sample.Help)
+			write("# TYPE %s %s\n", sample.Name, sample.Type)
+		}
+		labels := prometheusLabels(sample.Labels)
+		if sample.Type != "summary" {
+			write("%s%s %s\n", sample.Name, labels, prometheusValue(sample.Value))
+			continue
+		}
+		for _, q := range prometheusQuantiles {
+			quantileLabels := prometheusLabels(sample.Labels, "quantile", strconv.FormatFloat(q, 'g', -1, 64))
+			write("%s%s %s\n", sample.Name, quantileLabels, prometheusValue(sample.Quantiles[q]))
+		}
+		write("%s_sum%s %s\n", sample.Name, labels, prometheusValue(sample.Sum))
+		write("%s_count%s %d\n", sample.Name, labels, sample.Count)
+	}
+	return n, bw.Flush()
+}
+
+// ServeHTTP serves the metrics in the text exposition format of Prometheus.
+func (e *PrometheusExporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = e.WriteTo(w)
+}
+
+// sample returns the sample of a go-metrics metric, nil if not supported
+func (e *PrometheusExporter) sample(name string, metric interface{}) *PrometheusMetric {
+	base, labels := prometheusNameLabels(name)
+	sample := &PrometheusMetric{Labels: labels, Help: "Sarama " + base}
+	switch m := metric.(type) {
+	case metrics.Meter:
+		sample.Type = "counter"
+		sample.Name = strings.TrimSuffix(base, "-rate") + "-total"
+		sample.Value = float64(m.Count())
+	case metrics.Histogram:
+		snapshot := m.Snapshot()
+		sample.Type = "summary"
+		sample.Name = base
+		sample.Count = uint64(snapshot.Count())
+		sample.Sum = float64(snapshot.Sum())
+		sample.Quantiles = make(map[float64]float64, len(prometheusQuantiles))
+		for i, value := range snapshot.Percentiles(prometheusQuantiles) {
+			sample.Quantiles[prometheusQuantiles[i]] = value
+		}
+	case metrics.Counter:
+		sample.Type = "gauge"
+		if strings.HasPrefix(base, "consumer-group-") {
+			sample.Type = "counter"
+		}
+		sample.Name = base
+		sample.Value = float64(m.Count())
+	case metrics.Gauge:
+		sample.Type = "gauge"
+		sample.Name = base
+		sample.Value = float64(m.Value())
+	case metrics.GaugeFloat64:
+		sample.Type = "gauge"
+		sample.Name = base
+		sample.Value = m.Value()
+	default:
+		return nil
+	}
+	sample.Name = e.namespace + "_" + prometheusName(sample.Name)
+	return sample
+}
+
+// prometheusNameLabels splits the labels of a go-metrics name off it
+func prometheusNameLabels(name string) (string, map[string]string) {
+	labels := make(map[string]string)
+	for _, prefix := range prometheusGroupMetrics {
+		if strings.HasPrefix(name, prefix) {
+			labels["group"] = name[len(prefix):]
+			return prefix[:len(prefix)-1], labels
+		}
+	}
+	if i := strings.LastIndex(name, "-for-broker-"); i >= 0 {
+		labels["broker"] = name[i+len("-for-broker-"):]
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, "-for-api-"); i >= 0 {
+		labels["api"] = name[i+len("-for-api-"):]
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, "-for-partition-"); i >= 0 {
+		// the partition is followed by the consumer group, if any
+		partition := name[i+len("-for-partition-"):]
+		if j := strings.IndexByte(partition, '-'); j >= 0 {
+			labels["group"] = partition[j+1:]
+			partition = partition[:j]
+		}
+		labels["partition"] = partition
+		name = name[:i]
+	}
+	if i := strings.Index(name, "-for-topic-"); i >= 0 {
+		labels["topic"] = name[i+len("-for-topic-"):]
+		name = name[:i]
+	}
+	return name, labels
+}
+
+// prometheusName replaces the characters not allowed in the names of
+// Prometheus with underscores
+func prometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// prometheusLabels formats the labels, sorted, with the extra label pair if
+// any
+func prometheusLabels(labels map[string]string, extra ...string) string {
+	if len(labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+strconv.Quote(labels[key]))
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, extra[0]+"="+strconv.Quote(extra[1]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func prometheusValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
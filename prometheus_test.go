@@ -0,0 +1,75 @@
+package sarama
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestPrometheusExporter(t *testing.T) {
+	registry := metrics.NewRegistry()
+	recorder := NewGoMetricsRecorder(registry)
+	recorder.Counter("request-rate", nil).Add(3)
+	recorder.Counter("request-rate", MetricLabels{"broker": "1"}).Add(2)
+	recorder.Gauge("requests-in-flight", MetricLabels{"broker": "1"}).Set(1)
+	recorder.Histogram("batch-size", MetricLabels{"topic": "my_topic"}).Observe(10)
+	recorder.Histogram("batch-size", MetricLabels{"topic": "my_topic"}).Observe(30)
+	recorder.Counter("consumer-group-join-total", MetricLabels{"group": "my-group"}).Add(1)
+
+	exporter := NewPrometheusExporter(registry, "")
+	var buf bytes.Buffer
+	if _, err := exporter.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP sarama_batch_size Sarama batch-size
+# TYPE sarama_batch_size summary
+sarama_batch_size{topic="my_topic",quantile="0.5"} 20
+sarama_batch_size{topic="my_topic",quantile="0.75"} 30
+sarama_batch_size{topic="my_topic",quantile="0.95"} 30
+sarama_batch_size{topic="my_topic",quantile="0.99"} 30
+sarama_batch_size{topic="my_topic",quantile="0.999"} 30
+sarama_batch_size_sum{topic="my_topic"} 40
+sarama_batch_size_count{topic="my_topic"} 2
+# HELP sarama_consumer_group_join_total Sarama consumer-group-join-total
+# TYPE sarama_consumer_group_join_total counter
+sarama_consumer_group_join_total{group="my-group"} 1
+# HELP sarama_request_total Sarama request-rate
+# TYPE sarama_request_total counter
+sarama_request_total 3
+sarama_request_total{broker="1"} 2
+# HELP sarama_requests_in_flight Sarama requests-in-flight
+# TYPE sarama_requests_in_flight gauge
+sarama_requests_in_flight{broker="1"} 1
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected exposition:\n%s", buf.String())
+	}
+
+	rec := httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") || rec.Body.String() != expected {
+		t.Errorf("Unexpected response %q: %s", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestPrometheusNameLabels(t *testing.T) {
+	for name, expected := range map[string]string{
+		"record-send-rate-for-topic-my_topic":               `record-send-rate{topic="my_topic"}`,
+		"request-latency-in-ms-for-broker-12":               `request-latency-in-ms{broker="12"}`,
+		"request-rate-for-api-Fetch-for-broker-3":           `request-rate{api="Fetch",broker="3"}`,
+		"consumer-lag-for-topic-t-for-partition-2":          `consumer-lag{partition="2",topic="t"}`,
+		"consumer-lag-for-topic-t-for-partition-2-my-group": `consumer-lag{group="my-group",partition="2",topic="t"}`,
+		"response-size-for-api-Produce":                     `response-size{api="Produce"}`,
+		"consumer-group-sync-failed-a-b-c":                  `consumer-group-sync-failed{group="a-b-c"}`,
+		"oauth-token-refresh-failure-rate":                  `oauth-token-refresh-failure-rate`,
+		"compression-ratio-for-topic-for-test":              `compression-ratio{topic="for-test"}`,
+	} {
+		base, labels := prometheusNameLabels(name)
+		if got := base + prometheusLabels(labels); got != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, name, got)
+		}
+	}
+}
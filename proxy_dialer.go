@@ -0,0 +1,141 @@
+package sarama
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/proxy"
+)
+
+// contextDialerFunc is an adapter to use Config.Net.DialContext as a
+// proxy.Dialer.
+type contextDialerFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func (f contextDialerFunc) Dial(network, addr string) (net.Conn, error) {
+	return f(context.Background(), network, addr)
+}
+
+func (f contextDialerFunc) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return f(ctx, network, addr)
+}
+
+// failingDialer is the dialer of an invalid configuration, failing all dials.
+type failingDialer struct {
+	err error
+}
+
+func (d failingDialer) Dial(network, addr string) (net.Conn, error) {
+	return nil, d.err
+}
+
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, possible values are socks5, socks5h and http", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing proxy host in %q", rawURL)
+	}
+	return u, nil
+}
+
+// newProxyDialer returns a dialer connecting through the proxy of the given
+// URL, which is reached with the forward dialer.
+func newProxyDialer(rawURL string, forward proxy.Dialer) (proxy.Dialer, error) {
+	u, err := parseProxyURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "http" {
+		return &httpConnectDialer{proxy: u, forward: forward}, nil
+	}
+
+	var auth *proxy.Auth
+	if u.User != nil {
+		auth = &proxy.Auth{User: u.User.Username()}
+		auth.Password, _ = u.User.Password()
+	}
+	return proxy.SOCKS5("tcp", u.Host, auth, forward)
+}
+
+// httpConnectDialer tunnels the connections through an HTTP proxy with the
+// CONNECT method.
+type httpConnectDialer struct {
+	proxy   *url.URL
+	forward proxy.Dialer
+}
+
+func (d *httpConnectDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+func (d *httpConnectDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	if forward, ok := d.forward.(proxy.ContextDialer); ok {
+		conn, err = forward.DialContext(ctx, network, d.proxy.Host)
+	} else {
+		conn, err = d.forward.Dial(network, d.proxy.Host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// abort the handshake when the context is done
+	done := make(chan none)
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if d.proxy.User != nil {
+		password, _ := d.proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, proxyError(ctx, err)
+	}
+
+	// nothing is sent by the broker before the client speaks, so no bytes
+	// beyond the response can be buffered
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, proxyError(ctx, err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy %s refused to connect to %s: %s", d.proxy.Host, addr, res.Status)
+	}
+	return conn, nil
+}
+
+func proxyError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
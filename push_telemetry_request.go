@@ -0,0 +1,73 @@
+package sarama
+
+// PushTelemetryRequest is a request to push the metrics of the client
+// subscribed to with a GetTelemetrySubscriptionsRequest (KIP-714)
+type PushTelemetryRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ClientInstanceID Uuid
+	SubscriptionID   int32
+	// Terminating is set by the last push of a client being closed
+	Terminating     bool
+	CompressionType CompressionCodec
+	// Metrics are the metrics, serialized as OTLP MetricsData and compressed
+	// with CompressionType
+	Metrics []byte
+}
+
+func (r *PushTelemetryRequest) encode(pe packetEncoder) error {
+	if err := putUuid(pe, r.ClientInstanceID); err != nil {
+		return err
+	}
+	pe.putInt32(r.SubscriptionID)
+	pe.putBool(r.Terminating)
+	pe.putInt8(int8(r.CompressionType))
+	if err := pe.putCompactBytes(r.Metrics); err != nil {
+		return err
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *PushTelemetryRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.ClientInstanceID, err = getUuid(pd); err != nil {
+		return err
+	}
+	if r.SubscriptionID, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.Terminating, err = pd.getBool(); err != nil {
+		return err
+	}
+	codec, err := pd.getInt8()
+	if err != nil {
+		return err
+	}
+	r.CompressionType = CompressionCodec(codec)
+	if r.Metrics, err = pd.getCompactBytes(); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *PushTelemetryRequest) key() int16 {
+	return 72
+}
+
+func (r *PushTelemetryRequest) version() int16 {
+	return r.Version
+}
+
+func (r *PushTelemetryRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *PushTelemetryRequest) requiredVersion() KafkaVersion {
+	return V3_7_0_0
+}
@@ -0,0 +1,24 @@
+package sarama
+
+import "testing"
+
+var pushTelemetryRequest = []byte{
+	1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, // client instance ID
+	0, 0, 0, 7, // subscription ID
+	1,             // terminating
+	0,             // no compression
+	4, 10, 2, 'x', // metrics
+	0, // empty tagged fields
+}
+
+func TestPushTelemetryRequest(t *testing.T) {
+	request := &PushTelemetryRequest{
+		Version:          0,
+		ClientInstanceID: Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SubscriptionID:   7,
+		Terminating:      true,
+		CompressionType:  CompressionNone,
+		Metrics:          []byte{10, 2, 'x'},
+	}
+	testRequest(t, "basic", request, pushTelemetryRequest)
+}
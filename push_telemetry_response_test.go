@@ -0,0 +1,34 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	pushTelemetryResponseNoError = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		0, 0, // no error code
+		0, // empty tagged fields
+	}
+
+	pushTelemetryResponseError = []byte{
+		0, 0, 0, 0, // throttle time
+		0, 117, // unknown subscription ID
+		0, // empty tagged fields
+	}
+)
+
+func TestPushTelemetryResponse(t *testing.T) {
+	response := &PushTelemetryResponse{
+		Version:      0,
+		ThrottleTime: 100 * time.Millisecond,
+	}
+	testResponse(t, "no error", response, pushTelemetryResponseNoError)
+
+	response = &PushTelemetryResponse{
+		Version:   0,
+		ErrorCode: ErrUnknownSubscriptionID,
+	}
+	testResponse(t, "with error", response, pushTelemetryResponseError)
+}
@@ -19,3 +19,118 @@ const (
 	QuotaMatchDefault
 	QuotaMatchAny
 )
+
+// Quota configuration keys for client quotas.
+const (
+	QuotaProducerByteRate  = "producer_byte_rate"
+	QuotaConsumerByteRate  = "consumer_byte_rate"
+	QuotaRequestPercentage = "request_percentage"
+)
+
+// QuotaUser returns the entity component for the given user.
+func QuotaUser(user string) QuotaEntityComponent {
+	return QuotaEntityComponent{EntityType: QuotaEntityUser, MatchType: QuotaMatchExact, Name: user}
+}
+
+// QuotaDefaultUser returns the entity component for the default user.
+func QuotaDefaultUser() QuotaEntityComponent {
+	return QuotaEntityComponent{EntityType: QuotaEntityUser, MatchType: QuotaMatchDefault}
+}
+
+// QuotaClientID returns the entity component for the given client ID.
+func QuotaClientID(clientID string) QuotaEntityComponent {
+	return QuotaEntityComponent{EntityType: QuotaEntityClientID, MatchType: QuotaMatchExact, Name: clientID}
+}
+
+// QuotaDefaultClientID returns the entity component for the default client ID.
+func QuotaDefaultClientID() QuotaEntityComponent {
+	return QuotaEntityComponent{EntityType: QuotaEntityClientID, MatchType: QuotaMatchDefault}
+}
+
+// ClientQuotaValues are the produce, fetch and request quotas of a client
+// quota entity. Nil values are left unchanged by SetClientQuotas.
+type ClientQuotaValues struct {
+	ProducerByteRate  *float64
+	ConsumerByteRate  *float64
+	RequestPercentage *float64
+}
+
+func (v ClientQuotaValues) ops() []ClientQuotasOp {
+	var ops []ClientQuotasOp
+	if v.ProducerByteRate != nil {
+		ops = append(ops, ClientQuotasOp{Key: QuotaProducerByteRate, Value: *v.ProducerByteRate})
+	}
+	if v.ConsumerByteRate != nil {
+		ops = append(ops, ClientQuotasOp{Key: QuotaConsumerByteRate, Value: *v.ConsumerByteRate})
+	}
+	if v.RequestPercentage != nil {
+		ops = append(ops, ClientQuotasOp{Key: QuotaRequestPercentage, Value: *v.RequestPercentage})
+	}
+	return ops
+}
+
+// EffectiveClientQuota is a quota value along with the entity it was
+// configured on.
+type EffectiveClientQuota struct {
+	Value  float64
+	Entity []QuotaEntityComponent
+}
+
+// clientQuotaPrecedence lists the (user, client-id) entities in the order
+// the broker applies them, see
+// https://kafka.apache.org/documentation/#design_quotasconfig
+func clientQuotaPrecedence(user, clientID string) [][]QuotaEntityComponent {
+	return [][]QuotaEntityComponent{
+		{QuotaUser(user), QuotaClientID(clientID)},
+		{QuotaUser(user), QuotaDefaultClientID()},
+		{QuotaUser(user)},
+		{QuotaDefaultUser(), QuotaClientID(clientID)},
+		{QuotaDefaultUser(), QuotaDefaultClientID()},
+		{QuotaDefaultUser()},
+		{QuotaClientID(clientID)},
+		{QuotaDefaultClientID()},
+	}
+}
+
+// quotaEntityEqual returns whether both entities have the same components,
+// regardless of their order
+func quotaEntityEqual(a, b []QuotaEntityComponent) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, ca := range a {
+		found := false
+		for _, cb := range b {
+			if ca.EntityType != cb.EntityType || ca.MatchType != cb.MatchType {
+				continue
+			}
+			if ca.MatchType == QuotaMatchDefault || ca.Name == cb.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveClientQuotas returns the quotas that apply to the given user and
+// client ID, taking for each quota key the most specific entity it is set on
+func resolveClientQuotas(entries []DescribeClientQuotasEntry, user, clientID string) map[string]EffectiveClientQuota {
+	quotas := make(map[string]EffectiveClientQuota)
+	for _, entity := range clientQuotaPrecedence(user, clientID) {
+		for _, entry := range entries {
+			if !quotaEntityEqual(entity, entry.Entity) {
+				continue
+			}
+			for key, value := range entry.Values {
+				if _, ok := quotas[key]; !ok {
+					quotas[key] = EffectiveClientQuota{Value: value, Entity: entry.Entity}
+				}
+			}
+		}
+	}
+	return quotas
+}
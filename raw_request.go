@@ -0,0 +1,100 @@
+package sarama
+
+// apiVersionsKey is the API key of ApiVersions, whose response header is
+// never flexible for the clients to parse it whatever its version
+const apiVersionsKey = 18
+
+// RawRequest is a request of any API and version encoded by the caller, for
+// experimenting with the APIs sarama has no typed support for yet, see
+// Broker.SendRaw. The request header is built by the broker.
+type RawRequest struct {
+	APIKey     int16
+	APIVersion int16
+	// Flexible is whether the API version is a flexible one (KIP-482), with
+	// the tagged fields in the request and response headers.
+	Flexible bool
+	// Body is the encoded request, without the header.
+	Body []byte
+}
+
+func (r *RawRequest) encode(pe packetEncoder) error {
+	return pe.putRawBytes(r.Body)
+}
+
+func (r *RawRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.APIVersion = version
+	r.Body, err = pd.getRawBytes(pd.remaining())
+	return err
+}
+
+func (r *RawRequest) key() int16 {
+	return r.APIKey
+}
+
+func (r *RawRequest) version() int16 {
+	return r.APIVersion
+}
+
+func (r *RawRequest) headerVersion() int16 {
+	if r.Flexible {
+		return 2
+	}
+	return 1
+}
+
+// requiredVersion does not restrict the raw requests, the caller knowing the
+// brokers they are sent to.
+func (r *RawRequest) requiredVersion() KafkaVersion {
+	return MinVersion
+}
+
+// RawResponse is the response to a RawRequest, left for the caller to
+// decode.
+type RawResponse struct {
+	APIKey     int16
+	APIVersion int16
+	// Flexible is whether the response header has tagged fields, which is
+	// the case for the flexible versions of all the APIs but ApiVersions.
+	Flexible bool
+	// Body is the encoded response, without the header.
+	Body []byte
+}
+
+// NewRawResponse returns the response to the request, to be given to
+// Broker.AsyncSend with the request.
+func NewRawResponse(request *RawRequest) *RawResponse {
+	return &RawResponse{
+		APIKey:     request.APIKey,
+		APIVersion: request.APIVersion,
+		Flexible:   request.Flexible && request.APIKey != apiVersionsKey,
+	}
+}
+
+func (r *RawResponse) encode(pe packetEncoder) error {
+	return pe.putRawBytes(r.Body)
+}
+
+func (r *RawResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.APIVersion = version
+	r.Body, err = pd.getRawBytes(pd.remaining())
+	return err
+}
+
+func (r *RawResponse) key() int16 {
+	return r.APIKey
+}
+
+func (r *RawResponse) version() int16 {
+	return r.APIVersion
+}
+
+func (r *RawResponse) headerVersion() int16 {
+	if r.Flexible {
+		return 1
+	}
+	return 0
+}
+
+func (r *RawResponse) requiredVersion() KafkaVersion {
+	return MinVersion
+}
@@ -0,0 +1,51 @@
+package sarama
+
+import "testing"
+
+func TestBrokerSendRaw(t *testing.T) {
+	mockBroker := NewMockBroker(t, 1)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"ListGroupsRequest":  NewMockListGroupsResponse(t).AddGroup("my-group", "consumer"),
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+	})
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(NewTestConfig()); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	// ListGroups is not supported by Config.Version, which the raw requests
+	// are not checked against
+	res, err := broker.SendRaw(&RawRequest{APIKey: 16, APIVersion: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listGroups := new(ListGroupsResponse)
+	if err := versionedDecode(res.Body, listGroups, res.APIVersion); err != nil {
+		t.Fatal(err)
+	}
+	if listGroups.Groups["my-group"] != "consumer" {
+		t.Errorf("unexpected groups %v", listGroups.Groups)
+	}
+
+	body, err := encode(&ApiVersionsRequest{Version: 3, ClientSoftwareName: "sarama", ClientSoftwareVersion: "test"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = broker.SendRaw(&RawRequest{APIKey: 18, APIVersion: 3, Flexible: true, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Flexible {
+		t.Error("expected the ApiVersions response header not to be flexible")
+	}
+	apiVersions := &ApiVersionsResponse{Version: 3}
+	if err := versionedDecode(res.Body, apiVersions, res.APIVersion); err != nil {
+		t.Fatal(err)
+	}
+	if len(apiVersions.ApiKeys) != 2 {
+		t.Errorf("expected 2 API keys, got %v", apiVersions.ApiKeys)
+	}
+}
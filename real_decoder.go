@@ -18,6 +18,21 @@ type realDecoder struct {
 	raw   []byte
 	off   int
 	stack []pushDecoder
+
+	opts decodeOptions
+}
+
+// decodeOptions are the options of the consumer applying to the decoding of
+// the fetched record batches and messages
+type decodeOptions struct {
+	// maxDecompressedBytes limits the size of the decompressed data, 0 not
+	// limiting it
+	maxDecompressedBytes int
+	// skipCRC skips the validation of the CRCs
+	skipCRC bool
+	// deferRecords keeps the records of the record batches raw, see
+	// Consumer.Fetch.LazyBatchDecoding
+	deferRecords bool
 }
 
 // primitives
@@ -254,6 +269,9 @@ func (rd *realDecoder) getCompactString() (string, error) {
 	length := int(n - 1)
 	if length < 0 {
 		return "", errInvalidByteSliceLength
+	} else if length > rd.remaining() {
+		rd.off = len(rd.raw)
+		return "", ErrInsufficientData
 	}
 	tmpStr := string(rd.raw[rd.off : rd.off+length])
 	rd.off += length
@@ -270,6 +288,9 @@ func (rd *realDecoder) getCompactNullableString() (*string, error) {
 
 	if length < 0 {
 		return nil, err
+	} else if length > rd.remaining() {
+		rd.off = len(rd.raw)
+		return nil, ErrInsufficientData
 	}
 
 	tmpStr := string(rd.raw[rd.off : rd.off+length])
@@ -395,7 +416,7 @@ func (rd *realDecoder) getSubset(length int) (packetDecoder, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &realDecoder{raw: buf}, nil
+	return &realDecoder{raw: buf, opts: rd.opts}, nil
 }
 
 func (rd *realDecoder) getRawBytes(length int) ([]byte, error) {
@@ -416,7 +437,7 @@ func (rd *realDecoder) peek(offset, length int) (packetDecoder, error) {
 		return nil, ErrInsufficientData
 	}
 	off := rd.off + offset
-	return &realDecoder{raw: rd.raw[off : off+length]}, nil
+	return &realDecoder{raw: rd.raw[off : off+length], opts: rd.opts}, nil
 }
 
 func (rd *realDecoder) peekInt8(offset int) (int8, error) {
@@ -457,5 +478,16 @@ func (rd *realDecoder) pop() error {
 	in := rd.stack[len(rd.stack)-1]
 	rd.stack = rd.stack[:len(rd.stack)-1]
 
+	if _, ok := in.(*crc32Field); ok && rd.opts.skipCRC {
+		return nil
+	}
 	return in.check(rd.off, rd.raw)
 }
+
+func (rd *realDecoder) maxDecompressedBytes() int {
+	return rd.opts.maxDecompressedBytes
+}
+
+func (rd *realDecoder) deferRecords() bool {
+	return rd.opts.deferRecords
+}
@@ -0,0 +1,63 @@
+package sarama
+
+import (
+	"sync"
+	"time"
+)
+
+// reconnectJitter is the fraction by which the reconnect backoff delays are
+// randomized, like reconnect.backoff in the Java client
+const reconnectJitter = 0.2
+
+// reconnectDelay returns how long to wait before the next connection attempt
+// after the consecutive failed ones, see Net.ReconnectBackoff. It must be
+// called with b.lock held.
+func (b *Broker) reconnectDelay(conf *Config) time.Duration {
+	initial, max := conf.Net.ReconnectBackoff.Initial, conf.Net.ReconnectBackoff.Max
+	if b.connFailures == 0 || initial <= 0 {
+		return 0
+	}
+
+	backoff := exponentialBackoff(initial, max, reconnectJitter, b.connFailures)
+	return time.Until(b.lastConnFailure.Add(backoff))
+}
+
+// connectionFailed records a failed connection attempt. It must be called
+// with b.lock held.
+func (b *Broker) connectionFailed() {
+	b.connFailures++
+	b.lastConnFailure = time.Now()
+}
+
+// connectionAttemptLimiter spaces the connection attempts of the brokers
+// connected with a config, see Net.ReconnectBackoff.MaxAttemptsPerSecond
+type connectionAttemptLimiter struct {
+	lock sync.Mutex
+	next time.Time
+}
+
+// waitForConnectionAttempt waits until a connection attempt is allowed by
+// Net.ReconnectBackoff.MaxAttemptsPerSecond across all the brokers
+// connected with the config.
+func (c *Config) waitForConnectionAttempt() {
+	rate := c.Net.ReconnectBackoff.MaxAttemptsPerSecond
+	if rate <= 0 || c.connectionAttempts == nil {
+		return
+	}
+	time.Sleep(c.connectionAttempts.reserve(time.Duration(float64(time.Second) / rate)))
+}
+
+// reserve returns how long to wait for the next attempt, spaced from the
+// previous ones by interval
+func (l *connectionAttemptLimiter) reserve(interval time.Duration) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(interval)
+	return delay
+}
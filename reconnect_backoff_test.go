@@ -0,0 +1,103 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	config := NewTestConfig()
+	config.Net.ReconnectBackoff.Initial = 100 * time.Millisecond
+	config.Net.ReconnectBackoff.Max = time.Second
+
+	broker := NewBroker("localhost:0")
+	if delay := broker.reconnectDelay(config); delay != 0 {
+		t.Errorf("expected no delay before any failure, got %s", delay)
+	}
+
+	for failures, expected := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		broker.connFailures = failures
+		broker.lastConnFailure = time.Now()
+		delay := broker.reconnectDelay(config)
+		lower := time.Duration(float64(expected)*(1-reconnectJitter)) - 10*time.Millisecond
+		upper := time.Duration(float64(expected) * (1 + reconnectJitter))
+		if delay < lower || delay > upper {
+			t.Errorf("expected a delay between %s and %s after %d failures, got %s", lower, upper, failures, delay)
+		}
+	}
+
+	config.Net.ReconnectBackoff.Initial = 0
+	if delay := broker.reconnectDelay(config); delay != 0 {
+		t.Errorf("expected no delay with the backoff disabled, got %s", delay)
+	}
+}
+
+func TestBrokerReconnectBackoff(t *testing.T) {
+	config := NewTestConfig()
+	config.Net.ReconnectBackoff.Initial = 200 * time.Millisecond
+	config.Net.ReconnectBackoff.Max = time.Second
+
+	// nothing listens on a mock broker once closed
+	mockBroker := NewMockBroker(t, 1)
+	addr := mockBroker.Addr()
+	mockBroker.Close()
+
+	broker := NewBroker(addr)
+	_ = broker.Open(config)
+	if connected, err := broker.Connected(); connected || err == nil {
+		t.Fatalf("expected the connection to fail, got connected %v, error %v", connected, err)
+	}
+
+	start := time.Now()
+	_ = broker.Open(config)
+	_, _ = broker.Connected()
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected the second connection attempt to be delayed, took %s", elapsed)
+	}
+	if broker.connFailures != 2 {
+		t.Errorf("expected 2 consecutive failures, got %d", broker.connFailures)
+	}
+
+	// the broker is not locked while waiting to connect again
+	opened := make(chan struct{})
+	go func() {
+		defer close(opened)
+		_ = broker.Open(config)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	start = time.Now()
+	_, _ = broker.Connected()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the broker not to be locked during the delay, waited %s", elapsed)
+	}
+	<-opened
+	_, _ = broker.Connected()
+}
+
+func TestConfigConnectionAttemptLimiter(t *testing.T) {
+	config := NewTestConfig()
+	if config.connectionAttempts == nil {
+		t.Fatal("expected the config to have a connection attempt limiter")
+	}
+	if clone := config.Clone(); clone.connectionAttempts == config.connectionAttempts {
+		t.Error("expected the clone not to share the connection attempts of the config")
+	}
+}
+
+func TestConnectionAttemptLimiter(t *testing.T) {
+	var limiter connectionAttemptLimiter
+	interval := 100 * time.Millisecond
+	for i := 0; i < 3; i++ {
+		delay := limiter.reserve(interval)
+		expected := time.Duration(i) * interval
+		if delay < expected-10*time.Millisecond || delay > expected {
+			t.Errorf("expected attempt %d to wait about %s, got %s", i, expected, delay)
+		}
+	}
+}
@@ -36,6 +36,7 @@ type RecordBatch struct {
 	Version               int8
 	Codec                 CompressionCodec
 	CompressionLevel      int
+	ZstdDictionaryID      uint32 // registered zstd dictionary compressing the records, if any
 	Control               bool
 	LogAppendTime         bool
 	LastOffsetDelta       int32
@@ -50,6 +51,11 @@ type RecordBatch struct {
 
 	compressedRecords []byte
 	recordsLen        int // uncompressed records size
+
+	// deferredRecords are the raw records of a batch decoded with
+	// deferRecords, decompressed and decoded by decodeDeferred
+	deferredRecords      []byte
+	maxDecompressedBytes int
 }
 
 func (b *RecordBatch) LastOffset() int64 {
@@ -180,7 +186,16 @@ func (b *RecordBatch) decode(pd packetDecoder) (err error) {
 		return err
 	}
 
-	recBuffer, err = decompress(b.Codec, recBuffer)
+	if pd.deferRecords() {
+		b.deferredRecords = recBuffer
+		b.maxDecompressedBytes = pd.maxDecompressedBytes()
+		return nil
+	}
+	return b.decodeRecords(recBuffer, pd.maxDecompressedBytes())
+}
+
+func (b *RecordBatch) decodeRecords(recBuffer []byte, maxDecompressedBytes int) (err error) {
+	recBuffer, err = decompress(b.Codec, recBuffer, maxDecompressedBytes)
 	if err != nil {
 		return err
 	}
@@ -195,15 +210,26 @@ func (b *RecordBatch) decode(pd packetDecoder) (err error) {
 	return err
 }
 
+// decodeDeferred decompresses and decodes the records of a batch whose
+// decoding was deferred, releasing its raw records
+func (b *RecordBatch) decodeDeferred() error {
+	if b.deferredRecords == nil {
+		return nil
+	}
+	recBuffer := b.deferredRecords
+	b.deferredRecords = nil
+	return b.decodeRecords(recBuffer, b.maxDecompressedBytes)
+}
+
 func (b *RecordBatch) encodeRecords(pe packetEncoder) error {
 	var raw []byte
 	var err error
-	if raw, err = encode(recordsArray(b.Records), pe.metricRegistry()); err != nil {
+	if raw, err = encode(recordsArray(b.Records), pe.metricsRecorder()); err != nil {
 		return err
 	}
 	b.recordsLen = len(raw)
 
-	b.compressedRecords, err = compress(b.Codec, b.CompressionLevel, raw)
+	b.compressedRecords, err = compress(b.Codec, b.CompressionLevel, b.ZstdDictionaryID, raw)
 	return err
 }
 
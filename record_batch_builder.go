@@ -0,0 +1,138 @@
+package sarama
+
+import (
+	"errors"
+	"time"
+)
+
+// RecordBatchBuilder builds v2 record batches outside of the producer, such
+// as for Kafka protocol proxies, tiered storage tooling or test fixtures.
+// The batches are serialized with their Encode method and parsed back with
+// DecodeRecordBatches.
+type RecordBatchBuilder struct {
+	batch *RecordBatch
+}
+
+// NewRecordBatchBuilder returns a builder of an uncompressed batch whose
+// first record has the given offset, written by no idempotent producer.
+func NewRecordBatchBuilder(firstOffset int64) *RecordBatchBuilder {
+	return &RecordBatchBuilder{batch: &RecordBatch{
+		Version:          2,
+		FirstOffset:      firstOffset,
+		CompressionLevel: CompressionLevelDefault,
+		ProducerID:       -1,
+		ProducerEpoch:    -1,
+		FirstSequence:    -1,
+	}}
+}
+
+// SetCompression sets the codec and level compressing the records, the
+// level being CompressionLevelDefault for the default level of the codec.
+func (b *RecordBatchBuilder) SetCompression(codec CompressionCodec, level int) *RecordBatchBuilder {
+	b.batch.Codec = codec
+	b.batch.CompressionLevel = level
+	return b
+}
+
+// SetProducer sets the idempotent producer writing the batch, with the
+// sequence number of its first record.
+func (b *RecordBatchBuilder) SetProducer(producerID int64, producerEpoch int16, firstSequence int32) *RecordBatchBuilder {
+	b.batch.ProducerID = producerID
+	b.batch.ProducerEpoch = producerEpoch
+	b.batch.FirstSequence = firstSequence
+	return b
+}
+
+// SetTransactional marks the batch as part of a transaction of its producer.
+func (b *RecordBatchBuilder) SetTransactional(transactional bool) *RecordBatchBuilder {
+	b.batch.IsTransactional = transactional
+	return b
+}
+
+// SetPartitionLeaderEpoch sets the epoch of the leader which appended the
+// batch to the log.
+func (b *RecordBatchBuilder) SetPartitionLeaderEpoch(epoch int32) *RecordBatchBuilder {
+	b.batch.PartitionLeaderEpoch = epoch
+	return b
+}
+
+// SetLogAppendTime marks the timestamps of the records as the time the
+// broker appended the batch, which is the max timestamp of the batch.
+func (b *RecordBatchBuilder) SetLogAppendTime(timestamp time.Time) *RecordBatchBuilder {
+	b.batch.LogAppendTime = true
+	b.batch.MaxTimestamp = timestamp
+	return b
+}
+
+// AddRecord appends a record at the next offset of the batch.
+func (b *RecordBatchBuilder) AddRecord(key, value []byte, headers []RecordHeader, timestamp time.Time) *RecordBatchBuilder {
+	batch := b.batch
+	if len(batch.Records) == 0 {
+		batch.FirstTimestamp = timestamp
+		if !batch.LogAppendTime {
+			batch.MaxTimestamp = timestamp
+		}
+	}
+	if !batch.LogAppendTime && timestamp.After(batch.MaxTimestamp) {
+		batch.MaxTimestamp = timestamp
+	}
+
+	rec := &Record{
+		Key:            key,
+		Value:          value,
+		OffsetDelta:    int64(len(batch.Records)),
+		TimestampDelta: timestamp.Sub(batch.FirstTimestamp),
+	}
+	if len(headers) > 0 {
+		rec.Headers = make([]*RecordHeader, len(headers))
+		for i := range headers {
+			rec.Headers[i] = &headers[i]
+		}
+	}
+	batch.addRecord(rec)
+	batch.LastOffsetDelta = int32(rec.OffsetDelta)
+	// the records changed since the last encoding
+	batch.compressedRecords = nil
+	return b
+}
+
+// Build returns the batch, which is still modified by the builder.
+func (b *RecordBatchBuilder) Build() *RecordBatch {
+	return b.batch
+}
+
+// Encode serializes the batch as sent to and stored by the brokers,
+// compressing its records with its codec.
+func (b *RecordBatch) Encode() ([]byte, error) {
+	if err := checkCompressionLevel(b.Codec, b.CompressionLevel); err != nil {
+		return nil, PacketEncodingError{err.Error()}
+	}
+	return encode(b, nil)
+}
+
+// DecodeRecordBatches parses the v2 record batches serialized back to back,
+// such as the records of a fetch response or a log segment. A trailing
+// partial batch is ignored.
+func DecodeRecordBatches(buf []byte) ([]*RecordBatch, error) {
+	var batches []*RecordBatch
+	pd := &realDecoder{raw: buf}
+	for pd.remaining() > 0 {
+		records := &Records{}
+		if err := records.decode(pd); err != nil {
+			if errors.Is(err, ErrInsufficientData) {
+				break
+			}
+			return nil, err
+		}
+		if records.recordsType != defaultRecords {
+			return nil, PacketDecodingError{"legacy message sets are not record batches"}
+		}
+		if records.RecordBatch.PartialTrailingRecord {
+			break
+		}
+		// the level is not serialized, re-encoding uses the default one
+		records.RecordBatch.CompressionLevel = CompressionLevelDefault
+		batches = append(batches, records.RecordBatch)
+	}
+	return batches, nil
+}
@@ -0,0 +1,94 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecordBatchBuilderRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, codec := range []CompressionCodec{CompressionNone, CompressionGZIP, CompressionSnappy, CompressionLZ4, CompressionZSTD} {
+		first := NewRecordBatchBuilder(100).
+			SetCompression(codec, CompressionLevelDefault).
+			SetProducer(7, 1, 42).
+			SetTransactional(true).
+			SetPartitionLeaderEpoch(3).
+			AddRecord([]byte("k1"), []byte("v1"), []RecordHeader{{Key: []byte("h"), Value: []byte("1")}}, now).
+			AddRecord(nil, []byte("v2"), nil, now.Add(2*time.Second)).
+			AddRecord([]byte("k3"), nil, nil, now.Add(time.Second)).
+			Build()
+		second := NewRecordBatchBuilder(103).
+			AddRecord(nil, []byte("v4"), nil, now).
+			Build()
+
+		var buf []byte
+		for _, batch := range []*RecordBatch{first, second} {
+			encoded, err := batch.Encode()
+			if err != nil {
+				t.Fatalf("%s: %v", codec, err)
+			}
+			buf = append(buf, encoded...)
+		}
+		// a trailing partial batch is ignored
+		buf = append(buf, buf[:20]...)
+
+		batches, err := DecodeRecordBatches(buf)
+		if err != nil {
+			t.Fatalf("%s: %v", codec, err)
+		}
+		if len(batches) != 2 {
+			t.Fatalf("%s: expected 2 batches, got %d", codec, len(batches))
+		}
+
+		batch := batches[0]
+		if batch.Codec != codec || batch.FirstOffset != 100 || batch.LastOffset() != 102 ||
+			batch.ProducerID != 7 || batch.ProducerEpoch != 1 || batch.FirstSequence != 42 ||
+			!batch.IsTransactional || batch.PartitionLeaderEpoch != 3 {
+			t.Errorf("%s: unexpected batch %+v", codec, batch)
+		}
+		if !batch.FirstTimestamp.Equal(now) || !batch.MaxTimestamp.Equal(now.Add(2*time.Second)) {
+			t.Errorf("%s: unexpected timestamps %v %v", codec, batch.FirstTimestamp, batch.MaxTimestamp)
+		}
+		if len(batch.Records) != 3 || string(batch.Records[1].Value) != "v2" ||
+			batch.Records[2].OffsetDelta != 2 || batch.Records[2].TimestampDelta != time.Second ||
+			len(batch.Records[0].Headers) != 1 || string(batch.Records[0].Headers[0].Value) != "1" {
+			t.Errorf("%s: unexpected records %+v", codec, batch.Records)
+		}
+		if batches[1].FirstOffset != 103 || batches[1].ProducerID != -1 || len(batches[1].Records) != 1 {
+			t.Errorf("%s: unexpected second batch %+v", codec, batches[1])
+		}
+
+		// decoded batches are encoded back identically
+		reencoded, err := batch.Encode()
+		if err != nil {
+			t.Fatalf("%s: %v", codec, err)
+		}
+		if decoded, err := DecodeRecordBatches(reencoded); err != nil || len(decoded) != 1 || len(decoded[0].Records) != 3 {
+			t.Errorf("%s: unexpected re-encoded batch %v %v", codec, decoded, err)
+		}
+	}
+}
+
+func TestRecordBatchBuilderInvalidLevel(t *testing.T) {
+	batch := NewRecordBatchBuilder(0).
+		SetCompression(CompressionLZ4, 100).
+		AddRecord(nil, []byte("v"), nil, time.Now()).
+		Build()
+	var encodingErr PacketEncodingError
+	if _, err := batch.Encode(); !errors.As(err, &encodingErr) {
+		t.Errorf("expected a PacketEncodingError, got %v", err)
+	}
+}
+
+func TestDecodeRecordBatchesLegacy(t *testing.T) {
+	set := &MessageSet{}
+	set.addMessage(&Message{Codec: CompressionNone, Value: []byte("v")})
+	buf, err := encode(set, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeRecordBatches(buf); err == nil {
+		t.Error("expected an error decoding a legacy message set")
+	}
+}
@@ -213,6 +213,8 @@ func (r *Records) getControlRecord() (ControlRecord, error) {
 	if err != nil {
 		return ControlRecord{}, err
 	}
+	controlRecord.ProducerID = r.RecordBatch.ProducerID
+	controlRecord.ProducerEpoch = r.RecordBatch.ProducerEpoch
 
 	return controlRecord, nil
 }
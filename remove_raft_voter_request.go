@@ -0,0 +1,59 @@
+package sarama
+
+// RemoveRaftVoterRequest is a request to remove a controller from the voters
+// of the KRaft controller quorum (KIP-853)
+type RemoveRaftVoterRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	// ClusterID is checked against the cluster ID by the receiver if set
+	ClusterID        *string
+	VoterID          int32
+	VoterDirectoryID Uuid
+}
+
+func (r *RemoveRaftVoterRequest) encode(pe packetEncoder) error {
+	if err := pe.putNullableCompactString(r.ClusterID); err != nil {
+		return err
+	}
+	pe.putInt32(r.VoterID)
+	if err := putUuid(pe, r.VoterDirectoryID); err != nil {
+		return err
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *RemoveRaftVoterRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.ClusterID, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if r.VoterID, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.VoterDirectoryID, err = getUuid(pd); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *RemoveRaftVoterRequest) key() int16 {
+	return 81
+}
+
+func (r *RemoveRaftVoterRequest) version() int16 {
+	return r.Version
+}
+
+func (r *RemoveRaftVoterRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *RemoveRaftVoterRequest) requiredVersion() KafkaVersion {
+	return V3_9_0_0
+}
@@ -0,0 +1,21 @@
+package sarama
+
+import "testing"
+
+var removeRaftVoterRequest = []byte{
+	8, 'c', 'l', 'u', 's', 't', 'e', 'r', // cluster ID
+	0, 0, 0, 3, // voter ID
+	1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, // voter directory ID
+	0, // empty tagged fields
+}
+
+func TestRemoveRaftVoterRequest(t *testing.T) {
+	clusterID := "cluster"
+	request := &RemoveRaftVoterRequest{
+		Version:          0,
+		ClusterID:        &clusterID,
+		VoterID:          3,
+		VoterDirectoryID: Uuid{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+	}
+	testRequest(t, "basic", request, removeRaftVoterRequest)
+}
@@ -0,0 +1,63 @@
+package sarama
+
+import "time"
+
+// RemoveRaftVoterResponse is the response to a RemoveRaftVoterRequest
+type RemoveRaftVoterResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime time.Duration
+	ErrorCode    KError
+	ErrorMessage *string
+}
+
+func (r *RemoveRaftVoterResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	pe.putInt16(int16(r.ErrorCode))
+	if err := pe.putNullableCompactString(r.ErrorMessage); err != nil {
+		return err
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *RemoveRaftVoterResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.ErrorCode = KError(kerr)
+
+	if r.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *RemoveRaftVoterResponse) key() int16 {
+	return 81
+}
+
+func (r *RemoveRaftVoterResponse) version() int16 {
+	return r.Version
+}
+
+func (r *RemoveRaftVoterResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *RemoveRaftVoterResponse) requiredVersion() KafkaVersion {
+	return V3_9_0_0
+}
@@ -0,0 +1,20 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var renewDelegationTokenRequest = []byte{
+	0, 0, 0, 3, 1, 2, 3, // hmac
+	0, 0, 0, 0, 5, 38, 92, 0, // period (1 day)
+}
+
+func TestRenewDelegationTokenRequest(t *testing.T) {
+	request := &RenewDelegationTokenRequest{
+		Version:     1,
+		HMAC:        []byte{1, 2, 3},
+		RenewPeriod: 24 * time.Hour,
+	}
+	testRequest(t, "basic", request, renewDelegationTokenRequest)
+}
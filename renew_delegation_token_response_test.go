@@ -0,0 +1,20 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var renewDelegationTokenResponse = []byte{
+	0, 0, // no error
+	0, 0, 1, 118, 45, 208, 98, 0, // expiry timestamp
+	0, 0, 0, 0, // no throttle time
+}
+
+func TestRenewDelegationTokenResponse(t *testing.T) {
+	response := &RenewDelegationTokenResponse{
+		Version:    1,
+		ExpiryTime: time.Unix(1607086400, 0),
+	}
+	testResponse(t, "basic", response, renewDelegationTokenResponse)
+}
@@ -125,25 +125,25 @@ func allocateBody(key, version int16) protocolBody {
 	case 2:
 		return &OffsetRequest{Version: version}
 	case 3:
-		return &MetadataRequest{}
+		return &MetadataRequest{Version: version}
 	case 8:
 		return &OffsetCommitRequest{Version: version}
 	case 9:
 		return &OffsetFetchRequest{Version: version}
 	case 10:
-		return &FindCoordinatorRequest{}
+		return &FindCoordinatorRequest{Version: version}
 	case 11:
-		return &JoinGroupRequest{}
+		return &JoinGroupRequest{Version: version}
 	case 12:
-		return &HeartbeatRequest{}
+		return &HeartbeatRequest{Version: version}
 	case 13:
-		return &LeaveGroupRequest{}
+		return &LeaveGroupRequest{Version: version}
 	case 14:
-		return &SyncGroupRequest{}
+		return &SyncGroupRequest{Version: version}
 	case 15:
 		return &DescribeGroupsRequest{}
 	case 16:
-		return &ListGroupsRequest{}
+		return &ListGroupsRequest{Version: version}
 	case 17:
 		return &SaslHandshakeRequest{}
 	case 18:
@@ -151,11 +151,11 @@ func allocateBody(key, version int16) protocolBody {
 	case 19:
 		return &CreateTopicsRequest{}
 	case 20:
-		return &DeleteTopicsRequest{}
+		return &DeleteTopicsRequest{Version: version}
 	case 21:
 		return &DeleteRecordsRequest{}
 	case 22:
-		return &InitProducerIDRequest{}
+		return &InitProducerIDRequest{Version: version}
 	case 24:
 		return &AddPartitionsToTxnRequest{}
 	case 25:
@@ -163,7 +163,7 @@ func allocateBody(key, version int16) protocolBody {
 	case 26:
 		return &EndTxnRequest{}
 	case 28:
-		return &TxnOffsetCommitRequest{}
+		return &TxnOffsetCommitRequest{Version: version}
 	case 29:
 		return &DescribeAclsRequest{}
 	case 30:
@@ -174,14 +174,26 @@ func allocateBody(key, version int16) protocolBody {
 		return &DescribeConfigsRequest{}
 	case 33:
 		return &AlterConfigsRequest{}
+	case 34:
+		return &AlterReplicaLogDirsRequest{}
 	case 35:
 		return &DescribeLogDirsRequest{}
 	case 36:
 		return &SaslAuthenticateRequest{}
 	case 37:
 		return &CreatePartitionsRequest{}
+	case 38:
+		return &CreateDelegationTokenRequest{}
+	case 39:
+		return &RenewDelegationTokenRequest{}
+	case 40:
+		return &ExpireDelegationTokenRequest{}
+	case 41:
+		return &DescribeDelegationTokenRequest{}
 	case 42:
 		return &DeleteGroupsRequest{}
+	case 43:
+		return &ElectLeadersRequest{Version: version}
 	case 44:
 		return &IncrementalAlterConfigsRequest{}
 	case 45:
@@ -198,6 +210,34 @@ func allocateBody(key, version int16) protocolBody {
 		return &DescribeUserScramCredentialsRequest{}
 	case 51:
 		return &AlterUserScramCredentialsRequest{}
+	case 57:
+		return &UpdateFeaturesRequest{}
+	case 60:
+		return &DescribeClusterRequest{}
+	case 61:
+		return &DescribeProducersRequest{}
+	case 64:
+		return &UnregisterBrokerRequest{}
+	case 65:
+		return &DescribeTransactionsRequest{}
+	case 66:
+		return &ListTransactionsRequest{}
+	case 69:
+		return &ConsumerGroupDescribeRequest{}
+	case 71:
+		return &GetTelemetrySubscriptionsRequest{}
+	case 72:
+		return &PushTelemetryRequest{}
+	case 76:
+		return &ShareGroupHeartbeatRequest{}
+	case 78:
+		return &ShareFetchRequest{}
+	case 79:
+		return &ShareAcknowledgeRequest{}
+	case 80:
+		return &AddRaftVoterRequest{}
+	case 81:
+		return &RemoveRaftVoterRequest{}
 	}
 	return nil
 }
@@ -0,0 +1,113 @@
+package sarama
+
+import "sync"
+
+// the priority classes of the requests waiting for an in-flight slot with
+// Net.PrioritizeRequests, highest first
+const (
+	requestPriorityControl = iota
+	requestPriorityData
+	requestPriorities
+)
+
+// requestPriority returns the priority class of a request: the produce and
+// fetch requests, which can saturate a connection, come after all others
+// such as metadata, coordinator, group membership and admin requests.
+func requestPriority(rb protocolBody) int {
+	switch rb.key() {
+	case 0, 1: // Produce, Fetch
+		return requestPriorityData
+	default:
+		return requestPriorityControl
+	}
+}
+
+// requestSlots is the semaphore of the Net.MaxOpenRequests in-flight requests
+// of a connection with Net.PrioritizeRequests, handing the slots freed to the
+// waiting requests by priority class, and in arrival order within a class.
+type requestSlots struct {
+	lock sync.Mutex
+	// generation is incremented on every reset so that the slots acquired
+	// before are not released into the new ones
+	generation int
+	enabled    bool
+	free       int
+	waiters    [requestPriorities][]chan bool
+}
+
+// reset sets the number of slots, 0 disabling the semaphore, and wakes the
+// requests waiting for one, which then proceed without.
+func (s *requestSlots) reset(slots int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.generation++
+	s.enabled = slots > 0
+	s.free = slots
+	for priority, waiters := range s.waiters {
+		for _, waiter := range waiters {
+			waiter <- false
+		}
+		s.waiters[priority] = nil
+	}
+}
+
+// acquire waits for a slot for a request of the given priority class and
+// returns the function releasing it, which does nothing if the semaphore is
+// disabled.
+func (s *requestSlots) acquire(priority int) (release func()) {
+	s.lock.Lock()
+	if !s.enabled {
+		s.lock.Unlock()
+		return func() {}
+	}
+	generation := s.generation
+	if s.free > 0 && !s.waiting() {
+		s.free--
+		s.lock.Unlock()
+		return s.releaser(generation)
+	}
+	waiter := make(chan bool, 1)
+	s.waiters[priority] = append(s.waiters[priority], waiter)
+	s.lock.Unlock()
+
+	if acquired := <-waiter; !acquired {
+		return func() {}
+	}
+	return s.releaser(generation)
+}
+
+func (s *requestSlots) waiting() bool {
+	for _, waiters := range s.waiters {
+		if len(waiters) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *requestSlots) releaser(generation int) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { s.release(generation) })
+	}
+}
+
+// release hands the slot to the first waiting request of the highest
+// priority class, if any.
+func (s *requestSlots) release(generation int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if generation != s.generation {
+		return
+	}
+	for priority, waiters := range s.waiters {
+		if len(waiters) > 0 {
+			waiters[0] <- true
+			s.waiters[priority] = waiters[1:]
+			return
+		}
+	}
+	s.free++
+}
@@ -0,0 +1,137 @@
+package sarama
+
+import (
+	"sync"
+	"testing"
+)
+
+// waitForWaiters waits for the given number of requests of the priority class
+// to be waiting for a slot
+func waitForWaiters(s *requestSlots, priority, count int) {
+	for {
+		s.lock.Lock()
+		waiting := len(s.waiters[priority])
+		s.lock.Unlock()
+		if waiting == count {
+			return
+		}
+	}
+}
+
+func TestRequestSlotsPriority(t *testing.T) {
+	var slots requestSlots
+	slots.reset(1)
+
+	release := slots.acquire(requestPriorityData)
+
+	order := make(chan string, 3)
+	var wg sync.WaitGroup
+	acquire := func(name string, priority int) {
+		defer wg.Done()
+		release := slots.acquire(priority)
+		order <- name
+		release()
+	}
+	wg.Add(3)
+	go acquire("data 1", requestPriorityData)
+	waitForWaiters(&slots, requestPriorityData, 1)
+	go acquire("data 2", requestPriorityData)
+	waitForWaiters(&slots, requestPriorityData, 2)
+	go acquire("control", requestPriorityControl)
+	waitForWaiters(&slots, requestPriorityControl, 1)
+
+	release()
+	// releasing twice must not free another slot
+	release()
+	wg.Wait()
+	close(order)
+
+	var got []string
+	for name := range order {
+		got = append(got, name)
+	}
+	expected := []string{"control", "data 1", "data 2"}
+	for i := range expected {
+		if i >= len(got) || got[i] != expected[i] {
+			t.Fatalf("expected the slots to be acquired in order %v, got %v", expected, got)
+		}
+	}
+	if slots.free != 1 {
+		t.Errorf("expected 1 free slot, got %d", slots.free)
+	}
+}
+
+func TestRequestSlotsReset(t *testing.T) {
+	var slots requestSlots
+
+	// disabled
+	slots.acquire(requestPriorityData)()
+
+	slots.reset(1)
+	release := slots.acquire(requestPriorityControl)
+	done := make(chan none)
+	go func() {
+		slots.acquire(requestPriorityControl)()
+		close(done)
+	}()
+	waitForWaiters(&slots, requestPriorityControl, 1)
+
+	// waiters proceed when the connection is closed, and the slots acquired
+	// before are not released into those of the next connection
+	slots.reset(0)
+	<-done
+	slots.reset(2)
+	release()
+	if slots.free != 2 {
+		t.Errorf("expected 2 free slots, got %d", slots.free)
+	}
+}
+
+func TestBrokerPrioritizeRequests(t *testing.T) {
+	mockBroker := NewMockBroker(t, 1)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(mockBroker.Addr(), mockBroker.BrokerID()),
+		"ProduceRequest": NewMockProduceResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Net.MaxOpenRequests = 2
+	config.Net.PrioritizeRequests = true
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := broker.Produce(&ProduceRequest{RequiredAcks: WaitForLocal}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	broker.slots.lock.Lock()
+	free := broker.slots.free
+	broker.slots.lock.Unlock()
+	if free != config.Net.MaxOpenRequests {
+		t.Errorf("expected all %d slots to be freed, got %d", config.Net.MaxOpenRequests, free)
+	}
+	safeClose(t, broker)
+
+	if requests := len(mockBroker.History()); requests != 40 {
+		t.Errorf("expected 40 requests, got %d", requests)
+	}
+}
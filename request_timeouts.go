@@ -0,0 +1,63 @@
+package sarama
+
+import "time"
+
+// the API keys of the requests Net.ReadTimeouts.Admin applies to
+var adminAPIKeys = map[int16]none{
+	15: {}, // DescribeGroups
+	16: {}, // ListGroups
+	19: {}, // CreateTopics
+	20: {}, // DeleteTopics
+	21: {}, // DeleteRecords
+	29: {}, // DescribeAcls
+	30: {}, // CreateAcls
+	31: {}, // DeleteAcls
+	32: {}, // DescribeConfigs
+	33: {}, // AlterConfigs
+	34: {}, // AlterReplicaLogDirs
+	35: {}, // DescribeLogDirs
+	37: {}, // CreatePartitions
+	38: {}, // CreateDelegationToken
+	39: {}, // RenewDelegationToken
+	40: {}, // ExpireDelegationToken
+	41: {}, // DescribeDelegationToken
+	42: {}, // DeleteGroups
+	43: {}, // ElectLeaders
+	44: {}, // IncrementalAlterConfigs
+	45: {}, // AlterPartitionReassignments
+	46: {}, // ListPartitionReassignments
+	47: {}, // OffsetDelete
+	48: {}, // DescribeClientQuotas
+	49: {}, // AlterClientQuotas
+	50: {}, // DescribeUserScramCredentials
+	51: {}, // AlterUserScramCredentials
+	57: {}, // UpdateFeatures
+	60: {}, // DescribeCluster
+	61: {}, // DescribeProducers
+	64: {}, // UnregisterBroker
+	65: {}, // DescribeTransactions
+	66: {}, // ListTransactions
+	69: {}, // ConsumerGroupDescribe
+	80: {}, // AddRaftVoter
+	81: {}, // RemoveRaftVoter
+}
+
+// readTimeoutFor returns how long to wait for the response to the request,
+// Net.ReadTimeout unless overridden by Net.ReadTimeouts
+func (c *Config) readTimeoutFor(rb protocolBody) time.Duration {
+	var timeout time.Duration
+	switch key := rb.key(); key {
+	case 3: // Metadata
+		timeout = c.Net.ReadTimeouts.Metadata
+	case 2, 9, 23: // ListOffsets, OffsetFetch, OffsetForLeaderEpoch
+		timeout = c.Net.ReadTimeouts.Offsets
+	default:
+		if _, ok := adminAPIKeys[key]; ok {
+			timeout = c.Net.ReadTimeouts.Admin
+		}
+	}
+	if timeout > 0 {
+		return timeout
+	}
+	return c.Net.ReadTimeout
+}
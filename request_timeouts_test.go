@@ -0,0 +1,70 @@
+package sarama
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConfigReadTimeoutFor(t *testing.T) {
+	config := NewTestConfig()
+	config.Net.ReadTimeout = time.Second
+	config.Net.ReadTimeouts.Admin = time.Minute
+	config.Net.ReadTimeouts.Offsets = 2 * time.Second
+
+	for _, test := range []struct {
+		request  protocolBody
+		expected time.Duration
+	}{
+		{&CreateTopicsRequest{}, time.Minute},
+		{&AlterPartitionReassignmentsRequest{}, time.Minute},
+		{&OffsetFetchRequest{}, 2 * time.Second},
+		{&OffsetRequest{}, 2 * time.Second},
+		// not overridden
+		{&MetadataRequest{}, time.Second},
+		{&ProduceRequest{}, time.Second},
+	} {
+		if timeout := config.readTimeoutFor(test.request); timeout != test.expected {
+			t.Errorf("expected the read timeout of %T to be %s, got %s", test.request, test.expected, timeout)
+		}
+	}
+}
+
+func TestBrokerReadTimeouts(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		metadata time.Duration
+		timeout  bool
+	}{
+		{"inherited", 0, true},
+		{"overridden", 5 * time.Second, false},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mockBroker := NewMockBroker(t, 1)
+			defer mockBroker.Close()
+			mockBroker.SetLatency(200 * time.Millisecond)
+			mockBroker.SetHandlerByMap(map[string]MockResponse{
+				"MetadataRequest": NewMockMetadataResponse(t).
+					SetBroker(mockBroker.Addr(), mockBroker.BrokerID()),
+			})
+
+			config := NewTestConfig()
+			config.Net.ReadTimeout = 50 * time.Millisecond
+			config.Net.ReadTimeouts.Metadata = test.metadata
+
+			broker := NewBroker(mockBroker.Addr())
+			if err := broker.Open(config); err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = broker.Close() }()
+
+			_, err := broker.GetMetadata(&MetadataRequest{})
+			var netErr net.Error
+			if timedOut := errors.As(err, &netErr) && netErr.Timeout(); timedOut != test.timeout {
+				t.Errorf("expected timeout %t, got error %v", test.timeout, err)
+			}
+		})
+	}
+}
@@ -19,6 +19,11 @@ exactly with the protocol fields documented by Kafka at
 https://cwiki.apache.org/confluence/display/KAFKA/A+Guide+To+The+Kafka+Protocol
 
 Metrics are exposed through https://github.com/rcrowley/go-metrics library in a local registry.
+They can be reported to another monitoring stack instead by setting Config.MetricsRecorder, in
+which case the "-for-broker-<broker-id>", "-for-topic-<topic>", "-for-partition-<partition>",
+"-for-api-<api-key>" and "-<group>" suffixes below are the "broker", "topic", "partition", "api"
+and "group" labels of the metrics, and the meters are counters. The
+metrics of a registry can be scraped by Prometheus with a PrometheusExporter.
 
 Broker related metrics:
 
@@ -43,10 +48,49 @@ Broker related metrics:
 	|                                              |            | for all brokers                                               |
 	| requests-in-flight-for-broker-<broker-id>    | counter    | The current number of in-flight requests awaiting a response  |
 	|                                              |            | for a given broker                                            |
+	| tls-handshake-rate                           | meter      | TLS handshakes/second with all brokers                        |
+	| tls-handshake-rate-for-broker-<broker-id>    | meter      | TLS handshakes/second with a given broker                     |
+	| tls-handshake-in-ms                          | histogram  | Distribution of the TLS handshake duration in ms for all      |
+	|                                              |            | brokers                                                       |
+	| tls-handshake-in-ms-for-broker-<broker-id>   | histogram  | Distribution of the TLS handshake duration in ms for a given  |
+	|                                              |            | broker                                                        |
+	| tls-resumption-rate                          | meter      | TLS handshakes/second resuming a previous session with all    |
+	|                                              |            | brokers                                                       |
+	| tls-resumption-rate-for-broker-<broker-id>   | meter      | TLS handshakes/second resuming a previous session with a      |
+	|                                              |            | given broker                                                  |
+	| successful-authentication-rate               | meter      | SASL authentications/second succeeding with all brokers       |
+	| failed-authentication-rate                   | meter      | SASL authentications/second failing with all brokers          |
+	| successful-reauthentication-rate             | meter      | SASL re-authentications/second succeeding with all brokers    |
+	| failed-reauthentication-rate                 | meter      | SASL re-authentications/second failing with all brokers       |
 	+----------------------------------------------+------------+---------------------------------------------------------------+
 
 Note that we do not gather specific metrics for seed brokers but they are part of the "all brokers" metrics.
 
+Broker related metrics by API key, named as returned by APIKeyName (Produce, Fetch, Metadata, ...):
+
+	+----------------------------------------------------------------+------------+------------------------------------------------------------+
+	| Name                                                           | Type       | Description                                                |
+	+----------------------------------------------------------------+------------+------------------------------------------------------------+
+	| request-rate-for-api-<api-key>                                 | meter      | Requests/second of the API key sent to all brokers         |
+	| request-rate-for-api-<api-key>-for-broker-<broker-id>          | meter      | Requests/second of the API key sent to a given broker      |
+	| request-size-for-api-<api-key>                                 | histogram  | Distribution of the request size in bytes of the API key   |
+	|                                                                |            | for all brokers                                            |
+	| request-size-for-api-<api-key>-for-broker-<broker-id>          | histogram  | Distribution of the request size in bytes of the API key   |
+	|                                                                |            | for a given broker                                         |
+	| request-latency-in-ms-for-api-<api-key>                        | histogram  | Distribution of the request latency in ms of the API key   |
+	|                                                                |            | for all brokers                                            |
+	| request-latency-in-ms-for-api-<api-key>-for-broker-<broker-id> | histogram  | Distribution of the request latency in ms of the API key   |
+	|                                                                |            | for a given broker                                         |
+	| response-size-for-api-<api-key>                                | histogram  | Distribution of the response size in bytes of the API key  |
+	|                                                                |            | for all brokers                                            |
+	| response-size-for-api-<api-key>-for-broker-<broker-id>         | histogram  | Distribution of the response size in bytes of the API key  |
+	|                                                                |            | for a given broker                                         |
+	| request-error-rate-for-api-<api-key>                           | meter      | Requests/second of the API key failing to be sent or to    |
+	|                                                                |            | get a response from all brokers                            |
+	| request-error-rate-for-api-<api-key>-for-broker-<broker-id>    | meter      | Requests/second of the API key failing to be sent or to    |
+	|                                                                |            | get a response from a given broker                         |
+	+----------------------------------------------------------------+------------+------------------------------------------------------------+
+
 Producer related metrics:
 
 	+-------------------------------------------+------------+--------------------------------------------------------------------------------------+
@@ -60,6 +104,10 @@ Producer related metrics:
 	| records-per-request-for-topic-<topic>     | histogram  | Distribution of the number of records sent per request for a given topic             |
 	| compression-ratio                         | histogram  | Distribution of the compression ratio times 100 of record batches for all topics     |
 	| compression-ratio-for-topic-<topic>       | histogram  | Distribution of the compression ratio times 100 of record batches for a given topic  |
+	| produce-latency-in-ms                     | histogram  | Distribution of the time in ms from a message entering the producer to its           |
+	|                                           |            | acknowledgement for all topics                                                       |
+	| produce-latency-in-ms-for-topic-<topic>   | histogram  | Distribution of the time in ms from a message entering the producer to its           |
+	|                                           |            | acknowledgement for a given topic                                                    |
 	+-------------------------------------------+------------+--------------------------------------------------------------------------------------+
 
 Consumer related metrics:
@@ -68,10 +116,16 @@ Consumer related metrics:
 	| Name                                      | Type       | Description                                                                          |
 	+-------------------------------------------+------------+--------------------------------------------------------------------------------------+
 	| consumer-batch-size                       | histogram  | Distribution of the number of messages in a batch                                    |
+	| end-to-end-latency-in-ms                  | histogram  | Distribution of the time in ms from the timestamp of a message to its delivery for   |
+	|                                           |            | all topics                                                                           |
+	| end-to-end-latency-in-ms-for-topic-<topic>| histogram  | Distribution of the time in ms from the timestamp of a message to its delivery for   |
+	|                                           |            | a given topic                                                                        |
 	| consumer-group-join-total-<GroupID>       | counter    | Total count of consumer group join attempts                                          |
 	| consumer-group-join-failed-<GroupID>      | counter    | Total count of consumer group join failures                                          |
 	| consumer-group-sync-total-<GroupID>       | counter    | Total count of consumer group sync attempts                                          |
 	| consumer-group-sync-failed-<GroupID>      | counter    | Total count of consumer group sync failures                                          |
+	| consumer-lag-for-topic-<topic>-           | counter    | The number of messages of the partition left to deliver, from the high watermark of  |
+	| for-partition-<partition>[-<GroupID>]     |            | the last fetch response, suffixed by the group for the consumers of a ConsumerGroup  |
 	+-------------------------------------------+------------+--------------------------------------------------------------------------------------+
 
 */
@@ -85,7 +139,8 @@ import (
 var (
 	// Logger is the instance of a StdLogger interface that Sarama writes connection
 	// management events to. By default it is set to discard all log messages via ioutil.Discard,
-	// but you can set it to redirect wherever you want.
+	// but you can set it to redirect wherever you want. See StructuredLogger to get the lines with
+	// their levels and their broker, topic, partition and group fields instead.
 	Logger StdLogger = log.New(io.Discard, "[Sarama] ", log.LstdFlags)
 
 	// PanicHandler is called for recovering from panics spawned internally to the library (and thus
@@ -1,6 +1,8 @@
 package sarama
 
 type SaslAuthenticateRequest struct {
+	// Version defines the protocol version to use for encode and decode
+	Version       int16
 	SaslAuthBytes []byte
 }
 
@@ -12,6 +14,7 @@ func (r *SaslAuthenticateRequest) encode(pe packetEncoder) error {
 }
 
 func (r *SaslAuthenticateRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
 	r.SaslAuthBytes, err = pd.getBytes()
 	return err
 }
@@ -21,7 +24,7 @@ func (r *SaslAuthenticateRequest) key() int16 {
 }
 
 func (r *SaslAuthenticateRequest) version() int16 {
-	return 0
+	return r.Version
 }
 
 func (r *SaslAuthenticateRequest) headerVersion() int16 {
@@ -29,5 +32,10 @@ func (r *SaslAuthenticateRequest) headerVersion() int16 {
 }
 
 func (r *SaslAuthenticateRequest) requiredVersion() KafkaVersion {
-	return V1_0_0_0
+	switch r.Version {
+	case 1:
+		return V2_2_0_0
+	default:
+		return V1_0_0_0
+	}
 }
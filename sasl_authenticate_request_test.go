@@ -11,3 +11,10 @@ func TestSaslAuthenticateRequest(t *testing.T) {
 	request.SaslAuthBytes = []byte(`foo`)
 	testRequest(t, "basic", request, saslAuthenticateRequest)
 }
+
+func TestSaslAuthenticateRequestV1(t *testing.T) {
+	request := new(SaslAuthenticateRequest)
+	request.Version = 1
+	request.SaslAuthBytes = []byte(`foo`)
+	testRequest(t, "v1", request, saslAuthenticateRequest)
+}
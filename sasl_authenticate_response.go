@@ -1,9 +1,15 @@
 package sarama
 
 type SaslAuthenticateResponse struct {
+	// Version defines the protocol version to use for encode and decode
+	Version       int16
 	Err           KError
 	ErrorMessage  *string
 	SaslAuthBytes []byte
+	// SessionLifetimeMs is the lifetime of the authenticated session, after
+	// which the broker closes the connection unless the client authenticated
+	// again (KIP-368), or 0 if it does not expire. Only set from version 1.
+	SessionLifetimeMs int64
 }
 
 func (r *SaslAuthenticateResponse) encode(pe packetEncoder) error {
@@ -11,10 +17,17 @@ func (r *SaslAuthenticateResponse) encode(pe packetEncoder) error {
 	if err := pe.putNullableString(r.ErrorMessage); err != nil {
 		return err
 	}
-	return pe.putBytes(r.SaslAuthBytes)
+	if err := pe.putBytes(r.SaslAuthBytes); err != nil {
+		return err
+	}
+	if r.Version >= 1 {
+		pe.putInt64(r.SessionLifetimeMs)
+	}
+	return nil
 }
 
 func (r *SaslAuthenticateResponse) decode(pd packetDecoder, version int16) error {
+	r.Version = version
 	kerr, err := pd.getInt16()
 	if err != nil {
 		return err
@@ -26,7 +39,13 @@ func (r *SaslAuthenticateResponse) decode(pd packetDecoder, version int16) error
 		return err
 	}
 
-	r.SaslAuthBytes, err = pd.getBytes()
+	if r.SaslAuthBytes, err = pd.getBytes(); err != nil {
+		return err
+	}
+
+	if version >= 1 {
+		r.SessionLifetimeMs, err = pd.getInt64()
+	}
 
 	return err
 }
@@ -36,7 +55,7 @@ func (r *SaslAuthenticateResponse) key() int16 {
 }
 
 func (r *SaslAuthenticateResponse) version() int16 {
-	return 0
+	return r.Version
 }
 
 func (r *SaslAuthenticateResponse) headerVersion() int16 {
@@ -44,5 +63,10 @@ func (r *SaslAuthenticateResponse) headerVersion() int16 {
 }
 
 func (r *SaslAuthenticateResponse) requiredVersion() KafkaVersion {
-	return V1_0_0_0
+	switch r.Version {
+	case 1:
+		return V2_2_0_0
+	default:
+		return V1_0_0_0
+	}
 }
@@ -17,3 +17,19 @@ func TestSaslAuthenticateResponse(t *testing.T) {
 
 	testResponse(t, "authenticate response", response, saslAuthenticatResponseErr)
 }
+
+var saslAuthenticateResponseV1 = []byte{
+	0, 0,
+	255, 255,
+	0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0x0e, 0x10,
+}
+
+func TestSaslAuthenticateResponseV1(t *testing.T) {
+	response := new(SaslAuthenticateResponse)
+	response.Version = 1
+	response.SaslAuthBytes = []byte{}
+	response.SessionLifetimeMs = 3600
+
+	testResponse(t, "authenticate response v1", response, saslAuthenticateResponseV1)
+}
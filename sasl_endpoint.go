@@ -0,0 +1,55 @@
+package sarama
+
+import "fmt"
+
+// SASLEndpoint overrides the SASL mechanism and credentials of Net.SASL for
+// a broker, see Net.SASL.EndpointFunc. The zero fields keep those of
+// Net.SASL.
+type SASLEndpoint struct {
+	Mechanism           SASLMechanism
+	User                string
+	Password            string
+	CredentialsProvider SASLCredentialsProvider
+	TokenProvider       AccessTokenProvider
+}
+
+// forEndpoint returns the config to connect to the broker at addr with, a
+// copy of c with the SASL overrides of Net.SASL.EndpointFunc if any.
+func (c *Config) forEndpoint(addr string) (*Config, error) {
+	if !c.Net.SASL.Enable || c.Net.SASL.EndpointFunc == nil {
+		return c, nil
+	}
+	endpoint := c.Net.SASL.EndpointFunc(addr)
+	if endpoint == nil {
+		return c, nil
+	}
+
+	conf := *c
+	if endpoint.Mechanism != "" {
+		conf.Net.SASL.Mechanism = endpoint.Mechanism
+	}
+	if endpoint.User != "" {
+		conf.Net.SASL.User = endpoint.User
+	}
+	if endpoint.Password != "" {
+		conf.Net.SASL.Password = endpoint.Password
+	}
+	if endpoint.CredentialsProvider != nil {
+		conf.Net.SASL.CredentialsProvider = endpoint.CredentialsProvider
+	}
+	if endpoint.TokenProvider != nil {
+		conf.Net.SASL.TokenProvider = endpoint.TokenProvider
+	}
+
+	switch conf.Net.SASL.Mechanism {
+	case SASLTypePlaintext, SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512:
+		if (conf.Net.SASL.User == "" || conf.Net.SASL.Password == "") && conf.Net.SASL.CredentialsProvider == nil {
+			return nil, ConfigurationError(fmt.Sprintf("no SASL credentials for the broker %s", addr))
+		}
+	case SASLTypeOAuth:
+		if conf.Net.SASL.TokenProvider == nil {
+			return nil, ConfigurationError(fmt.Sprintf("no SASL token provider for the broker %s", addr))
+		}
+	}
+	return &conf, nil
+}
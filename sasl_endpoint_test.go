@@ -0,0 +1,64 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSASLEndpointCredentials(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"SaslHandshakeRequest":    NewMockSaslHandshakeResponse(t).SetEnabledMechanisms([]string{SASLTypePlaintext}),
+		"SaslAuthenticateRequest": NewMockSaslAuthenticateResponse(t),
+	})
+
+	conf := NewTestConfig()
+	conf.Version = V1_0_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypePlaintext
+	conf.Net.SASL.Version = SASLHandshakeV1
+	conf.Net.SASL.EndpointFunc = func(addr string) *SASLEndpoint {
+		if addr == mockBroker.Addr() {
+			return &SASLEndpoint{User: "gateway-user", Password: "gateway-password"}
+		}
+		return nil
+	}
+	if err := conf.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+	if _, err := broker.Connected(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rr := range mockBroker.History() {
+		if req, ok := rr.Request.(*SaslAuthenticateRequest); ok {
+			if msg := string(req.SaslAuthBytes); msg != "\x00gateway-user\x00gateway-password" {
+				t.Errorf("expected the credentials of the endpoint, got %q", msg)
+			}
+			return
+		}
+	}
+	t.Error("expected a SaslAuthenticateRequest")
+}
+
+func TestSASLEndpointWithoutCredentials(t *testing.T) {
+	conf := NewTestConfig()
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypePlaintext
+	conf.Net.SASL.EndpointFunc = func(addr string) *SASLEndpoint {
+		return &SASLEndpoint{Mechanism: SASLTypeOAuth}
+	}
+
+	_, err := conf.forEndpoint("broker:9092")
+	var configErr ConfigurationError
+	if !errors.As(err, &configErr) {
+		t.Errorf("expected a ConfigurationError, got %v", err)
+	}
+}
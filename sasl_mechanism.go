@@ -0,0 +1,121 @@
+package sarama
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SASLMechanismClient is the client side of a SASL mechanism registered with
+// RegisterSASLMechanism, a new one performing each authentication.
+type SASLMechanismClient interface {
+	// Start returns the initial response of the client sent to the broker
+	// at addr.
+	Start(addr string) ([]byte, error)
+	// Step handles a challenge of the broker, returning the response to
+	// send, or done once the exchange completed successfully.
+	Step(challenge []byte) (response []byte, done bool, err error)
+}
+
+// SASLMechanismClientFunc creates the client of a registered mechanism from
+// the configuration of the Broker.
+type SASLMechanismClientFunc func(conf *Config) (SASLMechanismClient, error)
+
+var (
+	saslMechanismsLock sync.RWMutex
+	saslMechanisms     = make(map[SASLMechanism]SASLMechanismClientFunc)
+)
+
+// RegisterSASLMechanism registers a custom SASL mechanism which can then be
+// used as Net.SASL.Mechanism, the brokers negotiating it with a v1 handshake
+// and exchanging its messages in SaslAuthenticate requests, which requires
+// Kafka 1.0.0 or later. The built-in mechanisms cannot be replaced, and
+// registering a mechanism again replaces its previous client function.
+func RegisterSASLMechanism(mechanism SASLMechanism, newClient SASLMechanismClientFunc) error {
+	switch mechanism {
+	case SASLTypeOAuth, SASLTypePlaintext, SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512, SASLTypeGSSAPI:
+		return ConfigurationError(fmt.Sprintf("the built-in %s SASL mechanism cannot be registered", mechanism))
+	case "":
+		return ConfigurationError("the SASL mechanism name must not be empty")
+	}
+	if newClient == nil {
+		return ConfigurationError(fmt.Sprintf("the client function of the %s SASL mechanism must not be nil", mechanism))
+	}
+
+	saslMechanismsLock.Lock()
+	defer saslMechanismsLock.Unlock()
+	saslMechanisms[mechanism] = newClient
+	return nil
+}
+
+// registeredSASLMechanism returns the client function of a registered mechanism
+func registeredSASLMechanism(mechanism SASLMechanism) (SASLMechanismClientFunc, bool) {
+	saslMechanismsLock.RLock()
+	defer saslMechanismsLock.RUnlock()
+	newClient, ok := saslMechanisms[mechanism]
+	return newClient, ok
+}
+
+// exchangeSASLMechanism runs the exchange of the client of a registered
+// mechanism, roundTrip sending a message to the broker and returning its
+// challenge.
+func (b *Broker) exchangeSASLMechanism(newClient SASLMechanismClientFunc, roundTrip func([]byte) ([]byte, error)) error {
+	client, err := newClient(b.conf)
+	if err != nil {
+		return err
+	}
+	msg, err := client.Start(b.addr)
+	if err != nil {
+		return fmt.Errorf("failed to start the %s exchange with the server: %w", b.conf.Net.SASL.Mechanism, err)
+	}
+	for {
+		challenge, err := roundTrip(msg)
+		if err != nil {
+			return err
+		}
+		var done bool
+		if msg, done, err = client.Step(challenge); err != nil {
+			return fmt.Errorf("failed to advance the %s exchange: %w", b.conf.Net.SASL.Mechanism, err)
+		} else if done {
+			return nil
+		}
+	}
+}
+
+// sendAndReceiveSASLMechanism authenticates with a registered mechanism
+func (b *Broker) sendAndReceiveSASLMechanism(newClient SASLMechanismClientFunc) error {
+	if err := b.sendAndReceiveSASLHandshake(b.conf.Net.SASL.Mechanism, SASLHandshakeV1); err != nil {
+		return err
+	}
+
+	err := b.exchangeSASLMechanism(newClient, func(msg []byte) ([]byte, error) {
+		requestTime := time.Now()
+		// Will be decremented in updateIncomingCommunicationMetrics (except error)
+		b.addRequestInFlightMetrics(1)
+		correlationID := b.correlationID
+		bytesWritten, err := b.sendSaslAuthenticateRequest(correlationID, msg)
+		b.updateOutgoingCommunicationMetrics(bytesWritten)
+		if err != nil {
+			b.addRequestInFlightMetrics(-1)
+			b.log().Warnf("Failed to write SASL auth header to broker %s: %s", b.addr, err)
+			return nil, err
+		}
+
+		b.correlationID++
+		challenge, err := b.receiveSaslAuthenticateResponse(correlationID)
+		if err != nil {
+			b.addRequestInFlightMetrics(-1)
+			b.log().Warnf("Failed to read response while authenticating with SASL to broker %s: %s", b.addr, err)
+			return nil, err
+		}
+		b.updateIncomingCommunicationMetrics(len(challenge), time.Since(requestTime))
+		return challenge, nil
+	})
+	if err != nil {
+		b.log().Warnf("SASL authentication failed: %v", err)
+		return err
+	}
+
+	b.log().Debugf("SASL authentication succeeded")
+	return nil
+}
@@ -0,0 +1,124 @@
+package sarama
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// pingPongSASLClient sends "ping" and expects "pong" from the broker
+type pingPongSASLClient struct {
+	addr string
+}
+
+func (c *pingPongSASLClient) Start(addr string) ([]byte, error) {
+	c.addr = addr
+	return []byte("ping"), nil
+}
+
+func (c *pingPongSASLClient) Step(challenge []byte) ([]byte, bool, error) {
+	if !bytes.Equal(challenge, []byte("pong")) {
+		return nil, false, errors.New("unexpected challenge")
+	}
+	return nil, true, nil
+}
+
+func TestRegisterSASLMechanism(t *testing.T) {
+	newClient := func(conf *Config) (SASLMechanismClient, error) { return &pingPongSASLClient{}, nil }
+	if err := RegisterSASLMechanism(SASLTypePlaintext, newClient); err == nil {
+		t.Error("expected the built-in mechanisms not to be replaceable")
+	}
+	if err := RegisterSASLMechanism("", newClient); err == nil {
+		t.Error("expected an empty mechanism to be rejected")
+	}
+	if err := RegisterSASLMechanism("PING-PONG", nil); err == nil {
+		t.Error("expected a nil client function to be rejected")
+	}
+	if err := RegisterSASLMechanism("PING-PONG", newClient); err != nil {
+		t.Fatal(err)
+	}
+
+	config := NewTestConfig()
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Mechanism = "PING-PONG"
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+	config.Net.SASL.Mechanism = "UNKNOWN"
+	if err := config.Validate(); err == nil {
+		t.Error("expected an unregistered mechanism to be invalid")
+	}
+}
+
+func TestSASLRegisteredMechanism(t *testing.T) {
+	var client *pingPongSASLClient
+	if err := RegisterSASLMechanism("PING-PONG", func(conf *Config) (SASLMechanismClient, error) {
+		client = &pingPongSASLClient{}
+		return client, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name      string
+		challenge string
+		authErr   KError
+		expectErr bool
+	}{
+		{name: "successful authentication", challenge: "pong", authErr: ErrNoError},
+		{name: "client step error", challenge: "gong", authErr: ErrNoError, expectErr: true},
+		{name: "server authentication error", challenge: "pong", authErr: ErrSASLAuthenticationFailed, expectErr: true},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mockBroker := NewMockBroker(t, 0)
+			defer mockBroker.Close()
+
+			mockSASLAuthResponse := NewMockSaslAuthenticateResponse(t).SetAuthBytes([]byte(test.challenge))
+			if !errors.Is(test.authErr, ErrNoError) {
+				mockSASLAuthResponse = mockSASLAuthResponse.SetError(test.authErr)
+			}
+			mockBroker.SetHandlerByMap(map[string]MockResponse{
+				"SaslAuthenticateRequest": mockSASLAuthResponse,
+				"SaslHandshakeRequest":    NewMockSaslHandshakeResponse(t).SetEnabledMechanisms([]string{"PING-PONG"}),
+			})
+
+			broker := NewBroker(mockBroker.Addr())
+			broker.requestRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.outgoingByteRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.incomingByteRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.requestSize = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.responseSize = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.responseRate = goMetricsMeter{metrics.NilMeter{}}
+			broker.requestLatency = goMetricsHistogram{metrics.NilHistogram{}}
+			broker.requestsInFlight = goMetricsCounter{metrics.NilCounter{}}
+
+			conf := NewTestConfig()
+			conf.Net.SASL.Mechanism = "PING-PONG"
+			conf.Version = V1_0_0_0
+			broker.conf = conf
+
+			conn, err := net.Dial("tcp", mockBroker.Addr())
+			if err != nil {
+				t.Fatal(err)
+			}
+			broker.conn = conn
+
+			err = broker.authenticateViaSASL()
+			if test.expectErr && err == nil {
+				t.Error("expected an error")
+			} else if !test.expectErr && err != nil {
+				t.Error(err)
+			}
+			if !errors.Is(test.authErr, ErrNoError) && !errors.Is(err, test.authErr) {
+				t.Errorf("expected %s, got %v", test.authErr, err)
+			}
+			if client.addr != mockBroker.Addr() {
+				t.Errorf("expected the client to be started with %s, got %s", mockBroker.Addr(), client.addr)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package sarama
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// saslAuthenticateVersion returns the version of the SaslAuthenticate
+// requests, from 1 on the session lifetime being reported (KIP-368)
+func (b *Broker) saslAuthenticateVersion() int16 {
+	if b.conf.Version.IsAtLeast(V2_2_0_0) {
+		return 1
+	}
+	return 0
+}
+
+// setSessionLifetime records the lifetime of the session the broker reported
+// at the end of a successful authentication
+func (b *Broker) setSessionLifetime(res *SaslAuthenticateResponse) {
+	if res.SessionLifetimeMs > 0 {
+		b.sessionLifetime = time.Duration(res.SessionLifetimeMs) * time.Millisecond
+	}
+}
+
+// scheduleReauthentication arms the timer authenticating again the session
+// before its lifetime, if any, expires. It must be called with b.lock held.
+func (b *Broker) scheduleReauthentication() {
+	if b.sessionLifetime <= 0 {
+		return
+	}
+
+	// like the Java client, re-authenticate between 85% and 95% of the
+	// lifetime so that the connections of a client do not all do so at once
+	delay := time.Duration(float64(b.sessionLifetime) * (0.85 + 0.1*rand.Float64()))
+	conn := b.conn
+	b.reauthTimer = time.AfterFunc(delay, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+
+		if b.conn != conn {
+			// closed, or reconnected and authenticated again meanwhile
+			return
+		}
+		b.reauthTimer = nil
+		start := time.Now()
+		err := b.reauthenticate()
+		b.recordAuthentication(true, start, err)
+		if err != nil {
+			b.log().Warnf("Failed to re-authenticate with broker %s: %s", b.addr, err)
+			return
+		}
+		b.log().Debugf("Re-authenticated with broker %s, session lifetime %s", b.addr, b.sessionLifetime)
+		b.scheduleReauthentication()
+	})
+}
+
+// reauthenticate authenticates again on the live connection (KIP-368), the
+// handshake and authentication being regular requests pipelined after those
+// in flight. It must be called with b.lock held, which keeps other requests
+// from being sent until the session is authenticated again.
+func (b *Broker) reauthenticate() error {
+	b.sessionLifetime = 0
+
+	mechanism := b.conf.Net.SASL.Mechanism
+	handshake := &SaslHandshakeRequest{Mechanism: string(mechanism), Version: SASLHandshakeV1}
+	handshakeResponse := new(SaslHandshakeResponse)
+	if err := b.sendAndReceiveLocked(handshake, handshakeResponse); err != nil {
+		return err
+	}
+	if !errors.Is(handshakeResponse.Err, ErrNoError) {
+		return handshakeResponse.Err
+	}
+
+	if newClient, ok := registeredSASLMechanism(mechanism); ok {
+		return b.exchangeSASLMechanism(newClient, func(msg []byte) ([]byte, error) {
+			res, err := b.reauthenticateStep(msg)
+			if err != nil {
+				return nil, err
+			}
+			return res.SaslAuthBytes, nil
+		})
+	}
+
+	switch mechanism {
+	case SASLTypeOAuth:
+		token, err := b.conf.Net.SASL.TokenProvider.Token()
+		if err != nil {
+			return err
+		}
+		message, err := buildClientFirstMessage(token)
+		if err != nil {
+			return err
+		}
+		res, err := b.reauthenticateStep(message)
+		if err != nil && len(res.SaslAuthBytes) > 0 {
+			// abort the token exchange, the broker returns the failure code
+			_, _ = b.reauthenticateStep([]byte("\x01"))
+		}
+		return err
+	case SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512:
+		user, password, err := b.saslCredentials()
+		if err != nil {
+			return err
+		}
+		scramClient, err := b.scramClient()
+		if err != nil {
+			return err
+		}
+		if err := scramClient.Begin(user, password, b.conf.Net.SASL.SCRAMAuthzID); err != nil {
+			return fmt.Errorf("failed to start SCRAM exchange with the server: %w", err)
+		}
+		msg, err := scramClient.Step("")
+		if err != nil {
+			return fmt.Errorf("failed to advance the SCRAM exchange: %w", err)
+		}
+		msg = b.scramClientFirstMessage(msg)
+		for !scramClient.Done() {
+			res, err := b.reauthenticateStep([]byte(msg))
+			if err != nil {
+				return err
+			}
+			if msg, err = scramClient.Step(string(res.SaslAuthBytes)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case SASLTypePlaintext:
+		user, password, err := b.saslCredentials()
+		if err != nil {
+			return err
+		}
+		_, err = b.reauthenticateStep([]byte(b.conf.Net.SASL.AuthIdentity + "\x00" + user + "\x00" + password))
+		return err
+	default:
+		return fmt.Errorf("re-authentication is not supported with the %s mechanism", mechanism)
+	}
+}
+
+// reauthenticateStep sends a SaslAuthenticateRequest of the re-authentication
+// and returns its response. It must be called with b.lock held.
+func (b *Broker) reauthenticateStep(authBytes []byte) (*SaslAuthenticateResponse, error) {
+	req := &SaslAuthenticateRequest{Version: b.saslAuthenticateVersion(), SaslAuthBytes: authBytes}
+	res := &SaslAuthenticateResponse{}
+	if err := b.sendAndReceiveLocked(req, res); err != nil {
+		return res, err
+	}
+
+	if !errors.Is(res.Err, ErrNoError) {
+		var err error = res.Err
+		if res.ErrorMessage != nil {
+			err = Wrap(res.Err, errors.New(*res.ErrorMessage))
+		}
+		return res, err
+	}
+
+	b.setSessionLifetime(res)
+	return res, nil
+}
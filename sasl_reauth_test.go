@@ -0,0 +1,61 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBrokerSASLReauthentication(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"SaslHandshakeRequest": NewMockSaslHandshakeResponse(t).
+			SetEnabledMechanisms([]string{SASLTypePlaintext}),
+		"SaslAuthenticateRequest": NewMockSaslAuthenticateResponse(t).
+			SetSessionLifetimeMs(200),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(mockBroker.Addr(), mockBroker.BrokerID()),
+	})
+
+	conf := NewTestConfig()
+	conf.Version = V2_2_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypePlaintext
+	conf.Net.SASL.User = "user"
+	conf.Net.SASL.Password = "password"
+	conf.Net.SASL.Version = SASLHandshakeV1
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	authentications := func() int {
+		count := 0
+		for _, rr := range mockBroker.History() {
+			if req, ok := rr.Request.(*SaslAuthenticateRequest); ok {
+				if req.Version != 1 {
+					t.Errorf("expected SaslAuthenticateRequest v1, got v%d", req.Version)
+				}
+				count++
+			}
+		}
+		return count
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for authentications() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if count := authentications(); count < 3 {
+		t.Fatalf("expected the session to be authenticated again twice, got %d authentications", count)
+	}
+
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
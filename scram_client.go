@@ -0,0 +1,77 @@
+package sarama
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"crypto/sha512"
+	"fmt"
+
+	"github.com/xdg-go/scram"
+)
+
+// defaultSCRAMClient is the SCRAMClient used for the SCRAM-SHA-256 and
+// SCRAM-SHA-512 mechanisms when no Net.SASL.SCRAMClientGeneratorFunc is set.
+type defaultSCRAMClient struct {
+	hashGenerator scram.HashGeneratorFcn
+	conversation  *scram.ClientConversation
+}
+
+// newDefaultSCRAMClient returns the built-in SCRAM client for the mechanism
+func newDefaultSCRAMClient(mechanism SASLMechanism) (SCRAMClient, error) {
+	switch mechanism {
+	case SASLTypeSCRAMSHA256:
+		return &defaultSCRAMClient{hashGenerator: sha256.New}, nil
+	case SASLTypeSCRAMSHA512:
+		return &defaultSCRAMClient{hashGenerator: sha512.New}, nil
+	default:
+		return nil, fmt.Errorf("no built-in SCRAM client for the %s mechanism", mechanism)
+	}
+}
+
+func (c *defaultSCRAMClient) Begin(userName, password, authzID string) error {
+	client, err := c.hashGenerator.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+func (c *defaultSCRAMClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+func (c *defaultSCRAMClient) Done() bool {
+	return c.conversation.Done()
+}
+
+// scramClient returns a new SCRAM client from Net.SASL.SCRAMClientGeneratorFunc,
+// falling back to the built-in one when none is configured.
+func (b *Broker) scramClient() (SCRAMClient, error) {
+	if b.conf.Net.SASL.SCRAMClientGeneratorFunc != nil {
+		return b.conf.Net.SASL.SCRAMClientGeneratorFunc(), nil
+	}
+	return newDefaultSCRAMClient(b.conf.Net.SASL.Mechanism)
+}
+
+// scramClientFirstMessage adds the extensions to the first message of the
+// client, which are not part of the message authenticated by the proofs.
+func (b *Broker) scramClientFirstMessage(msg string) string {
+	if b.conf.Net.SASL.SCRAMTokenAuth {
+		msg += ",tokenauth=true"
+	}
+	return msg
+}
+
+// SetDelegationToken configures SASL/SCRAM to authenticate with the
+// delegation token, e.g. created with ClusterAdmin.CreateDelegationToken,
+// SCRAM-SHA-512 being used unless Net.SASL.Mechanism is already a SCRAM one.
+func (c *Config) SetDelegationToken(token *DelegationToken) {
+	c.Net.SASL.Enable = true
+	if c.Net.SASL.Mechanism != SASLTypeSCRAMSHA256 && c.Net.SASL.Mechanism != SASLTypeSCRAMSHA512 {
+		c.Net.SASL.Mechanism = SASLTypeSCRAMSHA512
+	}
+	c.Net.SASL.User = token.TokenID
+	c.Net.SASL.Password = base64.StdEncoding.EncodeToString(token.HMAC)
+	c.Net.SASL.SCRAMTokenAuth = true
+}
@@ -0,0 +1,126 @@
+package sarama
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/xdg-go/scram"
+)
+
+func TestDefaultSCRAMClient(t *testing.T) {
+	for mechanism, hashGenerator := range map[SASLMechanism]scram.HashGeneratorFcn{
+		SASLTypeSCRAMSHA256: sha256.New,
+		SASLTypeSCRAMSHA512: sha512.New,
+	} {
+		t.Run(string(mechanism), func(t *testing.T) {
+			client, err := hashGenerator.NewClient("user", "pencil", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			credentials := client.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096})
+			server, err := hashGenerator.NewServer(func(string) (scram.StoredCredentials, error) {
+				return credentials, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			serverConversation := server.NewConversation()
+
+			scramClient, err := newDefaultSCRAMClient(mechanism)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := scramClient.Begin("user", "pencil", ""); err != nil {
+				t.Fatal(err)
+			}
+			msg, err := scramClient.Step("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for !scramClient.Done() {
+				challenge, err := serverConversation.Step(msg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if msg, err = scramClient.Step(challenge); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !serverConversation.Valid() {
+				t.Error("expected the server to authenticate the client")
+			}
+		})
+	}
+}
+
+func TestDefaultSCRAMClientUnknownMechanism(t *testing.T) {
+	if _, err := newDefaultSCRAMClient(SASLTypePlaintext); err == nil {
+		t.Error("expected an error for a non SCRAM mechanism")
+	}
+}
+
+func TestConfigSCRAMWithoutClientGenerator(t *testing.T) {
+	config := NewTestConfig()
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Mechanism = SASLTypeSCRAMSHA512
+	config.Net.SASL.User = "user"
+	config.Net.SASL.Password = "strong_password"
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfigSetDelegationToken(t *testing.T) {
+	config := NewTestConfig()
+	config.SetDelegationToken(&DelegationToken{TokenID: "token-id", HMAC: []byte{0x01, 0x02, 0x03}})
+
+	if !config.Net.SASL.Enable || config.Net.SASL.Mechanism != SASLTypeSCRAMSHA512 || !config.Net.SASL.SCRAMTokenAuth {
+		t.Errorf("expected SASL/SCRAM token authentication, got %+v", config.Net.SASL)
+	}
+	if config.Net.SASL.User != "token-id" || config.Net.SASL.Password != "AQID" {
+		t.Errorf("unexpected credentials %s %s", config.Net.SASL.User, config.Net.SASL.Password)
+	}
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	config.Net.SASL.Mechanism = SASLTypePlaintext
+	if err := config.Validate(); err == nil {
+		t.Error("expected token authentication to require a SCRAM mechanism")
+	}
+}
+
+func TestSASLSCRAMTokenAuth(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"SaslAuthenticateRequest": NewMockSaslAuthenticateResponse(t).SetAuthBytes([]byte("pong")),
+		"SaslHandshakeRequest":    NewMockSaslHandshakeResponse(t).SetEnabledMechanisms([]string{SASLTypeSCRAMSHA512}),
+	})
+
+	conf := NewTestConfig()
+	conf.Version = V1_0_0_0
+	conf.Net.SASL.Version = SASLHandshakeV1
+	conf.SetDelegationToken(&DelegationToken{TokenID: "token-id", HMAC: []byte("hmac")})
+	conf.Net.SASL.SCRAMClientGeneratorFunc = func() SCRAMClient { return &MockSCRAMClient{} }
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+	if _, err := broker.Connected(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rr := range mockBroker.History() {
+		if req, ok := rr.Request.(*SaslAuthenticateRequest); ok {
+			if msg := string(req.SaslAuthBytes); msg != "ping,tokenauth=true" {
+				t.Errorf("expected the tokenauth extension, got %q", msg)
+			}
+			return
+		}
+	}
+	t.Error("expected a SaslAuthenticateRequest")
+}
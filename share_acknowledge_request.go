@@ -0,0 +1,126 @@
+package sarama
+
+// ShareAcknowledgeRequest acknowledges the records delivered to a share group
+// member without fetching more, such as when closing the share session
+type ShareAcknowledgeRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	GroupID  string
+	MemberID string
+	// ShareSessionEpoch is -1 to close the share session and otherwise
+	// incremented by each request of the session
+	ShareSessionEpoch int32
+	Topics            []*ShareAcknowledgeTopic
+}
+
+// ShareAcknowledgeTopic are the partitions of a topic of a
+// ShareAcknowledgeRequest
+type ShareAcknowledgeTopic struct {
+	TopicID    Uuid
+	Partitions []*ShareAcknowledgePartition
+}
+
+// ShareAcknowledgePartition are the acknowledgements of the records of a
+// partition
+type ShareAcknowledgePartition struct {
+	Partition              int32
+	AcknowledgementBatches []*ShareAcknowledgementBatch
+}
+
+func (r *ShareAcknowledgeRequest) encode(pe packetEncoder) error {
+	if err := pe.putNullableCompactString(&r.GroupID); err != nil {
+		return err
+	}
+	if err := pe.putNullableCompactString(&r.MemberID); err != nil {
+		return err
+	}
+	pe.putInt32(r.ShareSessionEpoch)
+
+	pe.putCompactArrayLength(len(r.Topics))
+	for _, topic := range r.Topics {
+		if err := putUuid(pe, topic.TopicID); err != nil {
+			return err
+		}
+		pe.putCompactArrayLength(len(topic.Partitions))
+		for _, partition := range topic.Partitions {
+			pe.putInt32(partition.Partition)
+			if err := putShareAcknowledgementBatches(pe, partition.AcknowledgementBatches); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ShareAcknowledgeRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.GroupID, r.MemberID, err = getShareGroupMember(pd); err != nil {
+		return err
+	}
+	if r.ShareSessionEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.Topics = make([]*ShareAcknowledgeTopic, n)
+	}
+	for i := 0; i < n; i++ {
+		topic := &ShareAcknowledgeTopic{}
+		if topic.TopicID, err = getUuid(pd); err != nil {
+			return err
+		}
+		numPartitions, err := pd.getCompactArrayLength()
+		if err != nil {
+			return err
+		}
+		if numPartitions > 0 {
+			topic.Partitions = make([]*ShareAcknowledgePartition, numPartitions)
+		}
+		for j := 0; j < numPartitions; j++ {
+			partition := &ShareAcknowledgePartition{}
+			if partition.Partition, err = pd.getInt32(); err != nil {
+				return err
+			}
+			if partition.AcknowledgementBatches, err = getShareAcknowledgementBatches(pd); err != nil {
+				return err
+			}
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			topic.Partitions[j] = partition
+		}
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+		r.Topics[i] = topic
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *ShareAcknowledgeRequest) key() int16 {
+	return 79
+}
+
+func (r *ShareAcknowledgeRequest) version() int16 {
+	return r.Version
+}
+
+func (r *ShareAcknowledgeRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *ShareAcknowledgeRequest) requiredVersion() KafkaVersion {
+	return V4_0_0_0
+}
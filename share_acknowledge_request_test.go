@@ -0,0 +1,40 @@
+package sarama
+
+import "testing"
+
+var shareAcknowledgeRequestClose = []byte{
+	0x02, 'g', // GroupID
+	0x02, 'm', // MemberID
+	0xff, 0xff, 0xff, 0xff, // ShareSessionEpoch
+	0x02,                                                                                           // Topics
+	0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, // TopicID
+	0x02,                   // Partitions
+	0x00, 0x00, 0x00, 0x01, // Partition
+	0x02,                                           // AcknowledgementBatches
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0a, // FirstOffset
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, // LastOffset
+	0x02, 0x01, // AcknowledgeTypes
+	0x00, // empty tagged fields
+	0x00, // empty tagged fields
+	0x00, // empty tagged fields
+	0x00, // empty tagged fields
+}
+
+func TestShareAcknowledgeRequest(t *testing.T) {
+	testRequest(t, "close", &ShareAcknowledgeRequest{
+		GroupID:           "g",
+		MemberID:          "m",
+		ShareSessionEpoch: -1,
+		Topics: []*ShareAcknowledgeTopic{{
+			TopicID: Uuid{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+			Partitions: []*ShareAcknowledgePartition{{
+				Partition: 1,
+				AcknowledgementBatches: []*ShareAcknowledgementBatch{{
+					FirstOffset:      10,
+					LastOffset:       12,
+					AcknowledgeTypes: []ShareAcknowledgeType{ShareAcknowledgeAccept},
+				}},
+			}},
+		}},
+	}, shareAcknowledgeRequestClose)
+}
@@ -0,0 +1,153 @@
+package sarama
+
+import "time"
+
+// ShareAcknowledgeResponse is the response to a ShareAcknowledgeRequest
+type ShareAcknowledgeResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime  time.Duration
+	Err           KError
+	ErrorMessage  *string
+	Topics        []*ShareAcknowledgeResponseTopic
+	NodeEndpoints []*ShareNodeEndpoint
+}
+
+// ShareAcknowledgeResponseTopic are the partitions of a topic of a
+// ShareAcknowledgeResponse
+type ShareAcknowledgeResponseTopic struct {
+	TopicID    Uuid
+	Partitions []*ShareAcknowledgeResponsePartition
+}
+
+// ShareAcknowledgeResponsePartition is the result of the acknowledgements of
+// the records of a partition
+type ShareAcknowledgeResponsePartition struct {
+	Partition     int32
+	Err           KError
+	ErrorMessage  *string
+	CurrentLeader ShareLeader
+}
+
+func (r *ShareAcknowledgeResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	pe.putInt16(int16(r.Err))
+	if err := pe.putNullableCompactString(r.ErrorMessage); err != nil {
+		return err
+	}
+
+	pe.putCompactArrayLength(len(r.Topics))
+	for _, topic := range r.Topics {
+		if err := putUuid(pe, topic.TopicID); err != nil {
+			return err
+		}
+		pe.putCompactArrayLength(len(topic.Partitions))
+		for _, partition := range topic.Partitions {
+			pe.putInt32(partition.Partition)
+			pe.putInt16(int16(partition.Err))
+			if err := pe.putNullableCompactString(partition.ErrorMessage); err != nil {
+				return err
+			}
+			partition.CurrentLeader.encode(pe)
+			pe.putEmptyTaggedFieldArray()
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	if err := putShareNodeEndpoints(pe, r.NodeEndpoints); err != nil {
+		return err
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ShareAcknowledgeResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	if r.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.Topics = make([]*ShareAcknowledgeResponseTopic, n)
+	}
+	for i := 0; i < n; i++ {
+		topic := &ShareAcknowledgeResponseTopic{}
+		if topic.TopicID, err = getUuid(pd); err != nil {
+			return err
+		}
+		numPartitions, err := pd.getCompactArrayLength()
+		if err != nil {
+			return err
+		}
+		if numPartitions > 0 {
+			topic.Partitions = make([]*ShareAcknowledgeResponsePartition, numPartitions)
+		}
+		for j := 0; j < numPartitions; j++ {
+			partition := &ShareAcknowledgeResponsePartition{}
+			if partition.Partition, err = pd.getInt32(); err != nil {
+				return err
+			}
+			kerr, err := pd.getInt16()
+			if err != nil {
+				return err
+			}
+			partition.Err = KError(kerr)
+			if partition.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+				return err
+			}
+			if err := partition.CurrentLeader.decode(pd); err != nil {
+				return err
+			}
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			topic.Partitions[j] = partition
+		}
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+		r.Topics[i] = topic
+	}
+
+	if r.NodeEndpoints, err = getShareNodeEndpoints(pd); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *ShareAcknowledgeResponse) key() int16 {
+	return 79
+}
+
+func (r *ShareAcknowledgeResponse) version() int16 {
+	return r.Version
+}
+
+func (r *ShareAcknowledgeResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *ShareAcknowledgeResponse) requiredVersion() KafkaVersion {
+	return V4_0_0_0
+}
@@ -0,0 +1,30 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShareAcknowledgeResponse(t *testing.T) {
+	testResponse(t, "no error", &ShareAcknowledgeResponse{
+		ThrottleTime: 10 * time.Millisecond,
+		Topics: []*ShareAcknowledgeResponseTopic{{
+			TopicID: Uuid{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+			Partitions: []*ShareAcknowledgeResponsePartition{
+				{Partition: 0},
+				{
+					Partition:     1,
+					Err:           ErrInvalidRecordState,
+					ErrorMessage:  nullString("invalid"),
+					CurrentLeader: ShareLeader{LeaderID: 2, LeaderEpoch: 3},
+				},
+			},
+		}},
+		NodeEndpoints: []*ShareNodeEndpoint{{NodeID: 2, Host: "localhost", Port: 9092, Rack: nullString("r1")}},
+	}, nil)
+
+	testResponse(t, "error", &ShareAcknowledgeResponse{
+		Err:          ErrShareSessionNotFound,
+		ErrorMessage: nullString("not found"),
+	}, nil)
+}
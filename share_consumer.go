@@ -0,0 +1,764 @@
+package sarama
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosedShareConsumer is the error returned when a method is called on a share consumer that has been closed.
+var ErrClosedShareConsumer = errors.New("kafka: tried to use a share consumer that was closed")
+
+// ShareConsumer consumes topics as a member of a share group (KIP-932). Unlike
+// the members of a consumer group, those of a share group do not own the
+// partitions they are assigned but share their records, each record being
+// acquired by the member it is delivered to until the member acknowledges it
+// or until its acquisition lock times out on the broker, after which it is
+// delivered again.
+type ShareConsumer interface {
+	// Records returns the read channel of the records acquired by the
+	// member, each of which must be acknowledged with its Accept, Release or
+	// Reject method.
+	Records() <-chan *ShareRecord
+
+	// Errors returns a read channel of errors that occurred during the consumer life-cycle.
+	// By default, errors are logged and not returned over this channel.
+	// If you want to implement any custom error handling, set your config's
+	// Consumer.Return.Errors setting to true, and read from this channel.
+	Errors() <-chan error
+
+	// Close sends the pending acknowledgements, leaves the share group and
+	// closes the Records channel. The records acquired by the member but not
+	// acknowledged are released by the brokers. It is required to call this
+	// function before the object passes out of scope, as it will otherwise
+	// leak memory.
+	Close() error
+}
+
+// ShareRecord is a record acquired by a member of a share group
+type ShareRecord struct {
+	ConsumerMessage
+	// DeliveryCount is the number of times the record has been delivered to
+	// the members of the group, including this delivery
+	DeliveryCount int16
+
+	session      *shareSession
+	partition    sharePartition
+	acknowledged int32
+}
+
+// Accept acknowledges the record as successfully processed
+func (r *ShareRecord) Accept() {
+	r.acknowledge(ShareAcknowledgeAccept)
+}
+
+// Release releases the record, which is delivered again to a member of the
+// group
+func (r *ShareRecord) Release() {
+	r.acknowledge(ShareAcknowledgeRelease)
+}
+
+// Reject rejects the record as unprocessable, it is not delivered again
+func (r *ShareRecord) Reject() {
+	r.acknowledge(ShareAcknowledgeReject)
+}
+
+// acknowledge queues the first acknowledgement of the record, sent with the
+// next request of its share session
+func (r *ShareRecord) acknowledge(ackType ShareAcknowledgeType) {
+	if !atomic.CompareAndSwapInt32(&r.acknowledged, 0, 1) {
+		return
+	}
+	r.session.acknowledge(r.partition, r.Offset, ackType)
+}
+
+type shareConsumer struct {
+	client   Client
+	conf     *Config
+	groupID  string
+	memberID string
+	topics   []string
+
+	records chan *ShareRecord
+	errors  chan error
+
+	// owned by the heartbeat loop
+	memberEpoch int32
+	assignment  map[string][]int32
+	sessions    map[int32]*shareSession
+
+	reassign  chan none
+	closing   chan none
+	closed    chan none
+	closeOnce sync.Once
+}
+
+// NewShareConsumer creates a new member of the share group consuming the
+// topics, using the given broker addresses and configuration.
+func NewShareConsumer(addrs []string, groupID string, topics []string, config *Config) (ShareConsumer, error) {
+	client, err := NewClient(addrs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newShareConsumer(groupID, topics, client)
+	if err != nil {
+		_ = client.Close()
+	}
+	return c, err
+}
+
+// NewShareConsumerFromClient creates a new member of the share group
+// consuming the topics using the given client. It is still necessary to call
+// Close() on the underlying client when shutting down this consumer.
+func NewShareConsumerFromClient(groupID string, topics []string, client Client) (ShareConsumer, error) {
+	// For clients passed in by the client, ensure we don't
+	// call Close() on it.
+	cli := &nopCloserClient{client}
+	return newShareConsumer(groupID, topics, cli)
+}
+
+func newShareConsumer(groupID string, topics []string, client Client) (ShareConsumer, error) {
+	config := client.Config()
+	if !config.Version.IsAtLeast(V4_0_0_0) {
+		return nil, ConfigurationError("share groups require Version to be >= V4_0_0_0")
+	}
+	if len(topics) == 0 {
+		return nil, ConfigurationError("no topics provided")
+	}
+
+	// the assignments identify the topics by ID
+	if err := client.RefreshMetadata(topics...); err != nil {
+		return nil, err
+	}
+
+	memberID, err := randomUuid()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &shareConsumer{
+		client:   client,
+		conf:     config,
+		groupID:  groupID,
+		memberID: memberID.String(),
+		topics:   topics,
+		records:  make(chan *ShareRecord, config.ChannelBufferSize),
+		errors:   make(chan error, config.ChannelBufferSize),
+		sessions: make(map[int32]*shareSession),
+		reassign: make(chan none, 1),
+		closing:  make(chan none),
+		closed:   make(chan none),
+	}
+	go withRecover(c.heartbeatLoop)
+	return c, nil
+}
+
+// randomUuid returns a random (version 4) Uuid
+func randomUuid() (Uuid, error) {
+	var u Uuid
+	if _, err := rand.Read(u[:]); err != nil {
+		return NullUuid, err
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return u, nil
+}
+
+// Records implements ShareConsumer.
+func (c *shareConsumer) Records() <-chan *ShareRecord { return c.records }
+
+// Errors implements ShareConsumer.
+func (c *shareConsumer) Errors() <-chan error { return c.errors }
+
+// Close implements ShareConsumer.
+func (c *shareConsumer) Close() (err error) {
+	c.closeOnce.Do(func() {
+		close(c.closing)
+		<-c.closed
+
+		close(c.records)
+
+		// drain errors
+		go func() {
+			close(c.errors)
+		}()
+		for e := range c.errors {
+			err = e
+		}
+
+		if e := c.client.Close(); e != nil {
+			err = e
+		}
+	})
+	return
+}
+
+// heartbeatLoop keeps the membership of the consumer, routing its
+// assignment to the share sessions of the leaders of the partitions
+func (c *shareConsumer) heartbeatLoop() {
+	defer close(c.closed)
+
+	subscribe := true
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-c.closing:
+			c.route(nil)
+			c.leave()
+			return
+		case <-c.reassign:
+			c.route(c.assignment)
+			continue
+		case <-timer.C:
+		}
+
+		response, err := c.heartbeat(subscribe)
+		if err == nil && !errors.Is(response.Err, ErrNoError) {
+			err = response.Err
+		}
+		if err != nil {
+			c.handleError(err, "", -1)
+			switch {
+			case errors.Is(err, ErrFencedMemberEpoch), errors.Is(err, ErrUnknownMemberId):
+				// rejoin the group, the partitions being assigned again
+				c.memberEpoch = 0
+				subscribe = true
+				c.route(nil)
+			case errors.Is(err, ErrNotCoordinatorForConsumer), errors.Is(err, ErrConsumerCoordinatorNotAvailable):
+				_ = c.client.RefreshCoordinator(c.groupID)
+			}
+			timer.Reset(c.conf.Consumer.Retry.Backoff)
+			continue
+		}
+
+		subscribe = false
+		c.memberEpoch = response.MemberEpoch
+		if response.Assignment != nil {
+			c.route(c.resolve(response.Assignment))
+		}
+		interval := response.HeartbeatInterval
+		if interval <= 0 {
+			interval = c.conf.Consumer.Group.Heartbeat.Interval
+		}
+		timer.Reset(interval)
+	}
+}
+
+func (c *shareConsumer) heartbeat(subscribe bool) (*ShareGroupHeartbeatResponse, error) {
+	coordinator, err := c.client.Coordinator(c.groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &ShareGroupHeartbeatRequest{
+		GroupID:     c.groupID,
+		MemberID:    c.memberID,
+		MemberEpoch: c.memberEpoch,
+	}
+	if subscribe {
+		request.SubscribedTopicNames = c.topics
+		if c.conf.RackID != "" {
+			rackID := c.conf.RackID
+			request.RackID = &rackID
+		}
+	}
+
+	response, err := coordinator.ShareGroupHeartbeat(request)
+	if err != nil {
+		_ = coordinator.Close()
+		_ = c.client.RefreshCoordinator(c.groupID)
+		return nil, err
+	}
+	return response, nil
+}
+
+// leave removes the member from the group once its share sessions are closed
+func (c *shareConsumer) leave() {
+	if c.memberEpoch <= 0 {
+		return
+	}
+	c.memberEpoch = -1
+	response, err := c.heartbeat(false)
+	if err == nil && !errors.Is(response.Err, ErrNoError) {
+		err = response.Err
+	}
+	if err != nil {
+		c.handleError(err, "", -1)
+	}
+}
+
+// resolve returns the assigned partitions by topic name
+func (c *shareConsumer) resolve(assignment *ShareGroupAssignment) map[string][]int32 {
+	names := make(map[Uuid]string, len(c.topics))
+	for refreshed := false; ; refreshed = true {
+		for _, topic := range c.topics {
+			if id, err := c.client.TopicID(topic); err == nil {
+				names[id] = topic
+			}
+		}
+		unknown := false
+		for _, tp := range assignment.TopicPartitions {
+			if _, ok := names[tp.TopicID]; !ok {
+				unknown = true
+			}
+		}
+		if !unknown || refreshed {
+			break
+		}
+		_ = c.client.RefreshMetadata(c.topics...)
+	}
+
+	partitions := make(map[string][]int32, len(assignment.TopicPartitions))
+	for _, tp := range assignment.TopicPartitions {
+		topic, ok := names[tp.TopicID]
+		if !ok {
+			c.handleError(ErrUnknownTopicID, tp.TopicID.String(), -1)
+			continue
+		}
+		partitions[topic] = append(partitions[topic], tp.Partitions...)
+	}
+	return partitions
+}
+
+// route distributes the assigned partitions to the share sessions of their
+// leaders, stopping the sessions left without partitions
+func (c *shareConsumer) route(assignment map[string][]int32) {
+	c.assignment = assignment
+
+	brokers := make(map[int32]*Broker)
+	partitions := make(map[int32]map[sharePartition]none)
+	for topic, assigned := range assignment {
+		topicID, err := c.client.TopicID(topic)
+		if err == nil && topicID == NullUuid {
+			err = ErrUnknownTopicID
+		}
+		if err != nil {
+			c.handleError(err, topic, -1)
+			continue
+		}
+		for _, partition := range assigned {
+			leader, err := c.client.Leader(topic, partition)
+			if err != nil {
+				c.handleError(err, topic, partition)
+				continue
+			}
+			if partitions[leader.ID()] == nil {
+				brokers[leader.ID()] = leader
+				partitions[leader.ID()] = make(map[sharePartition]none)
+			}
+			partitions[leader.ID()][sharePartition{topic: topic, topicID: topicID, partition: partition}] = none{}
+		}
+	}
+
+	for id, session := range c.sessions {
+		if _, ok := partitions[id]; !ok || session.broker != brokers[id] {
+			session.stop()
+			delete(c.sessions, id)
+		}
+	}
+	for id, assigned := range partitions {
+		session, ok := c.sessions[id]
+		if !ok {
+			session = newShareSession(c, brokers[id])
+			c.sessions[id] = session
+		}
+		session.setPartitions(assigned)
+	}
+}
+
+// refresh refreshes the metadata of the topic whose leader changed, routing
+// the assignment again
+func (c *shareConsumer) refresh(topic string) {
+	_ = c.client.RefreshMetadata(topic)
+	select {
+	case c.reassign <- none{}:
+	default:
+	}
+}
+
+func (c *shareConsumer) handleError(err error, topic string, partition int32) {
+	var consumerError *ConsumerError
+	if ok := errors.As(err, &consumerError); !ok && topic != "" {
+		err = &ConsumerError{
+			Topic:     topic,
+			Partition: partition,
+			Err:       err,
+		}
+	}
+
+	if !c.conf.Consumer.Return.Errors {
+		Logger.Println(err)
+		return
+	}
+
+	select {
+	case c.errors <- err:
+	default:
+		// no error listener
+	}
+}
+
+type sharePartition struct {
+	topic     string
+	topicID   Uuid
+	partition int32
+}
+
+type shareAcknowledgement struct {
+	offset  int64
+	ackType ShareAcknowledgeType
+}
+
+// shareSession fetches the records of the assigned partitions led by a
+// broker in a share session, and sends the acknowledgements of their records
+type shareSession struct {
+	consumer *shareConsumer
+	broker   *Broker
+
+	lock       sync.Mutex
+	partitions map[sharePartition]none
+	acks       map[sharePartition][]shareAcknowledgement
+
+	// owned by the fetch loop
+	epoch     int32
+	inSession map[sharePartition]none
+
+	stopping chan none
+	done     chan none
+}
+
+func newShareSession(c *shareConsumer, broker *Broker) *shareSession {
+	s := &shareSession{
+		consumer: c,
+		broker:   broker,
+		acks:     make(map[sharePartition][]shareAcknowledgement),
+		stopping: make(chan none),
+		done:     make(chan none),
+	}
+	go withRecover(s.fetchLoop)
+	return s
+}
+
+func (s *shareSession) setPartitions(partitions map[sharePartition]none) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.partitions = partitions
+}
+
+func (s *shareSession) acknowledge(partition sharePartition, offset int64, ackType ShareAcknowledgeType) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.acks[partition] = append(s.acks[partition], shareAcknowledgement{offset: offset, ackType: ackType})
+}
+
+// stop closes the share session, sending its pending acknowledgements
+func (s *shareSession) stop() {
+	close(s.stopping)
+	<-s.done
+}
+
+func (s *shareSession) fetchLoop() {
+	defer close(s.done)
+
+	conf := s.consumer.conf
+	for {
+		select {
+		case <-s.stopping:
+			s.close()
+			return
+		default:
+		}
+
+		request, added, forgotten := s.fetchRequest()
+		response, err := s.broker.ShareFetch(request)
+		if err == nil && !errors.Is(response.Err, ErrNoError) {
+			err = response.Err
+		}
+		if err != nil {
+			s.consumer.handleError(err, "", -1)
+			// open a new session, the acknowledgements of the request
+			// being lost their records are delivered again
+			s.epoch = 0
+			s.inSession = nil
+			select {
+			case <-s.stopping:
+			case <-time.After(conf.Consumer.Retry.Backoff):
+			}
+			continue
+		}
+
+		if s.epoch == math.MaxInt32 {
+			s.epoch = 1
+		} else {
+			s.epoch++
+		}
+		if s.inSession == nil {
+			s.inSession = make(map[sharePartition]none)
+		}
+		for _, p := range added {
+			s.inSession[p] = none{}
+		}
+		for _, p := range forgotten {
+			delete(s.inSession, p)
+		}
+
+		records := s.parseResponse(response)
+		for i, record := range records {
+			select {
+			case s.consumer.records <- record:
+			case <-s.stopping:
+				for _, undelivered := range records[i:] {
+					undelivered.Release()
+				}
+				s.close()
+				return
+			}
+		}
+	}
+}
+
+// fetchRequest returns the next ShareFetchRequest of the session, with the
+// partitions it adds to the session and those it removes from it
+func (s *shareSession) fetchRequest() (request *ShareFetchRequest, added, forgotten []sharePartition) {
+	conf := s.consumer.conf
+	request = &ShareFetchRequest{
+		GroupID:           s.consumer.groupID,
+		MemberID:          s.consumer.memberID,
+		ShareSessionEpoch: s.epoch,
+		MaxWaitTime:       int32(conf.Consumer.MaxWaitTime / time.Millisecond),
+		MinBytes:          conf.Consumer.Fetch.Min,
+		MaxBytes:          conf.Consumer.Fetch.Max,
+	}
+	if request.MaxBytes == 0 {
+		request.MaxBytes = MaxResponseSize
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for p := range s.partitions {
+		if _, ok := s.inSession[p]; !ok {
+			added = append(added, p)
+		}
+	}
+	for p := range s.inSession {
+		if _, ok := s.partitions[p]; !ok && len(s.acks[p]) == 0 {
+			forgotten = append(forgotten, p)
+		}
+	}
+
+	// the partitions with acknowledgements are sent again, which keeps
+	// those no longer assigned in the session until they are sent
+	for _, p := range added {
+		request.AddPartition(p.topicID, p.partition, conf.Consumer.Fetch.Default)
+	}
+	for p, acks := range s.acks {
+		if _, ok := s.inSession[p]; !ok && !containsSharePartition(added, p) {
+			// not in the session anymore, the records are delivered again
+			continue
+		}
+		partition := request.AddPartition(p.topicID, p.partition, conf.Consumer.Fetch.Default)
+		partition.AcknowledgementBatches = shareAcknowledgementBatches(acks)
+	}
+	s.acks = make(map[sharePartition][]shareAcknowledgement)
+
+	for _, p := range forgotten {
+		var topic *ShareForgottenTopic
+		for _, t := range request.ForgottenTopics {
+			if t.TopicID == p.topicID {
+				topic = t
+			}
+		}
+		if topic == nil {
+			topic = &ShareForgottenTopic{TopicID: p.topicID}
+			request.ForgottenTopics = append(request.ForgottenTopics, topic)
+		}
+		topic.Partitions = append(topic.Partitions, p.partition)
+	}
+
+	return request, added, forgotten
+}
+
+func containsSharePartition(partitions []sharePartition, p sharePartition) bool {
+	for _, partition := range partitions {
+		if partition == p {
+			return true
+		}
+	}
+	return false
+}
+
+// shareAcknowledgementBatches returns the acknowledgements by ranges of
+// consecutive offsets acknowledged the same way
+func shareAcknowledgementBatches(acks []shareAcknowledgement) []*ShareAcknowledgementBatch {
+	sort.Slice(acks, func(i, j int) bool { return acks[i].offset < acks[j].offset })
+
+	var batches []*ShareAcknowledgementBatch
+	var last *ShareAcknowledgementBatch
+	for _, ack := range acks {
+		if last != nil && ack.offset == last.LastOffset+1 && ack.ackType == last.AcknowledgeTypes[0] {
+			last.LastOffset = ack.offset
+			continue
+		}
+		last = &ShareAcknowledgementBatch{
+			FirstOffset:      ack.offset,
+			LastOffset:       ack.offset,
+			AcknowledgeTypes: []ShareAcknowledgeType{ack.ackType},
+		}
+		batches = append(batches, last)
+	}
+	return batches
+}
+
+// parseResponse returns the records acquired from the partitions of the
+// response, acknowledging the acquired offsets without record as gaps
+func (s *shareSession) parseResponse(response *ShareFetchResponse) []*ShareRecord {
+	s.lock.Lock()
+	partitions := make(map[Uuid]map[int32]sharePartition)
+	for p := range s.inSession {
+		if partitions[p.topicID] == nil {
+			partitions[p.topicID] = make(map[int32]sharePartition)
+		}
+		partitions[p.topicID][p.partition] = p
+	}
+	s.lock.Unlock()
+
+	var records []*ShareRecord
+	for _, topic := range response.Topics {
+		for _, block := range topic.Partitions {
+			p, ok := partitions[topic.TopicID][block.Partition]
+			if !ok {
+				continue
+			}
+			if !errors.Is(block.AcknowledgeErr, ErrNoError) {
+				s.consumer.handleError(block.AcknowledgeErr, p.topic, p.partition)
+			}
+			if !errors.Is(block.Err, ErrNoError) {
+				s.consumer.handleError(block.Err, p.topic, p.partition)
+				switch {
+				case errors.Is(block.Err, ErrNotLeaderForPartition),
+					errors.Is(block.Err, ErrLeaderNotAvailable),
+					errors.Is(block.Err, ErrUnknownTopicOrPartition),
+					errors.Is(block.Err, ErrFencedLeaderEpoch):
+					s.consumer.refresh(p.topic)
+				}
+				continue
+			}
+			records = append(records, s.parseRecords(p, block)...)
+		}
+	}
+	return records
+}
+
+func (s *shareSession) parseRecords(p sharePartition, block *ShareFetchResponsePartition) []*ShareRecord {
+	var records []*ShareRecord
+	delivered := make(map[int64]bool)
+	for _, set := range block.RecordsSet {
+		batch := set.RecordBatch
+		if batch == nil || batch.Control {
+			continue
+		}
+		for _, rec := range batch.Records {
+			offset := batch.FirstOffset + rec.OffsetDelta
+			deliveryCount, acquired := shareDeliveryCount(block.AcquiredRecords, offset)
+			if !acquired {
+				continue
+			}
+			timestamp := batch.FirstTimestamp.Add(rec.TimestampDelta)
+			if batch.LogAppendTime {
+				timestamp = batch.MaxTimestamp
+			}
+			records = append(records, &ShareRecord{
+				ConsumerMessage: ConsumerMessage{
+					Topic:     p.topic,
+					Partition: p.partition,
+					Key:       rec.Key,
+					Value:     rec.Value,
+					Offset:    offset,
+					Timestamp: timestamp,
+					Headers:   rec.Headers,
+				},
+				DeliveryCount: deliveryCount,
+				session:       s,
+				partition:     p,
+			})
+			delivered[offset] = true
+		}
+	}
+
+	for _, acquired := range block.AcquiredRecords {
+		for offset := acquired.FirstOffset; offset <= acquired.LastOffset; offset++ {
+			if !delivered[offset] {
+				s.acknowledge(p, offset, ShareAcknowledgeGap)
+			}
+		}
+	}
+	return records
+}
+
+func shareDeliveryCount(acquired []*ShareAcquiredRecords, offset int64) (int16, bool) {
+	for _, a := range acquired {
+		if offset >= a.FirstOffset && offset <= a.LastOffset {
+			return a.DeliveryCount, true
+		}
+	}
+	return 0, false
+}
+
+// close closes the share session with its pending acknowledgements
+func (s *shareSession) close() {
+	if s.epoch == 0 {
+		// no session opened
+		return
+	}
+
+	request := &ShareAcknowledgeRequest{
+		GroupID:           s.consumer.groupID,
+		MemberID:          s.consumer.memberID,
+		ShareSessionEpoch: -1,
+	}
+	s.lock.Lock()
+	for p, acks := range s.acks {
+		if _, ok := s.inSession[p]; !ok {
+			continue
+		}
+		var topic *ShareAcknowledgeTopic
+		for _, t := range request.Topics {
+			if t.TopicID == p.topicID {
+				topic = t
+			}
+		}
+		if topic == nil {
+			topic = &ShareAcknowledgeTopic{TopicID: p.topicID}
+			request.Topics = append(request.Topics, topic)
+		}
+		topic.Partitions = append(topic.Partitions, &ShareAcknowledgePartition{
+			Partition:              p.partition,
+			AcknowledgementBatches: shareAcknowledgementBatches(acks),
+		})
+	}
+	s.acks = make(map[sharePartition][]shareAcknowledgement)
+	s.lock.Unlock()
+
+	response, err := s.broker.ShareAcknowledge(request)
+	if err == nil && !errors.Is(response.Err, ErrNoError) {
+		err = response.Err
+	}
+	if err != nil {
+		s.consumer.handleError(err, "", -1)
+		return
+	}
+	for _, topic := range response.Topics {
+		for _, partition := range topic.Partitions {
+			if !errors.Is(partition.Err, ErrNoError) {
+				s.consumer.handleError(partition.Err, topic.TopicID.String(), partition.Partition)
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShareConsumerRequiresVersion(t *testing.T) {
+	config := NewTestConfig()
+	config.Version = V3_9_0_0
+	if _, err := NewShareConsumer([]string{"localhost:9092"}, "group", []string{"my_topic"}, config); err == nil {
+		t.Error("Expected a configuration error")
+	}
+}
+
+func TestShareConsumerAcknowledges(t *testing.T) {
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	topicID := Uuid{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	records := &ShareFetchResponse{
+		Topics: []*ShareFetchResponseTopic{{
+			TopicID: topicID,
+			Partitions: []*ShareFetchResponsePartition{{
+				Partition:  0,
+				RecordsSet: newShareFetchRecords(10, "foo", "bar", "baz"),
+				// baz was acquired by another member
+				AcquiredRecords: []*ShareAcquiredRecords{
+					{FirstOffset: 10, LastOffset: 10, DeliveryCount: 1},
+					{FirstOffset: 11, LastOffset: 11, DeliveryCount: 3},
+				},
+			}},
+		}},
+	}
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()).
+			SetTopicID("my_topic", topicID),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "my_group", broker0),
+		"ShareGroupHeartbeatRequest": NewMockWrapper(&ShareGroupHeartbeatResponse{
+			MemberEpoch:       1,
+			HeartbeatInterval: time.Second,
+			Assignment: &ShareGroupAssignment{
+				TopicPartitions: []*ShareGroupTopicPartitions{{TopicID: topicID, Partitions: []int32{0}}},
+			},
+		}),
+		"ShareFetchRequest":       NewMockSequence(records, &ShareFetchResponse{}),
+		"ShareAcknowledgeRequest": NewMockWrapper(&ShareAcknowledgeResponse{}),
+	})
+
+	config := NewTestConfig()
+	config.Version = V4_0_0_0
+	config.Consumer.MaxWaitTime = 10 * time.Millisecond
+	consumer, err := NewShareConsumer([]string{broker0.Addr()}, "my_group", []string{"my_topic"}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []struct {
+		value         string
+		deliveryCount int16
+	}{{"foo", 1}, {"bar", 3}} {
+		select {
+		case record := <-consumer.Records():
+			if string(record.Value) != expected.value || record.DeliveryCount != expected.deliveryCount ||
+				record.Topic != "my_topic" || record.Partition != 0 {
+				t.Errorf("Unexpected record %+v", record)
+			}
+			if record.Offset == 10 {
+				record.Accept()
+			} else {
+				record.Release()
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for records")
+		}
+	}
+
+	if err := consumer.Close(); err != nil {
+		t.Error(err)
+	}
+
+	acknowledged := make(map[int64]ShareAcknowledgeType)
+	var joined, left bool
+	for _, rr := range broker0.History() {
+		switch req := rr.Request.(type) {
+		case *ShareGroupHeartbeatRequest:
+			joined = joined || (req.MemberEpoch == 0 && len(req.SubscribedTopicNames) == 1)
+			left = left || req.MemberEpoch == -1
+		case *ShareFetchRequest:
+			for _, topic := range req.Topics {
+				for _, partition := range topic.Partitions {
+					for _, batch := range partition.AcknowledgementBatches {
+						acknowledged[batch.FirstOffset] = batch.AcknowledgeTypes[0]
+					}
+				}
+			}
+		case *ShareAcknowledgeRequest:
+			for _, topic := range req.Topics {
+				for _, partition := range topic.Partitions {
+					for _, batch := range partition.AcknowledgementBatches {
+						acknowledged[batch.FirstOffset] = batch.AcknowledgeTypes[0]
+					}
+				}
+			}
+		}
+	}
+	if !joined || !left {
+		t.Errorf("Expected the member to join and leave the group, joined %v left %v", joined, left)
+	}
+	if acknowledged[10] != ShareAcknowledgeAccept || acknowledged[11] != ShareAcknowledgeRelease {
+		t.Errorf("Unexpected acknowledgements %v", acknowledged)
+	}
+}
+
+func TestShareConsumerReturnsErrors(t *testing.T) {
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "my_group", broker0),
+		"ShareGroupHeartbeatRequest": NewMockWrapper(&ShareGroupHeartbeatResponse{
+			Err: ErrGroupAuthorizationFailed,
+		}),
+	})
+
+	config := NewTestConfig()
+	config.Version = V4_0_0_0
+	config.Consumer.Return.Errors = true
+	consumer, err := NewShareConsumer([]string{broker0.Addr()}, "my_group", []string{"my_topic"}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-consumer.Errors():
+		if !errors.Is(err, ErrGroupAuthorizationFailed) {
+			t.Errorf("Unexpected error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the error")
+	}
+	_ = consumer.Close()
+}
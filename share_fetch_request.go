@@ -0,0 +1,316 @@
+package sarama
+
+// ShareAcknowledgeType is the way a share group member acknowledges the
+// delivery of a record (KIP-932)
+type ShareAcknowledgeType int8
+
+const (
+	// ShareAcknowledgeGap marks an offset without record, such as a control
+	// record or a compacted one
+	ShareAcknowledgeGap ShareAcknowledgeType = iota
+	// ShareAcknowledgeAccept acknowledges the record as processed
+	ShareAcknowledgeAccept
+	// ShareAcknowledgeRelease releases the record, to be delivered again
+	ShareAcknowledgeRelease
+	// ShareAcknowledgeReject rejects the record as unprocessable, it is not
+	// delivered again
+	ShareAcknowledgeReject
+)
+
+// ShareAcknowledgementBatch acknowledges the records from FirstOffset to
+// LastOffset, with either a single type for all of them or one per offset
+type ShareAcknowledgementBatch struct {
+	FirstOffset      int64
+	LastOffset       int64
+	AcknowledgeTypes []ShareAcknowledgeType
+}
+
+func (b *ShareAcknowledgementBatch) encode(pe packetEncoder) error {
+	pe.putInt64(b.FirstOffset)
+	pe.putInt64(b.LastOffset)
+	pe.putCompactArrayLength(len(b.AcknowledgeTypes))
+	for _, t := range b.AcknowledgeTypes {
+		pe.putInt8(int8(t))
+	}
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (b *ShareAcknowledgementBatch) decode(pd packetDecoder) (err error) {
+	if b.FirstOffset, err = pd.getInt64(); err != nil {
+		return err
+	}
+	if b.LastOffset, err = pd.getInt64(); err != nil {
+		return err
+	}
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		b.AcknowledgeTypes = make([]ShareAcknowledgeType, n)
+	}
+	for i := 0; i < n; i++ {
+		t, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		b.AcknowledgeTypes[i] = ShareAcknowledgeType(t)
+	}
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func putShareAcknowledgementBatches(pe packetEncoder, batches []*ShareAcknowledgementBatch) error {
+	pe.putCompactArrayLength(len(batches))
+	for _, b := range batches {
+		if err := b.encode(pe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getShareAcknowledgementBatches(pd packetDecoder) ([]*ShareAcknowledgementBatch, error) {
+	n, err := pd.getCompactArrayLength()
+	if err != nil || n <= 0 {
+		return nil, err
+	}
+	batches := make([]*ShareAcknowledgementBatch, n)
+	for i := range batches {
+		batches[i] = &ShareAcknowledgementBatch{}
+		if err := batches[i].decode(pd); err != nil {
+			return nil, err
+		}
+	}
+	return batches, nil
+}
+
+// ShareFetchRequest fetches records for a share group member, acquiring
+// them until they are acknowledged, and acknowledges the records delivered
+// by the previous requests of the share session
+type ShareFetchRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	GroupID  string
+	MemberID string
+	// ShareSessionEpoch is 0 to open a share session, -1 to close it and
+	// otherwise incremented by each request of the session
+	ShareSessionEpoch int32
+	// MaxWaitTime is the maximum time in milliseconds to wait for MinBytes
+	MaxWaitTime int32
+	MinBytes    int32
+	MaxBytes    int32
+	// Topics are the partitions to add to the share session or to
+	// acknowledge records of
+	Topics []*ShareFetchTopic
+	// ForgottenTopics are the partitions to remove from the share session
+	ForgottenTopics []*ShareForgottenTopic
+}
+
+// ShareFetchTopic are the partitions of a topic of a ShareFetchRequest
+type ShareFetchTopic struct {
+	TopicID    Uuid
+	Partitions []*ShareFetchPartition
+}
+
+// ShareFetchPartition is a partition of a ShareFetchRequest
+type ShareFetchPartition struct {
+	Partition              int32
+	PartitionMaxBytes      int32
+	AcknowledgementBatches []*ShareAcknowledgementBatch
+}
+
+// ShareForgottenTopic are partitions of a topic to remove from a share
+// session
+type ShareForgottenTopic struct {
+	TopicID    Uuid
+	Partitions []int32
+}
+
+func (r *ShareFetchRequest) encode(pe packetEncoder) error {
+	if err := pe.putNullableCompactString(&r.GroupID); err != nil {
+		return err
+	}
+	if err := pe.putNullableCompactString(&r.MemberID); err != nil {
+		return err
+	}
+	pe.putInt32(r.ShareSessionEpoch)
+	pe.putInt32(r.MaxWaitTime)
+	pe.putInt32(r.MinBytes)
+	pe.putInt32(r.MaxBytes)
+
+	pe.putCompactArrayLength(len(r.Topics))
+	for _, topic := range r.Topics {
+		if err := putUuid(pe, topic.TopicID); err != nil {
+			return err
+		}
+		pe.putCompactArrayLength(len(topic.Partitions))
+		for _, partition := range topic.Partitions {
+			pe.putInt32(partition.Partition)
+			pe.putInt32(partition.PartitionMaxBytes)
+			if err := putShareAcknowledgementBatches(pe, partition.AcknowledgementBatches); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putCompactArrayLength(len(r.ForgottenTopics))
+	for _, topic := range r.ForgottenTopics {
+		if err := putUuid(pe, topic.TopicID); err != nil {
+			return err
+		}
+		if err := pe.putCompactInt32Array(topic.Partitions); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ShareFetchRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.GroupID, r.MemberID, err = getShareGroupMember(pd); err != nil {
+		return err
+	}
+	if r.ShareSessionEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.MaxWaitTime, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.MinBytes, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.MaxBytes, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.Topics = make([]*ShareFetchTopic, n)
+	}
+	for i := 0; i < n; i++ {
+		topic := &ShareFetchTopic{}
+		if topic.TopicID, err = getUuid(pd); err != nil {
+			return err
+		}
+		numPartitions, err := pd.getCompactArrayLength()
+		if err != nil {
+			return err
+		}
+		if numPartitions > 0 {
+			topic.Partitions = make([]*ShareFetchPartition, numPartitions)
+		}
+		for j := 0; j < numPartitions; j++ {
+			partition := &ShareFetchPartition{}
+			if partition.Partition, err = pd.getInt32(); err != nil {
+				return err
+			}
+			if partition.PartitionMaxBytes, err = pd.getInt32(); err != nil {
+				return err
+			}
+			if partition.AcknowledgementBatches, err = getShareAcknowledgementBatches(pd); err != nil {
+				return err
+			}
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			topic.Partitions[j] = partition
+		}
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+		r.Topics[i] = topic
+	}
+
+	if n, err = pd.getCompactArrayLength(); err != nil {
+		return err
+	}
+	if n > 0 {
+		r.ForgottenTopics = make([]*ShareForgottenTopic, n)
+	}
+	for i := 0; i < n; i++ {
+		topic := &ShareForgottenTopic{}
+		if topic.TopicID, err = getUuid(pd); err != nil {
+			return err
+		}
+		if topic.Partitions, err = pd.getCompactInt32Array(); err != nil {
+			return err
+		}
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+		r.ForgottenTopics[i] = topic
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+// getShareGroupMember decodes the nullable group and member IDs of the
+// share session requests
+func getShareGroupMember(pd packetDecoder) (groupID, memberID string, err error) {
+	group, err := pd.getCompactNullableString()
+	if err != nil {
+		return "", "", err
+	}
+	member, err := pd.getCompactNullableString()
+	if err != nil {
+		return "", "", err
+	}
+	if group != nil {
+		groupID = *group
+	}
+	if member != nil {
+		memberID = *member
+	}
+	return groupID, memberID, nil
+}
+
+// AddPartition adds a partition to fetch from to the request
+func (r *ShareFetchRequest) AddPartition(topicID Uuid, partition int32, maxBytes int32) *ShareFetchPartition {
+	var topic *ShareFetchTopic
+	for _, t := range r.Topics {
+		if t.TopicID == topicID {
+			topic = t
+		}
+	}
+	if topic == nil {
+		topic = &ShareFetchTopic{TopicID: topicID}
+		r.Topics = append(r.Topics, topic)
+	}
+	for _, p := range topic.Partitions {
+		if p.Partition == partition {
+			return p
+		}
+	}
+	p := &ShareFetchPartition{Partition: partition, PartitionMaxBytes: maxBytes}
+	topic.Partitions = append(topic.Partitions, p)
+	return p
+}
+
+func (r *ShareFetchRequest) key() int16 {
+	return 78
+}
+
+func (r *ShareFetchRequest) version() int16 {
+	return r.Version
+}
+
+func (r *ShareFetchRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *ShareFetchRequest) requiredVersion() KafkaVersion {
+	return V4_0_0_0
+}
@@ -0,0 +1,56 @@
+package sarama
+
+import "testing"
+
+var shareFetchRequestOpen = []byte{
+	0x02, 'g', // GroupID
+	0x02, 'm', // MemberID
+	0x00, 0x00, 0x00, 0x00, // ShareSessionEpoch
+	0x00, 0x00, 0x01, 0xf4, // MaxWaitTime
+	0x00, 0x00, 0x00, 0x01, // MinBytes
+	0x00, 0x10, 0x00, 0x00, // MaxBytes
+	0x02,                                                                                           // Topics
+	0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, // TopicID
+	0x02,                   // Partitions
+	0x00, 0x00, 0x00, 0x00, // Partition
+	0x00, 0x01, 0x00, 0x00, // PartitionMaxBytes
+	0x01, // AcknowledgementBatches
+	0x00, // empty tagged fields
+	0x00, // empty tagged fields
+	0x01, // ForgottenTopics
+	0x00, // empty tagged fields
+}
+
+func TestShareFetchRequest(t *testing.T) {
+	request := &ShareFetchRequest{
+		GroupID:     "g",
+		MemberID:    "m",
+		MaxWaitTime: 500,
+		MinBytes:    1,
+		MaxBytes:    1 << 20,
+	}
+	topicID := Uuid{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	request.AddPartition(topicID, 0, 1<<16)
+	testRequest(t, "open session", request, shareFetchRequestOpen)
+
+	testRequest(t, "acknowledgements", &ShareFetchRequest{
+		GroupID:           "g",
+		MemberID:          "m",
+		ShareSessionEpoch: 3,
+		MaxWaitTime:       500,
+		MinBytes:          1,
+		MaxBytes:          1 << 20,
+		Topics: []*ShareFetchTopic{{
+			TopicID: topicID,
+			Partitions: []*ShareFetchPartition{{
+				Partition:         1,
+				PartitionMaxBytes: 1 << 16,
+				AcknowledgementBatches: []*ShareAcknowledgementBatch{
+					{FirstOffset: 0, LastOffset: 1, AcknowledgeTypes: []ShareAcknowledgeType{ShareAcknowledgeAccept, ShareAcknowledgeRelease}},
+					{FirstOffset: 5, LastOffset: 5, AcknowledgeTypes: []ShareAcknowledgeType{ShareAcknowledgeGap}},
+				},
+			}},
+		}},
+		ForgottenTopics: []*ShareForgottenTopic{{TopicID: topicID, Partitions: []int32{0}}},
+	}, nil)
+}
@@ -0,0 +1,355 @@
+package sarama
+
+import (
+	"errors"
+	"time"
+)
+
+// ShareFetchResponse is the response to a ShareFetchRequest
+type ShareFetchResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime  time.Duration
+	Err           KError
+	ErrorMessage  *string
+	Topics        []*ShareFetchResponseTopic
+	NodeEndpoints []*ShareNodeEndpoint
+}
+
+// ShareFetchResponseTopic are the partitions of a topic of a
+// ShareFetchResponse
+type ShareFetchResponseTopic struct {
+	TopicID    Uuid
+	Partitions []*ShareFetchResponsePartition
+}
+
+// ShareFetchResponsePartition are the records fetched from a partition and
+// the result of the acknowledgements of its records
+type ShareFetchResponsePartition struct {
+	Partition               int32
+	Err                     KError
+	ErrorMessage            *string
+	AcknowledgeErr          KError
+	AcknowledgeErrorMessage *string
+	CurrentLeader           ShareLeader
+	RecordsSet              []*Records
+	// AcquiredRecords are the ranges of offsets of the records acquired by
+	// the member
+	AcquiredRecords []*ShareAcquiredRecords
+}
+
+// ShareAcquiredRecords are the records from FirstOffset to LastOffset
+// acquired by a share group member, delivered DeliveryCount times so far
+type ShareAcquiredRecords struct {
+	FirstOffset   int64
+	LastOffset    int64
+	DeliveryCount int16
+}
+
+// ShareLeader is the current leader of a partition, returned when the
+// request was not sent to it
+type ShareLeader struct {
+	LeaderID    int32
+	LeaderEpoch int32
+}
+
+// ShareNodeEndpoint is a broker referenced by a ShareLeader
+type ShareNodeEndpoint struct {
+	NodeID int32
+	Host   string
+	Port   int32
+	Rack   *string
+}
+
+func (l *ShareLeader) encode(pe packetEncoder) {
+	pe.putInt32(l.LeaderID)
+	pe.putInt32(l.LeaderEpoch)
+	pe.putEmptyTaggedFieldArray()
+}
+
+func (l *ShareLeader) decode(pd packetDecoder) (err error) {
+	if l.LeaderID, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if l.LeaderEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func putShareNodeEndpoints(pe packetEncoder, endpoints []*ShareNodeEndpoint) error {
+	pe.putCompactArrayLength(len(endpoints))
+	for _, e := range endpoints {
+		pe.putInt32(e.NodeID)
+		if err := pe.putCompactString(e.Host); err != nil {
+			return err
+		}
+		pe.putInt32(e.Port)
+		if err := pe.putNullableCompactString(e.Rack); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+	return nil
+}
+
+func getShareNodeEndpoints(pd packetDecoder) ([]*ShareNodeEndpoint, error) {
+	n, err := pd.getCompactArrayLength()
+	if err != nil || n <= 0 {
+		return nil, err
+	}
+	endpoints := make([]*ShareNodeEndpoint, n)
+	for i := range endpoints {
+		e := &ShareNodeEndpoint{}
+		if e.NodeID, err = pd.getInt32(); err != nil {
+			return nil, err
+		}
+		if e.Host, err = pd.getCompactString(); err != nil {
+			return nil, err
+		}
+		if e.Port, err = pd.getInt32(); err != nil {
+			return nil, err
+		}
+		if e.Rack, err = pd.getCompactNullableString(); err != nil {
+			return nil, err
+		}
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return nil, err
+		}
+		endpoints[i] = e
+	}
+	return endpoints, nil
+}
+
+func (r *ShareFetchResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	pe.putInt16(int16(r.Err))
+	if err := pe.putNullableCompactString(r.ErrorMessage); err != nil {
+		return err
+	}
+
+	pe.putCompactArrayLength(len(r.Topics))
+	for _, topic := range r.Topics {
+		if err := putUuid(pe, topic.TopicID); err != nil {
+			return err
+		}
+		pe.putCompactArrayLength(len(topic.Partitions))
+		for _, partition := range topic.Partitions {
+			if err := partition.encode(pe); err != nil {
+				return err
+			}
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	if err := putShareNodeEndpoints(pe, r.NodeEndpoints); err != nil {
+		return err
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ShareFetchResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	if r.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.Topics = make([]*ShareFetchResponseTopic, n)
+	}
+	for i := 0; i < n; i++ {
+		topic := &ShareFetchResponseTopic{}
+		if topic.TopicID, err = getUuid(pd); err != nil {
+			return err
+		}
+		numPartitions, err := pd.getCompactArrayLength()
+		if err != nil {
+			return err
+		}
+		if numPartitions > 0 {
+			topic.Partitions = make([]*ShareFetchResponsePartition, numPartitions)
+		}
+		for j := 0; j < numPartitions; j++ {
+			topic.Partitions[j] = &ShareFetchResponsePartition{}
+			if err := topic.Partitions[j].decode(pd); err != nil {
+				return err
+			}
+		}
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+		r.Topics[i] = topic
+	}
+
+	if r.NodeEndpoints, err = getShareNodeEndpoints(pd); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (p *ShareFetchResponsePartition) encode(pe packetEncoder) error {
+	pe.putInt32(p.Partition)
+	pe.putInt16(int16(p.Err))
+	if err := pe.putNullableCompactString(p.ErrorMessage); err != nil {
+		return err
+	}
+	pe.putInt16(int16(p.AcknowledgeErr))
+	if err := pe.putNullableCompactString(p.AcknowledgeErrorMessage); err != nil {
+		return err
+	}
+	p.CurrentLeader.encode(pe)
+
+	var raw []byte
+	for _, records := range p.RecordsSet {
+		buf, err := encode(records, pe.metricsRecorder())
+		if err != nil {
+			return err
+		}
+		raw = append(raw, buf...)
+	}
+	if err := pe.putCompactBytes(raw); err != nil {
+		return err
+	}
+
+	pe.putCompactArrayLength(len(p.AcquiredRecords))
+	for _, acquired := range p.AcquiredRecords {
+		pe.putInt64(acquired.FirstOffset)
+		pe.putInt64(acquired.LastOffset)
+		pe.putInt16(acquired.DeliveryCount)
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (p *ShareFetchResponsePartition) decode(pd packetDecoder) (err error) {
+	if p.Partition, err = pd.getInt32(); err != nil {
+		return err
+	}
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	p.Err = KError(kerr)
+	if p.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if kerr, err = pd.getInt16(); err != nil {
+		return err
+	}
+	p.AcknowledgeErr = KError(kerr)
+	if p.AcknowledgeErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if err := p.CurrentLeader.decode(pd); err != nil {
+		return err
+	}
+
+	// compact nullable records, 0 being null
+	size, err := pd.getUVarint()
+	if err != nil {
+		return err
+	}
+	var recordsSize int
+	if size > 0 {
+		recordsSize = int(size - 1)
+	}
+	recordsDecoder, err := pd.getSubset(recordsSize)
+	if err != nil {
+		return err
+	}
+	for recordsDecoder.remaining() > 0 {
+		records := &Records{}
+		if err := records.decode(recordsDecoder); err != nil {
+			// the records acquired are complete batches, a trailing
+			// partial batch is not acquired
+			if errors.Is(err, ErrInsufficientData) {
+				break
+			}
+			return err
+		}
+		p.RecordsSet = append(p.RecordsSet, records)
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		p.AcquiredRecords = make([]*ShareAcquiredRecords, n)
+	}
+	for i := 0; i < n; i++ {
+		acquired := &ShareAcquiredRecords{}
+		if acquired.FirstOffset, err = pd.getInt64(); err != nil {
+			return err
+		}
+		if acquired.LastOffset, err = pd.getInt64(); err != nil {
+			return err
+		}
+		if acquired.DeliveryCount, err = pd.getInt16(); err != nil {
+			return err
+		}
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+		p.AcquiredRecords[i] = acquired
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+// GetPartition returns the partition of the response, nil if it has none
+func (r *ShareFetchResponse) GetPartition(topicID Uuid, partition int32) *ShareFetchResponsePartition {
+	for _, topic := range r.Topics {
+		if topic.TopicID != topicID {
+			continue
+		}
+		for _, p := range topic.Partitions {
+			if p.Partition == partition {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+func (r *ShareFetchResponse) key() int16 {
+	return 78
+}
+
+func (r *ShareFetchResponse) version() int16 {
+	return r.Version
+}
+
+func (r *ShareFetchResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *ShareFetchResponse) requiredVersion() KafkaVersion {
+	return V4_0_0_0
+}
@@ -0,0 +1,90 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newShareFetchRecords(firstOffset int64, values ...string) []*Records {
+	batch := &RecordBatch{
+		Version:        2,
+		FirstOffset:    firstOffset,
+		FirstTimestamp: time.Unix(1600000000, 0),
+		MaxTimestamp:   time.Unix(1600000000, 0),
+		ProducerID:     -1,
+		ProducerEpoch:  -1,
+		FirstSequence:  -1,
+	}
+	for i, value := range values {
+		batch.addRecord(&Record{OffsetDelta: int64(i), Value: []byte(value)})
+	}
+	batch.LastOffsetDelta = int32(len(values) - 1)
+	records := newDefaultRecords(batch)
+	return []*Records{&records}
+}
+
+func TestShareFetchResponse(t *testing.T) {
+	topicID := Uuid{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	response := &ShareFetchResponse{
+		ThrottleTime: 10 * time.Millisecond,
+		Topics: []*ShareFetchResponseTopic{{
+			TopicID: topicID,
+			Partitions: []*ShareFetchResponsePartition{
+				{
+					Partition:       0,
+					RecordsSet:      newShareFetchRecords(5, "foo", "bar", "baz"),
+					AcquiredRecords: []*ShareAcquiredRecords{{FirstOffset: 5, LastOffset: 6, DeliveryCount: 2}},
+				},
+				{
+					Partition:      1,
+					Err:            ErrNotLeaderForPartition,
+					AcknowledgeErr: ErrInvalidRecordState,
+					CurrentLeader:  ShareLeader{LeaderID: 2, LeaderEpoch: 4},
+				},
+			},
+		}},
+		NodeEndpoints: []*ShareNodeEndpoint{{NodeID: 2, Host: "localhost", Port: 9092}},
+	}
+
+	buf, err := encode(response, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &ShareFetchResponse{}
+	if err := versionedDecode(buf, decoded, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.ThrottleTime != 10*time.Millisecond {
+		t.Errorf("unexpected throttle time %v", decoded.ThrottleTime)
+	}
+	partition := decoded.GetPartition(topicID, 0)
+	if partition == nil {
+		t.Fatal("missing partition 0")
+	}
+	if len(partition.RecordsSet) != 1 || len(partition.RecordsSet[0].RecordBatch.Records) != 3 {
+		t.Fatalf("unexpected records %+v", partition.RecordsSet)
+	}
+	if batch := partition.RecordsSet[0].RecordBatch; batch.FirstOffset != 5 || string(batch.Records[2].Value) != "baz" {
+		t.Errorf("unexpected record batch %+v", batch)
+	}
+	if len(partition.AcquiredRecords) != 1 || *partition.AcquiredRecords[0] != (ShareAcquiredRecords{FirstOffset: 5, LastOffset: 6, DeliveryCount: 2}) {
+		t.Errorf("unexpected acquired records %+v", partition.AcquiredRecords)
+	}
+
+	partition = decoded.GetPartition(topicID, 1)
+	if partition == nil {
+		t.Fatal("missing partition 1")
+	}
+	if !errors.Is(partition.Err, ErrNotLeaderForPartition) || !errors.Is(partition.AcknowledgeErr, ErrInvalidRecordState) ||
+		partition.CurrentLeader != (ShareLeader{LeaderID: 2, LeaderEpoch: 4}) || partition.RecordsSet != nil {
+		t.Errorf("unexpected partition %+v", partition)
+	}
+	if len(decoded.NodeEndpoints) != 1 || decoded.NodeEndpoints[0].Host != "localhost" {
+		t.Errorf("unexpected node endpoints %+v", decoded.NodeEndpoints)
+	}
+	if decoded.GetPartition(topicID, 2) != nil {
+		t.Error("unexpected partition 2")
+	}
+}
@@ -0,0 +1,91 @@
+package sarama
+
+// ShareGroupHeartbeatRequest is sent by the members of a share group
+// (KIP-932) to join the group, keep their membership and receive their
+// assignment
+type ShareGroupHeartbeatRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	GroupID string
+	// MemberID is generated by the member and kept for its whole lifetime
+	MemberID string
+	// MemberEpoch is 0 to join the group, -1 to leave it and otherwise the
+	// epoch of the last response
+	MemberEpoch int32
+	RackID      *string
+	// SubscribedTopicNames are the topics the member subscribes to, nil if
+	// they did not change since the last heartbeat
+	SubscribedTopicNames []string
+}
+
+func (r *ShareGroupHeartbeatRequest) encode(pe packetEncoder) error {
+	if err := pe.putCompactString(r.GroupID); err != nil {
+		return err
+	}
+	if err := pe.putCompactString(r.MemberID); err != nil {
+		return err
+	}
+	pe.putInt32(r.MemberEpoch)
+	if err := pe.putNullableCompactString(r.RackID); err != nil {
+		return err
+	}
+
+	if r.SubscribedTopicNames == nil {
+		pe.putUVarint(0)
+	} else if err := putCompactStrings(pe, r.SubscribedTopicNames); err != nil {
+		return err
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ShareGroupHeartbeatRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.GroupID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if r.MemberID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if r.MemberEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.RackID, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getUVarint()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.SubscribedTopicNames = make([]string, n-1)
+		for i := range r.SubscribedTopicNames {
+			if r.SubscribedTopicNames[i], err = pd.getCompactString(); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *ShareGroupHeartbeatRequest) key() int16 {
+	return 76
+}
+
+func (r *ShareGroupHeartbeatRequest) version() int16 {
+	return r.Version
+}
+
+func (r *ShareGroupHeartbeatRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *ShareGroupHeartbeatRequest) requiredVersion() KafkaVersion {
+	return V4_0_0_0
+}
@@ -0,0 +1,38 @@
+package sarama
+
+import "testing"
+
+var (
+	shareGroupHeartbeatRequestJoin = []byte{
+		0x02, 'g', // GroupID
+		0x02, 'm', // MemberID
+		0x00, 0x00, 0x00, 0x00, // MemberEpoch
+		0x03, 'r', '1', // RackID
+		0x02, 0x02, 't', // SubscribedTopicNames
+		0x00, // empty tagged fields
+	}
+
+	shareGroupHeartbeatRequestHeartbeat = []byte{
+		0x02, 'g', // GroupID
+		0x02, 'm', // MemberID
+		0x00, 0x00, 0x00, 0x05, // MemberEpoch
+		0x00, // null RackID
+		0x00, // null SubscribedTopicNames
+		0x00, // empty tagged fields
+	}
+)
+
+func TestShareGroupHeartbeatRequest(t *testing.T) {
+	testRequest(t, "join", &ShareGroupHeartbeatRequest{
+		GroupID:              "g",
+		MemberID:             "m",
+		RackID:               nullString("r1"),
+		SubscribedTopicNames: []string{"t"},
+	}, shareGroupHeartbeatRequestJoin)
+
+	testRequest(t, "heartbeat", &ShareGroupHeartbeatRequest{
+		GroupID:     "g",
+		MemberID:    "m",
+		MemberEpoch: 5,
+	}, shareGroupHeartbeatRequestHeartbeat)
+}
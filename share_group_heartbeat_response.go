@@ -0,0 +1,159 @@
+package sarama
+
+import "time"
+
+// ShareGroupHeartbeatResponse is the response to a ShareGroupHeartbeatRequest
+type ShareGroupHeartbeatResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime time.Duration
+	Err          KError
+	ErrorMessage *string
+	MemberID     *string
+	MemberEpoch  int32
+	// HeartbeatInterval is the interval at which the member must send its
+	// heartbeats
+	HeartbeatInterval time.Duration
+	// Assignment is the assignment of the member, nil if it did not change
+	// since the last heartbeat
+	Assignment *ShareGroupAssignment
+}
+
+// ShareGroupAssignment are the partitions assigned to a share group member
+type ShareGroupAssignment struct {
+	TopicPartitions []*ShareGroupTopicPartitions
+}
+
+// ShareGroupTopicPartitions are the partitions of a topic identified by ID
+type ShareGroupTopicPartitions struct {
+	TopicID    Uuid
+	Partitions []int32
+}
+
+func (r *ShareGroupHeartbeatResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	pe.putInt16(int16(r.Err))
+	if err := pe.putNullableCompactString(r.ErrorMessage); err != nil {
+		return err
+	}
+	if err := pe.putNullableCompactString(r.MemberID); err != nil {
+		return err
+	}
+	pe.putInt32(r.MemberEpoch)
+	pe.putInt32(int32(r.HeartbeatInterval / time.Millisecond))
+
+	// nullable structs are preceded by -1 when null and 1 otherwise
+	if r.Assignment == nil {
+		pe.putInt8(-1)
+	} else {
+		pe.putInt8(1)
+		if err := r.Assignment.encode(pe); err != nil {
+			return err
+		}
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ShareGroupHeartbeatResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	if r.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if r.MemberID, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if r.MemberEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+	interval, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.HeartbeatInterval = time.Duration(interval) * time.Millisecond
+
+	present, err := pd.getInt8()
+	if err != nil {
+		return err
+	}
+	if present >= 0 {
+		r.Assignment = &ShareGroupAssignment{}
+		if err := r.Assignment.decode(pd); err != nil {
+			return err
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (a *ShareGroupAssignment) encode(pe packetEncoder) error {
+	pe.putCompactArrayLength(len(a.TopicPartitions))
+	for _, tp := range a.TopicPartitions {
+		if err := putUuid(pe, tp.TopicID); err != nil {
+			return err
+		}
+		if err := pe.putCompactInt32Array(tp.Partitions); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (a *ShareGroupAssignment) decode(pd packetDecoder) error {
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		a.TopicPartitions = make([]*ShareGroupTopicPartitions, n)
+	}
+	for i := 0; i < n; i++ {
+		tp := &ShareGroupTopicPartitions{}
+		if tp.TopicID, err = getUuid(pd); err != nil {
+			return err
+		}
+		if tp.Partitions, err = pd.getCompactInt32Array(); err != nil {
+			return err
+		}
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+		a.TopicPartitions[i] = tp
+	}
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *ShareGroupHeartbeatResponse) key() int16 {
+	return 76
+}
+
+func (r *ShareGroupHeartbeatResponse) version() int16 {
+	return r.Version
+}
+
+func (r *ShareGroupHeartbeatResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *ShareGroupHeartbeatResponse) requiredVersion() KafkaVersion {
+	return V4_0_0_0
+}
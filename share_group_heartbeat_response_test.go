@@ -0,0 +1,55 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	shareGroupHeartbeatResponseAssignment = []byte{
+		0x00, 0x00, 0x00, 0x64, // ThrottleTime
+		0x00, 0x00, // ErrNoError
+		0x00,      // null ErrorMessage
+		0x02, 'm', // MemberID
+		0x00, 0x00, 0x00, 0x02, // MemberEpoch
+		0x00, 0x00, 0x13, 0x88, // HeartbeatInterval
+		0x01,                                                                                           // Assignment
+		0x02,                                                                                           // TopicPartitions
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, // TopicID
+		0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, // Partitions
+		0x00, // empty tagged fields
+		0x00, // empty tagged fields
+		0x00, // empty tagged fields
+	}
+
+	shareGroupHeartbeatResponseError = []byte{
+		0x00, 0x00, 0x00, 0x00, // ThrottleTime
+		0x00, 0x6e, // ErrFencedMemberEpoch
+		0x04, 'e', 'r', 'r', // ErrorMessage
+		0x00,                   // null MemberID
+		0x00, 0x00, 0x00, 0x00, // MemberEpoch
+		0x00, 0x00, 0x00, 0x00, // HeartbeatInterval
+		0xff, // null Assignment
+		0x00, // empty tagged fields
+	}
+)
+
+func TestShareGroupHeartbeatResponse(t *testing.T) {
+	testResponse(t, "assignment", &ShareGroupHeartbeatResponse{
+		ThrottleTime:      100 * time.Millisecond,
+		MemberID:          nullString("m"),
+		MemberEpoch:       2,
+		HeartbeatInterval: 5 * time.Second,
+		Assignment: &ShareGroupAssignment{
+			TopicPartitions: []*ShareGroupTopicPartitions{{
+				TopicID:    Uuid{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+				Partitions: []int32{0, 2},
+			}},
+		},
+	}, shareGroupHeartbeatResponseAssignment)
+
+	testResponse(t, "error", &ShareGroupHeartbeatResponse{
+		Err:          ErrFencedMemberEpoch,
+		ErrorMessage: nullString("err"),
+	}, shareGroupHeartbeatResponseError)
+}
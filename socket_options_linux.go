@@ -0,0 +1,75 @@
+package sarama
+
+import (
+	"syscall"
+	"time"
+)
+
+const (
+	socketOptionsSupported = true
+
+	// TCP_USER_TIMEOUT, missing from the syscall package
+	tcpUserTimeout = 0x12
+)
+
+// defaultKeepAlive is the idle period before the first keep-alive probe used
+// by net.Dialer when Net.KeepAlive is 0
+const defaultKeepAlive = 15 * time.Second
+
+// socketControl returns the net.Dialer Control function setting the socket
+// options of the Net config before connecting, or nil if none is set
+func (c *Config) socketControl() func(network, address string, rc syscall.RawConn) error {
+	type option struct {
+		level, name, value int
+	}
+	var options []option
+	if c.Net.KeepAliveInterval > 0 || c.Net.KeepAliveCount > 0 {
+		idle := c.Net.KeepAlive
+		if idle == 0 {
+			idle = defaultKeepAlive
+		}
+		interval := c.Net.KeepAliveInterval
+		if interval == 0 {
+			interval = idle
+		}
+		options = append(options,
+			option{syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 1},
+			option{syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE, roundUpSeconds(idle)},
+			option{syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, roundUpSeconds(interval)},
+		)
+		if c.Net.KeepAliveCount > 0 {
+			options = append(options, option{syscall.IPPROTO_TCP, syscall.TCP_KEEPCNT, c.Net.KeepAliveCount})
+		}
+	}
+	if c.Net.UserTimeout > 0 {
+		options = append(options, option{syscall.IPPROTO_TCP, tcpUserTimeout, int(c.Net.UserTimeout / time.Millisecond)})
+	}
+	if c.Net.SendBufferSize > 0 {
+		options = append(options, option{syscall.SOL_SOCKET, syscall.SO_SNDBUF, c.Net.SendBufferSize})
+	}
+	if c.Net.ReceiveBufferSize > 0 {
+		options = append(options, option{syscall.SOL_SOCKET, syscall.SO_RCVBUF, c.Net.ReceiveBufferSize})
+	}
+	if len(options) == 0 {
+		return nil
+	}
+
+	return func(network, address string, rc syscall.RawConn) error {
+		var err error
+		controlErr := rc.Control(func(fd uintptr) {
+			for _, o := range options {
+				if err = syscall.SetsockoptInt(int(fd), o.level, o.name, o.value); err != nil {
+					return
+				}
+			}
+		})
+		if controlErr != nil {
+			return controlErr
+		}
+		return err
+	}
+}
+
+func roundUpSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
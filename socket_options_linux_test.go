@@ -0,0 +1,69 @@
+package sarama
+
+import (
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBrokerSocketOptions(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+
+	conf := NewTestConfig()
+	conf.Net.KeepAlive = 30 * time.Second
+	conf.Net.KeepAliveInterval = 5 * time.Second
+	conf.Net.KeepAliveCount = 4
+	conf.Net.UserTimeout = 20 * time.Second
+	conf.Net.SendBufferSize = 64 * 1024
+	conf.Net.ReceiveBufferSize = 128 * 1024
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+	if connected, err := broker.Connected(); !connected {
+		t.Fatal(err)
+	}
+
+	rc, err := broker.conn.(*bufConn).Conn.(*net.TCPConn).SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(level, name int) int {
+		var value int
+		var err error
+		if controlErr := rc.Control(func(fd uintptr) {
+			value, err = syscall.GetsockoptInt(int(fd), level, name)
+		}); controlErr != nil {
+			t.Fatal(controlErr)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	for _, test := range []struct {
+		name       string
+		level, opt int
+		expected   int
+		atLeast    bool
+	}{
+		{"SO_KEEPALIVE", syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 1, false},
+		{"TCP_KEEPIDLE", syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE, 30, false},
+		{"TCP_KEEPINTVL", syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, 5, false},
+		{"TCP_KEEPCNT", syscall.IPPROTO_TCP, syscall.TCP_KEEPCNT, 4, false},
+		{"TCP_USER_TIMEOUT", syscall.IPPROTO_TCP, tcpUserTimeout, 20000, false},
+		// the kernel doubles the buffer sizes for its bookkeeping
+		{"SO_SNDBUF", syscall.SOL_SOCKET, syscall.SO_SNDBUF, 64 * 1024, true},
+		{"SO_RCVBUF", syscall.SOL_SOCKET, syscall.SO_RCVBUF, 128 * 1024, true},
+	} {
+		value := get(test.level, test.opt)
+		if test.atLeast && value < test.expected || !test.atLeast && value != test.expected {
+			t.Errorf("expected %s to be %d, got %d", test.name, test.expected, value)
+		}
+	}
+}
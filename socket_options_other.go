@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package sarama
+
+import "syscall"
+
+const socketOptionsSupported = false
+
+// socketControl returns nil, the socket options of the Net config being only
+// supported on Linux
+func (c *Config) socketControl() func(network, address string, rc syscall.RawConn) error {
+	return nil
+}
@@ -1,27 +1,64 @@
 package sarama
 
 type SyncGroupRequest struct {
+	Version          int16
 	GroupId          string
 	GenerationId     int32
 	MemberId         string
+	GroupInstanceId  *string // version 3 or later
 	GroupAssignments map[string][]byte
 }
 
-func (r *SyncGroupRequest) encode(pe packetEncoder) error {
-	if err := pe.putString(r.GroupId); err != nil {
+func (r *SyncGroupRequest) encode(pe packetEncoder) (err error) {
+	isFlexible := r.Version >= 4
+
+	if isFlexible {
+		err = pe.putCompactString(r.GroupId)
+	} else {
+		err = pe.putString(r.GroupId)
+	}
+	if err != nil {
 		return err
 	}
 
 	pe.putInt32(r.GenerationId)
 
-	if err := pe.putString(r.MemberId); err != nil {
+	if isFlexible {
+		err = pe.putCompactString(r.MemberId)
+	} else {
+		err = pe.putString(r.MemberId)
+	}
+	if err != nil {
 		return err
 	}
 
-	if err := pe.putArrayLength(len(r.GroupAssignments)); err != nil {
+	if r.Version >= 3 {
+		if isFlexible {
+			err = pe.putNullableCompactString(r.GroupInstanceId)
+		} else {
+			err = pe.putNullableString(r.GroupInstanceId)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if isFlexible {
+		pe.putCompactArrayLength(len(r.GroupAssignments))
+	} else if err := pe.putArrayLength(len(r.GroupAssignments)); err != nil {
 		return err
 	}
 	for memberId, memberAssignment := range r.GroupAssignments {
+		if isFlexible {
+			if err := pe.putCompactString(memberId); err != nil {
+				return err
+			}
+			if err := pe.putCompactBytes(memberAssignment); err != nil {
+				return err
+			}
+			pe.putEmptyTaggedFieldArray()
+			continue
+		}
 		if err := pe.putString(memberId); err != nil {
 			return err
 		}
@@ -30,42 +67,92 @@ func (r *SyncGroupRequest) encode(pe packetEncoder) error {
 		}
 	}
 
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
+
 	return nil
 }
 
 func (r *SyncGroupRequest) decode(pd packetDecoder, version int16) (err error) {
-	if r.GroupId, err = pd.getString(); err != nil {
+	r.Version = version
+	isFlexible := r.Version >= 4
+
+	if isFlexible {
+		r.GroupId, err = pd.getCompactString()
+	} else {
+		r.GroupId, err = pd.getString()
+	}
+	if err != nil {
 		return
 	}
 	if r.GenerationId, err = pd.getInt32(); err != nil {
 		return
 	}
-	if r.MemberId, err = pd.getString(); err != nil {
+	if isFlexible {
+		r.MemberId, err = pd.getCompactString()
+	} else {
+		r.MemberId, err = pd.getString()
+	}
+	if err != nil {
 		return
 	}
 
-	n, err := pd.getArrayLength()
+	if r.Version >= 3 {
+		if isFlexible {
+			r.GroupInstanceId, err = pd.getCompactNullableString()
+		} else {
+			r.GroupInstanceId, err = pd.getNullableString()
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	var n int
+	if isFlexible {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
-	if n == 0 {
-		return nil
-	}
 
-	r.GroupAssignments = make(map[string][]byte)
+	if n > 0 {
+		r.GroupAssignments = make(map[string][]byte)
+	}
 	for i := 0; i < n; i++ {
-		memberId, err := pd.getString()
-		if err != nil {
-			return err
-		}
-		memberAssignment, err := pd.getBytes()
-		if err != nil {
-			return err
+		var memberId string
+		var memberAssignment []byte
+		if isFlexible {
+			if memberId, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if memberAssignment, err = pd.getCompactBytes(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		} else {
+			if memberId, err = pd.getString(); err != nil {
+				return err
+			}
+			if memberAssignment, err = pd.getBytes(); err != nil {
+				return err
+			}
 		}
 
 		r.GroupAssignments[memberId] = memberAssignment
 	}
 
+	if isFlexible {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -74,15 +161,45 @@ func (r *SyncGroupRequest) key() int16 {
 }
 
 func (r *SyncGroupRequest) version() int16 {
-	return 0
+	return r.Version
+}
+
+func (r *SyncGroupRequest) versionRange() (minVersion, maxVersion int16) {
+	if r.Version >= 3 && r.GroupInstanceId != nil {
+		return 3, 4
+	}
+	return 0, 4
+}
+
+func (r *SyncGroupRequest) withVersion(v int16) protocolBody {
+	request := *r
+	request.Version = v
+	if r.Version < 3 {
+		request.GroupInstanceId = nil
+	}
+	return &request
 }
 
 func (r *SyncGroupRequest) headerVersion() int16 {
+	if r.Version >= 4 {
+		return 2
+	}
 	return 1
 }
 
 func (r *SyncGroupRequest) requiredVersion() KafkaVersion {
-	return V0_9_0_0
+	switch r.Version {
+	case 4:
+		return V2_4_0_0
+	case 3:
+		return V2_3_0_0
+	case 2:
+		return V2_0_0_0
+	case 1:
+		return V0_11_0_0
+	default:
+		return V0_9_0_0
+	}
 }
 
 func (r *SyncGroupRequest) AddGroupAssignment(memberId string, memberAssignment []byte) {
@@ -36,3 +36,27 @@ func TestSyncGroupRequest(t *testing.T) {
 	request.AddGroupAssignment("baz", []byte("foo"))
 	testRequest(t, "populated", request, populatedSyncGroupRequest)
 }
+
+var syncGroupRequestV4 = []byte{
+	4, 'f', 'o', 'o', // Group ID
+	0x00, 0x01, 0x02, 0x03, // Generation ID
+	4, 'b', 'a', 'z', // Member ID
+	9, 'i', 'n', 's', 't', 'a', 'n', 'c', 'e', // Group Instance ID
+	2,                // one assignment
+	4, 'b', 'a', 'z', // Member ID
+	4, 'f', 'o', 'o', // Member assignment
+	0, // empty tagged fields
+	0, // empty tagged fields
+}
+
+func TestSyncGroupRequestV4(t *testing.T) {
+	instance := "instance"
+	request := new(SyncGroupRequest)
+	request.Version = 4
+	request.GroupId = "foo"
+	request.GenerationId = 66051
+	request.MemberId = "baz"
+	request.GroupInstanceId = &instance
+	request.AddGroupAssignment("baz", []byte("foo"))
+	testRequest(t, "v4", request, syncGroupRequestV4)
+}
@@ -39,3 +39,19 @@ func TestSyncGroupResponse(t *testing.T) {
 		t.Error("Decoding MemberAssignment failed, found:", response.MemberAssignment)
 	}
 }
+
+var syncGroupResponseV4 = []byte{
+	0x00, 0x00, 0x00, 0x64, // Throttle time
+	0x00, 0x00, // No error
+	4, 0x01, 0x02, 0x03, // Member assignment data
+	0, // empty tagged fields
+}
+
+func TestSyncGroupResponseV4(t *testing.T) {
+	response := &SyncGroupResponse{
+		Version:          4,
+		ThrottleTime:     100,
+		MemberAssignment: []byte{0x01, 0x02, 0x03},
+	}
+	testResponse(t, "v4", response, syncGroupResponseV4)
+}
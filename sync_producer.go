@@ -25,6 +25,44 @@ type SyncProducer interface {
 	// SendMessages will return an error.
 	SendMessages(msgs []*ProducerMessage) error
 
+	// IsTransactional returns whether the producer is transactional, see
+	// Producer.Transaction.ID.
+	IsTransactional() bool
+
+	// TxnStatus returns the status of the transactions of the producer, see
+	// AsyncProducer.TxnStatus.
+	TxnStatus() TxnStatus
+
+	// BeginTxn begins a transaction, see AsyncProducer.BeginTxn.
+	BeginTxn() error
+
+	// CommitTxn commits the transaction, see AsyncProducer.CommitTxn.
+	CommitTxn() error
+
+	// AbortTxn aborts the transaction, see AsyncProducer.AbortTxn.
+	AbortTxn() error
+
+	// AddOffsetsToTxn adds the offsets consumed by the consumer group to the
+	// transaction, see AsyncProducer.AddOffsetsToTxn.
+	AddOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, groupID string) error
+
+	// AddGroupOffsetsToTxn adds the offsets consumed by the member of the
+	// consumer group to the transaction, see
+	// AsyncProducer.AddGroupOffsetsToTxn.
+	AddGroupOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, group *ConsumerGroupMetadata) error
+
+	// AddMessageToTxn adds the offset following the consumed message to the
+	// transaction, see AsyncProducer.AddMessageToTxn.
+	AddMessageToTxn(msg *ConsumerMessage, groupID string, metadata *string) error
+
+	// PrepareTxn prepares the transaction for a two-phase commit, see
+	// AsyncProducer.PrepareTxn.
+	PrepareTxn() (PreparedTxnState, error)
+
+	// CompleteTxn completes the prepared transaction, see
+	// AsyncProducer.CompleteTxn.
+	CompleteTxn(state PreparedTxnState) error
+
 	// Close shuts down the producer; you must call this function before a producer
 	// object passes out of scope, as it may otherwise leak memory.
 	// You must call this before calling Close on the underlying client.
@@ -125,6 +163,46 @@ func (sp *syncProducer) SendMessages(msgs []*ProducerMessage) error {
 	return nil
 }
 
+func (sp *syncProducer) IsTransactional() bool {
+	return sp.producer.IsTransactional()
+}
+
+func (sp *syncProducer) TxnStatus() TxnStatus {
+	return sp.producer.TxnStatus()
+}
+
+func (sp *syncProducer) BeginTxn() error {
+	return sp.producer.BeginTxn()
+}
+
+func (sp *syncProducer) CommitTxn() error {
+	return sp.producer.CommitTxn()
+}
+
+func (sp *syncProducer) AbortTxn() error {
+	return sp.producer.AbortTxn()
+}
+
+func (sp *syncProducer) AddOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, groupID string) error {
+	return sp.producer.AddOffsetsToTxn(offsets, groupID)
+}
+
+func (sp *syncProducer) AddGroupOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, group *ConsumerGroupMetadata) error {
+	return sp.producer.AddGroupOffsetsToTxn(offsets, group)
+}
+
+func (sp *syncProducer) AddMessageToTxn(msg *ConsumerMessage, groupID string, metadata *string) error {
+	return sp.producer.AddMessageToTxn(msg, groupID, metadata)
+}
+
+func (sp *syncProducer) PrepareTxn() (PreparedTxnState, error) {
+	return sp.producer.PrepareTxn()
+}
+
+func (sp *syncProducer) CompleteTxn(state PreparedTxnState) error {
+	return sp.producer.CompleteTxn(state)
+}
+
 func (sp *syncProducer) handleSuccesses() {
 	defer sp.wg.Done()
 	for msg := range sp.producer.Successes() {
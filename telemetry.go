@@ -0,0 +1,365 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	// telemetryRetryBackoff is how long to wait before subscribing again
+	// after a failure
+	telemetryRetryBackoff = 30 * time.Second
+	// telemetryDefaultPushInterval is the push interval of the
+	// subscriptions which do not set one
+	telemetryDefaultPushInterval = 5 * time.Minute
+	// telemetryNamespace prefixes the names of the metrics specific to
+	// Sarama, the standard ones being prefixed with org.apache.kafka
+	telemetryNamespace = "org.apache.kafka.sarama."
+)
+
+// telemetryReporter pushes the metrics of a client to the brokers with
+// Config.Telemetry (KIP-714), as subscribed to with GetTelemetrySubscriptions.
+// The metrics of MetricRegistry are pushed as they are, under
+// telemetryNamespace, along with the standard node.request.latency of the
+// producers and consumers sharing it.
+type telemetryReporter struct {
+	client *client
+	conf   *Config
+
+	lock         sync.Mutex
+	disabled     bool
+	instanceID   Uuid
+	subscription *GetTelemetrySubscriptionsResponse
+	broker       *Broker            // the broker pushed to, kept as long as connected
+	start        time.Time          // start of the cumulative sums
+	lastPush     time.Time          // start of the delta sums
+	lastSums     map[string]float64 // values of the sums at lastPush, by name and attributes
+
+	closer, closed chan none
+}
+
+func newTelemetryReporter(client *client) *telemetryReporter {
+	return &telemetryReporter{
+		client:   client,
+		conf:     client.conf,
+		start:    client.conf.Clock.Now(),
+		lastSums: make(map[string]float64),
+		closer:   make(chan none),
+		closed:   make(chan none),
+	}
+}
+
+// run subscribes to the metrics and pushes them every push interval until
+// closed, when the last push is sent with Terminating
+func (r *telemetryReporter) run() {
+	defer close(r.closed)
+
+	var wait time.Duration
+	for {
+		select {
+		case <-r.conf.Clock.After(wait):
+		case <-r.closer:
+			r.lock.Lock()
+			if r.subscription != nil && len(r.subscription.RequestedMetrics) > 0 {
+				if err := r.push(true); err != nil {
+					r.log().Warnf("client/telemetry failed to push the last metrics: %v", err)
+				}
+			}
+			r.lock.Unlock()
+			return
+		}
+
+		r.lock.Lock()
+		wait = r.tick()
+		disabled := r.disabled
+		r.lock.Unlock()
+		if disabled {
+			return
+		}
+	}
+}
+
+// tick pushes the metrics once subscribed to, returning how long to wait for
+// the next tick
+func (r *telemetryReporter) tick() time.Duration {
+	if r.subscription == nil || len(r.subscription.RequestedMetrics) == 0 {
+		// the subscriptions without metrics are refreshed every push
+		// interval, in case the brokers start collecting some
+		if err := r.subscribe(context.Background()); err != nil {
+			r.log().Warnf("client/telemetry failed to get the telemetry subscriptions: %v", err)
+			return telemetryRetryBackoff
+		}
+		return r.pushInterval()
+	}
+
+	err := r.push(false)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrUnknownSubscriptionID):
+		// the subscriptions changed, get the new ones
+		r.subscription = nil
+		return 0
+	case errors.Is(err, ErrTelemetryTooLarge):
+		r.log().Warnf("client/telemetry the metrics were too large to be pushed")
+	default:
+		r.log().Warnf("client/telemetry failed to push the metrics: %v", err)
+		r.subscription = nil
+		return telemetryRetryBackoff
+	}
+	return r.pushInterval()
+}
+
+func (r *telemetryReporter) pushInterval() time.Duration {
+	if r.subscription == nil || r.subscription.PushInterval <= 0 {
+		return telemetryDefaultPushInterval
+	}
+	return r.subscription.PushInterval
+}
+
+// clientInstanceID returns the ID assigned to the client by the brokers,
+// subscribing to the metrics first if needed
+func (r *telemetryReporter) clientInstanceID(ctx context.Context) (Uuid, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.instanceID == NullUuid {
+		if err := r.subscribe(ctx); err != nil {
+			return NullUuid, err
+		}
+	}
+	return r.instanceID, nil
+}
+
+// subscribe gets the telemetry subscriptions of the client, which assigns
+// its instance ID the first time
+func (r *telemetryReporter) subscribe(ctx context.Context) error {
+	broker, err := r.pickBroker()
+	if err != nil {
+		return err
+	}
+	res, err := broker.getTelemetrySubscriptions(ctx, &GetTelemetrySubscriptionsRequest{ClientInstanceID: r.instanceID})
+	if err != nil {
+		r.broker = nil
+		return err
+	}
+	if !errors.Is(res.ErrorCode, ErrNoError) {
+		r.disableOn(res.ErrorCode)
+		return res.ErrorCode
+	}
+
+	if r.instanceID == NullUuid {
+		r.instanceID = res.ClientInstanceID
+		r.log().Debugf("client/telemetry assigned client instance ID %s", r.instanceID)
+	}
+	r.subscription = res
+	r.lastPush = r.conf.Clock.Now()
+	r.lastSums = make(map[string]float64)
+	return nil
+}
+
+// push pushes the metrics requested by the subscription to the brokers
+func (r *telemetryReporter) push(terminating bool) error {
+	sub := r.subscription
+	temporality := otlpTemporalityCumulative
+	if sub.DeltaTemporality {
+		temporality = otlpTemporalityDelta
+	}
+	now := r.conf.Clock.Now()
+	data := encodeOTLPMetrics("sarama", r.collect(now), temporality)
+	r.lastPush = now
+	if sub.TelemetryMaxBytes > 0 && len(data) > int(sub.TelemetryMaxBytes) {
+		return ErrTelemetryTooLarge
+	}
+
+	req := &PushTelemetryRequest{
+		ClientInstanceID: r.instanceID,
+		SubscriptionID:   sub.SubscriptionID,
+		Terminating:      terminating,
+		CompressionType:  CompressionNone,
+		Metrics:          data,
+	}
+	if codec := telemetryCompression(sub.AcceptedCompressionTypes); codec != CompressionNone {
+		if compressed, err := compress(codec, CompressionLevelDefault, 0, data); err == nil {
+			req.CompressionType = codec
+			req.Metrics = compressed
+		}
+	}
+
+	broker, err := r.pickBroker()
+	if err != nil {
+		return err
+	}
+	res, err := broker.PushTelemetry(req)
+	if err != nil {
+		r.broker = nil
+		return err
+	}
+	if !errors.Is(res.ErrorCode, ErrNoError) {
+		r.disableOn(res.ErrorCode)
+		return res.ErrorCode
+	}
+	return nil
+}
+
+// disableOn stops the telemetry if the brokers reject it for good
+func (r *telemetryReporter) disableOn(kerr KError) {
+	switch kerr {
+	case ErrUnsupportedVersion, ErrInvalidRequest, ErrInvalidRecord:
+		r.log().Warnf("client/telemetry disabled: %v", kerr)
+		r.disabled = true
+	}
+}
+
+// pickBroker returns the broker the metrics are pushed to, which is only
+// replaced once disconnected
+func (r *telemetryReporter) pickBroker() (*Broker, error) {
+	if r.broker != nil {
+		if connected, _ := r.broker.Connected(); connected {
+			return r.broker, nil
+		}
+	}
+	r.broker = r.client.LeastLoadedBroker()
+	if r.broker == nil {
+		return nil, ErrOutOfBrokers
+	}
+	return r.broker, nil
+}
+
+// log returns the logger of the lines about the telemetry of the client
+func (r *telemetryReporter) log() contextLogger {
+	return newContextLogger("clientInstanceID", r.instanceID.String())
+}
+
+func (r *telemetryReporter) close() {
+	close(r.closer)
+	<-r.closed
+}
+
+// telemetryCompression returns the preferred codec accepted by the brokers
+// among those supported, CompressionNone if none
+func telemetryCompression(accepted []CompressionCodec) CompressionCodec {
+	for _, codec := range accepted {
+		switch codec {
+		case CompressionGZIP, CompressionSnappy, CompressionLZ4, CompressionZSTD:
+			return codec
+		}
+	}
+	return CompressionNone
+}
+
+// collect returns the metrics of the registry requested by the subscription
+func (r *telemetryReporter) collect(now time.Time) []*otlpMetric {
+	registry := r.conf.MetricRegistry
+	if registry == nil {
+		return nil
+	}
+
+	var collected []*otlpMetric
+	add := func(metric *otlpMetric) {
+		if telemetryRequested(r.subscription.RequestedMetrics, metric.name) {
+			collected = append(collected, metric)
+		}
+	}
+
+	byName := make(map[string]*otlpMetric)
+	point := func(name, unit string, sum bool, labels map[string]string) *otlpDataPoint {
+		metric, ok := byName[name]
+		if !ok {
+			metric = &otlpMetric{name: name, unit: unit, sum: sum}
+			byName[name] = metric
+		}
+		metric.points = append(metric.points, otlpDataPoint{attributes: telemetryAttributes(labels), time: now})
+		return &metric.points[len(metric.points)-1]
+	}
+
+	var roles []string
+	if registry.Get("record-send-rate") != nil {
+		roles = append(roles, "producer")
+	}
+	if registry.Get("consumer-batch-size") != nil {
+		roles = append(roles, "consumer")
+	}
+
+	registry.Each(func(name string, m interface{}) {
+		base, labels := prometheusNameLabels(name)
+		dotted := telemetryNamespace + strings.Replace(base, "-", ".", -1)
+		switch m := m.(type) {
+		case metrics.Meter:
+			p := point(strings.TrimSuffix(dotted, ".rate")+".total", "", true, labels)
+			p.value = r.sumValue(p, name, float64(m.Count()))
+		case metrics.Histogram:
+			snapshot := m.Snapshot()
+			point(dotted+".avg", "", false, labels).value = snapshot.Mean()
+			point(dotted+".max", "", false, labels).value = float64(snapshot.Max())
+			if base != "request-latency-in-ms" || labels["broker"] == "" {
+				return
+			}
+			// the standard latency of the requests sent to each node
+			nodeLabels := map[string]string{"node.id": labels["broker"]}
+			for _, role := range roles {
+				prefix := "org.apache.kafka." + role + ".node.request.latency"
+				point(prefix+".avg", "ms", false, nodeLabels).value = snapshot.Mean()
+				point(prefix+".max", "ms", false, nodeLabels).value = float64(snapshot.Max())
+			}
+		case metrics.Counter:
+			p := point(dotted, "", false, labels)
+			p.intValue, p.isInt = m.Count(), true
+		case metrics.Gauge:
+			p := point(dotted, "", false, labels)
+			p.intValue, p.isInt = m.Value(), true
+		case metrics.GaugeFloat64:
+			point(dotted, "", false, labels).value = m.Value()
+		}
+	})
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(byName[name])
+	}
+	return collected
+}
+
+// sumValue sets the start of the point of a sum, returning its value since
+// the last push with DeltaTemporality
+func (r *telemetryReporter) sumValue(p *otlpDataPoint, key string, value float64) float64 {
+	if !r.subscription.DeltaTemporality {
+		p.start = r.start
+		return value
+	}
+	p.start = r.lastPush
+	last := r.lastSums[key]
+	r.lastSums[key] = value
+	return value - last
+}
+
+// telemetryRequested returns whether the metric is requested by the prefixes
+// of a subscription
+func telemetryRequested(requested []string, name string) bool {
+	for _, prefix := range requested {
+		if prefix == "*" || strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// telemetryAttributes returns the labels of a metric as attributes, sorted
+func telemetryAttributes(labels map[string]string) []otlpAttribute {
+	attributes := make([]otlpAttribute, 0, len(labels))
+	for key, value := range labels {
+		attributes = append(attributes, otlpAttribute{key: key, value: value})
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].key < attributes[j].key
+	})
+	return attributes
+}
@@ -0,0 +1,154 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func newTelemetryTestClient(t *testing.T, telemetry *MockTelemetryResponse) (*MockBroker, Client) {
+	t.Helper()
+	broker := NewMockBroker(t, 1)
+	broker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()),
+		"GetTelemetrySubscriptionsRequest": telemetry,
+		"PushTelemetryRequest":             telemetry,
+	})
+
+	config := NewTestConfig()
+	config.Version = V3_7_0_0
+	config.Telemetry.Enabled = true
+	client, err := NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		broker.Close()
+		t.Fatal(err)
+	}
+	return broker, client
+}
+
+// telemetryMetricNames returns the names of the metrics of a push
+func telemetryMetricNames(t *testing.T, push *PushTelemetryRequest) map[string]bool {
+	t.Helper()
+	names := make(map[string]bool)
+	resourceMetrics := protoFields(t, protoFieldOf(t, protoFields(t, push.Metrics), 1).bytes)
+	for _, field := range protoFields(t, protoFieldOf(t, resourceMetrics, 2).bytes) {
+		if field.number == 2 {
+			names[string(protoFieldOf(t, protoFields(t, field.bytes), 1).bytes)] = true
+		}
+	}
+	return names
+}
+
+func TestClientTelemetryPush(t *testing.T) {
+	telemetry := NewMockTelemetryResponse(t).
+		SetSubscription([]string{"org.apache.kafka.sarama.request", "org.apache.kafka.producer."}, 10*time.Millisecond, false)
+	broker, client := newTelemetryTestClient(t, telemetry)
+	defer broker.Close()
+
+	id, err := client.ClientInstanceID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == NullUuid {
+		t.Fatal("expected a client instance ID")
+	}
+
+	metrics.GetOrRegisterMeter("record-send-rate", client.Config().MetricRegistry).Mark(1)
+	metrics.GetOrRegisterMeter("request-rate", client.Config().MetricRegistry).Mark(2)
+	getOrRegisterHistogram("request-latency-in-ms-for-broker-1", client.Config().MetricRegistry).Update(5)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(telemetry.Pushes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	pushes := telemetry.Pushes()
+	if len(pushes) == 0 {
+		t.Fatal("expected the metrics to be pushed")
+	}
+	push := pushes[len(pushes)-1]
+	if push.ClientInstanceID != id || push.Terminating {
+		t.Errorf("unexpected push %+v", push)
+	}
+	names := telemetryMetricNames(t, push)
+	for _, name := range []string{
+		"org.apache.kafka.sarama.request.total",
+		"org.apache.kafka.sarama.request.latency.in.ms.avg",
+		"org.apache.kafka.producer.node.request.latency.avg",
+		"org.apache.kafka.producer.node.request.latency.max",
+	} {
+		if !names[name] {
+			t.Errorf("expected %s to be pushed, got %v", name, names)
+		}
+	}
+	if names["org.apache.kafka.sarama.record.send.total"] || names["org.apache.kafka.consumer.node.request.latency.avg"] {
+		t.Errorf("unexpected metrics not requested %v", names)
+	}
+
+	safeClose(t, client)
+	pushes = telemetry.Pushes()
+	if last := pushes[len(pushes)-1]; !last.Terminating {
+		t.Error("expected a terminating push on close")
+	}
+}
+
+func TestClientTelemetryResubscribe(t *testing.T) {
+	telemetry := NewMockTelemetryResponse(t).SetSubscription(nil, 10*time.Millisecond, false)
+	broker, client := newTelemetryTestClient(t, telemetry)
+	defer broker.Close()
+	defer safeClose(t, client)
+
+	id, err := client.ClientInstanceID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the new subscription is picked up by the next refresh
+	broker.lock.Lock()
+	telemetry.SetSubscription([]string{"*"}, 10*time.Millisecond, true)
+	broker.lock.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(telemetry.Pushes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(telemetry.Pushes()) == 0 {
+		t.Fatal("expected the metrics to be pushed")
+	}
+	if again, _ := client.ClientInstanceID(); again != id {
+		t.Errorf("the client instance ID changed from %s to %s", id, again)
+	}
+}
+
+func TestClientInstanceIDRequiresTelemetry(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()),
+	})
+
+	client, err := NewClient([]string{broker.Addr()}, NewTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	var cerr ConfigurationError
+	if _, err := client.ClientInstanceID(); !errors.As(err, &cerr) {
+		t.Errorf("expected a ConfigurationError, got %v", err)
+	}
+}
+
+func TestTelemetryRequiresVersion(t *testing.T) {
+	config := NewTestConfig()
+	config.Version = V3_6_0_0
+	config.Telemetry.Enabled = true
+	var cerr ConfigurationError
+	if err := config.Validate(); !errors.As(err, &cerr) {
+		t.Errorf("expected a ConfigurationError, got %v", err)
+	}
+}
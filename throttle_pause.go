@@ -0,0 +1,45 @@
+package sarama
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// throttledByClient reports whether the response to the request is sent
+// before the broker throttles the connection, leaving it to the client not
+// to send anything meanwhile (KIP-219). In the earlier versions the broker
+// delays the response itself.
+func throttledByClient(rb protocolBody) bool {
+	switch rb.key() {
+	case 0: // Produce
+		return rb.version() >= 6
+	case 1: // Fetch
+		return rb.version() >= 8
+	case 3: // Metadata
+		return rb.version() >= 6
+	default:
+		return false
+	}
+}
+
+// pauseForThrottle records that the requests to the broker must wait for the
+// throttle time with Net.PauseOnThrottle.
+func (b *Broker) pauseForThrottle(rb protocolBody, throttleTime time.Duration) {
+	if b.conf == nil || !b.conf.Net.PauseOnThrottle || !throttledByClient(rb) {
+		return
+	}
+	atomic.StoreInt64(&b.pausedUntil, time.Now().Add(throttleTime).UnixNano())
+}
+
+// waitForThrottle waits until the throttle time recorded by pauseForThrottle,
+// if any, has elapsed.
+func (b *Broker) waitForThrottle() {
+	until := atomic.LoadInt64(&b.pausedUntil)
+	if until == 0 {
+		return
+	}
+	if delay := time.Until(time.Unix(0, until)); delay > 0 {
+		b.log().Debugf("broker/%d pausing requests %v for throttling", b.ID(), delay)
+		time.Sleep(delay)
+	}
+}
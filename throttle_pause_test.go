@@ -0,0 +1,44 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBrokerPauseOnThrottle(t *testing.T) {
+	for _, tt := range []struct {
+		version int16
+		paused  bool
+	}{
+		{version: 6, paused: true},
+		{version: 5, paused: false},
+	} {
+		mockBroker := NewMockBroker(t, 1)
+		mockBroker.SetHandlerByMap(map[string]MockResponse{
+			"ProduceRequest": NewMockWrapper(&ProduceResponse{Version: tt.version, ThrottleTime: 300 * time.Millisecond}),
+		})
+
+		config := NewTestConfig()
+		config.Version = V2_0_0_0
+		config.Net.PauseOnThrottle = true
+		broker := NewBroker(mockBroker.Addr())
+		if err := broker.Open(config); err != nil {
+			t.Fatal(err)
+		}
+
+		request := &ProduceRequest{Version: tt.version, RequiredAcks: WaitForLocal}
+		if _, err := broker.Produce(request); err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		if _, err := broker.Produce(request); err != nil {
+			t.Fatal(err)
+		}
+		if paused := time.Since(start) >= 200*time.Millisecond; paused != tt.paused {
+			t.Errorf("v%d: expected paused %v, took %s", tt.version, tt.paused, time.Since(start))
+		}
+
+		safeClose(t, broker)
+		mockBroker.Close()
+	}
+}
@@ -0,0 +1,64 @@
+package sarama
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// withClientCertificate returns the TLS config of a connection, c derived
+// from the base config, presenting the certificates of
+// Net.TLS.CertificateProvider if set.
+func (conf *Config) withClientCertificate(c, base *tls.Config) *tls.Config {
+	provider := conf.Net.TLS.CertificateProvider
+	if provider == nil {
+		return c
+	}
+	if c == base {
+		c = c.Clone()
+	}
+	c.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return provider()
+	}
+	return c
+}
+
+// NewCertificateFileProvider returns a Net.TLS.CertificateProvider presenting
+// the PEM encoded certificate chain and private key of the files, loaded
+// again whenever one of them was modified, e.g. by kubelet or the SPIFFE
+// helper writing the short-lived SVIDs of the workload API to disk. While
+// the files are being replaced and cannot be loaded, the certificate loaded
+// last keeps being presented.
+func NewCertificateFileProvider(certFile, keyFile string) func() (*tls.Certificate, error) {
+	var (
+		lock                sync.Mutex
+		cert                *tls.Certificate
+		certMtime, keyMtime time.Time
+	)
+	return func() (*tls.Certificate, error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		certInfo, err := os.Stat(certFile)
+		if err == nil {
+			var keyInfo os.FileInfo
+			if keyInfo, err = os.Stat(keyFile); err == nil {
+				if cert != nil && certInfo.ModTime().Equal(certMtime) && keyInfo.ModTime().Equal(keyMtime) {
+					return cert, nil
+				}
+				var loaded tls.Certificate
+				if loaded, err = tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+					cert, certMtime, keyMtime = &loaded, certInfo.ModTime(), keyInfo.ModTime()
+					newContextLogger("certFile", certFile).Debugf("Loaded the TLS client certificate from %s", certFile)
+					return cert, nil
+				}
+			}
+		}
+		if cert == nil {
+			return nil, err
+		}
+		newContextLogger("certFile", certFile).Warnf("Failed to load the TLS client certificate from %s, presenting the previous one: %s", certFile, err)
+		return cert, nil
+	}
+}
@@ -0,0 +1,132 @@
+package sarama
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificateFiles writes a self-signed client certificate with the
+// common name and its key to the PEM files
+func writeCertificateFiles(t *testing.T, certFile, keyFile, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		Subject:      pkix.Name{CommonName: commonName},
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: commonName}}, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func certificateCommonName(t *testing.T, cert *tls.Certificate) string {
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestCertificateFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "svid.pem"), filepath.Join(dir, "svid_key.pem")
+	provider := NewCertificateFileProvider(certFile, keyFile)
+
+	if _, err := provider(); err == nil {
+		t.Error("expected an error without the files")
+	}
+
+	writeCertificateFiles(t, certFile, keyFile, "first")
+	cert, err := provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := certificateCommonName(t, cert); name != "first" {
+		t.Errorf("expected the first certificate, got %s", name)
+	}
+	if again, _ := provider(); again != cert {
+		t.Error("expected the certificate to be reused while the files are unchanged")
+	}
+
+	writeCertificateFiles(t, certFile, keyFile, "second")
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(certFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if cert, err = provider(); err != nil {
+		t.Fatal(err)
+	}
+	if name := certificateCommonName(t, cert); name != "second" {
+		t.Errorf("expected the rotated certificate, got %s", name)
+	}
+
+	// a partially written rotation keeps the current certificate
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if current, err := provider(); err != nil || current != cert {
+		t.Errorf("expected the current certificate, got %v %v", current, err)
+	}
+}
+
+func TestBrokerTLSCertificateProvider(t *testing.T) {
+	listener, pool := newTLSListenerWithClientAuth(t, tls.RequireAnyClientCert)
+	mockBroker := NewMockBrokerListener(t, 1, listener)
+	defer mockBroker.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "svid.pem"), filepath.Join(dir, "svid_key.pem")
+	writeCertificateFiles(t, certFile, keyFile, "client")
+	provider := NewCertificateFileProvider(certFile, keyFile)
+
+	calls := 0
+	config := NewTestConfig()
+	config.Net.TLS.Enable = true
+	config.Net.TLS.Config = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	config.Net.TLS.CertificateProvider = func() (*tls.Certificate, error) {
+		calls++
+		return provider()
+	}
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	if connected, err := broker.Connected(); !connected {
+		t.Fatal(err)
+	}
+	safeClose(t, broker)
+
+	if calls != 1 {
+		t.Errorf("expected the provider to be called for the handshake, got %d calls", calls)
+	}
+	if config.Net.TLS.Config.GetClientCertificate != nil {
+		t.Error("expected Net.TLS.Config to be left unchanged")
+	}
+}
@@ -0,0 +1,92 @@
+package sarama
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrCertificateNotPinned is returned when none of the certificates presented
+// by a broker matches its pins, see Net.TLS.PinnedCertificates.
+var ErrCertificateNotPinned = errors.New("kafka: the certificates of the broker do not match its pins")
+
+// NewPinnedCertificateVerifier returns a function to use as the
+// VerifyPeerCertificate of a tls.Config, accepting the peer only if one of
+// the certificates it presents matches one of the pins, which are the base64
+// encoded SHA-256 hashes of either the DER encoded certificate or its
+// SubjectPublicKeyInfo, as computed with
+//
+//	openssl x509 -in cert.pem -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+//
+// The certificates are verified against the CAs as well unless
+// InsecureSkipVerify is set, e.g. for self-signed certificates.
+func NewPinnedCertificateVerifier(pins ...string) (func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error, error) {
+	hashes := make(map[[sha256.Size]byte]bool, len(pins))
+	for _, pin := range pins {
+		decoded, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("the pin %q is not a base64 encoded SHA-256 hash", pin)
+		}
+		var hash [sha256.Size]byte
+		copy(hash[:], decoded)
+		hashes[hash] = true
+	}
+
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		for _, raw := range rawCerts {
+			if hashes[sha256.Sum256(raw)] {
+				return nil
+			}
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			if hashes[sha256.Sum256(cert.RawSubjectPublicKeyInfo)] {
+				return nil
+			}
+		}
+		return ErrCertificateNotPinned
+	}, nil
+}
+
+// pinnedCertificates returns the pins of Net.TLS.PinnedCertificates applying
+// to the broker at addr
+func (conf *Config) pinnedCertificates(addr string) []string {
+	if pins, ok := conf.Net.TLS.PinnedCertificates[addr]; ok {
+		return pins
+	}
+	return conf.Net.TLS.PinnedCertificates["*"]
+}
+
+// withPinnedCertificates returns the TLS config of a connection to the
+// broker at addr, c derived from the base config, verifying the certificates
+// of the broker against its pins as well, if any.
+func (conf *Config) withPinnedCertificates(addr string, c, base *tls.Config) *tls.Config {
+	pins := conf.pinnedCertificates(addr)
+	if len(pins) == 0 {
+		return c
+	}
+	// the pins are checked by Validate
+	verify, err := NewPinnedCertificateVerifier(pins...)
+	if err != nil {
+		verify = func([][]byte, [][]*x509.Certificate) error { return err }
+	}
+
+	if c == base {
+		c = c.Clone()
+	}
+	if previous := c.VerifyPeerCertificate; previous != nil {
+		c.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			if err := previous(rawCerts, verifiedChains); err != nil {
+				return err
+			}
+			return verify(rawCerts, verifiedChains)
+		}
+	} else {
+		c.VerifyPeerCertificate = verify
+	}
+	return c
+}
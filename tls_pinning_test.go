@@ -0,0 +1,66 @@
+package sarama
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestBrokerTLSPinnedCertificates(t *testing.T) {
+	listener, pool := newTLSListener(t)
+	mockBroker := NewMockBrokerListener(t, 1, listener)
+	defer mockBroker.Close()
+
+	conn, err := tls.Dial("tcp", mockBroker.Addr(), &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := conn.ConnectionState().PeerCertificates[0]
+	_ = conn.Close()
+	spkiHash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	certHash := sha256.Sum256(cert.Raw)
+	otherHash := sha256.Sum256([]byte("other"))
+
+	for _, test := range []struct {
+		name      string
+		pins      map[string][]string
+		connected bool
+	}{
+		{"SPKI pin", map[string][]string{mockBroker.Addr(): {base64.StdEncoding.EncodeToString(spkiHash[:])}}, true},
+		{"certificate pin", map[string][]string{"*": {base64.StdEncoding.EncodeToString(certHash[:])}}, true},
+		{"other pin", map[string][]string{"*": {base64.StdEncoding.EncodeToString(otherHash[:])}}, false},
+		{"pins of another broker", map[string][]string{"other:9092": {base64.StdEncoding.EncodeToString(otherHash[:])}}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewTestConfig()
+			config.Net.TLS.Enable = true
+			config.Net.TLS.Config = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+			config.Net.TLS.PinnedCertificates = test.pins
+
+			broker := NewBroker(mockBroker.Addr())
+			if err := broker.Open(config); err != nil {
+				t.Fatal(err)
+			}
+			connected, err := broker.Connected()
+			if connected != test.connected {
+				t.Errorf("expected connected %t, got %t: %v", test.connected, connected, err)
+			}
+			if !test.connected && !errors.Is(err, ErrCertificateNotPinned) {
+				t.Errorf("expected ErrCertificateNotPinned, got %v", err)
+			}
+			if connected {
+				safeClose(t, broker)
+			}
+		})
+	}
+}
+
+func TestConfigPinnedCertificatesValidation(t *testing.T) {
+	config := NewTestConfig()
+	config.Net.TLS.PinnedCertificates = map[string][]string{"*": {"not a hash"}}
+	if err := config.Validate(); err == nil {
+		t.Error("expected invalid pins to fail the validation")
+	}
+}
@@ -0,0 +1,117 @@
+package sarama
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// ErrTokenProviderOpen is returned, wrapping the last failure, by the
+// AccessTokenProvider of NewTokenProviderBreaker while the provider it wraps
+// is not called after failing.
+var ErrTokenProviderOpen = errors.New("kafka: the access token provider is failing, backing off")
+
+// TokenProviderBreakerConfig configures NewTokenProviderBreaker.
+type TokenProviderBreakerConfig struct {
+	// Failures is the number of consecutive failures of the provider after
+	// which it is not called until the backoff elapsed (defaults to 3).
+	Failures int
+	// MinBackoff is how long the provider is not called after Failures
+	// failures, doubled each time it fails again once called again, up to
+	// MaxBackoff (defaults to 1s and 1m).
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnStateChange, if set, is called when the provider starts being
+	// backed off, with the failure, and when it succeeds again, with nil.
+	OnStateChange func(open bool, err error)
+	// MetricRegistry, when set, records the oauth-token-failure-rate and
+	// oauth-token-breaker-open-rate meters, e.g. Config.MetricRegistry.
+	MetricRegistry metrics.Registry
+	// MetricsRecorder, when set, records them instead of MetricRegistry,
+	// e.g. Config.MetricsRecorder.
+	MetricsRecorder MetricsRecorder
+}
+
+// tokenProviderBreaker is the circuit breaker of an AccessTokenProvider
+type tokenProviderBreaker struct {
+	provider AccessTokenProvider
+	conf     TokenProviderBreakerConfig
+
+	lock      sync.Mutex
+	failures  int
+	trips     int
+	openUntil time.Time
+	lastErr   error
+
+	failureRate MetricCounter
+	openRate    MetricCounter
+}
+
+// NewTokenProviderBreaker wraps the AccessTokenProvider of Net.SASL.TokenProvider
+// so that once it failed Failures times in a row it is not called again, the
+// connections failing immediately with ErrTokenProviderOpen, until a backoff
+// growing exponentially with every further failure elapsed. It keeps a failing
+// identity provider from being called on every connection attempt to every
+// broker.
+func NewTokenProviderBreaker(provider AccessTokenProvider, conf TokenProviderBreakerConfig) AccessTokenProvider {
+	if conf.Failures <= 0 {
+		conf.Failures = 3
+	}
+	if conf.MinBackoff <= 0 {
+		conf.MinBackoff = time.Second
+	}
+	if conf.MaxBackoff <= 0 {
+		conf.MaxBackoff = time.Minute
+	}
+	if conf.MaxBackoff < conf.MinBackoff {
+		conf.MaxBackoff = conf.MinBackoff
+	}
+
+	b := &tokenProviderBreaker{provider: provider, conf: conf}
+	if recorder := optionalMetricsRecorder(conf.MetricsRecorder, conf.MetricRegistry); recorder != nil {
+		b.failureRate = recorder.Counter("oauth-token-failure-rate", nil)
+		b.openRate = recorder.Counter("oauth-token-breaker-open-rate", nil)
+	}
+	return b
+}
+
+func (b *tokenProviderBreaker) Token() (*AccessToken, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if time.Now().Before(b.openUntil) {
+		return nil, fmt.Errorf("%w: %v", ErrTokenProviderOpen, b.lastErr)
+	}
+
+	token, err := b.provider.Token()
+	if err == nil {
+		if b.trips > 0 && b.conf.OnStateChange != nil {
+			b.conf.OnStateChange(false, nil)
+		}
+		b.failures, b.trips, b.lastErr = 0, 0, nil
+		return token, nil
+	}
+
+	if b.failureRate != nil {
+		b.failureRate.Add(1)
+	}
+	b.failures++
+	b.lastErr = err
+	// once backed off, each failed attempt backs off again longer
+	if b.failures >= b.conf.Failures || b.trips > 0 {
+		b.trips++
+		backoff := exponentialBackoff(b.conf.MinBackoff, b.conf.MaxBackoff, 0.2, b.trips)
+		b.openUntil = time.Now().Add(backoff)
+		if b.openRate != nil {
+			b.openRate.Add(1)
+		}
+		newContextLogger("failures", b.failures, "backoff", backoff).Warnf("Access token provider failed %d times, backing off for %s: %v", b.failures, backoff, err)
+		if b.trips == 1 && b.conf.OnStateChange != nil {
+			b.conf.OnStateChange(true, err)
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,62 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+type failingTokenProvider struct {
+	calls int
+	err   error
+}
+
+func (p *failingTokenProvider) Token() (*AccessToken, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &AccessToken{Token: "token"}, nil
+}
+
+func TestTokenProviderBreaker(t *testing.T) {
+	provider := &failingTokenProvider{err: errors.New("identity provider unavailable")}
+	registry := metrics.NewRegistry()
+	var states []bool
+	breaker := NewTokenProviderBreaker(provider, TokenProviderBreakerConfig{
+		Failures:       2,
+		MinBackoff:     time.Hour,
+		OnStateChange:  func(open bool, err error) { states = append(states, open) },
+		MetricRegistry: registry,
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := breaker.Token(); !errors.Is(err, provider.err) {
+			t.Errorf("expected the provider error, got %v", err)
+		}
+	}
+	if _, err := breaker.Token(); !errors.Is(err, ErrTokenProviderOpen) {
+		t.Errorf("expected ErrTokenProviderOpen, got %v", err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("expected the provider not to be called while open, got %d calls", provider.calls)
+	}
+
+	// once the backoff elapsed the provider is called again
+	provider.err = nil
+	breaker.(*tokenProviderBreaker).openUntil = time.Now()
+	if token, err := breaker.Token(); err != nil || token.Token != "token" {
+		t.Errorf("expected the token, got %v %v", token, err)
+	}
+	if len(states) != 2 || !states[0] || states[1] {
+		t.Errorf("expected the breaker to open then close, got %v", states)
+	}
+	if count := metrics.GetOrRegisterMeter("oauth-token-failure-rate", registry).Count(); count != 2 {
+		t.Errorf("expected 2 failures, got %d", count)
+	}
+	if count := metrics.GetOrRegisterMeter("oauth-token-breaker-open-rate", registry).Count(); count != 1 {
+		t.Errorf("expected the breaker to open once, got %d", count)
+	}
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/tools/tls"
+)
+
+var (
+	brokerList    = flag.String("brokers", os.Getenv("KAFKA_PEERS"), "The comma separated list of brokers in the Kafka cluster")
+	version       = flag.String("version", sarama.DefaultVersion.String(), "The assumed version of Kafka")
+	validateOnly  = flag.Bool("validate-only", false, "Whether to validate the changes without applying them")
+	timeout       = flag.Duration("timeout", 30*time.Second, "The maximum duration of the command")
+	verbose       = flag.Bool("verbose", false, "Whether to turn on sarama logging")
+	tlsEnabled    = flag.Bool("tls-enabled", false, "Whether to enable TLS")
+	tlsSkipVerify = flag.Bool("tls-skip-verify", false, "Whether skip TLS server cert verification")
+	tlsClientCert = flag.String("tls-client-cert", "", "Client cert for client authentication (use with -tls-enabled and -tls-client-key)")
+	tlsClientKey  = flag.String("tls-client-key", "", "Client key for client authentication (use with tls-enabled and -tls-client-cert)")
+
+	logger = log.New(os.Stderr, "", log.LstdFlags)
+)
+
+// command is an operation of the admin API, run with the arguments following
+// its resource and action, returning the result printed as JSON, if any
+type command struct {
+	resource, action string
+	description      string
+	run              func(admin sarama.ClusterAdmin, args []string) (interface{}, error)
+}
+
+var commands = []command{
+	{"topic", "list", "List the topics and their details", listTopics},
+	{"topic", "describe", "Describe the metadata of topics", describeTopics},
+	{"topic", "create", "Create a topic", createTopic},
+	{"topic", "alter", "Increase the number of partitions of a topic", alterTopic},
+	{"topic", "delete", "Delete a topic", deleteTopic},
+	{"config", "describe", "Describe the configuration of a topic, broker or broker logger", describeConfig},
+	{"config", "alter", "Set or delete configuration entries of a topic, broker or broker logger", alterConfig},
+	{"acl", "list", "List the ACLs matching a filter", listACLs},
+	{"acl", "create", "Create an ACL", createACL},
+	{"acl", "delete", "Delete the ACLs matching a filter", deleteACLs},
+	{"group", "list", "List the consumer groups and their protocol types", listGroups},
+	{"group", "describe", "Describe consumer groups and their members", describeGroups},
+	{"group", "reset-offsets", "Reset the committed offsets of an inactive consumer group", resetGroupOffsets},
+	{"reassignment", "list", "List the ongoing replica reassignments of a topic", listReassignments},
+	{"reassignment", "alter", "Reassign the replicas of partitions of a topic", alterReassignments},
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if *brokerList == "" {
+		printUsageErrorAndExit("You have to provide -brokers as a comma-separated list, or set the KAFKA_PEERS environment variable.")
+	}
+	if flag.NArg() < 2 {
+		printUsageErrorAndExit("You have to provide a resource and an action")
+	}
+	cmd := findCommand(flag.Arg(0), flag.Arg(1))
+	if cmd == nil {
+		printUsageErrorAndExit("Unknown command: %s %s", flag.Arg(0), flag.Arg(1))
+	}
+
+	if *verbose {
+		sarama.Logger = logger
+	}
+
+	config := sarama.NewConfig()
+	kafkaVersion, err := sarama.ParseKafkaVersion(*version)
+	if err != nil {
+		printUsageErrorAndExit("Unknown -version: %s", *version)
+	}
+	config.Version = kafkaVersion
+	if *tlsEnabled {
+		tlsConfig, err := tls.NewConfig(*tlsClientCert, *tlsClientKey)
+		if err != nil {
+			printErrorAndExit(69, "Failed to create TLS config: %s", err)
+		}
+
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+		config.Net.TLS.Config.InsecureSkipVerify = *tlsSkipVerify
+	}
+
+	admin, err := sarama.NewClusterAdmin(strings.Split(*brokerList, ","), config)
+	if err != nil {
+		printErrorAndExit(69, "Failed to create cluster admin: %s", err)
+	}
+	defer func() {
+		if err := admin.Close(); err != nil {
+			logger.Println("Failed to close cluster admin: ", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	view := admin.WithContext(ctx).WithOptions(sarama.AdminOptions{ValidateOnly: *validateOnly})
+
+	result, err := cmd.run(view, flag.Args()[2:])
+	if err != nil {
+		printErrorAndExit(69, "Failed to %s %s: %s", cmd.action, cmd.resource, err)
+	}
+	if result != nil {
+		printJSON(result)
+	}
+}
+
+func findCommand(resource, action string) *command {
+	for i := range commands {
+		if commands[i].resource == resource && commands[i].action == action {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func listTopics(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	parseFlags("topic list", args)
+	return admin.ListTopics()
+}
+
+func describeTopics(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("topic describe", flag.ExitOnError)
+	topics := flags.String("topics", "", "REQUIRED: the comma separated list of topics")
+	_ = flags.Parse(args)
+	requireFlag(flags, "topics", *topics)
+	return admin.DescribeTopics(strings.Split(*topics, ","))
+}
+
+func createTopic(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("topic create", flag.ExitOnError)
+	topic := flags.String("topic", "", "REQUIRED: the topic to create")
+	partitions := flags.Int("partitions", -1, "The number of partitions, -1 for the default of the brokers")
+	replicationFactor := flags.Int("replication-factor", -1, "The replication factor, -1 for the default of the brokers")
+	var configs keyValues
+	flags.Var(&configs, "config", "A configuration entry of the topic as key=value, can be repeated")
+	_ = flags.Parse(args)
+	requireFlag(flags, "topic", *topic)
+
+	detail := &sarama.TopicDetail{
+		NumPartitions:     int32(*partitions),
+		ReplicationFactor: int16(*replicationFactor),
+		ConfigEntries:     make(map[string]*string, len(configs)),
+	}
+	for key, value := range configs {
+		value := value
+		detail.ConfigEntries[key] = &value
+	}
+	return nil, admin.CreateTopic(*topic, detail, false)
+}
+
+func alterTopic(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("topic alter", flag.ExitOnError)
+	topic := flags.String("topic", "", "REQUIRED: the topic to alter")
+	partitions := flags.Int("partitions", 0, "REQUIRED: the new number of partitions")
+	_ = flags.Parse(args)
+	requireFlag(flags, "topic", *topic)
+	if *partitions <= 0 {
+		printFlagsErrorAndExit(flags, "-partitions is required")
+	}
+	return admin.CreateBalancedPartitions(*topic, int32(*partitions), false)
+}
+
+func deleteTopic(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("topic delete", flag.ExitOnError)
+	topic := flags.String("topic", "", "REQUIRED: the topic to delete")
+	_ = flags.Parse(args)
+	requireFlag(flags, "topic", *topic)
+	return nil, admin.DeleteTopic(*topic)
+}
+
+func describeConfig(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("config describe", flag.ExitOnError)
+	resourceType := flags.String("type", "topic", "The type of the resource, can be `topic`, `broker` or `broker-logger`")
+	name := flags.String("name", "", "REQUIRED: the name of the topic, or the ID of the broker")
+	_ = flags.Parse(args)
+	requireFlag(flags, "name", *name)
+	return admin.DescribeConfig(sarama.ConfigResource{Type: parseConfigResourceType(flags, *resourceType), Name: *name})
+}
+
+func alterConfig(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("config alter", flag.ExitOnError)
+	resourceType := flags.String("type", "topic", "The type of the resource, can be `topic`, `broker` or `broker-logger`")
+	name := flags.String("name", "", "REQUIRED: the name of the topic, or the ID of the broker")
+	var set keyValues
+	flags.Var(&set, "set", "A configuration entry to set as key=value, can be repeated")
+	var remove stringList
+	flags.Var(&remove, "delete", "The key of a configuration entry to delete, can be repeated")
+	_ = flags.Parse(args)
+	requireFlag(flags, "name", *name)
+	if len(set) == 0 && len(remove) == 0 {
+		printFlagsErrorAndExit(flags, "-set or -delete is required")
+	}
+
+	entries := make(map[string]sarama.IncrementalAlterConfigsEntry, len(set)+len(remove))
+	for key, value := range set {
+		value := value
+		entries[key] = sarama.IncrementalAlterConfigsEntry{Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &value}
+	}
+	for _, key := range remove {
+		entries[key] = sarama.IncrementalAlterConfigsEntry{Operation: sarama.IncrementalAlterConfigsOperationDelete}
+	}
+	return nil, admin.IncrementalAlterConfig(parseConfigResourceType(flags, *resourceType), *name, entries, false)
+}
+
+// aclFlags are the flags describing an ACL, or a filter of ACLs
+type aclFlags struct {
+	flags          *flag.FlagSet
+	resourceType   *string
+	resourceName   *string
+	patternType    *string
+	principal      *string
+	host           *string
+	operation      *string
+	permissionType *string
+}
+
+func newACLFlags(name string, filter bool) *aclFlags {
+	a := &aclFlags{flags: flag.NewFlagSet(name, flag.ExitOnError)}
+	if filter {
+		a.resourceType = a.flags.String("resource-type", "any", "The type of the resources to match, can be `any`, `topic`, `group`, `cluster`, `transactionalid` or `delegationtoken`")
+		a.resourceName = a.flags.String("resource-name", "", "The name of the resources to match, empty for any")
+		a.patternType = a.flags.String("pattern", "any", "The pattern type of the resource names to match, can be `any`, `match`, `literal` or `prefixed`")
+		a.principal = a.flags.String("principal", "", "The principal to match, such as User:alice, empty for any")
+		a.host = a.flags.String("host", "", "The host to match, empty for any")
+		a.operation = a.flags.String("operation", "any", "The operation to match, such as `any`, `read`, `write`, `describe` or `all`")
+		a.permissionType = a.flags.String("permission", "any", "The permission type to match, can be `any`, `allow` or `deny`")
+		return a
+	}
+	a.resourceType = a.flags.String("resource-type", "topic", "The type of the resource, can be `topic`, `group`, `cluster`, `transactionalid` or `delegationtoken`")
+	a.resourceName = a.flags.String("resource-name", "", "The name of the resource, required except for the cluster")
+	a.patternType = a.flags.String("pattern", "literal", "The pattern type of the resource name, can be `literal` or `prefixed`")
+	a.principal = a.flags.String("principal", "", "REQUIRED: the principal, such as User:alice")
+	a.host = a.flags.String("host", "*", "The host")
+	a.operation = a.flags.String("operation", "read", "The operation, such as `read`, `write`, `describe` or `all`")
+	a.permissionType = a.flags.String("permission", "allow", "The permission type, can be `allow` or `deny`")
+	return a
+}
+
+func (a *aclFlags) parseEnums() (resourceType sarama.AclResourceType, patternType sarama.AclResourcePatternType, operation sarama.AclOperation, permissionType sarama.AclPermissionType) {
+	for _, parse := range []struct {
+		name, value string
+		target      interface{ UnmarshalText([]byte) error }
+	}{
+		{"resource-type", *a.resourceType, &resourceType},
+		{"pattern", *a.patternType, &patternType},
+		{"operation", *a.operation, &operation},
+		{"permission", *a.permissionType, &permissionType},
+	} {
+		if err := parse.target.UnmarshalText([]byte(parse.value)); err != nil {
+			printFlagsErrorAndExit(a.flags, "Invalid -%s: %s", parse.name, err)
+		}
+	}
+	return
+}
+
+func (a *aclFlags) filter(args []string) sarama.AclFilter {
+	_ = a.flags.Parse(args)
+	resourceType, patternType, operation, permissionType := a.parseEnums()
+	return sarama.AclFilter{
+		Version:                   1,
+		ResourceType:              resourceType,
+		ResourceName:              optionalString(*a.resourceName),
+		ResourcePatternTypeFilter: patternType,
+		Principal:                 optionalString(*a.principal),
+		Host:                      optionalString(*a.host),
+		Operation:                 operation,
+		PermissionType:            permissionType,
+	}
+}
+
+func listACLs(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	return admin.ListAcls(newACLFlags("acl list", true).filter(args))
+}
+
+func createACL(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	a := newACLFlags("acl create", false)
+	_ = a.flags.Parse(args)
+	requireFlag(a.flags, "principal", *a.principal)
+	resourceType, patternType, operation, permissionType := a.parseEnums()
+	if resourceType != sarama.AclResourceCluster {
+		requireFlag(a.flags, "resource-name", *a.resourceName)
+	} else if *a.resourceName == "" {
+		*a.resourceName = "kafka-cluster"
+	}
+	resource := sarama.Resource{ResourceType: resourceType, ResourceName: *a.resourceName, ResourcePatternType: patternType}
+	acl := sarama.Acl{Principal: *a.principal, Host: *a.host, Operation: operation, PermissionType: permissionType}
+	return nil, admin.CreateACL(resource, acl)
+}
+
+func deleteACLs(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	return admin.DeleteACL(newACLFlags("acl delete", true).filter(args), false)
+}
+
+func listGroups(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	parseFlags("group list", args)
+	return admin.ListConsumerGroups()
+}
+
+func describeGroups(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("group describe", flag.ExitOnError)
+	groups := flags.String("groups", "", "REQUIRED: the comma separated list of consumer groups")
+	_ = flags.Parse(args)
+	requireFlag(flags, "groups", *groups)
+	return admin.DescribeConsumerGroups(strings.Split(*groups, ","))
+}
+
+func resetGroupOffsets(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("group reset-offsets", flag.ExitOnError)
+	group := flags.String("group", "", "REQUIRED: the consumer group")
+	to := flags.String("to", "", "REQUIRED: how to reset the offsets, can be `earliest`, `latest`, `timestamp`, `offset` or `shift`")
+	value := flags.Int64("value", 0, "The timestamp in milliseconds, offset or shift for -to=timestamp, offset or shift")
+	topic := flags.String("topic", "", "The topic whose offsets are reset, all the topics with committed offsets if empty")
+	partitions := flags.String("partitions", "all", "The partitions of -topic whose offsets are reset, can be 'all' or comma-separated numbers")
+	dryRun := flags.Bool("dry-run", false, "Whether to print the new offsets without committing them")
+	_ = flags.Parse(args)
+	requireFlag(flags, "group", *group)
+
+	spec := sarama.OffsetResetSpec{DryRun: *dryRun}
+	switch *to {
+	case "earliest":
+		spec.Strategy = sarama.ResetToEarliest
+	case "latest":
+		spec.Strategy = sarama.ResetToLatest
+	case "timestamp":
+		spec.Strategy, spec.Timestamp = sarama.ResetToTimestamp, *value
+	case "offset":
+		spec.Strategy, spec.Offset = sarama.ResetToOffset, *value
+	case "shift":
+		spec.Strategy, spec.Shift = sarama.ResetShiftBy, *value
+	default:
+		printFlagsErrorAndExit(flags, "-to should be `earliest`, `latest`, `timestamp`, `offset` or `shift`")
+	}
+	if *topic != "" {
+		var partitionList []int32
+		if *partitions != "all" {
+			partitionList = parsePartitions(flags, *partitions)
+		} else {
+			metadata, err := admin.DescribeTopics([]string{*topic})
+			if err != nil {
+				return nil, err
+			}
+			for _, topicMetadata := range metadata {
+				for _, partition := range topicMetadata.Partitions {
+					partitionList = append(partitionList, partition.ID)
+				}
+			}
+		}
+		spec.TopicPartitions = map[string][]int32{*topic: partitionList}
+	}
+	return admin.ResetConsumerGroupOffsets(*group, spec)
+}
+
+func listReassignments(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("reassignment list", flag.ExitOnError)
+	topic := flags.String("topic", "", "REQUIRED: the topic")
+	partitions := flags.String("partitions", "", "REQUIRED: the comma separated list of partitions")
+	_ = flags.Parse(args)
+	requireFlag(flags, "topic", *topic)
+	requireFlag(flags, "partitions", *partitions)
+	return admin.ListPartitionReassignments(*topic, parsePartitions(flags, *partitions))
+}
+
+func alterReassignments(admin sarama.ClusterAdmin, args []string) (interface{}, error) {
+	flags := flag.NewFlagSet("reassignment alter", flag.ExitOnError)
+	topic := flags.String("topic", "", "REQUIRED: the topic")
+	assignment := flags.String("assignment", "", "REQUIRED: the replicas of the partitions from 0 as comma separated broker IDs, separated by semicolons, such as '1,2;2,3'. The partitions without replicas are not reassigned")
+	_ = flags.Parse(args)
+	requireFlag(flags, "topic", *topic)
+	requireFlag(flags, "assignment", *assignment)
+
+	var replicas [][]int32
+	for _, partition := range strings.Split(*assignment, ";") {
+		if partition == "" {
+			replicas = append(replicas, nil)
+			continue
+		}
+		replicas = append(replicas, parsePartitions(flags, partition))
+	}
+	return nil, admin.AlterPartitionReassignments(*topic, replicas)
+}
+
+// keyValues is a flag.Value of key=value pairs, which can be repeated
+type keyValues map[string]string
+
+func (kv keyValues) String() string {
+	pairs := make([]string, 0, len(kv))
+	for key, value := range kv {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (kv *keyValues) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	if *kv == nil {
+		*kv = make(keyValues)
+	}
+	(*kv)[parts[0]] = parts[1]
+	return nil
+}
+
+// stringList is a flag.Value of strings, which can be repeated
+type stringList []string
+
+func (l stringList) String() string {
+	return strings.Join(l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func parseFlags(name string, args []string) {
+	_ = flag.NewFlagSet(name, flag.ExitOnError).Parse(args)
+}
+
+func parseConfigResourceType(flags *flag.FlagSet, s string) sarama.ConfigResourceType {
+	switch s {
+	case "topic":
+		return sarama.TopicResource
+	case "broker":
+		return sarama.BrokerResource
+	case "broker-logger":
+		return sarama.BrokerLoggerResource
+	}
+	printFlagsErrorAndExit(flags, "-type should be `topic`, `broker` or `broker-logger`")
+	panic("should not happen")
+}
+
+func parsePartitions(flags *flag.FlagSet, s string) []int32 {
+	var list []int32
+	for _, p := range strings.Split(s, ",") {
+		val, err := strconv.ParseInt(strings.TrimSpace(p), 10, 32)
+		if err != nil {
+			printFlagsErrorAndExit(flags, "Invalid number %q: %s", p, err)
+		}
+		list = append(list, int32(val))
+	}
+	return list
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func requireFlag(flags *flag.FlagSet, name, value string) {
+	if value == "" {
+		printFlagsErrorAndExit(flags, "-%s is required", name)
+	}
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		printErrorAndExit(70, "Failed to encode the result: %s", err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: kafka-admin [options] <resource> <action> [action options]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-28s %s\n", cmd.resource+" "+cmd.action, cmd.description)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run kafka-admin <resource> <action> -help for the options of a command.")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Available command line options:")
+	flag.PrintDefaults()
+}
+
+func printErrorAndExit(code int, format string, values ...interface{}) {
+	fmt.Fprintf(os.Stderr, "ERROR: %s\n", fmt.Sprintf(format, values...))
+	fmt.Fprintln(os.Stderr)
+	os.Exit(code)
+}
+
+func printUsageErrorAndExit(format string, values ...interface{}) {
+	fmt.Fprintf(os.Stderr, "ERROR: %s\n", fmt.Sprintf(format, values...))
+	fmt.Fprintln(os.Stderr)
+	usage()
+	os.Exit(64)
+}
+
+func printFlagsErrorAndExit(flags *flag.FlagSet, format string, values ...interface{}) {
+	fmt.Fprintf(os.Stderr, "ERROR: %s\n", fmt.Sprintf(format, values...))
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintf(os.Stderr, "Available options of %s:\n", flags.Name())
+	flags.PrintDefaults()
+	os.Exit(64)
+}
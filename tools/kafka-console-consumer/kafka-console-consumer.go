@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -10,6 +12,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/Shopify/sarama/tools/tls"
@@ -20,6 +23,10 @@ var (
 	topic         = flag.String("topic", "", "REQUIRED: the topic to consume")
 	partitions    = flag.String("partitions", "all", "The partitions to consume, can be 'all' or comma-separated numbers")
 	offset        = flag.String("offset", "newest", "The offset to start with. Can be `oldest`, `newest`")
+	fromTimestamp = flag.String("from-timestamp", "", "Start with the first messages produced at or after this time, either RFC 3339 or milliseconds since the epoch. Takes precedence over -offset")
+	group         = flag.String("group", "", "The consumer group to join, committing the offsets of the consumed messages. The partitions are assigned by the group instead of -partitions")
+	output        = flag.String("output", "text", "The format of the messages printed, can be `text` or `json`")
+	version       = flag.String("version", sarama.DefaultVersion.String(), "The assumed version of Kafka")
 	verbose       = flag.Bool("verbose", false, "Whether to turn on sarama logging")
 	tlsEnabled    = flag.Bool("tls-enabled", false, "Whether to enable TLS")
 	tlsSkipVerify = flag.Bool("tls-skip-verify", false, "Whether skip TLS server cert verification")
@@ -56,7 +63,25 @@ func main() {
 		printUsageErrorAndExit("-offset should be `oldest` or `newest`")
 	}
 
+	var from time.Time
+	if *fromTimestamp != "" {
+		var err error
+		if from, err = parseTimestamp(*fromTimestamp); err != nil {
+			printUsageErrorAndExit("-from-timestamp should be RFC 3339 or milliseconds since the epoch: %s", err)
+		}
+	}
+
+	if *output != "text" && *output != "json" {
+		printUsageErrorAndExit("-output should be `text` or `json`")
+	}
+
 	config := sarama.NewConfig()
+	kafkaVersion, err := sarama.ParseKafkaVersion(*version)
+	if err != nil {
+		printUsageErrorAndExit("Unknown -version: %s", *version)
+	}
+	config.Version = kafkaVersion
+	config.Consumer.Offsets.Initial = initialOffset
 	if *tlsEnabled {
 		tlsConfig, err := tls.NewConfig(*tlsClientCert, *tlsClientKey)
 		if err != nil {
@@ -68,7 +93,32 @@ func main() {
 		config.Net.TLS.Config.InsecureSkipVerify = *tlsSkipVerify
 	}
 
-	c, err := sarama.NewConsumer(strings.Split(*brokerList, ","), config)
+	client, err := sarama.NewClient(strings.Split(*brokerList, ","), config)
+	if err != nil {
+		printErrorAndExit(69, "Failed to create client: %s", err)
+	}
+	defer client.Close()
+
+	closing := make(chan struct{})
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+		<-signals
+		logger.Println("Initiating shutdown of consumer...")
+		close(closing)
+	}()
+
+	if *group != "" {
+		consumeGroup(client, from, closing)
+	} else {
+		consumePartitions(client, initialOffset, from, closing)
+	}
+}
+
+// consumePartitions consumes the partitions of the topic until closing is
+// closed
+func consumePartitions(client sarama.Client, initialOffset int64, from time.Time, closing chan struct{}) {
+	c, err := sarama.NewConsumerFromClient(client)
 	if err != nil {
 		printErrorAndExit(69, "Failed to start consumer: %s", err)
 	}
@@ -80,20 +130,18 @@ func main() {
 
 	var (
 		messages = make(chan *sarama.ConsumerMessage, *bufferSize)
-		closing  = make(chan struct{})
+		printed  = make(chan struct{})
 		wg       sync.WaitGroup
 	)
 
-	go func() {
-		signals := make(chan os.Signal, 1)
-		signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
-		<-signals
-		logger.Println("Initiating shutdown of consumer...")
-		close(closing)
-	}()
-
 	for _, partition := range partitionList {
-		pc, err := c.ConsumePartition(*topic, partition, initialOffset)
+		partitionOffset := initialOffset
+		if !from.IsZero() {
+			if partitionOffset, err = timestampOffset(client, partition, from); err != nil {
+				printErrorAndExit(69, "Failed to get the offset of partition %d at %s: %s", partition, from, err)
+			}
+		}
+		pc, err := c.ConsumePartition(*topic, partition, partitionOffset)
 		if err != nil {
 			printErrorAndExit(69, "Failed to start consumer for partition %d: %s", partition, err)
 		}
@@ -113,24 +161,180 @@ func main() {
 	}
 
 	go func() {
+		defer close(printed)
 		for msg := range messages {
-			fmt.Printf("Partition:\t%d\n", msg.Partition)
-			fmt.Printf("Offset:\t%d\n", msg.Offset)
-			fmt.Printf("Key:\t%s\n", string(msg.Key))
-			fmt.Printf("Value:\t%s\n", string(msg.Value))
-			fmt.Println()
+			printMessage(msg)
 		}
 	}()
 
 	wg.Wait()
 	logger.Println("Done consuming topic", *topic)
 	close(messages)
+	<-printed
 
 	if err := c.Close(); err != nil {
 		logger.Println("Failed to close consumer: ", err)
 	}
 }
 
+// consumeGroup consumes the partitions of the topic assigned to the member of
+// the consumer group until closing is closed, committing the offsets of the
+// printed messages
+func consumeGroup(client sarama.Client, from time.Time, closing chan struct{}) {
+	consumerGroup, err := sarama.NewConsumerGroupFromClient(*group, client)
+	if err != nil {
+		printErrorAndExit(69, "Failed to join consumer group %s: %s", *group, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-closing
+		cancel()
+	}()
+	go func() {
+		for err := range consumerGroup.Errors() {
+			logger.Println("Consumer group error:", err)
+		}
+	}()
+
+	handler := &groupHandler{client: client, from: from, reset: make(map[int32]bool)}
+	for ctx.Err() == nil {
+		if err := consumerGroup.Consume(ctx, []string{*topic}, handler); err != nil {
+			printErrorAndExit(69, "Failed to consume topic %s: %s", *topic, err)
+		}
+	}
+	logger.Println("Done consuming topic", *topic)
+
+	if err := consumerGroup.Close(); err != nil {
+		logger.Println("Failed to close consumer group: ", err)
+	}
+}
+
+// groupHandler prints the messages of the partitions claimed by the member
+// of the consumer group
+type groupHandler struct {
+	client sarama.Client
+	from   time.Time
+	// reset are the partitions whose offset was reset to from
+	reset map[int32]bool
+}
+
+// Setup moves the offsets of the claimed partitions back to the first
+// messages produced at or after -from-timestamp, the messages produced before
+// being skipped by ConsumeClaim
+func (h *groupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	if h.from.IsZero() {
+		return nil
+	}
+	for _, partition := range session.Claims()[*topic] {
+		if h.reset[partition] {
+			continue
+		}
+		offset, err := timestampOffset(h.client, partition, h.from)
+		if err != nil {
+			return err
+		}
+		session.ResetOffset(*topic, partition, offset, "")
+		h.reset[partition] = true
+	}
+	return nil
+}
+
+func (h *groupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		if !msg.Timestamp.IsZero() && msg.Timestamp.Before(h.from) {
+			session.MarkMessage(msg, "")
+			continue
+		}
+		printMessage(msg)
+		session.MarkMessage(msg, "")
+	}
+	return nil
+}
+
+// timestampOffset returns the offset of the first message of the partition
+// produced at or after from, or the newest offset if there is none
+func timestampOffset(client sarama.Client, partition int32, from time.Time) (int64, error) {
+	offset, err := client.GetOffset(*topic, partition, from.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return 0, err
+	}
+	if offset == -1 {
+		return client.GetOffset(*topic, partition, sarama.OffsetNewest)
+	}
+	return offset, nil
+}
+
+// parseTimestamp parses a timestamp either RFC 3339 or in milliseconds since
+// the epoch
+func parseTimestamp(s string) (time.Time, error) {
+	if millis, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, millis*int64(time.Millisecond)), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+type jsonHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type jsonMessage struct {
+	Topic     string       `json:"topic"`
+	Partition int32        `json:"partition"`
+	Offset    int64        `json:"offset"`
+	Timestamp time.Time    `json:"timestamp"`
+	Headers   []jsonHeader `json:"headers"`
+	Key       *string      `json:"key"`
+	Value     *string      `json:"value"`
+}
+
+func printMessage(msg *sarama.ConsumerMessage) {
+	if *output == "json" {
+		printJSONMessage(msg)
+		return
+	}
+	fmt.Printf("Partition:\t%d\n", msg.Partition)
+	fmt.Printf("Offset:\t%d\n", msg.Offset)
+	fmt.Printf("Key:\t%s\n", string(msg.Key))
+	fmt.Printf("Value:\t%s\n", string(msg.Value))
+	fmt.Println()
+}
+
+// printJSONMessage prints the message as a JSON object on a single line, its
+// null key or value being printed as null
+func printJSONMessage(msg *sarama.ConsumerMessage) {
+	out := jsonMessage{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp,
+		Headers:   make([]jsonHeader, 0, len(msg.Headers)),
+		Key:       nullableString(msg.Key),
+		Value:     nullableString(msg.Value),
+	}
+	for _, header := range msg.Headers {
+		out.Headers = append(out.Headers, jsonHeader{Key: string(header.Key), Value: string(header.Value)})
+	}
+	buf, err := json.Marshal(out)
+	if err != nil {
+		printErrorAndExit(70, "Failed to encode message %s/%d/%d: %s", msg.Topic, msg.Partition, msg.Offset, err)
+	}
+	fmt.Println(string(buf))
+}
+
+func nullableString(b []byte) *string {
+	if b == nil {
+		return nil
+	}
+	s := string(b)
+	return &s
+}
+
 func getPartitions(c sarama.Consumer) ([]int32, error) {
 	if *partitions == "all" {
 		return c.Partitions(*topic)
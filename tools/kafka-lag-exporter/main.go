@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/tools/tls"
+)
+
+var (
+	brokerList    = flag.String("brokers", os.Getenv("KAFKA_PEERS"), "The comma separated list of brokers in the Kafka cluster")
+	groups        = flag.String("groups", "", "The comma separated list of consumer groups to export the lag of, all the groups if empty")
+	listen        = flag.String("listen", ":9308", "The address to serve the metrics on")
+	path          = flag.String("path", "/metrics", "The HTTP path to serve the metrics on")
+	version       = flag.String("version", sarama.DefaultVersion.String(), "The assumed version of Kafka. Version 3.0.0 or later fetches the offsets of all the groups sharing a coordinator with a single request")
+	verbose       = flag.Bool("verbose", false, "Whether to turn on sarama logging")
+	tlsEnabled    = flag.Bool("tls-enabled", false, "Whether to enable TLS")
+	tlsSkipVerify = flag.Bool("tls-skip-verify", false, "Whether skip TLS server cert verification")
+	tlsClientCert = flag.String("tls-client-cert", "", "Client cert for client authentication (use with -tls-enabled and -tls-client-key)")
+	tlsClientKey  = flag.String("tls-client-key", "", "Client key for client authentication (use with tls-enabled and -tls-client-cert)")
+
+	logger = log.New(os.Stderr, "", log.LstdFlags)
+)
+
+func main() {
+	flag.Parse()
+
+	if *brokerList == "" {
+		printUsageErrorAndExit("You have to provide -brokers as a comma-separated list, or set the KAFKA_PEERS environment variable.")
+	}
+
+	if *verbose {
+		sarama.Logger = logger
+	}
+
+	config := sarama.NewConfig()
+	kafkaVersion, err := sarama.ParseKafkaVersion(*version)
+	if err != nil {
+		printUsageErrorAndExit("Unknown -version: %s", *version)
+	}
+	config.Version = kafkaVersion
+	if *tlsEnabled {
+		tlsConfig, err := tls.NewConfig(*tlsClientCert, *tlsClientKey)
+		if err != nil {
+			printErrorAndExit(69, "Failed to create TLS config: %s", err)
+		}
+
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+		config.Net.TLS.Config.InsecureSkipVerify = *tlsSkipVerify
+	}
+
+	admin, err := sarama.NewClusterAdmin(strings.Split(*brokerList, ","), config)
+	if err != nil {
+		printErrorAndExit(69, "Failed to create cluster admin: %s", err)
+	}
+	defer admin.Close()
+
+	var groupList []string
+	if *groups != "" {
+		groupList = strings.Split(*groups, ",")
+	}
+	exporter := &lagExporter{admin: admin, groups: groupList}
+
+	http.Handle(*path, exporter)
+	logger.Printf("Serving the consumer lag on %s%s", *listen, *path)
+	if err := http.ListenAndServe(*listen, nil); err != nil {
+		printErrorAndExit(69, "Failed to serve the metrics: %s", err)
+	}
+}
+
+// lagExporter computes the lag of the consumer groups on each scrape and
+// writes it in the Prometheus text format
+type lagExporter struct {
+	admin  sarama.ClusterAdmin
+	groups []string
+
+	// lock serializes the scrapes, which would otherwise query the brokers
+	// concurrently for the same offsets
+	lock gosync.Mutex
+}
+
+// partitionLag is the lag of a consumer group on a partition
+type partitionLag struct {
+	group     string
+	topic     string
+	partition int32
+	committed int64
+	newest    int64
+}
+
+func (e *lagExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	start := time.Now()
+	lags, err := e.collect()
+	up := 1
+	if err != nil {
+		logger.Println("Failed to collect the consumer lag:", err)
+		up = 0
+	}
+
+	var buf bytes.Buffer
+	writeLags(&buf, lags)
+	writeHeader(&buf, "kafka_lag_exporter_up", "Whether the last scrape of the consumer lag succeeded.")
+	fmt.Fprintf(&buf, "kafka_lag_exporter_up %d\n", up)
+	writeHeader(&buf, "kafka_lag_exporter_scrape_duration_seconds", "The duration of the last scrape of the consumer lag.")
+	fmt.Fprintf(&buf, "kafka_lag_exporter_scrape_duration_seconds %g\n", time.Since(start).Seconds())
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// collect fetches the committed offsets of the consumer groups in batches,
+// and the newest offsets of their partitions, with a request per leader
+func (e *lagExporter) collect() ([]partitionLag, error) {
+	groupList := e.groups
+	if len(groupList) == 0 {
+		all, err := e.admin.ListConsumerGroups()
+		if err != nil {
+			return nil, err
+		}
+		for group := range all {
+			groupList = append(groupList, group)
+		}
+	}
+
+	request := make(map[string]map[string][]int32, len(groupList))
+	for _, group := range groupList {
+		// all the committed offsets of the group
+		request[group] = nil
+	}
+	committed, err := e.admin.ListConsumerGroupOffsetsBatch(request)
+	if err != nil {
+		return nil, err
+	}
+
+	topicPartitions := make(map[string][]int32)
+	seen := make(map[string]map[int32]bool)
+	for group, response := range committed {
+		if response.Err != sarama.ErrNoError {
+			logger.Printf("Failed to fetch the offsets of group %s: %s", group, response.Err)
+			continue
+		}
+		for topic, blocks := range response.Blocks {
+			if seen[topic] == nil {
+				seen[topic] = make(map[int32]bool)
+			}
+			for partition := range blocks {
+				if !seen[topic][partition] {
+					seen[topic][partition] = true
+					topicPartitions[topic] = append(topicPartitions[topic], partition)
+				}
+			}
+		}
+	}
+	if len(topicPartitions) == 0 {
+		return nil, nil
+	}
+
+	newest, err := e.admin.ListOffsets(topicPartitions, sarama.OffsetNewest)
+	if err != nil {
+		return nil, err
+	}
+
+	var lags []partitionLag
+	for group, response := range committed {
+		for topic, blocks := range response.Blocks {
+			for partition, block := range blocks {
+				if block.Err != sarama.ErrNoError || block.Offset < 0 {
+					continue
+				}
+				newestBlock := newest[topic][partition]
+				if newestBlock == nil || newestBlock.Err != sarama.ErrNoError {
+					continue
+				}
+				lags = append(lags, partitionLag{
+					group:     group,
+					topic:     topic,
+					partition: partition,
+					committed: block.Offset,
+					newest:    offsetOf(newestBlock),
+				})
+			}
+		}
+	}
+	sort.Slice(lags, func(i, j int) bool {
+		if lags[i].group != lags[j].group {
+			return lags[i].group < lags[j].group
+		}
+		if lags[i].topic != lags[j].topic {
+			return lags[i].topic < lags[j].topic
+		}
+		return lags[i].partition < lags[j].partition
+	})
+	return lags, nil
+}
+
+// offsetOf returns the offset of a ListOffsets block, which is in Offsets
+// for version 0
+func offsetOf(block *sarama.OffsetResponseBlock) int64 {
+	if len(block.Offsets) > 0 {
+		return block.Offsets[0]
+	}
+	return block.Offset
+}
+
+// lag returns the number of messages produced to the partition which the
+// group did not commit yet
+func (l partitionLag) lag() int64 {
+	if l.newest < l.committed {
+		return 0
+	}
+	return l.newest - l.committed
+}
+
+func writeLags(buf *bytes.Buffer, lags []partitionLag) {
+	writeHeader(buf, "kafka_consumergroup_lag", "The number of messages of the partition the consumer group did not commit yet.")
+	for _, l := range lags {
+		fmt.Fprintf(buf, "kafka_consumergroup_lag{%s} %d\n", partitionLabels(l), l.lag())
+	}
+
+	writeHeader(buf, "kafka_consumergroup_current_offset", "The offset committed by the consumer group for the partition.")
+	for _, l := range lags {
+		fmt.Fprintf(buf, "kafka_consumergroup_current_offset{%s} %d\n", partitionLabels(l), l.committed)
+	}
+
+	writeHeader(buf, "kafka_consumergroup_lag_sum", "The number of messages of the topic the consumer group did not commit yet.")
+	var sum int64
+	for i, l := range lags {
+		sum += l.lag()
+		if i == len(lags)-1 || lags[i+1].group != l.group || lags[i+1].topic != l.topic {
+			fmt.Fprintf(buf, "kafka_consumergroup_lag_sum{group=\"%s\",topic=\"%s\"} %d\n", escapeLabel(l.group), escapeLabel(l.topic), sum)
+			sum = 0
+		}
+	}
+
+	writeHeader(buf, "kafka_topic_partition_newest_offset", "The offset of the next message produced to the partition.")
+	newest := make(map[string]map[int32]int64)
+	for _, l := range lags {
+		if newest[l.topic] == nil {
+			newest[l.topic] = make(map[int32]int64)
+		}
+		newest[l.topic][l.partition] = l.newest
+	}
+	topics := make([]string, 0, len(newest))
+	for topic := range newest {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		partitions := make([]int32, 0, len(newest[topic]))
+		for partition := range newest[topic] {
+			partitions = append(partitions, partition)
+		}
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+		for _, partition := range partitions {
+			fmt.Fprintf(buf, "kafka_topic_partition_newest_offset{topic=\"%s\",partition=\"%d\"} %d\n", escapeLabel(topic), partition, newest[topic][partition])
+		}
+	}
+}
+
+func writeHeader(buf *bytes.Buffer, name, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+func partitionLabels(l partitionLag) string {
+	return fmt.Sprintf("group=\"%s\",topic=\"%s\",partition=\"%d\"", escapeLabel(l.group), escapeLabel(l.topic), l.partition)
+}
+
+// labelReplacer escapes the backslashes, double quotes and line feeds of
+// label values, as required by the Prometheus text format
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+func printErrorAndExit(code int, format string, values ...interface{}) {
+	fmt.Fprintf(os.Stderr, "ERROR: %s\n", fmt.Sprintf(format, values...))
+	fmt.Fprintln(os.Stderr)
+	os.Exit(code)
+}
+
+func printUsageErrorAndExit(format string, values ...interface{}) {
+	fmt.Fprintf(os.Stderr, "ERROR: %s\n", fmt.Sprintf(format, values...))
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Available command line options:")
+	flag.PrintDefaults()
+	os.Exit(64)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	gosync "sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// latencyStats collects the produce latencies of the acknowledged messages,
+// from the moment they are sent to the producer, over the current reporting
+// window and, sampled in microseconds, over the whole run
+type latencyStats struct {
+	lock        gosync.Mutex
+	start       time.Time
+	windowStart time.Time
+	window      []time.Duration
+	total       metrics.Histogram
+	failed      int
+	committed   int
+	aborted     int
+}
+
+func newLatencyStats() *latencyStats {
+	now := time.Now()
+	return &latencyStats{
+		start:       now,
+		windowStart: now,
+		total:       metrics.NewHistogram(metrics.NewUniformSample(1 << 16)),
+	}
+}
+
+func (s *latencyStats) record(latency time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.window = append(s.window, latency)
+	s.total.Update(int64(latency / time.Microsecond))
+}
+
+func (s *latencyStats) recordFailure() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failed++
+}
+
+func (s *latencyStats) recordTransaction(committed bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if committed {
+		s.committed++
+	} else {
+		s.aborted++
+	}
+}
+
+// printWindow prints the throughput and latency percentiles of the messages
+// acknowledged since the previous window, and starts a new one
+func (s *latencyStats) printWindow(w io.Writer) {
+	s.lock.Lock()
+	now := time.Now()
+	window, elapsed := s.window, now.Sub(s.windowStart)
+	s.window, s.windowStart = nil, now
+	s.lock.Unlock()
+
+	fmt.Fprintf(w, "window: %d records acked in %.1fs, %.1f records/sec, %s\n",
+		len(window), elapsed.Seconds(), float64(len(window))/elapsed.Seconds(), formatPercentiles(window))
+}
+
+// printTotal prints the throughput and latency percentiles of the whole run
+func (s *latencyStats) printTotal(w io.Writer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	elapsed := time.Since(s.start)
+	total := s.total.Snapshot()
+	percentiles := total.Percentiles([]float64{0.5, 0.95, 0.99})
+
+	fmt.Fprintf(w, "total: %d records acked, %d failed in %.1fs, %.1f records/sec, "+
+		"%.1f ms p50, %.1f ms p95, %.1f ms p99, %.1f ms max produce latency\n",
+		total.Count(), s.failed, elapsed.Seconds(), float64(total.Count())/elapsed.Seconds(),
+		percentiles[0]/1000, percentiles[1]/1000, percentiles[2]/1000, float64(total.Max())/1000)
+	if s.committed > 0 || s.aborted > 0 {
+		fmt.Fprintf(w, "total: %d transactions committed, %d aborted\n", s.committed, s.aborted)
+	}
+}
+
+func formatPercentiles(latencies []time.Duration) string {
+	if len(latencies) == 0 {
+		return "no produce latency"
+	}
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return fmt.Sprintf("%.1f ms p50, %.1f ms p95, %.1f ms p99, %.1f ms max produce latency",
+		percentile(sorted, 0.5), percentile(sorted, 0.95), percentile(sorted, 0.99), milliseconds(sorted[len(sorted)-1]))
+}
+
+// percentile returns the p-th percentile of the sorted latencies in
+// milliseconds, using the nearest-rank method
+func percentile(sorted []time.Duration, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return milliseconds(sorted[rank])
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
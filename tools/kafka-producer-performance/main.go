@@ -28,7 +28,12 @@ var (
 	messageLoad = flag.Int(
 		"message-load",
 		0,
-		"REQUIRED: The number of messages to produce to -topic.",
+		"REQUIRED unless -duration is set: The number of messages to produce to -topic.",
+	)
+	duration = flag.Duration(
+		"duration",
+		0,
+		"The duration to produce messages for instead of -message-load, at the rate set by -throughput (-sync=false only).",
 	)
 	messageSize = flag.Int(
 		"message-size",
@@ -80,6 +85,26 @@ var (
 		0,
 		"The maximum number of messages to send per second (0 for no limit).",
 	)
+	idempotent = flag.Bool(
+		"idempotent",
+		false,
+		"Use an idempotent producer, which implies -required-acks=-1 and -max-open-requests=1 and requires -version >= 0.11.0.0.",
+	)
+	transactionalID = flag.String(
+		"transactional-id",
+		"",
+		"Use a transactional producer with this transactional ID, which implies -idempotent (-sync=false only).",
+	)
+	transactionSize = flag.Int(
+		"transaction-size",
+		1000,
+		"The number of messages produced in each transaction with -transactional-id.",
+	)
+	reportInterval = flag.Duration(
+		"report-interval",
+		5*time.Second,
+		"The interval between the reports of the metrics, and of the throughput and produce latency of the window.",
+	)
 	maxOpenRequests = flag.Int(
 		"max-open-requests",
 		5,
@@ -216,6 +241,25 @@ func generateMessages(topic string, partition, messageLoad, messageSize int) []*
 	return messages
 }
 
+// generateMessagesFor returns a function generating messages until the given
+// duration elapsed, reusing a set of random payloads
+func generateMessagesFor(topic string, partition, messageSize int, duration time.Duration) func() *sarama.ProducerMessage {
+	payloads := generateMessages(topic, partition, 1000, messageSize)
+	deadline := time.Now().Add(duration)
+	i := 0
+	return func() *sarama.ProducerMessage {
+		if time.Now().After(deadline) {
+			return nil
+		}
+		i++
+		return &sarama.ProducerMessage{
+			Topic:     topic,
+			Partition: int32(partition),
+			Value:     payloads[i%len(payloads)].Value,
+		}
+	}
+}
+
 func main() {
 	flag.Parse()
 
@@ -225,15 +269,30 @@ func main() {
 	if *topic == "" {
 		printUsageErrorAndExit("-topic is required")
 	}
-	if *messageLoad <= 0 {
+	if *duration < 0 {
+		printUsageErrorAndExit("-duration must be greater than or equal to 0")
+	}
+	if *duration > 0 && *sync {
+		printUsageErrorAndExit("-duration is not supported with -sync")
+	}
+	if *duration == 0 && *messageLoad <= 0 {
 		printUsageErrorAndExit("-message-load must be greater than 0")
 	}
 	if *messageSize <= 0 {
 		printUsageErrorAndExit("-message-size must be greater than 0")
 	}
-	if *routines < 1 || *routines > *messageLoad {
+	if *sync && (*routines < 1 || *routines > *messageLoad) {
 		printUsageErrorAndExit("-routines must be greater than 0 and less than or equal to -message-load")
 	}
+	if *transactionalID != "" && *sync {
+		printUsageErrorAndExit("-transactional-id is not supported with -sync")
+	}
+	if *transactionalID != "" && *transactionSize <= 0 {
+		printUsageErrorAndExit("-transaction-size must be greater than 0")
+	}
+	if *reportInterval <= 0 {
+		printUsageErrorAndExit("-report-interval must be greater than 0")
+	}
 	if *securityProtocol != "PLAINTEXT" && *securityProtocol != "SSL" {
 		printUsageErrorAndExit(fmt.Sprintf("-security-protocol %q is not supported", *securityProtocol))
 	}
@@ -257,6 +316,12 @@ func main() {
 	config.ClientID = *clientID
 	config.ChannelBufferSize = *channelBufferSize
 	config.Version = parseVersion(*version)
+	if *idempotent || *transactionalID != "" {
+		config.Producer.Idempotent = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Net.MaxOpenRequests = 1
+		config.Producer.Transaction.ID = *transactionalID
+	}
 
 	if *securityProtocol == "SSL" {
 		tlsConfig, err := tls.NewConfig(*tlsClientCert, *tlsClientKey)
@@ -286,16 +351,20 @@ func main() {
 		printErrorAndExit(69, "Invalid configuration: %s", err)
 	}
 
+	stats := newLatencyStats()
+
 	// Print out metrics periodically.
 	done := make(chan struct{})
 	ctx, cancel := context.WithCancel(context.Background())
 	go func(ctx context.Context) {
 		defer close(done)
-		t := time.Tick(5 * time.Second)
+		t := time.NewTicker(*reportInterval)
+		defer t.Stop()
 		for {
 			select {
-			case <-t:
+			case <-t.C:
 				printMetrics(os.Stdout, config.MetricRegistry)
+				stats.printWindow(os.Stdout)
 			case <-ctx.Done():
 				return
 			}
@@ -305,10 +374,10 @@ func main() {
 	brokers := strings.Split(*brokers, ",")
 	if *sync {
 		runSyncProducer(*topic, *partition, *messageLoad, *messageSize, *routines,
-			config, brokers, *throughput)
+			config, brokers, *throughput, stats)
 	} else {
 		runAsyncProducer(*topic, *partition, *messageLoad, *messageSize,
-			config, brokers, *throughput)
+			config, brokers, *throughput, *duration, *transactionSize, stats)
 	}
 
 	cancel()
@@ -316,55 +385,113 @@ func main() {
 
 	// Print final metrics.
 	printMetrics(os.Stdout, config.MetricRegistry)
+	stats.printTotal(os.Stdout)
 }
 
+// runAsyncProducer produces messageLoad messages, or messages for the given
+// duration if not 0, in transactions of transactionSize messages if the
+// producer is transactional
 func runAsyncProducer(topic string, partition, messageLoad, messageSize int,
-	config *sarama.Config, brokers []string, throughput int) {
+	config *sarama.Config, brokers []string, throughput int, duration time.Duration,
+	transactionSize int, stats *latencyStats) {
 	producer, err := sarama.NewAsyncProducer(brokers, config)
 	if err != nil {
 		printErrorAndExit(69, "Failed to create producer: %s", err)
 	}
-	defer func() {
-		if err := producer.Close(); err != nil {
-			printErrorAndExit(69, "Failed to close producer: %s", err)
-		}
-	}()
-
-	messages := generateMessages(topic, partition, messageLoad, messageSize)
 
 	messagesDone := make(chan struct{})
 	go func() {
-		for i := 0; i < messageLoad; i++ {
+		defer close(messagesDone)
+		successes, errors := producer.Successes(), producer.Errors()
+		for successes != nil || errors != nil {
 			select {
-			case <-producer.Successes():
-			case err = <-producer.Errors():
-				printErrorAndExit(69, "%s", err)
+			case msg, ok := <-successes:
+				if !ok {
+					successes = nil
+					continue
+				}
+				stats.record(time.Since(msg.Metadata.(time.Time)))
+			case err, ok := <-errors:
+				if !ok {
+					errors = nil
+					continue
+				}
+				if !producer.IsTransactional() {
+					printErrorAndExit(69, "%s", err)
+				}
+				// the transaction of the message is aborted
+				stats.recordFailure()
 			}
 		}
-		messagesDone <- struct{}{}
 	}()
 
-	if throughput > 0 {
-		ticker := time.NewTicker(time.Second)
-		for idx, message := range messages {
-			producer.Input() <- message
-			if (idx+1)%throughput == 0 {
-				<-ticker.C
+	var next func() *sarama.ProducerMessage
+	if duration > 0 {
+		next = generateMessagesFor(topic, partition, messageSize, duration)
+	} else {
+		messages := generateMessages(topic, partition, messageLoad, messageSize)
+		next = func() *sarama.ProducerMessage {
+			if len(messages) == 0 {
+				return nil
 			}
+			message := messages[0]
+			messages = messages[1:]
+			return message
 		}
-		ticker.Stop()
-	} else {
-		for _, message := range messages {
-			producer.Input() <- message
+	}
+
+	start := time.Now()
+	sent := 0
+	for message := next(); message != nil; message = next() {
+		if producer.IsTransactional() && sent%transactionSize == 0 {
+			if sent > 0 {
+				endTransaction(producer, stats)
+			}
+			if err := producer.BeginTxn(); err != nil {
+				printErrorAndExit(69, "Failed to begin transaction: %s", err)
+			}
+		}
+		if throughput > 0 {
+			// pace the messages evenly to sustain the rate
+			due := start.Add(time.Duration(sent) * time.Second / time.Duration(throughput))
+			if wait := time.Until(due); wait > 0 {
+				time.Sleep(wait)
+			}
 		}
+		message.Metadata = time.Now()
+		producer.Input() <- message
+		sent++
+	}
+	if producer.IsTransactional() && sent > 0 {
+		endTransaction(producer, stats)
 	}
 
+	if err := producer.Close(); err != nil {
+		printErrorAndExit(69, "Failed to close producer: %s", err)
+	}
 	<-messagesDone
-	close(messagesDone)
+}
+
+// endTransaction commits the transaction of the producer, aborting it if one
+// of its messages failed
+func endTransaction(producer sarama.AsyncProducer, stats *latencyStats) {
+	err := producer.CommitTxn()
+	if err == nil {
+		stats.recordTransaction(true)
+		return
+	}
+	if producer.TxnStatus() != sarama.TxnAbortableError {
+		printErrorAndExit(69, "Failed to commit transaction: %s", err)
+	}
+	fmt.Fprintf(os.Stderr, "Aborting transaction: %s\n", err)
+	if err := producer.AbortTxn(); err != nil {
+		printErrorAndExit(69, "Failed to abort transaction: %s", err)
+	}
+	stats.recordTransaction(false)
 }
 
 func runSyncProducer(topic string, partition, messageLoad, messageSize, routines int,
-	config *sarama.Config, brokers []string, throughput int) {
+	config *sarama.Config, brokers []string, throughput int, stats *latencyStats) {
 	producer, err := sarama.NewSyncProducer(brokers, config)
 	if err != nil {
 		printErrorAndExit(69, "Failed to create producer: %s", err)
@@ -393,10 +520,7 @@ func runSyncProducer(topic string, partition, messageLoad, messageSize, routines
 				ticker := time.NewTicker(time.Second)
 				for _, message := range messages {
 					for i := 0; i < throughput; i++ {
-						_, _, err = producer.SendMessage(message)
-						if err != nil {
-							printErrorAndExit(69, "Failed to send message: %s", err)
-						}
+						sendMessage(producer, message, stats)
 					}
 					<-ticker.C
 				}
@@ -410,10 +534,7 @@ func runSyncProducer(topic string, partition, messageLoad, messageSize, routines
 			wg.Add(1)
 			go func() {
 				for _, message := range messages {
-					_, _, err = producer.SendMessage(message)
-					if err != nil {
-						printErrorAndExit(69, "Failed to send message: %s", err)
-					}
+					sendMessage(producer, message, stats)
 				}
 				wg.Done()
 			}()
@@ -422,6 +543,14 @@ func runSyncProducer(topic string, partition, messageLoad, messageSize, routines
 	wg.Wait()
 }
 
+func sendMessage(producer sarama.SyncProducer, message *sarama.ProducerMessage, stats *latencyStats) {
+	sent := time.Now()
+	if _, _, err := producer.SendMessage(message); err != nil {
+		printErrorAndExit(69, "Failed to send message: %s", err)
+	}
+	stats.record(time.Since(sent))
+}
+
 func printMetrics(w io.Writer, r metrics.Registry) {
 	recordSendRateMetric := r.Get("record-send-rate")
 	requestLatencyMetric := r.Get("request-latency-in-ms")
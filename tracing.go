@@ -0,0 +1,254 @@
+package sarama
+
+import (
+	"context"
+	"sync"
+)
+
+// Tracer starts the spans of the distributed tracing of the clients, see
+// Config.Tracing. It is a small subset of the OpenTelemetry tracer, so that
+// tracing does not require Sarama to depend on a given SDK: an adapter
+// wrapping trace.Tracer and trace.Span is enough to report to OpenTelemetry.
+type Tracer interface {
+	// Start starts a span of the kind as a child of the span of ctx, if any,
+	// returning the context of the new span.
+	Start(ctx context.Context, name string, kind SpanKind) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttribute sets an attribute of the span, whose value is a string,
+	// an int64 or an int32.
+	SetAttribute(key string, value interface{})
+	// End ends the span, which failed with err if not nil.
+	End(err error)
+}
+
+// SpanKind is the kind of a span, as defined by OpenTelemetry.
+type SpanKind int8
+
+const (
+	// SpanKindClient is the kind of the spans around the requests sent to
+	// the brokers.
+	SpanKindClient SpanKind = iota
+	// SpanKindProducer is the kind of the spans of the messages produced.
+	SpanKindProducer
+	// SpanKindConsumer is the kind of the spans of the messages consumed.
+	SpanKindConsumer
+)
+
+// TextMapPropagator injects the context of a span into the headers of a
+// message and extracts it on the other side, as the propagators of
+// OpenTelemetry do.
+type TextMapPropagator interface {
+	Inject(ctx context.Context, carrier TextMapCarrier)
+	Extract(ctx context.Context, carrier TextMapCarrier) context.Context
+}
+
+// TextMapCarrier is the carrier of a TextMapPropagator, which has the same
+// methods as the one of OpenTelemetry.
+type TextMapCarrier interface {
+	Get(key string) string
+	Set(key, value string)
+	Keys() []string
+}
+
+// ProducerMessageCarrier is the TextMapCarrier of the headers of a
+// ProducerMessage.
+type ProducerMessageCarrier struct {
+	msg *ProducerMessage
+}
+
+// NewProducerMessageCarrier returns the carrier of the headers of msg.
+func NewProducerMessageCarrier(msg *ProducerMessage) ProducerMessageCarrier {
+	return ProducerMessageCarrier{msg: msg}
+}
+
+func (c ProducerMessageCarrier) Get(key string) string {
+	for _, header := range c.msg.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set sets the header, replacing the one of the same key if any.
+func (c ProducerMessageCarrier) Set(key, value string) {
+	for i, header := range c.msg.Headers {
+		if string(header.Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c ProducerMessageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, header := range c.msg.Headers {
+		keys = append(keys, string(header.Key))
+	}
+	return keys
+}
+
+// ConsumerMessageCarrier is the TextMapCarrier of the headers of a
+// ConsumerMessage.
+type ConsumerMessageCarrier struct {
+	msg *ConsumerMessage
+}
+
+// NewConsumerMessageCarrier returns the carrier of the headers of msg.
+func NewConsumerMessageCarrier(msg *ConsumerMessage) ConsumerMessageCarrier {
+	return ConsumerMessageCarrier{msg: msg}
+}
+
+func (c ConsumerMessageCarrier) Get(key string) string {
+	for _, header := range c.msg.Headers {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set sets the header, replacing the one of the same key if any.
+func (c ConsumerMessageCarrier) Set(key, value string) {
+	for _, header := range c.msg.Headers {
+		if header != nil && string(header.Key) == key {
+			header.Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, &RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c ConsumerMessageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, header := range c.msg.Headers {
+		if header != nil {
+			keys = append(keys, string(header.Key))
+		}
+	}
+	return keys
+}
+
+// InjectProducerMessageContext injects the span of ctx into the headers of
+// msg, which makes it the parent of the span of the message with
+// Config.Tracing.
+func InjectProducerMessageContext(ctx context.Context, propagator TextMapPropagator, msg *ProducerMessage) {
+	propagator.Inject(ctx, NewProducerMessageCarrier(msg))
+}
+
+// ExtractConsumerMessageContext returns ctx with the span injected into the
+// headers of msg by its producer, if any.
+func ExtractConsumerMessageContext(ctx context.Context, propagator TextMapPropagator, msg *ConsumerMessage) context.Context {
+	return propagator.Extract(ctx, NewConsumerMessageCarrier(msg))
+}
+
+// StartConsumerMessageSpan starts the span of the processing of msg, as a
+// child of the span of its producer, to be ended by the caller once done.
+func StartConsumerMessageSpan(ctx context.Context, tracer Tracer, propagator TextMapPropagator, msg *ConsumerMessage) (context.Context, Span) {
+	if propagator != nil {
+		ctx = ExtractConsumerMessageContext(ctx, propagator, msg)
+	}
+	ctx, span := tracer.Start(ctx, msg.Topic+" receive", SpanKindConsumer)
+	span.SetAttribute("messaging.system", "kafka")
+	span.SetAttribute("messaging.destination.name", msg.Topic)
+	span.SetAttribute("messaging.kafka.destination.partition", msg.Partition)
+	span.SetAttribute("messaging.kafka.message.offset", msg.Offset)
+	return ctx, span
+}
+
+// startSpan starts the span of a message entering the producer, injecting it
+// into its headers, unless already started for a previous attempt
+func (m *ProducerMessage) startSpan(conf *Config) {
+	if m.span != nil || conf.Tracing.Tracer == nil {
+		return
+	}
+	ctx := context.Background()
+	// headers require Kafka at least v0.11
+	propagate := conf.Tracing.Propagator != nil && conf.Version.IsAtLeast(V0_11_0_0)
+	if propagate {
+		ctx = conf.Tracing.Propagator.Extract(ctx, NewProducerMessageCarrier(m))
+	}
+	ctx, m.span = conf.Tracing.Tracer.Start(ctx, m.Topic+" send", SpanKindProducer)
+	m.span.SetAttribute("messaging.system", "kafka")
+	m.span.SetAttribute("messaging.destination.name", m.Topic)
+	if propagate {
+		conf.Tracing.Propagator.Inject(ctx, NewProducerMessageCarrier(m))
+	}
+}
+
+// endSpan ends the span of a message leaving the producer, if any
+func (m *ProducerMessage) endSpan(err error) {
+	if m.span == nil {
+		return
+	}
+	if err == nil {
+		m.span.SetAttribute("messaging.kafka.destination.partition", m.Partition)
+		m.span.SetAttribute("messaging.kafka.message.offset", m.Offset)
+	}
+	m.span.End(err)
+	m.span = nil
+}
+
+// rpcSpanNames are the names of the spans of the requests traced with
+// Config.Tracing, by API key
+var rpcSpanNames = map[int16]string{
+	0: "kafka.produce",
+	1: "kafka.fetch",
+	8: "kafka.offset_commit",
+}
+
+// rpcTracer traces the produce, fetch and offset commit requests sent on a
+// connection with BrokerHooks
+type rpcTracer struct {
+	tracer     Tracer
+	noResponse bool
+
+	lock  sync.Mutex
+	spans map[int32]Span
+}
+
+func newRPCTracer(conf *Config) *rpcTracer {
+	return &rpcTracer{
+		tracer:     conf.Tracing.Tracer,
+		noResponse: conf.Producer.RequiredAcks == NoResponse,
+		spans:      make(map[int32]Span),
+	}
+}
+
+func (r *rpcTracer) hooks() BrokerHooks {
+	return BrokerHooks{BeforeRequest: r.beforeRequest, AfterResponse: r.afterResponse}
+}
+
+func (r *rpcTracer) beforeRequest(event RequestEvent) {
+	name, ok := rpcSpanNames[event.APIKey]
+	// the produce requests without response would never end their span
+	if !ok || (event.APIKey == 0 && r.noResponse) {
+		return
+	}
+	_, span := r.tracer.Start(context.Background(), name, SpanKindClient)
+	span.SetAttribute("messaging.system", "kafka")
+	span.SetAttribute("messaging.kafka.broker.id", event.BrokerID)
+	span.SetAttribute("net.peer.name", event.Addr)
+	span.SetAttribute("messaging.kafka.api.version", int32(event.APIVersion))
+	span.SetAttribute("messaging.kafka.request.size", int64(event.RequestSize))
+
+	r.lock.Lock()
+	r.spans[event.CorrelationID] = span
+	r.lock.Unlock()
+}
+
+func (r *rpcTracer) afterResponse(event ResponseEvent) {
+	r.lock.Lock()
+	span, ok := r.spans[event.CorrelationID]
+	delete(r.spans, event.CorrelationID)
+	r.lock.Unlock()
+	if !ok {
+		return
+	}
+	span.SetAttribute("messaging.kafka.response.size", int64(event.ResponseSize))
+	span.End(event.Err)
+}
@@ -0,0 +1,197 @@
+package sarama
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	tracer     *testTracer
+	name       string
+	kind       SpanKind
+	parent     string
+	attributes map[string]interface{}
+	ended      bool
+	err        error
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) {
+	s.tracer.lock.Lock()
+	defer s.tracer.lock.Unlock()
+	s.attributes[key] = value
+}
+
+func (s *testSpan) End(err error) {
+	s.tracer.lock.Lock()
+	defer s.tracer.lock.Unlock()
+	s.ended = true
+	s.err = err
+}
+
+// testTracer records its spans, the context of a span being its name
+type testTracer struct {
+	lock  sync.Mutex
+	spans []*testSpan
+}
+
+func (tr *testTracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, Span) {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+	parent, _ := ctx.Value(testSpanKey{}).(string)
+	span := &testSpan{tracer: tr, name: name, kind: kind, parent: parent, attributes: make(map[string]interface{})}
+	tr.spans = append(tr.spans, span)
+	return context.WithValue(ctx, testSpanKey{}, name), span
+}
+
+func (tr *testTracer) ended(name string) []*testSpan {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+	var spans []*testSpan
+	for _, span := range tr.spans {
+		if span.name == name && span.ended {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// testPropagator propagates the name of the span in the "span" header
+type testPropagator struct{}
+
+func (testPropagator) Inject(ctx context.Context, carrier TextMapCarrier) {
+	if name, ok := ctx.Value(testSpanKey{}).(string); ok {
+		carrier.Set("span", name)
+	}
+}
+
+func (testPropagator) Extract(ctx context.Context, carrier TextMapCarrier) context.Context {
+	if name := carrier.Get("span"); name != "" {
+		return context.WithValue(ctx, testSpanKey{}, name)
+	}
+	return ctx
+}
+
+func TestMessageCarriers(t *testing.T) {
+	pmsg := &ProducerMessage{Headers: []RecordHeader{{Key: []byte("a"), Value: []byte("1")}}}
+	carrier := NewProducerMessageCarrier(pmsg)
+	carrier.Set("a", "2")
+	carrier.Set("b", "3")
+	if got := fmt.Sprintf("%v %s %s %q", carrier.Keys(), carrier.Get("a"), carrier.Get("b"), carrier.Get("c")); got != `[a b] 2 3 ""` {
+		t.Errorf("unexpected producer message carrier %s", got)
+	}
+
+	cmsg := &ConsumerMessage{Headers: []*RecordHeader{{Key: []byte("a"), Value: []byte("1")}}}
+	ccarrier := NewConsumerMessageCarrier(cmsg)
+	ccarrier.Set("a", "2")
+	ccarrier.Set("b", "3")
+	if got := fmt.Sprintf("%v %s %s %q", ccarrier.Keys(), ccarrier.Get("a"), ccarrier.Get("b"), ccarrier.Get("c")); got != `[a b] 2 3 ""` {
+		t.Errorf("unexpected consumer message carrier %s", got)
+	}
+}
+
+func TestProducerTracing(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+		"ProduceRequest": NewMockProduceResponse(t).SetVersion(3),
+	})
+
+	tracer := new(testTracer)
+	config := NewTestConfig()
+	config.Version = V0_11_0_0
+	config.Producer.Return.Successes = true
+	config.Tracing.Tracer = tracer
+	config.Tracing.Propagator = testPropagator{}
+	producer, err := NewSyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	InjectProducerMessageContext(context.WithValue(context.Background(), testSpanKey{}, "parent"), testPropagator{}, msg)
+	if _, _, err := producer.SendMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	safeClose(t, producer)
+
+	sends := tracer.ended("my_topic send")
+	if len(sends) != 1 {
+		t.Fatalf("Expected 1 send span, got %d", len(sends))
+	}
+	if sends[0].parent != "parent" || sends[0].kind != SpanKindProducer || sends[0].err != nil {
+		t.Errorf("Unexpected send span %+v", sends[0])
+	}
+	if sends[0].attributes["messaging.kafka.destination.partition"] != int32(0) {
+		t.Errorf("Unexpected send span attributes %v", sends[0].attributes)
+	}
+	if got := NewProducerMessageCarrier(msg).Get("span"); got != "my_topic send" {
+		t.Errorf("Expected the send span to be injected, got %q", got)
+	}
+	if produces := tracer.ended("kafka.produce"); len(produces) != 1 || produces[0].kind != SpanKindClient {
+		t.Errorf("Expected 1 produce span, got %d", len(produces))
+	}
+
+	// the propagated context is the parent of the consumer span
+	cmsg := &ConsumerMessage{Topic: "my_topic", Offset: 3}
+	for _, header := range msg.Headers {
+		header := header
+		cmsg.Headers = append(cmsg.Headers, &header)
+	}
+	_, span := StartConsumerMessageSpan(context.Background(), tracer, testPropagator{}, cmsg)
+	span.End(nil)
+	receives := tracer.ended("my_topic receive")
+	if len(receives) != 1 || receives[0].parent != "my_topic send" || receives[0].attributes["messaging.kafka.message.offset"] != int64(3) {
+		t.Errorf("Unexpected receive spans %+v", receives)
+	}
+}
+
+func TestRPCTracingWriteFailure(t *testing.T) {
+	mockBroker := NewMockBroker(t, 1)
+	defer mockBroker.Close()
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"OffsetCommitRequest": NewMockOffsetCommitResponse(t),
+	})
+
+	tracer := new(testTracer)
+	config := NewTestConfig()
+	config.Tracing.Tracer = tracer
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = broker.Close() }()
+
+	request := &OffsetCommitRequest{ConsumerGroup: "my_group"}
+	request.AddBlock("my_topic", 0, 1, ReceiveTime, "")
+	if _, err := broker.CommitOffset(request); err != nil {
+		t.Fatal(err)
+	}
+
+	// the request fails to be written on the closed connection
+	broker.lock.Lock()
+	_ = broker.conn.Close()
+	broker.lock.Unlock()
+	if _, err := broker.CommitOffset(request); err == nil {
+		t.Fatal("Expected the request to fail")
+	}
+
+	commits := tracer.ended("kafka.offset_commit")
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 offset commit spans, got %d", len(commits))
+	}
+	if commits[0].err != nil || commits[1].err == nil {
+		t.Errorf("Expected the second offset commit span to fail, got %v and %v", commits[0].err, commits[1].err)
+	}
+	broker.tracing.lock.Lock()
+	defer broker.tracing.lock.Unlock()
+	if len(broker.tracing.spans) != 0 {
+		t.Errorf("Expected no span left, got %d", len(broker.tracing.spans))
+	}
+}
@@ -0,0 +1,819 @@
+package sarama
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConsumerGroupMetadata identifies the member of a consumer group whose
+// consumed offsets are added to a transaction with AddGroupOffsetsToTxn, so
+// that the transaction coordinator rejects them if the member was fenced by
+// a rebalance (KIP-447).
+type ConsumerGroupMetadata struct {
+	GroupID         string
+	GenerationID    int32
+	MemberID        string
+	GroupInstanceID *string
+}
+
+// NewConsumerGroupMetadata returns the metadata of the member of the
+// consumer group owning the session.
+func NewConsumerGroupMetadata(groupID string, session ConsumerGroupSession) *ConsumerGroupMetadata {
+	return &ConsumerGroupMetadata{
+		GroupID:      groupID,
+		GenerationID: session.GenerationID(),
+		MemberID:     session.MemberID(),
+	}
+}
+
+// TxnStatus is the state of the transactions of a transactional producer,
+// see AsyncProducer.TxnStatus and Producer.Transaction.TransitionHook.
+type TxnStatus int
+
+const (
+	// TxnUninitialized is the status of a producer which is not
+	// transactional, or whose producer ID is being initialized.
+	TxnUninitialized TxnStatus = iota
+	// TxnReady is the status of a producer which can begin a transaction.
+	TxnReady
+	// TxnInTransaction is the status of a producer with an ongoing
+	// transaction, which messages and offsets can be added to.
+	TxnInTransaction
+	// TxnCommittingTransaction is the status of a producer committing its
+	// transaction.
+	TxnCommittingTransaction
+	// TxnAbortingTransaction is the status of a producer aborting its
+	// transaction.
+	TxnAbortingTransaction
+	// TxnAbortableError is the status of a producer whose transaction
+	// failed, which must be aborted before beginning another one.
+	TxnAbortableError
+	// TxnFatalError is the status of a producer which cannot produce
+	// anymore and must be closed, such as when fenced by another producer
+	// with the same transactional ID.
+	TxnFatalError
+	// TxnPreparedTransaction is the status of a producer whose transaction
+	// was prepared for a two-phase commit, which must be completed with
+	// CompleteTxn.
+	TxnPreparedTransaction
+)
+
+// PreparedTxnState identifies a transaction prepared for a two-phase commit
+// with PrepareTxn, which the external transaction coordinator records to
+// complete it with CompleteTxn (KIP-939).
+type PreparedTxnState struct {
+	ProducerID    int64
+	ProducerEpoch int16
+}
+
+// String returns the state as "producerID:producerEpoch", which
+// ParsePreparedTxnState parses.
+func (s PreparedTxnState) String() string {
+	return fmt.Sprintf("%d:%d", s.ProducerID, s.ProducerEpoch)
+}
+
+// ParsePreparedTxnState parses a PreparedTxnState formatted by its String
+// method.
+func ParsePreparedTxnState(s string) (PreparedTxnState, error) {
+	var state PreparedTxnState
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return state, fmt.Errorf("invalid prepared transaction state %q", s)
+	}
+	producerID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return state, fmt.Errorf("invalid prepared transaction state %q: %w", s, err)
+	}
+	producerEpoch, err := strconv.ParseInt(parts[1], 10, 16)
+	if err != nil {
+		return state, fmt.Errorf("invalid prepared transaction state %q: %w", s, err)
+	}
+	state.ProducerID, state.ProducerEpoch = producerID, int16(producerEpoch)
+	return state, nil
+}
+
+func (s TxnStatus) String() string {
+	switch s {
+	case TxnUninitialized:
+		return "uninitialized"
+	case TxnReady:
+		return "ready"
+	case TxnInTransaction:
+		return "in transaction"
+	case TxnCommittingTransaction:
+		return "committing"
+	case TxnAbortingTransaction:
+		return "aborting"
+	case TxnAbortableError:
+		return "in abortable error"
+	case TxnFatalError:
+		return "in fatal error"
+	case TxnPreparedTransaction:
+		return "prepared"
+	}
+	return fmt.Sprintf("TxnStatus(%d)", int(s))
+}
+
+// transactionManager keeps the state necessary to ensure idempotent production,
+// and the state of the transactions of a transactional producer
+type transactionManager struct {
+	producerID      int64
+	producerEpoch   int16
+	sequenceNumbers map[string]int32
+	mutex           sync.Mutex
+
+	transactionalID string
+	client          Client
+	conf            *Config
+	log             contextLogger
+
+	// the following are guarded by mutex
+	status    TxnStatus
+	lastError error
+	// partitions are the partitions added to the ongoing transaction
+	partitions map[string]map[int32]bool
+	// offsetsAdded is whether consumed offsets were added to the ongoing
+	// transaction
+	offsetsAdded bool
+	// pending is the number of messages of the ongoing transaction neither
+	// acknowledged nor failed yet, flushed being signaled when it drops to 0
+	pending int
+	flushed *sync.Cond
+	// sequenceGap is whether a message failed after being assigned a
+	// sequence number, in which case the epoch is bumped once the
+	// transaction is aborted
+	sequenceGap bool
+	// prepared is the state of the transaction prepared for a two-phase
+	// commit, either by the producer or by the previous producer with the
+	// same transactional ID if kept when initialized
+	prepared *PreparedTxnState
+	// watchdog aborts the ongoing transaction before it exceeds its timeout,
+	// see Producer.Transaction.Watchdog, txnSeq identifying the transaction
+	// it watches, and expired is whether the watchdog aborted it
+	watchdog *time.Timer
+	txnSeq   int
+	expired  bool
+}
+
+const (
+	noProducerID    = -1
+	noProducerEpoch = -1
+)
+
+func (t *transactionManager) getAndIncrementSequenceNumber(topic string, partition int32) (int32, int16) {
+	key := fmt.Sprintf("%s-%d", topic, partition)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	sequence := t.sequenceNumbers[key]
+	t.sequenceNumbers[key] = sequence + 1
+	return sequence, t.producerEpoch
+}
+
+func (t *transactionManager) bumpEpoch() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.producerEpoch++
+	for k := range t.sequenceNumbers {
+		t.sequenceNumbers[k] = 0
+	}
+}
+
+func (t *transactionManager) getProducerID() (int64, int16) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.producerID, t.producerEpoch
+}
+
+func newTransactionManager(conf *Config, client Client) (*transactionManager, error) {
+	txnmgr := &transactionManager{
+		producerID:      noProducerID,
+		producerEpoch:   noProducerEpoch,
+		transactionalID: conf.Producer.Transaction.ID,
+		client:          client,
+		conf:            conf,
+		log:             newContextLogger("transactionalID", conf.Producer.Transaction.ID),
+		partitions:      make(map[string]map[int32]bool),
+	}
+	txnmgr.flushed = sync.NewCond(&txnmgr.mutex)
+
+	if conf.Producer.Idempotent {
+		var initProducerIDResponse *InitProducerIDResponse
+		var err error
+		if txnmgr.isTransactional() {
+			initProducerIDResponse, err = txnmgr.initProducerID(conf.Producer.Transaction.TwoPhaseCommit.KeepPreparedTxn)
+		} else {
+			initProducerIDResponse, err = client.InitProducerID()
+		}
+		if err != nil {
+			return nil, err
+		}
+		txnmgr.producerID = initProducerIDResponse.ProducerID
+		txnmgr.producerEpoch = initProducerIDResponse.ProducerEpoch
+		txnmgr.sequenceNumbers = make(map[string]int32)
+
+		newContextLogger("producerID", txnmgr.producerID).Infof("Obtained a ProducerId: %d and ProducerEpoch: %d", txnmgr.producerID, txnmgr.producerEpoch)
+		switch {
+		case txnmgr.isTransactional() && initProducerIDResponse.OngoingTxnProducerID != noProducerID && conf.Producer.Transaction.TwoPhaseCommit.KeepPreparedTxn:
+			txnmgr.prepared = &PreparedTxnState{
+				ProducerID:    initProducerIDResponse.OngoingTxnProducerID,
+				ProducerEpoch: initProducerIDResponse.OngoingTxnProducerEpoch,
+			}
+			txnmgr.transitionTo(TxnPreparedTransaction, nil)
+		case txnmgr.isTransactional():
+			txnmgr.transitionTo(TxnReady, nil)
+		}
+	}
+
+	return txnmgr, nil
+}
+
+func (t *transactionManager) isTransactional() bool {
+	return t.transactionalID != ""
+}
+
+// initProducerID obtains the producer ID and epoch of the transactional ID
+// from its coordinator, which fences the previous producers with the same
+// transactional ID and aborts their ongoing transaction, unless kept for a
+// two-phase commit with keepPreparedTxn
+func (t *transactionManager) initProducerID(keepPreparedTxn bool) (*InitProducerIDResponse, error) {
+	req := &InitProducerIDRequest{
+		TransactionalID:    &t.transactionalID,
+		TransactionTimeout: t.conf.Producer.Transaction.Timeout,
+	}
+	if t.conf.Producer.Transaction.TwoPhaseCommit.Enable {
+		req.Version = 6
+		req.ProducerID = noProducerID
+		req.ProducerEpoch = noProducerEpoch
+		req.Enable2Pc = true
+		req.KeepPreparedTxn = keepPreparedTxn
+	}
+	var res *InitProducerIDResponse
+	err := t.retry("InitProducerId", t.transactionCoordinator, func(coordinator *Broker) (err error) {
+		if res, err = coordinator.InitProducerID(req); err != nil {
+			return err
+		}
+		if !errors.Is(res.Err, ErrNoError) {
+			return res.Err
+		}
+		return nil
+	})
+	return res, err
+}
+
+// transitionTo changes the status of the transactions, err being the error
+// causing it, if any, and calls Producer.Transaction.TransitionHook. It must
+// be called with t.mutex held, except while the transaction manager is
+// created.
+func (t *transactionManager) transitionTo(status TxnStatus, err error) {
+	if err != nil {
+		t.log.Warnf("producer/txnmanager transaction %s -> %s because of %v", t.status, status, err)
+	} else {
+		t.log.Debugf("producer/txnmanager transaction %s -> %s", t.status, status)
+	}
+	from := t.status
+	t.status = status
+	t.lastError = err
+	if hook := t.conf.Producer.Transaction.TransitionHook; hook != nil {
+		hook(t.transactionalID, from, status, err)
+	}
+	if from == TxnInTransaction && status != TxnInTransaction {
+		t.stopWatchdog()
+	}
+}
+
+// txnStatus returns the status of the transactions and the error which
+// caused it, if any
+func (t *transactionManager) txnStatus() (TxnStatus, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.status, t.lastError
+}
+
+// fail transitions to the error status of err, unless the producer already
+// failed fatally. It must be called with t.mutex held.
+func (t *transactionManager) fail(err error) {
+	switch {
+	case t.status == TxnFatalError:
+	case txnFatal(err):
+		t.transitionTo(TxnFatalError, err)
+	case t.status == TxnInTransaction || t.status == TxnCommittingTransaction:
+		t.transitionTo(TxnAbortableError, err)
+	}
+}
+
+// notReady returns the error of an operation not allowed in the current
+// status. It must be called with t.mutex held.
+func (t *transactionManager) notReady() error {
+	switch t.status {
+	case TxnAbortableError:
+		return Wrap(ErrTxnAbortable, t.lastError)
+	case TxnFatalError:
+		return Wrap(ErrTxnFatal, t.lastError)
+	case TxnAbortingTransaction:
+		if t.expired {
+			return Wrap(ErrTxnAbortable, t.lastError)
+		}
+	}
+	return fmt.Errorf("%w: the producer is %s", ErrTransactionNotReady, t.status)
+}
+
+func (t *transactionManager) beginTxn() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.status != TxnReady {
+		return t.notReady()
+	}
+	t.transitionTo(TxnInTransaction, nil)
+	t.startWatchdog()
+	return nil
+}
+
+// startWatchdog watches the transaction which just began, if enabled. It
+// must be called with t.mutex held.
+func (t *transactionManager) startWatchdog() {
+	watchdog := t.conf.Producer.Transaction.Watchdog
+	if !watchdog.Enable || t.conf.Producer.Transaction.TwoPhaseCommit.Enable {
+		return
+	}
+	t.txnSeq++
+	seq, started := t.txnSeq, time.Now()
+	t.watchdog = time.AfterFunc(t.conf.Producer.Transaction.Timeout-watchdog.Margin, func() {
+		t.expire(seq, started)
+	})
+}
+
+// stopWatchdog stops watching the transaction. It must be called with
+// t.mutex held.
+func (t *transactionManager) stopWatchdog() {
+	if t.watchdog != nil {
+		t.watchdog.Stop()
+		t.watchdog = nil
+	}
+}
+
+// expire aborts the transaction seq begun at started if it is still ongoing,
+// or calls Producer.Transaction.Watchdog.Hook
+func (t *transactionManager) expire(seq int, started time.Time) {
+	t.mutex.Lock()
+	if t.status != TxnInTransaction || t.txnSeq != seq {
+		t.mutex.Unlock()
+		return
+	}
+	elapsed := time.Since(started)
+	if hook := t.conf.Producer.Transaction.Watchdog.Hook; hook != nil {
+		t.watchdog = nil
+		t.mutex.Unlock()
+		hook(t.transactionalID, elapsed)
+		return
+	}
+	t.log.Warnf("producer/txnmanager aborting the transaction begun %s ago before it exceeds its timeout of %s", elapsed, t.conf.Producer.Transaction.Timeout)
+	t.expired = true
+	t.transitionTo(TxnAbortingTransaction, ErrTxnTimedOut)
+	t.mutex.Unlock()
+
+	if err := t.endTxn(false); err != nil {
+		t.log.Errorf("producer/txnmanager failed to abort the transaction before it timed out: %v", err)
+	}
+}
+
+// close stops watching the ongoing transaction once the producer is closed
+func (t *transactionManager) close() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.stopWatchdog()
+	t.txnSeq++
+}
+
+// addMessage accounts for a message entering the producer, which can only
+// be produced in a transaction if the producer is transactional
+func (t *transactionManager) addMessage() error {
+	if !t.isTransactional() {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.status != TxnInTransaction {
+		return t.notReady()
+	}
+	t.pending++
+	return nil
+}
+
+// messageDone accounts for a message acknowledged by the broker, or failed
+// with err
+func (t *transactionManager) messageDone(msg *ProducerMessage, err error) {
+	if !t.isTransactional() {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if err != nil {
+		if msg.hasSequence {
+			t.sequenceGap = true
+		}
+		t.fail(err)
+	}
+	t.pending--
+	if t.pending == 0 {
+		t.flushed.Broadcast()
+	}
+}
+
+// addPartitionsToTxn adds the partitions of a produce set which are not
+// part of the transaction yet to it, before the set is produced
+func (t *transactionManager) addPartitionsToTxn(set *produceSet) error {
+	if !t.isTransactional() {
+		return nil
+	}
+
+	t.mutex.Lock()
+	var topicPartitions map[string][]int32
+	set.eachPartition(func(topic string, partition int32, _ *partitionSet) {
+		if t.partitions[topic][partition] {
+			return
+		}
+		if topicPartitions == nil {
+			topicPartitions = make(map[string][]int32)
+		}
+		topicPartitions[topic] = append(topicPartitions[topic], partition)
+	})
+	req := &AddPartitionsToTxnRequest{
+		TransactionalID: t.transactionalID,
+		ProducerID:      t.producerID,
+		ProducerEpoch:   t.producerEpoch,
+		TopicPartitions: topicPartitions,
+	}
+	t.mutex.Unlock()
+	if len(topicPartitions) == 0 {
+		return nil
+	}
+
+	err := t.retry("AddPartitionsToTxn", t.transactionCoordinator, func(coordinator *Broker) error {
+		res, err := coordinator.AddPartitionsToTxn(req)
+		if err != nil {
+			return err
+		}
+		for _, partitionErrors := range res.Errors {
+			for _, partitionError := range partitionErrors {
+				// the partitions which were not added because of the
+				// errors of the others are answered with
+				// ErrOperationNotAttempted
+				if !errors.Is(partitionError.Err, ErrNoError) && !errors.Is(partitionError.Err, ErrOperationNotAttempted) {
+					return partitionError.Err
+				}
+			}
+		}
+		return nil
+	})
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err != nil {
+		t.fail(err)
+		return Wrap(ErrAddPartitionsToTxn, err)
+	}
+	for topic, partitions := range topicPartitions {
+		if t.partitions[topic] == nil {
+			t.partitions[topic] = make(map[int32]bool)
+		}
+		for _, partition := range partitions {
+			t.partitions[topic][partition] = true
+		}
+	}
+	return nil
+}
+
+// addOffsetsToTxn adds the consumed offsets of the group to the
+// transaction, with the group metadata if the member is known. The
+// transaction must be aborted if it fails.
+func (t *transactionManager) addOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, group *ConsumerGroupMetadata) error {
+	t.mutex.Lock()
+	if t.status != TxnInTransaction {
+		err := t.notReady()
+		t.mutex.Unlock()
+		return err
+	}
+	producerID, producerEpoch := t.producerID, t.producerEpoch
+	t.mutex.Unlock()
+
+	err := t.retry("AddOffsetsToTxn", t.transactionCoordinator, func(coordinator *Broker) error {
+		res, err := coordinator.AddOffsetsToTxn(&AddOffsetsToTxnRequest{
+			TransactionalID: t.transactionalID,
+			ProducerID:      producerID,
+			ProducerEpoch:   producerEpoch,
+			GroupID:         group.GroupID,
+		})
+		if err != nil {
+			return err
+		}
+		if !errors.Is(res.Err, ErrNoError) {
+			return res.Err
+		}
+		return nil
+	})
+	if err == nil {
+		t.mutex.Lock()
+		t.offsetsAdded = true
+		t.mutex.Unlock()
+
+		err = t.txnOffsetCommit(offsets, group, producerID, producerEpoch)
+	}
+
+	if err != nil {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		t.fail(err)
+		return t.notReady()
+	}
+	return nil
+}
+
+// txnOffsetCommit sends the offsets added to the transaction to the
+// coordinator of the group
+func (t *transactionManager) txnOffsetCommit(offsets map[string][]*PartitionOffsetMetadata, group *ConsumerGroupMetadata, producerID int64, producerEpoch int16) error {
+	req := &TxnOffsetCommitRequest{
+		TransactionalID: t.transactionalID,
+		GroupID:         group.GroupID,
+		ProducerID:      producerID,
+		ProducerEpoch:   producerEpoch,
+		Topics:          offsets,
+	}
+	switch {
+	case t.conf.Version.IsAtLeast(V2_5_0_0):
+		req.Version = 3
+		req.GenerationID = group.GenerationID
+		req.MemberID = group.MemberID
+		req.GroupInstanceID = group.GroupInstanceID
+		if req.MemberID == "" {
+			req.GenerationID = -1
+		}
+	case t.conf.Version.IsAtLeast(V2_1_0_0):
+		req.Version = 2
+	case t.conf.Version.IsAtLeast(V2_0_0_0):
+		req.Version = 1
+	}
+
+	groupCoordinator := func(refresh bool) (*Broker, error) {
+		if refresh {
+			if err := t.client.RefreshCoordinator(group.GroupID); err != nil {
+				return nil, err
+			}
+		}
+		return t.client.Coordinator(group.GroupID)
+	}
+	return t.retry("TxnOffsetCommit", groupCoordinator, func(coordinator *Broker) error {
+		res, err := coordinator.TxnOffsetCommit(req)
+		if err != nil {
+			return err
+		}
+		for _, partitionErrors := range res.Topics {
+			for _, partitionError := range partitionErrors {
+				if !errors.Is(partitionError.Err, ErrNoError) {
+					return partitionError.Err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// prepareEndTxn transitions to the committing or aborting status, which
+// rejects the messages sent to the producer until the transaction ends
+func (t *transactionManager) prepareEndTxn(commit bool) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch {
+	case t.status == TxnInTransaction && commit:
+		t.transitionTo(TxnCommittingTransaction, nil)
+	case (t.status == TxnInTransaction || t.status == TxnAbortableError) && !commit:
+		t.transitionTo(TxnAbortingTransaction, nil)
+	default:
+		return t.notReady()
+	}
+	return nil
+}
+
+// endTxn waits for the messages of the transaction to be acknowledged or
+// to fail, and commits or aborts it
+func (t *transactionManager) endTxn(commit bool) error {
+	t.mutex.Lock()
+	for t.pending > 0 {
+		t.flushed.Wait()
+	}
+	if commit && t.status != TxnCommittingTransaction {
+		// a message failed
+		err := t.notReady()
+		t.mutex.Unlock()
+		return err
+	}
+	ongoing := len(t.partitions) > 0 || t.offsetsAdded || t.prepared != nil
+	req := &EndTxnRequest{
+		TransactionalID:   t.transactionalID,
+		ProducerID:        t.producerID,
+		ProducerEpoch:     t.producerEpoch,
+		TransactionResult: commit,
+	}
+	t.mutex.Unlock()
+
+	var err error
+	if ongoing {
+		// the coordinator only knows of the transactions with partitions or
+		// offsets
+		err = t.retry("EndTxn", t.transactionCoordinator, func(coordinator *Broker) error {
+			res, err := coordinator.EndTxn(req)
+			if err != nil {
+				return err
+			}
+			if !errors.Is(res.Err, ErrNoError) {
+				return res.Err
+			}
+			return nil
+		})
+	}
+	if err == nil && !commit {
+		err = t.closeSequenceGap()
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	expired := t.expired
+	t.expired = false
+	if err != nil {
+		if txnFatal(err) || !commit {
+			t.transitionTo(TxnFatalError, err)
+		} else {
+			t.transitionTo(TxnAbortableError, err)
+		}
+		return t.notReady()
+	}
+	t.partitions = make(map[string]map[int32]bool)
+	t.offsetsAdded = false
+	t.prepared = nil
+	if expired {
+		// the application acknowledges the abort with AbortTxn
+		t.transitionTo(TxnAbortableError, ErrTxnTimedOut)
+		return nil
+	}
+	t.transitionTo(TxnReady, nil)
+	return nil
+}
+
+// prepareTxn waits for the messages of the transaction to be acknowledged,
+// and prepares it for a two-phase commit, rejecting the messages and offsets
+// sent to the producer until it is completed
+func (t *transactionManager) prepareTxn() (PreparedTxnState, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.conf.Producer.Transaction.TwoPhaseCommit.Enable {
+		return PreparedTxnState{}, ConfigurationError("PrepareTxn requires Producer.Transaction.TwoPhaseCommit.Enable")
+	}
+	if t.status != TxnInTransaction {
+		return PreparedTxnState{}, t.notReady()
+	}
+	for t.pending > 0 {
+		t.flushed.Wait()
+	}
+	if t.status != TxnInTransaction {
+		// a message failed
+		return PreparedTxnState{}, t.notReady()
+	}
+	t.prepared = &PreparedTxnState{ProducerID: t.producerID, ProducerEpoch: t.producerEpoch}
+	t.transitionTo(TxnPreparedTransaction, nil)
+	return *t.prepared, nil
+}
+
+// completeTxn commits the prepared transaction if its state is the given
+// one, aborts it otherwise
+func (t *transactionManager) completeTxn(state PreparedTxnState) error {
+	t.mutex.Lock()
+	if t.status != TxnPreparedTransaction {
+		err := t.notReady()
+		t.mutex.Unlock()
+		return err
+	}
+	commit := *t.prepared == state
+	if commit {
+		t.transitionTo(TxnCommittingTransaction, nil)
+	} else {
+		t.log.Infof("producer/txnmanager aborting the prepared transaction %s, which is not the expected %s", t.prepared, state)
+		t.transitionTo(TxnAbortingTransaction, nil)
+	}
+	t.mutex.Unlock()
+
+	return t.endTxn(commit)
+}
+
+// closeSequenceGap bumps the epoch of the producer after an aborted
+// transaction whose messages failed after being assigned a sequence number,
+// which the broker would otherwise wait for
+func (t *transactionManager) closeSequenceGap() error {
+	t.mutex.Lock()
+	gap := t.sequenceGap
+	t.mutex.Unlock()
+	if !gap {
+		return nil
+	}
+
+	res, err := t.initProducerID(false)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.producerID = res.ProducerID
+	t.producerEpoch = res.ProducerEpoch
+	for k := range t.sequenceNumbers {
+		t.sequenceNumbers[k] = 0
+	}
+	t.sequenceGap = false
+	t.log.Infof("producer/txnmanager obtained ProducerId: %d and ProducerEpoch: %d after aborting", t.producerID, t.producerEpoch)
+	return nil
+}
+
+// transactionCoordinator returns the coordinator of the transactional ID,
+// looked up again if refresh is set
+func (t *transactionManager) transactionCoordinator(refresh bool) (*Broker, error) {
+	if refresh {
+		if err := t.client.RefreshTransactionCoordinator(t.transactionalID); err != nil {
+			return nil, err
+		}
+	}
+	return t.client.TransactionCoordinator(t.transactionalID)
+}
+
+// retry sends a request with send to the coordinator returned by
+// coordinator until it succeeds, retrying the errors after which it can be
+// sent again up to Producer.Transaction.Retry.Max times
+func (t *transactionManager) retry(api string, coordinator func(refresh bool) (*Broker, error), send func(*Broker) error) error {
+	maxRetries := t.conf.Producer.Transaction.Retry.Max
+	refresh := false
+	for retries := 0; ; retries++ {
+		broker, err := coordinator(refresh)
+		if err == nil {
+			err = send(broker)
+		}
+		if err == nil {
+			return nil
+		}
+
+		var retriable bool
+		retriable, refresh = txnRetriable(err)
+		if !retriable || retries >= maxRetries {
+			return err
+		}
+		t.log.Warnf("producer/txnmanager %s failed, retrying (%d attempts remaining): %v", api, maxRetries-retries, err)
+		t.conf.Clock.Sleep(t.backoff(retries + 1))
+	}
+}
+
+func (t *transactionManager) backoff(retries int) time.Duration {
+	retry := &t.conf.Producer.Transaction.Retry
+	if retry.BackoffFunc != nil {
+		return retry.BackoffFunc(retries, retry.Max)
+	}
+	return retry.Backoff
+}
+
+// txnRetriable returns whether a request to a coordinator failing with err
+// can be sent again, and whether the coordinator must be looked up again
+// first
+func txnRetriable(err error) (retriable, refresh bool) {
+	var kerr KError
+	if !errors.As(err, &kerr) {
+		// the request did not reach the coordinator, unless the client
+		// is closed or it could not be encoded
+		var configErr ConfigurationError
+		var encodingErr PacketEncodingError
+		if errors.Is(err, ErrClosedClient) || errors.As(err, &configErr) || errors.As(err, &encodingErr) {
+			return false, false
+		}
+		return true, true
+	}
+	switch kerr {
+	case ErrConsumerCoordinatorNotAvailable, ErrNotCoordinatorForConsumer:
+		return true, true
+	case ErrOffsetsLoadInProgress, ErrConcurrentTransactions, ErrRequestTimedOut, ErrUnknownTopicOrPartition:
+		return true, false
+	}
+	return false, false
+}
+
+// txnFatal returns whether a transactional producer failing with err
+// cannot produce anymore, as it was fenced by a producer with the same
+// transactional ID or is not authorized to use it
+func txnFatal(err error) bool {
+	return errors.Is(err, ErrProducerFenced) ||
+		errors.Is(err, ErrInvalidProducerEpoch) ||
+		errors.Is(err, ErrTransactionalIDAuthorizationFailed) ||
+		errors.Is(err, ErrInvalidTxnState)
+}
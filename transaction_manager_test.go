@@ -0,0 +1,482 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTxnProducerBroker(t *testing.T) (*MockBroker, *MockTransactionCoordinator, *MockGroupCoordinator) {
+	broker := NewMockBroker(t, 1)
+	coordinator := NewMockTransactionCoordinator(t, broker)
+	groups := NewMockGroupCoordinator(t, broker)
+	coordinator.SetGroupCoordinator(groups)
+
+	broker.SetHandlerByMap(coordinator.RegisterHandlers(groups.RegisterHandlers(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("my_topic", 0, broker.BrokerID()),
+		"ProduceRequest": NewMockProduceResponse(t).SetVersion(3),
+	})))
+	return broker, coordinator, groups
+}
+
+func newTxnProducerConfig(transactionalID string) *Config {
+	config := NewTestConfig()
+	config.Version = V0_11_0_0
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = WaitForAll
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Max = 1
+	config.Producer.Transaction.ID = transactionalID
+	config.Producer.Transaction.Retry.Max = 1
+	config.Net.MaxOpenRequests = 1
+	return config
+}
+
+func newTxnProducer(t *testing.T, broker *MockBroker, transactionalID string) AsyncProducer {
+	producer, err := NewAsyncProducer([]string{broker.Addr()}, newTxnProducerConfig(transactionalID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return producer
+}
+
+func produceTxnMessage(t *testing.T, producer AsyncProducer) {
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	select {
+	case <-producer.Successes():
+	case err := <-producer.Errors():
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionalProducerCommits(t *testing.T) {
+	broker, coordinator, groups := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newTxnProducer(t, broker, "txn")
+	defer safeClose(t, producer)
+	if !producer.IsTransactional() {
+		t.Fatal("expected a transactional producer")
+	}
+
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.BeginTxn(); !errors.Is(err, ErrTransactionNotReady) {
+		t.Errorf("expected ErrTransactionNotReady, got %v", err)
+	}
+	produceTxnMessage(t, producer)
+	if partitions := coordinator.Partitions("txn"); len(partitions["my_topic"]) != 1 {
+		t.Errorf("expected my_topic/0 in the transaction, got %v", partitions)
+	}
+	err := producer.AddMessageToTxn(&ConsumerMessage{Topic: "in_topic", Partition: 0, Offset: 41}, "my_group", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.CommitTxn(); err != nil {
+		t.Fatal(err)
+	}
+
+	if results := coordinator.Results("txn"); len(results) != 1 || !results[0] {
+		t.Errorf("expected 1 committed transaction, got %v", results)
+	}
+	if offset, ok := groups.Offset("my_group", "in_topic", 0); !ok || offset != 42 {
+		t.Errorf("expected committed offset 42, got %d (%v)", offset, ok)
+	}
+}
+
+func TestTransactionalProducerAborts(t *testing.T) {
+	broker, coordinator, groups := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newTxnProducer(t, broker, "txn")
+	defer safeClose(t, producer)
+
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	produceTxnMessage(t, producer)
+	offsets := map[string][]*PartitionOffsetMetadata{"in_topic": {{Partition: 0, Offset: 42}}}
+	if err := producer.AddOffsetsToTxn(offsets, "my_group"); err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.AbortTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.AbortTxn(); !errors.Is(err, ErrTransactionNotReady) {
+		t.Errorf("expected ErrTransactionNotReady, got %v", err)
+	}
+
+	if results := coordinator.Results("txn"); len(results) != 1 || results[0] {
+		t.Errorf("expected 1 aborted transaction, got %v", results)
+	}
+	if _, ok := groups.Offset("my_group", "in_topic", 0); ok {
+		t.Error("expected no committed offset")
+	}
+
+	// the producer can begin another transaction
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	produceTxnMessage(t, producer)
+	if err := producer.CommitTxn(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionalProducerRequiresTransaction(t *testing.T) {
+	broker, _, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newTxnProducer(t, broker, "txn")
+	defer safeClose(t, producer)
+
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	select {
+	case <-producer.Successes():
+		t.Fatal("expected the message outside of a transaction to fail")
+	case err := <-producer.Errors():
+		if !errors.Is(err, ErrTransactionNotReady) {
+			t.Errorf("expected ErrTransactionNotReady, got %v", err)
+		}
+	}
+	if err := producer.CommitTxn(); !errors.Is(err, ErrTransactionNotReady) {
+		t.Errorf("expected ErrTransactionNotReady, got %v", err)
+	}
+}
+
+func TestTransactionalProducerFenced(t *testing.T) {
+	broker, coordinator, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newTxnProducer(t, broker, "txn")
+	defer safeClose(t, producer)
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	produceTxnMessage(t, producer)
+
+	// a producer with the same transactional ID fences the first one and
+	// aborts its transaction
+	fencing := newTxnProducer(t, broker, "txn")
+	defer safeClose(t, fencing)
+	if _, epoch, _ := coordinator.ProducerID("txn"); epoch != 1 {
+		t.Errorf("expected epoch 1, got %d", epoch)
+	}
+
+	if err := producer.CommitTxn(); !errors.Is(err, ErrTxnFatal) {
+		t.Errorf("expected ErrTxnFatal, got %v", err)
+	}
+	if err := producer.BeginTxn(); !errors.Is(err, ErrTxnFatal) {
+		t.Errorf("expected ErrTxnFatal, got %v", err)
+	}
+	if err := fencing.BeginTxn(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNonTransactionalProducer(t *testing.T) {
+	broker, _, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newTxnProducer(t, broker, "")
+	defer safeClose(t, producer)
+
+	if producer.IsTransactional() {
+		t.Error("expected a non transactional producer")
+	}
+	if err := producer.BeginTxn(); !errors.Is(err, ErrNonTransactedProducer) {
+		t.Errorf("expected ErrNonTransactedProducer, got %v", err)
+	}
+	if err := producer.AddOffsetsToTxn(nil, "my_group"); !errors.Is(err, ErrNonTransactedProducer) {
+		t.Errorf("expected ErrNonTransactedProducer, got %v", err)
+	}
+	produceTxnMessage(t, producer)
+}
+
+func TestTransactionalProducerTransitionHook(t *testing.T) {
+	broker, _, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	type transition struct {
+		from, to TxnStatus
+		err      error
+	}
+	var transitions []transition
+	config := newTxnProducerConfig("txn")
+	config.Producer.Transaction.TransitionHook = func(transactionalID string, from, to TxnStatus, err error) {
+		if transactionalID != "txn" {
+			t.Errorf("unexpected transactional ID %s", transactionalID)
+		}
+		transitions = append(transitions, transition{from, to, err})
+	}
+	producer, err := NewAsyncProducer([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, producer)
+
+	if status := producer.TxnStatus(); status != TxnReady {
+		t.Errorf("expected %s, got %s", TxnReady, status)
+	}
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if status := producer.TxnStatus(); status != TxnInTransaction {
+		t.Errorf("expected %s, got %s", TxnInTransaction, status)
+	}
+	produceTxnMessage(t, producer)
+	if err := producer.CommitTxn(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a producer with the same transactional ID fences the first one
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	fencing := newTxnProducer(t, broker, "txn")
+	defer safeClose(t, fencing)
+	err = producer.AddOffsetsToTxn(map[string][]*PartitionOffsetMetadata{"in_topic": {{Offset: 1}}}, "my_group")
+	if !errors.Is(err, ErrTxnFatal) {
+		t.Errorf("expected ErrTxnFatal, got %v", err)
+	}
+	if status := producer.TxnStatus(); status != TxnFatalError {
+		t.Errorf("expected %s, got %s", TxnFatalError, status)
+	}
+
+	expected := []transition{
+		{TxnUninitialized, TxnReady, nil},
+		{TxnReady, TxnInTransaction, nil},
+		{TxnInTransaction, TxnCommittingTransaction, nil},
+		{TxnCommittingTransaction, TxnReady, nil},
+		{TxnReady, TxnInTransaction, nil},
+		{TxnInTransaction, TxnFatalError, ErrInvalidProducerEpoch},
+	}
+	if len(transitions) != len(expected) {
+		t.Fatalf("expected %d transitions, got %+v", len(expected), transitions)
+	}
+	for i, transition := range transitions {
+		if transition.from != expected[i].from || transition.to != expected[i].to || !errors.Is(transition.err, expected[i].err) {
+			t.Errorf("expected transition %d to be %+v, got %+v", i, expected[i], transition)
+		}
+	}
+}
+
+func newTwoPhaseCommitProducer(t *testing.T, broker *MockBroker, keepPreparedTxn bool) AsyncProducer {
+	config := newTxnProducerConfig("txn")
+	config.Version = V4_1_0_0
+	config.Producer.Transaction.TwoPhaseCommit.Enable = true
+	config.Producer.Transaction.TwoPhaseCommit.KeepPreparedTxn = keepPreparedTxn
+	producer, err := NewAsyncProducer([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return producer
+}
+
+func TestTransactionalProducerTwoPhaseCommit(t *testing.T) {
+	broker, coordinator, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newTwoPhaseCommitProducer(t, broker, false)
+	defer safeClose(t, producer)
+
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	produceTxnMessage(t, producer)
+	state, err := producer.PrepareTxn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := producer.TxnStatus(); status != TxnPreparedTransaction {
+		t.Errorf("expected %s, got %s", TxnPreparedTransaction, status)
+	}
+	if err := producer.CommitTxn(); !errors.Is(err, ErrTransactionNotReady) {
+		t.Errorf("expected ErrTransactionNotReady, got %v", err)
+	}
+	if err := producer.CompleteTxn(state); err != nil {
+		t.Fatal(err)
+	}
+
+	// a transaction whose state was not recorded by the external coordinator
+	// is aborted
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	produceTxnMessage(t, producer)
+	if _, err := producer.PrepareTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.CompleteTxn(PreparedTxnState{ProducerID: -1, ProducerEpoch: -1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if results := coordinator.Results("txn"); len(results) != 2 || !results[0] || results[1] {
+		t.Errorf("expected a committed then aborted transactions, got %v", results)
+	}
+}
+
+func TestTransactionalProducerKeepsPreparedTxn(t *testing.T) {
+	broker, coordinator, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newTwoPhaseCommitProducer(t, broker, false)
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	produceTxnMessage(t, producer)
+	state, err := producer.PrepareTxn()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the application restarts before completing the transaction
+	recovered := newTwoPhaseCommitProducer(t, broker, true)
+	defer safeClose(t, recovered)
+	_ = producer.Close()
+
+	if status := recovered.TxnStatus(); status != TxnPreparedTransaction {
+		t.Errorf("expected %s, got %s", TxnPreparedTransaction, status)
+	}
+	if err := recovered.BeginTxn(); !errors.Is(err, ErrTransactionNotReady) {
+		t.Errorf("expected ErrTransactionNotReady, got %v", err)
+	}
+	if err := recovered.CompleteTxn(state); err != nil {
+		t.Fatal(err)
+	}
+	if results := coordinator.Results("txn"); len(results) != 1 || !results[0] {
+		t.Errorf("expected 1 committed transaction, got %v", results)
+	}
+	if err := recovered.BeginTxn(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransactionalProducerPrepareRequiresTwoPhaseCommit(t *testing.T) {
+	broker, _, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newTxnProducer(t, broker, "txn")
+	defer safeClose(t, producer)
+
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	var target ConfigurationError
+	if _, err := producer.PrepareTxn(); !errors.As(err, &target) {
+		t.Errorf("expected a ConfigurationError, got %v", err)
+	}
+}
+
+func TestParsePreparedTxnState(t *testing.T) {
+	state := PreparedTxnState{ProducerID: 8000, ProducerEpoch: 3}
+	parsed, err := ParsePreparedTxnState(state.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != state {
+		t.Errorf("expected %s, got %s", state, parsed)
+	}
+	for _, s := range []string{"", "8000", "8000:", "a:3", "8000:70000"} {
+		if _, err := ParsePreparedTxnState(s); err == nil {
+			t.Errorf("expected %q to be invalid", s)
+		}
+	}
+}
+
+func newWatchedTxnProducer(t *testing.T, broker *MockBroker, hook func(transactionalID string, elapsed time.Duration)) AsyncProducer {
+	config := newTxnProducerConfig("txn")
+	config.Producer.Transaction.Timeout = 200 * time.Millisecond
+	config.Producer.Transaction.Watchdog.Enable = true
+	config.Producer.Transaction.Watchdog.Margin = 150 * time.Millisecond
+	config.Producer.Transaction.Watchdog.Hook = hook
+	producer, err := NewAsyncProducer([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return producer
+}
+
+func TestTransactionalProducerWatchdogAborts(t *testing.T) {
+	broker, coordinator, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newWatchedTxnProducer(t, broker, nil)
+	defer safeClose(t, producer)
+
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	produceTxnMessage(t, producer)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if producer.TxnStatus() == TxnAbortableError {
+			break
+		}
+	}
+	if results := coordinator.Results("txn"); len(results) != 1 || results[0] {
+		t.Errorf("expected 1 aborted transaction, got %v", results)
+	}
+
+	err := producer.CommitTxn()
+	if !errors.Is(err, ErrTxnAbortable) || !errors.Is(err, ErrTxnTimedOut) {
+		t.Errorf("expected ErrTxnAbortable wrapping ErrTxnTimedOut, got %v", err)
+	}
+	if err := producer.AbortTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if results := coordinator.Results("txn"); len(results) != 1 {
+		t.Errorf("expected the transaction to be aborted once, got %v", results)
+	}
+
+	// the transactions ending in time are not aborted
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	produceTxnMessage(t, producer)
+	if err := producer.CommitTxn(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if status := producer.TxnStatus(); status != TxnReady {
+		t.Errorf("expected %s, got %s", TxnReady, status)
+	}
+	if results := coordinator.Results("txn"); len(results) != 2 || !results[1] {
+		t.Errorf("expected an aborted then committed transactions, got %v", results)
+	}
+}
+
+func TestTransactionalProducerWatchdogHook(t *testing.T) {
+	broker, coordinator, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	expiring := make(chan time.Duration, 1)
+	producer := newWatchedTxnProducer(t, broker, func(transactionalID string, elapsed time.Duration) {
+		if transactionalID != "txn" {
+			t.Errorf("unexpected transactional ID %s", transactionalID)
+		}
+		expiring <- elapsed
+	})
+	defer safeClose(t, producer)
+
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	produceTxnMessage(t, producer)
+	if elapsed := <-expiring; elapsed < 50*time.Millisecond {
+		t.Errorf("expected the hook to be called after 50ms, got %s", elapsed)
+	}
+	// the application decides to commit the transaction
+	if status := producer.TxnStatus(); status != TxnInTransaction {
+		t.Errorf("expected %s, got %s", TxnInTransaction, status)
+	}
+	if err := producer.CommitTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if results := coordinator.Results("txn"); len(results) != 1 || !results[0] {
+		t.Errorf("expected 1 committed transaction, got %v", results)
+	}
+}
@@ -0,0 +1,294 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrClosedProducerPool is the error returned when a method is called on a producer pool that has been closed.
+var ErrClosedProducerPool = errors.New("kafka: tried to use a producer pool that was closed")
+
+// TransactionalProducerPool manages a fixed number of transactional producers
+// with distinct transactional IDs, so that transactions can run concurrently
+// instead of serializing on a single transactional ID.
+//
+// The transactional IDs are Producer.Transaction.ID followed by "-" and the
+// index of the producer in the pool, from 0. They must be stable across
+// restarts for the producers of a previous instance of the application to be
+// fenced, so the size of the pool should not decrease between restarts.
+//
+// The messages and errors returned by the producers are drained by the pool:
+// a message failing to be produced fails its transaction, which is reported
+// by TransactionSession.Commit.
+type TransactionalProducerPool struct {
+	addrs []string
+	conf  *Config
+
+	// idle are the producers not used by a session
+	idle chan *pooledProducer
+
+	lock   sync.Mutex
+	closed bool
+}
+
+// pooledProducer is a producer of the pool, created again after failing
+// fatally
+type pooledProducer struct {
+	transactionalID string
+	producer        AsyncProducer
+	done            chan none
+	drained         chan none
+}
+
+// NewTransactionalProducerPool creates a pool of size transactional producers
+// connected to the given brokers. Producer.Transaction.ID is the prefix of
+// their transactional IDs.
+func NewTransactionalProducerPool(addrs []string, conf *Config, size int) (*TransactionalProducerPool, error) {
+	if conf == nil {
+		conf = NewConfig()
+	}
+	if conf.Producer.Transaction.ID == "" {
+		return nil, ConfigurationError("TransactionalProducerPool requires Producer.Transaction.ID")
+	}
+	if size <= 0 {
+		return nil, ConfigurationError("TransactionalProducerPool size must be > 0")
+	}
+
+	pool := &TransactionalProducerPool{
+		addrs: addrs,
+		conf:  conf,
+		idle:  make(chan *pooledProducer, size),
+	}
+	for i := 0; i < size; i++ {
+		pp := &pooledProducer{transactionalID: fmt.Sprintf("%s-%d", conf.Producer.Transaction.ID, i)}
+		if err := pool.open(pp); err != nil {
+			_ = pool.Close()
+			return nil, err
+		}
+		pool.idle <- pp
+	}
+	return pool, nil
+}
+
+// Begin takes an idle producer of the pool and begins a transaction with it,
+// waiting for a producer to be released if all are used. The session must be
+// ended with Commit or Abort to release the producer.
+func (pool *TransactionalProducerPool) Begin(ctx context.Context) (*TransactionSession, error) {
+	if pool.isClosed() {
+		return nil, ErrClosedProducerPool
+	}
+	var pp *pooledProducer
+	select {
+	case pp = <-pool.idle:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if pool.isClosed() {
+		pool.release(pp, nil)
+		return nil, ErrClosedProducerPool
+	}
+
+	if pp.producer == nil {
+		// the producer failed fatally and could not be created again
+		if err := pool.open(pp); err != nil {
+			pool.release(pp, nil)
+			return nil, err
+		}
+	}
+	if err := pp.producer.BeginTxn(); err != nil {
+		pool.release(pp, err)
+		return nil, err
+	}
+	return &TransactionSession{
+		pool:            pool,
+		transactionalID: pp.transactionalID,
+		input:           pp.producer.Input(),
+		pp:              pp,
+	}, nil
+}
+
+// Close closes the idle producers of the pool, and the producers used by the
+// sessions when they end.
+func (pool *TransactionalProducerPool) Close() error {
+	pool.lock.Lock()
+	if pool.closed {
+		pool.lock.Unlock()
+		return ErrClosedProducerPool
+	}
+	pool.closed = true
+	pool.lock.Unlock()
+
+	var errs []error
+	for {
+		select {
+		case pp := <-pool.idle:
+			if err := pp.close(); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			if len(errs) > 0 {
+				return Wrap(ErrClosedProducerPool, errs...)
+			}
+			return nil
+		}
+	}
+}
+
+func (pool *TransactionalProducerPool) isClosed() bool {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return pool.closed
+}
+
+// open creates the producer of pp
+func (pool *TransactionalProducerPool) open(pp *pooledProducer) error {
+	conf := *pool.conf
+	conf.Producer.Transaction.ID = pp.transactionalID
+	producer, err := NewAsyncProducer(pool.addrs, &conf)
+	if err != nil {
+		return err
+	}
+	pp.producer = producer
+	pp.done = make(chan none)
+	pp.drained = make(chan none)
+	go withRecover(pp.drain)
+	return nil
+}
+
+// release returns the producer of an ended session to the pool, the
+// producer being closed if it failed fatally with err, or if the pool is
+// closed
+func (pool *TransactionalProducerPool) release(pp *pooledProducer, err error) {
+	if pp.producer != nil && errors.Is(err, ErrTxnFatal) {
+		newContextLogger("transactionalID", pp.transactionalID).Warnf("producer/pool closing the producer after it failed: %v", err)
+		_ = pp.close()
+	}
+
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	if pool.closed {
+		_ = pp.close()
+		return
+	}
+	pool.idle <- pp
+}
+
+// drain reads the messages and errors returned by the producer until it is
+// closed
+func (pp *pooledProducer) drain() {
+	defer close(pp.drained)
+	for {
+		select {
+		case <-pp.producer.Successes():
+		case err := <-pp.producer.Errors():
+			if err != nil {
+				newContextLogger("transactionalID", pp.transactionalID).Warnf("producer/pool failed to produce a message to %s/%d: %v", err.Msg.Topic, err.Msg.Partition, err.Err)
+			}
+		case <-pp.done:
+			return
+		}
+	}
+}
+
+// close closes the producer, which is created again on its next use
+func (pp *pooledProducer) close() error {
+	if pp.producer == nil {
+		return nil
+	}
+	close(pp.done)
+	<-pp.drained
+	err := pp.producer.Close()
+	pp.producer = nil
+	return err
+}
+
+// TransactionSession is a transaction of a producer of a
+// TransactionalProducerPool, see TransactionalProducerPool.Begin.
+type TransactionSession struct {
+	pool            *TransactionalProducerPool
+	transactionalID string
+	input           chan<- *ProducerMessage
+
+	lock sync.Mutex
+	// pp is the producer of the session, nil once it ended
+	pp *pooledProducer
+}
+
+// TransactionalID returns the transactional ID of the producer of the
+// session.
+func (s *TransactionSession) TransactionalID() string {
+	return s.transactionalID
+}
+
+// Input is the input channel of the producer of the session, see
+// AsyncProducer.Input. It must not be used after the session ended, as the
+// producer may be used by another session.
+func (s *TransactionSession) Input() chan<- *ProducerMessage {
+	return s.input
+}
+
+// AddOffsetsToTxn adds the offsets consumed by the group to the transaction,
+// see AsyncProducer.AddOffsetsToTxn.
+func (s *TransactionSession) AddOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, groupID string) error {
+	return s.AddGroupOffsetsToTxn(offsets, &ConsumerGroupMetadata{GroupID: groupID})
+}
+
+// AddGroupOffsetsToTxn adds the offsets consumed by the member of the group
+// to the transaction, see AsyncProducer.AddGroupOffsetsToTxn.
+func (s *TransactionSession) AddGroupOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, group *ConsumerGroupMetadata) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.pp == nil {
+		return ErrTransactionNotReady
+	}
+	return s.pp.producer.AddGroupOffsetsToTxn(offsets, group)
+}
+
+// AddMessageToTxn adds the offset of the consumed message to the
+// transaction, see AsyncProducer.AddMessageToTxn.
+func (s *TransactionSession) AddMessageToTxn(msg *ConsumerMessage, groupID string, metadata *string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.pp == nil {
+		return ErrTransactionNotReady
+	}
+	return s.pp.producer.AddMessageToTxn(msg, groupID, metadata)
+}
+
+// Commit commits the transaction and releases the producer of the session.
+// If the transaction cannot be committed, it is aborted and the error is
+// returned.
+func (s *TransactionSession) Commit() error {
+	return s.end(true)
+}
+
+// Abort aborts the transaction and releases the producer of the session.
+func (s *TransactionSession) Abort() error {
+	return s.end(false)
+}
+
+func (s *TransactionSession) end(commit bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.pp == nil {
+		return ErrTransactionNotReady
+	}
+
+	var err error
+	if commit {
+		err = s.pp.producer.CommitTxn()
+		if errors.Is(err, ErrTxnAbortable) {
+			if abortErr := s.pp.producer.AbortTxn(); abortErr != nil {
+				err = abortErr
+			}
+		}
+	} else {
+		err = s.pp.producer.AbortTxn()
+	}
+
+	s.pool.release(s.pp, err)
+	s.pp = nil
+	return err
+}
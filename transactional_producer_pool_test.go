@@ -0,0 +1,144 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestProducerPool(t *testing.T, broker *MockBroker, size int) *TransactionalProducerPool {
+	pool, err := NewTransactionalProducerPool([]string{broker.Addr()}, newTxnProducerConfig("txn"), size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+func TestTransactionalProducerPoolConcurrentSessions(t *testing.T) {
+	broker, coordinator, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	pool := newTestProducerPool(t, broker, 2)
+	defer safeClose(t, pool)
+	for _, id := range []string{"txn-0", "txn-1"} {
+		if _, _, ok := coordinator.ProducerID(id); !ok {
+			t.Errorf("expected the producer of %s to be initialized", id)
+		}
+	}
+
+	first, err := pool.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := pool.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.TransactionalID() == second.TransactionalID() {
+		t.Errorf("expected distinct transactional IDs, got %s twice", first.TransactionalID())
+	}
+
+	// all the producers are used
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Begin(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the pool to be exhausted, got %v", err)
+	}
+
+	for _, session := range []*TransactionSession{first, second} {
+		session.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	if err := first.Commit(); err != nil {
+		t.Error(err)
+	}
+	if err := second.Abort(); err != nil {
+		t.Error(err)
+	}
+	if err := first.Commit(); !errors.Is(err, ErrTransactionNotReady) {
+		t.Errorf("expected ErrTransactionNotReady once the session ended, got %v", err)
+	}
+
+	if results := coordinator.Results(first.TransactionalID()); len(results) != 1 || !results[0] {
+		t.Errorf("expected 1 committed transaction, got %v", results)
+	}
+	if results := coordinator.Results(second.TransactionalID()); len(results) != 1 || results[0] {
+		t.Errorf("expected 1 aborted transaction, got %v", results)
+	}
+
+	// the producers are recycled
+	for i := 0; i < 4; i++ {
+		session, err := pool.Begin(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := session.Commit(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTransactionalProducerPoolReplacesFencedProducer(t *testing.T) {
+	broker, coordinator, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	pool := newTestProducerPool(t, broker, 1)
+	defer safeClose(t, pool)
+
+	session, err := pool.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+
+	// another instance of the application fences the producer
+	fencing := newTxnProducer(t, broker, "txn-0")
+	if err := session.Commit(); !errors.Is(err, ErrTxnFatal) {
+		t.Errorf("expected ErrTxnFatal, got %v", err)
+	}
+	safeClose(t, fencing)
+
+	// the fenced producer is created again, fencing the other instance
+	session, err = pool.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Commit(); err != nil {
+		t.Error(err)
+	}
+	if _, epoch, _ := coordinator.ProducerID("txn-0"); epoch != 2 {
+		t.Errorf("expected epoch 2, got %d", epoch)
+	}
+}
+
+func TestTransactionalProducerPoolClose(t *testing.T) {
+	broker, _, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	pool := newTestProducerPool(t, broker, 2)
+	session, err := pool.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	safeClose(t, pool)
+
+	if _, err := pool.Begin(context.Background()); !errors.Is(err, ErrClosedProducerPool) {
+		t.Errorf("expected ErrClosedProducerPool, got %v", err)
+	}
+	// the producer of the session is closed when it ends
+	if err := session.Commit(); err != nil {
+		t.Error(err)
+	}
+	if session.pp != nil {
+		t.Error("expected the session to end")
+	}
+}
+
+func TestTransactionalProducerPoolConfig(t *testing.T) {
+	if _, err := NewTransactionalProducerPool(nil, NewTestConfig(), 1); err == nil {
+		t.Error("expected an error without Producer.Transaction.ID")
+	}
+	if _, err := NewTransactionalProducerPool(nil, newTxnProducerConfig("txn"), 0); err == nil {
+		t.Error("expected an error with an empty pool")
+	}
+}
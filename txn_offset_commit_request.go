@@ -1,48 +1,77 @@
 package sarama
 
 type TxnOffsetCommitRequest struct {
+	Version         int16
 	TransactionalID string
 	GroupID         string
 	ProducerID      int64
 	ProducerEpoch   int16
+	// GenerationID, MemberID and GroupInstanceID identify the member of the
+	// consumer group committing the offsets, which are rejected if the
+	// member was fenced by a rebalance (v3 or later, KIP-447)
+	GenerationID    int32
+	MemberID        string
+	GroupInstanceID *string
 	Topics          map[string][]*PartitionOffsetMetadata
 }
 
 func (t *TxnOffsetCommitRequest) encode(pe packetEncoder) error {
-	if err := pe.putString(t.TransactionalID); err != nil {
+	isFlexible := t.Version >= 3
+
+	if err := putTxnString(pe, t.TransactionalID, isFlexible); err != nil {
 		return err
 	}
-	if err := pe.putString(t.GroupID); err != nil {
+	if err := putTxnString(pe, t.GroupID, isFlexible); err != nil {
 		return err
 	}
 	pe.putInt64(t.ProducerID)
 	pe.putInt16(t.ProducerEpoch)
 
-	if err := pe.putArrayLength(len(t.Topics)); err != nil {
+	if isFlexible {
+		pe.putInt32(t.GenerationID)
+		if err := pe.putCompactString(t.MemberID); err != nil {
+			return err
+		}
+		if err := pe.putNullableCompactString(t.GroupInstanceID); err != nil {
+			return err
+		}
+		pe.putCompactArrayLength(len(t.Topics))
+	} else if err := pe.putArrayLength(len(t.Topics)); err != nil {
 		return err
 	}
 	for topic, partitions := range t.Topics {
-		if err := pe.putString(topic); err != nil {
+		if err := putTxnString(pe, topic, isFlexible); err != nil {
 			return err
 		}
-		if err := pe.putArrayLength(len(partitions)); err != nil {
+		if isFlexible {
+			pe.putCompactArrayLength(len(partitions))
+		} else if err := pe.putArrayLength(len(partitions)); err != nil {
 			return err
 		}
 		for _, partition := range partitions {
-			if err := partition.encode(pe); err != nil {
+			if err := partition.encode(pe, t.Version); err != nil {
 				return err
 			}
 		}
+		if isFlexible {
+			pe.putEmptyTaggedFieldArray()
+		}
 	}
 
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
 	return nil
 }
 
 func (t *TxnOffsetCommitRequest) decode(pd packetDecoder, version int16) (err error) {
-	if t.TransactionalID, err = pd.getString(); err != nil {
+	t.Version = version
+	isFlexible := version >= 3
+
+	if t.TransactionalID, err = getTxnString(pd, isFlexible); err != nil {
 		return err
 	}
-	if t.GroupID, err = pd.getString(); err != nil {
+	if t.GroupID, err = getTxnString(pd, isFlexible); err != nil {
 		return err
 	}
 	if t.ProducerID, err = pd.getInt64(); err != nil {
@@ -52,19 +81,38 @@ func (t *TxnOffsetCommitRequest) decode(pd packetDecoder, version int16) (err er
 		return err
 	}
 
-	n, err := pd.getArrayLength()
+	var n int
+	if isFlexible {
+		if t.GenerationID, err = pd.getInt32(); err != nil {
+			return err
+		}
+		if t.MemberID, err = pd.getCompactString(); err != nil {
+			return err
+		}
+		if t.GroupInstanceID, err = pd.getCompactNullableString(); err != nil {
+			return err
+		}
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
 
 	t.Topics = make(map[string][]*PartitionOffsetMetadata)
 	for i := 0; i < n; i++ {
-		topic, err := pd.getString()
+		topic, err := getTxnString(pd, isFlexible)
 		if err != nil {
 			return err
 		}
 
-		m, err := pd.getArrayLength()
+		var m int
+		if isFlexible {
+			m, err = pd.getCompactArrayLength()
+		} else {
+			m, err = pd.getArrayLength()
+		}
 		if err != nil {
 			return err
 		}
@@ -78,8 +126,19 @@ func (t *TxnOffsetCommitRequest) decode(pd packetDecoder, version int16) (err er
 			}
 			t.Topics[topic][j] = partitionOffsetMetadata
 		}
+
+		if isFlexible {
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
 	}
 
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -88,26 +147,80 @@ func (a *TxnOffsetCommitRequest) key() int16 {
 }
 
 func (a *TxnOffsetCommitRequest) version() int16 {
-	return 0
+	return a.Version
+}
+
+func (a *TxnOffsetCommitRequest) versionRange() (minVersion, maxVersion int16) {
+	if a.Version >= 3 && (a.GenerationID != -1 || a.MemberID != "" || a.GroupInstanceID != nil) {
+		return 3, 3
+	}
+	return 0, 3
+}
+
+func (a *TxnOffsetCommitRequest) withVersion(v int16) protocolBody {
+	request := *a
+	request.Version = v
+	if a.Version < 2 {
+		request.Topics = make(map[string][]*PartitionOffsetMetadata, len(a.Topics))
+		for topic, partitions := range a.Topics {
+			request.Topics[topic] = make([]*PartitionOffsetMetadata, len(partitions))
+			for j, partition := range partitions {
+				tmp := *partition
+				tmp.LeaderEpoch = -1
+				request.Topics[topic][j] = &tmp
+			}
+		}
+	}
+	if a.Version < 3 {
+		request.GenerationID = -1
+		request.MemberID = ""
+		request.GroupInstanceID = nil
+	}
+	return &request
 }
 
 func (a *TxnOffsetCommitRequest) headerVersion() int16 {
+	if a.Version >= 3 {
+		return 2
+	}
 	return 1
 }
 
 func (a *TxnOffsetCommitRequest) requiredVersion() KafkaVersion {
-	return V0_11_0_0
+	switch a.Version {
+	case 1:
+		return V2_0_0_0
+	case 2:
+		return V2_1_0_0
+	case 3:
+		return V2_5_0_0
+	default:
+		return V0_11_0_0
+	}
 }
 
 type PartitionOffsetMetadata struct {
 	Partition int32
 	Offset    int64
-	Metadata  *string
+	// LeaderEpoch is the leader epoch of the last consumed record, -1 if
+	// unknown (v2 or later)
+	LeaderEpoch int32
+	Metadata    *string
 }
 
-func (p *PartitionOffsetMetadata) encode(pe packetEncoder) error {
+func (p *PartitionOffsetMetadata) encode(pe packetEncoder, version int16) error {
 	pe.putInt32(p.Partition)
 	pe.putInt64(p.Offset)
+	if version >= 2 {
+		pe.putInt32(p.LeaderEpoch)
+	}
+	if version >= 3 {
+		if err := pe.putNullableCompactString(p.Metadata); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+		return nil
+	}
 	if err := pe.putNullableString(p.Metadata); err != nil {
 		return err
 	}
@@ -122,9 +235,37 @@ func (p *PartitionOffsetMetadata) decode(pd packetDecoder, version int16) (err e
 	if p.Offset, err = pd.getInt64(); err != nil {
 		return err
 	}
+	if version >= 2 {
+		if p.LeaderEpoch, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+	if version >= 3 {
+		if p.Metadata, err = pd.getCompactNullableString(); err != nil {
+			return err
+		}
+		_, err = pd.getEmptyTaggedFieldArray()
+		return err
+	}
 	if p.Metadata, err = pd.getNullableString(); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// putTxnString puts a string of the transaction requests and responses,
+// compact in their flexible versions
+func putTxnString(pe packetEncoder, s string, isFlexible bool) error {
+	if isFlexible {
+		return pe.putCompactString(s)
+	}
+	return pe.putString(s)
+}
+
+func getTxnString(pd packetDecoder, isFlexible bool) (string, error) {
+	if isFlexible {
+		return pd.getCompactString()
+	}
+	return pd.getString()
+}
@@ -31,3 +31,60 @@ func TestTxnOffsetCommitRequest(t *testing.T) {
 
 	testRequest(t, "", req, txnOffsetCommitRequest)
 }
+
+var txnOffsetCommitRequestV2 = []byte{
+	0, 3, 't', 'x', 'n',
+	0, 7, 'g', 'r', 'o', 'u', 'p', 'i', 'd',
+	0, 0, 0, 0, 0, 0, 31, 64, // producer ID
+	0, 1, // producer epoch
+	0, 0, 0, 1, // 1 topic
+	0, 5, 't', 'o', 'p', 'i', 'c',
+	0, 0, 0, 1, // 1 partition
+	0, 0, 0, 2, // partition no 2
+	0, 0, 0, 0, 0, 0, 0, 123,
+	0, 0, 0, 5, // leader epoch
+	255, 255, // no meta data
+}
+
+var txnOffsetCommitRequestV3 = []byte{
+	4, 't', 'x', 'n',
+	8, 'g', 'r', 'o', 'u', 'p', 'i', 'd',
+	0, 0, 0, 0, 0, 0, 31, 64, // producer ID
+	0, 1, // producer epoch
+	0, 0, 0, 3, // generation ID
+	7, 'm', 'e', 'm', 'b', 'e', 'r',
+	0, // no group instance ID
+	2, // 1 topic
+	6, 't', 'o', 'p', 'i', 'c',
+	2,          // 1 partition
+	0, 0, 0, 2, // partition no 2
+	0, 0, 0, 0, 0, 0, 0, 123,
+	0, 0, 0, 5, // leader epoch
+	0, // no meta data
+	0, // empty partition tagged fields
+	0, // empty topic tagged fields
+	0, // empty tagged fields
+}
+
+func TestTxnOffsetCommitRequestVersions(t *testing.T) {
+	req := &TxnOffsetCommitRequest{
+		Version:         2,
+		TransactionalID: "txn",
+		GroupID:         "groupid",
+		ProducerID:      8000,
+		ProducerEpoch:   1,
+		Topics: map[string][]*PartitionOffsetMetadata{
+			"topic": {{
+				Offset:      123,
+				Partition:   2,
+				LeaderEpoch: 5,
+			}},
+		},
+	}
+	testRequest(t, "V2", req, txnOffsetCommitRequestV2)
+
+	req.Version = 3
+	req.GenerationID = 3
+	req.MemberID = "member"
+	testRequest(t, "V3", req, txnOffsetCommitRequestV3)
+}
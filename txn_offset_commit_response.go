@@ -5,41 +5,65 @@ import (
 )
 
 type TxnOffsetCommitResponse struct {
+	Version      int16
 	ThrottleTime time.Duration
 	Topics       map[string][]*PartitionError
 }
 
 func (t *TxnOffsetCommitResponse) encode(pe packetEncoder) error {
+	isFlexible := t.Version >= 3
+
 	pe.putInt32(int32(t.ThrottleTime / time.Millisecond))
-	if err := pe.putArrayLength(len(t.Topics)); err != nil {
+	if isFlexible {
+		pe.putCompactArrayLength(len(t.Topics))
+	} else if err := pe.putArrayLength(len(t.Topics)); err != nil {
 		return err
 	}
 
 	for topic, e := range t.Topics {
-		if err := pe.putString(topic); err != nil {
+		if err := putTxnString(pe, topic, isFlexible); err != nil {
 			return err
 		}
-		if err := pe.putArrayLength(len(e)); err != nil {
+		if isFlexible {
+			pe.putCompactArrayLength(len(e))
+		} else if err := pe.putArrayLength(len(e)); err != nil {
 			return err
 		}
 		for _, partitionError := range e {
 			if err := partitionError.encode(pe); err != nil {
 				return err
 			}
+			if isFlexible {
+				pe.putEmptyTaggedFieldArray()
+			}
+		}
+		if isFlexible {
+			pe.putEmptyTaggedFieldArray()
 		}
 	}
 
+	if isFlexible {
+		pe.putEmptyTaggedFieldArray()
+	}
 	return nil
 }
 
 func (t *TxnOffsetCommitResponse) decode(pd packetDecoder, version int16) (err error) {
+	t.Version = version
+	isFlexible := version >= 3
+
 	throttleTime, err := pd.getInt32()
 	if err != nil {
 		return err
 	}
 	t.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
 
-	n, err := pd.getArrayLength()
+	var n int
+	if isFlexible {
+		n, err = pd.getCompactArrayLength()
+	} else {
+		n, err = pd.getArrayLength()
+	}
 	if err != nil {
 		return err
 	}
@@ -47,12 +71,17 @@ func (t *TxnOffsetCommitResponse) decode(pd packetDecoder, version int16) (err e
 	t.Topics = make(map[string][]*PartitionError)
 
 	for i := 0; i < n; i++ {
-		topic, err := pd.getString()
+		topic, err := getTxnString(pd, isFlexible)
 		if err != nil {
 			return err
 		}
 
-		m, err := pd.getArrayLength()
+		var m int
+		if isFlexible {
+			m, err = pd.getCompactArrayLength()
+		} else {
+			m, err = pd.getArrayLength()
+		}
 		if err != nil {
 			return err
 		}
@@ -64,9 +93,25 @@ func (t *TxnOffsetCommitResponse) decode(pd packetDecoder, version int16) (err e
 			if err := t.Topics[topic][j].decode(pd, version); err != nil {
 				return err
 			}
+			if isFlexible {
+				if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if isFlexible {
+			if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
 		}
 	}
 
+	if isFlexible {
+		if _, err := pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -75,13 +120,29 @@ func (a *TxnOffsetCommitResponse) key() int16 {
 }
 
 func (a *TxnOffsetCommitResponse) version() int16 {
-	return 0
+	return a.Version
+}
+
+func (a *TxnOffsetCommitResponse) setVersion(v int16) {
+	a.Version = v
 }
 
 func (a *TxnOffsetCommitResponse) headerVersion() int16 {
+	if a.Version >= 3 {
+		return 1
+	}
 	return 0
 }
 
 func (a *TxnOffsetCommitResponse) requiredVersion() KafkaVersion {
-	return V0_11_0_0
+	switch a.Version {
+	case 1:
+		return V2_0_0_0
+	case 2:
+		return V2_1_0_0
+	case 3:
+		return V2_5_0_0
+	default:
+		return V0_11_0_0
+	}
 }
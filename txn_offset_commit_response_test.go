@@ -27,3 +27,30 @@ func TestTxnOffsetCommitResponse(t *testing.T) {
 
 	testResponse(t, "", resp, txnOffsetCommitResponse)
 }
+
+var txnOffsetCommitResponseV3 = []byte{
+	0, 0, 0, 100,
+	2, // 1 topic
+	6, 't', 'o', 'p', 'i', 'c',
+	2,          // 1 partition response
+	0, 0, 0, 2, // partition number 2
+	0, 22, // err
+	0, // empty partition tagged fields
+	0, // empty topic tagged fields
+	0, // empty tagged fields
+}
+
+func TestTxnOffsetCommitResponseV3(t *testing.T) {
+	resp := &TxnOffsetCommitResponse{
+		Version:      3,
+		ThrottleTime: 100 * time.Millisecond,
+		Topics: map[string][]*PartitionError{
+			"topic": {{
+				Partition: 2,
+				Err:       ErrIllegalGeneration,
+			}},
+		},
+	}
+
+	testResponse(t, "V3", resp, txnOffsetCommitResponseV3)
+}
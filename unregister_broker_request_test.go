@@ -0,0 +1,16 @@
+package sarama
+
+import "testing"
+
+var unregisterBrokerRequest = []byte{
+	0, 0, 0, 4, // broker ID
+	0, // empty tagged fields
+}
+
+func TestUnregisterBrokerRequest(t *testing.T) {
+	request := &UnregisterBrokerRequest{
+		Version:  0,
+		BrokerID: 4,
+	}
+	testRequest(t, "basic", request, unregisterBrokerRequest)
+}
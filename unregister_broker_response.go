@@ -0,0 +1,63 @@
+package sarama
+
+import "time"
+
+// UnregisterBrokerResponse is the response to an UnregisterBrokerRequest
+type UnregisterBrokerResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime time.Duration
+	ErrorCode    KError
+	ErrorMessage *string
+}
+
+func (r *UnregisterBrokerResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	pe.putInt16(int16(r.ErrorCode))
+	if err := pe.putNullableCompactString(r.ErrorMessage); err != nil {
+		return err
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *UnregisterBrokerResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.ErrorCode = KError(kerr)
+
+	if r.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *UnregisterBrokerResponse) key() int16 {
+	return 64
+}
+
+func (r *UnregisterBrokerResponse) version() int16 {
+	return r.Version
+}
+
+func (r *UnregisterBrokerResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *UnregisterBrokerResponse) requiredVersion() KafkaVersion {
+	return V3_1_0_0
+}
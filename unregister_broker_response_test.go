@@ -0,0 +1,38 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	unregisterBrokerResponseNoError = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		0, 0, // no error code
+		0, // no error message
+		0, // empty tagged fields
+	}
+
+	unregisterBrokerResponseError = []byte{
+		0, 0, 0, 0, // throttle time
+		0, 102, // broker ID not registered
+		5, 'o', 'o', 'p', 's', // error message
+		0, // empty tagged fields
+	}
+)
+
+func TestUnregisterBrokerResponse(t *testing.T) {
+	response := &UnregisterBrokerResponse{
+		Version:      0,
+		ThrottleTime: 100 * time.Millisecond,
+	}
+	testResponse(t, "no error", response, unregisterBrokerResponseNoError)
+
+	errMsg := "oops"
+	response = &UnregisterBrokerResponse{
+		Version:      0,
+		ErrorCode:    ErrBrokerIDNotRegistered,
+		ErrorMessage: &errMsg,
+	}
+	testResponse(t, "with error", response, unregisterBrokerResponseError)
+}
@@ -0,0 +1,96 @@
+package sarama
+
+import "time"
+
+// UpdateFeaturesRequest is a request to update the cluster-wide finalized
+// feature version levels (KIP-584)
+type UpdateFeaturesRequest struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	Timeout        time.Duration
+	FeatureUpdates []*FeatureUpdateKey
+}
+
+// FeatureUpdateKey is a single feature update of an UpdateFeaturesRequest
+type FeatureUpdateKey struct {
+	Feature string
+	// MaxVersionLevel is the new maximum version level for the finalized
+	// feature, a value < 1 is special and denotes that the feature is to be
+	// deleted
+	MaxVersionLevel int16
+	// AllowDowngrade must be true if the new level is lower than the current
+	// finalized level
+	AllowDowngrade bool
+}
+
+func (r *UpdateFeaturesRequest) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.Timeout / time.Millisecond))
+
+	pe.putCompactArrayLength(len(r.FeatureUpdates))
+	for _, u := range r.FeatureUpdates {
+		if err := pe.putCompactString(u.Feature); err != nil {
+			return err
+		}
+		pe.putInt16(u.MaxVersionLevel)
+		pe.putBool(u.AllowDowngrade)
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *UpdateFeaturesRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	timeout, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.Timeout = time.Duration(timeout) * time.Millisecond
+
+	numUpdates, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+
+	if numUpdates > 0 {
+		r.FeatureUpdates = make([]*FeatureUpdateKey, numUpdates)
+		for i := 0; i < numUpdates; i++ {
+			u := &FeatureUpdateKey{}
+			if u.Feature, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if u.MaxVersionLevel, err = pd.getInt16(); err != nil {
+				return err
+			}
+			if u.AllowDowngrade, err = pd.getBool(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			r.FeatureUpdates[i] = u
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *UpdateFeaturesRequest) key() int16 {
+	return 57
+}
+
+func (r *UpdateFeaturesRequest) version() int16 {
+	return r.Version
+}
+
+func (r *UpdateFeaturesRequest) headerVersion() int16 {
+	return 2
+}
+
+func (r *UpdateFeaturesRequest) requiredVersion() KafkaVersion {
+	return V2_7_0_0
+}
@@ -0,0 +1,37 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	updateFeaturesRequestEmpty = []byte{
+		0, 0, 0x75, 0x30, // timeout (30000 ms)
+		1, // empty feature updates
+		0, // empty tagged fields
+	}
+
+	updateFeaturesRequestOneUpdate = []byte{
+		0, 0, 0x75, 0x30, // timeout (30000 ms)
+		2,                                                                                  // one feature update
+		17, 'm', 'e', 't', 'a', 'd', 'a', 't', 'a', '.', 'v', 'e', 'r', 's', 'i', 'o', 'n', // feature
+		0, 4, // max version level
+		1, // allow downgrade
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+)
+
+func TestUpdateFeaturesRequest(t *testing.T) {
+	request := &UpdateFeaturesRequest{
+		Version: 0,
+		Timeout: 30 * time.Second,
+	}
+	testRequest(t, "no updates", request, updateFeaturesRequestEmpty)
+
+	request.FeatureUpdates = []*FeatureUpdateKey{
+		{Feature: "metadata.version", MaxVersionLevel: 4, AllowDowngrade: true},
+	}
+	testRequest(t, "one update", request, updateFeaturesRequestOneUpdate)
+}
@@ -0,0 +1,115 @@
+package sarama
+
+import "time"
+
+// UpdateFeaturesResponse is the response to an UpdateFeaturesRequest (KIP-584)
+type UpdateFeaturesResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+
+	ThrottleTime time.Duration
+
+	// ErrorCode and ErrorMessage are the top-level error, or zero if there
+	// was no top-level error
+	ErrorCode    KError
+	ErrorMessage *string
+
+	Results []*UpdatableFeatureResult
+}
+
+// UpdatableFeatureResult is the result of a single feature update
+type UpdatableFeatureResult struct {
+	Feature      string
+	ErrorCode    KError
+	ErrorMessage *string
+}
+
+func (r *UpdateFeaturesResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+
+	pe.putInt16(int16(r.ErrorCode))
+	if err := pe.putNullableCompactString(r.ErrorMessage); err != nil {
+		return err
+	}
+
+	pe.putCompactArrayLength(len(r.Results))
+	for _, res := range r.Results {
+		if err := pe.putCompactString(res.Feature); err != nil {
+			return err
+		}
+		pe.putInt16(int16(res.ErrorCode))
+		if err := pe.putNullableCompactString(res.ErrorMessage); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *UpdateFeaturesResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.ErrorCode = KError(kerr)
+
+	if r.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+
+	numResults, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+
+	if numResults > 0 {
+		r.Results = make([]*UpdatableFeatureResult, numResults)
+		for i := 0; i < numResults; i++ {
+			res := &UpdatableFeatureResult{}
+			if res.Feature, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			kerr, err := pd.getInt16()
+			if err != nil {
+				return err
+			}
+			res.ErrorCode = KError(kerr)
+			if res.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+			r.Results[i] = res
+		}
+	}
+
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *UpdateFeaturesResponse) key() int16 {
+	return 57
+}
+
+func (r *UpdateFeaturesResponse) version() int16 {
+	return r.Version
+}
+
+func (r *UpdateFeaturesResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *UpdateFeaturesResponse) requiredVersion() KafkaVersion {
+	return V2_7_0_0
+}
@@ -0,0 +1,47 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	updateFeaturesResponseNoError = []byte{
+		0, 0, 0, 100, // throttle time (100 ms)
+		0, 0, // no error code
+		0,                                                                                  // no error message
+		2,                                                                                  // one result
+		17, 'm', 'e', 't', 'a', 'd', 'a', 't', 'a', '.', 'v', 'e', 'r', 's', 'i', 'o', 'n', // feature
+		0, 0, // no error code
+		0, // no error message
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+
+	updateFeaturesResponseError = []byte{
+		0, 0, 0, 0, // throttle time
+		0, 42, // invalid request
+		5, 'o', 'o', 'p', 's', // error message
+		1, // no results
+		0, // empty tagged fields
+	}
+)
+
+func TestUpdateFeaturesResponse(t *testing.T) {
+	response := &UpdateFeaturesResponse{
+		Version:      0,
+		ThrottleTime: 100 * time.Millisecond,
+		Results: []*UpdatableFeatureResult{
+			{Feature: "metadata.version", ErrorCode: ErrNoError},
+		},
+	}
+	testResponse(t, "no error", response, updateFeaturesResponseNoError)
+
+	errMsg := "oops"
+	response = &UpdateFeaturesResponse{
+		Version:      0,
+		ErrorCode:    ErrInvalidRequest,
+		ErrorMessage: &errMsg,
+	}
+	testResponse(t, "with error", response, updateFeaturesResponseError)
+}
@@ -2,6 +2,7 @@ package sarama
 
 import (
 	"bufio"
+	"encoding/base64"
 	"fmt"
 	"net"
 	"regexp"
@@ -181,6 +182,16 @@ var (
 	V2_8_1_0  = newKafkaVersion(2, 8, 1, 0)
 	V3_0_0_0  = newKafkaVersion(3, 0, 0, 0)
 	V3_1_0_0  = newKafkaVersion(3, 1, 0, 0)
+	V3_2_0_0  = newKafkaVersion(3, 2, 0, 0)
+	V3_3_0_0  = newKafkaVersion(3, 3, 0, 0)
+	V3_4_0_0  = newKafkaVersion(3, 4, 0, 0)
+	V3_5_0_0  = newKafkaVersion(3, 5, 0, 0)
+	V3_6_0_0  = newKafkaVersion(3, 6, 0, 0)
+	V3_7_0_0  = newKafkaVersion(3, 7, 0, 0)
+	V3_8_0_0  = newKafkaVersion(3, 8, 0, 0)
+	V3_9_0_0  = newKafkaVersion(3, 9, 0, 0)
+	V4_0_0_0  = newKafkaVersion(4, 0, 0, 0)
+	V4_1_0_0  = newKafkaVersion(4, 1, 0, 0)
 
 	SupportedVersions = []KafkaVersion{
 		V0_8_2_0,
@@ -225,14 +236,33 @@ var (
 		V2_8_1_0,
 		V3_0_0_0,
 		V3_1_0_0,
+		V3_2_0_0,
+		V3_3_0_0,
+		V3_4_0_0,
+		V3_5_0_0,
+		V3_6_0_0,
+		V3_7_0_0,
+		V3_8_0_0,
+		V3_9_0_0,
+		V4_0_0_0,
+		V4_1_0_0,
 	}
 	MinVersion     = V0_8_2_0
-	MaxVersion     = V3_1_0_0
+	MaxVersion     = V4_1_0_0
 	DefaultVersion = V1_0_0_0
+
+	// VersionAuto makes NewClient derive the Version from the API versions
+	// and the finalized features of the first seed broker answering, see
+	// Config.Version.
+	VersionAuto = KafkaVersion{}
 )
 
-// ParseKafkaVersion parses and returns kafka version or error from a string
+// ParseKafkaVersion parses and returns kafka version or error from a string,
+// "auto" being VersionAuto
 func ParseKafkaVersion(s string) (KafkaVersion, error) {
+	if s == "auto" {
+		return VersionAuto, nil
+	}
 	if len(s) < 5 {
 		return DefaultVersion, fmt.Errorf("invalid version `%s`", s)
 	}
@@ -258,9 +288,51 @@ func scanKafkaVersion(s string, pattern string, format string, v [3]*uint) error
 }
 
 func (v KafkaVersion) String() string {
+	if v == VersionAuto {
+		return "auto"
+	}
 	if v.version[0] == 0 {
 		return fmt.Sprintf("0.%d.%d.%d", v.version[1], v.version[2], v.version[3])
 	}
 
 	return fmt.Sprintf("%d.%d.%d", v.version[0], v.version[1], v.version[2])
 }
+
+// Uuid is a 128 bit identifier, such as the topic IDs of KIP-516
+type Uuid [16]byte
+
+// NullUuid is the zero Uuid, used by the protocol when no ID is set
+var NullUuid = Uuid{}
+
+// String returns the base64 representation used by the Kafka tools
+func (u Uuid) String() string {
+	return base64.RawURLEncoding.EncodeToString(u[:])
+}
+
+// ParseUuid parses the base64 representation of a Uuid
+func ParseUuid(s string) (Uuid, error) {
+	var u Uuid
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return u, err
+	}
+	if len(b) != len(u) {
+		return u, fmt.Errorf("invalid Uuid %q: expected %d bytes, got %d", s, len(u), len(b))
+	}
+	copy(u[:], b)
+	return u, nil
+}
+
+func putUuid(pe packetEncoder, u Uuid) error {
+	return pe.putRawBytes(u[:])
+}
+
+func getUuid(pd packetDecoder) (Uuid, error) {
+	var u Uuid
+	b, err := pd.getRawBytes(len(u))
+	if err != nil {
+		return u, err
+	}
+	copy(u[:], b)
+	return u, nil
+}
@@ -0,0 +1,159 @@
+package sarama
+
+import "sync/atomic"
+
+// apiVersionMarkers are the first Kafka versions supporting an API at a
+// version, in increasing order, from which the version of a broker is derived
+var apiVersionMarkers = []struct {
+	version    KafkaVersion
+	apiKey     int16
+	maxVersion int16
+}{
+	{V0_10_0_0, 18, 0}, // ApiVersions
+	{V0_10_1_0, 19, 0}, // CreateTopics
+	{V0_10_2_0, 9, 2},  // OffsetFetch v2
+	{V0_11_0_0, 22, 0}, // InitProducerId
+	{V1_0_0_0, 35, 0},  // DescribeLogDirs
+	{V1_1_0_0, 38, 0},  // CreateDelegationToken
+	{V2_0_0_0, 1, 8},   // Fetch v8
+	{V2_1_0_0, 0, 7},   // Produce v7
+	{V2_2_0_0, 43, 0},  // ElectLeaders
+	{V2_3_0_0, 44, 0},  // IncrementalAlterConfigs
+	{V2_4_0_0, 45, 0},  // AlterPartitionReassignments
+	{V2_5_0_0, 14, 5},  // SyncGroup v5
+	{V2_6_0_0, 48, 0},  // DescribeClientQuotas
+	{V2_7_0_0, 50, 0},  // DescribeUserScramCredentials
+	{V2_8_0_0, 60, 0},  // DescribeCluster
+	{V3_0_0_0, 61, 0},  // DescribeProducers
+	{V3_1_0_0, 1, 13},  // Fetch v13
+}
+
+// metadataVersionLevels are the Kafka versions of the levels of the
+// metadata.version feature finalized by the KRaft clusters (KIP-778), in
+// increasing order
+var metadataVersionLevels = []struct {
+	level   int16
+	version KafkaVersion
+}{
+	{1, V3_0_0_0},
+	{2, V3_1_0_0},
+	{3, V3_2_0_0},
+	{4, V3_3_0_0},
+	{8, V3_4_0_0},
+	{9, V3_5_0_0},
+	{12, V3_6_0_0},
+	{15, V3_7_0_0},
+	{20, V3_8_0_0},
+	{21, V3_9_0_0},
+	{22, V4_0_0_0},
+	{26, V4_1_0_0},
+}
+
+// kafkaVersionFromApiVersions returns the Kafka version of a broker derived
+// from its ApiVersionsResponse: the most recent of those of the APIs it
+// supports and of the metadata.version feature finalized by the cluster.
+func kafkaVersionFromApiVersions(response *ApiVersionsResponse) KafkaVersion {
+	maxVersions := make(map[int16]int16, len(response.ApiKeys))
+	for _, key := range response.ApiKeys {
+		maxVersions[key.ApiKey] = key.MaxVersion
+	}
+
+	version := MinVersion
+	for _, marker := range apiVersionMarkers {
+		if maxVersion, ok := maxVersions[marker.apiKey]; ok && maxVersion >= marker.maxVersion {
+			version = marker.version
+		}
+	}
+	for _, feature := range response.FinalizedFeatures {
+		if feature.Name != "metadata.version" {
+			continue
+		}
+		for _, level := range metadataVersionLevels {
+			if feature.MaxVersionLevel >= level.level && level.version.IsAtLeast(version) {
+				version = level.version
+			}
+		}
+	}
+	if version.IsAtLeast(MaxVersion) {
+		return MaxVersion
+	}
+	return version
+}
+
+// detectVersion sets the Version of the config, VersionAuto, to the one of
+// the first seed broker answering, or to DefaultVersion if none does.
+func (client *client) detectVersion() {
+	for _, seed := range client.seedBrokers {
+		version, err := detectBrokerVersion(seed.Addr(), client.conf)
+		if err != nil {
+			client.log().with("seed", seed.Addr()).Warnf("client/version failed to get the API versions of broker %s: %s", seed.Addr(), err)
+			continue
+		}
+		client.log().with("seed", seed.Addr()).Infof("client/version using Version %s derived from broker %s", version, seed.Addr())
+		client.conf.Version = version
+		return
+	}
+	client.log().Warnf("client/version no seed broker answered, using Version %s", DefaultVersion)
+	client.conf.Version = DefaultVersion
+}
+
+// detectBrokerVersion connects to the broker to derive its Kafka version
+// from the ApiVersions responses, with a copy of the config whose Version is
+// the first one supporting them. The copy is not validated, the settings
+// requiring a more recent version being checked once the actual one is known.
+func detectBrokerVersion(addr string, conf *Config) (KafkaVersion, error) {
+	detectConf := *conf
+	detectConf.Version = V0_10_0_0
+	detectConf.NegotiateApiVersions = false
+
+	broker := NewBroker(addr)
+	atomic.StoreInt32(&broker.opened, 1)
+	broker.connect(&detectConf)
+	defer func() { _ = broker.Close() }()
+
+	response, err := broker.ApiVersions(&ApiVersionsRequest{})
+	if err == nil && response.ErrorCode != int16(ErrNoError) {
+		err = KError(response.ErrorCode)
+	}
+	if err != nil {
+		return VersionAuto, err
+	}
+
+	for _, key := range response.ApiKeys {
+		if key.ApiKey == apiVersionsKey && key.MaxVersion >= 3 {
+			if features, err := apiVersionsWithFeatures(broker); err == nil {
+				response = features
+			} else {
+				broker.log().Warnf("client/version failed to get the features of broker %s: %s", addr, err)
+			}
+		}
+	}
+	return kafkaVersionFromApiVersions(response), nil
+}
+
+// apiVersionsWithFeatures sends an ApiVersionsRequest v3, the only version
+// reporting the finalized features, as a raw request as it requires a
+// Version >= V2_4_0_0 which would change the other requests of the
+// connection, such as the SASL ones.
+func apiVersionsWithFeatures(broker *Broker) (*ApiVersionsResponse, error) {
+	body, err := encode(&ApiVersionsRequest{
+		Version:               3,
+		ClientSoftwareName:    defaultClientSoftwareName,
+		ClientSoftwareVersion: version(),
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := broker.SendRaw(&RawRequest{APIKey: apiVersionsKey, APIVersion: 3, Flexible: true, Body: body})
+	if err != nil {
+		return nil, err
+	}
+	response := new(ApiVersionsResponse)
+	if err := versionedDecode(raw.Body, response, 3); err != nil {
+		return nil, err
+	}
+	if response.ErrorCode != int16(ErrNoError) {
+		return nil, KError(response.ErrorCode)
+	}
+	return response, nil
+}
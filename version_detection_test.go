@@ -0,0 +1,121 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKafkaVersionFromApiVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *ApiVersionsResponse
+		expected KafkaVersion
+	}{
+		{
+			"0.10.0",
+			&ApiVersionsResponse{ApiKeys: []ApiVersionsResponseKey{{ApiKey: 0, MaxVersion: 2}, {ApiKey: 18, MaxVersion: 0}}},
+			V0_10_0_0,
+		},
+		{
+			"2.1",
+			&ApiVersionsResponse{ApiKeys: []ApiVersionsResponseKey{{ApiKey: 0, MaxVersion: 7}, {ApiKey: 1, MaxVersion: 10}, {ApiKey: 18, MaxVersion: 2}, {ApiKey: 22, MaxVersion: 1}}},
+			V2_1_0_0,
+		},
+		{
+			"3.1 with ZooKeeper",
+			&ApiVersionsResponse{ApiKeys: []ApiVersionsResponseKey{{ApiKey: 1, MaxVersion: 13}, {ApiKey: 18, MaxVersion: 3}, {ApiKey: 61, MaxVersion: 0}}},
+			V3_1_0_0,
+		},
+		{
+			"3.7 with KRaft",
+			&ApiVersionsResponse{
+				ApiKeys:           []ApiVersionsResponseKey{{ApiKey: 1, MaxVersion: 16}, {ApiKey: 18, MaxVersion: 3}},
+				FinalizedFeatures: []ApiVersionsResponseFinalizedFeature{{Name: "metadata.version", MinVersionLevel: 1, MaxVersionLevel: 15}},
+			},
+			V3_7_0_0,
+		},
+		{
+			"newer than MaxVersion",
+			&ApiVersionsResponse{
+				ApiKeys:           []ApiVersionsResponseKey{{ApiKey: 18, MaxVersion: 4}},
+				FinalizedFeatures: []ApiVersionsResponseFinalizedFeature{{Name: "metadata.version", MinVersionLevel: 1, MaxVersionLevel: 99}},
+			},
+			MaxVersion,
+		},
+	}
+	for _, test := range tests {
+		if version := kafkaVersionFromApiVersions(test.response); version != test.expected {
+			t.Errorf("[%s] expected %s, got %s", test.name, test.expected, version)
+		}
+	}
+}
+
+func TestClientVersionAuto(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t).
+			SetApiKeys([]ApiVersionsResponseKey{
+				{ApiKey: 0, MaxVersion: 9},
+				{ApiKey: 1, MaxVersion: 13},
+				{ApiKey: 3, MaxVersion: 12},
+				{ApiKey: 18, MaxVersion: 3},
+				{ApiKey: 61, MaxVersion: 0},
+			}).
+			SetFinalizedFeature("metadata.version", 1, 20),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = VersionAuto
+	config.Producer.Compression = CompressionZSTD
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	if version := client.Config().Version; version != V3_8_0_0 {
+		t.Errorf("expected Version %s, got %s", V3_8_0_0, version)
+	}
+}
+
+func TestClientVersionAutoValidatesTheDetectedVersion(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t).
+			SetApiKeys([]ApiVersionsResponseKey{{ApiKey: 0, MaxVersion: 5}, {ApiKey: 18, MaxVersion: 1}, {ApiKey: 35, MaxVersion: 0}}),
+	})
+
+	config := NewTestConfig()
+	config.Version = VersionAuto
+	config.Producer.Compression = CompressionZSTD
+	_, err := NewClient([]string{seedBroker.Addr()}, config)
+	var target ConfigurationError
+	if !errors.As(err, &target) || string(target) != "zstd compression requires Version >= V2_1_0_0" {
+		t.Errorf("expected zstd to be rejected with Version %s, got %v", config.Version, err)
+	}
+	if config.Version != V1_0_0_0 {
+		t.Errorf("expected Version %s, got %s", V1_0_0_0, config.Version)
+	}
+}
+
+func TestBrokerOpenRejectsVersionAuto(t *testing.T) {
+	config := NewTestConfig()
+	config.Version = VersionAuto
+	broker := NewBroker("localhost:0")
+	if err := broker.Open(config); !errors.As(err, new(ConfigurationError)) {
+		t.Errorf("expected a ConfigurationError, got %v", err)
+	}
+}
+
+func TestParseKafkaVersionAuto(t *testing.T) {
+	if version, err := ParseKafkaVersion("auto"); err != nil || version != VersionAuto {
+		t.Errorf("expected VersionAuto, got %s, %v", version, err)
+	}
+	if VersionAuto.String() != "auto" {
+		t.Errorf("expected auto, got %s", VersionAuto)
+	}
+}
@@ -0,0 +1,111 @@
+package sarama
+
+// negotiableRequest is implemented by the requests whose version can be
+// negotiated with the broker, see Config.NegotiateApiVersions
+type negotiableRequest interface {
+	protocolBody
+	// versionRange returns the versions the request can be sent with while
+	// keeping the meaning it has at the version it was built for
+	versionRange() (minVersion, maxVersion int16)
+	// withVersion returns a copy of the request set to the version, leaving
+	// the request itself untouched
+	withVersion(v int16) protocolBody
+}
+
+// versionSetter is implemented by the responses of the negotiable requests
+// so that their header is decoded for the negotiated version
+type versionSetter interface {
+	setVersion(v int16)
+}
+
+// supportedVersions returns the versions of the API the broker reported in
+// its ApiVersionsResponse, if any
+func (b *Broker) supportedVersions(key int16) (minVersion, maxVersion int16, ok bool) {
+	b.apiVersionsLock.RLock()
+	defer b.apiVersionsLock.RUnlock()
+
+	versions, ok := b.apiVersions[key]
+	return versions.MinVersion, versions.MaxVersion, ok
+}
+
+// fetchSupportedVersions sends an ApiVersionsRequest to the broker, which
+// also identifies the client (KIP-511), and records the versions of the APIs
+// it supports. It must be called with b.lock held.
+func (b *Broker) fetchSupportedVersions() {
+	request := &ApiVersionsRequest{
+		ClientSoftwareName:    defaultClientSoftwareName,
+		ClientSoftwareVersion: version(),
+	}
+	if b.conf.Version.IsAtLeast(V2_4_0_0) {
+		request.Version = 3
+	}
+	response := new(ApiVersionsResponse)
+
+	err := b.sendAndReceiveLocked(request, response)
+	if err == nil && response.ErrorCode != int16(ErrNoError) {
+		err = KError(response.ErrorCode)
+	}
+	if err != nil {
+		b.log().Warnf("Error while fetching the API versions supported by broker %s: %s", b.addr, err)
+		return
+	}
+
+	b.setSupportedVersions(response)
+}
+
+func (b *Broker) setSupportedVersions(response *ApiVersionsResponse) {
+	apiVersions := make(map[int16]ApiVersionsResponseKey, len(response.ApiKeys))
+	for _, key := range response.ApiKeys {
+		apiVersions[key.ApiKey] = key
+	}
+
+	b.apiVersionsLock.Lock()
+	b.apiVersions = apiVersions
+	b.apiVersionsLock.Unlock()
+}
+
+// negotiated returns whether the version of the request was negotiated
+// with the broker
+func (b *Broker) negotiated(req protocolBody) bool {
+	if !b.conf.NegotiateApiVersions {
+		return false
+	}
+	if _, ok := req.(negotiableRequest); !ok {
+		return false
+	}
+	minVersion, maxVersion, ok := b.supportedVersions(req.key())
+	return ok && req.version() >= minVersion && req.version() <= maxVersion
+}
+
+// negotiateVersion returns the request to send in place of req, a copy of it
+// set to the highest version both the request and the broker support, and
+// sets the version of its response accordingly. The request is returned as
+// is when there is no version in common.
+func (b *Broker) negotiateVersion(conf *Config, req, res protocolBody) protocolBody {
+	if conf == nil || !conf.NegotiateApiVersions {
+		return req
+	}
+	negotiable, ok := req.(negotiableRequest)
+	if !ok {
+		return req
+	}
+	brokerMin, brokerMax, ok := b.supportedVersions(req.key())
+	if !ok {
+		return req
+	}
+	minVersion, maxVersion := negotiable.versionRange()
+	if brokerMin > minVersion {
+		minVersion = brokerMin
+	}
+	if brokerMax < maxVersion {
+		maxVersion = brokerMax
+	}
+	if minVersion > maxVersion || maxVersion == req.version() {
+		return req
+	}
+
+	if setter, ok := res.(versionSetter); ok {
+		setter.setVersion(maxVersion)
+	}
+	return negotiable.withVersion(maxVersion)
+}
@@ -0,0 +1,285 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBrokerNegotiateApiVersions(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t).SetApiKeys([]ApiVersionsResponseKey{
+			{ApiKey: 3, MinVersion: 0, MaxVersion: 9},
+			{ApiKey: 32, MinVersion: 0, MaxVersion: 1},
+		}),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"ListGroupsRequest": NewMockListGroupsResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	config.NegotiateApiVersions = true
+
+	broker := NewBroker(seedBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	if _, err := broker.GetMetadata(&MetadataRequest{Version: 5}); err != nil {
+		t.Fatal(err)
+	}
+	// not reported by the broker, so left as built
+	if _, err := broker.ListGroups(&ListGroupsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	history := seedBroker.History()
+	if len(history) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(history))
+	}
+	if _, ok := history[0].Request.(*ApiVersionsRequest); !ok {
+		t.Errorf("expected the ApiVersionsRequest to be sent first, got %T", history[0].Request)
+	}
+	if request, ok := history[1].Request.(*MetadataRequest); !ok || request.Version != 9 {
+		t.Errorf("expected a MetadataRequest at version 9, got %#v", history[1].Request)
+	}
+	if request, ok := history[2].Request.(*ListGroupsRequest); !ok || request.Version != 0 {
+		t.Errorf("expected a ListGroupsRequest at version 0, got %#v", history[2].Request)
+	}
+}
+
+func TestBrokerNegotiateApiVersionsUnsupported(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t).SetApiKeys([]ApiVersionsResponseKey{
+			{ApiKey: 3, MinVersion: 13, MaxVersion: 14},
+		}),
+		"MetadataRequest": NewMockMetadataResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	config.NegotiateApiVersions = true
+
+	broker := NewBroker(seedBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	// no version in common, so sent as built
+	if _, err := broker.GetMetadata(&MetadataRequest{Version: 5}); err != nil {
+		t.Fatal(err)
+	}
+	history := seedBroker.History()
+	if request, ok := history[len(history)-1].Request.(*MetadataRequest); !ok || request.Version != 5 {
+		t.Errorf("expected a MetadataRequest at version 5, got %#v", history[len(history)-1].Request)
+	}
+}
+
+func TestBrokerNegotiateFlexibleGroupApis(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t).SetApiKeys([]ApiVersionsResponseKey{
+			{ApiKey: 10, MinVersion: 0, MaxVersion: 4},
+			{ApiKey: 12, MinVersion: 0, MaxVersion: 4},
+		}),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "my_group", seedBroker),
+		"HeartbeatRequest": NewMockHeartbeatResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	config.NegotiateApiVersions = true
+
+	broker := NewBroker(seedBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	coordinator, err := broker.FindCoordinator(&FindCoordinatorRequest{CoordinatorKey: "my_group"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coordinator.Version != 3 || coordinator.Coordinator == nil || coordinator.Coordinator.Addr() != seedBroker.Addr() {
+		t.Errorf("expected a FindCoordinatorResponse at version 3, got %#v", coordinator)
+	}
+	heartbeat, err := broker.Heartbeat(&HeartbeatRequest{GroupId: "my_group"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if heartbeat.Version != 4 {
+		t.Errorf("expected a HeartbeatResponse at version 4, got %#v", heartbeat)
+	}
+}
+
+func TestBrokerNegotiateApiVersionsOnCopy(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t).SetApiKeys([]ApiVersionsResponseKey{
+			{ApiKey: 0, MinVersion: 0, MaxVersion: 9},
+			{ApiKey: 1, MinVersion: 0, MaxVersion: 13},
+			{ApiKey: 8, MinVersion: 0, MaxVersion: 8},
+		}),
+		"ProduceRequest":      NewMockProduceResponse(t).SetVersion(7),
+		"FetchRequest":        NewMockFetchResponse(t, 1).SetVersion(12),
+		"OffsetCommitRequest": NewMockOffsetCommitResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	config.NegotiateApiVersions = true
+
+	broker := NewBroker(seedBroker.Addr())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	produce := &ProduceRequest{Version: 3, RequiredAcks: WaitForLocal}
+	produce.AddBatch("my_topic", 0, &RecordBatch{
+		Version:        2,
+		FirstTimestamp: time.Unix(1479847795, 0),
+		MaxTimestamp:   time.Unix(0, 0),
+		Records:        []*Record{{Value: []byte(TestMessage)}},
+	})
+	if _, err := broker.Produce(produce); err != nil {
+		t.Fatal(err)
+	}
+	fetch := &FetchRequest{Version: 4}
+	fetch.AddBlock("my_topic", 0, 0, 1024)
+	fetchResponse, err := broker.Fetch(fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetchResponse.Version != 12 {
+		t.Errorf("expected a FetchResponse at version 12, got %d", fetchResponse.Version)
+	}
+	commit := &OffsetCommitRequest{Version: 2, ConsumerGroup: "my_group", RetentionTime: -1}
+	commit.AddBlock("my_topic", 0, 1, 0, "")
+	if _, err := broker.CommitOffset(commit); err != nil {
+		t.Fatal(err)
+	}
+
+	if produce.Version != 3 || fetch.Version != 4 || commit.Version != 2 {
+		t.Errorf("expected the requests to keep their versions, got %d, %d and %d",
+			produce.Version, fetch.Version, commit.Version)
+	}
+	if block := fetch.blocks["my_topic"][0]; block.Version != 4 || block.currentLeaderEpoch != 0 {
+		t.Errorf("expected the fetch block to be left as built, got %#v", block)
+	}
+
+	history := seedBroker.History()
+	if len(history) != 4 {
+		t.Fatalf("expected 4 requests, got %d", len(history))
+	}
+	if request, ok := history[1].Request.(*ProduceRequest); !ok || request.Version != 7 {
+		t.Errorf("expected a ProduceRequest at version 7, got %#v", history[1].Request)
+	}
+	if request, ok := history[2].Request.(*FetchRequest); !ok || request.Version != 12 ||
+		request.blocks["my_topic"][0].currentLeaderEpoch != -1 || request.SessionEpoch != -1 {
+		t.Errorf("expected a FetchRequest at version 12 outside of any session, got %#v", history[2].Request)
+	}
+	if request, ok := history[3].Request.(*OffsetCommitRequest); !ok || request.Version != 7 || request.RetentionTime != 0 {
+		t.Errorf("expected an OffsetCommitRequest at version 7, got %#v", history[3].Request)
+	}
+}
+
+func TestNegotiableRequestVersionRange(t *testing.T) {
+	retention := &OffsetCommitRequest{Version: 2, RetentionTime: 1000}
+	newMember := &JoinGroupRequest{Version: 1}
+	groupInstanceID := "my_instance"
+	staticMember := &JoinGroupRequest{Version: 5, GroupInstanceId: &groupInstanceID}
+	fetchOffsets := &OffsetFetchRequest{Version: 0}
+	offsets := &OffsetRequest{Version: 0}
+	offsets.AddBlock("my_topic", 0, OffsetNewest, 10)
+	readCommitted := &FetchRequest{Version: 4, Isolation: ReadCommitted}
+
+	for _, tt := range []struct {
+		name    string
+		request negotiableRequest
+		wantMin int16
+		wantMax int16
+	}{
+		{"retention time", retention, 2, 4},
+		{"new member", newMember, 1, 3},
+		{"static member", staticMember, 5, 6},
+		{"offsets from ZooKeeper", fetchOffsets, 0, 0},
+		{"several offsets", offsets, 0, 0},
+		{"read committed", readCommitted, 4, 12},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			minVersion, maxVersion := tt.request.versionRange()
+			if minVersion != tt.wantMin || maxVersion != tt.wantMax {
+				t.Errorf("expected versions %d to %d, got %d to %d", tt.wantMin, tt.wantMax, minVersion, maxVersion)
+			}
+		})
+	}
+}
+
+func TestNegotiableRequestWithVersion(t *testing.T) {
+	commit := &OffsetCommitRequest{Version: 1}
+	commit.AddBlock("my_topic", 0, 1, ReceiveTime, "")
+	upgraded := commit.withVersion(3).(*OffsetCommitRequest)
+	if upgraded.RetentionTime != -1 || upgraded.blocks["my_topic"][0].timestamp != 0 {
+		t.Errorf("expected the retention time and timestamp of the broker, got %#v", upgraded)
+	}
+	if commit.blocks["my_topic"][0].timestamp != ReceiveTime {
+		t.Error("expected the request to be left untouched")
+	}
+
+	join := &JoinGroupRequest{SessionTimeout: 10000}
+	if upgraded := join.withVersion(3).(*JoinGroupRequest); upgraded.RebalanceTimeout != 10000 {
+		t.Errorf("expected the session timeout as rebalance timeout, got %d", upgraded.RebalanceTimeout)
+	}
+
+	initProducerID := &InitProducerIDRequest{Version: 1}
+	if upgraded := initProducerID.withVersion(4).(*InitProducerIDRequest); upgraded.ProducerID != -1 || upgraded.ProducerEpoch != -1 {
+		t.Errorf("expected no producer ID, got %d/%d", upgraded.ProducerID, upgraded.ProducerEpoch)
+	}
+
+	fetchOffsets := &OffsetFetchRequest{Version: 1, ConsumerGroup: "my_group"}
+	if upgraded := fetchOffsets.withVersion(5).(*OffsetFetchRequest); upgraded.partitions == nil {
+		t.Error("expected no partitions rather than all of them")
+	}
+}
+
+func TestClientDefaultConfigDoesNotNegotiateApiVersions(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewConfig()
+	config.Metadata.Retry.Max = 0
+
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	for _, entry := range seedBroker.History() {
+		if _, ok := entry.Request.(*ApiVersionsRequest); ok {
+			t.Error("expected no ApiVersionsRequest with the default config")
+		}
+		if request, ok := entry.Request.(*MetadataRequest); ok && request.Version != 5 {
+			t.Errorf("expected a MetadataRequest at version 5, got %d", request.Version)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package sarama
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// wireDumpSecretKeys are the API keys whose requests and responses carry
+// secrets, such as SASL tokens, SCRAM credentials and delegation tokens,
+// which are never dumped
+var wireDumpSecretKeys = map[int16]bool{
+	36: true, // SaslAuthenticate
+	38: true, // CreateDelegationToken
+	39: true, // RenewDelegationToken
+	40: true, // ExpireDelegationToken
+	41: true, // DescribeDelegationToken
+	51: true, // AlterUserScramCredentials
+}
+
+// wireDumps returns whether the requests of the API key sent to the broker
+// and their responses are dumped with Net.WireDump
+func (b *Broker) wireDumps(key int16) bool {
+	dump := &b.conf.Net.WireDump
+	if !dump.Enable {
+		return false
+	}
+	if len(dump.APIKeys) > 0 && !int16Contains(dump.APIKeys, key) {
+		return false
+	}
+	if len(dump.Brokers) > 0 && !int32Contains(dump.Brokers, b.id) {
+		return false
+	}
+	return true
+}
+
+// dumpRequest logs a request, whose buf is the encoding with its header
+func (b *Broker) dumpRequest(rb protocolBody, correlationID int32, buf []byte) {
+	if !b.wireDumps(rb.key()) {
+		return
+	}
+	b.log().with("api", APIKeyName(rb.key()), "correlationID", correlationID).Debugf(
+		"request %s v%d (correlation ID %d, %d bytes): %s",
+		APIKeyName(rb.key()), rb.version(), correlationID, len(buf), b.wireDump(rb, rb.key(), buf))
+}
+
+// dumpResponse logs a response, whose buf is the encoding without its
+// header, which failed to be decoded if err is not nil
+func (b *Broker) dumpResponse(res versionedDecoder, version int16, correlationID int32, buf []byte, err error) {
+	body, ok := res.(protocolBody)
+	if !ok || !b.wireDumps(body.key()) {
+		return
+	}
+	log := b.log().with("api", APIKeyName(body.key()), "correlationID", correlationID)
+	if err != nil {
+		// the bytes are all there is to debug
+		log.Debugf("response %s v%d (correlation ID %d, %d bytes) failed to decode: %v: %s",
+			APIKeyName(body.key()), version, correlationID, len(buf), err, b.hexDump(body.key(), buf))
+		return
+	}
+	log.Debugf("response %s v%d (correlation ID %d, %d bytes): %s",
+		APIKeyName(body.key()), version, correlationID, len(buf), b.wireDump(body, body.key(), buf))
+}
+
+// wireDump returns the dump of a request or response, decoded as JSON unless
+// Net.WireDump.Hex is set or it cannot be, in which case its bytes are
+func (b *Broker) wireDump(body interface{}, key int16, buf []byte) string {
+	if wireDumpSecretKeys[key] {
+		return "<redacted>"
+	}
+	if b.conf.Net.WireDump.Hex {
+		return b.hexDump(key, buf)
+	}
+	decoded, err := json.Marshal(body)
+	if err != nil {
+		return b.hexDump(key, buf)
+	}
+	return truncateWireDump(string(decoded), b.conf.Net.WireDump.MaxBytes)
+}
+
+func (b *Broker) hexDump(key int16, buf []byte) string {
+	if wireDumpSecretKeys[key] {
+		return "<redacted>"
+	}
+	maxBytes := b.conf.Net.WireDump.MaxBytes
+	if maxBytes > 0 && len(buf) > maxBytes {
+		return fmt.Sprintf("\n%s... (%d bytes truncated)", hex.Dump(buf[:maxBytes]), len(buf)-maxBytes)
+	}
+	return "\n" + hex.Dump(buf)
+}
+
+// truncateWireDump truncates a dump to maxBytes, unless 0
+func truncateWireDump(dump string, maxBytes int) string {
+	if maxBytes <= 0 || len(dump) <= maxBytes {
+		return dump
+	}
+	return fmt.Sprintf("%s... (%d bytes truncated)", dump[:maxBytes], len(dump)-maxBytes)
+}
@@ -0,0 +1,75 @@
+package sarama
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWireDump(t *testing.T) {
+	lines, restore := captureStructuredLogs()
+	defer restore()
+
+	conf := NewTestConfig()
+	conf.Net.WireDump.Enable = true
+	conf.Net.WireDump.APIKeys = []int16{3, 36}
+	broker := &Broker{id: 1, addr: "localhost:9092", conf: conf}
+
+	req := &MetadataRequest{Version: 1, Topics: []string{"my_topic"}}
+	broker.dumpRequest(req, 7, []byte{0, 1, 2})
+	broker.dumpRequest(&ApiVersionsRequest{}, 8, []byte{0, 1, 2})
+	broker.dumpRequest(&SaslAuthenticateRequest{SaslAuthBytes: []byte("secret")}, 9, []byte("secret"))
+	broker.dumpResponse(&MetadataResponse{Version: 1}, 1, 7, []byte{0, 1, 2}, nil)
+	broker.dumpResponse(&MetadataResponse{Version: 1}, 1, 10, []byte{0xca, 0xfe}, ErrInsufficientData)
+
+	if len(*lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %+v", *lines)
+	}
+	for i, expected := range []string{
+		`request Metadata v1 (correlation ID 7, 3 bytes): {"Version":1,"Topics":["my_topic"]`,
+		`request SaslAuthenticate v0 (correlation ID 9, 6 bytes): <redacted>`,
+		`response Metadata v1 (correlation ID 7, 3 bytes): {"Version":1,`,
+		`response Metadata v1 (correlation ID 10, 2 bytes) failed to decode: ` + ErrInsufficientData.Error() + ": \n00000000  ca fe",
+	} {
+		line := (*lines)[i]
+		if line.level != LogLevelDebug || !strings.HasPrefix(line.msg, expected) {
+			t.Errorf("Expected line #%d to start with %q, got %+v", i, expected, line)
+		}
+	}
+	if strings.Contains((*lines)[1].msg, "secret") {
+		t.Error("Expected the SASL token to be redacted")
+	}
+}
+
+func TestWireDumpHexAndTruncation(t *testing.T) {
+	lines, restore := captureStructuredLogs()
+	defer restore()
+
+	conf := NewTestConfig()
+	conf.Net.WireDump.Enable = true
+	conf.Net.WireDump.Brokers = []int32{2}
+	conf.Net.WireDump.Hex = true
+	conf.Net.WireDump.MaxBytes = 4
+	other := &Broker{id: 1, conf: conf}
+	broker := &Broker{id: 2, conf: conf}
+
+	other.dumpRequest(&MetadataRequest{}, 1, []byte{0, 1, 2, 3, 4, 5})
+	broker.dumpRequest(&MetadataRequest{}, 1, []byte{0, 1, 2, 3, 4, 5})
+
+	if len(*lines) != 1 {
+		t.Fatalf("Expected 1 line, got %+v", *lines)
+	}
+	if msg := (*lines)[0].msg; !strings.Contains(msg, "00 01 02 03") || !strings.HasSuffix(msg, "... (2 bytes truncated)") {
+		t.Errorf("Unexpected dump %q", msg)
+	}
+
+	if got := truncateWireDump("abcdef", 0); got != "abcdef" {
+		t.Errorf("Expected no truncation, got %q", got)
+	}
+}
+
+func TestWireDumpDisabled(t *testing.T) {
+	broker := &Broker{conf: NewTestConfig()}
+	if broker.wireDumps(0) {
+		t.Error("Expected the wire dump to be disabled by default")
+	}
+}
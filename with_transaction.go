@@ -0,0 +1,92 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+)
+
+// Transactor is the transactional part of AsyncProducer and SyncProducer,
+// see WithTransaction.
+type Transactor interface {
+	BeginTxn() error
+	CommitTxn() error
+	AbortTxn() error
+}
+
+// WithTransaction begins a transaction with the producer, runs fn and
+// commits the transaction if fn succeeds. The transaction is aborted if fn
+// returns an error, if it panics, in which case the panic is propagated once
+// the transaction is aborted, or if ctx is done when fn returns, so that an
+// abandoned transaction does not hold back the consumers reading committed
+// messages until the broker times it out. The transaction is also aborted if
+// it cannot be committed.
+//
+// fn is run in the calling goroutine and should return once ctx is done.
+// The returned error wraps the error of fn, of the context or of the commit,
+// and the error aborting the transaction if it failed too.
+func WithTransaction(ctx context.Context, producer Transactor, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := producer.BeginTxn(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if abortErr := producer.AbortTxn(); abortErr != nil {
+				newContextLogger().Errorf("Failed to abort the transaction of a panicking function: %v", abortErr)
+			}
+			panic(r)
+		}
+	}()
+
+	err := fn(ctx)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		err = producer.CommitTxn()
+		if err == nil || !errors.Is(err, ErrTxnAbortable) {
+			// committed, or the producer failed fatally
+			return err
+		}
+	}
+	if abortErr := producer.AbortTxn(); abortErr != nil {
+		return Wrap(err, abortErr)
+	}
+	return err
+}
+
+// WithTransaction begins a transaction with a producer of the pool, runs fn
+// with the session and commits it if fn succeeds, releasing the producer.
+// The transaction is aborted if fn returns an error, panics or if ctx is
+// done when fn returns, see WithTransaction.
+func (pool *TransactionalProducerPool) WithTransaction(ctx context.Context, fn func(ctx context.Context, session *TransactionSession) error) error {
+	session, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if abortErr := session.Abort(); abortErr != nil {
+				newContextLogger("transactionalID", session.TransactionalID()).Errorf("Failed to abort the transaction of a panicking function: %v", abortErr)
+			}
+			panic(r)
+		}
+	}()
+
+	err = fn(ctx, session)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		// the session aborts the transaction if it cannot be committed
+		return session.Commit()
+	}
+	if abortErr := session.Abort(); abortErr != nil {
+		return Wrap(err, abortErr)
+	}
+	return err
+}
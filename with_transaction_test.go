@@ -0,0 +1,105 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithTransactionCommits(t *testing.T) {
+	broker, coordinator, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newTxnProducer(t, broker, "txn")
+	defer safeClose(t, producer)
+
+	err := WithTransaction(context.Background(), producer, func(ctx context.Context) error {
+		produceTxnMessage(t, producer)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results := coordinator.Results("txn"); len(results) != 1 || !results[0] {
+		t.Errorf("expected 1 committed transaction, got %v", results)
+	}
+}
+
+func TestWithTransactionAborts(t *testing.T) {
+	broker, coordinator, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	producer := newTxnProducer(t, broker, "txn")
+	defer safeClose(t, producer)
+
+	// on error
+	errFn := errors.New("failed")
+	err := WithTransaction(context.Background(), producer, func(ctx context.Context) error {
+		produceTxnMessage(t, producer)
+		return errFn
+	})
+	if !errors.Is(err, errFn) {
+		t.Errorf("expected the error of the function, got %v", err)
+	}
+
+	// on context cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	err = WithTransaction(ctx, producer, func(ctx context.Context) error {
+		produceTxnMessage(t, producer)
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// on panic
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected the panic to be propagated, got %v", r)
+			}
+		}()
+		_ = WithTransaction(context.Background(), producer, func(ctx context.Context) error {
+			produceTxnMessage(t, producer)
+			panic("boom")
+		})
+	}()
+
+	if results := coordinator.Results("txn"); len(results) != 3 || results[0] || results[1] || results[2] {
+		t.Errorf("expected 3 aborted transactions, got %v", results)
+	}
+	if status := producer.TxnStatus(); status != TxnReady {
+		t.Errorf("expected %s, got %s", TxnReady, status)
+	}
+}
+
+func TestTransactionalProducerPoolWithTransaction(t *testing.T) {
+	broker, coordinator, _ := newTxnProducerBroker(t)
+	defer broker.Close()
+
+	pool := newTestProducerPool(t, broker, 1)
+	defer safeClose(t, pool)
+
+	var transactionalID string
+	err := pool.WithTransaction(context.Background(), func(ctx context.Context, session *TransactionSession) error {
+		transactionalID = session.TransactionalID()
+		session.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errFn := errors.New("failed")
+	err = pool.WithTransaction(context.Background(), func(ctx context.Context, session *TransactionSession) error {
+		session.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+		return errFn
+	})
+	if !errors.Is(err, errFn) {
+		t.Errorf("expected the error of the function, got %v", err)
+	}
+
+	if results := coordinator.Results(transactionalID); len(results) != 2 || !results[0] || results[1] {
+		t.Errorf("expected a committed then an aborted transactions, got %v", results)
+	}
+}
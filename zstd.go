@@ -1,6 +1,8 @@
 package sarama
 
 import (
+	"encoding/binary"
+	"fmt"
 	"sync"
 
 	"github.com/klauspost/compress/zstd"
@@ -8,15 +10,68 @@ import (
 
 type ZstdEncoderParams struct {
 	Level int
+	// DictionaryID selects a dictionary registered with
+	// RegisterZstdDictionary, 0 compressing without dictionary.
+	DictionaryID uint32
 }
 type ZstdDecoderParams struct {
+	// MaxDecodedSize limits the size of the decompressed data, 0 not
+	// limiting it.
+	MaxDecodedSize uint64
 }
 
 var zstdEncMap, zstdDecMap sync.Map
 
-func getEncoder(params ZstdEncoderParams) *zstd.Encoder {
+var (
+	zstdDictionariesLock sync.RWMutex
+	zstdDictionaries     = make(map[uint32][]byte)
+)
+
+// RegisterZstdDictionary registers a zstd dictionary, as trained by
+// `zstd --train`, returning the ID it contains. The producer compresses with
+// it the batches of the topics whose Producer.ZstdDictionaryID is that ID,
+// and the consumer decompresses with it the batches compressed with it, zstd
+// recording the ID of the dictionary in the frames. Registering a dictionary
+// again with the same ID replaces the previous one.
+func RegisterZstdDictionary(dictionary []byte) (uint32, error) {
+	// validate the dictionary by loading it in an encoder
+	if _, err := zstd.NewWriter(nil, zstd.WithEncoderDict(dictionary)); err != nil {
+		return 0, ConfigurationError("invalid zstd dictionary: " + err.Error())
+	}
+	id := binary.LittleEndian.Uint32(dictionary[4:8])
+
+	zstdDictionariesLock.Lock()
+	defer zstdDictionariesLock.Unlock()
+	zstdDictionaries[id] = append([]byte(nil), dictionary...)
+
+	// drop the cached encoders of the ID and the decoders, which have to
+	// know every dictionary, while holding the lock for them not to be
+	// cached again with the previous dictionaries
+	zstdEncMap.Range(func(key, _ interface{}) bool {
+		if key.(ZstdEncoderParams).DictionaryID == id {
+			zstdEncMap.Delete(key)
+		}
+		return true
+	})
+	zstdDecMap.Range(func(key, _ interface{}) bool {
+		zstdDecMap.Delete(key)
+		return true
+	})
+	return id, nil
+}
+
+// zstdDictionaryRegistered reports whether a dictionary was registered with
+// the ID
+func zstdDictionaryRegistered(id uint32) bool {
+	zstdDictionariesLock.RLock()
+	defer zstdDictionariesLock.RUnlock()
+	_, ok := zstdDictionaries[id]
+	return ok
+}
+
+func getEncoder(params ZstdEncoderParams) (*zstd.Encoder, error) {
 	if ret, ok := zstdEncMap.Load(params); ok {
-		return ret.(*zstd.Encoder)
+		return ret.(*zstd.Encoder), nil
 	}
 	// It's possible to race and create multiple new writers.
 	// Only one will survive GC after use.
@@ -24,19 +79,41 @@ func getEncoder(params ZstdEncoderParams) *zstd.Encoder {
 	if params.Level != CompressionLevelDefault {
 		encoderLevel = zstd.EncoderLevelFromZstd(params.Level)
 	}
-	zstdEnc, _ := zstd.NewWriter(nil, zstd.WithZeroFrames(true),
-		zstd.WithEncoderLevel(encoderLevel))
+	opts := []zstd.EOption{zstd.WithZeroFrames(true), zstd.WithEncoderLevel(encoderLevel)}
+	zstdDictionariesLock.RLock()
+	defer zstdDictionariesLock.RUnlock()
+	if params.DictionaryID != 0 {
+		dictionary, ok := zstdDictionaries[params.DictionaryID]
+		if !ok {
+			return nil, PacketEncodingError{fmt.Sprintf("unknown zstd dictionary %d", params.DictionaryID)}
+		}
+		opts = append(opts, zstd.WithEncoderDict(dictionary))
+	}
+	zstdEnc, err := zstd.NewWriter(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
 	zstdEncMap.Store(params, zstdEnc)
-	return zstdEnc
+	return zstdEnc, nil
 }
 
 func getDecoder(params ZstdDecoderParams) *zstd.Decoder {
 	if ret, ok := zstdDecMap.Load(params); ok {
 		return ret.(*zstd.Decoder)
 	}
+	zstdDictionariesLock.RLock()
+	defer zstdDictionariesLock.RUnlock()
+	dictionaries := make([][]byte, 0, len(zstdDictionaries))
+	for _, dictionary := range zstdDictionaries {
+		dictionaries = append(dictionaries, dictionary)
+	}
 	// It's possible to race and create multiple new readers.
 	// Only one will survive GC after use.
-	zstdDec, _ := zstd.NewReader(nil)
+	opts := []zstd.DOption{zstd.WithDecoderDicts(dictionaries...)}
+	if params.MaxDecodedSize > 0 {
+		opts = append(opts, zstd.WithDecoderMaxMemory(params.MaxDecodedSize))
+	}
+	zstdDec, _ := zstd.NewReader(nil, opts...)
 	zstdDecMap.Store(params, zstdDec)
 	return zstdDec
 }
@@ -46,5 +123,9 @@ func zstdDecompress(params ZstdDecoderParams, dst, src []byte) ([]byte, error) {
 }
 
 func zstdCompress(params ZstdEncoderParams, dst, src []byte) ([]byte, error) {
-	return getEncoder(params).EncodeAll(src, dst), nil
+	zstdEnc, err := getEncoder(params)
+	if err != nil {
+		return nil, err
+	}
+	return zstdEnc.EncodeAll(src, dst), nil
 }
@@ -0,0 +1,105 @@
+package sarama
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+// testZstdDictionary was trained with `zstd --train --maxdict=400
+// --dictID=4242` on small JSON page view events
+const testZstdDictionary = "" +
+	"N6Qw7JIQAAAeEOBLwwH/////g1fV4C2llFKmFH2UPyIiIiIiIkICkwIA0AAAXowN" +
+	"AAAABEBAhoEZEAYAaxQAACQ+IAYAAAAAAAAAAAAAAEgCAASAvAIgAAAAAAB0LQgI" +
+	"7gLkAAAAAAAAAAAAAAAAAQAAAAQAAAAIAAAAZXciLCJwYWdlIjoiL3Byb2R1Y3Rz" +
+	"LzMyIiwic2Vzc2lvbiI6InMtMTc3OCIsImNvdW5lcl9pZCI6MjUsImV2ZW50Ijoi" +
+	"cGFnZV92aWV3IiwicGFnZSI6Ii9wcm9kdWN0cy8yNWlldyIsInBhZ2UiOiIvcHJv" +
+	"ZHVjdHMvMzQiLCJzZXNzaW9uIjoicy03NTYiLCJjb3VuZXciLCJwYWdlIjoiL3By" +
+	"b2R1Y3RzLzExIiwic2Vzc2lvbiI6InMtMjY2NyIsImNvdW5ldyIsInBhZ2UiOiIv" +
+	"cHJvZHVjdHMvMTciLCJzZXNzaW9uIjoicy0yNDUwIiwiY291bmV3IiwicGFnZSI6" +
+	"Ii9wcm9kdWN0cy8yMCIsIg=="
+
+func registerTestZstdDictionary(t *testing.T) uint32 {
+	t.Helper()
+	dictionary, err := base64.StdEncoding.DecodeString(testZstdDictionary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := RegisterZstdDictionary(dictionary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		zstdDictionariesLock.Lock()
+		defer zstdDictionariesLock.Unlock()
+		delete(zstdDictionaries, id)
+		zstdDecMap.Range(func(key, _ interface{}) bool {
+			zstdDecMap.Delete(key)
+			return true
+		})
+	})
+	return id
+}
+
+func TestRegisterZstdDictionary(t *testing.T) {
+	if _, err := RegisterZstdDictionary([]byte("not a dictionary")); err == nil {
+		t.Error("expected an invalid dictionary to be rejected")
+	}
+
+	config := NewTestConfig()
+	config.Version = V2_1_0_0
+	config.Producer.Compression = CompressionZSTD
+	config.Producer.ZstdDictionaryID = 4242
+	if err := config.Validate(); err == nil {
+		t.Error("expected an unregistered dictionary to be invalid")
+	}
+
+	if id := registerTestZstdDictionary(t); id != 4242 {
+		t.Errorf("expected the ID of the dictionary to be 4242, got %d", id)
+	}
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestZstdDictionaryCompression(t *testing.T) {
+	value := []byte(`{"user_id":9999,"event":"page_view","page":"/products/3","session":"s-1","country":"DE"}`)
+	batch := &RecordBatch{
+		Version:          2,
+		Codec:            CompressionZSTD,
+		CompressionLevel: CompressionLevelDefault,
+		ZstdDictionaryID: 4242,
+		FirstTimestamp:   time.Unix(1479847795, 0),
+		MaxTimestamp:     time.Unix(0, 0),
+		Records:          []*Record{{Value: value}},
+	}
+	if _, err := encode(batch, nil); err == nil {
+		t.Error("expected compressing with an unregistered dictionary to fail")
+	}
+
+	registerTestZstdDictionary(t)
+	buf, err := encode(batch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &RecordBatch{}
+	if err := decode(buf, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Records) != 1 || !bytes.Equal(decoded.Records[0].Value, value) {
+		t.Errorf("unexpected decoded batch %+v", decoded)
+	}
+
+	// a consumer not knowing the dictionary cannot decompress the batch
+	zstdDictionariesLock.Lock()
+	delete(zstdDictionaries, 4242)
+	zstdDictionariesLock.Unlock()
+	zstdDecMap.Range(func(key, _ interface{}) bool {
+		zstdDecMap.Delete(key)
+		return true
+	})
+	if err := decode(buf, &RecordBatch{}); err == nil {
+		t.Error("expected decompressing without the dictionary to fail")
+	}
+}